@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	database "seo-generator/api/internal/repository"
+	core "seo-generator/api/internal/service"
+	"seo-generator/api/pkg/config"
+)
+
+// runBootstrap 执行 bootstrap 子命令：按初始化清单创建默认站群、分组、管理员、示例模板和定时任务
+// 用法：api bootstrap [-manifest path] [-dry-run]
+func runBootstrap(projectRoot string, args []string) int {
+	fs := flag.NewFlagSet("bootstrap", flag.ContinueOnError)
+	manifestPath := fs.String("manifest", "", "seed manifest path (default: bootstrap.manifest in config.yaml)")
+	dryRun := fs.Bool("dry-run", false, "print planned actions without writing")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load(filepath.Join(projectRoot, "config.yaml"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load config: %v\n", err)
+		return 1
+	}
+	path := resolveManifestPath(projectRoot, *manifestPath, cfg.Bootstrap.Manifest)
+	manifest, err := core.LoadSeedManifest(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load manifest %s: %v\n", path, err)
+		return 1
+	}
+
+	if err := database.Init(&cfg.Database); err != nil {
+		fmt.Fprintf(os.Stderr, "connect database: %v\n", err)
+		return 1
+	}
+	defer database.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	report, err := core.Bootstrap(ctx, database.GetDB(), manifest, *dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bootstrap: %v\n", err)
+		return 1
+	}
+
+	for _, item := range report.Items {
+		line := fmt.Sprintf("%-8s %-15s %s", item.Action, item.Kind, item.Name)
+		if item.Detail != "" {
+			line += " (" + item.Detail + ")"
+		}
+		fmt.Println(line)
+	}
+	if report.DryRun {
+		fmt.Printf("dry run: %d item(s) would be created, nothing written\n", report.Created)
+		return 0
+	}
+	fmt.Printf("bootstrap done: %d item(s) created\n", report.Created)
+	if report.AdminPassword != "" {
+		fmt.Printf("generated admin password: %s (shown only once, change it after login)\n", report.AdminPassword)
+	}
+	return 0
+}
+
+// resolveManifestPath 命令行参数优先，其次配置；相对路径基于项目根目录
+func resolveManifestPath(projectRoot, flagPath, configPath string) string {
+	path := flagPath
+	if path == "" {
+		path = configPath
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(projectRoot, path)
+	}
+	return path
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/url"
+
+	"github.com/spf13/cobra"
+)
+
+func cacheCommand(c *cli) *cobra.Command {
+	cmd := &cobra.Command{Use: "cache", Short: "page cache stats, clear and reload"}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use: "stats", Short: "page cache statistics", Args: exactArgs(0),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.getObject("/api/cache/stats", nil)
+			},
+		},
+		&cobra.Command{
+			Use: "clear [domain]", Short: "clear page cache (all domains or one domain)", Args: maxArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.postDone(withPathArg("/api/cache/clear", args), "page cache cleared")
+			},
+		},
+		&cobra.Command{
+			Use: "reload", Short: "reload cache config from the database", Args: exactArgs(0),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.postDone("/api/cache/config/reload", "cache config reloaded")
+			},
+		},
+		&cobra.Command{
+			Use: "reload-templates [name]", Short: "reload template cache (all templates or one name)", Args: maxArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.postDone(withPathArg("/api/cache/template/reload", args), "template cache reloaded")
+			},
+		},
+	)
+	return cmd
+}
+
+func poolsCommand(c *cli) *cobra.Command {
+	cmd := &cobra.Command{Use: "pools", Short: "title/content pool stats, forecast and reload"}
+
+	var refresh bool
+	forecast := &cobra.Command{
+		Use: "forecast", Short: "consumption rate and hours until empty per group", Args: exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := url.Values{}
+			if refresh {
+				q.Set("refresh", "true")
+			}
+			return c.getObject("/api/cache-pool/forecast", q)
+		},
+	}
+	forecast.Flags().BoolVar(&refresh, "refresh", false, "recompute instead of using the last forecast")
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use: "stats", Short: "pool statistics", Args: exactArgs(0),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.getObject("/api/cache-pool/stats", nil)
+			},
+		},
+		forecast,
+		&cobra.Command{
+			Use: "reload", Short: "reload pool configuration", Args: exactArgs(0),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.postDone("/api/cache-pool/reload", "pool configuration reloaded")
+			},
+		},
+	)
+	return cmd
+}
+
+// withPathArg 可选的路径参数（域名、模板名）追加到路径末尾
+func withPathArg(path string, args []string) string {
+	if len(args) > 0 {
+		path += "/" + url.PathEscape(args[0])
+	}
+	return path
+}
+
+// getObject 读取并输出单个对象
+func (c *cli) getObject(path string, q url.Values) error {
+	var result interface{}
+	if err := c.client.get(path, q, &result); err != nil {
+		return err
+	}
+	return c.printObject(result)
+}
+
+// postDone 无参数的写操作
+func (c *cli) postDone(path, msg string) error {
+	var result interface{}
+	if err := c.client.post(path, nil, nil, &result); err != nil {
+		return err
+	}
+	return c.printDone(msg, result)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// client 管理 API 客户端，请求头 X-API-Token 携带 API Token
+type client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func newClient(baseURL, token string, timeout time.Duration) *client {
+	return &client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+// envelope 兼容两种响应格式：{code, message, data}（大部分接口）和 {success, message, data}（爬虫项目等）
+type envelope struct {
+	Code    *int            `json:"code"`
+	Success *bool           `json:"success"`
+	Message string          `json:"message"`
+	Error   string          `json:"error"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// apiError 接口返回的业务错误
+type apiError struct {
+	Status  int
+	Code    int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("%s (http %d, code %d)", e.Message, e.Status, e.Code)
+	}
+	return fmt.Sprintf("%s (http %d)", e.Message, e.Status)
+}
+
+// do 发送请求并把 data 解码到 out（out 为 nil 时忽略）；没有包装字段的响应整体解码到 out
+func (c *client) do(method, path string, query url.Values, body, out interface{}) error {
+	return c.request(method, path, query, body, out, false)
+}
+
+// request whole 为 true 时整体解码响应（分页字段在顶层的接口，如爬虫项目列表）
+func (c *client) request(method, path string, query url.Values, body, out interface{}, whole bool) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("X-API-Token", c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		if resp.StatusCode >= 400 {
+			return &apiError{Status: resp.StatusCode, Message: strings.TrimSpace(string(raw))}
+		}
+		return fmt.Errorf("invalid response from %s: %v", path, err)
+	}
+	if failed(resp.StatusCode, &env) {
+		msg := env.Message
+		if msg == "" {
+			msg = env.Error
+		}
+		if msg == "" {
+			msg = http.StatusText(resp.StatusCode)
+		}
+		code := 0
+		if env.Code != nil {
+			code = *env.Code
+		}
+		return &apiError{Status: resp.StatusCode, Code: code, Message: msg}
+	}
+	if out == nil {
+		return nil
+	}
+	payload := raw
+	if !whole && (env.Code != nil || env.Success != nil) {
+		payload = env.Data
+		if len(payload) == 0 {
+			payload = []byte("null")
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	return dec.Decode(out)
+}
+
+func failed(status int, env *envelope) bool {
+	if status >= 400 {
+		return true
+	}
+	if env.Code != nil && *env.Code != 0 {
+		return true
+	}
+	if env.Success != nil && !*env.Success {
+		return true
+	}
+	return env.Code == nil && env.Success == nil && env.Error != ""
+}
+
+func (c *client) get(path string, query url.Values, out interface{}) error {
+	return c.do(http.MethodGet, path, query, nil, out)
+}
+
+func (c *client) post(path string, query url.Values, body, out interface{}) error {
+	return c.do(http.MethodPost, path, query, body, out)
+}
+
+// page 分页列表响应（core.PagedData）
+type page struct {
+	Items    []map[string]interface{} `json:"items"`
+	Total    int64                    `json:"total"`
+	Page     int                      `json:"page"`
+	PageSize int                      `json:"page_size"`
+	Pages    int64                    `json:"pages"`
+}
+
+// listAll 逐页读取分页列表（每页 100 条，接口上限），fn 返回 false 时停止
+func (c *client) listAll(path string, query url.Values, fn func(items []map[string]interface{}) bool) error {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("page_size", "100")
+	for p := 1; ; p++ {
+		q.Set("page", fmt.Sprint(p))
+		var data page
+		if err := c.get(path, q, &data); err != nil {
+			return err
+		}
+		if !fn(data.Items) || len(data.Items) == 0 || int64(p) >= data.Pages {
+			return nil
+		}
+	}
+}
+
+// postResult 写操作：部分接口用 data.success=false 表示业务失败（如域名已存在），统一转为错误
+func (c *client) postResult(path string, query url.Values, body interface{}) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	if err := c.post(path, query, body, &result); err != nil {
+		return nil, err
+	}
+	if ok, exists := result["success"].(bool); exists && !ok {
+		msg, _ := result["message"].(string)
+		if msg == "" {
+			msg = "request failed"
+		}
+		return result, fmt.Errorf("%s", msg)
+	}
+	return result, nil
+}
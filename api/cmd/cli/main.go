@@ -0,0 +1,115 @@
+// Package main is seogen-cli, a command line tool for scripted management through the admin API
+//
+// 用法：seogen-cli [--server URL] [--token TOKEN] [-o table|json] <资源> <操作> [参数]
+// 认证使用命令行 Token（系统设置 /api/settings/cli-tokens 创建，按 scope 授权），可通过 SEOGEN_SERVER / SEOGEN_TOKEN 环境变量设置
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// cli 全局参数和输出
+type cli struct {
+	client  *client
+	server  string
+	token   string
+	output  string // table / json
+	timeout time.Duration
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+// errUsage 参数错误（已输出用法），退出码 2
+var errUsage = errors.New("usage")
+
+// rootCommand 命令树，全局参数在执行子命令前解析并创建 API 客户端
+func rootCommand(c *cli) *cobra.Command {
+	root := &cobra.Command{
+		Use:           "seogen-cli",
+		Short:         "SEO generator admin CLI",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if c.output != "table" && c.output != "json" {
+				return fmt.Errorf("%w: invalid output format %q (table or json)", errUsage, c.output)
+			}
+			c.client = newClient(c.server, c.token, c.timeout)
+			return nil
+		},
+	}
+	root.SetOut(c.stdout)
+	root.SetErr(c.stderr)
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		cmd.PrintErrln(cmd.UsageString())
+		return fmt.Errorf("%w: %v", errUsage, err)
+	})
+
+	pf := root.PersistentFlags()
+	pf.StringVar(&c.server, "server", envOr("SEOGEN_SERVER", "http://127.0.0.1:8010"), "admin API base URL (env SEOGEN_SERVER)")
+	pf.StringVar(&c.token, "token", os.Getenv("SEOGEN_TOKEN"), "CLI token cli_... (env SEOGEN_TOKEN)")
+	pf.StringVarP(&c.output, "output", "o", envOr("SEOGEN_OUTPUT", "table"), "output format: table or json (env SEOGEN_OUTPUT)")
+	pf.DurationVar(&c.timeout, "timeout", 60*time.Second, "request timeout")
+	root.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{"table", "json"}, cobra.ShellCompDirectiveNoFileComp))
+
+	root.AddCommand(
+		sitesCommand(c),
+		poolDataCommand(c, keywordsKind),
+		poolDataCommand(c, imagesKind),
+		cacheCommand(c),
+		poolsCommand(c),
+		spiderCommand(c),
+	)
+	return root
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	root := rootCommand(&cli{stdout: stdout, stderr: stderr})
+	root.SetArgs(args)
+	if err := root.Execute(); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// exactArgs 位置参数个数校验，错误时输出用法并按参数错误退出
+func exactArgs(n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(n)(cmd, args); err != nil {
+			cmd.PrintErrln(cmd.UsageString())
+			return fmt.Errorf("%w: %v", errUsage, err)
+		}
+		return nil
+	}
+}
+
+// maxArgs 最多 n 个位置参数
+func maxArgs(n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := cobra.MaximumNArgs(n)(cmd, args); err != nil {
+			cmd.PrintErrln(cmd.UsageString())
+			return fmt.Errorf("%w: %v", errUsage, err)
+		}
+		return nil
+	}
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
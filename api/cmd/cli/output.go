@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// printJSON 缩进输出 JSON
+func printJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printItems 按列输出列表，json 格式时输出完整条目
+func (c *cli) printItems(items []map[string]interface{}, columns []string) error {
+	if c.output == "json" {
+		if items == nil {
+			items = []map[string]interface{}{}
+		}
+		return printJSON(c.stdout, items)
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, item := range items {
+		cells := make([]string, len(columns))
+		for i, col := range columns {
+			cells[i] = cell(item[col])
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// printObject 输出单个对象，table 格式时按键排序输出两列
+func (c *cli) printObject(v interface{}) error {
+	if c.output == "json" {
+		return printJSON(c.stdout, v)
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return printJSON(c.stdout, v)
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	for _, k := range keys {
+		fmt.Fprintf(tw, "%s\t%s\n", k, cell(obj[k]))
+	}
+	return tw.Flush()
+}
+
+// printDone 写操作结果，json 格式时输出接口返回的 data
+func (c *cli) printDone(msg string, data interface{}) error {
+	if c.output == "json" {
+		if data == nil {
+			data = map[string]interface{}{"message": msg}
+		}
+		return printJSON(c.stdout, data)
+	}
+	fmt.Fprintln(c.stdout, msg)
+	return nil
+}
+
+// cell 表格单元格：嵌套值输出紧凑 JSON，过长时截断
+func cell(v interface{}) string {
+	var s string
+	switch x := v.(type) {
+	case nil:
+		s = "-"
+	case string:
+		s = x
+	case json.Number:
+		s = x.String()
+	case bool:
+		s = fmt.Sprint(x)
+	default:
+		b, _ := json.Marshal(x)
+		s = string(b)
+	}
+	s = strings.ReplaceAll(s, "\n", " ")
+	if r := []rune(s); len(r) > 80 {
+		s = string(r[:77]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// importChunkSize 批量导入每次提交的条数（接口单次上限 100000）
+const importChunkSize = 10000
+
+// poolDataKind 关键词和图片 URL 的接口差异，两者命令一致
+type poolDataKind struct {
+	name       string // 命令名
+	item       string // 单条名称（用于提示）
+	field      string // 列表条目和单条添加的字段名
+	batchField string // 批量添加的字段名
+	listPath   string
+	addPath    string
+	batchPath  string
+	reloadPath string
+	groupsPath string
+}
+
+var keywordsKind = poolDataKind{
+	name:       "keywords",
+	item:       "keyword",
+	field:      "keyword",
+	batchField: "keywords",
+	listPath:   "/api/keywords/list",
+	addPath:    "/api/keywords/add",
+	batchPath:  "/api/keywords/batch",
+	reloadPath: "/api/keywords/reload",
+	groupsPath: "/api/keywords/groups",
+}
+
+var imagesKind = poolDataKind{
+	name:       "images",
+	item:       "image url",
+	field:      "url",
+	batchField: "urls",
+	listPath:   "/api/images/urls/list",
+	addPath:    "/api/images/urls/add",
+	batchPath:  "/api/images/urls/batch",
+	reloadPath: "/api/images/urls/reload",
+	groupsPath: "/api/images/groups",
+}
+
+func poolDataCommand(c *cli, k poolDataKind) *cobra.Command {
+	cmd := &cobra.Command{Use: k.name, Short: "list, add, import, export and reload " + k.item + "s"}
+	cmd.AddCommand(k.groupsCommand(c), k.listCommand(c), k.addCommand(c), k.importCommand(c), k.exportCommand(c), k.reloadCommand(c))
+	return cmd
+}
+
+func (k poolDataKind) groupsCommand(c *cli) *cobra.Command {
+	var siteGroup int
+	cmd := &cobra.Command{
+		Use: "groups", Short: "list " + k.item + " groups", Args: exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := url.Values{}
+			if siteGroup > 0 {
+				q.Set("site_group_id", strconv.Itoa(siteGroup))
+			}
+			var items []map[string]interface{}
+			if err := c.client.get(k.groupsPath, q, &items); err != nil {
+				return err
+			}
+			return c.printItems(items, []string{"id", "site_group_id", "name", "is_default", "description"})
+		},
+	}
+	cmd.Flags().IntVar(&siteGroup, "site-group", 0, "site group ID")
+	return cmd
+}
+
+func (k poolDataKind) listCommand(c *cli) *cobra.Command {
+	var group, pageNum, pageSize int
+	var search string
+	cmd := &cobra.Command{
+		Use: "list", Short: "list " + k.item + "s in a group", Args: exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := url.Values{
+				"group_id":  {strconv.Itoa(group)},
+				"page":      {strconv.Itoa(pageNum)},
+				"page_size": {strconv.Itoa(pageSize)},
+			}
+			if search != "" {
+				q.Set("search", search)
+			}
+			var data page
+			if err := c.client.get(k.listPath, q, &data); err != nil {
+				return err
+			}
+			if c.output == "json" {
+				return printJSON(c.stdout, data)
+			}
+			if err := c.printItems(data.Items, []string{"id", k.field, "status", "created_at"}); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.stdout, "page %d/%d, total %d\n", data.Page, data.Pages, data.Total)
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.IntVar(&group, "group", 1, "group ID")
+	f.StringVar(&search, "search", "", k.field+" contains")
+	f.IntVar(&pageNum, "page", 1, "page number")
+	f.IntVar(&pageSize, "page-size", 20, "page size (max 100)")
+	return cmd
+}
+
+func (k poolDataKind) addCommand(c *cli) *cobra.Command {
+	var group int
+	cmd := &cobra.Command{
+		Use: "add <" + k.field + ">...", Short: "add " + k.item + "s given as arguments",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				cmd.PrintErrln(cmd.UsageString())
+				return fmt.Errorf("%w: at least one %s is required", errUsage, k.field)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				result, err := c.client.postResult(k.addPath, nil, map[string]interface{}{k.field: args[0], "group_id": group})
+				if err != nil {
+					return err
+				}
+				return c.printDone(fmt.Sprintf("%s added (id %s)", k.item, cell(result["id"])), result)
+			}
+			added, skipped, err := k.submit(c, group, args)
+			if err != nil {
+				return err
+			}
+			return c.printDone(fmt.Sprintf("added %d, skipped %d", added, skipped),
+				map[string]interface{}{"added": added, "skipped": skipped})
+		},
+	}
+	cmd.Flags().IntVar(&group, "group", 1, "group ID")
+	return cmd
+}
+
+func (k poolDataKind) importCommand(c *cli) *cobra.Command {
+	var group int
+	cmd := &cobra.Command{
+		Use: "import <file>", Short: "import " + k.item + "s from a text file (one per line, - for stdin)", Args: exactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var r io.Reader = os.Stdin
+			if path := args[0]; path != "-" {
+				f, err := os.Open(path)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+			}
+			return k.importFrom(c, group, r)
+		},
+	}
+	cmd.Flags().IntVar(&group, "group", 1, "group ID")
+	return cmd
+}
+
+// importFrom 按行读取并分批提交（每批 importChunkSize 条）
+func (k poolDataKind) importFrom(c *cli, group int, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var totalAdded, totalSkipped int
+	chunk := make([]string, 0, importChunkSize)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		added, skipped, err := k.submit(c, group, chunk)
+		if err != nil {
+			return err
+		}
+		totalAdded += added
+		totalSkipped += skipped
+		if c.output == "table" {
+			fmt.Fprintf(c.stderr, "submitted %d, added %d so far\n", totalAdded+totalSkipped, totalAdded)
+		}
+		chunk = chunk[:0]
+		return nil
+	}
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		chunk = append(chunk, line)
+		if len(chunk) >= importChunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	return c.printDone(fmt.Sprintf("imported: added %d, skipped %d", totalAdded, totalSkipped),
+		map[string]interface{}{"added": totalAdded, "skipped": totalSkipped})
+}
+
+// submit 批量添加，返回新增和跳过（重复或无效）的条数
+func (k poolDataKind) submit(c *cli, group int, values []string) (int, int, error) {
+	result, err := c.client.postResult(k.batchPath, nil, map[string]interface{}{k.batchField: values, "group_id": group})
+	if err != nil {
+		return 0, 0, err
+	}
+	added, _ := strconv.Atoi(cell(result["added"]))
+	skipped, _ := strconv.Atoi(cell(result["skipped"]))
+	return added, skipped, nil
+}
+
+func (k poolDataKind) exportCommand(c *cli) *cobra.Command {
+	var group int
+	var file string
+	cmd := &cobra.Command{
+		Use: "export", Short: "export " + k.item + "s of a group (one per line)", Args: exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			count := 0
+			write := func(w io.Writer) error {
+				bw := bufio.NewWriter(w)
+				var werr error
+				err := c.client.listAll(k.listPath, url.Values{"group_id": {strconv.Itoa(group)}}, func(items []map[string]interface{}) bool {
+					for _, item := range items {
+						if v, ok := item[k.field].(string); ok {
+							if _, werr = fmt.Fprintln(bw, v); werr != nil {
+								return false
+							}
+							count++
+						}
+					}
+					return true
+				})
+				if err != nil {
+					return err
+				}
+				if werr != nil {
+					return werr
+				}
+				return bw.Flush()
+			}
+			return writeOutput(c, file, write, func() string { return fmt.Sprintf("exported %d %s(s)", count, k.item) })
+		},
+	}
+	cmd.Flags().IntVar(&group, "group", 1, "group ID")
+	cmd.Flags().StringVar(&file, "file", "", "write to file instead of stdout")
+	return cmd
+}
+
+func (k poolDataKind) reloadCommand(c *cli) *cobra.Command {
+	var group int
+	cmd := &cobra.Command{
+		Use: "reload", Short: "reload " + k.item + " pool (all groups or one group)", Args: exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := url.Values{}
+			if group > 0 {
+				q.Set("group_id", strconv.Itoa(group))
+			}
+			var result interface{}
+			if err := c.client.post(k.reloadPath, q, nil, &result); err != nil {
+				return err
+			}
+			return c.printDone(k.item+" pool reloaded", result)
+		},
+	}
+	cmd.Flags().IntVar(&group, "group", 0, "group ID (default all groups)")
+	return cmd
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var siteColumns = []string{"id", "domain", "name", "template", "site_group_id", "status"}
+
+// siteIntFields CSV 导入时按整数提交的列
+var siteIntFields = map[string]bool{
+	"site_group_id": true, "keyword_group_id": true, "image_group_id": true, "article_group_id": true,
+	"cache_max_size_mb": true, "cache_max_entries": true,
+}
+
+func sitesCommand(c *cli) *cobra.Command {
+	cmd := &cobra.Command{Use: "sites", Short: "list, add, import, export and reload sites"}
+	cmd.AddCommand(sitesListCommand(c), sitesAddCommand(c), sitesImportCommand(c), sitesExportCommand(c),
+		&cobra.Command{
+			Use: "reload [domain]", Short: "reload site config cache (all sites or one domain)", Args: maxArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.postDone(withPathArg("/api/cache/site/reload", args), "site cache reloaded")
+			},
+		})
+	return cmd
+}
+
+func sitesListCommand(c *cli) *cobra.Command {
+	var group, pageNum, pageSize int
+	var search, status string
+	cmd := &cobra.Command{
+		Use: "list", Short: "list sites", Args: exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := url.Values{"page": {strconv.Itoa(pageNum)}, "page_size": {strconv.Itoa(pageSize)}}
+			if group > 0 {
+				q.Set("site_group_id", strconv.Itoa(group))
+			}
+			if search != "" {
+				q.Set("search", search)
+			}
+			if status != "" {
+				q.Set("status", status)
+			}
+			var data page
+			if err := c.client.get("/api/sites", q, &data); err != nil {
+				return err
+			}
+			if c.output == "json" {
+				return printJSON(c.stdout, data)
+			}
+			if err := c.printItems(data.Items, siteColumns); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.stdout, "page %d/%d, total %d\n", data.Page, data.Pages, data.Total)
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.IntVar(&group, "group", 0, "site group ID")
+	f.StringVar(&search, "search", "", "domain or name contains")
+	f.StringVar(&status, "status", "", "status filter (1 enabled, 0 disabled)")
+	f.IntVar(&pageNum, "page", 1, "page number")
+	f.IntVar(&pageSize, "page-size", 20, "page size (max 100)")
+	return cmd
+}
+
+func sitesAddCommand(c *cli) *cobra.Command {
+	var domain, name, template string
+	var group, keywordGroup, imageGroup, articleGroup int
+	cmd := &cobra.Command{
+		Use: "add", Short: "add a site", Args: exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			site := map[string]interface{}{"domain": domain, "name": name}
+			if template != "" {
+				site["template"] = template
+			}
+			for key, v := range map[string]int{
+				"site_group_id": group, "keyword_group_id": keywordGroup, "image_group_id": imageGroup, "article_group_id": articleGroup,
+			} {
+				if v > 0 {
+					site[key] = v
+				}
+			}
+			result, err := c.client.postResult("/api/sites", nil, site)
+			if err != nil {
+				return err
+			}
+			return c.printDone(fmt.Sprintf("site %s created (id %s)", domain, cell(result["id"])), result)
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&domain, "domain", "", "domain (required)")
+	f.StringVar(&name, "name", "", "site name (required)")
+	f.StringVar(&template, "template", "", "template name")
+	f.IntVar(&group, "group", 0, "site group ID (default 1)")
+	f.IntVar(&keywordGroup, "keyword-group", 0, "keyword group ID")
+	f.IntVar(&imageGroup, "image-group", 0, "image group ID")
+	f.IntVar(&articleGroup, "article-group", 0, "article group ID")
+	cmd.MarkFlagRequired("domain")
+	cmd.MarkFlagRequired("name")
+	return cmd
+}
+
+func sitesImportCommand(c *cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <file.json|file.csv>",
+		Short: "add sites from a JSON array or CSV file (header: domain,name,template,site_group_id,...)",
+		Args:  exactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sites, err := readSites(args[0])
+			if err != nil {
+				return err
+			}
+			created, failed := 0, 0
+			results := []map[string]interface{}{}
+			for _, site := range sites {
+				domain, _ := site["domain"].(string)
+				result, err := c.client.postResult("/api/sites", nil, site)
+				row := map[string]interface{}{"domain": domain, "ok": err == nil}
+				if err != nil {
+					failed++
+					row["error"] = err.Error()
+					if c.output == "table" {
+						fmt.Fprintf(c.stderr, "failed %s: %v\n", domain, err)
+					}
+				} else {
+					created++
+					row["id"] = result["id"]
+				}
+				results = append(results, row)
+			}
+			if err := c.printDone(fmt.Sprintf("imported %d site(s), %d failed", created, failed), results); err != nil {
+				return err
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d site(s) failed", failed)
+			}
+			return nil
+		},
+	}
+}
+
+// readSites 读取导入文件：.csv 按表头映射字段，其他按 JSON 数组（sites export 的输出可直接导入）
+func readSites(path string) ([]map[string]interface{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if !strings.HasSuffix(strings.ToLower(path), ".csv") {
+		var sites []map[string]interface{}
+		if err := json.NewDecoder(f).Decode(&sites); err != nil {
+			return nil, fmt.Errorf("parse %s: %v", path, err)
+		}
+		for _, s := range sites {
+			delete(s, "id")
+			delete(s, "version")
+			delete(s, "created_at")
+			delete(s, "updated_at")
+		}
+		return sites, nil
+	}
+
+	r := csv.NewReader(f)
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %v", err)
+	}
+	var sites []map[string]interface{}
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %v", err)
+		}
+		site := map[string]interface{}{}
+		for i, col := range header {
+			col = strings.TrimSpace(col)
+			if i >= len(record) || record[i] == "" {
+				continue
+			}
+			if siteIntFields[col] {
+				n, err := strconv.Atoi(record[i])
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %s must be an integer", line, col)
+				}
+				site[col] = n
+				continue
+			}
+			site[col] = record[i]
+		}
+		sites = append(sites, site)
+	}
+	return sites, nil
+}
+
+func sitesExportCommand(c *cli) *cobra.Command {
+	var group int
+	var file string
+	cmd := &cobra.Command{
+		Use: "export", Short: "export all sites as JSON", Args: exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := url.Values{}
+			if group > 0 {
+				q.Set("site_group_id", strconv.Itoa(group))
+			}
+			sites := []map[string]interface{}{}
+			if err := c.client.listAll("/api/sites", q, func(items []map[string]interface{}) bool {
+				sites = append(sites, items...)
+				return true
+			}); err != nil {
+				return err
+			}
+			return writeOutput(c, file, func(w io.Writer) error { return printJSON(w, sites) },
+				func() string { return fmt.Sprintf("exported %d site(s)", len(sites)) })
+		},
+	}
+	cmd.Flags().IntVar(&group, "group", 0, "site group ID")
+	cmd.Flags().StringVar(&file, "file", "", "write to file instead of stdout")
+	return cmd
+}
+
+// writeOutput 导出写入文件（完成后在 stderr 提示）或标准输出
+func writeOutput(c *cli, file string, write func(io.Writer) error, done func() string) error {
+	if file == "" {
+		return write(c.stdout)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "%s to %s\n", done(), file)
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	models "seo-generator/api/internal/model"
+)
+
+func spiderCommand(c *cli) *cobra.Command {
+	cmd := &cobra.Command{Use: "spider", Short: "list, add, import, export, run and stop spider projects"}
+	cmd.AddCommand(
+		spiderListCommand(c),
+		&cobra.Command{
+			Use: "get <id>", Short: "show a spider project", Args: spiderIDArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				var project map[string]interface{}
+				if err := c.client.get("/api/spider-projects/"+args[0], nil, &project); err != nil {
+					return err
+				}
+				return c.printObject(project)
+			},
+		},
+		&cobra.Command{
+			Use: "add <file.json>", Aliases: []string{"import"}, Short: "create a project from a JSON file (same format as export)",
+			Args: exactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error { return spiderImport(c, args[0]) },
+		},
+		spiderExportCommand(c),
+		spiderActionCommand(c, "run", "start a project run", "run requested"),
+		spiderActionCommand(c, "stop", "stop a running project", "stop requested"),
+		spiderActionCommand(c, "toggle", "enable or disable a project", "project toggled"),
+	)
+	return cmd
+}
+
+// spiderPage 爬虫项目列表响应（{success, data, total, page, page_size}）
+type spiderPage struct {
+	Data     []map[string]interface{} `json:"data"`
+	Total    int64                    `json:"total"`
+	Page     int                      `json:"page"`
+	PageSize int                      `json:"page_size"`
+}
+
+func spiderListCommand(c *cli) *cobra.Command {
+	var status, search string
+	var pageNum, pageSize int
+	cmd := &cobra.Command{
+		Use: "list", Short: "list spider projects", Args: exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := url.Values{"page": {strconv.Itoa(pageNum)}, "page_size": {strconv.Itoa(pageSize)}}
+			if status != "" {
+				q.Set("status", status)
+			}
+			if search != "" {
+				q.Set("search", search)
+			}
+			// 列表接口把分页字段放在顶层，整体解码
+			var data spiderPage
+			if err := c.client.request(http.MethodGet, "/api/spider-projects", q, nil, &data, true); err != nil {
+				return err
+			}
+			if c.output == "json" {
+				return printJSON(c.stdout, data)
+			}
+			if err := c.printItems(data.Data, []string{"id", "name", "status", "enabled", "schedule", "last_run_at", "total_items"}); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.stdout, "page %d, total %d\n", data.Page, data.Total)
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&status, "status", "", "status filter (idle/running/...)")
+	f.StringVar(&search, "search", "", "name or description contains")
+	f.IntVar(&pageNum, "page", 1, "page number")
+	f.IntVar(&pageSize, "page-size", 20, "page size (max 100)")
+	return cmd
+}
+
+func spiderExportCommand(c *cli) *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use: "export <id>", Short: "export project settings and files as JSON", Args: spiderIDArgs,
+		RunE: func(cmd *cobra.Command, args []string) error { return spiderExport(c, args[0], file) },
+	}
+	cmd.Flags().StringVar(&file, "file", "", "write to file instead of stdout")
+	return cmd
+}
+
+func spiderExport(c *cli, id, file string) error {
+	var project models.SpiderProject
+	if err := c.client.get("/api/spider-projects/"+id, nil, &project); err != nil {
+		return err
+	}
+	var files []models.SpiderProjectFile
+	if err := c.client.get("/api/spider-projects/"+id+"/files", nil, &files); err != nil {
+		return err
+	}
+
+	export := models.SpiderProjectCreate{
+		Name:          project.Name,
+		Description:   project.Description,
+		EntryFile:     project.EntryFile,
+		EntryFunction: project.EntryFunction,
+		StartURL:      project.StartURL,
+		Concurrency:   project.Concurrency,
+		CrawlType:     project.CrawlType,
+		OutputGroupID: project.OutputGroupID,
+		Schedule:      project.Schedule,
+		Enabled:       project.Enabled,
+		Files:         []models.SpiderFileCreate{},
+	}
+	if len(project.ConfigParsed) > 0 && string(project.ConfigParsed) != "null" {
+		if err := json.Unmarshal(project.ConfigParsed, &export.Config); err != nil {
+			return fmt.Errorf("parse project config: %v", err)
+		}
+	}
+	keep := project.FileVersionKeep
+	export.FileVersionKeep = &keep
+	limits := project.SpiderResourceLimits
+	export.MaxRuntime = &limits.MaxRuntime
+	export.MaxMemoryMB = &limits.MaxMemoryMB
+	export.MaxRequests = &limits.MaxRequests
+	export.DomainDelayMs = &limits.DomainDelayMs
+	export.RobotsMode = &limits.RobotsMode
+	for _, f := range files {
+		if f.Type == "file" {
+			// 创建接口按不带前导 / 的文件名匹配入口文件
+			export.Files = append(export.Files, models.SpiderFileCreate{Filename: strings.TrimPrefix(f.Path, "/"), Content: f.Content})
+		}
+	}
+
+	return writeOutput(c, file, func(w io.Writer) error { return printJSON(w, export) },
+		func() string {
+			return fmt.Sprintf("exported project %s with %d file(s)", project.Name, len(export.Files))
+		})
+}
+
+func spiderImport(c *cli, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var req models.SpiderProjectCreate
+	if err := json.Unmarshal(data, &req); err != nil {
+		return fmt.Errorf("parse %s: %v", path, err)
+	}
+	if req.Name == "" {
+		return fmt.Errorf("%s: name is required", path)
+	}
+	// 创建接口的 id 在顶层返回
+	var result map[string]interface{}
+	if err := c.client.request(http.MethodPost, "/api/spider-projects", nil, req, &result, true); err != nil {
+		return err
+	}
+	return c.printDone(fmt.Sprintf("project %s created (id %s)", req.Name, cell(result["id"])), result)
+}
+
+// spiderActionCommand 项目操作（run / stop / toggle）
+func spiderActionCommand(c *cli, action, short, msg string) *cobra.Command {
+	return &cobra.Command{
+		Use: action + " <id>", Short: short, Args: spiderIDArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.postDone("/api/spider-projects/"+args[0]+"/"+action, msg)
+		},
+	}
+}
+
+// spiderIDArgs 唯一的位置参数为数字项目 ID
+func spiderIDArgs(cmd *cobra.Command, args []string) error {
+	if err := exactArgs(1)(cmd, args); err != nil {
+		return err
+	}
+	if _, err := strconv.Atoi(args[0]); err != nil {
+		return fmt.Errorf("invalid project id %q", args[0])
+	}
+	return nil
+}
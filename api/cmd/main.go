@@ -17,6 +17,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"seo-generator/api/internal/di"
+	rpc "seo-generator/api/internal/grpc"
 	api "seo-generator/api/internal/handler"
 	models "seo-generator/api/internal/model"
 	database "seo-generator/api/internal/repository"
@@ -36,6 +37,11 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Failed to setup logger: %v\n", err)
 	}
 
+	// bootstrap 子命令：按初始化清单写入默认数据后退出，不启动服务
+	if len(os.Args) > 1 && os.Args[1] == "bootstrap" {
+		os.Exit(runBootstrap(findProjectRoot(), os.Args[2:]))
+	}
+
 	// Start pprof server for CPU profiling
 	go func() {
 		pprofPort := os.Getenv("PPROF_PORT")
@@ -65,7 +71,18 @@ func main() {
 		Bool("debug", cfg.Server.Debug).
 		Msg("Configuration loaded")
 
+	// 外部 HTTP 调用共用客户端（重试、熔断、限速、代理），需在创建各外部集成之前初始化
+	if _, err := core.InitHTTPClient(cfg.OutboundHTTP); err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize outbound HTTP client")
+	}
+
 	// Initialize database connection
+	// 调试模式启用故障注入（/api/admin/faults），数据库连接需在初始化前挂载钩子
+	if cfg.Server.Debug {
+		core.GetFaultInjector().Enable()
+		database.SetQueryHook(core.GetFaultInjector().DBHook)
+	}
+
 	if err := database.Init(&cfg.Database); err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize database")
 	}
@@ -73,6 +90,24 @@ func main() {
 
 	db := database.GetDB()
 
+	// 管理员提示语言偏好（响应文案按 ?lang=、偏好、Accept-Language 选择 zh-CN / en-US）
+	core.NewLocalePreferences(db)
+
+	// 空库提示执行初始化（站群或管理员为空时很多功能只能输出警告）
+	cfg.Bootstrap.Manifest = resolveManifestPath(projectRoot, "", cfg.Bootstrap.Manifest)
+	if needs, err := core.NeedsBootstrap(context.Background(), db); err == nil && needs {
+		log.Warn().
+			Str("manifest", cfg.Bootstrap.Manifest).
+			Msg("Database has no site group or admin, run `bootstrap` subcommand or POST /api/admin/bootstrap to seed defaults")
+	}
+
+	// 出站请求审计日志（挂到共享 HTTP 客户端，未启用时为 nil）
+	egressAuditor := core.NewEgressAuditor(db, cfg.EgressAudit)
+	if egressAuditor != nil {
+		egressAuditor.Start()
+		core.GetHTTPClient().SetAuditor(egressAuditor)
+	}
+
 	// Initialize Redis connection (optional)
 	var redisClient *redis.Client
 	if cfg.Redis.Enabled {
@@ -82,6 +117,10 @@ func main() {
 			DB:       cfg.Redis.DB,
 		})
 
+		if cfg.Server.Debug {
+			redisClient.AddHook(core.GetFaultInjector().RedisHook())
+		}
+
 		// Test connection
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		if err := redisClient.Ping(ctx).Err(); err != nil {
@@ -116,11 +155,13 @@ func main() {
 
 	siteCache := core.NewSiteCache(db)
 	templateCache := core.NewTemplateCache(db)
-	htmlCache := core.NewHTMLCache(cacheDir, cfg.Cache.MaxSizeGB)
+	htmlCache := core.NewHTMLCacheFromConfig(cfg.Cache, cacheDir, redisClient)
+	htmlCache.SetQuotaResolver(core.NewSiteQuotaResolver(siteCache, cfg.Cache))
 	funcsManager := core.NewTemplateFuncsManager(core.GetEncoder())
 
 	// Initialize pool manager for titles and contents (in-memory cache)
 	poolManager := core.NewPoolManager(db)
+	poolManager.ConfigureUpdates(cfg.PoolUpdates, projectRoot)
 
 	// Load emojis BEFORE Start() so KeywordEmojiGenerator workers have emoji data
 	emojisPath := filepath.Join(projectRoot, "data", "emojis.json")
@@ -130,6 +171,43 @@ func main() {
 		log.Info().Int("count", poolManager.GetEmojiCount()).Msg("Emojis loaded to PoolManager")
 	}
 
+	// Initialize banned-word content filter (applied at article insert and pool-fill time)
+	contentFilter := core.NewContentFilter(db)
+	if err := contentFilter.Start(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load content filter rules (tables may not exist)")
+	}
+	poolManager.SetContentFilter(contentFilter)
+
+	// 文章入库 HTML 清洗（白名单，分组策略）
+	articleSanitizer := core.NewArticleSanitizer(db)
+	if err := articleSanitizer.Reload(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load article sanitize policies (table may not exist)")
+	}
+
+	// 文章摘要（入库时生成，模板 summary() 输出）
+	excerpts, err := core.NewExcerptGenerator(cfg.Excerpt)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid excerpt config")
+	}
+
+	// 关键词入库过滤（长度、正则黑名单、分组停用词）
+	keywordFilter := core.NewKeywordFilter(db, cfg.KeywordFilter)
+	if err := keywordFilter.Reload(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load keyword stopwords (table may not exist)")
+	}
+
+	// 功能开关（feature_flags 表，Redis pub/sub 热更新）
+	featureFlags := core.NewFeatureFlags(db, redisClient)
+	if err := featureFlags.Start(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load feature flags, all flags disabled")
+	}
+
+	// 后台热点列表查询缓存（写操作按表失效，Redis 通知其他实例）
+	queryCache := core.InitQueryCache(cfg.QueryCache, redisClient)
+	if queryCache != nil {
+		queryCache.Start()
+	}
+
 	poolCtx := context.Background()
 	if err := poolManager.Start(poolCtx); err != nil {
 		log.Fatal().Err(err).Msg("Failed to start PoolManager")
@@ -142,6 +220,26 @@ func main() {
 	if err := siteCache.LoadAll(ctx); err != nil {
 		log.Fatal().Err(err).Msg("Failed to load sites into cache")
 	}
+	if err := siteCache.LoadRedirects(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to load site redirects (table may not exist)")
+	}
+	siteCache.StartSync(time.Duration(cfg.Cache.SiteSyncSeconds)*time.Second, redisClient)
+
+	// Enable extra template function packs (before templates are analyzed)
+	funcPacks := cfg.TemplateFuncs.Packs
+	if cfg.WASMExtensions.Enabled {
+		funcPacks = append(funcPacks, "wasm")
+	}
+	if cfg.AntiScrape.Enabled && cfg.AntiScrape.HoneypotPrefix != "" {
+		funcPacks = append(funcPacks, "honeypot")
+	}
+	if cfg.Archive.Enabled {
+		funcPacks = append(funcPacks, "archive")
+	}
+	if excerpts != nil {
+		funcPacks = append(funcPacks, "excerpt")
+	}
+	core.EnableTemplateFuncPacks(funcPacks)
 
 	// Initialize template analyzer
 	log.Info().Msg("Initializing template analyzer...")
@@ -152,6 +250,12 @@ func main() {
 	// Set analyzer on template cache (before loading templates)
 	templateCache.SetAnalyzer(templateAnalyzer)
 
+	// 模板片段库（模板载入缓存时展开 include，需在加载模板之前加载）
+	templateSnippets := core.NewTemplateSnippets(db, templateCache)
+	if err := templateSnippets.Load(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to load template snippets (table may not exist)")
+	}
+
 	// Load all templates into cache at startup
 	log.Info().Msg("Loading all templates into cache...")
 	if err := templateCache.LoadAll(ctx); err != nil {
@@ -183,11 +287,25 @@ func main() {
 	// Register task handlers
 	core.RegisterAllHandlers(scheduler, poolManager, templateCache, db, redisClient)
 
+	// 加密备份（定时任务按 backup.schedule 同步到 scheduled_tasks）
+	backupManager := core.NewBackupManager(db, cfg.Backup)
+	scheduler.RegisterHandler(core.NewBackupHandler(backupManager))
+
+	// 搜索词导入（百度统计 / GSC，定时任务按 keyword_import.schedule 同步）
+	keywordImporter := core.NewKeywordImporter(db, cfg.KeywordImport)
+	scheduler.RegisterHandler(core.NewKeywordImportHandler(keywordImporter))
+
 	// Start scheduler
 	schedCtx := context.Background()
 	if err := scheduler.Start(schedCtx); err != nil {
 		log.Warn().Err(err).Msg("Failed to start scheduler (tables may not exist)")
 	}
+	if err := backupManager.EnsureSchedule(schedCtx, scheduler); err != nil {
+		log.Warn().Err(err).Msg("Failed to sync backup schedule")
+	}
+	if err := keywordImporter.EnsureSchedule(schedCtx, scheduler); err != nil {
+		log.Warn().Err(err).Msg("Failed to sync search query import schedule")
+	}
 
 	// Create a separate emojiManager for funcsManager (used in template rendering fallback)
 	emojiManager := core.NewEmojiManager()
@@ -197,6 +315,22 @@ func main() {
 	funcsManager.SetEmojiManager(emojiManager)
 	funcsManager.SetKeywordEmojiGenerator(poolManager.GetKeywordEmojiGenerator())
 
+	// 图片 URL 签名（CDN 鉴权），配置错误时拒绝启动，避免输出无法访问的图片
+	imageSigner, err := core.NewImageURLSigner(cfg.ImageSigning)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid image signing configuration")
+	}
+	if imageSigner != nil {
+		funcsManager.SetImageURLSigner(imageSigner)
+		for _, g := range imageSigner.Groups() {
+			if cfg.Cache.Enabled && g.TTLSeconds < cfg.Cache.TTLHours*3600 {
+				log.Warn().Int("group_id", g.GroupID).Int("ttl_seconds", g.TTLSeconds).Int("cache_ttl_hours", cfg.Cache.TTLHours).
+					Msg("Signed image URL TTL is shorter than the page cache TTL; cached pages may show expired images")
+			}
+		}
+		log.Info().Int("groups", len(imageSigner.Groups())).Msg("Image URL signing enabled")
+	}
+
 	// Note: keywords/images are now loaded by PoolManager.Start()
 	// 初始化 TemplateFuncsManager 的关键词数据
 	keywordGroupIDs := poolManager.GetKeywordGroupIDs()
@@ -229,16 +363,181 @@ func main() {
 	log.Info().Int("groups", len(imageGroupIDs)).Int("total_images", totalImages).
 		Msg("All image groups loaded to funcs manager")
 
+	// Create ClickHouse sink (optional, for spider visit analytics)
+	var clickhouseSink *core.ClickHouseSink
+	if cfg.ClickHouse.Enabled {
+		sink, err := core.NewClickHouseSink(cfg.ClickHouse)
+		if err != nil {
+			log.Warn().Err(err).Msg("Invalid ClickHouse config, analytics stay on MySQL")
+		} else {
+			initCtx, initCancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := sink.EnsureTable(initCtx); err != nil {
+				log.Warn().Err(err).Msg("ClickHouse unavailable, analytics stay on MySQL")
+			} else {
+				clickhouseSink = sink
+				log.Info().Str("url", cfg.ClickHouse.URL).Bool("mirror_mysql", cfg.ClickHouse.MirrorMySQL).
+					Msg("ClickHouse sink enabled")
+			}
+			initCancel()
+		}
+	}
+
+	// Create spider log ingester (batch ingestion from page and Nginx Lua, async bulk insert)
+	// 内存缓冲区满时溢出到 Redis（未连接 Redis 时丢弃）
+	spiderLogIngester := core.NewSpiderLogIngester(db, core.DefaultSpiderLogIngesterConfig())
+	if redisClient != nil {
+		spiderLogIngester.SetRedis(redisClient)
+	}
+	if clickhouseSink != nil {
+		spiderLogIngester.SetClickHouseSink(clickhouseSink, cfg.ClickHouse.MirrorMySQL)
+	}
+	spiderLogIngester.Start()
+
+	// Template render sandbox (per-render timeout, iterate and function call caps)
+	core.SetRenderLimits(cfg.TemplateSandbox)
+	core.SetCompileCacheLimits(cfg.TemplateCompile)
+
+	// Create template health (render error budget, auto-degrade to group fallback template)
+	templateHealth := core.NewTemplateHealth(db, cfg.TemplateBudget)
+	if err := templateHealth.Start(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to load template health state (columns may not exist)")
+	}
+
+	// Create template usage (buffered render counters for the template library)
+	templateUsage := core.NewTemplateUsage(db)
+	templateUsage.Start()
+
 	// Create page handler
-	pageHandler := api.NewPageHandler(
-		db,
-		cfg,
-		siteCache,
-		templateCache,
-		htmlCache,
-		funcsManager,
-		poolManager,
-	)
+	// 蜘蛛内容策略（按站群和蜘蛛类型切换模板/关键词分组/标题格式）
+	spiderStrategies := core.NewSpiderStrategyResolver(db)
+	if err := spiderStrategies.Reload(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load spider strategies (table may not exist)")
+	}
+
+	// 反采集（非蜘蛛请求的 JS 挑战/延迟/封禁）
+	antiScrapeSecret := cfg.AntiScrape.Secret
+	if antiScrapeSecret == "" {
+		antiScrapeSecret = cfg.Auth.SecretKey
+	}
+	antiScraper := core.NewAntiScraper(cfg.AntiScrape, redisClient, antiScrapeSecret)
+	if antiScraper.Enabled() {
+		antiScraper.Start(context.Background())
+	}
+
+	// 关键词抓取反馈（页面关键词与蜘蛛访问关联）
+	keywordFeedback := core.NewKeywordFeedback(db, poolManager, cfg.KeywordFeedback)
+	if keywordFeedback.Enabled() {
+		keywordFeedback.Start(context.Background())
+	}
+
+	// 中文分词（关键词密度、拼音 slug、伪原创同义词替换）
+	segmenter := core.NewTextSegmenter(db, cfg.Segmenter)
+	if err := segmenter.Reload(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load segment words (table may not exist)")
+	}
+	spintax := core.NewSpintaxRewriter(segmenter, cfg.Spintax)
+	spintax.Reload()
+
+	// 拼音 slug 缓存（pinyinSlug 模板函数，关键词 slug 在后台预先计算）
+	pinyinSlugs := core.NewPinyinSlugCache(cfg.PinyinSlug, segmenter)
+	poolManager.SetPinyinSlugCache(pinyinSlugs)
+	funcsManager.SetPinyinSlugCache(pinyinSlugs)
+
+	// URL 生成策略（random_url、站内链接与 sitemap 共用）
+	urlStrategies := core.NewURLStrategyManager(db, cfg.URLStrategy, funcsManager, segmenter)
+	if err := urlStrategies.Reload(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load url strategies (table may not exist)")
+	}
+
+	// 归档列表页（/list/{分类}/{页码}.html，内容取自 URL 策略）
+	var archivePages *core.ArchivePages
+	if cfg.Archive.Enabled {
+		archivePages = core.NewArchivePages(cfg.Archive, urlStrategies)
+	}
+
+	// 模拟发布日期（{{ publish_date() }}）
+	publishDates := core.NewPublishDates(db, cfg.PublishDate)
+	if err := publishDates.Reload(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load publish date policies (table may not exist)")
+	}
+
+	// TDK 自动补全（功能开关 auto_tdk）
+	autoTDK := core.NewAutoTDK(cfg.AutoTDK)
+
+	// 固定页面（指定 URL 显示手写内容）
+	pinnedPages := core.NewPinnedPages(db, htmlCache, siteCache, spiderStrategies)
+	if err := pinnedPages.Reload(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load pinned pages (table may not exist)")
+	}
+
+	// 页面级 robots 规则（meta robots 和站外链接 nofollow）
+	robotsPolicies := core.NewRobotsPolicies(db)
+	if err := robotsPolicies.Reload(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load robots policies (table may not exist)")
+	}
+
+	// 模板灰度发布（站群按比例切换到新模板）
+	templateRollouts := core.NewTemplateRollouts(db, siteCache)
+	if err := templateRollouts.Reload(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load template rollouts (table may not exist)")
+	}
+
+	// 搜索引擎验证文件（按站点在域名根目录返回）
+	verificationFiles := core.NewVerificationFiles(db, htmlCache, spiderStrategies)
+	if err := verificationFiles.Reload(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load verification files (table may not exist)")
+	}
+
+	// CSS 混淆（功能开关 css_obfuscation）
+	cssObfuscator := core.NewCSSObfuscator(cfg.CSSObfuscation)
+	linkAuditor := core.NewLinkAuditor(cfg.LinkAudit)
+
+	// WASM 渲染扩展（按站群转换标题/正文，plugin() 模板函数），模块文件变化自动重载
+	var wasmExtensions *core.WASMExtensions
+	if cfg.WASMExtensions.Enabled {
+		wasmExtensions, err = core.NewWASMExtensions(context.Background(), db, cfg.WASMExtensions)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to create wasm extension runtime")
+		} else {
+			wasmCtx, wasmCancel := context.WithCancel(context.Background())
+			go wasmExtensions.Start(wasmCtx)
+			defer wasmCancel()
+		}
+	}
+
+	// IP 白名单与受信代理（页面反采集、登录限流和管理接口白名单共用同一客户端 IP 解析）
+	ipAllowlist := core.NewIPAllowlist(db, cfg.IPAllowlist)
+	ipAllowlist.Start(context.Background())
+
+	pageHandler := api.NewPageHandler(api.PageHandlerDeps{
+		DB:                db,
+		Config:            cfg,
+		SiteCache:         siteCache,
+		TemplateCache:     templateCache,
+		HTMLCache:         htmlCache,
+		TemplateFuncs:     funcsManager,
+		PoolManager:       poolManager,
+		LogIngester:       spiderLogIngester,
+		TemplateHealth:    templateHealth,
+		TemplateUsage:     templateUsage,
+		Strategies:        spiderStrategies,
+		AntiScrape:        antiScraper,
+		KeywordFeedback:   keywordFeedback,
+		URLStrategies:     urlStrategies,
+		PublishDates:      publishDates,
+		AutoTDK:           autoTDK,
+		PinnedPages:       pinnedPages,
+		VerificationFiles: verificationFiles,
+		Extensions:        wasmExtensions,
+		CSSObfuscator:     cssObfuscator,
+		RobotsPolicies:    robotsPolicies,
+		Archives:          archivePages,
+		Rollouts:          templateRollouts,
+		LinkAuditor:       linkAuditor,
+		Excerpts:          excerpts,
+		Allowlist:         ipAllowlist,
+		Spintax:           spintax,
+	})
 
 	// === 异步模板预热 ===
 	go func() {
@@ -282,7 +581,7 @@ func main() {
 	)
 
 	// Create log handler (for Nginx Lua cache hit logging)
-	logHandler := api.NewLogHandler(db)
+	logHandler := api.NewLogHandler(db, spiderLogIngester)
 
 	// Setup Gin
 	if !cfg.Server.Debug {
@@ -295,22 +594,44 @@ func main() {
 	r.Use(core.RequestLogger()) // 使用 core.RequestLogger 替代本地 requestLogger
 	r.Use(core.Recovery())      // 使用 core.Recovery 替代 gin.Recovery
 
+	// 按路由模板统计延迟直方图、状态码和处理中请求数（/api/admin/system/metrics、/api/metrics/prometheus）
+	r.Use(core.EndpointMetricsMiddleware())
+
+	// panic / 5xx 错误上报（error_reporting.enabled），注册在 Recovery 之后
+	errorReporter, err := core.NewErrorReporter(cfg.ErrorReporting)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize error reporting")
+	}
+	if errorReporter != nil {
+		r.Use(errorReporter.Middleware())
+		defer errorReporter.Close()
+	}
+
+	// 结构化访问日志（access_log.enabled）
+	accessLog, err := core.NewAccessLog(cfg.AccessLog, projectRoot)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to open access log")
+	}
+	if accessLog != nil {
+		r.Use(accessLog.Middleware())
+		defer accessLog.Close()
+	}
+
 	// CORS middleware for cross-origin requests from admin panel
-	r.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	r.Use(api.CORSMiddleware(cfg.CORS))
 
-		c.Next()
-	})
+	// 运行时日志级别（从 system_settings 恢复）
+	logLevels := core.NewLogLevels(db, logConfig.Level)
+	logLevels.Start(context.Background())
+	// 管理接口 IP 白名单（作用于 /api/*、/ws/*、/sse/*，需在注册路由前挂载）
+	r.Use(api.IPAllowlistMiddleware(ipAllowlist))
 
 	// Routes - Page rendering
 	r.GET("/page", pageHandler.ServePage)
+	// 直接对外服务（无 Nginx 改写为 /page?ua=&path=&domain=）时，其余请求按 Host 和路径渲染
+	if cfg.Server.TLS.Enabled || cfg.Server.DirectPages {
+		r.NoRoute(pageHandler.ServeDirect)
+	}
 	r.GET("/health", pageHandler.Health)
 	r.GET("/stats", pageHandler.Stats)
 
@@ -325,6 +646,7 @@ func main() {
 		// Cache reload routes (for permanent cache updates)
 		apiGroup.POST("/cache/site/reload", cacheHandler.ReloadAllSites)
 		apiGroup.POST("/cache/site/reload/:domain", cacheHandler.ReloadSite)
+		apiGroup.POST("/cache/site/sync", cacheHandler.SyncSites)
 		apiGroup.POST("/cache/template/reload", cacheHandler.ReloadAllTemplates)
 		apiGroup.POST("/cache/template/reload/:name", cacheHandler.ReloadTemplate)
 
@@ -334,46 +656,232 @@ func main() {
 		// Cache stats routes
 		apiGroup.GET("/cache/stats", cacheHandler.GetCacheStats)
 		apiGroup.POST("/cache/stats/recalculate", cacheHandler.RecalculateCacheStats)
+		apiGroup.GET("/cache/stats/recalculate/status", cacheHandler.RecalculateStatus)
 
 		// Log routes (for Nginx Lua cache hit logging)
 		apiGroup.GET("/log/spider", logHandler.LogSpiderVisit)
+		apiGroup.POST("/log/spider/batch", logHandler.LogSpiderVisitBatch)
 	}
 
 	// 初始化监控服务
 	log.Info().Msg("Initializing monitor service...")
 	monitor := core.NewMonitor(10*time.Second, 360) // 10秒采集一次，保留1小时历史
+	monitor.AddAlertRule(core.NewPoolExhaustionAlertRule(poolManager, core.DefaultPoolForecastAlertHours))
+	templateHealth.SetAlertManager(monitor.GetAlertManager())
+	templateRollouts.SetAlertManager(monitor.GetAlertManager())
+
+	// 登录防爆破（限流依赖 Redis，Redis 不可用时只记录登录尝试）
+	loginGuard := core.NewLoginGuard(db, redisClient, cfg.LoginGuard)
+	loginGuard.SetAlertManager(monitor.GetAlertManager())
+
+	// 站点域名健康检查（DNS / 公网 HTTP / 证书有效期，连续失败时告警）
+	domainMonitor := core.NewDomainMonitor(db, cfg.DomainMonitor)
+	domainMonitor.SetAlertManager(monitor.GetAlertManager())
+	if cfg.DomainMonitor.Enabled {
+		domainMonitorCtx, domainMonitorCancel := context.WithCancel(context.Background())
+		go domainMonitor.Start(domainMonitorCtx)
+		defer domainMonitorCancel()
+	}
+	// 自定义告警规则（alert_rules 表，每次监控采集后评估）
+	alertRules := core.NewAlertRules(db, monitor.GetAlertManager(), core.NewAlertWebhook(cfg.Alerting))
+	if err := alertRules.Reload(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load alert rules")
+	}
+	monitor.SetAlertRules(alertRules)
 	monitor.Start()
 
+	// 实时推送连接中心（/ws/* 和 /sse/* 的票据校验、连接数限制）
+	wsHub := core.NewWSHub(redisClient, cfg.WebSocket)
+	monitor.RegisterStats("websocket", wsHub.Stats)
+
 	// 初始化系统统计采集器
 	log.Info().Msg("Initializing system stats collector...")
 	systemStats := core.NewSystemStatsCollector()
 
+	// 系统指标历史持久化（每分钟采样，降采样为 5 分钟、1 小时）
+	var systemMetrics *core.SystemMetricsStore
+	if cfg.SystemMetrics.Enabled {
+		systemMetrics = core.NewSystemMetricsStore(db, systemStats, monitor, cfg.SystemMetrics)
+		systemMetricsCtx, systemMetricsCancel := context.WithCancel(context.Background())
+		go systemMetrics.Start(systemMetricsCtx)
+		defer systemMetricsCancel()
+	}
+
+	// 初始化后台作业管理器（批量删除、导入等长耗时操作）
+	jobManager := core.NewJobManager(db, 2, 100)
+	jobManager.Start()
+
+	// LLM 改写（OpenAI 兼容接口，按文章分组启用，改写完成后文章再进入加工队列）
+	llmGateway, err := core.NewLLMGateway(db, cfg.LLM)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid llm config")
+	}
+	articleRewriter := core.NewArticleRewriter(db, llmGateway, jobManager)
+	// 原始文章入库流程（后台 HTTP 接口、爬虫写入和 gRPC SubmitItems 共用）
+	articleIngester := core.NewArticleIngester(db, redisClient, contentFilter, articleSanitizer, excerpts, articleRewriter)
+	if llmGateway != nil {
+		if err := llmGateway.LoadUsage(context.Background()); err != nil {
+			log.Warn().Err(err).Msg("Failed to load LLM usage (table may not exist)")
+		}
+		if err := articleRewriter.Reload(context.Background()); err != nil {
+			log.Warn().Err(err).Msg("Failed to load LLM rewrite groups (table may not exist)")
+		}
+	}
+
+	// 长尾关键词扩展（定时任务 expand_keywords，以后台作业执行）
+	keywordExpander := core.NewKeywordExpander(db, cfg.KeywordExpand, contentFilter, poolManager, funcsManager, jobManager)
+	scheduler.RegisterHandler(core.NewExpandKeywordsHandler(keywordExpander))
+
+	// 冷正文归档（定时任务按 content_archive.schedule 同步，以后台作业执行）
+	contentArchiver := core.NewContentArchiver(db, cfg.ContentArchive, jobManager)
+	scheduler.RegisterHandler(core.NewArchiveContentsHandler(contentArchiver))
+	if err := contentArchiver.EnsureSchedule(schedCtx, scheduler); err != nil {
+		log.Warn().Err(err).Msg("Failed to sync content archive schedule")
+	}
+
+	// 内容保鲜（定时任务按 freshness.schedule 同步，以后台作业执行，用页面 handler 重新渲染）
+	freshness := core.NewFreshness(db, htmlCache, spiderStrategies, cfg.Freshness, jobManager)
+	freshness.SetRefresher(pageHandler)
+	scheduler.RegisterHandler(core.NewFreshnessRefreshHandler(freshness))
+	if err := freshness.EnsureSchedule(schedCtx, scheduler); err != nil {
+		log.Warn().Err(err).Msg("Failed to sync freshness schedule")
+	}
+
+	// 内容增量统计（定时任务按 activity_stats.schedule 同步，每日汇总新增内容和数据池消耗）
+	activityStats := core.NewActivityStats(db, cfg.ActivityStats, jobManager)
+	scheduler.RegisterHandler(core.NewActivityRollupHandler(activityStats))
+	if err := activityStats.EnsureSchedule(schedCtx, scheduler); err != nil {
+		log.Warn().Err(err).Msg("Failed to sync activity stats schedule")
+	}
+
+	// 文章摘要回填（爬虫直接写库和历史文章，定时任务按 excerpt.schedule 同步）
+	if excerpts != nil {
+		summaryBackfill := core.NewSummaryBackfill(db, excerpts, jobManager)
+		scheduler.RegisterHandler(core.NewBackfillSummariesHandler(summaryBackfill))
+		if err := summaryBackfill.EnsureSchedule(schedCtx, scheduler); err != nil {
+			log.Warn().Err(err).Msg("Failed to sync summary backfill schedule")
+		}
+	}
+
+	// 请求回放（升级切换前验证新实例）
+	replayer := core.NewReplayer(db, jobManager, core.AccessLogPath(cfg.AccessLog, projectRoot))
+
+	// 渲染自检（每个站群定期按正常流程渲染一页，连续失败时告警）
+	var canary *core.Canary
+	if cfg.Canary.Enabled {
+		canary = core.NewCanary(db, cfg.Canary)
+		canary.SetRenderer(pageHandler)
+		canary.SetAlertManager(monitor.GetAlertManager())
+		canaryCtx, canaryCancel := context.WithCancel(context.Background())
+		go canary.Start(canaryCtx)
+		defer canaryCancel()
+	}
+
+	// Initialize and start SpiderOutputCapture（保存测试运行数据项用于预览，需要 Redis）
+	var spiderOutput *core.SpiderOutputCapture
+	if redisClient != nil {
+		spiderOutput = core.NewSpiderOutputCapture(redisClient, cfg.SpiderOutput)
+		spiderOutputCtx, spiderOutputCancel := context.WithCancel(context.Background())
+		go spiderOutput.Start(spiderOutputCtx)
+		defer spiderOutputCancel()
+	}
+
+	// 内容处理代码文件管理（限制在根目录内）和文件回收站（定时清除到期条目）
+	workerFiles := core.NewConfinedFS(db, cfg.WorkerFiles)
+	fileTrash := core.NewFileTrash(db, workerFiles, cfg.Trash)
+	trashCtx, trashCancel := context.WithCancel(context.Background())
+	go fileTrash.Start(trashCtx)
+	defer trashCancel()
+
 	// Configure Admin API routes
 	deps := &api.Dependencies{
-		DB:               db,
-		Redis:            redisClient,
-		Config:           cfg,
-		TemplateAnalyzer: templateAnalyzer,
-		TemplateFuncs:    funcsManager,
-		Scheduler:        scheduler,
-		TemplateCache:    templateCache,
-		Monitor:          monitor,
-		PoolManager:      poolManager,
-		SystemStats:      systemStats,
-		SiteCache:        siteCache,
+		DB:                db,
+		Redis:             redisClient,
+		Config:            cfg,
+		TemplateAnalyzer:  templateAnalyzer,
+		TemplateFuncs:     funcsManager,
+		Scheduler:         scheduler,
+		TemplateCache:     templateCache,
+		Monitor:           monitor,
+		PoolManager:       poolManager,
+		SystemStats:       systemStats,
+		SiteCache:         siteCache,
+		JobManager:        jobManager,
+		ContentFilter:     contentFilter,
+		KeywordFilter:     keywordFilter,
+		ImageSigner:       imageSigner,
+		ArticleSanitizer:  articleSanitizer,
+		Excerpts:          excerpts,
+		LLMGateway:        llmGateway,
+		ArticleRewriter:   articleRewriter,
+		ArticleIngester:   articleIngester,
+		ClickHouse:        clickhouseSink,
+		TemplateHealth:    templateHealth,
+		TemplateUsage:     templateUsage,
+		Sessions:          core.NewSessionStore(db, cfg.Auth.MaxSessions),
+		LoginGuard:        loginGuard,
+		IPAllowlist:       ipAllowlist,
+		Backups:           backupManager,
+		LogLevels:         logLevels,
+		FeatureFlags:      featureFlags,
+		SpiderStrategies:  spiderStrategies,
+		AntiScraper:       antiScraper,
+		KeywordFeedback:   keywordFeedback,
+		KeywordImporter:   keywordImporter,
+		KeywordExpander:   keywordExpander,
+		URLStrategies:     urlStrategies,
+		Segmenter:         segmenter,
+		Spintax:           spintax,
+		PublishDates:      publishDates,
+		AutoTDK:           autoTDK,
+		ContentArchiver:   contentArchiver,
+		RobotsChecker:     core.NewRobotsChecker(db, cfg.SpiderRobots),
+		SpiderOutput:      spiderOutput,
+		Freshness:         freshness,
+		PinnedPages:       pinnedPages,
+		DomainMonitor:     domainMonitor,
+		VerificationFiles: verificationFiles,
+		WASMExtensions:    wasmExtensions,
+		CSSObfuscator:     cssObfuscator,
+		LinkAuditor:       linkAuditor,
+		EgressAuditor:     egressAuditor,
+		RobotsPolicies:    robotsPolicies,
+		WSHub:             wsHub,
+		SystemMetrics:     systemMetrics,
+		AlertRules:        alertRules,
+		Canary:            canary,
+		Replayer:          replayer,
+		Rollouts:          templateRollouts,
+		Snippets:          templateSnippets,
+		ActivityStats:     activityStats,
+		WorkerFiles:       workerFiles,
+		FileTrash:         fileTrash,
 	}
 	api.SetupRouter(r, deps)
 
-	// Initialize and start StatsArchiver (requires Redis)
-	var statsArchiver *core.StatsArchiver
+	// Initialize and start StatsArchiver（域名缓存统计归档 cache_stats_hourly，不依赖 Redis）
+	statsArchiver := core.NewStatsArchiver(db)
+	archiverCtx, archiverCancel := context.WithCancel(context.Background())
+	go statsArchiver.Start(archiverCtx)
+	defer archiverCancel()
+	log.Info().Msg("StatsArchiver initialized and started")
+
+	// Initialize and start SpiderStatsArchiver（爬虫项目实时统计归档到 spider_stats_history，需要 Redis）
+	var spiderStatsArchiver *core.SpiderStatsArchiver
 	if redisClient != nil {
-		statsArchiver = core.NewStatsArchiver(db, redisClient)
-		archiverCtx, archiverCancel := context.WithCancel(context.Background())
-		go statsArchiver.Start(archiverCtx)
-		defer archiverCancel()
-		log.Info().Msg("StatsArchiver initialized and started")
+		spiderStatsArchiver = core.NewSpiderStatsArchiver(db, redisClient, cfg.SpiderStats)
+		go spiderStatsArchiver.Start(archiverCtx)
+		log.Info().Msg("SpiderStatsArchiver initialized and started")
 	} else {
-		log.Info().Msg("StatsArchiver skipped (Redis not available)")
+		log.Info().Msg("SpiderStatsArchiver skipped (Redis not available)")
+	}
+
+	// Initialize and start SpiderRuntimeGuard（超出 max_runtime 的爬虫运行由 API 发送 stop 命令，需要 Redis）
+	if redisClient != nil {
+		spiderRuntimeGuard := core.NewSpiderRuntimeGuard(db, redisClient)
+		spiderRuntimeGuardCtx, spiderRuntimeGuardCancel := context.WithCancel(context.Background())
+		go spiderRuntimeGuard.Start(spiderRuntimeGuardCtx)
+		defer spiderRuntimeGuardCancel()
 	}
 
 	// Initialize and start SpiderLogsArchiver
@@ -394,23 +902,25 @@ func main() {
 		log.Info().Msg("PoolReloader skipped (Redis or TemplateFuncsManager not available)")
 	}
 
-	// Create server
-	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
-	srv := &http.Server{
-		Addr:         addr,
-		Handler:      r,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 60 * time.Second,
-		IdleTimeout:  120 * time.Second,
+	// Start internal gRPC API (content worker / spider runner; Redis path kept for old workers)
+	var grpcServer *rpc.Server
+	if cfg.GRPC.Enabled {
+		grpcServer = rpc.NewServer(db, redisClient, articleIngester, cfg.GRPC)
+		if err := grpcServer.Start(); err != nil {
+			log.Error().Err(err).Msg("Failed to start gRPC internal API")
+			grpcServer = nil
+		}
 	}
 
-	// Start server in goroutine
-	go func() {
-		log.Info().Str("addr", addr).Msg("Server starting")
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal().Err(err).Msg("Server failed")
-		}
-	}()
+	// Create server (HTTP, or HTTPS + HTTP redirect when server.tls.enabled)
+	if dir := cfg.Server.TLS.AutocertCacheDir; dir != "" && !filepath.IsAbs(dir) {
+		cfg.Server.TLS.AutocertCacheDir = filepath.Join(projectRoot, dir)
+	}
+	srv, err := core.NewHTTPServers(cfg.Server, r, siteCache)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to configure server")
+	}
+	srv.Start()
 
 	// Wait for interrupt signal (SIGINT, SIGTERM for shutdown, SIGHUP for reload)
 	quit := make(chan os.Signal, 1)
@@ -430,6 +940,17 @@ func main() {
 
 	log.Info().Msg("Shutting down server...")
 
+	// Stop gRPC server before closing Redis/DB it depends on
+	if grpcServer != nil {
+		grpcServer.Stop(10 * time.Second)
+		log.Info().Msg("gRPC internal API stopped")
+	}
+
+	// Stop SpiderStatsArchiver before closing Redis（进度和基准保存在 Redis，下次启动补齐）
+	if spiderStatsArchiver != nil {
+		spiderStatsArchiver.Stop()
+	}
+
 	// Close Redis connection
 	if redisClient != nil {
 		if err := redisClient.Close(); err != nil {
@@ -440,15 +961,43 @@ func main() {
 	}
 
 	// Stop StatsArchiver
-	if statsArchiver != nil {
-		statsArchiver.Stop()
-		log.Info().Msg("StatsArchiver stopped")
-	}
+	statsArchiver.Stop()
+	log.Info().Msg("StatsArchiver stopped")
 
 	// Stop SpiderLogsArchiver
 	spiderLogsArchiver.Stop()
 	log.Info().Msg("SpiderLogsArchiver stopped")
 
+	// Flush buffered spider logs
+	spiderLogIngester.Stop()
+	log.Info().Msg("SpiderLogIngester stopped")
+
+	// Flush buffered egress logs
+	if egressAuditor != nil {
+		egressAuditor.Stop()
+		log.Info().Msg("EgressAuditor stopped")
+	}
+
+	// Stop template health
+	templateHealth.Stop()
+	log.Info().Msg("TemplateHealth stopped")
+
+	// Stop HTML cache background tasks
+	htmlCache.Close()
+
+	// Flush template usage counters
+	templateUsage.Stop()
+	log.Info().Msg("TemplateUsage stopped")
+
+	ipAllowlist.Stop()
+	logLevels.Stop()
+	antiScraper.Stop()
+	keywordFeedback.Stop()
+
+	// Stop job manager (running jobs receive cancellation)
+	jobManager.Stop()
+	log.Info().Msg("JobManager stopped")
+
 	// 停止监控服务
 	monitor.Stop()
 	log.Info().Msg("Monitor stopped")
@@ -457,6 +1006,15 @@ func main() {
 	poolManager.Stop()
 	log.Info().Msg("PoolManager stopped")
 
+	// Flush banned-word hit stats
+	featureFlags.Stop()
+	if queryCache != nil {
+		queryCache.Stop()
+	}
+	siteCache.StopSync()
+	contentFilter.Stop()
+	log.Info().Msg("ContentFilter stopped")
+
 	// Stop object pools
 	funcsManager.StopPools()
 	log.Info().Msg("Object pools stopped")
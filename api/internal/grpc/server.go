@@ -0,0 +1,106 @@
+// Package rpc implements the internal gRPC API used by the Python content worker and spider runner
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"seo-generator/api/internal/grpc/workerpb"
+	core "seo-generator/api/internal/service"
+	"seo-generator/api/pkg/config"
+)
+
+// Server 内部 gRPC 服务
+type Server struct {
+	cfg config.GRPCConfig
+	srv *grpc.Server
+	lis net.Listener
+}
+
+// NewServer 创建内部 gRPC 服务
+// rdb 可为 nil，此时不同步写入 Redis 兼容键；写入的文章经 ingester 入库
+func NewServer(db *sqlx.DB, rdb *redis.Client, ingester *core.ArticleIngester, cfg config.GRPCConfig) *Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(recoveryInterceptor, authInterceptor(cfg.Token)),
+	)
+	workerpb.RegisterWorkerServiceServer(srv, &workerService{db: db, rdb: rdb, ingester: ingester})
+	return &Server{cfg: cfg, srv: srv}
+}
+
+// Start 监听端口并在后台提供服务，未配置共享令牌时拒绝启动
+func (s *Server) Start() error {
+	if s.cfg.Token == "" {
+		return fmt.Errorf("grpc.token is required when grpc is enabled")
+	}
+	lis, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("grpc listen %s: %w", s.cfg.Addr, err)
+	}
+	s.lis = lis
+
+	go func() {
+		if err := s.srv.Serve(lis); err != nil && err != grpc.ErrServerStopped {
+			log.Error().Err(err).Msg("gRPC server stopped unexpectedly")
+		}
+	}()
+
+	log.Info().Str("addr", s.cfg.Addr).Msg("gRPC internal API started")
+	return nil
+}
+
+// Stop 优雅停止，超时后强制关闭
+func (s *Server) Stop(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		s.srv.Stop()
+	}
+}
+
+// authInterceptor 校验 metadata 中的共享令牌（authorization: Bearer <token>）
+func authInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if token == "" {
+			return nil, status.Error(codes.Unauthenticated, "grpc token not configured")
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		var got string
+		if vals := md.Get("authorization"); len(vals) > 0 {
+			got = strings.TrimPrefix(vals[0], "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// recoveryInterceptor 捕获 handler panic，避免拖垮整个进程
+func recoveryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Interface("panic", r).Str("method", info.FullMethod).Msg("gRPC handler panic")
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
@@ -0,0 +1,229 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"seo-generator/api/internal/grpc/workerpb"
+	core "seo-generator/api/internal/service"
+)
+
+// Redis 兼容键，与 Python Worker 的 Redis 通道保持一致
+const (
+	processorStatusKey = "processor:status"
+	processorStatsKey  = "processor:stats"
+	spiderStatusKeyFmt = "spider:status:%d"
+)
+
+// configPrefixes GetConfig 允许读取的配置键前缀，其余配置（如 api_token）不对 Worker 开放
+var configPrefixes = []string{"processor.", "spider."}
+
+// maxSubmitItems 单次提交的最大条数
+const maxSubmitItems = 1000
+
+// workerService 实现 workerpb.WorkerServiceServer
+type workerService struct {
+	workerpb.UnimplementedWorkerServiceServer
+
+	db       *sqlx.DB
+	rdb      *redis.Client
+	ingester *core.ArticleIngester
+}
+
+// SubmitItems 写入原始文章并推入待处理队列
+// 与 HTTP 批量添加走同一入库流程（清洗、违禁词过滤、摘要、按标题跳过重复），分组启用 LLM 改写时先改写再入队
+func (s *workerService) SubmitItems(ctx context.Context, req *workerpb.SubmitItemsRequest) (*workerpb.SubmitItemsResponse, error) {
+	if len(req.Items) == 0 {
+		return &workerpb.SubmitItemsResponse{}, nil
+	}
+	if len(req.Items) > maxSubmitItems {
+		return nil, status.Errorf(codes.InvalidArgument, "too many items: %d > %d", len(req.Items), maxSubmitItems)
+	}
+
+	groupID := int(req.GroupId)
+	if groupID <= 0 {
+		groupID = 1
+	}
+
+	// 项目配置了字段映射时按映射转换（来源字段为 title/content/source_url）
+	var mapper *core.FieldMapper
+	if req.ProjectId > 0 {
+		mapping, err := core.LoadFieldMapping(ctx, s.db, int(req.ProjectId))
+		if err != nil {
+			log.Warn().Err(err).Int32("project_id", req.ProjectId).Msg("gRPC SubmitItems load field mapping failed")
+		} else if len(mapping) > 0 {
+			if mapper, err = mapping.Compile(); err != nil {
+				log.Warn().Err(err).Int32("project_id", req.ProjectId).Msg("gRPC SubmitItems invalid field mapping, ignored")
+			}
+		}
+	}
+
+	resp := &workerpb.SubmitItemsResponse{}
+	batch := s.ingester.NewBatch()
+	defer batch.Flush(ctx)
+	for _, item := range req.Items {
+		article := core.IngestArticle{
+			GroupID: groupID, SourceID: int(req.ProjectId),
+			Title: item.Title, Content: item.Content, SourceURL: item.SourceUrl,
+		}
+		if mapper != nil {
+			mapped := mapper.Apply(map[string]interface{}{
+				"title":      item.Title,
+				"content":    item.Content,
+				"source_url": item.SourceUrl,
+			})
+			article.Title, article.Content, article.SourceURL = mapped.Title, mapped.Content, mapped.SourceURL
+			article.Tags = mapped.JoinTags()
+		}
+
+		out := s.ingester.Ingest(ctx, article, core.ConflictSkip, core.ConflictKeyTitle, nil)
+		switch out.Outcome {
+		case core.OutcomeAdded:
+			batch.Add(groupID, out)
+			resp.ArticleIds = append(resp.ArticleIds, out.ID)
+			resp.Added++
+		case core.OutcomeError:
+			log.Error().Str("error", out.Message).Int32("project_id", req.ProjectId).Msg("gRPC SubmitItems insert failed")
+			return nil, status.Error(codes.Internal, "insert failed")
+		default:
+			resp.Skipped++
+		}
+	}
+	return resp, nil
+}
+
+// GetConfig 按前缀读取 system_settings，前缀必须属于 configPrefixes
+func (s *workerService) GetConfig(ctx context.Context, req *workerpb.GetConfigRequest) (*workerpb.GetConfigResponse, error) {
+	if !allowedConfigPrefix(req.Prefix) {
+		return nil, status.Errorf(codes.PermissionDenied, "prefix %q is not readable, allowed: %s", req.Prefix, strings.Join(configPrefixes, ", "))
+	}
+	var rows []struct {
+		Key   string `db:"setting_key"`
+		Value string `db:"setting_value"`
+	}
+	if err := s.db.SelectContext(ctx, &rows,
+		"SELECT setting_key, COALESCE(setting_value, '') AS setting_value FROM system_settings WHERE setting_key LIKE ?",
+		escapeLike(req.Prefix)+"%"); err != nil {
+		log.Error().Err(err).Str("prefix", req.Prefix).Msg("gRPC GetConfig query failed")
+		return nil, status.Error(codes.Internal, "query failed")
+	}
+
+	settings := make(map[string]string, len(rows))
+	for _, r := range rows {
+		settings[r.Key] = r.Value
+	}
+	return &workerpb.GetConfigResponse{Settings: settings}, nil
+}
+
+// Heartbeat 记录 Worker 状态，写入与 Redis 通道相同的 processor:status / processor:stats
+func (s *workerService) Heartbeat(ctx context.Context, req *workerpb.HeartbeatRequest) (*workerpb.HeartbeatResponse, error) {
+	now := time.Now()
+	if s.rdb != nil {
+		running := "false"
+		if req.Running {
+			running = "true"
+		}
+		updatedAt := now.Format("2006-01-02T15:04:05")
+
+		pipe := s.rdb.Pipeline()
+		pipe.HSet(ctx, processorStatusKey, map[string]interface{}{
+			"running":         running,
+			"workers":         req.Workers,
+			"processed_total": req.ProcessedTotal,
+			"processed_today": req.ProcessedToday,
+			"speed":           fmt.Sprintf("%.2f", req.Speed),
+			"worker_id":       req.WorkerId,
+			"last_error":      req.LastError,
+			"updated_at":      updatedAt,
+		})
+		pipe.HSet(ctx, processorStatsKey, map[string]interface{}{
+			"total_processed":   req.ProcessedTotal,
+			"total_failed":      req.FailedTotal,
+			"total_retried":     req.RetriedTotal,
+			"avg_processing_ms": fmt.Sprintf("%.2f", req.AvgProcessingMs),
+			"updated_at":        updatedAt,
+		})
+		if _, err := pipe.Exec(ctx); err != nil {
+			log.Warn().Err(err).Str("worker_id", req.WorkerId).Msg("gRPC Heartbeat write failed")
+			return nil, status.Error(codes.Unavailable, "redis unavailable")
+		}
+	}
+	return &workerpb.HeartbeatResponse{ServerTime: now.Unix()}, nil
+}
+
+// UpdateRunStatus 更新爬虫项目运行状态
+func (s *workerService) UpdateRunStatus(ctx context.Context, req *workerpb.UpdateRunStatusRequest) (*workerpb.UpdateRunStatusResponse, error) {
+	if req.ProjectId <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "project_id required")
+	}
+	switch req.Status {
+	case "running", "idle", "error":
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid status: %q", req.Status)
+	}
+
+	if s.rdb != nil {
+		payload := map[string]string{"status": req.Status}
+		if req.Status == "running" {
+			payload["started_at"] = time.Now().Format("2006-01-02T15:04:05")
+		}
+		data, _ := json.Marshal(payload)
+		if err := s.rdb.Set(ctx, fmt.Sprintf(spiderStatusKeyFmt, req.ProjectId), data, 0).Err(); err != nil {
+			log.Warn().Err(err).Int32("project_id", req.ProjectId).Msg("gRPC UpdateRunStatus redis write failed")
+		}
+	}
+
+	if req.Finished {
+		var lastError interface{}
+		if req.Error != "" {
+			lastError = req.Error
+		}
+		if _, err := s.db.ExecContext(ctx, `
+			UPDATE spider_projects SET
+				status = ?,
+				last_run_at = NOW(),
+				last_run_items = ?,
+				last_error = ?,
+				total_runs = total_runs + 1,
+				total_items = total_items + ?
+			WHERE id = ?`,
+			req.Status, req.Items, lastError, req.Items, req.ProjectId); err != nil {
+			log.Error().Err(err).Int32("project_id", req.ProjectId).Msg("gRPC UpdateRunStatus update failed")
+			return nil, status.Error(codes.Internal, "update failed")
+		}
+	}
+
+	return &workerpb.UpdateRunStatusResponse{}, nil
+}
+
+// allowedConfigPrefix 前缀是否落在允许读取的范围内（空前缀不允许）
+func allowedConfigPrefix(prefix string) bool {
+	for _, p := range configPrefixes {
+		if strings.HasPrefix(prefix, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeLike 转义 LIKE 通配符
+func escapeLike(s string) string {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '%', '_', '\\':
+			out = append(out, '\\')
+		}
+		out = append(out, s[i])
+	}
+	return string(out)
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// maxActivityRange 内容增量统计单次查询的最大天数
+const maxActivityRange = 366
+
+// ActivityHandler 内容增量统计 handler
+type ActivityHandler struct {
+	stats *core.ActivityStats
+}
+
+// NewActivityHandler 创建 ActivityHandler
+func NewActivityHandler(stats *core.ActivityStats) *ActivityHandler {
+	return &ActivityHandler{stats: stats}
+}
+
+// Articles 每日新增文章（按数据源，source_id 为 0 表示手工上传）
+// GET /api/dashboard/activity/articles?start=2026-01-01&end=2026-01-31&group_id=
+func (h *ActivityHandler) Articles(c *gin.Context) {
+	from, to, groupID, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+	items, err := h.stats.Articles(c.Request.Context(), from, to, groupID)
+	h.respond(c, items, err)
+}
+
+// Keywords 每日新增关键词（按分组）
+// GET /api/dashboard/activity/keywords?start=&end=&group_id=
+func (h *ActivityHandler) Keywords(c *gin.Context) {
+	from, to, groupID, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+	items, err := h.stats.Keywords(c.Request.Context(), from, to, groupID)
+	h.respond(c, items, err)
+}
+
+// Pools 标题/正文池每日剩余、新增和消耗
+// GET /api/dashboard/activity/pools?kind=title&start=&end=&group_id=
+func (h *ActivityHandler) Pools(c *gin.Context) {
+	kind := c.DefaultQuery("kind", core.ActivityKindTitle)
+	if !core.ValidPoolKind(kind) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "kind 只能是 title 或 content")
+		return
+	}
+	from, to, groupID, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+	items, err := h.stats.PoolVelocity(c.Request.Context(), kind, from, to, groupID)
+	h.respond(c, items, err)
+}
+
+// Rollup 立即汇总（date 为空时补齐所有未汇总的日期），返回作业 ID
+// POST /api/dashboard/activity/rollup?date=2026-01-31
+func (h *ActivityHandler) Rollup(c *gin.Context) {
+	var day time.Time
+	if v := c.Query("date"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil || !t.Before(startOfToday()) {
+			core.FailWithMessage(c, core.ErrInvalidParam, "date 需为今天之前的日期（2006-01-02）")
+			return
+		}
+		day = t
+	}
+	jobID, err := h.stats.Submit(c.Request.Context(), day)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to submit activity rollup")
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		return
+	}
+	core.Success(c, gin.H{"job_id": jobID})
+}
+
+// parseRange 解析日期范围（默认最近 30 天）和分组
+func (h *ActivityHandler) parseRange(c *gin.Context) (time.Time, time.Time, int, bool) {
+	to := startOfToday().AddDate(0, 0, -1)
+	if v := c.Query("end"); v != "" {
+		t, ok := parseSeriesTime(v)
+		if !ok {
+			core.FailWithMessage(c, core.ErrInvalidParam, "无效的 end 参数")
+			return time.Time{}, time.Time{}, 0, false
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -29)
+	if v := c.Query("start"); v != "" {
+		t, ok := parseSeriesTime(v)
+		if !ok {
+			core.FailWithMessage(c, core.ErrInvalidParam, "无效的 start 参数")
+			return time.Time{}, time.Time{}, 0, false
+		}
+		from = t
+	}
+	if from.After(to) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "start 不能晚于 end")
+		return time.Time{}, time.Time{}, 0, false
+	}
+	if to.Sub(from) > maxActivityRange*24*time.Hour {
+		core.FailWithMessage(c, core.ErrInvalidParam, "时间范围不能超过 366 天")
+		return time.Time{}, time.Time{}, 0, false
+	}
+	groupID, _ := strconv.Atoi(c.Query("group_id"))
+	return from, to, groupID, true
+}
+
+func (h *ActivityHandler) respond(c *gin.Context, items interface{}, err error) {
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to query activity stats")
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+	core.Success(c, gin.H{"items": items})
+}
+
+// startOfToday 今天零点（本地时间）
+func startOfToday() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
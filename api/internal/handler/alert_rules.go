@@ -0,0 +1,202 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// AlertRulesHandler 自定义告警规则和告警处理 handler
+type AlertRulesHandler struct {
+	rules *core.AlertRules
+}
+
+// NewAlertRulesHandler 创建 AlertRulesHandler
+func NewAlertRulesHandler(rules *core.AlertRules) *AlertRulesHandler {
+	return &AlertRulesHandler{rules: rules}
+}
+
+// AlertRuleRequest 创建/更新告警规则请求
+type AlertRuleRequest struct {
+	Name            string  `json:"name" binding:"required"`
+	Metric          string  `json:"metric" binding:"required"`
+	Comparator      string  `json:"comparator" binding:"required"`
+	Threshold       float64 `json:"threshold"`
+	DurationSeconds int     `json:"duration_seconds"`
+	Severity        string  `json:"severity"` // 默认 warning
+	Channel         string  `json:"channel"`  // 默认 log
+	WebhookURL      string  `json:"webhook_url"`
+	Enabled         *bool   `json:"enabled"` // 默认启用
+}
+
+// List 规则列表
+// GET /api/alert-rules
+func (h *AlertRulesHandler) List(c *gin.Context) {
+	items, err := h.rules.List(c.Request.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list alert rules")
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+	core.Success(c, gin.H{"items": items})
+}
+
+// Metrics 规则可用的指标和比较符
+// GET /api/alert-rules/metrics
+func (h *AlertRulesHandler) Metrics(c *gin.Context) {
+	core.Success(c, gin.H{
+		"metrics":     core.AlertMetricNames(),
+		"comparators": []string{">", ">=", "<", "<=", "==", "!="},
+		"severities":  []core.AlertLevel{core.AlertLevelInfo, core.AlertLevelWarning, core.AlertLevelError},
+		"channels":    []string{core.AlertChannelLog, core.AlertChannelWebhook},
+	})
+}
+
+// Create 创建规则
+// POST /api/alert-rules
+func (h *AlertRulesHandler) Create(c *gin.Context) {
+	rule, ok := h.bind(c)
+	if !ok {
+		return
+	}
+	id, err := h.rules.Create(c.Request.Context(), rule)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, id)
+}
+
+// Update 更新规则
+// PUT /api/alert-rules/:id
+func (h *AlertRulesHandler) Update(c *gin.Context) {
+	id, ok := parseAlertRuleID(c)
+	if !ok {
+		return
+	}
+	rule, ok := h.bind(c)
+	if !ok {
+		return
+	}
+	rule.ID = id
+	if err := h.rules.Update(c.Request.Context(), rule); err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, id)
+}
+
+// Delete 删除规则（该规则未解决的告警标记为已解决）
+// DELETE /api/alert-rules/:id
+func (h *AlertRulesHandler) Delete(c *gin.Context) {
+	id, ok := parseAlertRuleID(c)
+	if !ok {
+		return
+	}
+	if err := h.rules.Delete(c.Request.Context(), id); err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, nil)
+}
+
+// Acknowledge 确认告警
+// POST /api/admin/system/alerts/:id/ack
+func (h *AlertRulesHandler) Acknowledge(c *gin.Context) {
+	alert, err := h.rules.Acknowledge(c.Param("id"), currentUsername(c))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, alert)
+}
+
+// Resolve 手动解决告警（规则告警在条件仍满足时会重新计时并再次触发）
+// POST /api/admin/system/alerts/:id/resolve
+func (h *AlertRulesHandler) Resolve(c *gin.Context) {
+	alert, err := h.rules.Resolve(c.Param("id"), currentUsername(c))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, alert)
+}
+
+// bind 解析并校验请求
+func (h *AlertRulesHandler) bind(c *gin.Context) (*core.AlertRuleConfig, bool) {
+	var req AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return nil, false
+	}
+	rule := &core.AlertRuleConfig{
+		Name:            strings.TrimSpace(req.Name),
+		Metric:          req.Metric,
+		Comparator:      req.Comparator,
+		Threshold:       req.Threshold,
+		DurationSeconds: req.DurationSeconds,
+		Severity:        core.AlertLevel(req.Severity),
+		Channel:         req.Channel,
+		WebhookURL:      strings.TrimSpace(req.WebhookURL),
+		Enabled:         req.Enabled == nil || *req.Enabled,
+	}
+	if rule.Severity == "" {
+		rule.Severity = core.AlertLevelWarning
+	}
+	if rule.Channel == "" {
+		rule.Channel = core.AlertChannelLog
+	}
+	if err := rule.Validate(); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+		return nil, false
+	}
+	if rule.WebhookURL != "" && !strings.HasPrefix(rule.WebhookURL, "http://") && !strings.HasPrefix(rule.WebhookURL, "https://") {
+		core.FailWithMessage(c, core.ErrInvalidParam, "webhook_url 必须是 http(s) 地址")
+		return nil, false
+	}
+	return rule, true
+}
+
+// respond 返回保存后的规则
+func (h *AlertRulesHandler) respond(c *gin.Context, id int64) {
+	rule, err := h.rules.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, rule)
+}
+
+// fail 按错误类型返回
+func (h *AlertRulesHandler) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, core.ErrAlertRuleNotFound):
+		core.FailWithMessage(c, core.ErrNotFound, "规则不存在")
+	case errors.Is(err, core.ErrAlertNotFound):
+		core.FailWithMessage(c, core.ErrNotFound, "告警不存在")
+	default:
+		log.Error().Err(err).Msg("Alert rule operation failed")
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+	}
+}
+
+func parseAlertRuleID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的 ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// currentUsername 当前登录的管理员用户名
+func currentUsername(c *gin.Context) string {
+	username, _ := c.Get("username")
+	name, _ := username.(string)
+	return name
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	core "seo-generator/api/internal/service"
+)
+
+// ArticleSanitizeHandler 文章入库清洗策略 handler
+type ArticleSanitizeHandler struct {
+	db        *sqlx.DB
+	sanitizer *core.ArticleSanitizer
+}
+
+// NewArticleSanitizeHandler 创建 ArticleSanitizeHandler
+func NewArticleSanitizeHandler(db *sqlx.DB, sanitizer *core.ArticleSanitizer) *ArticleSanitizeHandler {
+	return &ArticleSanitizeHandler{db: db, sanitizer: sanitizer}
+}
+
+// SanitizePolicyRequest 设置分组清洗策略请求
+type SanitizePolicyRequest = core.SanitizePolicy
+
+// SanitizeTestRequest 清洗测试请求
+type SanitizeTestRequest struct {
+	GroupID   int    `json:"group_id"`
+	Content   string `json:"content" binding:"required"`
+	SourceURL string `json:"source_url"`
+}
+
+// ListPolicies 获取各文章分组的清洗策略（未配置的分组返回默认策略）
+// GET /api/articles/sanitize-policies
+func (h *ArticleSanitizeHandler) ListPolicies(c *gin.Context) {
+	var groups []struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+	if err := h.db.Select(&groups, "SELECT id, name FROM article_groups ORDER BY id"); err != nil {
+		core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+		return
+	}
+	items := make([]gin.H, 0, len(groups))
+	for _, g := range groups {
+		items = append(items, gin.H{"group_name": g.Name, "policy": h.sanitizer.PolicyFor(g.ID)})
+	}
+	core.Success(c, items)
+}
+
+// SetPolicy 设置分组清洗策略
+// PUT /api/articles/sanitize-policies
+func (h *ArticleSanitizeHandler) SetPolicy(c *gin.Context) {
+	var req SanitizePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.GroupID <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if err := core.ValidateSanitizePolicy(req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+		return
+	}
+	if err := h.sanitizer.SetPolicy(c.Request.Context(), req); err != nil {
+		core.FailWithMessage(c, core.ErrDBUpdate, err.Error())
+		return
+	}
+	core.Success(c, gin.H{"success": true})
+}
+
+// Test 按分组策略预览清洗结果（不入库）
+// POST /api/articles/sanitize/test
+func (h *ArticleSanitizeHandler) Test(c *gin.Context) {
+	var req SanitizeTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if req.GroupID == 0 {
+		req.GroupID = 1
+	}
+	content, result := h.sanitizer.Sanitize(req.GroupID, req.Content, req.SourceURL)
+	core.Success(c, gin.H{"content": content, "result": result})
+}
@@ -1,8 +1,8 @@
 package api
 
 import (
-	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -10,21 +10,22 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
-	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
 
+	database "seo-generator/api/internal/repository"
 	core "seo-generator/api/internal/service"
 )
 
 // ArticlesHandler 文章管理 handler
 type ArticlesHandler struct {
-	db  *sqlx.DB
-	rdb *redis.Client
+	db         *sqlx.DB
+	jobManager *core.JobManager
+	ingester   *core.ArticleIngester
 }
 
 // NewArticlesHandler 创建 ArticlesHandler
-func NewArticlesHandler(db *sqlx.DB, rdb *redis.Client) *ArticlesHandler {
-	return &ArticlesHandler{db: db, rdb: rdb}
+func NewArticlesHandler(db *sqlx.DB, jobManager *core.JobManager, ingester *core.ArticleIngester) *ArticlesHandler {
+	return &ArticlesHandler{db: db, jobManager: jobManager, ingester: ingester}
 }
 
 // ArticleGroup 文章分组
@@ -53,6 +54,7 @@ type ArticleDetail struct {
 	GroupID   int       `json:"group_id" db:"group_id"`
 	Title     string    `json:"title" db:"title"`
 	Content   string    `json:"content" db:"content"`
+	Summary   *string   `json:"summary" db:"summary"`
 	Status    int       `json:"status" db:"status"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
@@ -106,21 +108,26 @@ type ArticleDeleteAllRequest struct {
 
 // ArticleAddRequest 添加单篇文章请求
 type ArticleAddRequest struct {
-	GroupID int    `json:"group_id"`
-	Title   string `json:"title" binding:"required"`
-	Content string `json:"content" binding:"required"`
+	GroupID   int    `json:"group_id"`
+	Title     string `json:"title" binding:"required"`
+	Content   string `json:"content" binding:"required"`
+	SourceURL string `json:"source_url"` // 文章原始地址，用于补全正文中的相对链接
 }
 
 // ArticleBatchAddItem 批量添加文章项
 type ArticleBatchAddItem struct {
-	GroupID int    `json:"group_id"`
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	GroupID   int    `json:"group_id"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	SourceURL string `json:"source_url"`
 }
 
 // ArticleBatchAddRequest 批量添加文章请求
+// 请求体按条流式解析：冲突设置需放在 articles 之前（或使用同名查询参数）才对本批生效
 type ArticleBatchAddRequest struct {
-	Articles []ArticleBatchAddItem `json:"articles" binding:"required"`
+	ConflictStrategy string                `json:"conflict_strategy"` // skip / overwrite / version，为空时使用 API Token 的设置，默认 skip
+	ConflictKey      string                `json:"conflict_key"`      // title / content_hash / source_url，默认 title
+	Articles         []ArticleBatchAddItem `json:"articles" binding:"required"`
 }
 
 // ========== 分组管理方法 ==========
@@ -147,7 +154,7 @@ func (h *ArticlesHandler) ListGroups(c *gin.Context) {
 	          FROM article_groups WHERE ` + where + ` ORDER BY is_default DESC, name`
 
 	var groups []ArticleGroup
-	if err := h.db.Select(&groups, query, args...); err != nil {
+	if err := core.GetQueryCache().Select(c.Request.Context(), h.db, &groups, query, args...); err != nil {
 		log.Warn().Err(err).Msg("Failed to list article groups")
 		groups = []ArticleGroup{}
 	}
@@ -209,6 +216,10 @@ func (h *ArticlesHandler) CreateGroup(c *gin.Context) {
 	}
 
 	id, _ := result.LastInsertId()
+
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("article_groups")
+
 	core.Success(c, gin.H{"success": true, "id": id})
 }
 
@@ -288,6 +299,9 @@ func (h *ArticlesHandler) UpdateGroup(c *gin.Context) {
 		return
 	}
 
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("article_groups")
+
 	core.Success(c, gin.H{"success": true})
 }
 
@@ -343,6 +357,9 @@ func (h *ArticlesHandler) DeleteGroup(c *gin.Context) {
 		return
 	}
 
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("article_groups")
+
 	core.Success(c, gin.H{"success": true})
 }
 
@@ -352,50 +369,51 @@ func (h *ArticlesHandler) DeleteGroup(c *gin.Context) {
 // GET /api/articles/list
 func (h *ArticlesHandler) List(c *gin.Context) {
 	groupID, _ := strconv.Atoi(c.DefaultQuery("group_id", "1"))
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	search := c.Query("search")
-
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	q, ok := bindListQuery(c, articleListSpec)
+	if !ok {
+		return
 	}
-	offset := (page - 1) * pageSize
 
 	if h.db == nil {
-		core.SuccessPaged(c, []ArticleListItem{}, 0, page, pageSize)
+		respondList(c, q, []ArticleListItem{}, 0, articleListKey)
 		return
 	}
 
-	where := "group_id = ? AND status = 1"
-	args := []interface{}{groupID}
+	q.Where("group_id = ? AND status = 1", groupID)
 
-	if search != "" {
-		where += " AND (title LIKE ? OR content LIKE ?)"
-		args = append(args, "%"+search+"%", "%"+search+"%")
-	}
+	// 列表查询走只读副本
+	reader := database.Reader(c.Request.Context(), h.db)
 
 	var total int64
-	if err := h.db.Get(&total, "SELECT COUNT(*) FROM original_articles WHERE "+where, args...); err != nil {
-		log.Warn().Err(err).Msg("Failed to count articles")
-		total = 0
+	if q.NeedsTotal() {
+		countQuery, args := q.CountQuery("original_articles")
+		if err := reader.Get(&total, countQuery, args...); err != nil {
+			log.Warn().Err(err).Msg("Failed to count articles")
+			total = 0
+		}
 	}
 
-	args = append(args, pageSize, offset)
-	query := `SELECT id, group_id, title, status, created_at
-	          FROM original_articles WHERE ` + where + ` ORDER BY id DESC LIMIT ? OFFSET ?`
+	query, args := q.Query("SELECT id, group_id, title, status, created_at FROM original_articles")
 
 	var items []ArticleListItem
-	if err := h.db.Select(&items, query, args...); err != nil {
+	if err := reader.Select(&items, query, args...); err != nil {
 		log.Warn().Err(err).Msg("Failed to list articles")
 		items = []ArticleListItem{}
 	}
 
-	core.SuccessPaged(c, items, total, page, pageSize)
+	respondList(c, q, items, total, articleListKey)
 }
 
+// articleListSpec 文章列表的排序和搜索字段（大分组可用 cursor 分页）
+var articleListSpec = ListSpec{
+	SortFields:    map[string]string{"id": "id", "title": "title", "created_at": "created_at"},
+	DefaultSort:   "-id",
+	SearchColumns: []string{"title", "content"},
+	KeysetColumn:  "id",
+}
+
+func articleListKey(a ArticleListItem) int64 { return int64(a.ID) }
+
 // Get 获取单篇文章
 // GET /api/articles/:id
 func (h *ArticlesHandler) Get(c *gin.Context) {
@@ -412,7 +430,7 @@ func (h *ArticlesHandler) Get(c *gin.Context) {
 
 	var article ArticleDetail
 	err = h.db.Get(&article,
-		`SELECT id, group_id, title, content, status, created_at, updated_at
+		`SELECT id, group_id, title, content, summary, status, created_at, updated_at
 		 FROM original_articles WHERE id = ?`, id)
 
 	if err != nil {
@@ -465,8 +483,8 @@ func (h *ArticlesHandler) Update(c *gin.Context) {
 		args = append(args, *req.Title)
 	}
 	if req.Content != nil {
-		updates = append(updates, "content = ?")
-		args = append(args, *req.Content)
+		updates = append(updates, "content = ?", "summary = ?")
+		args = append(args, *req.Content, h.ingester.Summary(*req.Content))
 	}
 	if req.Status != nil {
 		updates = append(updates, "status = ?")
@@ -570,6 +588,24 @@ func (h *ArticlesHandler) DeleteAll(c *gin.Context) {
 		return
 	}
 
+	// 有作业管理器时异步分批删除，立即返回作业ID
+	if h.jobManager != nil {
+		jobID, err := h.jobManager.SubmitFunc(c.Request.Context(), "articles_delete_all", req, func(jc *core.JobContext) (any, error) {
+			where, args := "1=1", []interface{}{}
+			if req.GroupID != nil {
+				where, args = "group_id = ?", []interface{}{*req.GroupID}
+			}
+			deleted, err := core.ChunkedDelete(jc, h.db, "original_articles", where, args, 2000)
+			return gin.H{"deleted": deleted}, err
+		})
+		if err != nil {
+			core.Success(c, gin.H{"success": false, "message": err.Error(), "deleted": 0})
+			return
+		}
+		core.Success(c, gin.H{"success": true, "job_id": jobID})
+		return
+	}
+
 	var result sql.Result
 	var err error
 
@@ -688,120 +724,180 @@ func (h *ArticlesHandler) Add(c *gin.Context) {
 		groupID = 1
 	}
 
-	result, err := h.db.Exec(
-		"INSERT IGNORE INTO original_articles (group_id, title, content) VALUES (?, ?, ?)",
-		groupID, req.Title, req.Content)
-
-	if err != nil {
-		core.Success(c, gin.H{"success": false, "message": err.Error()})
+	sanitized := core.NewSanitizeResult()
+	out := h.ingester.Ingest(c.Request.Context(), core.IngestArticle{
+		GroupID: groupID, Title: req.Title, Content: req.Content, SourceURL: req.SourceURL,
+	}, core.ConflictSkip, core.ConflictKeyTitle, sanitized)
+	switch out.Outcome {
+	case core.OutcomeAdded:
+	case core.OutcomeInvalid:
+		core.Success(c, gin.H{"success": false, "message": out.Message, "sanitize": sanitized})
 		return
-	}
-
-	affected, _ := result.RowsAffected()
-	if affected == 0 {
+	case core.OutcomeRejected:
+		core.Success(c, gin.H{"success": false, "message": "文章包含违禁词，已拒绝"})
+		return
+	case core.OutcomeSkipped:
 		core.Success(c, gin.H{"success": false, "message": "文章标题已存在"})
 		return
-	}
-
-	id, _ := result.LastInsertId()
-
-	// 推入待处理队列，由 Python Worker 加工
-	h.pushToProcessQueue(c, id)
-
-	core.Success(c, gin.H{"success": true, "id": id})
-}
-
-// BatchAdd 批量添加文章
-// POST /api/articles/batch
-func (h *ArticlesHandler) BatchAdd(c *gin.Context) {
-	var req ArticleBatchAddRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+	default:
+		core.Success(c, gin.H{"success": false, "message": out.Message})
 		return
 	}
 
-	if len(req.Articles) == 0 {
-		core.Success(c, gin.H{"success": false, "message": "文章列表不能为空"})
+	// 分组启用 LLM 改写时先改写再入队，否则直接推入待处理队列，由 Python Worker 加工
+	batch := h.ingester.NewBatch()
+	batch.Add(groupID, out)
+	if batch.Rewriting() > 0 {
+		jobID := batch.Flush(c.Request.Context())
+		core.Success(c, gin.H{"success": true, "id": out.ID, "rewrite_job_id": jobID, "stripped": sanitized.Stripped, "sanitize": sanitized})
 		return
 	}
+	batch.Flush(c.Request.Context())
 
-	if len(req.Articles) > 1000 {
-		core.FailWithMessage(c, core.ErrInvalidParam, "单次最多添加 1000 篇文章")
-		return
-	}
+	core.Success(c, gin.H{"success": true, "id": out.ID, "stripped": sanitized.Stripped, "sanitize": sanitized})
+}
 
+// BatchAdd 批量添加文章（流式解析请求体，逐篇写入；超过 1000 篇或请求体上限时返回 413 和已处理结果）
+// POST /api/articles/batch?conflict_strategy=&conflict_key=
+func (h *ArticlesHandler) BatchAdd(c *gin.Context) {
 	if h.db == nil {
 		core.FailWithMessage(c, core.ErrInternalServer, "数据库未初始化")
 		return
 	}
 
-	added := 0
-	skipped := 0
-	var addedIDs []int64
-
-	for _, article := range req.Articles {
-		if article.Title == "" || article.Content == "" {
-			skipped++
-			continue
+	// 冲突策略：查询参数或 articles 之前的请求字段优先，其次是调用方 API Token 的默认设置
+	req := ArticleBatchAddRequest{
+		ConflictStrategy: c.Query("conflict_strategy"),
+		ConflictKey:      c.Query("conflict_key"),
+	}
+	var strategy, key string
+	resolve := func() error {
+		strategy, key = req.ConflictStrategy, req.ConflictKey
+		if feeder := feederFromContext(c); feeder != nil {
+			if strategy == "" {
+				strategy = feeder.ConflictStrategy
+			}
+			if key == "" {
+				key = feeder.ConflictKey
+			}
 		}
-
-		groupID := article.GroupID
-		if groupID == 0 {
-			groupID = 1
+		if err := core.ValidConflictStrategy(strategy, key); err != nil {
+			return err
 		}
-
-		result, err := h.db.Exec(
-			"INSERT IGNORE INTO original_articles (group_id, title, content) VALUES (?, ?, ?)",
-			groupID, article.Title, article.Content)
-		if err != nil {
-			skipped++
-			continue
+		if strategy == "" {
+			strategy = core.ConflictSkip
 		}
-
-		affected, _ := result.RowsAffected()
-		if affected > 0 {
-			added++
-			if id, err := result.LastInsertId(); err == nil {
-				addedIDs = append(addedIDs, id)
-			}
-		} else {
-			skipped++
+		if key == "" {
+			key = core.ConflictKeyTitle
 		}
-	}
-
-	// 批量推入待处理队列，由 Python Worker 加工
-	h.pushBatchToProcessQueue(c, addedIDs)
-
-	core.Success(c, gin.H{
-		"success": true,
-		"added":   added,
-		"skipped": skipped,
-		"total":   len(req.Articles),
-	})
-}
-
-const articlePendingQueue = "pending:articles"
-
-// pushToProcessQueue 将单个文章 ID 推入待处理队列
-func (h *ArticlesHandler) pushToProcessQueue(c *gin.Context, id int64) {
-	if h.rdb == nil {
+		return nil
+	}
+
+	counts := map[string]int{}
+	results := []core.ArticleImportOutcome{}
+	batch := h.ingester.NewBatch()
+	var paramErr error
+	var warning string
+	sanitized := core.NewSanitizeResult()
+
+	// 流式解析：每解码一篇立即清洗、过滤并写入，请求体不整体缓冲
+	total, err := decodeBatchStream(c.Request.Body, "articles", 1000,
+		func(field string, raw json.RawMessage, afterItems bool) error {
+			var target, applied *string
+			switch field {
+			case "conflict_strategy":
+				target, applied = &req.ConflictStrategy, &strategy
+			case "conflict_key":
+				target, applied = &req.ConflictKey, &key
+			default:
+				return nil
+			}
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return err
+			}
+			if afterItems {
+				if value != "" && *applied != "" && value != *applied {
+					warning = fmt.Sprintf("%s 出现在 articles 之后未生效，请放在 articles 之前或使用查询参数", field)
+				}
+				return nil
+			}
+			if *target == "" {
+				*target = value
+			}
+			return nil
+		},
+		func(dec *json.Decoder, i int) error {
+			if i == 0 {
+				if paramErr = resolve(); paramErr != nil {
+					return paramErr
+				}
+			}
+			var article ArticleBatchAddItem
+			if err := dec.Decode(&article); err != nil {
+				return err
+			}
+			out := h.ingester.Ingest(c.Request.Context(), core.IngestArticle{
+				GroupID: article.GroupID, Title: article.Title, Content: article.Content, SourceURL: article.SourceURL,
+			}, strategy, key, sanitized)
+			out.Index = i
+			counts[out.Outcome]++
+			results = append(results, out)
+			// 新增、覆盖和新版本的文章都需要（重新）加工
+			batch.Add(article.GroupID, out)
+			return nil
+		})
+	if paramErr != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, paramErr.Error())
+		return
+	}
+
+	// 已写入的文章（包括中途超限或解析失败前的部分）批量推入待处理队列，由 Python Worker 加工；启用 LLM 改写的分组改写完成后再入队
+	rewriting := batch.Rewriting()
+	rewriteJobID := batch.Flush(c.Request.Context())
+
+	data := gin.H{
+		"success":           err == nil,
+		"added":             counts[core.OutcomeAdded],
+		"updated":           counts[core.OutcomeUpdated],
+		"versioned":         counts[core.OutcomeVersioned],
+		"skipped":           counts[core.OutcomeSkipped] + counts[core.OutcomeUnchanged] + counts[core.OutcomeConflict] + counts[core.OutcomeInvalid] + counts[core.OutcomeError],
+		"rejected":          counts[core.OutcomeRejected],
+		"outcomes":          counts,
+		"results":           results,
+		"conflict_strategy": strategy,
+		"conflict_key":      key,
+		"rewriting":         rewriting,
+		"rewrite_job_id":    rewriteJobID,
+		"stripped":          sanitized.Stripped,
+		"sanitize":          sanitized,
+		"total":             total,
+	}
+	if warning != "" {
+		data["warning"] = warning
+	}
+
+	switch {
+	case err == nil:
+	case isBatchTooLarge(err):
+		data["processed"] = total
+		data["resume_index"] = total
+		failBodyTooLarge(c, data,
+			fmt.Sprintf("%s，前 %d 篇已处理，请从 index=%d 起拆分为较小的批次（每批不超过 1000 篇）继续提交",
+				batchTooLargeReason(c, err, 1000), total, total))
+		return
+	case total > 0:
+		core.FailWithMessage(c, core.ErrInvalidParam,
+			fmt.Sprintf("请求参数错误：第 %d 篇解析失败，前 %d 篇已处理", total, total))
+		return
+	default:
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
 		return
 	}
-	if err := h.rdb.LPush(context.Background(), articlePendingQueue, id).Err(); err != nil {
-		log.Warn().Err(err).Int64("article_id", id).Msg("推送文章到待处理队列失败")
-	}
-}
 
-// pushBatchToProcessQueue 将多个文章 ID 批量推入待处理队列
-func (h *ArticlesHandler) pushBatchToProcessQueue(c *gin.Context, ids []int64) {
-	if h.rdb == nil || len(ids) == 0 {
+	if total == 0 {
+		core.Success(c, gin.H{"success": false, "message": "文章列表不能为空"})
 		return
 	}
-	vals := make([]interface{}, len(ids))
-	for i, id := range ids {
-		vals[i] = id
-	}
-	if err := h.rdb.LPush(context.Background(), articlePendingQueue, vals...).Err(); err != nil {
-		log.Warn().Err(err).Int("count", len(ids)).Msg("批量推送文章到待处理队列失败")
-	}
+	core.Success(c, data)
 }
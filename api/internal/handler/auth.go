@@ -1,6 +1,8 @@
 package api
 
 import (
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,14 +17,20 @@ type AuthHandler struct {
 	db            *sqlx.DB
 	secret        string
 	expireMinutes int
+	sessions      *core.SessionStore // 为 nil 时不登记会话
+	loginGuard    *core.LoginGuard   // 为 nil 时不做登录限流
+	allowlist     *core.IPAllowlist  // 受信代理列表，用于解析客户端 IP
 }
 
 // NewAuthHandler 创建 AuthHandler
-func NewAuthHandler(secret string, expireMinutes int, db *sqlx.DB) *AuthHandler {
+func NewAuthHandler(secret string, expireMinutes int, db *sqlx.DB, sessions *core.SessionStore, loginGuard *core.LoginGuard, allowlist *core.IPAllowlist) *AuthHandler {
 	return &AuthHandler{
 		db:            db,
 		secret:        secret,
 		expireMinutes: expireMinutes,
+		sessions:      sessions,
+		loginGuard:    loginGuard,
+		allowlist:     allowlist,
 	}
 }
 
@@ -34,9 +42,10 @@ type LoginRequest struct {
 
 // LoginResponse 登录响应数据
 type LoginResponse struct {
-	Token   string `json:"token,omitempty"`
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Token     string `json:"token,omitempty"`
+	CSRFToken string `json:"csrf_token,omitempty"` // 启用 Cookie 会话时返回，写请求放入 X-CSRF-Token 请求头
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
 }
 
 // Login 管理员登录
@@ -52,6 +61,20 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	ctx := c.Request.Context()
+	ip := trustedClientIP(c, h.allowlist)
+	ua := c.Request.UserAgent()
+
+	if h.loginGuard != nil {
+		if retryAfter, locked := h.loginGuard.Check(ctx, ip, req.Username); locked {
+			seconds := int(retryAfter.Seconds()) + 1
+			h.loginGuard.RecordLocked(ctx, ip, req.Username, ua)
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			core.FailWithMessage(c, core.ErrTooManyRequests, fmt.Sprintf("登录失败次数过多，请 %d 秒后再试", seconds))
+			return
+		}
+	}
+
 	var admin struct {
 		ID        int        `db:"id"`
 		Username  string     `db:"username"`
@@ -62,23 +85,38 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	err := h.db.Get(&admin, "SELECT id, username, password, last_login FROM admins WHERE username = ?", req.Username)
 	if err != nil {
 		log.Debug().Str("username", req.Username).Msg("Admin not found")
+		if h.loginGuard != nil {
+			h.loginGuard.RecordFailure(ctx, ip, req.Username, ua, "user_not_found")
+		}
 		core.FailWithMessage(c, core.ErrUnauthorized, "用户名或密码错误")
 		return
 	}
 
 	if !core.VerifyPassword(req.Password, admin.Password) {
 		log.Debug().Str("username", req.Username).Msg("Invalid password")
+		if h.loginGuard != nil {
+			h.loginGuard.RecordFailure(ctx, ip, req.Username, ua, "bad_password")
+		}
 		core.FailWithMessage(c, core.ErrUnauthorized, "用户名或密码错误")
 		return
 	}
 
 	h.db.Exec("UPDATE admins SET last_login = NOW() WHERE id = ?", admin.ID)
 
+	jti, err := core.NewSessionID()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to generate session id")
+		core.FailWithMessage(c, core.ErrInternalServer, "Token 生成失败")
+		return
+	}
+
+	expiry := time.Duration(h.expireMinutes) * time.Minute
 	token, err := core.CreateAccessToken(map[string]interface{}{
 		"sub":      admin.Username,
 		"admin_id": admin.ID,
 		"role":     "admin",
-	}, h.secret, time.Duration(h.expireMinutes)*time.Minute)
+		"jti":      jti,
+	}, h.secret, expiry)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create token")
@@ -86,11 +124,226 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	core.Success(c, LoginResponse{Success: true, Token: token, Message: "登录成功"})
+	if h.sessions != nil {
+		if err := h.sessions.Create(ctx, admin.ID, jti, ip, ua, time.Now().Add(expiry)); err != nil {
+			log.Error().Err(err).Int("admin_id", admin.ID).Msg("Failed to create session")
+			core.FailWithMessage(c, core.ErrInternalServer, "会话创建失败")
+			return
+		}
+	}
+
+	if h.loginGuard != nil {
+		h.loginGuard.RecordSuccess(ctx, ip, req.Username, ua)
+	}
+
+	resp := LoginResponse{Success: true, Token: token, Message: "登录成功"}
+	if authCookie.Enabled {
+		resp.CSRFToken = setAuthCookies(c, token, int(expiry.Seconds()))
+	}
+
+	core.Success(c, resp)
 }
 
-// Logout 退出登录
+// Logout 退出登录，撤销当前 Token 对应的会话
 func (h *AuthHandler) Logout(c *gin.Context) {
+	if h.sessions != nil {
+		if token, _, errMsg := requestToken(c); errMsg == "" {
+			if claims, err := core.VerifyToken(token, h.secret); err == nil {
+				jti, _ := claims["jti"].(string)
+				if err := h.sessions.RevokeByJTI(c.Request.Context(), jti, core.SessionRevokeLogout); err != nil {
+					log.Warn().Err(err).Msg("Failed to revoke session on logout")
+				}
+			}
+		}
+	}
+	if authCookie.Enabled {
+		clearAuthCookies(c)
+	}
+	core.Success(c, gin.H{"success": true})
+}
+
+// currentSession 从认证上下文中取出管理员 ID 和会话 jti
+func currentSession(c *gin.Context) (int, string, bool) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return 0, "", false
+	}
+	claimsMap, ok := claims.(map[string]interface{})
+	if !ok {
+		return 0, "", false
+	}
+	adminID, ok := claimsMap["admin_id"].(float64)
+	if !ok {
+		return 0, "", false
+	}
+	jti, _ := claimsMap["jti"].(string)
+	return int(adminID), jti, true
+}
+
+// ListSessions 列出当前管理员的在线会话
+// GET /api/auth/sessions
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	adminID, jti, ok := currentSession(c)
+	if !ok {
+		core.FailWithCode(c, core.ErrUnauthorized)
+		return
+	}
+	if h.sessions == nil {
+		core.Success(c, gin.H{"sessions": []core.AdminSession{}, "max_sessions": 0})
+		return
+	}
+	sessions, err := h.sessions.ListActive(c.Request.Context(), adminID, jti)
+	if err != nil {
+		log.Error().Err(err).Int("admin_id", adminID).Msg("Failed to list sessions")
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+	if sessions == nil {
+		sessions = []core.AdminSession{}
+	}
+
+	core.Success(c, gin.H{"sessions": sessions, "max_sessions": h.sessions.MaxSessions()})
+}
+
+// RevokeSession 撤销当前管理员的指定会话（只能撤销自己的会话）
+// DELETE /api/auth/sessions/:id
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	adminID, _, ok := currentSession(c)
+	if !ok {
+		core.FailWithCode(c, core.ErrUnauthorized)
+		return
+	}
+	sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的会话 ID")
+		return
+	}
+	if h.sessions == nil {
+		core.Success(c, gin.H{"success": false, "message": "会话管理未启用"})
+		return
+	}
+	found, err := h.sessions.Revoke(c.Request.Context(), adminID, sessionID, core.SessionRevokeManual)
+	if err != nil {
+		log.Error().Err(err).Int64("session_id", sessionID).Msg("Failed to revoke session")
+		core.FailWithCode(c, core.ErrDBUpdate)
+		return
+	}
+	if !found {
+		core.Success(c, gin.H{"success": false, "message": "会话不存在或已失效"})
+		return
+	}
+
+	core.Success(c, gin.H{"success": true})
+}
+
+// RevokeOtherSessions 撤销当前管理员除本会话外的全部会话
+// POST /api/auth/sessions/revoke-others
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	adminID, jti, ok := currentSession(c)
+	if !ok {
+		core.FailWithCode(c, core.ErrUnauthorized)
+		return
+	}
+	if h.sessions == nil {
+		core.Success(c, gin.H{"success": false, "message": "会话管理未启用"})
+		return
+	}
+
+	revoked, err := h.sessions.RevokeAll(c.Request.Context(), adminID, jti, core.SessionRevokeManual)
+	if err != nil {
+		log.Error().Err(err).Int("admin_id", adminID).Msg("Failed to revoke sessions")
+		core.FailWithCode(c, core.ErrDBUpdate)
+		return
+	}
+
+	core.Success(c, gin.H{"success": true, "revoked": revoked})
+}
+
+// ListLoginAttempts 登录尝试记录
+// GET /api/auth/login-attempts?ip=&username=&outcome=&page=&page_size=
+func (h *AuthHandler) ListLoginAttempts(c *gin.Context) {
+	if h.loginGuard == nil {
+		core.Success(c, gin.H{"items": []core.LoginAttempt{}, "total": 0})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	outcome := c.Query("outcome")
+	switch outcome {
+	case "", core.LoginOutcomeSuccess, core.LoginOutcomeFailure, core.LoginOutcomeLocked:
+	default:
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的 outcome")
+		return
+	}
+
+	items, total, err := h.loginGuard.ListAttempts(c.Request.Context(), c.Query("ip"), c.Query("username"), outcome, page, pageSize)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list login attempts")
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+	if items == nil {
+		items = []core.LoginAttempt{}
+	}
+
+	core.Success(c, gin.H{"items": items, "total": total, "page": page, "page_size": pageSize})
+}
+
+// LoginAnomalies 最近一段时间的可疑登录模式
+// GET /api/auth/login-anomalies?hours=24
+func (h *AuthHandler) LoginAnomalies(c *gin.Context) {
+	if h.loginGuard == nil {
+		core.Success(c, gin.H{"anomalies": []core.LoginAnomaly{}})
+		return
+	}
+
+	hours, _ := strconv.Atoi(c.DefaultQuery("hours", "24"))
+	if hours < 1 || hours > 24*30 {
+		hours = 24
+	}
+
+	anomalies, err := h.loginGuard.DetectAnomalies(c.Request.Context(), time.Now().Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to detect login anomalies")
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+
+	core.Success(c, gin.H{"anomalies": anomalies, "hours": hours})
+}
+
+// LoginUnlockRequest 解除登录锁定请求
+type LoginUnlockRequest struct {
+	IP       string `json:"ip"`
+	Username string `json:"username"`
+}
+
+// UnlockLogin 手动解除 IP 或用户名的登录锁定
+// POST /api/auth/login-unlock
+func (h *AuthHandler) UnlockLogin(c *gin.Context) {
+	var req LoginUnlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.IP == "" && req.Username == "") {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请提供 ip 或 username")
+		return
+	}
+	if h.loginGuard == nil {
+		core.Success(c, gin.H{"success": false, "message": "登录防爆破未启用"})
+		return
+	}
+
+	if err := h.loginGuard.Unlock(c.Request.Context(), req.IP, req.Username); err != nil {
+		log.Error().Err(err).Msg("Failed to unlock login")
+		core.FailWithMessage(c, core.ErrInternalServer, "解除锁定失败")
+		return
+	}
+
 	core.Success(c, gin.H{"success": true})
 }
 
@@ -135,7 +388,69 @@ func (h *AuthHandler) Profile(c *gin.Context) {
 		lastLogin = admin.LastLogin.Format(time.RFC3339)
 	}
 
-	core.Success(c, gin.H{"id": admin.ID, "username": admin.Username, "role": "admin", "last_login": lastLogin})
+	var locale string
+	if prefs := core.GetLocalePreferences(); prefs != nil {
+		locale = prefs.Get(c.Request.Context(), admin.ID)
+	}
+
+	core.Success(c, gin.H{
+		"id":               admin.ID,
+		"username":         admin.Username,
+		"role":             "admin",
+		"last_login":       lastLogin,
+		"locale":           locale,
+		"effective_locale": core.RequestLocale(c),
+	})
+}
+
+// LocaleRequest 设置语言偏好请求
+type LocaleRequest struct {
+	Locale string `json:"locale"` // zh-CN / en-US，为空时清除偏好（按 Accept-Language）
+}
+
+// SetLocale 设置当前管理员的界面和接口提示语言
+// PUT /api/auth/locale
+func (h *AuthHandler) SetLocale(c *gin.Context) {
+	var req LocaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	locale := core.NormalizeLocale(req.Locale)
+	if req.Locale != "" && locale == "" {
+		core.FailWithMessage(c, core.ErrInvalidParam, "不支持的语言")
+		return
+	}
+	adminID, _, ok := currentSession(c)
+	if !ok {
+		core.FailWithCode(c, core.ErrUnauthorized)
+		return
+	}
+	prefs := core.GetLocalePreferences()
+	if prefs == nil {
+		core.FailWithMessage(c, core.ErrInternalServer, "数据库未初始化")
+		return
+	}
+	if err := prefs.Set(c.Request.Context(), adminID, locale); err != nil {
+		log.Error().Err(err).Int("admin_id", adminID).Msg("Failed to save admin locale")
+		core.FailWithMessage(c, core.ErrInternalServer, "保存失败")
+		return
+	}
+	core.Success(c, gin.H{"locale": locale, "effective_locale": core.RequestLocale(c)})
+}
+
+// I18nMessages 文案目录（含错误码文案），供前端按 key 取用
+// GET /api/i18n/messages?locale=
+func I18nMessages(c *gin.Context) {
+	locale := core.NormalizeLocale(c.Query("locale"))
+	if locale == "" {
+		locale = core.RequestLocale(c)
+	}
+	core.Success(c, gin.H{
+		"locale":    locale,
+		"supported": core.SupportedLocales,
+		"messages":  core.MessageCatalog(locale),
+	})
 }
 
 // ChangePasswordRequest 修改密码请求
@@ -198,5 +513,15 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
+	// 修改密码后下线该管理员的其他会话
+	if h.sessions != nil {
+		currentJTI, _ := claimsMap["jti"].(string)
+		if revoked, err := h.sessions.RevokeAll(c.Request.Context(), adminID, currentJTI, core.SessionRevokePasswordChanged); err != nil {
+			log.Error().Err(err).Int("admin_id", adminID).Msg("Failed to revoke sessions after password change")
+		} else if revoked > 0 {
+			log.Info().Int("admin_id", adminID).Int("revoked", revoked).Msg("Sessions revoked after password change")
+		}
+	}
+
 	core.Success(c, gin.H{"success": true, "message": "密码修改成功"})
 }
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// BackupHandler 加密备份与恢复
+type BackupHandler struct {
+	backups       *core.BackupManager
+	siteCache     *core.SiteCache
+	templateCache *core.TemplateCache
+	poolManager   *core.PoolManager
+}
+
+// NewBackupHandler 创建 BackupHandler
+func NewBackupHandler(backups *core.BackupManager, siteCache *core.SiteCache, templateCache *core.TemplateCache, poolManager *core.PoolManager) *BackupHandler {
+	return &BackupHandler{backups: backups, siteCache: siteCache, templateCache: templateCache, poolManager: poolManager}
+}
+
+// Download 流式下载加密备份
+// POST /api/admin/backup
+func (h *BackupHandler) Download(c *gin.Context) {
+	if !h.backups.Configured() {
+		core.FailWithMessage(c, core.ErrInvalidParam, "未配置备份口令（backup.passphrase）")
+		return
+	}
+
+	name := h.backups.FileName(time.Now())
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+	c.Status(http.StatusOK)
+
+	// 响应头已发送，中途失败只能中断连接；不完整的文件缺少结束块，恢复时会被拒绝
+	if err := h.backups.Export(c.Request.Context(), c.Writer); err != nil {
+		log.Error().Err(err).Msg("Backup export failed")
+		c.Abort()
+	}
+}
+
+// RunNow 立即执行一次定时备份（写入本地目录并按配置上传）
+// POST /api/admin/backups/run
+func (h *BackupHandler) RunNow(c *gin.Context) {
+	path, size, err := h.backups.RunScheduled(c.Request.Context())
+	if err != nil {
+		if path == "" {
+			h.fail(c, err)
+			return
+		}
+		// 本地已保存，上传失败
+		core.Success(c, gin.H{"success": false, "path": path, "size": size, "message": err.Error()})
+		return
+	}
+	core.Success(c, gin.H{"success": true, "path": path, "size": size})
+}
+
+// List 本地备份列表
+// GET /api/admin/backups
+func (h *BackupHandler) List(c *gin.Context) {
+	files, err := h.backups.ListLocal()
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		return
+	}
+	core.Success(c, gin.H{"files": files, "configured": h.backups.Configured()})
+}
+
+// DownloadLocal 下载本地备份文件
+// GET /api/admin/backups/:name
+func (h *BackupHandler) DownloadLocal(c *gin.Context) {
+	f, err := h.backups.OpenLocal(c.Param("name"))
+	if err != nil {
+		core.FailWithMessage(c, core.ErrNotFound, "备份文件不存在")
+		return
+	}
+	defer f.Close()
+
+	c.Header("Content-Disposition", `attachment; filename="`+c.Param("name")+`"`)
+	c.Header("Content-Type", "application/octet-stream")
+	io.Copy(c.Writer, f)
+}
+
+// Restore 从上传的备份恢复
+// POST /api/admin/restore?dry_run=true
+// 请求体为备份文件（application/octet-stream）或 multipart 的 file 字段；
+// 默认 dry_run=true 只返回差异，确认无误后传 dry_run=false 执行恢复；
+// 备份口令与当前配置不同时通过 X-Backup-Passphrase 请求头传入
+func (h *BackupHandler) Restore(c *gin.Context) {
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, "缺少备份文件")
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, "读取备份文件失败")
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+	h.restore(c, body)
+}
+
+// RestoreLocal 从本地备份文件恢复
+// POST /api/admin/backups/:name/restore?dry_run=true
+func (h *BackupHandler) RestoreLocal(c *gin.Context) {
+	f, err := h.backups.OpenLocal(c.Param("name"))
+	if err != nil {
+		core.FailWithMessage(c, core.ErrNotFound, "备份文件不存在")
+		return
+	}
+	defer f.Close()
+	h.restore(c, f)
+}
+
+func (h *BackupHandler) restore(c *gin.Context, body io.Reader) {
+	dryRun := c.DefaultQuery("dry_run", "true") != "false"
+	ctx := c.Request.Context()
+
+	report, err := h.backups.Restore(ctx, body, c.GetHeader("X-Backup-Passphrase"), dryRun)
+	if !dryRun && !errors.Is(err, core.ErrBackupRunning) {
+		// 恢复按批提交，失败时已写入的批次同样需要刷新缓存
+		h.reloadAfterRestore(ctx)
+	}
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	core.Success(c, report)
+}
+
+// reloadAfterRestore 恢复后刷新站点、模板缓存、数据池和查询缓存
+func (h *BackupHandler) reloadAfterRestore(ctx context.Context) {
+	if h.siteCache != nil {
+		if err := h.siteCache.ReloadAll(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to reload site cache after restore")
+		}
+	}
+	if h.templateCache != nil {
+		if err := h.templateCache.ReloadAll(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to reload template cache after restore")
+		}
+	}
+	if h.poolManager != nil {
+		if err := h.poolManager.Reload(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to reload pool config after restore")
+		}
+		if err := h.poolManager.RefreshData(ctx, "all"); err != nil {
+			log.Warn().Err(err).Msg("Failed to reload pools after restore")
+		}
+	}
+	core.GetQueryCache().Clear()
+}
+
+func (h *BackupHandler) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, core.ErrBackupNotConfigured):
+		core.FailWithMessage(c, core.ErrInvalidParam, "未配置备份口令（backup.passphrase）")
+	case errors.Is(err, core.ErrBackupRunning):
+		core.FailWithMessage(c, core.ErrConflict, "已有备份或恢复任务在进行中")
+	case errors.Is(err, core.ErrBackupDecrypt):
+		core.FailWithMessage(c, core.ErrInvalidParam, "口令错误或备份文件已损坏")
+	default:
+		log.Error().Err(err).Msg("Backup operation failed")
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+	}
+}
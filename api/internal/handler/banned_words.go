@@ -0,0 +1,364 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// BannedWordsHandler 违禁词管理 handler
+type BannedWordsHandler struct {
+	db            *sqlx.DB
+	contentFilter *core.ContentFilter
+}
+
+// NewBannedWordsHandler 创建 BannedWordsHandler
+func NewBannedWordsHandler(db *sqlx.DB, contentFilter *core.ContentFilter) *BannedWordsHandler {
+	return &BannedWordsHandler{db: db, contentFilter: contentFilter}
+}
+
+// BannedWordRequest 创建/更新违禁词请求
+type BannedWordRequest struct {
+	Word        string `json:"word" binding:"required"`
+	MatchType   string `json:"match_type"`
+	Replacement string `json:"replacement"`
+	Status      *int   `json:"status"`
+}
+
+// BannedWordBatchRequest 批量添加违禁词请求
+type BannedWordBatchRequest struct {
+	Words     []string `json:"words" binding:"required"`
+	MatchType string   `json:"match_type"`
+}
+
+// FilterPolicyRequest 设置分组策略请求
+type FilterPolicyRequest struct {
+	GroupID int    `json:"group_id" binding:"required"`
+	Policy  string `json:"policy" binding:"required"`
+}
+
+// FilterTestRequest 过滤测试请求
+type FilterTestRequest struct {
+	GroupID int    `json:"group_id"`
+	Text    string `json:"text" binding:"required"`
+}
+
+// reload 规则变更后重新加载过滤器
+func (h *BannedWordsHandler) reload(c *gin.Context) {
+	if h.contentFilter == nil {
+		return
+	}
+	if err := h.contentFilter.Reload(c.Request.Context()); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload content filter")
+	}
+}
+
+// List 获取违禁词列表
+// GET /api/banned-words?page=1&page_size=20&search=
+func (h *BannedWordsHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	search := c.Query("search")
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	where := "1=1"
+	args := []interface{}{}
+	if search != "" {
+		where += " AND word LIKE ?"
+		args = append(args, "%"+search+"%")
+	}
+
+	var total int64
+	h.db.Get(&total, "SELECT COUNT(*) FROM banned_words WHERE "+where, args...)
+
+	args = append(args, pageSize, offset)
+	query := `SELECT id, word, match_type, COALESCE(replacement, '') AS replacement, status, created_at
+	          FROM banned_words WHERE ` + where + ` ORDER BY id DESC LIMIT ? OFFSET ?`
+
+	var items []core.BannedWord
+	if err := h.db.Select(&items, query, args...); err != nil {
+		log.Warn().Err(err).Msg("Failed to list banned words")
+		items = []core.BannedWord{}
+	}
+
+	core.SuccessPaged(c, items, total, page, pageSize)
+}
+
+// Create 添加违禁词
+// POST /api/banned-words
+func (h *BannedWordsHandler) Create(c *gin.Context) {
+	var req BannedWordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if req.MatchType == "" {
+		req.MatchType = core.BannedMatchLiteral
+	}
+
+	word := core.BannedWord{Word: strings.TrimSpace(req.Word), MatchType: req.MatchType, Replacement: req.Replacement}
+	if err := core.ValidateBannedWord(word); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "违禁词无效: "+err.Error())
+		return
+	}
+
+	status := 1
+	if req.Status != nil {
+		status = *req.Status
+	}
+
+	result, err := h.db.Exec(
+		"INSERT INTO banned_words (word, match_type, replacement, status) VALUES (?, ?, ?, ?)",
+		word.Word, word.MatchType, nullIfEmpty(word.Replacement), status)
+	if err != nil {
+		if strings.Contains(err.Error(), "Duplicate") {
+			core.Success(c, gin.H{"success": false, "message": "违禁词已存在"})
+			return
+		}
+		core.Success(c, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	id, _ := result.LastInsertId()
+	h.reload(c)
+	core.Success(c, gin.H{"success": true, "id": id})
+}
+
+// BatchAdd 批量添加违禁词
+// POST /api/banned-words/batch
+func (h *BannedWordsHandler) BatchAdd(c *gin.Context) {
+	var req BannedWordBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if req.MatchType == "" {
+		req.MatchType = core.BannedMatchLiteral
+	}
+
+	added, skipped := 0, 0
+	for _, w := range req.Words {
+		word := core.BannedWord{Word: strings.TrimSpace(w), MatchType: req.MatchType}
+		if core.ValidateBannedWord(word) != nil {
+			skipped++
+			continue
+		}
+		result, err := h.db.Exec(
+			"INSERT IGNORE INTO banned_words (word, match_type) VALUES (?, ?)", word.Word, word.MatchType)
+		if err != nil {
+			skipped++
+			continue
+		}
+		if affected, _ := result.RowsAffected(); affected > 0 {
+			added++
+		} else {
+			skipped++
+		}
+	}
+
+	if added > 0 {
+		h.reload(c)
+	}
+	core.Success(c, gin.H{"success": true, "added": added, "skipped": skipped, "total": len(req.Words)})
+}
+
+// Update 更新违禁词
+// PUT /api/banned-words/:id
+func (h *BannedWordsHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的违禁词 ID")
+		return
+	}
+
+	var req BannedWordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if req.MatchType == "" {
+		req.MatchType = core.BannedMatchLiteral
+	}
+
+	word := core.BannedWord{Word: strings.TrimSpace(req.Word), MatchType: req.MatchType, Replacement: req.Replacement}
+	if err := core.ValidateBannedWord(word); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "违禁词无效: "+err.Error())
+		return
+	}
+
+	status := 1
+	if req.Status != nil {
+		status = *req.Status
+	}
+
+	result, err := h.db.Exec(
+		"UPDATE banned_words SET word = ?, match_type = ?, replacement = ?, status = ? WHERE id = ?",
+		word.Word, word.MatchType, nullIfEmpty(word.Replacement), status, id)
+	if err != nil {
+		core.Success(c, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		var exists int
+		if err := h.db.Get(&exists, "SELECT 1 FROM banned_words WHERE id = ?", id); err != nil {
+			core.Success(c, gin.H{"success": false, "message": "违禁词不存在"})
+			return
+		}
+	}
+
+	h.reload(c)
+	core.Success(c, gin.H{"success": true})
+}
+
+// Delete 删除违禁词
+// DELETE /api/banned-words/:id
+func (h *BannedWordsHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的违禁词 ID")
+		return
+	}
+
+	if _, err := h.db.Exec("DELETE FROM banned_words WHERE id = ?", id); err != nil {
+		core.Success(c, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	h.reload(c)
+	core.Success(c, gin.H{"success": true})
+}
+
+// ListPolicies 获取各文章分组的过滤策略
+// GET /api/banned-words/policies
+func (h *BannedWordsHandler) ListPolicies(c *gin.Context) {
+	var items []struct {
+		GroupID   int    `db:"group_id" json:"group_id"`
+		GroupName string `db:"group_name" json:"group_name"`
+		Policy    string `db:"policy" json:"policy"`
+	}
+	query := `SELECT g.id AS group_id, g.name AS group_name, COALESCE(p.policy, 'mask') AS policy
+	          FROM article_groups g LEFT JOIN banned_word_policies p ON p.group_id = g.id
+	          ORDER BY g.id`
+	if err := h.db.Select(&items, query); err != nil {
+		core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+		return
+	}
+	core.Success(c, items)
+}
+
+// SetPolicy 设置分组过滤策略
+// PUT /api/banned-words/policies
+func (h *BannedWordsHandler) SetPolicy(c *gin.Context) {
+	var req FilterPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if !core.ValidFilterPolicy(req.Policy) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的策略，可选: off, mask, replace, reject")
+		return
+	}
+
+	if _, err := h.db.Exec(
+		"INSERT INTO banned_word_policies (group_id, policy) VALUES (?, ?) ON DUPLICATE KEY UPDATE policy = VALUES(policy)",
+		req.GroupID, req.Policy); err != nil {
+		core.FailWithMessage(c, core.ErrDBUpdate, err.Error())
+		return
+	}
+
+	h.reload(c)
+	core.Success(c, gin.H{"success": true})
+}
+
+// Test 测试过滤效果（不计入命中统计）
+// POST /api/banned-words/test
+func (h *BannedWordsHandler) Test(c *gin.Context) {
+	var req FilterTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if h.contentFilter == nil {
+		core.FailWithMessage(c, core.ErrInternalServer, "内容过滤器未初始化")
+		return
+	}
+	if req.GroupID == 0 {
+		req.GroupID = 1
+	}
+
+	text, result := h.contentFilter.Preview(req.GroupID, req.Text)
+	core.Success(c, gin.H{"text": text, "result": result})
+}
+
+// Report 命中统计报表
+// GET /api/banned-words/report?days=7&group_id=
+func (h *BannedWordsHandler) Report(c *gin.Context) {
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if days < 1 || days > 90 {
+		days = 7
+	}
+
+	where := "h.hit_date >= DATE_SUB(CURDATE(), INTERVAL ? DAY)"
+	args := []interface{}{days - 1}
+	if gid, err := strconv.Atoi(c.Query("group_id")); err == nil && gid > 0 {
+		where += " AND h.group_id = ?"
+		args = append(args, gid)
+	}
+
+	var byWord []struct {
+		WordID int    `db:"word_id" json:"word_id"`
+		Word   string `db:"word" json:"word"`
+		Insert int64  `db:"insert_hits" json:"insert_hits"`
+		Pool   int64  `db:"pool_hits" json:"pool_hits"`
+		Total  int64  `db:"total_hits" json:"total_hits"`
+	}
+	query := `SELECT h.word_id, COALESCE(w.word, '') AS word,
+	                 SUM(CASE WHEN h.source = 'insert' THEN h.hits ELSE 0 END) AS insert_hits,
+	                 SUM(CASE WHEN h.source = 'pool' THEN h.hits ELSE 0 END) AS pool_hits,
+	                 SUM(h.hits) AS total_hits
+	          FROM banned_word_hits h LEFT JOIN banned_words w ON w.id = h.word_id
+	          WHERE ` + where + `
+	          GROUP BY h.word_id, w.word ORDER BY total_hits DESC LIMIT 100`
+	if err := h.db.Select(&byWord, query, args...); err != nil {
+		core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+		return
+	}
+
+	var byDate []struct {
+		Date  string `db:"hit_date" json:"date"`
+		Total int64  `db:"total_hits" json:"total_hits"`
+	}
+	dateQuery := `SELECT DATE_FORMAT(h.hit_date, '%Y-%m-%d') AS hit_date, SUM(h.hits) AS total_hits
+	              FROM banned_word_hits h WHERE ` + where + `
+	              GROUP BY h.hit_date ORDER BY h.hit_date`
+	if err := h.db.Select(&byDate, dateQuery, args...); err != nil {
+		core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+		return
+	}
+
+	resp := gin.H{"days": days, "by_word": byWord, "by_date": byDate}
+	if h.contentFilter != nil {
+		resp["runtime"] = h.contentFilter.GetStats()
+	}
+	core.Success(c, resp)
+}
+
+// nullIfEmpty 空字符串转为 NULL
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
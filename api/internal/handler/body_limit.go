@@ -0,0 +1,179 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	core "seo-generator/api/internal/service"
+	"seo-generator/api/pkg/config"
+)
+
+// bodyLimitKey context 中当前路由的请求体上限（字节）
+const bodyLimitKey = "body_limit"
+
+// BodyLimitMiddleware 按路由限制 /api 请求体大小（路由键为 "METHOD 路由模板"，未配置时使用 default_mb）
+// Content-Length 超限时不读取请求体直接返回 413；未声明长度（chunked）时读取超限由 MaxBytesReader 报错，
+// 批量接口的流式解析据此返回已处理的条数
+func BodyLimitMiddleware(cfg config.BodyLimitsConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody || !strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			c.Next()
+			return
+		}
+		mb, ok := cfg.Routes[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			mb = cfg.DefaultMB
+		}
+		if mb <= 0 {
+			c.Next()
+			return
+		}
+
+		limit := int64(mb) << 20
+		c.Set(bodyLimitKey, limit)
+		if c.Request.ContentLength > limit {
+			failBodyTooLarge(c, gin.H{}, fmt.Sprintf("请求体超过 %d MB 上限，请拆分为多个较小的批次提交", mb))
+			c.Abort()
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// failBodyTooLarge 返回 413，data 中附带上限和处理建议
+func failBodyTooLarge(c *gin.Context, data gin.H, hint string) {
+	if limit := c.GetInt64(bodyLimitKey); limit > 0 {
+		data["limit_bytes"] = limit
+		data["limit_mb"] = limit >> 20
+	}
+	data["hint"] = hint
+	core.FailWithData(c, core.ErrPayloadTooLarge, data)
+}
+
+// batchTooLargeReason 流式解析中止的原因（条数超限或请求体超限）
+func batchTooLargeReason(c *gin.Context, err error, maxItems int) string {
+	if errors.Is(err, errBatchTooManyItems) {
+		return fmt.Sprintf("单次最多提交 %d 条", maxItems)
+	}
+	return fmt.Sprintf("请求体超过 %d MB 上限", c.GetInt64(bodyLimitKey)>>20)
+}
+
+var (
+	// errBatchTooManyItems 批量数组条数超过单次上限
+	errBatchTooManyItems = errors.New("too many items")
+	// errBatchMissingItems 请求体中没有批量数组字段
+	errBatchMissingItems = errors.New("missing items")
+)
+
+// isBatchTooLarge 流式解析是否因请求体或条数超限而中止
+func isBatchTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr) || errors.Is(err, errBatchTooManyItems)
+}
+
+// decodeBatchStream 流式解析 {"<itemsField>": [...], ...} 形式的请求体，不缓冲整个请求体
+//
+// 数组元素逐条交给 onItem（由 onItem 调用 dec.Decode 读取一条），第 maxItems+1 条时返回 errBatchTooManyItems；
+// 其余字段交给 onField，afterItems 表示该字段出现在数组之后（已处理的元素未使用它）。
+// 返回已处理的元素数
+func decodeBatchStream(body io.Reader, itemsField string, maxItems int,
+	onField func(key string, raw json.RawMessage, afterItems bool) error,
+	onItem func(dec *json.Decoder, index int) error) (int, error) {
+	dec := json.NewDecoder(body)
+	if tok, err := dec.Token(); err != nil {
+		return 0, err
+	} else if tok != json.Delim('{') {
+		return 0, fmt.Errorf("请求体必须是 JSON 对象")
+	}
+
+	n, found := 0, false
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return n, err
+		}
+		key, _ := tok.(string)
+
+		if key != itemsField {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return n, err
+			}
+			if onField != nil {
+				if err := onField(key, raw, found); err != nil {
+					return n, err
+				}
+			}
+			continue
+		}
+
+		found = true
+		tok, err = dec.Token()
+		if err != nil {
+			return n, err
+		}
+		if tok == nil {
+			continue
+		}
+		if tok != json.Delim('[') {
+			return n, fmt.Errorf("%s 必须是数组", itemsField)
+		}
+		for dec.More() {
+			if n >= maxItems {
+				return n, errBatchTooManyItems
+			}
+			if err := onItem(dec, n); err != nil {
+				return n, err
+			}
+			n++
+		}
+		if _, err := dec.Token(); err != nil {
+			return n, err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return n, err
+	}
+	if !found {
+		return n, errBatchMissingItems
+	}
+	return n, nil
+}
+
+// bindStringBatch 流式解析字符串数组批量请求（关键词、图片 URL），fields 为其余字段的解析目标
+// 超限时返回 413，此时尚未写入任何数据；失败时已写入响应并返回 false
+func bindStringBatch(c *gin.Context, itemsField string, maxItems int, items *[]string, fields map[string]interface{}) bool {
+	n, err := decodeBatchStream(c.Request.Body, itemsField, maxItems,
+		func(key string, raw json.RawMessage, _ bool) error {
+			if target, ok := fields[key]; ok {
+				return json.Unmarshal(raw, target)
+			}
+			return nil
+		},
+		func(dec *json.Decoder, _ int) error {
+			var item string
+			if err := dec.Decode(&item); err != nil {
+				return err
+			}
+			*items = append(*items, item)
+			return nil
+		})
+	switch {
+	case err == nil:
+		return true
+	case isBatchTooLarge(err):
+		*items = nil
+		failBodyTooLarge(c, gin.H{"received": n, "max_items": maxItems},
+			fmt.Sprintf("%s，未写入任何数据，请拆分为较小的批次（每批不超过 %d 条）分别提交", batchTooLargeReason(c, err, maxItems), maxItems))
+	default:
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+	}
+	return false
+}
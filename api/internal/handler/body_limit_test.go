@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeBatchStream(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		maxItems   int
+		wantN      int
+		wantItems  []string
+		wantFields []string // key:afterItems
+		wantErr    error    // nil 表示成功；errAny 表示任意错误
+	}{
+		{
+			name:       "items only",
+			body:       `{"items":["a","b"]}`,
+			maxItems:   10,
+			wantN:      2,
+			wantItems:  []string{"a", "b"},
+			wantFields: []string{},
+		},
+		{
+			name:       "fields before and after",
+			body:       `{"group_id":1,"items":["a"],"status":{"x":1}}`,
+			maxItems:   10,
+			wantN:      1,
+			wantItems:  []string{"a"},
+			wantFields: []string{"group_id:false", "status:true"},
+		},
+		{
+			name:       "empty array",
+			body:       `{"items":[]}`,
+			maxItems:   10,
+			wantItems:  []string{},
+			wantFields: []string{},
+		},
+		{
+			name:       "null items",
+			body:       `{"items":null}`,
+			maxItems:   10,
+			wantItems:  []string{},
+			wantFields: []string{},
+		},
+		{
+			name:       "too many items",
+			body:       `{"items":["a","b","c"]}`,
+			maxItems:   2,
+			wantN:      2,
+			wantItems:  []string{"a", "b"},
+			wantFields: []string{},
+			wantErr:    errBatchTooManyItems,
+		},
+		{
+			name:       "missing items",
+			body:       `{"group_id":1}`,
+			maxItems:   10,
+			wantItems:  []string{},
+			wantFields: []string{"group_id:false"},
+			wantErr:    errBatchMissingItems,
+		},
+		{name: "not an object", body: `["a"]`, maxItems: 10, wantItems: []string{}, wantFields: []string{}, wantErr: errAny},
+		{name: "items not array", body: `{"items":"a"}`, maxItems: 10, wantItems: []string{}, wantFields: []string{}, wantErr: errAny},
+		{name: "item type mismatch", body: `{"items":[1]}`, maxItems: 10, wantItems: []string{}, wantFields: []string{}, wantErr: errAny},
+		{name: "truncated", body: `{"items":["a",`, maxItems: 10, wantN: 1, wantItems: []string{"a"}, wantFields: []string{}, wantErr: errAny},
+		{name: "empty body", body: ``, maxItems: 10, wantItems: []string{}, wantFields: []string{}, wantErr: errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := []string{}
+			fields := []string{}
+			n, err := decodeBatchStream(strings.NewReader(tt.body), "items", tt.maxItems,
+				func(key string, _ json.RawMessage, afterItems bool) error {
+					if afterItems {
+						fields = append(fields, key+":true")
+					} else {
+						fields = append(fields, key+":false")
+					}
+					return nil
+				},
+				func(dec *json.Decoder, _ int) error {
+					var s string
+					if err := dec.Decode(&s); err != nil {
+						return err
+					}
+					items = append(items, s)
+					return nil
+				})
+
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("unexpected err: %v", err)
+			case tt.wantErr == errAny && err == nil:
+				t.Fatal("expected error")
+			case tt.wantErr != nil && tt.wantErr != errAny && !errors.Is(err, tt.wantErr):
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if n != tt.wantN {
+				t.Errorf("n = %d, want %d", n, tt.wantN)
+			}
+			if !reflect.DeepEqual(items, tt.wantItems) {
+				t.Errorf("items = %q, want %q", items, tt.wantItems)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("fields = %q, want %q", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+// errAny 测试用：期望任意错误
+var errAny = errors.New("any error")
+
+// TestDecodeBatchStream_MaxBytes 请求体超限时返回已处理的条数，isBatchTooLarge 识别为超限
+func TestDecodeBatchStream_MaxBytes(t *testing.T) {
+	body := `{"items":["aaaa","bbbb","cccc","dddd"]}`
+	limited := http.MaxBytesReader(httptest.NewRecorder(), io.NopCloser(strings.NewReader(body)), 24)
+
+	n, err := decodeBatchStream(limited, "items", 100, nil, func(dec *json.Decoder, _ int) error {
+		var s string
+		return dec.Decode(&s)
+	})
+	if !isBatchTooLarge(err) {
+		t.Fatalf("err = %v, want body too large", err)
+	}
+	if n == 0 || n >= 4 {
+		t.Errorf("n = %d, want partial count", n)
+	}
+}
+
+func TestIsBatchTooLarge(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"too many items", errBatchTooManyItems, true},
+		{"max bytes", &http.MaxBytesError{Limit: 1}, true},
+		{"missing items", errBatchMissingItems, false},
+		{"syntax", &json.SyntaxError{}, false},
+	}
+	for _, tt := range tests {
+		if got := isBatchTooLarge(tt.err); got != tt.want {
+			t.Errorf("%s: isBatchTooLarge = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
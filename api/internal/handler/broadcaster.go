@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// broadcastEvent 广播的一条消息，ID 在同一广播器内单调递增（SSE 断线续传使用）
+type broadcastEvent struct {
+	ID   uint64
+	Data []byte
+}
+
+// broadcastSource 广播器的数据源，运行到 ctx 取消为止，通过 emit 发出消息
+type broadcastSource func(ctx context.Context, emit func(data []byte))
+
+// broadcaster 一个推送频道的共享广播器
+//
+// WebSocket 和 SSE 连接订阅同一个广播器，数据源（Redis 订阅或定时采集）只在有订阅者时运行一份，
+// 最后一个订阅者退出后停止。最近的消息保存在环形缓冲中：日志类频道用于 SSE 按 Last-Event-ID 续传，
+// 状态类频道（history 为 1）用于新订阅者立即收到最近一次状态
+type broadcaster struct {
+	name    string
+	source  broadcastSource
+	history int
+	replay  bool // 新订阅者（非续传）是否也回放缓冲中的消息，状态类频道为 true
+
+	mu     sync.Mutex
+	subs   map[chan broadcastEvent]struct{}
+	buffer []broadcastEvent
+	nextID uint64
+	cancel context.CancelFunc
+}
+
+// subscriberBuffer 订阅者通道容量，订阅者跟不上时丢弃消息（SSE 客户端可按 ID 续传补回）
+const subscriberBuffer = 64
+
+// subscribe 订阅广播器，resume 为 true 时回放 ID 大于 lastEventID 的缓冲消息
+// 返回回放消息、后续消息通道和取消订阅函数
+func (b *broadcaster) subscribe(lastEventID uint64, resume bool) ([]broadcastEvent, <-chan broadcastEvent, func()) {
+	ch := make(chan broadcastEvent, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.cancel == nil && b.replay {
+		// 数据源已停止，缓冲中的状态已过时，数据源启动后会立即采集一次
+		b.buffer = nil
+	}
+	var replay []broadcastEvent
+	if resume || b.replay {
+		for _, event := range b.buffer {
+			if !resume || event.ID > lastEventID {
+				replay = append(replay, event)
+			}
+		}
+	}
+	b.subs[ch] = struct{}{}
+	if b.cancel == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		b.cancel = cancel
+		go b.run(ctx)
+	}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			if len(b.subs) == 0 && b.cancel != nil {
+				b.cancel()
+				b.cancel = nil
+			}
+			b.mu.Unlock()
+		})
+	}
+	return replay, ch, unsubscribe
+}
+
+// run 运行数据源，数据源自行退出（非取消导致）时清除运行状态，下一个订阅者重新启动
+func (b *broadcaster) run(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Interface("panic", r).Str("channel", b.name).Msg("Broadcast source panicked")
+		}
+		b.mu.Lock()
+		if ctx.Err() == nil && b.cancel != nil {
+			b.cancel()
+			b.cancel = nil
+		}
+		b.mu.Unlock()
+	}()
+	b.source(ctx, func(data []byte) { b.publish(ctx, data) })
+}
+
+// publish 分配 ID、写入缓冲并发给所有订阅者
+func (b *broadcaster) publish(ctx context.Context, data []byte) {
+	if ctx.Err() != nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	event := broadcastEvent{ID: b.nextID, Data: data}
+	if b.history > 0 {
+		if len(b.buffer) >= b.history {
+			b.buffer = append(b.buffer[:0], b.buffer[len(b.buffer)-b.history+1:]...)
+		}
+		b.buffer = append(b.buffer, event)
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// broadcasterHub 按频道名管理广播器（广播器创建后常驻，保证消息 ID 在数据源重启后仍然递增）
+type broadcasterHub struct {
+	mu    sync.Mutex
+	items map[string]*broadcaster
+}
+
+func newBroadcasterHub() *broadcasterHub {
+	return &broadcasterHub{items: make(map[string]*broadcaster)}
+}
+
+// get 获取频道广播器，不存在时用 newSource 创建
+func (h *broadcasterHub) get(name string, history int, replay bool, newSource func() broadcastSource) *broadcaster {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if b, ok := h.items[name]; ok {
+		return b
+	}
+	b := &broadcaster{
+		name:    name,
+		source:  newSource(),
+		history: history,
+		replay:  replay,
+		subs:    make(map[chan broadcastEvent]struct{}),
+	}
+	h.items[name] = b
+	return b
+}
+
+// logHistory 日志类频道保留的续传消息数
+const logHistory = 500
+
+// redisChannel Redis Pub/Sub 频道的广播器（日志、爬虫统计）
+func (h *broadcasterHub) redisChannel(redisClient *redis.Client, channel string) *broadcaster {
+	return h.get("redis:"+channel, logHistory, false, func() broadcastSource {
+		return func(ctx context.Context, emit func([]byte)) {
+			pubsub := redisClient.Subscribe(ctx, channel)
+			defer pubsub.Close()
+
+			ch := pubsub.Channel()
+			for {
+				select {
+				case msg := <-ch:
+					if msg == nil {
+						return
+					}
+					emit([]byte(msg.Payload))
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	})
+}
+
+// ticker 定时采集状态的广播器，启动时立即采集一次，采集失败时跳过本次
+func (h *broadcasterHub) ticker(name string, interval time.Duration, collect func() ([]byte, error)) *broadcaster {
+	return h.get(name, 1, true, func() broadcastSource {
+		return func(ctx context.Context, emit func([]byte)) {
+			send := func() {
+				data, err := collect()
+				if err != nil {
+					log.Debug().Err(err).Str("channel", name).Msg("Broadcast collect failed")
+					return
+				}
+				if data != nil {
+					emit(data)
+				}
+			}
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			send()
+			for {
+				select {
+				case <-ticker.C:
+					send()
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	})
+}
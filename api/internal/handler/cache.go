@@ -17,7 +17,7 @@ import (
 
 // CacheHandler 缓存管理处理器
 type CacheHandler struct {
-	htmlCache        *core.HTMLCache
+	htmlCache        core.HTMLCache
 	templateRenderer *core.TemplateRenderer
 	siteCache        *core.SiteCache
 	templateCache    *core.TemplateCache
@@ -26,7 +26,7 @@ type CacheHandler struct {
 
 // NewCacheHandler 创建缓存管理处理器
 func NewCacheHandler(
-	htmlCache *core.HTMLCache,
+	htmlCache core.HTMLCache,
 	templateRenderer *core.TemplateRenderer,
 	siteCache *core.SiteCache,
 	templateCache *core.TemplateCache,
@@ -139,6 +139,29 @@ func (h *CacheHandler) ReloadAllSites(c *gin.Context) {
 	})
 }
 
+// SyncSites 增量同步站点缓存（只加载水位线之后变更的站点，移除已删除的站点）
+// POST /api/cache/site/sync
+func (h *CacheHandler) SyncSites(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := h.siteCache.Sync(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to sync site cache")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"result":  result,
+		"message": "站点缓存已增量同步",
+	})
+}
+
 // ReloadSite 重新加载指定站点缓存
 // POST /api/cache/site/reload/:domain
 func (h *CacheHandler) ReloadSite(c *gin.Context) {
@@ -286,8 +309,9 @@ func (h *CacheHandler) GetCacheStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
-// RecalculateCacheStats 重新计算缓存统计
+// RecalculateCacheStats 启动后台重新计算缓存统计
 // POST /api/cache/stats/recalculate
+// 扫描按域名分批进行并保存断点，进度通过 /api/cache/stats/recalculate/status 查询
 func (h *CacheHandler) RecalculateCacheStats(c *gin.Context) {
 	result, err := h.htmlCache.Recalculate()
 	if err != nil {
@@ -300,6 +324,12 @@ func (h *CacheHandler) RecalculateCacheStats(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// RecalculateStatus 查询缓存统计扫描进度
+// GET /api/cache/stats/recalculate/status
+func (h *CacheHandler) RecalculateStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.htmlCache.RecalculateStatus())
+}
+
 // ReloadCacheConfig 重载缓存配置
 // POST /api/cache/config/reload
 func (h *CacheHandler) ReloadCacheConfig(c *gin.Context) {
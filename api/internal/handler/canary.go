@@ -0,0 +1,65 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// CanaryHandler 渲染自检 handler
+type CanaryHandler struct {
+	canary *core.Canary
+}
+
+// NewCanaryHandler 创建 CanaryHandler
+func NewCanaryHandler(canary *core.Canary) *CanaryHandler {
+	return &CanaryHandler{canary: canary}
+}
+
+// Summary 各站群最近的自检状态（含连续失败次数）
+// GET /api/admin/system/canary
+func (h *CanaryHandler) Summary(c *gin.Context) {
+	core.Success(c, h.canary.Summary())
+}
+
+// Run 立即自检（site_group_id 为空时检查全部启用站群）
+// POST /api/admin/system/canary/run?site_group_id=1
+func (h *CanaryHandler) Run(c *gin.Context) {
+	groupID, _ := strconv.Atoi(c.Query("site_group_id"))
+	results, err := h.canary.Run(c.Request.Context(), groupID)
+	if err != nil {
+		if errors.Is(err, core.ErrCanaryRunning) {
+			core.FailWithMessage(c, core.ErrInvalidParam, "自检正在进行中，请稍后重试")
+			return
+		}
+		log.Warn().Err(err).Msg("Canary check failed")
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		return
+	}
+	if groupID > 0 && len(results) == 0 {
+		core.FailWithMessage(c, core.ErrNotFound, "站群不存在或没有启用的站点")
+		return
+	}
+	core.Success(c, gin.H{"items": results})
+}
+
+// History 最近的自检记录
+// GET /api/admin/system/canary/history?site_group_id=1&failed=true&limit=100
+func (h *CanaryHandler) History(c *gin.Context) {
+	groupID, _ := strconv.Atoi(c.Query("site_group_id"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+	items, err := h.canary.History(c.Request.Context(), groupID, limit, c.Query("failed") == "true")
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to load canary history")
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+	core.Success(c, gin.H{"items": items})
+}
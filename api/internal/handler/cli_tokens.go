@@ -0,0 +1,226 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// cliTokenPrefix 命令行 Token 前缀，认证时据此与 JWT / 全局 API Token 区分
+const cliTokenPrefix = "cli_"
+
+// cliScopes 命令行 Token 可授予的权限范围，带 ":read" 后缀时只允许 GET
+var cliScopes = map[string]bool{
+	"sites":    true, // /api/sites
+	"keywords": true, // /api/keywords
+	"images":   true, // /api/images
+	"pools":    true, // /api/cache-pool
+	"spider":   true, // /api/spider-projects
+}
+
+// CLIToken seogen-cli 使用的命令行 Token，只能访问授予的 scope 对应的接口
+// 数据库只保存 SHA-256，明文仅在创建时返回一次
+type CLIToken struct {
+	ID          int64      `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	TokenPrefix string     `json:"token_prefix" db:"token_prefix"`
+	Scopes      string     `json:"scopes" db:"scopes"`
+	Enabled     bool       `json:"enabled" db:"enabled"`
+	ExpiresAt   *time.Time `json:"expires_at" db:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at" db:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CLITokenRequest 创建 / 更新命令行 Token 请求
+type CLITokenRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Scopes      []string `json:"scopes" binding:"required"` // sites / keywords / images / pools / spider，可加 :read
+	ExpiresDays int      `json:"expires_days"`              // 有效天数，0 为不过期（仅创建时使用）
+	Enabled     *bool    `json:"enabled"`
+}
+
+// normalizeCLIScopes 校验并拼接 scope 列表
+func normalizeCLIScopes(scopes []string) (string, error) {
+	if len(scopes) == 0 {
+		return "", fmt.Errorf("至少授予一个权限范围")
+	}
+	seen := make(map[string]bool, len(scopes))
+	out := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		s = strings.TrimSpace(s)
+		if !cliScopes[strings.TrimSuffix(s, ":read")] {
+			return "", fmt.Errorf("无效的权限范围: %s", s)
+		}
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return strings.Join(out, ","), nil
+}
+
+// cliScopeAllows scopes 是否允许以 method 访问 scope 对应的接口
+func cliScopeAllows(scopes, scope, method string) bool {
+	for _, s := range strings.Split(scopes, ",") {
+		switch strings.TrimSpace(s) {
+		case scope:
+			return true
+		case scope + ":read":
+			if method == http.MethodGet || method == http.MethodHead {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hashAPIToken 命令行 Token / 推送方 Token 的 SHA-256（十六进制），数据库只保存哈希
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// lookupCLIToken 查找启用且未过期的命令行 Token，不存在时返回 nil，并更新最近使用时间
+func lookupCLIToken(db *sqlx.DB, token string) *CLIToken {
+	var t CLIToken
+	if err := db.Get(&t,
+		`SELECT id, name, token_prefix, scopes, enabled, expires_at, last_used_at, created_at
+		 FROM cli_tokens WHERE token_hash = ? AND enabled = 1 AND (expires_at IS NULL OR expires_at > NOW())`,
+		hashAPIToken(token)); err != nil {
+		if err != sql.ErrNoRows {
+			log.Warn().Err(err).Msg("Failed to look up CLI token")
+		}
+		return nil
+	}
+	go func(id int64) {
+		if _, err := db.Exec("UPDATE cli_tokens SET last_used_at = NOW() WHERE id = ?", id); err != nil {
+			log.Debug().Err(err).Int64("cli_token_id", id).Msg("Failed to update CLI token last_used_at")
+		}
+	}(t.ID)
+	return &t
+}
+
+// CLITokensHandler 命令行 Token 管理 handler
+type CLITokensHandler struct {
+	db *sqlx.DB
+}
+
+// NewCLITokensHandler 创建 CLITokensHandler
+func NewCLITokensHandler(db *sqlx.DB) *CLITokensHandler {
+	return &CLITokensHandler{db: db}
+}
+
+// List 命令行 Token 列表（不含明文）
+// GET /api/settings/cli-tokens
+func (h *CLITokensHandler) List(c *gin.Context) {
+	items := []CLIToken{}
+	if err := h.db.Select(&items,
+		`SELECT id, name, token_prefix, scopes, enabled, expires_at, last_used_at, created_at
+		 FROM cli_tokens ORDER BY id`); err != nil {
+		core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+		return
+	}
+	core.Success(c, items)
+}
+
+// Create 创建命令行 Token（随机生成，明文只在本次响应中返回）
+// POST /api/settings/cli-tokens
+func (h *CLITokensHandler) Create(c *gin.Context) {
+	var req CLITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	scopes, err := normalizeCLIScopes(req.Scopes)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+		return
+	}
+	if req.ExpiresDays < 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "有效天数不能为负数")
+		return
+	}
+	var expiresAt *time.Time
+	if req.ExpiresDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresDays)
+		expiresAt = &t
+	}
+	enabled := req.Enabled == nil || *req.Enabled
+
+	buf := make([]byte, 20)
+	rand.Read(buf)
+	token := cliTokenPrefix + hex.EncodeToString(buf)
+
+	result, err := h.db.Exec(
+		"INSERT INTO cli_tokens (name, token_hash, token_prefix, scopes, enabled, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		req.Name, hashAPIToken(token), token[:len(cliTokenPrefix)+8], scopes, enabled, expiresAt)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrDBUpdate, err.Error())
+		return
+	}
+	id, _ := result.LastInsertId()
+	core.Success(c, gin.H{"success": true, "id": id, "token": token, "scopes": scopes, "expires_at": expiresAt})
+}
+
+// Update 更新命令行 Token 的名称、权限范围和启用状态
+// PUT /api/settings/cli-tokens/:id
+func (h *CLITokensHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的 ID")
+		return
+	}
+	var req CLITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	scopes, err := normalizeCLIScopes(req.Scopes)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+		return
+	}
+	enabled := req.Enabled == nil || *req.Enabled
+
+	result, err := h.db.Exec("UPDATE cli_tokens SET name = ?, scopes = ?, enabled = ? WHERE id = ?",
+		req.Name, scopes, enabled, id)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrDBUpdate, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		var exists int
+		if h.db.Get(&exists, "SELECT 1 FROM cli_tokens WHERE id = ?", id) != nil {
+			core.FailWithMessage(c, core.ErrNotFound, "Token 不存在")
+			return
+		}
+	}
+	core.Success(c, gin.H{"success": true})
+}
+
+// Delete 删除命令行 Token
+// DELETE /api/settings/cli-tokens/:id
+func (h *CLITokensHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的 ID")
+		return
+	}
+	if _, err := h.db.Exec("DELETE FROM cli_tokens WHERE id = ?", id); err != nil {
+		core.FailWithMessage(c, core.ErrDBUpdate, err.Error())
+		return
+	}
+	core.Success(c, gin.H{"success": true})
+}
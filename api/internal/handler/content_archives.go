@@ -0,0 +1,92 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// ContentArchivesHandler 冷正文归档 handler
+type ContentArchivesHandler struct {
+	archiver *core.ContentArchiver
+}
+
+// NewContentArchivesHandler 创建 ContentArchivesHandler
+func NewContentArchivesHandler(archiver *core.ContentArchiver) *ContentArchivesHandler {
+	return &ContentArchivesHandler{archiver: archiver}
+}
+
+// ContentRestoreRequest 恢复批次请求
+// available 为 true 时恢复为可用状态，否则保持已使用（下次归档时会再次归档）
+type ContentRestoreRequest struct {
+	Available bool `json:"available"`
+}
+
+// List 归档批次列表
+// GET /api/content-archives?status=&page=1&page_size=20
+func (h *ContentArchivesHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	items, total, err := h.archiver.List(c.Request.Context(), c.Query("status"), page, pageSize)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list content archives")
+		items = []core.ContentArchive{}
+	}
+	core.SuccessPaged(c, items, total, page, pageSize)
+}
+
+// Run 立即执行归档
+// POST /api/content-archives/run
+func (h *ContentArchivesHandler) Run(c *gin.Context) {
+	jobID, err := h.archiver.Submit(c.Request.Context())
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		return
+	}
+	core.Success(c, gin.H{"job_id": jobID})
+}
+
+// Restore 按批次恢复正文
+// POST /api/content-archives/:id/restore
+func (h *ContentArchivesHandler) Restore(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的批次 ID")
+		return
+	}
+	var req ContentRestoreRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+			return
+		}
+	}
+
+	archive, err := h.archiver.Get(c.Request.Context(), id)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrNotFound, "批次不存在")
+		return
+	}
+	switch archive.Status {
+	case core.ContentArchiveUploaded, core.ContentArchivePurged, core.ContentArchiveRestored:
+	default:
+		core.FailWithMessage(c, core.ErrInvalidParam, "批次状态为 "+archive.Status+"，无法恢复")
+		return
+	}
+
+	jobID, err := h.archiver.SubmitRestore(c.Request.Context(), id, req.Available)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		return
+	}
+	core.Success(c, gin.H{"job_id": jobID})
+}
@@ -1,9 +1,14 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,36 +16,21 @@ import (
 	core "seo-generator/api/internal/service"
 )
 
-// 文件大小限制
-const maxFileSize = 10 * 1024 * 1024 // 10MB
-
-// 支持的文本文件扩展名
-var textExtensions = map[string]bool{
-	".py": true, ".txt": true, ".json": true, ".yaml": true, ".yml": true,
-	".md": true, ".html": true, ".css": true, ".js": true, ".ts": true,
-	".go": true, ".sh": true, ".conf": true, ".ini": true, ".toml": true,
-	".xml": true, ".sql": true, ".env": true, ".gitignore": true,
-}
-
-// isTextFile 判断文件是否为文本文件
-func isTextFile(filename string) bool {
-	ext := strings.ToLower(filepath.Ext(filename))
-	if ext == "" {
-		// 无扩展名的文件（如 Dockerfile, Makefile）
-		base := filepath.Base(filename)
-		return base == "Dockerfile" || base == "Makefile" || base == "requirements"
-	}
-	return textExtensions[ext]
-}
+// uploadBodyFactor 上传请求体上限为单文件上限的倍数
+const uploadBodyFactor = 10
 
 // ContentWorkerFilesHandler 内容处理代码文件管理 handler
+//
+// 路径限制在根目录内（拒绝 ".." 和指向根目录外的符号链接），大小限制、编辑类型校验和操作审计由 core.ConfinedFS 负责
 type ContentWorkerFilesHandler struct {
-	baseDir string
+	fs        *core.ConfinedFS
+	trash     *core.FileTrash
+	allowlist *core.IPAllowlist // 受信代理判断，审计记录的客户端 IP（可为 nil）
 }
 
 // NewContentWorkerFilesHandler 创建 ContentWorkerFilesHandler
-func NewContentWorkerFilesHandler(baseDir string) *ContentWorkerFilesHandler {
-	return &ContentWorkerFilesHandler{baseDir: baseDir}
+func NewContentWorkerFilesHandler(confined *core.ConfinedFS, trash *core.FileTrash, allowlist *core.IPAllowlist) *ContentWorkerFilesHandler {
+	return &ContentWorkerFilesHandler{fs: confined, trash: trash, allowlist: allowlist}
 }
 
 // FileInfo 文件信息
@@ -59,59 +49,75 @@ type TreeNode struct {
 	Children []*TreeNode `json:"children,omitempty"`
 }
 
-// validatePath 验证路径安全性，防止路径穿越
-func (h *ContentWorkerFilesHandler) validatePath(relativePath string) (string, bool) {
-	// 清理路径
-	cleanPath := filepath.Clean(relativePath)
+// actor 当前操作人（写入审计）
+func (h *ContentWorkerFilesHandler) actor(c *gin.Context) core.FileActor {
+	return fileActor(c, h.allowlist)
+}
 
-	// 空路径或当前目录指向 workerDir 根目录，这是允许的（用于列出根目录）
-	if cleanPath == "." || cleanPath == "" {
-		return h.baseDir, true
-	}
+// fileActor 文件操作审计中的操作人；客户端 IP 只在直连地址为受信代理时才采用转发头，审计记录不能被伪造
+func fileActor(c *gin.Context, allowlist *core.IPAllowlist) core.FileActor {
+	return core.FileActor{Username: c.GetString("username"), ClientIP: trustedClientIP(c, allowlist)}
+}
 
-	// 检查是否包含 ".."
-	if strings.HasPrefix(cleanPath, "..") || strings.Contains(cleanPath, ".."+string(filepath.Separator)) {
-		return "", false
+// fail 按文件操作错误类型返回响应
+func (h *ContentWorkerFilesHandler) fail(c *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		core.FailWithCode(c, core.ErrNotFound)
+	case errors.Is(err, os.ErrExist):
+		core.FailWithMessage(c, core.ErrInvalidParam, "文件或目录已存在")
+	case errors.Is(err, core.ErrPathOutsideRoot):
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的路径")
+	case errors.Is(err, core.ErrFileTooLarge):
+		core.FailWithMessage(c, core.ErrInvalidParam, fmt.Sprintf("文件过大，最大支持 %dMB", h.fs.MaxFileSize()/1024/1024))
+	case errors.Is(err, core.ErrNotEditable):
+		core.FailWithMessage(c, core.ErrInvalidParam, "不支持编辑二进制文件")
+	case errors.Is(err, core.ErrTrashNotFound):
+		core.FailWithMessage(c, core.ErrNotFound, "回收站中没有该条目（已恢复或已清除）")
+	case errors.Is(err, core.ErrTrashConflict):
+		core.FailWithMessage(c, core.ErrInvalidParam, "原路径已存在，无法恢复")
+	default:
+		core.FailWithMessage(c, core.ErrInternalServer, prefix+err.Error())
 	}
+}
 
-	fullPath := filepath.Join(h.baseDir, cleanPath)
+// validName 验证文件名不包含非法字符且不是隐藏文件
+func validName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, `/\:*?"<>|`) && !strings.HasPrefix(name, ".")
+}
 
-	// 使用 filepath.Rel 确保路径在 workerDir 内
-	rel, err := filepath.Rel(h.baseDir, fullPath)
-	if err != nil || strings.HasPrefix(rel, "..") {
-		return "", false
+// hiddenEntry 跳过隐藏文件/目录、__pycache__ 和指向根目录外的符号链接
+func (h *ContentWorkerFilesHandler) hiddenEntry(dir string, entry os.DirEntry) bool {
+	name := entry.Name()
+	if strings.HasPrefix(name, ".") || name == "__pycache__" {
+		return true
 	}
-
-	return fullPath, true
+	return entry.Type()&os.ModeSymlink != 0 && !h.fs.Contains(filepath.Join(dir, name))
 }
 
 // ListDir 列出目录内容或读取文件
 // GET /api/worker/files/*path
 func (h *ContentWorkerFilesHandler) ListDir(c *gin.Context) {
-	path := c.Param("path")
-	if path == "" || path == "/" {
-		path = ""
+	relPath := c.Param("path")
+	if relPath == "" || relPath == "/" {
+		relPath = ""
 	}
 
-	fullPath, ok := h.validatePath(path)
-	if !ok {
-		core.FailWithMessage(c, core.ErrInvalidParam, "无效的路径")
+	fullPath, err := h.fs.Resolve(relPath)
+	if err != nil {
+		h.fail(c, err, "")
 		return
 	}
 
 	info, err := os.Stat(fullPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			core.FailWithCode(c, core.ErrNotFound)
-			return
-		}
-		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		h.fail(c, err, "")
 		return
 	}
 
 	// 如果是文件，返回文件内容
 	if !info.IsDir() {
-		h.readFile(c, fullPath, path, info)
+		h.readFile(c, relPath)
 		return
 	}
 
@@ -124,10 +130,7 @@ func (h *ContentWorkerFilesHandler) ListDir(c *gin.Context) {
 
 	var files []FileInfo
 	for _, entry := range entries {
-		name := entry.Name()
-
-		// 跳过隐藏文件和 __pycache__ 目录
-		if strings.HasPrefix(name, ".") || name == "__pycache__" {
+		if h.hiddenEntry(fullPath, entry) {
 			continue
 		}
 
@@ -142,7 +145,7 @@ func (h *ContentWorkerFilesHandler) ListDir(c *gin.Context) {
 		}
 
 		files = append(files, FileInfo{
-			Name:  name,
+			Name:  entry.Name(),
 			Type:  fileType,
 			Size:  entryInfo.Size(),
 			Mtime: entryInfo.ModTime(),
@@ -158,28 +161,16 @@ func (h *ContentWorkerFilesHandler) ListDir(c *gin.Context) {
 	})
 
 	core.Success(c, gin.H{
-		"path":  path,
+		"path":  relPath,
 		"files": files,
 	})
 }
 
-// readFile 读取文件内容并返回 JSON
-func (h *ContentWorkerFilesHandler) readFile(c *gin.Context, fullPath, relativePath string, info os.FileInfo) {
-	// 检查文件大小
-	if info.Size() > maxFileSize {
-		core.FailWithMessage(c, core.ErrInvalidParam, "文件过大，最大支持 10MB")
-		return
-	}
-
-	// 检查是否为文本文件
-	if !isTextFile(relativePath) {
-		core.FailWithMessage(c, core.ErrInvalidParam, "不支持编辑二进制文件")
-		return
-	}
-
-	content, err := os.ReadFile(fullPath)
+// readFile 读取文件内容并返回 JSON（超过大小上限或非文本内容时拒绝）
+func (h *ContentWorkerFilesHandler) readFile(c *gin.Context, relativePath string) {
+	content, info, err := h.fs.ReadFile(relativePath)
 	if err != nil {
-		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		h.fail(c, err, "")
 		return
 	}
 
@@ -194,11 +185,11 @@ func (h *ContentWorkerFilesHandler) readFile(c *gin.Context, fullPath, relativeP
 // GetTree 获取目录树
 // GET /api/worker/files?tree=true
 func (h *ContentWorkerFilesHandler) GetTree(c *gin.Context) {
-	tree := h.buildTree(h.baseDir, "/")
+	tree := h.buildTree(h.fs.Root(), "/")
 	core.Success(c, tree)
 }
 
-// buildTree 递归构建目录树（包含文件和目录）
+// buildTree 递归构建目录树（包含文件和目录，不进入符号链接目录）
 func (h *ContentWorkerFilesHandler) buildTree(dirPath, relativePath string) *TreeNode {
 	info, err := os.Stat(dirPath)
 	if err != nil {
@@ -226,12 +217,11 @@ func (h *ContentWorkerFilesHandler) buildTree(dirPath, relativePath string) *Tre
 	}
 
 	for _, entry := range entries {
-		// 跳过隐藏文件/目录和 __pycache__
-		if strings.HasPrefix(entry.Name(), ".") || entry.Name() == "__pycache__" {
+		if h.hiddenEntry(dirPath, entry) {
 			continue
 		}
 
-		childPath := filepath.Join(relativePath, entry.Name())
+		childPath := path.Join(relativePath, entry.Name())
 		if entry.IsDir() {
 			// 递归处理目录
 			child := h.buildTree(filepath.Join(dirPath, entry.Name()), childPath)
@@ -268,25 +258,21 @@ type CreateRequest struct {
 // Create 创建文件或目录
 // POST /api/worker/files/*path
 func (h *ContentWorkerFilesHandler) Create(c *gin.Context) {
-	path := c.Param("path")
-	if path == "" || path == "/" {
-		path = ""
+	relPath := c.Param("path")
+	if relPath == "" || relPath == "/" {
+		relPath = ""
 	}
 
-	parentPath, ok := h.validatePath(path)
-	if !ok {
-		core.FailWithMessage(c, core.ErrInvalidParam, "无效的路径")
+	parentPath, err := h.fs.Resolve(relPath)
+	if err != nil {
+		h.fail(c, err, "")
 		return
 	}
 
 	// 确保父目录存在
 	info, err := os.Stat(parentPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			core.FailWithCode(c, core.ErrNotFound)
-			return
-		}
-		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		h.fail(c, err, "")
 		return
 	}
 	if !info.IsDir() {
@@ -300,37 +286,23 @@ func (h *ContentWorkerFilesHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// 验证文件名不包含非法字符
-	if strings.ContainsAny(req.Name, `/\:*?"<>|`) || strings.HasPrefix(req.Name, ".") {
+	if !validName(req.Name) {
 		core.FailWithMessage(c, core.ErrInvalidParam, "文件名包含非法字符")
 		return
 	}
 
-	targetPath := filepath.Join(parentPath, req.Name)
-
-	// 检查目标是否已存在
-	if _, err := os.Stat(targetPath); err == nil {
-		core.FailWithMessage(c, core.ErrInvalidParam, "文件或目录已存在")
-		return
-	}
-
+	target := path.Join(relPath, req.Name)
+	prefix := "创建文件失败: "
 	if req.Type == "dir" {
-		if err := os.MkdirAll(targetPath, 0755); err != nil {
-			core.FailWithMessage(c, core.ErrInternalServer, "创建目录失败: "+err.Error())
-			return
-		}
-	} else {
-		// 创建空文件
-		file, err := os.Create(targetPath)
-		if err != nil {
-			core.FailWithMessage(c, core.ErrInternalServer, "创建文件失败: "+err.Error())
-			return
-		}
-		file.Close()
+		prefix = "创建目录失败: "
+	}
+	if err := h.fs.Create(c.Request.Context(), h.actor(c), target, req.Type == "dir"); err != nil {
+		h.fail(c, err, prefix)
+		return
 	}
 
 	core.Success(c, gin.H{
-		"path": filepath.Join(path, req.Name),
+		"path": target,
 		"name": req.Name,
 		"type": req.Type,
 	})
@@ -344,105 +316,50 @@ type SaveRequest struct {
 // Save 保存文件
 // PUT /api/worker/files/*path
 func (h *ContentWorkerFilesHandler) Save(c *gin.Context) {
-	path := c.Param("path")
-	if path == "" || path == "/" {
+	relPath := c.Param("path")
+	if relPath == "" || relPath == "/" {
 		core.FailWithMessage(c, core.ErrInvalidParam, "路径不能为空")
 		return
 	}
 
-	fullPath, ok := h.validatePath(path)
-	if !ok {
-		core.FailWithMessage(c, core.ErrInvalidParam, "无效的路径")
-		return
-	}
-
-	// 检查文件是否存在
-	info, err := os.Stat(fullPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			core.FailWithCode(c, core.ErrNotFound)
-			return
-		}
-		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
-		return
-	}
-
-	// 不能保存目录
-	if info.IsDir() {
-		core.FailWithMessage(c, core.ErrInvalidParam, "不能保存目录")
-		return
-	}
-
-	// 检查是否为文本文件
-	if !isTextFile(path) {
-		core.FailWithMessage(c, core.ErrInvalidParam, "不支持编辑二进制文件")
-		return
-	}
-
 	var req SaveRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		core.FailWithMessage(c, core.ErrInvalidParam, "参数错误: "+err.Error())
 		return
 	}
 
-	// 写入内容
-	if err := os.WriteFile(fullPath, []byte(req.Content), 0644); err != nil {
-		core.FailWithMessage(c, core.ErrInternalServer, "保存文件失败: "+err.Error())
+	info, err := h.fs.WriteFile(c.Request.Context(), h.actor(c), relPath, []byte(req.Content))
+	if err != nil {
+		h.fail(c, err, "保存文件失败: ")
 		return
 	}
 
-	// 获取更新后的文件信息
-	newInfo, _ := os.Stat(fullPath)
-	mtime := time.Now()
-	if newInfo != nil {
-		mtime = newInfo.ModTime()
-	}
-
 	core.Success(c, gin.H{
-		"path":  path,
+		"path":  relPath,
 		"size":  len(req.Content),
-		"mtime": mtime,
+		"mtime": info.ModTime(),
 	})
 }
 
-// Delete 删除文件或目录
+// Delete 删除文件或目录（移入回收站，返回恢复令牌）
 // DELETE /api/worker/files/*path
 func (h *ContentWorkerFilesHandler) Delete(c *gin.Context) {
-	path := c.Param("path")
-	if path == "" || path == "/" {
+	relPath := c.Param("path")
+	if strings.Trim(relPath, "/") == "" {
 		core.FailWithMessage(c, core.ErrInvalidParam, "不能删除根目录")
 		return
 	}
 
-	fullPath, ok := h.validatePath(path)
-	if !ok {
-		core.FailWithMessage(c, core.ErrInvalidParam, "无效的路径")
-		return
-	}
-
-	// 再次检查是否试图删除根目录
-	if fullPath == h.baseDir {
-		core.FailWithMessage(c, core.ErrInvalidParam, "不能删除根目录")
-		return
-	}
-
-	// 检查文件或目录是否存在
-	if _, err := os.Stat(fullPath); err != nil {
-		if os.IsNotExist(err) {
-			core.FailWithCode(c, core.ErrNotFound)
-			return
-		}
-		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
-		return
-	}
-
-	// 删除文件或目录
-	if err := os.RemoveAll(fullPath); err != nil {
-		core.FailWithMessage(c, core.ErrInternalServer, "删除失败: "+err.Error())
+	entry, err := h.trash.TrashWorkerPath(c.Request.Context(), h.actor(c), relPath)
+	if err != nil {
+		h.fail(c, err, "删除失败: ")
 		return
 	}
 
-	core.SuccessWithMessage(c, "删除成功", nil)
+	core.SuccessWithMessage(c, "删除成功", gin.H{
+		"restore_token": entry.Token,
+		"expires_at":    entry.ExpiresAt,
+	})
 }
 
 // MoveRequest 移动/重命名请求
@@ -453,80 +370,49 @@ type MoveRequest struct {
 // Move 重命名或移动文件/目录
 // PATCH /api/worker/files/*path
 func (h *ContentWorkerFilesHandler) Move(c *gin.Context) {
-	path := c.Param("path")
-	if path == "" || path == "/" {
+	relPath := c.Param("path")
+	if strings.Trim(relPath, "/") == "" {
 		core.FailWithMessage(c, core.ErrInvalidParam, "不能移动根目录")
 		return
 	}
 
-	oldPath, ok := h.validatePath(path)
-	if !ok {
-		core.FailWithMessage(c, core.ErrInvalidParam, "无效的源路径")
-		return
-	}
-
 	var req MoveRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
 		return
 	}
-
-	newPath, ok := h.validatePath(req.NewPath)
-	if !ok {
+	if strings.Trim(req.NewPath, "/") == "" {
 		core.FailWithMessage(c, core.ErrInvalidParam, "无效的目标路径")
 		return
 	}
 
-	// 检查源是否存在
-	if _, err := os.Stat(oldPath); err != nil {
-		if os.IsNotExist(err) {
-			core.FailWithCode(c, core.ErrNotFound)
+	if err := h.fs.Rename(c.Request.Context(), h.actor(c), relPath, req.NewPath); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			core.FailWithMessage(c, core.ErrInvalidParam, "目标路径已存在")
 			return
 		}
-		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
-		return
-	}
-
-	// 检查目标是否已存在
-	if _, err := os.Stat(newPath); err == nil {
-		core.FailWithMessage(c, core.ErrInvalidParam, "目标路径已存在")
-		return
-	}
-
-	// 确保目标父目录存在
-	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
-		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
-		return
-	}
-
-	if err := os.Rename(oldPath, newPath); err != nil {
-		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		h.fail(c, err, "")
 		return
 	}
 
 	core.Success(c, gin.H{"message": "移动成功", "new_path": req.NewPath})
 }
 
-// Upload 上传文件
+// Upload 上传文件（超过大小上限或文件名非法的文件跳过，在 skipped 中返回）
 // POST /api/worker/upload/*path
 func (h *ContentWorkerFilesHandler) Upload(c *gin.Context) {
-	path := c.Param("path")
-	if path == "" || path == "/" {
-		path = ""
-	}
-
-	dirPath, ok := h.validatePath(path)
-	if !ok {
-		core.FailWithMessage(c, core.ErrInvalidParam, "无效的路径")
-		return
+	relPath := c.Param("path")
+	if relPath == "" || relPath == "/" {
+		relPath = ""
 	}
 
-	// 确保目录存在
-	if err := os.MkdirAll(dirPath, 0755); err != nil {
-		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+	if _, err := h.fs.Resolve(relPath); err != nil {
+		h.fail(c, err, "")
 		return
 	}
 
+	// 请求体上限：单文件上限的若干倍，避免超大表单写满临时目录
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.fs.MaxFileSize()*uploadBodyFactor)
 	form, err := c.MultipartForm()
 	if err != nil {
 		core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
@@ -540,22 +426,28 @@ func (h *ContentWorkerFilesHandler) Upload(c *gin.Context) {
 	}
 
 	uploaded := make([]string, 0, len(files))
+	skipped := make([]string, 0)
 	for _, file := range files {
-		// 检查文件大小
-		if file.Size > maxFileSize {
-			continue // 跳过过大的文件
-		}
-
-		// 验证文件名（非法字符和隐藏文件）
-		if strings.ContainsAny(file.Filename, "/\\:*?\"<>|") || strings.HasPrefix(file.Filename, ".") {
+		if !validName(file.Filename) || file.Size > h.fs.MaxFileSize() {
+			skipped = append(skipped, file.Filename)
 			continue
 		}
 
-		dst := filepath.Join(dirPath, file.Filename)
-		if err := c.SaveUploadedFile(file, dst); err != nil {
+		src, err := file.Open()
+		if err != nil {
 			core.FailWithMessage(c, core.ErrInternalServer, err.Error())
 			return
 		}
+		err = h.fs.SaveUpload(c.Request.Context(), h.actor(c), path.Join(relPath, file.Filename), src, file.Size)
+		src.Close()
+		if errors.Is(err, core.ErrFileTooLarge) {
+			skipped = append(skipped, file.Filename)
+			continue
+		}
+		if err != nil {
+			h.fail(c, err, "")
+			return
+		}
 		uploaded = append(uploaded, file.Filename)
 	}
 
@@ -563,27 +455,24 @@ func (h *ContentWorkerFilesHandler) Upload(c *gin.Context) {
 		"message": "上传成功",
 		"files":   uploaded,
 		"count":   len(uploaded),
+		"skipped": skipped,
 	})
 }
 
 // Download 下载文件
 // GET /api/worker/download/*path
 func (h *ContentWorkerFilesHandler) Download(c *gin.Context) {
-	path := c.Param("path")
+	relPath := c.Param("path")
 
-	fullPath, ok := h.validatePath(path)
-	if !ok {
-		core.FailWithMessage(c, core.ErrInvalidParam, "无效的路径")
+	fullPath, err := h.fs.Resolve(relPath)
+	if err != nil {
+		h.fail(c, err, "")
 		return
 	}
 
 	info, err := os.Stat(fullPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			core.FailWithCode(c, core.ErrNotFound)
-			return
-		}
-		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		h.fail(c, err, "")
 		return
 	}
 
@@ -592,5 +481,89 @@ func (h *ContentWorkerFilesHandler) Download(c *gin.Context) {
 		return
 	}
 
-	c.FileAttachment(fullPath, filepath.Base(path))
+	c.FileAttachment(fullPath, filepath.Base(relPath))
+}
+
+// AuditLogs 文件操作审计记录（op、username、path 前缀过滤）
+// GET /api/content-worker/audit
+func (h *ContentWorkerFilesHandler) AuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 500 {
+		pageSize = 50
+	}
+	items, total, err := h.fs.AuditLogs(c.Request.Context(), core.FileAuditFilter{
+		Op:       strings.TrimSpace(c.Query("op")),
+		Username: strings.TrimSpace(c.Query("username")),
+		Path:     strings.TrimSpace(c.Query("path")),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+		return
+	}
+	core.SuccessPaged(c, items, total, page, pageSize)
+}
+
+// ListTrash 回收站条目
+// GET /api/content-worker/trash
+func (h *ContentWorkerFilesHandler) ListTrash(c *gin.Context) {
+	items, err := h.trash.List(c.Request.Context(), core.TrashSourceContentWorker, 0)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+		return
+	}
+	core.Success(c, items)
+}
+
+// RestoreTrash 按恢复令牌放回原路径
+// POST /api/content-worker/trash/:token/restore
+func (h *ContentWorkerFilesHandler) RestoreTrash(c *gin.Context) {
+	entry, err := h.trash.Restore(c.Request.Context(), h.actor(c), core.TrashSourceContentWorker, 0, c.Param("token"))
+	if err != nil {
+		h.fail(c, err, "恢复失败: ")
+		return
+	}
+	core.SuccessWithMessage(c, "恢复成功", gin.H{"path": entry.Path})
+}
+
+// PurgeTrash 永久删除回收站条目
+// DELETE /api/content-worker/trash/:token
+func (h *ContentWorkerFilesHandler) PurgeTrash(c *gin.Context) {
+	if err := h.trash.Purge(c.Request.Context(), core.TrashSourceContentWorker, 0, c.Param("token")); err != nil {
+		h.fail(c, err, "清除失败: ")
+		return
+	}
+	core.SuccessWithMessage(c, "已永久删除", nil)
+}
+
+// fileSearchQuery 搜索参数：q、regex、case_sensitive、path（路径前缀）、max_results
+func fileSearchQuery(c *gin.Context) core.FileSearchQuery {
+	maxResults, _ := strconv.Atoi(c.Query("max_results"))
+	return core.FileSearchQuery{
+		Query:         c.Query("q"),
+		Regex:         c.Query("regex") == "true" || c.Query("regex") == "1",
+		CaseSensitive: c.Query("case_sensitive") == "true" || c.Query("case_sensitive") == "1",
+		PathPrefix:    c.Query("path"),
+		MaxResults:    maxResults,
+	}
+}
+
+// Search 在全部文件中搜索字面量或正则（跳过二进制和超过大小上限的文件）
+// GET /api/content-worker/search?q=def%20main&regex=false
+func (h *ContentWorkerFilesHandler) Search(c *gin.Context) {
+	result, err := h.fs.Search(c.Request.Context(), fileSearchQuery(c))
+	if errors.Is(err, core.ErrInvalidSearchQuery) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "搜索内容为空、过长或正则表达式无效")
+		return
+	}
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		return
+	}
+	core.Success(c, result)
 }
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	core "seo-generator/api/internal/service"
+	"seo-generator/api/pkg/config"
+)
+
+// TestContentWorkerFiles_AuditClientIP 审计中的客户端 IP 只在受信代理后采用转发头，伪造的请求头不能写入审计
+func TestContentWorkerFiles_AuditClientIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		wantIP  string
+	}{
+		{"直连", "192.0.2.1:1234", nil, "192.0.2.1"},
+		{"伪造 X-Forwarded-For", "192.0.2.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.7"}, "192.0.2.1"},
+		{"伪造 X-Real-IP", "192.0.2.1:1234", map[string]string{"X-Real-IP": "198.51.100.7"}, "192.0.2.1"},
+		{"受信代理转发", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "192.0.2.3"}, "192.0.2.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			if err := os.WriteFile(filepath.Join(root, "main.py"), []byte("print(1)\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO content_worker_file_audit")).
+				WithArgs(core.FileOpSave, "/main.py", "", int64(9), "admin", tt.wantIP, "", sqlmock.AnyArg()).
+				WillReturnResult(driver.RowsAffected(1))
+
+			confined := core.NewConfinedFS(sqlx.NewDb(db, "mysql"), config.WorkerFilesConfig{Root: root})
+			allowlist := core.NewIPAllowlist(nil, config.IPAllowlistConfig{TrustedProxies: []string{"10.0.0.1/32"}})
+			h := NewContentWorkerFilesHandler(confined, nil, allowlist)
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.PUT("/files/*path", func(c *gin.Context) { c.Set("username", "admin") }, h.Save)
+
+			req := httptest.NewRequest(http.MethodPut, "/files/main.py", strings.NewReader(`{"content":"print(2)\n"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	core "seo-generator/api/internal/service"
+	"seo-generator/api/pkg/config"
+)
+
+const (
+	authCookieName = "admin_token"
+	csrfCookieName = "csrf_token"
+	csrfHeaderName = "X-CSRF-Token"
+)
+
+// authCookie Cookie 会话配置，SetupRouter 中设置；Enabled 为 false 时只接受 Authorization 请求头
+var authCookie config.AuthCookieConfig
+
+// requestToken 取请求中的 JWT：优先 Authorization 请求头，其次 Cookie
+// 返回 Token、是否来自 Cookie，以及请求头格式错误时的提示
+func requestToken(c *gin.Context) (token string, fromCookie bool, errMsg string) {
+	if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+			return "", false, "认证格式错误"
+		}
+		return parts[1], false, ""
+	}
+	if authCookie.Enabled {
+		if v, err := c.Cookie(authCookieName); err == nil && v != "" {
+			return v, true, ""
+		}
+	}
+	return "", false, "缺少认证信息"
+}
+
+// csrfValid 双重提交校验：非安全方法必须携带与 csrf_token Cookie 相同的 X-CSRF-Token 请求头
+// 只对 Cookie 认证的请求生效，Authorization 请求头无法被跨站请求自动携带
+func csrfValid(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	cookie, err := c.Cookie(csrfCookieName)
+	header := c.GetHeader(csrfHeaderName)
+	if err != nil || cookie == "" || header == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
+
+// newCSRFToken 生成随机 CSRF Token
+func newCSRFToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// cookieSameSite 解析 same_site 配置
+func cookieSameSite() http.SameSite {
+	switch strings.ToLower(authCookie.SameSite) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteStrictMode
+	}
+}
+
+// setAuthCookies 登录成功后下发 Token Cookie（HttpOnly）和 CSRF Cookie（前端可读），返回 CSRF Token
+func setAuthCookies(c *gin.Context, token string, maxAge int) string {
+	csrf := newCSRFToken()
+	c.SetSameSite(cookieSameSite())
+	c.SetCookie(authCookieName, token, maxAge, "/", authCookie.Domain, authCookie.Secure, true)
+	c.SetSameSite(cookieSameSite())
+	c.SetCookie(csrfCookieName, csrf, maxAge, "/", authCookie.Domain, authCookie.Secure, false)
+	return csrf
+}
+
+// clearAuthCookies 退出登录时清除 Cookie
+func clearAuthCookies(c *gin.Context) {
+	c.SetSameSite(cookieSameSite())
+	c.SetCookie(authCookieName, "", -1, "/", authCookie.Domain, authCookie.Secure, true)
+	c.SetSameSite(cookieSameSite())
+	c.SetCookie(csrfCookieName, "", -1, "/", authCookie.Domain, authCookie.Secure, false)
+}
+
+// CSRFToken 获取当前 CSRF Token（页面刷新后前端从此处取回，或在 Cookie 丢失时重新下发）
+// GET /api/auth/csrf
+func CSRFToken(c *gin.Context) {
+	if !authCookie.Enabled {
+		core.Success(c, gin.H{"enabled": false})
+		return
+	}
+	token, err := c.Cookie(csrfCookieName)
+	if err != nil || token == "" {
+		token = newCSRFToken()
+		c.SetSameSite(cookieSameSite())
+		c.SetCookie(csrfCookieName, token, 0, "/", authCookie.Domain, authCookie.Secure, false)
+	}
+	core.Success(c, gin.H{"enabled": true, "csrf_token": token, "header": csrfHeaderName})
+}
+
+// CORSMiddleware 按配置设置跨域响应头
+// allowed_origins 含 "*" 时返回 *（此时不允许携带凭据）；否则仅对白名单中的 Origin 回显
+func CORSMiddleware(cfg config.CORSConfig) gin.HandlerFunc {
+	anyOrigin := false
+	origins := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			anyOrigin = true
+			continue
+		}
+		origins[strings.TrimRight(strings.ToLower(o), "/")] = true
+	}
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := ""
+	if cfg.MaxAge > 0 {
+		maxAge = strconv.Itoa(cfg.MaxAge)
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin != "" {
+			switch {
+			case origins[strings.ToLower(origin)]:
+				c.Header("Access-Control-Allow-Origin", origin)
+				c.Header("Vary", "Origin")
+				if cfg.AllowCredentials {
+					c.Header("Access-Control-Allow-Credentials", "true")
+				}
+			case anyOrigin:
+				c.Header("Access-Control-Allow-Origin", "*")
+			}
+			c.Header("Access-Control-Allow-Methods", methods)
+			c.Header("Access-Control-Allow-Headers", headers)
+		}
+
+		if c.Request.Method == "OPTIONS" {
+			if maxAge != "" {
+				c.Header("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	core "seo-generator/api/internal/service"
+	"seo-generator/api/pkg/config"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	cfg := config.CORSConfig{
+		AllowedOrigins:   []string{"https://admin.example.com/"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Authorization", "X-CSRF-Token"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	}
+	tests := []struct {
+		name            string
+		cfg             config.CORSConfig
+		method          string
+		origin          string
+		wantStatus      int
+		wantOrigin      string
+		wantCredentials string
+		wantMaxAge      string
+	}{
+		{"白名单来源回显", cfg, "GET", "https://admin.example.com", http.StatusOK, "https://admin.example.com", "true", ""},
+		{"来源大小写不敏感", cfg, "GET", "https://ADMIN.example.com", http.StatusOK, "https://ADMIN.example.com", "true", ""},
+		{"非白名单来源", cfg, "GET", "https://evil.example.com", http.StatusOK, "", "", ""},
+		{"无 Origin", cfg, "GET", "", http.StatusOK, "", "", ""},
+		{"预检请求", cfg, "OPTIONS", "https://admin.example.com", http.StatusNoContent, "https://admin.example.com", "true", "600"},
+		{"任意来源不携带凭据", config.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}, "GET", "https://evil.example.com", http.StatusOK, "*", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(CORSMiddleware(tt.cfg))
+			r.Any("/api/sites", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tt.method, "/api/sites", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+			}
+			if got := w.Header().Get("Access-Control-Max-Age"); got != tt.wantMaxAge {
+				t.Errorf("Max-Age = %q, want %q", got, tt.wantMaxAge)
+			}
+		})
+	}
+}
+
+// TestAuthMiddleware_CSRF Cookie 认证的非安全请求必须携带与 Cookie 一致的 CSRF 请求头，Authorization 请求头认证不受影响
+func TestAuthMiddleware_CSRF(t *testing.T) {
+	const secret = "test-secret"
+	saved := authCookie
+	authCookie = config.AuthCookieConfig{Enabled: true}
+	t.Cleanup(func() { authCookie = saved })
+
+	token, err := core.CreateAccessToken(map[string]interface{}{"sub": "admin", "admin_id": 1}, secret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Any("/api/sites", AuthMiddleware(secret), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name   string
+		method string
+		header bool   // 使用 Authorization 请求头（否则使用 Cookie）
+		csrf   string // csrf_token Cookie
+		sent   string // X-CSRF-Token 请求头
+		want   int
+	}{
+		{"Cookie 认证的 GET 不校验", "GET", false, "", "", http.StatusOK},
+		{"Cookie 认证缺少 CSRF 请求头", "POST", false, "abc", "", http.StatusForbidden},
+		{"Cookie 认证 CSRF 不一致", "POST", false, "abc", "xyz", http.StatusForbidden},
+		{"Cookie 认证缺少 CSRF Cookie", "DELETE", false, "", "abc", http.StatusForbidden},
+		{"Cookie 认证 CSRF 一致", "POST", false, "abc", "abc", http.StatusOK},
+		{"请求头认证不校验 CSRF", "POST", true, "", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/sites", nil)
+			if tt.header {
+				req.Header.Set("Authorization", "Bearer "+token)
+			} else {
+				req.AddCookie(&http.Cookie{Name: authCookieName, Value: token})
+			}
+			if tt.csrf != "" {
+				req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: tt.csrf})
+			}
+			if tt.sent != "" {
+				req.Header.Set(csrfHeaderName, tt.sent)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d, body = %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+
+	// Cookie 认证未启用时不读取 Cookie 中的 Token
+	authCookie = config.AuthCookieConfig{}
+	req := httptest.NewRequest("GET", "/api/sites", nil)
+	req.AddCookie(&http.Cookie{Name: authCookieName, Value: token})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("cookie disabled: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
@@ -1,6 +1,14 @@
 package api
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/rs/zerolog/log"
@@ -10,79 +18,120 @@ import (
 
 // DashboardHandler 仪表盘 handler
 type DashboardHandler struct {
-	db      *sqlx.DB
-	monitor *core.Monitor
+	db          *sqlx.DB
+	monitor     *core.Monitor
+	clickhouse  *core.ClickHouseSink
+	poolManager *core.PoolManager
+	systemStats *core.SystemStatsCollector
+
+	overviewMu    sync.Mutex
+	overview      gin.H
+	overviewAt    time.Time
+	overviewGroup sync.Mutex // 缓存过期时只让一个请求重新汇总
 }
 
-// NewDashboardHandler 创建 DashboardHandler
-func NewDashboardHandler(db *sqlx.DB, monitor *core.Monitor) *DashboardHandler {
-	return &DashboardHandler{db: db, monitor: monitor}
+// NewDashboardHandler 创建 DashboardHandler（poolManager、systemStats 可为 nil，对应的概览分区返回错误）
+func NewDashboardHandler(db *sqlx.DB, monitor *core.Monitor, clickhouse *core.ClickHouseSink,
+	poolManager *core.PoolManager, systemStats *core.SystemStatsCollector) *DashboardHandler {
+	return &DashboardHandler{
+		db:          db,
+		monitor:     monitor,
+		clickhouse:  clickhouse,
+		poolManager: poolManager,
+		systemStats: systemStats,
+	}
 }
 
 // Stats 获取仪表盘统计数据
 // GET /api/dashboard/stats
 func (h *DashboardHandler) Stats(c *gin.Context) {
+	core.Success(c, h.collectStats(c.Request.Context()))
+}
+
+// collectStats 统计各类数据条数（单项查询失败记日志并返回 0）
+func (h *DashboardHandler) collectStats(ctx context.Context) map[string]interface{} {
 	stats := make(map[string]interface{})
 
 	if h.db == nil {
-		core.Success(c, stats)
-		return
+		return stats
 	}
 
 	// 站点数量
 	var siteCount int
-	if err := h.db.Get(&siteCount, "SELECT COUNT(*) FROM sites WHERE status = 1"); err != nil {
+	if err := h.db.GetContext(ctx, &siteCount, "SELECT COUNT(*) FROM sites WHERE status = 1"); err != nil {
 		log.Warn().Err(err).Msg("Failed to count sites")
 	}
 	stats["site_count"] = siteCount
 
 	// 关键词数量
 	var keywordCount int
-	if err := h.db.Get(&keywordCount, "SELECT COUNT(*) FROM keywords WHERE status = 1"); err != nil {
+	if err := h.db.GetContext(ctx, &keywordCount, "SELECT COUNT(*) FROM keywords WHERE status = 1"); err != nil {
 		log.Warn().Err(err).Msg("Failed to count keywords")
 	}
 	stats["keyword_count"] = keywordCount
 
 	// 图片数量
 	var imageCount int
-	if err := h.db.Get(&imageCount, "SELECT COUNT(*) FROM images WHERE status = 1"); err != nil {
+	if err := h.db.GetContext(ctx, &imageCount, "SELECT COUNT(*) FROM images WHERE status = 1"); err != nil {
 		log.Warn().Err(err).Msg("Failed to count images")
 	}
 	stats["image_count"] = imageCount
 
 	// 文章数量
 	var articleCount int
-	if err := h.db.Get(&articleCount, "SELECT COUNT(*) FROM original_articles WHERE status = 1"); err != nil {
+	if err := h.db.GetContext(ctx, &articleCount, "SELECT COUNT(*) FROM original_articles WHERE status = 1"); err != nil {
 		log.Warn().Err(err).Msg("Failed to count articles")
 	}
 	stats["article_count"] = articleCount
 
 	// 模板数量
 	var templateCount int
-	if err := h.db.Get(&templateCount, "SELECT COUNT(*) FROM templates"); err != nil {
+	if err := h.db.GetContext(ctx, &templateCount, "SELECT COUNT(*) FROM templates"); err != nil {
 		log.Warn().Err(err).Msg("Failed to count templates")
 	}
 	stats["template_count"] = templateCount
 
-	core.Success(c, stats)
+	return stats
 }
 
 // SpiderVisits 获取蜘蛛访问统计
 // GET /api/dashboard/spider-visits
 func (h *DashboardHandler) SpiderVisits(c *gin.Context) {
+	// 返回前端期望的格式: { total, by_type }
+	core.Success(c, h.collectSpiderVisits(c.Request.Context()))
+}
+
+// collectSpiderVisits 按蜘蛛类型统计访问次数
+func (h *DashboardHandler) collectSpiderVisits(ctx context.Context) gin.H {
 	var total int
 	byType := make(map[string]int)
 
+	// 启用 ClickHouse 时优先查询 ClickHouse
+	if h.clickhouse != nil {
+		rows, err := h.clickhouse.SpiderCountsByType(ctx)
+		if err == nil {
+			for _, r := range rows {
+				byType[r.SpiderType] = r.Count
+				total += r.Count
+			}
+			return gin.H{
+				"total":   total,
+				"by_type": byType,
+			}
+		}
+		log.Warn().Err(err).Msg("ClickHouse spider visits query failed, falling back to MySQL")
+	}
+
 	if h.db != nil {
 		// 总访问次数
-		h.db.Get(&total, "SELECT COUNT(*) FROM spider_logs")
+		h.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM spider_logs")
 
 		// 按蜘蛛类型统计
 		var typeStats []struct {
 			SpiderType string `db:"spider_type"`
 			Count      int    `db:"count"`
 		}
-		err := h.db.Select(&typeStats, `
+		err := h.db.SelectContext(ctx, &typeStats, `
 			SELECT spider_type, COUNT(*) as count
 			FROM spider_logs
 			GROUP BY spider_type
@@ -95,29 +144,319 @@ func (h *DashboardHandler) SpiderVisits(c *gin.Context) {
 		}
 	}
 
-	// 返回前端期望的格式: { total, by_type }
-	core.Success(c, gin.H{
+	return gin.H{
 		"total":   total,
 		"by_type": byType,
-	})
+	}
 }
 
 // CacheStats 获取缓存统计
 // GET /api/dashboard/cache-stats
 func (h *DashboardHandler) CacheStats(c *gin.Context) {
+	core.Success(c, h.collectCacheStats())
+}
+
+// collectCacheStats 当前缓存命中统计（未启用监控时全部为 0）
+func (h *DashboardHandler) collectCacheStats() gin.H {
 	if h.monitor != nil {
 		snapshot := h.monitor.GetCurrentSnapshot()
-		core.Success(c, gin.H{
+		return gin.H{
 			"cache_hits":   snapshot.CacheHits,
 			"cache_misses": snapshot.CacheMisses,
 			"hit_rate":     snapshot.CacheHitRate,
-		})
-		return
+		}
 	}
 
-	core.Success(c, gin.H{
+	return gin.H{
 		"cache_hits":   0,
 		"cache_misses": 0,
 		"hit_rate":     0.0,
+	}
+}
+
+// overviewTTL 概览结果的服务端缓存时间（多个管理页面同时打开时避免重复查询）
+const overviewTTL = 5 * time.Second
+
+// overviewSectionTimeout 单个分区的超时，超时的分区记入 errors，不影响其他分区
+const overviewSectionTimeout = 3 * time.Second
+
+// overviewSection 概览中的一个分区
+type overviewSection struct {
+	name    string
+	collect func(ctx context.Context) (interface{}, error)
+}
+
+// overviewSections 概览包含的分区
+func (h *DashboardHandler) overviewSections() []overviewSection {
+	return []overviewSection{
+		{"stats", func(ctx context.Context) (interface{}, error) {
+			if h.db == nil {
+				return nil, fmt.Errorf("database not configured")
+			}
+			return h.collectStats(ctx), nil
+		}},
+		{"cache", func(ctx context.Context) (interface{}, error) {
+			return h.collectCacheStats(), nil
+		}},
+		{"pool", func(ctx context.Context) (interface{}, error) {
+			if h.poolManager == nil {
+				return nil, fmt.Errorf("pool manager not initialized")
+			}
+			return h.poolManager.GetStats(), nil
+		}},
+		{"spider", func(ctx context.Context) (interface{}, error) {
+			return h.collectSpiderVisits(ctx), nil
+		}},
+		{"alerts", func(ctx context.Context) (interface{}, error) {
+			if h.monitor == nil {
+				return nil, fmt.Errorf("monitor not initialized")
+			}
+			alerts := h.monitor.GetUnresolvedAlerts()
+			if alerts == nil {
+				alerts = []core.Alert{}
+			}
+			return gin.H{"unresolved": alerts, "count": len(alerts)}, nil
+		}},
+		{"system", func(ctx context.Context) (interface{}, error) {
+			if h.systemStats == nil {
+				return nil, fmt.Errorf("system stats collector not initialized")
+			}
+			return h.systemStats.Collect()
+		}},
+	}
+}
+
+// Overview 一次返回仪表盘所需的全部分区，减少前端并发请求数
+// 各分区并发采集、互相隔离：失败、超时或 panic 的分区值为 null，原因记入 errors；
+// 结果缓存 5 秒，refresh=true 时跳过缓存
+// GET /api/dashboard/overview
+func (h *DashboardHandler) Overview(c *gin.Context) {
+	refresh := c.Query("refresh") == "true"
+	if !refresh {
+		if cached := h.cachedOverview(); cached != nil {
+			core.Success(c, cached)
+			return
+		}
+	}
+
+	h.overviewGroup.Lock()
+	defer h.overviewGroup.Unlock()
+	// 等锁期间其他请求可能已经刷新了缓存
+	if !refresh {
+		if cached := h.cachedOverview(); cached != nil {
+			core.Success(c, cached)
+			return
+		}
+	}
+
+	result := h.collectOverview(c.Request.Context())
+	h.overviewMu.Lock()
+	h.overview = result
+	h.overviewAt = time.Now()
+	h.overviewMu.Unlock()
+
+	core.Success(c, result)
+}
+
+// cachedOverview 返回未过期的缓存结果
+func (h *DashboardHandler) cachedOverview() gin.H {
+	h.overviewMu.Lock()
+	defer h.overviewMu.Unlock()
+	if h.overview != nil && time.Since(h.overviewAt) < overviewTTL {
+		return h.overview
+	}
+	return nil
+}
+
+// collectOverview 并发采集所有分区
+func (h *DashboardHandler) collectOverview(ctx context.Context) gin.H {
+	sections := h.overviewSections()
+	values := make([]interface{}, len(sections))
+	errs := make([]error, len(sections))
+
+	var wg sync.WaitGroup
+	for i, section := range sections {
+		wg.Add(1)
+		go func(i int, section overviewSection) {
+			defer wg.Done()
+			values[i], errs[i] = collectOverviewSection(ctx, section)
+		}(i, section)
+	}
+	wg.Wait()
+
+	result := gin.H{}
+	sectionErrors := map[string]string{}
+	for i, section := range sections {
+		result[section.name] = values[i]
+		if errs[i] != nil {
+			sectionErrors[section.name] = errs[i].Error()
+			log.Warn().Err(errs[i]).Str("section", section.name).Msg("Dashboard overview section failed")
+		}
+	}
+	result["errors"] = sectionErrors
+	result["generated_at"] = time.Now()
+	return result
+}
+
+// collectOverviewSection 带超时和 panic 恢复地采集单个分区
+// 超时后立即返回错误，采集协程在后台结束（结果丢弃）
+func collectOverviewSection(ctx context.Context, section overviewSection) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, overviewSectionTimeout)
+	defer cancel()
+
+	type sectionResult struct {
+		value interface{}
+		err   error
+	}
+	done := make(chan sectionResult, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- sectionResult{err: fmt.Errorf("panic: %v", r)}
+			}
+		}()
+		value, err := section.collect(ctx)
+		done <- sectionResult{value: value, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return r.value, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out after %s", overviewSectionTimeout)
+	}
+}
+
+// cacheSeriesPoint 域名缓存时间序列数据点
+type cacheSeriesPoint struct {
+	Domain    string    `db:"domain" json:"domain"`
+	HourStart time.Time `db:"hour_start" json:"time"`
+	Hits      int64     `db:"hits" json:"hits"`
+	Misses    int64     `db:"misses" json:"misses"`
+	Bytes     int64     `db:"bytes" json:"bytes"`
+	HitRate   float64   `db:"-" json:"hit_rate"`
+}
+
+// maxCacheSeriesRange 单次查询最大时间跨度
+const maxCacheSeriesRange = 90 * 24 * time.Hour
+
+// CacheStatsSeries 获取按小时的域名缓存命中时间序列
+// 参数: domain（可选，逗号分隔多个）、start/end（Unix 秒、"2006-01-02 15:04:05" 或 "2006-01-02"，默认最近 24 小时）
+// format=grafana 时返回 [{target, datapoints: [[hit_rate, ts_ms]]}]，可直接用于 Grafana JSON 数据源
+// GET /api/dashboard/cache-stats/series
+func (h *DashboardHandler) CacheStatsSeries(c *gin.Context) {
+	end := time.Now()
+	if v := c.Query("end"); v != "" {
+		t, ok := parseSeriesTime(v)
+		if !ok {
+			core.FailWithMessage(c, core.ErrInvalidParam, "无效的 end 参数")
+			return
+		}
+		end = t
+	}
+	start := end.Add(-24 * time.Hour)
+	if v := c.Query("start"); v != "" {
+		t, ok := parseSeriesTime(v)
+		if !ok {
+			core.FailWithMessage(c, core.ErrInvalidParam, "无效的 start 参数")
+			return
+		}
+		start = t
+	}
+	if !start.Before(end) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "start 必须早于 end")
+		return
+	}
+	if end.Sub(start) > maxCacheSeriesRange {
+		core.FailWithMessage(c, core.ErrInvalidParam, "时间范围不能超过 90 天")
+		return
+	}
+
+	points := []cacheSeriesPoint{}
+	if h.db != nil {
+		where := "hour_start >= ? AND hour_start < ?"
+		args := []interface{}{start.Truncate(time.Hour), end}
+
+		if domainParam := strings.TrimSpace(c.Query("domain")); domainParam != "" {
+			domains := strings.Split(domainParam, ",")
+			placeholders := make([]string, 0, len(domains))
+			for _, d := range domains {
+				if d = strings.TrimSpace(d); d != "" {
+					placeholders = append(placeholders, "?")
+					args = append(args, d)
+				}
+			}
+			if len(placeholders) > 0 {
+				where += " AND domain IN (" + strings.Join(placeholders, ",") + ")"
+			}
+		}
+
+		if err := h.db.SelectContext(c.Request.Context(), &points, `
+			SELECT domain, hour_start, hits, misses, bytes
+			FROM cache_stats_hourly
+			WHERE `+where+`
+			ORDER BY domain ASC, hour_start ASC
+		`, args...); err != nil {
+			core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+			return
+		}
+	}
+
+	for i := range points {
+		if total := points[i].Hits + points[i].Misses; total > 0 {
+			points[i].HitRate = float64(points[i].Hits) / float64(total)
+		}
+	}
+
+	if c.Query("format") == "grafana" {
+		c.JSON(http.StatusOK, toGrafanaSeries(points))
+		return
+	}
+
+	core.Success(c, gin.H{
+		"start": start,
+		"end":   end,
+		"items": points,
 	})
 }
+
+// grafanaSeries Grafana JSON 数据源时间序列格式
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// toGrafanaSeries 按域名分组转换为 Grafana 格式（值为命中率）
+func toGrafanaSeries(points []cacheSeriesPoint) []grafanaSeries {
+	series := []grafanaSeries{}
+	index := make(map[string]int)
+	for _, p := range points {
+		i, ok := index[p.Domain]
+		if !ok {
+			i = len(series)
+			index[p.Domain] = i
+			series = append(series, grafanaSeries{Target: p.Domain, Datapoints: [][2]float64{}})
+		}
+		series[i].Datapoints = append(series[i].Datapoints, [2]float64{p.HitRate, float64(p.HourStart.UnixMilli())})
+	}
+	return series
+}
+
+// parseSeriesTime 解析时间参数
+func parseSeriesTime(v string) (time.Time, bool) {
+	if ts, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(ts, 0), true
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return t, true
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
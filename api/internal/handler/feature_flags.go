@@ -0,0 +1,136 @@
+package api
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	core "seo-generator/api/internal/service"
+)
+
+// FeatureFlagsHandler 功能开关管理 handler
+type FeatureFlagsHandler struct {
+	flags *core.FeatureFlags
+}
+
+// NewFeatureFlagsHandler 创建 FeatureFlagsHandler
+func NewFeatureFlagsHandler(flags *core.FeatureFlags) *FeatureFlagsHandler {
+	return &FeatureFlagsHandler{flags: flags}
+}
+
+// flagKeyPattern 开关标识只允许小写字母、数字、下划线和点
+var flagKeyPattern = regexp.MustCompile(`^[a-z0-9_.]{1,100}$`)
+
+// FeatureFlagRequest 创建/更新功能开关请求
+type FeatureFlagRequest struct {
+	Key                string       `json:"key"`
+	Description        string       `json:"description"`
+	Enabled            bool         `json:"enabled"`
+	RolloutPercent     *int         `json:"rollout_percent"`      // 默认 100
+	SiteGroupOverrides map[int]bool `json:"site_group_overrides"` // 站群ID -> 强制开/关
+}
+
+// List 获取全部功能开关
+// GET /api/feature-flags
+func (h *FeatureFlagsHandler) List(c *gin.Context) {
+	core.Success(c, gin.H{
+		"flags": h.flags.List(),
+		"builtin": []string{
+			core.FlagDeterministicRender,
+			core.FlagNewEncoder,
+			core.FlagStaleWhileRevalidate,
+			core.FlagAutoTDK,
+			core.FlagCSSObfuscation,
+		},
+	})
+}
+
+// Create 创建功能开关（已存在时覆盖）
+// POST /api/feature-flags
+func (h *FeatureFlagsHandler) Create(c *gin.Context) {
+	var req FeatureFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	h.save(c, req)
+}
+
+// Update 更新功能开关
+// PUT /api/feature-flags/:key
+func (h *FeatureFlagsHandler) Update(c *gin.Context) {
+	var req FeatureFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	req.Key = c.Param("key")
+	if _, ok := h.flags.Get(req.Key); !ok {
+		core.FailWithMessage(c, core.ErrNotFound, "功能开关不存在")
+		return
+	}
+	h.save(c, req)
+}
+
+func (h *FeatureFlagsHandler) save(c *gin.Context, req FeatureFlagRequest) {
+	if !flagKeyPattern.MatchString(req.Key) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "开关标识只允许小写字母、数字、下划线和点")
+		return
+	}
+	rollout := 100
+	if req.RolloutPercent != nil {
+		rollout = *req.RolloutPercent
+	}
+	if rollout < 0 || rollout > 100 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "灰度百分比需在 0-100 之间")
+		return
+	}
+
+	flag := &core.FeatureFlag{
+		Key:                req.Key,
+		Description:        req.Description,
+		Enabled:            req.Enabled,
+		RolloutPercent:     rollout,
+		SiteGroupOverrides: req.SiteGroupOverrides,
+	}
+	if err := h.flags.Save(c.Request.Context(), flag, c.GetString("username")); err != nil {
+		core.FailWithMessage(c, core.ErrDBUpdate, err.Error())
+		return
+	}
+
+	saved, _ := h.flags.Get(req.Key)
+	core.Success(c, saved)
+}
+
+// Delete 删除功能开关
+// DELETE /api/feature-flags/:key
+func (h *FeatureFlagsHandler) Delete(c *gin.Context) {
+	if err := h.flags.Delete(c.Request.Context(), c.Param("key")); err != nil {
+		if errors.Is(err, core.ErrFeatureFlagNotFound) {
+			core.FailWithMessage(c, core.ErrNotFound, "功能开关不存在")
+			return
+		}
+		core.FailWithMessage(c, core.ErrDBDelete, err.Error())
+		return
+	}
+	core.Success(c, nil)
+}
+
+// Evaluate 计算开关对指定站群和域名是否生效
+// GET /api/feature-flags/:key/evaluate?site_group_id=1&unit=example.com
+func (h *FeatureFlagsHandler) Evaluate(c *gin.Context) {
+	key := c.Param("key")
+	siteGroupID, _ := strconv.Atoi(c.DefaultQuery("site_group_id", "0"))
+	unit := c.Query("unit")
+
+	_, exists := h.flags.Get(key)
+	core.Success(c, gin.H{
+		"key":           key,
+		"exists":        exists,
+		"site_group_id": siteGroupID,
+		"unit":          unit,
+		"enabled":       h.flags.Enabled(key, siteGroupID, unit),
+	})
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// feederTokenPrefix 推送方 Token 前缀
+const feederTokenPrefix = "feed_"
+
+// FeederToken 数据推送方的 API Token，可为每个推送方设置不同的批量导入冲突策略
+// 只能调用文章添加接口（FeederAuthMiddleware），不能代替全局 API Token 访问其他接口
+// 与全局 API Token 一样受 api_token_enabled 开关控制，但不依赖全局 API Token 是否配置
+// 数据库只保存 SHA-256，明文仅在创建时返回一次
+type FeederToken struct {
+	ID               int64      `json:"id" db:"id"`
+	Name             string     `json:"name" db:"name"`
+	TokenPrefix      string     `json:"token_prefix" db:"token_prefix"`
+	ConflictStrategy string     `json:"conflict_strategy" db:"conflict_strategy"`
+	ConflictKey      string     `json:"conflict_key" db:"conflict_key"`
+	Enabled          bool       `json:"enabled" db:"enabled"`
+	LastUsedAt       *time.Time `json:"last_used_at" db:"last_used_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+}
+
+// FeederTokenRequest 创建 / 更新推送方 Token 请求
+type FeederTokenRequest struct {
+	Name             string `json:"name" binding:"required"`
+	ConflictStrategy string `json:"conflict_strategy"` // skip / overwrite / version
+	ConflictKey      string `json:"conflict_key"`      // title / content_hash / source_url
+	Enabled          *bool  `json:"enabled"`
+}
+
+// lookupFeederToken 查找启用的推送方 Token，不存在时返回 nil，并更新最近使用时间
+func lookupFeederToken(db *sqlx.DB, token string) *FeederToken {
+	var feeder FeederToken
+	if err := db.Get(&feeder,
+		`SELECT id, name, token_prefix, conflict_strategy, conflict_key, enabled, last_used_at, created_at
+		 FROM api_feeder_tokens WHERE token_hash = ? AND enabled = 1`, hashAPIToken(token)); err != nil {
+		if err != sql.ErrNoRows {
+			log.Warn().Err(err).Msg("Failed to look up feeder token")
+		}
+		return nil
+	}
+	go func(id int64) {
+		if _, err := db.Exec("UPDATE api_feeder_tokens SET last_used_at = NOW() WHERE id = ?", id); err != nil {
+			log.Debug().Err(err).Int64("feeder_id", id).Msg("Failed to update feeder token last_used_at")
+		}
+	}(feeder.ID)
+	return &feeder
+}
+
+// feederFromContext 当前请求使用的推送方 Token（JWT 或全局 API Token 认证时为 nil）
+func feederFromContext(c *gin.Context) *FeederToken {
+	if v, ok := c.Get("feeder"); ok {
+		if feeder, ok := v.(*FeederToken); ok {
+			return feeder
+		}
+	}
+	return nil
+}
+
+// FeederTokensHandler 推送方 Token 管理 handler
+type FeederTokensHandler struct {
+	db *sqlx.DB
+}
+
+// NewFeederTokensHandler 创建 FeederTokensHandler
+func NewFeederTokensHandler(db *sqlx.DB) *FeederTokensHandler {
+	return &FeederTokensHandler{db: db}
+}
+
+// List 推送方 Token 列表（不含明文）
+// GET /api/settings/feeder-tokens
+func (h *FeederTokensHandler) List(c *gin.Context) {
+	items := []FeederToken{}
+	if err := h.db.Select(&items,
+		`SELECT id, name, token_prefix, conflict_strategy, conflict_key, enabled, last_used_at, created_at
+		 FROM api_feeder_tokens ORDER BY id`); err != nil {
+		core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+		return
+	}
+	core.Success(c, items)
+}
+
+// Create 创建推送方 Token（随机生成，明文只在本次响应中返回）
+// POST /api/settings/feeder-tokens
+func (h *FeederTokensHandler) Create(c *gin.Context) {
+	var req FeederTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if err := core.ValidConflictStrategy(req.ConflictStrategy, req.ConflictKey); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+		return
+	}
+	enabled := req.Enabled == nil || *req.Enabled
+
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	token := feederTokenPrefix + hex.EncodeToString(buf)
+
+	result, err := h.db.Exec(
+		"INSERT INTO api_feeder_tokens (name, token_hash, token_prefix, conflict_strategy, conflict_key, enabled) VALUES (?, ?, ?, ?, ?, ?)",
+		req.Name, hashAPIToken(token), token[:len(feederTokenPrefix)+8], req.ConflictStrategy, req.ConflictKey, enabled)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrDBUpdate, err.Error())
+		return
+	}
+	id, _ := result.LastInsertId()
+	core.Success(c, gin.H{"success": true, "id": id, "token": token})
+}
+
+// Update 更新推送方名称、冲突策略和启用状态
+// PUT /api/settings/feeder-tokens/:id
+func (h *FeederTokensHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的 ID")
+		return
+	}
+	var req FeederTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if err := core.ValidConflictStrategy(req.ConflictStrategy, req.ConflictKey); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+		return
+	}
+	enabled := req.Enabled == nil || *req.Enabled
+
+	result, err := h.db.Exec(
+		"UPDATE api_feeder_tokens SET name = ?, conflict_strategy = ?, conflict_key = ?, enabled = ? WHERE id = ?",
+		req.Name, req.ConflictStrategy, req.ConflictKey, enabled, id)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrDBUpdate, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		var exists int
+		if h.db.Get(&exists, "SELECT 1 FROM api_feeder_tokens WHERE id = ?", id) != nil {
+			core.FailWithMessage(c, core.ErrNotFound, "Token 不存在")
+			return
+		}
+	}
+	core.Success(c, gin.H{"success": true})
+}
+
+// Delete 删除推送方 Token
+// DELETE /api/settings/feeder-tokens/:id
+func (h *FeederTokensHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的 ID")
+		return
+	}
+	if _, err := h.db.Exec("DELETE FROM api_feeder_tokens WHERE id = ?", id); err != nil {
+		core.FailWithMessage(c, core.ErrDBUpdate, err.Error())
+		return
+	}
+	core.Success(c, gin.H{"success": true})
+}
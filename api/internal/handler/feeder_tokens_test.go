@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	settingEnabledQuery  = "SELECT setting_value FROM system_settings WHERE setting_key = 'api_token_enabled'"
+	settingTokenQuery    = "SELECT setting_value FROM system_settings WHERE setting_key = 'api_token'"
+	feederTokenLookup    = "FROM api_feeder_tokens WHERE token_hash = ? AND enabled = 1"
+	testFeederToken      = "feed_0123456789abcdef0123456789abcdef"
+	testGlobalToken      = "global-api-token"
+	feederTokenTestRoute = "/api/articles/batch"
+)
+
+// newFeederAuthTestRouter 挂载认证中间件的测试路由，响应中返回认证方式和推送方名称
+func newFeederAuthTestRouter(db *sqlx.DB, feeder bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	auth := DualAuthMiddleware("secret", db)
+	if feeder {
+		auth = FeederAuthMiddleware("secret", db)
+	}
+	r.POST(feederTokenTestRoute, auth, func(c *gin.Context) {
+		name := ""
+		if f := feederFromContext(c); f != nil {
+			name = f.Name
+		}
+		c.String(http.StatusOK, c.GetString("auth_type")+":"+name)
+	})
+	return r
+}
+
+func TestFeederAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		feeder      bool   // FeederAuthMiddleware（否则 DualAuthMiddleware）
+		enabled     string // api_token_enabled
+		global      string // 全局 API Token，空表示未配置
+		token       string
+		feederFound bool
+		wantStatus  int
+		wantBody    string
+	}{
+		{"未配置全局 Token 时推送方 Token 仍可用", true, "true", "", testFeederToken, true, http.StatusOK, "api_token:feeder-a"},
+		{"配置了全局 Token 时推送方 Token 可用", true, "true", testGlobalToken, testFeederToken, true, http.StatusOK, "api_token:feeder-a"},
+		{"全局 Token 不查推送方表", true, "true", testGlobalToken, testGlobalToken, false, http.StatusOK, "api_token:"},
+		{"未知 Token", true, "true", "", "feed_unknown", false, http.StatusUnauthorized, ""},
+		{"API Token 认证关闭时推送方 Token 也拒绝", true, "false", "", testFeederToken, false, http.StatusUnauthorized, ""},
+		{"其他接口不接受推送方 Token", false, "true", testGlobalToken, testFeederToken, false, http.StatusUnauthorized, ""},
+		{"其他接口未配置全局 Token", false, "true", "", testGlobalToken, false, http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sqlDB.Close()
+			db := sqlx.NewDb(sqlDB, "mysql")
+
+			mock.ExpectQuery(regexp.QuoteMeta(settingEnabledQuery)).
+				WillReturnRows(sqlmock.NewRows([]string{"setting_value"}).AddRow(tt.enabled))
+			if tt.enabled == "true" {
+				tokenRows := sqlmock.NewRows([]string{"setting_value"})
+				if tt.global != "" {
+					tokenRows.AddRow(tt.global)
+				}
+				mock.ExpectQuery(regexp.QuoteMeta(settingTokenQuery)).WillReturnRows(tokenRows)
+				if tt.feeder && tt.token != tt.global {
+					rows := sqlmock.NewRows([]string{"id", "name", "token_prefix", "conflict_strategy", "conflict_key", "enabled", "last_used_at", "created_at"})
+					if tt.feederFound {
+						rows.AddRow(1, "feeder-a", tt.token[:13], "overwrite", "title", true, nil, time.Now())
+					}
+					// 数据库只保存哈希，按 SHA-256 查找
+					mock.ExpectQuery(regexp.QuoteMeta(feederTokenLookup)).WithArgs(hashAPIToken(tt.token)).WillReturnRows(rows)
+				}
+			}
+
+			req := httptest.NewRequest(http.MethodPost, feederTokenTestRoute, nil)
+			req.Header.Set("X-API-Token", tt.token)
+			w := httptest.NewRecorder()
+			newFeederAuthTestRouter(db, tt.feeder).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("SQL 期望未满足: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// FreshnessHandler 内容保鲜 handler
+type FreshnessHandler struct {
+	freshness *core.Freshness
+}
+
+// NewFreshnessHandler 创建 FreshnessHandler
+func NewFreshnessHandler(freshness *core.Freshness) *FreshnessHandler {
+	return &FreshnessHandler{freshness: freshness}
+}
+
+// FreshnessPolicyRequest 站群保鲜策略
+type FreshnessPolicyRequest struct {
+	Enabled          bool    `json:"enabled"`
+	Percent          float64 `json:"percent"`            // 每次刷新的缓存页面比例（%），0-100
+	MaxPages         int     `json:"max_pages"`          // 每次最多刷新的页面数，0 不限制
+	MinIntervalHours int     `json:"min_interval_hours"` // 同一 URL 两次刷新的最短间隔（小时）
+}
+
+// FreshnessRunRequest 立即执行请求
+type FreshnessRunRequest struct {
+	SiteGroupID int `json:"site_group_id"` // 为 0 时处理所有启用的站群
+}
+
+// ListPolicies 所有站群保鲜策略
+// GET /api/freshness/policies
+func (h *FreshnessHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.freshness.ListPolicies(c.Request.Context())
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list freshness policies")
+		policies = []core.FreshnessPolicy{}
+	}
+	core.Success(c, policies)
+}
+
+// UpdatePolicy 设置站群保鲜策略
+// PUT /api/freshness/policies/:site_group_id
+func (h *FreshnessHandler) UpdatePolicy(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("site_group_id"))
+	if err != nil || groupID <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的站群 ID")
+		return
+	}
+	var req FreshnessPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if req.Percent <= 0 || req.Percent > 100 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "percent 必须在 (0, 100] 之间")
+		return
+	}
+	if req.MaxPages < 0 || req.MinIntervalHours < 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "max_pages 和 min_interval_hours 不能为负数")
+		return
+	}
+
+	policy := &core.FreshnessPolicy{
+		SiteGroupID:      groupID,
+		Enabled:          req.Enabled,
+		Percent:          req.Percent,
+		MaxPages:         req.MaxPages,
+		MinIntervalHours: req.MinIntervalHours,
+	}
+	if err := h.freshness.SavePolicy(c.Request.Context(), policy); err != nil {
+		log.Error().Err(err).Int("site_group_id", groupID).Msg("Failed to save freshness policy")
+		core.FailWithMessage(c, core.ErrInternalServer, "保存失败")
+		return
+	}
+	saved, err := h.freshness.GetPolicy(c.Request.Context(), groupID)
+	if err != nil || saved == nil {
+		saved = policy
+	}
+	core.Success(c, saved)
+}
+
+// DeletePolicy 删除站群保鲜策略（刷新记录保留）
+// DELETE /api/freshness/policies/:site_group_id
+func (h *FreshnessHandler) DeletePolicy(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("site_group_id"))
+	if err != nil || groupID <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的站群 ID")
+		return
+	}
+	deleted, err := h.freshness.DeletePolicy(c.Request.Context(), groupID)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInternalServer, "删除失败")
+		return
+	}
+	if !deleted {
+		core.FailWithMessage(c, core.ErrNotFound, "策略不存在")
+		return
+	}
+	core.Success(c, nil)
+}
+
+// Run 立即执行保鲜
+// POST /api/freshness/run
+// 指定站群时即使策略未启用也会执行（需已配置策略）
+func (h *FreshnessHandler) Run(c *gin.Context) {
+	var req FreshnessRunRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+			return
+		}
+	}
+	if req.SiteGroupID > 0 {
+		policy, err := h.freshness.GetPolicy(c.Request.Context(), req.SiteGroupID)
+		if err != nil {
+			core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+			return
+		}
+		if policy == nil {
+			core.FailWithMessage(c, core.ErrNotFound, "站群未配置保鲜策略")
+			return
+		}
+	}
+	jobID, err := h.freshness.Submit(c.Request.Context(), req.SiteGroupID)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		return
+	}
+	core.Success(c, gin.H{"job_id": jobID})
+}
+
+// History 刷新记录
+// GET /api/freshness/history?site_group_id=&domain=&path=&status=&page=1&page_size=20
+// 指定 domain + path 查看单个 URL 的刷新历史
+func (h *FreshnessHandler) History(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	groupID, _ := strconv.Atoi(c.Query("site_group_id"))
+	filter := core.FreshnessHistoryFilter{
+		SiteGroupID: groupID,
+		Domain:      c.Query("domain"),
+		Path:        c.Query("path"),
+		Status:      c.Query("status"),
+	}
+	items, total, err := h.freshness.History(c.Request.Context(), filter, page, pageSize)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list freshness history")
+		items = []core.FreshnessRefresh{}
+	}
+	core.SuccessPaged(c, items, total, page, pageSize)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	core "seo-generator/api/internal/service"
+)
+
+// ImageSigningHandler 图片 URL 签名（CDN 鉴权）handler
+type ImageSigningHandler struct {
+	signer *core.ImageURLSigner
+}
+
+// NewImageSigningHandler 创建 ImageSigningHandler，signer 为 nil 表示未启用签名
+func NewImageSigningHandler(signer *core.ImageURLSigner) *ImageSigningHandler {
+	return &ImageSigningHandler{signer: signer}
+}
+
+// ImageSignPreviewRequest 签名预览请求
+type ImageSignPreviewRequest struct {
+	GroupID int    `json:"group_id" binding:"required"`
+	URL     string `json:"url" binding:"required"`
+}
+
+// Get 签名配置（不含密钥）
+// GET /api/images/signing
+func (h *ImageSigningHandler) Get(c *gin.Context) {
+	core.Success(c, gin.H{
+		"enabled":            h.signer != nil,
+		"clock_skew_seconds": int(h.signer.ClockSkew() / time.Second),
+		"groups":             h.signer.Groups(),
+	})
+}
+
+// Preview 用当前配置签名 URL，或校验已签名的 URL（排查 CDN 鉴权失败）
+// POST /api/images/signing/preview
+func (h *ImageSigningHandler) Preview(c *gin.Context) {
+	var req ImageSignPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if !h.signer.Enabled(req.GroupID) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "该图片分组未配置签名（image_signing.groups）")
+		return
+	}
+
+	now := time.Now()
+	signed, err := h.signer.SignAt(req.GroupID, req.URL, now)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "URL 无效："+err.Error())
+		return
+	}
+
+	// 传入的 URL 已带签名时一并校验
+	verify := "ok"
+	if err := h.signer.Verify(req.GroupID, req.URL, now); err != nil {
+		verify = err.Error()
+	}
+	core.Success(c, gin.H{
+		"signed_url":   signed,
+		"input_verify": verify,
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/rs/zerolog/log"
 
+	database "seo-generator/api/internal/repository"
 	core "seo-generator/api/internal/service"
 )
 
@@ -21,14 +22,16 @@ type ImagesHandler struct {
 	db           *sqlx.DB
 	poolManager  *core.PoolManager
 	funcsManager *core.TemplateFuncsManager
+	jobManager   *core.JobManager
 }
 
 // NewImagesHandler 创建 ImagesHandler
-func NewImagesHandler(db *sqlx.DB, poolManager *core.PoolManager, funcsManager *core.TemplateFuncsManager) *ImagesHandler {
+func NewImagesHandler(db *sqlx.DB, poolManager *core.PoolManager, funcsManager *core.TemplateFuncsManager, jobManager *core.JobManager) *ImagesHandler {
 	return &ImagesHandler{
 		db:           db,
 		poolManager:  poolManager,
 		funcsManager: funcsManager,
+		jobManager:   jobManager,
 	}
 }
 
@@ -144,7 +147,7 @@ func (h *ImagesHandler) ListGroups(c *gin.Context) {
 	          FROM image_groups WHERE ` + where + ` ORDER BY is_default DESC, name`
 
 	var groups []ImageGroup
-	if err := h.db.Select(&groups, query, args...); err != nil {
+	if err := core.GetQueryCache().Select(c.Request.Context(), h.db, &groups, query, args...); err != nil {
 		log.Warn().Err(err).Msg("Failed to list image groups")
 		groups = []ImageGroup{}
 	}
@@ -190,6 +193,10 @@ func (h *ImagesHandler) CreateGroup(c *gin.Context) {
 	}
 
 	id, _ := result.LastInsertId()
+
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("image_groups")
+
 	core.Success(c, gin.H{"success": true, "id": id})
 }
 
@@ -255,6 +262,9 @@ func (h *ImagesHandler) UpdateGroup(c *gin.Context) {
 		return
 	}
 
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("image_groups")
+
 	core.Success(c, gin.H{"success": true})
 }
 
@@ -314,6 +324,9 @@ func (h *ImagesHandler) DeleteGroup(c *gin.Context) {
 		h.asyncReloadImageGroup(id)
 	}
 
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("image_groups")
+
 	core.Success(c, gin.H{"success": true})
 }
 
@@ -321,47 +334,48 @@ func (h *ImagesHandler) DeleteGroup(c *gin.Context) {
 // GET /api/images/urls/list
 func (h *ImagesHandler) ListURLs(c *gin.Context) {
 	groupID, _ := strconv.Atoi(c.DefaultQuery("group_id", "1"))
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	search := c.Query("search")
-
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	q, ok := bindListQuery(c, imageListSpec)
+	if !ok {
+		return
 	}
-	offset := (page - 1) * pageSize
 
 	if h.db == nil {
-		core.SuccessPaged(c, []ImageListItem{}, 0, page, pageSize)
+		respondList(c, q, []ImageListItem{}, 0, imageListKey)
 		return
 	}
 
-	where := "group_id = ? AND status = 1"
-	args := []interface{}{groupID}
+	q.Where("group_id = ? AND status = 1", groupID)
 
-	if search != "" {
-		where += " AND url LIKE ?"
-		args = append(args, "%"+search+"%")
-	}
+	// 列表查询走只读副本
+	reader := database.Reader(c.Request.Context(), h.db)
 
 	var total int64
-	h.db.Get(&total, "SELECT COUNT(*) FROM images WHERE "+where, args...)
+	if q.NeedsTotal() {
+		countQuery, args := q.CountQuery("images")
+		reader.Get(&total, countQuery, args...)
+	}
 
-	args = append(args, pageSize, offset)
-	query := `SELECT id, group_id, url, status, created_at
-	          FROM images WHERE ` + where + ` ORDER BY id DESC LIMIT ? OFFSET ?`
+	query, args := q.Query("SELECT id, group_id, url, status, created_at FROM images")
 
 	var items []ImageListItem
-	if err := h.db.Select(&items, query, args...); err != nil {
+	if err := reader.Select(&items, query, args...); err != nil {
 		log.Warn().Err(err).Msg("Failed to list images")
 		items = []ImageListItem{}
 	}
 
-	core.SuccessPaged(c, items, total, page, pageSize)
+	respondList(c, q, items, total, imageListKey)
+}
+
+// imageListSpec 图片URL列表的排序和搜索字段（大分组可用 cursor 分页）
+var imageListSpec = ListSpec{
+	SortFields:    map[string]string{"id": "id", "url": "url", "created_at": "created_at"},
+	DefaultSort:   "-id",
+	SearchColumns: []string{"url"},
+	KeysetColumn:  "id",
 }
 
+func imageListKey(i ImageListItem) int64 { return int64(i.ID) }
+
 // AddURL 添加单个图片URL
 // POST /api/images/urls/add
 func (h *ImagesHandler) AddURL(c *gin.Context) {
@@ -406,12 +420,11 @@ func (h *ImagesHandler) AddURL(c *gin.Context) {
 	core.Success(c, gin.H{"success": true, "id": id})
 }
 
-// BatchAddURLs 批量添加图片URL
+// BatchAddURLs 批量添加图片URL（流式解析请求体，超过 100000 个或请求体上限时返回 413）
 // POST /api/images/urls/batch
 func (h *ImagesHandler) BatchAddURLs(c *gin.Context) {
 	var req ImageBatchAddRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+	if !bindStringBatch(c, "urls", 100000, &req.URLs, map[string]interface{}{"group_id": &req.GroupID}) {
 		return
 	}
 
@@ -420,11 +433,6 @@ func (h *ImagesHandler) BatchAddURLs(c *gin.Context) {
 		return
 	}
 
-	if len(req.URLs) > 100000 {
-		core.FailWithMessage(c, core.ErrInvalidParam, "单次最多添加 100000 个URL")
-		return
-	}
-
 	if h.db == nil {
 		core.FailWithMessage(c, core.ErrInternalServer, "数据库未初始化")
 		return
@@ -786,6 +794,31 @@ func (h *ImagesHandler) DeleteAll(c *gin.Context) {
 		return
 	}
 
+	// 有作业管理器时异步分批删除，立即返回作业ID
+	if h.jobManager != nil {
+		jobID, err := h.jobManager.SubmitFunc(c.Request.Context(), "images_delete_all", req, func(jc *core.JobContext) (any, error) {
+			where, args := "1=1", []interface{}{}
+			if req.GroupID != nil {
+				where, args = "group_id = ?", []interface{}{*req.GroupID}
+			}
+			deleted, err := core.ChunkedDelete(jc, h.db, "images", where, args, 5000)
+			if deleted > 0 && h.poolManager != nil {
+				if req.GroupID != nil {
+					h.asyncReloadImageGroup(*req.GroupID)
+				} else {
+					h.poolManager.RefreshData(context.Background(), "images")
+				}
+			}
+			return gin.H{"deleted": deleted}, err
+		})
+		if err != nil {
+			core.Success(c, gin.H{"success": false, "message": err.Error(), "deleted": 0})
+			return
+		}
+		core.Success(c, gin.H{"success": true, "job_id": jobID})
+		return
+	}
+
 	var result sql.Result
 	var err error
 
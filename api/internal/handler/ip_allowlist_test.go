@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	core "seo-generator/api/internal/service"
+	"seo-generator/api/pkg/config"
+)
+
+// newIPAllowlistTestRouter 挂载白名单中间件的测试路由
+func newIPAllowlistTestRouter(allowlist *core.IPAllowlist) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(IPAllowlistMiddleware(allowlist))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/page", ok)
+	r.GET("/api/sites", ok)
+	r.POST("/api/spider/report", ok)
+	r.GET("/ws/pool-status", ok)
+	r.GET("/sse/logs", ok)
+	return r
+}
+
+func TestIPAllowlistMiddleware(t *testing.T) {
+	allowlist := core.NewIPAllowlist(nil, config.IPAllowlistConfig{
+		Enabled:        true,
+		CIDRs:          []string{"203.0.113.0/24"},
+		TrustedProxies: []string{"10.0.0.1/32"},
+		ExemptPaths:    []string{"/api/spider/report"},
+		BypassToken:    "emergency",
+	})
+	r := newIPAllowlistTestRouter(allowlist)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		remote  string
+		headers map[string]string
+		want    int
+	}{
+		{"公开页面不受限制", "GET", "/page", "192.0.2.1:1234", nil, http.StatusOK},
+		{"白名单网段", "GET", "/api/sites", "203.0.113.5:1234", nil, http.StatusOK},
+		{"不在白名单", "GET", "/api/sites", "192.0.2.1:1234", nil, http.StatusForbidden},
+		{"本机地址始终允许", "GET", "/api/sites", "127.0.0.1:1234", nil, http.StatusOK},
+		{"伪造 X-Forwarded-For", "GET", "/api/sites", "192.0.2.1:1234", map[string]string{"X-Forwarded-For": "203.0.113.5"}, http.StatusForbidden},
+		{"伪造 X-Real-IP", "GET", "/api/sites", "192.0.2.1:1234", map[string]string{"X-Real-IP": "203.0.113.5"}, http.StatusForbidden},
+		{"受信代理转发白名单 IP", "GET", "/api/sites", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "192.0.2.9, 203.0.113.5"}, http.StatusOK},
+		{"受信代理转发非白名单 IP", "GET", "/api/sites", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "203.0.113.5, 192.0.2.9"}, http.StatusForbidden},
+		{"应急令牌放行", "GET", "/api/sites", "192.0.2.1:1234", map[string]string{"X-Admin-Bypass": "emergency"}, http.StatusOK},
+		{"错误的应急令牌", "GET", "/api/sites", "192.0.2.1:1234", map[string]string{"X-Admin-Bypass": "wrong"}, http.StatusForbidden},
+		{"豁免路径", "POST", "/api/spider/report", "192.0.2.1:1234", nil, http.StatusOK},
+		{"WebSocket 受限", "GET", "/ws/pool-status", "192.0.2.1:1234", nil, http.StatusForbidden},
+		{"SSE 受限", "GET", "/sse/logs", "192.0.2.1:1234", nil, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+
+	// 被拒绝的请求记录客户端 IP（受信代理后为转发的 IP）
+	rejects, total := allowlist.RecentRejections()
+	if total != 7 || len(rejects) != 7 {
+		t.Fatalf("RecentRejections = %d items, total %d, want 7", len(rejects), total)
+	}
+	for _, rej := range rejects {
+		if rej.IP != "192.0.2.1" && rej.IP != "192.0.2.9" {
+			t.Errorf("rejected ip = %q, want 192.0.2.1 or 192.0.2.9", rej.IP)
+		}
+	}
+}
+
+func TestIPAllowlistMiddleware_Disabled(t *testing.T) {
+	r := newIPAllowlistTestRouter(core.NewIPAllowlist(nil, config.IPAllowlistConfig{CIDRs: []string{"203.0.113.0/24"}}))
+	req := httptest.NewRequest("GET", "/api/sites", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
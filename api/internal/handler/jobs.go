@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	core "seo-generator/api/internal/service"
+)
+
+// JobsHandler 后台作业 handler
+type JobsHandler struct {
+	jobManager *core.JobManager
+}
+
+// NewJobsHandler 创建 JobsHandler
+func NewJobsHandler(jobManager *core.JobManager) *JobsHandler {
+	return &JobsHandler{jobManager: jobManager}
+}
+
+// jobView 作业详情（附带进度百分比）
+type jobView struct {
+	*core.Job
+	Progress float64 `json:"progress"`
+}
+
+// List 获取作业列表
+// GET /api/jobs?type=&status=&limit=
+func (h *JobsHandler) List(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	jobs, err := h.jobManager.List(c.Request.Context(), c.Query("type"), c.Query("status"), limit)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+		return
+	}
+
+	items := make([]jobView, len(jobs))
+	for i := range jobs {
+		items[i] = jobView{Job: &jobs[i], Progress: jobs[i].Progress()}
+	}
+	core.Success(c, items)
+}
+
+// Get 获取作业详情
+// GET /api/jobs/:id
+func (h *JobsHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的作业ID")
+		return
+	}
+
+	job, err := h.jobManager.Get(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, core.ErrJobNotFound) {
+			core.FailWithMessage(c, core.ErrNotFound, "作业不存在")
+			return
+		}
+		core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+		return
+	}
+
+	core.Success(c, jobView{Job: job, Progress: job.Progress()})
+}
+
+// Cancel 取消作业
+// POST /api/jobs/:id/cancel
+func (h *JobsHandler) Cancel(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的作业ID")
+		return
+	}
+
+	if err := h.jobManager.Cancel(c.Request.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, core.ErrJobNotFound):
+			core.FailWithMessage(c, core.ErrNotFound, "作业不存在")
+		case errors.Is(err, core.ErrJobFinished):
+			core.FailWithMessage(c, core.ErrInvalidParam, "作业已结束，无法取消")
+		default:
+			core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		}
+		return
+	}
+
+	core.Success(c, gin.H{"success": true, "message": "已请求取消"})
+}
+
+// Stream 作业进度实时推送
+// 支持 query 参数 id，只推送指定作业的事件
+// GET /ws/jobs?id=123
+func (h *JobsHandler) Stream(c *gin.Context) {
+	var filterID int64
+	if idStr := c.Query("id"); idStr != "" {
+		filterID, _ = strconv.ParseInt(idStr, 10, 64)
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 监听客户端断开
+	go func() {
+		for {
+			_, _, err := conn.ReadMessage()
+			if err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	events, unsubscribe := h.jobManager.Subscribe()
+	defer unsubscribe()
+
+	// 心跳，防止代理断开空闲连接
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if filterID > 0 && event.JobID != filterID {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
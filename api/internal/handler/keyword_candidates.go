@@ -0,0 +1,143 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// KeywordCandidatesHandler 搜索词候选审核 handler
+type KeywordCandidatesHandler struct {
+	importer     *core.KeywordImporter
+	poolManager  *core.PoolManager
+	funcsManager *core.TemplateFuncsManager
+}
+
+// NewKeywordCandidatesHandler 创建 KeywordCandidatesHandler
+func NewKeywordCandidatesHandler(importer *core.KeywordImporter, poolManager *core.PoolManager, funcsManager *core.TemplateFuncsManager) *KeywordCandidatesHandler {
+	return &KeywordCandidatesHandler{importer: importer, poolManager: poolManager, funcsManager: funcsManager}
+}
+
+// KeywordCandidateApproveRequest 审核通过请求
+type KeywordCandidateApproveRequest struct {
+	IDs     []int64 `json:"ids" binding:"required"`
+	GroupID int     `json:"group_id" binding:"required"`
+}
+
+// KeywordCandidateRejectRequest 拒绝请求
+type KeywordCandidateRejectRequest struct {
+	IDs []int64 `json:"ids" binding:"required"`
+}
+
+// KeywordCandidateImportRequest 立即导入请求，source 为空时导入所有已配置来源
+type KeywordCandidateImportRequest struct {
+	Source string `json:"source"`
+}
+
+// List 获取候选列表
+// GET /api/keywords/candidates?status=pending&source=gsc&search=&page=1&page_size=50
+func (h *KeywordCandidatesHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 500 {
+		pageSize = 50
+	}
+
+	items, total, err := h.importer.List(c.Request.Context(), core.KeywordCandidateFilter{
+		Status:   c.DefaultQuery("status", core.CandidatePending),
+		Source:   c.Query("source"),
+		Search:   c.Query("search"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list keyword candidates")
+		items = []core.KeywordCandidate{}
+	}
+	core.SuccessPaged(c, items, total, page, pageSize)
+}
+
+// Approve 批量审核通过并加入关键词分组
+// POST /api/keywords/candidates/approve
+func (h *KeywordCandidatesHandler) Approve(c *gin.Context) {
+	var req KeywordCandidateApproveRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) == 0 || req.GroupID <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if len(req.IDs) > 10000 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "单次最多审核 10000 个候选")
+		return
+	}
+
+	added, err := h.importer.Approve(c.Request.Context(), req.IDs, req.GroupID, c.GetString("username"))
+	if err != nil {
+		log.Error().Err(err).Int("group_id", req.GroupID).Msg("Failed to approve keyword candidates")
+		core.FailWithCode(c, core.ErrDBUpdate)
+		return
+	}
+
+	// 成功后追加到缓存
+	if len(added) > 0 && h.poolManager != nil {
+		h.poolManager.AppendKeywords(req.GroupID, added)
+		if h.funcsManager != nil {
+			encodedKeywords := h.poolManager.GetKeywords(req.GroupID)
+			rawKeywords := h.poolManager.GetAllRawKeywords(req.GroupID)
+			h.funcsManager.ReloadKeywordGroup(req.GroupID, encodedKeywords, rawKeywords)
+		}
+	}
+
+	core.Success(c, gin.H{"added": len(added)})
+}
+
+// Reject 批量拒绝
+// POST /api/keywords/candidates/reject
+func (h *KeywordCandidatesHandler) Reject(c *gin.Context) {
+	var req KeywordCandidateRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) == 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+
+	rejected, err := h.importer.Reject(c.Request.Context(), req.IDs, c.GetString("username"))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to reject keyword candidates")
+		core.FailWithCode(c, core.ErrDBUpdate)
+		return
+	}
+	core.Success(c, gin.H{"rejected": rejected})
+}
+
+// Import 立即从搜索词报表导入
+// POST /api/keywords/candidates/import
+func (h *KeywordCandidatesHandler) Import(c *gin.Context) {
+	var req KeywordCandidateImportRequest
+	_ = c.ShouldBindJSON(&req)
+
+	if req.Source != "" {
+		result, err := h.importer.Run(c.Request.Context(), req.Source)
+		if err != nil {
+			core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+			return
+		}
+		core.Success(c, []*core.KeywordImportResult{result})
+		return
+	}
+
+	if len(h.importer.Sources()) == 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "未配置搜索词来源")
+		return
+	}
+	results, err := h.importer.RunAll(c.Request.Context())
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		return
+	}
+	core.Success(c, results)
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+
+	core "seo-generator/api/internal/service"
+)
+
+// KeywordExpandHandler 长尾关键词扩展 handler
+type KeywordExpandHandler struct {
+	expander *core.KeywordExpander
+}
+
+// NewKeywordExpandHandler 创建 KeywordExpandHandler
+func NewKeywordExpandHandler(expander *core.KeywordExpander) *KeywordExpandHandler {
+	return &KeywordExpandHandler{expander: expander}
+}
+
+// KeywordExpandRequest 扩展请求，与 expand_keywords 定时任务参数相同
+type KeywordExpandRequest = core.ExpandKeywordsParams
+
+// Expand 立即提交扩展作业（定时执行请创建 expand_keywords 类型的定时任务，参数相同）
+// POST /api/keywords/expand
+func (h *KeywordExpandHandler) Expand(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	params, err := core.ParseExpandKeywordsParams(json.RawMessage(body))
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+		return
+	}
+
+	jobID, err := h.expander.Submit(c.Request.Context(), params)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		return
+	}
+	core.Success(c, gin.H{"job_id": jobID})
+}
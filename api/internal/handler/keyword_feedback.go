@@ -0,0 +1,52 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// KeywordFeedbackHandler 关键词抓取反馈 handler
+type KeywordFeedbackHandler struct {
+	feedback *core.KeywordFeedback
+}
+
+// NewKeywordFeedbackHandler 创建 KeywordFeedbackHandler
+func NewKeywordFeedbackHandler(feedback *core.KeywordFeedback) *KeywordFeedbackHandler {
+	return &KeywordFeedbackHandler{feedback: feedback}
+}
+
+// Scores 获取分组关键词抓取得分
+// GET /api/keywords/feedback?group_id=1&limit=100&order=desc
+func (h *KeywordFeedbackHandler) Scores(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.DefaultQuery("group_id", "1"))
+	if err != nil || groupID <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的分组 ID")
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	items, err := h.feedback.TopScores(c.Request.Context(), groupID, limit, c.Query("order") == "asc")
+	if err != nil {
+		log.Warn().Err(err).Int("group_id", groupID).Msg("Failed to query keyword crawl scores")
+		items = []core.KeywordCrawlScore{}
+	}
+	core.Success(c, gin.H{
+		"items":  items,
+		"status": h.feedback.Status(),
+	})
+}
+
+// Compute 立即重新计算关键词抓取得分
+// POST /api/keywords/feedback/compute
+func (h *KeywordFeedbackHandler) Compute(c *gin.Context) {
+	result, err := h.feedback.Compute(c.Request.Context())
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		return
+	}
+	core.Success(c, result)
+}
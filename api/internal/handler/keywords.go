@@ -9,11 +9,13 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/rs/zerolog/log"
 
+	database "seo-generator/api/internal/repository"
 	core "seo-generator/api/internal/service"
 )
 
@@ -22,14 +24,42 @@ type KeywordsHandler struct {
 	db           *sqlx.DB
 	poolManager  *core.PoolManager
 	funcsManager *core.TemplateFuncsManager
+	jobManager   *core.JobManager
+	filter       *core.KeywordFilter // 入库过滤，nil 表示未启用（仍会跳过空关键词）
 }
 
 // NewKeywordsHandler 创建 KeywordsHandler
-func NewKeywordsHandler(db *sqlx.DB, poolManager *core.PoolManager, funcsManager *core.TemplateFuncsManager) *KeywordsHandler {
+func NewKeywordsHandler(db *sqlx.DB, poolManager *core.PoolManager, funcsManager *core.TemplateFuncsManager, jobManager *core.JobManager, filter *core.KeywordFilter) *KeywordsHandler {
 	return &KeywordsHandler{
 		db:           db,
 		poolManager:  poolManager,
 		funcsManager: funcsManager,
+		jobManager:   jobManager,
+		filter:       filter,
+	}
+}
+
+// filterKeywords 按入库过滤规则筛选关键词，override 时只去掉空关键词
+func (h *KeywordsHandler) filterKeywords(groupID int, keywords []string, override bool) ([]string, *core.KeywordFilterReport) {
+	filter := h.filter
+	if override {
+		filter = nil
+	}
+	return filter.Filter(groupID, keywords)
+}
+
+// reloadAllKeywordGroups 重载全部关键词分组并同步到 TemplateFuncsManager
+func (h *KeywordsHandler) reloadAllKeywordGroups(ctx context.Context) {
+	if h.poolManager == nil {
+		return
+	}
+	h.poolManager.RefreshData(ctx, "keywords")
+	if h.funcsManager != nil {
+		for _, gid := range h.poolManager.GetKeywordGroupIDs() {
+			keywords := h.poolManager.GetKeywords(gid)
+			rawKeywords := h.poolManager.GetAllRawKeywords(gid)
+			h.funcsManager.ReloadKeywordGroup(gid, keywords, rawKeywords)
+		}
 	}
 }
 
@@ -112,6 +142,9 @@ type BatchMoveRequest struct {
 	GroupID int   `json:"group_id" binding:"required"`
 }
 
+// KeywordNormalizeRequest 关键词规范化请求（dry_run 只预览变更和各规则计数）
+type KeywordNormalizeRequest = core.KeywordNormalizeOptions
+
 // DeleteAllRequest 删除全部请求
 type DeleteAllRequest struct {
 	Confirm bool `json:"confirm" binding:"required"`
@@ -120,14 +153,22 @@ type DeleteAllRequest struct {
 
 // KeywordAddRequest 添加单个关键词请求
 type KeywordAddRequest struct {
-	Keyword string `json:"keyword" binding:"required"`
-	GroupID int    `json:"group_id"`
+	Keyword  string `json:"keyword" binding:"required"`
+	GroupID  int    `json:"group_id"`
+	Override bool   `json:"override"` // 跳过入库过滤（长度、正则、停用词）
 }
 
 // KeywordBatchAddRequest 批量添加关键词请求
 type KeywordBatchAddRequest struct {
 	Keywords []string `json:"keywords" binding:"required"`
 	GroupID  int      `json:"group_id"`
+	Override bool     `json:"override"` // 跳过入库过滤（长度、正则、停用词）
+}
+
+// KeywordStopwordsRequest 设置分组停用词请求
+type KeywordStopwordsRequest struct {
+	GroupID int      `json:"group_id"` // 0 表示对全部分组生效
+	Words   []string `json:"words"`
 }
 
 // ListGroups 获取分组列表
@@ -152,7 +193,7 @@ func (h *KeywordsHandler) ListGroups(c *gin.Context) {
 	          FROM keyword_groups WHERE ` + where + ` ORDER BY is_default DESC, name`
 
 	var groups []KeywordGroup
-	if err := h.db.Select(&groups, query, args...); err != nil {
+	if err := core.GetQueryCache().Select(c.Request.Context(), h.db, &groups, query, args...); err != nil {
 		log.Warn().Err(err).Msg("Failed to list keyword groups")
 		groups = []KeywordGroup{}
 	}
@@ -199,6 +240,10 @@ func (h *KeywordsHandler) CreateGroup(c *gin.Context) {
 	}
 
 	id, _ := result.LastInsertId()
+
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("keyword_groups")
+
 	core.Success(c, gin.H{"success": true, "id": id})
 }
 
@@ -265,6 +310,9 @@ func (h *KeywordsHandler) UpdateGroup(c *gin.Context) {
 		return
 	}
 
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("keyword_groups")
+
 	core.Success(c, gin.H{"success": true})
 }
 
@@ -336,6 +384,9 @@ func (h *KeywordsHandler) DeleteGroup(c *gin.Context) {
 		h.asyncReloadKeywordGroup(id)
 	}
 
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("keyword_groups")
+
 	core.Success(c, gin.H{"success": true})
 }
 
@@ -343,49 +394,50 @@ func (h *KeywordsHandler) DeleteGroup(c *gin.Context) {
 // GET /api/keywords/list
 func (h *KeywordsHandler) List(c *gin.Context) {
 	groupID, _ := strconv.Atoi(c.DefaultQuery("group_id", "1"))
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	search := c.Query("search")
-
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	q, ok := bindListQuery(c, keywordListSpec)
+	if !ok {
+		return
 	}
-	offset := (page - 1) * pageSize
 
 	if h.db == nil {
-		core.SuccessPaged(c, []KeywordListItem{}, 0, page, pageSize)
+		respondList(c, q, []KeywordListItem{}, 0, keywordListKey)
 		return
 	}
 
-	where := "group_id = ? AND status = 1"
-	args := []interface{}{groupID}
+	q.Where("group_id = ? AND status = 1", groupID)
 
-	if search != "" {
-		where += " AND keyword LIKE ?"
-		args = append(args, "%"+search+"%")
-	}
+	// 列表查询走只读副本
+	reader := database.Reader(c.Request.Context(), h.db)
 
 	// 获取总数
 	var total int64
-	h.db.Get(&total, "SELECT COUNT(*) FROM keywords WHERE "+where, args...)
+	if q.NeedsTotal() {
+		countQuery, args := q.CountQuery("keywords")
+		reader.Get(&total, countQuery, args...)
+	}
 
 	// 获取列表
-	args = append(args, pageSize, offset)
-	query := `SELECT id, group_id, keyword, status, created_at
-	          FROM keywords WHERE ` + where + ` ORDER BY id DESC LIMIT ? OFFSET ?`
+	query, args := q.Query("SELECT id, group_id, keyword, status, created_at FROM keywords")
 
 	var items []KeywordListItem
-	if err := h.db.Select(&items, query, args...); err != nil {
+	if err := reader.Select(&items, query, args...); err != nil {
 		log.Warn().Err(err).Msg("Failed to list keywords")
 		items = []KeywordListItem{}
 	}
 
-	core.SuccessPaged(c, items, total, page, pageSize)
+	respondList(c, q, items, total, keywordListKey)
 }
 
+// keywordListSpec 关键词列表的排序和搜索字段（大分组可用 cursor 分页）
+var keywordListSpec = ListSpec{
+	SortFields:    map[string]string{"id": "id", "keyword": "keyword", "created_at": "created_at"},
+	DefaultSort:   "-id",
+	SearchColumns: []string{"keyword"},
+	KeysetColumn:  "id",
+}
+
+func keywordListKey(k KeywordListItem) int64 { return int64(k.ID) }
+
 // Update 更新关键词
 // PUT /api/keywords/:id
 func (h *KeywordsHandler) Update(c *gin.Context) {
@@ -550,6 +602,31 @@ func (h *KeywordsHandler) DeleteAll(c *gin.Context) {
 		return
 	}
 
+	// 有作业管理器时异步分批删除，立即返回作业ID
+	if h.jobManager != nil {
+		jobID, err := h.jobManager.SubmitFunc(c.Request.Context(), "keywords_delete_all", req, func(jc *core.JobContext) (any, error) {
+			where, args := "1=1", []interface{}{}
+			if req.GroupID != nil {
+				where, args = "group_id = ?", []interface{}{*req.GroupID}
+			}
+			deleted, err := core.ChunkedDelete(jc, h.db, "keywords", where, args, 5000)
+			if deleted > 0 && h.poolManager != nil {
+				if req.GroupID != nil {
+					h.asyncReloadKeywordGroup(*req.GroupID)
+				} else {
+					h.reloadAllKeywordGroups(context.Background())
+				}
+			}
+			return gin.H{"deleted": deleted}, err
+		})
+		if err != nil {
+			core.Success(c, gin.H{"success": false, "message": err.Error(), "deleted": 0})
+			return
+		}
+		core.Success(c, gin.H{"success": true, "job_id": jobID})
+		return
+	}
+
 	var result sql.Result
 	var err error
 
@@ -569,21 +646,11 @@ func (h *KeywordsHandler) DeleteAll(c *gin.Context) {
 
 	// 删除后重载缓存
 	if h.poolManager != nil {
-		ctx := context.Background()
 		if req.GroupID != nil {
 			h.asyncReloadKeywordGroup(*req.GroupID)
 		} else {
 			// 全部删除，需要重载所有分组
-			h.poolManager.RefreshData(ctx, "keywords")
-			// 全部删除后，需要重载所有分组到 TemplateFuncsManager
-			if h.funcsManager != nil {
-				groupIDs := h.poolManager.GetKeywordGroupIDs()
-				for _, gid := range groupIDs {
-					keywords := h.poolManager.GetKeywords(gid)
-					rawKeywords := h.poolManager.GetAllRawKeywords(gid)
-					h.funcsManager.ReloadKeywordGroup(gid, keywords, rawKeywords)
-				}
-			}
+			h.reloadAllKeywordGroups(context.Background())
 		}
 	}
 
@@ -696,12 +763,12 @@ func (h *KeywordsHandler) BatchMove(c *gin.Context) {
 	core.Success(c, gin.H{"success": true, "moved": len(req.IDs)})
 }
 
-// BatchAdd 批量添加关键词
+// BatchAdd 批量添加关键词（流式解析请求体，超过 100000 个或请求体上限时返回 413）
 // POST /api/keywords/batch
 func (h *KeywordsHandler) BatchAdd(c *gin.Context) {
 	var req KeywordBatchAddRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+	if !bindStringBatch(c, "keywords", 100000, &req.Keywords,
+		map[string]interface{}{"group_id": &req.GroupID, "override": &req.Override}) {
 		return
 	}
 
@@ -710,11 +777,6 @@ func (h *KeywordsHandler) BatchAdd(c *gin.Context) {
 		return
 	}
 
-	if len(req.Keywords) > 100000 {
-		core.FailWithMessage(c, core.ErrInvalidParam, "单次最多添加 100000 个关键词")
-		return
-	}
-
 	if h.db == nil {
 		core.FailWithMessage(c, core.ErrInternalServer, "数据库未初始化")
 		return
@@ -725,18 +787,14 @@ func (h *KeywordsHandler) BatchAdd(c *gin.Context) {
 		groupID = 1
 	}
 
+	accepted, report := h.filterKeywords(groupID, req.Keywords, req.Override)
+
 	// 使用 INSERT IGNORE 批量插入
 	added := 0
 	skipped := 0
 	addedKeywords := []string{}
 
-	for _, kw := range req.Keywords {
-		kw = strings.TrimSpace(kw)
-		if kw == "" {
-			skipped++
-			continue
-		}
-
+	for _, kw := range accepted {
 		result, err := h.db.Exec(
 			"INSERT IGNORE INTO keywords (group_id, keyword) VALUES (?, ?)",
 			groupID, kw)
@@ -766,10 +824,12 @@ func (h *KeywordsHandler) BatchAdd(c *gin.Context) {
 	}
 
 	core.Success(c, gin.H{
-		"success": true,
-		"added":   added,
-		"skipped": skipped,
-		"total":   len(req.Keywords),
+		"success":  true,
+		"added":    added,
+		"skipped":  skipped,
+		"rejected": report.Rejected,
+		"report":   report,
+		"total":    len(req.Keywords),
 	})
 }
 
@@ -792,6 +852,17 @@ func (h *KeywordsHandler) Add(c *gin.Context) {
 		groupID = 1
 	}
 
+	req.Keyword = strings.TrimSpace(req.Keyword)
+	if _, report := h.filterKeywords(groupID, []string{req.Keyword}, req.Override); report.Rejected > 0 {
+		rejection := report.Items[0]
+		core.Success(c, gin.H{
+			"success":  false,
+			"message":  "关键词未通过入库过滤：" + rejection.Reason,
+			"rejected": rejection,
+		})
+		return
+	}
+
 	result, err := h.db.Exec(
 		"INSERT IGNORE INTO keywords (group_id, keyword) VALUES (?, ?)",
 		groupID, req.Keyword)
@@ -838,6 +909,7 @@ func (h *KeywordsHandler) Upload(c *gin.Context) {
 	}
 
 	groupID, _ := strconv.Atoi(c.DefaultPostForm("group_id", "1"))
+	override, _ := strconv.ParseBool(c.DefaultPostForm("override", "false"))
 
 	if h.db == nil {
 		core.FailWithMessage(c, core.ErrInternalServer, "数据库未初始化")
@@ -878,19 +950,83 @@ func (h *KeywordsHandler) Upload(c *gin.Context) {
 		return
 	}
 
-	// 批量插入（5000条/批 + 事务）
-	const batchSize = 5000
-	added := 0
-	skipped := 0
+	total := len(keywords)
+	keywords, report := h.filterKeywords(groupID, keywords, override)
+	if len(keywords) == 0 {
+		core.Success(c, gin.H{
+			"success":  false,
+			"message":  fmt.Sprintf("%d 个关键词全部未通过入库过滤", total),
+			"total":    total,
+			"rejected": report.Rejected,
+			"report":   report,
+		})
+		return
+	}
 
-	tx, err := h.db.Begin()
+	// 有作业管理器时异步导入，立即返回作业ID
+	if h.jobManager != nil {
+		params := gin.H{"group_id": groupID, "filename": file.Filename, "total": total, "override": override}
+		jobID, err := h.jobManager.SubmitFunc(c.Request.Context(), "keywords_upload", params, func(jc *core.JobContext) (any, error) {
+			jc.SetTotal(int64(len(keywords)))
+			added, skipped, err := h.insertKeywords(jc, groupID, keywords, jc.Advance)
+			if added > 0 {
+				h.reloadKeywordGroupSync(context.Background(), groupID)
+			}
+			return gin.H{"total": total, "added": added, "skipped": skipped, "rejected": report.Rejected, "report": report}, err
+		})
+		if err != nil {
+			core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+			return
+		}
+		core.Success(c, gin.H{
+			"success":  true,
+			"message":  fmt.Sprintf("已提交导入作业，共 %d 个关键词，%d 个未通过入库过滤", len(keywords), report.Rejected),
+			"total":    total,
+			"rejected": report.Rejected,
+			"report":   report,
+			"job_id":   jobID,
+		})
+		return
+	}
+
+	added, skipped, err := h.insertKeywords(c.Request.Context(), groupID, keywords, nil)
 	if err != nil {
-		core.FailWithMessage(c, core.ErrInternalServer, "开启事务失败")
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
 		return
 	}
+
+	// 成功后重载该分组缓存（批量上传难以追踪具体成功的）
+	if added > 0 {
+		h.reloadKeywordGroupSync(c.Request.Context(), groupID)
+	}
+
+	core.Success(c, gin.H{
+		"success":  true,
+		"message":  fmt.Sprintf("成功添加 %d 个关键词，跳过 %d 个重复，%d 个未通过入库过滤", added, skipped, report.Rejected),
+		"total":    total,
+		"added":    added,
+		"skipped":  skipped,
+		"rejected": report.Rejected,
+		"report":   report,
+	})
+}
+
+// insertKeywords 批量插入关键词（5000条/批 + 事务）
+// onBatch 在每批处理后回调已处理数量，可为 nil
+func (h *KeywordsHandler) insertKeywords(ctx context.Context, groupID int, keywords []string, onBatch func(n int64)) (added, skipped int, err error) {
+	const batchSize = 5000
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("开启事务失败")
+	}
 	defer tx.Rollback()
 
 	for i := 0; i < len(keywords); i += batchSize {
+		if ctx.Err() != nil {
+			return 0, 0, ctx.Err()
+		}
+
 		end := i + batchSize
 		if end > len(keywords) {
 			end = len(keywords)
@@ -907,41 +1043,132 @@ func (h *KeywordsHandler) Upload(c *gin.Context) {
 		}
 
 		query := "INSERT IGNORE INTO keywords (group_id, keyword) VALUES " + strings.Join(valueStrings, ",")
-		result, err := tx.Exec(query, valueArgs...)
+		result, err := tx.ExecContext(ctx, query, valueArgs...)
 		if err != nil {
 			log.Warn().Err(err).Int("batch", i/batchSize).Msg("Batch insert failed")
 			skipped += len(batch)
-			continue
+		} else {
+			affected, _ := result.RowsAffected()
+			added += int(affected)
+			skipped += len(batch) - int(affected)
 		}
 
-		affected, _ := result.RowsAffected()
-		added += int(affected)
-		skipped += len(batch) - int(affected)
+		if onBatch != nil {
+			onBatch(int64(len(batch)))
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		core.FailWithMessage(c, core.ErrInternalServer, "提交事务失败")
+		return 0, 0, fmt.Errorf("提交事务失败")
+	}
+	return added, skipped, nil
+}
+
+// GetStopwords 获取分组停用词
+// GET /api/keywords/stopwords?group_id=0
+func (h *KeywordsHandler) GetStopwords(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.DefaultQuery("group_id", "0"))
+	if err != nil || groupID < 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "group_id 无效")
 		return
 	}
+	core.Success(c, gin.H{
+		"enabled":  h.filter != nil,
+		"group_id": groupID,
+		"words":    h.filter.Stopwords(groupID),
+	})
+}
 
-	// 成功后重载该分组缓存（批量上传难以追踪具体成功的）
-	if added > 0 && h.poolManager != nil {
-		h.poolManager.ReloadKeywordGroup(c.Request.Context(), groupID)
-		// 同步到 TemplateFuncsManager
-		if h.funcsManager != nil {
-			keywords := h.poolManager.GetKeywords(groupID)
-			rawKeywords := h.poolManager.GetAllRawKeywords(groupID)
-			h.funcsManager.ReloadKeywordGroup(groupID, keywords, rawKeywords)
+// SetStopwords 替换分组停用词（group_id 为 0 时对全部分组生效）
+// PUT /api/keywords/stopwords
+func (h *KeywordsHandler) SetStopwords(c *gin.Context) {
+	var req KeywordStopwordsRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.GroupID < 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if h.filter == nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "关键词入库过滤未启用（keyword_filter.enabled）")
+		return
+	}
+	for _, w := range req.Words {
+		if utf8.RuneCountInString(strings.TrimSpace(w)) > 100 {
+			core.FailWithMessage(c, core.ErrInvalidParam, "停用词不能超过 100 个字符")
+			return
 		}
 	}
 
-	core.Success(c, gin.H{
-		"success": true,
-		"message": fmt.Sprintf("成功添加 %d 个关键词，跳过 %d 个重复", added, skipped),
-		"total":   len(keywords),
-		"added":   added,
-		"skipped": skipped,
-	})
+	words, err := h.filter.SetStopwords(c.Request.Context(), req.GroupID, req.Words)
+	if err != nil {
+		log.Error().Err(err).Int("group_id", req.GroupID).Msg("Failed to save keyword stopwords")
+		core.FailWithMessage(c, core.ErrInternalServer, "保存停用词失败")
+		return
+	}
+	core.Success(c, gin.H{"group_id": req.GroupID, "words": words})
+}
+
+// Normalize 规范化并去重关键词（全半角、大小写、标点、空白），有作业管理器时后台执行
+// POST /api/keywords/normalize
+func (h *KeywordsHandler) Normalize(c *gin.Context) {
+	var req KeywordNormalizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if len(req.Rules()) == 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请至少选择一条规范化规则")
+		return
+	}
+	if h.db == nil {
+		core.FailWithMessage(c, core.ErrInternalServer, "数据库未初始化")
+		return
+	}
+
+	run := func(ctx context.Context, jc *core.JobContext) (*core.KeywordNormalizeResult, error) {
+		result, err := core.NormalizeKeywords(ctx, jc, h.db, req)
+		if result != nil && !result.DryRun {
+			for _, gid := range result.Groups {
+				h.reloadKeywordGroupSync(context.Background(), gid)
+			}
+		}
+		return result, err
+	}
+
+	if h.jobManager != nil {
+		jobType := "keywords_normalize"
+		if req.DryRun {
+			jobType = "keywords_normalize_preview"
+		}
+		jobID, err := h.jobManager.SubmitFunc(c.Request.Context(), jobType, req, func(jc *core.JobContext) (any, error) {
+			return run(jc, jc)
+		})
+		if err != nil {
+			core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+			return
+		}
+		core.Success(c, gin.H{"job_id": jobID, "dry_run": req.DryRun})
+		return
+	}
+
+	result, err := run(c.Request.Context(), nil)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		return
+	}
+	core.Success(c, result)
+}
+
+// reloadKeywordGroupSync 同步重载关键词分组并同步到 TemplateFuncsManager
+func (h *KeywordsHandler) reloadKeywordGroupSync(ctx context.Context, groupID int) {
+	if h.poolManager == nil {
+		return
+	}
+	h.poolManager.ReloadKeywordGroup(ctx, groupID)
+	if h.funcsManager != nil {
+		keywords := h.poolManager.GetKeywords(groupID)
+		rawKeywords := h.poolManager.GetAllRawKeywords(groupID)
+		h.funcsManager.ReloadKeywordGroup(groupID, keywords, rawKeywords)
+	}
 }
 
 // Reload 重新加载关键词缓存
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	core "seo-generator/api/internal/service"
+)
+
+const (
+	defaultListPageSize = 20
+	maxListPageSize     = 100
+	maxListSearchLen    = 100
+)
+
+// ListSpec 列表接口允许的排序字段、搜索列和游标分页列
+type ListSpec struct {
+	SortFields    map[string]string // 请求参数 sort 可用的字段名 → SQL 列（白名单）
+	DefaultSort   string            // 默认排序，如 "-id"（- 前缀表示降序）
+	SearchColumns []string          // search 参数 LIKE 匹配的列，为空时忽略 search
+	KeysetColumn  string            // 游标分页列（唯一且单调，通常是 id），为空时不支持 cursor
+	MaxPageSize   int               // 默认 100
+}
+
+// ListQuery 解析后的分页、排序和过滤条件
+//
+// 请求参数：page、page_size、search、sort（字段名，- 前缀降序）、cursor。
+// 带 cursor 参数（首页传空值）时使用 keyset 分页：不计算总数、不支持跳页，
+// 响应返回 next_cursor，适合大表；否则为 page/page_size 偏移分页。
+type ListQuery struct {
+	Page     int
+	PageSize int
+	Search   string
+
+	spec       ListSpec
+	sortColumn string
+	desc       bool
+	cursorMode bool
+	after      int64 // 上一页最后一条的 keyset 值，0 表示首页
+
+	where []string
+	args  []interface{}
+}
+
+// BindListQuery 从请求参数解析 ListQuery，错误信息可直接返回给前端
+func BindListQuery(c *gin.Context, spec ListSpec) (*ListQuery, error) {
+	if spec.MaxPageSize <= 0 {
+		spec.MaxPageSize = maxListPageSize
+	}
+	q := &ListQuery{Page: 1, PageSize: defaultListPageSize, spec: spec}
+
+	if v := c.Query("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, errors.New("page 必须为正整数")
+		}
+		q.Page = n
+	}
+	if v := c.Query("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, errors.New("page_size 必须为正整数")
+		}
+		if n > spec.MaxPageSize {
+			n = spec.MaxPageSize
+		}
+		q.PageSize = n
+	}
+
+	q.Search = strings.TrimSpace(c.Query("search"))
+	if utf8.RuneCountInString(q.Search) > maxListSearchLen {
+		return nil, fmt.Errorf("search 不能超过 %d 个字符", maxListSearchLen)
+	}
+
+	sortParam := c.DefaultQuery("sort", spec.DefaultSort)
+	if err := q.setSort(sortParam); err != nil {
+		return nil, err
+	}
+
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		if spec.KeysetColumn == "" {
+			return nil, errors.New("该列表不支持 cursor 分页")
+		}
+		if q.sortColumn != spec.KeysetColumn {
+			return nil, fmt.Errorf("cursor 分页只支持按 %s 排序", q.keysetField())
+		}
+		after, err := decodeListCursor(cursor)
+		if err != nil {
+			return nil, errors.New("cursor 无效")
+		}
+		q.cursorMode = true
+		q.after = after
+		q.Page = 1
+	}
+
+	if q.Search != "" && len(spec.SearchColumns) > 0 {
+		like := "%" + escapeLikePattern(q.Search) + "%"
+		conds := make([]string, len(spec.SearchColumns))
+		for i, col := range spec.SearchColumns {
+			conds[i] = col + " LIKE ?"
+			q.args = append(q.args, like)
+		}
+		q.where = append(q.where, "("+strings.Join(conds, " OR ")+")")
+	}
+	return q, nil
+}
+
+// bindListQuery 解析 ListQuery，失败时返回 400（统一响应格式）
+func bindListQuery(c *gin.Context, spec ListSpec) (*ListQuery, bool) {
+	q, err := BindListQuery(c, spec)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+		return nil, false
+	}
+	return q, true
+}
+
+func (q *ListQuery) setSort(param string) error {
+	field := strings.TrimSpace(param)
+	desc := strings.HasPrefix(field, "-")
+	field = strings.TrimPrefix(field, "-")
+	col, ok := q.spec.SortFields[field]
+	if !ok {
+		allowed := make([]string, 0, len(q.spec.SortFields))
+		for name := range q.spec.SortFields {
+			allowed = append(allowed, name)
+		}
+		sort.Strings(allowed)
+		return fmt.Errorf("sort 只支持 %s", strings.Join(allowed, ", "))
+	}
+	q.sortColumn = col
+	q.desc = desc
+	return nil
+}
+
+// keysetField keyset 列对应的参数名（用于错误提示）
+func (q *ListQuery) keysetField() string {
+	for name, col := range q.spec.SortFields {
+		if col == q.spec.KeysetColumn {
+			return name
+		}
+	}
+	return q.spec.KeysetColumn
+}
+
+// Where 添加过滤条件（cond 中的 ? 与 args 一一对应）
+func (q *ListQuery) Where(cond string, args ...interface{}) *ListQuery {
+	q.where = append(q.where, cond)
+	q.args = append(q.args, args...)
+	return q
+}
+
+// Eq value 非空时添加 column = value 条件（用于可选的过滤参数）
+func (q *ListQuery) Eq(column, value string) *ListQuery {
+	if value != "" {
+		q.Where(column+" = ?", value)
+	}
+	return q
+}
+
+// CursorMode 是否为 keyset 分页
+func (q *ListQuery) CursorMode() bool {
+	return q.cursorMode
+}
+
+// NeedsTotal 是否需要计算总数（keyset 分页不计算）
+func (q *ListQuery) NeedsTotal() bool {
+	return !q.cursorMode
+}
+
+// Filter 过滤条件（不含 WHERE 关键字）及参数，用于 COUNT 和列表查询
+func (q *ListQuery) Filter() (string, []interface{}) {
+	if len(q.where) == 0 {
+		return "1=1", append([]interface{}(nil), q.args...)
+	}
+	return strings.Join(q.where, " AND "), append([]interface{}(nil), q.args...)
+}
+
+// PageClause 追加在过滤条件之后的 keyset 条件、ORDER BY 和 LIMIT，及其参数
+//
+// keyset 分页多取一条用于判断是否还有下一页，由 trimListPage 截掉
+func (q *ListQuery) PageClause() (string, []interface{}) {
+	dir := "ASC"
+	if q.desc {
+		dir = "DESC"
+	}
+	order := " ORDER BY " + q.sortColumn + " " + dir
+	if key := q.spec.KeysetColumn; key != "" && key != q.sortColumn {
+		order += ", " + key + " " + dir
+	}
+
+	if !q.cursorMode {
+		return order + " LIMIT ? OFFSET ?", []interface{}{q.PageSize, (q.Page - 1) * q.PageSize}
+	}
+	var clause string
+	var args []interface{}
+	if q.after > 0 {
+		op := ">"
+		if q.desc {
+			op = "<"
+		}
+		clause = " AND " + q.spec.KeysetColumn + " " + op + " ?"
+		args = append(args, q.after)
+	}
+	return clause + order + " LIMIT ?", append(args, q.PageSize+1)
+}
+
+// Query 拼接完整列表查询：selectFrom 为 "SELECT ... FROM ..."（不含 WHERE）
+func (q *ListQuery) Query(selectFrom string) (string, []interface{}) {
+	where, args := q.Filter()
+	page, pageArgs := q.PageClause()
+	return selectFrom + " WHERE " + where + page, append(args, pageArgs...)
+}
+
+// CountQuery 拼接总数查询：from 为表名（可带别名）
+func (q *ListQuery) CountQuery(from string) (string, []interface{}) {
+	where, args := q.Filter()
+	return "SELECT COUNT(*) FROM " + from + " WHERE " + where, args
+}
+
+// trimListPage keyset 分页时截掉多取的一条并生成下一页游标
+func trimListPage[T any](q *ListQuery, items []T, key func(T) int64) ([]T, string, bool) {
+	if !q.cursorMode || len(items) <= q.PageSize {
+		return items, "", false
+	}
+	items = items[:q.PageSize]
+	return items, encodeListCursor(key(items[len(items)-1])), true
+}
+
+// respondList 按分页方式返回列表（偏移分页为 SuccessPaged，keyset 分页为 SuccessCursor）
+func respondList[T any](c *gin.Context, q *ListQuery, items []T, total int64, key func(T) int64) {
+	if items == nil {
+		items = []T{}
+	}
+	if !q.cursorMode {
+		core.SuccessPaged(c, items, total, q.Page, q.PageSize)
+		return
+	}
+	items, next, hasMore := trimListPage(q, items, key)
+	core.SuccessCursor(c, items, next, hasMore, q.PageSize)
+}
+
+func encodeListCursor(v int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(v, 10)))
+}
+
+func decodeListCursor(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || v < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+	return v, nil
+}
+
+// escapeLikePattern 转义 LIKE 通配符
+func escapeLikePattern(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s)
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var testListSpec = ListSpec{
+	SortFields:    map[string]string{"id": "k.id", "name": "k.name"},
+	DefaultSort:   "-id",
+	SearchColumns: []string{"k.name", "k.note"},
+	KeysetColumn:  "k.id",
+	MaxPageSize:   50,
+}
+
+// newListQueryContext 构造带查询参数的 gin 上下文
+func newListQueryContext(rawQuery string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/api/list?"+rawQuery, nil)
+	return c
+}
+
+func TestBindListQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		spec      ListSpec
+		wantErr   bool
+		wantSQL   string
+		wantArgs  []interface{}
+		wantPage  int
+		wantSize  int
+		wantTotal bool
+	}{
+		{
+			name:      "defaults",
+			query:     "",
+			spec:      testListSpec,
+			wantSQL:   "SELECT * FROM k WHERE 1=1 ORDER BY k.id DESC LIMIT ? OFFSET ?",
+			wantArgs:  []interface{}{20, 0},
+			wantPage:  1,
+			wantSize:  20,
+			wantTotal: true,
+		},
+		{
+			name:      "page and sort",
+			query:     "page=3&page_size=10&sort=name",
+			spec:      testListSpec,
+			wantSQL:   "SELECT * FROM k WHERE 1=1 ORDER BY k.name ASC, k.id ASC LIMIT ? OFFSET ?",
+			wantArgs:  []interface{}{10, 20},
+			wantPage:  3,
+			wantSize:  10,
+			wantTotal: true,
+		},
+		{
+			name:      "page size capped",
+			query:     "page_size=1000",
+			spec:      testListSpec,
+			wantSQL:   "SELECT * FROM k WHERE 1=1 ORDER BY k.id DESC LIMIT ? OFFSET ?",
+			wantArgs:  []interface{}{50, 0},
+			wantPage:  1,
+			wantSize:  50,
+			wantTotal: true,
+		},
+		{
+			name:      "search escapes wildcards",
+			query:     "search=%2050%25_off%20",
+			spec:      testListSpec,
+			wantSQL:   "SELECT * FROM k WHERE (k.name LIKE ? OR k.note LIKE ?) ORDER BY k.id DESC LIMIT ? OFFSET ?",
+			wantArgs:  []interface{}{`%50\%\_off%`, `%50\%\_off%`, 20, 0},
+			wantPage:  1,
+			wantSize:  20,
+			wantTotal: true,
+		},
+		{
+			name:     "first cursor page",
+			query:    "cursor=&page=5",
+			spec:     testListSpec,
+			wantSQL:  "SELECT * FROM k WHERE 1=1 ORDER BY k.id DESC LIMIT ?",
+			wantArgs: []interface{}{21},
+			wantPage: 1,
+			wantSize: 20,
+		},
+		{
+			name:     "next cursor page descending",
+			query:    "cursor=" + encodeListCursor(42),
+			spec:     testListSpec,
+			wantSQL:  "SELECT * FROM k WHERE 1=1 AND k.id < ? ORDER BY k.id DESC LIMIT ?",
+			wantArgs: []interface{}{int64(42), 21},
+			wantPage: 1,
+			wantSize: 20,
+		},
+		{
+			name:     "next cursor page ascending",
+			query:    "sort=id&cursor=" + encodeListCursor(7),
+			spec:     testListSpec,
+			wantSQL:  "SELECT * FROM k WHERE 1=1 AND k.id > ? ORDER BY k.id ASC LIMIT ?",
+			wantArgs: []interface{}{int64(7), 21},
+			wantPage: 1,
+			wantSize: 20,
+		},
+		{name: "invalid page", query: "page=0", spec: testListSpec, wantErr: true},
+		{name: "invalid page size", query: "page_size=abc", spec: testListSpec, wantErr: true},
+		{name: "unknown sort", query: "sort=password", spec: testListSpec, wantErr: true},
+		{name: "cursor with other sort", query: "sort=name&cursor=", spec: testListSpec, wantErr: true},
+		{name: "invalid cursor", query: "cursor=!!", spec: testListSpec, wantErr: true},
+		{name: "cursor unsupported", query: "cursor=", spec: ListSpec{SortFields: map[string]string{"id": "id"}, DefaultSort: "id"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := BindListQuery(newListQueryContext(tt.query), tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			sql, args := q.Query("SELECT * FROM k")
+			if sql != tt.wantSQL {
+				t.Errorf("sql = %q, want %q", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+			if q.Page != tt.wantPage || q.PageSize != tt.wantSize {
+				t.Errorf("page = %d/%d, want %d/%d", q.Page, q.PageSize, tt.wantPage, tt.wantSize)
+			}
+			if q.NeedsTotal() != tt.wantTotal {
+				t.Errorf("NeedsTotal = %v, want %v", q.NeedsTotal(), tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestListQuery_Filters(t *testing.T) {
+	q, err := BindListQuery(newListQueryContext("search=abc"), testListSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Eq("k.status", "1").Eq("k.group_id", "").Where("k.created_at >= ?", "2026-01-01")
+
+	sql, args := q.CountQuery("keywords k")
+	wantSQL := "SELECT COUNT(*) FROM keywords k WHERE (k.name LIKE ? OR k.note LIKE ?) AND k.status = ? AND k.created_at >= ?"
+	if sql != wantSQL {
+		t.Errorf("sql = %q, want %q", sql, wantSQL)
+	}
+	wantArgs := []interface{}{"%abc%", "%abc%", "1", "2026-01-01"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", args, wantArgs)
+	}
+}
+
+func TestTrimListPage(t *testing.T) {
+	type row struct{ id int64 }
+	key := func(r row) int64 { return r.id }
+	rows := []row{{5}, {4}, {3}}
+
+	tests := []struct {
+		name     string
+		query    string
+		items    []row
+		wantLen  int
+		wantNext string
+		wantMore bool
+	}{
+		{"offset mode untouched", "page_size=2", rows, 3, "", false},
+		{"last cursor page", "cursor=&page_size=3", rows, 3, "", false},
+		{"more cursor pages", "cursor=&page_size=2", rows, 2, encodeListCursor(4), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := BindListQuery(newListQueryContext(tt.query), testListSpec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			items, next, more := trimListPage(q, tt.items, key)
+			if len(items) != tt.wantLen || next != tt.wantNext || more != tt.wantMore {
+				t.Errorf("trimListPage = (%d, %q, %v), want (%d, %q, %v)",
+					len(items), next, more, tt.wantLen, tt.wantNext, tt.wantMore)
+			}
+		})
+	}
+}
+
+func TestListCursorRoundTrip(t *testing.T) {
+	for _, v := range []int64{0, 1, 42, 1 << 40} {
+		got, err := decodeListCursor(encodeListCursor(v))
+		if err != nil || got != v {
+			t.Errorf("round trip %d = (%d, %v)", v, got, err)
+		}
+	}
+	for _, s := range []string{"!!", "LTE" /* "-1" */, "YWJj" /* "abc" */} {
+		if _, err := decodeListCursor(s); err == nil {
+			t.Errorf("decodeListCursor(%q) expected error", s)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+
+	core "seo-generator/api/internal/service"
+)
+
+// LLMRewriteHandler LLM 改写设置、手动重跑和用量 handler
+type LLMRewriteHandler struct {
+	db       *sqlx.DB
+	rdb      *redis.Client
+	gateway  *core.LLMGateway
+	rewriter *core.ArticleRewriter
+}
+
+// NewLLMRewriteHandler 创建 LLMRewriteHandler，gateway 为 nil 表示未启用 LLM
+func NewLLMRewriteHandler(db *sqlx.DB, rdb *redis.Client, gateway *core.LLMGateway, rewriter *core.ArticleRewriter) *LLMRewriteHandler {
+	return &LLMRewriteHandler{db: db, rdb: rdb, gateway: gateway, rewriter: rewriter}
+}
+
+// RewritePolicyRequest 设置分组改写请求
+type RewritePolicyRequest = core.RewritePolicy
+
+// RewriteRunRequest 手动重跑请求：指定文章 ID，或按分组改写尚未改写过的文章
+type RewriteRunRequest struct {
+	IDs     []int64 `json:"ids"`
+	GroupID int     `json:"group_id"`
+	Limit   int     `json:"limit"`   // 按分组时最多处理的文章数，默认 100，最大 1000
+	Requeue bool    `json:"requeue"` // 改写后推入加工队列，重新拆分进标题 / 段落池
+}
+
+// ListPolicies 获取各文章分组的改写设置
+// GET /api/articles/rewrite-policies
+func (h *LLMRewriteHandler) ListPolicies(c *gin.Context) {
+	var groups []struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+	if err := h.db.Select(&groups, "SELECT id, name FROM article_groups ORDER BY id"); err != nil {
+		core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+		return
+	}
+	items := make([]gin.H, 0, len(groups))
+	for _, g := range groups {
+		items = append(items, gin.H{"group_name": g.Name, "policy": h.rewriter.PolicyFor(g.ID)})
+	}
+	core.Success(c, gin.H{"enabled": h.gateway != nil, "groups": items})
+}
+
+// SetPolicy 设置分组改写
+// PUT /api/articles/rewrite-policies
+func (h *LLMRewriteHandler) SetPolicy(c *gin.Context) {
+	if h.rewriter == nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "LLM 改写未启用（llm.enabled）")
+		return
+	}
+	var req RewritePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.GroupID <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if err := h.rewriter.SetPolicy(c.Request.Context(), req); err != nil {
+		core.FailWithMessage(c, core.ErrDBUpdate, err.Error())
+		return
+	}
+	core.Success(c, gin.H{"success": true})
+}
+
+// Run 手动重跑改写（后台作业）
+// POST /api/articles/rewrite
+func (h *LLMRewriteHandler) Run(c *gin.Context) {
+	if h.rewriter == nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "LLM 改写未启用（llm.enabled）")
+		return
+	}
+	var req RewriteRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+
+	ids := req.IDs
+	if len(ids) == 0 {
+		if req.GroupID <= 0 {
+			core.FailWithMessage(c, core.ErrInvalidParam, "需要 ids 或 group_id")
+			return
+		}
+		if req.Limit <= 0 {
+			req.Limit = 100
+		}
+		if req.Limit > 1000 {
+			req.Limit = 1000
+		}
+		if err := h.db.Select(&ids,
+			"SELECT id FROM original_articles WHERE group_id = ? AND status = 1 AND rewritten_at IS NULL ORDER BY id LIMIT ?",
+			req.GroupID, req.Limit); err != nil {
+			core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+			return
+		}
+	}
+	if len(ids) == 0 {
+		core.Success(c, gin.H{"success": false, "message": "没有待改写的文章"})
+		return
+	}
+	if len(ids) > 1000 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "单次最多改写 1000 篇文章")
+		return
+	}
+
+	var then func([]int64)
+	if req.Requeue {
+		then = func(done []int64) { core.PushPendingArticles(h.rdb, done) }
+	}
+	jobID, err := h.rewriter.Submit(c.Request.Context(), ids, false, then)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		return
+	}
+	core.Success(c, gin.H{"success": true, "job_id": jobID, "count": len(ids)})
+}
+
+// Usage 当日预算、并发和最近的用量明细
+// GET /api/llm/usage?days=7
+func (h *LLMRewriteHandler) Usage(c *gin.Context) {
+	if h.gateway == nil {
+		core.Success(c, gin.H{"enabled": false})
+		return
+	}
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if days <= 0 || days > 90 {
+		days = 7
+	}
+	rows, err := h.gateway.Usage(c.Request.Context(), days)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+		return
+	}
+	core.Success(c, gin.H{"enabled": true, "today": h.gateway.Stats(), "daily": rows})
+}
@@ -2,7 +2,10 @@
 package api
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 	"strconv"
 	"time"
@@ -18,16 +21,34 @@ import (
 type LogHandler struct {
 	db             *sqlx.DB
 	spiderDetector *core.SpiderDetector
+	ingester       *core.SpiderLogIngester
 }
 
 // NewLogHandler creates a new log handler
-func NewLogHandler(db *sqlx.DB) *LogHandler {
+func NewLogHandler(db *sqlx.DB, ingester *core.SpiderLogIngester) *LogHandler {
 	return &LogHandler{
 		db:             db,
 		spiderDetector: core.GetSpiderDetector(),
+		ingester:       ingester,
 	}
 }
 
+// maxSpiderLogBatchBytes 批量上报请求体上限
+const maxSpiderLogBatchBytes = 16 << 20
+
+// spiderVisitItem Nginx Lua 批量上报的单条访问记录
+type spiderVisitItem struct {
+	UA       string `json:"ua"`
+	Domain   string `json:"domain"`
+	Path     string `json:"path"`
+	IP       string `json:"ip"`
+	CacheHit *int   `json:"cache_hit"`
+	RespTime int    `json:"resp_time"`
+	Status   int    `json:"status"`
+	Bytes    int    `json:"bytes"` // 响应体字节数（可选，用于域名缓存流量统计）
+	TS       int64  `json:"ts"`    // Unix 时间戳（秒），为空时使用接收时间
+}
+
 // LogSpiderVisit 记录蜘蛛访问日志（供 Nginx Lua 调用）
 func (h *LogHandler) LogSpiderVisit(c *gin.Context) {
 	ua := c.Query("ua")
@@ -36,6 +57,7 @@ func (h *LogHandler) LogSpiderVisit(c *gin.Context) {
 	ip := c.Query("ip")
 	cacheHitStr := c.DefaultQuery("cache_hit", "1")
 	respTimeStr := c.DefaultQuery("resp_time", "0")
+	bytesSent, _ := strconv.Atoi(c.Query("bytes"))
 
 	if ua == "" || domain == "" || path == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "missing parameters"})
@@ -51,14 +73,22 @@ func (h *LogHandler) LogSpiderVisit(c *gin.Context) {
 
 	cacheHit, _ := strconv.Atoi(cacheHitStr)
 	respTime, _ := strconv.Atoi(respTimeStr)
-
-	// 截断过长的值
-	if len(ua) > 500 {
-		ua = ua[:500]
-	}
-	if len(path) > 500 {
-		path = path[:500]
+	record := core.SpiderVisitRecord{
+		SpiderType: detection.SpiderType,
+		IP:         ip,
+		UA:         ua,
+		Domain:     domain,
+		Path:       path,
+		RespTime:   respTime,
+		CacheHit:   cacheHit,
+		Status:     http.StatusOK,
+		CreatedAt:  time.Now(),
+		Bytes:      bytesSent,
 	}
+	core.GetDomainCacheStats().RecordVisit(record)
+
+	// 截断过长的值（按字符截断）
+	r := core.NormalizeVisitRecord(record)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -66,7 +96,7 @@ func (h *LogHandler) LogSpiderVisit(c *gin.Context) {
 	query := `INSERT INTO spider_logs (spider_type, ip, ua, domain, path, dns_ok, resp_time, cache_hit, status)
               VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := h.db.ExecContext(ctx, query, detection.SpiderType, ip, ua, domain, path, 0, respTime, cacheHit, 200)
+	_, err := h.db.ExecContext(ctx, query, r.SpiderType, r.IP, r.UA, r.Domain, r.Path, 0, r.RespTime, r.CacheHit, r.Status)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to log spider visit")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
@@ -82,3 +112,97 @@ func (h *LogHandler) LogSpiderVisit(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
+
+// LogSpiderVisitBatch 批量接收蜘蛛访问日志（供 Nginx Lua 定时上报）
+// 请求体为 NDJSON：每行一个 JSON 对象或 JSON 数组
+// 记录进入内存缓冲区（满时溢出到 Redis）后立即返回，由 SpiderLogIngester 异步批量写库
+// POST /api/log/spider/batch
+func (h *LogHandler) LogSpiderVisitBatch(c *gin.Context) {
+	if h.ingester == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "batch ingestion disabled"})
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxSpiderLogBatchBytes)
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSpiderLogBatchBytes)
+
+	now := time.Now()
+	records := make([]core.SpiderVisitRecord, 0, 256)
+	invalid, skipped := 0, 0
+
+	addItem := func(item spiderVisitItem) {
+		if item.UA == "" || item.Domain == "" || item.Path == "" {
+			invalid++
+			return
+		}
+		detection := h.spiderDetector.Detect(item.UA)
+		if !detection.IsSpider {
+			skipped++
+			return
+		}
+		cacheHit := 1
+		if item.CacheHit != nil {
+			cacheHit = *item.CacheHit
+		}
+		createdAt := now
+		if item.TS > 0 {
+			createdAt = time.Unix(item.TS, 0)
+		}
+		records = append(records, core.SpiderVisitRecord{
+			SpiderType: detection.SpiderType,
+			IP:         item.IP,
+			UA:         item.UA,
+			Domain:     item.Domain,
+			Path:       item.Path,
+			RespTime:   item.RespTime,
+			CacheHit:   cacheHit,
+			Status:     item.Status,
+			CreatedAt:  createdAt,
+			Bytes:      item.Bytes,
+		})
+	}
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] == '[' {
+			var items []spiderVisitItem
+			if err := json.Unmarshal(line, &items); err != nil {
+				invalid++
+				continue
+			}
+			for _, item := range items {
+				addItem(item)
+			}
+			continue
+		}
+		var item spiderVisitItem
+		if err := json.Unmarshal(line, &item); err != nil {
+			invalid++
+			continue
+		}
+		addItem(item)
+	}
+	if err := scanner.Err(); err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "invalid or too large body"})
+		return
+	}
+
+	accepted, dropped := h.ingester.Enqueue(records)
+
+	if dropped > 0 {
+		log.Warn().Int("dropped", dropped).Int("pending", h.ingester.Pending()).
+			Msg("Spider log buffer full, records dropped")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "ok",
+		"accepted": accepted,
+		"dropped":  dropped,
+		"skipped":  skipped,
+		"invalid":  invalid,
+	})
+}
@@ -32,59 +32,49 @@ type SystemLog struct {
 // History 查询历史日志
 // GET /api/logs/history
 func (h *LogsHandler) History(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	level := c.Query("level")
-	module := c.Query("module")
-
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	q, ok := bindListQuery(c, systemLogListSpec)
+	if !ok {
+		return
 	}
-	offset := (page - 1) * pageSize
 
 	if h.db == nil {
-		core.SuccessPaged(c, []SystemLog{}, 0, page, pageSize)
+		respondList(c, q, []SystemLog{}, 0, systemLogListKey)
 		return
 	}
 
 	// 构建查询
-	query := "SELECT id, level, module, message, created_at FROM system_logs WHERE 1=1"
-	countQuery := "SELECT COUNT(*) FROM system_logs WHERE 1=1"
-	args := []interface{}{}
-
-	if level != "" {
-		query += " AND level = ?"
-		countQuery += " AND level = ?"
-		args = append(args, level)
-	}
-	if module != "" {
-		query += " AND module = ?"
-		countQuery += " AND module = ?"
-		args = append(args, module)
-	}
-
-	query += " ORDER BY created_at DESC LIMIT ? OFFSET ?"
+	q.Eq("level", c.Query("level")).Eq("module", c.Query("module"))
 
 	// 获取总数
 	var total int64
-	if err := h.db.Get(&total, countQuery, args...); err != nil {
-		log.Warn().Err(err).Msg("Failed to count logs")
+	if q.NeedsTotal() {
+		countQuery, args := q.CountQuery("system_logs")
+		if err := h.db.Get(&total, countQuery, args...); err != nil {
+			log.Warn().Err(err).Msg("Failed to count logs")
+		}
 	}
 
 	// 获取列表
-	args = append(args, pageSize, offset)
+	query, args := q.Query("SELECT id, level, module, message, created_at FROM system_logs")
 	var logs []SystemLog
 	if err := h.db.Select(&logs, query, args...); err != nil {
 		log.Warn().Err(err).Msg("Failed to query logs")
 		logs = []SystemLog{}
 	}
 
-	core.SuccessPaged(c, logs, total, page, pageSize)
+	respondList(c, q, logs, total, systemLogListKey)
+}
+
+// systemLogListSpec 系统日志的排序和搜索字段（id 自增，按 id 倒序即时间倒序，大表用 cursor 分页）
+var systemLogListSpec = ListSpec{
+	SortFields:    map[string]string{"id": "id", "created_at": "created_at", "level": "level", "module": "module"},
+	DefaultSort:   "-id",
+	SearchColumns: []string{"message"},
+	KeysetColumn:  "id",
 }
 
+func systemLogListKey(l SystemLog) int64 { return int64(l.ID) }
+
 // Stats 获取日志统计
 // GET /api/logs/stats
 func (h *LogsHandler) Stats(c *gin.Context) {
@@ -2,32 +2,38 @@ package api
 
 import (
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
 
 	core "seo-generator/api/internal/service"
 	"seo-generator/api/pkg/config"
 )
 
+// sessionStore 服务端会话表，SetupRouter 中设置；为 nil 时不校验会话（仅验证签名）
+var sessionStore *core.SessionStore
+
+// sessionValid 校验 Token 对应的会话未被撤销
+func sessionValid(c *gin.Context, claims map[string]interface{}) bool {
+	if sessionStore == nil {
+		return true
+	}
+	jti, _ := claims["jti"].(string)
+	return sessionStore.Validate(c.Request.Context(), jti) == nil
+}
+
 // AuthMiddleware JWT 认证中间件
 func AuthMiddleware(secret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			core.AbortWithMessage(c, core.ErrUnauthorized, "缺少认证信息")
-			return
-		}
-
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			core.AbortWithMessage(c, core.ErrUnauthorized, "认证格式错误")
+		token, fromCookie, errMsg := requestToken(c)
+		if errMsg != "" {
+			core.AbortWithMessage(c, core.ErrUnauthorized, errMsg)
 			return
 		}
 
-		token := parts[1]
-
 		claims, err := core.VerifyToken(token, secret)
 		if err != nil {
 			if err == core.ErrTokenExpired {
@@ -38,6 +44,16 @@ func AuthMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
+		if !sessionValid(c, claims) {
+			core.AbortWithMessage(c, core.ErrUnauthorized, "会话已失效，请重新登录")
+			return
+		}
+
+		if fromCookie && !csrfValid(c) {
+			core.AbortWithMessage(c, core.ErrForbidden, "CSRF 校验失败")
+			return
+		}
+
 		c.Set("claims", claims)
 		c.Set("admin_id", claims["admin_id"])
 		c.Set("username", claims["sub"])
@@ -49,21 +65,72 @@ func AuthMiddleware(secret string) gin.HandlerFunc {
 // DualAuthMiddleware 双轨认证中间件
 // 同时支持 JWT 和 API Token 认证，任一通过即可
 func DualAuthMiddleware(secret string, db *sqlx.DB) gin.HandlerFunc {
+	return dualAuth(secret, db, nil)
+}
+
+// FeederAuthMiddleware 文章推送接口认证中间件
+// 在 DualAuthMiddleware 的基础上接受推送方 Token，只用于 /api/articles/add 和 /api/articles/batch
+func FeederAuthMiddleware(secret string, db *sqlx.DB) gin.HandlerFunc {
+	return dualAuth(secret, db, func(c *gin.Context, token string) bool {
+		feeder := lookupFeederToken(db, token)
+		if feeder == nil {
+			return false
+		}
+		c.Set("feeder", feeder)
+		return true
+	})
+}
+
+// CLIScopeMiddleware 命令行 Token（seogen-cli）认证中间件
+// 请求携带 cli_ 前缀的 Token（X-API-Token 或 Authorization: Bearer）时按 scope 校验，否则交给 next（AuthMiddleware / DualAuthMiddleware）
+// 命令行 Token 只能访问授予了 scope 的接口，不能代替管理员登录或全局 API Token
+func CLIScopeMiddleware(db *sqlx.DB, scope string, next gin.HandlerFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 1. 尝试 JWT 认证
-		authHeader := c.GetHeader("Authorization")
-		if authHeader != "" {
-			parts := strings.SplitN(authHeader, " ", 2)
+		token := c.GetHeader("X-API-Token")
+		if token == "" {
+			parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
 			if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
-				claims, err := core.VerifyToken(parts[1], secret)
-				if err == nil {
-					c.Set("claims", claims)
-					c.Set("admin_id", claims["admin_id"])
-					c.Set("username", claims["sub"])
-					c.Set("auth_type", "jwt")
-					c.Next()
+				token = parts[1]
+			}
+		}
+		if !strings.HasPrefix(token, cliTokenPrefix) {
+			next(c)
+			return
+		}
+
+		cliToken := lookupCLIToken(db, token)
+		if cliToken == nil {
+			core.AbortWithMessage(c, core.ErrUnauthorized, "无效或已过期的命令行 Token")
+			return
+		}
+		if !cliScopeAllows(cliToken.Scopes, scope, c.Request.Method) {
+			core.AbortWithMessage(c, core.ErrForbidden, "命令行 Token 没有 "+scope+" 权限")
+			return
+		}
+		c.Set("auth_type", "cli_token")
+		c.Set("username", "cli:"+cliToken.Name)
+		c.Next()
+	}
+}
+
+// dualAuth JWT / API Token 双轨认证，extra 非 nil 时用于校验与全局 API Token 不一致的 Token
+func dualAuth(secret string, db *sqlx.DB, extra func(c *gin.Context, token string) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 1. 尝试 JWT 认证（Authorization 请求头或 Cookie）
+		authHeader := c.GetHeader("Authorization")
+		if token, fromCookie, errMsg := requestToken(c); errMsg == "" {
+			claims, err := core.VerifyToken(token, secret)
+			if err == nil && sessionValid(c, claims) {
+				if fromCookie && !csrfValid(c) {
+					core.AbortWithMessage(c, core.ErrForbidden, "CSRF 校验失败")
 					return
 				}
+				c.Set("claims", claims)
+				c.Set("admin_id", claims["admin_id"])
+				c.Set("username", claims["sub"])
+				c.Set("auth_type", "jwt")
+				c.Next()
+				return
 			}
 		}
 
@@ -89,15 +156,17 @@ func DualAuthMiddleware(secret string, db *sqlx.DB) gin.HandlerFunc {
 			return
 		}
 
-		// 验证 API Token
+		// 验证 API Token：先比较全局 API Token，不一致时交给 extra 独立校验（不要求全局 API Token 已配置）
 		var storedToken string
-		if err := db.Get(&storedToken, "SELECT setting_value FROM system_settings WHERE setting_key = 'api_token'"); err != nil || storedToken == "" {
-			core.AbortWithMessage(c, core.ErrUnauthorized, "API Token 未配置")
-			return
+		if err := db.Get(&storedToken, "SELECT setting_value FROM system_settings WHERE setting_key = 'api_token'"); err != nil {
+			storedToken = ""
 		}
-
-		if apiToken != storedToken {
-			core.AbortWithMessage(c, core.ErrUnauthorized, "无效的 API Token")
+		if apiToken != storedToken && (extra == nil || !extra(c, apiToken)) {
+			msg := "无效的 API Token"
+			if storedToken == "" && extra == nil {
+				msg = "API Token 未配置"
+			}
+			core.AbortWithMessage(c, core.ErrUnauthorized, msg)
 			return
 		}
 
@@ -106,6 +175,70 @@ func DualAuthMiddleware(secret string, db *sqlx.DB) gin.HandlerFunc {
 	}
 }
 
+// ipAllowlistPrefixes 受 IP 白名单保护的路径前缀：管理接口和后台实时推送（WebSocket / SSE）
+var ipAllowlistPrefixes = []string{"/api/", "/ws/", "/sse/"}
+
+// IPAllowlistMiddleware 管理接口 IP 白名单中间件
+// 作用于 /api/*、/ws/* 和 /sse/*（/page 等公开路由不受影响），携带正确的 X-Admin-Bypass 应急令牌时放行
+func IPAllowlistMiddleware(allowlist *core.IPAllowlist) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if !ipAllowlistProtected(path) || !allowlist.Enabled() || allowlist.Exempt(path) {
+			c.Next()
+			return
+		}
+
+		ip := trustedClientIP(c, allowlist)
+		if allowlist.Allowed(ip) {
+			c.Next()
+			return
+		}
+
+		if allowlist.BypassValid(c.GetHeader("X-Admin-Bypass")) {
+			log.Warn().Str("ip", ip).Str("path", path).Msg("IP allowlist bypassed with emergency token")
+			c.Next()
+			return
+		}
+
+		allowlist.RecordRejection(core.IPRejection{
+			IP:        ip,
+			Method:    c.Request.Method,
+			Path:      path,
+			UserAgent: c.Request.UserAgent(),
+			Time:      time.Now(),
+		})
+		core.AbortWithMessage(c, core.ErrForbidden, "当前 IP 不在管理后台白名单中")
+	}
+}
+
+// ipAllowlistProtected 路径是否受 IP 白名单保护
+func ipAllowlistProtected(path string) bool {
+	for _, prefix := range ipAllowlistPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// trustedClientIP 取客户端 IP，仅当直连地址为受信代理（ip_allowlist.trusted_proxies）时才采用转发头，
+// 防止伪造 X-Forwarded-For 绕过白名单、登录限流和反爬
+// 受信代理时优先 X-Real-IP，其次 X-Forwarded-For 的最后一项（由代理追加，客户端无法伪造）
+func trustedClientIP(c *gin.Context, allowlist *core.IPAllowlist) string {
+	remoteIP := c.RemoteIP()
+	if allowlist == nil || !allowlist.TrustedProxy(remoteIP) {
+		return remoteIP
+	}
+	if realIP := strings.TrimSpace(c.GetHeader("X-Real-IP")); realIP != "" {
+		return realIP
+	}
+	if forwardedFor := c.GetHeader("X-Forwarded-For"); forwardedFor != "" {
+		parts := strings.Split(forwardedFor, ",")
+		return strings.TrimSpace(parts[len(parts)-1])
+	}
+	return remoteIP
+}
+
 // DependencyInjectionMiddleware 依赖注入中间件
 // 将数据库、Redis 连接、配置和调度器注入到 Gin context 中，供 Handler 使用
 func DependencyInjectionMiddleware(db *sqlx.DB, rdb *redis.Client, cfg *config.Config, scheduler *core.Scheduler) gin.HandlerFunc {
@@ -0,0 +1,475 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	core "seo-generator/api/internal/service"
+)
+
+// routeDoc 路由文档注解，在 openapi_routes.go 中登记
+type routeDoc struct {
+	Summary string
+	Body    interface{}  // 请求体类型（零值实例），同时用于请求校验
+	Query   []queryParam // 查询参数
+	Public  bool         // 无需认证
+}
+
+// queryParam 查询参数说明
+type queryParam struct {
+	Name        string
+	Type        string // string / integer / boolean
+	Description string
+	Required    bool
+}
+
+// jsonSchema OpenAPI 3 Schema 的子集
+type jsonSchema struct {
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Nullable             bool                   `json:"nullable,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf 通过反射从 Go 类型生成 Schema，读取 json 和 binding 标签
+func schemaOf(t reflect.Type) *jsonSchema {
+	if t.Kind() == reflect.Ptr {
+		s := schemaOf(t.Elem())
+		s.Nullable = true
+		return s
+	}
+	if t == timeType {
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: schemaOf(t.Elem())}
+	case reflect.Struct:
+		s := &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}}
+		addStructFields(s, t)
+		return s
+	}
+	// interface{} 等任意类型
+	return &jsonSchema{}
+}
+
+// addStructFields 将结构体字段加入 Schema（匿名嵌入字段展开）
+func addStructFields(s *jsonSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := schemaOf(f.Type)
+		for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+			switch {
+			case rule == "required":
+				s.Required = append(s.Required, name)
+			case strings.HasPrefix(rule, "oneof="):
+				prop.Enum = strings.Fields(strings.TrimPrefix(rule, "oneof="))
+			case strings.HasPrefix(rule, "min=") && prop.Type == "string":
+				if n, err := strconv.Atoi(strings.TrimPrefix(rule, "min=")); err == nil {
+					prop.MinLength = &n
+				}
+			}
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// validate 按 Schema 校验 JSON 解码后的值（json.Decoder 需开启 UseNumber）
+func (s *jsonSchema) validate(v interface{}, path string) error {
+	if v == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return fmt.Errorf("%s 不能为 null", path)
+	}
+
+	switch s.Type {
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s 应为字符串", path)
+		}
+		if s.MinLength != nil && len([]rune(str)) < *s.MinLength {
+			return fmt.Errorf("%s 长度不能少于 %d", path, *s.MinLength)
+		}
+		if len(s.Enum) > 0 {
+			for _, e := range s.Enum {
+				if e == str {
+					return nil
+				}
+			}
+			return fmt.Errorf("%s 取值无效，可选: %s", path, strings.Join(s.Enum, ", "))
+		}
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s 应为整数", path)
+		}
+		if _, err := n.Int64(); err != nil {
+			return fmt.Errorf("%s 应为整数", path)
+		}
+	case "number":
+		if _, ok := v.(json.Number); !ok {
+			return fmt.Errorf("%s 应为数字", path)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s 应为布尔值", path)
+		}
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s 应为数组", path)
+		}
+		for i, item := range arr {
+			if err := s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s 应为对象", path)
+		}
+		for _, name := range s.Required {
+			if _, exists := obj[name]; !exists {
+				return fmt.Errorf("缺少必填字段 %s", joinPath(path, name))
+			}
+		}
+		for name, val := range obj {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil {
+					prop = s.AdditionalProperties
+				} else {
+					continue // 未登记字段忽略，与 gin 绑定行为一致
+				}
+			}
+			if err := prop.validate(val, joinPath(path, name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// ============================================
+// Spec 生成
+// ============================================
+
+// OpenAPISpec 从 gin 已注册的路由和 routeDocs 注解生成的 OpenAPI 3 文档
+type OpenAPISpec struct {
+	engine  *gin.Engine
+	title   string
+	version string
+
+	once sync.Once
+	spec []byte
+}
+
+// NewOpenAPISpec 创建文档生成器，路由在首次请求时读取（此时所有路由已注册）
+func NewOpenAPISpec(engine *gin.Engine, title, version string) *OpenAPISpec {
+	return &OpenAPISpec{engine: engine, title: title, version: version}
+}
+
+// Build 生成文档 JSON
+func (o *OpenAPISpec) Build() []byte {
+	o.once.Do(func() {
+		o.spec, _ = json.Marshal(o.build())
+	})
+	return o.spec
+}
+
+func (o *OpenAPISpec) build() map[string]interface{} {
+	routes := o.engine.Routes()
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	paths := map[string]map[string]interface{}{}
+	for _, rt := range routes {
+		oaPath, pathParams := toOpenAPIPath(rt.Path)
+		doc := routeDocs[rt.Method+" "+rt.Path]
+
+		summary := doc.Summary
+		if summary == "" {
+			summary = handlerShortName(rt.Handler)
+		}
+
+		params := make([]map[string]interface{}, 0, len(pathParams)+len(doc.Query))
+		for _, p := range pathParams {
+			params = append(params, map[string]interface{}{
+				"name": p, "in": "path", "required": true, "schema": &jsonSchema{Type: "string"},
+			})
+		}
+		for _, q := range doc.Query {
+			params = append(params, map[string]interface{}{
+				"name": q.Name, "in": "query", "required": q.Required,
+				"description": q.Description, "schema": &jsonSchema{Type: q.Type},
+			})
+		}
+
+		op := map[string]interface{}{
+			"summary":     summary,
+			"operationId": operationID(rt.Method, rt.Path),
+			"tags":        []string{routeTag(rt.Path)},
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "成功",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": map[string]string{"$ref": "#/components/schemas/Response"}},
+					},
+				},
+			},
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		if doc.Body != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemaOf(reflect.TypeOf(doc.Body))},
+				},
+			}
+		}
+		if doc.Public || !strings.HasPrefix(rt.Path, "/api/") {
+			op["security"] = []interface{}{}
+		}
+
+		if paths[oaPath] == nil {
+			paths[oaPath] = map[string]interface{}{}
+		}
+		paths[oaPath][strings.ToLower(rt.Method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]string{
+			"title":   o.title,
+			"version": o.version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]string{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+			"schemas": map[string]interface{}{
+				"Response": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]*jsonSchema{
+						"code":       {Type: "integer"},
+						"message":    {Type: "string"},
+						"data":       {},
+						"timestamp":  {Type: "integer"},
+						"request_id": {Type: "string"},
+					},
+				},
+			},
+		},
+		"security": []map[string][]string{{"bearerAuth": {}}},
+	}
+}
+
+// toOpenAPIPath 将 gin 路径参数 :id / *path 转换为 {id} / {path}
+func toOpenAPIPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			name := seg[1:]
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID 由方法和路径生成唯一 ID，如 PUT /api/sites/:id -> put_api_sites_id
+func operationID(method, path string) string {
+	id := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(method)+path)
+	return strings.Join(strings.FieldsFunc(id, func(r rune) bool { return r == '_' }), "_")
+}
+
+// routeTag 以 /api/ 之后的第一段作为分组标签
+func routeTag(path string) string {
+	trimmed := strings.TrimPrefix(path, "/api/")
+	if trimmed == path {
+		return "public"
+	}
+	return strings.SplitN(trimmed, "/", 2)[0]
+}
+
+// handlerShortName 未登记摘要时使用 handler 方法名，如 (*TemplatesHandler).List-fm -> TemplatesHandler.List
+func handlerShortName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSuffix(name, "-fm")
+	return strings.NewReplacer("(", "", ")", "", "*", "").Replace(name)
+}
+
+// ServeSpec GET /api/openapi.json
+func (o *OpenAPISpec) ServeSpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", o.Build())
+}
+
+// swaggerUIPage Swagger UI 页面（静态资源走 CDN）
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+  <meta charset="utf-8">
+  <title>SEO Generator API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui", persistAuthorization: true });
+  </script>
+</body>
+</html>`
+
+// ServeUI GET /api/docs
+func (o *OpenAPISpec) ServeUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
+
+// ============================================
+// 请求校验中间件
+// ============================================
+
+// maxValidatedBodySize 参与校验的请求体上限，超过则跳过校验交由 handler 处理
+const maxValidatedBodySize = 10 << 20
+
+// requestSchemas 缓存各路由请求体 Schema
+var requestSchemas sync.Map // "METHOD /path" -> *jsonSchema
+
+// OpenAPIValidationMiddleware 按 routeDocs 中登记的请求体类型校验 JSON 请求
+// 未登记请求体的路由直接放行
+func OpenAPIValidationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+		if !strings.HasPrefix(c.ContentType(), "application/json") {
+			c.Next()
+			return
+		}
+
+		key := c.Request.Method + " " + c.FullPath()
+		doc, ok := routeDocs[key]
+		if !ok || doc.Body == nil {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxValidatedBodySize+1))
+		if err != nil {
+			core.AbortWithMessage(c, core.ErrInvalidParam, "读取请求体失败")
+			return
+		}
+		if len(body) > maxValidatedBodySize {
+			c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+			c.Next()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var schema *jsonSchema
+		if cached, ok := requestSchemas.Load(key); ok {
+			schema = cached.(*jsonSchema)
+		} else {
+			schema = schemaOf(reflect.TypeOf(doc.Body))
+			requestSchemas.Store(key, schema)
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var payload interface{}
+		if err := dec.Decode(&payload); err != nil {
+			core.AbortWithMessage(c, core.ErrInvalidParam, "请求体不是有效的 JSON")
+			return
+		}
+		if err := schema.validate(payload, ""); err != nil {
+			core.AbortWithMessage(c, core.ErrInvalidParam, err.Error())
+			return
+		}
+
+		c.Next()
+	}
+}
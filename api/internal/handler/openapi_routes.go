@@ -0,0 +1,519 @@
+package api
+
+import (
+	"sort"
+	"strings"
+)
+
+// activityRangeParams 内容增量统计接口的公共查询参数
+var activityRangeParams = []queryParam{
+	{Name: "start", Type: "string", Description: "开始日期（2006-01-02），默认 end 前 29 天"},
+	{Name: "end", Type: "string", Description: "结束日期，默认昨天"},
+	{Name: "group_id", Type: "integer", Description: "按分组过滤"},
+}
+
+// listQueryParams 使用 ListQuery 的列表接口的公共查询参数（sort 可选字段取自 spec）
+func listQueryParams(spec ListSpec, extra ...queryParam) []queryParam {
+	fields := make([]string, 0, len(spec.SortFields))
+	for name := range spec.SortFields {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	params := []queryParam{
+		{Name: "page", Type: "integer", Description: "页码（cursor 分页时忽略）"},
+		{Name: "page_size", Type: "integer", Description: "每页数量，默认 20，最多 100"},
+		{Name: "sort", Type: "string", Description: strings.Join(fields, " / ") + "，- 前缀降序，默认 " + spec.DefaultSort},
+	}
+	if len(spec.SearchColumns) > 0 {
+		params = append(params, queryParam{Name: "search", Type: "string", Description: "模糊搜索 " + strings.Join(spec.SearchColumns, " / ")})
+	}
+	if spec.KeysetColumn != "" {
+		params = append(params, queryParam{Name: "cursor", Type: "string", Description: "keyset 分页：首页传空值，之后传响应中的 next_cursor（不返回 total，仅支持按 id 排序）"})
+	}
+	return append(params, extra...)
+}
+
+// routeDocs 路由文档注解，键为 "METHOD 路由模式"（与 gin 注册的路径一致）
+// 未登记的路由仍会出现在 /api/openapi.json 中，摘要取 handler 方法名；
+// 登记了 Body 的路由在开启 openapi.validate_requests 时会按 Schema 校验请求体
+var routeDocs = map[string]routeDoc{
+	// 认证
+	"POST /api/auth/login":  {Summary: "登录", Body: LoginRequest{}, Public: true},
+	"POST /api/auth/logout": {Summary: "退出登录", Public: true},
+	"GET /api/auth/csrf":    {Summary: "获取 CSRF Token（Cookie 会话）", Public: true},
+	"GET /api/auth/profile": {Summary: "当前用户信息（含语言偏好）"},
+	"PUT /api/auth/locale":  {Summary: "设置当前管理员的提示语言（zh-CN / en-US，为空按 Accept-Language）", Body: LocaleRequest{}},
+	"GET /api/i18n/messages": {Summary: "文案目录（含错误码文案），语言优先级：locale 参数 > Accept-Language", Query: []queryParam{
+		{Name: "locale", Type: "string", Description: "zh-CN / en-US"},
+	}},
+	"POST /api/auth/change-password": {Summary: "修改密码（其他会话将被下线）", Body: ChangePasswordRequest{}},
+	"POST /api/auth/ws-ticket":       {Summary: "签发实时推送连接票据（一次性，连接 /ws/* 或 /sse/* 时以 ?ticket= 携带）"},
+	"GET /api/auth/sessions": {Summary: "在线会话列表", Query: []queryParam{
+		{Name: "admin_id", Type: "integer", Description: "管理员 ID，默认当前管理员"},
+	}},
+	"DELETE /api/auth/sessions/:id":         {Summary: "撤销会话"},
+	"POST /api/auth/sessions/revoke-others": {Summary: "下线除当前会话外的全部会话"},
+	"GET /api/auth/login-attempts": {Summary: "登录尝试记录", Query: []queryParam{
+		{Name: "ip", Type: "string"},
+		{Name: "username", Type: "string"},
+		{Name: "outcome", Type: "string", Description: "success / failure / locked"},
+		{Name: "page", Type: "integer"},
+		{Name: "page_size", Type: "integer"},
+	}},
+	"GET /api/auth/login-anomalies": {Summary: "可疑登录模式（密码喷洒、分布式爆破）", Query: []queryParam{
+		{Name: "hours", Type: "integer", Description: "统计最近 N 小时，默认 24"},
+	}},
+	"POST /api/auth/login-unlock": {Summary: "解除登录锁定", Body: LoginUnlockRequest{}},
+
+	// 仪表盘
+	"GET /api/dashboard/stats": {Summary: "仪表盘统计"},
+	"GET /api/dashboard/overview": {Summary: "仪表盘概览（统计、缓存、缓存池、蜘蛛、告警、系统信息，分区独立失败，缓存 5 秒）", Query: []queryParam{
+		{Name: "refresh", Type: "boolean", Description: "为 true 时跳过服务端缓存"},
+	}},
+	"GET /api/dashboard/cache-stats/series": {Summary: "按域名的缓存统计时间序列", Query: []queryParam{
+		{Name: "domain", Type: "string", Description: "域名，为空时汇总全部"},
+		{Name: "start", Type: "string", Description: "开始时间（RFC3339 或 2006-01-02 15:04:05）"},
+		{Name: "end", Type: "string", Description: "结束时间"},
+		{Name: "format", Type: "string", Description: "grafana 时输出 Grafana JSON 数据源格式"},
+	}},
+	"GET /api/dashboard/activity/articles": {Summary: "每日新增文章（按数据源，source_id 为 0 表示手工上传）", Query: activityRangeParams},
+	"GET /api/dashboard/activity/keywords": {Summary: "每日新增关键词（按分组）", Query: activityRangeParams},
+	"GET /api/dashboard/activity/pools": {Summary: "标题/正文池每日剩余、新增和消耗", Query: append([]queryParam{
+		{Name: "kind", Type: "string", Description: "title（默认）或 content"},
+	}, activityRangeParams...)},
+	"POST /api/dashboard/activity/rollup": {Summary: "立即汇总内容增量（后台作业），date 为空时补齐未汇总的日期", Query: []queryParam{
+		{Name: "date", Type: "string", Description: "汇总的日期（2006-01-02），需早于今天"},
+	}},
+
+	// 列表接口（分页、排序、搜索见 ListQuery）
+	"GET /api/keywords/list": {Summary: "关键词列表", Query: listQueryParams(keywordListSpec,
+		queryParam{Name: "group_id", Type: "integer", Description: "分组 ID，默认 1"},
+	)},
+	"GET /api/images/urls/list": {Summary: "图片URL列表", Query: listQueryParams(imageListSpec,
+		queryParam{Name: "group_id", Type: "integer", Description: "分组 ID，默认 1"},
+	)},
+	"GET /api/articles/list": {Summary: "文章列表", Query: listQueryParams(articleListSpec,
+		queryParam{Name: "group_id", Type: "integer", Description: "分组 ID，默认 1"},
+	)},
+	"GET /api/sites": {Summary: "站点列表", Query: listQueryParams(siteListSpec,
+		queryParam{Name: "site_group_id", Type: "integer"},
+		queryParam{Name: "status", Type: "integer"},
+	)},
+	"GET /api/spider-projects": {Summary: "爬虫项目列表", Query: listQueryParams(spiderProjectListSpec,
+		queryParam{Name: "status", Type: "string"},
+		queryParam{Name: "enabled", Type: "integer"},
+	)},
+	"GET /api/spiders/logs": {Summary: "蜘蛛访问日志", Query: listQueryParams(spiderLogListSpec,
+		queryParam{Name: "spider_type", Type: "string"},
+		queryParam{Name: "domain", Type: "string"},
+	)},
+	"GET /api/logs/history": {Summary: "系统日志", Query: listQueryParams(systemLogListSpec,
+		queryParam{Name: "level", Type: "string"},
+		queryParam{Name: "module", Type: "string"},
+	)},
+
+	// 模板
+	"GET /api/templates": {Summary: "模板列表", Query: listQueryParams(templateListSpec,
+		queryParam{Name: "status", Type: "integer"},
+		queryParam{Name: "site_group_id", Type: "integer"},
+	)},
+	"GET /api/templates/options":     {Summary: "模板下拉选项", Query: []queryParam{{Name: "site_group_id", Type: "integer"}}},
+	"GET /api/templates/health":      {Summary: "模板渲染健康状态（错误预算）"},
+	"GET /api/templates/functions":   {Summary: "扩展模板函数和函数包"},
+	"POST /api/templates/check":      {Summary: "模板数据契约检查（引用的变量和函数是否存在）", Body: TemplateCheckRequest{}},
+	"GET /api/templates/:id":         {Summary: "模板详情"},
+	"GET /api/templates/:id/sites":   {Summary: "使用此模板的站点"},
+	"POST /api/templates":            {Summary: "创建模板", Body: TemplateCreateRequest{}},
+	"PUT /api/templates/:id":         {Summary: "更新模板（携带 version 时启用并发编辑检测）", Body: TemplateUpdateRequest{}},
+	"DELETE /api/templates/:id":      {Summary: "删除模板"},
+	"POST /api/templates/:id/enable": {Summary: "恢复已降级的模板"},
+	"PUT /api/templates/:id/tags":    {Summary: "设置模板标签、分类和目标引擎", Body: TemplateTagsRequest{}},
+	"GET /api/templates/library": {Summary: "模板库（按标签、分类、引擎、最近使用、渲染成本筛选）", Query: []queryParam{
+		{Name: "tag", Type: "string", Description: "标签，逗号分隔，需全部命中"},
+		{Name: "category", Type: "string"},
+		{Name: "engine", Type: "string", Description: "目标搜索引擎，generic 表示通用模板"},
+		{Name: "keyword", Type: "string", Description: "匹配名称、显示名称和描述"},
+		{Name: "site_group_id", Type: "integer"},
+		{Name: "status", Type: "integer"},
+		{Name: "used_within_days", Type: "integer", Description: "最近 N 天内渲染过"},
+		{Name: "unused_days", Type: "integer", Description: "超过 N 天未渲染"},
+		{Name: "cost_min", Type: "integer", Description: "最小渲染成本（单次渲染函数调用数）"},
+		{Name: "cost_max", Type: "integer", Description: "最大渲染成本"},
+		{Name: "sort", Type: "string", Description: "id/name/last_used/render_count/sites_count/render_cost"},
+		{Name: "order", Type: "string", Description: "asc/desc"},
+		{Name: "page", Type: "integer"},
+		{Name: "page_size", Type: "integer"},
+	}},
+	"GET /api/templates/tags": {Summary: "模板标签、分类、引擎汇总"},
+	"GET /api/templates/unused": {Summary: "未使用模板报告", Query: []queryParam{
+		{Name: "days", Type: "integer", Description: "未渲染天数，默认 30"},
+		{Name: "include_idle", Type: "boolean", Description: "包含有站点绑定但长期未渲染的模板，默认 true"},
+	}},
+
+	// 关键词
+	"POST /api/keywords/groups":       {Summary: "创建关键词分组", Body: GroupCreateRequest{}},
+	"PUT /api/keywords/groups/:id":    {Summary: "更新关键词分组", Body: GroupUpdateRequest{}},
+	"PUT /api/keywords/:id":           {Summary: "更新关键词", Body: KeywordUpdateRequest{}},
+	"DELETE /api/keywords/batch":      {Summary: "批量删除关键词", Body: BatchIdsRequest{}},
+	"DELETE /api/keywords/delete-all": {Summary: "删除全部关键词", Body: DeleteAllRequest{}},
+	"PUT /api/keywords/batch/status":  {Summary: "批量更新关键词状态", Body: BatchStatusRequest{}},
+	"PUT /api/keywords/batch/move":    {Summary: "批量移动关键词", Body: BatchMoveRequest{}},
+	"POST /api/keywords/add":          {Summary: "添加关键词（支持 API Token）", Body: KeywordAddRequest{}},
+	"POST /api/keywords/batch":        {Summary: "批量添加关键词（支持 API Token）", Body: KeywordBatchAddRequest{}},
+	"GET /api/keywords/feedback": {Summary: "关键词抓取反馈得分", Query: []queryParam{
+		{Name: "group_id", Type: "integer", Description: "关键词分组 ID，默认 1"},
+		{Name: "limit", Type: "integer", Description: "默认 100，最大 1000"},
+		{Name: "order", Type: "string", Description: "desc（默认，得分最高）/ asc（得分最低）"},
+	}},
+	"POST /api/keywords/feedback/compute": {Summary: "立即重新计算关键词抓取得分"},
+	"GET /api/keywords/candidates": {Summary: "搜索词候选列表（按展现量倒序）", Query: []queryParam{
+		{Name: "status", Type: "string", Description: "pending（默认）/ approved / rejected"},
+		{Name: "source", Type: "string", Description: "baidu_tongji / gsc"},
+		{Name: "search", Type: "string", Description: "搜索词模糊匹配"},
+		{Name: "page", Type: "integer", Description: "页码"},
+		{Name: "page_size", Type: "integer", Description: "每页数量，默认 50"},
+	}},
+	"POST /api/keywords/candidates/approve": {Summary: "审核通过并加入关键词分组", Body: KeywordCandidateApproveRequest{}},
+	"POST /api/keywords/candidates/reject":  {Summary: "拒绝搜索词候选", Body: KeywordCandidateRejectRequest{}},
+	"POST /api/keywords/candidates/import":  {Summary: "立即从百度统计 / GSC 导入搜索词", Body: KeywordCandidateImportRequest{}},
+	"POST /api/keywords/expand":             {Summary: "提交长尾关键词扩展作业（进度见 /api/jobs）", Body: KeywordExpandRequest{}},
+	"POST /api/keywords/normalize":          {Summary: "规范化并去重关键词（后台作业，dry_run 预览各规则变更数和样例）", Body: KeywordNormalizeRequest{}},
+	"GET /api/keywords/stopwords": {Summary: "获取入库过滤停用词", Query: []queryParam{
+		{Name: "group_id", Type: "integer", Description: "关键词分组 ID，0（默认）为全部分组生效的停用词"},
+	}},
+	"PUT /api/keywords/stopwords": {Summary: "替换入库过滤停用词（去掉停用词后长度不足 min_length 的关键词被拒绝）", Body: KeywordStopwordsRequest{}},
+
+	// 图片
+	"POST /api/images/groups":          {Summary: "创建图片分组", Body: ImageGroupCreateRequest{}},
+	"PUT /api/images/groups/:id":       {Summary: "更新图片分组", Body: ImageGroupUpdateRequest{}},
+	"PUT /api/images/urls/:id":         {Summary: "更新图片 URL", Body: ImageURLUpdateRequest{}},
+	"GET /api/images/signing":          {Summary: "图片 URL 签名配置（CDN 鉴权，不含密钥）"},
+	"POST /api/images/signing/preview": {Summary: "按分组签名配置生成签名 URL，传入已签名 URL 时同时返回校验结果", Body: ImageSignPreviewRequest{}},
+	"DELETE /api/images/batch":         {Summary: "批量删除图片", Body: ImageBatchIdsRequest{}},
+	"DELETE /api/images/delete-all":    {Summary: "删除全部图片", Body: ImageDeleteAllRequest{}},
+	"PUT /api/images/batch/status":     {Summary: "批量更新图片状态", Body: ImageBatchStatusRequest{}},
+	"PUT /api/images/batch/move":       {Summary: "批量移动图片", Body: ImageBatchMoveRequest{}},
+	"POST /api/images/urls/add":        {Summary: "添加图片 URL（支持 API Token）", Body: ImageAddRequest{}},
+	"POST /api/images/urls/batch":      {Summary: "批量添加图片 URL（支持 API Token）", Body: ImageBatchAddRequest{}},
+
+	// 文章
+	"POST /api/articles/groups":         {Summary: "创建文章分组", Body: ArticleGroupCreateRequest{}},
+	"PUT /api/articles/groups/:id":      {Summary: "更新文章分组", Body: ArticleGroupUpdateRequest{}},
+	"PUT /api/articles/:id":             {Summary: "更新文章", Body: ArticleUpdateRequest{}},
+	"DELETE /api/articles/batch/delete": {Summary: "批量删除文章", Body: ArticleBatchIdsRequest{}},
+	"DELETE /api/articles/delete-all":   {Summary: "删除全部文章", Body: ArticleDeleteAllRequest{}},
+	"PUT /api/articles/batch/status":    {Summary: "批量更新文章状态", Body: ArticleBatchStatusRequest{}},
+	"PUT /api/articles/batch/move":      {Summary: "批量移动文章", Body: ArticleBatchMoveRequest{}},
+	"POST /api/articles/add":            {Summary: "添加文章（支持 API Token）", Body: ArticleAddRequest{}},
+	"POST /api/articles/batch": {Summary: "批量添加文章（支持 API Token，冲突策略 skip / overwrite / version，流式逐篇写入并返回逐条结果，超限返回 413）", Body: ArticleBatchAddRequest{}, Query: []queryParam{
+		{Name: "conflict_strategy", Type: "string", Description: "skip / overwrite / version，优先于请求体和 API Token 设置"},
+		{Name: "conflict_key", Type: "string", Description: "title / content_hash / source_url"},
+	}},
+	"POST /api/settings/feeder-tokens":    {Summary: "创建推送方 API Token（各自的批量导入冲突策略，明文只返回一次）", Body: FeederTokenRequest{}},
+	"PUT /api/settings/feeder-tokens/:id": {Summary: "更新推送方 API Token 的名称、冲突策略和启用状态", Body: FeederTokenRequest{}},
+	"POST /api/settings/cli-tokens":       {Summary: "创建 seogen-cli 命令行 Token（按 scope 授权，明文只返回一次）", Body: CLITokenRequest{}},
+	"PUT /api/settings/cli-tokens/:id":    {Summary: "更新命令行 Token 的名称、权限范围和启用状态", Body: CLITokenRequest{}},
+	"PUT /api/articles/sanitize-policies": {Summary: "设置文章分组入库清洗策略（基准 URL、图片代理、允许的 iframe 域名）", Body: SanitizePolicyRequest{}},
+	"POST /api/articles/sanitize/test":    {Summary: "按分组策略预览正文清洗结果（不入库）", Body: SanitizeTestRequest{}},
+	"PUT /api/articles/rewrite-policies":  {Summary: "设置文章分组 LLM 改写（是否启用、改写标题 / 正文）", Body: RewritePolicyRequest{}},
+	"POST /api/articles/rewrite":          {Summary: "手动重跑 LLM 改写（指定文章或按分组改写未改写过的文章，后台作业）", Body: RewriteRunRequest{}},
+
+	// 违禁词
+	"POST /api/banned-words":         {Summary: "添加违禁词", Body: BannedWordRequest{}},
+	"POST /api/banned-words/batch":   {Summary: "批量添加违禁词", Body: BannedWordBatchRequest{}},
+	"PUT /api/banned-words/policies": {Summary: "设置分组过滤策略", Body: FilterPolicyRequest{}},
+	"POST /api/banned-words/test":    {Summary: "测试过滤效果", Body: FilterTestRequest{}},
+	"PUT /api/banned-words/:id":      {Summary: "更新违禁词", Body: BannedWordRequest{}},
+
+	// 系统设置
+	"GET /api/settings/ip-allowlist": {Summary: "管理接口 IP 白名单设置及最近拒绝记录"},
+	"PUT /api/settings/ip-allowlist": {Summary: "更新管理接口 IP 白名单", Body: IPAllowlistRequest{}},
+
+	// 站点与站群
+	"POST /api/sites":                {Summary: "创建站点", Body: SiteCreateRequest{}},
+	"PUT /api/sites/:id":             {Summary: "更新站点（携带 version 时启用并发编辑检测）", Body: SiteUpdateRequest{}},
+	"DELETE /api/sites/batch/delete": {Summary: "批量删除站点", Body: SiteBatchIdsRequest{}},
+	"PUT /api/sites/batch/status":    {Summary: "批量更新站点状态", Body: SiteBatchStatusRequest{}},
+	"POST /api/site-groups":          {Summary: "创建站群", Body: SiteGroupCreateRequest{}},
+	"PUT /api/site-groups/:id":       {Summary: "更新站群", Body: SiteGroupUpdateRequest{}},
+	"GET /api/site-groups/:id/export": {Summary: "导出站群配方（模板、分组、池配置）", Query: []queryParam{
+		{Name: "include_data", Type: "boolean", Description: "同时导出关键词和图片数据"},
+		{Name: "download", Type: "boolean", Description: "以附件形式下载"},
+	}},
+	"POST /api/site-groups/import": {Summary: "导入站群配方", Body: SiteGroupImportRequest{}},
+
+	// 数据加工 Worker 代码文件
+	"POST /api/content-worker/files/*path":          {Summary: "创建文件或目录", Body: CreateRequest{}},
+	"PUT /api/content-worker/files/*path":           {Summary: "保存文件", Body: SaveRequest{}},
+	"PATCH /api/content-worker/files/*path":         {Summary: "移动或重命名", Body: MoveRequest{}},
+	"GET /api/content-worker/search":                {Summary: "跨文件搜索字面量或正则（q、regex、case_sensitive、path、max_results）"},
+	"GET /api/content-worker/audit":                 {Summary: "文件操作审计记录（创建、保存、上传、删除、移动，含越界拒绝）"},
+	"GET /api/content-worker/trash":                 {Summary: "内容处理代码回收站条目"},
+	"POST /api/content-worker/trash/:token/restore": {Summary: "按恢复令牌恢复删除的文件或目录"},
+	"DELETE /api/content-worker/trash/:token":       {Summary: "永久删除回收站条目"},
+
+	// 爬虫项目文件搜索与回收站
+	"GET /api/spider-projects/:id/search":                {Summary: "项目文件中搜索字面量或正则（q、regex、case_sensitive、path、max_results）"},
+	"GET /api/spider-projects/:id/trash":                 {Summary: "项目文件回收站条目"},
+	"POST /api/spider-projects/:id/trash/:token/restore": {Summary: "按恢复令牌恢复删除的项目文件或目录"},
+	"DELETE /api/spider-projects/:id/trash/:token":       {Summary: "永久删除项目回收站条目"},
+
+	// 正文池
+	"GET /api/cache-pool/config/groups":                    {Summary: "分组级池配置覆盖及生效值"},
+	"PUT /api/cache-pool/config/groups":                    {Summary: "保存分组级池配置（null 沿用全局，立即生效）", Body: PoolGroupConfigRequest{}},
+	"DELETE /api/cache-pool/config/groups/:type/:group_id": {Summary: "删除分组级池配置，恢复全局配置"},
+	"GET /api/cache-pool/updates":                          {Summary: "消费标记批量写库指标（积压、标记延迟）"},
+	"POST /api/cache-pool/titles/reserve":                  {Summary: "预留标题（外部消费方）", Body: PoolReserveRequest{}},
+	"POST /api/cache-pool/contents/reserve":                {Summary: "预留正文，超时未确认放回池中", Body: PoolReserveRequest{}},
+	"GET /api/cache-pool/reservations":                     {Summary: "预留统计"},
+	"GET /api/cache-pool/reservations/:id":                 {Summary: "未确认的预留详情"},
+	"POST /api/cache-pool/reservations/:id/ack":            {Summary: "确认预留（ids 为空时确认全部，其余放回池中）", Body: PoolReservationAckRequest{}},
+	"POST /api/cache-pool/reservations/:id/release":        {Summary: "释放预留，条目放回池中"},
+	"GET /api/cache-pool/forecast": {Summary: "正文池消耗预测", Query: []queryParam{
+		{Name: "refresh", Type: "boolean", Description: "true 时立即重新统计"},
+	}},
+
+	// 备份与恢复
+	"POST /api/admin/backup": {Summary: "下载加密备份（流式）"},
+	"POST /api/admin/restore": {Summary: "上传备份恢复（默认 dry_run 只返回差异）", Query: []queryParam{
+		{Name: "dry_run", Type: "boolean", Description: "false 时执行恢复，默认 true"},
+	}},
+	"GET /api/admin/backups":       {Summary: "本地备份列表"},
+	"POST /api/admin/backups/run":  {Summary: "立即备份到本地目录（按配置上传 S3）"},
+	"GET /api/admin/backups/:name": {Summary: "下载本地备份文件"},
+	"POST /api/admin/backups/:name/restore": {Summary: "从本地备份恢复", Query: []queryParam{
+		{Name: "dry_run", Type: "boolean", Description: "false 时执行恢复，默认 true"},
+	}},
+
+	// 蜘蛛内容策略
+	"GET /api/spider-strategies": {Summary: "蜘蛛内容策略列表", Query: []queryParam{
+		{Name: "site_group_id", Type: "integer", Description: "按站群筛选"},
+	}},
+	"POST /api/spider-strategies":       {Summary: "添加蜘蛛内容策略", Body: SpiderStrategyRequest{}},
+	"PUT /api/spider-strategies/:id":    {Summary: "更新蜘蛛内容策略", Body: SpiderStrategyRequest{}},
+	"DELETE /api/spider-strategies/:id": {Summary: "删除蜘蛛内容策略"},
+	"GET /api/spider-strategies/stats": {Summary: "按站群和蜘蛛类型拆分的请求统计", Query: []queryParam{
+		{Name: "site_group_id", Type: "integer", Description: "按站群筛选"},
+	}},
+
+	// 分词与关键词密度
+	"GET /api/segmenter/words": {Summary: "自定义分词词典", Query: []queryParam{
+		{Name: "search", Type: "string", Description: "按词模糊搜索"},
+		{Name: "page", Type: "integer", Description: "页码"},
+		{Name: "page_size", Type: "integer", Description: "每页条数，最多 500"},
+	}},
+	"POST /api/segmenter/words":           {Summary: "批量添加自定义词（已存在则更新词频）", Body: SegmentWordsAddRequest{}},
+	"DELETE /api/segmenter/words/:id":     {Summary: "删除自定义词"},
+	"GET /api/segmenter/stats":            {Summary: "分词词典统计"},
+	"POST /api/segmenter/cut":             {Summary: "分词", Body: SegmentCutRequest{}},
+	"POST /api/segmenter/density":         {Summary: "关键词密度报告（文本或原始文章）", Body: KeywordDensityRequest{}},
+	"POST /api/segmenter/spintax/preview": {Summary: "伪原创预览（展开 spintax 并替换同义词）", Body: SegmentCutRequest{}},
+	"POST /api/segmenter/spintax/reload":  {Summary: "重新加载同义词表"},
+
+	// URL 生成策略
+	"GET /api/url-strategies":                   {Summary: "各站群生效的 URL 生成策略"},
+	"PUT /api/url-strategies/:site_group_id":    {Summary: "设置站群 URL 生成策略", Body: URLStrategyRequest{}},
+	"DELETE /api/url-strategies/:site_group_id": {Summary: "删除站群 URL 策略（恢复默认）"},
+	"GET /api/url-strategies/preview": {Summary: "预览站点 URL（与模板、站内链接和 sitemap 一致）", Query: []queryParam{
+		{Name: "domain", Type: "string", Description: "站点域名"},
+		{Name: "count", Type: "integer", Description: "预览条数，默认 20，最多 200"},
+	}},
+
+	// 冷正文归档
+	"GET /api/content-archives": {Summary: "归档批次列表", Query: []queryParam{
+		{Name: "status", Type: "string", Description: "exporting / uploaded / purged / restored / failed"},
+		{Name: "page", Type: "integer", Description: "页码"},
+		{Name: "page_size", Type: "integer", Description: "每页数量"},
+	}},
+	"POST /api/content-archives/run":         {Summary: "立即执行归档（后台作业，返回 job_id）"},
+	"POST /api/content-archives/:id/restore": {Summary: "按批次恢复正文（后台作业，返回 job_id）", Body: ContentRestoreRequest{}},
+
+	// 站点域名健康
+	"GET /api/sites/health": {Summary: "域名健康汇总（DNS / HTTP / 证书，含连续失败次数）", Query: []queryParam{
+		{Name: "unhealthy", Type: "boolean", Description: "为 true 时只列出不健康的域名"},
+	}},
+	"POST /api/sites/health/check": {Summary: "立即检查（不传 domain 时后台检查全部启用站点）", Query: []queryParam{
+		{Name: "domain", Type: "string", Description: "只检查该域名并返回结果"},
+	}},
+	"GET /api/sites/health/:site_id/history": {Summary: "站点最近的检查记录", Query: []queryParam{
+		{Name: "limit", Type: "integer", Description: "条数，默认 100，最多 1000"},
+	}},
+
+	// 站点验证文件
+	"GET /api/sites/:id/verification-files":             {Summary: "站点的搜索引擎验证文件"},
+	"POST /api/sites/:id/verification-files":            {Summary: "上传验证文件（同名覆盖，在域名根目录返回）", Body: VerificationFileRequest{}},
+	"DELETE /api/sites/:id/verification-files/:file_id": {Summary: "删除验证文件"},
+	"GET /api/sites/:id/redirects":                      {Summary: "站点的重定向规则（含命中次数）"},
+	"GET /api/sites/:id/redirects/test": {Summary: "按当前生效的规则测试路径（不计入命中）", Query: []queryParam{
+		{Name: "path", Type: "string", Description: "路径（可含查询串）", Required: true},
+	}},
+	"POST /api/sites/:id/redirects":                {Summary: "创建重定向规则", Body: SiteRedirectRequest{}},
+	"PUT /api/sites/:id/redirects/:redirect_id":    {Summary: "更新重定向规则", Body: SiteRedirectRequest{}},
+	"DELETE /api/sites/:id/redirects/:redirect_id": {Summary: "删除重定向规则"},
+
+	// 固定页面
+	"GET /api/pinned-pages": {Summary: "固定页面列表（不含 HTML）", Query: []queryParam{
+		{Name: "domain", Type: "string", Description: "域名"},
+		{Name: "page", Type: "integer", Description: "页码"},
+		{Name: "page_size", Type: "integer", Description: "每页数量"},
+	}},
+	"POST /api/pinned-pages":       {Summary: "创建固定页面（清除该 URL 已缓存页面）", Body: PinnedPageRequest{}},
+	"GET /api/pinned-pages/:id":    {Summary: "固定页面详情"},
+	"PUT /api/pinned-pages/:id":    {Summary: "更新固定页面（清除缓存）", Body: PinnedPageRequest{}},
+	"DELETE /api/pinned-pages/:id": {Summary: "删除固定页面（恢复正常渲染）"},
+
+	// 页面级 robots 规则
+	"GET /api/robots-policies": {Summary: "robots 规则列表", Query: []queryParam{
+		{Name: "site_group_id", Type: "integer", Description: "站群 ID（0 为全局规则），为空时列出全部"},
+	}},
+	"GET /api/robots-policies/test": {Summary: "按当前生效的规则评估 URL（返回判定和每条规则的匹配过程）", Query: []queryParam{
+		{Name: "url", Type: "string", Description: "完整 URL（按域名确定站群）"},
+		{Name: "site_group_id", Type: "integer", Description: "站群 ID（未传 url 时使用）"},
+		{Name: "path", Type: "string", Description: "路径和查询串（未传 url 时使用）"},
+	}},
+	"POST /api/robots-policies":       {Summary: "创建 robots 规则", Body: RobotsPolicyRequest{}},
+	"PUT /api/robots-policies/:id":    {Summary: "更新 robots 规则", Body: RobotsPolicyRequest{}},
+	"DELETE /api/robots-policies/:id": {Summary: "删除 robots 规则"},
+	"GET /api/template-rollouts": {Summary: "模板灰度列表（进行中的附带新旧模板两组的渲染统计）", Query: []queryParam{
+		{Name: "site_group_id", Type: "integer", Description: "按站群过滤"},
+	}},
+	"POST /api/template-rollouts":              {Summary: "创建模板灰度（按 URL 哈希取一定比例的请求使用新模板）", Body: TemplateRolloutRequest{}},
+	"GET /api/template-rollouts/:id":           {Summary: "模板灰度详情"},
+	"PUT /api/template-rollouts/:id/percent":   {Summary: "调整新模板比例（两组统计重新计数）", Body: RolloutPercentRequest{}},
+	"POST /api/template-rollouts/:id/complete": {Summary: "完成灰度，站群所有站点切换为新模板"},
+	"POST /api/template-rollouts/:id/rollback": {Summary: "回滚灰度，所有请求恢复使用原模板", Body: RolloutRollbackRequest{}},
+	"GET /api/template-snippets":               {Summary: "模板片段列表（含引用它的模板和片段）"},
+	"POST /api/template-snippets":              {Summary: "创建模板片段（模板中用 {{ include \"name\" arg1 arg2 }} 引用）", Body: TemplateSnippetRequest{}},
+	"GET /api/template-snippets/:name":         {Summary: "模板片段详情"},
+	"GET /api/template-snippets/:name/usage":   {Summary: "直接或间接引用片段的模板"},
+	"PUT /api/template-snippets/:name":         {Summary: "更新模板片段，引用它的模板立即重新加载", Body: TemplateSnippetUpdateRequest{}},
+	"DELETE /api/template-snippets/:name":      {Summary: "删除模板片段（仍被引用时拒绝）"},
+
+	// WASM 渲染扩展
+	"GET /api/wasm-extensions":                   {Summary: "WASM 模块加载状态和站群绑定"},
+	"POST /api/wasm-extensions/reload":           {Summary: "立即重新加载模块和绑定"},
+	"PUT /api/wasm-extensions/:site_group_id":    {Summary: "设置站群 WASM 扩展", Body: WASMBindingRequest{}},
+	"DELETE /api/wasm-extensions/:site_group_id": {Summary: "删除站群 WASM 扩展"},
+
+	// 内容保鲜
+	"GET /api/freshness/policies":                   {Summary: "各站群保鲜策略"},
+	"PUT /api/freshness/policies/:site_group_id":    {Summary: "设置站群保鲜策略", Body: FreshnessPolicyRequest{}},
+	"DELETE /api/freshness/policies/:site_group_id": {Summary: "删除站群保鲜策略"},
+	"POST /api/freshness/run":                       {Summary: "立即执行保鲜（后台作业，返回 job_id）", Body: FreshnessRunRequest{}},
+	"GET /api/freshness/history": {Summary: "刷新记录（指定 domain + path 查看单个 URL）", Query: []queryParam{
+		{Name: "site_group_id", Type: "integer", Description: "站群 ID"},
+		{Name: "domain", Type: "string", Description: "域名"},
+		{Name: "path", Type: "string", Description: "页面路径（精确匹配）"},
+		{Name: "status", Type: "string", Description: "refreshed / failed"},
+		{Name: "page", Type: "integer", Description: "页码"},
+		{Name: "page_size", Type: "integer", Description: "每页数量"},
+	}},
+
+	// 模拟发布日期
+	"GET /api/publish-dates":                   {Summary: "各站群生效的发布日期分布"},
+	"PUT /api/publish-dates/:site_group_id":    {Summary: "设置站群发布日期分布", Body: PublishDatePolicyRequest{}},
+	"DELETE /api/publish-dates/:site_group_id": {Summary: "删除站群发布日期分布（恢复默认）"},
+	"GET /api/publish-dates/preview": {Summary: "预览站点 URL 的发布日期及星期/月份分布", Query: []queryParam{
+		{Name: "domain", Type: "string", Description: "站点域名"},
+		{Name: "count", Type: "integer", Description: "统计的 URL 数，默认 200，最多 5000"},
+	}},
+
+	// 功能开关
+	"GET /api/feature-flags":         {Summary: "功能开关列表"},
+	"POST /api/feature-flags":        {Summary: "创建功能开关（已存在时覆盖）", Body: FeatureFlagRequest{}},
+	"PUT /api/feature-flags/:key":    {Summary: "更新功能开关（全局/站群覆盖/灰度百分比）", Body: FeatureFlagRequest{}},
+	"DELETE /api/feature-flags/:key": {Summary: "删除功能开关"},
+	"GET /api/feature-flags/:key/evaluate": {Summary: "计算开关对站群和域名是否生效", Query: []queryParam{
+		{Name: "site_group_id", Type: "integer", Description: "站群ID"},
+		{Name: "unit", Type: "string", Description: "灰度分桶键（通常为域名）"},
+	}},
+
+	// 运行时日志级别
+	"POST /api/admin/system/alerts/:id/ack":     {Summary: "确认告警（规则告警按通知渠道推送 acknowledged 事件）"},
+	"POST /api/admin/system/alerts/:id/resolve": {Summary: "手动解决告警（规则条件仍满足时重新计时后再次触发）"},
+	"GET /api/admin/system/canary":              {Summary: "渲染自检状态（各站群最近结果和连续失败次数）"},
+	"GET /api/admin/system/canary/history": {Summary: "渲染自检记录", Query: []queryParam{
+		{Name: "site_group_id", Type: "integer", Description: "站群 ID，为空时不限"},
+		{Name: "failed", Type: "boolean", Description: "只返回失败记录"},
+		{Name: "limit", Type: "integer", Description: "默认 100，最大 1000"},
+	}},
+	"POST /api/admin/system/canary/run": {Summary: "立即执行渲染自检", Query: []queryParam{
+		{Name: "site_group_id", Type: "integer", Description: "只检查该站群，为空时检查全部启用站群"},
+	}},
+
+	// 自定义告警规则
+	"GET /api/alert-rules":         {Summary: "告警规则列表"},
+	"GET /api/alert-rules/metrics": {Summary: "规则可用的指标、比较符、级别和通知渠道"},
+	"POST /api/alert-rules":        {Summary: "创建告警规则", Body: AlertRuleRequest{}},
+	"PUT /api/alert-rules/:id":     {Summary: "更新告警规则", Body: AlertRuleRequest{}},
+	"DELETE /api/alert-rules/:id":  {Summary: "删除告警规则"},
+
+	"GET /api/admin/system/metrics": {Summary: "实时指标（http 为按路由模板统计的延迟分位数、状态码和错误率）"},
+	"GET /api/metrics/prometheus":   {Summary: "Prometheus 文本格式的接口指标（延迟直方图、状态码计数、处理中请求数）"},
+	"GET /api/admin/system/metrics/history": {Summary: "历史指标（不带时间范围时为内存最近快照，带时间范围时查询持久化的降采样数据）", Query: []queryParam{
+		{Name: "limit", Type: "integer", Description: "内存快照条数，默认 60"},
+		{Name: "start", Type: "string", Description: "开始时间（Unix 秒、2006-01-02 15:04:05 或 RFC3339）"},
+		{Name: "end", Type: "string", Description: "结束时间，默认当前"},
+		{Name: "range", Type: "string", Description: "相对 end 的时间跨度，如 12h、7d（未指定 start 时使用）"},
+		{Name: "resolution", Type: "string", Description: "1m / 5m / 1h，默认按时间跨度自动选择"},
+	}},
+	"GET /api/admin/logging":         {Summary: "获取日志级别和模块级别覆盖"},
+	"PUT /api/admin/logging":         {Summary: "修改日志级别（保存到系统设置，重启后恢复）", Body: LoggingRequest{}},
+	"POST /api/admin/replay":         {Summary: "回放访问日志或蜘蛛日志中的页面请求到目标实例，生成状态码/耗时/内容哈希对比报告（后台作业）", Body: ReplayRunRequest{}},
+	"POST /api/admin/replay/preview": {Summary: "预览将回放的请求（条数和前 20 条）", Body: ReplayRunRequest{}},
+	"GET /api/admin/faults":          {Summary: "故障注入规则和注入次数（仅调试模式）"},
+	"PUT /api/admin/faults":          {Summary: "设置故障注入规则：db / redis / template 的延迟和错误率（仅调试模式）", Body: FaultRequest{}},
+	"DELETE /api/admin/faults": {Summary: "清除故障注入规则（仅调试模式）", Query: []queryParam{
+		{Name: "target", Type: "string", Description: "db / redis / template，为空时清除全部"},
+	}},
+
+	// 反采集
+	"GET /api/admin/anti-scrape":                 {Summary: "反采集统计、封禁列表和高频 IP"},
+	"POST /api/admin/anti-scrape/block":          {Summary: "手动封禁 IP", Body: AntiScrapeBlockRequest{}},
+	"DELETE /api/admin/anti-scrape/block/:ip":    {Summary: "解除 IP 封禁"},
+	"GET /api/admin/anti-scrape/bad-bots":        {Summary: "蜜罐标记的坏爬虫（IP/UA、命中次数、到期赦免时间）"},
+	"DELETE /api/admin/anti-scrape/bad-bots/:ip": {Summary: "提前赦免坏爬虫（删除标记并解除封禁）"},
+
+	// TDK 自动补全
+	"GET /api/admin/auto-tdk":        {Summary: "TDK 自动补全统计（按模板、站群的注入页面数）"},
+	"GET /api/admin/css-obfuscation": {Summary: "CSS 混淆统计和开关状态"},
+	"GET /api/admin/outbound":        {Summary: "外部 HTTP 调用配置和按主机的调用、重试、熔断统计"},
+	"POST /api/admin/outbound/reset": {Summary: "手动恢复熔断", Query: []queryParam{
+		{Name: "host", Type: "string", Description: "只恢复该主机（host:port），为空恢复全部"},
+	}},
+	"GET /api/admin/query-cache":    {Summary: "后台列表查询缓存命中率、失效次数和按语句的命中统计"},
+	"DELETE /api/admin/query-cache": {Summary: "清空列表查询缓存（同时通知其他实例）"},
+	"GET /api/admin/db-pools":       {Summary: "主库和只读副本连接池统计、复制延迟、读路由计数"},
+	"POST /api/admin/bootstrap": {Summary: "按初始化清单创建缺失的默认站群、分组、管理员、示例模板和定时任务（已存在的不修改）", Query: []queryParam{
+		{Name: "dry_run", Type: "boolean", Description: "只返回将要执行的动作，不写入"},
+	}},
+	"GET /api/admin/egress/logs": {Summary: "出站请求审计明细（按时间倒序，默认最近 24 小时）", Query: []queryParam{
+		{Name: "host", Type: "string", Description: "目标主机（含端口）"},
+		{Name: "purpose", Type: "string", Description: "用途：robots_check / error_report / clickhouse / keyword_suggest / keyword_import / backup_s3 / alert_webhook"},
+		{Name: "failed", Type: "boolean", Description: "只看网络错误和 4xx/5xx"},
+		{Name: "start", Type: "string"},
+		{Name: "end", Type: "string"},
+		{Name: "page", Type: "integer"},
+		{Name: "page_size", Type: "integer"},
+	}},
+	"GET /api/admin/egress/daily": {Summary: "出站请求每日汇总（按主机和用途，默认最近 30 天）", Query: []queryParam{
+		{Name: "host", Type: "string"},
+		{Name: "purpose", Type: "string"},
+		{Name: "start", Type: "string"},
+		{Name: "end", Type: "string"},
+	}},
+	"GET /api/admin/link-audit": {Summary: "站外链接审计统计（汇总和按域名，按站外链接数降序）", Query: []queryParam{
+		{Name: "domain", Type: "string", Description: "只返回该域名"},
+	}},
+	"DELETE /api/admin/link-audit": {Summary: "清空链接审计统计", Query: []queryParam{
+		{Name: "domain", Type: "string", Description: "只清空该域名，为空清空全部"},
+	}},
+
+	// 文档
+	"GET /api/openapi.json": {Summary: "OpenAPI 文档", Public: true},
+	"GET /api/docs":         {Summary: "Swagger UI", Public: true},
+
+	// Nginx Lua 上报
+	"GET /api/log/spider":        {Summary: "记录蜘蛛访问（Nginx 缓存命中）", Public: true},
+	"POST /api/log/spider/batch": {Summary: "批量记录蜘蛛访问", Public: true},
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	core "seo-generator/api/internal/service"
+)
+
+// FieldConflict 并发编辑冲突的字段差异
+type FieldConflict struct {
+	Field  string      `json:"field"`
+	Yours  interface{} `json:"yours"`  // 本次提交的值
+	Theirs interface{} `json:"theirs"` // 数据库当前值
+}
+
+// ConflictInfo 409 响应数据，供前端展示差异并合并
+type ConflictInfo struct {
+	ExpectedVersion *int            `json:"expected_version,omitempty"`
+	CurrentVersion  int             `json:"current_version"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	Current         interface{}     `json:"current"`
+	Conflicts       []FieldConflict `json:"conflicts"`
+}
+
+// EditPrecondition 乐观锁前置条件，version 优先，其次 updated_at
+// 两者都未提供时不做检查（兼容旧客户端）
+type EditPrecondition struct {
+	Version   *int       `json:"version"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// whereClause 返回附加到 UPDATE 的条件和参数
+func (p EditPrecondition) whereClause() (string, []interface{}) {
+	switch {
+	case p.Version != nil:
+		return " AND version = ?", []interface{}{*p.Version}
+	case p.UpdatedAt != nil:
+		// updated_at 为秒级精度，按秒比较
+		return " AND updated_at = ?", []interface{}{p.UpdatedAt.Truncate(time.Second)}
+	}
+	return "", nil
+}
+
+// active 是否需要检查
+func (p EditPrecondition) active() bool {
+	return p.Version != nil || p.UpdatedAt != nil
+}
+
+// addFieldConflict 提交值与当前值不同时记录差异
+// theirs 可以是值或指针，指针为 nil 时视为 null
+func addFieldConflict[T any](conflicts *[]FieldConflict, field string, yours *T, theirs interface{}) {
+	if yours == nil {
+		return
+	}
+	current := theirs
+	if rv := reflect.ValueOf(theirs); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			current = nil
+		} else {
+			current = rv.Elem().Interface()
+		}
+	}
+	if current != nil && reflect.DeepEqual(*yours, current) {
+		return
+	}
+	*conflicts = append(*conflicts, FieldConflict{Field: field, Yours: *yours, Theirs: current})
+}
+
+// failConflict 返回 409 冲突响应
+func failConflict(c *gin.Context, info ConflictInfo) {
+	if info.Conflicts == nil {
+		info.Conflicts = []FieldConflict{}
+	}
+	core.FailWithData(c, core.ErrConflict, info)
+}
@@ -4,14 +4,16 @@ package api
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"html/template"
+	"net"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 
 	"seo-generator/api/internal/model"
 	core "seo-generator/api/internal/service"
@@ -20,105 +22,315 @@ import (
 
 // PageHandler handles /page requests
 type PageHandler struct {
-	db               *sqlx.DB
-	cfg              *config.Config
-	spiderDetector   *core.SpiderDetector
-	siteCache        *core.SiteCache
-	templateCache    *core.TemplateCache
-	htmlCache        *core.HTMLCache
-	templateRenderer *core.TemplateRenderer
-	funcsManager     *core.TemplateFuncsManager
-	poolManager      *core.PoolManager
+	db                *sqlx.DB
+	cfg               *config.Config
+	spiderDetector    *core.SpiderDetector
+	siteCache         *core.SiteCache
+	templateCache     *core.TemplateCache
+	htmlCache         core.HTMLCache
+	templateRenderer  *core.TemplateRenderer
+	funcsManager      *core.TemplateFuncsManager
+	poolManager       *core.PoolManager
+	logIngester       *core.SpiderLogIngester
+	templateHealth    *core.TemplateHealth
+	templateUsage     *core.TemplateUsage
+	strategies        *core.SpiderStrategyResolver
+	antiScrape        *core.AntiScraper
+	keywordFeedback   *core.KeywordFeedback
+	urlStrategies     *core.URLStrategyManager
+	publishDates      *core.PublishDates
+	autoTDK           *core.AutoTDK
+	pinnedPages       *core.PinnedPages
+	verificationFiles *core.VerificationFiles
+	extensions        *core.WASMExtensions
+	cssObfuscator     *core.CSSObfuscator
+	robotsPolicies    *core.RobotsPolicies
+	archives          *core.ArchivePages
+	rollouts          *core.TemplateRollouts
+	linkAuditor       *core.LinkAuditor
+	excerpts          *core.ExcerptGenerator
+	allowlist         *core.IPAllowlist // 受信代理列表，用于解析访客 IP
+	spintax           *core.SpintaxRewriter
+}
+
+// PageHandlerDeps holds the dependencies of PageHandler
+type PageHandlerDeps struct {
+	DB                *sqlx.DB
+	Config            *config.Config
+	SiteCache         *core.SiteCache
+	TemplateCache     *core.TemplateCache
+	HTMLCache         core.HTMLCache
+	TemplateFuncs     *core.TemplateFuncsManager
+	PoolManager       *core.PoolManager
+	LogIngester       *core.SpiderLogIngester
+	TemplateHealth    *core.TemplateHealth
+	TemplateUsage     *core.TemplateUsage
+	Strategies        *core.SpiderStrategyResolver
+	AntiScrape        *core.AntiScraper
+	KeywordFeedback   *core.KeywordFeedback
+	URLStrategies     *core.URLStrategyManager
+	PublishDates      *core.PublishDates
+	AutoTDK           *core.AutoTDK
+	PinnedPages       *core.PinnedPages
+	VerificationFiles *core.VerificationFiles
+	Extensions        *core.WASMExtensions
+	CSSObfuscator     *core.CSSObfuscator
+	RobotsPolicies    *core.RobotsPolicies
+	Archives          *core.ArchivePages
+	Rollouts          *core.TemplateRollouts
+	LinkAuditor       *core.LinkAuditor
+	Excerpts          *core.ExcerptGenerator
+	Allowlist         *core.IPAllowlist // 受信代理列表，用于解析访客 IP
+	Spintax           *core.SpintaxRewriter
 }
 
 // NewPageHandler creates a new page handler
-func NewPageHandler(
-	db *sqlx.DB,
-	cfg *config.Config,
-	siteCache *core.SiteCache,
-	templateCache *core.TemplateCache,
-	htmlCache *core.HTMLCache,
-	funcsManager *core.TemplateFuncsManager,
-	poolManager *core.PoolManager,
-) *PageHandler {
+func NewPageHandler(deps PageHandlerDeps) *PageHandler {
 	return &PageHandler{
-		db:               db,
-		cfg:              cfg,
-		spiderDetector:   core.GetSpiderDetector(),
-		siteCache:        siteCache,
-		templateCache:    templateCache,
-		htmlCache:        htmlCache,
-		templateRenderer: core.NewTemplateRenderer(funcsManager),
-		funcsManager:     funcsManager,
-		poolManager:      poolManager,
+		db:                deps.DB,
+		cfg:               deps.Config,
+		spiderDetector:    core.GetSpiderDetector(),
+		siteCache:         deps.SiteCache,
+		templateCache:     deps.TemplateCache,
+		htmlCache:         deps.HTMLCache,
+		templateRenderer:  core.NewTemplateRenderer(deps.TemplateFuncs),
+		funcsManager:      deps.TemplateFuncs,
+		poolManager:       deps.PoolManager,
+		logIngester:       deps.LogIngester,
+		templateHealth:    deps.TemplateHealth,
+		templateUsage:     deps.TemplateUsage,
+		strategies:        deps.Strategies,
+		antiScrape:        deps.AntiScrape,
+		keywordFeedback:   deps.KeywordFeedback,
+		urlStrategies:     deps.URLStrategies,
+		publishDates:      deps.PublishDates,
+		autoTDK:           deps.AutoTDK,
+		pinnedPages:       deps.PinnedPages,
+		verificationFiles: deps.VerificationFiles,
+		extensions:        deps.Extensions,
+		cssObfuscator:     deps.CSSObfuscator,
+		robotsPolicies:    deps.RobotsPolicies,
+		archives:          deps.Archives,
+		rollouts:          deps.Rollouts,
+		linkAuditor:       deps.LinkAuditor,
+		excerpts:          deps.Excerpts,
+		allowlist:         deps.Allowlist,
+		spintax:           deps.Spintax,
 	}
 }
 
+// directPageSkipPrefixes 直接对外服务时不作为页面渲染的路径（管理接口和实时推送保持 404）
+var directPageSkipPrefixes = []string{"/api/", "/ws/", "/sse/"}
+
+// ServeDirect 直接对外服务时的兜底路由：域名取自 Host，路径取自 URL.Path，UA 取自 User-Agent，
+// 按与 Nginx 改写后的 /page 请求相同的流程渲染
+func (h *PageHandler) ServeDirect(c *gin.Context) {
+	reqPath := c.Request.URL.Path
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	for _, prefix := range directPageSkipPrefixes {
+		if strings.HasPrefix(reqPath, prefix) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+			return
+		}
+	}
+
+	ua, path, domain := directPageParams(c.Request)
+	h.servePage(c, ua, path, domain)
+}
+
+// directPageParams 从直接访问的请求中取 UA、路径和域名（去掉端口并转小写）
+func directPageParams(r *http.Request) (ua, path, domain string) {
+	domain = r.Host
+	if hostname, _, err := net.SplitHostPort(domain); err == nil {
+		domain = hostname
+	}
+	return r.UserAgent(), r.URL.Path, strings.ToLower(domain)
+}
+
 // ServePage handles the /page endpoint
 func (h *PageHandler) ServePage(c *gin.Context) {
-	startTime := time.Now()
+	h.servePage(c, c.Query("ua"), c.Query("path"), c.Query("domain"))
+}
 
-	// Get query parameters
-	ua := c.Query("ua")
-	path := c.Query("path")
-	domain := c.Query("domain")
+// servePage 渲染 domain + path 对应的页面
+func (h *PageHandler) servePage(c *gin.Context, ua, path, domain string) {
+	startTime := time.Now()
+	ctx := c.Request.Context()
+	logger := core.LoggerFrom(ctx)
+	requestID := core.RequestIDFromContext(ctx)
+	// 内部重新渲染（内容保鲜），不经过防护和缓存读写
+	override := core.PageOverrideFrom(ctx)
 
 	// Validate required parameters
 	if ua == "" || path == "" || domain == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required parameters: ua, path, domain"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required parameters: ua, path, domain", "request_id": requestID})
 		return
 	}
 
-	clientIP := getClientIP(c)
+	// 访客 IP 只在直连地址为受信代理（Nginx）时采用转发头，反采集判定、挑战令牌和蜜罐封禁都基于它
+	clientIP := trustedClientIP(c, h.allowlist)
 
 	// Spider detection
 	t1 := time.Now()
 	detection := h.spiderDetector.Detect(ua)
 	spiderTime := time.Since(t1)
+	core.SetAccessSpider(c, detection.SpiderType)
 
-	// Non-spider handling
-	if !detection.IsSpider {
-		if h.cfg.SpiderDetector.Return404ForNonSpider {
-			c.AbortWithStatus(http.StatusNotFound)
+	// 搜索引擎验证文件：域名根目录下的指定文件直接返回（不经过防护和模板，不写缓存）
+	if vf := h.verificationFiles.Get(domain, path); vf != nil && override == nil {
+		core.SetAccessRender(c, true, 0)
+		c.Data(http.StatusOK, vf.ContentType, []byte(vf.Content))
+		return
+	}
+
+	// 蜜罐链接和 robots.txt（robots.txt 中禁止抓取蜜罐前缀）
+	if override == nil {
+		if h.antiScrape.IsTrapPath(path) {
+			h.serveTrap(c, ctx, detection, domain, path, clientIP, ua)
+			return
+		}
+		if path == "/robots.txt" {
+			core.SetAccessRender(c, true, 0)
+			h.serveRobots(c)
+			return
+		}
+	}
+
+	// 固定页面：手写 HTML 直接返回（不经过防护和模板），固定文章进入模板渲染；固定页面不写缓存
+	pinned := h.pinnedPages.Get(domain, path)
+	if pinned != nil {
+		if override != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Pinned page", "request_id": requestID})
+			return
+		}
+		if pinned.Mode == core.PinnedModeHTML {
+			h.servePinnedHTML(c, detection, clientIP, ua, domain, path, pinned, startTime)
+			return
+		}
+	}
+
+	// Non-spider handling: anti-scrape first, then per-site policy (redirect/page/block),
+	// content falls through to render
+	if !detection.IsSpider && override == nil {
+		if h.guardScrape(c, ctx, logger, domain, path, clientIP, ua) || h.handleHuman(c, ctx, logger, domain, clientIP, ua) {
 			return
 		}
-		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte("<html><body>Hello</body></html>"))
-		return
 	}
 
 	// Get site config
 	t3 := time.Now()
-	ctx := context.Background()
 	site, err := h.siteCache.Get(ctx, domain)
 	if err != nil {
-		log.Error().Err(err).Str("domain", domain).Msg("Failed to get site config")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		logger.Error().Err(err).Str("domain", domain).Msg("Failed to get site config")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error", "request_id": requestID})
 		return
 	}
 	if site == nil {
-		log.Warn().Str("domain", domain).Msg("Domain not registered")
-		c.JSON(http.StatusForbidden, gin.H{"error": "Domain not registered"})
+		logger.Warn().Str("domain", domain).Msg("Domain not registered")
+		c.JSON(http.StatusForbidden, gin.H{"error": "Domain not registered", "request_id": requestID})
 		return
 	}
 	siteTime := time.Since(t3)
 
+	// 站点重定向规则：旧路径 301/302 到新地址（内部重新渲染不跳转）
+	if override == nil {
+		if m := h.siteCache.MatchRedirect(site, path); m != nil {
+			core.SetAccessRender(c, true, 0)
+			if detection.IsSpider {
+				go h.logSpiderVisit(logger, detection, clientIP, ua, domain, path, true, int(time.Since(startTime).Milliseconds()), m.Code, 0)
+			}
+			c.Redirect(m.Code, m.Location)
+			return
+		}
+	}
+
+	// sitemap 由 URL 策略实时生成，不进入页面缓存
+	if h.serveSitemap(c, site, path) {
+		return
+	}
+
+	// 归档列表页：前缀下的无效路径（分类不存在、页码超出）返回 404
+	archive := h.archives.Match(site, siteKeywordGroupID(site), path)
+	if archive == nil && pinned == nil && h.archives.IsArchivePath(path) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Archive page not found", "request_id": requestID})
+		return
+	}
+
+	// 按蜘蛛类型解析内容策略，配置了策略的站群使用独立缓存命名空间
+	spiderType := detection.SpiderType
+	if override != nil {
+		spiderType = override.SpiderType
+	}
+	strategy := h.strategies.Resolve(site.SiteGroupID, spiderType)
+	cachePath := strategy.CachePath(path)
+
+	// 模板灰度：比例内的 URL 改用新模板，使用灰度独立的缓存命名空间（蜘蛛策略指定了模板或归档页时不参与）
+	rollout := h.rollouts.Assign(site.SiteGroupID, domain, path)
+	if s := strategy.Strategy; rollout != nil && (archive != nil || s != nil && s.Template.Valid && s.Template.String != "") {
+		rollout = nil
+	}
+	cachePath = rollout.CachePath(cachePath)
+
+	// 共享缓存命中（Redis/混合后端，其他实例已渲染过）直接返回，避免重复渲染；
+	// 命名空间缓存不会被 Nginx 直接命中，任何后端都需要在这里读取
+	if override == nil && pinned == nil && (cachePath != path || h.htmlCache.Backend() != core.HTMLCacheBackendDisk) {
+		if cached, ok := h.htmlCache.Get(domain, cachePath); ok {
+			elapsed := time.Since(startTime)
+			core.SetAccessRender(c, true, 0)
+			if detection.IsSpider {
+				h.strategies.Record(site.SiteGroupID, detection.SpiderType, true)
+				go h.logSpiderVisit(logger, detection, clientIP, ua, domain, path, true, int(elapsed.Milliseconds()), 200, len(cached))
+			}
+			c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(cached))
+			return
+		}
+	}
+
 	// Get template content from cache (no DB query)
 	t4 := time.Now()
 	templateName := site.Template
 	if templateName == "" {
 		templateName = "download_site"
 	}
+	siteTemplate := templateName
+	if rollout != nil && rollout.Canary {
+		templateName = rollout.Rollout.Template
+	}
+	if s := strategy.Strategy; s != nil && s.Template.Valid && s.Template.String != "" {
+		templateName = s.Template.String
+	}
+	if archive != nil && h.archives.Template() != "" {
+		templateName = h.archives.Template()
+	}
 
 	// Use templateCache for fast lookup
-	templateData := h.templateCache.Get(templateName, site.SiteGroupID)
-	if templateData == nil || templateData.Content == "" {
-		// Fallback to DB query for newly added templates
-		var err error
-		templateData, err = h.templateCache.GetWithFallback(ctx, templateName, site.SiteGroupID)
-		if err != nil || templateData == nil || templateData.Content == "" {
-			log.Error().Err(err).Str("template", templateName).Msg("Template not found or empty")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Template not found"})
-			return
+	templateData, err := h.loadTemplate(ctx, templateName, site.SiteGroupID)
+	if (err != nil || templateData == nil || templateData.Content == "") && templateName != siteTemplate {
+		logger.Warn().Err(err).Str("template", templateName).Str("spider", detection.SpiderType).
+			Msg("Strategy template unavailable, using site template")
+		templateName = siteTemplate
+		templateData, err = h.loadTemplate(ctx, templateName, site.SiteGroupID)
+	}
+	if err != nil || templateData == nil || templateData.Content == "" {
+		logger.Error().Err(err).Str("template", templateName).Msg("Template not found or empty")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Template not found", "request_id": requestID})
+		return
+	}
+
+	// 模板因渲染错误预算超限已降级，改用站群备用模板
+	if h.templateHealth != nil && h.templateHealth.IsDegraded(templateData.ID) {
+		fallbackName := h.templateHealth.FallbackTemplate(site.SiteGroupID)
+		if fallbackName != templateName {
+			fallback, ferr := h.loadTemplate(ctx, fallbackName, site.SiteGroupID)
+			if ferr == nil && fallback != nil && fallback.Content != "" {
+				templateName, templateData = fallbackName, fallback
+			} else {
+				logger.Warn().Err(ferr).Str("template", templateName).Str("fallback", fallbackName).
+					Msg("Fallback template unavailable, using degraded template")
+			}
 		}
 	}
 
@@ -127,6 +339,9 @@ func (h *PageHandler) ServePage(c *gin.Context) {
 	if site.KeywordGroupID.Valid {
 		keywordGroupID = int(site.KeywordGroupID.Int64)
 	}
+	if s := strategy.Strategy; s != nil && s.KeywordGroupID.Valid {
+		keywordGroupID = int(s.KeywordGroupID.Int64)
+	}
 
 	// Get article group ID
 	articleGroupID := 1
@@ -141,22 +356,58 @@ func (h *PageHandler) ServePage(c *gin.Context) {
 	}
 
 	// Get title and content from pool
-	var title, content string
-	title, err = h.poolManager.Pop("titles", keywordGroupID)
-	if err != nil {
-		log.Warn().Err(err).Int("group", keywordGroupID).Msg("Failed to get title from pool")
+	var title, content, summary string
+	if pinned != nil {
+		title, content = pinned.Title, pinned.Content
+	} else {
+		// 只读渲染查看队首条目，不从池中取出
+		popItem := h.poolManager.PopItem
+		if override != nil && override.DryRun {
+			popItem = h.poolManager.PeekItem
+		}
+		var item core.PoolItem
+		item, err = popItem(ctx, "titles", keywordGroupID)
+		if err != nil {
+			logger.Warn().Err(err).Int("group", keywordGroupID).Msg("Failed to get title from pool")
+		}
+		title = item.Text
+		item, err = popItem(ctx, "contents", articleGroupID)
+		if err != nil {
+			logger.Warn().Err(err).Int("group", articleGroupID).Msg("Failed to get content from pool")
+		}
+		content, summary = item.Text, item.Summary
+		// 伪原创改写和站群 WASM 扩展转换正文（固定页面的文章内容不转换）
+		content = h.spintax.Rewrite(content)
+		if h.extensions != nil {
+			content = h.extensions.TransformContent(ctx, site.SiteGroupID, content)
+		}
 	}
-	content, err = h.poolManager.Pop("contents", articleGroupID)
-	if err != nil {
-		log.Warn().Err(err).Int("group", articleGroupID).Msg("Failed to get content from pool")
+	// 标题格式：策略配置了 title_pattern 时按格式生成，否则使用默认格式
+	titlePattern := ""
+	if s := strategy.Strategy; s != nil && s.TitlePattern.Valid {
+		titlePattern = s.TitlePattern.String
+	}
+	titleKeywordCount := 3
+	if titlePattern != "" {
+		titleKeywordCount = core.TitleKeywordCount(titlePattern)
 	}
-	// 获取关键词用于标题生成（使用关键词分组）
-	titleKeywords := h.poolManager.GetRandomKeywords(keywordGroupID, 3)
+	makeTitle := func(kws []string) string {
+		if titlePattern != "" {
+			return core.RenderTitlePattern(titlePattern, kws, site.Name, h.poolManager.GetRandomEmojiExclude)
+		}
+		return h.generateTitle(kws)
+	}
+
+	// 获取关键词用于标题生成（使用关键词分组），原始关键词用于抓取反馈
+	titleKeywords, rawTitleKeywords := h.poolManager.GetRandomKeywordPairs(keywordGroupID, titleKeywordCount)
 	fetchTime := time.Since(t4)
 
 	// Build article content using fetched title and content
 	articleContent := core.BuildArticleContentFromSingle(title, content)
-
+	// 列表页正文为链接列表和分页（模板直接调用 archive_list() 时不重复输出）
+	if archive != nil && !strings.Contains(templateData.Content, "archive_list") {
+		articleContent = archive.ListHTML() + archive.PaginationHTML()
+	}
 
 	// Prepare render data
 	analyticsCode := getNullString(site.Analytics)
@@ -165,19 +416,45 @@ func (h *PageHandler) ServePage(c *gin.Context) {
 		baiduPushJS = generateBaiduPushJS(baiduToken)
 	}
 
-	// 创建标题生成器闭包，同一页面多次调用返回相同标题
+	// 静态标题使用 titleKeywords，抓取反馈只记录这组关键词
+	pageTitle := makeTitle(titleKeywords)
+	if h.extensions != nil {
+		pageTitle = h.extensions.TransformTitle(ctx, site.SiteGroupID, pageTitle)
+	}
+	fixedTitle := false
+	if archive != nil {
+		pageTitle = archive.Title
+		fixedTitle = true
+	}
+	if override != nil && override.Title != "" {
+		pageTitle = override.Title
+		fixedTitle = true
+	}
+	if pinned != nil {
+		pageTitle = pinned.Title
+		fixedTitle = true
+	}
+
+	// 创建标题生成器闭包，同一页面多次调用返回相同标题。
+	// 动态标题另取一组关键词（与静态标题不同）；列表页、覆盖和固定页面使用固定标题
 	var cachedTitle string
 	titleGenerator := func() string {
+		if fixedTitle {
+			return pageTitle
+		}
 		if cachedTitle == "" {
-			kws := h.poolManager.GetRandomKeywords(keywordGroupID, 3)
-			cachedTitle = h.generateTitle(kws)
+			kws := h.poolManager.GetRandomKeywords(keywordGroupID, titleKeywordCount)
+			cachedTitle = makeTitle(kws)
+			if h.extensions != nil {
+				cachedTitle = h.extensions.TransformTitle(ctx, site.SiteGroupID, cachedTitle)
+			}
 		}
 		return cachedTitle
 	}
 
 	renderData := &core.RenderData{
-		Title:          h.generateTitle(titleKeywords), // 兼容静态用途
-		TitleGenerator: titleGenerator,                 // 动态生成器
+		Title:          pageTitle,      // 兼容静态用途
+		TitleGenerator: titleGenerator, // 动态生成器
 		SiteID:         site.ID,
 		KeywordGroupID: keywordGroupID,
 		ImageGroupID:   imageGroupID,
@@ -185,34 +462,105 @@ func (h *PageHandler) ServePage(c *gin.Context) {
 		BaiduPushJS:    template.HTML(baiduPushJS),
 		ArticleContent: template.HTML(articleContent),
 	}
+	// random_url / internal_link 与 sitemap 使用同一 URL 策略（按站点关键词分组生成，不受蜘蛛策略影响）
+	if h.urlStrategies != nil {
+		renderData.URLGenerator = h.urlStrategies.URLFunc(site, siteKeywordGroupID(site))
+		renderData.InternalLink = h.urlStrategies.LinkFunc(site, siteKeywordGroupID(site))
+	}
+	// 摘要优先使用来源文章已存储的摘要；没有时（固定页面、旧正文）只在模板用到 summary() 时生成
+	if h.excerpts != nil && archive == nil {
+		renderData.Summary = summary
+		if summary == "" && strings.Contains(templateData.Content, "summary") {
+			renderData.Summary = h.excerpts.Generate(content)
+		}
+	}
+	if h.extensions != nil {
+		renderData.PluginFunc = h.extensions.FuncFor(ctx, site.SiteGroupID)
+	}
+	if h.archives != nil {
+		renderData.Archive = archive
+		renderData.ArchiveLinks = h.archives.CategoriesFunc(site, siteKeywordGroupID(site))
+	}
+	if h.antiScrape.HoneypotEnabled() {
+		renderData.TrapLink = h.antiScrape.TrapLink
+	}
+	// 模拟发布日期：由域名和路径确定，重新渲染不变
+	if h.publishDates != nil {
+		renderData.PublishDate = h.publishDates.Date(site, path)
+	}
 
 	// Render template
 	t5 := time.Now()
-	html, err := h.templateRenderer.Render(templateData.Content, templateName, renderData, content)
+	html, err := h.templateRenderer.RenderContext(ctx, templateData.Content, templateName, renderData, content)
+	// 请求被取消（客户端断开）不计入模板错误预算
+	if h.templateHealth != nil && !errors.Is(err, context.Canceled) {
+		h.templateHealth.RecordRender(templateData.ID, templateName, site.SiteGroupID, err)
+	}
+	dryRun := override != nil && override.DryRun
+	if !errors.Is(err, context.Canceled) && !dryRun {
+		h.rollouts.RecordRender(rollout, templateName, err)
+	}
+	if h.templateUsage != nil && err == nil && !dryRun {
+		h.templateUsage.Record(templateData.ID)
+		h.templateUsage.RecordPage(site.SiteGroupID, domain, path, templateName)
+	}
 	if err != nil {
-		log.Error().Err(err).Str("template", templateName).Msg("Failed to render template")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Render failed"})
+		logger.Error().Err(err).Str("template", templateName).Msg("Failed to render template")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Render failed", "request_id": requestID})
 		return
 	}
+	// 模板缺少 TDK 时自动补全（功能开关 auto_tdk，可按站群覆盖）
+	if h.autoTDK != nil && core.FeatureEnabled(core.FlagAutoTDK, site.SiteGroupID, domain) {
+		html = h.autoTDK.Apply(html, site.SiteGroupID, templateData.ID, templateName, core.TDKSource{
+			Title:    pageTitle,
+			Keywords: rawTitleKeywords,
+			Content:  content,
+		})
+	}
+	// 页面 CSS 混淆（功能开关 css_obfuscation，可按站群覆盖）
+	if h.cssObfuscator != nil && core.FeatureEnabled(core.FlagCSSObfuscation, site.SiteGroupID, domain) {
+		html = h.cssObfuscator.Apply(html)
+	}
+	// 页面级 robots 规则：meta robots 和站外链接 nofollow
+	if decision := h.robotsPolicies.Evaluate(site.SiteGroupID, path); !decision.Empty() {
+		html = h.robotsPolicies.Apply(html, domain, decision)
+	}
+	// 站外链接审计：补 rel、限制每页站外链接数（未启用时为 nil）
+	html = h.linkAuditor.Apply(html, domain)
 	renderTime := time.Since(t5)
 
+	// 内部重新渲染由调用方写入缓存
+	if override != nil {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+		return
+	}
+
 	// Cache the result asynchronously
-	go func() {
-		if err := h.htmlCache.Set(domain, path, html); err != nil {
-			log.Warn().Err(err).Str("domain", domain).Str("path", path).Msg("Failed to cache HTML")
-		}
-	}()
+	if pinned == nil {
+		go func() {
+			if err := h.htmlCache.Set(domain, cachePath, html); err != nil {
+				logger.Warn().Err(err).Str("domain", domain).Str("path", path).Msg("Failed to cache HTML")
+			}
+		}()
+	}
 
 	elapsed := time.Since(startTime)
 
-	log.Info().
+	core.SetAccessRender(c, false, renderTime)
+	if detection.IsSpider {
+		h.strategies.Record(site.SiteGroupID, detection.SpiderType, false)
+		h.keywordFeedback.Record(domain, path, keywordGroupID, rawTitleKeywords)
+	}
+
+	logger.Info().
 		Str("domain", domain).
 		Str("path", path).
 		Str("spider", detection.SpiderType).
+		Str("strategy", strategy.Namespace).
 		Dur("elapsed", elapsed).
 		Msg("Page generated")
 
-	log.Debug().
+	logger.Debug().
 		Dur("spider_time", spiderTime).
 		Dur("site_time", siteTime).
 		Dur("fetch_time", fetchTime).
@@ -221,7 +569,9 @@ func (h *PageHandler) ServePage(c *gin.Context) {
 		Msg("Performance metrics")
 
 	// Log spider visit asynchronously
-	go h.logSpiderVisit(detection, clientIP, ua, domain, path, false, int(elapsed.Milliseconds()), 200)
+	if detection.IsSpider {
+		go h.logSpiderVisit(logger, detection, clientIP, ua, domain, path, false, int(elapsed.Milliseconds()), 200, len(html))
+	}
 
 	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
 }
@@ -254,50 +604,62 @@ func (h *PageHandler) generateTitle(keywords []string) string {
 	return builder.String()
 }
 
-// logSpiderVisit logs spider visit to database asynchronously
+// logSpiderVisit 记录一次蜘蛛访问，调用方在独立 goroutine 中调用
+// 有摄入器时只入队，由 SpiderLogIngester 批量写入 MySQL 和/或 ClickHouse（内存缓冲区满时溢出到 Redis）；
+// 没有摄入器时同步写 MySQL。logger 为请求 logger，写库日志与渲染日志带同一个 request_id
 func (h *PageHandler) logSpiderVisit(
+	logger *zerolog.Logger,
 	detection *models.DetectionResult,
 	ip, ua, domain, path string,
 	cacheHit bool,
 	respTime int,
 	status int,
+	bytes int,
 ) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	// Truncate long values
-	if len(ua) > 500 {
-		ua = ua[:500]
+	cacheHitInt := 0
+	if cacheHit {
+		cacheHitInt = 1
 	}
-	if len(path) > 500 {
-		path = path[:500]
+	record := core.SpiderVisitRecord{
+		SpiderType: detection.SpiderType,
+		IP:         ip,
+		UA:         ua,
+		Domain:     domain,
+		Path:       path,
+		RespTime:   respTime,
+		CacheHit:   cacheHitInt,
+		Status:     status,
+		CreatedAt:  time.Now(),
+		Bytes:      bytes,
 	}
 
-	spiderType := detection.SpiderType
-	if spiderType == "" {
-		spiderType = "unknown"
+	if h.logIngester != nil {
+		h.logIngester.Enqueue([]core.SpiderVisitRecord{record})
+		return
 	}
+	core.GetDomainCacheStats().RecordVisit(record)
 
-	cacheHitInt := 0
-	if cacheHit {
-		cacheHitInt = 1
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 截断过长的值、补充默认蜘蛛类型
+	r := core.NormalizeVisitRecord(record)
 
 	query := `INSERT INTO spider_logs (spider_type, ip, ua, domain, path, dns_ok, resp_time, cache_hit, status)
               VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	log.Debug().
-		Str("spider_type", spiderType).
-		Str("ip", ip).
-		Str("domain", domain).
-		Str("path", path).
+	logger.Debug().
+		Str("spider_type", r.SpiderType).
+		Str("ip", r.IP).
+		Str("domain", r.Domain).
+		Str("path", r.Path).
 		Msg("Inserting spider log")
 
-	_, err := h.db.ExecContext(ctx, query, spiderType, ip, ua, domain, path, 0, respTime, cacheHitInt, status)
+	_, err := h.db.ExecContext(ctx, query, r.SpiderType, r.IP, r.UA, r.Domain, r.Path, 0, r.RespTime, r.CacheHit, r.Status)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to log spider visit")
+		logger.Error().Err(err).Msg("Failed to log spider visit")
 	} else {
-		log.Debug().Msg("Spider log inserted successfully")
+		logger.Debug().Msg("Spider log inserted successfully")
 	}
 }
 
@@ -309,26 +671,6 @@ func getNullString(ns sql.NullString) string {
 	return ""
 }
 
-// getClientIP gets the client's real IP address
-func getClientIP(c *gin.Context) string {
-	// Try X-Forwarded-For header
-	forwardedFor := c.GetHeader("X-Forwarded-For")
-	if forwardedFor != "" {
-		// Take the first IP (original client)
-		parts := strings.Split(forwardedFor, ",")
-		return strings.TrimSpace(parts[0])
-	}
-
-	// Try X-Real-IP header
-	realIP := c.GetHeader("X-Real-IP")
-	if realIP != "" {
-		return realIP
-	}
-
-	// Fall back to connection IP
-	return c.ClientIP()
-}
-
 // generateBaiduPushJS generates Baidu push JavaScript code
 func generateBaiduPushJS(token string) string {
 	if token == "" {
@@ -373,6 +715,14 @@ func (h *PageHandler) Stats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// loadTemplate gets a template from cache, falling back to DB for newly added templates
+func (h *PageHandler) loadTemplate(ctx context.Context, name string, siteGroupID int) (*models.Template, error) {
+	if tmpl := h.templateCache.Get(name, siteGroupID); tmpl != nil && tmpl.Content != "" {
+		return tmpl, nil
+	}
+	return h.templateCache.GetWithFallback(ctx, name, siteGroupID)
+}
+
 // GetTemplateRenderer returns the template renderer for cache management
 func (h *PageHandler) GetTemplateRenderer() *core.TemplateRenderer {
 	return h.templateRenderer
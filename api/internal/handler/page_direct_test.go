@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestPageHandler_ServeDirect 直接对外服务时，未匹配的请求按 Host、路径和 User-Agent 渲染
+func TestPageHandler_ServeDirect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewPageHandler(PageHandlerDeps{})
+	r := gin.New()
+	r.GET("/page", h.ServePage)
+	r.NoRoute(h.ServeDirect)
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		host     string
+		ua       string
+		want     int
+		wantBody string
+	}{
+		{"robots via host and path", "GET", "/robots.txt", "Example.com:8443", "Baiduspider", http.StatusOK, "User-agent"},
+		{"query string ignored", "GET", "/robots.txt?x=1", "example.com", "Mozilla/5.0", http.StatusOK, "User-agent"},
+		{"missing user agent", "GET", "/robots.txt", "example.com", "", http.StatusBadRequest, "Missing required parameters"},
+		{"missing host", "GET", "/robots.txt", "", "Mozilla/5.0", http.StatusBadRequest, "Missing required parameters"},
+		{"page route still works", "GET", "/page?ua=Baiduspider&path=/robots.txt&domain=example.com", "admin.local", "", http.StatusOK, "User-agent"},
+		{"api paths not rendered", "GET", "/api/unknown", "example.com", "Mozilla/5.0", http.StatusNotFound, "Not found"},
+		{"websocket paths not rendered", "GET", "/ws/unknown", "example.com", "Mozilla/5.0", http.StatusNotFound, "Not found"},
+		{"post not rendered", "POST", "/robots.txt", "example.com", "Mozilla/5.0", http.StatusNotFound, "Not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, nil)
+			req.Host = tt.host
+			req.Header.Set("User-Agent", tt.ua)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %q)", w.Code, tt.want, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want containing %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestDirectPageParams(t *testing.T) {
+	tests := []struct {
+		name, url, host, ua          string
+		wantUA, wantPath, wantDomain string
+	}{
+		{"plain", "/a/b.html", "example.com", "Baiduspider", "Baiduspider", "/a/b.html", "example.com"},
+		{"port and case", "/x", "WWW.Example.com:8443", "UA", "UA", "/x", "www.example.com"},
+		{"query dropped", "/list?page=2", "example.com", "UA", "UA", "/list", "example.com"},
+		{"escaped path", "/%E4%B8%AD.html", "example.com", "UA", "UA", "/中.html", "example.com"},
+		{"ipv6 host", "/", "[::1]:443", "UA", "UA", "/", "::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			req.Host = tt.host
+			req.Header.Set("User-Agent", tt.ua)
+			ua, path, domain := directPageParams(req)
+			if ua != tt.wantUA || path != tt.wantPath || domain != tt.wantDomain {
+				t.Errorf("directPageParams = (%q, %q, %q), want (%q, %q, %q)",
+					ua, path, domain, tt.wantUA, tt.wantPath, tt.wantDomain)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+
+	core "seo-generator/api/internal/service"
+)
+
+// freshnessUA 内部重新渲染使用的 User-Agent（不会被识别为蜘蛛）
+const freshnessUA = "SEOGenerator-Freshness/1.0"
+
+// RefreshPage 实现 core.PageRefresher：按正常渲染流程重新生成页面，标题和内容策略由参数指定
+// 返回的 HTML 不写入缓存，由调用方覆盖
+func (h *PageHandler) RefreshPage(ctx context.Context, domain, path, title, spiderType string) (string, error) {
+	return h.renderInternal(core.WithPageOverride(ctx, &core.PageOverride{Title: title, SpiderType: spiderType}), domain, path)
+}
+
+// DryRunPage 实现 core.PageDryRunner：按正常渲染流程生成页面（渲染自检使用），
+// 标题和正文只查看不取出数据池，不计入模板使用和灰度统计
+func (h *PageHandler) DryRunPage(ctx context.Context, domain, path string) (string, error) {
+	return h.renderInternal(core.WithPageOverride(ctx, &core.PageOverride{DryRun: true}), domain, path)
+}
+
+// renderInternal 以内部请求调用 ServePage，ctx 携带覆盖参数
+func (h *PageHandler) renderInternal(ctx context.Context, domain, path string) (string, error) {
+	query := url.Values{"ua": {freshnessUA}, "path": {path}, "domain": {domain}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/page?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.RemoteAddr = "127.0.0.1:0"
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	h.ServePage(c)
+
+	if w.Code != http.StatusOK {
+		body := w.Body.String()
+		if len(body) > 200 {
+			body = body[:200]
+		}
+		return "", fmt.Errorf("render returned %d: %s", w.Code, body)
+	}
+	return w.Body.String(), nil
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+
+	core "seo-generator/api/internal/service"
+)
+
+// humanFallbackHTML 未配置站点策略且不返回 404 时给普通访客的页面
+var humanFallbackHTML = []byte("<html><body>Hello</body></html>")
+
+// handleHuman 按站点的非蜘蛛访问策略处理普通访客
+// 返回 true 表示已写入响应；content 策略返回 false，由 ServePage 继续渲染
+func (h *PageHandler) handleHuman(c *gin.Context, ctx context.Context, logger *zerolog.Logger, domain, clientIP, ua string) bool {
+	site, err := h.siteCache.Get(ctx, domain)
+	if err != nil {
+		logger.Warn().Err(err).Str("domain", domain).Msg("Failed to get site config for human visitor")
+	}
+	policy := core.SiteHumanPolicy(site)
+
+	decision := policy
+	switch policy {
+	case core.HumanPolicyRedirect:
+		if !site.HumanTargetURL.Valid || site.HumanTargetURL.String == "" {
+			decision = ""
+			break
+		}
+		c.Redirect(http.StatusFound, site.HumanTargetURL.String)
+	case core.HumanPolicyPage:
+		c.Data(http.StatusOK, "text/html; charset=utf-8", core.RenderHumanPage(site))
+	case core.HumanPolicyBlock:
+		c.AbortWithStatus(http.StatusForbidden)
+	case core.HumanPolicyContent:
+	default:
+		decision = ""
+	}
+
+	// 未配置或配置不完整时沿用全局 spider_detector 配置
+	if decision == "" {
+		if h.cfg.SpiderDetector.Return404ForNonSpider {
+			decision = "not_found"
+			c.AbortWithStatus(http.StatusNotFound)
+		} else {
+			decision = "fallback"
+			c.Data(http.StatusOK, "text/html; charset=utf-8", humanFallbackHTML)
+		}
+	}
+
+	event := logger.Info()
+	if decision == "not_found" || decision == "fallback" {
+		event = logger.Debug()
+	}
+	event.Str("domain", domain).
+		Str("policy", policy).
+		Str("decision", decision).
+		Str("ip", clientIP).
+		Str("ua", ua).
+		Msg("Non-spider visitor")
+
+	return decision != core.HumanPolicyContent
+}
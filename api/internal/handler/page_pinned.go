@@ -0,0 +1,23 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"seo-generator/api/internal/model"
+	core "seo-generator/api/internal/service"
+)
+
+// servePinnedHTML 返回固定页面的手写 HTML（内存命中，按缓存命中记录蜘蛛访问）
+func (h *PageHandler) servePinnedHTML(c *gin.Context, detection *models.DetectionResult, clientIP, ua, domain, path string, pinned *core.PinnedContent, startTime time.Time) {
+	core.SetAccessRender(c, true, 0)
+	if detection.IsSpider {
+		elapsed := time.Since(startTime)
+		go h.logSpiderVisit(core.LoggerFrom(c.Request.Context()), detection, clientIP, ua, domain, path, true, int(elapsed.Milliseconds()), http.StatusOK, len(pinned.HTML))
+	}
+	core.LoggerFrom(c.Request.Context()).Debug().Str("domain", domain).Str("path", path).Int64("pinned_id", pinned.ID).
+		Msg("Pinned page served")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(pinned.HTML))
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+
+	models "seo-generator/api/internal/model"
+	core "seo-generator/api/internal/service"
+)
+
+// guardScrape 对非蜘蛛请求执行反采集判定
+// 返回 true 表示已写入响应（挑战页或 403）；tarpit 延迟后返回 false，由 ServePage 继续处理
+func (h *PageHandler) guardScrape(c *gin.Context, ctx context.Context, logger *zerolog.Logger, domain, path, clientIP, ua string) bool {
+	if !h.antiScrape.Enabled() {
+		return false
+	}
+	token, _ := c.Cookie(h.antiScrape.CookieName())
+	verdict := h.antiScrape.Inspect(clientIP, ua, path, token)
+	if verdict.Action == core.ScrapeAllow {
+		return false
+	}
+
+	logger.Info().
+		Str("domain", domain).
+		Str("path", path).
+		Str("ip", clientIP).
+		Str("ua", ua).
+		Str("action", verdict.Action).
+		Str("reason", verdict.Reason).
+		Msg("Anti-scrape decision")
+
+	switch verdict.Action {
+	case core.ScrapeChallenge:
+		c.Header("Cache-Control", "no-store")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", h.antiScrape.ChallengePage(clientIP))
+		return true
+	case core.ScrapeBlock:
+		c.AbortWithStatus(http.StatusForbidden)
+		return true
+	case core.ScrapeTarpit:
+		h.antiScrape.Tarpit(ctx, verdict.Delay)
+		if ctx.Err() != nil {
+			c.Abort()
+			return true
+		}
+	}
+	return false
+}
+
+// serveTrap 蜜罐链接：标记坏爬虫并返回 404（不渲染、不写缓存）
+// 无论 UA 是否声称为蜘蛛都记录（真实搜索引擎遵守 robots.txt 不会访问），封禁只对非蜘蛛请求生效；
+// clientIP 为经受信代理解析的访客 IP，客户端伪造的 X-Forwarded-For 不会让封禁落到他人 IP 上
+func (h *PageHandler) serveTrap(c *gin.Context, ctx context.Context, detection *models.DetectionResult, domain, path, clientIP, ua string) {
+	spider := ""
+	if detection.IsSpider {
+		spider = detection.SpiderType
+	}
+	h.antiScrape.Trap(ctx, clientIP, ua, spider, domain, path)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusNotFound, "text/html; charset=utf-8", []byte("<html><body><h1>404 Not Found</h1></body></html>"))
+}
+
+// serveRobots 输出 /robots.txt（蜜罐前缀加入 Disallow）
+func (h *PageHandler) serveRobots(c *gin.Context) {
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(h.antiScrape.RobotsTxt()))
+}
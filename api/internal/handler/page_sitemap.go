@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/xml"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"seo-generator/api/internal/model"
+)
+
+// sitemapPagePattern 分页 sitemap 路径：/sitemap-1.xml
+var sitemapPagePattern = regexp.MustCompile(`^/sitemap-(\d+)\.xml$`)
+
+const sitemapXMLNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// sitemapArchivePath 归档列表页 sitemap（URL 数超过单文件上限时由索引指向）
+const sitemapArchivePath = "/sitemap-archive.xml"
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapLoc `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// siteKeywordGroupID 站点关键词分组（URL 策略使用，不受蜘蛛策略覆盖影响）
+func siteKeywordGroupID(site *models.Site) int {
+	if site.KeywordGroupID.Valid {
+		return int(site.KeywordGroupID.Int64)
+	}
+	return 1
+}
+
+// serveSitemap 按站群 URL 策略输出 /sitemap.xml 和 /sitemap-N.xml
+// URL 数不超过单文件上限时 /sitemap.xml 直接是 urlset，否则是指向分页文件的索引；
+// 归档列表页（archive.sitemap）在单文件时直接并入，否则由索引指向 /sitemap-archive.xml
+// 返回 true 表示已写入响应
+func (h *PageHandler) serveSitemap(c *gin.Context, site *models.Site, path string) bool {
+	if h.urlStrategies == nil {
+		return false
+	}
+	page := 0
+	archivePage := path == sitemapArchivePath
+	if path != "/sitemap.xml" && !archivePage {
+		m := sitemapPagePattern.FindStringSubmatch(path)
+		if m == nil {
+			return false
+		}
+		page, _ = strconv.Atoi(m[1])
+		if page < 1 {
+			return false
+		}
+	}
+
+	keywordGroupID := siteKeywordGroupID(site)
+	base := h.urlStrategies.BaseURL(site.Domain)
+	pageSize := h.urlStrategies.SitemapPageSize()
+	size := h.urlStrategies.Size(site, keywordGroupID)
+	pages := (size + pageSize - 1) / pageSize
+	archives := h.archives.SitemapURLs(site, keywordGroupID, pageSize)
+	splitArchives := len(archives) > 0 && (pages > 1 || size+len(archives) > pageSize)
+
+	var doc interface{}
+	switch {
+	case archivePage:
+		if !splitArchives {
+			c.AbortWithStatus(http.StatusNotFound)
+			return true
+		}
+		set := sitemapURLSet{XMLNS: sitemapXMLNS, URLs: make([]sitemapLoc, 0, len(archives))}
+		for _, u := range archives {
+			set.URLs = append(set.URLs, sitemapLoc{Loc: base + u})
+		}
+		doc = set
+	case page == 0 && (pages > 1 || splitArchives):
+		index := sitemapIndex{XMLNS: sitemapXMLNS, Sitemaps: make([]sitemapLoc, 0, pages+1)}
+		for i := 1; i <= pages; i++ {
+			index.Sitemaps = append(index.Sitemaps, sitemapLoc{Loc: base + "/sitemap-" + strconv.Itoa(i) + ".xml"})
+		}
+		if splitArchives {
+			index.Sitemaps = append(index.Sitemaps, sitemapLoc{Loc: base + sitemapArchivePath})
+		}
+		doc = index
+	case page > pages:
+		c.AbortWithStatus(http.StatusNotFound)
+		return true
+	default:
+		offset := 0
+		if page > 0 {
+			offset = (page - 1) * pageSize
+		}
+		targets := h.urlStrategies.Range(site, keywordGroupID, offset, pageSize)
+		set := sitemapURLSet{XMLNS: sitemapXMLNS, URLs: make([]sitemapLoc, 0, len(targets))}
+		seen := make(map[string]struct{}, len(targets))
+		for _, t := range targets {
+			if _, dup := seen[t.URL]; dup {
+				continue
+			}
+			seen[t.URL] = struct{}{}
+			set.URLs = append(set.URLs, sitemapLoc{Loc: base + t.URL})
+		}
+		if !splitArchives {
+			for _, u := range archives {
+				set.URLs = append(set.URLs, sitemapLoc{Loc: base + u})
+			}
+		}
+		doc = set
+	}
+
+	body, err := xml.Marshal(doc)
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return true
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", append([]byte(xml.Header), body...))
+	return true
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	database "seo-generator/api/internal/repository"
+	core "seo-generator/api/internal/service"
+)
+
+// PinnedPagesHandler 固定页面 handler
+type PinnedPagesHandler struct {
+	db          *sqlx.DB
+	pinnedPages *core.PinnedPages
+	siteCache   *core.SiteCache
+}
+
+// NewPinnedPagesHandler 创建 PinnedPagesHandler
+func NewPinnedPagesHandler(db *sqlx.DB, pinnedPages *core.PinnedPages, siteCache *core.SiteCache) *PinnedPagesHandler {
+	return &PinnedPagesHandler{db: db, pinnedPages: pinnedPages, siteCache: siteCache}
+}
+
+// PinnedPageRequest 创建/更新固定页面请求
+// mode=html 时 html 必填；mode=article 时 article_id 为原始文章 ID，使用站点模板渲染
+type PinnedPageRequest struct {
+	Domain    string `json:"domain" binding:"required"`
+	Path      string `json:"path" binding:"required"`
+	Mode      string `json:"mode" binding:"required"`
+	HTML      string `json:"html"`
+	ArticleID int64  `json:"article_id"`
+	Enabled   *bool  `json:"enabled"` // 默认启用
+	Note      string `json:"note"`
+}
+
+// List 固定页面列表（不含 HTML 内容）
+// GET /api/pinned-pages?domain=&page=1&page_size=20
+func (h *PinnedPagesHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	items, total, err := h.pinnedPages.List(c.Request.Context(), strings.ToLower(c.Query("domain")), page, pageSize)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list pinned pages")
+		items = []core.PinnedPage{}
+	}
+	core.SuccessPaged(c, items, total, page, pageSize)
+}
+
+// Get 固定页面详情
+// GET /api/pinned-pages/:id
+func (h *PinnedPagesHandler) Get(c *gin.Context) {
+	id, ok := parsePinnedPageID(c)
+	if !ok {
+		return
+	}
+	page, err := h.pinnedPages.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, page)
+}
+
+// Create 创建固定页面
+// POST /api/pinned-pages
+func (h *PinnedPagesHandler) Create(c *gin.Context) {
+	page, ok := h.bind(c)
+	if !ok {
+		return
+	}
+	id, err := h.pinnedPages.Create(c.Request.Context(), page)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	page, err = h.pinnedPages.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, page)
+}
+
+// Update 更新固定页面
+// PUT /api/pinned-pages/:id
+func (h *PinnedPagesHandler) Update(c *gin.Context) {
+	id, ok := parsePinnedPageID(c)
+	if !ok {
+		return
+	}
+	page, ok := h.bind(c)
+	if !ok {
+		return
+	}
+	page.ID = id
+	if err := h.pinnedPages.Update(c.Request.Context(), page); err != nil {
+		h.fail(c, err)
+		return
+	}
+	page, err := h.pinnedPages.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, page)
+}
+
+// Delete 删除固定页面（恢复正常渲染）
+// DELETE /api/pinned-pages/:id
+func (h *PinnedPagesHandler) Delete(c *gin.Context) {
+	id, ok := parsePinnedPageID(c)
+	if !ok {
+		return
+	}
+	if err := h.pinnedPages.Delete(c.Request.Context(), id); err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, nil)
+}
+
+// bind 解析并校验请求
+func (h *PinnedPagesHandler) bind(c *gin.Context) (*core.PinnedPage, bool) {
+	var req PinnedPageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return nil, false
+	}
+	req.Domain = strings.ToLower(strings.TrimSpace(req.Domain))
+	req.Path = strings.TrimSpace(req.Path)
+	if !strings.HasPrefix(req.Path, "/") || len(req.Path) > 500 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "path 必须以 / 开头且不超过 500 字符")
+		return nil, false
+	}
+	if !core.ValidPinnedMode(req.Mode) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "mode 必须为 html 或 article")
+		return nil, false
+	}
+	site, err := h.siteCache.Get(c.Request.Context(), req.Domain)
+	if err != nil {
+		core.FailWithCode(c, core.ErrDBQuery)
+		return nil, false
+	}
+	if site == nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "域名未注册: "+req.Domain)
+		return nil, false
+	}
+
+	page := &core.PinnedPage{
+		Domain:  req.Domain,
+		Path:    req.Path,
+		Mode:    req.Mode,
+		Enabled: req.Enabled == nil || *req.Enabled,
+		Note:    req.Note,
+	}
+	switch req.Mode {
+	case core.PinnedModeHTML:
+		if strings.TrimSpace(req.HTML) == "" {
+			core.FailWithMessage(c, core.ErrInvalidParam, "html 不能为空")
+			return nil, false
+		}
+		if len(req.HTML) > core.MaxPinnedHTMLSize {
+			core.FailWithMessage(c, core.ErrInvalidParam, "html 超过 2MB")
+			return nil, false
+		}
+		page.HTML = &req.HTML
+	case core.PinnedModeArticle:
+		var exists int
+		if err := h.db.Get(&exists, "SELECT COUNT(*) FROM original_articles WHERE id = ?", req.ArticleID); err != nil || exists == 0 {
+			core.FailWithMessage(c, core.ErrInvalidParam, "文章不存在")
+			return nil, false
+		}
+		page.ArticleID = &req.ArticleID
+	}
+	return page, true
+}
+
+// fail 按错误类型返回
+func (h *PinnedPagesHandler) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, core.ErrPinnedPageNotFound):
+		core.FailWithMessage(c, core.ErrNotFound, "固定页面不存在")
+	case database.IsDuplicateKeyError(err):
+		core.FailWithMessage(c, core.ErrDBDuplicate, "该 URL 已固定")
+	default:
+		log.Error().Err(err).Msg("Pinned page operation failed")
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+	}
+}
+
+func parsePinnedPageID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的 ID")
+		return 0, false
+	}
+	return id, true
+}
@@ -2,7 +2,10 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
@@ -49,6 +52,10 @@ func (h *PoolHandler) UpdateConfig(c *gin.Context) {
 		ContentWorkers          int     `json:"content_workers"`
 		ContentRefillIntervalMs int     `json:"content_refill_interval_ms"`
 		ContentThreshold        float64 `json:"content_threshold"`
+		// 正文池分片补充（不传时保持当前值）
+		ContentShardThreshold *int `json:"content_shard_threshold"`
+		ContentShards         *int `json:"content_shards"`
+		ContentShardWorkers   *int `json:"content_shard_workers"`
 		// cls类名池
 		ClsPoolSize         int     `json:"cls_pool_size"`
 		ClsWorkers          int     `json:"cls_workers"`
@@ -107,6 +114,30 @@ func (h *PoolHandler) UpdateConfig(c *gin.Context) {
 		return
 	}
 
+	// Validate content shard config
+	current, _ := core.LoadCachePoolConfig(c.Request.Context(), h.db)
+	if req.ContentShardThreshold == nil {
+		req.ContentShardThreshold = &current.ContentShardThreshold
+	}
+	if req.ContentShards == nil {
+		req.ContentShards = &current.ContentShards
+	}
+	if req.ContentShardWorkers == nil {
+		req.ContentShardWorkers = &current.ContentShardWorkers
+	}
+	if *req.ContentShardThreshold < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "content_shard_threshold must be >= 0"})
+		return
+	}
+	if *req.ContentShards < 1 || *req.ContentShards > 256 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "content_shards must be between 1 and 256"})
+		return
+	}
+	if *req.ContentShardWorkers < 1 || *req.ContentShardWorkers > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "content_shard_workers must be between 1 and 50"})
+		return
+	}
+
 	// Validate cls config
 	if req.ClsPoolSize < 1000 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "cls_pool_size must be >= 1000"})
@@ -172,6 +203,9 @@ func (h *PoolHandler) UpdateConfig(c *gin.Context) {
 		ContentWorkers:          req.ContentWorkers,
 		ContentRefillIntervalMs: req.ContentRefillIntervalMs,
 		ContentThreshold:        req.ContentThreshold,
+		ContentShardThreshold:   *req.ContentShardThreshold,
+		ContentShards:           *req.ContentShards,
+		ContentShardWorkers:     *req.ContentShardWorkers,
 		// cls类名池
 		ClsPoolSize:         req.ClsPoolSize,
 		ClsWorkers:          req.ClsWorkers,
@@ -218,6 +252,12 @@ func (h *PoolHandler) GetStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// UpdateStats returns consumption marking batch metrics
+// GET /api/cache-pool/updates
+func (h *PoolHandler) UpdateStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.poolManager.GetUpdateStats())
+}
+
 // Reload triggers a configuration reload
 func (h *PoolHandler) Reload(c *gin.Context) {
 	if err := h.poolManager.Reload(c.Request.Context()); err != nil {
@@ -226,3 +266,192 @@ func (h *PoolHandler) Reload(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
+
+// Forecast returns consumption rate and hours-until-empty per content group
+// GET /api/cache-pool/forecast?refresh=true
+func (h *PoolHandler) Forecast(c *gin.Context) {
+	report := h.poolManager.GetForecast()
+	if report == nil || c.Query("refresh") == "true" {
+		var err error
+		report, err = h.poolManager.RefreshForecast(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// PoolReserveRequest 预留标题/正文请求
+type PoolReserveRequest struct {
+	Count   int `json:"count" binding:"required,min=1,max=1000"`
+	GroupID int `json:"group_id"`
+	TTL     int `json:"ttl" binding:"omitempty,min=1,max=3600"` // 秒，默认 300
+}
+
+// PoolReservationAckRequest 确认预留请求，ids 为空时确认全部条目
+type PoolReservationAckRequest struct {
+	IDs []int64 `json:"ids"`
+}
+
+// ReserveTitles reserves titles for an external consumer
+// POST /api/cache-pool/titles/reserve
+func (h *PoolHandler) ReserveTitles(c *gin.Context) {
+	h.reserve(c, "titles")
+}
+
+// ReserveContents reserves contents for an external consumer; unacked items are requeued on timeout
+// POST /api/cache-pool/contents/reserve
+func (h *PoolHandler) ReserveContents(c *gin.Context) {
+	h.reserve(c, "contents")
+}
+
+func (h *PoolHandler) reserve(c *gin.Context, poolType string) {
+	var req PoolReserveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.GroupID <= 0 {
+		req.GroupID = 1
+	}
+	res, err := h.poolManager.Reserve(poolType, req.GroupID, req.Count, time.Duration(req.TTL)*time.Second)
+	if err != nil {
+		if errors.Is(err, core.ErrCachePoolEmpty) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// AckReservation marks reserved items as consumed and requeues the ones not listed
+// POST /api/cache-pool/reservations/:id/ack
+func (h *PoolHandler) AckReservation(c *gin.Context) {
+	var req PoolReservationAckRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	acked, released, err := h.poolManager.Ack(c.Param("id"), req.IDs)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "acked": acked, "released": released})
+}
+
+// ReleaseReservation puts all reserved items back into the pool
+// POST /api/cache-pool/reservations/:id/release
+func (h *PoolHandler) ReleaseReservation(c *gin.Context) {
+	released, err := h.poolManager.Release(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "released": released})
+}
+
+// GetReservation returns an unacknowledged reservation
+// GET /api/cache-pool/reservations/:id
+func (h *PoolHandler) GetReservation(c *gin.Context) {
+	res := h.poolManager.GetReservation(c.Param("id"))
+	if res == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": core.ErrReservationNotFound.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// ReservationStats returns reservation counters
+// GET /api/cache-pool/reservations
+func (h *PoolHandler) ReservationStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.poolManager.GetReservationStats())
+}
+
+// PoolGroupConfigRequest 分组级池配置（字段为 null 时沿用全局配置）
+type PoolGroupConfigRequest struct {
+	PoolType         string   `json:"pool_type" binding:"required,oneof=titles contents"`
+	GroupID          int      `json:"group_id" binding:"required,min=1"`
+	PoolSize         *int     `json:"pool_size"`
+	Workers          *int     `json:"workers"`
+	RefillIntervalMs *int     `json:"refill_interval_ms"`
+	Threshold        *float64 `json:"threshold"`
+}
+
+// GetGroupConfigs lists group-level pool config overrides with effective values
+// GET /api/cache-pool/config/groups
+func (h *PoolHandler) GetGroupConfigs(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"items": h.poolManager.GetGroupConfigs()})
+}
+
+// UpdateGroupConfig saves a group-level override and applies it without restart
+// PUT /api/cache-pool/config/groups
+func (h *PoolHandler) UpdateGroupConfig(c *gin.Context) {
+	var req PoolGroupConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.PoolSize != nil && *req.PoolSize < 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pool_size must be >= 1000"})
+		return
+	}
+	if req.Workers != nil && (*req.Workers < 1 || *req.Workers > 50) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "workers must be between 1 and 50"})
+		return
+	}
+	if req.RefillIntervalMs != nil && (*req.RefillIntervalMs < 10 || *req.RefillIntervalMs > 60000) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refill_interval_ms must be between 10 and 60000"})
+		return
+	}
+	if req.Threshold != nil && (*req.Threshold < 0.1 || *req.Threshold > 0.9) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "threshold must be between 0.1 and 0.9"})
+		return
+	}
+
+	config := &core.PoolGroupConfig{
+		PoolType:         req.PoolType,
+		GroupID:          req.GroupID,
+		PoolSize:         req.PoolSize,
+		Workers:          req.Workers,
+		RefillIntervalMs: req.RefillIntervalMs,
+		Threshold:        req.Threshold,
+	}
+	if err := h.poolManager.SetGroupConfig(c.Request.Context(), config); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"effective": h.poolManager.GroupSettings(req.PoolType, req.GroupID),
+	})
+}
+
+// DeleteGroupConfig removes a group-level override; the group falls back to the global config
+// DELETE /api/cache-pool/config/groups/:type/:group_id
+func (h *PoolHandler) DeleteGroupConfig(c *gin.Context) {
+	poolType := c.Param("type")
+	if poolType != "titles" && poolType != "contents" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be titles or contents"})
+		return
+	}
+	groupID, err := strconv.Atoi(c.Param("group_id"))
+	if err != nil || groupID < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group_id"})
+		return
+	}
+	if err := h.poolManager.DeleteGroupConfig(c.Request.Context(), poolType, groupID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"effective": h.poolManager.GroupSettings(poolType, groupID),
+	})
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// PublishDatesHandler 站群模拟发布日期分布管理 handler
+type PublishDatesHandler struct {
+	db            *sqlx.DB
+	publishDates  *core.PublishDates
+	urlStrategies *core.URLStrategyManager
+	siteCache     *core.SiteCache
+}
+
+// NewPublishDatesHandler 创建 PublishDatesHandler
+func NewPublishDatesHandler(db *sqlx.DB, publishDates *core.PublishDates, urlStrategies *core.URLStrategyManager, siteCache *core.SiteCache) *PublishDatesHandler {
+	return &PublishDatesHandler{db: db, publishDates: publishDates, urlStrategies: urlStrategies, siteCache: siteCache}
+}
+
+// PublishDatePolicyRequest 设置站群发布日期分布请求
+// range_days / half_life_days 为 0 表示使用全局默认；anchor_date 为空表示使用策略更新日
+type PublishDatePolicyRequest struct {
+	RangeDays      int    `json:"range_days"`
+	WeekdayWeights string `json:"weekday_weights"`
+	Recency        string `json:"recency"`
+	HalfLifeDays   int    `json:"half_life_days"`
+	HourStart      int    `json:"hour_start"`
+	HourEnd        int    `json:"hour_end"`
+	AnchorDate     string `json:"anchor_date"`
+}
+
+// List 获取所有站群生效的发布日期分布
+// GET /api/publish-dates
+func (h *PublishDatesHandler) List(c *gin.Context) {
+	var groupIDs []int
+	if err := h.db.Select(&groupIDs, "SELECT id FROM site_groups ORDER BY id"); err != nil {
+		log.Warn().Err(err).Msg("Failed to list site groups for publish dates")
+	}
+	items := make([]*core.PublishDatePolicy, 0, len(groupIDs))
+	for _, id := range groupIDs {
+		items = append(items, h.publishDates.Policy(id))
+	}
+	core.Success(c, gin.H{
+		"items":   items,
+		"recency": []string{core.RecencyUniform, core.RecencyLinear, core.RecencyExponential},
+	})
+}
+
+// Update 设置站群发布日期分布
+// PUT /api/publish-dates/:site_group_id
+func (h *PublishDatesHandler) Update(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("site_group_id"))
+	if err != nil || groupID <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的站群 ID")
+		return
+	}
+	var req PublishDatePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if req.Recency == "" {
+		req.Recency = core.RecencyExponential
+	}
+	if !core.ValidRecencyCurve(req.Recency) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "未知的分布曲线: "+req.Recency)
+		return
+	}
+	if req.RangeDays < 0 || req.RangeDays > 3650 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "range_days 范围为 0-3650")
+		return
+	}
+	if req.HalfLifeDays < 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "half_life_days 不能为负数")
+		return
+	}
+	if req.HourStart < 0 || req.HourEnd > 24 || req.HourStart >= req.HourEnd {
+		core.FailWithMessage(c, core.ErrInvalidParam, "发布时间段无效，需满足 0 <= hour_start < hour_end <= 24")
+		return
+	}
+	req.WeekdayWeights = strings.TrimSpace(req.WeekdayWeights)
+	if _, err := core.ParseWeekdayWeights(req.WeekdayWeights); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "星期权重无效: "+err.Error())
+		return
+	}
+	var anchorDate interface{}
+	if req.AnchorDate != "" {
+		if _, err := time.ParseInLocation("2006-01-02", req.AnchorDate, time.Local); err != nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, "anchor_date 格式应为 YYYY-MM-DD")
+			return
+		}
+		anchorDate = req.AnchorDate
+	}
+
+	if _, err := h.db.Exec(`
+		INSERT INTO publish_date_policies (site_group_id, range_days, weekday_weights, recency, half_life_days, hour_start, hour_end, anchor_date)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE range_days = VALUES(range_days), weekday_weights = VALUES(weekday_weights),
+			recency = VALUES(recency), half_life_days = VALUES(half_life_days), hour_start = VALUES(hour_start),
+			hour_end = VALUES(hour_end), anchor_date = VALUES(anchor_date)`,
+		groupID, req.RangeDays, req.WeekdayWeights, req.Recency, req.HalfLifeDays, req.HourStart, req.HourEnd, anchorDate); err != nil {
+		log.Error().Err(err).Int("site_group_id", groupID).Msg("Failed to save publish date policy")
+		core.FailWithCode(c, core.ErrDBUpdate)
+		return
+	}
+	h.reload(c)
+	core.Success(c, h.publishDates.Policy(groupID))
+}
+
+// Delete 删除站群配置，恢复全局默认分布
+// DELETE /api/publish-dates/:site_group_id
+func (h *PublishDatesHandler) Delete(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("site_group_id"))
+	if err != nil || groupID <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的站群 ID")
+		return
+	}
+	if _, err := h.db.Exec("DELETE FROM publish_date_policies WHERE site_group_id = ?", groupID); err != nil {
+		log.Error().Err(err).Int("site_group_id", groupID).Msg("Failed to delete publish date policy")
+		core.FailWithCode(c, core.ErrDBDelete)
+		return
+	}
+	h.reload(c)
+	core.Success(c, h.publishDates.Policy(groupID))
+}
+
+// publishDateSample 预览样本
+type publishDateSample struct {
+	URL  string `json:"url"`
+	Date string `json:"date"`
+}
+
+// Preview 预览站点 URL 的发布日期及按星期、月份的分布
+// GET /api/publish-dates/preview?domain=example.com&count=200
+func (h *PublishDatesHandler) Preview(c *gin.Context) {
+	domain := strings.TrimSpace(c.Query("domain"))
+	count, _ := strconv.Atoi(c.DefaultQuery("count", "200"))
+	if count < 1 || count > 5000 {
+		count = 200
+	}
+	site, err := h.siteCache.Get(c.Request.Context(), domain)
+	if err != nil || site == nil {
+		core.FailWithMessage(c, core.ErrNotFound, "站点不存在: "+domain)
+		return
+	}
+
+	// 优先使用站点实际 URL，URL 策略不可用时使用 /<n>.html
+	var paths []string
+	if h.urlStrategies != nil {
+		for _, t := range h.urlStrategies.Range(site, siteKeywordGroupID(site), 0, count) {
+			paths = append(paths, t.URL)
+		}
+	}
+	for i := len(paths); i < count; i++ {
+		paths = append(paths, "/"+strconv.Itoa(i+1)+".html")
+	}
+
+	weekdays := make(map[string]int, 7)
+	months := make(map[string]int)
+	samples := make([]publishDateSample, 0, min(len(paths), 50))
+	for _, p := range paths {
+		d := h.publishDates.Date(site, p)
+		weekdays[d.Weekday().String()]++
+		months[d.Format("2006-01")]++
+		if len(samples) < cap(samples) {
+			samples = append(samples, publishDateSample{URL: p, Date: d.Format("2006-01-02 15:04:05")})
+		}
+	}
+	core.Success(c, gin.H{
+		"policy":   h.publishDates.Policy(site.SiteGroupID),
+		"samples":  samples,
+		"weekdays": weekdays,
+		"months":   months,
+	})
+}
+
+// reload 策略变更后重新加载
+func (h *PublishDatesHandler) reload(c *gin.Context) {
+	if err := h.publishDates.Reload(c.Request.Context()); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload publish date policies")
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// ReplayHandler 请求回放 handler
+type ReplayHandler struct {
+	replayer *core.Replayer
+}
+
+// NewReplayHandler 创建 ReplayHandler
+func NewReplayHandler(replayer *core.Replayer) *ReplayHandler {
+	return &ReplayHandler{replayer: replayer}
+}
+
+// ReplayRunRequest 回放请求
+type ReplayRunRequest struct {
+	Source      string  `json:"source"` // access_log（默认）/ spider_logs
+	Start       string  `json:"start"`  // 默认 end 前 1 小时
+	End         string  `json:"end"`    // 默认当前
+	Domain      string  `json:"domain"`
+	SpiderOnly  bool    `json:"spider_only"`
+	Limit       int     `json:"limit"`                         // 默认 1000，最大 10000
+	TargetURL   string  `json:"target_url" binding:"required"` // 待验证实例，如 http://10.0.0.2:8080
+	BaselineURL string  `json:"baseline_url"`                  // 对照实例，为空时与日志记录对比
+	Rate        float64 `json:"rate"`                          // 每秒请求数，默认 10，最大 500
+	Concurrency int     `json:"concurrency"`                   // 默认 8
+	Timeout     int     `json:"timeout"`                       // 单个请求超时（秒），默认 10
+}
+
+// Run 提交回放作业，报告见作业结果（GET /api/jobs/:id）
+// POST /api/admin/replay
+func (h *ReplayHandler) Run(c *gin.Context) {
+	params, ok := h.bind(c)
+	if !ok {
+		return
+	}
+	jobID, err := h.replayer.Submit(c.Request.Context(), params)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to submit replay")
+		core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+		return
+	}
+	core.Success(c, gin.H{"job_id": jobID})
+}
+
+// Preview 预览将回放的请求（条数和前 20 条）
+// POST /api/admin/replay/preview
+func (h *ReplayHandler) Preview(c *gin.Context) {
+	params, ok := h.bind(c)
+	if !ok {
+		return
+	}
+	if err := params.Validate(); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+		return
+	}
+	requests, err := h.replayer.Load(c.Request.Context(), params)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		return
+	}
+	sample := requests
+	if len(sample) > 20 {
+		sample = sample[:20]
+	}
+	core.Success(c, gin.H{"total": len(requests), "items": sample})
+}
+
+// bind 解析请求参数
+func (h *ReplayHandler) bind(c *gin.Context) (core.ReplayParams, bool) {
+	var req ReplayRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return core.ReplayParams{}, false
+	}
+	params := core.ReplayParams{
+		Source:      req.Source,
+		Domain:      req.Domain,
+		SpiderOnly:  req.SpiderOnly,
+		Limit:       req.Limit,
+		TargetURL:   req.TargetURL,
+		BaselineURL: req.BaselineURL,
+		Rate:        req.Rate,
+		Concurrency: req.Concurrency,
+		Timeout:     req.Timeout,
+	}
+	if req.Start != "" {
+		start, ok := parseSeriesTime(req.Start)
+		if !ok {
+			core.FailWithMessage(c, core.ErrInvalidParam, "无效的开始时间")
+			return core.ReplayParams{}, false
+		}
+		params.Start = start
+	}
+	if req.End != "" {
+		end, ok := parseSeriesTime(req.End)
+		if !ok {
+			core.FailWithMessage(c, core.ErrInvalidParam, "无效的结束时间")
+			return core.ReplayParams{}, false
+		}
+		params.End = end
+	}
+	return params, true
+}
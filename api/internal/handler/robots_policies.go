@@ -0,0 +1,218 @@
+package api
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// RobotsPoliciesHandler 页面级 robots 规则 handler
+type RobotsPoliciesHandler struct {
+	policies  *core.RobotsPolicies
+	siteCache *core.SiteCache
+}
+
+// NewRobotsPoliciesHandler 创建 RobotsPoliciesHandler
+func NewRobotsPoliciesHandler(policies *core.RobotsPolicies, siteCache *core.SiteCache) *RobotsPoliciesHandler {
+	return &RobotsPoliciesHandler{policies: policies, siteCache: siteCache}
+}
+
+// RobotsPolicyRequest 创建/更新规则请求
+type RobotsPolicyRequest struct {
+	SiteGroupID      int    `json:"site_group_id"` // 0 表示所有站群
+	Name             string `json:"name" binding:"required"`
+	Pattern          string `json:"pattern" binding:"required"`
+	MinPage          int    `json:"min_page"`
+	Robots           string `json:"robots"`
+	NofollowExternal bool   `json:"nofollow_external"`
+	Priority         int    `json:"priority"`
+	Enabled          *bool  `json:"enabled"` // 默认启用
+}
+
+// List 规则列表
+// GET /api/robots-policies?site_group_id=
+func (h *RobotsPoliciesHandler) List(c *gin.Context) {
+	siteGroupID := -1
+	if v := c.Query("site_group_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id < 0 {
+			core.FailWithMessage(c, core.ErrInvalidParam, "无效的站群 ID")
+			return
+		}
+		siteGroupID = id
+	}
+	items, err := h.policies.List(c.Request.Context(), siteGroupID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list robots policies")
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+	core.Success(c, gin.H{"items": items, "stats": h.policies.Stats()})
+}
+
+// Create 创建规则
+// POST /api/robots-policies
+func (h *RobotsPoliciesHandler) Create(c *gin.Context) {
+	policy, ok := h.bind(c)
+	if !ok {
+		return
+	}
+	id, err := h.policies.Create(c.Request.Context(), policy)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, id)
+}
+
+// Update 更新规则
+// PUT /api/robots-policies/:id
+func (h *RobotsPoliciesHandler) Update(c *gin.Context) {
+	id, ok := parseRobotsPolicyID(c)
+	if !ok {
+		return
+	}
+	policy, ok := h.bind(c)
+	if !ok {
+		return
+	}
+	policy.ID = id
+	if err := h.policies.Update(c.Request.Context(), policy); err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, id)
+}
+
+// Delete 删除规则
+// DELETE /api/robots-policies/:id
+func (h *RobotsPoliciesHandler) Delete(c *gin.Context) {
+	id, ok := parseRobotsPolicyID(c)
+	if !ok {
+		return
+	}
+	if err := h.policies.Delete(c.Request.Context(), id); err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, nil)
+}
+
+// Test 按当前生效的规则评估 URL（返回判定和每条规则的匹配过程）
+// GET /api/robots-policies/test?url=http://example.com/list_6.html
+// GET /api/robots-policies/test?site_group_id=1&path=/list_6.html
+func (h *RobotsPoliciesHandler) Test(c *gin.Context) {
+	path := c.Query("path")
+	siteGroupID, _ := strconv.Atoi(c.Query("site_group_id"))
+	domain := ""
+	if raw := strings.TrimSpace(c.Query("url")); raw != "" {
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			core.FailWithMessage(c, core.ErrInvalidParam, "无效的 URL")
+			return
+		}
+		domain = strings.ToLower(u.Hostname())
+		path = u.EscapedPath()
+		if path == "" {
+			path = "/"
+		}
+		if u.RawQuery != "" {
+			path += "?" + u.RawQuery
+		}
+		site, err := h.siteCache.Get(c.Request.Context(), domain)
+		if err != nil {
+			core.FailWithCode(c, core.ErrDBQuery)
+			return
+		}
+		if site == nil {
+			core.FailWithMessage(c, core.ErrNotFound, "站点不存在: "+domain)
+			return
+		}
+		siteGroupID = site.SiteGroupID
+	}
+	if !strings.HasPrefix(path, "/") {
+		core.FailWithMessage(c, core.ErrInvalidParam, "需要 url 或以 / 开头的 path")
+		return
+	}
+
+	decision, rules := h.policies.Explain(siteGroupID, path)
+	core.Success(c, gin.H{
+		"domain":        domain,
+		"site_group_id": siteGroupID,
+		"path":          path,
+		"decision":      decision,
+		"rules":         rules,
+	})
+}
+
+// bind 解析并校验请求
+func (h *RobotsPoliciesHandler) bind(c *gin.Context) (*core.RobotsPolicy, bool) {
+	var req RobotsPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return nil, false
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.SiteGroupID < 0 || req.MinPage < 0 || len(req.Name) > 100 || len(req.Pattern) > 500 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return nil, false
+	}
+	if _, _, err := core.CompileRobotsPattern(req.Pattern); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的正则: "+err.Error())
+		return nil, false
+	}
+	robots, err := core.NormalizeRobotsDirectives(req.Robots)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+		return nil, false
+	}
+	if robots == "" && !req.NofollowExternal {
+		core.FailWithMessage(c, core.ErrInvalidParam, "robots 和 nofollow_external 至少设置一项")
+		return nil, false
+	}
+	return &core.RobotsPolicy{
+		SiteGroupID:      req.SiteGroupID,
+		Name:             req.Name,
+		Pattern:          req.Pattern,
+		MinPage:          req.MinPage,
+		Robots:           robots,
+		NofollowExternal: req.NofollowExternal,
+		Priority:         req.Priority,
+		Enabled:          req.Enabled == nil || *req.Enabled,
+	}, true
+}
+
+// respond 返回保存后的规则
+func (h *RobotsPoliciesHandler) respond(c *gin.Context, id int64) {
+	policy, err := h.policies.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, policy)
+}
+
+// fail 按错误类型返回
+func (h *RobotsPoliciesHandler) fail(c *gin.Context, err error) {
+	if errors.Is(err, core.ErrRobotsPolicyNotFound) {
+		core.FailWithMessage(c, core.ErrNotFound, "规则不存在")
+		return
+	}
+	log.Error().Err(err).Msg("Robots policy operation failed")
+	core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+}
+
+func parseRobotsPolicyID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的 ID")
+		return 0, false
+	}
+	return id, true
+}
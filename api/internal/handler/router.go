@@ -3,15 +3,19 @@ package api
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
 
+	database "seo-generator/api/internal/repository"
 	core "seo-generator/api/internal/service"
 	"seo-generator/api/pkg/config"
 )
@@ -21,17 +25,67 @@ var startTime = time.Now()
 
 // Dependencies holds all dependencies required by the API handlers
 type Dependencies struct {
-	DB               *sqlx.DB
-	Redis            *redis.Client
-	Config           *config.Config
-	TemplateAnalyzer *core.TemplateAnalyzer
-	TemplateFuncs    *core.TemplateFuncsManager
-	Scheduler        *core.Scheduler
-	TemplateCache    *core.TemplateCache
-	Monitor          *core.Monitor
-	PoolManager      *core.PoolManager
-	SystemStats      *core.SystemStatsCollector
-	SiteCache        *core.SiteCache
+	DB                *sqlx.DB
+	Redis             *redis.Client
+	Config            *config.Config
+	TemplateAnalyzer  *core.TemplateAnalyzer
+	TemplateFuncs     *core.TemplateFuncsManager
+	Scheduler         *core.Scheduler
+	TemplateCache     *core.TemplateCache
+	Monitor           *core.Monitor
+	PoolManager       *core.PoolManager
+	SystemStats       *core.SystemStatsCollector
+	SiteCache         *core.SiteCache
+	JobManager        *core.JobManager
+	ContentFilter     *core.ContentFilter
+	KeywordFilter     *core.KeywordFilter
+	ImageSigner       *core.ImageURLSigner
+	ArticleSanitizer  *core.ArticleSanitizer
+	Excerpts          *core.ExcerptGenerator
+	LLMGateway        *core.LLMGateway
+	ArticleRewriter   *core.ArticleRewriter
+	ArticleIngester   *core.ArticleIngester
+	ClickHouse        *core.ClickHouseSink // 可选，nil 时统计走 MySQL
+	TemplateHealth    *core.TemplateHealth
+	TemplateUsage     *core.TemplateUsage
+	Sessions          *core.SessionStore // 可选，nil 时 JWT 仅校验签名
+	LoginGuard        *core.LoginGuard   // 可选，nil 时不做登录限流
+	IPAllowlist       *core.IPAllowlist  // 可选，白名单中间件在 main 中全局挂载
+	Backups           *core.BackupManager
+	LogLevels         *core.LogLevels // 可选，nil 时日志级别接口返回未启用
+	FeatureFlags      *core.FeatureFlags
+	SpiderStrategies  *core.SpiderStrategyResolver
+	AntiScraper       *core.AntiScraper
+	KeywordFeedback   *core.KeywordFeedback
+	KeywordImporter   *core.KeywordImporter
+	KeywordExpander   *core.KeywordExpander
+	URLStrategies     *core.URLStrategyManager
+	Segmenter         *core.TextSegmenter
+	Spintax           *core.SpintaxRewriter
+	PublishDates      *core.PublishDates
+	AutoTDK           *core.AutoTDK
+	ContentArchiver   *core.ContentArchiver
+	RobotsChecker     *core.RobotsChecker
+	SpiderOutput      *core.SpiderOutputCapture // 无 Redis 时为 nil
+	Freshness         *core.Freshness
+	PinnedPages       *core.PinnedPages
+	DomainMonitor     *core.DomainMonitor
+	VerificationFiles *core.VerificationFiles
+	WASMExtensions    *core.WASMExtensions // 未启用时为 nil
+	CSSObfuscator     *core.CSSObfuscator
+	RobotsPolicies    *core.RobotsPolicies
+	LinkAuditor       *core.LinkAuditor        // 未启用时为 nil
+	EgressAuditor     *core.EgressAuditor      // 未启用时为 nil
+	WSHub             *core.WSHub              // 实时推送连接票据和连接数限制，nil 时不限制
+	SystemMetrics     *core.SystemMetricsStore // 未启用时为 nil，历史指标只有内存窗口
+	AlertRules        *core.AlertRules
+	Canary            *core.Canary // 渲染自检
+	Replayer          *core.Replayer
+	Rollouts          *core.TemplateRollouts // 模板灰度发布
+	Snippets          *core.TemplateSnippets // 模板片段库
+	ActivityStats     *core.ActivityStats
+	WorkerFiles       *core.ConfinedFS // 内容处理代码文件管理根目录
+	FileTrash         *core.FileTrash
 }
 
 // SetupRouter configures all API routes
@@ -40,8 +94,28 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 	// 供使用 c.Get("db")、c.Get("redis")、c.Get("config") 和 c.Get("scheduler") 的 Handler 使用
 	r.Use(DependencyInjectionMiddleware(deps.DB, deps.Redis, deps.Config, deps.Scheduler))
 
+	// 请求体大小限制（需在读取请求体的 OpenAPI 校验之前）
+	r.Use(BodyLimitMiddleware(deps.Config.BodyLimits))
+
+	// 会话校验（AuthMiddleware / DualAuthMiddleware 使用）
+	sessionStore = deps.Sessions
+	authCookie = deps.Config.Auth.Cookie
+
+	// 按 OpenAPI 注解校验请求体（需在注册路由前挂载，分组才会继承）
+	if deps.Config.OpenAPI.ValidateRequests {
+		r.Use(OpenAPIValidationMiddleware())
+	}
+
+	// OpenAPI 文档与 Swagger UI（公开）
+	if deps.Config.OpenAPI.Enabled {
+		spec := NewOpenAPISpec(r, "SEO Generator API", "1.0.0")
+		r.GET("/api/openapi.json", spec.ServeSpec)
+		r.GET("/api/docs", spec.ServeUI)
+	}
+
 	// 双轨认证中间件（JWT 或 API Token），用于外部可调用的添加接口
 	dualAuth := DualAuthMiddleware(deps.Config.Auth.SecretKey, deps.DB)
+	jwtAuth := AuthMiddleware(deps.Config.Auth.SecretKey)
 
 	// Auth routes (public - no middleware required)
 	authGroup := r.Group("/api/auth")
@@ -50,27 +124,51 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 			deps.Config.Auth.SecretKey,
 			deps.Config.Auth.AccessTokenExpireMinutes,
 			deps.DB,
+			deps.Sessions,
+			deps.LoginGuard,
+			deps.IPAllowlist,
 		)
 		authGroup.POST("/login", authHandler.Login)
 		authGroup.POST("/logout", authHandler.Logout)
+		authGroup.GET("/csrf", CSRFToken)
 
 		// Protected auth routes (require JWT)
 		authProtected := authGroup.Group("")
 		authProtected.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
 		{
 			authProtected.GET("/profile", authHandler.Profile)
+			authProtected.PUT("/locale", authHandler.SetLocale)
 			authProtected.POST("/change-password", authHandler.ChangePassword)
+			authProtected.GET("/sessions", authHandler.ListSessions)
+			authProtected.DELETE("/sessions/:id", authHandler.RevokeSession)
+			authProtected.POST("/sessions/revoke-others", authHandler.RevokeOtherSessions)
+			authProtected.GET("/login-attempts", authHandler.ListLoginAttempts)
+			authProtected.GET("/login-anomalies", authHandler.LoginAnomalies)
+			authProtected.POST("/login-unlock", authHandler.UnlockLogin)
+			authProtected.POST("/ws-ticket", IssueWSTicket(deps.WSHub))
 		}
 	}
 
+	// I18n routes (public - 登录页也需要文案)
+	r.GET("/api/i18n/messages", I18nMessages)
+
 	// Dashboard routes (require JWT)
-	dashboardHandler := NewDashboardHandler(deps.DB, deps.Monitor)
+	dashboardHandler := NewDashboardHandler(deps.DB, deps.Monitor, deps.ClickHouse, deps.PoolManager, deps.SystemStats)
 	dashboardGroup := r.Group("/api/dashboard")
 	dashboardGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
 	{
 		dashboardGroup.GET("/stats", dashboardHandler.Stats)
+		dashboardGroup.GET("/overview", dashboardHandler.Overview)
 		dashboardGroup.GET("/spider-visits", dashboardHandler.SpiderVisits)
 		dashboardGroup.GET("/cache-stats", dashboardHandler.CacheStats)
+		dashboardGroup.GET("/cache-stats/series", dashboardHandler.CacheStatsSeries)
+	}
+	if deps.ActivityStats != nil {
+		activityHandler := NewActivityHandler(deps.ActivityStats)
+		dashboardGroup.GET("/activity/articles", activityHandler.Articles)
+		dashboardGroup.GET("/activity/keywords", activityHandler.Keywords)
+		dashboardGroup.GET("/activity/pools", activityHandler.Pools)
+		dashboardGroup.POST("/activity/rollup", activityHandler.Rollup)
 	}
 
 	// Logs routes (require JWT)
@@ -84,23 +182,31 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 	}
 
 	// Templates routes (require JWT)
-	templatesHandler := NewTemplatesHandler(deps.DB, deps.TemplateAnalyzer)
+	templatesHandler := NewTemplatesHandler(deps.DB, deps.TemplateAnalyzer, deps.TemplateHealth)
 	templatesGroup := r.Group("/api/templates")
 	templatesGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
 	{
 		templatesGroup.GET("", templatesHandler.List)
 		templatesGroup.GET("/options", templatesHandler.Options)
+		templatesGroup.GET("/health", templatesHandler.Health)
+		templatesGroup.GET("/library", templatesHandler.Library)
+		templatesGroup.GET("/tags", templatesHandler.Tags)
+		templatesGroup.GET("/unused", templatesHandler.Unused)
+		templatesGroup.GET("/functions", templatesHandler.Functions)
+		templatesGroup.POST("/check", templatesHandler.Check)
 		templatesGroup.GET("/:id", templatesHandler.Get)
 		templatesGroup.GET("/:id/sites", templatesHandler.GetSites)
 		templatesGroup.POST("", templatesHandler.Create)
 		templatesGroup.PUT("/:id", templatesHandler.Update)
 		templatesGroup.DELETE("/:id", templatesHandler.Delete)
+		templatesGroup.POST("/:id/enable", templatesHandler.Enable)
+		templatesGroup.PUT("/:id/tags", templatesHandler.UpdateTags)
 	}
 
-	// Keywords routes (require JWT)
-	keywordsHandler := NewKeywordsHandler(deps.DB, deps.PoolManager, deps.TemplateFuncs)
+	// Keywords routes（JWT 或 keywords 权限的命令行 Token）
+	keywordsHandler := NewKeywordsHandler(deps.DB, deps.PoolManager, deps.TemplateFuncs, deps.JobManager, deps.KeywordFilter)
 	keywordsGroup := r.Group("/api/keywords")
-	keywordsGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+	keywordsGroup.Use(CLIScopeMiddleware(deps.DB, "keywords", jwtAuth))
 	{
 		// 分组管理
 		keywordsGroup.GET("/groups", keywordsHandler.ListGroups)
@@ -118,26 +224,52 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 		keywordsGroup.DELETE("/delete-all", keywordsHandler.DeleteAll)
 		keywordsGroup.PUT("/batch/status", keywordsHandler.BatchUpdateStatus)
 		keywordsGroup.PUT("/batch/move", keywordsHandler.BatchMove)
+		keywordsGroup.POST("/normalize", keywordsHandler.Normalize)
+
+		// 入库过滤停用词
+		keywordsGroup.GET("/stopwords", keywordsHandler.GetStopwords)
+		keywordsGroup.PUT("/stopwords", keywordsHandler.SetStopwords)
 
 		// 上传
 		keywordsGroup.POST("/upload", keywordsHandler.Upload)
 
 		// 辅助功能
 		keywordsGroup.POST("/reload", keywordsHandler.Reload)
+
+		// 抓取反馈得分
+		if deps.KeywordFeedback != nil {
+			feedbackHandler := NewKeywordFeedbackHandler(deps.KeywordFeedback)
+			keywordsGroup.GET("/feedback", feedbackHandler.Scores)
+			keywordsGroup.POST("/feedback/compute", feedbackHandler.Compute)
+		}
+
+		// 搜索词候选审核
+		if deps.KeywordImporter != nil {
+			candidatesHandler := NewKeywordCandidatesHandler(deps.KeywordImporter, deps.PoolManager, deps.TemplateFuncs)
+			keywordsGroup.GET("/candidates", candidatesHandler.List)
+			keywordsGroup.POST("/candidates/approve", candidatesHandler.Approve)
+			keywordsGroup.POST("/candidates/reject", candidatesHandler.Reject)
+			keywordsGroup.POST("/candidates/import", candidatesHandler.Import)
+		}
+
+		// 长尾关键词扩展
+		if deps.KeywordExpander != nil {
+			keywordsGroup.POST("/expand", NewKeywordExpandHandler(deps.KeywordExpander).Expand)
+		}
 	}
 
-	// Keywords 添加接口（支持 JWT 或 API Token 双轨认证）
+	// Keywords 添加接口（支持 JWT 或 API Token 双轨认证，以及 keywords 权限的命令行 Token）
 	keywordsDual := r.Group("/api/keywords")
-	keywordsDual.Use(dualAuth)
+	keywordsDual.Use(CLIScopeMiddleware(deps.DB, "keywords", dualAuth))
 	{
 		keywordsDual.POST("/add", keywordsHandler.Add)
 		keywordsDual.POST("/batch", keywordsHandler.BatchAdd)
 	}
 
-	// Images routes (require JWT)
-	imagesHandler := NewImagesHandler(deps.DB, deps.PoolManager, deps.TemplateFuncs)
+	// Images routes（JWT 或 images 权限的命令行 Token）
+	imagesHandler := NewImagesHandler(deps.DB, deps.PoolManager, deps.TemplateFuncs, deps.JobManager)
 	imagesGroup := r.Group("/api/images")
-	imagesGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+	imagesGroup.Use(CLIScopeMiddleware(deps.DB, "images", jwtAuth))
 	{
 		// 分组管理
 		imagesGroup.GET("/groups", imagesHandler.ListGroups)
@@ -159,18 +291,24 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 
 		// 辅助功能
 		imagesGroup.POST("/urls/reload", imagesHandler.Reload)
+
+		// CDN 签名配置和预览
+		imageSigningHandler := NewImageSigningHandler(deps.ImageSigner)
+		imagesGroup.GET("/signing", imageSigningHandler.Get)
+		imagesGroup.POST("/signing/preview", imageSigningHandler.Preview)
 	}
 
-	// Images 添加接口（支持 JWT 或 API Token 双轨认证）
+	// Images 添加接口（支持 JWT 或 API Token 双轨认证，以及 images 权限的命令行 Token）
 	imagesDual := r.Group("/api/images")
-	imagesDual.Use(dualAuth)
+	imagesDual.Use(CLIScopeMiddleware(deps.DB, "images", dualAuth))
 	{
 		imagesDual.POST("/urls/add", imagesHandler.AddURL)
 		imagesDual.POST("/urls/batch", imagesHandler.BatchAddURLs)
 	}
 
 	// Articles routes (require JWT)
-	articlesHandler := NewArticlesHandler(deps.DB, deps.Redis)
+	articlesHandler := NewArticlesHandler(deps.DB, deps.JobManager, deps.ArticleIngester)
+	rewriteHandler := NewLLMRewriteHandler(deps.DB, deps.Redis, deps.LLMGateway, deps.ArticleRewriter)
 	articlesGroup := r.Group("/api/articles")
 	articlesGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
 	{
@@ -191,20 +329,237 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 		articlesGroup.DELETE("/delete-all", articlesHandler.DeleteAll)
 		articlesGroup.PUT("/batch/status", articlesHandler.BatchUpdateStatus)
 		articlesGroup.PUT("/batch/move", articlesHandler.BatchMove)
+
+		// 入库清洗策略
+		sanitizeHandler := NewArticleSanitizeHandler(deps.DB, deps.ArticleSanitizer)
+		articlesGroup.GET("/sanitize-policies", sanitizeHandler.ListPolicies)
+		articlesGroup.PUT("/sanitize-policies", sanitizeHandler.SetPolicy)
+		articlesGroup.POST("/sanitize/test", sanitizeHandler.Test)
+
+		// LLM 改写
+		articlesGroup.GET("/rewrite-policies", rewriteHandler.ListPolicies)
+		articlesGroup.PUT("/rewrite-policies", rewriteHandler.SetPolicy)
+		articlesGroup.POST("/rewrite", rewriteHandler.Run)
 	}
 
-	// Articles 添加接口（支持 JWT 或 API Token 双轨认证）
+	// LLM usage routes (改写用量和成本，require JWT)
+	llmRoutes := r.Group("/api/llm")
+	llmRoutes.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+	{
+		llmRoutes.GET("/usage", rewriteHandler.Usage)
+	}
+
+	// Articles 添加接口（支持 JWT、API Token 或推送方 Token）
 	articlesDual := r.Group("/api/articles")
-	articlesDual.Use(dualAuth)
+	articlesDual.Use(FeederAuthMiddleware(deps.Config.Auth.SecretKey, deps.DB))
 	{
 		articlesDual.POST("/add", articlesHandler.Add)
 		articlesDual.POST("/batch", articlesHandler.BatchAdd)
 	}
 
-	// Sites routes (require JWT)
+	// Banned words routes (违禁词过滤，require JWT)
+	bannedWordsHandler := NewBannedWordsHandler(deps.DB, deps.ContentFilter)
+	bannedWordsGroup := r.Group("/api/banned-words")
+	bannedWordsGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+	{
+		bannedWordsGroup.GET("", bannedWordsHandler.List)
+		bannedWordsGroup.POST("", bannedWordsHandler.Create)
+		bannedWordsGroup.POST("/batch", bannedWordsHandler.BatchAdd)
+		bannedWordsGroup.GET("/policies", bannedWordsHandler.ListPolicies)
+		bannedWordsGroup.PUT("/policies", bannedWordsHandler.SetPolicy)
+		bannedWordsGroup.POST("/test", bannedWordsHandler.Test)
+		bannedWordsGroup.GET("/report", bannedWordsHandler.Report)
+		bannedWordsGroup.PUT("/:id", bannedWordsHandler.Update)
+		bannedWordsGroup.DELETE("/:id", bannedWordsHandler.Delete)
+	}
+
+	// Feature flag routes (require JWT)
+	if deps.FeatureFlags != nil {
+		featureFlagsHandler := NewFeatureFlagsHandler(deps.FeatureFlags)
+		featureFlagsGroup := r.Group("/api/feature-flags")
+		featureFlagsGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+		{
+			featureFlagsGroup.GET("", featureFlagsHandler.List)
+			featureFlagsGroup.POST("", featureFlagsHandler.Create)
+			featureFlagsGroup.PUT("/:key", featureFlagsHandler.Update)
+			featureFlagsGroup.DELETE("/:key", featureFlagsHandler.Delete)
+			featureFlagsGroup.GET("/:key/evaluate", featureFlagsHandler.Evaluate)
+		}
+	}
+
+	// Spider strategy routes (蜘蛛内容策略，require JWT)
+	if deps.SpiderStrategies != nil {
+		spiderStrategiesHandler := NewSpiderStrategiesHandler(deps.DB, deps.SpiderStrategies)
+		spiderStrategiesGroup := r.Group("/api/spider-strategies")
+		spiderStrategiesGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+		{
+			spiderStrategiesGroup.GET("", spiderStrategiesHandler.List)
+			spiderStrategiesGroup.POST("", spiderStrategiesHandler.Create)
+			spiderStrategiesGroup.GET("/stats", spiderStrategiesHandler.Stats)
+			spiderStrategiesGroup.PUT("/:id", spiderStrategiesHandler.Update)
+			spiderStrategiesGroup.DELETE("/:id", spiderStrategiesHandler.Delete)
+		}
+	}
+
+	// URL strategy routes (站群 URL 生成策略，require JWT)
+	if deps.URLStrategies != nil {
+		urlStrategiesHandler := NewURLStrategiesHandler(deps.DB, deps.URLStrategies, deps.SiteCache)
+		urlStrategiesGroup := r.Group("/api/url-strategies")
+		urlStrategiesGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+		{
+			urlStrategiesGroup.GET("", urlStrategiesHandler.List)
+			urlStrategiesGroup.GET("/preview", urlStrategiesHandler.Preview)
+			urlStrategiesGroup.PUT("/:site_group_id", urlStrategiesHandler.Update)
+			urlStrategiesGroup.DELETE("/:site_group_id", urlStrategiesHandler.Delete)
+		}
+	}
+
+	// Publish date routes (站群模拟发布日期分布，require JWT)
+	if deps.PublishDates != nil {
+		publishDatesHandler := NewPublishDatesHandler(deps.DB, deps.PublishDates, deps.URLStrategies, deps.SiteCache)
+		publishDatesGroup := r.Group("/api/publish-dates")
+		publishDatesGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+		{
+			publishDatesGroup.GET("", publishDatesHandler.List)
+			publishDatesGroup.GET("/preview", publishDatesHandler.Preview)
+			publishDatesGroup.PUT("/:site_group_id", publishDatesHandler.Update)
+			publishDatesGroup.DELETE("/:site_group_id", publishDatesHandler.Delete)
+		}
+	}
+
+	// Content archive routes (冷正文归档，require JWT)
+	if deps.ContentArchiver != nil {
+		contentArchivesHandler := NewContentArchivesHandler(deps.ContentArchiver)
+		contentArchivesGroup := r.Group("/api/content-archives")
+		contentArchivesGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+		{
+			contentArchivesGroup.GET("", contentArchivesHandler.List)
+			contentArchivesGroup.POST("/run", contentArchivesHandler.Run)
+			contentArchivesGroup.POST("/:id/restore", contentArchivesHandler.Restore)
+		}
+	}
+
+	// Pinned page routes (固定页面，require JWT)
+	if deps.PinnedPages != nil {
+		pinnedPagesHandler := NewPinnedPagesHandler(deps.DB, deps.PinnedPages, deps.SiteCache)
+		pinnedPagesGroup := r.Group("/api/pinned-pages")
+		pinnedPagesGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+		{
+			pinnedPagesGroup.GET("", pinnedPagesHandler.List)
+			pinnedPagesGroup.POST("", pinnedPagesHandler.Create)
+			pinnedPagesGroup.GET("/:id", pinnedPagesHandler.Get)
+			pinnedPagesGroup.PUT("/:id", pinnedPagesHandler.Update)
+			pinnedPagesGroup.DELETE("/:id", pinnedPagesHandler.Delete)
+		}
+	}
+
+	// Robots policy routes (页面级 noindex/nofollow 规则，require JWT)
+	if deps.RobotsPolicies != nil {
+		robotsPoliciesHandler := NewRobotsPoliciesHandler(deps.RobotsPolicies, deps.SiteCache)
+		robotsPoliciesGroup := r.Group("/api/robots-policies")
+		robotsPoliciesGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+		{
+			robotsPoliciesGroup.GET("", robotsPoliciesHandler.List)
+			robotsPoliciesGroup.GET("/test", robotsPoliciesHandler.Test)
+			robotsPoliciesGroup.POST("", robotsPoliciesHandler.Create)
+			robotsPoliciesGroup.PUT("/:id", robotsPoliciesHandler.Update)
+			robotsPoliciesGroup.DELETE("/:id", robotsPoliciesHandler.Delete)
+		}
+	}
+
+	// Template rollout routes (模板灰度发布，require JWT)
+	if deps.Rollouts != nil {
+		rolloutsHandler := NewTemplateRolloutsHandler(deps.Rollouts)
+		rolloutsGroup := r.Group("/api/template-rollouts")
+		rolloutsGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+		{
+			rolloutsGroup.GET("", rolloutsHandler.List)
+			rolloutsGroup.POST("", rolloutsHandler.Create)
+			rolloutsGroup.GET("/:id", rolloutsHandler.Get)
+			rolloutsGroup.PUT("/:id/percent", rolloutsHandler.SetPercent)
+			rolloutsGroup.POST("/:id/complete", rolloutsHandler.Complete)
+			rolloutsGroup.POST("/:id/rollback", rolloutsHandler.Rollback)
+		}
+	}
+
+	// Template snippet routes (模板片段，require JWT)
+	if deps.Snippets != nil {
+		snippetsHandler := NewTemplateSnippetsHandler(deps.Snippets)
+		snippetsGroup := r.Group("/api/template-snippets")
+		snippetsGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+		{
+			snippetsGroup.GET("", snippetsHandler.List)
+			snippetsGroup.POST("", snippetsHandler.Create)
+			snippetsGroup.GET("/:name", snippetsHandler.Get)
+			snippetsGroup.GET("/:name/usage", snippetsHandler.Usage)
+			snippetsGroup.PUT("/:name", snippetsHandler.Update)
+			snippetsGroup.DELETE("/:name", snippetsHandler.Delete)
+		}
+	}
+
+	// Alert rule routes (自定义告警规则，require JWT)
+	var alertRulesHandler *AlertRulesHandler
+	if deps.AlertRules != nil {
+		alertRulesHandler = NewAlertRulesHandler(deps.AlertRules)
+		alertRulesGroup := r.Group("/api/alert-rules")
+		alertRulesGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+		{
+			alertRulesGroup.GET("", alertRulesHandler.List)
+			alertRulesGroup.GET("/metrics", alertRulesHandler.Metrics)
+			alertRulesGroup.POST("", alertRulesHandler.Create)
+			alertRulesGroup.PUT("/:id", alertRulesHandler.Update)
+			alertRulesGroup.DELETE("/:id", alertRulesHandler.Delete)
+		}
+	}
+
+	// WASM extension routes (站群渲染扩展，require JWT)
+	if deps.WASMExtensions != nil {
+		wasmExtensionsHandler := NewWASMExtensionsHandler(deps.WASMExtensions)
+		wasmExtensionsGroup := r.Group("/api/wasm-extensions")
+		wasmExtensionsGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+		{
+			wasmExtensionsGroup.GET("", wasmExtensionsHandler.List)
+			wasmExtensionsGroup.POST("/reload", wasmExtensionsHandler.Reload)
+			wasmExtensionsGroup.PUT("/:site_group_id", wasmExtensionsHandler.Save)
+			wasmExtensionsGroup.DELETE("/:site_group_id", wasmExtensionsHandler.Delete)
+		}
+	}
+
+	// Freshness routes (内容保鲜，require JWT)
+	if deps.Freshness != nil {
+		freshnessHandler := NewFreshnessHandler(deps.Freshness)
+		freshnessGroup := r.Group("/api/freshness")
+		freshnessGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+		{
+			freshnessGroup.GET("/policies", freshnessHandler.ListPolicies)
+			freshnessGroup.PUT("/policies/:site_group_id", freshnessHandler.UpdatePolicy)
+			freshnessGroup.DELETE("/policies/:site_group_id", freshnessHandler.DeletePolicy)
+			freshnessGroup.POST("/run", freshnessHandler.Run)
+			freshnessGroup.GET("/history", freshnessHandler.History)
+		}
+	}
+
+	// Segmenter routes (分词词典与关键词密度，require JWT)
+	if deps.Segmenter != nil {
+		segmenterHandler := NewSegmenterHandler(deps.DB, deps.Segmenter, deps.Spintax, deps.PoolManager)
+		segmenterGroup := r.Group("/api/segmenter")
+		segmenterGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+		{
+			segmenterGroup.GET("/words", segmenterHandler.ListWords)
+			segmenterGroup.POST("/words", segmenterHandler.AddWords)
+			segmenterGroup.DELETE("/words/:id", segmenterHandler.DeleteWord)
+			segmenterGroup.GET("/stats", segmenterHandler.Stats)
+			segmenterGroup.POST("/cut", segmenterHandler.Cut)
+			segmenterGroup.POST("/density", segmenterHandler.Density)
+			segmenterGroup.POST("/spintax/preview", segmenterHandler.SpintaxPreview)
+			segmenterGroup.POST("/spintax/reload", segmenterHandler.SpintaxReload)
+		}
+	}
+
+	// Sites routes（JWT 或 sites 权限的命令行 Token）
 	sitesHandler := NewSitesHandler(deps.DB, deps.SiteCache)
 	sitesGroup := r.Group("/api/sites")
-	sitesGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+	sitesGroup.Use(CLIScopeMiddleware(deps.DB, "sites", jwtAuth))
 	{
 		sitesGroup.GET("", sitesHandler.List)
 		sitesGroup.POST("", sitesHandler.Create)
@@ -215,7 +570,32 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 		sitesGroup.PUT("/batch/status", sitesHandler.BatchUpdateStatus)
 	}
 
+	// Site verification file routes (搜索引擎验证文件，require JWT)
+	if deps.VerificationFiles != nil {
+		siteVerificationHandler := NewSiteVerificationHandler(deps.DB, deps.VerificationFiles)
+		sitesGroup.GET("/:id/verification-files", siteVerificationHandler.List)
+		sitesGroup.POST("/:id/verification-files", siteVerificationHandler.Save)
+		sitesGroup.DELETE("/:id/verification-files/:file_id", siteVerificationHandler.Delete)
+	}
+
+	// Site redirect routes (站点 301/302 重定向规则，require JWT)
+	siteRedirectsHandler := NewSiteRedirectsHandler(deps.DB, deps.SiteCache)
+	sitesGroup.GET("/:id/redirects", siteRedirectsHandler.List)
+	sitesGroup.GET("/:id/redirects/test", siteRedirectsHandler.Test)
+	sitesGroup.POST("/:id/redirects", siteRedirectsHandler.Create)
+	sitesGroup.PUT("/:id/redirects/:redirect_id", siteRedirectsHandler.Update)
+	sitesGroup.DELETE("/:id/redirects/:redirect_id", siteRedirectsHandler.Delete)
+
+	// Site health routes (域名 DNS/HTTP/证书健康检查，require JWT)
+	if deps.DomainMonitor != nil {
+		siteHealthHandler := NewSiteHealthHandler(deps.DomainMonitor)
+		sitesGroup.GET("/health", siteHealthHandler.Summary)
+		sitesGroup.POST("/health/check", siteHealthHandler.Check)
+		sitesGroup.GET("/health/:site_id/history", siteHealthHandler.History)
+	}
+
 	// Site Groups routes (require JWT)
+	siteGroupBundleHandler := NewSiteGroupBundleHandler(deps.DB, deps.TemplateCache)
 	siteGroupsGroup := r.Group("/api/site-groups")
 	siteGroupsGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
 	{
@@ -225,18 +605,21 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 		siteGroupsGroup.GET("/:id/options", sitesHandler.GetGroupOptions)
 		siteGroupsGroup.PUT("/:id", sitesHandler.UpdateGroup)
 		siteGroupsGroup.DELETE("/:id", sitesHandler.DeleteGroup)
+		siteGroupsGroup.GET("/:id/export", siteGroupBundleHandler.Export)
+		siteGroupsGroup.POST("/import", siteGroupBundleHandler.Import)
 	}
 
 	// Groups options route (require JWT)
 	r.GET("/api/groups/options", AuthMiddleware(deps.Config.Auth.SecretKey), sitesHandler.GetAllGroupOptions)
 
-	// Spider Projects routes (require JWT)
+	// Spider Projects routes（JWT 或 spider 权限的命令行 Token）
 	spiderProjectsHandler := &SpiderProjectsHandler{}
-	spiderFilesHandler := &SpiderFilesHandler{}
+	spiderFilesHandler := &SpiderFilesHandler{trash: deps.FileTrash, python: core.NewPythonSyntaxChecker(deps.Config.PythonCheck), allowlist: deps.IPAllowlist}
 	spiderExecutionHandler := &SpiderExecutionHandler{}
-	spiderProjectStatsHandler := &SpiderStatsHandler{}
+	spiderStatsReader := core.NewSpiderStatsReader(deps.Redis)
+	spiderProjectStatsHandler := &SpiderStatsHandler{reader: spiderStatsReader}
 	spiderRoutes := r.Group("/api/spider-projects")
-	spiderRoutes.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+	spiderRoutes.Use(CLIScopeMiddleware(deps.DB, "spider", jwtAuth))
 	{
 		spiderRoutes.GET("", spiderProjectsHandler.List)
 		spiderRoutes.POST("", spiderProjectsHandler.Create)
@@ -254,6 +637,18 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 		spiderRoutes.PUT("/:id/files/*path", spiderFilesHandler.UpdateFile)    // 更新文件
 		spiderRoutes.DELETE("/:id/files/*path", spiderFilesHandler.DeleteFile) // 删除文件/目录
 		spiderRoutes.PATCH("/:id/files/*path", spiderFilesHandler.MoveItem)    // 移动/重命名
+		spiderRoutes.GET("/:id/search", spiderFilesHandler.SearchFiles)        // ?q=&regex=&case_sensitive=&path=&max_results=
+
+		// 文件版本历史
+		spiderRoutes.GET("/:id/file-versions", spiderFilesHandler.ListFileVersions)                 // ?path= 按文件过滤
+		spiderRoutes.GET("/:id/file-versions/diff", spiderFilesHandler.DiffFileVersions)            // ?from=&to=（to 为空时与当前文件比较）
+		spiderRoutes.GET("/:id/file-versions/:vid", spiderFilesHandler.GetFileVersion)              // 版本内容
+		spiderRoutes.POST("/:id/file-versions/:vid/restore", spiderFilesHandler.RestoreFileVersion) // 恢复到该版本
+
+		// 文件回收站（删除文件接口返回 restore_token）
+		spiderRoutes.GET("/:id/trash", spiderFilesHandler.ListTrash)
+		spiderRoutes.POST("/:id/trash/:token/restore", spiderFilesHandler.RestoreTrash)
+		spiderRoutes.DELETE("/:id/trash/:token", spiderFilesHandler.PurgeTrash)
 
 		// 任务控制
 		spiderRoutes.POST("/:id/run", spiderExecutionHandler.Run)
@@ -279,8 +674,27 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 		spiderRoutes.DELETE("/:id/failed/:fid", spiderProjectStatsHandler.DeleteFailed)
 	}
 
+	// Spider robots.txt compliance routes
+	// 检查接口供 Worker 调用（JWT 或 API Token），报告与管理接口需要 JWT
+	if deps.RobotsChecker != nil {
+		spiderRobotsHandler := NewSpiderRobotsHandler(deps.RobotsChecker)
+		r.POST("/api/spider-projects/:id/robots/check", dualAuth, spiderRobotsHandler.Check)
+		spiderRoutes.GET("/:id/run-report", spiderRobotsHandler.RunReport)
+		spiderRoutes.DELETE("/:id/robots/violations", spiderRobotsHandler.ClearViolations)
+		spiderRoutes.POST("/robots/cache/clear", spiderRobotsHandler.ClearRobotsCache)
+	}
+
+	// Spider output preview & field mapping routes
+	// 写入接口供 Worker 调用（JWT 或 API Token）
+	spiderOutputHandler := NewSpiderOutputHandler(deps.SpiderOutput, deps.ArticleIngester)
+	spiderRoutes.GET("/:id/test-items", spiderOutputHandler.TestItems) // ?mapped=true 附带映射结果
+	spiderRoutes.GET("/:id/field-mapping", spiderOutputHandler.GetFieldMapping)
+	spiderRoutes.PUT("/:id/field-mapping", spiderOutputHandler.UpdateFieldMapping)
+	spiderRoutes.POST("/:id/field-mapping/preview", spiderOutputHandler.PreviewFieldMapping)
+	r.POST("/api/spider-projects/:id/items", dualAuth, spiderOutputHandler.Ingest)
+
 	// Spider Stats routes (require JWT)
-	spiderStatsHandler := &SpiderStatsHandler{}
+	spiderStatsHandler := &SpiderStatsHandler{reader: spiderStatsReader}
 	statsRoutes := r.Group("/api/spider-stats")
 	statsRoutes.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
 	{
@@ -288,6 +702,7 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 		statsRoutes.GET("/chart", spiderStatsHandler.GetChart)
 		statsRoutes.GET("/scheduled", spiderStatsHandler.GetScheduled)
 		statsRoutes.GET("/by-project", spiderStatsHandler.GetByProject)
+		statsRoutes.GET("/redis-metrics", spiderStatsHandler.GetRedisMetrics)
 	}
 
 	// Pool config routes (require JWT) - 使用 PoolConfigHandler
@@ -299,21 +714,32 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 		poolConfigGroup.PUT("", poolConfigHandler.UpdateConfig)
 	}
 
-	// Cache Pool routes (require JWT) - 标题和正文缓存池配置
+	// Cache Pool routes（JWT 或 pools 权限的命令行 Token）- 标题和正文缓存池配置
 	if deps.PoolManager != nil {
 		cachePoolHandler := NewPoolHandler(deps.DB, deps.PoolManager, deps.TemplateFuncs)
 		cachePoolGroup := r.Group("/api/cache-pool")
-		cachePoolGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+		cachePoolGroup.Use(CLIScopeMiddleware(deps.DB, "pools", jwtAuth))
 		{
 			cachePoolGroup.GET("/config", cachePoolHandler.GetConfig)
 			cachePoolGroup.PUT("/config", cachePoolHandler.UpdateConfig)
+			cachePoolGroup.GET("/config/groups", cachePoolHandler.GetGroupConfigs)
+			cachePoolGroup.PUT("/config/groups", cachePoolHandler.UpdateGroupConfig)
+			cachePoolGroup.DELETE("/config/groups/:type/:group_id", cachePoolHandler.DeleteGroupConfig)
 			cachePoolGroup.GET("/stats", cachePoolHandler.GetStats)
 			cachePoolGroup.POST("/reload", cachePoolHandler.Reload)
+			cachePoolGroup.GET("/forecast", cachePoolHandler.Forecast)
+			cachePoolGroup.GET("/updates", cachePoolHandler.UpdateStats)
+			cachePoolGroup.POST("/titles/reserve", cachePoolHandler.ReserveTitles)
+			cachePoolGroup.POST("/contents/reserve", cachePoolHandler.ReserveContents)
+			cachePoolGroup.GET("/reservations", cachePoolHandler.ReservationStats)
+			cachePoolGroup.GET("/reservations/:id", cachePoolHandler.GetReservation)
+			cachePoolGroup.POST("/reservations/:id/ack", cachePoolHandler.AckReservation)
+			cachePoolGroup.POST("/reservations/:id/release", cachePoolHandler.ReleaseReservation)
 		}
 	}
 
 	// Settings routes (require JWT)
-	settingsHandler := &SettingsHandler{}
+	settingsHandler := &SettingsHandler{allowlist: deps.IPAllowlist}
 	settingsRoutes := r.Group("/api/settings")
 	settingsRoutes.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
 	{
@@ -325,10 +751,22 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 		settingsRoutes.GET("/api-token", settingsHandler.GetAPIToken)
 		settingsRoutes.PUT("/api-token", settingsHandler.UpdateAPIToken)
 		settingsRoutes.POST("/api-token/generate", settingsHandler.GenerateAPIToken)
+		feederTokensHandler := NewFeederTokensHandler(deps.DB)
+		settingsRoutes.GET("/feeder-tokens", feederTokensHandler.List)
+		settingsRoutes.POST("/feeder-tokens", feederTokensHandler.Create)
+		settingsRoutes.PUT("/feeder-tokens/:id", feederTokensHandler.Update)
+		settingsRoutes.DELETE("/feeder-tokens/:id", feederTokensHandler.Delete)
+		cliTokensHandler := NewCLITokensHandler(deps.DB)
+		settingsRoutes.GET("/cli-tokens", cliTokensHandler.List)
+		settingsRoutes.POST("/cli-tokens", cliTokensHandler.Create)
+		settingsRoutes.PUT("/cli-tokens/:id", cliTokensHandler.Update)
+		settingsRoutes.DELETE("/cli-tokens/:id", cliTokensHandler.Delete)
+		settingsRoutes.GET("/ip-allowlist", settingsHandler.GetIPAllowlist)
+		settingsRoutes.PUT("/ip-allowlist", settingsHandler.UpdateIPAllowlist)
 	}
 
 	// Spider Detector routes (require JWT)
-	spiderDetectorHandler := &SpiderDetectorHandler{}
+	spiderDetectorHandler := &SpiderDetectorHandler{clickhouse: deps.ClickHouse, templateUsage: deps.TemplateUsage}
 	spiderDetectorRoutes := r.Group("/api/spiders")
 	spiderDetectorRoutes.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
 	{
@@ -340,6 +778,7 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 		spiderDetectorRoutes.GET("/hourly-stats", spiderDetectorHandler.GetSpiderHourlyStats)
 		spiderDetectorRoutes.DELETE("/logs/clear", spiderDetectorHandler.ClearSpiderLogs)
 		spiderDetectorRoutes.GET("/trend", spiderDetectorHandler.GetSpiderTrend)
+		spiderDetectorRoutes.GET("/templates", spiderDetectorHandler.GetTemplateCrawlStats)
 	}
 
 	// Processor routes (数据加工，require JWT)
@@ -356,7 +795,7 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 	}
 
 	// Content Worker Files routes (内容处理代码编辑器，require JWT)
-	contentWorkerHandler := NewContentWorkerFilesHandler("/project/content_worker")
+	contentWorkerHandler := NewContentWorkerFilesHandler(deps.WorkerFiles, deps.FileTrash, deps.IPAllowlist)
 	contentWorkerRoutes := r.Group("/api/content-worker")
 	contentWorkerRoutes.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
 	{
@@ -379,24 +818,76 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 		contentWorkerRoutes.POST("/upload/*path", contentWorkerHandler.Upload)
 		contentWorkerRoutes.GET("/download/*path", contentWorkerHandler.Download)
 
+		// 跨文件搜索（?q=&regex=&case_sensitive=&path=&max_results=）
+		contentWorkerRoutes.GET("/search", contentWorkerHandler.Search)
+
+		// 文件操作审计
+		contentWorkerRoutes.GET("/audit", contentWorkerHandler.AuditLogs)
+
+		// 回收站（删除接口返回 restore_token）
+		contentWorkerRoutes.GET("/trash", contentWorkerHandler.ListTrash)
+		contentWorkerRoutes.POST("/trash/:token/restore", contentWorkerHandler.RestoreTrash)
+		contentWorkerRoutes.DELETE("/trash/:token", contentWorkerHandler.PurgeTrash)
+
 	}
 
-	// WebSocket routes (不需要认证)
+	// Jobs routes (后台作业，require JWT)
+	var jobsHandler *JobsHandler
+	if deps.JobManager != nil {
+		jobsHandler = NewJobsHandler(deps.JobManager)
+		jobsGroup := r.Group("/api/jobs")
+		jobsGroup.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
+		{
+			jobsGroup.GET("", jobsHandler.List)
+			jobsGroup.GET("/:id", jobsHandler.Get)
+			jobsGroup.POST("/:id/cancel", jobsHandler.Cancel)
+		}
+	}
+
+	// WebSocket routes（票据校验和连接数限制见 WSHubMiddleware）
+	realtime := r.Group("")
+	realtime.Use(WSHubMiddleware(deps.WSHub, deps.IPAllowlist))
 	wsHandler := NewWebSocketHandler(deps.TemplateFuncs, deps.PoolManager, deps.SystemStats)
-	r.GET("/ws/spider-logs/:id", wsHandler.SpiderLogs)
-	r.GET("/ws/spider-stats/:id", wsHandler.SpiderStats)
-	r.GET("/ws/worker-restart", wsHandler.WorkerRestart)
-	r.GET("/ws/worker-logs", wsHandler.WorkerLogs)
-	r.GET("/ws/processor-logs", wsHandler.ProcessorLogs)
-	r.GET("/ws/processor-status", wsHandler.ProcessorStatus)
-	r.GET("/ws/pool-status", wsHandler.PoolStatus)
-	r.GET("/api/logs/ws", wsHandler.SystemLogs)
-	r.GET("/ws/system-stats", wsHandler.SystemStats)
+	realtime.GET("/ws/spider-logs/:id", wsHandler.SpiderLogs)
+	realtime.GET("/ws/spider-stats/:id", wsHandler.SpiderStats)
+	realtime.GET("/ws/worker-restart", wsHandler.WorkerRestart)
+	realtime.GET("/ws/worker-logs", wsHandler.WorkerLogs)
+	realtime.GET("/ws/processor-logs", wsHandler.ProcessorLogs)
+	realtime.GET("/ws/processor-status", wsHandler.ProcessorStatus)
+	realtime.GET("/ws/pool-status", wsHandler.PoolStatus)
+	realtime.GET("/api/logs/ws", wsHandler.SystemLogs)
+	realtime.GET("/ws/system-stats", wsHandler.SystemStats)
+
+	// SSE routes：WebSocket 被拦截时的替代方案，与 WebSocket 共享广播器
+	realtime.GET("/sse/spider-logs/:id", wsHandler.SpiderLogsSSE)
+	realtime.GET("/sse/spider-stats/:id", wsHandler.SpiderStatsSSE)
+	realtime.GET("/sse/processor-logs", wsHandler.ProcessorLogsSSE)
+	realtime.GET("/sse/processor-status", wsHandler.ProcessorStatusSSE)
+	realtime.GET("/sse/pool-status", wsHandler.PoolStatusSSE)
+	realtime.GET("/api/logs/sse", wsHandler.SystemLogsSSE)
+	realtime.GET("/sse/system-stats", wsHandler.SystemStatsSSE)
+	if jobsHandler != nil {
+		realtime.GET("/ws/jobs", jobsHandler.Stream)
+	}
 
 	// Admin API group (require JWT)
+	// Prometheus 抓取接口指标（JWT 或 API Token，Authorization: Bearer <api_token>）
+	r.GET("/api/metrics/prometheus", dualAuth, prometheusMetricsHandler())
+
 	admin := r.Group("/api/admin")
 	admin.Use(AuthMiddleware(deps.Config.Auth.SecretKey))
 
+	// Backup and restore routes
+	if deps.Backups != nil {
+		backupHandler := NewBackupHandler(deps.Backups, deps.SiteCache, deps.TemplateCache, deps.PoolManager)
+		admin.POST("/backup", backupHandler.Download)
+		admin.POST("/restore", backupHandler.Restore)
+		admin.GET("/backups", backupHandler.List)
+		admin.POST("/backups/run", backupHandler.RunNow)
+		admin.GET("/backups/:name", backupHandler.DownloadLocal)
+		admin.POST("/backups/:name/restore", backupHandler.RestoreLocal)
+	}
+
 	// Pool management routes
 	pool := admin.Group("/pool")
 	{
@@ -442,8 +933,70 @@ func SetupRouter(r *gin.Engine, deps *Dependencies) {
 		system.GET("/metrics", metricsHandler(deps))
 		system.GET("/metrics/history", metricsHistoryHandler(deps))
 		system.GET("/alerts", alertsHandler(deps))
+		if alertRulesHandler != nil {
+			system.POST("/alerts/:id/ack", alertRulesHandler.Acknowledge)
+			system.POST("/alerts/:id/resolve", alertRulesHandler.Resolve)
+		}
 		system.GET("/monitor", monitorStatsHandler(deps))
+		if deps.Canary != nil {
+			canaryHandler := NewCanaryHandler(deps.Canary)
+			system.GET("/canary", canaryHandler.Summary)
+			system.GET("/canary/history", canaryHandler.History)
+			system.POST("/canary/run", canaryHandler.Run)
+		}
+	}
+
+	// Request replay routes（从访问日志或蜘蛛日志回放请求到目标实例，对比报告见作业结果）
+	if deps.Replayer != nil {
+		replayHandler := NewReplayHandler(deps.Replayer)
+		admin.POST("/replay", replayHandler.Run)
+		admin.POST("/replay/preview", replayHandler.Preview)
+	}
+
+	// Runtime log level routes
+	admin.GET("/logging", loggingGetHandler(deps))
+	admin.PUT("/logging", loggingUpdateHandler(deps))
+
+	// Fault injection routes（仅调试模式注册，压测时模拟数据库/Redis/模板渲染故障）
+	if deps.Config.Server.Debug {
+		admin.GET("/faults", faultsGetHandler())
+		admin.PUT("/faults", faultsSetHandler())
+		admin.DELETE("/faults", faultsClearHandler())
 	}
+
+	// Anti-scrape routes
+	antiScrape := admin.Group("/anti-scrape")
+	{
+		antiScrape.GET("", antiScrapeStatsHandler(deps))
+		antiScrape.POST("/block", antiScrapeBlockHandler(deps))
+		antiScrape.DELETE("/block/:ip", antiScrapeUnblockHandler(deps))
+		antiScrape.GET("/bad-bots", antiScrapeBadBotsHandler(deps))
+		antiScrape.DELETE("/bad-bots/:ip", antiScrapePardonHandler(deps))
+	}
+
+	// Auto TDK routes
+	admin.GET("/auto-tdk", autoTDKStatsHandler(deps))
+	admin.GET("/css-obfuscation", cssObfuscationStatsHandler(deps))
+
+	// Outbound HTTP client routes（外部调用按主机的熔断状态）
+	admin.GET("/outbound", outboundStatsHandler())
+	admin.POST("/outbound/reset", outboundResetHandler())
+	admin.GET("/egress/logs", egressLogsHandler(deps))
+	admin.GET("/egress/daily", egressDailyHandler(deps))
+
+	// Link audit routes
+	admin.GET("/link-audit", linkAuditStatsHandler(deps))
+	admin.DELETE("/link-audit", linkAuditResetHandler(deps))
+
+	// Database pool routes（主库和只读副本连接池）
+	admin.GET("/db-pools", dbPoolsHandler())
+
+	// Query cache routes（后台列表查询缓存）
+	admin.GET("/query-cache", queryCacheStatsHandler())
+	admin.DELETE("/query-cache", queryCacheClearHandler())
+
+	// Bootstrap routes（按初始化清单创建默认数据）
+	admin.POST("/bootstrap", bootstrapHandler(deps))
 }
 
 // ============ Pool Management Handlers ============
@@ -1118,13 +1671,32 @@ func metricsHandler(deps *Dependencies) gin.HandlerFunc {
 		}
 
 		snapshot := deps.Monitor.GetCurrentSnapshot()
-		core.Success(c, snapshot)
+		core.Success(c, struct {
+			core.MetricsSnapshot
+			HTTP core.EndpointMetricsSnapshot `json:"http"`
+		}{snapshot, core.GetEndpointMetrics().Snapshot()})
+	}
+}
+
+// prometheusMetricsHandler GET /api/metrics/prometheus - 以 Prometheus 文本格式输出接口指标
+func prometheusMetricsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(http.StatusOK)
+		_ = core.GetEndpointMetrics().WritePrometheus(c.Writer) // 写失败说明客户端已断开
 	}
 }
 
 // metricsHistoryHandler GET /metrics/history - 获取历史指标
+// 不带 start/end 时返回内存中最近 limit 个快照；带 start/end（或 range，如 7d、12h）时查询持久化的
+// 系统指标历史，resolution 可指定 1m/5m/1h，不指定时按时间跨度自动选择
 func metricsHistoryHandler(deps *Dependencies) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if c.Query("start") != "" || c.Query("end") != "" || c.Query("range") != "" {
+			persistedMetricsHistory(c, deps)
+			return
+		}
+
 		if deps.Monitor == nil {
 			core.FailWithCode(c, core.ErrInternalServer)
 			return
@@ -1147,6 +1719,76 @@ func metricsHistoryHandler(deps *Dependencies) gin.HandlerFunc {
 	}
 }
 
+// persistedMetricsHistory 查询 system_metrics 中任意时间范围的历史指标
+func persistedMetricsHistory(c *gin.Context, deps *Dependencies) {
+	if deps.SystemMetrics == nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "系统指标历史未启用（system_metrics.enabled）")
+		return
+	}
+
+	end := time.Now()
+	if v := c.Query("end"); v != "" {
+		t, ok := parseSeriesTime(v)
+		if !ok {
+			core.FailWithMessage(c, core.ErrInvalidParam, "无效的 end 参数")
+			return
+		}
+		end = t
+	}
+	start := end.Add(-24 * time.Hour)
+	if v := c.Query("range"); v != "" {
+		d, err := parseMetricsRange(v)
+		if err != nil || d <= 0 {
+			core.FailWithMessage(c, core.ErrInvalidParam, "无效的 range 参数")
+			return
+		}
+		start = end.Add(-d)
+	}
+	if v := c.Query("start"); v != "" {
+		t, ok := parseSeriesTime(v)
+		if !ok {
+			core.FailWithMessage(c, core.ErrInvalidParam, "无效的 start 参数")
+			return
+		}
+		start = t
+	}
+	if !start.Before(end) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "start 必须早于 end")
+		return
+	}
+
+	resolution := c.Query("resolution")
+	if resolution != "" && !core.ValidResolution(resolution) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "resolution 只能是 1m、5m 或 1h")
+		return
+	}
+
+	points, resolution, err := deps.SystemMetrics.Query(c.Request.Context(), start, end, resolution)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+		return
+	}
+	core.Success(c, gin.H{
+		"start":      start,
+		"end":        end,
+		"resolution": resolution,
+		"history":    points,
+		"total":      len(points),
+	})
+}
+
+// parseMetricsRange 解析时间跨度，支持 Go duration（如 12h）和天数（如 7d）
+func parseMetricsRange(v string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(v)
+}
+
 // alertsHandler GET /alerts - 获取告警列表
 func alertsHandler(deps *Dependencies) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -1195,3 +1837,428 @@ func monitorStatsHandler(deps *Dependencies) gin.HandlerFunc {
 		core.Success(c, stats)
 	}
 }
+
+// ============ Logging Handlers ============
+
+// loggingGetHandler GET /logging - 获取当前日志级别和模块级别
+func loggingGetHandler(deps *Dependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if deps.LogLevels == nil {
+			core.FailWithMessage(c, core.ErrInternalServer, "日志级别管理未启用")
+			return
+		}
+		core.Success(c, deps.LogLevels.Settings())
+	}
+}
+
+// LoggingRequest 更新日志级别请求
+// level 为空时恢复配置文件中的级别；modules 为模块级别覆盖（如 pool_manager=debug），传空对象清除全部覆盖
+type LoggingRequest struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules"`
+}
+
+// loggingUpdateHandler PUT /logging - 运行时修改日志级别，保存后重启仍然生效
+func loggingUpdateHandler(deps *Dependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if deps.LogLevels == nil {
+			core.FailWithMessage(c, core.ErrInternalServer, "日志级别管理未启用")
+			return
+		}
+
+		var req LoggingRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+			return
+		}
+
+		settings, err := deps.LogLevels.Update(c.Request.Context(), core.LogLevelSettings{
+			Level:   req.Level,
+			Modules: req.Modules,
+		})
+		if err != nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+			return
+		}
+		core.Success(c, settings)
+	}
+}
+
+// ============ Fault Injection Handlers ============
+
+// FaultRequest 设置故障规则请求
+// latency_ms + 0~jitter_ms 为每次调用增加的延迟，error_rate 为返回错误的概率（0~1），
+// duration_seconds 大于 0 时规则到期自动失效
+type FaultRequest struct {
+	Target          string  `json:"target" binding:"required"` // db / redis / template
+	LatencyMs       int     `json:"latency_ms"`
+	JitterMs        int     `json:"jitter_ms"`
+	ErrorRate       float64 `json:"error_rate"`
+	DurationSeconds int     `json:"duration_seconds"`
+}
+
+// faultsGetHandler GET /faults - 当前故障规则和注入次数
+func faultsGetHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		injector := core.GetFaultInjector()
+		core.Success(c, gin.H{
+			"enabled": injector.Enabled(),
+			"targets": core.FaultTargets,
+			"rules":   injector.Rules(),
+		})
+	}
+}
+
+// faultsSetHandler PUT /faults - 设置目标的故障规则（覆盖原规则）
+func faultsSetHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req FaultRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+			return
+		}
+		rule := core.FaultRule{
+			Target:    req.Target,
+			LatencyMs: req.LatencyMs,
+			JitterMs:  req.JitterMs,
+			ErrorRate: req.ErrorRate,
+		}
+		if req.DurationSeconds > 0 {
+			expires := time.Now().Add(time.Duration(req.DurationSeconds) * time.Second)
+			rule.ExpiresAt = &expires
+		}
+		if err := core.GetFaultInjector().Set(rule); err != nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+			return
+		}
+		core.Success(c, core.GetFaultInjector().Rules())
+	}
+}
+
+// faultsClearHandler DELETE /faults?target=db - 清除目标的故障规则，不带 target 时清除全部
+func faultsClearHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		core.GetFaultInjector().Clear(c.Query("target"))
+		core.Success(c, core.GetFaultInjector().Rules())
+	}
+}
+
+// ============ Anti-scrape Handlers ============
+
+// antiScrapeStatsHandler GET /anti-scrape - 反采集统计、封禁列表和高频 IP
+func antiScrapeStatsHandler(deps *Dependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if deps.AntiScraper == nil {
+			core.FailWithMessage(c, core.ErrInternalServer, "反采集未初始化")
+			return
+		}
+		core.Success(c, deps.AntiScraper.Stats())
+	}
+}
+
+// autoTDKStatsHandler GET /auto-tdk - TDK 自动补全统计（按模板、站群的注入页面数）
+func autoTDKStatsHandler(deps *Dependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if deps.AutoTDK == nil {
+			core.FailWithMessage(c, core.ErrInternalServer, "TDK 自动补全未初始化")
+			return
+		}
+		stats := deps.AutoTDK.Stats()
+		// 功能开关未创建时为 nil（全部站群关闭）
+		stats["flag"] = nil
+		if deps.FeatureFlags != nil {
+			if flag, ok := deps.FeatureFlags.Get(core.FlagAutoTDK); ok {
+				stats["flag"] = flag
+			}
+		}
+		core.Success(c, stats)
+	}
+}
+
+// cssObfuscationStatsHandler GET /css-obfuscation - CSS 混淆统计和开关状态
+func cssObfuscationStatsHandler(deps *Dependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if deps.CSSObfuscator == nil {
+			core.FailWithMessage(c, core.ErrInternalServer, "CSS 混淆未初始化")
+			return
+		}
+		stats := deps.CSSObfuscator.Stats()
+		stats["flag"] = nil
+		if deps.FeatureFlags != nil {
+			if flag, ok := deps.FeatureFlags.Get(core.FlagCSSObfuscation); ok {
+				stats["flag"] = flag
+			}
+		}
+		core.Success(c, stats)
+	}
+}
+
+// outboundStatsHandler GET /outbound - 外部 HTTP 调用配置和按主机的调用、熔断统计
+func outboundStatsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := core.GetHTTPClient()
+		core.Success(c, gin.H{"config": client.Config(), "hosts": client.HostStats()})
+	}
+}
+
+// outboundResetHandler POST /outbound/reset - 手动恢复熔断（host 参数只恢复该主机）
+func outboundResetHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := core.GetHTTPClient()
+		client.ResetBreaker(strings.TrimSpace(c.Query("host")))
+		core.Success(c, gin.H{"hosts": client.HostStats()})
+	}
+}
+
+// dbPoolsHandler GET /db-pools - 主库和只读副本的连接池统计、副本复制延迟和读路由计数
+func dbPoolsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		core.Success(c, database.GetPoolStats())
+	}
+}
+
+// queryCacheStatsHandler GET /query-cache - 列表查询缓存命中率和按语句的命中统计
+func queryCacheStatsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		qc := core.GetQueryCache()
+		if qc == nil {
+			core.Success(c, gin.H{"enabled": false})
+			return
+		}
+		stats := qc.Stats()
+		stats["enabled"] = true
+		core.Success(c, stats)
+	}
+}
+
+// queryCacheClearHandler DELETE /query-cache - 清空列表查询缓存（同时通知其他实例）
+func queryCacheClearHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		qc := core.GetQueryCache()
+		if qc == nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, "查询缓存未启用（query_cache.enabled）")
+			return
+		}
+		qc.Clear()
+		core.Success(c, nil)
+	}
+}
+
+// bootstrapHandler POST /bootstrap - 按初始化清单创建缺失的默认站群、分组、管理员、示例模板和定时任务
+// ?dry_run=true 只返回将要执行的动作；已存在的条目不修改，可重复执行
+func bootstrapHandler(deps *Dependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		manifest, err := core.LoadSeedManifest(deps.Config.Bootstrap.Manifest)
+		if err != nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, "初始化清单无效: "+err.Error())
+			return
+		}
+		dryRun := c.Query("dry_run") == "true" || c.Query("dry_run") == "1"
+		report, err := core.Bootstrap(c.Request.Context(), deps.DB, manifest, dryRun)
+		if err != nil {
+			log.Error().Err(err).Msg("Bootstrap failed")
+			core.FailWithMessage(c, core.ErrInternalServer, "初始化失败: "+err.Error())
+			return
+		}
+		if !dryRun && report.Created > 0 && deps.Scheduler != nil {
+			if err := deps.Scheduler.ReloadTasks(c.Request.Context()); err != nil {
+				log.Warn().Err(err).Msg("Failed to reload scheduled tasks after bootstrap")
+			}
+		}
+		core.Success(c, report)
+	}
+}
+
+// egressLogsHandler GET /egress/logs - 出站请求明细（host、purpose、failed、start、end 过滤）
+func egressLogsHandler(deps *Dependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if deps.EgressAuditor == nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, "出站请求审计未启用（egress_audit.enabled）")
+			return
+		}
+		start, end, ok := parseEgressRange(c, 24*time.Hour)
+		if !ok {
+			return
+		}
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+		if page < 1 {
+			page = 1
+		}
+		if pageSize < 1 || pageSize > 500 {
+			pageSize = 50
+		}
+		items, total, err := deps.EgressAuditor.Logs(c.Request.Context(), core.EgressLogFilter{
+			Host:     strings.TrimSpace(c.Query("host")),
+			Purpose:  strings.TrimSpace(c.Query("purpose")),
+			Failed:   c.Query("failed") == "true" || c.Query("failed") == "1",
+			Start:    start,
+			End:      end,
+			Page:     page,
+			PageSize: pageSize,
+		})
+		if err != nil {
+			core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+			return
+		}
+		core.SuccessPaged(c, items, total, page, pageSize)
+	}
+}
+
+// egressDailyHandler GET /egress/daily - 出站请求每日汇总（默认最近 30 天）
+func egressDailyHandler(deps *Dependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if deps.EgressAuditor == nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, "出站请求审计未启用（egress_audit.enabled）")
+			return
+		}
+		start, end, ok := parseEgressRange(c, 30*24*time.Hour)
+		if !ok {
+			return
+		}
+		items, err := deps.EgressAuditor.Daily(c.Request.Context(),
+			strings.TrimSpace(c.Query("host")), strings.TrimSpace(c.Query("purpose")), start, end)
+		if err != nil {
+			core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+			return
+		}
+		core.Success(c, gin.H{"items": items, "stats": deps.EgressAuditor.Stats()})
+	}
+}
+
+// parseEgressRange 解析 start/end（默认 end 为当前时间，start 为 end 往前 window）
+func parseEgressRange(c *gin.Context, window time.Duration) (time.Time, time.Time, bool) {
+	end := time.Now()
+	if v := c.Query("end"); v != "" {
+		t, ok := parseSeriesTime(v)
+		if !ok {
+			core.FailWithMessage(c, core.ErrInvalidParam, "无效的 end 参数")
+			return time.Time{}, time.Time{}, false
+		}
+		end = t
+	}
+	start := end.Add(-window)
+	if v := c.Query("start"); v != "" {
+		t, ok := parseSeriesTime(v)
+		if !ok {
+			core.FailWithMessage(c, core.ErrInvalidParam, "无效的 start 参数")
+			return time.Time{}, time.Time{}, false
+		}
+		start = t
+	}
+	if start.After(end) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "start 不能晚于 end")
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// linkAuditStatsHandler GET /link-audit - 站外链接审计统计（汇总和按域名）
+func linkAuditStatsHandler(deps *Dependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if deps.LinkAuditor == nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, "链接审计未启用（link_audit.enabled）")
+			return
+		}
+		stats := deps.LinkAuditor.Stats()
+		stats["by_domain"] = deps.LinkAuditor.DomainStats(strings.TrimSpace(c.Query("domain")))
+		core.Success(c, stats)
+	}
+}
+
+// linkAuditResetHandler DELETE /link-audit - 清空链接审计统计（domain 参数只清空该域名）
+func linkAuditResetHandler(deps *Dependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if deps.LinkAuditor == nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, "链接审计未启用（link_audit.enabled）")
+			return
+		}
+		deps.LinkAuditor.Reset(strings.TrimSpace(c.Query("domain")))
+		core.Success(c, nil)
+	}
+}
+
+// AntiScrapeBlockRequest 手动封禁请求
+type AntiScrapeBlockRequest struct {
+	IP      string `json:"ip" binding:"required"`
+	Seconds int    `json:"seconds"` // 默认使用 anti_scrape.block_seconds
+	Reason  string `json:"reason"`
+}
+
+// antiScrapeBlockHandler POST /anti-scrape/block - 手动封禁 IP
+func antiScrapeBlockHandler(deps *Dependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if deps.AntiScraper == nil {
+			core.FailWithMessage(c, core.ErrInternalServer, "反采集未初始化")
+			return
+		}
+		var req AntiScrapeBlockRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+			return
+		}
+		if net.ParseIP(req.IP) == nil {
+			core.FailWithMessage(c, core.ErrInvalidParam, "无效的 IP 地址")
+			return
+		}
+		seconds := req.Seconds
+		if seconds <= 0 {
+			seconds = deps.Config.AntiScrape.BlockSeconds
+		}
+		if req.Reason == "" {
+			req.Reason = "manual"
+		}
+		entry := deps.AntiScraper.Block(c.Request.Context(), req.IP, req.Reason, time.Duration(seconds)*time.Second)
+		core.Success(c, entry)
+	}
+}
+
+// antiScrapeUnblockHandler DELETE /anti-scrape/block/:ip - 解除封禁
+func antiScrapeUnblockHandler(deps *Dependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if deps.AntiScraper == nil {
+			core.FailWithMessage(c, core.ErrInternalServer, "反采集未初始化")
+			return
+		}
+		if !deps.AntiScraper.Unblock(c.Request.Context(), c.Param("ip")) {
+			core.FailWithMessage(c, core.ErrNotFound, "该 IP 未被封禁")
+			return
+		}
+		core.Success(c, nil)
+	}
+}
+
+// antiScrapeBadBotsHandler GET /anti-scrape/bad-bots - 访问过蜜罐链接的 IP/UA（含到期赦免时间）
+func antiScrapeBadBotsHandler(deps *Dependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if deps.AntiScraper == nil {
+			core.FailWithMessage(c, core.ErrInternalServer, "反采集未初始化")
+			return
+		}
+		entries, err := deps.AntiScraper.BadBots(c.Request.Context())
+		if err != nil {
+			core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+			return
+		}
+		core.Success(c, gin.H{
+			"honeypot_enabled": deps.AntiScraper.HoneypotEnabled(),
+			"total":            len(entries),
+			"items":            entries,
+		})
+	}
+}
+
+// antiScrapePardonHandler DELETE /anti-scrape/bad-bots/:ip - 提前赦免（删除坏爬虫标记并解除封禁）
+func antiScrapePardonHandler(deps *Dependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if deps.AntiScraper == nil {
+			core.FailWithMessage(c, core.ErrInternalServer, "反采集未初始化")
+			return
+		}
+		if !deps.AntiScraper.Pardon(c.Request.Context(), c.Param("ip")) {
+			core.FailWithMessage(c, core.ErrNotFound, "该 IP 未被标记")
+			return
+		}
+		core.Success(c, nil)
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// SegmenterHandler 分词词典、关键词密度与伪原创 handler
+type SegmenterHandler struct {
+	db          *sqlx.DB
+	segmenter   *core.TextSegmenter
+	spintax     *core.SpintaxRewriter
+	poolManager *core.PoolManager
+}
+
+// NewSegmenterHandler 创建 SegmenterHandler
+func NewSegmenterHandler(db *sqlx.DB, segmenter *core.TextSegmenter, spintax *core.SpintaxRewriter, poolManager *core.PoolManager) *SegmenterHandler {
+	return &SegmenterHandler{db: db, segmenter: segmenter, spintax: spintax, poolManager: poolManager}
+}
+
+// SegmentWordsAddRequest 添加自定义词请求，freq 为空使用默认词频
+type SegmentWordsAddRequest struct {
+	Words []string `json:"words" binding:"required"`
+	Freq  int      `json:"freq"`
+}
+
+// SegmentCutRequest 分词请求
+type SegmentCutRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// KeywordDensityRequest 关键词密度分析请求
+// text 与 article_id 二选一；keywords 与 keyword_group_id 可同时指定
+type KeywordDensityRequest struct {
+	Text           string   `json:"text"`
+	ArticleID      int      `json:"article_id"`
+	Keywords       []string `json:"keywords"`
+	KeywordGroupID int      `json:"keyword_group_id"`
+	Top            int      `json:"top"`
+}
+
+// ListWords 自定义词列表
+// GET /api/segmenter/words?search=&page=1&page_size=50
+func (h *SegmenterHandler) ListWords(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 500 {
+		pageSize = 50
+	}
+	items, total, err := h.segmenter.ListWords(c.Request.Context(), c.Query("search"), page, pageSize)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list segment words")
+		items = []core.SegmentWord{}
+	}
+	core.SuccessPaged(c, items, total, page, pageSize)
+}
+
+// AddWords 批量添加自定义词
+// POST /api/segmenter/words
+func (h *SegmenterHandler) AddWords(c *gin.Context) {
+	var req SegmentWordsAddRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Words) == 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if len(req.Words) > 100000 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "单次最多添加 100000 个词")
+		return
+	}
+	added, err := h.segmenter.AddWords(c.Request.Context(), req.Words, req.Freq)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to add segment words")
+		core.FailWithCode(c, core.ErrDBInsert)
+		return
+	}
+	core.Success(c, gin.H{"added": added})
+}
+
+// DeleteWord 删除自定义词
+// DELETE /api/segmenter/words/:id
+func (h *SegmenterHandler) DeleteWord(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的 ID")
+		return
+	}
+	deleted, err := h.segmenter.DeleteWord(c.Request.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Int64("id", id).Msg("Failed to delete segment word")
+		core.FailWithCode(c, core.ErrDBDelete)
+		return
+	}
+	if !deleted {
+		core.FailWithCode(c, core.ErrNotFound)
+		return
+	}
+	core.Success(c, nil)
+}
+
+// Stats 词典统计
+// GET /api/segmenter/stats
+func (h *SegmenterHandler) Stats(c *gin.Context) {
+	stats := h.segmenter.Stats()
+	stats["spintax"] = h.spintax.Stats()
+	core.Success(c, stats)
+}
+
+// SpintaxPreview 伪原创预览（展开 spintax 并替换同义词，未启用时也可预览）
+// POST /api/segmenter/spintax/preview
+func (h *SegmenterHandler) SpintaxPreview(c *gin.Context) {
+	var req SegmentCutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	text, replaced := h.spintax.Spin(req.Text)
+	core.Success(c, gin.H{"text": text, "replaced": replaced})
+}
+
+// SpintaxReload 重新加载同义词表
+// POST /api/segmenter/spintax/reload
+func (h *SegmenterHandler) SpintaxReload(c *gin.Context) {
+	h.spintax.Reload()
+	core.Success(c, h.spintax.Stats())
+}
+
+// Cut 分词（用于调试自定义词典）
+// POST /api/segmenter/cut
+func (h *SegmenterHandler) Cut(c *gin.Context) {
+	var req SegmentCutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	core.Success(c, gin.H{"words": h.segmenter.Cut(req.Text)})
+}
+
+// Density 关键词密度报告
+// POST /api/segmenter/density
+func (h *SegmenterHandler) Density(c *gin.Context) {
+	var req KeywordDensityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+
+	text := req.Text
+	if req.ArticleID > 0 {
+		var article struct {
+			Title   string `db:"title"`
+			Content string `db:"content"`
+		}
+		if err := h.db.GetContext(c.Request.Context(), &article,
+			"SELECT title, content FROM original_articles WHERE id = ?", req.ArticleID); err != nil {
+			core.FailWithMessage(c, core.ErrNotFound, "文章不存在")
+			return
+		}
+		text = article.Title + "\n" + article.Content
+	}
+	if text == "" {
+		core.FailWithMessage(c, core.ErrInvalidParam, "text 与 article_id 不能同时为空")
+		return
+	}
+
+	keywords := req.Keywords
+	if req.KeywordGroupID > 0 && h.poolManager != nil {
+		keywords = append(keywords, h.poolManager.GetAllRawKeywords(req.KeywordGroupID)...)
+	}
+	top := req.Top
+	if top <= 0 || top > 500 {
+		top = 50
+	}
+	core.Success(c, h.segmenter.Density(text, keywords, top))
+}
@@ -3,10 +3,14 @@ package api
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"net"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
 )
 
 // SystemSetting 系统设置
@@ -19,7 +23,9 @@ type SystemSetting struct {
 }
 
 // SettingsHandler 系统设置处理器
-type SettingsHandler struct{}
+type SettingsHandler struct {
+	allowlist *core.IPAllowlist // 管理接口 IP 白名单，为 nil 时相关接口返回未启用
+}
 
 // 池大小默认设置
 var cacheDefaultSettings = map[string]struct {
@@ -280,3 +286,87 @@ func (h *SettingsHandler) GenerateAPIToken(c *gin.Context) {
 
 	c.JSON(200, gin.H{"success": true, "token": token})
 }
+
+// GetIPAllowlist 获取管理接口 IP 白名单设置及最近被拒绝的请求
+func (h *SettingsHandler) GetIPAllowlist(c *gin.Context) {
+	if h.allowlist == nil {
+		c.JSON(200, gin.H{"success": false, "message": "IP 白名单未初始化"})
+		return
+	}
+
+	settings := h.allowlist.Settings()
+	ip := trustedClientIP(c, h.allowlist)
+	rejections, rejectedTotal := h.allowlist.RecentRejections()
+
+	c.JSON(200, gin.H{
+		"success":           true,
+		"enabled":           settings.Enabled,
+		"cidrs":             settings.CIDRs,
+		"your_ip":           ip,
+		"your_ip_allowed":   h.allowlist.Allowed(ip),
+		"bypass_configured": h.allowlist.BypassConfigured(),
+		"rejected_total":    rejectedTotal,
+		"recent_rejections": rejections,
+	})
+}
+
+// IPAllowlistRequest 更新 IP 白名单请求
+type IPAllowlistRequest struct {
+	Enabled bool     `json:"enabled"`
+	CIDRs   []string `json:"cidrs"`
+	Force   bool     `json:"force"` // 当前 IP 不在新白名单中时仍然保存
+}
+
+// UpdateIPAllowlist 更新管理接口 IP 白名单
+// 启用后若当前请求 IP 不在白名单中会被拒绝保存（避免把自己锁在外面），force=true 时跳过检查
+func (h *SettingsHandler) UpdateIPAllowlist(c *gin.Context) {
+	if h.allowlist == nil {
+		c.JSON(200, gin.H{"success": false, "message": "IP 白名单未初始化"})
+		return
+	}
+
+	var req IPAllowlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+
+	networks, err := core.ParseCIDRs(req.CIDRs)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if req.Enabled && len(networks) == 0 {
+		c.JSON(400, gin.H{"success": false, "message": "启用白名单时至少需要一个网段"})
+		return
+	}
+
+	ip := trustedClientIP(c, h.allowlist)
+	if req.Enabled && !req.Force {
+		parsed := net.ParseIP(ip)
+		allowed := parsed != nil && parsed.IsLoopback()
+		for _, n := range networks {
+			if parsed != nil && n.Contains(parsed) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			c.JSON(200, gin.H{
+				"success": false,
+				"message": "当前 IP " + ip + " 不在新白名单中，保存后将无法访问后台；确认请传 force=true",
+				"your_ip": ip,
+			})
+			return
+		}
+	}
+
+	settings, err := h.allowlist.Update(c.Request.Context(), req.Enabled, req.CIDRs)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to update IP allowlist")
+		c.JSON(500, gin.H{"success": false, "message": "保存失败"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "enabled": settings.Enabled, "cidrs": settings.CIDRs})
+}
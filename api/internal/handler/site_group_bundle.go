@@ -0,0 +1,95 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// SiteGroupBundleHandler 站群配方导入导出
+type SiteGroupBundleHandler struct {
+	db            *sqlx.DB
+	templateCache *core.TemplateCache
+}
+
+// NewSiteGroupBundleHandler 创建 SiteGroupBundleHandler
+func NewSiteGroupBundleHandler(db *sqlx.DB, templateCache *core.TemplateCache) *SiteGroupBundleHandler {
+	return &SiteGroupBundleHandler{db: db, templateCache: templateCache}
+}
+
+// SiteGroupImportRequest 导入请求
+type SiteGroupImportRequest struct {
+	core.BundleImportOptions
+	Bundle *core.SiteGroupBundle `json:"bundle" binding:"required"`
+}
+
+// Export 导出站群为 JSON bundle
+// GET /api/site-groups/:id/export?include_data=false
+func (h *SiteGroupBundleHandler) Export(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的站群 ID")
+		return
+	}
+
+	bundle, err := core.ExportSiteGroupBundle(c.Request.Context(), h.db, id, c.Query("include_data") == "true")
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			core.FailWithMessage(c, core.ErrNotFound, "站群不存在")
+			return
+		}
+		log.Error().Err(err).Int("site_group_id", id).Msg("Failed to export site group bundle")
+		core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+		return
+	}
+
+	if c.Query("download") == "true" {
+		name := fmt.Sprintf("site-group-%d-%s.json", id, time.Now().Format("20060102-150405"))
+		c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+		c.JSON(200, bundle)
+		return
+	}
+	core.Success(c, bundle)
+}
+
+// Import 导入站群 bundle
+// POST /api/site-groups/import
+// 同名模板/分组按 on_conflict 处理：skip 保留已有、overwrite 覆盖、rename 以 name_2 等新名称另建；
+// dry_run=true 时只返回报告
+func (h *SiteGroupBundleHandler) Import(c *gin.Context) {
+	var req SiteGroupImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+
+	ctx := c.Request.Context()
+	report, err := core.ImportSiteGroupBundle(ctx, h.db, req.Bundle, req.BundleImportOptions)
+	if err != nil {
+		if errors.Is(err, core.ErrBundleInvalid) {
+			core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+			return
+		}
+		log.Error().Err(err).Msg("Failed to import site group bundle")
+		core.FailWithMessage(c, core.ErrDBUpdate, err.Error())
+		return
+	}
+
+	if !report.DryRun && h.templateCache != nil {
+		if err := h.templateCache.ReloadAll(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to reload template cache after bundle import")
+		}
+	}
+
+	log.Info().Int64("site_group_id", report.SiteGroupID).Bool("dry_run", report.DryRun).
+		Int("items", len(report.Results)).Msg("Site group bundle imported")
+	core.Success(c, report)
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// SiteHealthHandler 站点域名健康 handler
+type SiteHealthHandler struct {
+	monitor *core.DomainMonitor
+}
+
+// NewSiteHealthHandler 创建 SiteHealthHandler
+func NewSiteHealthHandler(monitor *core.DomainMonitor) *SiteHealthHandler {
+	return &SiteHealthHandler{monitor: monitor}
+}
+
+// Summary 域名健康汇总（DNS / HTTP / 证书，含连续失败次数）
+// GET /api/sites/health?unhealthy=true
+func (h *SiteHealthHandler) Summary(c *gin.Context) {
+	summary, err := h.monitor.Summary(c.Request.Context(), c.Query("unhealthy") == "true")
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to load site health")
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+	core.Success(c, summary)
+}
+
+// Check 立即检查（domain 为空时检查全部启用站点，在后台执行）
+// POST /api/sites/health/check?domain=example.com
+func (h *SiteHealthHandler) Check(c *gin.Context) {
+	domain := c.Query("domain")
+	if domain == "" {
+		go func() {
+			if _, err := h.monitor.Run(context.Background(), ""); err != nil {
+				log.Warn().Err(err).Msg("Domain health check failed")
+			}
+		}()
+		core.Success(c, gin.H{"started": true})
+		return
+	}
+
+	checked, err := h.monitor.Run(c.Request.Context(), domain)
+	if err != nil {
+		if errors.Is(err, core.ErrDomainCheckRunning) {
+			core.FailWithMessage(c, core.ErrInvalidParam, "检查正在进行中，请稍后重试")
+			return
+		}
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+		return
+	}
+	if checked == 0 {
+		core.FailWithMessage(c, core.ErrNotFound, "站点不存在")
+		return
+	}
+	summary, err := h.monitor.Summary(c.Request.Context(), false)
+	if err != nil {
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+	for _, item := range summary.Items {
+		if item.Domain == domain {
+			core.Success(c, item)
+			return
+		}
+	}
+	core.Success(c, nil)
+}
+
+// History 站点最近的检查记录
+// GET /api/sites/health/:site_id/history?limit=100
+func (h *SiteHealthHandler) History(c *gin.Context) {
+	siteID, err := strconv.Atoi(c.Param("site_id"))
+	if err != nil || siteID <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的站点 ID")
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+	items, err := h.monitor.History(c.Request.Context(), siteID, limit)
+	if err != nil {
+		log.Warn().Err(err).Int("site_id", siteID).Msg("Failed to load site health history")
+		items = []core.DomainHealthCheck{}
+	}
+	core.Success(c, items)
+}
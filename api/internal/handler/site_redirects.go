@@ -0,0 +1,187 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// SiteRedirectsHandler 站点重定向规则 handler
+type SiteRedirectsHandler struct {
+	db        *sqlx.DB
+	siteCache *core.SiteCache
+}
+
+// NewSiteRedirectsHandler 创建 SiteRedirectsHandler
+func NewSiteRedirectsHandler(db *sqlx.DB, siteCache *core.SiteCache) *SiteRedirectsHandler {
+	return &SiteRedirectsHandler{db: db, siteCache: siteCache}
+}
+
+// SiteRedirectRequest 创建/更新重定向规则请求
+type SiteRedirectRequest struct {
+	Pattern  string `json:"pattern" binding:"required"` // 匹配 路径+查询串 的正则，如 /old/(\d+)\.html
+	Target   string `json:"target" binding:"required"`  // 如 /new/$1.html 或完整 URL
+	Code     int    `json:"code"`                       // 301（默认）或 302
+	Priority int    `json:"priority"`
+	Enabled  *bool  `json:"enabled"` // 默认启用
+}
+
+// List 站点的重定向规则
+// GET /api/sites/:id/redirects
+func (h *SiteRedirectsHandler) List(c *gin.Context) {
+	siteID, _, ok := h.site(c)
+	if !ok {
+		return
+	}
+	items, err := h.siteCache.ListRedirects(c.Request.Context(), siteID)
+	if err != nil {
+		log.Warn().Err(err).Int("site_id", siteID).Msg("Failed to list site redirects")
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+	core.Success(c, gin.H{"items": items})
+}
+
+// Test 按当前生效的规则测试路径（不计入命中）
+// GET /api/sites/:id/redirects/test?path=/old/1.html
+func (h *SiteRedirectsHandler) Test(c *gin.Context) {
+	_, domain, ok := h.site(c)
+	if !ok {
+		return
+	}
+	path := c.Query("path")
+	if !strings.HasPrefix(path, "/") {
+		core.FailWithMessage(c, core.ErrInvalidParam, "path 需以 / 开头")
+		return
+	}
+	site, err := h.siteCache.Get(c.Request.Context(), domain)
+	if err != nil {
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+	core.Success(c, gin.H{"path": path, "match": h.siteCache.TestRedirect(site, path)})
+}
+
+// Create 创建重定向规则
+// POST /api/sites/:id/redirects
+func (h *SiteRedirectsHandler) Create(c *gin.Context) {
+	h.save(c, 0)
+}
+
+// Update 更新重定向规则
+// PUT /api/sites/:id/redirects/:redirect_id
+func (h *SiteRedirectsHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("redirect_id"), 10, 64)
+	if err != nil || id <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的规则 ID")
+		return
+	}
+	h.save(c, id)
+}
+
+// Delete 删除重定向规则
+// DELETE /api/sites/:id/redirects/:redirect_id
+func (h *SiteRedirectsHandler) Delete(c *gin.Context) {
+	siteID, domain, ok := h.site(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("redirect_id"), 10, 64)
+	if err != nil || id <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的规则 ID")
+		return
+	}
+	if err := h.siteCache.DeleteRedirect(c.Request.Context(), siteID, id, domain); err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, nil)
+}
+
+// save 校验并保存规则，id 为 0 时创建
+func (h *SiteRedirectsHandler) save(c *gin.Context, id int64) {
+	siteID, domain, ok := h.site(c)
+	if !ok {
+		return
+	}
+	var req SiteRedirectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	req.Target = strings.TrimSpace(req.Target)
+	if req.Code == 0 {
+		req.Code = http.StatusMovedPermanently
+	}
+	if !core.ValidRedirectCode(req.Code) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "code 只能是 301 或 302")
+		return
+	}
+	if len(req.Pattern) > 500 || len(req.Target) > 1000 || req.Target == "" {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if _, err := core.CompileRedirectPattern(req.Pattern); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的正则: "+err.Error())
+		return
+	}
+	lower := strings.ToLower(req.Target)
+	if !strings.HasPrefix(req.Target, "/") && !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		core.FailWithMessage(c, core.ErrInvalidParam, "target 需为 / 开头的路径或 http(s) 地址")
+		return
+	}
+
+	redirect := &core.SiteRedirect{
+		ID:       id,
+		SiteID:   siteID,
+		Pattern:  req.Pattern,
+		Target:   req.Target,
+		Code:     req.Code,
+		Priority: req.Priority,
+		Enabled:  req.Enabled == nil || *req.Enabled,
+	}
+	ctx := c.Request.Context()
+	id, err := h.siteCache.SaveRedirect(ctx, redirect, domain)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	saved, err := h.siteCache.GetRedirect(ctx, siteID, id)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, saved)
+}
+
+// fail 按错误类型返回
+func (h *SiteRedirectsHandler) fail(c *gin.Context, err error) {
+	if errors.Is(err, core.ErrSiteRedirectNotFound) {
+		core.FailWithMessage(c, core.ErrNotFound, "重定向规则不存在")
+		return
+	}
+	log.Error().Err(err).Msg("Site redirect operation failed")
+	core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+}
+
+// site 解析站点 ID 并确认站点存在，返回站点域名
+func (h *SiteRedirectsHandler) site(c *gin.Context) (int, string, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的站点 ID")
+		return 0, "", false
+	}
+	var domain string
+	if err := h.db.Get(&domain, "SELECT domain FROM sites WHERE id = ?", id); err != nil {
+		core.FailWithMessage(c, core.ErrNotFound, "站点不存在")
+		return 0, "", false
+	}
+	return id, domain, true
+}
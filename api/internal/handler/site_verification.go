@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// SiteVerificationHandler 站点搜索引擎验证文件 handler
+type SiteVerificationHandler struct {
+	db    *sqlx.DB
+	files *core.VerificationFiles
+}
+
+// NewSiteVerificationHandler 创建 SiteVerificationHandler
+func NewSiteVerificationHandler(db *sqlx.DB, files *core.VerificationFiles) *SiteVerificationHandler {
+	return &SiteVerificationHandler{db: db, files: files}
+}
+
+// VerificationFileRequest 上传验证文件请求（同名文件覆盖）
+type VerificationFileRequest struct {
+	Filename string `json:"filename" binding:"required"` // 根目录文件名，如 baidu_verify_codeva-xxx.html
+	Content  string `json:"content"`
+}
+
+// List 站点的验证文件
+// GET /api/sites/:id/verification-files
+func (h *SiteVerificationHandler) List(c *gin.Context) {
+	siteID, ok := h.siteID(c)
+	if !ok {
+		return
+	}
+	files, err := h.files.List(c.Request.Context(), siteID)
+	if err != nil {
+		log.Warn().Err(err).Int("site_id", siteID).Msg("Failed to list verification files")
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+	core.Success(c, files)
+}
+
+// Save 上传验证文件（同名覆盖）
+// POST /api/sites/:id/verification-files
+func (h *SiteVerificationHandler) Save(c *gin.Context) {
+	siteID, ok := h.siteID(c)
+	if !ok {
+		return
+	}
+	var req VerificationFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	req.Filename = strings.TrimPrefix(strings.TrimSpace(req.Filename), "/")
+	if !core.ValidVerificationFilename(req.Filename) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "文件名只能包含字母、数字、点、下划线和横线，且位于根目录")
+		return
+	}
+	if len(req.Content) > core.MaxVerificationFileSize {
+		core.FailWithMessage(c, core.ErrInvalidParam, "文件内容超过 64KB")
+		return
+	}
+
+	file, err := h.files.Save(c.Request.Context(), siteID, req.Filename, req.Content)
+	if err != nil {
+		log.Error().Err(err).Int("site_id", siteID).Str("filename", req.Filename).Msg("Failed to save verification file")
+		core.FailWithCode(c, core.ErrDBInsert)
+		return
+	}
+	core.Success(c, file)
+}
+
+// Delete 删除验证文件
+// DELETE /api/sites/:id/verification-files/:file_id
+func (h *SiteVerificationHandler) Delete(c *gin.Context) {
+	siteID, ok := h.siteID(c)
+	if !ok {
+		return
+	}
+	fileID, err := strconv.ParseInt(c.Param("file_id"), 10, 64)
+	if err != nil || fileID <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的文件 ID")
+		return
+	}
+	if err := h.files.Delete(c.Request.Context(), siteID, fileID); err != nil {
+		if errors.Is(err, core.ErrVerificationFileNotFound) {
+			core.FailWithMessage(c, core.ErrNotFound, "验证文件不存在")
+			return
+		}
+		log.Error().Err(err).Int64("file_id", fileID).Msg("Failed to delete verification file")
+		core.FailWithCode(c, core.ErrDBDelete)
+		return
+	}
+	core.Success(c, nil)
+}
+
+// siteID 解析站点 ID 并确认站点存在
+func (h *SiteVerificationHandler) siteID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的站点 ID")
+		return 0, false
+	}
+	var count int
+	if err := h.db.Get(&count, "SELECT COUNT(*) FROM sites WHERE id = ?", id); err != nil || count == 0 {
+		core.FailWithMessage(c, core.ErrNotFound, "站点不存在")
+		return 0, false
+	}
+	return id, true
+}
@@ -4,6 +4,7 @@ package api
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -28,31 +29,40 @@ func NewSitesHandler(db *sqlx.DB, siteCache *core.SiteCache) *SitesHandler {
 
 // Site 站点
 type Site struct {
-	ID             int       `json:"id" db:"id"`
-	SiteGroupID    int       `json:"site_group_id" db:"site_group_id"`
-	Domain         string    `json:"domain" db:"domain"`
-	Name           string    `json:"name" db:"name"`
-	Template       string    `json:"template" db:"template"`
-	KeywordGroupID *int      `json:"keyword_group_id" db:"keyword_group_id"`
-	ImageGroupID   *int      `json:"image_group_id" db:"image_group_id"`
-	ArticleGroupID *int      `json:"article_group_id" db:"article_group_id"`
-	Status         int       `json:"status" db:"status"`
-	IcpNumber      *string   `json:"icp_number" db:"icp_number"`
-	BaiduToken     *string   `json:"baidu_token" db:"baidu_token"`
-	Analytics      *string   `json:"analytics" db:"analytics"`
+	ID             int     `json:"id" db:"id"`
+	SiteGroupID    int     `json:"site_group_id" db:"site_group_id"`
+	Domain         string  `json:"domain" db:"domain"`
+	Name           string  `json:"name" db:"name"`
+	Template       string  `json:"template" db:"template"`
+	KeywordGroupID *int    `json:"keyword_group_id" db:"keyword_group_id"`
+	ImageGroupID   *int    `json:"image_group_id" db:"image_group_id"`
+	ArticleGroupID *int    `json:"article_group_id" db:"article_group_id"`
+	Status         int     `json:"status" db:"status"`
+	IcpNumber      *string `json:"icp_number" db:"icp_number"`
+	BaiduToken     *string `json:"baidu_token" db:"baidu_token"`
+	Analytics      *string `json:"analytics" db:"analytics"`
+	// CacheMaxSizeMB / CacheMaxEntries 页面缓存配额，null 使用全局默认，0 不限制
+	CacheMaxSizeMB  *int `json:"cache_max_size_mb" db:"cache_max_size_mb"`
+	CacheMaxEntries *int `json:"cache_max_entries" db:"cache_max_entries"`
+	// HumanPolicy 非蜘蛛访问策略（redirect/page/content/block），null 按全局配置
+	HumanPolicy    *string   `json:"human_policy" db:"human_policy"`
+	HumanTargetURL *string   `json:"human_target_url" db:"human_target_url"`
+	Version        int       `json:"version" db:"version"`
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // SiteGroup 站群
 type SiteGroup struct {
-	ID          int       `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name"`
-	Description *string   `json:"description" db:"description"`
-	IsDefault   int       `json:"is_default" db:"is_default"`
-	Status      int       `json:"status" db:"status"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          int     `json:"id" db:"id"`
+	Name        string  `json:"name" db:"name"`
+	Description *string `json:"description" db:"description"`
+	IsDefault   int     `json:"is_default" db:"is_default"`
+	Status      int     `json:"status" db:"status"`
+	// FallbackTemplate 模板降级时使用的备用模板，为空时使用 download_site
+	FallbackTemplate *string   `json:"fallback_template" db:"fallback_template"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // SiteGroupWithStats 站群（含统计）
@@ -77,10 +87,18 @@ type SiteCreateRequest struct {
 	IcpNumber      *string `json:"icp_number"`
 	BaiduToken     *string `json:"baidu_token"`
 	Analytics      *string `json:"analytics"`
+	// CacheMaxSizeMB / CacheMaxEntries 页面缓存配额，不传使用全局默认，0 不限制
+	CacheMaxSizeMB  *int `json:"cache_max_size_mb"`
+	CacheMaxEntries *int `json:"cache_max_entries"`
+	// HumanPolicy 非蜘蛛访问策略，不传按全局配置；redirect 时 HumanTargetURL 必填
+	HumanPolicy    *string `json:"human_policy"`
+	HumanTargetURL *string `json:"human_target_url"`
 }
 
 // SiteUpdateRequest 更新站点请求
+// 携带 version（或 updated_at）时启用并发编辑检测，版本不一致返回 409
 type SiteUpdateRequest struct {
+	EditPrecondition
 	SiteGroupID    *int    `json:"site_group_id"`
 	Name           *string `json:"name"`
 	Template       *string `json:"template"`
@@ -91,6 +109,12 @@ type SiteUpdateRequest struct {
 	IcpNumber      *string `json:"icp_number"`
 	BaiduToken     *string `json:"baidu_token"`
 	Analytics      *string `json:"analytics"`
+	// CacheMaxSizeMB / CacheMaxEntries 页面缓存配额，0 不限制，负数恢复为全局默认
+	CacheMaxSizeMB  *int `json:"cache_max_size_mb"`
+	CacheMaxEntries *int `json:"cache_max_entries"`
+	// HumanPolicy 非蜘蛛访问策略，空字符串恢复为全局配置
+	HumanPolicy    *string `json:"human_policy"`
+	HumanTargetURL *string `json:"human_target_url"`
 }
 
 // SiteBatchIdsRequest 批量ID请求
@@ -116,6 +140,8 @@ type SiteGroupUpdateRequest struct {
 	Description *string `json:"description"`
 	Status      *int    `json:"status"`
 	IsDefault   *int    `json:"is_default"`
+	// FallbackTemplate 备用模板名，空字符串表示清除
+	FallbackTemplate *string `json:"fallback_template"`
 }
 
 // GroupOption 分组选项
@@ -151,69 +177,58 @@ type AllGroupOptionsResponse struct {
 // List 获取站点列表
 // GET /api/sites
 func (h *SitesHandler) List(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	siteGroupID := c.Query("site_group_id")
-	status := c.Query("status")
-	search := c.Query("search")
-
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	q, ok := bindListQuery(c, siteListSpec)
+	if !ok {
+		return
 	}
-	offset := (page - 1) * pageSize
 
 	if h.db == nil {
-		core.SuccessPaged(c, []Site{}, 0, page, pageSize)
+		respondList(c, q, []Site{}, 0, siteListKey)
 		return
 	}
 
 	// 构建查询条件
-	where := "1=1"
-	args := []interface{}{}
-
-	if siteGroupID != "" {
-		where += " AND site_group_id = ?"
-		args = append(args, siteGroupID)
-	}
-	if status != "" {
-		where += " AND status = ?"
-		args = append(args, status)
-	}
-	if search != "" {
-		where += " AND (domain LIKE ? OR name LIKE ?)"
-		args = append(args, "%"+search+"%", "%"+search+"%")
-	}
+	q.Eq("site_group_id", c.Query("site_group_id")).Eq("status", c.Query("status"))
 
 	// 获取总数
 	var total int64
-	countQuery := "SELECT COUNT(*) FROM sites WHERE " + where
-	if err := h.db.Get(&total, countQuery, args...); err != nil {
-		log.Warn().Err(err).Msg("Failed to count sites")
+	if q.NeedsTotal() {
+		countQuery, args := q.CountQuery("sites")
+		if err := core.GetQueryCache().Get(c.Request.Context(), h.db, &total, countQuery, args...); err != nil {
+			log.Warn().Err(err).Msg("Failed to count sites")
+		}
 	}
 
 	// 获取列表
-	query := `SELECT id, site_group_id, domain, name, template,
+	query, args := q.Query(`SELECT id, site_group_id, domain, name, template,
 	                 keyword_group_id, image_group_id, article_group_id,
 	                 status, icp_number, baidu_token, analytics,
-	                 created_at, updated_at
-	          FROM sites
-	          WHERE ` + where + `
-	          ORDER BY id DESC
-	          LIMIT ? OFFSET ?`
-	args = append(args, pageSize, offset)
+	                 cache_max_size_mb, cache_max_entries, human_policy, human_target_url,
+	                 version, created_at, updated_at
+	          FROM sites`)
 
 	var items []Site
-	if err := h.db.Select(&items, query, args...); err != nil {
+	if err := core.GetQueryCache().Select(c.Request.Context(), h.db, &items, query, args...); err != nil {
 		log.Warn().Err(err).Msg("Failed to query sites")
 		items = []Site{}
 	}
 
-	core.SuccessPaged(c, items, total, page, pageSize)
+	respondList(c, q, items, total, siteListKey)
+}
+
+// siteListSpec 站点列表的排序和搜索字段
+var siteListSpec = ListSpec{
+	SortFields: map[string]string{
+		"id": "id", "domain": "domain", "name": "name", "status": "status",
+		"created_at": "created_at", "updated_at": "updated_at",
+	},
+	DefaultSort:   "-id",
+	SearchColumns: []string{"domain", "name"},
+	KeysetColumn:  "id",
 }
 
+func siteListKey(s Site) int64 { return int64(s.ID) }
+
 // Create 创建站点
 // POST /api/sites
 func (h *SitesHandler) Create(c *gin.Context) {
@@ -232,15 +247,22 @@ func (h *SitesHandler) Create(c *gin.Context) {
 	if req.SiteGroupID == 0 {
 		req.SiteGroupID = 1
 	}
+	if msg := validateHumanPolicy(req.HumanPolicy, req.HumanTargetURL); msg != "" {
+		core.FailWithMessage(c, core.ErrInvalidParam, msg)
+		return
+	}
 
 	result, err := h.db.Exec(
 		`INSERT INTO sites (site_group_id, domain, name, template,
 		                    keyword_group_id, image_group_id, article_group_id,
-		                    icp_number, baidu_token, analytics, status)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)`,
+		                    icp_number, baidu_token, analytics,
+		                    cache_max_size_mb, cache_max_entries, human_policy, human_target_url, status)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)`,
 		req.SiteGroupID, req.Domain, req.Name, req.Template,
 		req.KeywordGroupID, req.ImageGroupID, req.ArticleGroupID,
-		req.IcpNumber, req.BaiduToken, req.Analytics)
+		req.IcpNumber, req.BaiduToken, req.Analytics,
+		cacheQuotaValue(req.CacheMaxSizeMB), cacheQuotaValue(req.CacheMaxEntries),
+		optionalString(req.HumanPolicy), optionalString(req.HumanTargetURL))
 
 	if err != nil {
 		if strings.Contains(err.Error(), "Duplicate") {
@@ -261,6 +283,9 @@ func (h *SitesHandler) Create(c *gin.Context) {
 		}
 	}
 
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("sites")
+
 	core.Success(c, gin.H{"success": true, "id": id})
 }
 
@@ -283,7 +308,8 @@ func (h *SitesHandler) Get(c *gin.Context) {
 		`SELECT id, site_group_id, domain, name, template,
 		        keyword_group_id, image_group_id, article_group_id,
 		        status, icp_number, baidu_token, analytics,
-		        created_at, updated_at
+		        cache_max_size_mb, cache_max_entries, human_policy, human_target_url,
+		        version, created_at, updated_at
 		 FROM sites WHERE id = ?`, id)
 
 	if err != nil {
@@ -326,6 +352,19 @@ func (h *SitesHandler) Update(c *gin.Context) {
 		return
 	}
 
+	// 切换为跳转策略但未传跳转地址时，使用已保存的地址校验
+	if req.HumanPolicy != nil && *req.HumanPolicy == core.HumanPolicyRedirect && req.HumanTargetURL == nil {
+		var target sql.NullString
+		h.db.Get(&target, "SELECT human_target_url FROM sites WHERE id = ?", id)
+		if msg := validateHumanPolicy(req.HumanPolicy, &target.String); msg != "" {
+			core.FailWithMessage(c, core.ErrInvalidParam, msg)
+			return
+		}
+	} else if msg := validateHumanPolicy(req.HumanPolicy, req.HumanTargetURL); msg != "" {
+		core.FailWithMessage(c, core.ErrInvalidParam, msg)
+		return
+	}
+
 	// 构建更新语句
 	updates := []string{}
 	args := []interface{}{}
@@ -370,6 +409,22 @@ func (h *SitesHandler) Update(c *gin.Context) {
 		updates = append(updates, "analytics = ?")
 		args = append(args, *req.Analytics)
 	}
+	if req.CacheMaxSizeMB != nil {
+		updates = append(updates, "cache_max_size_mb = ?")
+		args = append(args, cacheQuotaValue(req.CacheMaxSizeMB))
+	}
+	if req.CacheMaxEntries != nil {
+		updates = append(updates, "cache_max_entries = ?")
+		args = append(args, cacheQuotaValue(req.CacheMaxEntries))
+	}
+	if req.HumanPolicy != nil {
+		updates = append(updates, "human_policy = ?")
+		args = append(args, optionalString(req.HumanPolicy))
+	}
+	if req.HumanTargetURL != nil {
+		updates = append(updates, "human_target_url = ?")
+		args = append(args, optionalString(req.HumanTargetURL))
+	}
 
 	if len(updates) == 0 {
 		core.Success(c, gin.H{"success": true, "message": "没有需要更新的字段"})
@@ -377,13 +432,20 @@ func (h *SitesHandler) Update(c *gin.Context) {
 	}
 
 	args = append(args, id)
-	query := "UPDATE sites SET " + strings.Join(updates, ", ") + ", updated_at = NOW() WHERE id = ?"
+	lockWhere, lockArgs := req.whereClause()
+	query := "UPDATE sites SET " + strings.Join(updates, ", ") + ", version = version + 1, updated_at = NOW() WHERE id = ?" + lockWhere
+	args = append(args, lockArgs...)
 
-	if _, err := h.db.Exec(query, args...); err != nil {
+	result, err := h.db.Exec(query, args...)
+	if err != nil {
 		log.Error().Err(err).Int("id", id).Msg("Failed to update site")
 		core.Success(c, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	if affected, _ := result.RowsAffected(); affected == 0 && req.active() {
+		h.respondConflict(c, id, &req)
+		return
+	}
 
 	// 同步站点缓存
 	if h.siteCache != nil {
@@ -395,7 +457,89 @@ func (h *SitesHandler) Update(c *gin.Context) {
 		}
 	}
 
-	core.Success(c, gin.H{"success": true})
+	var version int
+	h.db.Get(&version, "SELECT version FROM sites WHERE id = ?", id)
+
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("sites")
+
+	core.Success(c, gin.H{"success": true, "version": version})
+}
+
+// cacheQuotaValue 缓存配额入库值，负数表示使用全局默认（NULL）
+func cacheQuotaValue(v *int) interface{} {
+	if v == nil || *v < 0 {
+		return nil
+	}
+	return *v
+}
+
+// optionalString 可空字符串入库值，nil 或空字符串写入 NULL
+func optionalString(v *string) interface{} {
+	if v == nil || strings.TrimSpace(*v) == "" {
+		return nil
+	}
+	return strings.TrimSpace(*v)
+}
+
+// validateHumanPolicy 校验非蜘蛛访问策略，返回错误消息
+func validateHumanPolicy(policy, targetURL *string) string {
+	if policy == nil || *policy == "" {
+		return ""
+	}
+	if !core.ValidHumanPolicy(*policy) {
+		return "无效的非蜘蛛访问策略: " + *policy
+	}
+	if *policy != core.HumanPolicyRedirect {
+		return ""
+	}
+	if targetURL == nil || strings.TrimSpace(*targetURL) == "" {
+		return "跳转策略需要填写跳转地址"
+	}
+	if u, err := url.Parse(strings.TrimSpace(*targetURL)); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "跳转地址必须是 http(s) 完整地址"
+	}
+	return ""
+}
+
+// respondConflict 版本不一致时返回当前站点及字段差异
+func (h *SitesHandler) respondConflict(c *gin.Context, id int, req *SiteUpdateRequest) {
+	var current Site
+	err := h.db.Get(&current,
+		`SELECT id, site_group_id, domain, name, template,
+		        keyword_group_id, image_group_id, article_group_id,
+		        status, icp_number, baidu_token, analytics,
+		        cache_max_size_mb, cache_max_entries, human_policy, human_target_url,
+		        version, created_at, updated_at
+		 FROM sites WHERE id = ?`, id)
+	if err != nil {
+		core.Success(c, gin.H{"success": false, "message": "站点不存在"})
+		return
+	}
+
+	var conflicts []FieldConflict
+	addFieldConflict(&conflicts, "site_group_id", req.SiteGroupID, current.SiteGroupID)
+	addFieldConflict(&conflicts, "name", req.Name, current.Name)
+	addFieldConflict(&conflicts, "template", req.Template, current.Template)
+	addFieldConflict(&conflicts, "keyword_group_id", req.KeywordGroupID, current.KeywordGroupID)
+	addFieldConflict(&conflicts, "image_group_id", req.ImageGroupID, current.ImageGroupID)
+	addFieldConflict(&conflicts, "article_group_id", req.ArticleGroupID, current.ArticleGroupID)
+	addFieldConflict(&conflicts, "status", req.Status, current.Status)
+	addFieldConflict(&conflicts, "icp_number", req.IcpNumber, current.IcpNumber)
+	addFieldConflict(&conflicts, "baidu_token", req.BaiduToken, current.BaiduToken)
+	addFieldConflict(&conflicts, "analytics", req.Analytics, current.Analytics)
+	addFieldConflict(&conflicts, "cache_max_size_mb", req.CacheMaxSizeMB, current.CacheMaxSizeMB)
+	addFieldConflict(&conflicts, "cache_max_entries", req.CacheMaxEntries, current.CacheMaxEntries)
+	addFieldConflict(&conflicts, "human_policy", req.HumanPolicy, current.HumanPolicy)
+	addFieldConflict(&conflicts, "human_target_url", req.HumanTargetURL, current.HumanTargetURL)
+
+	failConflict(c, ConflictInfo{
+		ExpectedVersion: req.Version,
+		CurrentVersion:  current.Version,
+		UpdatedAt:       current.UpdatedAt,
+		Current:         current,
+		Conflicts:       conflicts,
+	})
 }
 
 // Delete 删除站点
@@ -429,6 +573,9 @@ func (h *SitesHandler) Delete(c *gin.Context) {
 		h.siteCache.Invalidate(domain)
 	}
 
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("sites")
+
 	core.Success(c, gin.H{"success": true})
 }
 
@@ -482,6 +629,9 @@ func (h *SitesHandler) BatchDelete(c *gin.Context) {
 		}
 	}
 
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("sites")
+
 	core.Success(c, gin.H{"success": true, "deleted": len(req.IDs)})
 }
 
@@ -512,7 +662,7 @@ func (h *SitesHandler) BatchUpdateStatus(c *gin.Context) {
 		args = append(args, id)
 	}
 
-	query := fmt.Sprintf("UPDATE sites SET status = ?, updated_at = NOW() WHERE id IN (%s)", placeholders)
+	query := fmt.Sprintf("UPDATE sites SET status = ?, version = version + 1, updated_at = NOW() WHERE id IN (%s)", placeholders)
 	if _, err := h.db.Exec(query, args...); err != nil {
 		core.Success(c, gin.H{"success": false, "message": err.Error(), "updated": 0})
 		return
@@ -537,6 +687,9 @@ func (h *SitesHandler) BatchUpdateStatus(c *gin.Context) {
 		}
 	}
 
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("sites")
+
 	core.Success(c, gin.H{"success": true, "updated": len(req.IDs)})
 }
 
@@ -551,7 +704,7 @@ func (h *SitesHandler) ListGroups(c *gin.Context) {
 	}
 
 	query := `SELECT
-	            sg.id, sg.name, sg.description, sg.is_default, sg.status, sg.created_at, sg.updated_at,
+	            sg.id, sg.name, sg.description, sg.is_default, sg.status, sg.fallback_template, sg.created_at, sg.updated_at,
 	            COALESCE((SELECT COUNT(*) FROM sites WHERE site_group_id = sg.id AND status = 1), 0) as sites_count,
 	            COALESCE((SELECT COUNT(*) FROM keyword_groups WHERE site_group_id = sg.id AND status = 1), 0) as keyword_groups_count,
 	            COALESCE((SELECT COUNT(*) FROM image_groups WHERE site_group_id = sg.id AND status = 1), 0) as image_groups_count,
@@ -562,7 +715,7 @@ func (h *SitesHandler) ListGroups(c *gin.Context) {
 	          ORDER BY sg.is_default DESC, sg.id`
 
 	var groups []SiteGroupWithStats
-	if err := h.db.Select(&groups, query); err != nil {
+	if err := core.GetQueryCache().Select(c.Request.Context(), h.db, &groups, query); err != nil {
 		log.Warn().Err(err).Msg("Failed to list site groups")
 		groups = []SiteGroupWithStats{}
 	}
@@ -585,7 +738,7 @@ func (h *SitesHandler) GetGroup(c *gin.Context) {
 	}
 
 	query := `SELECT
-	            sg.id, sg.name, sg.description, sg.is_default, sg.status, sg.created_at, sg.updated_at,
+	            sg.id, sg.name, sg.description, sg.is_default, sg.status, sg.fallback_template, sg.created_at, sg.updated_at,
 	            COALESCE((SELECT COUNT(*) FROM sites WHERE site_group_id = sg.id AND status = 1), 0) as sites_count,
 	            COALESCE((SELECT COUNT(*) FROM keyword_groups WHERE site_group_id = sg.id AND status = 1), 0) as keyword_groups_count,
 	            COALESCE((SELECT COUNT(*) FROM image_groups WHERE site_group_id = sg.id AND status = 1), 0) as image_groups_count,
@@ -627,6 +780,8 @@ func (h *SitesHandler) GetGroupOptions(c *gin.Context) {
 		return
 	}
 
+	// 分组选项变化少、读取频繁，走查询缓存
+	ctx, qc := c.Request.Context(), core.GetQueryCache()
 	response := GroupOptionsResponse{
 		KeywordGroups: []GroupOption{},
 		ImageGroups:   []GroupOption{},
@@ -635,25 +790,25 @@ func (h *SitesHandler) GetGroupOptions(c *gin.Context) {
 	}
 
 	// 获取关键词分组
-	h.db.Select(&response.KeywordGroups,
+	qc.Select(ctx, h.db, &response.KeywordGroups,
 		`SELECT id, name, is_default FROM keyword_groups
 		 WHERE site_group_id = ? AND status = 1
 		 ORDER BY is_default DESC, name`, id)
 
 	// 获取图片分组
-	h.db.Select(&response.ImageGroups,
+	qc.Select(ctx, h.db, &response.ImageGroups,
 		`SELECT id, name, is_default FROM image_groups
 		 WHERE site_group_id = ? AND status = 1
 		 ORDER BY is_default DESC, name`, id)
 
 	// 获取文章分组
-	h.db.Select(&response.ArticleGroups,
+	qc.Select(ctx, h.db, &response.ArticleGroups,
 		`SELECT id, name, is_default FROM article_groups
 		 WHERE site_group_id = ? AND status = 1
 		 ORDER BY is_default DESC, name`, id)
 
 	// 获取模板
-	h.db.Select(&response.Templates,
+	qc.Select(ctx, h.db, &response.Templates,
 		`SELECT id, name, display_name FROM templates
 		 WHERE site_group_id = ? AND status = 1
 		 ORDER BY name`, id)
@@ -691,6 +846,10 @@ func (h *SitesHandler) CreateGroup(c *gin.Context) {
 	}
 
 	id, _ := result.LastInsertId()
+
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("site_groups")
+
 	core.Success(c, gin.H{"success": true, "id": id})
 }
 
@@ -745,6 +904,14 @@ func (h *SitesHandler) UpdateGroup(c *gin.Context) {
 		updates = append(updates, "is_default = ?")
 		args = append(args, *req.IsDefault)
 	}
+	if req.FallbackTemplate != nil {
+		updates = append(updates, "fallback_template = ?")
+		if *req.FallbackTemplate == "" {
+			args = append(args, nil)
+		} else {
+			args = append(args, *req.FallbackTemplate)
+		}
+	}
 
 	if len(updates) == 0 {
 		core.Success(c, gin.H{"success": true, "message": "没有需要更新的字段"})
@@ -764,6 +931,9 @@ func (h *SitesHandler) UpdateGroup(c *gin.Context) {
 		return
 	}
 
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("site_groups")
+
 	core.Success(c, gin.H{"success": true})
 }
 
@@ -806,6 +976,9 @@ func (h *SitesHandler) DeleteGroup(c *gin.Context) {
 		return
 	}
 
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("site_groups")
+
 	core.Success(c, gin.H{"success": true})
 }
 
@@ -824,6 +997,8 @@ func (h *SitesHandler) GetAllGroupOptions(c *gin.Context) {
 		return
 	}
 
+	// 分组选项变化少、读取频繁，走查询缓存
+	ctx, qc := c.Request.Context(), core.GetQueryCache()
 	response := AllGroupOptionsResponse{
 		KeywordGroups: []GroupOption{},
 		ImageGroups:   []GroupOption{},
@@ -831,23 +1006,23 @@ func (h *SitesHandler) GetAllGroupOptions(c *gin.Context) {
 
 	if siteGroupID != "" {
 		// 获取指定站群的分组
-		h.db.Select(&response.KeywordGroups,
+		qc.Select(ctx, h.db, &response.KeywordGroups,
 			`SELECT id, name, is_default FROM keyword_groups
 			 WHERE site_group_id = ? AND status = 1
 			 ORDER BY is_default DESC, name`, siteGroupID)
 
-		h.db.Select(&response.ImageGroups,
+		qc.Select(ctx, h.db, &response.ImageGroups,
 			`SELECT id, name, is_default FROM image_groups
 			 WHERE site_group_id = ? AND status = 1
 			 ORDER BY is_default DESC, name`, siteGroupID)
 	} else {
 		// 获取所有分组
-		h.db.Select(&response.KeywordGroups,
+		qc.Select(ctx, h.db, &response.KeywordGroups,
 			`SELECT id, name, is_default FROM keyword_groups
 			 WHERE status = 1
 			 ORDER BY is_default DESC, name`)
 
-		h.db.Select(&response.ImageGroups,
+		qc.Select(ctx, h.db, &response.ImageGroups,
 			`SELECT id, name, is_default FROM image_groups
 			 WHERE status = 1
 			 ORDER BY is_default DESC, name`)
@@ -2,16 +2,22 @@ package api
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
 
 	models "seo-generator/api/internal/model"
 	core "seo-generator/api/internal/service"
 )
 
 // SpiderDetectorHandler 蜘蛛检测处理器
-type SpiderDetectorHandler struct{}
+// clickhouse 非空时统计类接口优先查询 ClickHouse，失败回退 MySQL
+type SpiderDetectorHandler struct {
+	clickhouse    *core.ClickHouseSink
+	templateUsage *core.TemplateUsage // 按模板统计抓取，为 nil 时接口返回空列表
+}
 
 // GetSpiderConfig 获取蜘蛛检测配置
 // GET /api/spiders/config
@@ -75,63 +81,72 @@ func (h *SpiderDetectorHandler) GetSpiderLogs(c *gin.Context) {
 	}
 	sqlxDB := db.(*sqlx.DB)
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	spiderType := c.Query("spider_type")
-	domain := c.Query("domain")
-
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
-	}
-
-	where := "1=1"
-	args := []interface{}{}
-
-	if spiderType != "" {
-		where += " AND spider_type = ?"
-		args = append(args, spiderType)
-	}
-	if domain != "" {
-		where += " AND domain = ?"
-		args = append(args, domain)
+	q, ok := bindListQuery(c, spiderLogListSpec)
+	if !ok {
+		return
 	}
+	q.Eq("spider_type", c.Query("spider_type")).Eq("domain", c.Query("domain"))
 
 	var total int
-	countQuery := "SELECT COUNT(*) FROM spider_logs WHERE " + where
-	sqlxDB.Get(&total, countQuery, args...)
-
-	offset := (page - 1) * pageSize
-	args = append(args, pageSize, offset)
+	if q.NeedsTotal() {
+		countQuery, args := q.CountQuery("spider_logs")
+		sqlxDB.Get(&total, countQuery, args...)
+	}
 
 	var logs []models.SpiderLog
-	query := `
+	query, args := q.Query(`
 		SELECT id, spider_type, ip, ua, domain, path, dns_ok, resp_time, cache_hit, status, created_at
-		FROM spider_logs
-		WHERE ` + where + `
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?
-	`
+		FROM spider_logs`)
 	sqlxDB.Select(&logs, query, args...)
 
 	if logs == nil {
 		logs = []models.SpiderLog{}
 	}
+	logs, nextCursor, hasMore := trimListPage(q, logs, func(l models.SpiderLog) int64 { return l.ID })
 
 	// 前端期望 items 而不是 data
-	core.Success(c, gin.H{
+	resp := gin.H{
 		"items":     logs,
-		"total":     total,
-		"page":      page,
-		"page_size": pageSize,
-	})
+		"page":      q.Page,
+		"page_size": q.PageSize,
+	}
+	if q.CursorMode() {
+		resp["next_cursor"] = nextCursor
+		resp["has_more"] = hasMore
+	} else {
+		resp["total"] = total
+	}
+	core.Success(c, resp)
+}
+
+// spiderLogListSpec 蜘蛛日志的排序字段（id 自增，按 id 倒序即时间倒序，大表用 cursor 分页）
+var spiderLogListSpec = ListSpec{
+	SortFields:   map[string]string{"id": "id", "created_at": "created_at", "resp_time": "resp_time"},
+	DefaultSort:  "-id",
+	KeysetColumn: "id",
 }
 
 // GetSpiderStats 获取蜘蛛统计概览
 // GET /api/spiders/stats
 func (h *SpiderDetectorHandler) GetSpiderStats(c *gin.Context) {
+	if h.clickhouse != nil {
+		rows, err := h.clickhouse.SpiderCountsByType(c.Request.Context())
+		if err == nil {
+			total := 0
+			byType := make(map[string]int, len(rows))
+			for _, r := range rows {
+				byType[r.SpiderType] = r.Count
+				total += r.Count
+			}
+			core.Success(c, gin.H{
+				"total":   total,
+				"by_type": byType,
+			})
+			return
+		}
+		log.Warn().Err(err).Msg("ClickHouse spider stats query failed, falling back to MySQL")
+	}
+
 	db, exists := c.Get("db")
 	if !exists {
 		core.Success(c, gin.H{
@@ -184,6 +199,18 @@ func (h *SpiderDetectorHandler) GetSpiderDailyStats(c *gin.Context) {
 
 	spiderType := c.Query("spider_type")
 
+	if h.clickhouse != nil {
+		rows, err := h.clickhouse.SpiderDaily(c.Request.Context(), days, spiderType)
+		if err == nil {
+			if rows == nil {
+				rows = []core.SpiderDailyPoint{}
+			}
+			core.Success(c, gin.H{"days": rows})
+			return
+		}
+		log.Warn().Err(err).Msg("ClickHouse daily stats query failed, falling back to MySQL")
+	}
+
 	where := "created_at >= DATE_SUB(NOW(), INTERVAL ? DAY)"
 	args := []interface{}{days}
 
@@ -234,6 +261,18 @@ func (h *SpiderDetectorHandler) GetSpiderHourlyStats(c *gin.Context) {
 
 	spiderType := c.Query("spider_type")
 
+	if h.clickhouse != nil {
+		rows, err := h.clickhouse.SpiderHourly(c.Request.Context(), hoursParam, spiderType)
+		if err == nil {
+			if rows == nil {
+				rows = []core.SpiderHourlyPoint{}
+			}
+			core.Success(c, gin.H{"hours": rows})
+			return
+		}
+		log.Warn().Err(err).Msg("ClickHouse hourly stats query failed, falling back to MySQL")
+	}
+
 	where := "created_at >= DATE_SUB(NOW(), INTERVAL ? HOUR)"
 	args := []interface{}{hoursParam}
 
@@ -299,6 +338,13 @@ func (h *SpiderDetectorHandler) ClearSpiderLogs(c *gin.Context) {
 		return
 	}
 
+	// 同步清理 ClickHouse 中的明细，避免统计与日志不一致
+	if h.clickhouse != nil {
+		if err := h.clickhouse.Clear(c.Request.Context(), spiderType, beforeDays); err != nil {
+			log.Warn().Err(err).Msg("Failed to clear ClickHouse spider logs")
+		}
+	}
+
 	affected, _ := result.RowsAffected()
 	core.Success(c, gin.H{
 		"message": "日志已清空",
@@ -330,6 +376,16 @@ func (h *SpiderDetectorHandler) GetSpiderTrend(c *gin.Context) {
 		period = "hour"
 	}
 
+	// ClickHouse 直接从明细表按周期聚合，无需预聚合表
+	if h.clickhouse != nil {
+		data, err := h.clickhouse.SpiderTrend(c.Request.Context(), period, spiderType, limit)
+		if err == nil {
+			core.Success(c, models.SpiderLogsTrendResponse{Period: period, Items: data})
+			return
+		}
+		log.Warn().Err(err).Msg("ClickHouse trend query failed, falling back to MySQL")
+	}
+
 	// 周期回退顺序
 	periodFallback := map[string]string{
 		"month": "day",
@@ -385,3 +441,25 @@ func (h *SpiderDetectorHandler) GetSpiderTrend(c *gin.Context) {
 		period = fallback
 	}
 }
+
+// GetTemplateCrawlStats 按模板对比蜘蛛抓取：抓取次数、抓取到的 URL 数和同一 URL 的平均回访间隔
+// GET /api/spiders/templates?site_group_id=&days=7&spider_type=
+func (h *SpiderDetectorHandler) GetTemplateCrawlStats(c *gin.Context) {
+	if h.templateUsage == nil {
+		core.Success(c, gin.H{"items": []core.TemplateCrawlStat{}})
+		return
+	}
+	siteGroupID, _ := strconv.Atoi(c.Query("site_group_id"))
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if days < 1 || days > 90 {
+		days = 7
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	items, err := h.templateUsage.CrawlReport(c.Request.Context(), siteGroupID, since, c.Query("spider_type"))
+	if err != nil {
+		core.FailWithMessage(c, core.ErrDBQuery, err.Error())
+		return
+	}
+	core.Success(c, gin.H{"items": items, "days": days})
+}
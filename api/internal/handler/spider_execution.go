@@ -11,6 +11,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/redis/go-redis/v9"
 	"seo-generator/api/internal/model"
+	core "seo-generator/api/internal/service"
 )
 
 // SpiderExecutionHandler 爬虫执行处理器
@@ -56,12 +57,19 @@ func (h *SpiderExecutionHandler) Run(c *gin.Context) {
 		return
 	}
 
-	sqlxDB.Exec("UPDATE spider_projects SET status = 'running' WHERE id = ?", id)
+	limits, err := core.LoadSpiderLimits(sqlxDB, id)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "message": "读取资源限制失败"})
+		return
+	}
+
+	sqlxDB.Exec("UPDATE spider_projects SET status = 'running', run_started_at = NOW() WHERE id = ?", id)
 
 	cmd := models.SpiderCommand{
 		Action:    "run",
 		ProjectID: id,
 		Timestamp: time.Now().Unix(),
+		Limits:    limits,
 	}
 	if err := publishCommand(redisClient, cmd); err != nil {
 		c.JSON(500, gin.H{"success": false, "message": "发送命令失败"})
@@ -97,6 +105,9 @@ func (h *SpiderExecutionHandler) Test(c *gin.Context) {
 		return
 	}
 
+	// 清空上次测试保存的数据项预览
+	redisClient.Del(context.Background(), core.SpiderTestItemsKey(id))
+
 	cmd := models.SpiderCommand{
 		Action:    "test",
 		ProjectID: id,
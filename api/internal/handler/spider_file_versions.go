@@ -0,0 +1,256 @@
+package api
+
+import (
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"seo-generator/api/internal/model"
+	core "seo-generator/api/internal/service"
+)
+
+// 文件版本来源
+const (
+	spiderFileVersionCreate  = "create"
+	spiderFileVersionSave    = "save"
+	spiderFileVersionRestore = "restore"
+)
+
+const (
+	defaultSpiderFileVersionKeep = 50   // 新建项目默认每个文件保留的版本数
+	maxSpiderFileVersionKeep     = 1000 // 保留版本数上限
+)
+
+// recordSpiderFileVersion 记录一次文件保存
+// 内容与该文件最新版本相同时跳过；超过项目的保留数（file_versions_keep，0 不限制）时删除最旧的版本
+func recordSpiderFileVersion(db sqlx.Ext, projectID int, path, content, source string) error {
+	sum := sha1.Sum([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+
+	var latest string
+	err := sqlx.Get(db, &latest, `
+		SELECT content_hash FROM spider_file_versions
+		WHERE project_id = ? AND path = ? ORDER BY id DESC LIMIT 1
+	`, projectID, path)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if latest == hash {
+		return nil
+	}
+
+	if _, err := db.Exec(`
+		INSERT INTO spider_file_versions (project_id, path, content, size, content_hash, source)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, projectID, path, content, len(content), hash, source); err != nil {
+		return err
+	}
+
+	var keep int
+	if err := sqlx.Get(db, &keep, "SELECT file_versions_keep FROM spider_projects WHERE id = ?", projectID); err != nil || keep <= 0 {
+		return nil
+	}
+	// 删除比第 keep 新的版本更旧的记录（子查询包一层派生表以绕过 MySQL 对同表 LIMIT 子查询的限制）
+	_, err = db.Exec(`
+		DELETE FROM spider_file_versions
+		WHERE project_id = ? AND path = ? AND id < (
+			SELECT id FROM (
+				SELECT id FROM spider_file_versions
+				WHERE project_id = ? AND path = ? ORDER BY id DESC LIMIT 1 OFFSET ?
+			) t
+		)
+	`, projectID, path, projectID, path, keep-1)
+	return err
+}
+
+// ListFileVersions 获取文件历史版本（不含内容）
+// GET /api/spider-projects/:id/file-versions?path=/spider.py&page=1&page_size=20
+// 不传 path 时返回项目全部文件的版本（包括已删除文件的版本）
+func (h *SpiderFilesHandler) ListFileVersions(c *gin.Context) {
+	db, exists := c.Get("db")
+	if !exists {
+		c.JSON(500, gin.H{"success": false, "message": "数据库未连接"})
+		return
+	}
+	sqlxDB := db.(*sqlx.DB)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "message": "无效的ID"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	var keep int
+	if err := sqlxDB.Get(&keep, "SELECT file_versions_keep FROM spider_projects WHERE id = ?", id); err != nil {
+		c.JSON(404, gin.H{"success": false, "message": "项目不存在"})
+		return
+	}
+
+	where := "project_id = ?"
+	args := []interface{}{id}
+	if path := c.Query("path"); path != "" {
+		where += " AND path = ?"
+		args = append(args, path)
+	}
+
+	var total int
+	sqlxDB.Get(&total, "SELECT COUNT(*) FROM spider_file_versions WHERE "+where, args...)
+
+	versions := []models.SpiderFileVersion{}
+	sqlxDB.Select(&versions, `
+		SELECT id, project_id, path, size, content_hash, source, created_at
+		FROM spider_file_versions WHERE `+where+`
+		ORDER BY id DESC LIMIT ? OFFSET ?
+	`, append(args, pageSize, (page-1)*pageSize)...)
+
+	c.JSON(200, gin.H{"success": true, "data": versions, "total": total, "page": page, "page_size": pageSize, "keep": keep})
+}
+
+// GetFileVersion 获取单个版本（含内容）
+// GET /api/spider-projects/:id/file-versions/:vid
+func (h *SpiderFilesHandler) GetFileVersion(c *gin.Context) {
+	db, exists := c.Get("db")
+	if !exists {
+		c.JSON(500, gin.H{"success": false, "message": "数据库未连接"})
+		return
+	}
+	sqlxDB := db.(*sqlx.DB)
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	vid, _ := strconv.ParseInt(c.Param("vid"), 10, 64)
+	version, err := getSpiderFileVersion(sqlxDB, id, vid)
+	if err != nil {
+		c.JSON(404, gin.H{"success": false, "message": "版本不存在"})
+		return
+	}
+	c.JSON(200, gin.H{"success": true, "data": version})
+}
+
+// DiffFileVersions 两个版本之间的 unified diff
+// GET /api/spider-projects/:id/file-versions/diff?from=12&to=15&context=3
+// to 为空或 current 时与 from 所在路径的当前文件内容比较
+func (h *SpiderFilesHandler) DiffFileVersions(c *gin.Context) {
+	db, exists := c.Get("db")
+	if !exists {
+		c.JSON(500, gin.H{"success": false, "message": "数据库未连接"})
+		return
+	}
+	sqlxDB := db.(*sqlx.DB)
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	fromID, err := strconv.ParseInt(c.Query("from"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "message": "缺少 from 版本ID"})
+		return
+	}
+	contextLines, err := strconv.Atoi(c.DefaultQuery("context", "3"))
+	if err != nil || contextLines < 0 || contextLines > 100 {
+		contextLines = 3
+	}
+
+	from, err := getSpiderFileVersion(sqlxDB, id, fromID)
+	if err != nil {
+		c.JSON(404, gin.H{"success": false, "message": "版本不存在"})
+		return
+	}
+	fromLabel := fmt.Sprintf("a%s (#%d)", from.Path, from.ID)
+
+	var toContent, toLabel string
+	if to := c.Query("to"); to == "" || to == "current" {
+		var file models.SpiderProjectFile
+		if err := sqlxDB.Get(&file, `
+			SELECT id, project_id, path, type, content, created_at, updated_at
+			FROM spider_project_files WHERE project_id = ? AND path = ?
+		`, id, from.Path); err != nil {
+			c.JSON(404, gin.H{"success": false, "message": "当前文件不存在"})
+			return
+		}
+		toContent, toLabel = file.Content, "b"+file.Path+" (current)"
+	} else {
+		toID, err := strconv.ParseInt(to, 10, 64)
+		if err != nil {
+			c.JSON(400, gin.H{"success": false, "message": "无效的 to 版本ID"})
+			return
+		}
+		toVersion, err := getSpiderFileVersion(sqlxDB, id, toID)
+		if err != nil {
+			c.JSON(404, gin.H{"success": false, "message": "版本不存在"})
+			return
+		}
+		toContent, toLabel = toVersion.Content, fmt.Sprintf("b%s (#%d)", toVersion.Path, toVersion.ID)
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": core.UnifiedDiff(from.Content, toContent, fromLabel, toLabel, contextLines)})
+}
+
+// RestoreFileVersion 将文件恢复到指定版本（文件已删除时重新创建），恢复本身也记录为一个新版本
+// POST /api/spider-projects/:id/file-versions/:vid/restore
+func (h *SpiderFilesHandler) RestoreFileVersion(c *gin.Context) {
+	db, exists := c.Get("db")
+	if !exists {
+		c.JSON(500, gin.H{"success": false, "message": "数据库未连接"})
+		return
+	}
+	sqlxDB := db.(*sqlx.DB)
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	vid, _ := strconv.ParseInt(c.Param("vid"), 10, 64)
+
+	var status string
+	if err := sqlxDB.Get(&status, "SELECT status FROM spider_projects WHERE id = ?", id); err != nil {
+		c.JSON(404, gin.H{"success": false, "message": "项目不存在"})
+		return
+	}
+	if status == "running" {
+		c.JSON(400, gin.H{"success": false, "message": "项目正在运行中，无法修改文件"})
+		return
+	}
+
+	version, err := getSpiderFileVersion(sqlxDB, id, vid)
+	if err != nil {
+		c.JSON(404, gin.H{"success": false, "message": "版本不存在"})
+		return
+	}
+
+	if _, err := sqlxDB.Exec(`
+		INSERT INTO spider_project_files (project_id, path, type, content)
+		VALUES (?, ?, 'file', ?)
+		ON DUPLICATE KEY UPDATE content = VALUES(content)
+	`, id, version.Path, version.Content); err != nil {
+		c.JSON(500, gin.H{"success": false, "message": "恢复文件失败: " + err.Error()})
+		return
+	}
+	if err := recordSpiderFileVersion(sqlxDB, id, version.Path, version.Content, spiderFileVersionRestore); err != nil {
+		c.JSON(500, gin.H{"success": false, "message": "记录文件版本失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "恢复成功", "path": version.Path})
+}
+
+// getSpiderFileVersion 获取项目下的版本
+func getSpiderFileVersion(db *sqlx.DB, projectID int, versionID int64) (*models.SpiderFileVersion, error) {
+	var version models.SpiderFileVersion
+	err := db.Get(&version, `
+		SELECT id, project_id, path, content, size, content_hash, source, created_at
+		FROM spider_file_versions WHERE id = ? AND project_id = ?
+	`, versionID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
@@ -1,16 +1,25 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
 	"seo-generator/api/internal/model"
+	core "seo-generator/api/internal/service"
 )
 
 // SpiderFilesHandler 爬虫文件处理器
-type SpiderFilesHandler struct{}
+type SpiderFilesHandler struct {
+	trash     *core.FileTrash           // 删除的文件移入回收站
+	python    *core.PythonSyntaxChecker // .py 保存时的语法检查，nil 时不检查
+	allowlist *core.IPAllowlist         // 受信代理判断，审计记录的客户端 IP（可为 nil）
+}
 
 // ListFiles 获取项目文件列表
 func (h *SpiderFilesHandler) ListFiles(c *gin.Context) {
@@ -130,6 +139,42 @@ func (h *SpiderFilesHandler) insertNode(root *models.SpiderTreeNode, path string
 	}
 }
 
+// SearchFiles 在项目全部文件中搜索字面量或正则
+// GET /api/spider-projects/:id/search?q=parse&regex=false&case_sensitive=false
+func (h *SpiderFilesHandler) SearchFiles(c *gin.Context) {
+	db, exists := c.Get("db")
+	if !exists {
+		c.JSON(500, gin.H{"success": false, "message": "数据库未连接"})
+		return
+	}
+	sqlxDB := db.(*sqlx.DB)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "message": "无效的ID"})
+		return
+	}
+
+	var projectCount int
+	sqlxDB.Get(&projectCount, "SELECT COUNT(*) FROM spider_projects WHERE id = ?", id)
+	if projectCount == 0 {
+		c.JSON(404, gin.H{"success": false, "message": "项目不存在"})
+		return
+	}
+
+	result, err := core.SearchSpiderFiles(c.Request.Context(), sqlxDB, id, fileSearchQuery(c))
+	if errors.Is(err, core.ErrInvalidSearchQuery) {
+		c.JSON(400, gin.H{"success": false, "message": "搜索内容为空、过长或正则表达式无效"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": result})
+}
+
 // GetFile 获取单个文件内容
 func (h *SpiderFilesHandler) GetFile(c *gin.Context) {
 	db, exists := c.Get("db")
@@ -233,10 +278,16 @@ func (h *SpiderFilesHandler) CreateItem(c *gin.Context) {
 	}
 
 	fileID, _ := result.LastInsertId()
+	if req.Type == "file" {
+		if err := recordSpiderFileVersion(sqlxDB, id, fullPath, content, spiderFileVersionCreate); err != nil {
+			log.Warn().Err(err).Int("project_id", id).Str("path", fullPath).Msg("Failed to record spider file version")
+		}
+	}
 	c.JSON(200, gin.H{"success": true, "id": fileID, "path": fullPath, "message": "创建成功"})
 }
 
 // UpdateFile 更新文件内容
+// .py 文件按检查模式做语法检查：warn 保存并返回 syntax_errors，block 有语法错误时不保存
 func (h *SpiderFilesHandler) UpdateFile(c *gin.Context) {
 	db, exists := c.Get("db")
 	if !exists {
@@ -265,6 +316,18 @@ func (h *SpiderFilesHandler) UpdateFile(c *gin.Context) {
 		return
 	}
 
+	syntaxStatus, syntaxErrors := h.checkPython(c, path, req)
+	if syntaxStatus == core.PythonCheckStatusErrors && h.checkMode(req) == core.PythonCheckBlock {
+		first := syntaxErrors[0]
+		c.JSON(400, gin.H{
+			"success":       false,
+			"message":       fmt.Sprintf("Python 语法错误（第 %d 行）: %s", first.Line, first.Message),
+			"syntax_check":  syntaxStatus,
+			"syntax_errors": syntaxErrors,
+		})
+		return
+	}
+
 	// 使用 upsert
 	_, err = sqlxDB.Exec(`
 		INSERT INTO spider_project_files (project_id, path, type, content)
@@ -276,11 +339,43 @@ func (h *SpiderFilesHandler) UpdateFile(c *gin.Context) {
 		c.JSON(500, gin.H{"success": false, "message": "保存文件失败: " + err.Error()})
 		return
 	}
+	if err := recordSpiderFileVersion(sqlxDB, id, path, req.Content, spiderFileVersionSave); err != nil {
+		log.Warn().Err(err).Int("project_id", id).Str("path", path).Msg("Failed to record spider file version")
+	}
+
+	c.JSON(200, gin.H{
+		"success":       true,
+		"message":       "保存成功",
+		"syntax_check":  syntaxStatus,
+		"syntax_errors": syntaxErrors,
+	})
+}
+
+// checkMode 本次保存的检查模式（请求未指定时使用配置）
+func (h *SpiderFilesHandler) checkMode(req models.SpiderFileUpdate) string {
+	if req.Check != "" {
+		return req.Check
+	}
+	return h.python.Mode()
+}
 
-	c.JSON(200, gin.H{"success": true, "message": "保存成功"})
+// checkPython 检查 .py 文件语法，返回状态和错误列表（解释器不可用时不阻止保存）
+func (h *SpiderFilesHandler) checkPython(c *gin.Context, path string, req models.SpiderFileUpdate) (string, []core.PythonSyntaxError) {
+	if h.checkMode(req) == core.PythonCheckOff || !h.python.Applies(path) {
+		return core.PythonCheckStatusSkipped, []core.PythonSyntaxError{}
+	}
+	errs, err := h.python.Check(c.Request.Context(), path, req.Content)
+	if err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("Python syntax check failed to run")
+		return core.PythonCheckStatusUnavailable, []core.PythonSyntaxError{}
+	}
+	if len(errs) > 0 {
+		return core.PythonCheckStatusErrors, errs
+	}
+	return core.PythonCheckStatusOK, errs
 }
 
-// DeleteFile 删除文件或目录
+// DeleteFile 删除文件或目录（移入回收站，返回恢复令牌）
 func (h *SpiderFilesHandler) DeleteFile(c *gin.Context) {
 	db, exists := c.Get("db")
 	if !exists {
@@ -313,24 +408,94 @@ func (h *SpiderFilesHandler) DeleteFile(c *gin.Context) {
 		return
 	}
 
-	// 删除文件或目录（目录会递归删除子项）
-	result, err := sqlxDB.Exec(`
-		DELETE FROM spider_project_files
-		WHERE project_id = ? AND (path = ? OR path LIKE ?)
-	`, id, path, path+"/%")
-
+	// 删除文件或目录（目录会递归删除子项），文件行移入回收站
+	actor := fileActor(c, h.allowlist)
+	entry, err := h.trash.TrashSpiderPath(c.Request.Context(), actor, id, path)
+	if errors.Is(err, os.ErrNotExist) {
+		c.JSON(404, gin.H{"success": false, "message": "文件不存在"})
+		return
+	}
 	if err != nil {
 		c.JSON(500, gin.H{"success": false, "message": "删除失败"})
 		return
 	}
 
-	affected, _ := result.RowsAffected()
-	if affected == 0 {
-		c.JSON(404, gin.H{"success": false, "message": "文件不存在"})
+	c.JSON(200, gin.H{
+		"success":       true,
+		"message":       "删除成功",
+		"restore_token": entry.Token,
+		"expires_at":    entry.ExpiresAt,
+	})
+}
+
+// ListTrash 项目回收站条目
+// GET /api/spider-projects/:id/trash
+func (h *SpiderFilesHandler) ListTrash(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "message": "无效的ID"})
+		return
+	}
+	items, err := h.trash.List(c.Request.Context(), core.TrashSourceSpiderProject, id)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"success": true, "data": items})
+}
+
+// RestoreTrash 按恢复令牌恢复文件或目录
+// POST /api/spider-projects/:id/trash/:token/restore
+func (h *SpiderFilesHandler) RestoreTrash(c *gin.Context) {
+	db, exists := c.Get("db")
+	if !exists {
+		c.JSON(500, gin.H{"success": false, "message": "数据库未连接"})
+		return
+	}
+	sqlxDB := db.(*sqlx.DB)
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	var status string
+	if err := sqlxDB.Get(&status, "SELECT status FROM spider_projects WHERE id = ?", id); err != nil {
+		c.JSON(404, gin.H{"success": false, "message": "项目不存在"})
+		return
+	}
+	if status == "running" {
+		c.JSON(400, gin.H{"success": false, "message": "项目正在运行中，无法恢复文件"})
+		return
+	}
+
+	actor := fileActor(c, h.allowlist)
+	entry, err := h.trash.Restore(c.Request.Context(), actor, core.TrashSourceSpiderProject, id, c.Param("token"))
+	switch {
+	case errors.Is(err, core.ErrTrashNotFound):
+		c.JSON(404, gin.H{"success": false, "message": "回收站中没有该条目（已恢复或已清除）"})
+		return
+	case errors.Is(err, core.ErrTrashConflict):
+		c.JSON(400, gin.H{"success": false, "message": "原路径已存在，无法恢复"})
+		return
+	case err != nil:
+		c.JSON(500, gin.H{"success": false, "message": "恢复失败: " + err.Error()})
 		return
 	}
 
-	c.JSON(200, gin.H{"success": true, "message": "删除成功"})
+	c.JSON(200, gin.H{"success": true, "message": "恢复成功", "path": entry.Path})
+}
+
+// PurgeTrash 永久删除回收站条目
+// DELETE /api/spider-projects/:id/trash/:token
+func (h *SpiderFilesHandler) PurgeTrash(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	err := h.trash.Purge(c.Request.Context(), core.TrashSourceSpiderProject, id, c.Param("token"))
+	if errors.Is(err, core.ErrTrashNotFound) {
+		c.JSON(404, gin.H{"success": false, "message": "回收站中没有该条目（已恢复或已清除）"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "message": "清除失败: " + err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"success": true, "message": "已永久删除"})
 }
 
 // MoveItem 移动或重命名文件/目录
@@ -400,6 +565,18 @@ func (h *SpiderFilesHandler) MoveItem(c *gin.Context) {
 		return
 	}
 
+	// 历史版本随文件移动
+	_, err = tx.Exec(`
+		UPDATE spider_file_versions
+		SET path = CASE WHEN path = ? THEN ? ELSE CONCAT(?, SUBSTRING(path, ?)) END
+		WHERE project_id = ? AND (path = ? OR path LIKE ?)
+	`, oldPath, newPath, newPath, len(oldPath)+1, id, oldPath, oldPath+"/%")
+	if err != nil {
+		tx.Rollback()
+		c.JSON(500, gin.H{"success": false, "message": "移动文件版本失败"})
+		return
+	}
+
 	tx.Commit()
 	c.JSON(200, gin.H{"success": true, "message": "移动成功", "new_path": newPath})
 }
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"encoding/json"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// maxSpiderIngestItems 单次写入的最大数据项数
+const maxSpiderIngestItems = 1000
+
+// SpiderOutputHandler 爬虫输出预览与字段映射处理器
+type SpiderOutputHandler struct {
+	capture  *core.SpiderOutputCapture // 无 Redis 时为 nil
+	ingester *core.ArticleIngester
+}
+
+// NewSpiderOutputHandler 创建 SpiderOutputHandler
+func NewSpiderOutputHandler(capture *core.SpiderOutputCapture, ingester *core.ArticleIngester) *SpiderOutputHandler {
+	return &SpiderOutputHandler{capture: capture, ingester: ingester}
+}
+
+// FieldMappingRequest 保存/预览字段映射请求，mapping 为空时清除映射（按同名字段取值）
+type FieldMappingRequest struct {
+	Mapping core.FieldMapping `json:"mapping"`
+}
+
+// SpiderIngestRequest 写入数据项请求
+type SpiderIngestRequest struct {
+	GroupID int                      `json:"group_id"` // 为空时使用项目的 output_group_id
+	Items   []map[string]interface{} `json:"items" binding:"required"`
+}
+
+// previewItem 预览结果：原始数据项和映射结果
+type previewItem struct {
+	core.SpiderTestItem
+	Mapped *core.MappedItem `json:"mapped,omitempty"`
+	Valid  bool             `json:"valid"` // 映射后 title 和 content 均不为空
+}
+
+// TestItems 最近测试运行的数据项
+// GET /api/spider-projects/:id/test-items?mapped=true
+func (h *SpiderOutputHandler) TestItems(c *gin.Context) {
+	db, exists := c.Get("db")
+	if !exists {
+		c.JSON(500, gin.H{"success": false, "message": "数据库未连接"})
+		return
+	}
+	sqlxDB := db.(*sqlx.DB)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "message": "无效的ID"})
+		return
+	}
+
+	var mapper *core.FieldMapper
+	if c.Query("mapped") == "true" {
+		mapping, err := core.LoadFieldMapping(c.Request.Context(), sqlxDB, id)
+		if err != nil {
+			c.JSON(500, gin.H{"success": false, "message": "读取字段映射失败: " + err.Error()})
+			return
+		}
+		if mapper, err = mapping.Compile(); err != nil {
+			c.JSON(400, gin.H{"success": false, "message": "字段映射无效: " + err.Error()})
+			return
+		}
+	}
+	h.preview(c, id, mapper)
+}
+
+// PreviewFieldMapping 用未保存的映射预览最近测试数据项
+// POST /api/spider-projects/:id/field-mapping/preview
+func (h *SpiderOutputHandler) PreviewFieldMapping(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "message": "无效的ID"})
+		return
+	}
+	var req FieldMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+	mapper, err := req.Mapping.Compile()
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	h.preview(c, id, mapper)
+}
+
+// preview 返回测试数据项，mapper 不为空时附带映射结果
+func (h *SpiderOutputHandler) preview(c *gin.Context, projectID int, mapper *core.FieldMapper) {
+	if h.capture == nil {
+		c.JSON(500, gin.H{"success": false, "message": "Redis未连接"})
+		return
+	}
+	items, err := h.capture.Items(c.Request.Context(), projectID)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "message": "读取测试数据失败"})
+		return
+	}
+
+	result := make([]previewItem, len(items))
+	for i, item := range items {
+		result[i].SpiderTestItem = item
+		if mapper != nil {
+			mapped := mapper.Apply(item.Data)
+			result[i].Mapped = mapped
+			result[i].Valid = mapped.Title != "" && mapped.Content != ""
+		}
+	}
+	c.JSON(200, gin.H{"success": true, "data": result, "total": len(result)})
+}
+
+// GetFieldMapping 获取字段映射配置
+// GET /api/spider-projects/:id/field-mapping
+func (h *SpiderOutputHandler) GetFieldMapping(c *gin.Context) {
+	db, exists := c.Get("db")
+	if !exists {
+		c.JSON(500, gin.H{"success": false, "message": "数据库未连接"})
+		return
+	}
+	sqlxDB := db.(*sqlx.DB)
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	mapping, err := core.LoadFieldMapping(c.Request.Context(), sqlxDB, id)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "message": "读取字段映射失败: " + err.Error()})
+		return
+	}
+	if mapping == nil {
+		mapping = core.FieldMapping{}
+	}
+	c.JSON(200, gin.H{"success": true, "data": gin.H{
+		"mapping": mapping,
+		"targets": core.FieldMappingTargets,
+		"functions": []string{
+			"trim", "lower", "upper", "strip_tags", "html_unescape", "collapse_space", "first",
+			"truncate(n)", "default(\"x\")", "prefix(\"x\")", "suffix(\"x\")", "split(\",\")", "join(\",\")",
+			"replace(\"a\", \"b\")", "regex_replace(\"re\", \"repl\")",
+		},
+	}})
+}
+
+// UpdateFieldMapping 保存字段映射配置
+// PUT /api/spider-projects/:id/field-mapping
+func (h *SpiderOutputHandler) UpdateFieldMapping(c *gin.Context) {
+	db, exists := c.Get("db")
+	if !exists {
+		c.JSON(500, gin.H{"success": false, "message": "数据库未连接"})
+		return
+	}
+	sqlxDB := db.(*sqlx.DB)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "message": "无效的ID"})
+		return
+	}
+	var req FieldMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+	if _, err := req.Mapping.Compile(); err != nil {
+		c.JSON(400, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	var value *string
+	if len(req.Mapping) > 0 {
+		b, _ := json.Marshal(req.Mapping)
+		s := string(b)
+		value = &s
+	}
+	result, err := sqlxDB.Exec("UPDATE spider_projects SET field_mapping = ? WHERE id = ?", value, id)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "message": "保存失败"})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		var count int
+		if sqlxDB.Get(&count, "SELECT COUNT(*) FROM spider_projects WHERE id = ?", id); count == 0 {
+			c.JSON(404, gin.H{"success": false, "message": "项目不存在"})
+			return
+		}
+	}
+	c.JSON(200, gin.H{"success": true, "message": "保存成功"})
+}
+
+// Ingest 按字段映射写入数据项到原始文章（供 Worker 调用，支持 API Token）
+// POST /api/spider-projects/:id/items
+func (h *SpiderOutputHandler) Ingest(c *gin.Context) {
+	db, exists := c.Get("db")
+	if !exists {
+		c.JSON(500, gin.H{"success": false, "message": "数据库未连接"})
+		return
+	}
+	sqlxDB := db.(*sqlx.DB)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "message": "无效的ID"})
+		return
+	}
+	var req SpiderIngestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+	if len(req.Items) > maxSpiderIngestItems {
+		c.JSON(400, gin.H{"success": false, "message": "单次最多写入 " + strconv.Itoa(maxSpiderIngestItems) + " 条"})
+		return
+	}
+
+	var outputGroupID int
+	if err := sqlxDB.Get(&outputGroupID, "SELECT output_group_id FROM spider_projects WHERE id = ?", id); err != nil {
+		c.JSON(404, gin.H{"success": false, "message": "项目不存在"})
+		return
+	}
+	groupID := req.GroupID
+	if groupID <= 0 {
+		groupID = outputGroupID
+	}
+	mapping, err := core.LoadFieldMapping(c.Request.Context(), sqlxDB, id)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "message": "读取字段映射失败: " + err.Error()})
+		return
+	}
+	mapper, err := mapping.Compile()
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "message": "字段映射无效: " + err.Error()})
+		return
+	}
+
+	// 与 HTTP 批量添加走同一入库流程（清洗、违禁词过滤、摘要、标题去重）
+	added, skipped, invalid := 0, 0, 0
+	batch := h.ingester.NewBatch()
+	for _, raw := range req.Items {
+		item := mapper.Apply(raw)
+		if utf8.RuneCountInString(item.Title) > 500 {
+			item.Title = string([]rune(item.Title)[:500])
+		}
+		out := h.ingester.Ingest(c.Request.Context(), core.IngestArticle{
+			GroupID: groupID, SourceID: id, Title: item.Title, Content: item.Content,
+			SourceURL: item.SourceURL, Tags: item.JoinTags(),
+		}, core.ConflictSkip, core.ConflictKeyTitle, nil)
+		switch out.Outcome {
+		case core.OutcomeAdded:
+			added++
+			batch.Add(groupID, out)
+		case core.OutcomeError:
+			log.Error().Str("error", out.Message).Int("project_id", id).Msg("Failed to ingest spider item")
+			batch.Flush(c.Request.Context())
+			c.JSON(500, gin.H{"success": false, "message": "写入失败", "added": added})
+			return
+		case core.OutcomeInvalid:
+			invalid++
+		default:
+			skipped++
+		}
+	}
+	batch.Flush(c.Request.Context())
+
+	c.JSON(200, gin.H{"success": true, "added": added, "skipped": skipped, "invalid": invalid})
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -72,55 +73,35 @@ func (h *SpiderProjectsHandler) List(c *gin.Context) {
 	}
 	sqlxDB := db.(*sqlx.DB)
 
-	status := c.Query("status")
-	enabledStr := c.Query("enabled")
-	search := c.Query("search")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	q, err := BindListQuery(c, spiderProjectListSpec)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "message": err.Error()})
+		return
 	}
 
-	where := "1=1"
-	args := []interface{}{}
-
-	if status != "" {
-		where += " AND status = ?"
-		args = append(args, status)
-	}
-	if enabledStr != "" {
+	q.Eq("status", c.Query("status"))
+	if enabledStr := c.Query("enabled"); enabledStr != "" {
 		enabled, _ := strconv.Atoi(enabledStr)
-		where += " AND enabled = ?"
-		args = append(args, enabled)
-	}
-	if search != "" {
-		where += " AND (name LIKE ? OR description LIKE ?)"
-		args = append(args, "%"+search+"%", "%"+search+"%")
+		q.Where("enabled = ?", enabled)
 	}
 
 	var total int
-	countSQL := "SELECT COUNT(*) FROM spider_projects WHERE " + where
-	sqlxDB.Get(&total, countSQL, args...)
+	if q.NeedsTotal() {
+		countSQL, args := q.CountQuery("spider_projects")
+		sqlxDB.Get(&total, countSQL, args...)
+	}
 
-	offset := (page - 1) * pageSize
-	dataSQL := `
+	dataSQL, args := q.Query(`
 		SELECT id, name, description, entry_file, entry_function, start_url,
 		       config, concurrency, crawl_type, output_group_id, schedule, enabled, status,
 		       last_run_at, last_run_duration, last_run_items, last_error,
-		       total_runs, total_items, created_at, updated_at
-		FROM spider_projects
-		WHERE ` + where + `
-		ORDER BY id DESC
-		LIMIT ? OFFSET ?
-	`
-	args = append(args, pageSize, offset)
-
-	var projects []models.SpiderProject
+		       total_runs, total_items, file_versions_keep, run_started_at,
+		       max_runtime, max_memory_mb, max_requests, domain_delay_ms, robots_mode, created_at, updated_at
+		FROM spider_projects`)
+
+	projects := []models.SpiderProject{}
 	sqlxDB.Select(&projects, dataSQL, args...)
+	projects, nextCursor, hasMore := trimListPage(q, projects, func(p models.SpiderProject) int64 { return int64(p.ID) })
 
 	for i := range projects {
 		if projects[i].Config != nil {
@@ -128,13 +109,30 @@ func (h *SpiderProjectsHandler) List(c *gin.Context) {
 		}
 	}
 
-	c.JSON(200, gin.H{
+	resp := gin.H{
 		"success":   true,
 		"data":      projects,
-		"total":     total,
-		"page":      page,
-		"page_size": pageSize,
-	})
+		"page":      q.Page,
+		"page_size": q.PageSize,
+	}
+	if q.CursorMode() {
+		resp["next_cursor"] = nextCursor
+		resp["has_more"] = hasMore
+	} else {
+		resp["total"] = total
+	}
+	c.JSON(200, resp)
+}
+
+// spiderProjectListSpec 爬虫项目列表的排序和搜索字段
+var spiderProjectListSpec = ListSpec{
+	SortFields: map[string]string{
+		"id": "id", "name": "name", "status": "status", "last_run_at": "last_run_at",
+		"total_items": "total_items", "created_at": "created_at", "updated_at": "updated_at",
+	},
+	DefaultSort:   "-id",
+	SearchColumns: []string{"name", "description"},
+	KeysetColumn:  "id",
 }
 
 // Get 获取项目详情
@@ -157,7 +155,8 @@ func (h *SpiderProjectsHandler) Get(c *gin.Context) {
 		SELECT id, name, description, entry_file, entry_function, start_url,
 		       config, concurrency, crawl_type, output_group_id, schedule, enabled, status,
 		       last_run_at, last_run_duration, last_run_items, last_error,
-		       total_runs, total_items, created_at, updated_at
+		       total_runs, total_items, file_versions_keep, run_started_at,
+		       max_runtime, max_memory_mb, max_requests, domain_delay_ms, robots_mode, created_at, updated_at
 		FROM spider_projects WHERE id = ?
 	`, id)
 
@@ -203,6 +202,43 @@ func (h *SpiderProjectsHandler) Create(c *gin.Context) {
 	if req.OutputGroupID == 0 {
 		req.OutputGroupID = 1
 	}
+	fileVersionKeep := defaultSpiderFileVersionKeep
+	if req.FileVersionKeep != nil {
+		if *req.FileVersionKeep < 0 || *req.FileVersionKeep > maxSpiderFileVersionKeep {
+			c.JSON(400, gin.H{"success": false, "message": "file_versions_keep 范围为 0-1000"})
+			return
+		}
+		fileVersionKeep = *req.FileVersionKeep
+	}
+	var limits models.SpiderResourceLimits
+	for _, f := range []struct {
+		value *int
+		field *int
+		name  string
+		max   int
+	}{
+		{req.MaxRuntime, &limits.MaxRuntime, "max_runtime", maxSpiderRuntime},
+		{req.MaxMemoryMB, &limits.MaxMemoryMB, "max_memory_mb", maxSpiderMemoryMB},
+		{req.MaxRequests, &limits.MaxRequests, "max_requests", maxSpiderRequests},
+		{req.DomainDelayMs, &limits.DomainDelayMs, "domain_delay_ms", maxSpiderDomainDelayMs},
+	} {
+		if f.value == nil {
+			continue
+		}
+		if msg := validateSpiderLimit(f.name, *f.value, f.max); msg != "" {
+			c.JSON(400, gin.H{"success": false, "message": msg})
+			return
+		}
+		*f.field = *f.value
+	}
+	limits.RobotsMode = core.RobotsModeOff
+	if req.RobotsMode != nil {
+		if !core.ValidRobotsMode(*req.RobotsMode) {
+			c.JSON(400, gin.H{"success": false, "message": "robots_mode 只能为 off/warn/enforce"})
+			return
+		}
+		limits.RobotsMode = *req.RobotsMode
+	}
 
 	var configJSON *string
 	if req.Config != nil {
@@ -222,11 +258,13 @@ func (h *SpiderProjectsHandler) Create(c *gin.Context) {
 	result, err := tx.Exec(`
 		INSERT INTO spider_projects
 		(name, description, entry_file, entry_function, start_url, config,
-		 concurrency, crawl_type, output_group_id, schedule, enabled)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 concurrency, crawl_type, output_group_id, schedule, enabled, file_versions_keep,
+		 max_runtime, max_memory_mb, max_requests, domain_delay_ms, robots_mode)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, req.Name, req.Description, req.EntryFile, req.EntryFunction,
 		req.StartURL, configJSON, req.Concurrency, req.CrawlType, req.OutputGroupID,
-		req.Schedule, req.Enabled)
+		req.Schedule, req.Enabled, fileVersionKeep,
+		limits.MaxRuntime, limits.MaxMemoryMB, limits.MaxRequests, limits.DomainDelayMs, limits.RobotsMode)
 
 	if err != nil {
 		tx.Rollback()
@@ -268,6 +306,11 @@ func (h *SpiderProjectsHandler) Create(c *gin.Context) {
 			c.JSON(500, gin.H{"success": false, "message": "创建文件失败: " + err.Error()})
 			return
 		}
+		if err := recordSpiderFileVersion(tx, int(projectID), filePath, f.Content, spiderFileVersionCreate); err != nil {
+			tx.Rollback()
+			c.JSON(500, gin.H{"success": false, "message": "记录文件版本失败: " + err.Error()})
+			return
+		}
 	}
 
 	// 提交事务
@@ -368,6 +411,42 @@ func (h *SpiderProjectsHandler) Update(c *gin.Context) {
 		updates = append(updates, "enabled = ?")
 		args = append(args, *req.Enabled)
 	}
+	if req.FileVersionKeep != nil {
+		if *req.FileVersionKeep < 0 || *req.FileVersionKeep > maxSpiderFileVersionKeep {
+			c.JSON(400, gin.H{"success": false, "message": "file_versions_keep 范围为 0-1000"})
+			return
+		}
+		updates = append(updates, "file_versions_keep = ?")
+		args = append(args, *req.FileVersionKeep)
+	}
+	for _, f := range []struct {
+		value *int
+		name  string
+		max   int
+	}{
+		{req.MaxRuntime, "max_runtime", maxSpiderRuntime},
+		{req.MaxMemoryMB, "max_memory_mb", maxSpiderMemoryMB},
+		{req.MaxRequests, "max_requests", maxSpiderRequests},
+		{req.DomainDelayMs, "domain_delay_ms", maxSpiderDomainDelayMs},
+	} {
+		if f.value == nil {
+			continue
+		}
+		if msg := validateSpiderLimit(f.name, *f.value, f.max); msg != "" {
+			c.JSON(400, gin.H{"success": false, "message": msg})
+			return
+		}
+		updates = append(updates, f.name+" = ?")
+		args = append(args, *f.value)
+	}
+	if req.RobotsMode != nil {
+		if !core.ValidRobotsMode(*req.RobotsMode) {
+			c.JSON(400, gin.H{"success": false, "message": "robots_mode 只能为 off/warn/enforce"})
+			return
+		}
+		updates = append(updates, "robots_mode = ?")
+		args = append(args, *req.RobotsMode)
+	}
 
 	if len(updates) == 0 {
 		c.JSON(200, gin.H{"success": true, "message": "无需更新"})
@@ -438,6 +517,8 @@ func (h *SpiderProjectsHandler) Delete(c *gin.Context) {
 	}
 
 	sqlxDB.Exec("DELETE FROM spider_project_files WHERE project_id = ?", id)
+	sqlxDB.Exec("DELETE FROM spider_file_versions WHERE project_id = ?", id)
+	sqlxDB.Exec("DELETE FROM spider_robots_violations WHERE project_id = ?", id)
 	sqlxDB.Exec("DELETE FROM spider_projects WHERE id = ?", id)
 
 	c.JSON(200, gin.H{"success": true, "message": "删除成功"})
@@ -577,3 +658,19 @@ def main():
 
 	c.JSON(200, gin.H{"success": true, "data": templates})
 }
+
+// 资源限制上限
+const (
+	maxSpiderRuntime       = 7 * 24 * 3600 // 7 天
+	maxSpiderMemoryMB      = 64 * 1024
+	maxSpiderRequests      = 100000000
+	maxSpiderDomainDelayMs = 10 * 60 * 1000 // 10 分钟
+)
+
+// validateSpiderLimit 校验资源限制取值，返回错误信息（合法时为空）
+func validateSpiderLimit(name string, value, max int) string {
+	if value < 0 || value > max {
+		return fmt.Sprintf("%s 范围为 0-%d（0 表示不限制）", name, max)
+	}
+	return ""
+}
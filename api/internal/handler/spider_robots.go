@@ -0,0 +1,149 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// SpiderRobotsHandler 爬虫 robots.txt 合规检查处理器
+type SpiderRobotsHandler struct {
+	checker *core.RobotsChecker
+}
+
+// NewSpiderRobotsHandler 创建 SpiderRobotsHandler
+func NewSpiderRobotsHandler(checker *core.RobotsChecker) *SpiderRobotsHandler {
+	return &SpiderRobotsHandler{checker: checker}
+}
+
+// RobotsCheckRequest 批量检查请求
+type RobotsCheckRequest struct {
+	URLs      []string `json:"urls" binding:"required"`
+	UserAgent string   `json:"user_agent"` // 为空时使用配置的默认 User-agent
+}
+
+// Check 批量检查 URL 是否允许抓取（供 Worker 调用，支持 API Token）
+// POST /api/spider-projects/:id/robots/check
+// warn 模式下 allowed 总是 true，enforce 模式下 robots.txt 不允许的 URL 返回 allowed=false；两种模式都会记录违规
+func (h *SpiderRobotsHandler) Check(c *gin.Context) {
+	db, exists := c.Get("db")
+	if !exists {
+		c.JSON(500, gin.H{"success": false, "message": "数据库未连接"})
+		return
+	}
+	sqlxDB := db.(*sqlx.DB)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "message": "无效的ID"})
+		return
+	}
+
+	var req RobotsCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+	if len(req.URLs) > h.checker.MaxURLs() {
+		c.JSON(400, gin.H{"success": false, "message": "单次最多检查 " + strconv.Itoa(h.checker.MaxURLs()) + " 个 URL"})
+		return
+	}
+
+	var mode string
+	if err := sqlxDB.Get(&mode, "SELECT robots_mode FROM spider_projects WHERE id = ?", id); err != nil {
+		c.JSON(404, gin.H{"success": false, "message": "项目不存在"})
+		return
+	}
+
+	results := h.checker.Check(c.Request.Context(), mode, req.UserAgent, req.URLs)
+	recorded, err := h.checker.RecordViolations(c.Request.Context(), id, mode, results)
+	if err != nil {
+		log.Warn().Err(err).Int("project_id", id).Msg("Failed to record robots violations")
+	}
+
+	c.JSON(200, gin.H{"success": true, "mode": mode, "data": results, "violations": recorded})
+}
+
+// RunReport 项目最近一次运行报告（运行状态 + robots 违规汇总）
+// GET /api/spider-projects/:id/run-report?scope=run|all&limit=100
+// scope=run（默认）只统计本次运行开始后的违规
+func (h *SpiderRobotsHandler) RunReport(c *gin.Context) {
+	db, exists := c.Get("db")
+	if !exists {
+		c.JSON(500, gin.H{"success": false, "message": "数据库未连接"})
+		return
+	}
+	sqlxDB := db.(*sqlx.DB)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "message": "无效的ID"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	var run struct {
+		Status          string     `db:"status" json:"status"`
+		RunStartedAt    *time.Time `db:"run_started_at" json:"run_started_at"`
+		LastRunAt       *time.Time `db:"last_run_at" json:"last_run_at"`
+		LastRunDuration *int       `db:"last_run_duration" json:"last_run_duration"`
+		LastRunItems    *int       `db:"last_run_items" json:"last_run_items"`
+		LastError       *string    `db:"last_error" json:"last_error"`
+		RobotsMode      string     `db:"robots_mode" json:"robots_mode"`
+	}
+	err = sqlxDB.Get(&run, `
+		SELECT status, run_started_at, last_run_at, last_run_duration, last_run_items, last_error, robots_mode
+		FROM spider_projects WHERE id = ?
+	`, id)
+	if err != nil {
+		c.JSON(404, gin.H{"success": false, "message": "项目不存在"})
+		return
+	}
+
+	since := run.RunStartedAt
+	if c.Query("scope") == "all" {
+		since = nil
+	}
+	robots, err := h.checker.Report(c.Request.Context(), id, run.RobotsMode, since, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "message": "查询违规记录失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": gin.H{"run": run, "robots": robots}})
+}
+
+// ClearViolations 清空项目的 robots 违规记录
+// DELETE /api/spider-projects/:id/robots/violations
+func (h *SpiderRobotsHandler) ClearViolations(c *gin.Context) {
+	db, exists := c.Get("db")
+	if !exists {
+		c.JSON(500, gin.H{"success": false, "message": "数据库未连接"})
+		return
+	}
+	sqlxDB := db.(*sqlx.DB)
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	result, err := sqlxDB.Exec("DELETE FROM spider_robots_violations WHERE project_id = ?", id)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "message": "清空失败"})
+		return
+	}
+	deleted, _ := result.RowsAffected()
+	c.JSON(200, gin.H{"success": true, "message": "已清空", "deleted": deleted})
+}
+
+// ClearRobotsCache 清除 robots.txt 缓存
+// POST /api/spider-projects/robots/cache/clear?host=example.com（不传 host 清空全部）
+func (h *SpiderRobotsHandler) ClearRobotsCache(c *gin.Context) {
+	h.checker.Invalidate(c.Query("host"))
+	c.JSON(200, gin.H{"success": true, "message": "缓存已清除"})
+}
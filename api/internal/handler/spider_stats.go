@@ -6,16 +6,18 @@ import (
 	"fmt"
 	"math"
 	"strconv"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/redis/go-redis/v9"
 	"seo-generator/api/internal/model"
+	core "seo-generator/api/internal/service"
 )
 
 // SpiderStatsHandler 爬虫统计处理器
-type SpiderStatsHandler struct{}
+type SpiderStatsHandler struct {
+	reader *core.SpiderStatsReader // 概览和按项目统计的批量读取，无 Redis 时为 nil
+}
 
 // GetRealtimeStats 获取实时统计
 func (h *SpiderStatsHandler) GetRealtimeStats(c *gin.Context) {
@@ -344,77 +346,44 @@ func (h *SpiderStatsHandler) DeleteFailed(c *gin.Context) {
 	c.JSON(200, gin.H{"success": true, "message": "已删除"})
 }
 
-// GetOverview 获取统计概览（从 Redis 读取实时数据）
+// GetOverview 获取统计概览（从 Redis 读取实时数据，批量读取并短暂缓存）
 func (h *SpiderStatsHandler) GetOverview(c *gin.Context) {
-	rdb, exists := c.Get("redis")
-	if !exists {
+	if h.reader == nil {
 		c.JSON(200, gin.H{"success": true, "data": map[string]interface{}{
 			"total": 0, "completed": 0, "failed": 0, "retried": 0, "success_rate": 0, "avg_speed": 0,
 		}})
 		return
 	}
-	redisClient := rdb.(*redis.Client)
 
-	projectIDStr := c.Query("project_id")
-	ctx := context.Background()
-
-	var total, completed, failed, retried int64
-
-	if projectIDStr != "" && projectIDStr != "0" {
-		// 单个项目统计
-		projectID, _ := strconv.Atoi(projectIDStr)
-		statsKey := fmt.Sprintf("spider:%d:stats", projectID)
-		statsData, err := redisClient.HGetAll(ctx, statsKey).Result()
-		if err == nil && len(statsData) > 0 {
-			total, _ = strconv.ParseInt(statsData["total"], 10, 64)
-			completed, _ = strconv.ParseInt(statsData["completed"], 10, 64)
-			failed, _ = strconv.ParseInt(statsData["failed"], 10, 64)
-			retried, _ = strconv.ParseInt(statsData["retried"], 10, 64)
-		}
-	} else {
-		// 全部项目统计：扫描所有 spider:*:stats 键
-		iter := redisClient.Scan(ctx, 0, "spider:*:stats", 100).Iterator()
-		for iter.Next(ctx) {
-			key := iter.Val()
-			// 排除 archived 和 test 键
-			if strings.Contains(key, ":archived") || strings.HasPrefix(key, "test_spider:") {
-				continue
-			}
-			statsData, err := redisClient.HGetAll(ctx, key).Result()
-			if err == nil {
-				t, _ := strconv.ParseInt(statsData["total"], 10, 64)
-				comp, _ := strconv.ParseInt(statsData["completed"], 10, 64)
-				f, _ := strconv.ParseInt(statsData["failed"], 10, 64)
-				r, _ := strconv.ParseInt(statsData["retried"], 10, 64)
-				total += t
-				completed += comp
-				failed += f
-				retried += r
-			}
-		}
-		// 检查迭代器错误
-		if err := iter.Err(); err != nil {
-			c.JSON(500, gin.H{"success": false, "message": "Redis 扫描失败"})
-			return
-		}
-	}
-
-	var successRate float64
-	totalDone := completed + failed
-	if totalDone > 0 {
-		successRate = math.Round(float64(completed)/float64(totalDone)*10000) / 100
+	// project_id 为空或 0 时汇总全部项目
+	projectID, _ := strconv.Atoi(c.Query("project_id"))
+	counts, err := h.reader.Overview(c.Request.Context(), projectID)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "message": "Redis 读取统计失败"})
+		return
 	}
 
 	c.JSON(200, gin.H{"success": true, "data": gin.H{
-		"total":        total,
-		"completed":    completed,
-		"failed":       failed,
-		"retried":      retried,
-		"success_rate": successRate,
+		"total":        counts.Total,
+		"completed":    counts.Completed,
+		"failed":       counts.Failed,
+		"retried":      counts.Retried,
+		"success_rate": counts.SuccessRate(),
 		"avg_speed":    0, // 实时统计不计算速度
 	}})
 }
 
+// GetRedisMetrics 统计读取的缓存命中和 Redis 调用延迟
+func (h *SpiderStatsHandler) GetRedisMetrics(c *gin.Context) {
+	if h.reader == nil {
+		c.JSON(200, gin.H{"success": true, "data": gin.H{"enabled": false}})
+		return
+	}
+	stats := h.reader.Stats()
+	stats["enabled"] = true
+	c.JSON(200, gin.H{"success": true, "data": stats})
+}
+
 // GetChart 获取图表数据
 func (h *SpiderStatsHandler) GetChart(c *gin.Context) {
 	db, exists := c.Get("db")
@@ -508,18 +477,15 @@ func (h *SpiderStatsHandler) GetScheduled(c *gin.Context) {
 // GetByProject 按项目统计（从 Redis 读取实时数据）
 func (h *SpiderStatsHandler) GetByProject(c *gin.Context) {
 	db, dbExists := c.Get("db")
-	rdb, redisExists := c.Get("redis")
 	if !dbExists {
 		c.JSON(500, gin.H{"success": false, "message": "数据库未连接"})
 		return
 	}
-	if !redisExists {
+	if h.reader == nil {
 		c.JSON(500, gin.H{"success": false, "message": "Redis未连接"})
 		return
 	}
 	sqlxDB := db.(*sqlx.DB)
-	redisClient := rdb.(*redis.Client)
-	ctx := context.Background()
 
 	// 获取所有项目
 	var projects []struct {
@@ -531,34 +497,28 @@ func (h *SpiderStatsHandler) GetByProject(c *gin.Context) {
 		return
 	}
 
-	// 从 Redis 获取每个项目的统计
+	// 一次 pipeline 读取全部项目的统计
+	ids := make([]int, len(projects))
+	for i, p := range projects {
+		ids[i] = p.ID
+	}
+	stats, err := h.reader.ByProject(c.Request.Context(), ids)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "message": "Redis 读取统计失败"})
+		return
+	}
+
 	result := make([]gin.H, 0, len(projects))
 	for _, p := range projects {
-		statsKey := fmt.Sprintf("spider:%d:stats", p.ID)
-		statsData, err := redisClient.HGetAll(ctx, statsKey).Result()
-
-		var total, completed, failed, retried int64
-		if err == nil && len(statsData) > 0 {
-			total, _ = strconv.ParseInt(statsData["total"], 10, 64)
-			completed, _ = strconv.ParseInt(statsData["completed"], 10, 64)
-			failed, _ = strconv.ParseInt(statsData["failed"], 10, 64)
-			retried, _ = strconv.ParseInt(statsData["retried"], 10, 64)
-		}
-
-		var successRate float64
-		totalDone := completed + failed
-		if totalDone > 0 {
-			successRate = math.Round(float64(completed)/float64(totalDone)*10000) / 100
-		}
-
+		counts := stats[p.ID]
 		result = append(result, gin.H{
 			"project_id":   p.ID,
 			"project_name": p.Name,
-			"total":        total,
-			"completed":    completed,
-			"failed":       failed,
-			"retried":      retried,
-			"success_rate": successRate,
+			"total":        counts.Total,
+			"completed":    counts.Completed,
+			"failed":       counts.Failed,
+			"retried":      counts.Retried,
+			"success_rate": counts.SuccessRate(),
 		})
 	}
 
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// SpiderStrategiesHandler 蜘蛛内容策略管理 handler
+type SpiderStrategiesHandler struct {
+	db       *sqlx.DB
+	resolver *core.SpiderStrategyResolver
+}
+
+// NewSpiderStrategiesHandler 创建 SpiderStrategiesHandler
+func NewSpiderStrategiesHandler(db *sqlx.DB, resolver *core.SpiderStrategyResolver) *SpiderStrategiesHandler {
+	return &SpiderStrategiesHandler{db: db, resolver: resolver}
+}
+
+// SpiderStrategyRequest 创建/更新蜘蛛策略请求
+// template / keyword_group_id / title_pattern 为空表示沿用站点配置
+type SpiderStrategyRequest struct {
+	SiteGroupID    int    `json:"site_group_id" binding:"required"`
+	SpiderType     string `json:"spider_type" binding:"required"`
+	Template       string `json:"template"`
+	KeywordGroupID *int   `json:"keyword_group_id"`
+	TitlePattern   string `json:"title_pattern"`
+	Status         *int   `json:"status"`
+}
+
+// reload 策略变更后重新加载解析器
+func (h *SpiderStrategiesHandler) reload(c *gin.Context) {
+	if err := h.resolver.Reload(c.Request.Context()); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload spider strategies")
+	}
+}
+
+// validate 校验请求，返回错误消息
+func (h *SpiderStrategiesHandler) validate(req *SpiderStrategyRequest) string {
+	req.SpiderType = strings.TrimSpace(req.SpiderType)
+	req.Template = strings.TrimSpace(req.Template)
+	known := false
+	for _, t := range core.GetSpiderDetector().GetAllSpiderTypes() {
+		if t == req.SpiderType {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return "未知的蜘蛛类型: " + req.SpiderType
+	}
+	if err := core.ValidateTitlePattern(req.TitlePattern); err != nil {
+		return "标题格式无效: " + err.Error()
+	}
+	return ""
+}
+
+// List 获取策略列表
+// GET /api/spider-strategies?site_group_id=1
+func (h *SpiderStrategiesHandler) List(c *gin.Context) {
+	where := "1=1"
+	args := []interface{}{}
+	if groupID, _ := strconv.Atoi(c.Query("site_group_id")); groupID > 0 {
+		where += " AND site_group_id = ?"
+		args = append(args, groupID)
+	}
+
+	var items []*core.SpiderStrategy
+	if err := h.db.Select(&items, `
+		SELECT id, site_group_id, spider_type, template, keyword_group_id, title_pattern, status, created_at, updated_at
+		FROM spider_strategies WHERE `+where+` ORDER BY site_group_id, spider_type`, args...); err != nil {
+		log.Warn().Err(err).Msg("Failed to list spider strategies")
+		items = nil
+	}
+
+	views := make([]core.SpiderStrategyView, 0, len(items))
+	for _, s := range items {
+		views = append(views, s.View())
+	}
+	core.Success(c, gin.H{
+		"items":        views,
+		"spider_types": core.GetSpiderDetector().GetAllSpiderTypes(),
+	})
+}
+
+// Create 添加策略（同一站群同一蜘蛛类型只能有一条）
+// POST /api/spider-strategies
+func (h *SpiderStrategiesHandler) Create(c *gin.Context) {
+	var req SpiderStrategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if msg := h.validate(&req); msg != "" {
+		core.FailWithMessage(c, core.ErrInvalidParam, msg)
+		return
+	}
+
+	status := 1
+	if req.Status != nil {
+		status = *req.Status
+	}
+	result, err := h.db.Exec(`
+		INSERT INTO spider_strategies (site_group_id, spider_type, template, keyword_group_id, title_pattern, status)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		req.SiteGroupID, req.SpiderType, nullableString(req.Template), nullableInt(req.KeywordGroupID),
+		nullableString(req.TitlePattern), status)
+	if err != nil {
+		if strings.Contains(err.Error(), "Duplicate") {
+			core.FailWithMessage(c, core.ErrConflict, "该站群已配置此蜘蛛类型的策略")
+			return
+		}
+		log.Error().Err(err).Msg("Failed to create spider strategy")
+		core.FailWithCode(c, core.ErrDBInsert)
+		return
+	}
+
+	h.reload(c)
+	id, _ := result.LastInsertId()
+	core.Success(c, gin.H{"id": id})
+}
+
+// Update 更新策略
+// PUT /api/spider-strategies/:id
+func (h *SpiderStrategiesHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的 ID")
+		return
+	}
+	var req SpiderStrategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if msg := h.validate(&req); msg != "" {
+		core.FailWithMessage(c, core.ErrInvalidParam, msg)
+		return
+	}
+
+	status := 1
+	if req.Status != nil {
+		status = *req.Status
+	}
+	result, err := h.db.Exec(`
+		UPDATE spider_strategies SET site_group_id = ?, spider_type = ?, template = ?, keyword_group_id = ?,
+			title_pattern = ?, status = ?
+		WHERE id = ?`,
+		req.SiteGroupID, req.SpiderType, nullableString(req.Template), nullableInt(req.KeywordGroupID),
+		nullableString(req.TitlePattern), status, id)
+	if err != nil {
+		if strings.Contains(err.Error(), "Duplicate") {
+			core.FailWithMessage(c, core.ErrConflict, "该站群已配置此蜘蛛类型的策略")
+			return
+		}
+		log.Error().Err(err).Int("id", id).Msg("Failed to update spider strategy")
+		core.FailWithCode(c, core.ErrDBUpdate)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		var exists int
+		if h.db.Get(&exists, "SELECT COUNT(*) FROM spider_strategies WHERE id = ?", id); exists == 0 {
+			core.FailWithMessage(c, core.ErrNotFound, "策略不存在")
+			return
+		}
+	}
+
+	h.reload(c)
+	core.Success(c, nil)
+}
+
+// Delete 删除策略
+// DELETE /api/spider-strategies/:id
+func (h *SpiderStrategiesHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的 ID")
+		return
+	}
+	if _, err := h.db.Exec("DELETE FROM spider_strategies WHERE id = ?", id); err != nil {
+		log.Error().Err(err).Int("id", id).Msg("Failed to delete spider strategy")
+		core.FailWithCode(c, core.ErrDBDelete)
+		return
+	}
+
+	h.reload(c)
+	core.Success(c, nil)
+}
+
+// Stats 按站群和蜘蛛类型拆分的请求/缓存命中/渲染次数（进程启动以来）
+// GET /api/spider-strategies/stats?site_group_id=1
+func (h *SpiderStrategiesHandler) Stats(c *gin.Context) {
+	groupID, _ := strconv.Atoi(c.Query("site_group_id"))
+	core.Success(c, h.resolver.Stats(groupID))
+}
+
+// nullableString 空字符串写入 NULL
+func nullableString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullableInt nil 写入 NULL
+func nullableInt(v *int) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*v), Valid: true}
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// SSE 推送：与 /ws/* 对应的 Server-Sent Events 接口，供不允许 WebSocket 的网络环境使用
+// 与 WebSocket 共享同一个广播器，消息内容与 WebSocket 相同。
+// 每条消息带 id，客户端断线重连时浏览器 EventSource 自动携带 Last-Event-ID 请求头
+// （也可通过 last_event_id 参数指定），服务端回放缓冲中该 ID 之后的消息
+
+// sseHeartbeatInterval 心跳间隔，防止代理断开空闲连接
+const sseHeartbeatInterval = 15 * time.Second
+
+// sseRetryMs 建议客户端的重连间隔
+const sseRetryMs = 3000
+
+// lastEventID 读取续传位置（Last-Event-ID 请求头优先）
+func lastEventID(c *gin.Context) (uint64, bool) {
+	v := c.GetHeader("Last-Event-ID")
+	if v == "" {
+		v = c.Query("last_event_id")
+	}
+	if v == "" {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// serveSSE 订阅广播器并以 SSE 格式推送，直到客户端断开
+func serveSSE(c *gin.Context, b *broadcaster) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "不支持流式响应"})
+		return
+	}
+
+	lastID, resume := lastEventID(c)
+	replay, events, unsubscribe := b.subscribe(lastID, resume)
+	defer unsubscribe()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // 关闭 Nginx 缓冲
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetryMs)
+	for _, event := range replay {
+		if writeSSEEvent(c.Writer, event) != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case event := <-events:
+			if writeSSEEvent(c.Writer, event) != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// writeSSEEvent 写入一条消息（多行内容拆成多个 data 字段）
+func writeSSEEvent(w gin.ResponseWriter, event broadcastEvent) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "id: %d\n", event.ID)
+	for _, line := range bytes.Split(event.Data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(bytes.TrimSuffix(line, []byte("\r")))
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// redisFromContext 获取注入的 Redis 客户端，未连接时返回错误响应
+func redisFromContext(c *gin.Context) (*redis.Client, bool) {
+	rdb, exists := c.Get("redis")
+	if !exists {
+		c.JSON(500, gin.H{"success": false, "message": "Redis未连接"})
+		return nil, false
+	}
+	redisClient, ok := rdb.(*redis.Client)
+	if !ok || redisClient == nil {
+		c.JSON(500, gin.H{"success": false, "message": "Redis未连接"})
+		return nil, false
+	}
+	return redisClient, true
+}
+
+// SpiderLogsSSE 爬虫日志 SSE，参数同 /ws/spider-logs/:id
+// GET /sse/spider-logs/:id?type=test
+func (h *WebSocketHandler) SpiderLogsSSE(c *gin.Context) {
+	redisClient, ok := redisFromContext(c)
+	if !ok {
+		return
+	}
+	channel := "spider:logs:" + c.DefaultQuery("type", "project") + "_" + c.Param("id")
+	serveSSE(c, h.broadcasters.redisChannel(redisClient, channel))
+}
+
+// SpiderStatsSSE 爬虫统计 SSE
+// GET /sse/spider-stats/:id
+func (h *WebSocketHandler) SpiderStatsSSE(c *gin.Context) {
+	redisClient, ok := redisFromContext(c)
+	if !ok {
+		return
+	}
+	serveSSE(c, h.broadcasters.redisChannel(redisClient, "spider:stats:project_"+c.Param("id")))
+}
+
+// ProcessorLogsSSE 数据处理日志 SSE
+// GET /sse/processor-logs
+func (h *WebSocketHandler) ProcessorLogsSSE(c *gin.Context) {
+	redisClient, ok := redisFromContext(c)
+	if !ok {
+		return
+	}
+	serveSSE(c, h.broadcasters.redisChannel(redisClient, "processor:logs"))
+}
+
+// SystemLogsSSE 系统日志 SSE
+// GET /api/logs/sse
+func (h *WebSocketHandler) SystemLogsSSE(c *gin.Context) {
+	redisClient, ok := redisFromContext(c)
+	if !ok {
+		return
+	}
+	serveSSE(c, h.broadcasters.redisChannel(redisClient, "system:logs"))
+}
+
+// ProcessorStatusSSE 数据处理状态 SSE（每秒一次）
+// GET /sse/processor-status
+func (h *WebSocketHandler) ProcessorStatusSSE(c *gin.Context) {
+	redisClient, ok := redisFromContext(c)
+	if !ok {
+		return
+	}
+	serveSSE(c, h.processorStatusBroadcaster(redisClient))
+}
+
+// PoolStatusSSE 池状态 SSE（每秒一次）
+// GET /sse/pool-status
+func (h *WebSocketHandler) PoolStatusSSE(c *gin.Context) {
+	serveSSE(c, h.poolStatusBroadcaster())
+}
+
+// SystemStatsSSE 系统资源 SSE（每秒一次）
+// GET /sse/system-stats
+func (h *WebSocketHandler) SystemStatsSSE(c *gin.Context) {
+	serveSSE(c, h.systemStatsBroadcaster())
+}
@@ -0,0 +1,473 @@
+package api
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// 模板标签限制
+const (
+	maxTemplateTags      = 20
+	maxTemplateTagLength = 50
+)
+
+// templateEngines 模板可选的目标搜索引擎（与蜘蛛类型一致），空表示通用
+var templateEngines = map[string]bool{
+	"baidu": true, "sogou": true, "360": true, "shenma": true, "google": true, "bing": true,
+}
+
+// TemplateLibraryItem 模板库列表项
+type TemplateLibraryItem struct {
+	ID          int        `json:"id" db:"id"`
+	SiteGroupID int        `json:"site_group_id" db:"site_group_id"`
+	Name        string     `json:"name" db:"name"`
+	DisplayName string     `json:"display_name" db:"display_name"`
+	Description *string    `json:"description" db:"description"`
+	Category    *string    `json:"category" db:"category"`
+	Engine      *string    `json:"engine" db:"engine"`
+	Status      int        `json:"status" db:"status"`
+	Degraded    int        `json:"degraded" db:"degraded"`
+	SitesCount  int        `json:"sites_count" db:"sites_count"`
+	RenderCount int64      `json:"render_count" db:"render_count"`
+	LastUsedAt  *time.Time `json:"last_used_at" db:"last_used_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	Tags        []string   `json:"tags" db:"-"`
+	// RenderCost 单次渲染的模板函数调用次数（TemplateAnalyzer 展开循环后统计），未分析时为空
+	RenderCost *int `json:"render_cost" db:"-"`
+	LoopCount  int  `json:"loop_count" db:"-"`
+}
+
+// TemplateTagsRequest 设置模板标签/分类/目标引擎
+type TemplateTagsRequest struct {
+	Tags     []string `json:"tags"`
+	Category *string  `json:"category"`
+	Engine   *string  `json:"engine"`
+}
+
+// UnusedTemplate 未使用模板
+type UnusedTemplate struct {
+	TemplateLibraryItem
+	IsFallback bool `json:"is_fallback" db:"is_fallback"`
+	// Reason unbound=没有站点绑定且不是备用模板, idle=有站点绑定但超过天数未渲染
+	Reason string `json:"reason" db:"-"`
+}
+
+// templateLibrarySelect 模板库查询列（不含 content）
+const templateLibrarySelect = `SELECT t.id, t.site_group_id, t.name, t.display_name, t.description,
+	       t.category, t.engine, t.status, t.degraded, t.render_count, t.last_used_at, t.updated_at,
+	       (SELECT COUNT(*) FROM sites WHERE sites.template = t.name) as sites_count`
+
+// Library 模板库列表
+// GET /api/templates/library
+// 支持按标签（逗号分隔，需全部命中）、分类、目标引擎、关键字、最近使用、渲染成本筛选和排序
+func (h *TemplatesHandler) Library(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	if h.db == nil {
+		core.SuccessPaged(c, []TemplateLibraryItem{}, 0, page, pageSize)
+		return
+	}
+
+	where := "1=1"
+	args := []interface{}{}
+
+	if v := c.Query("site_group_id"); v != "" {
+		where += " AND t.site_group_id = ?"
+		args = append(args, v)
+	}
+	if v := c.Query("status"); v != "" {
+		where += " AND t.status = ?"
+		args = append(args, v)
+	}
+	if v := c.Query("category"); v != "" {
+		where += " AND t.category = ?"
+		args = append(args, v)
+	}
+	if v := c.Query("engine"); v != "" {
+		if v == "generic" {
+			where += " AND (t.engine IS NULL OR t.engine = '')"
+		} else {
+			where += " AND t.engine = ?"
+			args = append(args, v)
+		}
+	}
+	if v := strings.TrimSpace(c.Query("keyword")); v != "" {
+		where += " AND (t.name LIKE ? OR t.display_name LIKE ? OR t.description LIKE ?)"
+		like := "%" + v + "%"
+		args = append(args, like, like, like)
+	}
+	if tags := normalizeTemplateTags(strings.Split(c.Query("tag"), ",")); len(tags) > 0 {
+		where += " AND t.id IN (SELECT template_id FROM template_tags WHERE tag IN (?" +
+			strings.Repeat(",?", len(tags)-1) + ") GROUP BY template_id HAVING COUNT(*) = ?)"
+		for _, tag := range tags {
+			args = append(args, tag)
+		}
+		args = append(args, len(tags))
+	}
+	// 最近 N 天内使用过 / 超过 N 天未使用
+	if days, _ := strconv.Atoi(c.Query("used_within_days")); days > 0 {
+		where += " AND t.last_used_at >= ?"
+		args = append(args, time.Now().AddDate(0, 0, -days))
+	}
+	if days, _ := strconv.Atoi(c.Query("unused_days")); days > 0 {
+		where += " AND (t.last_used_at IS NULL OR t.last_used_at < ?)"
+		args = append(args, time.Now().AddDate(0, 0, -days))
+	}
+
+	var items []TemplateLibraryItem
+	if err := h.db.Select(&items, templateLibrarySelect+" FROM templates t WHERE "+where, args...); err != nil {
+		log.Error().Err(err).Msg("Failed to query template library")
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+
+	// 渲染成本来自内存中的分析结果，筛选和排序在内存中完成
+	h.fillRenderCost(items)
+	costMin, _ := strconv.Atoi(c.Query("cost_min"))
+	costMax, _ := strconv.Atoi(c.Query("cost_max"))
+	if costMin > 0 || costMax > 0 {
+		filtered := items[:0]
+		for _, it := range items {
+			if it.RenderCost == nil {
+				continue
+			}
+			if (costMin > 0 && *it.RenderCost < costMin) || (costMax > 0 && *it.RenderCost > costMax) {
+				continue
+			}
+			filtered = append(filtered, it)
+		}
+		items = filtered
+	}
+	sortTemplateLibrary(items, c.DefaultQuery("sort", "id"), c.DefaultQuery("order", "desc") == "asc")
+
+	total := int64(len(items))
+	start := (page - 1) * pageSize
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + pageSize
+	if end > len(items) {
+		end = len(items)
+	}
+	items = items[start:end]
+
+	if err := h.fillTemplateTags(items); err != nil {
+		log.Warn().Err(err).Msg("Failed to load template tags")
+	}
+
+	core.SuccessPaged(c, items, total, page, pageSize)
+}
+
+// Tags 标签、分类、目标引擎汇总（用于模板库筛选项）
+// GET /api/templates/tags
+func (h *TemplatesHandler) Tags(c *gin.Context) {
+	type facet struct {
+		Value string `json:"value" db:"value"`
+		Count int    `json:"count" db:"count"`
+	}
+	tags, categories, engines := []facet{}, []facet{}, []facet{}
+
+	if h.db != nil {
+		if err := h.db.Select(&tags,
+			"SELECT tag as value, COUNT(*) as count FROM template_tags GROUP BY tag ORDER BY count DESC, tag"); err != nil {
+			log.Warn().Err(err).Msg("Failed to query template tags")
+		}
+		if err := h.db.Select(&categories,
+			`SELECT category as value, COUNT(*) as count FROM templates
+			 WHERE category IS NOT NULL AND category != '' GROUP BY category ORDER BY count DESC, category`); err != nil {
+			log.Warn().Err(err).Msg("Failed to query template categories")
+		}
+		if err := h.db.Select(&engines,
+			`SELECT engine as value, COUNT(*) as count FROM templates
+			 WHERE engine IS NOT NULL AND engine != '' GROUP BY engine ORDER BY count DESC, engine`); err != nil {
+			log.Warn().Err(err).Msg("Failed to query template engines")
+		}
+	}
+
+	core.Success(c, gin.H{"tags": tags, "categories": categories, "engines": engines})
+}
+
+// UpdateTags 设置模板标签、分类和目标引擎（不改变模板版本号）
+// PUT /api/templates/:id/tags
+func (h *TemplatesHandler) UpdateTags(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的模板 ID")
+		return
+	}
+
+	var req TemplateTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if msg := validateTemplateTags(&req); msg != "" {
+		core.FailWithMessage(c, core.ErrInvalidParam, msg)
+		return
+	}
+
+	if h.db == nil {
+		core.FailWithMessage(c, core.ErrInternalServer, "数据库未初始化")
+		return
+	}
+
+	var exists int
+	if err := h.db.Get(&exists, "SELECT COUNT(*) FROM templates WHERE id = ?", id); err != nil || exists == 0 {
+		core.FailWithMessage(c, core.ErrNotFound, "模板不存在")
+		return
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		core.FailWithCode(c, core.ErrDBUpdate)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := saveTemplateMeta(tx, id, &req); err != nil {
+		log.Error().Err(err).Int("id", id).Msg("Failed to update template tags")
+		core.FailWithCode(c, core.ErrDBUpdate)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		core.FailWithCode(c, core.ErrDBUpdate)
+		return
+	}
+
+	core.Success(c, gin.H{"success": true, "tags": normalizeTemplateTags(req.Tags)})
+}
+
+// Unused 未使用模板报告
+// GET /api/templates/unused?days=30
+// unbound：没有站点绑定且不是任何站群的备用模板，可直接清理；
+// idle：有站点绑定但超过 days 天未渲染（站点可能已停止被抓取）
+func (h *TemplatesHandler) Unused(c *gin.Context) {
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if days < 1 {
+		days = 30
+	}
+	cutoff := time.Now().AddDate(0, 0, -days)
+
+	if h.db == nil {
+		core.Success(c, gin.H{"items": []UnusedTemplate{}, "days": days})
+		return
+	}
+
+	var items []UnusedTemplate
+	err := h.db.Select(&items, templateLibrarySelect+`,
+	       EXISTS(SELECT 1 FROM site_groups sg WHERE sg.fallback_template = t.name) as is_fallback
+	  FROM templates t
+	 WHERE t.last_used_at IS NULL OR t.last_used_at < ?
+	 ORDER BY t.last_used_at, t.id`, cutoff)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to query unused templates")
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+
+	includeIdle := c.DefaultQuery("include_idle", "true") != "false"
+	result := make([]UnusedTemplate, 0, len(items))
+	unbound := 0
+	for _, it := range items {
+		switch {
+		case it.SitesCount == 0 && !it.IsFallback:
+			it.Reason = "unbound"
+			unbound++
+		case it.SitesCount > 0 && includeIdle:
+			it.Reason = "idle"
+		default:
+			continue
+		}
+		result = append(result, it)
+	}
+
+	library := make([]TemplateLibraryItem, len(result))
+	for i := range result {
+		library[i] = result[i].TemplateLibraryItem
+	}
+	h.fillRenderCost(library)
+	if err := h.fillTemplateTags(library); err != nil {
+		log.Warn().Err(err).Msg("Failed to load template tags")
+	}
+	for i := range result {
+		result[i].TemplateLibraryItem = library[i]
+	}
+
+	core.Success(c, gin.H{
+		"items":   result,
+		"days":    days,
+		"unbound": unbound,
+		"idle":    len(result) - unbound,
+	})
+}
+
+// fillRenderCost 填充 TemplateAnalyzer 的渲染成本
+func (h *TemplatesHandler) fillRenderCost(items []TemplateLibraryItem) {
+	if h.templateAnalyzer == nil {
+		return
+	}
+	for i := range items {
+		analysis := h.templateAnalyzer.GetAnalysis(items[i].Name, items[i].SiteGroupID)
+		if analysis == nil || analysis.Stats == nil {
+			continue
+		}
+		cost := analysis.Stats.Total()
+		items[i].RenderCost = &cost
+		items[i].LoopCount = analysis.LoopCount
+	}
+}
+
+// fillTemplateTags 批量加载模板标签
+func (h *TemplatesHandler) fillTemplateTags(items []TemplateLibraryItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]int, len(items))
+	index := make(map[int]int, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+		index[items[i].ID] = i
+		items[i].Tags = []string{}
+	}
+
+	query, args, err := sqlx.In("SELECT template_id, tag FROM template_tags WHERE template_id IN (?) ORDER BY tag", ids)
+	if err != nil {
+		return err
+	}
+	var rows []struct {
+		TemplateID int    `db:"template_id"`
+		Tag        string `db:"tag"`
+	}
+	if err := h.db.Select(&rows, h.db.Rebind(query), args...); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if i, ok := index[r.TemplateID]; ok {
+			items[i].Tags = append(items[i].Tags, r.Tag)
+		}
+	}
+	return nil
+}
+
+// sortTemplateLibrary 模板库排序，未分析/未使用的模板始终排在最后
+func sortTemplateLibrary(items []TemplateLibraryItem, field string, asc bool) {
+	less := func(i, j int) bool { return items[i].ID < items[j].ID }
+	switch field {
+	case "name":
+		less = func(i, j int) bool { return items[i].Name < items[j].Name }
+	case "render_count":
+		less = func(i, j int) bool { return items[i].RenderCount < items[j].RenderCount }
+	case "sites_count":
+		less = func(i, j int) bool { return items[i].SitesCount < items[j].SitesCount }
+	case "last_used":
+		sort.SliceStable(items, func(i, j int) bool {
+			a, b := items[i].LastUsedAt, items[j].LastUsedAt
+			if a == nil || b == nil {
+				return b == nil && a != nil
+			}
+			if asc {
+				return a.Before(*b)
+			}
+			return a.After(*b)
+		})
+		return
+	case "render_cost":
+		sort.SliceStable(items, func(i, j int) bool {
+			a, b := items[i].RenderCost, items[j].RenderCost
+			if a == nil || b == nil {
+				return b == nil && a != nil
+			}
+			if asc {
+				return *a < *b
+			}
+			return *a > *b
+		})
+		return
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if asc {
+			return less(i, j)
+		}
+		return less(j, i)
+	})
+}
+
+// normalizeTemplateTags 去除空白和重复标签
+func normalizeTemplateTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// validateTemplateTags 校验标签、分类和目标引擎，返回错误信息
+func validateTemplateTags(req *TemplateTagsRequest) string {
+	tags := normalizeTemplateTags(req.Tags)
+	if len(tags) > maxTemplateTags {
+		return "标签数量不能超过 " + strconv.Itoa(maxTemplateTags) + " 个"
+	}
+	for _, tag := range tags {
+		if len([]rune(tag)) > maxTemplateTagLength {
+			return "标签长度不能超过 " + strconv.Itoa(maxTemplateTagLength) + " 个字符"
+		}
+	}
+	if req.Category != nil && len([]rune(strings.TrimSpace(*req.Category))) > 50 {
+		return "分类长度不能超过 50 个字符"
+	}
+	if req.Engine != nil && *req.Engine != "" && !templateEngines[*req.Engine] {
+		return "不支持的目标引擎: " + *req.Engine
+	}
+	return ""
+}
+
+// saveTemplateMeta 写入模板标签（整体替换）以及分类、目标引擎
+// Tags 为 nil 时不修改标签；Category/Engine 为 nil 时不修改，空字符串表示清空
+func saveTemplateMeta(tx *sqlx.Tx, templateID int, req *TemplateTagsRequest) error {
+	if req.Category != nil {
+		if _, err := tx.Exec("UPDATE templates SET category = ? WHERE id = ?",
+			nullIfEmpty(strings.TrimSpace(*req.Category)), templateID); err != nil {
+			return err
+		}
+	}
+	if req.Engine != nil {
+		if _, err := tx.Exec("UPDATE templates SET engine = ? WHERE id = ?",
+			nullIfEmpty(*req.Engine), templateID); err != nil {
+			return err
+		}
+	}
+	if req.Tags == nil {
+		return nil
+	}
+
+	if _, err := tx.Exec("DELETE FROM template_tags WHERE template_id = ?", templateID); err != nil {
+		return err
+	}
+	for _, tag := range normalizeTemplateTags(req.Tags) {
+		if _, err := tx.Exec("INSERT INTO template_tags (template_id, tag) VALUES (?, ?)", templateID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// TemplateRolloutsHandler 模板灰度发布 handler
+type TemplateRolloutsHandler struct {
+	rollouts *core.TemplateRollouts
+}
+
+// NewTemplateRolloutsHandler 创建 TemplateRolloutsHandler
+func NewTemplateRolloutsHandler(rollouts *core.TemplateRollouts) *TemplateRolloutsHandler {
+	return &TemplateRolloutsHandler{rollouts: rollouts}
+}
+
+// TemplateRolloutRequest 创建灰度请求
+type TemplateRolloutRequest struct {
+	SiteGroupID   int      `json:"site_group_id" binding:"required"`
+	Template      string   `json:"template" binding:"required"`
+	Percent       int      `json:"percent"`         // 初始比例 0-100
+	MaxErrorDelta *float64 `json:"max_error_delta"` // 默认 0.05
+	MinRenders    *int     `json:"min_renders"`     // 默认 50
+}
+
+// RolloutPercentRequest 调整比例请求
+type RolloutPercentRequest struct {
+	Percent *int `json:"percent" binding:"required"`
+}
+
+// RolloutRollbackRequest 回滚请求
+type RolloutRollbackRequest struct {
+	Reason string `json:"reason"`
+}
+
+// List 灰度列表（进行中的附带两组实时统计）
+// GET /api/template-rollouts?site_group_id=
+func (h *TemplateRolloutsHandler) List(c *gin.Context) {
+	siteGroupID, _ := strconv.Atoi(c.Query("site_group_id"))
+	items, err := h.rollouts.List(c.Request.Context(), siteGroupID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list template rollouts")
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+	core.Success(c, gin.H{"items": items})
+}
+
+// Get 灰度详情
+// GET /api/template-rollouts/:id
+func (h *TemplateRolloutsHandler) Get(c *gin.Context) {
+	id, ok := parseRolloutID(c)
+	if !ok {
+		return
+	}
+	h.respond(c, id)
+}
+
+// Create 创建灰度
+// POST /api/template-rollouts
+func (h *TemplateRolloutsHandler) Create(c *gin.Context) {
+	var req TemplateRolloutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	rollout := &core.TemplateRollout{
+		SiteGroupID:   req.SiteGroupID,
+		Template:      strings.TrimSpace(req.Template),
+		Percent:       req.Percent,
+		MaxErrorDelta: 0.05,
+		MinRenders:    50,
+	}
+	if req.MaxErrorDelta != nil {
+		rollout.MaxErrorDelta = *req.MaxErrorDelta
+	}
+	if req.MinRenders != nil {
+		rollout.MinRenders = *req.MinRenders
+	}
+	if rollout.Percent < 0 || rollout.Percent > 100 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "percent 须在 0-100 之间")
+		return
+	}
+	if rollout.MaxErrorDelta <= 0 || rollout.MaxErrorDelta > 1 || rollout.MinRenders < 1 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "max_error_delta 须在 (0, 1] 之间，min_renders 须大于 0")
+		return
+	}
+	id, err := h.rollouts.Create(c.Request.Context(), rollout)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, id)
+}
+
+// SetPercent 调整新模板比例
+// PUT /api/template-rollouts/:id/percent
+func (h *TemplateRolloutsHandler) SetPercent(c *gin.Context) {
+	id, ok := parseRolloutID(c)
+	if !ok {
+		return
+	}
+	var req RolloutPercentRequest
+	if err := c.ShouldBindJSON(&req); err != nil || *req.Percent < 0 || *req.Percent > 100 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "percent 须在 0-100 之间")
+		return
+	}
+	if err := h.rollouts.SetPercent(c.Request.Context(), id, *req.Percent); err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, id)
+}
+
+// Complete 完成灰度，站群所有站点切换为新模板
+// POST /api/template-rollouts/:id/complete
+func (h *TemplateRolloutsHandler) Complete(c *gin.Context) {
+	id, ok := parseRolloutID(c)
+	if !ok {
+		return
+	}
+	if err := h.rollouts.Complete(c.Request.Context(), id); err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, id)
+}
+
+// Rollback 回滚灰度，所有请求恢复使用原模板
+// POST /api/template-rollouts/:id/rollback
+func (h *TemplateRolloutsHandler) Rollback(c *gin.Context) {
+	id, ok := parseRolloutID(c)
+	if !ok {
+		return
+	}
+	var req RolloutRollbackRequest
+	_ = c.ShouldBindJSON(&req) // 请求体可选
+	if err := h.rollouts.Rollback(c.Request.Context(), id, strings.TrimSpace(req.Reason)); err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, id)
+}
+
+// respond 返回灰度详情
+func (h *TemplateRolloutsHandler) respond(c *gin.Context, id int64) {
+	rollout, err := h.rollouts.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, rollout)
+}
+
+// fail 按错误类型返回
+func (h *TemplateRolloutsHandler) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, core.ErrRolloutNotFound):
+		core.FailWithMessage(c, core.ErrNotFound, "灰度不存在")
+	case errors.Is(err, core.ErrRolloutNotActive):
+		core.FailWithMessage(c, core.ErrInvalidParam, "灰度已结束")
+	case errors.Is(err, core.ErrRolloutExists):
+		core.FailWithMessage(c, core.ErrInvalidParam, "该站群已有进行中的灰度")
+	case errors.Is(err, core.ErrRolloutTemplateNotFound):
+		core.FailWithMessage(c, core.ErrInvalidParam, "模板不存在或未启用")
+	default:
+		log.Error().Err(err).Msg("Template rollout operation failed")
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+	}
+}
+
+func parseRolloutID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的 ID")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// TemplateSnippetsHandler 模板片段 handler
+type TemplateSnippetsHandler struct {
+	snippets *core.TemplateSnippets
+}
+
+// NewTemplateSnippetsHandler 创建 TemplateSnippetsHandler
+func NewTemplateSnippetsHandler(snippets *core.TemplateSnippets) *TemplateSnippetsHandler {
+	return &TemplateSnippetsHandler{snippets: snippets}
+}
+
+// TemplateSnippetRequest 创建片段请求
+type TemplateSnippetRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Content     string `json:"content" binding:"required"`
+}
+
+// TemplateSnippetUpdateRequest 更新片段请求（名称不可修改）
+type TemplateSnippetUpdateRequest struct {
+	Description string `json:"description"`
+	Content     string `json:"content" binding:"required"`
+}
+
+// List 片段列表（含引用的模板和片段）
+// GET /api/template-snippets
+func (h *TemplateSnippetsHandler) List(c *gin.Context) {
+	items, err := h.snippets.List(c.Request.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list template snippets")
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+	core.Success(c, gin.H{"items": items})
+}
+
+// Get 片段详情
+// GET /api/template-snippets/:name
+func (h *TemplateSnippetsHandler) Get(c *gin.Context) {
+	h.respond(c, c.Param("name"))
+}
+
+// Usage 引用片段的模板（含经由其他片段间接引用）
+// GET /api/template-snippets/:name/usage
+func (h *TemplateSnippetsHandler) Usage(c *gin.Context) {
+	usage, err := h.snippets.Usage(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, gin.H{"templates": usage})
+}
+
+// Create 创建片段
+// POST /api/template-snippets
+func (h *TemplateSnippetsHandler) Create(c *gin.Context) {
+	var req TemplateSnippetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	snippet := &core.TemplateSnippet{
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		Content:     req.Content,
+	}
+	if _, err := h.snippets.Create(c.Request.Context(), snippet); err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, snippet.Name)
+}
+
+// Update 更新片段，引用它的模板立即重新加载
+// PUT /api/template-snippets/:name
+func (h *TemplateSnippetsHandler) Update(c *gin.Context) {
+	var req TemplateSnippetUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	snippet := &core.TemplateSnippet{
+		Name:        c.Param("name"),
+		Description: strings.TrimSpace(req.Description),
+		Content:     req.Content,
+	}
+	if err := h.snippets.Update(c.Request.Context(), snippet); err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.respond(c, snippet.Name)
+}
+
+// Delete 删除片段（仍被引用时拒绝）
+// DELETE /api/template-snippets/:name
+func (h *TemplateSnippetsHandler) Delete(c *gin.Context) {
+	if err := h.snippets.Delete(c.Request.Context(), c.Param("name")); err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, nil)
+}
+
+// respond 返回片段详情
+func (h *TemplateSnippetsHandler) respond(c *gin.Context, name string) {
+	item, err := h.snippets.Get(c.Request.Context(), name)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	core.Success(c, item)
+}
+
+// fail 按错误类型返回
+func (h *TemplateSnippetsHandler) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, core.ErrSnippetNotFound):
+		core.FailWithMessage(c, core.ErrNotFound, "片段不存在")
+	case errors.Is(err, core.ErrSnippetExists):
+		core.FailWithMessage(c, core.ErrInvalidParam, "片段名已存在")
+	case errors.Is(err, core.ErrSnippetInUse):
+		core.FailWithMessage(c, core.ErrInvalidParam, "片段仍被模板或其他片段引用")
+	case errors.Is(err, core.ErrSnippetInvalid):
+		core.FailWithMessage(c, core.ErrInvalidParam, err.Error())
+	default:
+		log.Error().Err(err).Msg("Template snippet operation failed")
+		core.FailWithMessage(c, core.ErrInternalServer, err.Error())
+	}
+}
@@ -18,13 +18,15 @@ import (
 type TemplatesHandler struct {
 	db               *sqlx.DB
 	templateAnalyzer *core.TemplateAnalyzer
+	templateHealth   *core.TemplateHealth
 }
 
 // NewTemplatesHandler 创建 TemplatesHandler
-func NewTemplatesHandler(db *sqlx.DB, templateAnalyzer *core.TemplateAnalyzer) *TemplatesHandler {
+func NewTemplatesHandler(db *sqlx.DB, templateAnalyzer *core.TemplateAnalyzer, templateHealth *core.TemplateHealth) *TemplatesHandler {
 	return &TemplatesHandler{
 		db:               db,
 		templateAnalyzer: templateAnalyzer,
+		templateHealth:   templateHealth,
 	}
 }
 
@@ -37,6 +39,7 @@ type TemplateListItem struct {
 	Description *string   `json:"description" db:"description"`
 	Status      int       `json:"status" db:"status"`
 	Version     int       `json:"version" db:"version"`
+	Degraded    int       `json:"degraded" db:"degraded"`
 	SitesCount  int       `json:"sites_count" db:"sites_count"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
@@ -44,16 +47,19 @@ type TemplateListItem struct {
 
 // TemplateDetail 模板详情（含 content）
 type TemplateDetail struct {
-	ID          int       `json:"id" db:"id"`
-	SiteGroupID int       `json:"site_group_id" db:"site_group_id"`
-	Name        string    `json:"name" db:"name"`
-	DisplayName string    `json:"display_name" db:"display_name"`
-	Description *string   `json:"description" db:"description"`
-	Content     string    `json:"content" db:"content"`
-	Status      int       `json:"status" db:"status"`
-	Version     int       `json:"version" db:"version"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID             int        `json:"id" db:"id"`
+	SiteGroupID    int        `json:"site_group_id" db:"site_group_id"`
+	Name           string     `json:"name" db:"name"`
+	DisplayName    string     `json:"display_name" db:"display_name"`
+	Description    *string    `json:"description" db:"description"`
+	Content        string     `json:"content" db:"content"`
+	Status         int        `json:"status" db:"status"`
+	Version        int        `json:"version" db:"version"`
+	Degraded       int        `json:"degraded" db:"degraded"`
+	DegradedAt     *time.Time `json:"degraded_at" db:"degraded_at"`
+	DegradedReason *string    `json:"degraded_reason" db:"degraded_reason"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // TemplateOption 模板下拉选项
@@ -70,15 +76,25 @@ type TemplateCreateRequest struct {
 	DisplayName string `json:"display_name" binding:"required"`
 	Description string `json:"description"`
 	Content     string `json:"content" binding:"required"`
+	Force       bool   `json:"force"` // 模板引用了不存在的变量/函数时仍然保存
+	TemplateTagsRequest
 }
 
 // TemplateUpdateRequest 更新模板请求
+// 携带 version（或 updated_at）时启用并发编辑检测，版本不一致返回 409
 type TemplateUpdateRequest struct {
+	EditPrecondition
 	SiteGroupID *int    `json:"site_group_id"`
 	DisplayName *string `json:"display_name"`
 	Description *string `json:"description"`
 	Content     *string `json:"content"`
 	Status      *int    `json:"status"`
+	Force       bool    `json:"force"` // 模板引用了不存在的变量/函数时仍然保存
+}
+
+// TemplateCheckRequest 模板数据契约检查请求
+type TemplateCheckRequest struct {
+	Content string `json:"content" binding:"required"`
 }
 
 // TemplateSite 使用模板的站点
@@ -93,53 +109,33 @@ type TemplateSite struct {
 // List 获取模板列表
 // GET /api/templates
 func (h *TemplatesHandler) List(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	status := c.Query("status")
-	siteGroupID := c.Query("site_group_id")
-
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	q, ok := bindListQuery(c, templateListSpec)
+	if !ok {
+		return
 	}
-	offset := (page - 1) * pageSize
 
 	if h.db == nil {
-		core.SuccessPaged(c, []TemplateListItem{}, 0, page, pageSize)
+		respondList(c, q, []TemplateListItem{}, 0, templateListKey)
 		return
 	}
 
 	// 构建查询条件
-	where := "1=1"
-	args := []interface{}{}
-
-	if status != "" {
-		where += " AND status = ?"
-		args = append(args, status)
-	}
-	if siteGroupID != "" {
-		where += " AND site_group_id = ?"
-		args = append(args, siteGroupID)
-	}
+	q.Eq("t.status", c.Query("status")).Eq("t.site_group_id", c.Query("site_group_id"))
 
 	// 获取总数
 	var total int64
-	countQuery := "SELECT COUNT(*) FROM templates WHERE " + where
-	if err := h.db.Get(&total, countQuery, args...); err != nil {
-		log.Warn().Err(err).Msg("Failed to count templates")
+	if q.NeedsTotal() {
+		countQuery, args := q.CountQuery("templates t")
+		if err := h.db.Get(&total, countQuery, args...); err != nil {
+			log.Warn().Err(err).Msg("Failed to count templates")
+		}
 	}
 
 	// 获取列表
-	query := `SELECT t.id, t.site_group_id, t.name, t.display_name, t.description,
-	                 t.status, t.version, t.created_at, t.updated_at,
+	query, args := q.Query(`SELECT t.id, t.site_group_id, t.name, t.display_name, t.description,
+	                 t.status, t.version, t.degraded, t.created_at, t.updated_at,
 	                 (SELECT COUNT(*) FROM sites WHERE sites.template = t.name) as sites_count
-	          FROM templates t
-	          WHERE ` + where + `
-	          ORDER BY t.id DESC
-	          LIMIT ? OFFSET ?`
-	args = append(args, pageSize, offset)
+	          FROM templates t`)
 
 	var items []TemplateListItem
 	if err := h.db.Select(&items, query, args...); err != nil {
@@ -147,9 +143,22 @@ func (h *TemplatesHandler) List(c *gin.Context) {
 		items = []TemplateListItem{}
 	}
 
-	core.SuccessPaged(c, items, total, page, pageSize)
+	respondList(c, q, items, total, templateListKey)
+}
+
+// templateListSpec 模板列表的排序和搜索字段
+var templateListSpec = ListSpec{
+	SortFields: map[string]string{
+		"id": "t.id", "name": "t.name", "status": "t.status", "sites_count": "sites_count",
+		"created_at": "t.created_at", "updated_at": "t.updated_at",
+	},
+	DefaultSort:   "-id",
+	SearchColumns: []string{"t.name", "t.display_name"},
+	KeysetColumn:  "t.id",
 }
 
+func templateListKey(t TemplateListItem) int64 { return int64(t.ID) }
+
 // Options 获取模板下拉选项
 // GET /api/templates/options
 func (h *TemplatesHandler) Options(c *gin.Context) {
@@ -164,13 +173,13 @@ func (h *TemplatesHandler) Options(c *gin.Context) {
 	var err error
 
 	if siteGroupID != "" {
-		err = h.db.Select(&options,
+		err = core.GetQueryCache().Select(c.Request.Context(), h.db, &options,
 			`SELECT id, name, display_name FROM templates
 			 WHERE status = 1 AND (site_group_id = ? OR site_group_id = 1)
 			 ORDER BY site_group_id DESC, name`,
 			siteGroupID)
 	} else {
-		err = h.db.Select(&options,
+		err = core.GetQueryCache().Select(c.Request.Context(), h.db, &options,
 			`SELECT id, name, display_name FROM templates
 			 WHERE status = 1
 			 ORDER BY name`)
@@ -184,6 +193,47 @@ func (h *TemplatesHandler) Options(c *gin.Context) {
 	core.Success(c, gin.H{"options": options})
 }
 
+// Functions 已启用的扩展模板函数和已注册的函数包（模板编辑器提示用）
+// GET /api/templates/functions
+func (h *TemplatesHandler) Functions(c *gin.Context) {
+	core.Success(c, gin.H{
+		"functions": core.ListTemplateFuncs(),
+		"packs":     core.ListTemplateFuncPacks(),
+	})
+}
+
+// Check 检查模板引用的变量和函数是否存在（不保存）
+// POST /api/templates/check
+func (h *TemplatesHandler) Check(c *gin.Context) {
+	var req TemplateCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	issues := core.CheckTemplateContract(req.Content)
+	if issues == nil {
+		issues = []core.TemplateContractIssue{}
+	}
+	core.Success(c, gin.H{"valid": len(issues) == 0, "issues": issues})
+}
+
+// rejectTemplateContract 模板引用了渲染上下文中不存在的变量或未启用的函数时拒绝保存（force=true 时跳过），
+// 返回 true 表示已写入响应
+func rejectTemplateContract(c *gin.Context, content string, force bool) bool {
+	if force {
+		return false
+	}
+	issues := core.CheckTemplateContract(content)
+	if len(issues) == 0 {
+		return false
+	}
+	core.FailWithData(c, core.ErrTemplateInvalid, gin.H{
+		"message": "模板引用了不存在的变量或函数，渲染时会失败；确认保存请传 force=true",
+		"issues":  issues,
+	})
+	return true
+}
+
 // Get 获取模板详情
 // GET /api/templates/:id
 func (h *TemplatesHandler) Get(c *gin.Context) {
@@ -201,7 +251,7 @@ func (h *TemplatesHandler) Get(c *gin.Context) {
 	var template TemplateDetail
 	err = h.db.Get(&template,
 		`SELECT id, site_group_id, name, display_name, description, content,
-		        status, version, created_at, updated_at
+		        status, version, degraded, degraded_at, degraded_reason, created_at, updated_at
 		 FROM templates WHERE id = ?`, id)
 
 	if err != nil {
@@ -270,12 +320,27 @@ func (h *TemplatesHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if msg := validateTemplateTags(&req.TemplateTagsRequest); msg != "" {
+		core.FailWithMessage(c, core.ErrInvalidParam, msg)
+		return
+	}
+	if rejectTemplateContract(c, req.Content, req.Force) {
+		return
+	}
+
 	if h.db == nil {
 		core.FailWithMessage(c, core.ErrInternalServer, "数据库未初始化")
 		return
 	}
 
-	result, err := h.db.Exec(
+	tx, err := h.db.Beginx()
+	if err != nil {
+		core.Success(c, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
 		`INSERT INTO templates (site_group_id, name, display_name, description, content, status, version)
 		 VALUES (?, ?, ?, ?, ?, 1, 1)`,
 		req.SiteGroupID, req.Name, req.DisplayName, req.Description, req.Content)
@@ -292,9 +357,22 @@ func (h *TemplatesHandler) Create(c *gin.Context) {
 
 	id, _ := result.LastInsertId()
 
+	if err := saveTemplateMeta(tx, int(id), &req.TemplateTagsRequest); err != nil {
+		log.Error().Err(err).Msg("Failed to save template tags")
+		core.Success(c, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		core.Success(c, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
 	// 异步分析模板
 	h.analyzeTemplateAsync(int(id), req.Name, req.SiteGroupID, req.Content)
 
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("templates")
+
 	core.Success(c, gin.H{"success": true, "id": id})
 }
 
@@ -312,6 +390,9 @@ func (h *TemplatesHandler) Update(c *gin.Context) {
 		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
 		return
 	}
+	if req.Content != nil && rejectTemplateContract(c, *req.Content, req.Force) {
+		return
+	}
 
 	if h.db == nil {
 		core.FailWithMessage(c, core.ErrInternalServer, "数据库未初始化")
@@ -347,7 +428,6 @@ func (h *TemplatesHandler) Update(c *gin.Context) {
 	if req.Content != nil {
 		updates = append(updates, "content = ?")
 		args = append(args, *req.Content)
-		updates = append(updates, "version = version + 1")
 	}
 	if req.Status != nil {
 		updates = append(updates, "status = ?")
@@ -359,14 +439,23 @@ func (h *TemplatesHandler) Update(c *gin.Context) {
 		return
 	}
 
+	// 每次保存版本号 +1，作为乐观锁依据
+	updates = append(updates, "version = version + 1")
 	args = append(args, id)
-	query := "UPDATE templates SET " + strings.Join(updates, ", ") + " WHERE id = ?"
+	lockWhere, lockArgs := req.whereClause()
+	query := "UPDATE templates SET " + strings.Join(updates, ", ") + " WHERE id = ?" + lockWhere
+	args = append(args, lockArgs...)
 
-	if _, err := h.db.Exec(query, args...); err != nil {
+	result, err := h.db.Exec(query, args...)
+	if err != nil {
 		log.Error().Err(err).Int("id", id).Msg("Failed to update template")
 		core.Success(c, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	if affected, _ := result.RowsAffected(); affected == 0 && req.active() {
+		h.respondConflict(c, id, &req)
+		return
+	}
 
 	// 如果更新了 Content，异步分析模板
 	if req.Content != nil {
@@ -378,7 +467,41 @@ func (h *TemplatesHandler) Update(c *gin.Context) {
 		h.analyzeTemplateAsync(id, templateInfo.Name, siteGroupID, *req.Content)
 	}
 
-	core.Success(c, gin.H{"success": true})
+	var version int
+	h.db.Get(&version, "SELECT version FROM templates WHERE id = ?", id)
+
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("templates")
+
+	core.Success(c, gin.H{"success": true, "version": version})
+}
+
+// respondConflict 版本不一致时返回当前模板及字段差异
+func (h *TemplatesHandler) respondConflict(c *gin.Context, id int, req *TemplateUpdateRequest) {
+	var current TemplateDetail
+	err := h.db.Get(&current,
+		`SELECT id, site_group_id, name, display_name, description, content,
+		        status, version, created_at, updated_at
+		 FROM templates WHERE id = ?`, id)
+	if err != nil {
+		core.Success(c, gin.H{"success": false, "message": "模板不存在"})
+		return
+	}
+
+	var conflicts []FieldConflict
+	addFieldConflict(&conflicts, "site_group_id", req.SiteGroupID, current.SiteGroupID)
+	addFieldConflict(&conflicts, "display_name", req.DisplayName, current.DisplayName)
+	addFieldConflict(&conflicts, "description", req.Description, current.Description)
+	addFieldConflict(&conflicts, "content", req.Content, current.Content)
+	addFieldConflict(&conflicts, "status", req.Status, current.Status)
+
+	failConflict(c, ConflictInfo{
+		ExpectedVersion: req.Version,
+		CurrentVersion:  current.Version,
+		UpdatedAt:       current.UpdatedAt,
+		Current:         current,
+		Conflicts:       conflicts,
+	})
 }
 
 // Delete 删除模板
@@ -420,10 +543,69 @@ func (h *TemplatesHandler) Delete(c *gin.Context) {
 		core.Success(c, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	if _, err := h.db.Exec("DELETE FROM template_tags WHERE template_id = ?", id); err != nil {
+		log.Warn().Err(err).Int("id", id).Msg("Failed to delete template tags")
+	}
+
+	// 失效后台列表查询缓存
+	core.GetQueryCache().Invalidate("templates")
 
 	core.Success(c, gin.H{"success": true})
 }
 
+// Enable 手动恢复因渲染错误预算超限而降级的模板
+// POST /api/templates/:id/enable
+func (h *TemplatesHandler) Enable(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的模板 ID")
+		return
+	}
+
+	if h.db == nil || h.templateHealth == nil {
+		core.FailWithMessage(c, core.ErrInternalServer, "模板健康服务未初始化")
+		return
+	}
+
+	var degraded int
+	if err := h.db.Get(&degraded, "SELECT degraded FROM templates WHERE id = ?", id); err != nil {
+		if err == sql.ErrNoRows {
+			core.FailWithMessage(c, core.ErrNotFound, "模板不存在")
+			return
+		}
+		log.Error().Err(err).Int("id", id).Msg("Failed to get template")
+		core.FailWithCode(c, core.ErrInternalServer)
+		return
+	}
+	if degraded == 0 && !h.templateHealth.IsDegraded(id) {
+		core.Success(c, gin.H{"success": false, "message": "模板未处于降级状态"})
+		return
+	}
+
+	if err := h.templateHealth.Enable(c.Request.Context(), id); err != nil {
+		log.Error().Err(err).Int("id", id).Msg("Failed to re-enable template")
+		core.Success(c, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	core.Success(c, gin.H{"success": true})
+}
+
+// Health 获取模板渲染健康状态（已降级模板和当前窗口统计）
+// GET /api/templates/health
+func (h *TemplatesHandler) Health(c *gin.Context) {
+	if h.templateHealth == nil {
+		core.Success(c, gin.H{"degraded": []core.DegradedTemplate{}, "renders": []core.TemplateRenderStats{}})
+		return
+	}
+
+	core.Success(c, gin.H{
+		"config":   h.templateHealth.GetConfig(),
+		"degraded": h.templateHealth.ListDegraded(),
+		"renders":  h.templateHealth.GetRenderStats(),
+	})
+}
+
 // analyzeTemplateAsync 异步分析模板并更新数据库
 func (h *TemplatesHandler) analyzeTemplateAsync(templateID int, name string, siteGroupID int, content string) {
 	go func() {
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// URLStrategiesHandler 站群 URL 生成策略管理 handler
+type URLStrategiesHandler struct {
+	db        *sqlx.DB
+	manager   *core.URLStrategyManager
+	siteCache *core.SiteCache
+}
+
+// NewURLStrategiesHandler 创建 URLStrategiesHandler
+func NewURLStrategiesHandler(db *sqlx.DB, manager *core.URLStrategyManager, siteCache *core.SiteCache) *URLStrategiesHandler {
+	return &URLStrategiesHandler{db: db, manager: manager, siteCache: siteCache}
+}
+
+// URLStrategyRequest 设置站群 URL 策略请求
+// url_space / slug_max_len 为 0 表示使用全局默认
+type URLStrategyRequest struct {
+	Strategy      string `json:"strategy" binding:"required"`
+	URLSpace      int    `json:"url_space"`
+	SlugSeparator string `json:"slug_separator"`
+	SlugMaxLen    int    `json:"slug_max_len"`
+}
+
+// List 获取所有站群生效的 URL 策略
+// GET /api/url-strategies
+func (h *URLStrategiesHandler) List(c *gin.Context) {
+	var groupIDs []int
+	if err := h.db.Select(&groupIDs, "SELECT id FROM site_groups ORDER BY id"); err != nil {
+		log.Warn().Err(err).Msg("Failed to list site groups for url strategies")
+	}
+	items := make([]*core.SiteGroupURLStrategy, 0, len(groupIDs))
+	for _, id := range groupIDs {
+		items = append(items, h.manager.Strategy(id))
+	}
+	core.Success(c, gin.H{
+		"items":      items,
+		"strategies": core.URLStrategyNames,
+	})
+}
+
+// Update 设置站群 URL 策略
+// PUT /api/url-strategies/:site_group_id
+func (h *URLStrategiesHandler) Update(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("site_group_id"))
+	if err != nil || groupID <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的站群 ID")
+		return
+	}
+	var req URLStrategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	req.Strategy = strings.TrimSpace(req.Strategy)
+	if !core.ValidURLStrategy(req.Strategy) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "未知的 URL 策略: "+req.Strategy)
+		return
+	}
+	if req.URLSpace < 0 || req.URLSpace > 10000000 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "url_space 范围为 0-10000000")
+		return
+	}
+	if len(req.SlugSeparator) > 5 || strings.ContainsAny(req.SlugSeparator, "/?#&%<>\"' ") {
+		core.FailWithMessage(c, core.ErrInvalidParam, "slug 分隔符无效")
+		return
+	}
+
+	if _, err := h.db.Exec(`
+		INSERT INTO url_strategies (site_group_id, strategy, url_space, slug_separator, slug_max_len)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE strategy = VALUES(strategy), url_space = VALUES(url_space),
+			slug_separator = VALUES(slug_separator), slug_max_len = VALUES(slug_max_len)`,
+		groupID, req.Strategy, req.URLSpace, req.SlugSeparator, req.SlugMaxLen); err != nil {
+		log.Error().Err(err).Int("site_group_id", groupID).Msg("Failed to save url strategy")
+		core.FailWithCode(c, core.ErrDBUpdate)
+		return
+	}
+	h.reload(c)
+	core.Success(c, h.manager.Strategy(groupID))
+}
+
+// Delete 删除站群配置，恢复全局默认策略
+// DELETE /api/url-strategies/:site_group_id
+func (h *URLStrategiesHandler) Delete(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("site_group_id"))
+	if err != nil || groupID <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的站群 ID")
+		return
+	}
+	if _, err := h.db.Exec("DELETE FROM url_strategies WHERE site_group_id = ?", groupID); err != nil {
+		log.Error().Err(err).Int("site_group_id", groupID).Msg("Failed to delete url strategy")
+		core.FailWithCode(c, core.ErrDBDelete)
+		return
+	}
+	h.reload(c)
+	core.Success(c, h.manager.Strategy(groupID))
+}
+
+// Preview 预览站点的 URL（与模板、站内链接和 sitemap 生成结果一致）
+// GET /api/url-strategies/preview?domain=example.com&count=20
+func (h *URLStrategiesHandler) Preview(c *gin.Context) {
+	domain := strings.TrimSpace(c.Query("domain"))
+	count, _ := strconv.Atoi(c.DefaultQuery("count", "20"))
+	if count < 1 || count > 200 {
+		count = 20
+	}
+	site, err := h.siteCache.Get(c.Request.Context(), domain)
+	if err != nil || site == nil {
+		core.FailWithMessage(c, core.ErrNotFound, "站点不存在: "+domain)
+		return
+	}
+
+	keywordGroupID := siteKeywordGroupID(site)
+	core.Success(c, gin.H{
+		"strategy": h.manager.Strategy(site.SiteGroupID),
+		"size":     h.manager.Size(site, keywordGroupID),
+		"urls":     h.manager.Range(site, keywordGroupID, 0, count),
+		"sitemap":  h.manager.BaseURL(site.Domain) + "/sitemap.xml",
+	})
+}
+
+// reload 策略变更后重新加载
+func (h *URLStrategiesHandler) reload(c *gin.Context) {
+	if err := h.manager.Reload(c.Request.Context()); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload url strategies")
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// WASMExtensionsHandler WASM 渲染扩展 handler
+type WASMExtensionsHandler struct {
+	extensions *core.WASMExtensions
+}
+
+// NewWASMExtensionsHandler 创建 WASMExtensionsHandler
+func NewWASMExtensionsHandler(extensions *core.WASMExtensions) *WASMExtensionsHandler {
+	return &WASMExtensionsHandler{extensions: extensions}
+}
+
+// WASMBindingRequest 站群扩展绑定请求
+type WASMBindingRequest struct {
+	Module           string `json:"module" binding:"required"` // 模块目录下的文件名，如 brand.wasm
+	TransformTitle   bool   `json:"transform_title"`
+	TransformContent bool   `json:"transform_content"`
+	TemplateFunc     bool   `json:"template_func"`
+	Enabled          *bool  `json:"enabled"` // 默认启用
+}
+
+// List 模块加载状态和站群绑定
+// GET /api/wasm-extensions
+func (h *WASMExtensionsHandler) List(c *gin.Context) {
+	bindings, err := h.extensions.ListBindings(c.Request.Context())
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list wasm extension bindings")
+		bindings = []core.WASMBinding{}
+	}
+	core.Success(c, gin.H{
+		"modules":  h.extensions.Modules(),
+		"bindings": bindings,
+	})
+}
+
+// Save 创建或更新站群绑定
+// PUT /api/wasm-extensions/:site_group_id
+func (h *WASMExtensionsHandler) Save(c *gin.Context) {
+	siteGroupID, err := strconv.Atoi(c.Param("site_group_id"))
+	if err != nil || siteGroupID <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的站群 ID")
+		return
+	}
+	var req WASMBindingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.FailWithMessage(c, core.ErrInvalidParam, "请求参数错误")
+		return
+	}
+	if !core.ValidWASMModuleName(req.Module) {
+		core.FailWithMessage(c, core.ErrInvalidParam, "module 必须是模块目录下的 .wasm 文件名")
+		return
+	}
+	binding := &core.WASMBinding{
+		SiteGroupID:      siteGroupID,
+		Module:           req.Module,
+		TransformTitle:   req.TransformTitle,
+		TransformContent: req.TransformContent,
+		TemplateFunc:     req.TemplateFunc,
+		Enabled:          req.Enabled == nil || *req.Enabled,
+	}
+	if err := h.extensions.SaveBinding(c.Request.Context(), binding); err != nil {
+		log.Error().Err(err).Int("site_group_id", siteGroupID).Msg("Failed to save wasm extension binding")
+		core.FailWithCode(c, core.ErrDBInsert)
+		return
+	}
+	core.Success(c, binding)
+}
+
+// Delete 删除站群绑定
+// DELETE /api/wasm-extensions/:site_group_id
+func (h *WASMExtensionsHandler) Delete(c *gin.Context) {
+	siteGroupID, err := strconv.Atoi(c.Param("site_group_id"))
+	if err != nil || siteGroupID <= 0 {
+		core.FailWithMessage(c, core.ErrInvalidParam, "无效的站群 ID")
+		return
+	}
+	if err := h.extensions.DeleteBinding(c.Request.Context(), siteGroupID); err != nil {
+		log.Error().Err(err).Int("site_group_id", siteGroupID).Msg("Failed to delete wasm extension binding")
+		core.FailWithCode(c, core.ErrDBDelete)
+		return
+	}
+	core.Success(c, nil)
+}
+
+// Reload 立即重新加载模块文件和绑定
+// POST /api/wasm-extensions/reload
+func (h *WASMExtensionsHandler) Reload(c *gin.Context) {
+	h.extensions.ReloadModules(c.Request.Context())
+	if err := h.extensions.ReloadBindings(c.Request.Context()); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload wasm extension bindings")
+		core.FailWithCode(c, core.ErrDBQuery)
+		return
+	}
+	core.Success(c, h.extensions.Modules())
+}
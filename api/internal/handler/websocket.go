@@ -26,6 +26,7 @@ type WebSocketHandler struct {
 	templateFuncs *core.TemplateFuncsManager
 	poolManager   *core.PoolManager
 	systemStats   *core.SystemStatsCollector
+	broadcasters  *broadcasterHub // WebSocket 和 SSE 共享的推送频道
 }
 
 // NewWebSocketHandler 创建 WebSocket 处理器
@@ -34,17 +35,23 @@ func NewWebSocketHandler(templateFuncs *core.TemplateFuncsManager, poolManager *
 		templateFuncs: templateFuncs,
 		poolManager:   poolManager,
 		systemStats:   systemStats,
+		broadcasters:  newBroadcasterHub(),
 	}
 }
 
 // subscribeAndForward 订阅 Redis 频道并转发消息到 WebSocket
 // 这是一个通用的辅助函数，用于简化 Redis Pub/Sub 到 WebSocket 的转发逻辑
-func subscribeAndForward(conn *websocket.Conn, redisClient *redis.Client, channel string) {
+func (h *WebSocketHandler) subscribeAndForward(conn *websocket.Conn, redisClient *redis.Client, channel string) {
+	forwardToWebSocket(conn, h.broadcasters.redisChannel(redisClient, channel))
+}
+
+// forwardToWebSocket 订阅广播器并转发消息到 WebSocket，直到客户端断开或写入失败
+func forwardToWebSocket(conn *websocket.Conn, b *broadcaster) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	pubsub := redisClient.Subscribe(ctx, channel)
-	defer pubsub.Close()
+	replay, events, unsubscribe := b.subscribe(0, false)
+	defer unsubscribe()
 
 	// 监听客户端断开
 	go func() {
@@ -57,15 +64,17 @@ func subscribeAndForward(conn *websocket.Conn, redisClient *redis.Client, channe
 		}
 	}()
 
+	for _, event := range replay {
+		if err := conn.WriteMessage(websocket.TextMessage, event.Data); err != nil {
+			return
+		}
+	}
+
 	// 接收并转发消息
-	ch := pubsub.Channel()
 	for {
 		select {
-		case msg := <-ch:
-			if msg == nil {
-				return
-			}
-			if err := conn.WriteMessage(websocket.TextMessage, []byte(msg.Payload)); err != nil {
+		case event := <-events:
+			if err := conn.WriteMessage(websocket.TextMessage, event.Data); err != nil {
 				return
 			}
 		case <-ctx.Done():
@@ -90,7 +99,7 @@ func (h *WebSocketHandler) SystemLogs(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	subscribeAndForward(conn, redisClient, "system:logs")
+	h.subscribeAndForward(conn, redisClient, "system:logs")
 }
 
 // SpiderStats 爬虫统计实时推送
@@ -111,7 +120,7 @@ func (h *WebSocketHandler) SpiderStats(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	subscribeAndForward(conn, redisClient, "spider:stats:project_"+projectID)
+	h.subscribeAndForward(conn, redisClient, "spider:stats:project_"+projectID)
 }
 
 // WorkerRestart Worker 重启 WebSocket
@@ -358,7 +367,7 @@ func (h *WebSocketHandler) ProcessorLogs(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	subscribeAndForward(conn, redisClient, "processor:logs")
+	h.subscribeAndForward(conn, redisClient, "processor:logs")
 }
 
 // SpiderLogs 爬虫日志 WebSocket
@@ -383,7 +392,7 @@ func (h *WebSocketHandler) SpiderLogs(c *gin.Context) {
 
 	// 订阅 Redis 日志频道（格式：spider:logs:test_1 或 spider:logs:project_1）
 	channel := "spider:logs:" + logType + "_" + projectID
-	subscribeAndForward(conn, redisClient, channel)
+	h.subscribeAndForward(conn, redisClient, channel)
 }
 
 // ProcessorStatus 数据处理状态实时推送
@@ -403,41 +412,18 @@ func (h *WebSocketHandler) ProcessorStatus(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// 监听客户端断开
-	go func() {
-		for {
-			_, _, err := conn.ReadMessage()
-			if err != nil {
-				cancel()
-				return
-			}
-		}
-	}()
-
-	// 每秒推送一次状态
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	// 立即发送一次初始状态
-	h.sendProcessorStatus(conn, redisClient)
+	forwardToWebSocket(conn, h.processorStatusBroadcaster(redisClient))
+}
 
-	for {
-		select {
-		case <-ticker.C:
-			if err := h.sendProcessorStatus(conn, redisClient); err != nil {
-				return
-			}
-		case <-ctx.Done():
-			return
-		}
-	}
+// processorStatusBroadcaster 每秒采集一次处理器状态的共享广播器
+func (h *WebSocketHandler) processorStatusBroadcaster(redisClient *redis.Client) *broadcaster {
+	return h.broadcasters.ticker("processor-status", time.Second, func() ([]byte, error) {
+		return processorStatusMessage(redisClient)
+	})
 }
 
-// sendProcessorStatus 发送处理器状态消息
-func (h *WebSocketHandler) sendProcessorStatus(conn *websocket.Conn, redisClient *redis.Client) error {
+// processorStatusMessage 构建处理器状态消息
+func processorStatusMessage(redisClient *redis.Client) ([]byte, error) {
 	ctx := context.Background()
 
 	// 获取队列长度
@@ -466,13 +452,7 @@ func (h *WebSocketHandler) sendProcessorStatus(conn *websocket.Conn, redisClient
 		msg["last_error"] = lastErr
 	}
 
-	// 序列化并发送
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return err
-	}
-
-	return conn.WriteMessage(websocket.TextMessage, data)
+	return json.Marshal(msg)
 }
 
 // PoolStatus 池状态实时推送
@@ -485,41 +465,16 @@ func (h *WebSocketHandler) PoolStatus(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// 监听客户端断开
-	go func() {
-		for {
-			_, _, err := conn.ReadMessage()
-			if err != nil {
-				cancel()
-				return
-			}
-		}
-	}()
-
-	// 每秒推送一次状态
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	// 立即发送一次初始状态
-	h.sendPoolStatus(conn)
+	forwardToWebSocket(conn, h.poolStatusBroadcaster())
+}
 
-	for {
-		select {
-		case <-ticker.C:
-			if err := h.sendPoolStatus(conn); err != nil {
-				return
-			}
-		case <-ctx.Done():
-			return
-		}
-	}
+// poolStatusBroadcaster 每秒采集一次池状态的共享广播器
+func (h *WebSocketHandler) poolStatusBroadcaster() *broadcaster {
+	return h.broadcasters.ticker("pool-status", time.Second, h.poolStatusMessage)
 }
 
-// sendPoolStatus 发送池状态消息
-func (h *WebSocketHandler) sendPoolStatus(conn *websocket.Conn) error {
+// poolStatusMessage 构建池状态消息
+func (h *WebSocketHandler) poolStatusMessage() ([]byte, error) {
 	// 构建状态消息
 	msg := map[string]interface{}{
 		"type":      "pool_status",
@@ -540,13 +495,7 @@ func (h *WebSocketHandler) sendPoolStatus(conn *websocket.Conn) error {
 		msg["data_pools"] = []interface{}{}
 	}
 
-	// 序列化并发送
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return err
-	}
-
-	return conn.WriteMessage(websocket.TextMessage, data)
+	return json.Marshal(msg)
 }
 
 // SystemStats 系统资源实时推送
@@ -558,47 +507,23 @@ func (h *WebSocketHandler) SystemStats(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// 监听客户端断开
-	go func() {
-		for {
-			_, _, err := conn.ReadMessage()
-			if err != nil {
-				cancel()
-				return
-			}
-		}
-	}()
-
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	// 立即发送一次初始状态
-	h.sendSystemStats(conn)
+	forwardToWebSocket(conn, h.systemStatsBroadcaster())
+}
 
-	for {
-		select {
-		case <-ticker.C:
-			if err := h.sendSystemStats(conn); err != nil {
-				return
-			}
-		case <-ctx.Done():
-			return
-		}
-	}
+// systemStatsBroadcaster 每秒采集一次系统资源的共享广播器
+func (h *WebSocketHandler) systemStatsBroadcaster() *broadcaster {
+	return h.broadcasters.ticker("system-stats", time.Second, h.systemStatsMessage)
 }
 
-// sendSystemStats 发送系统统计消息
-func (h *WebSocketHandler) sendSystemStats(conn *websocket.Conn) error {
+// systemStatsMessage 构建系统统计消息（未启用采集时返回 nil，不推送）
+func (h *WebSocketHandler) systemStatsMessage() ([]byte, error) {
 	if h.systemStats == nil {
-		return nil
+		return nil, nil
 	}
 
 	stats, err := h.systemStats.Collect()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	msg := map[string]interface{}{
@@ -611,10 +536,5 @@ func (h *WebSocketHandler) sendSystemStats(conn *websocket.Conn) error {
 		"disks":     stats.Disks,
 	}
 
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return err
-	}
-
-	return conn.WriteMessage(websocket.TextMessage, data)
+	return json.Marshal(msg)
 }
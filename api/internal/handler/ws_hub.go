@@ -0,0 +1,76 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	core "seo-generator/api/internal/service"
+)
+
+// IssueWSTicket 签发实时推送连接票据（一次性，有效期见 websocket.ticket_ttl_seconds）
+// 连接 /ws/* 或 /sse/* 时通过 ?ticket= 携带；SSE 断线重连需重新获取票据，并用 last_event_id 续传
+// POST /api/auth/ws-ticket
+func IssueWSTicket(hub *core.WSHub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if hub == nil {
+			core.FailWithMessage(c, core.ErrInternalServer, "实时推送连接中心未初始化")
+			return
+		}
+		adminID, _, ok := currentSession(c)
+		if !ok {
+			core.FailWithCode(c, core.ErrUnauthorized)
+			return
+		}
+		ticket, err := hub.IssueTicket(c.Request.Context(), adminID, currentUsername(c))
+		if err != nil {
+			log.Error().Err(err).Int("admin_id", adminID).Msg("Failed to issue websocket ticket")
+			core.FailWithMessage(c, core.ErrInternalServer, "签发连接票据失败")
+			return
+		}
+		core.Success(c, ticket)
+	}
+}
+
+// WSHubMiddleware 实时推送连接的票据校验和连接数限制
+// 携带 ticket 时总是校验（require_ticket 默认开启，必须携带）；连接数按票据用户计，
+// 未携带票据时按客户端 IP 计（只信任受信代理的转发头）。名额在 handler 返回（连接关闭）后释放
+func WSHubMiddleware(hub *core.WSHub, allowlist *core.IPAllowlist) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if hub == nil {
+			c.Next()
+			return
+		}
+
+		user := "ip:" + trustedClientIP(c, allowlist)
+		if token := c.Query("ticket"); token != "" || hub.RequireTicket() {
+			ticket, err := hub.RedeemTicket(c.Request.Context(), token)
+			if err != nil {
+				hub.Reject("invalid_ticket")
+				if err != core.ErrWSTicketInvalid {
+					log.Warn().Err(err).Msg("Failed to redeem websocket ticket")
+				}
+				core.AbortWithMessage(c, core.ErrUnauthorized, "连接票据无效或已过期")
+				return
+			}
+			user = ticket.Username
+			if user == "" {
+				user = "admin:" + strconv.Itoa(ticket.AdminID)
+			}
+		}
+
+		release, err := hub.Acquire(user, c.FullPath())
+		if err != nil {
+			msg := "实时推送连接数已达上限"
+			if err == core.ErrWSUserLimit {
+				msg = "当前用户的实时推送连接数已达上限"
+			}
+			core.AbortWithMessage(c, core.ErrTooManyRequests, msg)
+			return
+		}
+		defer release()
+
+		c.Next()
+	}
+}
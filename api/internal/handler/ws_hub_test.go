@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	core "seo-generator/api/internal/service"
+	"seo-generator/api/pkg/config"
+)
+
+// newWSHubTestRouter 挂载 WSHubMiddleware 的测试路由
+func newWSHubTestRouter(hub *core.WSHub, allowlist *core.IPAllowlist) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(WSHubMiddleware(hub, allowlist))
+	r.GET("/ws/pool-status", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestWSHubMiddleware_Ticket(t *testing.T) {
+	hub := core.NewWSHub(nil, config.WebSocketConfig{RequireTicket: true})
+	r := newWSHubTestRouter(hub, nil)
+
+	ticket, err := hub.IssueTicket(context.Background(), 1, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		url  string
+		want int
+	}{
+		{"missing ticket", "/ws/pool-status", http.StatusUnauthorized},
+		{"invalid ticket", "/ws/pool-status?ticket=bogus", http.StatusUnauthorized},
+		{"valid ticket", "/ws/pool-status?ticket=" + ticket.Ticket, http.StatusOK},
+		{"ticket reused", "/ws/pool-status?ticket=" + ticket.Ticket, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+// TestWSHubMiddleware_PerIPLimit 未启用票据时按客户端 IP 限制连接数，伪造 X-Forwarded-For 无法绕过
+func TestWSHubMiddleware_PerIPLimit(t *testing.T) {
+	hub := core.NewWSHub(nil, config.WebSocketConfig{MaxPerUser: 1})
+	allowlist := core.NewIPAllowlist(nil, config.IPAllowlistConfig{TrustedProxies: []string{"10.0.0.1/32"}})
+	r := newWSHubTestRouter(hub, allowlist)
+
+	// 192.0.2.1 已占满名额
+	release, err := hub.Acquire("ip:192.0.2.1", "/ws/pool-status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    int
+	}{
+		{"same ip", "192.0.2.1:1234", nil, http.StatusTooManyRequests},
+		{"spoofed forwarded for", "192.0.2.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.7"}, http.StatusTooManyRequests},
+		{"spoofed real ip", "192.0.2.1:1234", map[string]string{"X-Real-IP": "198.51.100.7"}, http.StatusTooManyRequests},
+		{"other ip", "192.0.2.2:1234", nil, http.StatusOK},
+		{"via trusted proxy", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "192.0.2.1"}, http.StatusTooManyRequests},
+		{"other ip via trusted proxy", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "192.0.2.3"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/ws/pool-status", nil)
+			req.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
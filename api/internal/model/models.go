@@ -150,6 +150,17 @@ type Site struct {
 	BaiduToken sql.NullString `db:"baidu_token"  json:"baidu_token"`
 	Analytics  sql.NullString `db:"analytics"    json:"analytics"`
 
+	// Cache quota overrides (NULL = global default, 0 = unlimited)
+	CacheMaxSizeMB  sql.NullInt64 `db:"cache_max_size_mb" json:"cache_max_size_mb"`
+	CacheMaxEntries sql.NullInt64 `db:"cache_max_entries" json:"cache_max_entries"`
+
+	// Non-spider visitor handling (NULL = global spider_detector config)
+	HumanPolicy    sql.NullString `db:"human_policy"     json:"human_policy"`
+	HumanTargetURL sql.NullString `db:"human_target_url" json:"human_target_url"`
+
+	// Metadata
+	Version int `db:"version" json:"version"`
+
 	// Timestamps
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
@@ -174,6 +185,17 @@ type Template struct {
 	Status  int `db:"status"  json:"status"`
 	Version int `db:"version" json:"version"`
 
+	// Render health (error budget)
+	Degraded       int            `db:"degraded"        json:"degraded"`
+	DegradedAt     sql.NullTime   `db:"degraded_at"     json:"degraded_at"`
+	DegradedReason sql.NullString `db:"degraded_reason" json:"degraded_reason"`
+
+	// Library metadata
+	Category    sql.NullString `db:"category"     json:"category"`
+	Engine      sql.NullString `db:"engine"       json:"engine"`
+	RenderCount int64          `db:"render_count" json:"render_count"`
+	LastUsedAt  sql.NullTime   `db:"last_used_at" json:"last_used_at"`
+
 	// Timestamps
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
@@ -27,8 +27,22 @@ type SpiderProject struct {
 	LastError       *string         `db:"last_error" json:"last_error"`
 	TotalRuns       int             `db:"total_runs" json:"total_runs"`
 	TotalItems      int             `db:"total_items" json:"total_items"`
+	FileVersionKeep int             `db:"file_versions_keep" json:"file_versions_keep"`
+	RunStartedAt    *time.Time      `db:"run_started_at" json:"run_started_at"`
 	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
+
+	SpiderResourceLimits
+}
+
+// SpiderResourceLimits 项目资源限制（0 表示不限制），随 run 命令下发给 Worker
+type SpiderResourceLimits struct {
+	MaxRuntime    int `db:"max_runtime" json:"max_runtime"`         // 最长运行时间（秒），超时由 API 发送 stop 命令强制停止
+	MaxMemoryMB   int `db:"max_memory_mb" json:"max_memory_mb"`     // 最大内存（MB）
+	MaxRequests   int `db:"max_requests" json:"max_requests"`       // 单次运行最大请求数
+	DomainDelayMs int `db:"domain_delay_ms" json:"domain_delay_ms"` // 同一域名两次请求的最小间隔（毫秒）
+	// RobotsMode robots.txt 合规模式：off / warn / enforce，非 off 时 Worker 抓取前调用检查接口
+	RobotsMode string `db:"robots_mode" json:"robots_mode"`
 }
 
 // SpiderProjectFile 项目文件
@@ -56,6 +70,15 @@ type SpiderProjectCreate struct {
 	Schedule      *string                `json:"schedule"`
 	Enabled       int                    `json:"enabled"`
 	Files         []SpiderFileCreate     `json:"files"`
+	// FileVersionKeep 每个文件保留的历史版本数，nil 时为 50，0 不限制
+	FileVersionKeep *int `json:"file_versions_keep"`
+	// 资源限制，nil 时为 0（不限制）
+	MaxRuntime    *int `json:"max_runtime"`
+	MaxMemoryMB   *int `json:"max_memory_mb"`
+	MaxRequests   *int `json:"max_requests"`
+	DomainDelayMs *int `json:"domain_delay_ms"`
+	// RobotsMode robots.txt 合规模式：off / warn / enforce
+	RobotsMode *string `json:"robots_mode"`
 }
 
 // SpiderProjectUpdate 更新请求
@@ -71,6 +94,15 @@ type SpiderProjectUpdate struct {
 	OutputGroupID *int                   `json:"output_group_id"`
 	Schedule      *string                `json:"schedule"`
 	Enabled       *int                   `json:"enabled"`
+	// FileVersionKeep 每个文件保留的历史版本数，0 不限制
+	FileVersionKeep *int `json:"file_versions_keep"`
+	// 资源限制，0 不限制
+	MaxRuntime    *int `json:"max_runtime"`
+	MaxMemoryMB   *int `json:"max_memory_mb"`
+	MaxRequests   *int `json:"max_requests"`
+	DomainDelayMs *int `json:"domain_delay_ms"`
+	// RobotsMode robots.txt 合规模式：off / warn / enforce
+	RobotsMode *string `json:"robots_mode"`
 }
 
 // SpiderFileCreate 创建文件请求
@@ -82,6 +114,19 @@ type SpiderFileCreate struct {
 // SpiderFileUpdate 更新文件请求
 type SpiderFileUpdate struct {
 	Content string `json:"content" binding:"required"`
+	Check   string `json:"check" binding:"omitempty,oneof=off warn block"` // .py 语法检查模式，为空时使用 python_check.mode
+}
+
+// SpiderFileVersion 文件历史版本（每次保存记录一条）
+type SpiderFileVersion struct {
+	ID          int64     `db:"id" json:"id"`
+	ProjectID   int       `db:"project_id" json:"project_id"`
+	Path        string    `db:"path" json:"path"`
+	Content     string    `db:"content" json:"content,omitempty"`
+	Size        int       `db:"size" json:"size"`
+	ContentHash string    `db:"content_hash" json:"content_hash"`
+	Source      string    `db:"source" json:"source"` // create / save / restore
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
 }
 
 // SpiderCommand Redis 命令结构
@@ -90,6 +135,10 @@ type SpiderCommand struct {
 	ProjectID int    `json:"project_id"`
 	MaxItems  int    `json:"max_items,omitempty"`
 	Timestamp int64  `json:"timestamp"`
+	// Limits 资源限制，仅 run 命令携带
+	Limits *SpiderResourceLimits `json:"limits,omitempty"`
+	// Reason 停止原因，API 因超出限制强制停止时设置
+	Reason string `json:"reason,omitempty"`
 }
 
 // SpiderFailedRequest 失败请求
@@ -73,7 +73,7 @@ func (r *articleRepo) List(ctx context.Context, filter ArticleFilter) ([]*models
 	// Count query
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM original_articles %s", whereClause)
 	var total int64
-	err := r.db.GetContext(ctx, &total, countQuery, args...)
+	err := Reader(ctx, r.db).GetContext(ctx, &total, countQuery, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("count articles: %w", err)
 	}
@@ -96,7 +96,7 @@ func (r *articleRepo) List(ctx context.Context, filter ArticleFilter) ([]*models
 	paginationArgs := append(args, limit, offset)
 
 	var articles []*models.OriginalArticle
-	err = r.db.SelectContext(ctx, &articles, query, paginationArgs...)
+	err = Reader(ctx, r.db).SelectContext(ctx, &articles, query, paginationArgs...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("list articles: %w", err)
 	}
@@ -204,7 +204,7 @@ func (r *articleRepo) CountByGroupID(ctx context.Context, groupID int) (int64, e
 	query := `SELECT COUNT(*) FROM original_articles WHERE group_id = ?`
 
 	var count int64
-	if err := r.db.GetContext(ctx, &count, query, groupID); err != nil {
+	if err := Reader(ctx, r.db).GetContext(ctx, &count, query, groupID); err != nil {
 		return 0, fmt.Errorf("count articles by group: %w", err)
 	}
 
@@ -3,11 +3,12 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
 	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	"github.com/rs/zerolog/log"
 
@@ -28,9 +29,9 @@ func Init(cfg *config.DatabaseConfig) error {
 	)
 
 	var err error
-	db, err = sqlx.Connect("mysql", dsn)
+	db, err = openDB(dsn)
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return err
 	}
 
 	// Configure connection pool for high concurrency
@@ -63,9 +64,28 @@ func Init(cfg *config.DatabaseConfig) error {
 		Int("pool_size", cfg.PoolSize).
 		Msg("Database connection established")
 
+	initReplicas(cfg, maxConns, idleConns)
+
 	return nil
 }
 
+// openDB 按 DSN 创建连接池（不检查连通性），设置了钩子时包装驱动连接
+func openDB(dsn string) (*sqlx.DB, error) {
+	if queryHook == nil {
+		return sqlx.Open("mysql", dsn)
+	}
+	// 设置了钩子（调试模式故障注入）时包装驱动连接，连通性由调用方 Ping 检查
+	mysqlCfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database dsn: %w", err)
+	}
+	connector, err := mysql.NewConnector(mysqlCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database connector: %w", err)
+	}
+	return sqlx.NewDb(sql.OpenDB(&hookConnector{base: connector, hook: queryHook}), "mysql"), nil
+}
+
 // GetDB returns the database connection
 func GetDB() *sqlx.DB {
 	return db
@@ -73,6 +93,7 @@ func GetDB() *sqlx.DB {
 
 // Close closes the database connection
 func Close() error {
+	closeReplicas()
 	if db != nil {
 		return db.Close()
 	}
@@ -81,7 +81,7 @@ func (r *imageRepo) List(ctx context.Context, filter ImageFilter) ([]*models.Ima
 	// Count total
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM images %s", whereClause)
 	var total int64
-	if err := r.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+	if err := Reader(ctx, r.db).GetContext(ctx, &total, countQuery, args...); err != nil {
 		return nil, 0, fmt.Errorf("count images: %w", err)
 	}
 
@@ -92,7 +92,7 @@ func (r *imageRepo) List(ctx context.Context, filter ImageFilter) ([]*models.Ima
 	}
 
 	var images []*models.Image
-	if err := r.db.SelectContext(ctx, &images, query, args...); err != nil {
+	if err := Reader(ctx, r.db).SelectContext(ctx, &images, query, args...); err != nil {
 		return nil, 0, fmt.Errorf("list images: %w", err)
 	}
 
@@ -179,7 +179,7 @@ func (r *imageRepo) Count(ctx context.Context, filter ImageFilter) (int64, error
 	query := fmt.Sprintf("SELECT COUNT(*) FROM images %s", whereClause)
 
 	var count int64
-	if err := r.db.GetContext(ctx, &count, query, args...); err != nil {
+	if err := Reader(ctx, r.db).GetContext(ctx, &count, query, args...); err != nil {
 		return 0, fmt.Errorf("count images: %w", err)
 	}
 
@@ -190,7 +190,7 @@ func (r *imageRepo) CountByGroupID(ctx context.Context, groupID int) (int64, err
 	query := `SELECT COUNT(*) FROM images WHERE group_id = ? AND status = 1`
 
 	var count int64
-	if err := r.db.GetContext(ctx, &count, query, groupID); err != nil {
+	if err := Reader(ctx, r.db).GetContext(ctx, &count, query, groupID); err != nil {
 		return 0, fmt.Errorf("count images by group: %w", err)
 	}
 
@@ -78,7 +78,7 @@ func (r *keywordRepo) List(ctx context.Context, filter KeywordFilter) ([]*models
 	// Count total
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM keywords %s", whereClause)
 	var total int64
-	if err := r.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+	if err := Reader(ctx, r.db).GetContext(ctx, &total, countQuery, args...); err != nil {
 		return nil, 0, fmt.Errorf("count keywords: %w", err)
 	}
 
@@ -89,7 +89,7 @@ func (r *keywordRepo) List(ctx context.Context, filter KeywordFilter) ([]*models
 	}
 
 	var keywords []*models.Keyword
-	if err := r.db.SelectContext(ctx, &keywords, query, args...); err != nil {
+	if err := Reader(ctx, r.db).SelectContext(ctx, &keywords, query, args...); err != nil {
 		return nil, 0, fmt.Errorf("list keywords: %w", err)
 	}
 
@@ -197,7 +197,7 @@ func (r *keywordRepo) Count(ctx context.Context, filter KeywordFilter) (int64, e
 	query := fmt.Sprintf("SELECT COUNT(*) FROM keywords %s", whereClause)
 
 	var count int64
-	if err := r.db.GetContext(ctx, &count, query, args...); err != nil {
+	if err := Reader(ctx, r.db).GetContext(ctx, &count, query, args...); err != nil {
 		return 0, fmt.Errorf("count keywords: %w", err)
 	}
 
@@ -208,7 +208,7 @@ func (r *keywordRepo) CountByGroupID(ctx context.Context, groupID int) (int64, e
 	query := `SELECT COUNT(*) FROM keywords WHERE group_id = ? AND status = 1`
 
 	var count int64
-	if err := r.db.GetContext(ctx, &count, query, groupID); err != nil {
+	if err := Reader(ctx, r.db).GetContext(ctx, &count, query, groupID); err != nil {
 		return 0, fmt.Errorf("count keywords by group: %w", err)
 	}
 
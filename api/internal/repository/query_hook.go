@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// QueryHook 每次执行 SQL（查询、执行、预编译、开启事务）前调用，返回错误时不执行该 SQL
+// 用于调试模式下的故障注入（延迟、错误）
+type QueryHook func(ctx context.Context) error
+
+var queryHook QueryHook
+
+// SetQueryHook 设置 SQL 执行前的钩子，需在 Init 之前调用
+func SetQueryHook(hook QueryHook) {
+	queryHook = hook
+}
+
+// hookConnector 包装 MySQL connector，返回的连接在执行 SQL 前调用钩子
+type hookConnector struct {
+	base driver.Connector
+	hook QueryHook
+}
+
+func (c *hookConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &hookConn{Conn: conn, hook: c.hook}, nil
+}
+
+func (c *hookConnector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// hookConn 包装 MySQL 连接；除执行 SQL 前调用钩子外，其余接口原样转发（含参数转换和连接校验）
+type hookConn struct {
+	driver.Conn
+	hook QueryHook
+}
+
+func (c *hookConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.hook(ctx); err != nil {
+		return nil, err
+	}
+	return q.QueryContext(ctx, query, args)
+}
+
+func (c *hookConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.hook(ctx); err != nil {
+		return nil, err
+	}
+	return e.ExecContext(ctx, query, args)
+}
+
+func (c *hookConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := c.hook(ctx); err != nil {
+		return nil, err
+	}
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *hookConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := c.hook(ctx); err != nil {
+		return nil, err
+	}
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() // 驱动不支持 BeginTx 时的回退
+}
+
+func (c *hookConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *hookConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *hookConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *hookConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// replicaPool 一个只读副本连接池
+type replicaPool struct {
+	name    string // host:port/db（不含凭证，用于日志和统计）
+	db      *sqlx.DB
+	healthy atomic.Bool
+	lag     atomic.Int64 // 复制延迟（秒），-1 表示未知
+	lastErr atomic.Value // string
+}
+
+// PoolStats 连接池统计
+type PoolStats struct {
+	Name       string      `json:"name"`
+	Role       string      `json:"role"` // primary / replica
+	Healthy    bool        `json:"healthy"`
+	LagSeconds int64       `json:"lag_seconds"` // 仅副本，-1 表示未知
+	LastError  string      `json:"last_error,omitempty"`
+	Stats      sql.DBStats `json:"stats"`
+}
+
+type primaryKey struct{}
+
+var (
+	replicas      []*replicaPool
+	replicaNext   atomic.Uint64
+	replicaMaxLag int64
+	replicaStop   chan struct{}
+	replicaWG     sync.WaitGroup
+
+	replicaReads atomic.Int64
+	primaryReads atomic.Int64 // 本应走副本但回退主库的读
+)
+
+// initReplicas 打开配置的只读副本并启动健康和延迟检查（连接失败的副本标记为不可用，不影响启动）
+func initReplicas(cfg *config.DatabaseConfig, maxConns, idleConns int) {
+	if len(cfg.Replicas) == 0 {
+		return
+	}
+	replicaMaxLag = int64(cfg.ReplicaMaxLagSeconds)
+	if replicaMaxLag <= 0 {
+		replicaMaxLag = 5
+	}
+	interval := time.Duration(cfg.ReplicaCheckSeconds) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	for _, raw := range cfg.Replicas {
+		dsn, name, err := replicaDSN(raw, cfg.Charset)
+		if err != nil {
+			log.Warn().Err(err).Msg("Invalid replica dsn, skipped")
+			continue
+		}
+		rdb, err := openDB(dsn)
+		if err != nil {
+			log.Warn().Err(err).Str("replica", name).Msg("Failed to open replica, skipped")
+			continue
+		}
+		rdb.SetMaxOpenConns(maxConns)
+		rdb.SetMaxIdleConns(idleConns)
+		rdb.SetConnMaxLifetime(5 * time.Minute)
+		rdb.SetConnMaxIdleTime(2 * time.Minute)
+
+		p := &replicaPool{name: name, db: rdb}
+		p.lag.Store(-1)
+		p.lastErr.Store("")
+		replicas = append(replicas, p)
+	}
+	if len(replicas) == 0 {
+		return
+	}
+
+	for _, p := range replicas {
+		p.check()
+	}
+	replicaStop = make(chan struct{})
+	replicaWG.Add(1)
+	go monitorReplicas(interval)
+
+	log.Info().
+		Int("replicas", len(replicas)).
+		Int64("max_lag_seconds", replicaMaxLag).
+		Msg("Database read replicas configured")
+}
+
+// replicaDSN 补全副本 DSN 的 charset/parseTime/loc 参数，返回 DSN 和不含凭证的名称
+func replicaDSN(raw, charset string) (string, string, error) {
+	c, err := mysql.ParseDSN(strings.TrimSpace(raw))
+	if err != nil {
+		return "", "", err
+	}
+	if c.Params == nil {
+		c.Params = map[string]string{}
+	}
+	if _, ok := c.Params["charset"]; !ok && charset != "" {
+		c.Params["charset"] = charset
+	}
+	c.ParseTime = true
+	if !strings.Contains(raw, "loc=") {
+		c.Loc = time.Local // 与主库 DSN 一致
+	}
+	return c.FormatDSN(), c.Addr + "/" + c.DBName, nil
+}
+
+func monitorReplicas(interval time.Duration) {
+	defer replicaWG.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, p := range replicas {
+				p.check()
+			}
+		case <-replicaStop:
+			return
+		}
+	}
+}
+
+// check 读取复制延迟，IO/SQL 线程停止（延迟为 NULL）、查询失败或延迟超限时标记为不可用
+func (p *replicaPool) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lag, err := p.replicationLag(ctx)
+	if err != nil {
+		p.lag.Store(-1)
+		p.lastErr.Store(err.Error())
+		p.setHealthy(false, err.Error())
+		return
+	}
+	p.lag.Store(lag)
+	if lag < 0 {
+		p.lastErr.Store("replication stopped")
+		p.setHealthy(false, "replication stopped")
+		return
+	}
+	p.lastErr.Store("")
+	if lag > replicaMaxLag {
+		p.setHealthy(false, "replication lag too high")
+		return
+	}
+	p.setHealthy(true, "")
+}
+
+func (p *replicaPool) setHealthy(healthy bool, reason string) {
+	if p.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		log.Info().Str("replica", p.name).Int64("lag", p.lag.Load()).Msg("Read replica back in rotation")
+	} else {
+		log.Warn().Str("replica", p.name).Int64("lag", p.lag.Load()).Str("reason", reason).Msg("Read replica out of rotation")
+	}
+}
+
+// replicationLag 复制延迟（秒），复制线程停止时返回 -1；非副本（无复制状态）视为 0
+// MySQL 8.0.22+ 使用 SHOW REPLICA STATUS，旧版本回退 SHOW SLAVE STATUS
+func (p *replicaPool) replicationLag(ctx context.Context) (int64, error) {
+	rows, err := p.db.QueryxContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		rows, err = p.db.QueryxContext(ctx, "SHOW SLAVE STATUS")
+		if err != nil {
+			return 0, err
+		}
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+	row := map[string]interface{}{}
+	if err := rows.MapScan(row); err != nil {
+		return 0, err
+	}
+	for _, col := range []string{"Seconds_Behind_Source", "Seconds_Behind_Master"} {
+		v, ok := row[col]
+		if !ok {
+			continue
+		}
+		if v == nil {
+			return -1, nil
+		}
+		var lag int64
+		switch n := v.(type) {
+		case []byte:
+			lag = parseLag(string(n))
+		case int64:
+			lag = n
+		default:
+			lag = -1
+		}
+		return lag, nil
+	}
+	return 0, nil
+}
+
+func parseLag(s string) int64 {
+	var n int64
+	for _, ch := range s {
+		if ch < '0' || ch > '9' {
+			return -1
+		}
+		n = n*10 + int64(ch-'0')
+	}
+	return n
+}
+
+func closeReplicas() {
+	if replicaStop != nil {
+		close(replicaStop)
+		replicaWG.Wait()
+		replicaStop = nil
+	}
+	for _, p := range replicas {
+		p.db.Close()
+	}
+	replicas = nil
+}
+
+// WithPrimary 标记 ctx 下的读查询必须走主库（写后立即读、扣减前校验等不能容忍延迟的场景）
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// Reader 只读查询选择连接池：轮询可用副本，没有可用副本或 ctx 要求主库时返回主库
+// primary 不是全局主库（如测试或独立连接）时原样返回
+func Reader(ctx context.Context, primary *sqlx.DB) *sqlx.DB {
+	if len(replicas) == 0 || primary != db {
+		return primary
+	}
+	if ctx != nil {
+		if v, _ := ctx.Value(primaryKey{}).(bool); v {
+			return primary
+		}
+	}
+	n := uint64(len(replicas))
+	start := replicaNext.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if p := replicas[(start+i)%n]; p.healthy.Load() {
+			replicaReads.Add(1)
+			return p.db
+		}
+	}
+	primaryReads.Add(1)
+	return primary
+}
+
+// GetPoolStats 主库和各副本的连接池统计
+func GetPoolStats() map[string]interface{} {
+	pools := []PoolStats{}
+	if db != nil {
+		pools = append(pools, PoolStats{Name: "primary", Role: "primary", Healthy: true, Stats: db.Stats()})
+	}
+	for _, p := range replicas {
+		pools = append(pools, PoolStats{
+			Name:       p.name,
+			Role:       "replica",
+			Healthy:    p.healthy.Load(),
+			LagSeconds: p.lag.Load(),
+			LastError:  p.lastErr.Load().(string),
+			Stats:      p.db.Stats(),
+		})
+	}
+	return map[string]interface{}{
+		"pools":            pools,
+		"max_lag_seconds":  replicaMaxLag,
+		"replica_reads":    replicaReads.Load(),
+		"primary_fallback": primaryReads.Load(),
+	}
+}
@@ -112,7 +112,7 @@ func (r *siteRepo) List(ctx context.Context, filter SiteFilter) ([]*models.Site,
 	// Count total
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM sites %s", whereClause)
 	var total int64
-	if err := r.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+	if err := Reader(ctx, r.db).GetContext(ctx, &total, countQuery, args...); err != nil {
 		return nil, 0, fmt.Errorf("count sites: %w", err)
 	}
 
@@ -130,7 +130,7 @@ func (r *siteRepo) List(ctx context.Context, filter SiteFilter) ([]*models.Site,
 	}
 
 	var sites []*models.Site
-	if err := r.db.SelectContext(ctx, &sites, query, args...); err != nil {
+	if err := Reader(ctx, r.db).SelectContext(ctx, &sites, query, args...); err != nil {
 		return nil, 0, fmt.Errorf("list sites: %w", err)
 	}
 
@@ -248,7 +248,7 @@ func (r *siteRepo) Count(ctx context.Context, filter SiteFilter) (int64, error)
 	query := fmt.Sprintf("SELECT COUNT(*) FROM sites %s", whereClause)
 
 	var count int64
-	if err := r.db.GetContext(ctx, &count, query, args...); err != nil {
+	if err := Reader(ctx, r.db).GetContext(ctx, &count, query, args...); err != nil {
 		return 0, fmt.Errorf("count sites: %w", err)
 	}
 
@@ -99,7 +99,7 @@ func (r *titleRepo) CountByTemplateID(ctx context.Context, templateID int) (int6
 	query := `SELECT COUNT(*) FROM titles WHERE group_id = ? AND status = 1`
 
 	var count int64
-	if err := r.db.GetContext(ctx, &count, query, templateID); err != nil {
+	if err := Reader(ctx, r.db).GetContext(ctx, &count, query, templateID); err != nil {
 		return 0, fmt.Errorf("count titles by template: %w", err)
 	}
 
@@ -0,0 +1,251 @@
+// Package core provides the structured JSON access log with sampling and spider classification
+package core
+
+import (
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"seo-generator/api/pkg/config"
+)
+
+// 处理器写入的访问日志字段（gin.Context key）
+const (
+	accessKeySpider   = "access_spider"
+	accessKeyCacheHit = "access_cache_hit"
+	accessKeyRenderMs = "access_render_ms"
+)
+
+// SetAccessSpider 记录请求的蜘蛛类型（空表示非蜘蛛）
+func SetAccessSpider(c *gin.Context, spider string) {
+	c.Set(accessKeySpider, spider)
+}
+
+// SetAccessRender 记录缓存命中和渲染耗时
+func SetAccessRender(c *gin.Context, cacheHit bool, render time.Duration) {
+	c.Set(accessKeyCacheHit, cacheHit)
+	c.Set(accessKeyRenderMs, float64(render.Microseconds())/1000)
+}
+
+// AccessLog 结构化访问日志
+// 独立于应用日志输出（单独文件按大小/时间切分，或 stdout 以 log=access 区分），
+// 蜘蛛请求和非蜘蛛请求分别采样，5xx 始终记录
+type AccessLog struct {
+	cfg    config.AccessLogConfig
+	logger zerolog.Logger
+	file   *lumberjack.Logger
+
+	rngMu sync.Mutex
+	rng   *rand.Rand
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewAccessLog 创建访问日志，未启用时返回 nil
+func NewAccessLog(cfg config.AccessLogConfig, projectRoot string) (*AccessLog, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	a := &AccessLog{
+		cfg:  cfg,
+		rng:  rand.New(rand.NewSource(time.Now().UnixNano())),
+		stop: make(chan struct{}),
+	}
+
+	var w io.Writer = os.Stdout
+	if cfg.Output != "stdout" {
+		path := AccessLogPath(cfg, projectRoot)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		a.file = &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAgeDays,
+			Compress:   cfg.Compress,
+			LocalTime:  true,
+		}
+		w = a.file
+	}
+
+	ctx := zerolog.New(w).With().Timestamp()
+	if a.file == nil {
+		ctx = ctx.Str("log", "access")
+	}
+	a.logger = ctx.Logger()
+
+	if a.file != nil && rotateEvery(cfg.RotateInterval) > 0 {
+		a.wg.Add(1)
+		go a.rotateLoop(rotateEvery(cfg.RotateInterval))
+	}
+
+	log.Info().
+		Str("output", cfg.Output).
+		Str("file", cfg.FilePath).
+		Float64("sample_rate", cfg.SampleRate).
+		Float64("spider_sample_rate", cfg.SpiderSampleRate).
+		Msg("Access log enabled")
+	return a, nil
+}
+
+// AccessLogPath 访问日志文件的绝对路径，输出到 stdout 时返回空
+func AccessLogPath(cfg config.AccessLogConfig, projectRoot string) string {
+	if cfg.Output == "stdout" {
+		return ""
+	}
+	if filepath.IsAbs(cfg.FilePath) {
+		return cfg.FilePath
+	}
+	return filepath.Join(projectRoot, cfg.FilePath)
+}
+
+func rotateEvery(interval string) time.Duration {
+	switch interval {
+	case "hourly":
+		return time.Hour
+	case "daily":
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// rotateLoop 在整点/零点切分文件
+func (a *AccessLog) rotateLoop(every time.Duration) {
+	defer a.wg.Done()
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Hour).Add(time.Hour)
+		if every == 24*time.Hour {
+			y, m, d := now.Date()
+			next = time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+		}
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-a.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			if err := a.file.Rotate(); err != nil {
+				log.Warn().Err(err).Msg("Failed to rotate access log")
+			}
+		}
+	}
+}
+
+// Close 停止切分并关闭文件
+func (a *AccessLog) Close() {
+	if a == nil {
+		return
+	}
+	close(a.stop)
+	a.wg.Wait()
+	if a.file != nil {
+		a.file.Close()
+	}
+}
+
+func (a *AccessLog) sampled(rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	a.rngMu.Lock()
+	v := a.rng.Float64()
+	a.rngMu.Unlock()
+	return v < rate
+}
+
+func (a *AccessLog) skipped(path string) bool {
+	for _, p := range a.cfg.SkipPaths {
+		if p != "" && strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware 访问日志中间件，需注册在 RequestLogger 之后以获取 request_id
+// /page 请求的域名和 UA 来自 Nginx 传入的 domain / ua 参数
+func (a *AccessLog) Middleware() gin.HandlerFunc {
+	detector := GetSpiderDetector()
+	return func(c *gin.Context) {
+		if a.skipped(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
+
+		ua := c.Query("ua")
+		if ua == "" {
+			ua = c.Request.UserAgent()
+		}
+		domain := c.Query("domain")
+		if domain == "" {
+			domain = c.Request.Host
+		}
+
+		spider, known := c.Get(accessKeySpider)
+		spiderName, _ := spider.(string)
+		if !known {
+			if d := detector.Detect(ua); d.IsSpider {
+				spiderName = d.SpiderType
+			}
+		}
+
+		status := c.Writer.Status()
+		rate := a.cfg.SampleRate
+		uaClass := "human"
+		if spiderName != "" {
+			rate = a.cfg.SpiderSampleRate
+			uaClass = "spider"
+		}
+		if status < 500 && !a.sampled(rate) {
+			return
+		}
+
+		path := c.Query("path")
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+
+		ev := a.logger.Log().
+			Str("request_id", c.GetString("request_id")).
+			Str("method", c.Request.Method).
+			Str("domain", domain).
+			Str("path", path).
+			Int("status", status).
+			Int("bytes", c.Writer.Size()).
+			Float64("latency_ms", float64(latency.Microseconds())/1000).
+			Str("client_ip", c.ClientIP()).
+			Str("ua_class", uaClass).
+			Str("spider", spiderName).
+			Str("user_agent", ua).
+			Float64("sample_rate", rate)
+		if v, ok := c.Get(accessKeyCacheHit); ok {
+			ev = ev.Bool("cache_hit", v.(bool))
+		}
+		if v, ok := c.Get(accessKeyRenderMs); ok {
+			ev = ev.Float64("render_ms", v.(float64))
+		}
+		if ref := c.Request.Referer(); ref != "" {
+			ev = ev.Str("referer", ref)
+		}
+		ev.Send()
+	}
+}
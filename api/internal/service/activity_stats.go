@@ -0,0 +1,432 @@
+// Package core provides nightly content activity rollups (articles, keywords, pool velocity)
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// TaskTypeActivityRollup 内容增量每日汇总任务类型
+const TaskTypeActivityRollup TaskType = "activity_rollup"
+
+// ErrActivityRollupRunning 已有汇总在执行
+var ErrActivityRollupRunning = errors.New("another activity rollup is running")
+
+// 统计的内容类型
+const (
+	ActivityKindArticle = "article"
+	ActivityKindKeyword = "keyword"
+	ActivityKindTitle   = "title"
+	ActivityKindContent = "content"
+)
+
+// activitySource 汇总的来源表
+type activitySource struct {
+	kind   string
+	table  string
+	source string // 数据源列，为空表示不区分来源
+}
+
+var activitySources = []activitySource{
+	{kind: ActivityKindArticle, table: "original_articles", source: "source_id"},
+	{kind: ActivityKindKeyword, table: "keywords"},
+	{kind: ActivityKindTitle, table: "titles"},
+	{kind: ActivityKindContent, table: "contents"},
+}
+
+// poolTables 计算消耗速度的数据池（按 status = 1 统计剩余）
+var poolTables = map[string]string{
+	ActivityKindTitle:   "titles",
+	ActivityKindContent: "contents",
+}
+
+// ActivityPoint 每日新增（文章按数据源，关键词按分组）
+type ActivityPoint struct {
+	Date       string `db:"stat_date" json:"date"`
+	GroupID    int    `db:"group_id" json:"group_id"`
+	GroupName  string `db:"group_name" json:"group_name"`
+	SourceID   int    `db:"source_id" json:"source_id"` // 0 表示手工上传
+	SourceName string `db:"source_name" json:"source_name"`
+	Added      int64  `db:"added" json:"added"`
+}
+
+// PoolVelocityPoint 数据池每日剩余、新增和消耗
+type PoolVelocityPoint struct {
+	Date      string `db:"stat_date" json:"date"`
+	GroupID   int    `db:"group_id" json:"group_id"`
+	Available int64  `db:"available" json:"available"`
+	Added     int64  `db:"added" json:"added"`
+	Consumed  *int64 `db:"consumed" json:"consumed"` // 缺少前一天快照时为空
+}
+
+// ActivityRollupResult 一次汇总的结果
+type ActivityRollupResult struct {
+	Days     []string `json:"days"`
+	Rows     int      `json:"rows"`
+	Duration string   `json:"duration"`
+}
+
+// ActivityStats 内容增量统计
+// 每日凌晨由定时任务汇总前一天新增的文章（按数据源）、关键词、标题和正文（按分组），
+// 并记录标题/正文池的剩余量，消耗量 = 前一天剩余 + 当天新增 - 当天剩余。
+// 大表没有 created_at 索引，按自增 ID 与 created_at 同序二分出当天的 ID 区间后按主键范围统计；
+// 剩余量只能在汇总时读取，补汇总历史日期时只更新新增数
+type ActivityStats struct {
+	db      *sqlx.DB
+	config  config.ActivityStatsConfig
+	jobs    *JobManager
+	running sync.Mutex
+}
+
+// NewActivityStats 创建内容增量统计
+func NewActivityStats(db *sqlx.DB, cfg config.ActivityStatsConfig, jobs *JobManager) *ActivityStats {
+	if cfg.BackfillDays <= 0 {
+		cfg.BackfillDays = 30
+	}
+	return &ActivityStats{db: db, config: cfg, jobs: jobs}
+}
+
+// Submit 提交汇总作业；day 为零值时汇总 backfill_days 内所有未汇总的日期
+func (a *ActivityStats) Submit(ctx context.Context, day time.Time) (int64, error) {
+	if a.jobs == nil {
+		return 0, fmt.Errorf("job manager not available")
+	}
+	params := map[string]interface{}{}
+	if !day.IsZero() {
+		params["date"] = day.Format("2006-01-02")
+	}
+	return a.jobs.SubmitFunc(ctx, string(TaskTypeActivityRollup), params, func(jc *JobContext) (any, error) {
+		days := []time.Time{day}
+		if day.IsZero() {
+			var err error
+			if days, err = a.pendingDays(jc); err != nil {
+				return nil, err
+			}
+		}
+		jc.SetTotal(int64(len(days)))
+		return a.Run(jc, days, jc.Advance)
+	})
+}
+
+// Run 汇总指定日期，progress 可为 nil
+func (a *ActivityStats) Run(ctx context.Context, days []time.Time, progress func(n int64)) (*ActivityRollupResult, error) {
+	if !a.running.TryLock() {
+		return nil, ErrActivityRollupRunning
+	}
+	defer a.running.Unlock()
+	if progress == nil {
+		progress = func(int64) {}
+	}
+
+	start := time.Now()
+	result := &ActivityRollupResult{Days: []string{}}
+	for _, day := range days {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		rows, err := a.rollupDay(ctx, day)
+		if err != nil {
+			return result, fmt.Errorf("rollup %s: %w", day.Format("2006-01-02"), err)
+		}
+		result.Days = append(result.Days, day.Format("2006-01-02"))
+		result.Rows += rows
+		progress(1)
+	}
+	result.Duration = time.Since(start).Round(time.Millisecond).String()
+	log.Info().Strs("days", result.Days).Int("rows", result.Rows).Msg("Activity stats rolled up")
+	return result, nil
+}
+
+// pendingDays backfill_days 内未汇总的日期（不含今天），从早到晚
+func (a *ActivityStats) pendingDays(ctx context.Context) ([]time.Time, error) {
+	today := truncateDay(time.Now())
+	from := today.AddDate(0, 0, -a.config.BackfillDays)
+	var done []string
+	if err := a.db.SelectContext(ctx, &done,
+		"SELECT DATE_FORMAT(stat_date, '%Y-%m-%d') FROM activity_rollup_days WHERE stat_date >= ?", from); err != nil {
+		return nil, err
+	}
+	rolled := make(map[string]bool, len(done))
+	for _, d := range done {
+		rolled[d] = true
+	}
+	var days []time.Time
+	for d := from; d.Before(today); d = d.AddDate(0, 0, 1) {
+		if !rolled[d.Format("2006-01-02")] {
+			days = append(days, d)
+		}
+	}
+	return days, nil
+}
+
+// rollupDay 汇总一天，返回写入的行数
+func (a *ActivityStats) rollupDay(ctx context.Context, day time.Time) (int, error) {
+	day = truncateDay(day)
+	next := day.AddDate(0, 0, 1)
+	date := day.Format("2006-01-02")
+	latest := !next.Before(truncateDay(time.Now())) // 前一天（或今天）才读取当前剩余量
+
+	rows := 0
+	poolAdded := map[string]map[int]int64{}
+	for _, src := range activitySources {
+		lo, err := a.firstIDFrom(ctx, src.table, day)
+		if err != nil {
+			return rows, err
+		}
+		hi, err := a.firstIDFrom(ctx, src.table, next)
+		if err != nil {
+			return rows, err
+		}
+		sourceCol := "0"
+		if src.source != "" {
+			sourceCol = "COALESCE(" + src.source + ", 0)"
+		}
+		var added []struct {
+			GroupID  int   `db:"group_id"`
+			SourceID int   `db:"source_id"`
+			N        int64 `db:"n"`
+		}
+		if hi > lo {
+			if err := a.db.SelectContext(ctx, &added, fmt.Sprintf(
+				"SELECT group_id, %s AS source_id, COUNT(*) AS n FROM %s WHERE id >= ? AND id < ? GROUP BY group_id, source_id",
+				sourceCol, src.table), lo, hi); err != nil {
+				return rows, err
+			}
+		}
+
+		if _, err := a.db.ExecContext(ctx, "DELETE FROM activity_daily_added WHERE stat_date = ? AND kind = ?", date, src.kind); err != nil {
+			return rows, err
+		}
+		if len(added) > 0 {
+			values := make([]string, 0, len(added))
+			args := make([]interface{}, 0, len(added)*5)
+			for _, r := range added {
+				values = append(values, "(?, ?, ?, ?, ?)")
+				args = append(args, date, src.kind, r.GroupID, r.SourceID, r.N)
+			}
+			if _, err := a.db.ExecContext(ctx,
+				"INSERT INTO activity_daily_added (stat_date, kind, group_id, source_id, added) VALUES "+strings.Join(values, ", "),
+				args...); err != nil {
+				return rows, err
+			}
+			rows += len(added)
+		}
+
+		if _, ok := poolTables[src.kind]; ok {
+			byGroup := map[int]int64{}
+			for _, r := range added {
+				byGroup[r.GroupID] += r.N
+			}
+			poolAdded[src.kind] = byGroup
+		}
+	}
+
+	for kind, table := range poolTables {
+		n, err := a.snapshotPool(ctx, date, kind, table, poolAdded[kind], latest)
+		if err != nil {
+			return rows, err
+		}
+		rows += n
+	}
+
+	_, err := a.db.ExecContext(ctx,
+		"INSERT INTO activity_rollup_days (stat_date, rolled_at) VALUES (?, NOW()) ON DUPLICATE KEY UPDATE rolled_at = NOW()", date)
+	return rows, err
+}
+
+// snapshotPool 记录数据池当天的新增和剩余量（latest 为 false 时只更新已有快照的新增数），并计算消耗
+func (a *ActivityStats) snapshotPool(ctx context.Context, date, kind, table string, added map[int]int64, latest bool) (int, error) {
+	rows := 0
+	if latest {
+		var available []struct {
+			GroupID int   `db:"group_id"`
+			N       int64 `db:"n"`
+		}
+		if err := a.db.SelectContext(ctx, &available,
+			"SELECT group_id, COUNT(*) AS n FROM "+table+" WHERE status = 1 GROUP BY group_id"); err != nil {
+			return 0, err
+		}
+		groups := map[int]int64{}
+		for _, r := range available {
+			groups[r.GroupID] = r.N
+		}
+		for groupID := range added {
+			if _, ok := groups[groupID]; !ok {
+				groups[groupID] = 0
+			}
+		}
+		for groupID, n := range groups {
+			if _, err := a.db.ExecContext(ctx, `
+				INSERT INTO pool_daily_snapshots (stat_date, kind, group_id, available, added) VALUES (?, ?, ?, ?, ?)
+				ON DUPLICATE KEY UPDATE available = VALUES(available), added = VALUES(added)`,
+				date, kind, groupID, n, added[groupID]); err != nil {
+				return rows, err
+			}
+			rows++
+		}
+	} else {
+		if _, err := a.db.ExecContext(ctx, "UPDATE pool_daily_snapshots SET added = 0 WHERE stat_date = ? AND kind = ?", date, kind); err != nil {
+			return 0, err
+		}
+		for groupID, n := range added {
+			if _, err := a.db.ExecContext(ctx,
+				"UPDATE pool_daily_snapshots SET added = ? WHERE stat_date = ? AND kind = ? AND group_id = ?",
+				n, date, kind, groupID); err != nil {
+				return rows, err
+			}
+		}
+	}
+
+	_, err := a.db.ExecContext(ctx, `
+		UPDATE pool_daily_snapshots cur
+		JOIN pool_daily_snapshots prev ON prev.kind = cur.kind AND prev.group_id = cur.group_id
+			AND prev.stat_date = DATE_SUB(cur.stat_date, INTERVAL 1 DAY)
+		SET cur.consumed = GREATEST(CAST(prev.available AS SIGNED) + cur.added - CAST(cur.available AS SIGNED), 0)
+		WHERE cur.stat_date = ? AND cur.kind = ?`, date, kind)
+	return rows, err
+}
+
+// firstIDFrom 返回 created_at >= t 的 ID 下界：ID 小于返回值的行都早于 t，之后的行都不早于 t
+// 依赖自增 ID 与 created_at 同序，按主键二分查找，不需要 created_at 索引
+func (a *ActivityStats) firstIDFrom(ctx context.Context, table string, t time.Time) (uint64, error) {
+	var maxID uint64
+	if err := a.db.GetContext(ctx, &maxID, "SELECT COALESCE(MAX(id), 0) FROM "+table); err != nil {
+		return 0, err
+	}
+	lo, hi := uint64(0), maxID+1
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		var row struct {
+			ID        uint64    `db:"id"`
+			CreatedAt time.Time `db:"created_at"`
+		}
+		err := a.db.GetContext(ctx, &row, "SELECT id, created_at FROM "+table+" WHERE id >= ? ORDER BY id LIMIT 1", mid)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			hi = mid
+		case err != nil:
+			return 0, err
+		case !row.CreatedAt.Before(t):
+			hi = mid
+		default:
+			lo = row.ID + 1
+		}
+	}
+	return lo, nil
+}
+
+// Articles 每日新增文章（按数据源），groupID 为 0 时不限分组
+func (a *ActivityStats) Articles(ctx context.Context, from, to time.Time, groupID int) ([]ActivityPoint, error) {
+	where, args := a.rangeWhere(ActivityKindArticle, from, to, groupID)
+	items := []ActivityPoint{}
+	err := a.db.SelectContext(ctx, &items, `
+		SELECT DATE_FORMAT(d.stat_date, '%Y-%m-%d') AS stat_date, 0 AS group_id, '' AS group_name,
+			d.source_id, COALESCE(p.name, '') AS source_name, SUM(d.added) AS added
+		FROM activity_daily_added d
+		LEFT JOIN spider_projects p ON p.id = d.source_id
+		WHERE `+where+`
+		GROUP BY d.stat_date, d.source_id, p.name
+		ORDER BY d.stat_date, d.source_id`, args...)
+	return items, err
+}
+
+// Keywords 每日新增关键词（按分组），groupID 为 0 时列出全部分组
+func (a *ActivityStats) Keywords(ctx context.Context, from, to time.Time, groupID int) ([]ActivityPoint, error) {
+	where, args := a.rangeWhere(ActivityKindKeyword, from, to, groupID)
+	items := []ActivityPoint{}
+	err := a.db.SelectContext(ctx, &items, `
+		SELECT DATE_FORMAT(d.stat_date, '%Y-%m-%d') AS stat_date, d.group_id, COALESCE(g.name, '') AS group_name,
+			0 AS source_id, '' AS source_name, SUM(d.added) AS added
+		FROM activity_daily_added d
+		LEFT JOIN keyword_groups g ON g.id = d.group_id
+		WHERE `+where+`
+		GROUP BY d.stat_date, d.group_id, g.name
+		ORDER BY d.stat_date, d.group_id`, args...)
+	return items, err
+}
+
+// PoolVelocity 标题/正文池每日剩余、新增和消耗，groupID 为 0 时列出全部分组
+func (a *ActivityStats) PoolVelocity(ctx context.Context, kind string, from, to time.Time, groupID int) ([]PoolVelocityPoint, error) {
+	if _, ok := poolTables[kind]; !ok {
+		return nil, fmt.Errorf("unknown pool kind: %s", kind)
+	}
+	where, args := a.rangeWhere(kind, from, to, groupID)
+	items := []PoolVelocityPoint{}
+	err := a.db.SelectContext(ctx, &items, `
+		SELECT DATE_FORMAT(d.stat_date, '%Y-%m-%d') AS stat_date, d.group_id, d.available, d.added, d.consumed
+		FROM pool_daily_snapshots d
+		WHERE `+where+`
+		ORDER BY d.stat_date, d.group_id`, args...)
+	return items, err
+}
+
+// ValidPoolKind 是否为支持消耗统计的数据池
+func ValidPoolKind(kind string) bool {
+	_, ok := poolTables[kind]
+	return ok
+}
+
+func (a *ActivityStats) rangeWhere(kind string, from, to time.Time, groupID int) (string, []interface{}) {
+	where := "d.kind = ? AND d.stat_date >= ? AND d.stat_date <= ?"
+	args := []interface{}{kind, from.Format("2006-01-02"), to.Format("2006-01-02")}
+	if groupID > 0 {
+		where += " AND d.group_id = ?"
+		args = append(args, groupID)
+	}
+	return where, args
+}
+
+// EnsureSchedule 按配置创建或更新每日汇总任务
+func (a *ActivityStats) EnsureSchedule(ctx context.Context, scheduler *Scheduler) error {
+	var existing struct {
+		ID       int64  `db:"id"`
+		CronExpr string `db:"cron_expr"`
+		Enabled  bool   `db:"enabled"`
+	}
+	err := a.db.GetContext(ctx, &existing,
+		"SELECT id, cron_expr, enabled FROM scheduled_tasks WHERE task_type = ? LIMIT 1", TaskTypeActivityRollup)
+	exists := err == nil && existing.ID > 0
+
+	if a.config.Schedule == "" {
+		if exists {
+			return scheduler.DeleteTask(ctx, existing.ID)
+		}
+		return nil
+	}
+
+	task := &ScheduledTask{
+		Name:     "内容增量统计",
+		TaskType: TaskTypeActivityRollup,
+		CronExpr: a.config.Schedule,
+		Params:   json.RawMessage("{}"),
+		Enabled:  true,
+	}
+	if exists {
+		// 保留后台手动设置的启用状态，只同步 Cron 表达式
+		if existing.CronExpr == a.config.Schedule {
+			return nil
+		}
+		task.ID = existing.ID
+		task.Enabled = existing.Enabled
+		return scheduler.UpdateTask(ctx, task)
+	}
+	_, err = scheduler.CreateTask(ctx, task)
+	return err
+}
+
+// truncateDay 当地时间零点
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,412 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// ErrAlertRuleNotFound 告警规则不存在
+var ErrAlertRuleNotFound = errors.New("alert rule not found")
+
+// 告警通知渠道
+const (
+	AlertChannelLog     = "log"     // 只记录日志（所有告警都会记录日志）
+	AlertChannelWebhook = "webhook" // 同时推送到 webhook（规则的 webhook_url，为空时用 alerting.webhook_url）
+)
+
+// 告警 webhook 事件类型
+const (
+	AlertEventFiring       = "firing"
+	AlertEventResolved     = "resolved"
+	AlertEventAcknowledged = "acknowledged"
+)
+
+// alertMetrics 自定义规则可引用的指标（取值来自 Monitor 每次采集的快照）
+var alertMetrics = map[string]func(MetricsSnapshot) float64{
+	"error_rate": func(s MetricsSnapshot) float64 {
+		if s.TotalRequests == 0 {
+			return 0
+		}
+		return float64(s.ErrorRequests) / float64(s.TotalRequests) * 100
+	},
+	"qps":                func(s MetricsSnapshot) float64 { return s.QPS },
+	"avg_latency_ms":     func(s MetricsSnapshot) float64 { return s.AvgLatencyMs },
+	"max_latency_ms":     func(s MetricsSnapshot) float64 { return s.MaxLatencyMs },
+	"pool_hit_rate":      func(s MetricsSnapshot) float64 { return s.PoolHitRate },
+	"cache_hit_rate":     func(s MetricsSnapshot) float64 { return s.CacheHitRate },
+	"render_errors":      func(s MetricsSnapshot) float64 { return float64(s.RenderErrorCount) },
+	"heap_alloc_mb":      func(s MetricsSnapshot) float64 { return float64(s.HeapAllocBytes) / (1024 * 1024) },
+	"goroutines":         func(s MetricsSnapshot) float64 { return float64(s.NumGoroutine) },
+	"spider_requests":    func(s MetricsSnapshot) float64 { return float64(s.SpiderRequests) },
+	"spider_log_dropped": func(s MetricsSnapshot) float64 { return float64(s.SpiderLogDropped) },
+}
+
+// alertComparators 支持的比较符
+var alertComparators = map[string]func(value, threshold float64) bool{
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+	"==": func(v, t float64) bool { return v == t },
+	"!=": func(v, t float64) bool { return v != t },
+}
+
+// AlertMetricNames 自定义规则可用的指标名
+func AlertMetricNames() []string {
+	names := make([]string, 0, len(alertMetrics))
+	for name := range alertMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AlertRuleConfig 用户定义的告警规则（alert_rules 表）
+// 指标满足 metric comparator threshold 并持续 duration_seconds 后触发，条件恢复后自动解决
+type AlertRuleConfig struct {
+	ID              int64      `db:"id" json:"id"`
+	Name            string     `db:"name" json:"name"`
+	Metric          string     `db:"metric" json:"metric"`
+	Comparator      string     `db:"comparator" json:"comparator"`
+	Threshold       float64    `db:"threshold" json:"threshold"`
+	DurationSeconds int        `db:"duration_seconds" json:"duration_seconds"`
+	Severity        AlertLevel `db:"severity" json:"severity"`
+	Channel         string     `db:"channel" json:"channel"`
+	WebhookURL      string     `db:"webhook_url" json:"webhook_url"`
+	Enabled         bool       `db:"enabled" json:"enabled"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// Validate 校验规则字段
+func (r *AlertRuleConfig) Validate() error {
+	if r.Name == "" || len(r.Name) > 100 {
+		return fmt.Errorf("规则名称不能为空且不超过 100 个字符")
+	}
+	if _, ok := alertMetrics[r.Metric]; !ok {
+		return fmt.Errorf("不支持的指标: %s", r.Metric)
+	}
+	if _, ok := alertComparators[r.Comparator]; !ok {
+		return fmt.Errorf("不支持的比较符: %s", r.Comparator)
+	}
+	if r.DurationSeconds < 0 {
+		return fmt.Errorf("持续时间不能为负数")
+	}
+	switch r.Severity {
+	case AlertLevelInfo, AlertLevelWarning, AlertLevelError:
+	default:
+		return fmt.Errorf("告警级别只能是 info、warning 或 error")
+	}
+	switch r.Channel {
+	case AlertChannelLog, AlertChannelWebhook:
+	default:
+		return fmt.Errorf("通知渠道只能是 log 或 webhook")
+	}
+	return nil
+}
+
+// alertType 规则触发的告警类型
+func (r *AlertRuleConfig) alertType() string {
+	return "rule:" + strconv.FormatInt(r.ID, 10)
+}
+
+// alertRuleState 规则评估状态
+type alertRuleState struct {
+	pendingSince time.Time // 条件开始满足的时间，零值表示当前不满足
+	alertID      string    // 已触发且未解决的告警
+}
+
+const alertRuleColumns = "id, name, metric, comparator, threshold, duration_seconds, severity, channel, webhook_url, enabled, created_at, updated_at"
+
+// AlertRules 用户定义告警规则的存储和评估
+// Monitor 每次采集后调用 Evaluate；规则增删改后立即重新加载
+type AlertRules struct {
+	db       *sqlx.DB
+	manager  *AlertManager
+	notifier *AlertWebhook
+
+	mu    sync.Mutex
+	rules []*AlertRuleConfig
+	state map[int64]*alertRuleState
+}
+
+// NewAlertRules 创建告警规则引擎
+func NewAlertRules(db *sqlx.DB, manager *AlertManager, notifier *AlertWebhook) *AlertRules {
+	return &AlertRules{
+		db:       db,
+		manager:  manager,
+		notifier: notifier,
+		state:    make(map[int64]*alertRuleState),
+	}
+}
+
+// Reload 从数据库加载启用的规则（已删除、停用的规则的未解决告警标记为已解决）
+func (r *AlertRules) Reload(ctx context.Context) error {
+	var rules []*AlertRuleConfig
+	if err := r.db.SelectContext(ctx, &rules, "SELECT "+alertRuleColumns+" FROM alert_rules WHERE enabled = 1"); err != nil {
+		return fmt.Errorf("load alert rules: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	active := make(map[int64]bool, len(rules))
+	for _, rule := range rules {
+		active[rule.ID] = true
+	}
+	for id, st := range r.state {
+		if !active[id] {
+			if st.alertID != "" {
+				r.manager.Resolve("rule:" + strconv.FormatInt(id, 10))
+			}
+			delete(r.state, id)
+		}
+	}
+	r.rules = rules
+	log.Info().Int("rules", len(rules)).Msg("Alert rules loaded")
+	return nil
+}
+
+// Evaluate 用最新的指标快照评估所有规则
+func (r *AlertRules) Evaluate(snapshot MetricsSnapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, rule := range r.rules {
+		value := alertMetrics[rule.Metric](snapshot)
+		st := r.state[rule.ID]
+		if st == nil {
+			st = &alertRuleState{}
+			r.state[rule.ID] = st
+		}
+
+		if !alertComparators[rule.Comparator](value, rule.Threshold) {
+			st.pendingSince = time.Time{}
+			if st.alertID != "" {
+				r.manager.Resolve(rule.alertType())
+				if alert, ok := r.manager.GetAlert(st.alertID); ok {
+					r.notify(rule, AlertEventResolved, alert)
+				}
+				st.alertID = ""
+			}
+			continue
+		}
+
+		if st.pendingSince.IsZero() {
+			st.pendingSince = now
+		}
+		if st.alertID != "" || now.Sub(st.pendingSince) < time.Duration(rule.DurationSeconds)*time.Second {
+			continue
+		}
+
+		alert := r.manager.RaiseAlert(Alert{
+			Level:     rule.Severity,
+			Type:      rule.alertType(),
+			Message:   fmt.Sprintf("%s: %s %s %.2f，当前值 %.2f", rule.Name, rule.Metric, rule.Comparator, rule.Threshold, value),
+			Value:     value,
+			Threshold: rule.Threshold,
+			RuleID:    rule.ID,
+		})
+		st.alertID = alert.ID
+		r.notify(rule, AlertEventFiring, alert)
+	}
+}
+
+// notify 按规则的通知渠道推送告警事件（调用方持有 r.mu）
+func (r *AlertRules) notify(rule *AlertRuleConfig, event string, alert Alert) {
+	if rule.Channel != AlertChannelWebhook || r.notifier == nil {
+		return
+	}
+	r.notifier.Send(rule.WebhookURL, event, rule.Name, alert)
+}
+
+// ruleByID 查找已加载的规则（调用方持有 r.mu）
+func (r *AlertRules) ruleByID(id int64) *AlertRuleConfig {
+	for _, rule := range r.rules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Acknowledge 确认告警，规则告警按渠道推送确认事件
+func (r *AlertRules) Acknowledge(id, by string) (*Alert, error) {
+	alert, err := r.manager.Acknowledge(id, by)
+	if err != nil {
+		return nil, err
+	}
+	if alert.RuleID > 0 {
+		r.mu.Lock()
+		if rule := r.ruleByID(alert.RuleID); rule != nil {
+			r.notify(rule, AlertEventAcknowledged, *alert)
+		}
+		r.mu.Unlock()
+	}
+	return alert, nil
+}
+
+// Resolve 手动解决告警
+// 规则告警解决后重新开始计时，条件仍满足时持续 duration_seconds 后再次触发
+func (r *AlertRules) Resolve(id, by string) (*Alert, error) {
+	alert, err := r.manager.ResolveAlert(id, by)
+	if err != nil {
+		return nil, err
+	}
+	if alert.RuleID > 0 {
+		r.mu.Lock()
+		if st := r.state[alert.RuleID]; st != nil && st.alertID == id {
+			st.alertID = ""
+			st.pendingSince = time.Time{}
+		}
+		if rule := r.ruleByID(alert.RuleID); rule != nil {
+			r.notify(rule, AlertEventResolved, *alert)
+		}
+		r.mu.Unlock()
+	}
+	return alert, nil
+}
+
+// List 列出所有规则（含停用）
+func (r *AlertRules) List(ctx context.Context) ([]AlertRuleConfig, error) {
+	items := []AlertRuleConfig{}
+	err := r.db.SelectContext(ctx, &items, "SELECT "+alertRuleColumns+" FROM alert_rules ORDER BY id")
+	return items, err
+}
+
+// GetByID 获取规则
+func (r *AlertRules) GetByID(ctx context.Context, id int64) (*AlertRuleConfig, error) {
+	var rule AlertRuleConfig
+	if err := r.db.GetContext(ctx, &rule, "SELECT "+alertRuleColumns+" FROM alert_rules WHERE id = ?", id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAlertRuleNotFound
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// Create 创建规则，返回 ID
+func (r *AlertRules) Create(ctx context.Context, rule *AlertRuleConfig) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO alert_rules (name, metric, comparator, threshold, duration_seconds, severity, channel, webhook_url, enabled)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rule.Name, rule.Metric, rule.Comparator, rule.Threshold, rule.DurationSeconds, rule.Severity, rule.Channel, rule.WebhookURL, rule.Enabled)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := res.LastInsertId()
+	r.changed(ctx)
+	return id, nil
+}
+
+// Update 更新规则（未解决的告警保留，按新条件继续评估）
+func (r *AlertRules) Update(ctx context.Context, rule *AlertRuleConfig) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE alert_rules SET name = ?, metric = ?, comparator = ?, threshold = ?, duration_seconds = ?,
+			severity = ?, channel = ?, webhook_url = ?, enabled = ?
+		WHERE id = ?`,
+		rule.Name, rule.Metric, rule.Comparator, rule.Threshold, rule.DurationSeconds,
+		rule.Severity, rule.Channel, rule.WebhookURL, rule.Enabled, rule.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := r.GetByID(ctx, rule.ID); err != nil {
+			return err
+		}
+	}
+	r.changed(ctx)
+	return nil
+}
+
+// Delete 删除规则
+func (r *AlertRules) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM alert_rules WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAlertRuleNotFound
+	}
+	r.changed(ctx)
+	return nil
+}
+
+func (r *AlertRules) changed(ctx context.Context) {
+	if err := r.Reload(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload alert rules")
+	}
+}
+
+// AlertWebhook 告警 webhook 通知（异步发送，失败只记日志）
+type AlertWebhook struct {
+	client     *http.Client
+	defaultURL string
+}
+
+// alertWebhookPayload webhook 请求体
+type alertWebhookPayload struct {
+	Event string `json:"event"` // firing / resolved / acknowledged
+	Rule  string `json:"rule"`
+	Alert Alert  `json:"alert"`
+}
+
+// NewAlertWebhook 创建告警 webhook 通知
+func NewAlertWebhook(cfg config.AlertingConfig) *AlertWebhook {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &AlertWebhook{client: GetHTTPClient().Client(EgressPurposeAlertWebhook, timeout), defaultURL: cfg.WebhookURL}
+}
+
+// Send 异步推送告警事件，target 为空时使用默认地址
+func (w *AlertWebhook) Send(target, event, rule string, alert Alert) {
+	if target == "" {
+		target = w.defaultURL
+	}
+	if target == "" {
+		log.Warn().Str("rule", rule).Msg("Alert webhook channel configured but no webhook URL set")
+		return
+	}
+	body, _ := json.Marshal(alertWebhookPayload{Event: event, Rule: rule, Alert: alert})
+	go func() {
+		if err := w.post(target, body); err != nil {
+			log.Warn().Err(err).Str("rule", rule).Str("event", event).Msg("Failed to send alert webhook")
+		}
+	}()
+}
+
+func (w *AlertWebhook) post(target string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), w.client.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
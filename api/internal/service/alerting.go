@@ -2,6 +2,7 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -9,6 +10,9 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// ErrAlertNotFound 告警不存在（或已超出保留条数被移除）
+var ErrAlertNotFound = errors.New("alert not found")
+
 // AlertLevel 告警级别
 type AlertLevel string
 
@@ -28,6 +32,13 @@ type Alert struct {
 	Threshold float64    `json:"threshold"` // 阈值
 	Timestamp time.Time  `json:"timestamp"` // 告警时间
 	Resolved  bool       `json:"resolved"`  // 是否已解决
+
+	RuleID         int64      `json:"rule_id,omitempty"` // 自定义规则触发时为规则 ID
+	Acknowledged   bool       `json:"acknowledged"`      // 是否已确认（确认后仍未解决，只表示有人在处理）
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	ResolvedBy     string     `json:"resolved_by,omitempty"` // 手动解决时为操作人，条件恢复自动解决时为空
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
 }
 
 // AlertRule 告警规则
@@ -168,15 +179,94 @@ func (m *AlertManager) Check(snapshot MetricsSnapshot) {
 	}
 }
 
+// Raise 直接触发一条告警（用于非指标驱动的事件，如模板降级）
+func (m *AlertManager) Raise(level AlertLevel, alertType, message string, value, threshold float64) {
+	m.RaiseAlert(Alert{
+		Level:     level,
+		Type:      alertType,
+		Message:   message,
+		Value:     value,
+		Threshold: threshold,
+	})
+}
+
+// RaiseAlert 触发告警（自动生成 ID 和时间），返回保存的告警
+func (m *AlertManager) RaiseAlert(alert Alert) Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.alertSeq++
+	alert.ID = fmt.Sprintf("alert-%d-%d", now.UnixNano(), m.alertSeq)
+	alert.Timestamp = now
+
+	m.alerts = append(m.alerts, alert)
+	if len(m.alerts) > m.maxAlerts {
+		m.alerts = m.alerts[len(m.alerts)-m.maxAlerts:]
+	}
+
+	for _, handler := range m.handlers {
+		handler.Handle(alert)
+	}
+	return alert
+}
+
+// Resolve 将指定类型的未解决告警标记为已解决
+func (m *AlertManager) Resolve(alertType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resolveAlertsByType(alertType)
+}
+
 // resolveAlertsByType 将指定类型的未解决告警标记为已解决
 func (m *AlertManager) resolveAlertsByType(alertType string) {
+	now := time.Now()
 	for i := range m.alerts {
 		if m.alerts[i].Type == alertType && !m.alerts[i].Resolved {
 			m.alerts[i].Resolved = true
+			m.alerts[i].ResolvedAt = &now
 		}
 	}
 }
 
+// Acknowledge 确认告警（by 为操作人）
+func (m *AlertManager) Acknowledge(id, by string) (*Alert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.alerts {
+		if m.alerts[i].ID == id {
+			if !m.alerts[i].Acknowledged {
+				now := time.Now()
+				m.alerts[i].Acknowledged = true
+				m.alerts[i].AcknowledgedBy = by
+				m.alerts[i].AcknowledgedAt = &now
+			}
+			alert := m.alerts[i]
+			return &alert, nil
+		}
+	}
+	return nil, ErrAlertNotFound
+}
+
+// ResolveAlert 手动解决告警（by 为操作人）
+func (m *AlertManager) ResolveAlert(id, by string) (*Alert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.alerts {
+		if m.alerts[i].ID == id {
+			if !m.alerts[i].Resolved {
+				now := time.Now()
+				m.alerts[i].Resolved = true
+				m.alerts[i].ResolvedBy = by
+				m.alerts[i].ResolvedAt = &now
+			}
+			alert := m.alerts[i]
+			return &alert, nil
+		}
+	}
+	return nil, ErrAlertNotFound
+}
+
 // GetAlerts 获取最近告警
 func (m *AlertManager) GetAlerts(limit int) []Alert {
 	m.mu.RLock()
@@ -199,6 +289,18 @@ func (m *AlertManager) GetAlerts(limit int) []Alert {
 	return result
 }
 
+// GetAlert 按 ID 获取告警
+func (m *AlertManager) GetAlert(id string) (Alert, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for i := len(m.alerts) - 1; i >= 0; i-- {
+		if m.alerts[i].ID == id {
+			return m.alerts[i], true
+		}
+	}
+	return Alert{}, false
+}
+
 // GetUnresolvedAlerts 获取未解决告警
 func (m *AlertManager) GetUnresolvedAlerts() []Alert {
 	m.mu.RLock()
@@ -0,0 +1,548 @@
+// Package core provides rate-based anti-scrape protection for non-spider traffic
+package core
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// 反采集判定结果
+const (
+	ScrapeAllow     = "allow"
+	ScrapeChallenge = "challenge" // 返回 JS 挑战页
+	ScrapeTarpit    = "tarpit"    // 延迟后正常响应
+	ScrapeBlock     = "block"     // 403
+)
+
+const (
+	antiScrapeBlockKeyPrefix = "anti_scrape:block:"    // Redis 封禁键前缀，值为封禁原因，TTL 为剩余封禁时长
+	antiScrapeChannel        = "anti_scrape:blocklist" // 封禁变更通知，消息格式 block|ip|过期时间戳|原因 或 unblock|ip
+	antiScrapeMaxTracked     = 100000                  // 最多跟踪的 IP 数，超过后新 IP 不计数
+	antiScrapeTopOffenders   = 20
+)
+
+// ScrapeVerdict 一次请求的判定
+type ScrapeVerdict struct {
+	Action string
+	Reason string
+	Delay  time.Duration // 仅 tarpit
+}
+
+// ScrapeBlockEntry 封禁记录
+type ScrapeBlockEntry struct {
+	IP        string    `json:"ip"`
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ScrapeOffender 窗口内请求量较高的 IP
+type ScrapeOffender struct {
+	IP         string `json:"ip"`
+	Requests   int    `json:"requests"`
+	Unsolved   int    `json:"unsolved_challenges"`
+	Tarpitted  int    `json:"tarpitted"`
+	LastUA     string `json:"last_ua"`
+	LastPath   string `json:"last_path"`
+	WindowFrom int64  `json:"window_from"`
+}
+
+// AntiScrapeStats 反采集统计（进程启动以来）
+type AntiScrapeStats struct {
+	Enabled          bool               `json:"enabled"`
+	Checked          int64              `json:"checked"`
+	Allowed          int64              `json:"allowed"`
+	Challenged       int64              `json:"challenged"`
+	ChallengesPassed int64              `json:"challenges_passed"`
+	Tarpitted        int64              `json:"tarpitted"`
+	Blocked          int64              `json:"blocked"`
+	HoneypotHits     int64              `json:"honeypot_hits"`
+	Tracked          int                `json:"tracked"`
+	ActiveTarpits    int64              `json:"active_tarpits"`
+	Blocklist        []ScrapeBlockEntry `json:"blocklist"`
+	TopOffenders     []ScrapeOffender   `json:"top_offenders"`
+}
+
+type scrapeClient struct {
+	windowStart time.Time
+	requests    int
+	unsolved    int
+	tarpitted   int
+	lastSeen    time.Time
+	lastUA      string
+	lastPath    string
+}
+
+// AntiScraper 反采集
+// 按 IP 统计固定窗口内的请求数，逐级升级：JS 挑战 -> 延迟响应 -> 封禁；
+// 访问蜜罐链接或多次不通过挑战直接封禁。封禁列表保存在内存，并写入 Redis 同步到其他实例
+type AntiScraper struct {
+	config    config.AntiScrapeConfig
+	rdb       *redis.Client
+	secret    []byte
+	allowlist []*net.IPNet
+
+	mu      sync.Mutex
+	clients map[string]*scrapeClient
+
+	blockMu sync.RWMutex
+	blocked map[string]ScrapeBlockEntry
+
+	badBotMu sync.Mutex
+	badBots  map[string]BadBotEntry // 未配置 Redis 时的坏爬虫记录
+
+	checked, allowed, challenged, passed atomic.Int64
+	tarpitted, blockedCount, honeypots   atomic.Int64
+	activeTarpits                        atomic.Int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewAntiScraper 创建反采集，secret 为挑战凭证签名密钥；rdb 为 nil 时封禁列表只在本实例生效
+func NewAntiScraper(cfg config.AntiScrapeConfig, rdb *redis.Client, secret string) *AntiScraper {
+	if cfg.WindowSeconds <= 0 {
+		cfg.WindowSeconds = 60
+	}
+	if cfg.CookieName == "" {
+		cfg.CookieName = "_sgc"
+	}
+	if cfg.CookieTTLSeconds <= 0 {
+		cfg.CookieTTLSeconds = 86400
+	}
+	if cfg.BlockSeconds <= 0 {
+		cfg.BlockSeconds = 3600
+	}
+	allowlist, err := ParseCIDRs(cfg.Allowlist)
+	if err != nil {
+		log.Warn().Err(err).Msg("Invalid anti_scrape.allowlist, ignoring")
+	}
+	return &AntiScraper{
+		config:    cfg,
+		rdb:       rdb,
+		secret:    []byte(secret),
+		allowlist: allowlist,
+		clients:   make(map[string]*scrapeClient),
+		blocked:   make(map[string]ScrapeBlockEntry),
+		badBots:   make(map[string]BadBotEntry),
+	}
+}
+
+// Enabled 是否启用
+func (a *AntiScraper) Enabled() bool {
+	return a != nil && a.config.Enabled
+}
+
+// CookieName 挑战凭证 Cookie 名
+func (a *AntiScraper) CookieName() string {
+	return a.config.CookieName
+}
+
+// Start 加载 Redis 中的封禁列表，订阅封禁变更并定期清理过期数据
+func (a *AntiScraper) Start(ctx context.Context) {
+	a.ctx, a.cancel = context.WithCancel(ctx)
+	if a.rdb != nil {
+		a.loadBlocklist(a.ctx)
+		a.wg.Add(1)
+		go a.listen()
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		ticker := time.NewTicker(time.Duration(a.config.WindowSeconds) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-a.ctx.Done():
+				return
+			case now := <-ticker.C:
+				a.cleanup(now)
+			}
+		}
+	}()
+}
+
+// Stop 停止同步和清理
+func (a *AntiScraper) Stop() {
+	if a.cancel != nil {
+		a.cancel()
+	}
+	a.wg.Wait()
+}
+
+// Inspect 判定一次非蜘蛛请求，token 为请求携带的挑战凭证 Cookie
+func (a *AntiScraper) Inspect(ip, ua, path, token string) ScrapeVerdict {
+	if !a.Enabled() || ip == "" {
+		return ScrapeVerdict{Action: ScrapeAllow}
+	}
+	a.checked.Add(1)
+
+	if parsed := net.ParseIP(ip); parsed == nil || parsed.IsLoopback() || containsIP(a.allowlist, parsed) {
+		a.allowed.Add(1)
+		return ScrapeVerdict{Action: ScrapeAllow}
+	}
+	if entry, ok := a.blockedEntry(ip); ok {
+		a.blockedCount.Add(1)
+		return ScrapeVerdict{Action: ScrapeBlock, Reason: entry.Reason}
+	}
+	if a.IsTrapPath(path) {
+		a.Trap(context.Background(), ip, ua, "", "", path)
+		return ScrapeVerdict{Action: ScrapeBlock, Reason: "honeypot"}
+	}
+
+	now := time.Now()
+	solved := a.VerifyToken(ip, token, now)
+
+	a.mu.Lock()
+	client := a.clients[ip]
+	if client == nil {
+		if len(a.clients) >= antiScrapeMaxTracked {
+			a.mu.Unlock()
+			a.allowed.Add(1)
+			return ScrapeVerdict{Action: ScrapeAllow}
+		}
+		client = &scrapeClient{windowStart: now}
+		a.clients[ip] = client
+	}
+	if now.Sub(client.windowStart) >= time.Duration(a.config.WindowSeconds)*time.Second {
+		client.windowStart = now
+		client.requests = 0
+		client.tarpitted = 0
+	}
+	client.requests++
+	client.lastSeen = now
+	client.lastUA = ua
+	client.lastPath = path
+	if solved && client.unsolved > 0 {
+		client.unsolved = 0
+		a.passed.Add(1)
+	}
+	requests, unsolved := client.requests, client.unsolved
+	a.mu.Unlock()
+
+	switch {
+	case a.config.BlockThreshold > 0 && requests > a.config.BlockThreshold:
+		return a.block(ip, "rate")
+	case a.config.TarpitThreshold > 0 && requests > a.config.TarpitThreshold:
+		if a.config.MaxTarpitted > 0 && a.activeTarpits.Load() >= int64(a.config.MaxTarpitted) {
+			return a.block(ip, "tarpit_overflow")
+		}
+		a.mu.Lock()
+		client.tarpitted++
+		a.mu.Unlock()
+		a.tarpitted.Add(1)
+		return ScrapeVerdict{Action: ScrapeTarpit, Reason: "rate", Delay: time.Duration(a.config.TarpitDelayMs) * time.Millisecond}
+	case !solved && a.config.ChallengeThreshold > 0 && requests > a.config.ChallengeThreshold:
+		if a.config.MaxUnsolvedChallenges > 0 && unsolved >= a.config.MaxUnsolvedChallenges {
+			return a.block(ip, "unsolved_challenge")
+		}
+		a.mu.Lock()
+		client.unsolved++
+		a.mu.Unlock()
+		a.challenged.Add(1)
+		return ScrapeVerdict{Action: ScrapeChallenge, Reason: "rate"}
+	}
+
+	a.allowed.Add(1)
+	return ScrapeVerdict{Action: ScrapeAllow}
+}
+
+// Tarpit 延迟响应，请求取消时提前返回
+func (a *AntiScraper) Tarpit(ctx context.Context, delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+	a.activeTarpits.Add(1)
+	defer a.activeTarpits.Add(-1)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// IssueToken 生成挑战凭证：过期时间戳.签名（绑定 IP）
+func (a *AntiScraper) IssueToken(ip string, now time.Time) string {
+	expires := strconv.FormatInt(now.Add(time.Duration(a.config.CookieTTLSeconds)*time.Second).Unix(), 10)
+	return expires + "." + a.sign(ip, expires)
+}
+
+// VerifyToken 校验挑战凭证
+func (a *AntiScraper) VerifyToken(ip, token string, now time.Time) bool {
+	expires, sig, ok := strings.Cut(token, ".")
+	if !ok || sig == "" {
+		return false
+	}
+	ts, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > ts {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(a.sign(ip, expires)))
+}
+
+func (a *AntiScraper) sign(ip, expires string) string {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(ip))
+	mac.Write([]byte{'|'})
+	mac.Write([]byte(expires))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// ChallengePage 生成 JS 挑战页
+// 凭证拆分后逆序嵌入脚本，由浏览器拼接写入 Cookie 后刷新；不执行 JS 的采集器拿不到凭证。
+// 页面内含隐藏的蜜罐链接，跟随链接的采集器会被直接封禁
+func (a *AntiScraper) ChallengePage(ip string) []byte {
+	token := a.IssueToken(ip, time.Now())
+	parts := make([]string, 0, 4)
+	for len(token) > 0 {
+		n := 12
+		if n > len(token) {
+			n = len(token)
+		}
+		parts = append(parts, `"`+token[:n]+`"`)
+		token = token[n:]
+	}
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+
+	var b strings.Builder
+	b.Grow(1024)
+	b.WriteString(`<!DOCTYPE html><html><head><meta charset="utf-8"><meta name="robots" content="noindex,nofollow"><title>Loading</title></head><body>`)
+	b.WriteString(a.TrapLink())
+	b.WriteString(`<noscript>Please enable JavaScript.</noscript><script>(function(){var p=[`)
+	b.WriteString(strings.Join(parts, ","))
+	b.WriteString(`];document.cookie="`)
+	b.WriteString(a.config.CookieName)
+	b.WriteString(`="+p.reverse().join("")+";path=/;max-age=`)
+	b.WriteString(strconv.Itoa(a.config.CookieTTLSeconds))
+	b.WriteString(`;SameSite=Lax";setTimeout(function(){location.reload()},300)})();</script></body></html>`)
+	return []byte(b.String())
+}
+
+// block 封禁 IP 并同步到其他实例
+func (a *AntiScraper) block(ip, reason string) ScrapeVerdict {
+	a.Block(context.Background(), ip, reason, time.Duration(a.config.BlockSeconds)*time.Second)
+	a.blockedCount.Add(1)
+	return ScrapeVerdict{Action: ScrapeBlock, Reason: reason}
+}
+
+// Block 封禁 IP，duration 内所有非蜘蛛请求返回 403
+func (a *AntiScraper) Block(ctx context.Context, ip, reason string, duration time.Duration) ScrapeBlockEntry {
+	entry := ScrapeBlockEntry{IP: ip, Reason: reason, ExpiresAt: time.Now().Add(duration)}
+	a.blockMu.Lock()
+	a.blocked[ip] = entry
+	a.blockMu.Unlock()
+
+	a.mu.Lock()
+	delete(a.clients, ip)
+	a.mu.Unlock()
+
+	log.Warn().Str("ip", ip).Str("reason", reason).Dur("duration", duration).Msg("Scraper blocked")
+
+	if a.rdb != nil {
+		if err := a.rdb.Set(ctx, antiScrapeBlockKeyPrefix+ip, reason, duration).Err(); err != nil {
+			log.Warn().Err(err).Str("ip", ip).Msg("Failed to save scraper block to Redis")
+		}
+		msg := fmt.Sprintf("block|%s|%d|%s", ip, entry.ExpiresAt.Unix(), reason)
+		if err := a.rdb.Publish(ctx, antiScrapeChannel, msg).Err(); err != nil {
+			log.Warn().Err(err).Msg("Failed to publish scraper block")
+		}
+	}
+	return entry
+}
+
+// Unblock 解除封禁
+func (a *AntiScraper) Unblock(ctx context.Context, ip string) bool {
+	a.blockMu.Lock()
+	_, ok := a.blocked[ip]
+	delete(a.blocked, ip)
+	a.blockMu.Unlock()
+
+	if a.rdb != nil {
+		if n, err := a.rdb.Del(ctx, antiScrapeBlockKeyPrefix+ip).Result(); err != nil {
+			log.Warn().Err(err).Str("ip", ip).Msg("Failed to remove scraper block from Redis")
+		} else if n > 0 {
+			ok = true
+		}
+		a.rdb.Publish(ctx, antiScrapeChannel, "unblock|"+ip)
+	}
+	if ok {
+		log.Info().Str("ip", ip).Msg("Scraper unblocked")
+	}
+	return ok
+}
+
+func (a *AntiScraper) blockedEntry(ip string) (ScrapeBlockEntry, bool) {
+	a.blockMu.RLock()
+	entry, ok := a.blocked[ip]
+	a.blockMu.RUnlock()
+	if !ok || time.Now().After(entry.ExpiresAt) {
+		return ScrapeBlockEntry{}, false
+	}
+	return entry, true
+}
+
+// loadBlocklist 从 Redis 加载其他实例的封禁记录
+func (a *AntiScraper) loadBlocklist(ctx context.Context) {
+	var cursor uint64
+	loaded := 0
+	for {
+		keys, next, err := a.rdb.Scan(ctx, cursor, antiScrapeBlockKeyPrefix+"*", 500).Result()
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to load scraper blocklist from Redis")
+			return
+		}
+		for _, key := range keys {
+			reason, err := a.rdb.Get(ctx, key).Result()
+			if err != nil {
+				continue
+			}
+			ttl, err := a.rdb.TTL(ctx, key).Result()
+			if err != nil || ttl <= 0 {
+				continue
+			}
+			ip := strings.TrimPrefix(key, antiScrapeBlockKeyPrefix)
+			a.blockMu.Lock()
+			a.blocked[ip] = ScrapeBlockEntry{IP: ip, Reason: reason, ExpiresAt: time.Now().Add(ttl)}
+			a.blockMu.Unlock()
+			loaded++
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	if loaded > 0 {
+		log.Info().Int("count", loaded).Msg("Scraper blocklist loaded from Redis")
+	}
+}
+
+func (a *AntiScraper) listen() {
+	defer a.wg.Done()
+	pubsub := a.rdb.Subscribe(a.ctx, antiScrapeChannel)
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case msg := <-ch:
+			if msg == nil {
+				return
+			}
+			a.applyMessage(msg.Payload)
+		}
+	}
+}
+
+// applyMessage 应用其他实例的封禁变更
+func (a *AntiScraper) applyMessage(payload string) {
+	fields := strings.SplitN(payload, "|", 4)
+	switch {
+	case len(fields) == 4 && fields[0] == "block":
+		ts, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return
+		}
+		a.blockMu.Lock()
+		a.blocked[fields[1]] = ScrapeBlockEntry{IP: fields[1], Reason: fields[3], ExpiresAt: time.Unix(ts, 0)}
+		a.blockMu.Unlock()
+	case len(fields) == 2 && fields[0] == "unblock":
+		a.blockMu.Lock()
+		delete(a.blocked, fields[1])
+		a.blockMu.Unlock()
+	}
+}
+
+// cleanup 清理过期封禁和长时间未访问的 IP
+func (a *AntiScraper) cleanup(now time.Time) {
+	a.blockMu.Lock()
+	for ip, entry := range a.blocked {
+		if now.After(entry.ExpiresAt) {
+			delete(a.blocked, ip)
+		}
+	}
+	a.blockMu.Unlock()
+
+	idle := 2 * time.Duration(a.config.WindowSeconds) * time.Second
+	a.mu.Lock()
+	for ip, client := range a.clients {
+		if now.Sub(client.lastSeen) > idle {
+			delete(a.clients, ip)
+		}
+	}
+	a.mu.Unlock()
+
+	a.cleanupBadBots(now)
+}
+
+// Stats 返回统计、当前封禁列表和窗口内请求量最高的 IP
+func (a *AntiScraper) Stats() AntiScrapeStats {
+	stats := AntiScrapeStats{
+		Enabled:          a.Enabled(),
+		Checked:          a.checked.Load(),
+		Allowed:          a.allowed.Load(),
+		Challenged:       a.challenged.Load(),
+		ChallengesPassed: a.passed.Load(),
+		Tarpitted:        a.tarpitted.Load(),
+		Blocked:          a.blockedCount.Load(),
+		HoneypotHits:     a.honeypots.Load(),
+		ActiveTarpits:    a.activeTarpits.Load(),
+		Blocklist:        []ScrapeBlockEntry{},
+		TopOffenders:     []ScrapeOffender{},
+	}
+
+	now := time.Now()
+	a.blockMu.RLock()
+	for _, entry := range a.blocked {
+		if now.Before(entry.ExpiresAt) {
+			stats.Blocklist = append(stats.Blocklist, entry)
+		}
+	}
+	a.blockMu.RUnlock()
+	sort.Slice(stats.Blocklist, func(i, j int) bool {
+		return stats.Blocklist[i].ExpiresAt.After(stats.Blocklist[j].ExpiresAt)
+	})
+
+	a.mu.Lock()
+	stats.Tracked = len(a.clients)
+	for ip, client := range a.clients {
+		stats.TopOffenders = append(stats.TopOffenders, ScrapeOffender{
+			IP:         ip,
+			Requests:   client.requests,
+			Unsolved:   client.unsolved,
+			Tarpitted:  client.tarpitted,
+			LastUA:     client.lastUA,
+			LastPath:   client.lastPath,
+			WindowFrom: client.windowStart.Unix(),
+		})
+	}
+	a.mu.Unlock()
+	sort.Slice(stats.TopOffenders, func(i, j int) bool {
+		return stats.TopOffenders[i].Requests > stats.TopOffenders[j].Requests
+	})
+	if len(stats.TopOffenders) > antiScrapeTopOffenders {
+		stats.TopOffenders = stats.TopOffenders[:antiScrapeTopOffenders]
+	}
+	return stats
+}
@@ -0,0 +1,309 @@
+// Package core provides paginated archive (list/category) pages built from the URL strategy space
+package core
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"seo-generator/api/internal/model"
+	"seo-generator/api/pkg/config"
+)
+
+// archiveCategorySalt 分类关键词序号偏移，与 URL 锚文本错开
+const archiveCategorySalt = 0x5bd1e995
+
+// archiveCategoryTTL 站点分类缓存时间（关键词池变化后最迟在此时间后生效）
+const archiveCategoryTTL = 10 * time.Minute
+
+func init() {
+	// archive_list() / archive_pagination()：列表页的链接列表和分页导航（非列表页输出空）
+	// archive_categories()：各分类第一页的链接（任意页面可用，便于蜘蛛发现列表页）
+	RegisterTemplateFuncPack(TemplateFuncPack{
+		Name: "archive",
+		Funcs: []TemplateFunc{
+			{Name: "archive_list", Arity: 0, Cost: 3, Impl: func(_ *TemplateFuncsManager, data *RenderData, _ []string) string {
+				if data == nil || data.Archive == nil {
+					return ""
+				}
+				return data.Archive.ListHTML()
+			}},
+			{Name: "archive_pagination", Arity: 0, Cost: 1, Impl: func(_ *TemplateFuncsManager, data *RenderData, _ []string) string {
+				if data == nil || data.Archive == nil {
+					return ""
+				}
+				return data.Archive.PaginationHTML()
+			}},
+			{Name: "archive_categories", Arity: 0, Cost: 2, Impl: func(_ *TemplateFuncsManager, data *RenderData, _ []string) string {
+				if data == nil || data.ArchiveLinks == nil {
+					return ""
+				}
+				return data.ArchiveLinks()
+			}},
+		},
+	})
+}
+
+// ArchiveCategory 列表页分类
+type ArchiveCategory struct {
+	Index   int    `json:"index"`
+	Keyword string `json:"keyword"`
+	Slug    string `json:"slug"`
+	URL     string `json:"url"` // 第一页
+}
+
+// ArchivePage 一个列表页
+type ArchivePage struct {
+	Category   ArchiveCategory `json:"category"`
+	Page       int             `json:"page"`
+	TotalPages int             `json:"total_pages"`
+	Items      []URLTarget     `json:"items"`
+	Title      string          `json:"title"`
+
+	prefix string
+}
+
+// PageURL 分类第 page 页的路径
+func (p *ArchivePage) PageURL(page int) string {
+	return archivePageURL(p.prefix, p.Category.Slug, page)
+}
+
+// ListHTML 链接列表
+func (p *ArchivePage) ListHTML() string {
+	var b strings.Builder
+	b.WriteString(`<ul class="archive-list">`)
+	for _, item := range p.Items {
+		b.WriteString("<li>" + internalLinkHTML(item.URL, item.Anchor) + "</li>")
+	}
+	b.WriteString("</ul>")
+	return b.String()
+}
+
+// PaginationHTML 分页导航：首页、上一页、当前页前后各 2 页、下一页、末页
+func (p *ArchivePage) PaginationHTML() string {
+	if p.TotalPages <= 1 {
+		return ""
+	}
+	var b strings.Builder
+	link := func(page int, text string) {
+		b.WriteString(`<a href="` + html.EscapeString(p.PageURL(page)) + `">` + text + `</a>`)
+	}
+	b.WriteString(`<div class="archive-pages">`)
+	if p.Page > 1 {
+		link(1, "首页")
+		link(p.Page-1, "上一页")
+	}
+	for n := max(1, p.Page-2); n <= min(p.TotalPages, p.Page+2); n++ {
+		if n == p.Page {
+			b.WriteString(`<span class="current">` + strconv.Itoa(n) + `</span>`)
+			continue
+		}
+		link(n, strconv.Itoa(n))
+	}
+	if p.Page < p.TotalPages {
+		link(p.Page+1, "下一页")
+		link(p.TotalPages, "末页")
+	}
+	b.WriteString("</div>")
+	return b.String()
+}
+
+type archiveCategoryCache struct {
+	categories []ArchiveCategory
+	bySlug     map[string]int
+	expires    time.Time
+}
+
+// ArchivePages 归档列表页
+//
+// 站点有 categories 个分类（分类名取关键词分组中按域名偏移的关键词，slug 为拼音），
+// URL 策略的第 n 个 URL 属于第 n % categories 个分类，分类内按序号分页，
+// 因此同一站点的分页内容固定，且所有列表页合起来覆盖 URL 策略的全部 URL（受 max_pages 限制）
+type ArchivePages struct {
+	cfg  config.ArchiveConfig
+	urls *URLStrategyManager
+
+	mu    sync.Mutex
+	cache map[string]*archiveCategoryCache // domain+关键词分组 -> 分类
+}
+
+// NewArchivePages 创建归档列表页
+func NewArchivePages(cfg config.ArchiveConfig, urls *URLStrategyManager) *ArchivePages {
+	if cfg.Prefix == "" {
+		cfg.Prefix = "/list/"
+	}
+	if !strings.HasPrefix(cfg.Prefix, "/") {
+		cfg.Prefix = "/" + cfg.Prefix
+	}
+	if !strings.HasSuffix(cfg.Prefix, "/") {
+		cfg.Prefix += "/"
+	}
+	if cfg.Categories <= 0 {
+		cfg.Categories = 20
+	}
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = 30
+	}
+	if cfg.MaxPages <= 0 {
+		cfg.MaxPages = 50
+	}
+	return &ArchivePages{cfg: cfg, urls: urls, cache: make(map[string]*archiveCategoryCache)}
+}
+
+// Template 列表页模板名（为空时使用站点模板）
+func (a *ArchivePages) Template() string {
+	return a.cfg.Template
+}
+
+// IsArchivePath 路径是否在列表页前缀下
+func (a *ArchivePages) IsArchivePath(path string) bool {
+	return a != nil && strings.HasPrefix(path, a.cfg.Prefix)
+}
+
+func archivePageURL(prefix, slug string, page int) string {
+	return prefix + slug + "/" + strconv.Itoa(page) + ".html"
+}
+
+// Categories 站点的分类
+func (a *ArchivePages) Categories(site *models.Site, keywordGroupID int) []ArchiveCategory {
+	return a.categories(site, keywordGroupID).categories
+}
+
+func (a *ArchivePages) categories(site *models.Site, keywordGroupID int) *archiveCategoryCache {
+	key := site.Domain + "\x00" + strconv.Itoa(keywordGroupID)
+	now := time.Now()
+	a.mu.Lock()
+	cached, ok := a.cache[key]
+	a.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached
+	}
+
+	s := a.urls.Strategy(site.SiteGroupID)
+	dh := domainHash(site.Domain)
+	cc := &archiveCategoryCache{
+		categories: make([]ArchiveCategory, 0, a.cfg.Categories),
+		bySlug:     make(map[string]int, a.cfg.Categories),
+		expires:    now.Add(archiveCategoryTTL),
+	}
+	for i := 0; i < a.cfg.Categories; i++ {
+		keyword, _ := a.urls.funcs.KeywordAt(keywordGroupID, dh+archiveCategorySalt+uint64(i))
+		slug := a.urls.slug(keyword, s)
+		if slug == "" {
+			slug = "c" + strconv.Itoa(i+1)
+		}
+		if _, dup := cc.bySlug[slug]; dup {
+			slug += s.SlugSeparator + strconv.Itoa(i+1)
+		}
+		cc.bySlug[slug] = i
+		cc.categories = append(cc.categories, ArchiveCategory{
+			Index:   i,
+			Keyword: keyword,
+			Slug:    slug,
+			URL:     archivePageURL(a.cfg.Prefix, slug, 1),
+		})
+	}
+
+	a.mu.Lock()
+	if len(a.cache) > 10000 {
+		clear(a.cache)
+	}
+	a.cache[key] = cc
+	a.mu.Unlock()
+	return cc
+}
+
+// totalPages 分类的页数
+func (a *ArchivePages) totalPages(size, index int) int {
+	items := 0
+	if index < size {
+		items = (size - index + a.cfg.Categories - 1) / a.cfg.Categories
+	}
+	return min((items+a.cfg.PageSize-1)/a.cfg.PageSize, a.cfg.MaxPages)
+}
+
+// Match 解析列表页路径，不是有效的列表页（分类不存在、页码超出）时返回 nil
+func (a *ArchivePages) Match(site *models.Site, keywordGroupID int, path string) *ArchivePage {
+	if !a.IsArchivePath(path) {
+		return nil
+	}
+	rest := strings.TrimPrefix(path, a.cfg.Prefix)
+	slug, file, ok := strings.Cut(rest, "/")
+	pageStr, isHTML := strings.CutSuffix(file, ".html")
+	if !ok || !isHTML {
+		return nil
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil || page < 1 || strconv.Itoa(page) != pageStr {
+		return nil
+	}
+	cc := a.categories(site, keywordGroupID)
+	index, ok := cc.bySlug[slug]
+	if !ok {
+		return nil
+	}
+	size := a.urls.Size(site, keywordGroupID)
+	total := a.totalPages(size, index)
+	if page > total {
+		return nil
+	}
+
+	s := a.urls.Strategy(site.SiteGroupID)
+	dh := domainHash(site.Domain)
+	start := index + a.cfg.Categories*(page-1)*a.cfg.PageSize
+	items := make([]URLTarget, 0, a.cfg.PageSize)
+	for j := 0; j < a.cfg.PageSize; j++ {
+		n := start + j*a.cfg.Categories
+		if n >= size {
+			break
+		}
+		items = append(items, a.urls.at(site, s, keywordGroupID, dh, uint64(n)))
+	}
+
+	category := cc.categories[index]
+	title := category.Keyword
+	if page > 1 {
+		title += " - 第" + strconv.Itoa(page) + "页"
+	}
+	return &ArchivePage{
+		Category:   category,
+		Page:       page,
+		TotalPages: total,
+		Items:      items,
+		Title:      title,
+		prefix:     a.cfg.Prefix,
+	}
+}
+
+// CategoriesFunc 返回页面渲染用的 archive_categories 生成器，输出各分类第一页的链接
+func (a *ArchivePages) CategoriesFunc(site *models.Site, keywordGroupID int) func() string {
+	return func() string {
+		var b strings.Builder
+		b.WriteString(`<ul class="archive-categories">`)
+		for _, c := range a.Categories(site, keywordGroupID) {
+			b.WriteString("<li>" + internalLinkHTML(c.URL, c.Keyword) + "</li>")
+		}
+		b.WriteString("</ul>")
+		return b.String()
+	}
+}
+
+// SitemapURLs 所有列表页路径（未启用 sitemap 时为 nil），最多 limit 个
+func (a *ArchivePages) SitemapURLs(site *models.Site, keywordGroupID int, limit int) []string {
+	if a == nil || !a.cfg.Sitemap {
+		return nil
+	}
+	size := a.urls.Size(site, keywordGroupID)
+	var urls []string
+	for _, c := range a.Categories(site, keywordGroupID) {
+		for page := 1; page <= a.totalPages(size, c.Index); page++ {
+			if len(urls) >= limit {
+				return urls
+			}
+			urls = append(urls, archivePageURL(a.cfg.Prefix, c.Slug, page))
+		}
+	}
+	return urls
+}
@@ -0,0 +1,300 @@
+// Package core provides article summary (excerpt) generation and backfill
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// TaskTypeBackfillSummaries 回填文章摘要任务类型
+const TaskTypeBackfillSummaries TaskType = "backfill_summaries"
+
+// 摘要提取策略
+const (
+	ExcerptStrategySentences = "sentences" // 全文前 N 句
+	ExcerptStrategyParagraph = "paragraph" // 第一个足够长的段落
+)
+
+// summaryMaxLength original_articles.summary 列长度
+const summaryMaxLength = 500
+
+// ErrSummaryBackfillRunning 已有回填在执行
+var ErrSummaryBackfillRunning = errors.New("summary backfill already running")
+
+var (
+	excerptDropPattern  = regexp.MustCompile(`(?is)<(?:script|style|noscript)\b.*?</(?:script|style|noscript)\s*>`)
+	excerptBlockPattern = regexp.MustCompile(`(?i)</?(?:p|div|br|h[1-6]|li|ul|ol|tr|table|blockquote|section|article|pre|figure|figcaption)\b[^>]*>`)
+)
+
+func init() {
+	// summary()：当前页面正文的摘要（纯文本，已转义）
+	RegisterTemplateFuncPack(TemplateFuncPack{
+		Name: "excerpt",
+		Funcs: []TemplateFunc{
+			{Name: "summary", Arity: 0, Cost: 1, Impl: func(_ *TemplateFuncsManager, data *RenderData, _ []string) string {
+				if data == nil {
+					return ""
+				}
+				return html.EscapeString(data.Summary)
+			}},
+		},
+	})
+}
+
+// ExcerptGenerator 从正文生成摘要：去掉 HTML 后取前 N 句或第一个段落，可配置优先使用的提取正则
+type ExcerptGenerator struct {
+	cfg     config.ExcerptConfig
+	pattern *regexp.Regexp
+}
+
+// NewExcerptGenerator 创建摘要生成器，未启用时返回 nil
+func NewExcerptGenerator(cfg config.ExcerptConfig) (*ExcerptGenerator, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.Sentences <= 0 {
+		cfg.Sentences = 2
+	}
+	if cfg.MaxLength <= 0 || cfg.MaxLength > summaryMaxLength {
+		cfg.MaxLength = summaryMaxLength
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	switch cfg.Strategy {
+	case "":
+		cfg.Strategy = ExcerptStrategySentences
+	case ExcerptStrategySentences, ExcerptStrategyParagraph:
+	default:
+		return nil, fmt.Errorf("excerpt: unknown strategy %q", cfg.Strategy)
+	}
+	g := &ExcerptGenerator{cfg: cfg}
+	if cfg.Pattern != "" {
+		re, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("excerpt: invalid pattern: %w", err)
+		}
+		if re.NumSubexp() < 1 {
+			return nil, fmt.Errorf("excerpt: pattern needs a capture group")
+		}
+		g.pattern = re
+	}
+	return g, nil
+}
+
+// Generate 生成摘要（纯文本），g 为 nil 或正文为空时返回空串
+func (g *ExcerptGenerator) Generate(content string) string {
+	if g == nil || strings.TrimSpace(content) == "" {
+		return ""
+	}
+	if g.pattern != nil {
+		if m := g.pattern.FindStringSubmatch(content); len(m) > 1 {
+			if text := strings.Join(excerptParagraphs(m[1]), " "); text != "" {
+				return g.clip(text)
+			}
+		}
+	}
+
+	paragraphs := excerptParagraphs(content)
+	if g.cfg.Strategy == ExcerptStrategyParagraph {
+		for _, p := range paragraphs {
+			if utf8.RuneCountInString(p) >= g.cfg.MinLength {
+				return g.clip(p)
+			}
+		}
+	}
+	return g.clip(firstSentences(strings.Join(paragraphs, " "), g.cfg.Sentences))
+}
+
+// clip 超过最大长度时截断并加省略号
+func (g *ExcerptGenerator) clip(s string) string {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) <= g.cfg.MaxLength {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimRightFunc(string(runes[:g.cfg.MaxLength-1]), unicode.IsSpace) + "…"
+}
+
+// excerptParagraphs 去掉 HTML 标签，按块级标签和换行拆成段落（合并空白、去掉空段落）
+func excerptParagraphs(content string) []string {
+	text := excerptDropPattern.ReplaceAllString(content, "")
+	text = excerptBlockPattern.ReplaceAllString(text, "\n")
+	text = html.UnescapeString(tdkTagPattern.ReplaceAllString(text, ""))
+
+	var paragraphs []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			paragraphs = append(paragraphs, line)
+		}
+	}
+	return paragraphs
+}
+
+// firstSentences 前 n 句（中文句末标点，或后跟空白的英文句号、问号、叹号）
+func firstSentences(text string, n int) string {
+	count := 0
+	for i, r := range text {
+		end := false
+		switch r {
+		case '。', '！', '？', '；', '…':
+			end = true
+		case '.', '!', '?', ';':
+			next, _ := utf8.DecodeRuneInString(text[i+1:])
+			end = i+1 == len(text) || unicode.IsSpace(next)
+		}
+		if !end {
+			continue
+		}
+		// 连续的句末标点（如 ！！、……）算作同一句
+		if next, _ := utf8.DecodeRuneInString(text[i+utf8.RuneLen(r):]); strings.ContainsRune("。！？；….!?;", next) {
+			continue
+		}
+		if count++; count >= n {
+			return text[:i+utf8.RuneLen(r)]
+		}
+	}
+	return text
+}
+
+// SummaryBackfillResult 回填结果
+type SummaryBackfillResult struct {
+	Scanned int64 `json:"scanned"`
+	Updated int64 `json:"updated"`
+	Empty   int64 `json:"empty"` // 正文无文字，写入空摘要（不再重复处理）
+}
+
+// SummaryBackfill 为 summary 为空（NULL）的文章生成摘要（爬虫直接写库的文章和历史数据）
+type SummaryBackfill struct {
+	db      *sqlx.DB
+	gen     *ExcerptGenerator
+	jobs    *JobManager
+	running sync.Mutex
+}
+
+// NewSummaryBackfill 创建摘要回填
+func NewSummaryBackfill(db *sqlx.DB, gen *ExcerptGenerator, jobs *JobManager) *SummaryBackfill {
+	return &SummaryBackfill{db: db, gen: gen, jobs: jobs}
+}
+
+// Submit 提交回填作业
+func (b *SummaryBackfill) Submit(ctx context.Context) (int64, error) {
+	if b.jobs == nil {
+		return 0, fmt.Errorf("job manager not available")
+	}
+	return b.jobs.SubmitFunc(ctx, string(TaskTypeBackfillSummaries), map[string]interface{}{}, func(jc *JobContext) (any, error) {
+		return b.Run(jc, jc.Advance)
+	})
+}
+
+// Run 按 id 顺序回填全部缺少摘要的文章，progress 可为 nil
+func (b *SummaryBackfill) Run(ctx context.Context, progress func(n int64)) (*SummaryBackfillResult, error) {
+	if !b.running.TryLock() {
+		return nil, ErrSummaryBackfillRunning
+	}
+	defer b.running.Unlock()
+	if progress == nil {
+		progress = func(int64) {}
+	}
+
+	result := &SummaryBackfillResult{}
+	var lastID int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		var rows []struct {
+			ID      int64  `db:"id"`
+			Content string `db:"content"`
+		}
+		if err := b.db.SelectContext(ctx, &rows,
+			"SELECT id, content FROM original_articles WHERE id > ? AND summary IS NULL ORDER BY id LIMIT ?",
+			lastID, b.gen.cfg.BatchSize); err != nil {
+			return result, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		tx, err := b.db.BeginTxx(ctx, nil)
+		if err != nil {
+			return result, err
+		}
+		for _, row := range rows {
+			summary := b.gen.Generate(row.Content)
+			if _, err := tx.ExecContext(ctx,
+				"UPDATE original_articles SET summary = ? WHERE id = ? AND summary IS NULL", summary, row.ID); err != nil {
+				tx.Rollback()
+				return result, fmt.Errorf("update summary of article %d: %w", row.ID, err)
+			}
+			if summary == "" {
+				result.Empty++
+			} else {
+				result.Updated++
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return result, err
+		}
+		result.Scanned += int64(len(rows))
+		lastID = rows[len(rows)-1].ID
+		progress(int64(len(rows)))
+	}
+
+	if result.Scanned > 0 {
+		log.Info().Int64("updated", result.Updated).Int64("empty", result.Empty).Msg("Article summaries backfilled")
+	}
+	return result, nil
+}
+
+// EnsureSchedule 按配置创建或更新回填定时任务
+func (b *SummaryBackfill) EnsureSchedule(ctx context.Context, scheduler *Scheduler) error {
+	var existing struct {
+		ID       int64  `db:"id"`
+		CronExpr string `db:"cron_expr"`
+		Enabled  bool   `db:"enabled"`
+	}
+	err := b.db.GetContext(ctx, &existing,
+		"SELECT id, cron_expr, enabled FROM scheduled_tasks WHERE task_type = ? LIMIT 1", TaskTypeBackfillSummaries)
+	exists := err == nil && existing.ID > 0
+
+	schedule := b.gen.cfg.Schedule
+	if schedule == "" {
+		if exists {
+			return scheduler.DeleteTask(ctx, existing.ID)
+		}
+		return nil
+	}
+
+	task := &ScheduledTask{
+		Name:     "文章摘要回填",
+		TaskType: TaskTypeBackfillSummaries,
+		CronExpr: schedule,
+		Params:   json.RawMessage("{}"),
+		Enabled:  true,
+	}
+	if exists {
+		// 保留后台手动设置的启用状态，只同步 Cron 表达式
+		if existing.CronExpr == schedule {
+			return nil
+		}
+		task.ID = existing.ID
+		task.Enabled = existing.Enabled
+		return scheduler.UpdateTask(ctx, task)
+	}
+	_, err = scheduler.CreateTask(ctx, task)
+	return err
+}
@@ -0,0 +1,327 @@
+package core
+
+import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// PendingArticlesQueue 待加工文章队列（Python Worker 消费）
+const PendingArticlesQueue = "pending:articles"
+
+// 批量导入冲突策略：已存在匹配文章时的处理方式
+const (
+	ConflictSkip      = "skip"      // 跳过（默认）
+	ConflictOverwrite = "overwrite" // 覆盖已有文章的标题和正文
+	ConflictVersion   = "version"   // 作为新版本插入（version + 1，previous_id 指向上一版本）
+)
+
+// 判断冲突的字段
+const (
+	ConflictKeyTitle       = "title"        // 同分组同标题（默认，与唯一索引一致）
+	ConflictKeyContentHash = "content_hash" // 同分组正文 MD5 相同
+	ConflictKeySourceURL   = "source_url"   // 同分组来源 URL 相同（未提供 source_url 时按标题）
+)
+
+// 单篇文章的导入结果
+const (
+	OutcomeAdded     = "added"
+	OutcomeSkipped   = "skipped"
+	OutcomeUpdated   = "updated"
+	OutcomeVersioned = "versioned"
+	OutcomeUnchanged = "unchanged" // 覆盖 / 新版本时内容与已有文章相同
+	OutcomeConflict  = "conflict"  // 写入时与其他文章的唯一索引冲突
+	OutcomeRejected  = "rejected"  // 违禁词
+	OutcomeInvalid   = "invalid"   // 标题或正文为空
+	OutcomeError     = "error"
+)
+
+// ArticleImportOutcome 批量添加中单篇文章的结果
+type ArticleImportOutcome struct {
+	Index      int    `json:"index"`
+	Outcome    string `json:"outcome"`
+	ID         int64  `json:"id,omitempty"`          // 新增、覆盖或新版本文章的 ID
+	ExistingID int64  `json:"existing_id,omitempty"` // 匹配到的已有文章
+	Message    string `json:"message,omitempty"`
+}
+
+// Written 是否写入了需要（重新）加工的文章
+func (o ArticleImportOutcome) Written() bool {
+	return o.ID != 0 && o.Outcome != OutcomeUnchanged
+}
+
+// ValidConflictStrategy 策略和冲突字段是否有效（空值表示使用默认）
+// 按正文哈希匹配时不能作为新版本插入：正文相同即未变化，正文不同则匹配不到，
+// 且 (group_id, content_hash) 唯一索引不允许两个版本正文相同
+func ValidConflictStrategy(strategy, key string) error {
+	switch strategy {
+	case "", ConflictSkip, ConflictOverwrite, ConflictVersion:
+	default:
+		return fmt.Errorf("无效的冲突策略: %s", strategy)
+	}
+	switch key {
+	case "", ConflictKeyTitle, ConflictKeyContentHash, ConflictKeySourceURL:
+	default:
+		return fmt.Errorf("无效的冲突字段: %s", key)
+	}
+	if strategy == ConflictVersion && key == ConflictKeyContentHash {
+		return fmt.Errorf("冲突策略 version 不能与冲突字段 content_hash 同时使用")
+	}
+	return nil
+}
+
+// ArticleContentHash original_articles.content_hash
+func ArticleContentHash(content string) string {
+	sum := md5.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// IngestArticle 待入库的一篇文章（HTTP 添加接口、爬虫数据项、gRPC SubmitItems）
+type IngestArticle struct {
+	GroupID   int
+	SourceID  int // 爬虫项目 ID，手工添加为 0
+	Title     string
+	Content   string
+	SourceURL string
+	Tags      string
+}
+
+// ArticleIngester 原始文章入库流程：正文清洗 → 违禁词过滤 → 摘要 → 按冲突策略写入，
+// 写入后由 IngestBatch 按分组设置提交 LLM 改写或推入待处理队列
+// 所有写入 original_articles 的入口都应经过这里，保证清洗、过滤和冲突处理一致
+type ArticleIngester struct {
+	db        *sqlx.DB
+	rdb       *redis.Client
+	filter    *ContentFilter
+	sanitizer *ArticleSanitizer
+	excerpts  *ExcerptGenerator
+	rewriter  *ArticleRewriter
+}
+
+// NewArticleIngester 创建入库流程，filter / sanitizer / excerpts / rewriter 为 nil 时跳过对应步骤
+func NewArticleIngester(db *sqlx.DB, rdb *redis.Client, filter *ContentFilter, sanitizer *ArticleSanitizer, excerpts *ExcerptGenerator, rewriter *ArticleRewriter) *ArticleIngester {
+	return &ArticleIngester{db: db, rdb: rdb, filter: filter, sanitizer: sanitizer, excerpts: excerpts, rewriter: rewriter}
+}
+
+// Summary 入库时生成摘要；未启用摘要时返回 NULL，由回填任务处理
+func (ing *ArticleIngester) Summary(content string) interface{} {
+	if ing.excerpts == nil {
+		return nil
+	}
+	return ing.excerpts.Generate(content)
+}
+
+// Ingest 清洗、过滤并按冲突策略写入一篇文章，清洗统计累加到 sanitized
+// strategy / key 为空时使用 skip / title
+func (ing *ArticleIngester) Ingest(ctx context.Context, a IngestArticle, strategy, key string, sanitized *SanitizeResult) ArticleImportOutcome {
+	if a.Title == "" || a.Content == "" {
+		return ArticleImportOutcome{Outcome: OutcomeInvalid, Message: "标题和正文不能为空"}
+	}
+	if a.GroupID <= 0 {
+		a.GroupID = 1
+	}
+	if strategy == "" {
+		strategy = ConflictSkip
+	}
+	if key == "" {
+		key = ConflictKeyTitle
+	}
+
+	if ing.sanitizer != nil {
+		var cleaned *SanitizeResult
+		a.Content, cleaned = ing.sanitizer.Sanitize(a.GroupID, a.Content, a.SourceURL)
+		if sanitized != nil {
+			sanitized.Add(cleaned)
+		}
+	}
+	if strings.TrimSpace(a.Content) == "" {
+		return ArticleImportOutcome{Outcome: OutcomeInvalid, Message: "清洗后正文为空"}
+	}
+
+	if ing.filter != nil {
+		var result *FilterResult
+		if a.Title, result = ing.filter.Apply(a.GroupID, a.Title, FilterSourceInsert); result.Rejected {
+			return ArticleImportOutcome{Outcome: OutcomeRejected, Message: "包含违禁词"}
+		}
+		if a.Content, result = ing.filter.Apply(a.GroupID, a.Content, FilterSourceInsert); result.Rejected {
+			return ArticleImportOutcome{Outcome: OutcomeRejected, Message: "包含违禁词"}
+		}
+	}
+	return ing.importArticle(ctx, a, strategy, key)
+}
+
+// existingArticle 冲突匹配到的已有文章（最新版本）
+type existingArticle struct {
+	ID          int64          `db:"id"`
+	Title       string         `db:"title"`
+	ContentHash sql.NullString `db:"content_hash"`
+	Version     int            `db:"version"`
+}
+
+// findConflict 按冲突字段查找同分组的已有文章，没有时返回 nil
+func (ing *ArticleIngester) findConflict(ctx context.Context, groupID int, key, title, hash, sourceURL string) (*existingArticle, error) {
+	column, value := "title", title
+	switch {
+	case key == ConflictKeyContentHash:
+		column, value = "content_hash", hash
+	case key == ConflictKeySourceURL && sourceURL != "":
+		column, value = "source_url", sourceURL
+	}
+	var existing existingArticle
+	err := ing.db.GetContext(ctx, &existing,
+		"SELECT id, title, content_hash, version FROM original_articles WHERE group_id = ? AND "+column+" = ? ORDER BY version DESC LIMIT 1",
+		groupID, value)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// importArticle 按冲突策略写入一篇已清洗、过滤的文章
+func (ing *ArticleIngester) importArticle(ctx context.Context, a IngestArticle, strategy, key string) ArticleImportOutcome {
+	hash := ArticleContentHash(a.Content)
+	existing, err := ing.findConflict(ctx, a.GroupID, key, a.Title, hash, a.SourceURL)
+	if err != nil {
+		return ArticleImportOutcome{Outcome: OutcomeError, Message: err.Error()}
+	}
+
+	if existing == nil {
+		return ing.insertArticle(ctx, a, hash, 1, nil, OutcomeAdded)
+	}
+
+	out := ArticleImportOutcome{ExistingID: existing.ID}
+	unchanged := existing.ContentHash.Valid && existing.ContentHash.String == hash
+	switch strategy {
+	case ConflictOverwrite:
+		if unchanged && existing.Title == a.Title {
+			out.Outcome = OutcomeUnchanged
+			return out
+		}
+		// 正文变化后清空摘要和改写时间，由回填和改写流程重新处理
+		result, err := ing.db.ExecContext(ctx,
+			`UPDATE IGNORE original_articles SET title = ?, content = ?, content_hash = ?,
+			 source_url = COALESCE(NULLIF(?, ''), source_url), tags = COALESCE(NULLIF(?, ''), tags),
+			 summary = ?, rewritten_at = NULL WHERE id = ?`,
+			a.Title, a.Content, hash, a.SourceURL, a.Tags, ing.Summary(a.Content), existing.ID)
+		if err != nil {
+			out.Outcome, out.Message = OutcomeError, err.Error()
+			return out
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			out.Outcome, out.Message = OutcomeConflict, "标题或正文与其他文章重复"
+			return out
+		}
+		out.Outcome, out.ID = OutcomeUpdated, existing.ID
+		return out
+	case ConflictVersion:
+		if unchanged {
+			out.Outcome = OutcomeUnchanged
+			return out
+		}
+		inserted := ing.insertArticle(ctx, a, hash, existing.Version+1, &existing.ID, OutcomeVersioned)
+		inserted.ExistingID = existing.ID
+		return inserted
+	default:
+		out.Outcome = OutcomeSkipped
+		return out
+	}
+}
+
+// insertArticle 插入文章，唯一索引冲突（标题或正文重复）时返回 skipped 或 conflict
+func (ing *ArticleIngester) insertArticle(ctx context.Context, a IngestArticle, hash string, version int, previousID *int64, outcome string) ArticleImportOutcome {
+	var sourceID interface{}
+	if a.SourceID > 0 {
+		sourceID = a.SourceID
+	}
+	result, err := ing.db.ExecContext(ctx,
+		`INSERT IGNORE INTO original_articles (group_id, source_id, title, content, content_hash, source_url, tags, summary, version, previous_id)
+		 VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?)`,
+		a.GroupID, sourceID, a.Title, a.Content, hash, a.SourceURL, a.Tags, ing.Summary(a.Content), version, previousID)
+	if err != nil {
+		return ArticleImportOutcome{Outcome: OutcomeError, Message: err.Error()}
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		if outcome == OutcomeAdded {
+			return ArticleImportOutcome{Outcome: OutcomeSkipped, Message: "标题或正文已存在"}
+		}
+		return ArticleImportOutcome{Outcome: OutcomeConflict, Message: "标题或正文与其他文章重复"}
+	}
+	id, _ := result.LastInsertId()
+	return ArticleImportOutcome{Outcome: outcome, ID: id}
+}
+
+// IngestBatch 收集一批写入的文章，Flush 时启用 LLM 改写的分组先改写再入队，其余直接推入待处理队列
+type IngestBatch struct {
+	ing     *ArticleIngester
+	queue   []int64
+	rewrite []int64
+}
+
+// NewBatch 创建入库批次
+func (ing *ArticleIngester) NewBatch() *IngestBatch {
+	return &IngestBatch{ing: ing}
+}
+
+// Add 记录一篇文章的入库结果（新增、覆盖和新版本的文章都需要重新加工）
+func (b *IngestBatch) Add(groupID int, out ArticleImportOutcome) {
+	if !out.Written() {
+		return
+	}
+	if groupID <= 0 {
+		groupID = 1
+	}
+	if b.ing.rewriter != nil && b.ing.rewriter.Enabled(groupID) {
+		b.rewrite = append(b.rewrite, out.ID)
+	} else {
+		b.queue = append(b.queue, out.ID)
+	}
+}
+
+// Rewriting 等待 LLM 改写的文章数
+func (b *IngestBatch) Rewriting() int {
+	return len(b.rewrite)
+}
+
+// Flush 推入待处理队列并提交改写作业，返回改写作业 ID（没有需要改写的文章或提交失败时为 0）
+func (b *IngestBatch) Flush(ctx context.Context) int64 {
+	PushPendingArticles(b.ing.rdb, b.queue)
+	b.queue = nil
+	if len(b.rewrite) == 0 {
+		return 0
+	}
+	ids := b.rewrite
+	b.rewrite = nil
+	jobID, err := b.ing.rewriter.Submit(ctx, ids, true, func(done []int64) {
+		PushPendingArticles(b.ing.rdb, done)
+	})
+	if err != nil {
+		log.Warn().Err(err).Int("count", len(ids)).Msg("提交 LLM 改写作业失败，文章直接入队")
+		PushPendingArticles(b.ing.rdb, ids)
+		return 0
+	}
+	return jobID
+}
+
+// PushPendingArticles 将文章 ID 批量推入待处理队列
+func PushPendingArticles(rdb *redis.Client, ids []int64) {
+	if rdb == nil || len(ids) == 0 {
+		return
+	}
+	vals := make([]interface{}, len(ids))
+	for i, id := range ids {
+		vals[i] = id
+	}
+	if err := rdb.LPush(context.Background(), PendingArticlesQueue, vals...).Err(); err != nil {
+		log.Warn().Err(err).Int("count", len(ids)).Msg("批量推送文章到待处理队列失败")
+	}
+}
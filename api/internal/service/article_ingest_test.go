@@ -0,0 +1,30 @@
+package core
+
+import "testing"
+
+func TestValidConflictStrategy(t *testing.T) {
+	tests := []struct {
+		strategy string
+		key      string
+		wantErr  bool
+	}{
+		{"", "", false},
+		{ConflictSkip, ConflictKeyContentHash, false},
+		{ConflictOverwrite, ConflictKeyContentHash, false},
+		{ConflictVersion, ConflictKeyTitle, false},
+		{ConflictVersion, ConflictKeySourceURL, false},
+		{ConflictVersion, "", false},
+		// 按正文哈希匹配时不存在“新版本”：正文相同即未变化，且唯一索引不允许两个版本正文相同
+		{ConflictVersion, ConflictKeyContentHash, true},
+		{"replace", "", true},
+		{"", "url", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy+"/"+tt.key, func(t *testing.T) {
+			err := ValidConflictStrategy(tt.strategy, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidConflictStrategy(%q, %q) err = %v, wantErr %v", tt.strategy, tt.key, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,399 @@
+// Package core provides allowlist-based HTML cleaning of ingested articles
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// SanitizePolicy 文章分组的清洗策略
+type SanitizePolicy struct {
+	GroupID     int    `db:"group_id" json:"group_id"`
+	Enabled     bool   `db:"enabled" json:"enabled"`
+	BaseURL     string `db:"base_url" json:"base_url"`         // 解析相对链接的基准 URL（请求未带 source_url 时使用）
+	ImageProxy  string `db:"image_proxy" json:"image_proxy"`   // 图片代理地址，{url} 替换为转义后的原图地址，为空时不改写
+	IframeHosts string `db:"iframe_hosts" json:"iframe_hosts"` // 允许保留的 iframe 域名（逗号分隔，含子域名）
+	KeepLinks   bool   `db:"keep_links" json:"keep_links"`     // 保留 <a> 链接，关闭时只保留链接文字
+}
+
+// defaultSanitizePolicy 未单独配置的分组使用的策略
+func defaultSanitizePolicy(groupID int) SanitizePolicy {
+	return SanitizePolicy{GroupID: groupID, Enabled: true, KeepLinks: true}
+}
+
+// SanitizeResult 清洗统计
+type SanitizeResult struct {
+	Stripped        int            `json:"stripped"`         // 删除的节点数（含注释和整段删除的子树根）
+	StrippedTags    map[string]int `json:"stripped_tags"`    // 按标签统计删除数
+	Unwrapped       int            `json:"unwrapped"`        // 去掉标签只保留内容的节点数
+	StrippedAttrs   int            `json:"stripped_attrs"`   // 删除的属性数（事件、样式、危险链接等）
+	Trackers        int            `json:"trackers"`         // 删除的 1x1 跟踪像素
+	ImagesRewritten int            `json:"images_rewritten"` // 改写为代理地址的图片数
+	LinksResolved   int            `json:"links_resolved"`   // 补全为绝对地址的相对链接数
+}
+
+// Add 累加另一次清洗的统计
+func (r *SanitizeResult) Add(o *SanitizeResult) {
+	r.Stripped += o.Stripped
+	r.Unwrapped += o.Unwrapped
+	r.StrippedAttrs += o.StrippedAttrs
+	r.Trackers += o.Trackers
+	r.ImagesRewritten += o.ImagesRewritten
+	r.LinksResolved += o.LinksResolved
+	for tag, n := range o.StrippedTags {
+		r.StrippedTags[tag] += n
+	}
+}
+
+// NewSanitizeResult 创建空的清洗统计
+func NewSanitizeResult() *SanitizeResult {
+	return &SanitizeResult{StrippedTags: map[string]int{}}
+}
+
+// sanitizeDropTags 连同内容一起删除的标签
+var sanitizeDropTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Iframe: true, atom.Frame: true,
+	atom.Frameset: true, atom.Object: true, atom.Embed: true, atom.Applet: true, atom.Form: true,
+	atom.Input: true, atom.Button: true, atom.Select: true, atom.Textarea: true, atom.Link: true,
+	atom.Meta: true, atom.Base: true, atom.Svg: true, atom.Math: true, atom.Template: true,
+	atom.Head: true, atom.Title: true, atom.Audio: true, atom.Video: true, atom.Canvas: true,
+}
+
+// sanitizeAllowedAttrs 白名单标签及各自允许的属性，不在表中的标签去掉标签保留内容
+var sanitizeAllowedAttrs = map[atom.Atom][]string{
+	atom.P: nil, atom.Br: nil, atom.Hr: nil, atom.Div: nil, atom.Span: nil, atom.Section: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.Strong: nil, atom.B: nil, atom.Em: nil, atom.I: nil, atom.U: nil, atom.S: nil,
+	atom.Del: nil, atom.Ins: nil, atom.Sub: nil, atom.Sup: nil, atom.Mark: nil, atom.Small: nil,
+	atom.Blockquote: nil, atom.Pre: nil, atom.Code: nil,
+	atom.Ul: nil, atom.Ol: nil, atom.Li: nil, atom.Dl: nil, atom.Dt: nil, atom.Dd: nil,
+	atom.Table: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tfoot: nil, atom.Tr: nil, atom.Caption: nil,
+	atom.Th: {"colspan", "rowspan"}, atom.Td: {"colspan", "rowspan"},
+	atom.Figure: nil, atom.Figcaption: nil,
+	atom.A:      {"href", "title"},
+	atom.Img:    {"src", "alt", "title", "width", "height"},
+	atom.Iframe: {"src", "width", "height", "allowfullscreen", "frameborder"},
+}
+
+// ArticleSanitizer 文章入库 HTML 清洗（白名单），策略按文章分组保存在 article_sanitize_policies
+type ArticleSanitizer struct {
+	db       *sqlx.DB
+	policies atomic.Pointer[map[int]SanitizePolicy]
+}
+
+// NewArticleSanitizer 创建文章清洗器
+func NewArticleSanitizer(db *sqlx.DB) *ArticleSanitizer {
+	s := &ArticleSanitizer{db: db}
+	empty := map[int]SanitizePolicy{}
+	s.policies.Store(&empty)
+	return s
+}
+
+// Reload 从数据库重新加载分组策略
+func (s *ArticleSanitizer) Reload(ctx context.Context) error {
+	var rows []SanitizePolicy
+	if err := s.db.SelectContext(ctx, &rows,
+		"SELECT group_id, enabled, base_url, image_proxy, iframe_hosts, keep_links FROM article_sanitize_policies"); err != nil {
+		return fmt.Errorf("load sanitize policies: %w", err)
+	}
+	policies := make(map[int]SanitizePolicy, len(rows))
+	for _, p := range rows {
+		policies[p.GroupID] = p
+	}
+	s.policies.Store(&policies)
+	log.Info().Int("policies", len(policies)).Msg("Article sanitize policies loaded")
+	return nil
+}
+
+// PolicyFor 获取分组的清洗策略
+func (s *ArticleSanitizer) PolicyFor(groupID int) SanitizePolicy {
+	if p, ok := (*s.policies.Load())[groupID]; ok {
+		return p
+	}
+	return defaultSanitizePolicy(groupID)
+}
+
+// SetPolicy 保存分组策略并重新加载
+func (s *ArticleSanitizer) SetPolicy(ctx context.Context, p SanitizePolicy) error {
+	if err := ValidateSanitizePolicy(p); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO article_sanitize_policies (group_id, enabled, base_url, image_proxy, iframe_hosts, keep_links)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON DUPLICATE KEY UPDATE enabled = VALUES(enabled), base_url = VALUES(base_url), image_proxy = VALUES(image_proxy),
+		 iframe_hosts = VALUES(iframe_hosts), keep_links = VALUES(keep_links)`,
+		p.GroupID, p.Enabled, p.BaseURL, p.ImageProxy, p.IframeHosts, p.KeepLinks)
+	if err != nil {
+		return err
+	}
+	return s.Reload(ctx)
+}
+
+// ValidateSanitizePolicy 校验基准 URL 和图片代理地址
+func ValidateSanitizePolicy(p SanitizePolicy) error {
+	if p.BaseURL != "" {
+		if u, err := url.Parse(p.BaseURL); err != nil || !u.IsAbs() || u.Host == "" {
+			return fmt.Errorf("base_url must be an absolute http(s) URL")
+		}
+	}
+	if p.ImageProxy != "" {
+		if !strings.Contains(p.ImageProxy, "{url}") {
+			return fmt.Errorf("image_proxy must contain the {url} placeholder")
+		}
+		if _, err := url.Parse(strings.ReplaceAll(p.ImageProxy, "{url}", "x")); err != nil {
+			return fmt.Errorf("invalid image_proxy: %w", err)
+		}
+	}
+	return nil
+}
+
+// Sanitize 按分组策略清洗正文；sourceURL 为文章原始地址（用于补全相对链接），可为空
+func (s *ArticleSanitizer) Sanitize(groupID int, content, sourceURL string) (string, *SanitizeResult) {
+	return SanitizeArticleHTML(s.PolicyFor(groupID), content, sourceURL)
+}
+
+// SanitizeArticleHTML 白名单清洗 HTML
+//
+// 危险标签（script、iframe、表单等）连同内容删除，未知标签只去掉标签；属性只保留白名单，
+// 链接只允许 http(s)、mailto 和相对地址；不含 '<' 的纯文本原样返回，解析失败时返回空串。
+func SanitizeArticleHTML(policy SanitizePolicy, content, sourceURL string) (string, *SanitizeResult) {
+	result := NewSanitizeResult()
+	if !policy.Enabled || !strings.Contains(content, "<") {
+		return content, result
+	}
+
+	container := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(content), container)
+	if err != nil {
+		// 解析失败时不放行原文
+		return "", result
+	}
+	for _, n := range nodes {
+		container.AppendChild(n)
+	}
+
+	sz := &htmlSanitizer{policy: policy, result: result}
+	if sourceURL != "" {
+		sz.base, _ = url.Parse(sourceURL)
+	}
+	if (sz.base == nil || !sz.base.IsAbs()) && policy.BaseURL != "" {
+		sz.base, _ = url.Parse(policy.BaseURL)
+	}
+	if sz.base != nil && !sz.base.IsAbs() {
+		sz.base = nil
+	}
+	for _, h := range strings.Split(policy.IframeHosts, ",") {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			sz.iframeHosts = append(sz.iframeHosts, h)
+		}
+	}
+	sz.cleanChildren(container)
+
+	var b strings.Builder
+	for c := container.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&b, c); err != nil {
+			return "", result
+		}
+	}
+	return strings.TrimSpace(b.String()), result
+}
+
+type htmlSanitizer struct {
+	policy      SanitizePolicy
+	base        *url.URL
+	iframeHosts []string
+	result      *SanitizeResult
+}
+
+func (sz *htmlSanitizer) cleanChildren(parent *html.Node) {
+	for c := parent.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch c.Type {
+		case html.CommentNode, html.DoctypeNode:
+			parent.RemoveChild(c)
+			sz.strip("#comment")
+		case html.ElementNode:
+			next = sz.cleanElement(parent, c, next)
+		}
+		c = next
+	}
+}
+
+// cleanElement 清洗单个元素，返回下一个待处理的节点（去掉标签时为提升上来的第一个子节点）
+func (sz *htmlSanitizer) cleanElement(parent, n, next *html.Node) *html.Node {
+	if n.DataAtom == atom.Iframe && sz.allowIframe(n) {
+		sz.cleanAttrs(n)
+		removeChildren(n)
+		return next
+	}
+	if sanitizeDropTags[n.DataAtom] || n.DataAtom == 0 && strings.Contains(n.Data, ":") {
+		parent.RemoveChild(n)
+		sz.strip(n.Data)
+		return next
+	}
+	if _, ok := sanitizeAllowedAttrs[n.DataAtom]; !ok || n.DataAtom == atom.A && !sz.policy.KeepLinks {
+		first := n.FirstChild
+		for c := n.FirstChild; c != nil; c = n.FirstChild {
+			n.RemoveChild(c)
+			parent.InsertBefore(c, n)
+		}
+		parent.RemoveChild(n)
+		sz.result.Unwrapped++
+		if first != nil {
+			return first
+		}
+		return next
+	}
+
+	sz.cleanAttrs(n)
+	if n.DataAtom == atom.Img {
+		if isTrackingPixel(n) {
+			parent.RemoveChild(n)
+			sz.result.Trackers++
+			sz.strip("img")
+			return next
+		}
+		if attrValue(n, "src") == "" {
+			parent.RemoveChild(n)
+			sz.strip("img")
+			return next
+		}
+		sz.rewriteImage(n)
+	}
+	if n.DataAtom == atom.A && attrValue(n, "href") != "" {
+		setAttr(n, "rel", "nofollow noopener")
+		setAttr(n, "target", "_blank")
+	}
+	sz.cleanChildren(n)
+	return next
+}
+
+func (sz *htmlSanitizer) strip(tag string) {
+	sz.result.Stripped++
+	sz.result.StrippedTags[tag]++
+}
+
+// cleanAttrs 只保留白名单属性，链接属性校验协议并补全相对地址
+func (sz *htmlSanitizer) cleanAttrs(n *html.Node) {
+	allowed := sanitizeAllowedAttrs[n.DataAtom]
+	kept := n.Attr[:0]
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !containsString(allowed, strings.ToLower(a.Key)) {
+			sz.result.StrippedAttrs++
+			continue
+		}
+		a.Key = strings.ToLower(a.Key)
+		if a.Key == "href" || a.Key == "src" {
+			v, ok := sz.cleanURL(a.Val, a.Key == "href")
+			if !ok {
+				sz.result.StrippedAttrs++
+				continue
+			}
+			a.Val = v
+		}
+		kept = append(kept, a)
+	}
+	n.Attr = kept
+}
+
+// cleanURL 只允许 http(s)、相对地址和 mailto（仅 href），有基准 URL 时补全相对地址
+func (sz *htmlSanitizer) cleanURL(raw string, isHref bool) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return raw, true
+	case "mailto":
+		return raw, isHref
+	case "":
+		if strings.HasPrefix(raw, "#") || sz.base == nil {
+			return raw, true
+		}
+		sz.result.LinksResolved++
+		return sz.base.ResolveReference(u).String(), true
+	}
+	return "", false
+}
+
+func (sz *htmlSanitizer) allowIframe(n *html.Node) bool {
+	if len(sz.iframeHosts) == 0 {
+		return false
+	}
+	u, err := url.Parse(attrValue(n, "src"))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range sz.iframeHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteImage 绝对地址的图片改写为代理地址（已是代理地址的跳过）
+func (sz *htmlSanitizer) rewriteImage(n *html.Node) {
+	proxy := sz.policy.ImageProxy
+	if proxy == "" {
+		return
+	}
+	src := attrValue(n, "src")
+	u, err := url.Parse(src)
+	if err != nil || !u.IsAbs() {
+		return
+	}
+	if prefix := proxy[:strings.Index(proxy, "{url}")]; prefix != "" && strings.HasPrefix(src, prefix) {
+		return
+	}
+	setAttr(n, "src", strings.ReplaceAll(proxy, "{url}", url.QueryEscape(src)))
+	sz.result.ImagesRewritten++
+}
+
+// isTrackingPixel 宽高都不超过 1 的图片视为跟踪像素
+func isTrackingPixel(n *html.Node) bool {
+	w, errW := strconv.Atoi(strings.TrimSuffix(attrValue(n, "width"), "px"))
+	h, errH := strconv.Atoi(strings.TrimSuffix(attrValue(n, "height"), "px"))
+	return errW == nil && errH == nil && w <= 1 && h <= 1
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+func removeChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = n.FirstChild {
+		n.RemoveChild(c)
+	}
+}
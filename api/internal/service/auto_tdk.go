@@ -0,0 +1,266 @@
+// Package core provides automatic TDK (title/description/keywords) injection for rendered pages
+package core
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"seo-generator/api/pkg/config"
+)
+
+var (
+	tdkHeadOpenPattern  = regexp.MustCompile(`(?i)<head\b[^>]*>`)
+	tdkHeadClosePattern = regexp.MustCompile(`(?i)</head\s*>`)
+	tdkHTMLOpenPattern  = regexp.MustCompile(`(?i)<html\b[^>]*>`)
+	tdkTitlePattern     = regexp.MustCompile(`(?is)<title\b[^>]*>(.*?)</title\s*>`)
+	tdkMetaPattern      = regexp.MustCompile(`(?is)<meta\b[^>]*>`)
+	tdkMetaNamePattern  = regexp.MustCompile(`(?is)\bname\s*=\s*["']?\s*(description|keywords)\s*["'\s/>]`)
+	tdkMetaContent      = regexp.MustCompile(`(?is)\bcontent\s*=\s*(?:"\s*([^"]*)"|'\s*([^']*)')`)
+	tdkTagPattern       = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// TDKSource 生成 TDK 的页面数据
+type TDKSource struct {
+	Title    string   // 页面标题
+	Keywords []string // 页面使用的关键词
+	Content  string   // 正文（HTML 或纯文本），用于生成描述摘要
+}
+
+// tdkCounts TDK 注入计数
+type tdkCounts struct {
+	Name        string `json:"name,omitempty"`
+	Injected    int64  `json:"injected"`
+	Title       int64  `json:"title"`
+	Description int64  `json:"description"`
+	Keywords    int64  `json:"keywords"`
+}
+
+func (c *tdkCounts) add(title, description, keywords bool) {
+	c.Injected++
+	if title {
+		c.Title++
+	}
+	if description {
+		c.Description++
+	}
+	if keywords {
+		c.Keywords++
+	}
+}
+
+// AutoTDK 渲染后检查页面 TDK，缺失时用页面数据生成并注入 <head>
+// 是否启用由功能开关 auto_tdk 控制（可按站群覆盖）；按模板统计注入次数，方便找出需要补全的旧模板
+type AutoTDK struct {
+	config config.AutoTDKConfig
+
+	checked atomic.Int64
+	skipped atomic.Int64 // 没有 <head>/<html> 无法注入的页面
+
+	mu         sync.Mutex
+	total      tdkCounts
+	templates  map[int]*tdkCounts // templateID -> 计数
+	siteGroups map[int]*tdkCounts // siteGroupID -> 计数
+}
+
+// NewAutoTDK 创建 TDK 自动补全
+func NewAutoTDK(cfg config.AutoTDKConfig) *AutoTDK {
+	if cfg.DescriptionLen <= 0 {
+		cfg.DescriptionLen = 120
+	}
+	if cfg.MaxKeywords <= 0 {
+		cfg.MaxKeywords = 5
+	}
+	return &AutoTDK{
+		config:     cfg,
+		templates:  make(map[int]*tdkCounts),
+		siteGroups: make(map[int]*tdkCounts),
+	}
+}
+
+// Apply 检查并补全页面 TDK，返回处理后的 HTML（无缺失时原样返回）
+func (a *AutoTDK) Apply(page string, siteGroupID, templateID int, templateName string, src TDKSource) string {
+	a.checked.Add(1)
+
+	head := page
+	if loc := tdkHeadClosePattern.FindStringIndex(page); loc != nil {
+		head = page[:loc[0]]
+	}
+	needTitle := true
+	if m := tdkTitlePattern.FindStringSubmatch(head); m != nil && strings.TrimSpace(m[1]) != "" {
+		needTitle = false
+	}
+	needDescription, needKeywords := true, true
+	var emptyMeta [][]int // content 为空的 description/keywords，注入时移除
+	for _, loc := range tdkMetaPattern.FindAllStringIndex(head, -1) {
+		tag := head[loc[0]:loc[1]]
+		name := tdkMetaNamePattern.FindStringSubmatch(tag)
+		if name == nil {
+			continue
+		}
+		if c := tdkMetaContent.FindStringSubmatch(tag); c != nil && strings.TrimSpace(c[1]+c[2]) != "" {
+			if strings.EqualFold(name[1], "description") {
+				needDescription = false
+			} else {
+				needKeywords = false
+			}
+			continue
+		}
+		emptyMeta = append(emptyMeta, loc)
+	}
+	if !needTitle && !needDescription && !needKeywords {
+		return page
+	}
+
+	// 关键词池和标题池中的文本可能已做 HTML 实体编码，先解码再统一转义，避免 &amp;amp; 双重编码
+	src = src.unescaped()
+
+	var b strings.Builder
+	if needTitle {
+		title := src.Title
+		if title == "" {
+			title = strings.Join(src.Keywords, " ")
+		}
+		if title == "" {
+			needTitle = false
+		} else {
+			b.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+		}
+	}
+	if needDescription {
+		if desc := a.description(src); desc != "" {
+			b.WriteString(`<meta name="description" content="` + html.EscapeString(desc) + "\">\n")
+		} else {
+			needDescription = false
+		}
+	}
+	if needKeywords {
+		if kws := a.keywords(src.Keywords); kws != "" {
+			b.WriteString(`<meta name="keywords" content="` + html.EscapeString(kws) + "\">\n")
+		} else {
+			needKeywords = false
+		}
+	}
+	if b.Len() == 0 {
+		return page
+	}
+
+	// 空的 title 标签替换为生成的标题，空的 meta 移除后重新注入
+	if needTitle {
+		if loc := tdkTitlePattern.FindStringIndex(head); loc != nil {
+			emptyMeta = append(emptyMeta, loc)
+		}
+	}
+	if len(emptyMeta) > 0 {
+		sort.Slice(emptyMeta, func(i, j int) bool { return emptyMeta[i][0] > emptyMeta[j][0] })
+		for _, loc := range emptyMeta {
+			page = page[:loc[0]] + page[loc[1]:]
+		}
+	}
+
+	injected, ok := injectIntoHead(page, b.String())
+	if !ok {
+		a.skipped.Add(1)
+		return page
+	}
+	a.record(siteGroupID, templateID, templateName, needTitle, needDescription, needKeywords)
+	return injected
+}
+
+// injectIntoHead 插入到 <head> 开头；没有 <head> 时在 <html> 后补一个
+func injectIntoHead(page, tags string) (string, bool) {
+	if loc := tdkHeadOpenPattern.FindStringIndex(page); loc != nil {
+		return page[:loc[1]] + "\n" + tags + page[loc[1]:], true
+	}
+	if loc := tdkHTMLOpenPattern.FindStringIndex(page); loc != nil {
+		return page[:loc[1]] + "\n<head>\n" + tags + "</head>" + page[loc[1]:], true
+	}
+	return page, false
+}
+
+// unescaped 标题和关键词解码 HTML 实体后的副本（正文在 description 中按纯文本提取时解码）
+func (src TDKSource) unescaped() TDKSource {
+	src.Title = html.UnescapeString(src.Title)
+	keywords := make([]string, len(src.Keywords))
+	for i, kw := range src.Keywords {
+		keywords[i] = html.UnescapeString(kw)
+	}
+	src.Keywords = keywords
+	return src
+}
+
+// description 取正文纯文本摘要，没有正文时用标题和关键词
+func (a *AutoTDK) description(src TDKSource) string {
+	text := html.UnescapeString(tdkTagPattern.ReplaceAllString(src.Content, " "))
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		text = strings.Join(append([]string{src.Title}, src.Keywords...), "，")
+		text = strings.Trim(text, "，")
+	}
+	if utf8.RuneCountInString(text) <= a.config.DescriptionLen {
+		return text
+	}
+	return string([]rune(text)[:a.config.DescriptionLen])
+}
+
+// keywords 去重后取前 MaxKeywords 个，逗号分隔
+func (a *AutoTDK) keywords(keywords []string) string {
+	seen := make(map[string]bool, len(keywords))
+	out := make([]string, 0, a.config.MaxKeywords)
+	for _, kw := range keywords {
+		kw = strings.TrimSpace(kw)
+		if kw == "" || seen[kw] {
+			continue
+		}
+		seen[kw] = true
+		out = append(out, kw)
+		if len(out) >= a.config.MaxKeywords {
+			break
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+func (a *AutoTDK) record(siteGroupID, templateID int, templateName string, title, description, keywords bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.total.add(title, description, keywords)
+	t, ok := a.templates[templateID]
+	if !ok {
+		t = &tdkCounts{}
+		a.templates[templateID] = t
+	}
+	t.Name = templateName
+	t.add(title, description, keywords)
+	g, ok := a.siteGroups[siteGroupID]
+	if !ok {
+		g = &tdkCounts{}
+		a.siteGroups[siteGroupID] = g
+	}
+	g.add(title, description, keywords)
+}
+
+// Stats 注入统计（进程启动以来）
+func (a *AutoTDK) Stats() map[string]interface{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	templates := make(map[int]tdkCounts, len(a.templates))
+	for id, t := range a.templates {
+		templates[id] = *t
+	}
+	siteGroups := make(map[int]tdkCounts, len(a.siteGroups))
+	for id, g := range a.siteGroups {
+		siteGroups[id] = *g
+	}
+	return map[string]interface{}{
+		"checked":     a.checked.Load(),
+		"skipped":     a.skipped.Load(),
+		"injected":    a.total,
+		"templates":   templates,
+		"site_groups": siteGroups,
+	}
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"testing"
+
+	"seo-generator/api/pkg/config"
+)
+
+func TestAutoTDK_Apply(t *testing.T) {
+	a := NewAutoTDK(config.AutoTDKConfig{DescriptionLen: 10, MaxKeywords: 2})
+	src := TDKSource{
+		Title:    "页面标题",
+		Keywords: []string{"关键词一", "关键词二", "关键词三"},
+		Content:  "<p>正文内容第一段</p><p>正文内容第二段</p>",
+	}
+	injected := "<html><head>\n" +
+		"<title>页面标题</title>\n" +
+		`<meta name="description" content="正文内容第一段 正文">` + "\n" +
+		`<meta name="keywords" content="关键词一,关键词二">` + "\n" +
+		"</head><body></body></html>"
+
+	tests := []struct {
+		name string
+		page string
+		src  TDKSource
+		want string
+	}{
+		{
+			name: "complete page unchanged",
+			page: `<html><head><title>T</title><meta name="description" content="D"><meta name="keywords" content="K"></head></html>`,
+			src:  src,
+			want: `<html><head><title>T</title><meta name="description" content="D"><meta name="keywords" content="K"></head></html>`,
+		},
+		{
+			name: "all missing",
+			page: "<html><head></head><body></body></html>",
+			src:  src,
+			want: injected,
+		},
+		{
+			name: "empty tags replaced",
+			page: `<html><head><title> </title><meta name="description" content=""><meta name="keywords" content=''></head><body></body></html>`,
+			src:  src,
+			want: injected,
+		},
+		{
+			name: "only description missing",
+			page: `<html><head><title>T</title><meta name="keywords" content="K"></head></html>`,
+			src:  src,
+			want: "<html><head>\n" + `<meta name="description" content="正文内容第一段 正文">` + "\n" + `<title>T</title><meta name="keywords" content="K"></head></html>`,
+		},
+		{
+			name: "head added after html",
+			page: "<html><body></body></html>",
+			src:  TDKSource{Title: "T"},
+			want: "<html>\n<head>\n<title>T</title>\n" + `<meta name="description" content="T">` + "\n</head><body></body></html>",
+		},
+		{
+			name: "no head or html",
+			page: "<p>fragment</p>",
+			src:  src,
+			want: "<p>fragment</p>",
+		},
+		{
+			name: "entities not double escaped",
+			page: `<html><head><meta name="description" content="D"></head></html>`,
+			src:  TDKSource{Title: "A &amp; B", Keywords: []string{"C&amp;D", "&lt;E&gt;"}},
+			want: "<html><head>\n<title>A &amp; B</title>\n" + `<meta name="keywords" content="C&amp;D,&lt;E&gt;">` + "\n" + `<meta name="description" content="D"></head></html>`,
+		},
+		{
+			name: "title from keywords",
+			page: `<html><head><meta name="description" content="D"><meta name="keywords" content="K"></head></html>`,
+			src:  TDKSource{Keywords: []string{"甲", "乙"}},
+			want: "<html><head>\n<title>甲 乙</title>\n" + `<meta name="description" content="D"><meta name="keywords" content="K"></head></html>`,
+		},
+		{
+			name: "nothing to inject",
+			page: "<html><head></head></html>",
+			src:  TDKSource{},
+			want: "<html><head></head></html>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.Apply(tt.page, 1, 1, "tpl", tt.src); got != tt.want {
+				t.Errorf("Apply =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTDKSource_Unescaped(t *testing.T) {
+	src := TDKSource{Title: "A &amp; B", Keywords: []string{"&quot;x&quot;", "y"}, Content: "&amp;"}
+	got := src.unescaped()
+	if got.Title != "A & B" {
+		t.Errorf("Title = %q", got.Title)
+	}
+	if got.Keywords[0] != `"x"` || got.Keywords[1] != "y" {
+		t.Errorf("Keywords = %q", got.Keywords)
+	}
+	if got.Content != "&amp;" {
+		t.Errorf("Content = %q, want unchanged", got.Content)
+	}
+	if src.Keywords[0] != "&quot;x&quot;" {
+		t.Errorf("source keywords modified: %q", src.Keywords)
+	}
+}
+
+func TestAutoTDK_Description(t *testing.T) {
+	a := NewAutoTDK(config.AutoTDKConfig{DescriptionLen: 6})
+	tests := []struct {
+		name string
+		src  TDKSource
+		want string
+	}{
+		{"content text", TDKSource{Content: "<p>一二</p>\n<p>三</p>"}, "一二 三"},
+		{"truncated by runes", TDKSource{Content: "一二三四五六七八"}, "一二三四五六"},
+		{"entities decoded", TDKSource{Content: "a&amp;b"}, "a&b"},
+		{"fallback to title and keywords", TDKSource{Title: "标题", Keywords: []string{"词"}}, "标题，词"},
+		{"fallback without title", TDKSource{Keywords: []string{"词"}}, "词"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.description(tt.src); got != tt.want {
+				t.Errorf("description = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
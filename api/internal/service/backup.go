@@ -0,0 +1,792 @@
+// Package core provides encrypted backup and restore of SEO data
+package core
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// BackupTables 参与备份的表，按恢复顺序排列
+var BackupTables = []string{
+	"site_groups",
+	"sites",
+	"templates",
+	"keyword_groups",
+	"keywords",
+	"image_groups",
+	"images",
+	"system_settings",
+}
+
+const (
+	backupFormatVersion = 1
+	backupPartRows      = 10000 // 每个 tar 条目最多的行数
+	backupFileExt       = ".tar.gz.enc"
+	backupSampleIDs     = 20 // 差异报告中每类最多列出的 ID 数
+)
+
+// ErrBackupNotConfigured 未配置备份口令
+var ErrBackupNotConfigured = errors.New("backup passphrase not configured")
+
+// ErrBackupRunning 已有备份或恢复在进行中
+var ErrBackupRunning = errors.New("another backup or restore is running")
+
+// backupMeta 备份元信息（归档中的第一个条目）
+type backupMeta struct {
+	Version   int      `json:"version"`
+	CreatedAt string   `json:"created_at"`
+	Tables    []string `json:"tables"`
+}
+
+// backupPartHeader 每个数据条目的首行
+type backupPartHeader struct {
+	Table   string   `json:"table"`
+	Columns []string `json:"columns"`
+}
+
+// BackupFile 本地备份文件
+type BackupFile struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableRestoreDiff 单表恢复差异
+type TableRestoreDiff struct {
+	Table          string   `json:"table"`
+	BackupRows     int      `json:"backup_rows"`
+	Inserted       int      `json:"inserted"`
+	Updated        int      `json:"updated"`
+	Deleted        int      `json:"deleted"`
+	Unchanged      int      `json:"unchanged"`
+	SkippedColumns []string `json:"skipped_columns,omitempty"` // 备份中有但当前表已不存在的列
+	InsertedIDs    []string `json:"inserted_ids,omitempty"`
+	UpdatedIDs     []string `json:"updated_ids,omitempty"`
+	DeletedIDs     []string `json:"deleted_ids,omitempty"`
+}
+
+// RestoreReport 恢复结果（dry_run 时为预计差异）
+type RestoreReport struct {
+	DryRun          bool                `json:"dry_run"`
+	BackupCreatedAt string              `json:"backup_created_at"`
+	Tables          []*TableRestoreDiff `json:"tables"`
+	Duration        int64               `json:"duration_ms"`
+}
+
+// BackupManager 加密备份与恢复
+// 备份为 tar.gz 归档（每张表按 backupPartRows 行切分为 JSON Lines 条目），整体以口令派生密钥分块加密；
+// 恢复时逐表按 id 区间分批比对替换（不持有整表数据），dry_run 只比对差异不写入
+type BackupManager struct {
+	db     *sqlx.DB
+	config config.BackupConfig
+	s3     *s3Uploader
+
+	running sync.Mutex
+}
+
+// NewBackupManager 创建备份管理器
+func NewBackupManager(db *sqlx.DB, cfg config.BackupConfig) *BackupManager {
+	m := &BackupManager{db: db, config: cfg}
+	if cfg.S3.Enabled {
+		m.s3 = newS3Uploader(cfg.S3)
+	}
+	return m
+}
+
+// Configured 是否配置了备份口令
+func (m *BackupManager) Configured() bool {
+	return m.config.Passphrase != ""
+}
+
+// FileName 生成备份文件名
+func (m *BackupManager) FileName(t time.Time) string {
+	return "seo-backup-" + t.Format("20060102-150405") + backupFileExt
+}
+
+// Export 将全部备份表写入加密归档
+func (m *BackupManager) Export(ctx context.Context, w io.Writer) error {
+	if !m.Configured() {
+		return ErrBackupNotConfigured
+	}
+	if !m.running.TryLock() {
+		return ErrBackupRunning
+	}
+	defer m.running.Unlock()
+
+	enc, err := newBackupEncryptWriter(w, m.config.Passphrase)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(enc)
+	tw := tar.NewWriter(gz)
+
+	meta, _ := json.Marshal(backupMeta{
+		Version:   backupFormatVersion,
+		CreatedAt: time.Now().Format(time.RFC3339),
+		Tables:    BackupTables,
+	})
+	if err := writeTarEntry(tw, "meta.json", meta); err != nil {
+		return err
+	}
+
+	for _, table := range BackupTables {
+		if err := m.exportTable(ctx, tw, table); err != nil {
+			return fmt.Errorf("export %s: %w", table, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// exportTable 按主键顺序导出表，每 backupPartRows 行写一个条目
+func (m *BackupManager) exportTable(ctx context.Context, tw *tar.Writer, table string) error {
+	// 不带参数的查询走文本协议，所有值以 []byte/time.Time 返回，便于统一序列化
+	rows, err := m.db.QueryxContext(ctx, "SELECT * FROM `"+table+"` ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	header, _ := json.Marshal(backupPartHeader{Table: table, Columns: columns})
+
+	var buf strings.Builder
+	part, count := 0, 0
+	flush := func() error {
+		if count == 0 && part > 0 {
+			return nil
+		}
+		part++
+		data := make([]byte, 0, len(header)+1+buf.Len())
+		data = append(data, header...)
+		data = append(data, '\n')
+		data = append(data, buf.String()...)
+		buf.Reset()
+		count = 0
+		return writeTarEntry(tw, fmt.Sprintf("tables/%s/%06d.jsonl", table, part), data)
+	}
+
+	for rows.Next() {
+		values, err := rows.SliceScan()
+		if err != nil {
+			return err
+		}
+		line, _ := json.Marshal(normalizeBackupRow(values))
+		buf.Write(line)
+		buf.WriteByte('\n')
+		count++
+		if count >= backupPartRows {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+func writeTarEntry(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// normalizeBackupRow 将数据库值统一转换为字符串（NULL 保持为 nil）
+func normalizeBackupRow(values []interface{}) []*string {
+	row := make([]*string, len(values))
+	for i, v := range values {
+		var s string
+		switch x := v.(type) {
+		case nil:
+			continue
+		case []byte:
+			s = string(x)
+		case string:
+			s = x
+		case time.Time:
+			s = x.Format("2006-01-02 15:04:05")
+		case int64:
+			s = strconv.FormatInt(x, 10)
+		case float64:
+			s = strconv.FormatFloat(x, 'f', -1, 64)
+		case bool:
+			s = "0"
+			if x {
+				s = "1"
+			}
+		default:
+			s = fmt.Sprint(x)
+		}
+		row[i] = &s
+	}
+	return row
+}
+
+// hashBackupRow 按列名顺序计算行哈希，用于比对差异
+func hashBackupRow(row []*string, indexes []int) uint64 {
+	h := fnv.New64a()
+	for _, i := range indexes {
+		if i >= len(row) || row[i] == nil {
+			h.Write([]byte{0})
+			continue
+		}
+		h.Write([]byte{1})
+		h.Write([]byte(*row[i]))
+		h.Write([]byte{0xff})
+	}
+	return h.Sum64()
+}
+
+// restoreTable 单表恢复状态
+// 备份按 id 升序导出，恢复时每攒够一批就与当前表同一 id 区间的行比对并替换，内存中只保留一批
+type restoreTable struct {
+	diff       *TableRestoreDiff
+	columns    []string // 写入的列（备份与当前表的交集，按备份列顺序）
+	backupIdx  []int    // 写入列在备份行中的下标
+	currentIdx []int    // 写入列在当前表行中的下标
+	idIdx      int      // id 在备份行中的下标
+	currentID  int      // id 在当前表行中的下标
+
+	done    int64 // 已处理区间的上界（含）
+	hasDone bool
+	pending []restorePendingRow
+}
+
+// restorePendingRow 待比对的备份行
+type restorePendingRow struct {
+	id  int64
+	row []*string
+}
+
+// Restore 从加密归档恢复，dryRun 时只计算差异
+// 逐表分批恢复，每批在一个短事务中删除区间内备份没有的行并写入新增和变化的行；
+// 中途失败时已完成的批次保留，修复问题后可重新执行恢复
+func (m *BackupManager) Restore(ctx context.Context, r io.Reader, passphrase string, dryRun bool) (*RestoreReport, error) {
+	if passphrase == "" {
+		passphrase = m.config.Passphrase
+	}
+	if passphrase == "" {
+		return nil, ErrBackupNotConfigured
+	}
+	if !m.running.TryLock() {
+		return nil, ErrBackupRunning
+	}
+	defer m.running.Unlock()
+
+	start := time.Now()
+	dec, err := newBackupDecryptReader(r, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(dec)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	report := &RestoreReport{DryRun: dryRun}
+	allowed := make(map[string]bool, len(BackupTables))
+	for _, t := range BackupTables {
+		allowed[t] = true
+	}
+
+	var cur *restoreTable
+	finish := func() error {
+		if cur == nil {
+			return nil
+		}
+		if err := m.finishRestoreTable(ctx, cur, dryRun); err != nil {
+			return fmt.Errorf("restore %s: %w", cur.diff.Table, err)
+		}
+		report.Tables = append(report.Tables, cur.diff)
+		if !dryRun {
+			log.Info().Str("table", cur.diff.Table).Int("rows", cur.diff.BackupRows).Msg("Backup table restored")
+		}
+		cur = nil
+		return nil
+	}
+
+	sawMeta := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read archive: %w", err)
+		}
+
+		if hdr.Name == "meta.json" {
+			var meta backupMeta
+			if err := json.NewDecoder(tr).Decode(&meta); err != nil {
+				return nil, fmt.Errorf("read meta: %w", err)
+			}
+			if meta.Version > backupFormatVersion {
+				return nil, fmt.Errorf("unsupported backup version %d", meta.Version)
+			}
+			report.BackupCreatedAt = meta.CreatedAt
+			sawMeta = true
+			continue
+		}
+		if !sawMeta {
+			return nil, errors.New("invalid backup: missing meta.json")
+		}
+
+		dec := json.NewDecoder(tr)
+		var header backupPartHeader
+		if err := dec.Decode(&header); err != nil {
+			return nil, fmt.Errorf("read %s: %w", hdr.Name, err)
+		}
+		if !allowed[header.Table] {
+			return nil, fmt.Errorf("unexpected table in backup: %s", header.Table)
+		}
+
+		if cur == nil || cur.diff.Table != header.Table {
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			if cur, err = m.beginRestoreTable(ctx, header); err != nil {
+				return nil, fmt.Errorf("prepare %s: %w", header.Table, err)
+			}
+		}
+
+		for {
+			var row []*string
+			if err := dec.Decode(&row); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("read %s: %w", hdr.Name, err)
+			}
+			if err := m.restoreRow(ctx, cur, row, dryRun); err != nil {
+				return nil, fmt.Errorf("restore %s: %w", header.Table, err)
+			}
+		}
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	if !sawMeta {
+		return nil, errors.New("invalid backup: missing meta.json")
+	}
+
+	if !dryRun {
+		log.Info().Str("backup_created_at", report.BackupCreatedAt).Msg("Backup restored")
+	}
+	report.Duration = time.Since(start).Milliseconds()
+	return report, nil
+}
+
+// beginRestoreTable 对齐备份列与当前表的列
+func (m *BackupManager) beginRestoreTable(ctx context.Context, header backupPartHeader) (*restoreTable, error) {
+	rows, err := m.db.QueryxContext(ctx, "SELECT * FROM `"+header.Table+"` LIMIT 0")
+	if err != nil {
+		return nil, err
+	}
+	currentCols, err := rows.Columns()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	currentIdx := make(map[string]int, len(currentCols))
+	for i, c := range currentCols {
+		currentIdx[c] = i
+	}
+
+	t := &restoreTable{
+		diff:      &TableRestoreDiff{Table: header.Table},
+		idIdx:     -1,
+		currentID: -1,
+	}
+	for i, c := range header.Columns {
+		ci, ok := currentIdx[c]
+		if !ok {
+			t.diff.SkippedColumns = append(t.diff.SkippedColumns, c)
+			continue
+		}
+		t.columns = append(t.columns, c)
+		t.backupIdx = append(t.backupIdx, i)
+		t.currentIdx = append(t.currentIdx, ci)
+		if c == "id" {
+			t.idIdx = i
+			t.currentID = ci
+		}
+	}
+	if t.idIdx < 0 {
+		return nil, errors.New("backup has no id column")
+	}
+	return t, nil
+}
+
+// restoreRow 加入待比对批次，攒够一批后比对并写入
+func (m *BackupManager) restoreRow(ctx context.Context, t *restoreTable, row []*string, dryRun bool) error {
+	if t.idIdx >= len(row) || row[t.idIdx] == nil {
+		return errors.New("row without id")
+	}
+	id, err := strconv.ParseInt(*row[t.idIdx], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid id %q", *row[t.idIdx])
+	}
+	last := t.done
+	hasLast := t.hasDone
+	if n := len(t.pending); n > 0 {
+		last, hasLast = t.pending[n-1].id, true
+	}
+	if hasLast && id <= last {
+		return fmt.Errorf("rows not ordered by id at %d", id)
+	}
+
+	t.diff.BackupRows++
+	t.pending = append(t.pending, restorePendingRow{id: id, row: row})
+	if len(t.pending) >= restoreBatchRows(len(t.columns)) {
+		return m.flushRestoreBatch(ctx, t, dryRun)
+	}
+	return nil
+}
+
+// restoreRange 当前批次对应的 id 区间（上一批上界之后到 hi）
+func (t *restoreTable) restoreRange(hi int64, bounded bool) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if t.hasDone {
+		conds = append(conds, "id > ?")
+		args = append(args, t.done)
+	}
+	if bounded {
+		conds = append(conds, "id <= ?")
+		args = append(args, hi)
+	}
+	if len(conds) == 0 {
+		return "1 = 1", nil
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// flushRestoreBatch 比对当前批次与当前表同一区间的行：区间内备份没有的行删除，新增和变化的行写入
+func (m *BackupManager) flushRestoreBatch(ctx context.Context, t *restoreTable, dryRun bool) error {
+	if len(t.pending) == 0 {
+		return nil
+	}
+	hi := t.pending[len(t.pending)-1].id
+	where, args := t.restoreRange(hi, true)
+
+	inBatch := make(map[int64]bool, len(t.pending))
+	for _, p := range t.pending {
+		inBatch[p.id] = true
+	}
+	current, err := m.scanRestoreRange(ctx, t, where, args, inBatch)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]interface{}, 0, len(t.pending))
+	changed := make([][]interface{}, 0, len(t.pending))
+	for _, p := range t.pending {
+		ids = append(ids, p.id)
+		idStr := strconv.FormatInt(p.id, 10)
+		if oldHash, ok := current[p.id]; !ok {
+			t.diff.Inserted++
+			if len(t.diff.InsertedIDs) < backupSampleIDs {
+				t.diff.InsertedIDs = append(t.diff.InsertedIDs, idStr)
+			}
+		} else if oldHash != hashBackupRow(p.row, t.backupIdx) {
+			t.diff.Updated++
+			if len(t.diff.UpdatedIDs) < backupSampleIDs {
+				t.diff.UpdatedIDs = append(t.diff.UpdatedIDs, idStr)
+			}
+		} else {
+			t.diff.Unchanged++
+			continue
+		}
+		values := make([]interface{}, len(t.backupIdx))
+		for i, bi := range t.backupIdx {
+			if bi < len(p.row) && p.row[bi] != nil {
+				values[i] = *p.row[bi]
+			}
+		}
+		changed = append(changed, values)
+	}
+
+	if !dryRun {
+		if err := m.writeRestoreBatch(ctx, t, where, args, ids, changed); err != nil {
+			return err
+		}
+	}
+	t.done, t.hasDone = hi, true
+	t.pending = t.pending[:0]
+	return nil
+}
+
+// finishRestoreTable 写入最后一批，并删除最后一批之后备份中没有的行
+func (m *BackupManager) finishRestoreTable(ctx context.Context, t *restoreTable, dryRun bool) error {
+	if err := m.flushRestoreBatch(ctx, t, dryRun); err != nil {
+		return err
+	}
+	where, args := t.restoreRange(0, false)
+	if _, err := m.scanRestoreRange(ctx, t, where, args, nil); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+	_, err := m.db.ExecContext(ctx, "DELETE FROM `"+t.diff.Table+"` WHERE "+where, args...)
+	return err
+}
+
+// scanRestoreRange 流式读取当前表区间内的行，返回属于本批次的行哈希；不在本批次的行计为删除
+func (m *BackupManager) scanRestoreRange(ctx context.Context, t *restoreTable, where string, args []interface{}, inBatch map[int64]bool) (map[int64]uint64, error) {
+	rows, err := m.db.QueryxContext(ctx, "SELECT * FROM `"+t.diff.Table+"` WHERE "+where+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	current := make(map[int64]uint64, len(inBatch))
+	for rows.Next() {
+		values, err := rows.SliceScan()
+		if err != nil {
+			return nil, err
+		}
+		row := normalizeBackupRow(values)
+		if row[t.currentID] == nil {
+			continue
+		}
+		id, err := strconv.ParseInt(*row[t.currentID], 10, 64)
+		if err != nil {
+			continue
+		}
+		if inBatch[id] {
+			current[id] = hashBackupRow(row, t.currentIdx)
+			continue
+		}
+		t.diff.Deleted++
+		if len(t.diff.DeletedIDs) < backupSampleIDs {
+			t.diff.DeletedIDs = append(t.diff.DeletedIDs, *row[t.currentID])
+		}
+	}
+	return current, rows.Err()
+}
+
+// writeRestoreBatch 在一个短事务中删除区间内备份没有的行，并用 REPLACE 写入新增和变化的行
+// REPLACE 同时处理与其他 id 冲突的唯一索引（如同组同名关键词），冲突行的备份版本会在后续批次写回
+func (m *BackupManager) writeRestoreBatch(ctx context.Context, t *restoreTable, where string, args []interface{}, ids []interface{}, changed [][]interface{}) error {
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	deleteArgs := append(append([]interface{}{}, args...), ids...)
+	if _, err := tx.ExecContext(ctx, "DELETE FROM `"+t.diff.Table+"` WHERE "+where+
+		" AND id NOT IN ("+strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")+")", deleteArgs...); err != nil {
+		return err
+	}
+
+	if len(changed) > 0 {
+		quoted := make([]string, len(t.columns))
+		for i, c := range t.columns {
+			quoted[i] = "`" + c + "`"
+		}
+		placeholder := "(" + strings.TrimSuffix(strings.Repeat("?,", len(t.columns)), ",") + ")"
+
+		var sb strings.Builder
+		sb.WriteString("REPLACE INTO `" + t.diff.Table + "` (" + strings.Join(quoted, ",") + ") VALUES ")
+		values := make([]interface{}, 0, len(changed)*len(t.columns))
+		for i, row := range changed {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(placeholder)
+			values = append(values, row...)
+		}
+		if _, err := tx.ExecContext(ctx, sb.String(), values...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// restoreBatchRows 每批恢复的行数（单条 REPLACE 的行数），保证占位符数量不超过 MySQL 上限
+func restoreBatchRows(columns int) int {
+	n := 60000 / columns
+	if n > 500 {
+		n = 500
+	}
+	return n
+}
+
+// RunScheduled 备份到本地目录，按配置上传到 S3 并清理旧备份，返回文件路径
+func (m *BackupManager) RunScheduled(ctx context.Context) (string, int64, error) {
+	if !m.Configured() {
+		return "", 0, ErrBackupNotConfigured
+	}
+	if err := os.MkdirAll(m.config.LocalDir, 0700); err != nil {
+		return "", 0, err
+	}
+
+	name := m.FileName(time.Now())
+	path := filepath.Join(m.config.LocalDir, name)
+	tmp := path + ".part"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := m.Export(ctx, f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return "", 0, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return "", 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", 0, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if m.s3 != nil {
+		if err := m.upload(ctx, path, name, info.Size()); err != nil {
+			return path, info.Size(), fmt.Errorf("upload to s3: %w", err)
+		}
+	}
+
+	m.rotate()
+	return path, info.Size(), nil
+}
+
+func (m *BackupManager) upload(ctx context.Context, path, name string, size int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return m.s3.PutObject(ctx, m.config.S3.Prefix+name, f, size)
+}
+
+// rotate 只保留最近 keep 份本地备份
+func (m *BackupManager) rotate() {
+	if m.config.Keep <= 0 {
+		return
+	}
+	files, err := m.ListLocal()
+	if err != nil || len(files) <= m.config.Keep {
+		return
+	}
+	for _, f := range files[m.config.Keep:] {
+		if err := os.Remove(filepath.Join(m.config.LocalDir, f.Name)); err != nil {
+			log.Warn().Err(err).Str("file", f.Name).Msg("Failed to remove old backup")
+		}
+	}
+}
+
+// ListLocal 列出本地备份（新的在前）
+func (m *BackupManager) ListLocal() ([]BackupFile, error) {
+	entries, err := os.ReadDir(m.config.LocalDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []BackupFile{}, nil
+		}
+		return nil, err
+	}
+	files := make([]BackupFile, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), backupFileExt) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, BackupFile{Name: e.Name(), Size: info.Size(), CreatedAt: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name > files[j].Name })
+	return files, nil
+}
+
+// OpenLocal 打开本地备份文件（只允许备份目录下的文件名）
+func (m *BackupManager) OpenLocal(name string) (*os.File, error) {
+	if name != filepath.Base(name) || !strings.HasSuffix(name, backupFileExt) {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(filepath.Join(m.config.LocalDir, name))
+}
+
+// EnsureSchedule 按配置创建或更新定时备份任务
+func (m *BackupManager) EnsureSchedule(ctx context.Context, scheduler *Scheduler) error {
+	var existing struct {
+		ID       int64  `db:"id"`
+		CronExpr string `db:"cron_expr"`
+		Enabled  bool   `db:"enabled"`
+	}
+	err := m.db.GetContext(ctx, &existing,
+		"SELECT id, cron_expr, enabled FROM scheduled_tasks WHERE task_type = ? LIMIT 1", TaskTypeBackup)
+	exists := err == nil && existing.ID > 0
+
+	if m.config.Schedule == "" || !m.Configured() {
+		if exists {
+			return scheduler.DeleteTask(ctx, existing.ID)
+		}
+		return nil
+	}
+
+	task := &ScheduledTask{
+		Name:     "数据备份",
+		TaskType: TaskTypeBackup,
+		CronExpr: m.config.Schedule,
+		Params:   json.RawMessage("{}"),
+		Enabled:  true,
+	}
+	if exists {
+		// 保留后台手动设置的启用状态，只同步 Cron 表达式
+		if existing.CronExpr == m.config.Schedule {
+			return nil
+		}
+		task.ID = existing.ID
+		task.Enabled = existing.Enabled
+		return scheduler.UpdateTask(ctx, task)
+	}
+	_, err = scheduler.CreateTask(ctx, task)
+	return err
+}
@@ -0,0 +1,216 @@
+// Package core provides streaming authenticated encryption for backup archives
+package core
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// 备份文件格式：
+//
+//	magic(8) | salt(16) | noncePrefix(4) | chunk...
+//	chunk = length(4, 最高位为结束标记) | AES-256-GCM 密文
+//
+// 每块明文最多 backupChunkSize 字节，nonce = noncePrefix || 块序号，
+// 结束标记同时作为附加数据参与认证，截断或调换块顺序都会导致解密失败
+const (
+	backupMagic       = "SEOBAK01"
+	backupChunkSize   = 64 * 1024
+	backupSaltSize    = 16
+	backupPrefixSize  = 4
+	backupFinalFlag   = uint32(1) << 31
+	backupScryptN     = 1 << 15
+	backupScryptR     = 8
+	backupScryptP     = 1
+	backupKeySize     = 32
+	backupMaxChunkLen = backupChunkSize + 16
+)
+
+// ErrBackupDecrypt 口令错误或文件损坏
+var ErrBackupDecrypt = errors.New("backup decrypt failed: wrong passphrase or corrupted file")
+
+func backupAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, backupScryptN, backupScryptR, backupScryptP, backupKeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// backupEncryptWriter 分块加密写入器，必须调用 Close 写入结束块
+type backupEncryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	prefix []byte
+	seq    uint64
+	buf    []byte
+	closed bool
+}
+
+// newBackupEncryptWriter 写入文件头并返回加密写入器
+func newBackupEncryptWriter(w io.Writer, passphrase string) (*backupEncryptWriter, error) {
+	header := make([]byte, len(backupMagic)+backupSaltSize+backupPrefixSize)
+	copy(header, backupMagic)
+	if _, err := rand.Read(header[len(backupMagic):]); err != nil {
+		return nil, err
+	}
+	salt := header[len(backupMagic) : len(backupMagic)+backupSaltSize]
+	prefix := header[len(backupMagic)+backupSaltSize:]
+
+	aead, err := backupAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &backupEncryptWriter{
+		w:      w,
+		aead:   aead,
+		prefix: append([]byte(nil), prefix...),
+		buf:    make([]byte, 0, backupChunkSize*2),
+	}, nil
+}
+
+func (e *backupEncryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("write to closed backup writer")
+	}
+	e.buf = append(e.buf, p...)
+	// 保留最后一块到 Close 时作为结束块写出
+	for len(e.buf) > backupChunkSize {
+		if err := e.seal(e.buf[:backupChunkSize], false); err != nil {
+			return 0, err
+		}
+		e.buf = append(e.buf[:0], e.buf[backupChunkSize:]...)
+	}
+	return len(p), nil
+}
+
+// Close 写入结束块
+func (e *backupEncryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.seal(e.buf, true)
+}
+
+func (e *backupEncryptWriter) seal(plain []byte, final bool) error {
+	flag := uint32(0)
+	if final {
+		flag = backupFinalFlag
+	}
+	aad := make([]byte, 4)
+	binary.BigEndian.PutUint32(aad, flag)
+
+	ciphertext := e.aead.Seal(nil, e.nonce(), plain, aad)
+	e.seq++
+
+	lenBuf := make([]byte, 4)
+	binary.BigEndian.PutUint32(lenBuf, uint32(len(ciphertext))|flag)
+	if _, err := e.w.Write(lenBuf); err != nil {
+		return err
+	}
+	_, err := e.w.Write(ciphertext)
+	return err
+}
+
+func (e *backupEncryptWriter) nonce() []byte {
+	nonce := make([]byte, e.aead.NonceSize())
+	copy(nonce, e.prefix)
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], e.seq)
+	return nonce
+}
+
+// backupDecryptReader 分块解密读取器，读到结束块后返回 io.EOF，文件被截断时返回错误
+type backupDecryptReader struct {
+	r      io.Reader
+	aead   cipher.AEAD
+	prefix []byte
+	seq    uint64
+	plain  []byte
+	final  bool
+}
+
+// newBackupDecryptReader 校验文件头并返回解密读取器
+func newBackupDecryptReader(r io.Reader, passphrase string) (*backupDecryptReader, error) {
+	header := make([]byte, len(backupMagic)+backupSaltSize+backupPrefixSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("read backup header: %w", err)
+	}
+	if string(header[:len(backupMagic)]) != backupMagic {
+		return nil, errors.New("not a backup file")
+	}
+	salt := header[len(backupMagic) : len(backupMagic)+backupSaltSize]
+	aead, err := backupAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &backupDecryptReader{
+		r:      r,
+		aead:   aead,
+		prefix: append([]byte(nil), header[len(backupMagic)+backupSaltSize:]...),
+	}, nil
+}
+
+func (d *backupDecryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.final {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *backupDecryptReader) next() error {
+	lenBuf := make([]byte, 4)
+	if _, err := io.ReadFull(d.r, lenBuf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return errors.New("backup file truncated")
+		}
+		return err
+	}
+	raw := binary.BigEndian.Uint32(lenBuf)
+	flag := raw & backupFinalFlag
+	size := raw &^ backupFinalFlag
+	if size > backupMaxChunkLen {
+		return ErrBackupDecrypt
+	}
+
+	ciphertext := make([]byte, size)
+	if _, err := io.ReadFull(d.r, ciphertext); err != nil {
+		return errors.New("backup file truncated")
+	}
+
+	aad := make([]byte, 4)
+	binary.BigEndian.PutUint32(aad, flag)
+	nonce := make([]byte, d.aead.NonceSize())
+	copy(nonce, d.prefix)
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], d.seq)
+
+	plain, err := d.aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return ErrBackupDecrypt
+	}
+	d.seq++
+	d.plain = plain
+	d.final = flag != 0
+	return nil
+}
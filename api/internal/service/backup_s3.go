@@ -0,0 +1,149 @@
+// Package core provides a minimal S3-compatible client for backups and archives
+package core
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"seo-generator/api/pkg/config"
+)
+
+// s3Uploader 兼容 S3 协议的对象存储客户端（AWS S3、MinIO、OSS/COS 的 S3 兼容接口）
+// 只实现备份和正文归档需要的 PutObject / GetObject，使用 SigV4 签名和 UNSIGNED-PAYLOAD 流式传输
+type s3Uploader struct {
+	cfg    config.BackupS3Config
+	client *http.Client
+}
+
+func newS3Uploader(cfg config.BackupS3Config) *s3Uploader {
+	return &s3Uploader{cfg: cfg, client: GetHTTPClient().Client(EgressPurposeBackupS3, 30*time.Minute)}
+}
+
+// PutObject 上传对象，body 需提供准确的 size
+func (u *s3Uploader) PutObject(ctx context.Context, key string, body io.Reader, size int64) error {
+	req, err := u.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 put %s: %s: %s", key, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// GetObject 下载对象，调用方负责关闭返回的 body
+func (u *s3Uploader) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := u.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("s3 get %s: %s: %s", key, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp.Body, nil
+}
+
+// newRequest 构造已签名的对象请求
+func (u *s3Uploader) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	endpoint, err := url.Parse(u.cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %q", u.cfg.Endpoint)
+	}
+
+	host := endpoint.Host
+	path := "/" + s3EscapePath(key)
+	if u.cfg.PathStyle {
+		path = "/" + u.cfg.Bucket + path
+	} else {
+		host = u.cfg.Bucket + "." + host
+	}
+	target := endpoint.Scheme + "://" + host + path
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	u.sign(req, host, path, time.Now().UTC())
+	return req, nil
+}
+
+// sign AWS Signature Version 4
+func (u *s3Uploader) sign(req *http.Request, host, path string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	region := u.cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	req.Host = host
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + host + "\n" +
+		"x-amz-content-sha256:UNSIGNED-PAYLOAD\n" +
+		"x-amz-date:" + amzDate + "\n"
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, "", canonicalHeaders, signedHeaders, "UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := s3HMAC([]byte("AWS4"+u.cfg.SecretKey), date)
+	key = s3HMAC(key, region)
+	key = s3HMAC(key, "s3")
+	key = s3HMAC(key, "aws4_request")
+	signature := hex.EncodeToString(s3HMAC(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		u.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3EscapePath 按 SigV4 规则编码对象键（保留 /）
+func s3EscapePath(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
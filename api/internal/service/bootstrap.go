@@ -0,0 +1,374 @@
+// Package core provides idempotent bootstrap seeding of a fresh install from a seed manifest
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// 初始化结果动作
+const (
+	BootstrapActionCreated = "created"
+	BootstrapActionExists  = "exists"
+)
+
+// ErrSeedManifestInvalid 初始化清单格式错误
+var ErrSeedManifestInvalid = errors.New("invalid seed manifest")
+
+// bootstrapCronParser 与调度器一致的 6 段 cron（含秒）
+var bootstrapCronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// SeedManifest 初始化清单（YAML）
+// 所有条目按名称判断是否已存在，已存在的不修改（管理员密码不会被覆盖），可重复执行
+type SeedManifest struct {
+	SiteGroup      SeedSiteGroup    `yaml:"site_group"`
+	KeywordGroups  []SeedGroup      `yaml:"keyword_groups"`
+	ImageGroups    []SeedGroup      `yaml:"image_groups"`
+	ArticleGroups  []SeedGroup      `yaml:"article_groups"`
+	Admin          *SeedAdmin       `yaml:"admin"`
+	Templates      []SeedTemplate   `yaml:"templates"`
+	ScheduledTasks []SeedTaskConfig `yaml:"scheduled_tasks"`
+
+	dir string // 清单所在目录，模板文件路径相对于此
+}
+
+// SeedSiteGroup 默认站群（id 为 1，其他配置默认引用站群 1）
+type SeedSiteGroup struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+}
+
+// SeedGroup 关键词 / 图片 / 文章分组（属于默认站群）
+type SeedGroup struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Default     bool   `yaml:"default"` // 表中还没有默认分组时设为默认
+}
+
+// SeedAdmin 初始管理员，password 为空时生成随机密码并在结果中返回（仅创建时）
+type SeedAdmin struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// SeedTemplate 示例模板，content 与 file 二选一（file 相对于清单目录）
+type SeedTemplate struct {
+	Name        string `yaml:"name"`
+	DisplayName string `yaml:"display_name"`
+	Description string `yaml:"description"`
+	Content     string `yaml:"content"`
+	File        string `yaml:"file"`
+}
+
+// SeedTaskConfig 定时任务
+type SeedTaskConfig struct {
+	Name     string                 `yaml:"name"`
+	TaskType string                 `yaml:"task_type"`
+	CronExpr string                 `yaml:"cron_expr"`
+	Params   map[string]interface{} `yaml:"params"`
+	Enabled  *bool                  `yaml:"enabled"` // 默认启用
+}
+
+// BootstrapItem 单个条目的处理结果
+type BootstrapItem struct {
+	Kind   string `json:"kind"` // site_group / keyword_group / image_group / article_group / admin / template / scheduled_task
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// BootstrapReport 初始化结果
+type BootstrapReport struct {
+	DryRun        bool            `json:"dry_run"`
+	Items         []BootstrapItem `json:"items"`
+	Created       int             `json:"created"`
+	AdminPassword string          `json:"admin_password,omitempty"` // 生成的管理员密码，仅本次创建时返回
+}
+
+func (r *BootstrapReport) add(kind, name, action, detail string) {
+	r.Items = append(r.Items, BootstrapItem{Kind: kind, Name: name, Action: action, Detail: detail})
+	if action == BootstrapActionCreated {
+		r.Created++
+	}
+}
+
+// LoadSeedManifest 读取并校验初始化清单
+func LoadSeedManifest(path string) (*SeedManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m SeedManifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSeedManifestInvalid, err)
+	}
+	m.dir = filepath.Dir(path)
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (m *SeedManifest) validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrSeedManifestInvalid, fmt.Sprintf(format, args...))
+	}
+	if strings.TrimSpace(m.SiteGroup.Name) == "" {
+		return invalid("site_group.name is required")
+	}
+	for kind, groups := range map[string][]SeedGroup{
+		"keyword_groups": m.KeywordGroups, "image_groups": m.ImageGroups, "article_groups": m.ArticleGroups,
+	} {
+		for i, g := range groups {
+			if strings.TrimSpace(g.Name) == "" {
+				return invalid("%s[%d].name is required", kind, i)
+			}
+		}
+	}
+	if m.Admin != nil && strings.TrimSpace(m.Admin.Username) == "" {
+		return invalid("admin.username is required")
+	}
+	if m.Admin != nil && m.Admin.Password != "" && len(m.Admin.Password) < 6 {
+		return invalid("admin.password must be at least 6 characters")
+	}
+	for i := range m.Templates {
+		t := &m.Templates[i]
+		if strings.TrimSpace(t.Name) == "" || len(t.Name) > 100 {
+			return invalid("templates[%d].name is required (max 100 characters)", i)
+		}
+		if t.Content == "" && t.File == "" {
+			return invalid("templates[%d] needs content or file", i)
+		}
+		if t.Content == "" {
+			path := t.File
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(m.dir, path)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return invalid("templates[%d].file: %v", i, err)
+			}
+			t.Content = string(data)
+		}
+	}
+	for i, t := range m.ScheduledTasks {
+		if strings.TrimSpace(t.Name) == "" || strings.TrimSpace(t.TaskType) == "" {
+			return invalid("scheduled_tasks[%d] needs name and task_type", i)
+		}
+		if _, err := bootstrapCronParser.Parse(t.CronExpr); err != nil {
+			return invalid("scheduled_tasks[%d].cron_expr: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// NeedsBootstrap 是否为未初始化的空库（没有站群或没有管理员）
+func NeedsBootstrap(ctx context.Context, db *sqlx.DB) (bool, error) {
+	var groups, admins int
+	if err := db.GetContext(ctx, &groups, "SELECT COUNT(*) FROM site_groups"); err != nil {
+		return false, err
+	}
+	if err := db.GetContext(ctx, &admins, "SELECT COUNT(*) FROM admins"); err != nil {
+		return false, err
+	}
+	return groups == 0 || admins == 0, nil
+}
+
+// Bootstrap 按清单初始化默认数据（单个事务，dryRun 时回滚，只返回将要执行的动作）
+func Bootstrap(ctx context.Context, db *sqlx.DB, m *SeedManifest, dryRun bool) (*BootstrapReport, error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	s := &bootstrapper{ctx: ctx, tx: tx, report: &BootstrapReport{DryRun: dryRun, Items: []BootstrapItem{}}}
+	steps := []func(*SeedManifest) error{
+		s.siteGroup,
+		func(m *SeedManifest) error { return s.groups("keyword_groups", "keyword_group", m.KeywordGroups) },
+		func(m *SeedManifest) error { return s.groups("image_groups", "image_group", m.ImageGroups) },
+		func(m *SeedManifest) error { return s.groups("article_groups", "article_group", m.ArticleGroups) },
+		s.admin,
+		s.templates,
+		s.scheduledTasks,
+	}
+	for _, step := range steps {
+		if err := step(m); err != nil {
+			return nil, err
+		}
+	}
+
+	if !dryRun {
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		if s.report.Created > 0 {
+			GetQueryCache().Invalidate("site_groups", "keyword_groups", "image_groups", "article_groups", "templates")
+		}
+	}
+	return s.report, nil
+}
+
+type bootstrapper struct {
+	ctx    context.Context
+	tx     *sqlx.Tx
+	report *BootstrapReport
+}
+
+// siteGroup 默认站群固定为 id 1（站点、分组、模板创建时的默认站群）
+func (s *bootstrapper) siteGroup(m *SeedManifest) error {
+	var name string
+	err := s.tx.GetContext(s.ctx, &name, "SELECT name FROM site_groups WHERE id = 1")
+	if err == nil {
+		s.report.add("site_group", name, BootstrapActionExists, "")
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if _, err := s.tx.ExecContext(s.ctx,
+		"INSERT INTO site_groups (id, name, description, is_default, status) VALUES (1, ?, ?, 1, 1)",
+		m.SiteGroup.Name, m.SiteGroup.Description); err != nil {
+		return fmt.Errorf("create site group: %w", err)
+	}
+	s.report.add("site_group", m.SiteGroup.Name, BootstrapActionCreated, "")
+	return nil
+}
+
+func (s *bootstrapper) groups(table, kind string, groups []SeedGroup) error {
+	for _, g := range groups {
+		var exists int
+		if err := s.tx.GetContext(s.ctx, &exists,
+			"SELECT COUNT(*) FROM "+table+" WHERE site_group_id = 1 AND name = ?", g.Name); err != nil {
+			return err
+		}
+		if exists > 0 {
+			s.report.add(kind, g.Name, BootstrapActionExists, "")
+			continue
+		}
+		isDefault := 0
+		if g.Default {
+			var defaults int
+			if err := s.tx.GetContext(s.ctx, &defaults, "SELECT COUNT(*) FROM "+table+" WHERE is_default = 1"); err != nil {
+				return err
+			}
+			if defaults == 0 {
+				isDefault = 1
+			}
+		}
+		if _, err := s.tx.ExecContext(s.ctx,
+			"INSERT INTO "+table+" (site_group_id, name, description, is_default) VALUES (1, ?, ?, ?)",
+			g.Name, g.Description, isDefault); err != nil {
+			return fmt.Errorf("create %s %q: %w", kind, g.Name, err)
+		}
+		detail := ""
+		if isDefault == 1 {
+			detail = "default"
+		}
+		s.report.add(kind, g.Name, BootstrapActionCreated, detail)
+	}
+	return nil
+}
+
+func (s *bootstrapper) admin(m *SeedManifest) error {
+	if m.Admin == nil {
+		return nil
+	}
+	var exists int
+	if err := s.tx.GetContext(s.ctx, &exists, "SELECT COUNT(*) FROM admins WHERE username = ?", m.Admin.Username); err != nil {
+		return err
+	}
+	if exists > 0 {
+		s.report.add("admin", m.Admin.Username, BootstrapActionExists, "password unchanged")
+		return nil
+	}
+	password := m.Admin.Password
+	if password == "" {
+		buf := make([]byte, 9)
+		if _, err := rand.Read(buf); err != nil {
+			return err
+		}
+		password = hex.EncodeToString(buf)
+		s.report.AdminPassword = password
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if _, err := s.tx.ExecContext(s.ctx, "INSERT INTO admins (username, password) VALUES (?, ?)", m.Admin.Username, hash); err != nil {
+		return fmt.Errorf("create admin: %w", err)
+	}
+	detail := "password from manifest"
+	if m.Admin.Password == "" {
+		detail = "generated password"
+	}
+	s.report.add("admin", m.Admin.Username, BootstrapActionCreated, detail)
+	return nil
+}
+
+func (s *bootstrapper) templates(m *SeedManifest) error {
+	for _, t := range m.Templates {
+		var exists int
+		if err := s.tx.GetContext(s.ctx, &exists,
+			"SELECT COUNT(*) FROM templates WHERE site_group_id = 1 AND name = ?", t.Name); err != nil {
+			return err
+		}
+		if exists > 0 {
+			s.report.add("template", t.Name, BootstrapActionExists, "")
+			continue
+		}
+		displayName := t.DisplayName
+		if displayName == "" {
+			displayName = t.Name
+		}
+		if _, err := s.tx.ExecContext(s.ctx,
+			`INSERT INTO templates (site_group_id, name, display_name, description, content, status, version)
+			 VALUES (1, ?, ?, ?, ?, 1, 1)`,
+			t.Name, displayName, t.Description, t.Content); err != nil {
+			return fmt.Errorf("create template %q: %w", t.Name, err)
+		}
+		s.report.add("template", t.Name, BootstrapActionCreated, "")
+	}
+	return nil
+}
+
+func (s *bootstrapper) scheduledTasks(m *SeedManifest) error {
+	for _, t := range m.ScheduledTasks {
+		var exists int
+		if err := s.tx.GetContext(s.ctx, &exists, "SELECT COUNT(*) FROM scheduled_tasks WHERE name = ?", t.Name); err != nil {
+			return err
+		}
+		if exists > 0 {
+			s.report.add("scheduled_task", t.Name, BootstrapActionExists, "")
+			continue
+		}
+		params := t.Params
+		if params == nil {
+			params = map[string]interface{}{}
+		}
+		paramsJSON, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("scheduled task %q params: %w", t.Name, err)
+		}
+		enabled := t.Enabled == nil || *t.Enabled
+		if _, err := s.tx.ExecContext(s.ctx,
+			"INSERT INTO scheduled_tasks (name, task_type, cron_expr, params, enabled) VALUES (?, ?, ?, ?, ?)",
+			t.Name, t.TaskType, t.CronExpr, string(paramsJSON), enabled); err != nil {
+			return fmt.Errorf("create scheduled task %q: %w", t.Name, err)
+		}
+		s.report.add("scheduled_task", t.Name, BootstrapActionCreated, t.TaskType+" "+t.CronExpr)
+	}
+	return nil
+}
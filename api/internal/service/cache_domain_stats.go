@@ -0,0 +1,120 @@
+// Package core provides per-domain cache hit accounting
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// DomainCacheCounter 单个域名在某小时内的缓存计数
+type DomainCacheCounter struct {
+	Domain    string
+	HourStart time.Time
+	Hits      int64
+	Misses    int64
+	Bytes     int64
+}
+
+// maxDomainCacheCounters 内存中最多保留的计数条目，防止写库长期失败时无限增长
+const maxDomainCacheCounters = 50000
+
+// domainHourKey 聚合键
+type domainHourKey struct {
+	domain string
+	hour   int64 // 小时起点 Unix 时间戳
+}
+
+// DomainCacheStats 按域名、按小时累计缓存命中/未命中/流量
+// 命中和未命中都按蜘蛛访问记录计数（RecordVisit）：Nginx 缓存命中由 Lua 上报，/page 的 Go 缓存命中和
+// 渲染（未命中）由 PageHandler 记录，统计口径相同（只含蜘蛛）；由 StatsArchiver 定期取出增量写入 cache_stats_hourly
+type DomainCacheStats struct {
+	mu       sync.Mutex
+	counters map[domainHourKey]*DomainCacheCounter
+}
+
+// 全局实例
+var globalDomainCacheStats = &DomainCacheStats{counters: make(map[domainHourKey]*DomainCacheCounter)}
+
+// GetDomainCacheStats 获取全局域名缓存统计实例
+func GetDomainCacheStats() *DomainCacheStats {
+	return globalDomainCacheStats
+}
+
+// Record 记录一次缓存访问
+func (s *DomainCacheStats) Record(domain string, hit bool, bytes int, at time.Time) {
+	if domain == "" {
+		return
+	}
+	if len(domain) > 255 {
+		domain = domain[:255]
+	}
+	hour := at.Truncate(time.Hour)
+	key := domainHourKey{domain: domain, hour: hour.Unix()}
+
+	s.mu.Lock()
+	c, ok := s.counters[key]
+	if !ok {
+		c = &DomainCacheCounter{Domain: domain, HourStart: hour}
+		s.counters[key] = c
+	}
+	if hit {
+		c.Hits++
+	} else {
+		c.Misses++
+	}
+	if bytes > 0 {
+		c.Bytes += int64(bytes)
+	}
+	s.mu.Unlock()
+}
+
+// RecordVisit 按一条蜘蛛访问记录计数（CacheHit=1 为命中），重定向和错误响应不计入
+func (s *DomainCacheStats) RecordVisit(r SpiderVisitRecord) {
+	if r.Status >= 300 {
+		return
+	}
+	at := r.CreatedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	s.Record(r.Domain, r.CacheHit == 1, r.Bytes, at)
+}
+
+// Drain 取出并清空当前累计的增量
+func (s *DomainCacheStats) Drain() []DomainCacheCounter {
+	s.mu.Lock()
+	if len(s.counters) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	pending := s.counters
+	s.counters = make(map[domainHourKey]*DomainCacheCounter)
+	s.mu.Unlock()
+
+	out := make([]DomainCacheCounter, 0, len(pending))
+	for _, c := range pending {
+		out = append(out, *c)
+	}
+	return out
+}
+
+// Restore 写库失败时放回增量，下次重试
+func (s *DomainCacheStats) Restore(counters []DomainCacheCounter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range counters {
+		if len(s.counters) >= maxDomainCacheCounters {
+			return
+		}
+		key := domainHourKey{domain: c.Domain, hour: c.HourStart.Unix()}
+		existing, ok := s.counters[key]
+		if !ok {
+			cc := c
+			s.counters[key] = &cc
+			continue
+		}
+		existing.Hits += c.Hits
+		existing.Misses += c.Misses
+		existing.Bytes += c.Bytes
+	}
+}
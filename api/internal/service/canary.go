@@ -0,0 +1,353 @@
+// Package core provides the synthetic page render self-check (canary)
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// AlertTypeCanaryFailed 渲染自检连续失败告警类型
+const AlertTypeCanaryFailed = "canary_failed"
+
+// ErrCanaryRunning 已有自检在进行中
+var ErrCanaryRunning = errors.New("canary check is running")
+
+// CanaryCheck 一次渲染自检结果（canary_checks 表）
+type CanaryCheck struct {
+	ID          int64     `db:"id" json:"id"`
+	SiteGroupID int       `db:"site_group_id" json:"site_group_id"`
+	Domain      string    `db:"domain" json:"domain"`
+	Path        string    `db:"path" json:"path"`
+	Success     bool      `db:"success" json:"success"`
+	LatencyMs   int       `db:"latency_ms" json:"latency_ms"`
+	Bytes       int       `db:"bytes" json:"bytes"`
+	Error       string    `db:"error" json:"error"`
+	CheckedAt   time.Time `db:"checked_at" json:"checked_at"`
+}
+
+// CanaryGroupStatus 站群的自检状态
+type CanaryGroupStatus struct {
+	SiteGroupID   int          `json:"site_group_id"`
+	FailureStreak int          `json:"failure_streak"`
+	Alerting      bool         `json:"alerting"` // 连续失败次数达到告警阈值
+	LastOKAt      *time.Time   `json:"last_ok_at"`
+	Last          *CanaryCheck `json:"last"`
+}
+
+// CanarySummary 自检汇总
+type CanarySummary struct {
+	Enabled   bool                `json:"enabled"`
+	Total     int                 `json:"total"` // 已检查的站群数
+	Healthy   int                 `json:"healthy"`
+	Failing   int                 `json:"failing"`
+	Alerting  int                 `json:"alerting"`
+	LastRunAt *time.Time          `json:"last_run_at"`
+	Groups    []CanaryGroupStatus `json:"groups"`
+}
+
+// Canary 渲染自检
+// 每个间隔为每个站群随机挑选一个启用站点，经页面 handler 的正常渲染流程只读生成一页（不读写缓存，
+// 标题和正文只查看不取出数据池，不计入模板使用和灰度统计），检查渲染成功、页面大小、<title> 非空和耗时上限；
+// 每次结果写入 canary_checks，站群连续失败达到阈值时告警，全部恢复后解除。
+// 用于在蜘蛛之前发现模板损坏、数据池为空等问题
+type Canary struct {
+	db       *sqlx.DB
+	config   config.CanaryConfig
+	alerts   *AlertManager
+	renderer PageDryRunner
+
+	running   sync.Mutex
+	mu        sync.Mutex
+	groups    map[int]*CanaryGroupStatus
+	lastRunAt *time.Time
+}
+
+// NewCanary 创建渲染自检
+func NewCanary(db *sqlx.DB, cfg config.CanaryConfig) *Canary {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 60
+	}
+	if cfg.Path == "" || !strings.HasPrefix(cfg.Path, "/") {
+		cfg.Path = "/canary-check.html"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 3
+	}
+	if cfg.HistoryDays <= 0 {
+		cfg.HistoryDays = 7
+	}
+	return &Canary{db: db, config: cfg, groups: make(map[int]*CanaryGroupStatus)}
+}
+
+// SetAlertManager 设置告警管理器
+func (c *Canary) SetAlertManager(am *AlertManager) {
+	c.alerts = am
+}
+
+// SetRenderer 设置页面渲染器（页面 handler）
+func (c *Canary) SetRenderer(r PageDryRunner) {
+	c.renderer = r
+}
+
+// Start 按间隔自检，ctx 取消时退出
+func (c *Canary) Start(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(c.config.Interval) * time.Second)
+	defer ticker.Stop()
+
+	var lastCleanup time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := c.Run(ctx, 0); err != nil && !errors.Is(err, ErrCanaryRunning) {
+				log.Warn().Err(err).Msg("Canary check failed")
+			}
+			if now.Sub(lastCleanup) >= time.Hour {
+				if _, err := c.db.ExecContext(ctx, "DELETE FROM canary_checks WHERE checked_at < ?",
+					now.AddDate(0, 0, -c.config.HistoryDays)); err != nil {
+					log.Warn().Err(err).Msg("Failed to prune canary checks")
+				}
+				lastCleanup = now
+			}
+		}
+	}
+}
+
+// Run 对每个启用站群渲染一页（siteGroupID 不为 0 时只检查该站群），返回本次结果
+func (c *Canary) Run(ctx context.Context, siteGroupID int) ([]CanaryCheck, error) {
+	if c.renderer == nil {
+		return nil, errors.New("canary renderer not set")
+	}
+	if !c.running.TryLock() {
+		return nil, ErrCanaryRunning
+	}
+	defer c.running.Unlock()
+
+	query := `SELECT s.site_group_id, s.domain FROM sites s
+		JOIN site_groups g ON g.id = s.site_group_id
+		WHERE s.status = 1 AND g.status = 1`
+	args := []interface{}{}
+	if siteGroupID > 0 {
+		query += " AND s.site_group_id = ?"
+		args = append(args, siteGroupID)
+	}
+	var sites []struct {
+		SiteGroupID int    `db:"site_group_id"`
+		Domain      string `db:"domain"`
+	}
+	if err := c.db.SelectContext(ctx, &sites, query, args...); err != nil {
+		return nil, fmt.Errorf("load sites: %w", err)
+	}
+	// 每个站群随机挑一个站点，轮流覆盖不同站点的配置
+	picked := make(map[int]string)
+	seen := make(map[int]int)
+	for _, s := range sites {
+		seen[s.SiteGroupID]++
+		if rand.Intn(seen[s.SiteGroupID]) == 0 {
+			picked[s.SiteGroupID] = s.Domain
+		}
+	}
+
+	results := make([]CanaryCheck, 0, len(picked))
+	var resultsMu sync.Mutex
+	sem := make(chan struct{}, c.config.Concurrency)
+	var wg sync.WaitGroup
+	for groupID, domain := range picked {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(groupID int, domain string) {
+			defer func() { <-sem; wg.Done() }()
+			check := c.check(ctx, groupID, domain)
+			if err := c.save(ctx, check); err != nil {
+				log.Warn().Err(err).Int("site_group_id", groupID).Msg("Failed to save canary check")
+			}
+			resultsMu.Lock()
+			results = append(results, *check)
+			resultsMu.Unlock()
+		}(groupID, domain)
+	}
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool { return results[i].SiteGroupID < results[j].SiteGroupID })
+
+	now := time.Now()
+	c.mu.Lock()
+	for _, r := range results {
+		c.record(r)
+	}
+	// 已删除、禁用或没有启用站点的站群不再参与汇总
+	if siteGroupID == 0 {
+		for id := range c.groups {
+			if _, ok := picked[id]; !ok {
+				delete(c.groups, id)
+			}
+		}
+		c.lastRunAt = &now
+	}
+	c.mu.Unlock()
+
+	c.raiseAlerts(results)
+	return results, nil
+}
+
+// check 渲染一页并校验输出
+func (c *Canary) check(ctx context.Context, siteGroupID int, domain string) *CanaryCheck {
+	check := &CanaryCheck{SiteGroupID: siteGroupID, Domain: domain, Path: c.config.Path, CheckedAt: time.Now()}
+
+	renderCtx, cancel := context.WithTimeout(ctx, time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+	start := time.Now()
+	page, err := c.renderer.DryRunPage(renderCtx, domain, c.config.Path)
+	check.LatencyMs = int(time.Since(start).Milliseconds())
+	check.Bytes = len(page)
+
+	var errs []string
+	if err != nil {
+		errs = append(errs, "render: "+err.Error())
+	} else {
+		if strings.TrimSpace(page) == "" {
+			errs = append(errs, "empty output")
+		} else if c.config.MinBytes > 0 && check.Bytes < c.config.MinBytes {
+			errs = append(errs, fmt.Sprintf("output too small: %d bytes < %d", check.Bytes, c.config.MinBytes))
+		}
+		// 标题来自关键词池，为空通常说明数据池已空
+		if m := freshnessTitlePattern.FindStringSubmatch(page); m == nil || strings.TrimSpace(html.UnescapeString(m[1])) == "" {
+			errs = append(errs, "empty title")
+		}
+	}
+	if c.config.MaxLatencyMs > 0 && check.LatencyMs > c.config.MaxLatencyMs {
+		errs = append(errs, fmt.Sprintf("slow render: %dms > %dms", check.LatencyMs, c.config.MaxLatencyMs))
+	}
+
+	check.Success = len(errs) == 0
+	check.Error = truncateRunes(strings.Join(errs, "; "), 500)
+	return check
+}
+
+// save 写入检查记录
+func (c *Canary) save(ctx context.Context, check *CanaryCheck) error {
+	res, err := c.db.ExecContext(ctx, `
+		INSERT INTO canary_checks (site_group_id, domain, path, success, latency_ms, bytes, error, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		check.SiteGroupID, check.Domain, check.Path, check.Success, check.LatencyMs, check.Bytes, check.Error, check.CheckedAt)
+	if err != nil {
+		return err
+	}
+	check.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// record 更新站群状态（调用方持有 c.mu）
+func (c *Canary) record(check CanaryCheck) {
+	status, ok := c.groups[check.SiteGroupID]
+	if !ok {
+		status = &CanaryGroupStatus{SiteGroupID: check.SiteGroupID}
+		c.groups[check.SiteGroupID] = status
+	}
+	if check.Success {
+		status.FailureStreak = 0
+		status.LastOKAt = &check.CheckedAt
+	} else {
+		status.FailureStreak++
+	}
+	status.Alerting = status.FailureStreak >= c.config.FailureThreshold
+	status.Last = &check
+}
+
+// raiseAlerts 站群连续失败达到阈值时告警（只在达到阈值的那一次触发），全部恢复后解除
+func (c *Canary) raiseAlerts(results []CanaryCheck) {
+	if c.alerts == nil {
+		return
+	}
+	c.mu.Lock()
+	var newly []string
+	alerting := 0
+	for _, r := range results {
+		if status := c.groups[r.SiteGroupID]; status != nil && status.FailureStreak == c.config.FailureThreshold {
+			newly = append(newly, fmt.Sprintf("站群 %d / %s（%s）", r.SiteGroupID, r.Domain, r.Error))
+		}
+	}
+	for _, status := range c.groups {
+		if status.Alerting {
+			alerting++
+		}
+	}
+	c.mu.Unlock()
+
+	if len(newly) > 0 {
+		c.alerts.Raise(AlertLevelError, AlertTypeCanaryFailed,
+			fmt.Sprintf("%d 个站群渲染自检连续 %d 次失败: %s", len(newly), c.config.FailureThreshold, strings.Join(newly, ", ")),
+			float64(alerting), float64(c.config.FailureThreshold))
+	} else if alerting == 0 {
+		c.alerts.Resolve(AlertTypeCanaryFailed)
+	}
+}
+
+// Summary 各站群最近的自检状态，失败的站群排在前面
+func (c *Canary) Summary() *CanarySummary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	summary := &CanarySummary{Enabled: c.config.Enabled, LastRunAt: c.lastRunAt, Groups: []CanaryGroupStatus{}}
+	for _, status := range c.groups {
+		summary.Total++
+		switch {
+		case status.FailureStreak == 0:
+			summary.Healthy++
+		case status.Alerting:
+			summary.Alerting++
+			summary.Failing++
+		default:
+			summary.Failing++
+		}
+		summary.Groups = append(summary.Groups, *status)
+	}
+	sort.Slice(summary.Groups, func(i, j int) bool {
+		a, b := summary.Groups[i], summary.Groups[j]
+		if a.FailureStreak != b.FailureStreak {
+			return a.FailureStreak > b.FailureStreak
+		}
+		return a.SiteGroupID < b.SiteGroupID
+	})
+	return summary
+}
+
+// History 站群最近的自检记录（siteGroupID 为 0 时不限站群）
+func (c *Canary) History(ctx context.Context, siteGroupID, limit int, failedOnly bool) ([]CanaryCheck, error) {
+	query := "SELECT id, site_group_id, domain, path, success, latency_ms, bytes, error, checked_at FROM canary_checks WHERE 1 = 1"
+	args := []interface{}{}
+	if siteGroupID > 0 {
+		query += " AND site_group_id = ?"
+		args = append(args, siteGroupID)
+	}
+	if failedOnly {
+		query += " AND success = 0"
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	items := []CanaryCheck{}
+	err := c.db.SelectContext(ctx, &items, query, args...)
+	return items, err
+}
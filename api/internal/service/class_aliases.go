@@ -0,0 +1,55 @@
+package core
+
+import (
+	"math/rand/v2"
+	"sync"
+)
+
+// ClassAliases 单次渲染的 CSS 类名别名表
+// 同一页面中 cls('btn') / cls_name('btn') 得到相同的随机类名，<style> 和标签可以对应；
+// 每次渲染重新生成，不同页面的别名不同
+type ClassAliases struct {
+	mu      sync.Mutex
+	aliases map[string]string
+	used    map[string]bool
+}
+
+// NewClassAliases 创建别名表
+func NewClassAliases() *ClassAliases {
+	return &ClassAliases{aliases: map[string]string{}, used: map[string]bool{}}
+}
+
+// Alias 返回逻辑类名在本页面的随机别名，首次调用时生成（页面内不重复）
+func (a *ClassAliases) Alias(name string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if alias, ok := a.aliases[name]; ok {
+		return alias
+	}
+	alias := generateClassAlias()
+	for a.used[alias] {
+		alias = generateClassAlias()
+	}
+	a.aliases[name] = alias
+	a.used[alias] = true
+	return alias
+}
+
+// Len 已生成的别名数
+func (a *ClassAliases) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.aliases)
+}
+
+// generateClassAlias 生成合法的 CSS 类名（字母开头，可直接用作选择器）
+func generateClassAlias() string {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	const chars = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, 10)
+	b[0] = letters[rand.IntN(len(letters))]
+	for i := 1; i < len(b); i++ {
+		b[i] = chars[rand.IntN(len(chars))]
+	}
+	return string(b)
+}
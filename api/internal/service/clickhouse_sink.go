@@ -0,0 +1,402 @@
+// Package core provides an optional ClickHouse sink for spider visit analytics
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	models "seo-generator/api/internal/model"
+	"seo-generator/api/pkg/config"
+)
+
+// clickHouseIdentRe 库名/表名白名单，防止拼接 SQL 注入
+var clickHouseIdentRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ClickHouseSink 通过 HTTP 接口写入和查询 ClickHouse
+// 仅用于蜘蛛访问分析：数据量大时聚合查询远快于 MySQL
+type ClickHouseSink struct {
+	endpoint string
+	database string
+	table    string
+	user     string
+	password string
+	client   *http.Client
+
+	// 按日/小时聚合的时区：created_at 以 UTC 存储，查询时换算到 tz，与 MySQL（本地时间）的统计口径一致
+	tz  string
+	loc *time.Location
+
+	written atomic.Int64
+	failed  atomic.Int64
+}
+
+// NewClickHouseSink 根据配置创建 ClickHouse 写入器
+func NewClickHouseSink(cfg config.ClickHouseConfig) (*ClickHouseSink, error) {
+	if _, err := url.Parse(cfg.URL); err != nil || cfg.URL == "" {
+		return nil, fmt.Errorf("invalid clickhouse url: %q", cfg.URL)
+	}
+	if !clickHouseIdentRe.MatchString(cfg.Database) || !clickHouseIdentRe.MatchString(cfg.Table) {
+		return nil, fmt.Errorf("invalid clickhouse database/table name: %s.%s", cfg.Database, cfg.Table)
+	}
+	tz, loc := cfg.Timezone, time.Local
+	if tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("invalid clickhouse timezone: %q", tz)
+		}
+	} else {
+		tz = localTimezoneName()
+	}
+	return &ClickHouseSink{
+		endpoint: cfg.URL,
+		database: cfg.Database,
+		table:    cfg.Table,
+		user:     cfg.User,
+		password: cfg.Password,
+		client:   GetHTTPClient().Client(EgressPurposeClickHouse, 30*time.Second),
+		tz:       tz,
+		loc:      loc,
+	}, nil
+}
+
+// localTimezoneName 进程本地时区的 IANA 名称（time.Local 的名称固定为 "Local"，不能直接传给 ClickHouse）
+// 依次取 TZ 环境变量、/etc/localtime 链接目标；都取不到时按当前 UTC 偏移换算为 Etc/GMT±N（不含夏令时规则）
+func localTimezoneName() string {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" {
+		if _, err := time.LoadLocation(tz); err == nil {
+			return tz
+		}
+	}
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(target, "zoneinfo/"); ok && name != "" {
+			return name
+		}
+	}
+	_, offset := time.Now().Zone()
+	if offset == 0 || offset%3600 != 0 {
+		return "UTC"
+	}
+	// Etc/GMT 的符号与 UTC 偏移相反：UTC+8 为 Etc/GMT-8
+	return fmt.Sprintf("Etc/GMT%+d", -offset/3600)
+}
+
+// tableName 返回完整表名
+func (s *ClickHouseSink) tableName() string {
+	return s.database + "." + s.table
+}
+
+// EnsureTable 创建蜘蛛日志表（如不存在）
+// created_at 固定为 UTC，写入和比较不受 ClickHouse 服务器时区影响；按日/小时聚合时换算到 s.tz。
+// 旧版本创建的表（DateTime 无时区）在服务器时区不是 UTC 时需执行
+// ALTER TABLE ... MODIFY COLUMN created_at DateTime('UTC')
+func (s *ClickHouseSink) EnsureTable(ctx context.Context) error {
+	ddl := `CREATE TABLE IF NOT EXISTS ` + s.tableName() + ` (
+		spider_type LowCardinality(String),
+		ip String,
+		ua String,
+		domain String,
+		path String,
+		dns_ok UInt8,
+		resp_time UInt32,
+		cache_hit UInt8,
+		status UInt16,
+		created_at DateTime('UTC')
+	) ENGINE = MergeTree
+	PARTITION BY toYYYYMM(created_at)
+	ORDER BY (spider_type, created_at)`
+
+	if err := s.exec(ctx, "CREATE DATABASE IF NOT EXISTS "+s.database, nil, nil); err != nil {
+		return err
+	}
+	return s.exec(ctx, ddl, nil, nil)
+}
+
+// clickHouseRow JSONEachRow 写入格式
+type clickHouseRow struct {
+	SpiderType string `json:"spider_type"`
+	IP         string `json:"ip"`
+	UA         string `json:"ua"`
+	Domain     string `json:"domain"`
+	Path       string `json:"path"`
+	DNSOk      int    `json:"dns_ok"`
+	RespTime   int    `json:"resp_time"`
+	CacheHit   int    `json:"cache_hit"`
+	Status     int    `json:"status"`
+	CreatedAt  string `json:"created_at"` // UTC
+}
+
+// Write 批量写入蜘蛛访问记录
+func (s *ClickHouseSink) Write(ctx context.Context, records []SpiderVisitRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, r := range records {
+		row := clickHouseRow{
+			SpiderType: r.SpiderType,
+			IP:         r.IP,
+			UA:         r.UA,
+			Domain:     r.Domain,
+			Path:       r.Path,
+			DNSOk:      r.DNSOk,
+			RespTime:   r.RespTime,
+			CacheHit:   r.CacheHit,
+			Status:     r.Status,
+			CreatedAt:  r.CreatedAt.UTC().Format("2006-01-02 15:04:05"),
+		}
+		if err := enc.Encode(&row); err != nil {
+			return err
+		}
+	}
+
+	err := s.exec(ctx, "INSERT INTO "+s.tableName()+" FORMAT JSONEachRow", nil, &body)
+	if err != nil {
+		s.failed.Add(int64(len(records)))
+		return err
+	}
+	s.written.Add(int64(len(records)))
+	return nil
+}
+
+// Query 执行查询并将 data 部分解码到 dest
+// params 通过 ClickHouse 参数化查询传递，SQL 中以 {name:Type} 引用
+func (s *ClickHouseSink) Query(ctx context.Context, query string, params map[string]string, dest interface{}) error {
+	var out bytes.Buffer
+	if err := s.do(ctx, query+" FORMAT JSON", params, nil, &out); err != nil {
+		return err
+	}
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return fmt.Errorf("decode clickhouse response: %w", err)
+	}
+	return json.Unmarshal(resp.Data, dest)
+}
+
+// exec 执行无结果语句
+func (s *ClickHouseSink) exec(ctx context.Context, query string, params map[string]string, body io.Reader) error {
+	return s.do(ctx, query, params, body, io.Discard)
+}
+
+// do 发送 HTTP 请求；有 body 时 SQL 放在 query 参数中
+func (s *ClickHouseSink) do(ctx context.Context, query string, params map[string]string, body io.Reader, out io.Writer) error {
+	q := url.Values{}
+	q.Set("database", s.database)
+	// 64 位整数不加引号，便于直接解码为 int
+	q.Set("output_format_json_quote_64bit_integers", "0")
+	for k, v := range params {
+		q.Set("param_"+k, v)
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		q.Set("query", query)
+		reqBody = body
+	} else {
+		reqBody = bytes.NewBufferString(query)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/?"+q.Encode(), reqBody)
+	if err != nil {
+		return err
+	}
+	if s.user != "" {
+		req.Header.Set("X-ClickHouse-User", s.user)
+		req.Header.Set("X-ClickHouse-Key", s.password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("clickhouse request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return fmt.Errorf("clickhouse error (status %d): %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
+
+// Ping 检查 ClickHouse 是否可用
+func (s *ClickHouseSink) Ping(ctx context.Context) error {
+	return s.exec(ctx, "SELECT 1", nil, nil)
+}
+
+// GetStats 获取写入统计
+func (s *ClickHouseSink) GetStats() map[string]interface{} {
+	return map[string]interface{}{
+		"table":   s.tableName(),
+		"written": s.written.Load(),
+		"failed":  s.failed.Load(),
+	}
+}
+
+// Clear 删除蜘蛛日志（ClickHouse mutation，后台异步执行）
+func (s *ClickHouseSink) Clear(ctx context.Context, spiderType string, beforeDays int) error {
+	where := "1 = 1"
+	params := map[string]string{}
+	if spiderType != "" {
+		where += " AND spider_type = {spider_type:String}"
+		params["spider_type"] = spiderType
+	}
+	if beforeDays > 0 {
+		where += " AND created_at < now() - INTERVAL {before_days:UInt32} DAY"
+		params["before_days"] = strconv.Itoa(beforeDays)
+	}
+	return s.exec(ctx, "ALTER TABLE "+s.tableName()+" DELETE WHERE "+where, params, nil)
+}
+
+// ============================================
+// 分析查询（与 MySQL 版本返回结构一致）
+// ============================================
+
+// SpiderTypeCount 按蜘蛛类型的访问数
+type SpiderTypeCount struct {
+	SpiderType string `json:"spider_type"`
+	Count      int    `json:"count"`
+}
+
+// SpiderCountsByType 按蜘蛛类型统计访问总数
+func (s *ClickHouseSink) SpiderCountsByType(ctx context.Context) ([]SpiderTypeCount, error) {
+	var rows []SpiderTypeCount
+	err := s.Query(ctx, `
+		SELECT spider_type, count() AS count
+		FROM `+s.tableName()+`
+		GROUP BY spider_type
+		ORDER BY count DESC`, nil, &rows)
+	return rows, err
+}
+
+// SpiderDailyPoint 每日统计点
+type SpiderDailyPoint struct {
+	Date  string `json:"date"`
+	Total int    `json:"total"`
+}
+
+// SpiderDaily 最近 days 天每日访问量
+func (s *ClickHouseSink) SpiderDaily(ctx context.Context, days int, spiderType string) ([]SpiderDailyPoint, error) {
+	where, params := s.spiderWhere("created_at >= now() - INTERVAL {n:UInt32} DAY", days, spiderType)
+	var rows []SpiderDailyPoint
+	err := s.Query(ctx, `
+		SELECT toString(toDate(created_at, {tz:String})) AS date, count() AS total
+		FROM `+s.tableName()+`
+		WHERE `+where+`
+		GROUP BY date
+		ORDER BY date ASC`, params, &rows)
+	return rows, err
+}
+
+// SpiderHourlyPoint 每小时统计点
+type SpiderHourlyPoint struct {
+	Hour  int `json:"hour"`
+	Total int `json:"total"`
+}
+
+// SpiderHourly 最近 hours 小时按小时（0-23）聚合访问量
+func (s *ClickHouseSink) SpiderHourly(ctx context.Context, hours int, spiderType string) ([]SpiderHourlyPoint, error) {
+	where, params := s.spiderWhere("created_at >= now() - INTERVAL {n:UInt32} HOUR", hours, spiderType)
+	var rows []SpiderHourlyPoint
+	err := s.Query(ctx, `
+		SELECT toHour(created_at, {tz:String}) AS hour, count() AS total
+		FROM `+s.tableName()+`
+		WHERE `+where+`
+		GROUP BY hour
+		ORDER BY hour ASC`, params, &rows)
+	return rows, err
+}
+
+// clickHouseTrendFuncs 周期对应的时间截断函数
+var clickHouseTrendFuncs = map[string]string{
+	"minute": "toStartOfMinute",
+	"hour":   "toStartOfHour",
+	"day":    "toStartOfDay",
+	"month":  "toStartOfMonth",
+}
+
+// SpiderTrend 按周期聚合的访问趋势（时间正序），直接从明细表计算
+func (s *ClickHouseSink) SpiderTrend(ctx context.Context, period, spiderType string, limit int) ([]models.SpiderLogsStatsPoint, error) {
+	fn, ok := clickHouseTrendFuncs[period]
+	if !ok {
+		return nil, fmt.Errorf("invalid period: %s", period)
+	}
+
+	where := "1 = 1"
+	params := map[string]string{"limit": strconv.Itoa(limit), "tz": s.tz}
+	if spiderType != "" {
+		where = "spider_type = {spider_type:String}"
+		params["spider_type"] = spiderType
+	}
+
+	var rows []struct {
+		Time        string  `json:"time"`
+		Total       int     `json:"total"`
+		Status2xx   int     `json:"status_2xx"`
+		Status3xx   int     `json:"status_3xx"`
+		Status4xx   int     `json:"status_4xx"`
+		Status5xx   int     `json:"status_5xx"`
+		AvgRespTime float64 `json:"avg_resp_time"`
+	}
+	err := s.Query(ctx, `
+		SELECT * FROM (
+			SELECT toString(`+fn+`(created_at, {tz:String})) AS time,
+				count() AS total,
+				countIf(status >= 200 AND status < 300) AS status_2xx,
+				countIf(status >= 300 AND status < 400) AS status_3xx,
+				countIf(status >= 400 AND status < 500) AS status_4xx,
+				countIf(status >= 500) AS status_5xx,
+				avg(resp_time) AS avg_resp_time
+			FROM `+s.tableName()+`
+			WHERE `+where+`
+			GROUP BY time
+			ORDER BY time DESC
+			LIMIT {limit:UInt32}
+		) ORDER BY time ASC`, params, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]models.SpiderLogsStatsPoint, 0, len(rows))
+	for _, r := range rows {
+		// 时间按 s.tz 截断，返回的是该时区的本地时间
+		t, _ := time.ParseInLocation("2006-01-02 15:04:05", r.Time, s.loc)
+		if t.IsZero() {
+			t, _ = time.ParseInLocation("2006-01-02", r.Time, s.loc)
+		}
+		points = append(points, models.SpiderLogsStatsPoint{
+			Time:        t.Local(),
+			Total:       r.Total,
+			Status2xx:   r.Status2xx,
+			Status3xx:   r.Status3xx,
+			Status4xx:   r.Status4xx,
+			Status5xx:   r.Status5xx,
+			AvgRespTime: int(r.AvgRespTime),
+		})
+	}
+	return points, nil
+}
+
+// spiderWhere 构建时间窗口 + 可选蜘蛛类型过滤条件
+func (s *ClickHouseSink) spiderWhere(base string, n int, spiderType string) (string, map[string]string) {
+	params := map[string]string{"n": strconv.Itoa(n), "tz": s.tz}
+	if spiderType != "" {
+		base += " AND spider_type = {spider_type:String}"
+		params["spider_type"] = spiderType
+	}
+	return base, params
+}
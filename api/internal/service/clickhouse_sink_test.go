@@ -0,0 +1,192 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"seo-generator/api/pkg/config"
+)
+
+// fakeClickHouse 最小化的 ClickHouse HTTP 接口：保存 JSONEachRow 写入的行，
+// 按 SQL 中的时间函数（toDate/toHour/toStartOfHour）及其时区参数聚合。
+// 未传时区参数时按列定义的 DateTime('UTC') 计算，与真实 ClickHouse 一致
+type fakeClickHouse struct {
+	mu   sync.Mutex
+	rows []clickHouseRow
+}
+
+func (f *fakeClickHouse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := q.Get("query")
+	if query == "" {
+		body, _ := io.ReadAll(r.Body)
+		query = string(body)
+	} else {
+		f.mu.Lock()
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var row clickHouseRow
+			if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				f.mu.Unlock()
+				return
+			}
+			f.rows = append(f.rows, row)
+		}
+		f.mu.Unlock()
+		return
+	}
+
+	loc := time.UTC
+	if strings.Contains(query, "(created_at, {tz:String})") {
+		var err error
+		if loc, err = time.LoadLocation(q.Get("param_tz")); err != nil {
+			http.Error(w, "unknown timezone", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var bucket func(t time.Time) any
+	var key string
+	switch {
+	case strings.Contains(query, "toDate("):
+		key, bucket = "date", func(t time.Time) any { return t.Format("2006-01-02") }
+	case strings.Contains(query, "toHour("):
+		key, bucket = "hour", func(t time.Time) any { return t.Hour() }
+	case strings.Contains(query, "toStartOfHour("):
+		key, bucket = "time", func(t time.Time) any { return t.Truncate(time.Hour).Format("2006-01-02 15:04:05") }
+	default:
+		w.Write([]byte(`{"data":[]}`))
+		return
+	}
+
+	f.mu.Lock()
+	counts := map[any]int{}
+	var order []any
+	for _, row := range f.rows {
+		t, _ := time.ParseInLocation("2006-01-02 15:04:05", row.CreatedAt, time.UTC)
+		k := bucket(t.In(loc))
+		if _, ok := counts[k]; !ok {
+			order = append(order, k)
+		}
+		counts[k]++
+	}
+	f.mu.Unlock()
+
+	data := make([]map[string]any, 0, len(order))
+	for _, k := range order {
+		data = append(data, map[string]any{key: k, "total": counts[k]})
+	}
+	json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+// mysqlBuckets 模拟 MySQL 回退路径的统计：created_at 以本地时间（DSN loc=Local）存储，
+// DATE()/HOUR() 直接取本地时间的日期和小时
+func mysqlBuckets(records []SpiderVisitRecord, loc *time.Location) (daily map[string]int, hourly map[int]int) {
+	daily, hourly = map[string]int{}, map[int]int{}
+	for _, r := range records {
+		local := r.CreatedAt.In(loc)
+		daily[local.Format("2006-01-02")]++
+		hourly[local.Hour()]++
+	}
+	return daily, hourly
+}
+
+func TestClickHouseSink_BucketsMatchMySQL(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+
+	fake := &fakeClickHouse{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	sink, err := NewClickHouseSink(config.ClickHouseConfig{
+		URL:      srv.URL,
+		Database: "seo_generator",
+		Table:    "spider_logs",
+		Timezone: "Asia/Shanghai",
+	})
+	if err != nil {
+		t.Fatalf("NewClickHouseSink: %v", err)
+	}
+
+	// 本地时间零点前后的记录：按 UTC 划分会落到前一天、小时也相差 8
+	records := []SpiderVisitRecord{
+		{SpiderType: "baidu", CreatedAt: time.Date(2026, 10, 15, 0, 10, 0, 0, loc)},
+		{SpiderType: "baidu", CreatedAt: time.Date(2026, 10, 15, 7, 59, 0, 0, loc)},
+		{SpiderType: "google", CreatedAt: time.Date(2026, 10, 14, 23, 50, 0, 0, loc)},
+		{SpiderType: "google", CreatedAt: time.Date(2026, 10, 15, 8, 0, 0, 0, loc)},
+	}
+	if err := sink.Write(t.Context(), records); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	wantDaily, wantHourly := mysqlBuckets(records, loc)
+
+	daily, err := sink.SpiderDaily(t.Context(), 7, "")
+	if err != nil {
+		t.Fatalf("SpiderDaily: %v", err)
+	}
+	gotDaily := map[string]int{}
+	for _, p := range daily {
+		gotDaily[p.Date] = p.Total
+	}
+	if !reflect.DeepEqual(gotDaily, wantDaily) {
+		t.Errorf("每日统计 ClickHouse = %v, MySQL = %v", gotDaily, wantDaily)
+	}
+
+	hourly, err := sink.SpiderHourly(t.Context(), 24, "")
+	if err != nil {
+		t.Fatalf("SpiderHourly: %v", err)
+	}
+	gotHourly := map[int]int{}
+	for _, p := range hourly {
+		gotHourly[p.Hour] = p.Total
+	}
+	if !reflect.DeepEqual(gotHourly, wantHourly) {
+		t.Errorf("每小时统计 ClickHouse = %v, MySQL = %v", gotHourly, wantHourly)
+	}
+
+	trend, err := sink.SpiderTrend(t.Context(), "hour", "", 100)
+	if err != nil {
+		t.Fatalf("SpiderTrend: %v", err)
+	}
+	gotTrend := map[time.Time]int{}
+	for _, p := range trend {
+		gotTrend[p.Time.UTC()] = p.Total
+	}
+	wantTrend := map[time.Time]int{}
+	for _, r := range records {
+		wantTrend[r.CreatedAt.Truncate(time.Hour).UTC()]++
+	}
+	if !reflect.DeepEqual(gotTrend, wantTrend) {
+		t.Errorf("小时趋势 = %v, want %v", gotTrend, wantTrend)
+	}
+}
+
+func TestNewClickHouseSink_Timezone(t *testing.T) {
+	base := config.ClickHouseConfig{URL: "http://localhost:8123", Database: "db", Table: "logs"}
+
+	sink, err := NewClickHouseSink(base)
+	if err != nil {
+		t.Fatalf("NewClickHouseSink: %v", err)
+	}
+	if sink.loc != time.Local || sink.tz == "" || sink.tz == "Local" {
+		t.Errorf("默认时区 tz=%q loc=%v，应为进程本地时区的 IANA 名称", sink.tz, sink.loc)
+	}
+
+	bad := base
+	bad.Timezone = "Mars/Olympus"
+	if _, err := NewClickHouseSink(bad); err == nil {
+		t.Error("无效时区应返回错误")
+	}
+}
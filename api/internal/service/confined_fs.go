@@ -0,0 +1,525 @@
+// Package core provides a filesystem confined to one root directory, used by the content worker file manager
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// 文件操作审计类型
+const (
+	FileOpCreate  = "create"
+	FileOpSave    = "save"
+	FileOpUpload  = "upload"
+	FileOpDelete  = "delete"
+	FileOpMove    = "move"
+	FileOpRestore = "restore"
+)
+
+// trashDirName 回收站目录（根目录下，列表中隐藏，不能通过普通路径访问）
+const trashDirName = ".trash"
+
+var (
+	// ErrPathOutsideRoot 路径（或其符号链接目标）位于根目录之外
+	ErrPathOutsideRoot = errors.New("path is outside the root directory")
+	// ErrFileTooLarge 文件超过大小上限
+	ErrFileTooLarge = errors.New("file is too large")
+	// ErrNotEditable 扩展名或内容类型不允许在线编辑
+	ErrNotEditable = errors.New("file type is not editable")
+)
+
+// editableExtensions 允许在线编辑的扩展名（内容还需通过 MIME 检测）
+var editableExtensions = map[string]bool{
+	".py": true, ".txt": true, ".json": true, ".yaml": true, ".yml": true,
+	".md": true, ".html": true, ".css": true, ".js": true, ".ts": true,
+	".go": true, ".sh": true, ".conf": true, ".ini": true, ".toml": true,
+	".xml": true, ".sql": true, ".env": true, ".gitignore": true,
+}
+
+// editableNames 允许在线编辑的无扩展名文件
+var editableNames = map[string]bool{"Dockerfile": true, "Makefile": true, "requirements": true}
+
+// FileActor 操作人（写入审计日志）
+type FileActor struct {
+	Username string
+	ClientIP string
+}
+
+// FileAuditRecord 文件操作审计记录
+type FileAuditRecord struct {
+	ID        int64     `db:"id" json:"id"`
+	Op        string    `db:"op" json:"op"`
+	Path      string    `db:"path" json:"path"`
+	NewPath   string    `db:"new_path" json:"new_path"`
+	Size      int64     `db:"size" json:"size"`
+	Username  string    `db:"username" json:"username"`
+	ClientIP  string    `db:"client_ip" json:"client_ip"`
+	Error     string    `db:"error" json:"error"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// FileAuditFilter 审计记录查询条件
+type FileAuditFilter struct {
+	Op       string
+	Username string
+	Path     string // 路径前缀
+	Page     int
+	PageSize int
+}
+
+// ConfinedFS 限制在根目录内的文件系统
+//
+// 所有路径先按根目录拼接，再解析已存在部分的符号链接，真实路径不在根目录内时拒绝；
+// 写入、删除和移动（含被拒绝的请求）记录到 content_worker_file_audit。
+type ConfinedFS struct {
+	root        string
+	maxFileSize int64
+	db          *sqlx.DB // 为 nil 时审计只写日志
+}
+
+// NewConfinedFS 创建 ConfinedFS，根目录解析为绝对真实路径（根目录本身可以是符号链接）
+func NewConfinedFS(db *sqlx.DB, cfg config.WorkerFilesConfig) *ConfinedFS {
+	root := cfg.Root
+	if root == "" {
+		root = "/project/content_worker"
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	if real, err := filepath.EvalSymlinks(root); err == nil {
+		root = real
+	} else {
+		log.Warn().Err(err).Str("root", root).Msg("Content worker files root is not accessible")
+	}
+	if cfg.MaxFileSizeMB <= 0 {
+		cfg.MaxFileSizeMB = 10
+	}
+	return &ConfinedFS{
+		root:        root,
+		maxFileSize: int64(cfg.MaxFileSizeMB) * 1024 * 1024,
+		db:          db,
+	}
+}
+
+// Root 根目录真实路径
+func (f *ConfinedFS) Root() string {
+	return f.root
+}
+
+// MaxFileSize 单文件大小上限（字节）
+func (f *ConfinedFS) MaxFileSize() int64 {
+	return f.maxFileSize
+}
+
+// Resolve 解析相对路径为根目录内的真实路径（跟随符号链接），不存在的尾部原样拼接
+func (f *ConfinedFS) Resolve(rel string) (string, error) {
+	full, err := f.join(rel)
+	if err != nil {
+		return "", err
+	}
+	return f.canonical(full)
+}
+
+// ResolveEntry 解析相对路径但不跟随最后一级符号链接（删除、移动作用于链接本身）
+func (f *ConfinedFS) ResolveEntry(rel string) (string, error) {
+	full, err := f.join(rel)
+	if err != nil {
+		return "", err
+	}
+	if full == f.root {
+		return f.root, nil
+	}
+	parent, err := f.canonical(filepath.Dir(full))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(parent, filepath.Base(full)), nil
+}
+
+// Contains 判断路径（跟随符号链接后）是否在根目录内，用于过滤目录列表中越界的链接
+func (f *ConfinedFS) Contains(path string) bool {
+	real, err := filepath.EvalSymlinks(path)
+	return err == nil && f.within(real)
+}
+
+// join 拒绝 ".."、NUL 和回收站目录，按根目录拼接
+// 回收站按清理后的第一级目录判断，"./.trash/x" 等写法同样被拒绝
+func (f *ConfinedFS) join(rel string) (string, error) {
+	if strings.ContainsRune(rel, 0) {
+		return "", ErrPathOutsideRoot
+	}
+	rel = strings.ReplaceAll(rel, "\\", "/")
+	for _, part := range strings.Split(rel, "/") {
+		if part == ".." {
+			return "", ErrPathOutsideRoot
+		}
+	}
+	cleaned := strings.TrimPrefix(path.Clean("/"+rel), "/")
+	if first, _, _ := strings.Cut(cleaned, "/"); first == trashDirName {
+		return "", ErrPathOutsideRoot
+	}
+	return filepath.Join(f.root, filepath.FromSlash(cleaned)), nil
+}
+
+// canonical 解析最深的已存在祖先的符号链接，校验其真实路径在根目录内
+func (f *ConfinedFS) canonical(full string) (string, error) {
+	existing, rest := full, ""
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+	real, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+	if !f.within(real) {
+		return "", ErrPathOutsideRoot
+	}
+	return filepath.Join(real, rest), nil
+}
+
+func (f *ConfinedFS) within(path string) bool {
+	rel, err := filepath.Rel(f.root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// CheckEditable 校验文件是否允许在线编辑：扩展名在白名单内，且内容嗅探为文本（UTF-8）
+func CheckEditable(name string, content []byte) error {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == "" {
+		if !editableNames[filepath.Base(name)] {
+			return ErrNotEditable
+		}
+	} else if !editableExtensions[ext] {
+		return ErrNotEditable
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	head := content
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	if !editableMIME(http.DetectContentType(head)) || !utf8.Valid(content) {
+		return ErrNotEditable
+	}
+	return nil
+}
+
+func editableMIME(contentType string) bool {
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/x-sh":
+		return true
+	}
+	return false
+}
+
+// ReadFile 读取可编辑的文本文件（跟随根目录内的符号链接）
+func (f *ConfinedFS) ReadFile(rel string) ([]byte, os.FileInfo, error) {
+	full, err := f.Resolve(rel)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		return nil, nil, fmt.Errorf("%s is a directory", rel)
+	}
+	if info.Size() > f.maxFileSize {
+		return nil, nil, ErrFileTooLarge
+	}
+	content, err := os.ReadFile(full)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := CheckEditable(rel, content); err != nil {
+		return nil, nil, err
+	}
+	return content, info, nil
+}
+
+// WriteFile 保存已存在的文本文件，新内容同样需要通过大小和类型校验
+func (f *ConfinedFS) WriteFile(ctx context.Context, actor FileActor, rel string, content []byte) (info os.FileInfo, err error) {
+	defer func() { f.audit(ctx, actor, FileOpSave, rel, "", int64(len(content)), err) }()
+
+	full, err := f.Resolve(rel)
+	if err != nil {
+		return nil, err
+	}
+	old, err := os.Stat(full)
+	if err != nil {
+		return nil, err
+	}
+	if old.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", rel)
+	}
+	if int64(len(content)) > f.maxFileSize {
+		return nil, ErrFileTooLarge
+	}
+	if err := CheckEditable(rel, content); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(full, content, old.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	return os.Stat(full)
+}
+
+// Create 创建空文件或目录，目标已存在（含悬空符号链接）时返回 os.ErrExist
+func (f *ConfinedFS) Create(ctx context.Context, actor FileActor, rel string, dir bool) (err error) {
+	defer func() { f.audit(ctx, actor, FileOpCreate, rel, "", 0, err) }()
+
+	full, err := f.ResolveEntry(rel)
+	if err != nil {
+		return err
+	}
+	if dir {
+		return os.Mkdir(full, 0755)
+	}
+	file, err := os.OpenFile(full, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Trash 把文件或目录移入回收站 .trash/<token>/（符号链接只移动链接本身），返回条目类型和大小
+func (f *ConfinedFS) Trash(ctx context.Context, actor FileActor, rel, token string) (itemType string, size int64, err error) {
+	defer func() { f.audit(ctx, actor, FileOpDelete, rel, "", size, err) }()
+
+	full, err := f.ResolveEntry(rel)
+	if err != nil {
+		return "", 0, err
+	}
+	if full == f.root {
+		return "", 0, ErrPathOutsideRoot
+	}
+	info, err := os.Lstat(full)
+	if err != nil {
+		return "", 0, err
+	}
+	itemType, size = "file", info.Size()
+	if info.IsDir() {
+		itemType, size = "dir", dirSize(full)
+	}
+	dir := f.trashPath(token)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(full, filepath.Join(dir, filepath.Base(full))); err != nil {
+		os.Remove(dir)
+		return "", 0, err
+	}
+	return itemType, size, nil
+}
+
+// RestoreTrash 把回收站条目移回原路径，原路径已存在时返回 os.ErrExist
+func (f *ConfinedFS) RestoreTrash(ctx context.Context, actor FileActor, token, rel string) (err error) {
+	defer func() { f.audit(ctx, actor, FileOpRestore, rel, "", 0, err) }()
+
+	full, err := f.ResolveEntry(rel)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(full); err == nil {
+		return os.ErrExist
+	}
+	src := filepath.Join(f.trashPath(token), filepath.Base(full))
+	if _, err := os.Lstat(src); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, full); err != nil {
+		return err
+	}
+	return os.Remove(f.trashPath(token))
+}
+
+// PurgeTrash 永久删除回收站条目
+func (f *ConfinedFS) PurgeTrash(token string) error {
+	if token == "" || strings.ContainsAny(token, `./\\`) {
+		return ErrTrashNotFound
+	}
+	return os.RemoveAll(f.trashPath(token))
+}
+
+// trashTokens 回收站目录中的条目（token 和修改时间）
+func (f *ConfinedFS) trashTokens() map[string]time.Time {
+	tokens := make(map[string]time.Time)
+	entries, err := os.ReadDir(filepath.Join(f.root, trashDirName))
+	if err != nil {
+		return tokens
+	}
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil {
+			tokens[entry.Name()] = info.ModTime()
+		}
+	}
+	return tokens
+}
+
+func (f *ConfinedFS) trashPath(token string) string {
+	return filepath.Join(f.root, trashDirName, filepath.Base(token))
+}
+
+// dirSize 目录内普通文件的总大小
+func dirSize(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(_ string, d os.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// Rename 移动或重命名，目标已存在时返回 os.ErrExist，缺失的目标父目录自动创建
+func (f *ConfinedFS) Rename(ctx context.Context, actor FileActor, oldRel, newRel string) (err error) {
+	defer func() { f.audit(ctx, actor, FileOpMove, oldRel, newRel, 0, err) }()
+
+	oldPath, err := f.ResolveEntry(oldRel)
+	if err != nil {
+		return err
+	}
+	newPath, err := f.ResolveEntry(newRel)
+	if err != nil {
+		return err
+	}
+	if oldPath == f.root || newPath == f.root {
+		return ErrPathOutsideRoot
+	}
+	if _, err := os.Lstat(oldPath); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(newPath); err == nil {
+		return os.ErrExist
+	}
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return err
+	}
+	return os.Rename(oldPath, newPath)
+}
+
+// SaveUpload 写入上传文件（覆盖同名文件），超过大小上限时返回 ErrFileTooLarge
+func (f *ConfinedFS) SaveUpload(ctx context.Context, actor FileActor, rel string, src io.Reader, size int64) (err error) {
+	defer func() { f.audit(ctx, actor, FileOpUpload, rel, "", size, err) }()
+
+	if size > f.maxFileSize {
+		return ErrFileTooLarge
+	}
+	full, err := f.Resolve(rel)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(full, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	// 多读一个字节，防止声明大小与实际内容不符
+	n, err := io.Copy(dst, io.LimitReader(src, f.maxFileSize+1))
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && n > f.maxFileSize {
+		os.Remove(full)
+		err = ErrFileTooLarge
+	}
+	return err
+}
+
+// audit 记录文件操作（失败和越界请求同样记录）
+func (f *ConfinedFS) audit(ctx context.Context, actor FileActor, op, path, newPath string, size int64, opErr error) {
+	errMsg := ""
+	event := log.Info()
+	if opErr != nil {
+		errMsg = truncateRunes(opErr.Error(), 500)
+		event = log.Warn()
+		if errors.Is(opErr, ErrPathOutsideRoot) {
+			event = log.Error()
+		}
+	}
+	event.Str("op", op).Str("path", path).Str("new_path", newPath).Int64("size", size).
+		Str("username", actor.Username).Str("client_ip", actor.ClientIP).Str("error", errMsg).
+		Msg("Content worker file operation")
+
+	if f.db == nil {
+		return
+	}
+	_, err := f.db.ExecContext(ctx, `
+		INSERT INTO content_worker_file_audit (op, path, new_path, size, username, client_ip, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		op, truncateRunes(path, 500), truncateRunes(newPath, 500), size, actor.Username, actor.ClientIP, errMsg, time.Now())
+	if err != nil {
+		log.Warn().Err(err).Str("op", op).Str("path", path).Msg("Failed to write content worker file audit")
+	}
+}
+
+// AuditLogs 审计记录分页查询（按时间倒序）
+func (f *ConfinedFS) AuditLogs(ctx context.Context, filter FileAuditFilter) ([]FileAuditRecord, int64, error) {
+	if f.db == nil {
+		return []FileAuditRecord{}, 0, nil
+	}
+	where := []string{"1=1"}
+	args := []interface{}{}
+	if filter.Op != "" {
+		where = append(where, "op = ?")
+		args = append(args, filter.Op)
+	}
+	if filter.Username != "" {
+		where = append(where, "username = ?")
+		args = append(args, filter.Username)
+	}
+	if filter.Path != "" {
+		where = append(where, "path LIKE ?")
+		args = append(args, filter.Path+"%")
+	}
+	cond := strings.Join(where, " AND ")
+
+	var total int64
+	if err := f.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM content_worker_file_audit WHERE "+cond, args...); err != nil {
+		return nil, 0, err
+	}
+	items := []FileAuditRecord{}
+	err := f.db.SelectContext(ctx, &items, `
+		SELECT id, op, path, new_path, size, username, client_ip, error, created_at
+		FROM content_worker_file_audit WHERE `+cond+` ORDER BY id DESC LIMIT ? OFFSET ?`,
+		append(args, filter.PageSize, (filter.Page-1)*filter.PageSize)...)
+	return items, total, err
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"seo-generator/api/pkg/config"
+)
+
+func newTestConfinedFS(t *testing.T) *ConfinedFS {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, trashDirName, "token"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return NewConfinedFS(nil, config.WorkerFilesConfig{Root: root})
+}
+
+func TestConfinedFS_Resolve(t *testing.T) {
+	f := newTestConfinedFS(t)
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(f.Root(), "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		rel     string
+		want    string // 相对根目录，wantErr 时忽略
+		wantErr bool
+	}{
+		{"", "", false},
+		{"main.py", "main.py", false},
+		{"/lib/util.py", "lib/util.py", false},
+		{"./lib/./util.py", "lib/util.py", false},
+		{"lib/.trash/x.py", "lib/.trash/x.py", false}, // 只有根目录下的 .trash 是回收站
+		{"../etc/passwd", "", true},
+		{"lib/../../etc/passwd", "", true},
+		{"lib\\..\\..\\etc", "", true},
+		{".trash/token/x.py", "", true},
+		{"/.trash/token", "", true},
+		{".trash", "", true},
+		{"./.trash/x.py", "", true},
+		{".//.trash/x.py", "", true},
+		{"x/./../.trash/x.py", "", true},
+		{"\\.trash\\x.py", "", true},
+		{"bad\x00name", "", true},
+		{"escape/secret", "", true}, // 指向根目录外的符号链接
+	}
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			got, err := f.Resolve(tt.rel)
+			if tt.wantErr {
+				if !errors.Is(err, ErrPathOutsideRoot) {
+					t.Errorf("Resolve(%q) = (%q, %v), want ErrPathOutsideRoot", tt.rel, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q): %v", tt.rel, err)
+			}
+			if want := filepath.Join(f.Root(), tt.want); got != want {
+				t.Errorf("Resolve(%q) = %q, want %q", tt.rel, got, want)
+			}
+		})
+	}
+}
+
+// TestConfinedFS_ResolveEntry 删除、移动解析到链接本身，但同样拒绝回收站路径
+func TestConfinedFS_ResolveEntry(t *testing.T) {
+	f := newTestConfinedFS(t)
+	link := filepath.Join(f.Root(), "link")
+	if err := os.Symlink(t.TempDir(), link); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := f.ResolveEntry("link"); err != nil || got != link {
+		t.Errorf("ResolveEntry(link) = (%q, %v), want %q", got, err, link)
+	}
+	for _, rel := range []string{"./.trash/token", "x/./../.trash/token", "../outside"} {
+		if _, err := f.ResolveEntry(rel); !errors.Is(err, ErrPathOutsideRoot) {
+			t.Errorf("ResolveEntry(%q) err = %v, want ErrPathOutsideRoot", rel, err)
+		}
+	}
+}
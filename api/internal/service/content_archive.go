@@ -0,0 +1,566 @@
+// Package core provides archival of consumed contents to object storage
+package core
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// TaskTypeArchiveContents 冷正文归档任务类型
+const TaskTypeArchiveContents TaskType = "archive_contents"
+
+// 归档批次状态
+const (
+	ContentArchiveExporting = "exporting" // 导出中，中断后标记为 failed（MySQL 数据未删除）
+	ContentArchiveUploaded  = "uploaded"  // 已写入存储，删除未完成（下次运行时继续删除）
+	ContentArchivePurged    = "purged"    // 已从 MySQL 删除
+	ContentArchiveRestored  = "restored"  // 已恢复到 MySQL
+	ContentArchiveFailed    = "failed"
+)
+
+// 归档存储位置
+const (
+	ContentArchiveStorageS3    = "s3"
+	ContentArchiveStorageLocal = "local"
+)
+
+// contentArchiveReadRows 导出时每次查询的行数
+const contentArchiveReadRows = 1000
+
+// contentArchiveInsertRows 恢复时每条 INSERT 的行数
+const contentArchiveInsertRows = 200
+
+// ErrContentArchiveRunning 已有归档或恢复在进行中
+var ErrContentArchiveRunning = errors.New("another content archive or restore is running")
+
+// ErrContentArchiveNotFound 归档批次不存在
+var ErrContentArchiveNotFound = errors.New("content archive not found")
+
+// contentArchiveColumns content_archives 查询列
+const contentArchiveColumns = `id, storage, object_key, cutoff, row_count, min_id, max_id, size_bytes,
+	deleted_rows, restored_rows, status, error, created_at, purged_at, restored_at`
+
+// ContentArchive 归档批次
+type ContentArchive struct {
+	ID           int64      `db:"id" json:"id"`
+	Storage      string     `db:"storage" json:"storage"`
+	ObjectKey    string     `db:"object_key" json:"object_key"`
+	Cutoff       time.Time  `db:"cutoff" json:"cutoff"`
+	RowCount     int64      `db:"row_count" json:"row_count"`
+	MinID        uint64     `db:"min_id" json:"min_id"`
+	MaxID        uint64     `db:"max_id" json:"max_id"`
+	SizeBytes    int64      `db:"size_bytes" json:"size_bytes"`
+	DeletedRows  int64      `db:"deleted_rows" json:"deleted_rows"`
+	RestoredRows int64      `db:"restored_rows" json:"restored_rows"`
+	Status       string     `db:"status" json:"status"`
+	Error        *string    `db:"error" json:"error,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	PurgedAt     *time.Time `db:"purged_at" json:"purged_at"`
+	RestoredAt   *time.Time `db:"restored_at" json:"restored_at"`
+}
+
+// archivedContent 归档文件中的一行（JSON Lines）
+type archivedContent struct {
+	ID        uint64    `db:"id" json:"id"`
+	GroupID   int       `db:"group_id" json:"group_id"`
+	ArticleID *int64    `db:"article_id" json:"article_id,omitempty"` // 来源文章（旧归档没有该字段）
+	Content   string    `db:"content" json:"content"`
+	BatchID   int       `db:"batch_id" json:"batch_id"`
+	Status    int       `db:"status" json:"status"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// ContentArchiveRunResult 一次归档运行的结果
+type ContentArchiveRunResult struct {
+	Batches  []int64 `json:"batches"` // 本次新建的批次 ID
+	Resumed  []int64 `json:"resumed"` // 上次中断、本次继续删除的批次 ID
+	Rows     int64   `json:"rows"`    // 导出行数
+	Deleted  int64   `json:"deleted"` // 从 MySQL 删除的行数
+	Bytes    int64   `json:"bytes"`   // 归档文件总大小
+	Duration int64   `json:"duration_ms"`
+}
+
+// ContentRestoreResult 恢复结果
+type ContentRestoreResult struct {
+	ArchiveID int64 `json:"archive_id"`
+	Rows      int64 `json:"rows"`     // 归档文件中的行数
+	Inserted  int64 `json:"inserted"` // 实际插入的行数（ID 已存在的跳过）
+	Duration  int64 `json:"duration_ms"`
+}
+
+// ContentArchiver 冷正文归档
+// 已使用（status=0）且创建超过 after_days 天的正文按 ID 顺序导出为 gzip JSON Lines，
+// 写入 S3 兼容存储（未启用时保存在本地目录）后再分批从 MySQL 删除；批次记录在 content_archives 表，可按批次 ID 恢复
+type ContentArchiver struct {
+	db     *sqlx.DB
+	config config.ContentArchiveConfig
+	s3     *s3Uploader
+	jobs   *JobManager
+
+	running sync.Mutex
+}
+
+// NewContentArchiver 创建冷正文归档
+func NewContentArchiver(db *sqlx.DB, cfg config.ContentArchiveConfig, jobs *JobManager) *ContentArchiver {
+	if cfg.AfterDays <= 0 {
+		cfg.AfterDays = 30
+	}
+	if cfg.BatchRows <= 0 {
+		cfg.BatchRows = 50000
+	}
+	if cfg.DeleteChunk <= 0 {
+		cfg.DeleteChunk = 1000
+	}
+	if cfg.LocalDir == "" {
+		cfg.LocalDir = "./data/archives"
+	}
+	a := &ContentArchiver{db: db, config: cfg, jobs: jobs}
+	if cfg.S3.Enabled {
+		a.s3 = newS3Uploader(cfg.S3)
+	}
+	return a
+}
+
+// Submit 作为后台作业执行归档，进度和结果通过 /api/jobs 查看
+func (a *ContentArchiver) Submit(ctx context.Context) (int64, error) {
+	if a.jobs == nil {
+		return 0, fmt.Errorf("job manager not available")
+	}
+	return a.jobs.SubmitFunc(ctx, string(TaskTypeArchiveContents), nil, func(jc *JobContext) (any, error) {
+		return a.Run(jc, jc.Advance)
+	})
+}
+
+// SubmitRestore 作为后台作业恢复批次
+func (a *ContentArchiver) SubmitRestore(ctx context.Context, id int64, available bool) (int64, error) {
+	if a.jobs == nil {
+		return 0, fmt.Errorf("job manager not available")
+	}
+	params := map[string]interface{}{"archive_id": id, "available": available}
+	return a.jobs.SubmitFunc(ctx, "restore_contents", params, func(jc *JobContext) (any, error) {
+		return a.Restore(jc, id, available, jc.Advance)
+	})
+}
+
+// Run 执行归档：先继续删除上次中断的批次，再导出新批次，progress 按处理行数回调（可为 nil）
+func (a *ContentArchiver) Run(ctx context.Context, progress func(n int64)) (*ContentArchiveRunResult, error) {
+	if !a.running.TryLock() {
+		return nil, ErrContentArchiveRunning
+	}
+	defer a.running.Unlock()
+	if progress == nil {
+		progress = func(int64) {}
+	}
+
+	start := time.Now()
+	result := &ContentArchiveRunResult{Batches: []int64{}, Resumed: []int64{}}
+
+	// 导出中断的批次没有删除任何数据，直接标记失败
+	if _, err := a.db.ExecContext(ctx,
+		"UPDATE content_archives SET status = ?, error = 'interrupted' WHERE status = ?",
+		ContentArchiveFailed, ContentArchiveExporting); err != nil {
+		return nil, fmt.Errorf("mark interrupted archives: %w", err)
+	}
+
+	var pending []*ContentArchive
+	if err := a.db.SelectContext(ctx, &pending,
+		"SELECT "+contentArchiveColumns+" FROM content_archives WHERE status = ? ORDER BY id", ContentArchiveUploaded); err != nil {
+		return nil, fmt.Errorf("load pending archives: %w", err)
+	}
+	for _, archive := range pending {
+		ids, err := a.readIDs(ctx, archive)
+		if err != nil {
+			return result, fmt.Errorf("read archive #%d: %w", archive.ID, err)
+		}
+		deleted, err := a.purge(ctx, archive.ID, ids, progress)
+		result.Deleted += deleted
+		if err != nil {
+			return result, err
+		}
+		result.Resumed = append(result.Resumed, archive.ID)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -a.config.AfterDays)
+	var lastID uint64
+	for a.config.MaxBatches <= 0 || len(result.Batches) < a.config.MaxBatches {
+		archive, ids, err := a.export(ctx, cutoff, &lastID, progress)
+		if err != nil {
+			return result, err
+		}
+		if archive == nil {
+			break
+		}
+		result.Batches = append(result.Batches, archive.ID)
+		result.Rows += archive.RowCount
+		result.Bytes += archive.SizeBytes
+
+		deleted, err := a.purge(ctx, archive.ID, ids, progress)
+		result.Deleted += deleted
+		if err != nil {
+			return result, err
+		}
+	}
+
+	result.Duration = time.Since(start).Milliseconds()
+	log.Info().Int("batches", len(result.Batches)).Int64("rows", result.Rows).Int64("deleted", result.Deleted).
+		Int64("bytes", result.Bytes).Msg("Content archive finished")
+	return result, nil
+}
+
+// export 导出一个批次，没有可归档的数据时返回 nil
+func (a *ContentArchiver) export(ctx context.Context, cutoff time.Time, lastID *uint64, progress func(int64)) (*ContentArchive, []uint64, error) {
+	rows, err := a.readChunk(ctx, cutoff, *lastID, min(contentArchiveReadRows, a.config.BatchRows))
+	if err != nil || len(rows) == 0 {
+		return nil, nil, err
+	}
+
+	storage := ContentArchiveStorageLocal
+	if a.s3 != nil {
+		storage = ContentArchiveStorageS3
+	}
+	res, err := a.db.ExecContext(ctx,
+		"INSERT INTO content_archives (storage, object_key, cutoff, status) VALUES (?, '', ?, ?)",
+		storage, cutoff, ContentArchiveExporting)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create archive: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	archive := &ContentArchive{ID: id, Storage: storage, Cutoff: cutoff, Status: ContentArchiveExporting}
+	archive.ObjectKey = time.Now().Format("2006/01/") + fmt.Sprintf("contents-%d.jsonl.gz", id)
+
+	ids, err := a.write(ctx, archive, rows, cutoff, lastID, progress)
+	if err != nil {
+		a.fail(archive.ID, err)
+		return nil, nil, fmt.Errorf("export archive #%d: %w", archive.ID, err)
+	}
+
+	archive.Status = ContentArchiveUploaded
+	if _, err := a.db.ExecContext(ctx, `
+		UPDATE content_archives SET object_key = ?, row_count = ?, min_id = ?, max_id = ?, size_bytes = ?, status = ?
+		WHERE id = ?`,
+		archive.ObjectKey, archive.RowCount, archive.MinID, archive.MaxID, archive.SizeBytes, archive.Status, archive.ID); err != nil {
+		return nil, nil, fmt.Errorf("update archive #%d: %w", archive.ID, err)
+	}
+	return archive, ids, nil
+}
+
+// write 写入临时文件后保存到存储，返回已归档的 ID
+func (a *ContentArchiver) write(ctx context.Context, archive *ContentArchive, rows []archivedContent, cutoff time.Time, lastID *uint64, progress func(int64)) ([]uint64, error) {
+	if err := os.MkdirAll(a.config.LocalDir, 0o755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(a.config.LocalDir, "contents-*.jsonl.gz.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	gz := gzip.NewWriter(tmp)
+	enc := json.NewEncoder(gz)
+	ids := make([]uint64, 0, a.config.BatchRows)
+	for len(rows) > 0 {
+		for i := range rows {
+			if err := enc.Encode(&rows[i]); err != nil {
+				return nil, err
+			}
+			ids = append(ids, rows[i].ID)
+		}
+		*lastID = rows[len(rows)-1].ID
+		progress(int64(len(rows)))
+
+		limit := min(contentArchiveReadRows, a.config.BatchRows-len(ids))
+		if limit <= 0 {
+			break
+		}
+		if rows, err = a.readChunk(ctx, cutoff, *lastID, limit); err != nil {
+			return nil, err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	if err := tmp.Sync(); err != nil {
+		return nil, err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		return nil, err
+	}
+	archive.RowCount = int64(len(ids))
+	archive.MinID, archive.MaxID = ids[0], ids[len(ids)-1]
+	archive.SizeBytes = info.Size()
+
+	if a.s3 == nil {
+		target := filepath.Join(a.config.LocalDir, filepath.FromSlash(archive.ObjectKey))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, err
+		}
+		tmp.Close()
+		return ids, os.Rename(tmp.Name(), target)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return ids, a.s3.PutObject(ctx, a.config.S3.Prefix+archive.ObjectKey, tmp, archive.SizeBytes)
+}
+
+// readChunk 按 ID 顺序读取一段可归档的正文
+func (a *ContentArchiver) readChunk(ctx context.Context, cutoff time.Time, afterID uint64, limit int) ([]archivedContent, error) {
+	var rows []archivedContent
+	err := a.db.SelectContext(ctx, &rows, `
+		SELECT id, group_id, article_id, content, COALESCE(batch_id, 0) AS batch_id, status, created_at
+		FROM contents
+		WHERE status = 0 AND created_at < ? AND id > ?
+		ORDER BY id LIMIT ?`, cutoff, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("read contents: %w", err)
+	}
+	return rows, nil
+}
+
+// purge 分批删除已归档的行（只删除仍为已使用状态的行）
+func (a *ContentArchiver) purge(ctx context.Context, archiveID int64, ids []uint64, progress func(int64)) (int64, error) {
+	var deleted int64
+	for start := 0; start < len(ids); start += a.config.DeleteChunk {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		chunk := ids[start:min(start+a.config.DeleteChunk, len(ids))]
+		query, args, err := sqlx.In("DELETE FROM contents WHERE id IN (?) AND status = 0", chunk)
+		if err != nil {
+			return deleted, err
+		}
+		res, err := a.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return deleted, fmt.Errorf("delete archived contents #%d: %w", archiveID, err)
+		}
+		affected, _ := res.RowsAffected()
+		deleted += affected
+		progress(int64(len(chunk)))
+	}
+	if _, err := a.db.ExecContext(ctx,
+		"UPDATE content_archives SET status = ?, deleted_rows = deleted_rows + ?, purged_at = NOW() WHERE id = ?",
+		ContentArchivePurged, deleted, archiveID); err != nil {
+		return deleted, fmt.Errorf("update archive #%d: %w", archiveID, err)
+	}
+	return deleted, nil
+}
+
+// Restore 按批次恢复正文（保留原 ID，已存在的行跳过）
+// available 为 true 时恢复为可用状态（status=1），否则保持已使用，下次归档时会再次被归档
+func (a *ContentArchiver) Restore(ctx context.Context, id int64, available bool, progress func(n int64)) (*ContentRestoreResult, error) {
+	if !a.running.TryLock() {
+		return nil, ErrContentArchiveRunning
+	}
+	defer a.running.Unlock()
+	if progress == nil {
+		progress = func(int64) {}
+	}
+
+	start := time.Now()
+	archive, err := a.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch archive.Status {
+	case ContentArchiveUploaded, ContentArchivePurged, ContentArchiveRestored:
+	default:
+		return nil, fmt.Errorf("archive #%d is %s and cannot be restored", id, archive.Status)
+	}
+
+	result := &ContentRestoreResult{ArchiveID: id}
+	var batch []archivedContent
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		var b strings.Builder
+		b.WriteString("INSERT IGNORE INTO contents (id, group_id, article_id, content, batch_id, status, created_at) VALUES ")
+		args := make([]interface{}, 0, len(batch)*7)
+		for i, row := range batch {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString("(?, ?, ?, ?, ?, ?, ?)")
+			status := row.Status
+			if available {
+				status = 1
+			}
+			args = append(args, row.ID, row.GroupID, row.ArticleID, row.Content, row.BatchID, status, row.CreatedAt)
+		}
+		res, err := a.db.ExecContext(ctx, b.String(), args...)
+		if err != nil {
+			return fmt.Errorf("insert contents: %w", err)
+		}
+		affected, _ := res.RowsAffected()
+		result.Inserted += affected
+		progress(int64(len(batch)))
+		batch = batch[:0]
+		return nil
+	}
+
+	err = a.scan(ctx, archive, func(row *archivedContent) error {
+		result.Rows++
+		batch = append(batch, *row)
+		if len(batch) >= contentArchiveInsertRows {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("restore archive #%d: %w", id, err)
+	}
+
+	if _, err := a.db.ExecContext(ctx,
+		"UPDATE content_archives SET status = ?, restored_rows = ?, restored_at = NOW() WHERE id = ?",
+		ContentArchiveRestored, result.Inserted, id); err != nil {
+		return nil, fmt.Errorf("update archive #%d: %w", id, err)
+	}
+	result.Duration = time.Since(start).Milliseconds()
+	log.Info().Int64("archive_id", id).Int64("rows", result.Rows).Int64("inserted", result.Inserted).Msg("Content archive restored")
+	return result, nil
+}
+
+// readIDs 读取归档文件中的 ID
+func (a *ContentArchiver) readIDs(ctx context.Context, archive *ContentArchive) ([]uint64, error) {
+	ids := make([]uint64, 0, archive.RowCount)
+	err := a.scan(ctx, archive, func(row *archivedContent) error {
+		ids = append(ids, row.ID)
+		return nil
+	})
+	return ids, err
+}
+
+// scan 逐行读取归档文件
+func (a *ContentArchiver) scan(ctx context.Context, archive *ContentArchive, fn func(row *archivedContent) error) error {
+	var r io.ReadCloser
+	var err error
+	if archive.Storage == ContentArchiveStorageS3 {
+		if a.s3 == nil {
+			return fmt.Errorf("archive #%d is stored in s3 but content_archive.s3 is not enabled", archive.ID)
+		}
+		r, err = a.s3.GetObject(ctx, a.config.S3.Prefix+archive.ObjectKey)
+	} else {
+		r, err = os.Open(filepath.Join(a.config.LocalDir, filepath.FromSlash(archive.ObjectKey)))
+	}
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	dec := json.NewDecoder(gz)
+	for {
+		var row archivedContent
+		if err := dec.Decode(&row); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+}
+
+// fail 标记批次失败（导出阶段失败时 MySQL 数据未删除）
+func (a *ContentArchiver) fail(id int64, cause error) {
+	msg := cause.Error()
+	if len(msg) > 500 {
+		msg = msg[:500]
+	}
+	if _, err := a.db.Exec("UPDATE content_archives SET status = ?, error = ? WHERE id = ?", ContentArchiveFailed, msg, id); err != nil {
+		log.Warn().Err(err).Int64("archive_id", id).Msg("Failed to mark content archive failed")
+	}
+}
+
+// Get 获取批次
+func (a *ContentArchiver) Get(ctx context.Context, id int64) (*ContentArchive, error) {
+	var archive ContentArchive
+	if err := a.db.GetContext(ctx, &archive, "SELECT "+contentArchiveColumns+" FROM content_archives WHERE id = ?", id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrContentArchiveNotFound
+		}
+		return nil, fmt.Errorf("query content archive: %w", err)
+	}
+	return &archive, nil
+}
+
+// List 分页列出批次（新的在前）
+func (a *ContentArchiver) List(ctx context.Context, status string, page, pageSize int) ([]ContentArchive, int64, error) {
+	where, args := "1=1", []interface{}{}
+	if status != "" {
+		where, args = "status = ?", append(args, status)
+	}
+	var total int64
+	if err := a.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM content_archives WHERE "+where, args...); err != nil {
+		return nil, 0, err
+	}
+	items := []ContentArchive{}
+	err := a.db.SelectContext(ctx, &items,
+		"SELECT "+contentArchiveColumns+" FROM content_archives WHERE "+where+" ORDER BY id DESC LIMIT ? OFFSET ?",
+		append(args, pageSize, (page-1)*pageSize)...)
+	return items, total, err
+}
+
+// EnsureSchedule 按配置创建或更新定时归档任务
+func (a *ContentArchiver) EnsureSchedule(ctx context.Context, scheduler *Scheduler) error {
+	var existing struct {
+		ID       int64  `db:"id"`
+		CronExpr string `db:"cron_expr"`
+		Enabled  bool   `db:"enabled"`
+	}
+	err := a.db.GetContext(ctx, &existing,
+		"SELECT id, cron_expr, enabled FROM scheduled_tasks WHERE task_type = ? LIMIT 1", TaskTypeArchiveContents)
+	exists := err == nil && existing.ID > 0
+
+	if a.config.Schedule == "" {
+		if exists {
+			return scheduler.DeleteTask(ctx, existing.ID)
+		}
+		return nil
+	}
+
+	task := &ScheduledTask{
+		Name:     "冷正文归档",
+		TaskType: TaskTypeArchiveContents,
+		CronExpr: a.config.Schedule,
+		Params:   json.RawMessage("{}"),
+		Enabled:  true,
+	}
+	if exists {
+		// 保留后台手动设置的启用状态，只同步 Cron 表达式
+		if existing.CronExpr == a.config.Schedule {
+			return nil
+		}
+		task.ID = existing.ID
+		task.Enabled = existing.Enabled
+		return scheduler.UpdateTask(ctx, task)
+	}
+	_, err = scheduler.CreateTask(ctx, task)
+	return err
+}
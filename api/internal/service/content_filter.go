@@ -0,0 +1,399 @@
+// Package core provides banned-word filtering for ingested content
+package core
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// 违禁词匹配方式
+const (
+	BannedMatchLiteral = "literal"
+	BannedMatchRegex   = "regex"
+)
+
+// 违禁词处理策略
+const (
+	FilterPolicyOff     = "off"     // 不过滤
+	FilterPolicyMask    = "mask"    // 用 * 遮盖
+	FilterPolicyReplace = "replace" // 替换为指定文本
+	FilterPolicyReject  = "reject"  // 整条拒绝
+)
+
+// 过滤来源
+const (
+	FilterSourceInsert = "insert" // 文章入库
+	FilterSourcePool   = "pool"   // 正文池填充
+)
+
+// defaultFilterPolicy 未单独配置的分组使用的策略
+const defaultFilterPolicy = FilterPolicyMask
+
+// BannedWord 违禁词
+type BannedWord struct {
+	ID          int       `db:"id" json:"id"`
+	Word        string    `db:"word" json:"word"`
+	MatchType   string    `db:"match_type" json:"match_type"`
+	Replacement string    `db:"replacement" json:"replacement"`
+	Status      int       `db:"status" json:"status"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+// FilterHit 单个违禁词命中
+type FilterHit struct {
+	WordID int    `json:"word_id"`
+	Word   string `json:"word"`
+	Count  int    `json:"count"`
+}
+
+// FilterResult 过滤结果
+type FilterResult struct {
+	Policy   string      `json:"policy"`
+	Hits     []FilterHit `json:"hits"`
+	Rejected bool        `json:"rejected"`
+}
+
+// HasHits 是否命中违禁词
+func (r *FilterResult) HasHits() bool {
+	return r != nil && len(r.Hits) > 0
+}
+
+// compiledWord 预编译的违禁词
+type compiledWord struct {
+	BannedWord
+	re *regexp.Regexp
+}
+
+// filterRules 一份不可变的规则快照，支持无锁读取
+type filterRules struct {
+	words    []compiledWord
+	policies map[int]string // groupID -> policy
+}
+
+// hitKey 命中统计聚合键
+type hitKey struct {
+	wordID  int
+	groupID int
+	source  string
+}
+
+// ContentFilter 违禁词过滤器
+// 规则存储在数据库，启动时加载并预编译；只匹配 HTML 文本节点（标签和属性不参与）；命中统计按天聚合后定期写库
+type ContentFilter struct {
+	db    *sqlx.DB
+	rules atomic.Pointer[filterRules]
+
+	hitsMu sync.Mutex
+	hits   map[hitKey]int
+
+	totalChecked  atomic.Int64
+	totalHits     atomic.Int64
+	totalRejected atomic.Int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewContentFilter 创建违禁词过滤器
+func NewContentFilter(db *sqlx.DB) *ContentFilter {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &ContentFilter{
+		db:     db,
+		hits:   make(map[hitKey]int),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	f.rules.Store(&filterRules{policies: map[int]string{}})
+	return f
+}
+
+// Start 加载规则并启动命中统计写库循环
+func (f *ContentFilter) Start(ctx context.Context) error {
+	if err := f.Reload(ctx); err != nil {
+		return err
+	}
+	f.wg.Add(1)
+	go f.flushLoop()
+	return nil
+}
+
+// Stop 停止并写入剩余命中统计
+func (f *ContentFilter) Stop() {
+	f.cancel()
+	f.wg.Wait()
+	f.flushHits()
+}
+
+// Reload 从数据库重新加载违禁词和分组策略
+func (f *ContentFilter) Reload(ctx context.Context) error {
+	var words []BannedWord
+	if err := f.db.SelectContext(ctx, &words,
+		"SELECT id, word, match_type, COALESCE(replacement, '') AS replacement, status, created_at FROM banned_words WHERE status = 1"); err != nil {
+		return fmt.Errorf("load banned words: %w", err)
+	}
+
+	compiled := make([]compiledWord, 0, len(words))
+	for _, w := range words {
+		cw, err := compileBannedWord(w)
+		if err != nil {
+			log.Warn().Err(err).Int("id", w.ID).Str("word", w.Word).Msg("Invalid banned word regex, skipped")
+			continue
+		}
+		compiled = append(compiled, cw)
+	}
+
+	var policyRows []struct {
+		GroupID int    `db:"group_id"`
+		Policy  string `db:"policy"`
+	}
+	if err := f.db.SelectContext(ctx, &policyRows, "SELECT group_id, policy FROM banned_word_policies"); err != nil {
+		return fmt.Errorf("load filter policies: %w", err)
+	}
+	policies := make(map[int]string, len(policyRows))
+	for _, p := range policyRows {
+		policies[p.GroupID] = p.Policy
+	}
+
+	f.rules.Store(&filterRules{words: compiled, policies: policies})
+	log.Info().Int("words", len(compiled)).Int("policies", len(policies)).Msg("Content filter rules loaded")
+	return nil
+}
+
+// compileBannedWord 预编译违禁词，字面量也统一转为正则便于替换
+func compileBannedWord(w BannedWord) (compiledWord, error) {
+	pattern := regexp.QuoteMeta(w.Word)
+	if w.MatchType == BannedMatchRegex {
+		pattern = w.Word
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return compiledWord{}, err
+	}
+	return compiledWord{BannedWord: w, re: re}, nil
+}
+
+// ValidateBannedWord 校验违禁词定义（正则是否合法）
+func ValidateBannedWord(w BannedWord) error {
+	if strings.TrimSpace(w.Word) == "" {
+		return fmt.Errorf("word is empty")
+	}
+	if w.MatchType != BannedMatchLiteral && w.MatchType != BannedMatchRegex {
+		return fmt.Errorf("invalid match_type: %s", w.MatchType)
+	}
+	_, err := compileBannedWord(w)
+	return err
+}
+
+// ValidFilterPolicy 检查策略是否合法
+func ValidFilterPolicy(policy string) bool {
+	switch policy {
+	case FilterPolicyOff, FilterPolicyMask, FilterPolicyReplace, FilterPolicyReject:
+		return true
+	}
+	return false
+}
+
+// PolicyFor 获取分组的过滤策略
+func (f *ContentFilter) PolicyFor(groupID int) string {
+	if p, ok := f.rules.Load().policies[groupID]; ok {
+		return p
+	}
+	return defaultFilterPolicy
+}
+
+// Apply 按分组策略过滤文本，返回处理后的文本和结果
+// 被拒绝时返回原文本，由调用方决定丢弃
+func (f *ContentFilter) Apply(groupID int, text, source string) (string, *FilterResult) {
+	return f.apply(groupID, text, source, true)
+}
+
+// Preview 与 Apply 相同，但不计入命中统计（用于后台测试）
+func (f *ContentFilter) Preview(groupID int, text string) (string, *FilterResult) {
+	return f.apply(groupID, text, "", false)
+}
+
+// apply 过滤实现，record 控制是否记录统计
+func (f *ContentFilter) apply(groupID int, text, source string, record bool) (string, *FilterResult) {
+	rules := f.rules.Load()
+	policy := f.PolicyFor(groupID)
+	result := &FilterResult{Policy: policy}
+
+	if policy == FilterPolicyOff || len(rules.words) == 0 || text == "" {
+		return text, result
+	}
+	if record {
+		f.totalChecked.Add(1)
+	}
+
+	// 只匹配和改写文本节点，正则不会跨标签匹配，也不会改动标签和属性
+	segments := splitHTMLText(text)
+	for i := range rules.words {
+		w := &rules.words[i]
+		count := 0
+		for j := range segments {
+			if segments[j].tag {
+				continue
+			}
+			n := len(w.re.FindAllStringIndex(segments[j].text, -1))
+			if n == 0 {
+				continue
+			}
+			count += n
+			if policy == FilterPolicyReject {
+				continue
+			}
+			if policy == FilterPolicyReplace && w.Replacement != "" {
+				segments[j].text = w.re.ReplaceAllLiteralString(segments[j].text, w.Replacement)
+			} else {
+				segments[j].text = w.re.ReplaceAllStringFunc(segments[j].text, maskString)
+			}
+		}
+		if count == 0 {
+			continue
+		}
+		result.Hits = append(result.Hits, FilterHit{WordID: w.ID, Word: w.Word, Count: count})
+
+		if policy == FilterPolicyReject {
+			// 命中即拒绝，无需继续匹配
+			break
+		}
+	}
+
+	if !result.HasHits() {
+		return text, result
+	}
+
+	if record {
+		f.recordHits(groupID, source, result.Hits)
+	}
+	if policy == FilterPolicyReject {
+		result.Rejected = true
+		if record {
+			f.totalRejected.Add(1)
+		}
+		return text, result
+	}
+	var out strings.Builder
+	out.Grow(len(text))
+	for _, seg := range segments {
+		out.WriteString(seg.text)
+	}
+	return out.String(), result
+}
+
+// htmlSegment HTML 片段：标签或标签之间的文本
+type htmlSegment struct {
+	text string
+	tag  bool
+}
+
+// splitHTMLText 按标签切分 HTML，纯文本返回单个文本片段
+func splitHTMLText(s string) []htmlSegment {
+	locs := htmlTagPattern.FindAllStringIndex(s, -1)
+	segments := make([]htmlSegment, 0, 2*len(locs)+1)
+	last := 0
+	for _, loc := range locs {
+		if loc[0] > last {
+			segments = append(segments, htmlSegment{text: s[last:loc[0]]})
+		}
+		segments = append(segments, htmlSegment{text: s[loc[0]:loc[1]], tag: true})
+		last = loc[1]
+	}
+	if last < len(s) {
+		segments = append(segments, htmlSegment{text: s[last:]})
+	}
+	return segments
+}
+
+// Contains 文本是否命中任一违禁词（不区分分组策略，不计入统计），用于关键词等短文本的整条丢弃
+func (f *ContentFilter) Contains(text string) bool {
+	if text == "" {
+		return false
+	}
+	rules := f.rules.Load()
+	for i := range rules.words {
+		if rules.words[i].re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskString 按字符数生成等长的遮盖串
+func maskString(s string) string {
+	return strings.Repeat("*", len([]rune(s)))
+}
+
+// recordHits 记录命中到内存聚合
+func (f *ContentFilter) recordHits(groupID int, source string, hits []FilterHit) {
+	f.hitsMu.Lock()
+	for _, h := range hits {
+		f.hits[hitKey{wordID: h.WordID, groupID: groupID, source: source}] += h.Count
+		f.totalHits.Add(int64(h.Count))
+	}
+	f.hitsMu.Unlock()
+}
+
+// flushLoop 定期将命中统计写库
+func (f *ContentFilter) flushLoop() {
+	defer f.wg.Done()
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case <-ticker.C:
+			f.flushHits()
+		}
+	}
+}
+
+// flushHits 将聚合的命中数写入 banned_word_hits（按天累加）
+func (f *ContentFilter) flushHits() {
+	f.hitsMu.Lock()
+	if len(f.hits) == 0 {
+		f.hitsMu.Unlock()
+		return
+	}
+	pending := f.hits
+	f.hits = make(map[hitKey]int)
+	f.hitsMu.Unlock()
+
+	today := time.Now().Format("2006-01-02")
+	valueStrings := make([]string, 0, len(pending))
+	args := make([]interface{}, 0, len(pending)*5)
+	for k, n := range pending {
+		valueStrings = append(valueStrings, "(?, ?, ?, ?, ?)")
+		args = append(args, k.wordID, k.groupID, k.source, today, n)
+	}
+
+	query := `INSERT INTO banned_word_hits (word_id, group_id, source, hit_date, hits) VALUES ` +
+		strings.Join(valueStrings, ",") +
+		` ON DUPLICATE KEY UPDATE hits = hits + VALUES(hits)`
+	if _, err := f.db.Exec(query, args...); err != nil {
+		log.Error().Err(err).Int("rows", len(pending)).Msg("Failed to flush banned word hits")
+	}
+}
+
+// GetStats 获取过滤器统计
+func (f *ContentFilter) GetStats() map[string]interface{} {
+	rules := f.rules.Load()
+	return map[string]interface{}{
+		"words":          len(rules.words),
+		"policies":       len(rules.policies),
+		"default_policy": defaultFilterPolicy,
+		"total_checked":  f.totalChecked.Load(),
+		"total_hits":     f.totalHits.Load(),
+		"total_rejected": f.totalRejected.Load(),
+	}
+}
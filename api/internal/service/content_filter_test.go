@@ -0,0 +1,131 @@
+package core
+
+import "testing"
+
+// newTestContentFilter 创建带内存规则的过滤器（不访问数据库）
+func newTestContentFilter(t *testing.T, policies map[int]string, words ...BannedWord) *ContentFilter {
+	t.Helper()
+	f := NewContentFilter(nil)
+	t.Cleanup(f.cancel)
+	compiled := make([]compiledWord, 0, len(words))
+	for _, w := range words {
+		cw, err := compileBannedWord(w)
+		if err != nil {
+			t.Fatalf("compile %q: %v", w.Word, err)
+		}
+		compiled = append(compiled, cw)
+	}
+	f.rules.Store(&filterRules{words: compiled, policies: policies})
+	return f
+}
+
+func TestContentFilter_Apply(t *testing.T) {
+	words := []BannedWord{
+		{ID: 1, Word: "赌博", MatchType: BannedMatchLiteral, Replacement: "娱乐"},
+		{ID: 2, Word: "span", MatchType: BannedMatchLiteral},
+		{ID: 3, Word: `v\d+`, MatchType: BannedMatchRegex},
+	}
+	policies := map[int]string{
+		2: FilterPolicyReplace,
+		3: FilterPolicyReject,
+		4: FilterPolicyOff,
+	}
+	f := newTestContentFilter(t, policies, words...)
+
+	tests := []struct {
+		name     string
+		groupID  int
+		text     string
+		want     string
+		hits     int
+		rejected bool
+	}{
+		{"no hit", 1, "正常内容", "正常内容", 0, false},
+		{"mask plain text", 1, "这里有赌博信息", "这里有**信息", 1, false},
+		{"mask case insensitive regex", 1, "版本 V12 发布", "版本 *** 发布", 1, false},
+		{"replace policy", 2, "禁止赌博", "禁止娱乐", 1, false},
+		{"replace without replacement masks", 2, "a span b", "a **** b", 1, false},
+		{"reject keeps text", 3, "赌博", "赌博", 1, true},
+		{"off policy", 4, "赌博", "赌博", 0, false},
+		{"tag names untouched", 1, `<span class="x">文本</span>`, `<span class="x">文本</span>`, 0, false},
+		{"attributes untouched", 1, `<a title="赌博">链接</a>`, `<a title="赌博">链接</a>`, 0, false},
+		{"text nodes masked", 1, `<p>赌博</p><span>span</span>`, `<p>**</p><span>****</span>`, 2, false},
+		{"no match across tags", 1, `赌<b>博</b>`, `赌<b>博</b>`, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, result := f.Preview(tt.groupID, tt.text)
+			if got != tt.want {
+				t.Errorf("text = %q, want %q", got, tt.want)
+			}
+			hits := 0
+			for _, h := range result.Hits {
+				hits += h.Count
+			}
+			if hits != tt.hits {
+				t.Errorf("hits = %d, want %d", hits, tt.hits)
+			}
+			if result.Rejected != tt.rejected {
+				t.Errorf("rejected = %v, want %v", result.Rejected, tt.rejected)
+			}
+		})
+	}
+}
+
+func TestContentFilter_Contains(t *testing.T) {
+	f := newTestContentFilter(t, nil, BannedWord{ID: 1, Word: "赌博", MatchType: BannedMatchLiteral})
+
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"", false},
+		{"正常关键词", false},
+		{"网络赌博平台", true},
+	}
+	for _, tt := range tests {
+		if got := f.Contains(tt.text); got != tt.want {
+			t.Errorf("Contains(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSplitHTMLText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []htmlSegment
+	}{
+		{"empty", "", []htmlSegment{}},
+		{"plain text", "abc", []htmlSegment{{text: "abc"}}},
+		{"tag only", "<br/>", []htmlSegment{{text: "<br/>", tag: true}}},
+		{"mixed", "a<b>c</b>d", []htmlSegment{
+			{text: "a"},
+			{text: "<b>", tag: true},
+			{text: "c"},
+			{text: "</b>", tag: true},
+			{text: "d"},
+		}},
+		{"adjacent tags", "<p><i>x</i></p>", []htmlSegment{
+			{text: "<p>", tag: true},
+			{text: "<i>", tag: true},
+			{text: "x"},
+			{text: "</i>", tag: true},
+			{text: "</p>", tag: true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitHTMLText(tt.in)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d segments %+v, want %+v", len(got), got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("segment %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
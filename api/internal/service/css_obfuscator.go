@@ -0,0 +1,565 @@
+// Package core provides post-render CSS obfuscation against page fingerprinting
+package core
+
+import (
+	"math/rand/v2"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"seo-generator/api/pkg/config"
+)
+
+var (
+	cssStyleBlockPattern  = regexp.MustCompile(`(?is)(<style\b[^>]*>)(.*?)(</style\s*>)`)
+	cssScriptBlockPattern = regexp.MustCompile(`(?is)<script\b[^>]*>.*?</script\s*>`)
+	cssTagPattern         = regexp.MustCompile(`<[A-Za-z][^>]*>`)
+	cssAttrPattern        = regexp.MustCompile(`(?i)(\s)(class|id|for|href)(\s*=\s*)(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	cssIdentPattern       = regexp.MustCompile(`[A-Za-z_-][\w-]*`)
+)
+
+// 嵌套规则的 @ 块（其内容仍是规则列表）
+var cssNestedAtRules = []string{"@media", "@supports", "@document", "@layer", "@container"}
+
+// junkDeclarations 干扰规则的声明（选择器为页面中不存在的类名，不影响显示）
+var junkDeclarations = []string{
+	"margin:0", "padding:0", "display:block", "display:inline-block", "float:left", "clear:both",
+	"overflow:hidden", "position:relative", "text-align:center", "font-weight:700", "line-height:1.5",
+	"color:#333", "color:#666", "background:#fff", "border:0", "cursor:pointer", "opacity:.9",
+	"font-size:14px", "font-size:12px", "width:100%", "vertical-align:middle", "white-space:nowrap",
+}
+
+// cssRule CSS 规则
+type cssRule struct {
+	prelude   string    // 选择器或 @ 规则头
+	body      string    // 声明块内容（普通规则和不含规则列表的 @ 块）
+	children  []cssRule // 嵌套规则（@media 等）
+	nested    bool
+	statement bool // 无块语句，如 @import url(...);
+}
+
+func (r *cssRule) isAt() bool {
+	return strings.HasPrefix(r.prelude, "@")
+}
+
+// CSSObfuscator 渲染后 CSS 混淆
+// 同一页面内一致地重命名 <style> 中定义的 class/id（同时改写标签的 class、id、for、href="#id"），
+// 打乱规则顺序（有共同选择器标识的规则保持相对顺序，@ 规则不移动），插入不匹配任何元素的干扰规则。
+// 是否启用由功能开关 css_obfuscation 控制（可按站群覆盖）；白名单中的名称（JS 使用的类名等）不改名
+type CSSObfuscator struct {
+	config    config.CSSObfuscationConfig
+	whitelist map[string]bool
+	prefixes  []string // 白名单中以 * 结尾的前缀
+
+	pages   atomic.Int64
+	renamed atomic.Int64
+	junk    atomic.Int64
+	skipped atomic.Int64 // CSS 解析失败未处理的页面
+}
+
+// NewCSSObfuscator 创建 CSS 混淆
+func NewCSSObfuscator(cfg config.CSSObfuscationConfig) *CSSObfuscator {
+	o := &CSSObfuscator{config: cfg, whitelist: map[string]bool{}}
+	for _, name := range cfg.Whitelist {
+		name = strings.TrimLeft(strings.TrimSpace(name), ".#")
+		if prefix, ok := strings.CutSuffix(name, "*"); ok {
+			o.prefixes = append(o.prefixes, prefix)
+		} else if name != "" {
+			o.whitelist[name] = true
+		}
+	}
+	return o
+}
+
+// keep 名称是否在白名单中
+func (o *CSSObfuscator) keep(name string) bool {
+	if o.whitelist[name] {
+		return true
+	}
+	for _, prefix := range o.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply 混淆页面 CSS，没有 <style> 或解析失败时原样返回
+func (o *CSSObfuscator) Apply(page string) string {
+	blocks := cssStyleBlockPattern.FindAllStringSubmatchIndex(page, -1)
+	if len(blocks) == 0 {
+		return page
+	}
+
+	// 1. 解析所有 <style>，收集选择器中的 class/id
+	sheets := make([][]cssRule, len(blocks))
+	classes, ids := map[string]string{}, map[string]string{}
+	for i, loc := range blocks {
+		rules, ok := parseCSSRules(page[loc[4]:loc[5]])
+		if !ok {
+			o.skipped.Add(1)
+			return page
+		}
+		sheets[i] = rules
+		walkCSSSelectors(rules, func(prelude string) string {
+			forEachCSSSelectorName(prelude, func(kind byte, name string) string {
+				if kind == '.' {
+					classes[name] = ""
+				} else {
+					ids[name] = ""
+				}
+				return name
+			})
+			return prelude
+		})
+	}
+
+	// 2. 生成本页面的新名称（页面内不重复）
+	used := map[string]bool{}
+	for name := range classes {
+		used[name] = true
+	}
+	for name := range ids {
+		used[name] = true
+	}
+	newName := func() string {
+		for {
+			name := generateClassAlias()
+			if !used[name] {
+				used[name] = true
+				return name
+			}
+		}
+	}
+	renames := 0
+	if o.config.Rename {
+		for _, m := range []map[string]string{classes, ids} {
+			for name := range m {
+				if o.keep(name) {
+					delete(m, name)
+					continue
+				}
+				m[name] = newName()
+				renames++
+			}
+		}
+	} else {
+		clear(classes)
+		clear(ids)
+	}
+
+	// 3. 重写 <style>：改名、打乱、插入干扰规则
+	var b strings.Builder
+	b.Grow(len(page) + 256)
+	last := 0
+	junk := 0
+	for i, loc := range blocks {
+		rules := sheets[i]
+		if renames > 0 {
+			walkCSSSelectors(rules, func(prelude string) string {
+				return forEachCSSSelectorName(prelude, func(kind byte, name string) string {
+					m := classes
+					if kind == '#' {
+						m = ids
+					}
+					if renamed, ok := m[name]; ok {
+						return renamed
+					}
+					return name
+				})
+			})
+		}
+		if o.config.Shuffle {
+			rules = shuffleCSSRules(rules)
+		}
+		if n := o.config.JunkRules; n > 0 {
+			count := n/2 + rand.IntN(n-n/2+1)
+			rules = injectJunkRules(rules, count, newName)
+			junk += count
+		}
+		b.WriteString(o.rewriteMarkup(page[last:loc[4]], classes, ids, renames > 0))
+		b.WriteString(serializeCSSRules(rules))
+		last = loc[5]
+	}
+	b.WriteString(o.rewriteMarkup(page[last:], classes, ids, renames > 0))
+
+	o.pages.Add(1)
+	o.renamed.Add(int64(renames))
+	o.junk.Add(int64(junk))
+	return b.String()
+}
+
+// rewriteMarkup 改写标签的 class、id、for、href="#id"（跳过 <script> 内容）
+func (o *CSSObfuscator) rewriteMarkup(html string, classes, ids map[string]string, rename bool) string {
+	if !rename {
+		return html
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range cssScriptBlockPattern.FindAllStringIndex(html, -1) {
+		b.WriteString(rewriteTags(html[last:loc[0]], classes, ids))
+		b.WriteString(html[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(rewriteTags(html[last:], classes, ids))
+	return b.String()
+}
+
+// rewriteTags 改写 HTML 片段中标签的属性
+func rewriteTags(html string, classes, ids map[string]string) string {
+	return cssTagPattern.ReplaceAllStringFunc(html, func(tag string) string {
+		return cssAttrPattern.ReplaceAllStringFunc(tag, func(attr string) string {
+			m := cssAttrPattern.FindStringSubmatch(attr)
+			value, quote := m[4], `"`
+			switch {
+			case m[5] != "":
+				value, quote = m[5], `'`
+			case m[6] != "":
+				value, quote = m[6], ``
+			}
+			var out string
+			switch strings.ToLower(m[2]) {
+			case "class":
+				fields := strings.Fields(value)
+				for i, f := range fields {
+					if renamed, ok := classes[f]; ok {
+						fields[i] = renamed
+					}
+				}
+				out = strings.Join(fields, " ")
+				if quote == "" && len(fields) > 1 {
+					quote = `"`
+				}
+			case "id", "for":
+				out = value
+				if renamed, ok := ids[value]; ok {
+					out = renamed
+				}
+			default: // href
+				out = value
+				if strings.HasPrefix(value, "#") {
+					if renamed, ok := ids[value[1:]]; ok {
+						out = "#" + renamed
+					}
+				}
+			}
+			return m[1] + m[2] + m[3] + quote + out + quote
+		})
+	})
+}
+
+// Stats 混淆统计
+func (o *CSSObfuscator) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		"pages":      o.pages.Load(),
+		"renamed":    o.renamed.Load(),
+		"junk_rules": o.junk.Load(),
+		"skipped":    o.skipped.Load(),
+		"rename":     o.config.Rename,
+		"shuffle":    o.config.Shuffle,
+		"whitelist":  o.config.Whitelist,
+	}
+}
+
+// parseCSSRules 解析规则列表（去掉注释），括号不配对时返回 false
+func parseCSSRules(css string) ([]cssRule, bool) {
+	css = stripCSSComments(css)
+	var rules []cssRule
+	i := 0
+	for i < len(css) {
+		// 前导空白
+		for i < len(css) && isCSSSpace(css[i]) {
+			i++
+		}
+		if i >= len(css) {
+			break
+		}
+		end := scanCSSUntil(css, i, "{;}")
+		if end < 0 {
+			// 末尾残留文本（缺少分号的语句）
+			rules = append(rules, cssRule{prelude: strings.TrimSpace(css[i:]), statement: true})
+			break
+		}
+		if css[end] == '}' {
+			return nil, false
+		}
+		prelude := strings.TrimSpace(css[i:end])
+		if css[end] == ';' {
+			rules = append(rules, cssRule{prelude: prelude, statement: true})
+			i = end + 1
+			continue
+		}
+		closeIdx := matchCSSBrace(css, end)
+		if closeIdx < 0 {
+			return nil, false
+		}
+		inner := css[end+1 : closeIdx]
+		rule := cssRule{prelude: prelude}
+		if isNestedAtRule(prelude) {
+			children, ok := parseCSSRules(inner)
+			if !ok {
+				return nil, false
+			}
+			rule.children, rule.nested = children, true
+		} else {
+			rule.body = strings.TrimSpace(inner)
+		}
+		rules = append(rules, rule)
+		i = closeIdx + 1
+	}
+	return rules, true
+}
+
+func isCSSSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\t' || c == '\r' || c == '\f'
+}
+
+func isNestedAtRule(prelude string) bool {
+	lower := strings.ToLower(prelude)
+	for _, at := range cssNestedAtRules {
+		if strings.HasPrefix(lower, at) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripCSSComments 去掉 /* */ 注释（字符串内除外）
+func stripCSSComments(css string) string {
+	if !strings.Contains(css, "/*") {
+		return css
+	}
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(css); i++ {
+		c := css[i]
+		if quote != 0 {
+			b.WriteByte(c)
+			if c == '\\' && i+1 < len(css) {
+				i++
+				b.WriteByte(css[i])
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '"' || c == '\'' {
+			quote = c
+			b.WriteByte(c)
+			continue
+		}
+		if c == '/' && i+1 < len(css) && css[i+1] == '*' {
+			end := strings.Index(css[i+2:], "*/")
+			if end < 0 {
+				break
+			}
+			i += end + 3
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// scanCSSUntil 从 start 开始查找 chars 中任一字符（跳过字符串和括号），未找到返回 -1
+func scanCSSUntil(css string, start int, chars string) int {
+	var quote byte
+	parens := 0
+	for i := start; i < len(css); i++ {
+		c := css[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			parens++
+		case c == ')':
+			if parens > 0 {
+				parens--
+			}
+		case parens == 0 && strings.IndexByte(chars, c) >= 0:
+			return i
+		}
+	}
+	return -1
+}
+
+// matchCSSBrace 返回与 open 位置 { 配对的 } 位置
+func matchCSSBrace(css string, open int) int {
+	depth := 0
+	for i := open; i < len(css); {
+		j := scanCSSUntil(css, i, "{}")
+		if j < 0 {
+			return -1
+		}
+		if css[j] == '{' {
+			depth++
+		} else {
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+		i = j + 1
+	}
+	return -1
+}
+
+// walkCSSSelectors 对所有普通规则的选择器调用 fn 并替换为返回值
+func walkCSSSelectors(rules []cssRule, fn func(prelude string) string) {
+	for i := range rules {
+		r := &rules[i]
+		switch {
+		case r.nested:
+			walkCSSSelectors(r.children, fn)
+		case r.statement || r.isAt():
+		default:
+			r.prelude = fn(r.prelude)
+		}
+	}
+}
+
+// forEachCSSSelectorName 遍历选择器中的 .class 和 #id（跳过属性选择器和字符串），按 fn 返回值替换名称
+func forEachCSSSelectorName(selector string, fn func(kind byte, name string) string) string {
+	var b strings.Builder
+	brackets := 0
+	var quote byte
+	for i := 0; i < len(selector); i++ {
+		c := selector[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			brackets++
+		case c == ']':
+			if brackets > 0 {
+				brackets--
+			}
+		case brackets == 0 && (c == '.' || c == '#') && (i == 0 || selector[i-1] != '\\'):
+			loc := cssIdentPattern.FindStringIndex(selector[i+1:])
+			if loc != nil && loc[0] == 0 {
+				name := selector[i+1 : i+1+loc[1]]
+				b.WriteByte(c)
+				b.WriteString(fn(c, name))
+				i += loc[1]
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// shuffleCSSRules 打乱规则顺序：
+// 依次放入每条规则，插入位置随机但必须在所有与其冲突的已放入规则之后
+// （选择器有共同标识、含通配符或 @ 规则时视为冲突），保证层叠结果不变
+func shuffleCSSRules(rules []cssRule) []cssRule {
+	if len(rules) < 2 {
+		return rules
+	}
+	type placed struct {
+		rule   cssRule
+		tokens map[string]bool
+		fixed  bool
+	}
+	out := make([]placed, 0, len(rules))
+	for _, r := range rules {
+		p := placed{rule: r}
+		if r.nested {
+			p.rule.children = shuffleCSSRules(r.children)
+		}
+		if r.statement || r.isAt() || strings.Contains(r.prelude, "*") {
+			p.fixed = true
+		} else {
+			p.tokens = map[string]bool{}
+			for _, t := range cssIdentPattern.FindAllString(r.prelude, -1) {
+				p.tokens[strings.ToLower(t)] = true
+			}
+		}
+		minPos := 0
+		for i := len(out) - 1; i >= 0; i-- {
+			if p.fixed || out[i].fixed || tokensOverlap(p.tokens, out[i].tokens) {
+				minPos = i + 1
+				break
+			}
+		}
+		pos := minPos + rand.IntN(len(out)-minPos+1)
+		out = append(out, placed{})
+		copy(out[pos+1:], out[pos:])
+		out[pos] = p
+	}
+	result := make([]cssRule, len(out))
+	for i := range out {
+		result[i] = out[i].rule
+	}
+	return result
+}
+
+func tokensOverlap(a, b map[string]bool) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	for t := range a {
+		if b[t] {
+			return true
+		}
+	}
+	return false
+}
+
+// injectJunkRules 在非 @ 规则之间的随机位置插入干扰规则（@import/@charset 等保持在最前）
+func injectJunkRules(rules []cssRule, count int, newName func() string) []cssRule {
+	start := 0
+	for start < len(rules) && rules[start].statement {
+		start++
+	}
+	for n := 0; n < count; n++ {
+		decls := make([]string, 1+rand.IntN(3))
+		for i := range decls {
+			decls[i] = junkDeclarations[rand.IntN(len(junkDeclarations))]
+		}
+		junk := cssRule{prelude: "." + newName(), body: strings.Join(decls, ";")}
+		pos := start + rand.IntN(len(rules)-start+1)
+		rules = append(rules, cssRule{})
+		copy(rules[pos+1:], rules[pos:])
+		rules[pos] = junk
+	}
+	return rules
+}
+
+// serializeCSSRules 输出规则列表
+func serializeCSSRules(rules []cssRule) string {
+	var b strings.Builder
+	b.WriteByte('\n')
+	writeCSSRules(&b, rules)
+	return b.String()
+}
+
+func writeCSSRules(b *strings.Builder, rules []cssRule) {
+	for _, r := range rules {
+		switch {
+		case r.statement:
+			b.WriteString(r.prelude)
+			b.WriteString(";\n")
+		case r.nested:
+			b.WriteString(r.prelude)
+			b.WriteString("{\n")
+			writeCSSRules(b, r.children)
+			b.WriteString("}\n")
+		default:
+			b.WriteString(r.prelude)
+			b.WriteByte('{')
+			b.WriteString(r.body)
+			b.WriteString("}\n")
+		}
+	}
+}
@@ -0,0 +1,475 @@
+// Package core provides periodic DNS, HTTP reachability and certificate checks for site domains
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// 域名健康告警类型
+const (
+	AlertTypeDomainUnhealthy  = "domain_unhealthy"
+	AlertTypeDomainCertExpiry = "domain_cert_expiry"
+)
+
+// ErrDomainCheckRunning 已有检查在进行中
+var ErrDomainCheckRunning = errors.New("domain health check is running")
+
+// DomainHealth 站点域名最近一次检查结果
+type DomainHealth struct {
+	SiteID        int        `db:"site_id" json:"site_id"`
+	Domain        string     `db:"domain" json:"domain"`
+	DNSOK         bool       `db:"dns_ok" json:"dns_ok"`
+	DNSAddrs      string     `db:"dns_addrs" json:"dns_addrs"` // 逗号分隔
+	HTTPOK        bool       `db:"http_ok" json:"http_ok"`
+	HTTPStatus    int        `db:"http_status" json:"http_status"`
+	HTTPMs        int        `db:"http_ms" json:"http_ms"`
+	CertOK        bool       `db:"cert_ok" json:"cert_ok"` // http 协议时不检查，恒为 true
+	CertExpiresAt *time.Time `db:"cert_expires_at" json:"cert_expires_at"`
+	CertDaysLeft  *int       `db:"cert_days_left" json:"cert_days_left"`
+	CertIssuer    string     `db:"cert_issuer" json:"cert_issuer"`
+	Healthy       bool       `db:"healthy" json:"healthy"`
+	Error         string     `db:"error" json:"error"` // 各项失败原因，分号分隔
+	FailureStreak int        `db:"failure_streak" json:"failure_streak"`
+	LastOKAt      *time.Time `db:"last_ok_at" json:"last_ok_at"`
+	CheckedAt     time.Time  `db:"checked_at" json:"checked_at"`
+}
+
+// domainHealthColumns site_health 查询列
+const domainHealthColumns = `site_id, domain, dns_ok, dns_addrs, http_ok, http_status, http_ms, cert_ok, cert_expires_at,
+	cert_days_left, cert_issuer, healthy, error, failure_streak, last_ok_at, checked_at`
+
+// DomainHealthSummary 健康汇总
+type DomainHealthSummary struct {
+	Total        int            `json:"total"` // 已检查的启用站点数
+	Healthy      int            `json:"healthy"`
+	Unhealthy    int            `json:"unhealthy"`
+	DNSFailed    int            `json:"dns_failed"`
+	HTTPFailed   int            `json:"http_failed"`
+	CertFailed   int            `json:"cert_failed"`
+	CertExpiring int            `json:"cert_expiring"` // 证书剩余天数低于 cert_warn_days
+	Alerting     int            `json:"alerting"`      // 连续失败次数达到告警阈值
+	LastRunAt    *time.Time     `json:"last_run_at"`
+	Items        []DomainHealth `json:"items"`
+}
+
+// DomainMonitor 站点域名健康检查
+// 定期对所有启用站点的域名做 DNS 解析、经公网入口的 HTTP 请求和证书有效期检查，
+// 最新结果保存在 site_health 表（含连续失败次数），每次检查写入 site_health_checks 历史；
+// 连续失败达到阈值或证书即将过期时通过 Monitor 告警
+type DomainMonitor struct {
+	db     *sqlx.DB
+	config config.DomainMonitorConfig
+	alerts *AlertManager
+	client *http.Client
+
+	running   sync.Mutex
+	mu        sync.Mutex
+	lastRunAt *time.Time
+}
+
+// NewDomainMonitor 创建域名健康检查
+func NewDomainMonitor(db *sqlx.DB, cfg config.DomainMonitorConfig) *DomainMonitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 900
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 8
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10
+	}
+	if cfg.Scheme != "http" {
+		cfg.Scheme = "https"
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 3
+	}
+	if cfg.HistoryDays <= 0 {
+		cfg.HistoryDays = 30
+	}
+
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			// 配置了公网入口时连接入口地址，Host 头和 SNI 仍为站点域名
+			if cfg.EdgeAddr != "" {
+				addr = cfg.EdgeAddr
+			}
+			return dialer.DialContext(ctx, network, addr)
+		},
+		TLSHandshakeTimeout: timeout,
+		DisableKeepAlives:   true,
+	}
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		// 只检查入口响应，不跟随跳转（停放域名常见跳转到第三方页面）
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return &DomainMonitor{db: db, config: cfg, client: client}
+}
+
+// SetAlertManager 设置告警管理器
+func (m *DomainMonitor) SetAlertManager(am *AlertManager) {
+	m.alerts = am
+}
+
+// Start 按间隔检查，ctx 取消时退出
+func (m *DomainMonitor) Start(ctx context.Context) {
+	timer := time.NewTimer(time.Minute) // 启动后稍等再做首次检查
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			if _, err := m.Run(ctx, ""); err != nil && !errors.Is(err, ErrDomainCheckRunning) {
+				log.Warn().Err(err).Msg("Domain health check failed")
+			}
+			timer.Reset(time.Duration(m.config.Interval) * time.Second)
+		}
+	}
+}
+
+// Run 检查所有启用站点（domain 不为空时只检查该域名），返回检查的域名数
+func (m *DomainMonitor) Run(ctx context.Context, domain string) (int, error) {
+	if !m.running.TryLock() {
+		return 0, ErrDomainCheckRunning
+	}
+	defer m.running.Unlock()
+
+	query, args := "SELECT id, domain FROM sites WHERE status = 1", []interface{}{}
+	if domain != "" {
+		query, args = "SELECT id, domain FROM sites WHERE domain = ?", append(args, domain)
+	}
+	var sites []struct {
+		ID     int    `db:"id"`
+		Domain string `db:"domain"`
+	}
+	if err := m.db.SelectContext(ctx, &sites, query, args...); err != nil {
+		return 0, fmt.Errorf("load sites: %w", err)
+	}
+
+	sem := make(chan struct{}, m.config.Concurrency)
+	var wg sync.WaitGroup
+	for _, site := range sites {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(siteID int, domain string) {
+			defer func() { <-sem; wg.Done() }()
+			result := m.check(ctx, siteID, domain)
+			if err := m.save(ctx, result); err != nil {
+				log.Warn().Err(err).Str("domain", domain).Msg("Failed to save domain health")
+			}
+		}(site.ID, site.Domain)
+	}
+	wg.Wait()
+
+	// 已删除或禁用的站点不再参与汇总
+	if domain == "" {
+		if _, err := m.db.ExecContext(ctx,
+			"DELETE FROM site_health WHERE site_id NOT IN (SELECT id FROM sites WHERE status = 1)"); err != nil {
+			log.Warn().Err(err).Msg("Failed to prune site health")
+		}
+		if _, err := m.db.ExecContext(ctx, "DELETE FROM site_health_checks WHERE checked_at < ?",
+			time.Now().AddDate(0, 0, -m.config.HistoryDays)); err != nil {
+			log.Warn().Err(err).Msg("Failed to prune site health history")
+		}
+		now := time.Now()
+		m.mu.Lock()
+		m.lastRunAt = &now
+		m.mu.Unlock()
+	}
+	m.raiseAlerts(ctx)
+	return len(sites), nil
+}
+
+// check 检查单个域名
+func (m *DomainMonitor) check(ctx context.Context, siteID int, domain string) *DomainHealth {
+	timeout := time.Duration(m.config.Timeout) * time.Second
+	h := &DomainHealth{SiteID: siteID, Domain: domain, CertOK: true, CheckedAt: time.Now()}
+	var errs []string
+
+	// DNS
+	dnsCtx, cancel := context.WithTimeout(ctx, timeout)
+	addrs, err := net.DefaultResolver.LookupHost(dnsCtx, domain)
+	cancel()
+	if err != nil || len(addrs) == 0 {
+		errs = append(errs, "dns: "+errString(err, "no address"))
+	} else {
+		h.DNSOK = true
+		h.DNSAddrs = truncateRunes(strings.Join(addrs, ","), 255)
+	}
+
+	// HTTP（未配置公网入口且 DNS 失败时无需再请求）
+	if h.DNSOK || m.config.EdgeAddr != "" {
+		start := time.Now()
+		status, err := m.httpStatus(ctx, domain)
+		h.HTTPMs = int(time.Since(start).Milliseconds())
+		h.HTTPStatus = status
+		switch {
+		case err != nil:
+			errs = append(errs, "http: "+err.Error())
+		case status >= 400:
+			errs = append(errs, fmt.Sprintf("http: status %d", status))
+		default:
+			h.HTTPOK = true
+		}
+	} else {
+		errs = append(errs, "http: skipped")
+	}
+
+	// 证书
+	if m.config.Scheme == "https" {
+		h.CertOK = false
+		if h.DNSOK || m.config.EdgeAddr != "" {
+			if err := m.checkCert(ctx, domain, h); err != nil {
+				errs = append(errs, "cert: "+err.Error())
+			} else {
+				h.CertOK = true
+			}
+		} else {
+			errs = append(errs, "cert: skipped")
+		}
+	}
+
+	h.Healthy = h.DNSOK && h.HTTPOK && h.CertOK
+	h.Error = truncateRunes(strings.Join(errs, "; "), 500)
+	return h
+}
+
+// httpStatus 请求站点首页，返回状态码
+func (m *DomainMonitor) httpStatus(ctx context.Context, domain string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.config.Scheme+"://"+domain+"/", nil)
+	if err != nil {
+		return 0, err
+	}
+	if m.config.UserAgent != "" {
+		req.Header.Set("User-Agent", m.config.UserAgent)
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// checkCert 读取证书有效期并校验域名匹配和有效期
+func (m *DomainMonitor) checkCert(ctx context.Context, domain string, h *DomainHealth) error {
+	addr := net.JoinHostPort(domain, "443")
+	if m.config.EdgeAddr != "" {
+		addr = m.config.EdgeAddr
+	}
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: time.Duration(m.config.Timeout) * time.Second},
+		// 证书链单独校验，过期或不受信任的证书也要读取有效期
+		Config: &tls.Config{ServerName: domain, InsecureSkipVerify: true},
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, time.Duration(m.config.Timeout)*time.Second)
+	defer cancel()
+	conn, err := dialer.DialContext(dialCtx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return errors.New("no certificate")
+	}
+	leaf := certs[0]
+	expires := leaf.NotAfter
+	days := int(time.Until(expires).Hours() / 24)
+	h.CertExpiresAt, h.CertDaysLeft = &expires, &days
+	h.CertIssuer = truncateRunes(leaf.Issuer.CommonName, 255)
+
+	if time.Now().After(expires) {
+		return fmt.Errorf("expired at %s", expires.Format("2006-01-02"))
+	}
+	if err := leaf.VerifyHostname(domain); err != nil {
+		return err
+	}
+	return nil
+}
+
+// save 写入最新结果（累计连续失败次数）和检查历史
+func (m *DomainMonitor) save(ctx context.Context, h *DomainHealth) error {
+	var prev struct {
+		FailureStreak int        `db:"failure_streak"`
+		LastOKAt      *time.Time `db:"last_ok_at"`
+	}
+	if err := m.db.GetContext(ctx, &prev, "SELECT failure_streak, last_ok_at FROM site_health WHERE site_id = ?", h.SiteID); err != nil {
+		prev.FailureStreak = 0
+	}
+	if h.Healthy {
+		h.FailureStreak, h.LastOKAt = 0, &h.CheckedAt
+	} else {
+		h.FailureStreak, h.LastOKAt = prev.FailureStreak+1, prev.LastOKAt
+	}
+
+	if _, err := m.db.ExecContext(ctx, `
+		INSERT INTO site_health (site_id, domain, dns_ok, dns_addrs, http_ok, http_status, http_ms, cert_ok, cert_expires_at,
+			cert_days_left, cert_issuer, healthy, error, failure_streak, last_ok_at, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE domain = VALUES(domain), dns_ok = VALUES(dns_ok), dns_addrs = VALUES(dns_addrs),
+			http_ok = VALUES(http_ok), http_status = VALUES(http_status), http_ms = VALUES(http_ms), cert_ok = VALUES(cert_ok),
+			cert_expires_at = VALUES(cert_expires_at), cert_days_left = VALUES(cert_days_left), cert_issuer = VALUES(cert_issuer),
+			healthy = VALUES(healthy), error = VALUES(error), failure_streak = VALUES(failure_streak),
+			last_ok_at = VALUES(last_ok_at), checked_at = VALUES(checked_at)`,
+		h.SiteID, h.Domain, h.DNSOK, h.DNSAddrs, h.HTTPOK, h.HTTPStatus, h.HTTPMs, h.CertOK, h.CertExpiresAt,
+		h.CertDaysLeft, h.CertIssuer, h.Healthy, h.Error, h.FailureStreak, h.LastOKAt, h.CheckedAt); err != nil {
+		return err
+	}
+	_, err := m.db.ExecContext(ctx, `
+		INSERT INTO site_health_checks (site_id, domain, dns_ok, http_ok, http_status, http_ms, cert_ok, cert_days_left, healthy, error, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		h.SiteID, h.Domain, h.DNSOK, h.HTTPOK, h.HTTPStatus, h.HTTPMs, h.CertOK, h.CertDaysLeft, h.Healthy, h.Error, h.CheckedAt)
+	return err
+}
+
+// raiseAlerts 按当前状态告警：有域名连续失败达到阈值、或证书即将过期时告警，全部恢复后解除
+func (m *DomainMonitor) raiseAlerts(ctx context.Context) {
+	if m.alerts == nil {
+		return
+	}
+	var failing []struct {
+		Domain        string `db:"domain"`
+		FailureStreak int    `db:"failure_streak"`
+		Error         string `db:"error"`
+	}
+	if err := m.db.SelectContext(ctx, &failing,
+		"SELECT domain, failure_streak, error FROM site_health WHERE failure_streak >= ? ORDER BY failure_streak DESC",
+		m.config.FailureThreshold); err != nil {
+		log.Warn().Err(err).Msg("Failed to query failing domains")
+		return
+	}
+	// 告警只在达到阈值的那一次触发，持续失败不重复告警
+	var newly []string
+	for _, f := range failing {
+		if f.FailureStreak == m.config.FailureThreshold {
+			newly = append(newly, f.Domain+"（"+f.Error+"）")
+		}
+	}
+	if len(newly) > 0 {
+		m.alerts.Raise(AlertLevelError, AlertTypeDomainUnhealthy,
+			fmt.Sprintf("%d 个域名连续 %d 次检查失败: %s", len(newly), m.config.FailureThreshold, strings.Join(newly, ", ")),
+			float64(len(failing)), float64(m.config.FailureThreshold))
+	} else if len(failing) == 0 {
+		m.alerts.Resolve(AlertTypeDomainUnhealthy)
+	}
+
+	if m.config.CertWarnDays <= 0 {
+		return
+	}
+	var expiring []string
+	if err := m.db.SelectContext(ctx, &expiring,
+		"SELECT domain FROM site_health WHERE cert_days_left IS NOT NULL AND cert_days_left < ? ORDER BY cert_days_left",
+		m.config.CertWarnDays); err != nil {
+		log.Warn().Err(err).Msg("Failed to query expiring certificates")
+		return
+	}
+	if len(expiring) == 0 {
+		m.alerts.Resolve(AlertTypeDomainCertExpiry)
+		return
+	}
+	// 证书告警每天最多一次
+	for _, a := range m.alerts.GetUnresolvedAlerts() {
+		if a.Type == AlertTypeDomainCertExpiry && time.Since(a.Timestamp) < 24*time.Hour {
+			return
+		}
+	}
+	m.alerts.Raise(AlertLevelWarning, AlertTypeDomainCertExpiry,
+		fmt.Sprintf("%d 个域名证书将在 %d 天内过期: %s", len(expiring), m.config.CertWarnDays, strings.Join(expiring, ", ")),
+		float64(len(expiring)), float64(m.config.CertWarnDays))
+}
+
+// Summary 健康汇总，onlyUnhealthy 为 true 时 items 只包含不健康的域名
+func (m *DomainMonitor) Summary(ctx context.Context, onlyUnhealthy bool) (*DomainHealthSummary, error) {
+	var items []DomainHealth
+	if err := m.db.SelectContext(ctx, &items,
+		"SELECT "+domainHealthColumns+" FROM site_health ORDER BY healthy, failure_streak DESC, domain"); err != nil {
+		return nil, err
+	}
+	summary := &DomainHealthSummary{Total: len(items), Items: []DomainHealth{}}
+	for _, h := range items {
+		if h.Healthy {
+			summary.Healthy++
+		} else {
+			summary.Unhealthy++
+		}
+		if !h.DNSOK {
+			summary.DNSFailed++
+		}
+		if !h.HTTPOK {
+			summary.HTTPFailed++
+		}
+		if !h.CertOK {
+			summary.CertFailed++
+		}
+		if h.CertDaysLeft != nil && *h.CertDaysLeft < m.config.CertWarnDays {
+			summary.CertExpiring++
+		}
+		if h.FailureStreak >= m.config.FailureThreshold {
+			summary.Alerting++
+		}
+		if !onlyUnhealthy || !h.Healthy {
+			summary.Items = append(summary.Items, h)
+		}
+	}
+	m.mu.Lock()
+	summary.LastRunAt = m.lastRunAt
+	m.mu.Unlock()
+	return summary, nil
+}
+
+// DomainHealthCheck 单次检查记录
+type DomainHealthCheck struct {
+	ID           int64     `db:"id" json:"id"`
+	DNSOK        bool      `db:"dns_ok" json:"dns_ok"`
+	HTTPOK       bool      `db:"http_ok" json:"http_ok"`
+	HTTPStatus   int       `db:"http_status" json:"http_status"`
+	HTTPMs       int       `db:"http_ms" json:"http_ms"`
+	CertOK       bool      `db:"cert_ok" json:"cert_ok"`
+	CertDaysLeft *int      `db:"cert_days_left" json:"cert_days_left"`
+	Healthy      bool      `db:"healthy" json:"healthy"`
+	Error        string    `db:"error" json:"error"`
+	CheckedAt    time.Time `db:"checked_at" json:"checked_at"`
+}
+
+// History 站点最近的检查记录（新的在前）
+func (m *DomainMonitor) History(ctx context.Context, siteID, limit int) ([]DomainHealthCheck, error) {
+	items := []DomainHealthCheck{}
+	err := m.db.SelectContext(ctx, &items, `
+		SELECT id, dns_ok, http_ok, http_status, http_ms, cert_ok, cert_days_left, healthy, error, checked_at
+		FROM site_health_checks WHERE site_id = ? ORDER BY id DESC LIMIT ?`, siteID, limit)
+	return items, err
+}
+
+func errString(err error, fallback string) string {
+	if err == nil {
+		return fallback
+	}
+	return err.Error()
+}
@@ -0,0 +1,338 @@
+// Package core provides the audit log of outbound HTTP requests made through the shared client
+package core
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// 出站请求用途
+const (
+	EgressPurposeRobotsCheck    = "robots_check"
+	EgressPurposeErrorReport    = "error_report"
+	EgressPurposeClickHouse     = "clickhouse"
+	EgressPurposeKeywordSuggest = "keyword_suggest"
+	EgressPurposeKeywordImport  = "keyword_import"
+	EgressPurposeBackupS3       = "backup_s3"
+	EgressPurposeAlertWebhook   = "alert_webhook"
+	EgressPurposeLLM            = "llm"
+)
+
+// EgressRecord 单次出站请求（重试的每次请求各一条）
+type EgressRecord struct {
+	ID         int64     `db:"id" json:"id"`
+	Host       string    `db:"host" json:"host"`
+	Method     string    `db:"method" json:"method"`
+	Path       string    `db:"path" json:"path"` // 不含查询串（可能带凭证）
+	Purpose    string    `db:"purpose" json:"purpose"`
+	Attempt    int       `db:"attempt" json:"attempt"`
+	Status     int       `db:"status" json:"status"` // 0 表示网络错误，未收到响应
+	BytesOut   int64     `db:"bytes_out" json:"bytes_out"`
+	BytesIn    int64     `db:"bytes_in" json:"bytes_in"`
+	DurationMs int       `db:"duration_ms" json:"duration_ms"` // 到响应体读完或关闭
+	Error      string    `db:"error" json:"error"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+// EgressDaily 出站请求每日汇总（按主机和用途）
+type EgressDaily struct {
+	StatDate string `db:"stat_date" json:"stat_date"`
+	Host     string `db:"host" json:"host"`
+	Purpose  string `db:"purpose" json:"purpose"`
+	Requests int64  `db:"requests" json:"requests"`
+	Errors   int64  `db:"errors" json:"errors"` // 网络错误和 4xx/5xx
+	BytesOut int64  `db:"bytes_out" json:"bytes_out"`
+	BytesIn  int64  `db:"bytes_in" json:"bytes_in"`
+}
+
+// EgressLogFilter 出站日志查询条件
+type EgressLogFilter struct {
+	Host     string
+	Purpose  string
+	Failed   bool // 只看失败（网络错误和 4xx/5xx）
+	Start    time.Time
+	End      time.Time
+	Page     int
+	PageSize int
+}
+
+// egressBody 包装响应体，读完或关闭时记录收到的字节数和耗时（只记录一次）
+type egressBody struct {
+	io.ReadCloser
+	rec     EgressRecord
+	auditor *EgressAuditor
+	once    sync.Once
+}
+
+func (b *egressBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.rec.BytesIn += int64(n)
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *egressBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+func (b *egressBody) finish() {
+	b.once.Do(func() {
+		b.rec.DurationMs = int(time.Since(b.rec.CreatedAt).Milliseconds())
+		b.auditor.Record(b.rec)
+	})
+}
+
+// EgressAuditor 出站请求审计日志
+// 共享 HTTP 客户端发出的每次请求先进入内存缓冲区，后台批量写入 egress_logs，
+// 同时累加到 egress_daily 每日汇总；缓冲区满时丢弃并计数，明细按 retention_days 清理，汇总保留
+type EgressAuditor struct {
+	db     *sqlx.DB
+	config config.EgressAuditConfig
+	buffer chan EgressRecord
+
+	recorded atomic.Int64
+	dropped  atomic.Int64
+	written  atomic.Int64
+	failed   atomic.Int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewEgressAuditor 创建出站审计日志，未启用时返回 nil
+func NewEgressAuditor(db *sqlx.DB, cfg config.EgressAuditConfig) *EgressAuditor {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 10000
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 200
+	}
+	if cfg.FlushSeconds <= 0 {
+		cfg.FlushSeconds = 5
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &EgressAuditor{
+		db:     db,
+		config: cfg,
+		buffer: make(chan EgressRecord, cfg.BufferSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start 启动后台写入和清理协程
+func (a *EgressAuditor) Start() {
+	a.wg.Add(1)
+	go a.run()
+	log.Info().Int("retention_days", a.config.RetentionDays).Msg("Egress auditor started")
+}
+
+// Stop 停止并写入缓冲区中剩余的记录
+func (a *EgressAuditor) Stop() {
+	a.cancel()
+	a.wg.Wait()
+}
+
+// Record 记录一次出站请求（不阻塞，缓冲区满时丢弃）
+func (a *EgressAuditor) Record(r EgressRecord) {
+	if len(r.Path) > 255 {
+		r.Path = r.Path[:255]
+	}
+	if len(r.Error) > 500 {
+		r.Error = r.Error[:500]
+	}
+	select {
+	case a.buffer <- r:
+		a.recorded.Add(1)
+	default:
+		a.dropped.Add(1)
+	}
+}
+
+// Stats 写入统计
+func (a *EgressAuditor) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		"pending":        len(a.buffer),
+		"buffer_size":    a.config.BufferSize,
+		"recorded":       a.recorded.Load(),
+		"dropped":        a.dropped.Load(),
+		"written":        a.written.Load(),
+		"failed":         a.failed.Load(),
+		"retention_days": a.config.RetentionDays,
+	}
+}
+
+func (a *EgressAuditor) run() {
+	defer a.wg.Done()
+	ticker := time.NewTicker(time.Duration(a.config.FlushSeconds) * time.Second)
+	defer ticker.Stop()
+	purge := time.NewTicker(time.Hour)
+	defer purge.Stop()
+
+	batch := make([]EgressRecord, 0, a.config.BatchSize)
+	for {
+		select {
+		case r := <-a.buffer:
+			batch = append(batch, r)
+			if len(batch) >= a.config.BatchSize {
+				a.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				a.flush(batch)
+				batch = batch[:0]
+			}
+		case <-purge.C:
+			a.purge()
+		case <-a.ctx.Done():
+			for {
+				select {
+				case r := <-a.buffer:
+					batch = append(batch, r)
+					if len(batch) >= a.config.BatchSize {
+						a.flush(batch)
+						batch = batch[:0]
+					}
+				default:
+					if len(batch) > 0 {
+						a.flush(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush 写入明细并累加每日汇总
+func (a *EgressAuditor) flush(batch []EgressRecord) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	values := make([]string, len(batch))
+	args := make([]interface{}, 0, len(batch)*11)
+	type dailyKey struct{ date, host, purpose string }
+	daily := map[dailyKey]*EgressDaily{}
+	for i, r := range batch {
+		values[i] = "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		args = append(args, r.Host, r.Method, r.Path, r.Purpose, r.Attempt, r.Status, r.BytesOut, r.BytesIn, r.DurationMs, r.Error, r.CreatedAt)
+
+		key := dailyKey{r.CreatedAt.Format("2006-01-02"), r.Host, r.Purpose}
+		d := daily[key]
+		if d == nil {
+			d = &EgressDaily{StatDate: key.date, Host: key.host, Purpose: key.purpose}
+			daily[key] = d
+		}
+		d.Requests++
+		if r.Status == 0 || r.Status >= 400 {
+			d.Errors++
+		}
+		d.BytesOut += r.BytesOut
+		d.BytesIn += r.BytesIn
+	}
+	if _, err := a.db.ExecContext(ctx, `INSERT INTO egress_logs
+		(host, method, path, purpose, attempt, status, bytes_out, bytes_in, duration_ms, error, created_at)
+		VALUES `+strings.Join(values, ","), args...); err != nil {
+		a.failed.Add(int64(len(batch)))
+		log.Error().Err(err).Int("count", len(batch)).Msg("Failed to write egress logs")
+		return
+	}
+	a.written.Add(int64(len(batch)))
+
+	for _, d := range daily {
+		if _, err := a.db.ExecContext(ctx, `INSERT INTO egress_daily
+			(stat_date, host, purpose, requests, errors, bytes_out, bytes_in) VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE requests = requests + VALUES(requests), errors = errors + VALUES(errors),
+				bytes_out = bytes_out + VALUES(bytes_out), bytes_in = bytes_in + VALUES(bytes_in)`,
+			d.StatDate, d.Host, d.Purpose, d.Requests, d.Errors, d.BytesOut, d.BytesIn); err != nil {
+			log.Warn().Err(err).Str("host", d.Host).Msg("Failed to update egress daily rollup")
+		}
+	}
+}
+
+// purge 分批删除超过保留天数的明细
+func (a *EgressAuditor) purge() {
+	if a.config.RetentionDays <= 0 {
+		return
+	}
+	cutoff := time.Now().AddDate(0, 0, -a.config.RetentionDays)
+	var total int64
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		res, err := a.db.ExecContext(ctx, "DELETE FROM egress_logs WHERE created_at < ? LIMIT 5000", cutoff)
+		cancel()
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to purge egress logs")
+			return
+		}
+		n, _ := res.RowsAffected()
+		total += n
+		if n < 5000 || a.ctx.Err() != nil {
+			break
+		}
+	}
+	if total > 0 {
+		log.Info().Int64("deleted", total).Int("retention_days", a.config.RetentionDays).Msg("Egress logs purged")
+	}
+}
+
+// Logs 查询出站请求明细（按时间倒序）
+func (a *EgressAuditor) Logs(ctx context.Context, f EgressLogFilter) ([]EgressRecord, int64, error) {
+	where, args := egressWhere(f.Host, f.Purpose, "created_at >= ? AND created_at < ?", f.Start, f.End)
+	if f.Failed {
+		where += " AND (status = 0 OR status >= 400)"
+	}
+	var total int64
+	if err := a.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM egress_logs WHERE "+where, args...); err != nil {
+		return nil, 0, err
+	}
+	items := []EgressRecord{}
+	err := a.db.SelectContext(ctx, &items, `
+		SELECT id, host, method, path, purpose, attempt, status, bytes_out, bytes_in, duration_ms, error, created_at
+		FROM egress_logs WHERE `+where+` ORDER BY id DESC LIMIT ? OFFSET ?`,
+		append(args, f.PageSize, (f.Page-1)*f.PageSize)...)
+	return items, total, err
+}
+
+// Daily 每日汇总，start/end 为日期（含两端）
+func (a *EgressAuditor) Daily(ctx context.Context, host, purpose string, start, end time.Time) ([]EgressDaily, error) {
+	where, args := egressWhere(host, purpose, "stat_date >= ? AND stat_date <= ?",
+		start.Format("2006-01-02"), end.Format("2006-01-02"))
+	items := []EgressDaily{}
+	err := a.db.SelectContext(ctx, &items, `
+		SELECT DATE_FORMAT(stat_date, '%Y-%m-%d') AS stat_date, host, purpose, requests, errors, bytes_out, bytes_in
+		FROM egress_daily WHERE `+where+` ORDER BY stat_date DESC, requests DESC`, args...)
+	return items, err
+}
+
+func egressWhere(host, purpose, rangeCond string, from, to interface{}) (string, []interface{}) {
+	where := []string{rangeCond}
+	args := []interface{}{from, to}
+	if host != "" {
+		where = append(where, "host = ?")
+		args = append(args, host)
+	}
+	if purpose != "" {
+		where = append(where, "purpose = ?")
+		args = append(args, purpose)
+	}
+	return strings.Join(where, " AND "), args
+}
@@ -0,0 +1,334 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 接口指标的路由标签
+const (
+	// EndpointUnmatched 未匹配任何路由的请求（404 扫描等）统一记为该标签
+	EndpointUnmatched = "unmatched"
+	// EndpointOverflow 路由数超过上限后新出现的路由统一记为该标签
+	EndpointOverflow = "other"
+
+	maxEndpointRoutes = 1000
+)
+
+// endpointLatencyBuckets 延迟直方图的桶上界（秒），最后隐含 +Inf
+var endpointLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// endpointKey 路由维度：方法 + 路由模板（c.FullPath()，如 /api/sites/:id），不使用原始路径，避免高基数
+type endpointKey struct {
+	method string
+	route  string
+}
+
+// endpointStats 单个路由的指标
+type endpointStats struct {
+	inFlight int64 // atomic
+
+	mu       sync.Mutex
+	buckets  []int64 // 与 endpointLatencyBuckets 对应，最后一个为 +Inf，非累计
+	count    int64
+	sumNs    int64
+	maxNs    int64
+	statuses map[int]int64
+}
+
+// EndpointMetrics 按路由统计请求延迟直方图、状态码计数和处理中请求数
+type EndpointMetrics struct {
+	mu       sync.RWMutex
+	routes   map[endpointKey]*endpointStats
+	inFlight int64 // atomic，全部路由
+	started  time.Time
+}
+
+// EndpointStat 单个路由的指标快照
+type EndpointStat struct {
+	Method       string           `json:"method"`
+	Route        string           `json:"route"`
+	Requests     int64            `json:"requests"`
+	InFlight     int64            `json:"in_flight"`
+	Errors       int64            `json:"errors"`     // 5xx
+	ErrorRate    float64          `json:"error_rate"` // 5xx 占比
+	AvgLatencyMs float64          `json:"avg_latency_ms"`
+	MaxLatencyMs float64          `json:"max_latency_ms"`
+	P50Ms        float64          `json:"p50_ms"` // 由直方图估算
+	P95Ms        float64          `json:"p95_ms"`
+	P99Ms        float64          `json:"p99_ms"`
+	StatusCodes  map[string]int64 `json:"status_codes"`
+	StatusGroups map[string]int64 `json:"status_groups"` // 2xx / 3xx / 4xx / 5xx
+}
+
+// EndpointMetricsSnapshot 接口指标快照
+type EndpointMetricsSnapshot struct {
+	InFlight      int64          `json:"in_flight"`
+	UptimeSeconds int64          `json:"uptime_seconds"`
+	Endpoints     []EndpointStat `json:"endpoints"`
+}
+
+// 全局接口指标实例
+var globalEndpointMetrics = NewEndpointMetrics()
+
+// GetEndpointMetrics 获取全局接口指标实例
+func GetEndpointMetrics() *EndpointMetrics {
+	return globalEndpointMetrics
+}
+
+// NewEndpointMetrics 创建接口指标
+func NewEndpointMetrics() *EndpointMetrics {
+	return &EndpointMetrics{
+		routes:  make(map[endpointKey]*endpointStats),
+		started: time.Now(),
+	}
+}
+
+// EndpointMetricsMiddleware 记录每个请求的路由指标（使用全局实例）
+func EndpointMetricsMiddleware() gin.HandlerFunc {
+	return GetEndpointMetrics().Middleware()
+}
+
+// Middleware 返回记录指标的 gin 中间件，需在注册路由前挂载
+func (m *EndpointMetrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = EndpointUnmatched
+		}
+		stats := m.stats(endpointKey{method: endpointMethod(c.Request.Method), route: route})
+
+		atomic.AddInt64(&m.inFlight, 1)
+		atomic.AddInt64(&stats.inFlight, 1)
+		start := time.Now()
+		defer func() {
+			atomic.AddInt64(&stats.inFlight, -1)
+			atomic.AddInt64(&m.inFlight, -1)
+			status := c.Writer.Status()
+			// panic 由外层 Recovery 处理，此时尚未写响应，按 500 记录
+			if r := recover(); r != nil {
+				stats.observe(500, time.Since(start))
+				panic(r)
+			}
+			stats.observe(status, time.Since(start))
+		}()
+
+		c.Next()
+	}
+}
+
+// stats 获取路由的指标，不存在时创建；路由数超过上限后归入 EndpointOverflow
+func (m *EndpointMetrics) stats(key endpointKey) *endpointStats {
+	m.mu.RLock()
+	stats, ok := m.routes[key]
+	m.mu.RUnlock()
+	if ok {
+		return stats
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stats, ok = m.routes[key]; ok {
+		return stats
+	}
+	if len(m.routes) >= maxEndpointRoutes {
+		key = endpointKey{method: key.method, route: EndpointOverflow}
+		if stats, ok = m.routes[key]; ok {
+			return stats
+		}
+	}
+	stats = &endpointStats{
+		buckets:  make([]int64, len(endpointLatencyBuckets)+1),
+		statuses: make(map[int]int64),
+	}
+	m.routes[key] = stats
+	return stats
+}
+
+// endpointMethod 非标准方法统一记为 OTHER（方法由客户端任意指定）
+func endpointMethod(method string) string {
+	switch method {
+	case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+		return method
+	}
+	return "OTHER"
+}
+
+// observe 记录一次请求
+func (s *endpointStats) observe(status int, latency time.Duration) {
+	seconds := latency.Seconds()
+	idx := sort.SearchFloat64s(endpointLatencyBuckets, seconds)
+
+	s.mu.Lock()
+	s.buckets[idx]++
+	s.count++
+	s.sumNs += latency.Nanoseconds()
+	s.maxNs = max(s.maxNs, latency.Nanoseconds())
+	s.statuses[status]++
+	s.mu.Unlock()
+}
+
+// quantile 由直方图线性插值估算分位数（毫秒）；落在 +Inf 桶时返回最大值
+func (s *endpointStats) quantile(q float64) float64 {
+	if s.count == 0 {
+		return 0
+	}
+	rank := q * float64(s.count)
+	var cumulative int64
+	for i, n := range s.buckets {
+		if n == 0 {
+			continue
+		}
+		if float64(cumulative+n) >= rank {
+			if i == len(endpointLatencyBuckets) {
+				return float64(s.maxNs) / 1e6
+			}
+			lower := 0.0
+			if i > 0 {
+				lower = endpointLatencyBuckets[i-1]
+			}
+			upper := endpointLatencyBuckets[i]
+			value := lower + (upper-lower)*(rank-float64(cumulative))/float64(n)
+			return math.Min(value*1000, float64(s.maxNs)/1e6)
+		}
+		cumulative += n
+	}
+	return float64(s.maxNs) / 1e6
+}
+
+// Snapshot 返回所有路由的指标，按请求数降序
+func (m *EndpointMetrics) Snapshot() EndpointMetricsSnapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	endpoints := make([]EndpointStat, 0, len(m.routes))
+	for key, s := range m.routes {
+		stat := EndpointStat{
+			Method:       key.method,
+			Route:        key.route,
+			InFlight:     atomic.LoadInt64(&s.inFlight),
+			StatusCodes:  make(map[string]int64),
+			StatusGroups: make(map[string]int64),
+		}
+
+		s.mu.Lock()
+		stat.Requests = s.count
+		for code, n := range s.statuses {
+			stat.StatusCodes[strconv.Itoa(code)] = n
+			stat.StatusGroups[fmt.Sprintf("%dxx", code/100)] += n
+			if code >= 500 {
+				stat.Errors += n
+			}
+		}
+		if s.count > 0 {
+			stat.ErrorRate = float64(stat.Errors) / float64(s.count)
+			stat.AvgLatencyMs = float64(s.sumNs) / float64(s.count) / 1e6
+		}
+		stat.MaxLatencyMs = float64(s.maxNs) / 1e6
+		stat.P50Ms = s.quantile(0.5)
+		stat.P95Ms = s.quantile(0.95)
+		stat.P99Ms = s.quantile(0.99)
+		s.mu.Unlock()
+
+		endpoints = append(endpoints, stat)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].Requests != endpoints[j].Requests {
+			return endpoints[i].Requests > endpoints[j].Requests
+		}
+		if endpoints[i].Route != endpoints[j].Route {
+			return endpoints[i].Route < endpoints[j].Route
+		}
+		return endpoints[i].Method < endpoints[j].Method
+	})
+
+	return EndpointMetricsSnapshot{
+		InFlight:      atomic.LoadInt64(&m.inFlight),
+		UptimeSeconds: int64(time.Since(m.started).Seconds()),
+		Endpoints:     endpoints,
+	}
+}
+
+// WritePrometheus 以 Prometheus 文本格式（text/plain; version=0.0.4）输出接口指标
+func (m *EndpointMetrics) WritePrometheus(w io.Writer) error {
+	m.mu.RLock()
+	keys := make([]endpointKey, 0, len(m.routes))
+	for key := range m.routes {
+		keys = append(keys, key)
+	}
+	m.mu.RUnlock()
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].method < keys[j].method
+	})
+
+	var b strings.Builder
+	b.WriteString("# HELP seo_http_requests_in_flight Requests currently being served per route.\n")
+	b.WriteString("# TYPE seo_http_requests_in_flight gauge\n")
+	for _, key := range keys {
+		s := m.lookup(key)
+		fmt.Fprintf(&b, "seo_http_requests_in_flight{%s} %d\n", promLabels(key), atomic.LoadInt64(&s.inFlight))
+	}
+
+	b.WriteString("# HELP seo_http_requests_total Requests served per route and status code.\n")
+	b.WriteString("# TYPE seo_http_requests_total counter\n")
+	for _, key := range keys {
+		s := m.lookup(key)
+		s.mu.Lock()
+		codes := make([]int, 0, len(s.statuses))
+		for code := range s.statuses {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Fprintf(&b, "seo_http_requests_total{%s,code=\"%d\"} %d\n", promLabels(key), code, s.statuses[code])
+		}
+		s.mu.Unlock()
+	}
+
+	b.WriteString("# HELP seo_http_request_duration_seconds Request latency per route.\n")
+	b.WriteString("# TYPE seo_http_request_duration_seconds histogram\n")
+	for _, key := range keys {
+		s := m.lookup(key)
+		labels := promLabels(key)
+		s.mu.Lock()
+		var cumulative int64
+		for i, upper := range endpointLatencyBuckets {
+			cumulative += s.buckets[i]
+			fmt.Fprintf(&b, "seo_http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(upper, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&b, "seo_http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, s.count)
+		fmt.Fprintf(&b, "seo_http_request_duration_seconds_sum{%s} %s\n",
+			labels, strconv.FormatFloat(float64(s.sumNs)/1e9, 'g', -1, 64))
+		fmt.Fprintf(&b, "seo_http_request_duration_seconds_count{%s} %d\n", labels, s.count)
+		s.mu.Unlock()
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// lookup 获取已存在的路由指标（路由只增不删）
+func (m *EndpointMetrics) lookup(key endpointKey) *endpointStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.routes[key]
+}
+
+// promLabels 输出 method/route 标签，转义反斜杠、引号和换行
+func promLabels(key endpointKey) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return fmt.Sprintf(`method="%s",route="%s"`, escape.Replace(key.method), escape.Replace(key.route))
+}
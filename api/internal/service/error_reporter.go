@@ -0,0 +1,459 @@
+// Package core provides panic and 5xx error reporting to Sentry-compatible endpoints or webhooks
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	mrand "math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// ErrorEvent 上报的错误事件（Sentry store 格式，webhook 使用同一结构）
+type ErrorEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Level       string            `json:"level"`
+	Logger      string            `json:"logger"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Exception   *eventExceptions  `json:"exception,omitempty"`
+	Request     *eventRequest     `json:"request,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+	Fingerprint []string          `json:"fingerprint,omitempty"`
+}
+
+type eventExceptions struct {
+	Values []eventException `json:"values"`
+}
+
+type eventException struct {
+	Type       string           `json:"type"`
+	Value      string           `json:"value"`
+	Stacktrace *eventStacktrace `json:"stacktrace,omitempty"`
+}
+
+type eventStacktrace struct {
+	Frames []eventFrame `json:"frames"`
+}
+
+type eventFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type eventRequest struct {
+	URL         string            `json:"url"`
+	Method      string            `json:"method"`
+	QueryString string            `json:"query_string,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// sentryDSN 解析后的 DSN
+type sentryDSN struct {
+	storeURL  string
+	publicKey string
+}
+
+// parseSentryDSN 解析 https://<key>@host[/path]/<project_id>
+func parseSentryDSN(dsn string) (*sentryDSN, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("dsn missing public key")
+	}
+	path := strings.Trim(u.Path, "/")
+	idx := strings.LastIndex(path, "/")
+	projectID, prefix := path, ""
+	if idx >= 0 {
+		projectID, prefix = path[idx+1:], "/"+path[:idx]
+	}
+	if projectID == "" {
+		return nil, fmt.Errorf("dsn missing project id")
+	}
+	return &sentryDSN{
+		storeURL:  fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, projectID),
+		publicKey: u.User.Username(),
+	}, nil
+}
+
+// ErrorReporter 错误上报
+// panic 和 5xx 响应按采样率进入队列，由后台 goroutine 发送到 Sentry 和/或 webhook；
+// 相同指纹的错误在去重窗口内只发送一次，下一次发送时附带期间被抑制的次数
+type ErrorReporter struct {
+	cfg        config.ErrorReportingConfig
+	dsn        *sentryDSN
+	release    string
+	serverName string
+	client     *http.Client
+
+	rngMu sync.Mutex
+	rng   *mrand.Rand
+
+	dedupMu sync.Mutex
+	dedup   map[string]*dedupEntry
+
+	queue chan *ErrorEvent
+	wg    sync.WaitGroup
+}
+
+type dedupEntry struct {
+	lastSent   time.Time
+	suppressed int
+}
+
+// NewErrorReporter 创建错误上报，未启用或未配置目标时返回 nil
+func NewErrorReporter(cfg config.ErrorReportingConfig) (*ErrorReporter, error) {
+	if !cfg.Enabled || (cfg.DSN == "" && cfg.WebhookURL == "") {
+		return nil, nil
+	}
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	r := &ErrorReporter{
+		cfg:     cfg,
+		release: cfg.Release,
+		client:  GetHTTPClient().Client(EgressPurposeErrorReport, timeout),
+		rng:     mrand.New(mrand.NewSource(time.Now().UnixNano())),
+		dedup:   make(map[string]*dedupEntry),
+	}
+	if cfg.DSN != "" {
+		dsn, err := parseSentryDSN(cfg.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("invalid error_reporting.dsn: %w", err)
+		}
+		r.dsn = dsn
+	}
+	if r.release == "" {
+		r.release = buildRelease()
+	}
+	r.serverName, _ = os.Hostname()
+
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = 100
+	}
+	r.queue = make(chan *ErrorEvent, queueSize)
+	r.wg.Add(1)
+	go r.sendLoop()
+
+	log.Info().
+		Bool("sentry", r.dsn != nil).
+		Bool("webhook", cfg.WebhookURL != "").
+		Str("release", r.release).
+		Str("environment", cfg.Environment).
+		Msg("Error reporting enabled")
+	return r, nil
+}
+
+// buildRelease 从构建信息中取 VCS 版本
+func buildRelease() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	var revision, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+	if revision == "" {
+		return info.Main.Version
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified == "true" {
+		revision += "-dirty"
+	}
+	return revision
+}
+
+// Close 发送队列中剩余的事件后退出
+func (r *ErrorReporter) Close() {
+	if r == nil {
+		return
+	}
+	close(r.queue)
+	r.wg.Wait()
+}
+
+func (r *ErrorReporter) sampled(rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	r.rngMu.Lock()
+	v := r.rng.Float64()
+	r.rngMu.Unlock()
+	return v < rate
+}
+
+// Middleware 捕获 panic 和 5xx 响应，需注册在 Recovery 之后（panic 上报后继续抛出，由 Recovery 记录日志并返回响应）
+func (r *ErrorReporter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec != http.ErrAbortHandler && r.sampled(r.cfg.PanicSampleRate) {
+					r.enqueue(r.panicEvent(c, rec, captureFrames(3)))
+				}
+				panic(rec)
+			}
+		}()
+
+		c.Next()
+
+		if status := c.Writer.Status(); status >= 500 && r.sampled(r.cfg.ErrorSampleRate) {
+			r.enqueue(r.httpErrorEvent(c, status))
+		}
+	}
+}
+
+// panicEvent 构造 panic 事件
+func (r *ErrorReporter) panicEvent(c *gin.Context, rec any, frames []eventFrame) *ErrorEvent {
+	ev := r.newEvent(c, "fatal")
+	value := fmt.Sprint(rec)
+	typ := "panic"
+	if err, ok := rec.(error); ok {
+		typ = fmt.Sprintf("%T", err)
+	}
+	ev.Exception = &eventExceptions{Values: []eventException{{
+		Type:       typ,
+		Value:      value,
+		Stacktrace: &eventStacktrace{Frames: frames},
+	}}}
+	ev.Fingerprint = []string{"panic", c.FullPath(), culpritFrame(frames)}
+	return ev
+}
+
+// httpErrorEvent 构造 5xx 事件，附带 gin 上下文中记录的错误
+func (r *ErrorReporter) httpErrorEvent(c *gin.Context, status int) *ErrorEvent {
+	ev := r.newEvent(c, "error")
+	value := http.StatusText(status)
+	if len(c.Errors) > 0 {
+		value = c.Errors.String()
+	}
+	ev.Exception = &eventExceptions{Values: []eventException{{
+		Type:  fmt.Sprintf("HTTP %d", status),
+		Value: value,
+	}}}
+	ev.Tags["status"] = fmt.Sprint(status)
+	ev.Fingerprint = []string{"http", fmt.Sprint(status), c.Request.Method, c.FullPath()}
+	return ev
+}
+
+func (r *ErrorReporter) newEvent(c *gin.Context, level string) *ErrorEvent {
+	ev := &ErrorEvent{
+		EventID:     newEventID(),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Platform:    "go",
+		Level:       level,
+		Logger:      "seo-generator",
+		ServerName:  r.serverName,
+		Release:     r.release,
+		Environment: r.cfg.Environment,
+		Tags:        map[string]string{},
+		Extra:       map[string]any{},
+	}
+	if c == nil || c.Request == nil {
+		return ev
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	ev.Request = &eventRequest{
+		URL:         scheme + "://" + c.Request.Host + c.Request.URL.Path,
+		Method:      c.Request.Method,
+		QueryString: c.Request.URL.RawQuery,
+		Headers: map[string]string{
+			"User-Agent": c.Request.UserAgent(),
+			"Referer":    c.Request.Referer(),
+		},
+	}
+	if id := getRequestIDFromContext(c); id != "" {
+		ev.Tags["request_id"] = id
+	}
+	if route := c.FullPath(); route != "" {
+		ev.Tags["route"] = route
+	}
+	ev.Extra["client_ip"] = c.ClientIP()
+	return ev
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%032x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// captureFrames 返回调用栈（Sentry 要求最旧的帧在前），跳过 runtime 内部帧
+func captureFrames(skip int) []eventFrame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var out []eventFrame
+	for {
+		frame, more := frames.Next()
+		if !strings.Contains(frame.File, "runtime/") {
+			module, function := splitFunctionName(frame.Function)
+			out = append(out, eventFrame{
+				Function: function,
+				Module:   module,
+				AbsPath:  frame.File,
+				Lineno:   frame.Line,
+				InApp:    strings.HasPrefix(frame.Function, "seo-generator/"),
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// splitFunctionName 将 seo-generator/api/internal/service.(*X).Y 拆为包路径和函数名
+func splitFunctionName(name string) (string, string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	return name[:slash+1+dot], name[slash+2+dot:]
+}
+
+// culpritFrame 最内层的项目代码帧，用于指纹
+func culpritFrame(frames []eventFrame) string {
+	for i := len(frames) - 1; i >= 0; i-- {
+		if frames[i].InApp {
+			return frames[i].Module + "." + frames[i].Function
+		}
+	}
+	return ""
+}
+
+// enqueue 去重后放入发送队列，队列满时丢弃
+func (r *ErrorReporter) enqueue(ev *ErrorEvent) {
+	if window := time.Duration(r.cfg.DedupWindowSeconds) * time.Second; window > 0 {
+		key := strings.Join(ev.Fingerprint, "|")
+		now := time.Now()
+
+		r.dedupMu.Lock()
+		entry := r.dedup[key]
+		if entry != nil && now.Sub(entry.lastSent) < window {
+			entry.suppressed++
+			r.dedupMu.Unlock()
+			return
+		}
+		if entry == nil {
+			entry = &dedupEntry{}
+			r.dedup[key] = entry
+		}
+		if entry.suppressed > 0 {
+			ev.Extra["suppressed_since_last"] = entry.suppressed
+		}
+		entry.lastSent, entry.suppressed = now, 0
+		// 清理过期指纹，避免路由/位置组合过多时无限增长
+		if len(r.dedup) > 1000 {
+			for k, e := range r.dedup {
+				if now.Sub(e.lastSent) >= window {
+					delete(r.dedup, k)
+				}
+			}
+		}
+		r.dedupMu.Unlock()
+	}
+
+	select {
+	case r.queue <- ev:
+	default:
+		log.Warn().Str("event_id", ev.EventID).Msg("Error reporting queue full, event dropped")
+	}
+}
+
+func (r *ErrorReporter) sendLoop() {
+	defer r.wg.Done()
+	for ev := range r.queue {
+		body, err := json.Marshal(ev)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to encode error event")
+			continue
+		}
+		if r.dsn != nil {
+			if err := r.post(r.dsn.storeURL, body, r.sentryAuth()); err != nil {
+				log.Warn().Err(err).Str("event_id", ev.EventID).Msg("Failed to send error event to Sentry")
+			}
+		}
+		if r.cfg.WebhookURL != "" {
+			if err := r.post(r.cfg.WebhookURL, body, ""); err != nil {
+				log.Warn().Err(err).Str("event_id", ev.EventID).Msg("Failed to send error event to webhook")
+			}
+		}
+	}
+}
+
+func (r *ErrorReporter) sentryAuth() string {
+	return fmt.Sprintf("Sentry sentry_version=7, sentry_timestamp=%d, sentry_key=%s, sentry_client=seo-generator/1.0",
+		time.Now().Unix(), r.dsn.publicKey)
+}
+
+func (r *ErrorReporter) post(target string, body []byte, auth string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.client.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth != "" {
+		req.Header.Set("X-Sentry-Auth", auth)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -23,6 +23,8 @@ const (
 	ErrInternalServer  ErrorCode = 1007
 	ErrTimeout         ErrorCode = 1008
 	ErrValidation      ErrorCode = 1009
+	ErrConflict        ErrorCode = 1010
+	ErrPayloadTooLarge ErrorCode = 1011
 
 	// Database errors (2000-2999)
 	ErrDBConnection ErrorCode = 2000
@@ -100,6 +102,8 @@ var errorMessages = map[ErrorCode]string{
 	ErrInternalServer:  "服务器内部错误",
 	ErrTimeout:         "请求超时",
 	ErrValidation:      "数据验证失败",
+	ErrConflict:        "数据已被他人修改",
+	ErrPayloadTooLarge: "请求体过大",
 
 	// Database errors
 	ErrDBConnection: "数据库连接失败",
@@ -177,6 +181,8 @@ var errorHTTPStatus = map[ErrorCode]int{
 	ErrInternalServer:  http.StatusInternalServerError,
 	ErrTimeout:         http.StatusGatewayTimeout,
 	ErrValidation:      http.StatusUnprocessableEntity,
+	ErrConflict:        http.StatusConflict,
+	ErrPayloadTooLarge: http.StatusRequestEntityTooLarge,
 
 	// Database errors
 	ErrDBConnection: http.StatusServiceUnavailable,
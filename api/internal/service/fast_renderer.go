@@ -2,9 +2,12 @@ package core
 
 import (
 	"bytes"
+	"fmt"
 	"html/template"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // 全局对象池 - 复用 bytes.Buffer
@@ -28,6 +31,11 @@ const (
 	PlaceholderContent
 	PlaceholderTitle          // Title 动态占位符
 	PlaceholderArticleContent // ArticleContent 动态占位符
+	PlaceholderInternalLink   // 站内链接 <a> 标签
+	PlaceholderPinyinSlug     // 拼音 slug，Arg 为来源（随机关键词/标题）或静态文本
+	PlaceholderPublishDate    // 模拟发布时间，Arg 为 Go 时间格式
+	PlaceholderFunc           // 注册的模板函数，Func 为函数，Args 为字面量参数
+	PlaceholderClsName        // 页面内一致的类名别名（cls_name），Arg 为逻辑类名
 )
 
 // Placeholder 占位符信息
@@ -36,6 +44,8 @@ type Placeholder struct {
 	Type   PlaceholderType // 类型
 	Arg    string          // 参数，如 cls("header") 中的 "header"
 	MinMax [2]int          // 用于 random_number
+	Func   *TemplateFunc   // 用于注册的模板函数
+	Args   []string        // 注册的模板函数参数
 }
 
 // CompiledFastTemplate 预编译的快速模板
@@ -71,8 +81,11 @@ func (r *FastRenderer) Render(cacheKey string, data *RenderData) (string, bool)
 		return "", false
 	}
 
-	ct := cached.(*CompiledFastTemplate)
+	return r.RenderCompiled(cached.(*CompiledFastTemplate), data), true
+}
 
+// RenderCompiled 使用已编译的快速模板渲染
+func (r *FastRenderer) RenderCompiled(ct *CompiledFastTemplate, data *RenderData) string {
 	// 请求级缓存：NowFunc 只计算一次，避免 ~1200 次重复调用
 	if data != nil && data.Now == "" {
 		data.Now = NowFunc()
@@ -94,7 +107,7 @@ func (r *FastRenderer) Render(cacheKey string, data *RenderData) (string, bool)
 	result := buf.String()
 	bufferPool.Put(buf)
 
-	return result, true
+	return result
 }
 
 // getValue 获取占位符对应的实际值
@@ -106,9 +119,52 @@ func (r *FastRenderer) getValue(p Placeholder, data *RenderData) string {
 func resolvePlaceholder(p Placeholder, data *RenderData, fm *TemplateFuncsManager) string {
 	switch p.Type {
 	case PlaceholderCls:
+		// 有名称的 cls() 带上页面内一致的别名，<style> 中用 cls_name() 引用
+		if data != nil && data.classAliases != nil && p.Arg != "" {
+			return fm.ClsWithAlias(p.Arg, data.classAliases.Alias(p.Arg))
+		}
 		return fm.Cls(p.Arg)
+	case PlaceholderClsName:
+		if data != nil && data.classAliases != nil {
+			return data.classAliases.Alias(p.Arg)
+		}
+		return p.Arg
 	case PlaceholderURL:
+		if data != nil && data.URLGenerator != nil {
+			return data.URLGenerator()
+		}
 		return fm.RandomURL()
+	case PlaceholderPinyinSlug:
+		switch p.Arg {
+		case slugSourceKeyword:
+			if data != nil {
+				return fm.RandomKeywordSlug(data.KeywordGroupID)
+			}
+			return fm.RandomKeywordSlug(1)
+		case slugSourceTitle:
+			if data != nil && data.TitleGenerator != nil {
+				return fm.PinyinSlug(data.TitleGenerator())
+			}
+			if data != nil {
+				return fm.PinyinSlug(data.Title)
+			}
+			return ""
+		default:
+			return fm.PinyinSlug(p.Arg)
+		}
+	case PlaceholderPublishDate:
+		if data != nil && !data.PublishDate.IsZero() {
+			return data.PublishDate.Format(p.Arg)
+		}
+		return time.Now().Format(p.Arg)
+	case PlaceholderInternalLink:
+		if data != nil && data.InternalLink != nil {
+			return data.InternalLink()
+		}
+		if data != nil {
+			return internalLinkHTML(fm.RandomURL(), fm.RandomKeyword(data.KeywordGroupID))
+		}
+		return internalLinkHTML(fm.RandomURL(), fm.RandomKeyword(1))
 	case PlaceholderKeyword:
 		if data != nil {
 			return fm.RandomKeyword(data.KeywordGroupID)
@@ -149,6 +205,11 @@ func resolvePlaceholder(p Placeholder, data *RenderData, fm *TemplateFuncsManage
 			return string(data.ArticleContent)
 		}
 		return ""
+	case PlaceholderFunc:
+		if p.Func != nil {
+			return p.Func.Impl(fm, data, p.Args)
+		}
+		return ""
 	default:
 		return ""
 	}
@@ -227,10 +288,17 @@ type MarkerContext struct {
 	titleCounter          int64 // Title 占位符计数器
 	contentCounter        int64 // Content 占位符计数器
 	articleContentCounter int64 // ArticleContent 占位符计数器
+	internalLinkCounter   int64 // 站内链接占位符计数器
+	pinyinSlugCounter     int64 // 拼音 slug 占位符计数器
+	publishDateCounter    int64 // 发布时间占位符计数器
+	funcCounter           int64 // 注册函数占位符计数器
 
 	// 收集的占位符
 	placeholders []Placeholder
 	mu           sync.Mutex
+
+	// 本次渲染预算，每个占位符计一次函数调用
+	guard *renderGuard
 }
 
 // NewMarkerContext 创建标记上下文
@@ -246,6 +314,12 @@ func NewMarkerContext(data *RenderData, content string) *MarkerContext {
 	}
 }
 
+// pinyinSlug 占位符的动态来源（Arg 取值），其他 Arg 为静态文本
+const (
+	slugSourceKeyword = "\x00keyword"
+	slugSourceTitle   = "\x00title"
+)
+
 // GetPlaceholders 获取收集的占位符列表
 func (c *MarkerContext) GetPlaceholders() []Placeholder {
 	c.mu.Lock()
@@ -255,6 +329,11 @@ func (c *MarkerContext) GetPlaceholders() []Placeholder {
 
 // addPlaceholder 添加占位符（线程安全）
 func (c *MarkerContext) addPlaceholder(p Placeholder) {
+	construct := placeholderConstructs[p.Type]
+	if p.Func != nil {
+		construct = p.Func.Name
+	}
+	c.guard.call(construct)
 	c.mu.Lock()
 	c.placeholders = append(c.placeholders, p)
 	c.mu.Unlock()
@@ -316,6 +395,18 @@ func (c *MarkerContext) Cls(name string) string {
 	return token
 }
 
+// ClsName 返回类名别名占位符（页面内与 cls() 的别名一致，用于 <style> 选择器）
+func (c *MarkerContext) ClsName(name string) string {
+	idx := atomic.AddInt64(&c.clsCounter, 1) - 1
+	token := "__PH_CLS_" + formatInt(int(idx)) + "__"
+	c.addPlaceholder(Placeholder{
+		Token: token,
+		Type:  PlaceholderClsName,
+		Arg:   name,
+	})
+	return token
+}
+
 // Encode 编码文本（直接返回，不需要动态替换）
 func (c *MarkerContext) Encode(text string) template.HTML {
 	// Encode 是静态的，直接使用全局 encoder
@@ -377,3 +468,85 @@ func (c *MarkerContext) ArticleContent() template.HTML {
 	})
 	return template.HTML(token)
 }
+
+// InternalLink 返回站内链接占位符标记（动态生成）
+func (c *MarkerContext) InternalLink() template.HTML {
+	idx := atomic.AddInt64(&c.internalLinkCounter, 1) - 1
+	token := "__PH_LINK_" + formatInt(int(idx)) + "__"
+	c.addPlaceholder(Placeholder{
+		Token: token,
+		Type:  PlaceholderInternalLink,
+	})
+	return template.HTML(token)
+}
+
+// PinyinSlug 返回拼音 slug 占位符标记
+// 参数是 RandomKeyword / Title 返回的占位符时，撤销该占位符并改为对应来源的 slug（输出中不再出现原占位符）；
+// 其他参数按静态文本处理
+func (c *MarkerContext) PinyinSlug(v interface{}) string {
+	text := fmt.Sprint(v)
+	arg := text
+	switch {
+	case strings.HasPrefix(text, "__PH_KW_") && c.removePlaceholder(text):
+		arg = slugSourceKeyword
+	case strings.HasPrefix(text, "__PH_TITLE_") && c.removePlaceholder(text):
+		arg = slugSourceTitle
+	}
+	idx := atomic.AddInt64(&c.pinyinSlugCounter, 1) - 1
+	token := "__PH_SLUG_" + formatInt(int(idx)) + "__"
+	c.addPlaceholder(Placeholder{
+		Token: token,
+		Type:  PlaceholderPinyinSlug,
+		Arg:   arg,
+	})
+	return token
+}
+
+// removePlaceholder 撤销最近添加的指定占位符（被其他函数作为参数消费时调用）
+func (c *MarkerContext) removePlaceholder(token string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := len(c.placeholders) - 1; i >= 0; i-- {
+		if c.placeholders[i].Token == token {
+			c.placeholders = append(c.placeholders[:i], c.placeholders[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// PublishDate 返回发布时间占位符标记，format 支持 Go 格式和 strftime
+func (c *MarkerContext) PublishDate(format string) string {
+	idx := atomic.AddInt64(&c.publishDateCounter, 1) - 1
+	token := "__PH_PUBDATE_" + formatInt(int(idx)) + "__"
+	c.addPlaceholder(Placeholder{
+		Token: token,
+		Type:  PlaceholderPublishDate,
+		Arg:   DateLayout(format),
+	})
+	return token
+}
+
+// Call 注册的模板函数，返回占位符标记
+// 由 {{ name('arg') }} 转换而来，函数未启用、参数个数不符或参数不是字面量时返回错误
+func (c *MarkerContext) Call(name string, args ...string) (template.HTML, error) {
+	fn, ok := LookupTemplateFunc(name)
+	if !ok {
+		return "", fmt.Errorf("template function %s is not enabled", name)
+	}
+	if len(args) == 1 && strings.HasPrefix(args[0], templateFuncBadArgs) {
+		return "", fmt.Errorf("template function %s: arguments must be string or number literals, got (%s)", name, args[0][len(templateFuncBadArgs):])
+	}
+	if len(args) != fn.Arity {
+		return "", fmt.Errorf("template function %s expects %d arguments, got %d", name, fn.Arity, len(args))
+	}
+	idx := atomic.AddInt64(&c.funcCounter, 1) - 1
+	token := "__PH_FN_" + formatInt(int(idx)) + "__"
+	c.addPlaceholder(Placeholder{
+		Token: token,
+		Type:  PlaceholderFunc,
+		Func:  fn,
+		Args:  args,
+	})
+	return template.HTML(token), nil
+}
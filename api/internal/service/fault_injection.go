@@ -0,0 +1,218 @@
+// Package core provides opt-in fault injection for load testing (debug mode only)
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 故障注入目标
+const (
+	FaultTargetDB       = "db"
+	FaultTargetRedis    = "redis"
+	FaultTargetTemplate = "template"
+)
+
+// FaultTargets 支持的故障注入目标
+var FaultTargets = []string{FaultTargetDB, FaultTargetRedis, FaultTargetTemplate}
+
+// ErrFaultInjected 注入的错误
+var ErrFaultInjected = errors.New("fault injected")
+
+// ErrFaultInjectionDisabled 故障注入未启用（非调试模式）
+var ErrFaultInjectionDisabled = errors.New("fault injection is only available in debug mode")
+
+// FaultRule 单个目标的故障规则
+type FaultRule struct {
+	Target    string     `json:"target"`
+	LatencyMs int        `json:"latency_ms"` // 每次调用增加的延迟
+	JitterMs  int        `json:"jitter_ms"`  // 延迟随机增加 0 ~ jitter_ms
+	ErrorRate float64    `json:"error_rate"` // 0 ~ 1，按该概率返回 ErrFaultInjected
+	ExpiresAt *time.Time `json:"expires_at"` // 到期自动失效，为空时直到清除
+
+	Delayed int64 `json:"delayed"` // 已注入延迟的次数
+	Failed  int64 `json:"failed"`  // 已注入错误的次数
+}
+
+// FaultInjector 故障注入
+// 只在调试模式（server.debug）下启用，用于压测时模拟数据库、Redis 变慢或出错以及模板渲染失败，
+// 验证熔断、模板降级和缓存兜底等行为。规则只保存在内存中，重启后清空
+type FaultInjector struct {
+	enabled atomic.Bool
+	active  atomic.Bool // 有生效的规则（调用路径上的快速判断）
+
+	mu    sync.RWMutex
+	rules map[string]*FaultRule
+}
+
+// 全局故障注入实例
+var globalFaultInjector = &FaultInjector{rules: make(map[string]*FaultRule)}
+
+// GetFaultInjector 获取全局故障注入实例
+func GetFaultInjector() *FaultInjector {
+	return globalFaultInjector
+}
+
+// Enable 启用故障注入（启动时按调试模式设置）
+func (f *FaultInjector) Enable() {
+	f.enabled.Store(true)
+}
+
+// Enabled 是否已启用
+func (f *FaultInjector) Enabled() bool {
+	return f.enabled.Load()
+}
+
+// ValidFaultTarget 是否为支持的目标
+func ValidFaultTarget(target string) bool {
+	for _, t := range FaultTargets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Set 设置目标的故障规则（覆盖原规则，计数清零）
+func (f *FaultInjector) Set(rule FaultRule) error {
+	if !f.Enabled() {
+		return ErrFaultInjectionDisabled
+	}
+	if !ValidFaultTarget(rule.Target) {
+		return fmt.Errorf("unknown target: %s", rule.Target)
+	}
+	if rule.LatencyMs < 0 || rule.JitterMs < 0 {
+		return errors.New("latency_ms and jitter_ms must not be negative")
+	}
+	if rule.ErrorRate < 0 || rule.ErrorRate > 1 {
+		return errors.New("error_rate must be between 0 and 1")
+	}
+
+	rule.Delayed, rule.Failed = 0, 0
+	f.mu.Lock()
+	f.rules[rule.Target] = &rule
+	f.active.Store(true)
+	f.mu.Unlock()
+	return nil
+}
+
+// Clear 清除目标的规则，target 为空时清除全部
+func (f *FaultInjector) Clear(target string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if target == "" {
+		f.rules = make(map[string]*FaultRule)
+	} else {
+		delete(f.rules, target)
+	}
+	f.active.Store(len(f.rules) > 0)
+}
+
+// Rules 当前规则（已过期的规则不返回）
+func (f *FaultInjector) Rules() []FaultRule {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	now := time.Now()
+	rules := make([]FaultRule, 0, len(f.rules))
+	for _, rule := range f.rules {
+		if rule.ExpiresAt != nil && now.After(*rule.ExpiresAt) {
+			continue
+		}
+		rules = append(rules, FaultRule{
+			Target:    rule.Target,
+			LatencyMs: rule.LatencyMs,
+			JitterMs:  rule.JitterMs,
+			ErrorRate: rule.ErrorRate,
+			ExpiresAt: rule.ExpiresAt,
+			Delayed:   atomic.LoadInt64(&rule.Delayed),
+			Failed:    atomic.LoadInt64(&rule.Failed),
+		})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Target < rules[j].Target })
+	return rules
+}
+
+// Inject 按目标规则注入延迟和错误；未启用或没有规则时立即返回 nil
+// 延迟期间 ctx 取消时返回 ctx.Err()
+func (f *FaultInjector) Inject(ctx context.Context, target string) error {
+	if !f.active.Load() || !f.enabled.Load() {
+		return nil
+	}
+	f.mu.RLock()
+	rule := f.rules[target]
+	f.mu.RUnlock()
+	if rule == nil {
+		return nil
+	}
+	if rule.ExpiresAt != nil && time.Now().After(*rule.ExpiresAt) {
+		return nil
+	}
+
+	delay := time.Duration(rule.LatencyMs) * time.Millisecond
+	if rule.JitterMs > 0 {
+		delay += time.Duration(rand.Intn(rule.JitterMs+1)) * time.Millisecond
+	}
+	if delay > 0 {
+		atomic.AddInt64(&rule.Delayed, 1)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if rule.ErrorRate > 0 && rand.Float64() < rule.ErrorRate {
+		atomic.AddInt64(&rule.Failed, 1)
+		return fmt.Errorf("%s: %w", target, ErrFaultInjected)
+	}
+	return nil
+}
+
+// DBHook 数据库查询钩子（repository.SetQueryHook）
+func (f *FaultInjector) DBHook(ctx context.Context) error {
+	return f.Inject(ctx, FaultTargetDB)
+}
+
+// RedisHook 返回 go-redis 钩子，每条命令（流水线按一次）执行前注入故障
+func (f *FaultInjector) RedisHook() redis.Hook {
+	return faultRedisHook{f: f}
+}
+
+type faultRedisHook struct {
+	f *FaultInjector
+}
+
+func (h faultRedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h faultRedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.f.Inject(ctx, FaultTargetRedis); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h faultRedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.f.Inject(ctx, FaultTargetRedis); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		return next(ctx, cmds)
+	}
+}
@@ -0,0 +1,308 @@
+// Package core provides feature flags with site-group overrides and percentage rollouts
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// 内置的实验开关，未在 feature_flags 表中创建时视为关闭
+const (
+	FlagDeterministicRender  = "deterministic_render"   // 同一 URL 渲染结果确定
+	FlagNewEncoder           = "new_encoder"            // 新版 HTML 实体编码
+	FlagStaleWhileRevalidate = "stale_while_revalidate" // 缓存过期后先返回旧页面再后台刷新
+	FlagAutoTDK              = "auto_tdk"               // 模板缺少 title/description/keywords 时自动补全
+	FlagCSSObfuscation       = "css_obfuscation"        // 渲染后混淆页面 CSS（改名、打乱、干扰规则）
+)
+
+// featureFlagsChannel 开关变更后通知其他实例重新加载
+const featureFlagsChannel = "feature_flags:reload"
+
+// featureFlagsRefreshInterval 未启用 Redis 时的定期刷新间隔
+const featureFlagsRefreshInterval = 60 * time.Second
+
+// ErrFeatureFlagNotFound 开关不存在
+var ErrFeatureFlagNotFound = errors.New("feature flag not found")
+
+// FeatureFlag 功能开关
+// 求值顺序：站群覆盖 > 全局开关 > 灰度百分比（按 flag_key + 分桶键哈希，同一域名结果稳定）
+type FeatureFlag struct {
+	ID                 int          `db:"id" json:"id"`
+	Key                string       `db:"flag_key" json:"key"`
+	Description        string       `db:"description" json:"description"`
+	Enabled            bool         `db:"enabled" json:"enabled"`
+	RolloutPercent     int          `db:"rollout_percent" json:"rollout_percent"`
+	SiteGroupOverrides map[int]bool `db:"-" json:"site_group_overrides"`
+	UpdatedBy          string       `db:"updated_by" json:"updated_by"`
+	UpdatedAt          time.Time    `db:"updated_at" json:"updated_at"`
+
+	OverridesJSON sql.NullString `db:"site_group_overrides" json:"-"`
+}
+
+// Evaluate 计算开关对站群和分桶键（通常为域名）是否生效
+func (f *FeatureFlag) Evaluate(siteGroupID int, unit string) bool {
+	if v, ok := f.SiteGroupOverrides[siteGroupID]; ok {
+		return v
+	}
+	if !f.Enabled {
+		return false
+	}
+	if f.RolloutPercent >= 100 {
+		return true
+	}
+	if f.RolloutPercent <= 0 {
+		return false
+	}
+	return rolloutBucket(f.Key, unit) < f.RolloutPercent
+}
+
+// rolloutBucket 返回 0-99 的稳定分桶
+func rolloutBucket(key, unit string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	h.Write([]byte{':'})
+	h.Write([]byte(unit))
+	return int(h.Sum32() % 100)
+}
+
+// FeatureFlags 功能开关服务
+// 开关存储在 feature_flags 表，内存快照无锁读取；修改后通过 Redis pub/sub 通知所有实例重新加载
+type FeatureFlags struct {
+	db    *sqlx.DB
+	redis *redis.Client
+
+	flags atomic.Pointer[map[string]*FeatureFlag]
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var globalFeatureFlags atomic.Pointer[FeatureFlags]
+
+// GetFeatureFlags 返回全局功能开关服务（未初始化时为 nil）
+func GetFeatureFlags() *FeatureFlags {
+	return globalFeatureFlags.Load()
+}
+
+// FeatureEnabled 使用全局服务求值，未初始化时返回 false
+func FeatureEnabled(key string, siteGroupID int, unit string) bool {
+	return GetFeatureFlags().Enabled(key, siteGroupID, unit)
+}
+
+// NewFeatureFlags 创建功能开关服务并设为全局实例
+func NewFeatureFlags(db *sqlx.DB, rdb *redis.Client) *FeatureFlags {
+	f := &FeatureFlags{db: db, redis: rdb}
+	empty := map[string]*FeatureFlag{}
+	f.flags.Store(&empty)
+	globalFeatureFlags.Store(f)
+	return f
+}
+
+// Start 加载开关并监听变更通知（无 Redis 时定期刷新）
+func (f *FeatureFlags) Start(ctx context.Context) error {
+	f.ctx, f.cancel = context.WithCancel(ctx)
+	if err := f.Reload(f.ctx); err != nil {
+		return err
+	}
+
+	f.wg.Add(1)
+	if f.redis != nil {
+		go f.listen()
+	} else {
+		go f.refreshLoop()
+	}
+	return nil
+}
+
+// Stop 停止监听
+func (f *FeatureFlags) Stop() {
+	if f.cancel != nil {
+		f.cancel()
+	}
+	f.wg.Wait()
+}
+
+func (f *FeatureFlags) listen() {
+	defer f.wg.Done()
+	pubsub := f.redis.Subscribe(f.ctx, featureFlagsChannel)
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case msg := <-ch:
+			if msg == nil {
+				return
+			}
+			if err := f.Reload(f.ctx); err != nil {
+				log.Warn().Err(err).Msg("Failed to reload feature flags")
+			}
+		}
+	}
+}
+
+func (f *FeatureFlags) refreshLoop() {
+	defer f.wg.Done()
+	ticker := time.NewTicker(featureFlagsRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := f.Reload(f.ctx); err != nil {
+				log.Warn().Err(err).Msg("Failed to reload feature flags")
+			}
+		}
+	}
+}
+
+// Reload 从数据库重新加载全部开关
+func (f *FeatureFlags) Reload(ctx context.Context) error {
+	var rows []*FeatureFlag
+	if err := f.db.SelectContext(ctx, &rows, `
+		SELECT id, flag_key, COALESCE(description, '') AS description, enabled, rollout_percent,
+		       site_group_overrides, COALESCE(updated_by, '') AS updated_by, updated_at
+		FROM feature_flags`); err != nil {
+		return err
+	}
+
+	flags := make(map[string]*FeatureFlag, len(rows))
+	for _, flag := range rows {
+		flag.SiteGroupOverrides = map[int]bool{}
+		if flag.OverridesJSON.Valid && flag.OverridesJSON.String != "" {
+			var raw map[string]bool
+			if err := json.Unmarshal([]byte(flag.OverridesJSON.String), &raw); err != nil {
+				log.Warn().Err(err).Str("flag", flag.Key).Msg("Invalid feature flag site group overrides, ignoring")
+			}
+			for k, v := range raw {
+				if id, err := strconv.Atoi(k); err == nil {
+					flag.SiteGroupOverrides[id] = v
+				}
+			}
+		}
+		flags[flag.Key] = flag
+	}
+	f.flags.Store(&flags)
+
+	log.Info().Int("count", len(flags)).Msg("Feature flags loaded")
+	return nil
+}
+
+// Enabled 开关对站群和分桶键是否生效，开关不存在时返回 false
+func (f *FeatureFlags) Enabled(key string, siteGroupID int, unit string) bool {
+	if f == nil {
+		return false
+	}
+	flag, ok := (*f.flags.Load())[key]
+	if !ok {
+		return false
+	}
+	return flag.Evaluate(siteGroupID, unit)
+}
+
+// Get 返回单个开关
+func (f *FeatureFlags) Get(key string) (*FeatureFlag, bool) {
+	flag, ok := (*f.flags.Load())[key]
+	return flag, ok
+}
+
+// List 按 key 排序返回全部开关
+func (f *FeatureFlags) List() []*FeatureFlag {
+	flags := *f.flags.Load()
+	out := make([]*FeatureFlag, 0, len(flags))
+	for _, flag := range flags {
+		out = append(out, flag)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// Save 创建或更新开关，写库后重新加载并通知其他实例
+func (f *FeatureFlags) Save(ctx context.Context, flag *FeatureFlag, updatedBy string) error {
+	if flag.Key == "" || len(flag.Key) > 100 {
+		return fmt.Errorf("invalid flag key")
+	}
+	if flag.RolloutPercent < 0 || flag.RolloutPercent > 100 {
+		return fmt.Errorf("rollout_percent must be between 0 and 100")
+	}
+	overrides, err := encodeSiteGroupOverrides(flag.SiteGroupOverrides)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.db.ExecContext(ctx, `
+		INSERT INTO feature_flags (flag_key, description, enabled, rollout_percent, site_group_overrides, updated_by)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE description = VALUES(description), enabled = VALUES(enabled),
+			rollout_percent = VALUES(rollout_percent), site_group_overrides = VALUES(site_group_overrides),
+			updated_by = VALUES(updated_by)`,
+		flag.Key, flag.Description, flag.Enabled, flag.RolloutPercent, overrides, updatedBy); err != nil {
+		return err
+	}
+
+	LoggerFrom(ctx).Info().
+		Str("flag", flag.Key).
+		Bool("enabled", flag.Enabled).
+		Int("rollout_percent", flag.RolloutPercent).
+		Str("updated_by", updatedBy).
+		Msg("Feature flag updated")
+	return f.changed(ctx)
+}
+
+// Delete 删除开关
+func (f *FeatureFlags) Delete(ctx context.Context, key string) error {
+	res, err := f.db.ExecContext(ctx, "DELETE FROM feature_flags WHERE flag_key = ?", key)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrFeatureFlagNotFound
+	}
+	LoggerFrom(ctx).Info().Str("flag", key).Msg("Feature flag deleted")
+	return f.changed(ctx)
+}
+
+// changed 本实例立即重新加载，并通过 Redis 通知其他实例
+func (f *FeatureFlags) changed(ctx context.Context) error {
+	if err := f.Reload(ctx); err != nil {
+		return err
+	}
+	if f.redis != nil {
+		if err := f.redis.Publish(ctx, featureFlagsChannel, "reload").Err(); err != nil {
+			LoggerFrom(ctx).Warn().Err(err).Msg("Failed to publish feature flag change")
+		}
+	}
+	return nil
+}
+
+func encodeSiteGroupOverrides(overrides map[int]bool) (interface{}, error) {
+	if len(overrides) == 0 {
+		return nil, nil
+	}
+	raw := make(map[string]bool, len(overrides))
+	for id, v := range overrides {
+		raw[strconv.Itoa(id)] = v
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,272 @@
+// Package core provides text search across spider project files and content worker files
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultSearchResults = 200
+	maxSearchResults     = 1000
+	maxSearchQueryLen    = 500
+	searchSnippetRunes   = 200 // 片段最长字符数（以匹配位置为中心截取）
+)
+
+// ErrInvalidSearchQuery 查询为空、过长或正则无法编译
+var ErrInvalidSearchQuery = errors.New("invalid search query")
+
+// FileSearchQuery 搜索条件
+type FileSearchQuery struct {
+	Query         string
+	Regex         bool
+	CaseSensitive bool
+	PathPrefix    string // 只搜索该路径下的文件
+	MaxResults    int    // 匹配行上限，默认 200，最大 1000
+}
+
+// FileSearchMatch 一处匹配（行号和列号从 1 开始，列号按字符计）
+type FileSearchMatch struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Snippet string `json:"snippet"`
+}
+
+// FileSearchResult 搜索结果
+type FileSearchResult struct {
+	Matches      []FileSearchMatch `json:"matches"`
+	FilesScanned int               `json:"files_scanned"`
+	FilesMatched int               `json:"files_matched"`
+	FilesSkipped int               `json:"files_skipped"` // 二进制或超过大小上限
+	Truncated    bool              `json:"truncated"`     // 达到结果上限，后续文件未搜索
+}
+
+// fileSearcher 单次搜索的匹配器和结果
+type fileSearcher struct {
+	match  func(line string) int // 返回匹配的字节偏移，-1 表示不匹配
+	prefix string
+	max    int
+	result *FileSearchResult
+}
+
+func newFileSearcher(q FileSearchQuery) (*fileSearcher, error) {
+	if q.Query == "" || len(q.Query) > maxSearchQueryLen {
+		return nil, ErrInvalidSearchQuery
+	}
+	if q.MaxResults <= 0 {
+		q.MaxResults = defaultSearchResults
+	}
+	if q.MaxResults > maxSearchResults {
+		q.MaxResults = maxSearchResults
+	}
+
+	s := &fileSearcher{
+		prefix: strings.Trim(q.PathPrefix, "/"),
+		max:    q.MaxResults,
+		result: &FileSearchResult{Matches: []FileSearchMatch{}},
+	}
+	switch {
+	case q.Regex:
+		expr := q.Query
+		if !q.CaseSensitive {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, ErrInvalidSearchQuery
+		}
+		s.match = func(line string) int {
+			if loc := re.FindStringIndex(line); loc != nil {
+				return loc[0]
+			}
+			return -1
+		}
+	case q.CaseSensitive:
+		s.match = func(line string) int { return strings.Index(line, q.Query) }
+	default:
+		// 不区分大小写的字面量按转义后的正则匹配（转小写会改变字节偏移）
+		re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(q.Query))
+		s.match = func(line string) int {
+			if loc := re.FindStringIndex(line); loc != nil {
+				return loc[0]
+			}
+			return -1
+		}
+	}
+	return s, nil
+}
+
+// wants 路径是否在搜索范围内（path 为不带前导 / 的相对路径）
+func (s *fileSearcher) wants(path string) bool {
+	return s.prefix == "" || path == s.prefix || strings.HasPrefix(path, s.prefix+"/")
+}
+
+// full 是否已达到结果上限
+func (s *fileSearcher) full() bool {
+	return len(s.result.Matches) >= s.max
+}
+
+// scan 搜索一个文件的内容，二进制内容跳过
+func (s *fileSearcher) scan(path string, content []byte) {
+	if isBinaryContent(content) {
+		s.result.FilesSkipped++
+		return
+	}
+	s.result.FilesScanned++
+	matched := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), len(content)+1)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		pos := s.match(line)
+		if pos < 0 {
+			continue
+		}
+		matched = true
+		if s.full() {
+			s.result.Truncated = true
+			break
+		}
+		s.result.Matches = append(s.result.Matches, FileSearchMatch{
+			Path:    path,
+			Line:    lineNo,
+			Column:  utf8.RuneCountInString(line[:pos]) + 1,
+			Snippet: searchSnippet(line, pos),
+		})
+	}
+	if matched {
+		s.result.FilesMatched++
+	}
+}
+
+// isBinaryContent 按内容嗅探判断二进制（含 NUL、非 UTF-8 或非文本 MIME）
+func isBinaryContent(content []byte) bool {
+	if len(content) == 0 {
+		return false
+	}
+	head := content
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	return bytes.IndexByte(head, 0) >= 0 || !editableMIME(http.DetectContentType(head)) || !utf8.Valid(content)
+}
+
+// searchSnippet 截取匹配位置附近的内容，超长时两端加省略号
+func searchSnippet(line string, pos int) string {
+	line = strings.TrimRight(line, "\r")
+	if utf8.RuneCountInString(line) <= searchSnippetRunes {
+		return line
+	}
+	runes := []rune(line)
+	center := utf8.RuneCountInString(line[:pos])
+	start := center - searchSnippetRunes/4
+	if start < 0 {
+		start = 0
+	}
+	end := start + searchSnippetRunes
+	if end > len(runes) {
+		end = len(runes)
+		start = end - searchSnippetRunes
+	}
+	snippet := string(runes[start:end])
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// SearchSpiderFiles 搜索爬虫项目的全部文件（路径带前导 /，与文件接口一致）
+func SearchSpiderFiles(ctx context.Context, db *sqlx.DB, projectID int, q FileSearchQuery) (*FileSearchResult, error) {
+	s, err := newFileSearcher(q)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryxContext(ctx, `
+		SELECT path, content FROM spider_project_files
+		WHERE project_id = ? AND type = 'file' ORDER BY path`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var path, content string
+		if err := rows.Scan(&path, &content); err != nil {
+			return nil, err
+		}
+		if !s.wants(strings.TrimPrefix(path, "/")) {
+			continue
+		}
+		if s.full() {
+			s.result.Truncated = true
+			break
+		}
+		s.scan(path, []byte(content))
+	}
+	return s.result, rows.Err()
+}
+
+// Search 搜索根目录下的文件（不进入隐藏目录、__pycache__ 和符号链接，超过大小上限的文件跳过）
+func (f *ConfinedFS) Search(ctx context.Context, q FileSearchQuery) (*FileSearchResult, error) {
+	s, err := newFileSearcher(q)
+	if err != nil {
+		return nil, err
+	}
+	err = filepath.WalkDir(f.root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		name := d.Name()
+		if path != f.root && (strings.HasPrefix(name, ".") || name == "__pycache__") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(f.root, path)
+		if err != nil || !s.wants(filepath.ToSlash(rel)) {
+			return nil
+		}
+		if s.full() {
+			s.result.Truncated = true
+			return filepath.SkipAll
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.Size() > f.maxFileSize {
+			s.result.FilesSkipped++
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		s.scan("/"+filepath.ToSlash(rel), content) // 与目录树路径格式一致
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.result, nil
+}
@@ -0,0 +1,362 @@
+// Package core provides the recycle bin for content worker and spider project file deletions
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// 回收站来源
+const (
+	TrashSourceContentWorker = "content_worker"
+	TrashSourceSpiderProject = "spider_project"
+)
+
+var (
+	// ErrTrashNotFound 回收站条目不存在（已恢复、已清除或 token 错误）
+	ErrTrashNotFound = errors.New("trash entry not found")
+	// ErrTrashConflict 原路径已被占用，无法恢复
+	ErrTrashConflict = errors.New("original path already exists")
+)
+
+// TrashEntry 回收站条目
+type TrashEntry struct {
+	ID        int64     `db:"id" json:"id"`
+	Token     string    `db:"token" json:"token"`
+	Source    string    `db:"source" json:"source"`
+	ProjectID int       `db:"project_id" json:"project_id"`
+	Path      string    `db:"path" json:"path"`
+	ItemType  string    `db:"item_type" json:"item_type"`
+	Items     int       `db:"items" json:"items"`
+	Size      int64     `db:"size" json:"size"`
+	DeletedBy string    `db:"deleted_by" json:"deleted_by"`
+	DeletedAt time.Time `db:"deleted_at" json:"deleted_at"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+}
+
+// trashedSpiderFile 爬虫项目文件行（回收站 payload）
+type trashedSpiderFile struct {
+	Path    string `db:"path" json:"path"`
+	Type    string `db:"type" json:"type"`
+	Content string `db:"content" json:"content"`
+}
+
+// FileTrash 文件回收站
+//
+// 内容处理代码文件移到根目录下的 .trash/<token>/，爬虫项目文件行序列化后存入 file_trash.payload；
+// 删除接口返回 token，恢复时放回原路径（原路径被占用时拒绝），到期条目由 Start 定时清除。
+type FileTrash struct {
+	db        *sqlx.DB
+	workerFS  *ConfinedFS
+	retention time.Duration
+}
+
+// NewFileTrash 创建 FileTrash
+func NewFileTrash(db *sqlx.DB, workerFS *ConfinedFS, cfg config.TrashConfig) *FileTrash {
+	if cfg.RetentionDays <= 0 {
+		cfg.RetentionDays = 7
+	}
+	return &FileTrash{
+		db:        db,
+		workerFS:  workerFS,
+		retention: time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+	}
+}
+
+// TrashWorkerPath 删除内容处理代码文件或目录（移入回收站）
+func (t *FileTrash) TrashWorkerPath(ctx context.Context, actor FileActor, rel string) (*TrashEntry, error) {
+	token, err := newTrashToken()
+	if err != nil {
+		return nil, err
+	}
+	itemType, size, err := t.workerFS.Trash(ctx, actor, rel, token)
+	if err != nil {
+		return nil, err
+	}
+	entry := t.newEntry(token, TrashSourceContentWorker, 0, rel, itemType, 1, size, actor.Username)
+	if err := t.insert(ctx, t.db, entry, nil); err != nil {
+		// 记录写入失败时放回原处，避免文件留在回收站却无法恢复
+		if rerr := t.workerFS.RestoreTrash(ctx, actor, token, rel); rerr != nil {
+			log.Error().Err(rerr).Str("token", token).Str("path", rel).Msg("Failed to roll back trashed file")
+		}
+		return nil, err
+	}
+	return entry, nil
+}
+
+// TrashSpiderPath 删除爬虫项目文件或目录（目录包含全部子项），文件行移入回收站
+func (t *FileTrash) TrashSpiderPath(ctx context.Context, actor FileActor, projectID int, path string) (*TrashEntry, error) {
+	token, err := newTrashToken()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := t.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	files := []trashedSpiderFile{}
+	if err := tx.SelectContext(ctx, &files, `
+		SELECT path, type, content FROM spider_project_files
+		WHERE project_id = ? AND (path = ? OR path LIKE ?) ORDER BY path FOR UPDATE`,
+		projectID, path, path+"/%"); err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, os.ErrNotExist
+	}
+	itemType := "dir"
+	var size int64
+	for _, f := range files {
+		if f.Path == path {
+			itemType = f.Type
+		}
+		size += int64(len(f.Content))
+	}
+
+	entry := t.newEntry(token, TrashSourceSpiderProject, projectID, path, itemType, len(files), size, actor.Username)
+	if err := t.insert(ctx, tx, entry, files); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM spider_project_files WHERE project_id = ? AND (path = ? OR path LIKE ?)`,
+		projectID, path, path+"/%"); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Restore 按 token 恢复条目（source 和 projectID 需与条目一致）
+func (t *FileTrash) Restore(ctx context.Context, actor FileActor, source string, projectID int, token string) (*TrashEntry, error) {
+	if source == TrashSourceSpiderProject {
+		return t.restoreSpider(ctx, projectID, token)
+	}
+	entry, err := t.get(ctx, source, projectID, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.workerFS.RestoreTrash(ctx, actor, token, entry.Path); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil, ErrTrashConflict
+		}
+		return nil, err
+	}
+	if _, err := t.db.ExecContext(ctx, "DELETE FROM file_trash WHERE id = ?", entry.ID); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (t *FileTrash) restoreSpider(ctx context.Context, projectID int, token string) (*TrashEntry, error) {
+	tx, err := t.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var row struct {
+		TrashEntry
+		Payload sql.NullString `db:"payload"`
+	}
+	err = tx.GetContext(ctx, &row, `
+		SELECT id, token, source, project_id, path, item_type, items, size, deleted_by, deleted_at, expires_at, payload
+		FROM file_trash WHERE token = ? AND source = ? AND project_id = ? FOR UPDATE`,
+		token, TrashSourceSpiderProject, projectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTrashNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var projects int
+	if err := tx.GetContext(ctx, &projects, "SELECT COUNT(*) FROM spider_projects WHERE id = ?", projectID); err != nil {
+		return nil, err
+	}
+	if projects == 0 {
+		return nil, ErrTrashNotFound
+	}
+	var files []trashedSpiderFile
+	if err := json.Unmarshal([]byte(row.Payload.String), &files); err != nil {
+		return nil, fmt.Errorf("decode trash payload: %w", err)
+	}
+
+	var taken int
+	if err := tx.GetContext(ctx, &taken, `
+		SELECT COUNT(*) FROM spider_project_files WHERE project_id = ? AND (path = ? OR path LIKE ?)`,
+		projectID, row.Path, row.Path+"/%"); err != nil {
+		return nil, err
+	}
+	if taken > 0 {
+		return nil, ErrTrashConflict
+	}
+	for _, f := range files {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO spider_project_files (project_id, path, type, content) VALUES (?, ?, ?, ?)`,
+			projectID, f.Path, f.Type, f.Content); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM file_trash WHERE id = ?", row.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &row.TrashEntry, nil
+}
+
+// List 回收站条目（最近删除的在前）
+func (t *FileTrash) List(ctx context.Context, source string, projectID int) ([]TrashEntry, error) {
+	items := []TrashEntry{}
+	err := t.db.SelectContext(ctx, &items, `
+		SELECT id, token, source, project_id, path, item_type, items, size, deleted_by, deleted_at, expires_at
+		FROM file_trash WHERE source = ? AND project_id = ? ORDER BY id DESC`, source, projectID)
+	return items, err
+}
+
+// Purge 立即永久删除条目
+func (t *FileTrash) Purge(ctx context.Context, source string, projectID int, token string) error {
+	entry, err := t.get(ctx, source, projectID, token)
+	if err != nil {
+		return err
+	}
+	return t.purge(ctx, entry.ID, entry.Source, entry.Token)
+}
+
+// PurgeExpired 清除到期条目，以及回收站目录中没有记录的残留（超过保留期）
+func (t *FileTrash) PurgeExpired(ctx context.Context) (int, error) {
+	var expired []TrashEntry
+	if err := t.db.SelectContext(ctx, &expired,
+		"SELECT id, token, source FROM file_trash WHERE expires_at < ?", time.Now()); err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, e := range expired {
+		if err := t.purge(ctx, e.ID, e.Source, e.Token); err != nil {
+			log.Warn().Err(err).Str("token", e.Token).Msg("Failed to purge trash entry")
+			continue
+		}
+		purged++
+	}
+
+	var known []string
+	if err := t.db.SelectContext(ctx, &known, "SELECT token FROM file_trash WHERE source = ?", TrashSourceContentWorker); err != nil {
+		return purged, err
+	}
+	live := make(map[string]bool, len(known))
+	for _, token := range known {
+		live[token] = true
+	}
+	for token, mtime := range t.workerFS.trashTokens() {
+		if !live[token] && time.Since(mtime) > t.retention {
+			if err := t.workerFS.PurgeTrash(token); err == nil {
+				purged++
+			}
+		}
+	}
+	return purged, nil
+}
+
+// Start 每小时清除到期条目，ctx 取消时退出
+func (t *FileTrash) Start(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		if n, err := t.PurgeExpired(ctx); err != nil {
+			log.Warn().Err(err).Msg("Trash purge failed")
+		} else if n > 0 {
+			log.Info().Int("purged", n).Msg("Expired trash entries purged")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newTrashToken 随机恢复令牌（同时作为 .trash 下的目录名）
+func newTrashToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (t *FileTrash) newEntry(token, source string, projectID int, path, itemType string, items int, size int64, deletedBy string) *TrashEntry {
+	now := time.Now()
+	return &TrashEntry{
+		Token:     token,
+		Source:    source,
+		ProjectID: projectID,
+		Path:      path,
+		ItemType:  itemType,
+		Items:     items,
+		Size:      size,
+		DeletedBy: deletedBy,
+		DeletedAt: now,
+		ExpiresAt: now.Add(t.retention),
+	}
+}
+
+func (t *FileTrash) insert(ctx context.Context, db sqlx.ExecerContext, e *TrashEntry, files []trashedSpiderFile) error {
+	var payload interface{}
+	if files != nil {
+		data, err := json.Marshal(files)
+		if err != nil {
+			return err
+		}
+		payload = string(data)
+	}
+	result, err := db.ExecContext(ctx, `
+		INSERT INTO file_trash (token, source, project_id, path, item_type, items, size, payload, deleted_by, deleted_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.Token, e.Source, e.ProjectID, truncateRunes(e.Path, 500), e.ItemType, e.Items, e.Size, payload,
+		truncateRunes(e.DeletedBy, 100), e.DeletedAt, e.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	e.ID, _ = result.LastInsertId()
+	return nil
+}
+
+func (t *FileTrash) get(ctx context.Context, source string, projectID int, token string) (*TrashEntry, error) {
+	var entry TrashEntry
+	err := t.db.GetContext(ctx, &entry, `
+		SELECT id, token, source, project_id, path, item_type, items, size, deleted_by, deleted_at, expires_at
+		FROM file_trash WHERE token = ? AND source = ? AND project_id = ?`, token, source, projectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTrashNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (t *FileTrash) purge(ctx context.Context, id int64, source, token string) error {
+	if source == TrashSourceContentWorker {
+		if err := t.workerFS.PurgeTrash(token); err != nil {
+			return err
+		}
+	}
+	_, err := t.db.ExecContext(ctx, "DELETE FROM file_trash WHERE id = ?", id)
+	return err
+}
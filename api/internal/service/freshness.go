@@ -0,0 +1,505 @@
+// Package core provides scheduled content freshness refresh for cached pages
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"math/rand"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// TaskTypeFreshnessRefresh 内容保鲜任务类型
+const TaskTypeFreshnessRefresh TaskType = "freshness_refresh"
+
+// 刷新记录状态
+const (
+	FreshnessRefreshed = "refreshed"
+	FreshnessFailed    = "failed"
+)
+
+// freshnessMaxCandidates 每个站群最多读取的候选 URL 数
+const freshnessMaxCandidates = 200000
+
+// ErrFreshnessRunning 已有保鲜任务在执行
+var ErrFreshnessRunning = errors.New("another freshness refresh is running")
+
+// freshnessTitlePattern 从缓存页面提取 <title>
+var freshnessTitlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// PageOverride 内部重新渲染页面时的覆盖参数
+// ServePage 看到该参数时跳过非蜘蛛防护、不读取共享缓存、不写缓存和统计，由调用方自行写入缓存
+type PageOverride struct {
+	Title      string // 不为空时作为页面标题（保持标题不变）
+	SpiderType string // 按该蜘蛛类型解析内容策略（决定缓存命名空间）
+	DryRun     bool   // 只读渲染：标题和正文只查看不取出，不计入模板使用和灰度统计
+}
+
+type pageOverrideKey struct{}
+
+// WithPageOverride 返回携带覆盖参数的 context
+func WithPageOverride(ctx context.Context, o *PageOverride) context.Context {
+	return context.WithValue(ctx, pageOverrideKey{}, o)
+}
+
+// PageOverrideFrom 读取 context 中的覆盖参数，普通请求返回 nil
+func PageOverrideFrom(ctx context.Context) *PageOverride {
+	if ctx == nil {
+		return nil
+	}
+	o, _ := ctx.Value(pageOverrideKey{}).(*PageOverride)
+	return o
+}
+
+// PageDryRunner 只读渲染页面（由页面 handler 实现），不消耗数据池，不影响模板使用和灰度统计
+type PageDryRunner interface {
+	DryRunPage(ctx context.Context, domain, path string) (string, error)
+}
+
+// PageRefresher 重新渲染页面（由页面 handler 实现），返回新的 HTML
+type PageRefresher interface {
+	RefreshPage(ctx context.Context, domain, path, title, spiderType string) (string, error)
+}
+
+// FreshnessPolicy 站群保鲜策略
+type FreshnessPolicy struct {
+	SiteGroupID      int        `db:"site_group_id" json:"site_group_id"`
+	Enabled          bool       `db:"enabled" json:"enabled"`
+	Percent          float64    `db:"percent" json:"percent"`                       // 每次刷新的缓存页面比例（%）
+	MaxPages         int        `db:"max_pages" json:"max_pages"`                   // 每次最多刷新的页面数
+	MinIntervalHours int        `db:"min_interval_hours" json:"min_interval_hours"` // 同一 URL 两次刷新的最短间隔
+	LastRunAt        *time.Time `db:"last_run_at" json:"last_run_at"`
+	LastRefreshed    int        `db:"last_refreshed" json:"last_refreshed"`
+	UpdatedAt        time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// FreshnessRefresh 单个 URL 的刷新记录
+type FreshnessRefresh struct {
+	ID          int64     `db:"id" json:"id"`
+	SiteGroupID int       `db:"site_group_id" json:"site_group_id"`
+	Domain      string    `db:"domain" json:"domain"`
+	Path        string    `db:"path" json:"path"`
+	CachePath   string    `db:"cache_path" json:"cache_path"` // 带策略命名空间的缓存路径
+	Title       string    `db:"title" json:"title"`
+	OldSize     int       `db:"old_size" json:"old_size"`
+	NewSize     int       `db:"new_size" json:"new_size"`
+	Status      string    `db:"status" json:"status"`
+	Error       *string   `db:"error" json:"error,omitempty"`
+	JobID       *int64    `db:"job_id" json:"job_id,omitempty"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+// FreshnessGroupResult 单个站群的刷新结果
+type FreshnessGroupResult struct {
+	SiteGroupID int `json:"site_group_id"`
+	Candidates  int `json:"candidates"` // 回溯期内被蜘蛛访问过、且不在最短间隔内的 URL 数
+	Target      int `json:"target"`     // 按比例计算的刷新数
+	Refreshed   int `json:"refreshed"`
+	Failed      int `json:"failed"`
+	NotCached   int `json:"not_cached"` // 已不在缓存中而跳过的 URL 数
+}
+
+// FreshnessRunResult 一次保鲜运行的结果
+type FreshnessRunResult struct {
+	Groups   []FreshnessGroupResult `json:"groups"`
+	Pruned   int64                  `json:"pruned"` // 清理的过期刷新记录数
+	Duration int64                  `json:"duration_ms"`
+}
+
+// freshnessURL 候选 URL
+type freshnessURL struct {
+	Domain     string `db:"domain"`
+	Path       string `db:"path"`
+	SpiderType string `db:"spider_type"`
+	cachePath  string
+}
+
+// Freshness 内容保鲜
+// 按站群策略每天从缓存页面中抽取一定比例，用数据池中的新内容重新渲染并覆盖缓存，URL 和标题保持不变。
+// 候选页面为回溯期内蜘蛛访问过（spider_logs）且仍在缓存中的页面，按蜘蛛类型解析内容策略，命名空间缓存一并刷新；
+// 每个 URL 的刷新记录保存在 freshness_refreshes 表，后续刷新沿用记录中的标题
+type Freshness struct {
+	db         *sqlx.DB
+	cache      HTMLCache
+	strategies *SpiderStrategyResolver
+	config     config.FreshnessConfig
+	jobs       *JobManager
+
+	refresher PageRefresher
+	running   sync.Mutex
+}
+
+// NewFreshness 创建内容保鲜
+func NewFreshness(db *sqlx.DB, cache HTMLCache, strategies *SpiderStrategyResolver, cfg config.FreshnessConfig, jobs *JobManager) *Freshness {
+	if cfg.LookbackDays <= 0 {
+		cfg.LookbackDays = 7
+	}
+	if cfg.HistoryDays <= 0 {
+		cfg.HistoryDays = 90
+	}
+	return &Freshness{db: db, cache: cache, strategies: strategies, config: cfg, jobs: jobs}
+}
+
+// SetRefresher 设置页面渲染器（页面 handler 创建后注入）
+func (f *Freshness) SetRefresher(r PageRefresher) {
+	f.refresher = r
+}
+
+// Submit 作为后台作业执行保鲜，siteGroupID 为 0 时处理所有启用的站群
+func (f *Freshness) Submit(ctx context.Context, siteGroupID int) (int64, error) {
+	if f.jobs == nil {
+		return 0, fmt.Errorf("job manager not available")
+	}
+	params := map[string]interface{}{"site_group_id": siteGroupID}
+	return f.jobs.SubmitFunc(ctx, string(TaskTypeFreshnessRefresh), params, func(jc *JobContext) (any, error) {
+		return f.Run(jc, siteGroupID, jc.JobID(), jc.SetTotal, jc.Advance)
+	})
+}
+
+// Run 执行保鲜，siteGroupID 为 0 时处理所有启用的站群；total/progress 可为 nil
+func (f *Freshness) Run(ctx context.Context, siteGroupID int, jobID int64, total, progress func(n int64)) (*FreshnessRunResult, error) {
+	if f.refresher == nil {
+		return nil, fmt.Errorf("page refresher not available")
+	}
+	if !f.running.TryLock() {
+		return nil, ErrFreshnessRunning
+	}
+	defer f.running.Unlock()
+	if total == nil {
+		total = func(int64) {}
+	}
+	if progress == nil {
+		progress = func(int64) {}
+	}
+
+	start := time.Now()
+	result := &FreshnessRunResult{Groups: []FreshnessGroupResult{}}
+
+	query := "SELECT " + freshnessPolicyColumns + " FROM freshness_policies WHERE enabled = 1"
+	args := []interface{}{}
+	if siteGroupID > 0 {
+		query, args = "SELECT "+freshnessPolicyColumns+" FROM freshness_policies WHERE site_group_id = ?", append(args, siteGroupID)
+	}
+	var policies []FreshnessPolicy
+	if err := f.db.SelectContext(ctx, &policies, query+" ORDER BY site_group_id", args...); err != nil {
+		return nil, fmt.Errorf("load freshness policies: %w", err)
+	}
+
+	type groupPlan struct {
+		policy FreshnessPolicy
+		picks  []freshnessURL
+		result FreshnessGroupResult
+	}
+	plans := make([]*groupPlan, 0, len(policies))
+	var planned int64
+	for _, p := range policies {
+		candidates, err := f.candidates(ctx, p)
+		if err != nil {
+			return result, fmt.Errorf("load candidates for site group %d: %w", p.SiteGroupID, err)
+		}
+		target := int(math.Ceil(float64(len(candidates)) * p.Percent / 100))
+		if p.MaxPages > 0 && target > p.MaxPages {
+			target = p.MaxPages
+		}
+		rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+		plans = append(plans, &groupPlan{
+			policy: p,
+			picks:  candidates,
+			result: FreshnessGroupResult{SiteGroupID: p.SiteGroupID, Candidates: len(candidates), Target: target},
+		})
+		planned += int64(target)
+	}
+	total(planned)
+
+	for _, plan := range plans {
+		gr := &plan.result
+		for _, u := range plan.picks {
+			if gr.Refreshed+gr.Failed >= gr.Target {
+				break
+			}
+			if err := ctx.Err(); err != nil {
+				result.Groups = append(result.Groups, *gr)
+				return result, err
+			}
+			ok, err := f.refresh(ctx, plan.policy.SiteGroupID, u, jobID)
+			switch {
+			case !ok && err == nil:
+				gr.NotCached++
+				continue
+			case err != nil:
+				gr.Failed++
+			default:
+				gr.Refreshed++
+			}
+			progress(1)
+			if f.config.DelayMs > 0 {
+				time.Sleep(time.Duration(f.config.DelayMs) * time.Millisecond)
+			}
+		}
+		if _, err := f.db.ExecContext(ctx,
+			"UPDATE freshness_policies SET last_run_at = NOW(), last_refreshed = ? WHERE site_group_id = ?",
+			gr.Refreshed, plan.policy.SiteGroupID); err != nil {
+			log.Warn().Err(err).Int("site_group_id", plan.policy.SiteGroupID).Msg("Failed to update freshness policy")
+		}
+		result.Groups = append(result.Groups, *gr)
+		log.Info().Int("site_group_id", gr.SiteGroupID).Int("candidates", gr.Candidates).Int("refreshed", gr.Refreshed).
+			Int("failed", gr.Failed).Int("not_cached", gr.NotCached).Msg("Freshness refresh finished for site group")
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -f.config.HistoryDays)
+	if res, err := f.db.ExecContext(ctx, "DELETE FROM freshness_refreshes WHERE created_at < ?", cutoff); err != nil {
+		log.Warn().Err(err).Msg("Failed to prune freshness history")
+	} else {
+		result.Pruned, _ = res.RowsAffected()
+	}
+
+	result.Duration = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// candidates 回溯期内蜘蛛成功访问过的 URL，排除最短间隔内已刷新过的
+func (f *Freshness) candidates(ctx context.Context, p FreshnessPolicy) ([]freshnessURL, error) {
+	var rows []freshnessURL
+	err := f.db.SelectContext(ctx, &rows, `
+		SELECT l.domain, l.path, l.spider_type
+		FROM spider_logs l JOIN sites s ON s.domain = l.domain
+		WHERE s.site_group_id = ? AND s.status = 1 AND l.status = 200 AND l.created_at >= ?
+		GROUP BY l.domain, l.path, l.spider_type
+		LIMIT ?`,
+		p.SiteGroupID, time.Now().AddDate(0, 0, -f.config.LookbackDays), freshnessMaxCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	recent := map[string]bool{}
+	if p.MinIntervalHours > 0 {
+		var refreshed []struct {
+			Domain    string `db:"domain"`
+			CachePath string `db:"cache_path"`
+		}
+		if err := f.db.SelectContext(ctx, &refreshed, `
+			SELECT DISTINCT domain, cache_path FROM freshness_refreshes
+			WHERE site_group_id = ? AND status = ? AND created_at >= ?`,
+			p.SiteGroupID, FreshnessRefreshed, time.Now().Add(-time.Duration(p.MinIntervalHours)*time.Hour)); err != nil {
+			return nil, err
+		}
+		for _, r := range refreshed {
+			recent[r.Domain+"\x00"+r.CachePath] = true
+		}
+	}
+
+	// 未配置策略的蜘蛛类型共用同一缓存路径，按缓存路径去重
+	seen := make(map[string]bool, len(rows))
+	out := rows[:0]
+	for _, u := range rows {
+		u.cachePath = f.strategies.Resolve(p.SiteGroupID, u.SpiderType).CachePath(u.Path)
+		key := u.Domain + "\x00" + u.cachePath
+		if seen[key] || recent[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, u)
+	}
+	return out, nil
+}
+
+// refresh 重新渲染单个 URL 并覆盖缓存；页面已不在缓存中时返回 false, nil
+func (f *Freshness) refresh(ctx context.Context, siteGroupID int, u freshnessURL, jobID int64) (bool, error) {
+	old, ok := f.cache.Get(u.Domain, u.cachePath)
+	if !ok {
+		return false, nil
+	}
+
+	title := f.stableTitle(ctx, u, old)
+	newHTML, err := f.refresher.RefreshPage(ctx, u.Domain, u.Path, title, u.SpiderType)
+	if err == nil {
+		err = f.cache.Set(u.Domain, u.cachePath, newHTML)
+	}
+
+	rec := &FreshnessRefresh{
+		SiteGroupID: siteGroupID,
+		Domain:      u.Domain,
+		Path:        u.Path,
+		CachePath:   u.cachePath,
+		Title:       truncateRunes(title, 500),
+		OldSize:     len(old),
+		NewSize:     len(newHTML),
+		Status:      FreshnessRefreshed,
+	}
+	if jobID > 0 {
+		rec.JobID = &jobID
+	}
+	if err != nil {
+		msg := truncateRunes(err.Error(), 500)
+		rec.Status, rec.Error, rec.NewSize = FreshnessFailed, &msg, 0
+		log.Warn().Err(err).Str("domain", u.Domain).Str("path", u.Path).Msg("Freshness refresh failed")
+	}
+	if _, ierr := f.db.ExecContext(ctx, `
+		INSERT INTO freshness_refreshes (site_group_id, domain, path, cache_path, title, old_size, new_size, status, error, job_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.SiteGroupID, rec.Domain, truncateRunes(rec.Path, 500), truncateRunes(rec.CachePath, 500), rec.Title,
+		rec.OldSize, rec.NewSize, rec.Status, rec.Error, rec.JobID); ierr != nil {
+		log.Warn().Err(ierr).Str("domain", u.Domain).Str("path", u.Path).Msg("Failed to record freshness refresh")
+	}
+	return true, err
+}
+
+// stableTitle 沿用上次刷新记录的标题，没有记录时从缓存页面的 <title> 提取
+func (f *Freshness) stableTitle(ctx context.Context, u freshnessURL, old string) string {
+	var title string
+	err := f.db.GetContext(ctx, &title, `
+		SELECT title FROM freshness_refreshes
+		WHERE domain = ? AND cache_path = ? AND status = ? AND title <> ''
+		ORDER BY id DESC LIMIT 1`,
+		u.Domain, u.cachePath, FreshnessRefreshed)
+	if err == nil && title != "" {
+		return title
+	}
+	return extractHTMLTitle(old)
+}
+
+// extractHTMLTitle 提取页面 <title> 文本
+func extractHTMLTitle(page string) string {
+	m := freshnessTitlePattern.FindStringSubmatch(page)
+	if m == nil {
+		return ""
+	}
+	title := strings.TrimSpace(html.UnescapeString(m[1]))
+	if !utf8.ValidString(title) {
+		return ""
+	}
+	return title
+}
+
+// freshnessPolicyColumns freshness_policies 查询列
+const freshnessPolicyColumns = `site_group_id, enabled, percent, max_pages, min_interval_hours, last_run_at, last_refreshed, updated_at`
+
+// ListPolicies 所有站群策略
+func (f *Freshness) ListPolicies(ctx context.Context) ([]FreshnessPolicy, error) {
+	policies := []FreshnessPolicy{}
+	err := f.db.SelectContext(ctx, &policies, "SELECT "+freshnessPolicyColumns+" FROM freshness_policies ORDER BY site_group_id")
+	return policies, err
+}
+
+// GetPolicy 获取站群策略，未配置时返回 nil
+func (f *Freshness) GetPolicy(ctx context.Context, siteGroupID int) (*FreshnessPolicy, error) {
+	var p FreshnessPolicy
+	err := f.db.GetContext(ctx, &p, "SELECT "+freshnessPolicyColumns+" FROM freshness_policies WHERE site_group_id = ?", siteGroupID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SavePolicy 创建或更新站群策略
+func (f *Freshness) SavePolicy(ctx context.Context, p *FreshnessPolicy) error {
+	_, err := f.db.ExecContext(ctx, `
+		INSERT INTO freshness_policies (site_group_id, enabled, percent, max_pages, min_interval_hours)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE enabled = VALUES(enabled), percent = VALUES(percent),
+			max_pages = VALUES(max_pages), min_interval_hours = VALUES(min_interval_hours)`,
+		p.SiteGroupID, p.Enabled, p.Percent, p.MaxPages, p.MinIntervalHours)
+	return err
+}
+
+// DeletePolicy 删除站群策略（刷新记录保留）
+func (f *Freshness) DeletePolicy(ctx context.Context, siteGroupID int) (bool, error) {
+	res, err := f.db.ExecContext(ctx, "DELETE FROM freshness_policies WHERE site_group_id = ?", siteGroupID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// FreshnessHistoryFilter 刷新记录查询条件
+type FreshnessHistoryFilter struct {
+	SiteGroupID int
+	Domain      string
+	Path        string // 精确匹配原始路径
+	Status      string
+}
+
+// History 分页查询刷新记录（新的在前）
+func (f *Freshness) History(ctx context.Context, filter FreshnessHistoryFilter, page, pageSize int) ([]FreshnessRefresh, int64, error) {
+	where := []string{"1=1"}
+	args := []interface{}{}
+	if filter.SiteGroupID > 0 {
+		where, args = append(where, "site_group_id = ?"), append(args, filter.SiteGroupID)
+	}
+	if filter.Domain != "" {
+		where, args = append(where, "domain = ?"), append(args, filter.Domain)
+	}
+	if filter.Path != "" {
+		where, args = append(where, "path = ?"), append(args, filter.Path)
+	}
+	if filter.Status != "" {
+		where, args = append(where, "status = ?"), append(args, filter.Status)
+	}
+	cond := strings.Join(where, " AND ")
+
+	var total int64
+	if err := f.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM freshness_refreshes WHERE "+cond, args...); err != nil {
+		return nil, 0, err
+	}
+	items := []FreshnessRefresh{}
+	err := f.db.SelectContext(ctx, &items, `
+		SELECT id, site_group_id, domain, path, cache_path, title, old_size, new_size, status, error, job_id, created_at
+		FROM freshness_refreshes WHERE `+cond+" ORDER BY id DESC LIMIT ? OFFSET ?",
+		append(args, pageSize, (page-1)*pageSize)...)
+	return items, total, err
+}
+
+// EnsureSchedule 按配置创建或更新定时保鲜任务
+func (f *Freshness) EnsureSchedule(ctx context.Context, scheduler *Scheduler) error {
+	var existing struct {
+		ID       int64  `db:"id"`
+		CronExpr string `db:"cron_expr"`
+		Enabled  bool   `db:"enabled"`
+	}
+	err := f.db.GetContext(ctx, &existing,
+		"SELECT id, cron_expr, enabled FROM scheduled_tasks WHERE task_type = ? LIMIT 1", TaskTypeFreshnessRefresh)
+	exists := err == nil && existing.ID > 0
+
+	if f.config.Schedule == "" {
+		if exists {
+			return scheduler.DeleteTask(ctx, existing.ID)
+		}
+		return nil
+	}
+
+	task := &ScheduledTask{
+		Name:     "内容保鲜",
+		TaskType: TaskTypeFreshnessRefresh,
+		CronExpr: f.config.Schedule,
+		Params:   json.RawMessage("{}"),
+		Enabled:  true,
+	}
+	if exists {
+		// 保留后台手动设置的启用状态，只同步 Cron 表达式
+		if existing.CronExpr == f.config.Schedule {
+			return nil
+		}
+		task.ID = existing.ID
+		task.Enabled = existing.Enabled
+		return scheduler.UpdateTask(ctx, task)
+	}
+	_, err = scheduler.CreateTask(ctx, task)
+	return err
+}
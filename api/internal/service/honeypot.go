@@ -0,0 +1,266 @@
+package core
+
+import (
+	"context"
+	"html"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	antiScrapeBadBotKeyPrefix = "anti_scrape:badbot:" // Redis 坏爬虫记录前缀（hash），TTL 为剩余赦免时间
+	defaultRobotsTxt          = "User-agent: *\nAllow: /\n"
+)
+
+// 蜜罐链接的隐藏方式和锚文本（随机组合，避免被按固定特征过滤）
+var (
+	trapLinkStyles = []string{
+		"display:none",
+		"position:absolute;left:-9999px;top:-9999px",
+		"visibility:hidden;width:0;height:0;overflow:hidden",
+		"opacity:0;position:absolute;width:1px;height:1px;overflow:hidden",
+	}
+	trapLinkTexts = []string{"index", "more", "archive", "list", "next", "detail", "page", "tags"}
+)
+
+func init() {
+	// trap_link()：隐藏的蜜罐链接（robots.txt 中禁止抓取，访问即标记为坏爬虫），
+	// 配置 anti_scrape.enabled 且 honeypot_prefix 非空时启用
+	RegisterTemplateFuncPack(TemplateFuncPack{
+		Name: "honeypot",
+		Funcs: []TemplateFunc{
+			{Name: "trap_link", Arity: 0, Cost: 1, Impl: func(_ *TemplateFuncsManager, data *RenderData, _ []string) string {
+				if data == nil || data.TrapLink == nil {
+					return ""
+				}
+				return data.TrapLink()
+			}},
+		},
+	})
+}
+
+// BadBotEntry 访问过蜜罐链接的客户端
+type BadBotEntry struct {
+	IP        string    `json:"ip"`
+	UA        string    `json:"ua"`
+	Spider    string    `json:"spider"` // UA 声称的蜘蛛类型（真实搜索引擎遵守 robots.txt，不会访问蜜罐）
+	Domain    string    `json:"domain"`
+	Path      string    `json:"path"`
+	Hits      int64     `json:"hits"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	ExpiresAt time.Time `json:"expires_at"` // 到期后自动赦免（记录删除、封禁解除）
+}
+
+// HoneypotEnabled 是否启用蜜罐链接
+func (a *AntiScraper) HoneypotEnabled() bool {
+	return a.Enabled() && a.config.HoneypotPrefix != ""
+}
+
+// IsTrapPath 路径是否为蜜罐链接
+func (a *AntiScraper) IsTrapPath(path string) bool {
+	return a.HoneypotEnabled() && strings.HasPrefix(path, a.config.HoneypotPrefix)
+}
+
+// amnesty 坏爬虫标记和蜜罐封禁的有效期
+func (a *AntiScraper) amnesty() time.Duration {
+	if a.config.HoneypotAmnestyHours <= 0 {
+		return time.Duration(a.config.BlockSeconds) * time.Second
+	}
+	return time.Duration(a.config.HoneypotAmnestyHours) * time.Hour
+}
+
+// TrapPath 生成随机蜜罐路径
+func (a *AntiScraper) TrapPath() string {
+	return a.config.HoneypotPrefix + strconv.FormatInt(rand.Int63(), 36) + ".html"
+}
+
+// TrapLink 生成隐藏的蜜罐链接 HTML（随机隐藏方式和锚文本）
+func (a *AntiScraper) TrapLink() string {
+	if !a.HoneypotEnabled() {
+		return ""
+	}
+	return `<a href="` + html.EscapeString(a.TrapPath()) + `" rel="nofollow" tabindex="-1" aria-hidden="true" style="` +
+		trapLinkStyles[rand.Intn(len(trapLinkStyles))] + `">` + trapLinkTexts[rand.Intn(len(trapLinkTexts))] + `</a>`
+}
+
+// Trap 记录一次蜜罐访问：标记为坏爬虫（Redis，TTL 为赦免时间）并加入封禁列表
+// 白名单和本机地址只记录日志不封禁；spider 为 UA 声称的蜘蛛类型（可为空）
+func (a *AntiScraper) Trap(ctx context.Context, ip, ua, spider, domain, path string) BadBotEntry {
+	now := time.Now()
+	amnesty := a.amnesty()
+	a.honeypots.Add(1)
+
+	entry := BadBotEntry{IP: ip, UA: ua, Spider: spider, Domain: domain, Path: path, Hits: 1, FirstSeen: now, LastSeen: now, ExpiresAt: now.Add(amnesty)}
+	if a.rdb != nil {
+		key := antiScrapeBadBotKeyPrefix + ip
+		pipe := a.rdb.TxPipeline()
+		pipe.HSetNX(ctx, key, "first_seen", now.Unix())
+		pipe.HSet(ctx, key, "ua", ua, "spider", spider, "domain", domain, "path", path, "last_seen", now.Unix())
+		hits := pipe.HIncrBy(ctx, key, "hits", 1)
+		pipe.Expire(ctx, key, amnesty)
+		if _, err := pipe.Exec(ctx); err != nil {
+			log.Warn().Err(err).Str("ip", ip).Msg("Failed to save bad bot to Redis")
+		} else {
+			entry.Hits = hits.Val()
+		}
+	} else {
+		a.badBotMu.Lock()
+		if prev, ok := a.badBots[ip]; ok && now.Before(prev.ExpiresAt) {
+			entry.FirstSeen = prev.FirstSeen
+			entry.Hits = prev.Hits + 1
+		}
+		if len(a.badBots) < antiScrapeMaxTracked || entry.Hits > 1 {
+			a.badBots[ip] = entry
+		}
+		a.badBotMu.Unlock()
+	}
+
+	log.Warn().Str("ip", ip).Str("ua", ua).Str("domain", domain).Str("path", path).Msg("Honeypot trap hit")
+	if parsed := net.ParseIP(ip); parsed == nil || parsed.IsLoopback() || containsIP(a.allowlist, parsed) {
+		return entry
+	}
+	a.Block(ctx, ip, "honeypot", amnesty)
+	a.blockedCount.Add(1)
+	return entry
+}
+
+// BadBots 当前标记的坏爬虫（按最近访问时间倒序）
+func (a *AntiScraper) BadBots(ctx context.Context) ([]BadBotEntry, error) {
+	entries := []BadBotEntry{}
+	now := time.Now()
+	if a.rdb == nil {
+		a.badBotMu.Lock()
+		for _, entry := range a.badBots {
+			if now.Before(entry.ExpiresAt) {
+				entries = append(entries, entry)
+			}
+		}
+		a.badBotMu.Unlock()
+	} else {
+		var cursor uint64
+		for {
+			keys, next, err := a.rdb.Scan(ctx, cursor, antiScrapeBadBotKeyPrefix+"*", 500).Result()
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range keys {
+				fields, err := a.rdb.HGetAll(ctx, key).Result()
+				if err != nil || len(fields) == 0 {
+					continue
+				}
+				ttl, err := a.rdb.TTL(ctx, key).Result()
+				if err != nil || ttl <= 0 {
+					continue
+				}
+				entries = append(entries, badBotFromHash(strings.TrimPrefix(key, antiScrapeBadBotKeyPrefix), fields, now.Add(ttl)))
+			}
+			cursor = next
+			if cursor == 0 {
+				break
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].LastSeen.After(entries[j].LastSeen) })
+	return entries, nil
+}
+
+func badBotFromHash(ip string, fields map[string]string, expiresAt time.Time) BadBotEntry {
+	unix := func(name string) time.Time {
+		ts, _ := strconv.ParseInt(fields[name], 10, 64)
+		return time.Unix(ts, 0)
+	}
+	hits, _ := strconv.ParseInt(fields["hits"], 10, 64)
+	return BadBotEntry{
+		IP:        ip,
+		UA:        fields["ua"],
+		Spider:    fields["spider"],
+		Domain:    fields["domain"],
+		Path:      fields["path"],
+		Hits:      hits,
+		FirstSeen: unix("first_seen"),
+		LastSeen:  unix("last_seen"),
+		ExpiresAt: expiresAt,
+	}
+}
+
+// Pardon 提前赦免：删除坏爬虫标记并解除封禁，返回是否存在标记
+func (a *AntiScraper) Pardon(ctx context.Context, ip string) bool {
+	found := false
+	if a.rdb != nil {
+		n, err := a.rdb.Del(ctx, antiScrapeBadBotKeyPrefix+ip).Result()
+		if err != nil {
+			log.Warn().Err(err).Str("ip", ip).Msg("Failed to remove bad bot from Redis")
+		}
+		found = n > 0
+	} else {
+		a.badBotMu.Lock()
+		_, found = a.badBots[ip]
+		delete(a.badBots, ip)
+		a.badBotMu.Unlock()
+	}
+	a.Unblock(ctx, ip)
+	return found
+}
+
+// cleanupBadBots 清理过期的内存坏爬虫记录（Redis 记录由 TTL 过期）
+func (a *AntiScraper) cleanupBadBots(now time.Time) {
+	a.badBotMu.Lock()
+	for ip, entry := range a.badBots {
+		if now.After(entry.ExpiresAt) {
+			delete(a.badBots, ip)
+		}
+	}
+	a.badBotMu.Unlock()
+}
+
+// RobotsTxt 生成 robots.txt：基础内容（anti_scrape.robots_txt）中每个 User-agent 分组加入蜜罐前缀的 Disallow，
+// 未配置 User-agent 分组时追加 User-agent: * 分组
+func (a *AntiScraper) RobotsTxt() string {
+	base := defaultRobotsTxt
+	if a != nil && strings.TrimSpace(a.config.RobotsTxt) != "" {
+		base = a.config.RobotsTxt
+	}
+	if !a.HoneypotEnabled() {
+		return ensureTrailingNewline(base)
+	}
+	disallow := "Disallow: " + a.config.HoneypotPrefix
+
+	lines := strings.Split(strings.ReplaceAll(base, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines)+4)
+	groups := 0
+	for i, line := range lines {
+		out = append(out, line)
+		if !isRobotsUserAgent(line) {
+			continue
+		}
+		// 连续的 User-agent 行属于同一分组，在最后一行之后插入
+		if i+1 < len(lines) && isRobotsUserAgent(lines[i+1]) {
+			continue
+		}
+		out = append(out, disallow)
+		groups++
+	}
+	result := strings.TrimRight(strings.Join(out, "\n"), "\n") + "\n"
+	if groups == 0 {
+		result += "\nUser-agent: *\n" + disallow + "\n"
+	}
+	return result
+}
+
+func isRobotsUserAgent(line string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "user-agent:")
+}
+
+func ensureTrailingNewline(s string) string {
+	if strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
@@ -8,11 +8,14 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
 )
 
 // CacheStats holds cache statistics with atomic counters
@@ -24,12 +27,45 @@ type CacheStats struct {
 	scanning    atomic.Bool  // 是否正在扫描中
 }
 
-// HTMLCache manages HTML file caching with hash-layered directory structure
-type HTMLCache struct {
+// HTMLCache 页面 HTML 缓存后端
+// 磁盘后端供 Nginx 直接读取；Redis/混合后端让多个实例共享渲染结果
+type HTMLCache interface {
+	// Get 读取缓存页面
+	Get(domain, path string) (string, bool)
+	Set(domain, path, html string) error
+	Delete(domain, path string) error
+	Exists(domain, path string) bool
+	// Clear 清空指定域名（domain 为空时清空全部），返回清除条数
+	Clear(domain string) (int, error)
+	GetStats() map[string]interface{}
+	// Recalculate 启动后台重新统计，RecalculateStatus 查询进度
+	Recalculate() (map[string]interface{}, error)
+	RecalculateStatus() map[string]interface{}
+	// SetQuotaResolver 设置单域名配额，写入后超出配额时按从旧到新淘汰该域名的缓存
+	SetQuotaResolver(fn DomainQuotaResolver)
+	ReloadCacheDir(newDir string) error
+	GetCacheDir() string
+	// Backend 后端类型：disk / redis / hybrid
+	Backend() string
+	// Close 停止后台任务
+	Close()
+}
+
+// HTML 缓存后端类型
+const (
+	HTMLCacheBackendDisk   = "disk"
+	HTMLCacheBackendRedis  = "redis"
+	HTMLCacheBackendHybrid = "hybrid"
+)
+
+// DiskHTMLCache manages HTML file caching with hash-layered directory structure
+type DiskHTMLCache struct {
 	cacheDir  string
 	maxSizeGB float64
 	mu        sync.RWMutex
 	stats     *CacheStats
+	scanner   *cacheScanner
+	quota     *cacheQuota
 }
 
 // CacheMeta holds metadata for a cached file
@@ -41,8 +77,8 @@ type CacheMeta struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
-// NewHTMLCache creates a new HTML cache manager
-func NewHTMLCache(cacheDir string, maxSizeGB float64) *HTMLCache {
+// NewDiskHTMLCache creates a new disk HTML cache manager
+func NewDiskHTMLCache(cacheDir string, cfg config.CacheConfig) *DiskHTMLCache {
 	// Ensure cache directory exists
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		log.Error().Err(err).Str("dir", cacheDir).Msg("Failed to create cache directory")
@@ -54,45 +90,48 @@ func NewHTMLCache(cacheDir string, maxSizeGB float64) *HTMLCache {
 		log.Error().Err(err).Str("dir", metaDir).Msg("Failed to create meta directory")
 	}
 
-	cache := &HTMLCache{
+	cache := &DiskHTMLCache{
 		cacheDir:  cacheDir,
-		maxSizeGB: maxSizeGB,
+		maxSizeGB: cfg.MaxSizeGB,
 		stats:     &CacheStats{},
+		quota:     newCacheQuota(),
 	}
+	cache.scanner = newCacheScanner(cache, cfg.WatchEnabled, cfg.WatchMaxDirs)
 
-	// 启动后台扫描统计
-	go cache.scanAndUpdateStats()
+	// 启动后台扫描统计（存在断点时继续上次未完成的扫描）
+	cache.scanner.start(true)
 
 	log.Info().
 		Str("dir", cacheDir).
-		Float64("max_size_gb", maxSizeGB).
+		Float64("max_size_gb", cfg.MaxSizeGB).
+		Bool("watch", cfg.WatchEnabled).
 		Msg("HTML cache initialized, background scan started")
 
 	return cache
 }
 
 // generateCacheKey generates a cache key from domain and path
-func (c *HTMLCache) generateCacheKey(domain, path string) string {
+func (c *DiskHTMLCache) generateCacheKey(domain, path string) string {
 	raw := domain + ":" + path
 	hash := md5.Sum([]byte(raw))
 	return hex.EncodeToString(hash[:])
 }
 
 // getPathHash generates a hash for the path
-func (c *HTMLCache) getPathHash(path string) string {
+func (c *DiskHTMLCache) getPathHash(path string) string {
 	hash := md5.Sum([]byte(path))
 	return hex.EncodeToString(hash[:])
 }
 
 // getCacheDir returns the current cache directory (thread-safe)
-func (c *HTMLCache) getCacheDirSafe() string {
+func (c *DiskHTMLCache) getCacheDirSafe() string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return c.cacheDir
 }
 
 // normalizePath normalizes a URL path for file storage
-func (c *HTMLCache) normalizePath(path string) string {
+func (c *DiskHTMLCache) normalizePath(path string) string {
 	// Remove leading slashes
 	for len(path) > 0 && path[0] == '/' {
 		path = path[1:]
@@ -112,7 +151,7 @@ func (c *HTMLCache) normalizePath(path string) string {
 }
 
 // getCachePath returns the cache file path using hash-layered structure
-func (c *HTMLCache) getCachePath(domain, path string) string {
+func (c *DiskHTMLCache) getCachePath(domain, path string) string {
 	normalized := c.normalizePath(path)
 	pathHash := c.getPathHash(path)
 	// Structure: {cache_dir}/{domain}/{hash[0:2]}/{hash[2:4]}/{normalized_path}
@@ -120,14 +159,111 @@ func (c *HTMLCache) getCachePath(domain, path string) string {
 }
 
 // getMetaPath returns the metadata file path
-func (c *HTMLCache) getMetaPath(domain, path string) string {
+func (c *DiskHTMLCache) getMetaPath(domain, path string) string {
 	cacheKey := c.generateCacheKey(domain, path)
 	pathHash := c.getPathHash(path)
 	return filepath.Join(c.getCacheDirSafe(), "_meta", domain, pathHash[:2], pathHash[2:4], cacheKey+".json")
 }
 
+// Get reads a cached page from disk
+func (c *DiskHTMLCache) Get(domain, path string) (string, bool) {
+	data, err := os.ReadFile(c.getCachePath(domain, path))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// Backend returns the backend type
+func (c *DiskHTMLCache) Backend() string {
+	return HTMLCacheBackendDisk
+}
+
+// Close 停止后台扫描（保存断点）和目录监听
+func (c *DiskHTMLCache) Close() {
+	c.scanner.close()
+}
+
+// SetQuotaResolver 设置单域名配额
+func (c *DiskHTMLCache) SetQuotaResolver(fn DomainQuotaResolver) {
+	c.quota.setResolver(fn)
+}
+
+// evictDomain 按修改时间从旧到新删除域名缓存，直到条数和大小不超过目标值
+func (c *DiskHTMLCache) evictDomain(domain string, maxEntries, maxBytes int64) (int64, int64, error) {
+	cacheDir := c.getCacheDirSafe()
+	domainDir := filepath.Join(cacheDir, domain)
+
+	var items []evictCandidate
+	err := filepath.WalkDir(domainDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".html" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		items = append(items, evictCandidate{key: path, size: info.Size(), order: info.ModTime().UnixNano()})
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var files, bytes int64
+	metaDirs := make(map[string]struct{})
+	for _, it := range pickEvictions(items, maxEntries, maxBytes) {
+		if err := os.Remove(it.key); err != nil {
+			continue
+		}
+		files++
+		bytes += it.size
+		// 元数据与缓存文件同在 {domain}/{hash[0:2]}/{hash[2:4]} 下
+		if rel, err := filepath.Rel(domainDir, it.key); err == nil {
+			if parts := strings.SplitN(filepath.ToSlash(rel), "/", 3); len(parts) == 3 {
+				metaDirs[filepath.Join(cacheDir, "_meta", domain, parts[0], parts[1])] = struct{}{}
+			}
+		}
+	}
+	for dir := range metaDirs {
+		c.pruneMeta(domain, dir)
+	}
+
+	if files > 0 && c.stats.initialized.Load() && !c.scanner.watching.Load() {
+		c.stats.totalFiles.Add(-files)
+		c.stats.totalBytes.Add(-bytes)
+		c.quota.add(domain, -files, -bytes)
+	}
+	return files, bytes, nil
+}
+
+// pruneMeta 删除缓存文件已不存在的元数据
+func (c *DiskHTMLCache) pruneMeta(domain, metaDir string) {
+	entries, err := os.ReadDir(metaDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		metaPath := filepath.Join(metaDir, e.Name())
+		data, err := os.ReadFile(metaPath)
+		if err != nil {
+			continue
+		}
+		var meta CacheMeta
+		if json.Unmarshal(data, &meta) != nil {
+			continue
+		}
+		if _, err := os.Stat(c.getCachePath(domain, meta.Path)); os.IsNotExist(err) {
+			os.Remove(metaPath)
+		}
+	}
+}
+
 // Set stores HTML content in the cache
-func (c *HTMLCache) Set(domain, path, html string) error {
+func (c *DiskHTMLCache) Set(domain, path, html string) error {
 	cachePath := c.getCachePath(domain, path)
 	metaPath := c.getMetaPath(domain, path)
 
@@ -153,16 +289,21 @@ func (c *HTMLCache) Set(domain, path, html string) error {
 		return err
 	}
 
-	// 更新统计计数器
-	if c.stats.initialized.Load() {
+	// 更新统计计数器（监听模式下由文件事件驱动）
+	if c.stats.initialized.Load() && !c.scanner.watching.Load() {
 		if isNewFile {
 			c.stats.totalFiles.Add(1)
 			c.stats.totalBytes.Add(newSize)
+			c.quota.add(domain, 1, newSize)
 		} else {
 			// 覆盖文件：只更新大小差值
 			c.stats.totalBytes.Add(newSize - oldSize)
+			c.quota.add(domain, 0, newSize-oldSize)
 		}
 	}
+	if c.stats.initialized.Load() {
+		c.quota.enforce(domain, c.evictDomain)
+	}
 
 	// Write metadata
 	meta := CacheMeta{
@@ -182,7 +323,7 @@ func (c *HTMLCache) Set(domain, path, html string) error {
 }
 
 // Delete removes a cached file
-func (c *HTMLCache) Delete(domain, path string) error {
+func (c *DiskHTMLCache) Delete(domain, path string) error {
 	cachePath := c.getCachePath(domain, path)
 	metaPath := c.getMetaPath(domain, path)
 
@@ -196,23 +337,24 @@ func (c *HTMLCache) Delete(domain, path string) error {
 	os.Remove(metaPath)
 
 	// 文件删除成功后更新统计计数器
-	if err1 == nil && c.stats.initialized.Load() {
+	if err1 == nil && c.stats.initialized.Load() && !c.scanner.watching.Load() {
 		c.stats.totalFiles.Add(-1)
 		c.stats.totalBytes.Add(-fileSize)
+		c.quota.add(domain, -1, -fileSize)
 	}
 
 	return nil
 }
 
 // Exists checks if a cache entry exists
-func (c *HTMLCache) Exists(domain, path string) bool {
+func (c *DiskHTMLCache) Exists(domain, path string) bool {
 	cachePath := c.getCachePath(domain, path)
 	_, err := os.Stat(cachePath)
 	return err == nil
 }
 
 // Clear clears all cache for a domain (or all if domain is empty)
-func (c *HTMLCache) Clear(domain string) (int, error) {
+func (c *DiskHTMLCache) Clear(domain string) (int, error) {
 	var count int
 	cacheDir := c.getCacheDirSafe()
 
@@ -222,11 +364,16 @@ func (c *HTMLCache) Clear(domain string) (int, error) {
 		metaDir := filepath.Join(cacheDir, "_meta", domain)
 
 		count = c.countFiles(domainDir)
+		size := c.getDirSize(domainDir)
 		os.RemoveAll(domainDir)
 		os.RemoveAll(metaDir)
 
-		// 清空单个域名后重新扫描以确保准确
-		go c.scanAndUpdateStats()
+		// 按删除的文件数和大小扣减统计（监听模式下由删除事件驱动重算）
+		if c.stats.initialized.Load() && !c.scanner.watching.Load() {
+			c.stats.totalFiles.Add(-int64(count))
+			c.stats.totalBytes.Add(-size)
+			c.scanner.dropDomain(domain)
+		}
 	} else {
 		// Clear all
 		count = c.countFiles(cacheDir)
@@ -247,6 +394,7 @@ func (c *HTMLCache) Clear(domain string) (int, error) {
 		os.MkdirAll(filepath.Join(cacheDir, "_meta"), 0755)
 
 		// 清空所有后重置计数器为 0
+		c.scanner.reset()
 		c.stats.totalFiles.Store(0)
 		c.stats.totalBytes.Store(0)
 		c.stats.lastScanAt.Store(time.Now().Unix())
@@ -257,7 +405,7 @@ func (c *HTMLCache) Clear(domain string) (int, error) {
 }
 
 // countFiles counts HTML files in a directory
-func (c *HTMLCache) countFiles(dir string) int {
+func (c *DiskHTMLCache) countFiles(dir string) int {
 	var count int
 	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err == nil && !info.IsDir() && filepath.Ext(path) == ".html" {
@@ -269,7 +417,7 @@ func (c *HTMLCache) countFiles(dir string) int {
 }
 
 // getDirSize returns the total size of a directory
-func (c *HTMLCache) getDirSize(dir string) int64 {
+func (c *DiskHTMLCache) getDirSize(dir string) int64 {
 	var size int64
 	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err == nil && !info.IsDir() {
@@ -281,7 +429,7 @@ func (c *HTMLCache) getDirSize(dir string) int64 {
 }
 
 // GetStats returns cache statistics (O(1) from memory counters)
-func (c *HTMLCache) GetStats() map[string]interface{} {
+func (c *DiskHTMLCache) GetStats() map[string]interface{} {
 	lastScanAt := c.stats.lastScanAt.Load()
 	var lastScanTime *time.Time
 	if lastScanAt > 0 {
@@ -290,16 +438,19 @@ func (c *HTMLCache) GetStats() map[string]interface{} {
 	}
 
 	return map[string]interface{}{
+		"backend":       HTMLCacheBackendDisk,
 		"total_entries": c.stats.totalFiles.Load(),
 		"total_size_mb": float64(c.stats.totalBytes.Load()) / 1024 / 1024,
 		"initialized":   c.stats.initialized.Load(),
-		"scanning":      c.stats.scanning.Load(),
+		"scanning":      c.scanner.running.Load(),
+		"watching":      c.scanner.watching.Load(),
 		"last_scan_at":  lastScanTime,
+		"domains":       c.quota.usage(),
 	}
 }
 
 // ReloadCacheDir 动态重载缓存目录
-func (c *HTMLCache) ReloadCacheDir(newDir string) error {
+func (c *DiskHTMLCache) ReloadCacheDir(newDir string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -322,79 +473,38 @@ func (c *HTMLCache) ReloadCacheDir(newDir string) error {
 		Str("new_dir", newDir).
 		Msg("Cache directory reloaded")
 
+	// 新目录重新全量扫描（需在释放锁后进行）
+	if oldDir != newDir {
+		go c.scanner.restart()
+	}
 	return nil
 }
 
 // GetCacheDir 获取当前缓存目录
-func (c *HTMLCache) GetCacheDir() string {
+func (c *DiskHTMLCache) GetCacheDir() string {
 	return c.getCacheDirSafe()
 }
 
-// scanAndUpdateStats 扫描目录并更新统计数据
-func (c *HTMLCache) scanAndUpdateStats() {
-	// 防止并发扫描
-	if !c.stats.scanning.CompareAndSwap(false, true) {
-		log.Debug().Msg("Cache scan already in progress, skipping")
-		return
+// Recalculate 启动后台增量扫描（支持断点续扫），通过 RecalculateStatus 查询进度
+func (c *DiskHTMLCache) Recalculate() (map[string]interface{}, error) {
+	started := c.scanner.start(false)
+	message := "已开始后台重新计算"
+	if !started {
+		message = "统计扫描已在进行中"
 	}
-	defer c.stats.scanning.Store(false)
-
-	startTime := time.Now()
-	cacheDir := c.getCacheDirSafe()
-
-	var totalFiles int64
-	var totalBytes int64
-
-	// 使用 WalkDir 比 Walk 更快（减少 stat 调用）
-	err := filepath.WalkDir(cacheDir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil // 忽略错误，继续扫描
-		}
-		if d.IsDir() {
-			return nil
-		}
-		// 只统计 .html 文件
-		if filepath.Ext(path) == ".html" {
-			totalFiles++
-			if info, err := d.Info(); err == nil {
-				totalBytes += info.Size()
-			}
-		}
-		return nil
-	})
-
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to scan cache directory")
-		return
-	}
-
-	// 原子更新统计数据
-	c.stats.totalFiles.Store(totalFiles)
-	c.stats.totalBytes.Store(totalBytes)
-	c.stats.lastScanAt.Store(time.Now().Unix())
-	c.stats.initialized.Store(true)
-
-	duration := time.Since(startTime)
-	log.Info().
-		Int64("files", totalFiles).
-		Int64("bytes", totalBytes).
-		Dur("duration", duration).
-		Msg("Cache directory scan completed")
+	return map[string]interface{}{
+		"started": started,
+		"message": message,
+		"status":  c.scanner.snapshot(),
+	}, nil
 }
 
-// Recalculate 手动触发重新计算统计数据
-func (c *HTMLCache) Recalculate() (map[string]interface{}, error) {
-	startTime := time.Now()
-
-	// 同步执行扫描
-	c.scanAndUpdateStats()
-
-	duration := time.Since(startTime)
-
+// RecalculateStatus 返回统计扫描进度
+func (c *DiskHTMLCache) RecalculateStatus() map[string]interface{} {
 	return map[string]interface{}{
+		"backend":       HTMLCacheBackendDisk,
+		"status":        c.scanner.snapshot(),
 		"total_entries": c.stats.totalFiles.Load(),
 		"total_size_mb": float64(c.stats.totalBytes.Load()) / 1024 / 1024,
-		"duration_ms":   duration.Milliseconds(),
-		"message":       "重新计算完成",
-	}, nil
+	}
 }
@@ -0,0 +1,264 @@
+// Package core provides per-domain HTML cache quotas with oldest-first eviction
+package core
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// cacheQuotaLowWater 超出配额后淘汰到配额的 90%，避免每次写入都触发淘汰
+const cacheQuotaLowWater = 0.9
+
+// DomainCacheQuota 单域名缓存配额，0 表示不限制
+type DomainCacheQuota struct {
+	MaxBytes   int64
+	MaxEntries int64
+}
+
+// Unlimited 是否不限制
+func (q DomainCacheQuota) Unlimited() bool {
+	return q.MaxBytes <= 0 && q.MaxEntries <= 0
+}
+
+// exceeded 用量是否超出配额
+func (q DomainCacheQuota) exceeded(entries, bytes int64) bool {
+	return (q.MaxEntries > 0 && entries > q.MaxEntries) || (q.MaxBytes > 0 && bytes > q.MaxBytes)
+}
+
+// target 淘汰目标（低水位）
+func (q DomainCacheQuota) target() (entries, bytes int64) {
+	entries, bytes = -1, -1
+	if q.MaxEntries > 0 {
+		entries = int64(float64(q.MaxEntries) * cacheQuotaLowWater)
+	}
+	if q.MaxBytes > 0 {
+		bytes = int64(float64(q.MaxBytes) * cacheQuotaLowWater)
+	}
+	return entries, bytes
+}
+
+// DomainQuotaResolver 返回域名的缓存配额
+type DomainQuotaResolver func(domain string) DomainCacheQuota
+
+// NewSiteQuotaResolver 站点配置了 cache_max_size_mb / cache_max_entries 时使用站点配额，
+// 否则使用 cache.domain_max_size_mb / cache.domain_max_entries 默认值
+func NewSiteQuotaResolver(siteCache *SiteCache, cfg config.CacheConfig) DomainQuotaResolver {
+	def := DomainCacheQuota{
+		MaxBytes:   int64(cfg.DomainMaxSizeMB * 1024 * 1024),
+		MaxEntries: int64(cfg.DomainMaxEntries),
+	}
+	return func(domain string) DomainCacheQuota {
+		q := def
+		if siteCache == nil {
+			return q
+		}
+		site, err := siteCache.Get(context.Background(), domain)
+		if err != nil || site == nil {
+			return q
+		}
+		if site.CacheMaxSizeMB.Valid {
+			q.MaxBytes = site.CacheMaxSizeMB.Int64 * 1024 * 1024
+		}
+		if site.CacheMaxEntries.Valid {
+			q.MaxEntries = site.CacheMaxEntries.Int64
+		}
+		return q
+	}
+}
+
+// DomainCacheUsage 单域名缓存用量
+type DomainCacheUsage struct {
+	Domain     string  `json:"domain"`
+	Entries    int64   `json:"entries"`
+	Bytes      int64   `json:"bytes"`
+	SizeMB     float64 `json:"size_mb"`
+	MaxEntries int64   `json:"max_entries"`
+	MaxSizeMB  float64 `json:"max_size_mb"`
+	OverQuota  bool    `json:"over_quota"`
+	Evictions  int64   `json:"evictions"`
+	Evicting   bool    `json:"evicting"`
+}
+
+type domainUsage struct {
+	files     atomic.Int64
+	bytes     atomic.Int64
+	evictions atomic.Int64
+	evicting  atomic.Bool
+}
+
+// cacheQuota 按域名统计缓存用量并在写入后检查配额
+// 用量来源与总数一致：全量扫描结果加上写入/删除增量
+type cacheQuota struct {
+	resolver atomic.Value // DomainQuotaResolver
+
+	mu      sync.RWMutex
+	domains map[string]*domainUsage
+}
+
+func newCacheQuota() *cacheQuota {
+	return &cacheQuota{domains: make(map[string]*domainUsage)}
+}
+
+func (q *cacheQuota) setResolver(fn DomainQuotaResolver) {
+	q.resolver.Store(fn)
+}
+
+func (q *cacheQuota) quota(domain string) DomainCacheQuota {
+	if fn, ok := q.resolver.Load().(DomainQuotaResolver); ok && fn != nil {
+		return fn(domain)
+	}
+	return DomainCacheQuota{}
+}
+
+func (q *cacheQuota) get(domain string) *domainUsage {
+	q.mu.RLock()
+	u := q.domains[domain]
+	q.mu.RUnlock()
+	if u != nil {
+		return u
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if u = q.domains[domain]; u == nil {
+		u = &domainUsage{}
+		q.domains[domain] = u
+	}
+	return u
+}
+
+// add 计入增量
+func (q *cacheQuota) add(domain string, files, bytes int64) {
+	u := q.get(domain)
+	u.files.Add(files)
+	u.bytes.Add(bytes)
+}
+
+// replace 全量扫描完成后替换用量（保留淘汰计数）
+func (q *cacheQuota) replace(usage map[string]cacheBucket) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for domain, u := range q.domains {
+		if _, ok := usage[domain]; !ok && !u.evicting.Load() {
+			delete(q.domains, domain)
+		}
+	}
+	for domain, b := range usage {
+		u := q.domains[domain]
+		if u == nil {
+			u = &domainUsage{}
+			q.domains[domain] = u
+		}
+		u.files.Store(b.Files)
+		u.bytes.Store(b.Bytes)
+	}
+}
+
+func (q *cacheQuota) drop(domain string) {
+	q.mu.Lock()
+	delete(q.domains, domain)
+	q.mu.Unlock()
+}
+
+func (q *cacheQuota) reset() {
+	q.mu.Lock()
+	q.domains = make(map[string]*domainUsage)
+	q.mu.Unlock()
+}
+
+// enforce 写入后检查配额，超出时后台淘汰该域名最旧的缓存
+// evict 按从旧到新删除直到用量不超过目标值，返回删除的条数和字节数
+func (q *cacheQuota) enforce(domain string, evict func(domain string, maxEntries, maxBytes int64) (int64, int64, error)) {
+	quota := q.quota(domain)
+	if quota.Unlimited() {
+		return
+	}
+	u := q.get(domain)
+	if !quota.exceeded(u.files.Load(), u.bytes.Load()) {
+		return
+	}
+	if !u.evicting.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer u.evicting.Store(false)
+		maxEntries, maxBytes := quota.target()
+		files, bytes, err := evict(domain, maxEntries, maxBytes)
+		if err != nil {
+			log.Warn().Err(err).Str("domain", domain).Msg("Failed to evict domain cache")
+		}
+		if files > 0 {
+			u.evictions.Add(files)
+			log.Info().
+				Str("domain", domain).
+				Int64("evicted", files).
+				Int64("bytes", bytes).
+				Int64("max_entries", quota.MaxEntries).
+				Int64("max_bytes", quota.MaxBytes).
+				Msg("Domain cache quota exceeded, oldest entries evicted")
+		}
+	}()
+}
+
+// usage 按占用空间从大到小返回各域名用量
+func (q *cacheQuota) usage() []DomainCacheUsage {
+	q.mu.RLock()
+	out := make([]DomainCacheUsage, 0, len(q.domains))
+	for domain, u := range q.domains {
+		out = append(out, DomainCacheUsage{
+			Domain:    domain,
+			Entries:   u.files.Load(),
+			Bytes:     u.bytes.Load(),
+			Evictions: u.evictions.Load(),
+			Evicting:  u.evicting.Load(),
+		})
+	}
+	q.mu.RUnlock()
+
+	for i := range out {
+		out[i].SizeMB = float64(out[i].Bytes) / 1024 / 1024
+		quota := q.quota(out[i].Domain)
+		out[i].MaxEntries = quota.MaxEntries
+		out[i].MaxSizeMB = float64(quota.MaxBytes) / 1024 / 1024
+		out[i].OverQuota = quota.exceeded(out[i].Entries, out[i].Bytes)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Bytes != out[j].Bytes {
+			return out[i].Bytes > out[j].Bytes
+		}
+		return out[i].Domain < out[j].Domain
+	})
+	return out
+}
+
+// evictCandidate 淘汰候选
+type evictCandidate struct {
+	key   string
+	size  int64
+	order int64 // 越小越旧
+}
+
+// pickEvictions 从最旧开始选出需要删除的条目，使剩余用量不超过目标值（-1 表示不限制）
+func pickEvictions(items []evictCandidate, maxEntries, maxBytes int64) []evictCandidate {
+	var entries, bytes int64
+	for _, it := range items {
+		entries++
+		bytes += it.size
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].order < items[j].order })
+
+	n := 0
+	for n < len(items) && ((maxEntries >= 0 && entries > maxEntries) || (maxBytes >= 0 && bytes > maxBytes)) {
+		entries--
+		bytes -= items[n].size
+		n++
+	}
+	return items[:n]
+}
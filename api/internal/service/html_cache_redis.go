@@ -0,0 +1,724 @@
+// Package core provides Redis and hybrid HTML cache backends shared across instances
+package core
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+const (
+	// redisHTMLCacheOpTimeout 单次 Redis 操作超时，避免 Redis 抖动拖慢页面生成
+	redisHTMLCacheOpTimeout = 2 * time.Second
+	// redisHTMLCacheScanBatch SCAN/UNLINK 每批 key 数
+	redisHTMLCacheScanBatch = 1000
+)
+
+// NewHTMLCacheFromConfig 按 cache.backend 创建 HTML 缓存后端
+// redis/hybrid 后端需要 Redis 可用，否则回退到磁盘缓存
+func NewHTMLCacheFromConfig(cfg config.CacheConfig, cacheDir string, rdb *redis.Client) HTMLCache {
+	backend := cfg.Backend
+	if backend == "" {
+		backend = HTMLCacheBackendDisk
+	}
+	if backend != HTMLCacheBackendDisk && rdb == nil {
+		log.Warn().Str("backend", backend).Msg("Redis not available, HTML cache falls back to disk")
+		backend = HTMLCacheBackendDisk
+	}
+
+	switch backend {
+	case HTMLCacheBackendRedis:
+		return NewRedisHTMLCache(rdb, cfg)
+	case HTMLCacheBackendHybrid:
+		return NewHybridHTMLCache(NewDiskHTMLCache(cacheDir, cfg), NewRedisHTMLCache(rdb, cfg))
+	case HTMLCacheBackendDisk:
+	default:
+		log.Warn().Str("backend", backend).Msg("Unknown HTML cache backend, using disk")
+	}
+	return NewDiskHTMLCache(cacheDir, cfg)
+}
+
+// RedisHTMLCache Redis HTML 缓存
+// key: {prefix}{domain}:{md5(path)}，按 cache.ttl_hours 过期；
+// 统计由后台定期 SCAN + MEMORY USAGE 计算（多实例共享同一份数据，实例内计数器无法准确），
+// 两次扫描之间按本实例的写入增量估算
+type RedisHTMLCache struct {
+	rdb    *redis.Client
+	prefix string
+	ttl    time.Duration
+	stats  *CacheStats
+	quota  *cacheQuota
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRedisHTMLCache 创建 Redis HTML 缓存并启动后台统计
+func NewRedisHTMLCache(rdb *redis.Client, cfg config.CacheConfig) *RedisHTMLCache {
+	prefix := cfg.RedisPrefix
+	if prefix == "" {
+		prefix = "html:"
+	}
+	interval := time.Duration(cfg.RedisStatsIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &RedisHTMLCache{
+		rdb:    rdb,
+		prefix: prefix,
+		ttl:    time.Duration(cfg.TTLHours) * time.Hour,
+		stats:  &CacheStats{},
+		quota:  newCacheQuota(),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	c.wg.Add(1)
+	go c.statsLoop(interval)
+
+	log.Info().
+		Str("prefix", prefix).
+		Dur("ttl", c.ttl).
+		Msg("Redis HTML cache initialized, background scan started")
+	return c
+}
+
+func (c *RedisHTMLCache) key(domain, path string) string {
+	hash := md5.Sum([]byte(path))
+	return c.prefix + domain + ":" + hex.EncodeToString(hash[:])
+}
+
+// domainOf 从 key 中取出域名
+func (c *RedisHTMLCache) domainOf(key string) string {
+	key = strings.TrimPrefix(key, c.prefix)
+	if i := strings.LastIndexByte(key, ':'); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
+
+// matchPattern 域名（为空表示全部）对应的 SCAN 匹配模式
+func (c *RedisHTMLCache) matchPattern(domain string) string {
+	if domain == "" {
+		return escapeRedisGlob(c.prefix) + "*"
+	}
+	return escapeRedisGlob(c.prefix+domain+":") + "*"
+}
+
+func (c *RedisHTMLCache) opContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.ctx, redisHTMLCacheOpTimeout)
+}
+
+// Get 读取缓存页面
+func (c *RedisHTMLCache) Get(domain, path string) (string, bool) {
+	ctx, cancel := c.opContext()
+	defer cancel()
+	html, err := c.rdb.Get(ctx, c.key(domain, path)).Result()
+	if err != nil {
+		if err != redis.Nil {
+			log.Debug().Err(err).Str("domain", domain).Msg("Redis HTML cache get failed")
+		}
+		return "", false
+	}
+	return html, true
+}
+
+// Set 写入缓存页面
+func (c *RedisHTMLCache) Set(domain, path, html string) error {
+	ctx, cancel := c.opContext()
+	defer cancel()
+
+	var oldLen *redis.IntCmd
+	_, err := c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		key := c.key(domain, path)
+		oldLen = pipe.StrLen(ctx, key)
+		pipe.Set(ctx, key, html, c.ttl)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.stats.initialized.Load() {
+		if old := oldLen.Val(); old == 0 {
+			c.stats.totalFiles.Add(1)
+			c.stats.totalBytes.Add(int64(len(html)))
+			c.quota.add(domain, 1, int64(len(html)))
+		} else {
+			c.stats.totalBytes.Add(int64(len(html)) - old)
+			c.quota.add(domain, 0, int64(len(html))-old)
+		}
+		c.quota.enforce(domain, c.evictDomain)
+	}
+	return nil
+}
+
+// SetQuotaResolver 设置单域名配额
+func (c *RedisHTMLCache) SetQuotaResolver(fn DomainQuotaResolver) {
+	c.quota.setResolver(fn)
+}
+
+// evictDomain 删除域名最旧的缓存直到不超过目标值
+// 所有 key 使用相同 TTL，剩余 TTL 越短写入越早；未设置 TTL 时按空闲时间（OBJECT IDLETIME）淘汰
+func (c *RedisHTMLCache) evictDomain(domain string, maxEntries, maxBytes int64) (int64, int64, error) {
+	var items []evictCandidate
+	var iter uint64
+	for {
+		ctx, cancel := c.opContext()
+		keys, next, err := c.rdb.Scan(ctx, iter, c.matchPattern(domain), redisHTMLCacheScanBatch).Result()
+		if err != nil {
+			cancel()
+			return 0, 0, err
+		}
+		if len(keys) > 0 {
+			sizes := make([]*redis.IntCmd, len(keys))
+			ages := make([]redis.Cmder, len(keys))
+			_, err = c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for i, key := range keys {
+					sizes[i] = pipe.StrLen(ctx, key)
+					if c.ttl > 0 {
+						ages[i] = pipe.PTTL(ctx, key)
+					} else {
+						ages[i] = pipe.ObjectIdleTime(ctx, key)
+					}
+				}
+				return nil
+			})
+			if err != nil && err != redis.Nil {
+				cancel()
+				return 0, 0, err
+			}
+			for i, key := range keys {
+				size, err := sizes[i].Result()
+				if err != nil || size == 0 {
+					continue
+				}
+				var order int64
+				switch cmd := ages[i].(type) {
+				case *redis.DurationCmd:
+					d, err := cmd.Result()
+					if err != nil || d < 0 {
+						continue
+					}
+					if c.ttl > 0 {
+						order = int64(d)
+					} else {
+						order = -int64(d)
+					}
+				default:
+					continue
+				}
+				items = append(items, evictCandidate{key: key, size: size, order: order})
+			}
+		}
+		cancel()
+		iter = next
+		if iter == 0 {
+			break
+		}
+	}
+
+	victims := pickEvictions(items, maxEntries, maxBytes)
+	var files, bytes int64
+	for start := 0; start < len(victims); start += redisHTMLCacheScanBatch {
+		end := start + redisHTMLCacheScanBatch
+		if end > len(victims) {
+			end = len(victims)
+		}
+		keys := make([]string, 0, end-start)
+		var batchBytes int64
+		for _, v := range victims[start:end] {
+			keys = append(keys, v.key)
+			batchBytes += v.size
+		}
+		ctx, cancel := c.opContext()
+		n, err := c.rdb.Unlink(ctx, keys...).Result()
+		cancel()
+		if err != nil {
+			return files, bytes, err
+		}
+		files += n
+		bytes += batchBytes
+	}
+
+	if files > 0 && c.stats.initialized.Load() {
+		c.stats.totalFiles.Add(-files)
+		c.stats.totalBytes.Add(-bytes)
+		c.quota.add(domain, -files, -bytes)
+	}
+	return files, bytes, nil
+}
+
+// Delete 删除缓存页面
+func (c *RedisHTMLCache) Delete(domain, path string) error {
+	ctx, cancel := c.opContext()
+	defer cancel()
+
+	key := c.key(domain, path)
+	size, _ := c.rdb.StrLen(ctx, key).Result()
+	n, err := c.rdb.Unlink(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	if n > 0 && c.stats.initialized.Load() {
+		c.stats.totalFiles.Add(-1)
+		c.stats.totalBytes.Add(-size)
+		c.quota.add(domain, -1, -size)
+	}
+	return nil
+}
+
+// Exists 检查缓存是否存在
+func (c *RedisHTMLCache) Exists(domain, path string) bool {
+	ctx, cancel := c.opContext()
+	defer cancel()
+	n, err := c.rdb.Exists(ctx, c.key(domain, path)).Result()
+	return err == nil && n > 0
+}
+
+// Clear 清空指定域名（domain 为空时清空全部）的缓存
+func (c *RedisHTMLCache) Clear(domain string) (int, error) {
+	count := 0
+	var iter uint64
+	for {
+		ctx, cancel := c.opContext()
+		keys, next, err := c.rdb.Scan(ctx, iter, c.matchPattern(domain), redisHTMLCacheScanBatch).Result()
+		if err == nil && len(keys) > 0 {
+			var n int64
+			n, err = c.rdb.Unlink(ctx, keys...).Result()
+			count += int(n)
+		}
+		cancel()
+		if err != nil {
+			return count, err
+		}
+		iter = next
+		if iter == 0 {
+			break
+		}
+	}
+
+	if domain == "" {
+		c.stats.totalFiles.Store(0)
+		c.stats.totalBytes.Store(0)
+		c.stats.lastScanAt.Store(time.Now().Unix())
+		c.quota.reset()
+	} else {
+		c.quota.drop(domain)
+		go c.scanAndUpdateStats()
+	}
+
+	log.Info().Int("count", count).Str("domain", domain).Msg("Redis HTML cache cleared")
+	return count, nil
+}
+
+// GetStats 返回缓存统计（条数和大小来自最近一次扫描加本实例增量）
+func (c *RedisHTMLCache) GetStats() map[string]interface{} {
+	var lastScanTime *time.Time
+	if ts := c.stats.lastScanAt.Load(); ts > 0 {
+		t := time.Unix(ts, 0)
+		lastScanTime = &t
+	}
+
+	stats := map[string]interface{}{
+		"backend":       HTMLCacheBackendRedis,
+		"total_entries": c.stats.totalFiles.Load(),
+		"total_size_mb": float64(c.stats.totalBytes.Load()) / 1024 / 1024,
+		"initialized":   c.stats.initialized.Load(),
+		"scanning":      c.stats.scanning.Load(),
+		"last_scan_at":  lastScanTime,
+		"ttl_hours":     c.ttl.Hours(),
+		"domains":       c.quota.usage(),
+	}
+
+	ctx, cancel := c.opContext()
+	defer cancel()
+	if info, err := c.rdb.Info(ctx, "memory").Result(); err == nil {
+		mem := parseRedisInfo(info)
+		if v, err := strconv.ParseInt(mem["used_memory"], 10, 64); err == nil {
+			stats["redis_used_memory_mb"] = float64(v) / 1024 / 1024
+		}
+		if v, err := strconv.ParseInt(mem["maxmemory"], 10, 64); err == nil && v > 0 {
+			stats["redis_maxmemory_mb"] = float64(v) / 1024 / 1024
+		}
+		stats["redis_maxmemory_policy"] = mem["maxmemory_policy"]
+	}
+	return stats
+}
+
+// Recalculate 启动后台扫描
+func (c *RedisHTMLCache) Recalculate() (map[string]interface{}, error) {
+	started := !c.stats.scanning.Load()
+	message := "统计扫描已在进行中"
+	if started {
+		message = "已开始后台重新计算"
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			if err := c.scanAndUpdateStats(); err != nil && c.ctx.Err() == nil {
+				log.Warn().Err(err).Msg("Failed to scan Redis HTML cache")
+			}
+		}()
+	}
+	return map[string]interface{}{
+		"started": started,
+		"message": message,
+		"status":  c.scanStatus(),
+	}, nil
+}
+
+// RecalculateStatus 返回扫描状态
+func (c *RedisHTMLCache) RecalculateStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"backend":       HTMLCacheBackendRedis,
+		"status":        c.scanStatus(),
+		"total_entries": c.stats.totalFiles.Load(),
+		"total_size_mb": float64(c.stats.totalBytes.Load()) / 1024 / 1024,
+	}
+}
+
+func (c *RedisHTMLCache) scanStatus() CacheScanProgress {
+	p := CacheScanProgress{State: CacheScanIdle}
+	if c.stats.scanning.Load() {
+		p.State = CacheScanRunning
+	} else if ts := c.stats.lastScanAt.Load(); ts > 0 {
+		t := time.Unix(ts, 0)
+		p.State = CacheScanDone
+		p.FinishedAt = &t
+		p.Percent = 100
+	}
+	p.FilesScanned = c.stats.totalFiles.Load()
+	p.BytesScanned = c.stats.totalBytes.Load()
+	return p
+}
+
+// ReloadCacheDir Redis 后端没有缓存目录，忽略
+func (c *RedisHTMLCache) ReloadCacheDir(newDir string) error {
+	return nil
+}
+
+// GetCacheDir Redis 后端没有缓存目录
+func (c *RedisHTMLCache) GetCacheDir() string {
+	return ""
+}
+
+// Backend 后端类型
+func (c *RedisHTMLCache) Backend() string {
+	return HTMLCacheBackendRedis
+}
+
+// Close 停止后台统计
+func (c *RedisHTMLCache) Close() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+func (c *RedisHTMLCache) statsLoop(interval time.Duration) {
+	defer c.wg.Done()
+	if err := c.scanAndUpdateStats(); err != nil && c.ctx.Err() == nil {
+		log.Warn().Err(err).Msg("Failed to scan Redis HTML cache")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.scanAndUpdateStats(); err != nil && c.ctx.Err() == nil {
+				log.Warn().Err(err).Msg("Failed to scan Redis HTML cache")
+			}
+		}
+	}
+}
+
+// scanAndUpdateStats SCAN 全部缓存 key，用 MEMORY USAGE 统计实际占用
+func (c *RedisHTMLCache) scanAndUpdateStats() error {
+	if !c.stats.scanning.CompareAndSwap(false, true) {
+		return nil
+	}
+	defer c.stats.scanning.Store(false)
+
+	startTime := time.Now()
+	var totalKeys, totalBytes int64
+	usage := make(map[string]cacheBucket)
+	var iter uint64
+	for {
+		ctx, cancel := c.opContext()
+		keys, next, err := c.rdb.Scan(ctx, iter, c.matchPattern(""), redisHTMLCacheScanBatch).Result()
+		if err != nil {
+			cancel()
+			return err
+		}
+		if len(keys) > 0 {
+			cmds := make([]*redis.IntCmd, len(keys))
+			_, err = c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for i, key := range keys {
+					cmds[i] = pipe.MemoryUsage(ctx, key, 0)
+				}
+				return nil
+			})
+			// 扫描期间过期的 key 返回 nil，忽略
+			if err != nil && err != redis.Nil {
+				cancel()
+				return err
+			}
+			for i, cmd := range cmds {
+				if v, err := cmd.Result(); err == nil {
+					totalKeys++
+					totalBytes += v
+					domain := c.domainOf(keys[i])
+					u := usage[domain]
+					u.Files++
+					u.Bytes += v
+					usage[domain] = u
+				}
+			}
+		}
+		cancel()
+		iter = next
+		if iter == 0 {
+			break
+		}
+	}
+
+	c.stats.totalFiles.Store(totalKeys)
+	c.stats.totalBytes.Store(totalBytes)
+	c.stats.lastScanAt.Store(time.Now().Unix())
+	c.stats.initialized.Store(true)
+	c.quota.replace(usage)
+
+	log.Info().
+		Int64("keys", totalKeys).
+		Int64("bytes", totalBytes).
+		Dur("duration", time.Since(startTime)).
+		Msg("Redis HTML cache scan completed")
+	return nil
+}
+
+// HybridHTMLCache 磁盘 + Redis 混合缓存
+// 写入同时落盘（供本机 Nginx 直接读取）和 Redis（供其他实例共享）；
+// 本机磁盘未命中而 Redis 命中时回填磁盘；清空操作通过 Redis 发布订阅同步到所有实例的磁盘缓存
+type HybridHTMLCache struct {
+	disk     *DiskHTMLCache
+	redis    *RedisHTMLCache
+	channel  string
+	instance string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// hybridInstanceSeq 同进程多个实例时区分发布者
+var hybridInstanceSeq atomic.Int64
+
+// NewHybridHTMLCache 创建混合缓存并订阅清空事件
+func NewHybridHTMLCache(disk *DiskHTMLCache, rc *RedisHTMLCache) *HybridHTMLCache {
+	host, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &HybridHTMLCache{
+		disk:     disk,
+		redis:    rc,
+		channel:  rc.prefix + "clear",
+		instance: fmt.Sprintf("%s-%d-%d", host, os.Getpid(), hybridInstanceSeq.Add(1)),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	c.wg.Add(1)
+	go c.subscribeClear()
+	return c
+}
+
+// Get 先读本机磁盘，未命中再读 Redis 并回填磁盘
+func (c *HybridHTMLCache) Get(domain, path string) (string, bool) {
+	if html, ok := c.disk.Get(domain, path); ok {
+		return html, true
+	}
+	html, ok := c.redis.Get(domain, path)
+	if !ok {
+		return "", false
+	}
+	go func() {
+		if err := c.disk.Set(domain, path, html); err != nil {
+			log.Warn().Err(err).Str("domain", domain).Str("path", path).Msg("Failed to backfill disk HTML cache")
+		}
+	}()
+	return html, true
+}
+
+// Set 同时写入磁盘和 Redis
+func (c *HybridHTMLCache) Set(domain, path, html string) error {
+	diskErr := c.disk.Set(domain, path, html)
+	redisErr := c.redis.Set(domain, path, html)
+	return errors.Join(diskErr, redisErr)
+}
+
+// Delete 同时删除磁盘和 Redis
+func (c *HybridHTMLCache) Delete(domain, path string) error {
+	diskErr := c.disk.Delete(domain, path)
+	redisErr := c.redis.Delete(domain, path)
+	return errors.Join(diskErr, redisErr)
+}
+
+// Exists 任一层存在即返回 true
+func (c *HybridHTMLCache) Exists(domain, path string) bool {
+	return c.disk.Exists(domain, path) || c.redis.Exists(domain, path)
+}
+
+// Clear 清空 Redis 和本机磁盘，并通知其他实例清空各自的磁盘缓存
+func (c *HybridHTMLCache) Clear(domain string) (int, error) {
+	diskCount, diskErr := c.disk.Clear(domain)
+	redisCount, redisErr := c.redis.Clear(domain)
+
+	ctx, cancel := c.redis.opContext()
+	defer cancel()
+	if err := c.redis.rdb.Publish(ctx, c.channel, c.instance+"|"+domain).Err(); err != nil {
+		log.Warn().Err(err).Msg("Failed to publish HTML cache clear event")
+	}
+
+	count := redisCount
+	if diskCount > count {
+		count = diskCount
+	}
+	return count, errors.Join(diskErr, redisErr)
+}
+
+// GetStats 磁盘统计为主（Nginx 直接命中的部分），附带 Redis 统计
+func (c *HybridHTMLCache) GetStats() map[string]interface{} {
+	stats := c.disk.GetStats()
+	stats["backend"] = HTMLCacheBackendHybrid
+	stats["redis"] = c.redis.GetStats()
+	return stats
+}
+
+// Recalculate 重新计算磁盘和 Redis 统计
+func (c *HybridHTMLCache) Recalculate() (map[string]interface{}, error) {
+	result, err := c.disk.Recalculate()
+	if err != nil {
+		return nil, err
+	}
+	redisResult, err := c.redis.Recalculate()
+	if err != nil {
+		return nil, err
+	}
+	result["redis"] = redisResult
+	return result, nil
+}
+
+// RecalculateStatus 磁盘和 Redis 扫描状态
+func (c *HybridHTMLCache) RecalculateStatus() map[string]interface{} {
+	status := c.disk.RecalculateStatus()
+	status["backend"] = HTMLCacheBackendHybrid
+	status["redis"] = c.redis.RecalculateStatus()
+	return status
+}
+
+// SetQuotaResolver 磁盘和 Redis 分别按配额淘汰
+func (c *HybridHTMLCache) SetQuotaResolver(fn DomainQuotaResolver) {
+	c.disk.SetQuotaResolver(fn)
+	c.redis.SetQuotaResolver(fn)
+}
+
+// ReloadCacheDir 重载磁盘缓存目录
+func (c *HybridHTMLCache) ReloadCacheDir(newDir string) error {
+	return c.disk.ReloadCacheDir(newDir)
+}
+
+// GetCacheDir 磁盘缓存目录
+func (c *HybridHTMLCache) GetCacheDir() string {
+	return c.disk.GetCacheDir()
+}
+
+// Backend 后端类型
+func (c *HybridHTMLCache) Backend() string {
+	return HTMLCacheBackendHybrid
+}
+
+// Close 停止订阅和后台统计
+func (c *HybridHTMLCache) Close() {
+	c.cancel()
+	c.wg.Wait()
+	c.redis.Close()
+	c.disk.Close()
+}
+
+// subscribeClear 接收其他实例的清空事件，清空本机磁盘缓存
+func (c *HybridHTMLCache) subscribeClear() {
+	defer c.wg.Done()
+	for c.ctx.Err() == nil {
+		sub := c.redis.rdb.Subscribe(c.ctx, c.channel)
+		ch := sub.Channel()
+	loop:
+		for {
+			select {
+			case <-c.ctx.Done():
+				sub.Close()
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					break loop
+				}
+				from, domain, _ := strings.Cut(msg.Payload, "|")
+				if from == c.instance {
+					continue
+				}
+				if _, err := c.disk.Clear(domain); err != nil {
+					log.Warn().Err(err).Str("domain", domain).Msg("Failed to clear disk HTML cache on remote event")
+				}
+			}
+		}
+		sub.Close()
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// parseRedisInfo 解析 INFO 输出为 key -> value
+func parseRedisInfo(info string) map[string]string {
+	out := make(map[string]string)
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// escapeRedisGlob 转义 SCAN MATCH 的通配符
+func escapeRedisGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
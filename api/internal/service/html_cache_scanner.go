@@ -0,0 +1,669 @@
+// Package core provides incremental, checkpointed statistics scanning for the disk HTML cache
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// cacheScanCheckpointFile 扫描断点文件（位于 _meta 目录）
+	cacheScanCheckpointFile = "stats_checkpoint.json"
+	// cacheScanCheckpointInterval 断点保存间隔
+	cacheScanCheckpointInterval = 10 * time.Second
+	// cacheWatchFlushInterval 监听模式下合并重算脏分桶的间隔
+	cacheWatchFlushInterval = 2 * time.Second
+	// defaultCacheWatchMaxDirs 监听目录数上限（受 fs.inotify.max_user_watches 限制）
+	defaultCacheWatchMaxDirs = 50000
+)
+
+// 扫描状态
+const (
+	CacheScanIdle      = "idle"
+	CacheScanRunning   = "running"
+	CacheScanDone      = "done"
+	CacheScanFailed    = "failed"
+	CacheScanCancelled = "cancelled"
+)
+
+// CacheScanProgress 缓存统计扫描进度
+type CacheScanProgress struct {
+	State         string     `json:"state"`
+	Resumed       bool       `json:"resumed"`
+	StartedAt     *time.Time `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+	DomainsTotal  int        `json:"domains_total"`
+	DomainsDone   int        `json:"domains_done"`
+	CurrentDomain string     `json:"current_domain,omitempty"`
+	FilesScanned  int64      `json:"files_scanned"`
+	BytesScanned  int64      `json:"bytes_scanned"`
+	Percent       float64    `json:"percent"`
+	Error         string     `json:"error,omitempty"`
+	Watching      bool       `json:"watching"`
+	WatchedDirs   int        `json:"watched_dirs"`
+}
+
+// cacheBucket 分桶统计（{domain}/{hash[0:2]} 一级目录）
+type cacheBucket struct {
+	Files int64 `json:"f"`
+	Bytes int64 `json:"b"`
+}
+
+// cacheScanCheckpoint 扫描断点：已完成的域名及其分桶统计
+type cacheScanCheckpoint struct {
+	CacheDir  string                 `json:"cache_dir"`
+	StartedAt time.Time              `json:"started_at"`
+	Completed []string               `json:"completed"`
+	Buckets   map[string]cacheBucket `json:"buckets"`
+}
+
+// cacheScanner 磁盘缓存统计扫描器
+// 全量扫描按域名分批进行并定期保存断点，进程重启后从断点继续；
+// 开启监听模式后用 fsnotify 监听缓存目录，变化的分桶合并后重新统计，统计持续保持准确
+type cacheScanner struct {
+	cache        *DiskHTMLCache
+	watchEnabled bool
+	maxWatchDirs int
+
+	mu        sync.Mutex
+	progress  CacheScanProgress
+	buckets   map[string]cacheBucket
+	dirty     map[string]struct{}
+	watcher   *fsnotify.Watcher
+	watchDir  string
+	runCancel context.CancelFunc
+	runDone   chan struct{}
+
+	running  atomic.Bool
+	watching atomic.Bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newCacheScanner(cache *DiskHTMLCache, watchEnabled bool, maxWatchDirs int) *cacheScanner {
+	if maxWatchDirs <= 0 {
+		maxWatchDirs = defaultCacheWatchMaxDirs
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &cacheScanner{
+		cache:        cache,
+		watchEnabled: watchEnabled,
+		maxWatchDirs: maxWatchDirs,
+		progress:     CacheScanProgress{State: CacheScanIdle},
+		buckets:      make(map[string]cacheBucket),
+		dirty:        make(map[string]struct{}),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// start 启动后台全量扫描，已在扫描时返回 false
+func (s *cacheScanner) start(resume bool) bool {
+	if !s.running.CompareAndSwap(false, true) {
+		return false
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	done := make(chan struct{})
+
+	s.mu.Lock()
+	s.runCancel = cancel
+	s.runDone = done
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(done)
+		defer s.running.Store(false)
+		defer cancel()
+		s.run(ctx, resume)
+	}()
+	return true
+}
+
+// stopRun 取消正在进行的扫描并等待退出
+func (s *cacheScanner) stopRun() {
+	s.mu.Lock()
+	cancel, done := s.runCancel, s.runDone
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// restart 缓存目录变化后停止监听并重新全量扫描
+func (s *cacheScanner) restart() {
+	s.stopRun()
+	s.stopWatcher()
+	os.Remove(s.checkpointPath(s.cache.getCacheDirSafe()))
+
+	s.mu.Lock()
+	s.buckets = make(map[string]cacheBucket)
+	s.dirty = make(map[string]struct{})
+	s.mu.Unlock()
+	s.start(false)
+}
+
+// close 停止扫描（保存断点）和监听
+func (s *cacheScanner) close() {
+	s.cancel()
+	s.stopWatcher()
+	s.wg.Wait()
+}
+
+// snapshot 返回扫描进度
+func (s *cacheScanner) snapshot() CacheScanProgress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.progress
+	if p.DomainsTotal > 0 {
+		p.Percent = float64(p.DomainsDone) / float64(p.DomainsTotal) * 100
+	} else if p.State == CacheScanDone {
+		p.Percent = 100
+	}
+	p.Watching = s.watching.Load()
+	return p
+}
+
+func (s *cacheScanner) checkpointPath(cacheDir string) string {
+	return filepath.Join(cacheDir, "_meta", cacheScanCheckpointFile)
+}
+
+func (s *cacheScanner) loadCheckpoint(cacheDir string) *cacheScanCheckpoint {
+	data, err := os.ReadFile(s.checkpointPath(cacheDir))
+	if err != nil {
+		return nil
+	}
+	var cp cacheScanCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil || cp.CacheDir != cacheDir {
+		return nil
+	}
+	if cp.Buckets == nil {
+		cp.Buckets = make(map[string]cacheBucket)
+	}
+	return &cp
+}
+
+func (s *cacheScanner) saveCheckpoint(cp *cacheScanCheckpoint) {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return
+	}
+	path := s.checkpointPath(cp.CacheDir)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		log.Warn().Err(err).Msg("Failed to save cache scan checkpoint")
+		return
+	}
+	os.Rename(tmp, path)
+}
+
+func (s *cacheScanner) updateProgress(fn func(p *CacheScanProgress)) {
+	s.mu.Lock()
+	fn(&s.progress)
+	s.mu.Unlock()
+}
+
+// run 按域名扫描缓存目录，每个域名完成后计入断点
+// 扫描期间本实例的写入仍累加到旧计数器，扫描结束时以扫描结果为准
+func (s *cacheScanner) run(ctx context.Context, resume bool) {
+	startTime := time.Now()
+	cacheDir := s.cache.getCacheDirSafe()
+
+	cp := &cacheScanCheckpoint{CacheDir: cacheDir, StartedAt: startTime, Buckets: make(map[string]cacheBucket)}
+	if resume {
+		if saved := s.loadCheckpoint(cacheDir); saved != nil {
+			cp = saved
+		}
+	}
+	resumed := len(cp.Completed) > 0
+	completed := make(map[string]bool, len(cp.Completed))
+	for _, d := range cp.Completed {
+		completed[d] = true
+	}
+
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	var domains []string
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != "_meta" {
+			domains = append(domains, e.Name())
+		}
+	}
+
+	var files, bytes int64
+	for _, b := range cp.Buckets {
+		files += b.Files
+		bytes += b.Bytes
+	}
+	done := 0
+	for _, d := range domains {
+		if completed[d] {
+			done++
+		}
+	}
+	s.updateProgress(func(p *CacheScanProgress) {
+		*p = CacheScanProgress{
+			State:        CacheScanRunning,
+			Resumed:      resumed,
+			StartedAt:    &startTime,
+			DomainsTotal: len(domains),
+			DomainsDone:  done,
+			FilesScanned: files,
+			BytesScanned: bytes,
+			WatchedDirs:  p.WatchedDirs,
+		}
+	})
+	s.cache.stats.scanning.Store(true)
+	defer s.cache.stats.scanning.Store(false)
+
+	var watchDirs []string
+	collectDirs := s.watchEnabled && !s.watching.Load()
+	lastSave := time.Now()
+
+	for _, domain := range domains {
+		if completed[domain] {
+			continue
+		}
+		s.updateProgress(func(p *CacheScanProgress) { p.CurrentDomain = domain })
+
+		buckets, dirs, err := s.scanDomain(ctx, cacheDir, domain, collectDirs)
+		if err != nil {
+			if ctx.Err() != nil {
+				s.saveCheckpoint(cp)
+				now := time.Now()
+				s.updateProgress(func(p *CacheScanProgress) {
+					p.State = CacheScanCancelled
+					p.FinishedAt = &now
+					p.CurrentDomain = ""
+				})
+				log.Info().Int("domains_done", len(cp.Completed)).Msg("Cache scan cancelled, checkpoint saved")
+				return
+			}
+			log.Warn().Err(err).Str("domain", domain).Msg("Failed to scan cache domain")
+		}
+		if collectDirs {
+			watchDirs = append(watchDirs, dirs...)
+			if len(watchDirs) > s.maxWatchDirs {
+				collectDirs = false
+				watchDirs = nil
+				log.Warn().Int("max_dirs", s.maxWatchDirs).Msg("Cache directory count exceeds watch limit, watch mode disabled")
+			}
+		}
+
+		var df, db int64
+		for k, b := range buckets {
+			cp.Buckets[k] = b
+			df += b.Files
+			db += b.Bytes
+		}
+		cp.Completed = append(cp.Completed, domain)
+		s.updateProgress(func(p *CacheScanProgress) {
+			p.DomainsDone++
+			p.FilesScanned += df
+			p.BytesScanned += db
+		})
+
+		if time.Since(lastSave) >= cacheScanCheckpointInterval {
+			s.saveCheckpoint(cp)
+			lastSave = time.Now()
+		}
+	}
+
+	// 断点中可能包含已被删除的域名
+	present := make(map[string]bool, len(domains))
+	for _, d := range domains {
+		present[d] = true
+	}
+	var totalFiles, totalBytes int64
+	for k, b := range cp.Buckets {
+		if domain, _, _ := strings.Cut(k, "/"); !present[domain] {
+			delete(cp.Buckets, k)
+			continue
+		}
+		totalFiles += b.Files
+		totalBytes += b.Bytes
+	}
+
+	s.mu.Lock()
+	s.buckets = cp.Buckets
+	s.mu.Unlock()
+	s.cache.quota.replace(domainUsageFromBuckets(cp.Buckets))
+
+	s.cache.stats.totalFiles.Store(totalFiles)
+	s.cache.stats.totalBytes.Store(totalBytes)
+	s.cache.stats.lastScanAt.Store(time.Now().Unix())
+	s.cache.stats.initialized.Store(true)
+	os.Remove(s.checkpointPath(cacheDir))
+
+	now := time.Now()
+	s.updateProgress(func(p *CacheScanProgress) {
+		p.State = CacheScanDone
+		p.FinishedAt = &now
+		p.CurrentDomain = ""
+		p.FilesScanned = totalFiles
+		p.BytesScanned = totalBytes
+	})
+
+	log.Info().
+		Int64("files", totalFiles).
+		Int64("bytes", totalBytes).
+		Int("domains", len(domains)).
+		Bool("resumed", resumed).
+		Dur("duration", time.Since(startTime)).
+		Msg("Cache directory scan completed")
+
+	if s.watchEnabled && !s.watching.Load() {
+		if collectDirs || resumed {
+			s.startWatcher(cacheDir, watchDirs, resumed)
+		}
+	}
+}
+
+func (s *cacheScanner) fail(err error) {
+	now := time.Now()
+	s.updateProgress(func(p *CacheScanProgress) {
+		p.State = CacheScanFailed
+		p.FinishedAt = &now
+		p.Error = err.Error()
+	})
+	log.Error().Err(err).Msg("Failed to scan cache directory")
+}
+
+// scanDomain 统计单个域名目录，按一级子目录分桶
+func (s *cacheScanner) scanDomain(ctx context.Context, cacheDir, domain string, collectDirs bool) (map[string]cacheBucket, []string, error) {
+	domainDir := filepath.Join(cacheDir, domain)
+	entries, err := os.ReadDir(domainDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buckets := make(map[string]cacheBucket, len(entries))
+	var dirs []string
+	if collectDirs {
+		dirs = append(dirs, domainDir)
+	}
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return buckets, dirs, err
+		}
+		b, bucketDirs, err := countCacheBucket(ctx, filepath.Join(domainDir, e.Name()), collectDirs)
+		if err != nil && ctx.Err() != nil {
+			return buckets, dirs, err
+		}
+		if b.Files > 0 {
+			buckets[domain+"/"+e.Name()] = b
+		}
+		dirs = append(dirs, bucketDirs...)
+	}
+	return buckets, dirs, nil
+}
+
+// countCacheBucket 统计目录下的 .html 文件
+func countCacheBucket(ctx context.Context, root string, collectDirs bool) (cacheBucket, []string, error) {
+	var b cacheBucket
+	var dirs []string
+	n := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if n++; n%1000 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			if collectDirs {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		if filepath.Ext(path) == ".html" {
+			b.Files++
+			if info, err := d.Info(); err == nil {
+				b.Bytes += info.Size()
+			}
+		}
+		return nil
+	})
+	return b, dirs, err
+}
+
+// startWatcher 监听缓存目录变化
+// 断点恢复的扫描没有收集完整目录列表，此时重新遍历目录建立监听
+func (s *cacheScanner) startWatcher(cacheDir string, dirs []string, collect bool) {
+	if collect {
+		dirs = nil
+		filepath.WalkDir(cacheDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			if d.Name() == "_meta" {
+				return filepath.SkipDir
+			}
+			if path != cacheDir {
+				dirs = append(dirs, path)
+			}
+			if len(dirs) > s.maxWatchDirs {
+				return filepath.SkipAll
+			}
+			return nil
+		})
+		if len(dirs) > s.maxWatchDirs {
+			log.Warn().Int("max_dirs", s.maxWatchDirs).Msg("Cache directory count exceeds watch limit, watch mode disabled")
+			return
+		}
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to create cache watcher")
+		return
+	}
+	if err := w.Add(cacheDir); err != nil {
+		w.Close()
+		log.Warn().Err(err).Msg("Failed to watch cache directory")
+		return
+	}
+	for _, d := range dirs {
+		if err := w.Add(d); err != nil {
+			w.Close()
+			log.Warn().Err(err).Int("dirs", len(dirs)).Msg("Failed to watch cache directory (check fs.inotify.max_user_watches)")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	s.watcher = w
+	s.watchDir = cacheDir
+	s.progress.WatchedDirs = len(dirs) + 1
+	s.mu.Unlock()
+	s.watching.Store(true)
+
+	s.wg.Add(1)
+	go s.watchLoop(w, cacheDir)
+	log.Info().Int("dirs", len(dirs)+1).Msg("Cache watch mode started")
+}
+
+func (s *cacheScanner) stopWatcher() {
+	s.mu.Lock()
+	w := s.watcher
+	s.watcher = nil
+	s.mu.Unlock()
+	if w != nil {
+		w.Close()
+	}
+	s.watching.Store(false)
+}
+
+func (s *cacheScanner) watchLoop(w *fsnotify.Watcher, cacheDir string) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(cacheWatchFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case ev, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			s.handleEvent(w, cacheDir, ev)
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			// 事件队列溢出时无法确定哪些分桶变化，退回全量扫描
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				log.Warn().Msg("Cache watch event overflow, starting full rescan")
+				s.start(false)
+				continue
+			}
+			log.Warn().Err(err).Msg("Cache watcher error")
+		case <-ticker.C:
+			s.flushDirty(cacheDir)
+			// 被删除的目录会自动移出监听，按实际监听数校正
+			watched := len(w.WatchList())
+			s.mu.Lock()
+			s.progress.WatchedDirs = watched
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *cacheScanner) handleEvent(w *fsnotify.Watcher, cacheDir string, ev fsnotify.Event) {
+	rel, err := filepath.Rel(cacheDir, ev.Name)
+	if err != nil {
+		return
+	}
+	parts := strings.SplitN(filepath.ToSlash(rel), "/", 3)
+	if parts[0] == "_meta" || parts[0] == "." {
+		return
+	}
+
+	// 新建目录需要加入监听（inotify 不递归）
+	if ev.Has(fsnotify.Create) {
+		if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+			s.watchNewDir(w, ev.Name)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(parts) == 1 {
+		// 域名目录本身被删除或重命名：该域名所有分桶都需要重算
+		if ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+			prefix := parts[0] + "/"
+			for k := range s.buckets {
+				if strings.HasPrefix(k, prefix) {
+					s.dirty[k] = struct{}{}
+				}
+			}
+		}
+		return
+	}
+	s.dirty[parts[0]+"/"+parts[1]] = struct{}{}
+}
+
+func (s *cacheScanner) watchNewDir(w *fsnotify.Watcher, root string) {
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		s.mu.Lock()
+		full := s.progress.WatchedDirs >= s.maxWatchDirs
+		if !full {
+			s.progress.WatchedDirs++
+		}
+		s.mu.Unlock()
+		if full {
+			return filepath.SkipAll
+		}
+		if err := w.Add(path); err != nil {
+			log.Debug().Err(err).Str("dir", path).Msg("Failed to watch new cache directory")
+		}
+		return nil
+	})
+}
+
+// flushDirty 重新统计变化的分桶并把差值计入总数
+func (s *cacheScanner) flushDirty(cacheDir string) {
+	s.mu.Lock()
+	if len(s.dirty) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	dirty := s.dirty
+	s.dirty = make(map[string]struct{})
+	s.mu.Unlock()
+
+	for key := range dirty {
+		b, _, _ := countCacheBucket(s.ctx, filepath.Join(cacheDir, filepath.FromSlash(key)), false)
+
+		s.mu.Lock()
+		old := s.buckets[key]
+		if b.Files == 0 {
+			delete(s.buckets, key)
+		} else {
+			s.buckets[key] = b
+		}
+		s.mu.Unlock()
+
+		s.cache.stats.totalFiles.Add(b.Files - old.Files)
+		s.cache.stats.totalBytes.Add(b.Bytes - old.Bytes)
+		domain, _, _ := strings.Cut(key, "/")
+		s.cache.quota.add(domain, b.Files-old.Files, b.Bytes-old.Bytes)
+	}
+}
+
+// domainUsageFromBuckets 分桶统计汇总为域名用量
+func domainUsageFromBuckets(buckets map[string]cacheBucket) map[string]cacheBucket {
+	usage := make(map[string]cacheBucket)
+	for k, b := range buckets {
+		domain, _, _ := strings.Cut(k, "/")
+		u := usage[domain]
+		u.Files += b.Files
+		u.Bytes += b.Bytes
+		usage[domain] = u
+	}
+	return usage
+}
+
+// reset 清空全部缓存后重置分桶
+func (s *cacheScanner) reset() {
+	s.mu.Lock()
+	s.buckets = make(map[string]cacheBucket)
+	s.dirty = make(map[string]struct{})
+	s.mu.Unlock()
+	s.cache.quota.reset()
+}
+
+// dropDomain 清空域名缓存后移除其分桶（非监听模式，监听模式由事件驱动重算）
+func (s *cacheScanner) dropDomain(domain string) {
+	prefix := domain + "/"
+	s.mu.Lock()
+	for k := range s.buckets {
+		if strings.HasPrefix(k, prefix) {
+			delete(s.buckets, k)
+		}
+	}
+	s.mu.Unlock()
+	s.cache.quota.drop(domain)
+}
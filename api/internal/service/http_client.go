@@ -0,0 +1,461 @@
+// Package core provides the shared outbound HTTP client (retries, per-host circuit breaker, egress rate limit)
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// ErrCircuitOpen 目标主机熔断中，请求未发出
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// HostBreakerStats 单个主机的调用和熔断统计
+type HostBreakerStats struct {
+	Host        string     `json:"host"`
+	State       string     `json:"state"`
+	Failures    int        `json:"failures"` // 连续失败次数
+	Requests    int64      `json:"requests"` // 实际发出的请求（含重试）
+	Errors      int64      `json:"errors"`
+	Retries     int64      `json:"retries"`
+	Rejected    int64      `json:"rejected"` // 熔断期间拒绝的请求
+	OpenedAt    *time.Time `json:"opened_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// hostBreaker 单个主机的熔断器：连续失败达到阈值后熔断，熔断期满放行一次探测，探测成功后恢复
+type hostBreaker struct {
+	mu          sync.Mutex
+	state       string
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	lastError   string
+	lastErrorAt time.Time
+
+	requests, errors, retries, rejected atomic.Int64
+}
+
+// allow 是否放行请求
+func (b *hostBreaker) allow(openFor time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < openFor {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// record 记录一次请求结果，返回是否由此触发熔断
+func (b *hostBreaker) record(err error, threshold int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil {
+		b.state = BreakerClosed
+		b.failures = 0
+		return false
+	}
+	b.failures++
+	b.lastError = err.Error()
+	b.lastErrorAt = time.Now()
+	if threshold > 0 && (b.state == BreakerHalfOpen || b.failures >= threshold) {
+		opened := b.state != BreakerOpen
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		return opened
+	}
+	return false
+}
+
+// release 结束探测但不记录结果
+func (b *hostBreaker) release() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// egressLimiter 全局出口限速（令牌桶）
+type egressLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// wait 等待一个令牌，ctx 取消时返回 ctx.Err()
+func (l *egressLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// HTTPClient 外部 HTTP 调用共用的客户端
+// 所有外部集成通过 Client 获取 *http.Client，共享连接池、代理、全局限速和按主机的熔断；
+// 网络错误、429 和 5xx 按指数退避加抖动重试（非幂等请求只在 429/503 即服务端未处理时重试，
+// 且请求体可重放），Retry-After 不超过退避上限时优先使用
+type HTTPClient struct {
+	config    config.OutboundHTTPConfig
+	transport *http.Transport
+	limiter   *egressLimiter
+	breakers  sync.Map // host -> *hostBreaker
+	auditor   atomic.Pointer[EgressAuditor]
+}
+
+// 全局客户端
+var globalHTTPClient atomic.Pointer[HTTPClient]
+
+// InitHTTPClient 按配置创建全局客户端（启动时在创建各外部集成之前调用）
+func InitHTTPClient(cfg config.OutboundHTTPConfig) (*HTTPClient, error) {
+	c, err := NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	globalHTTPClient.Store(c)
+	return c, nil
+}
+
+// GetHTTPClient 获取全局客户端，未初始化时使用默认配置
+func GetHTTPClient() *HTTPClient {
+	if c := globalHTTPClient.Load(); c != nil {
+		return c
+	}
+	c, _ := NewHTTPClient(config.OutboundHTTPConfig{TimeoutSeconds: 15, MaxRetries: 2, RetryBaseMs: 200, RetryMaxMs: 5000, BreakerFailures: 5, BreakerOpenSeconds: 30})
+	if globalHTTPClient.CompareAndSwap(nil, c) {
+		return c
+	}
+	return globalHTTPClient.Load()
+}
+
+// NewHTTPClient 创建客户端
+func NewHTTPClient(cfg config.OutboundHTTPConfig) (*HTTPClient, error) {
+	if cfg.TimeoutSeconds <= 0 {
+		cfg.TimeoutSeconds = 15
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBaseMs <= 0 {
+		cfg.RetryBaseMs = 200
+	}
+	if cfg.RetryMaxMs < cfg.RetryBaseMs {
+		cfg.RetryMaxMs = cfg.RetryBaseMs
+	}
+	if cfg.BreakerOpenSeconds <= 0 {
+		cfg.BreakerOpenSeconds = 30
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if cfg.Proxy != "" {
+		u, err := url.Parse(cfg.Proxy)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid outbound_http.proxy: %q", cfg.Proxy)
+		}
+		proxy = http.ProxyURL(u)
+	}
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           (&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	c := &HTTPClient{config: cfg, transport: transport}
+	if cfg.RateLimit > 0 {
+		burst := float64(cfg.RateBurst)
+		if burst < 1 {
+			burst = 1
+		}
+		c.limiter = &egressLimiter{rate: cfg.RateLimit, burst: burst, tokens: burst, last: time.Now()}
+	}
+	return c, nil
+}
+
+// Client 返回使用共享传输层的 *http.Client
+// purpose 为调用用途（EgressPurpose*，记入出站审计日志），timeout 为整个调用（含重试）的超时，<= 0 时使用配置的默认值
+func (c *HTTPClient) Client(purpose string, timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = time.Duration(c.config.TimeoutSeconds) * time.Second
+	}
+	return &http.Client{Transport: &purposeTransport{client: c, purpose: purpose}, Timeout: timeout}
+}
+
+// SetAuditor 设置出站审计日志，每次实际发出的请求（含重试）记录一条
+func (c *HTTPClient) SetAuditor(a *EgressAuditor) {
+	c.auditor.Store(a)
+}
+
+// purposeTransport 带用途标签的 http.RoundTripper
+type purposeTransport struct {
+	client  *HTTPClient
+	purpose string
+}
+
+func (t *purposeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.client.roundTrip(req, t.purpose)
+}
+
+// send 发出单次请求并记录出站审计日志（响应体读完或关闭时记录收到的字节数）
+func (c *HTTPClient) send(req *http.Request, purpose string, attempt int) (*http.Response, error) {
+	auditor := c.auditor.Load()
+	if auditor == nil {
+		return c.transport.RoundTrip(req)
+	}
+	rec := EgressRecord{
+		Host:      req.URL.Host,
+		Method:    req.Method,
+		Path:      req.URL.Path,
+		Purpose:   purpose,
+		Attempt:   attempt + 1,
+		CreatedAt: time.Now(),
+	}
+	if req.ContentLength > 0 {
+		rec.BytesOut = req.ContentLength
+	}
+	resp, err := c.transport.RoundTrip(req)
+	if err != nil {
+		rec.Error = err.Error()
+		rec.DurationMs = int(time.Since(rec.CreatedAt).Milliseconds())
+		auditor.Record(rec)
+		return nil, err
+	}
+	rec.Status = resp.StatusCode
+	resp.Body = &egressBody{ReadCloser: resp.Body, rec: rec, auditor: auditor}
+	return resp, nil
+}
+
+// roundTrip 限速、熔断和重试
+func (c *HTTPClient) roundTrip(req *http.Request, purpose string) (*http.Response, error) {
+	host := req.URL.Host
+	b := c.breaker(host)
+	openFor := time.Duration(c.config.BreakerOpenSeconds) * time.Second
+	idempotent := isIdempotentMethod(req.Method)
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.wait(req.Context()); err != nil {
+			return nil, err
+		}
+		if c.config.BreakerFailures > 0 && !b.allow(openFor) {
+			b.rejected.Add(1)
+			return nil, fmt.Errorf("%s: %w", host, ErrCircuitOpen)
+		}
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				b.release()
+				return nil, err
+			}
+			// RoundTripper 不应修改调用方的请求，重试使用副本
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		b.requests.Add(1)
+		resp, err := c.send(req, purpose, attempt)
+		failure := err
+		if err == nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500) {
+			failure = fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		if failure != nil {
+			b.errors.Add(1)
+		}
+		if c.config.BreakerFailures > 0 {
+			if req.Context().Err() != nil {
+				b.release() // 调用方取消不计入主机失败
+			} else if b.record(failure, c.config.BreakerFailures) {
+				log.Warn().Str("host", host).Str("error", failure.Error()).Int("open_seconds", c.config.BreakerOpenSeconds).Msg("Outbound circuit breaker opened")
+			}
+		}
+
+		if failure == nil || attempt >= c.config.MaxRetries || !replayable || req.Context().Err() != nil ||
+			!shouldRetry(resp, err, idempotent) {
+			return resp, err
+		}
+		delay := c.backoff(attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		b.retries.Add(1)
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry 幂等请求在网络错误、429、5xx 时重试；非幂等请求只在 429/503 时重试
+func shouldRetry(resp *http.Response, err error, idempotent bool) bool {
+	if err != nil {
+		return idempotent
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout, http.StatusInternalServerError:
+		return idempotent
+	}
+	return false
+}
+
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff 第 attempt 次失败后的等待时间：Retry-After（不超过上限）或指数退避加随机抖动
+func (c *HTTPClient) backoff(attempt int, resp *http.Response) time.Duration {
+	max := time.Duration(c.config.RetryMaxMs) * time.Millisecond
+	if resp != nil {
+		if secs, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get("Retry-After"))); err == nil && secs >= 0 {
+			if d := time.Duration(secs) * time.Second; d <= max {
+				return d
+			}
+		}
+	}
+	d := time.Duration(c.config.RetryBaseMs) * time.Millisecond << uint(attempt)
+	if d > max || d <= 0 {
+		d = max
+	}
+	// 抖动：[d/2, d)
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (c *HTTPClient) breaker(host string) *hostBreaker {
+	if v, ok := c.breakers.Load(host); ok {
+		return v.(*hostBreaker)
+	}
+	v, _ := c.breakers.LoadOrStore(host, &hostBreaker{state: BreakerClosed})
+	return v.(*hostBreaker)
+}
+
+// HostStats 按主机的调用和熔断统计（熔断中的排在前面）
+func (c *HTTPClient) HostStats() []HostBreakerStats {
+	stats := []HostBreakerStats{}
+	c.breakers.Range(func(key, value interface{}) bool {
+		b := value.(*hostBreaker)
+		b.mu.Lock()
+		s := HostBreakerStats{
+			Host:      key.(string),
+			State:     b.state,
+			Failures:  b.failures,
+			LastError: b.lastError,
+		}
+		if b.state != BreakerClosed {
+			t := b.openedAt
+			s.OpenedAt = &t
+		}
+		if !b.lastErrorAt.IsZero() {
+			t := b.lastErrorAt
+			s.LastErrorAt = &t
+		}
+		b.mu.Unlock()
+		s.Requests = b.requests.Load()
+		s.Errors = b.errors.Load()
+		s.Retries = b.retries.Load()
+		s.Rejected = b.rejected.Load()
+		stats = append(stats, s)
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		if (stats[i].State == BreakerClosed) != (stats[j].State == BreakerClosed) {
+			return stats[i].State != BreakerClosed
+		}
+		return stats[i].Host < stats[j].Host
+	})
+	return stats
+}
+
+// ResetBreaker 手动恢复主机的熔断器，host 为空时恢复全部
+func (c *HTTPClient) ResetBreaker(host string) {
+	c.breakers.Range(func(key, value interface{}) bool {
+		if host == "" || key.(string) == host {
+			b := value.(*hostBreaker)
+			b.mu.Lock()
+			b.state, b.failures, b.probing = BreakerClosed, 0, false
+			b.mu.Unlock()
+		}
+		return true
+	})
+}
+
+// Config 当前配置（代理地址不含认证信息）
+func (c *HTTPClient) Config() config.OutboundHTTPConfig {
+	cfg := c.config
+	if u, err := url.Parse(cfg.Proxy); err == nil && u.User != nil {
+		u.User = nil
+		cfg.Proxy = u.String()
+	}
+	return cfg
+}
@@ -0,0 +1,360 @@
+// Package core provides the HTTP/HTTPS listeners (TCP, unix socket or systemd socket activation)
+// with optional autocert and HTTP→HTTPS redirect
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/acme/autocert"
+
+	"seo-generator/api/pkg/config"
+)
+
+// 监听方式
+const (
+	ServerListenTCP     = "tcp"
+	ServerListenUnix    = "unix"
+	ServerListenSystemd = "systemd"
+)
+
+// HTTPServers 管理 HTTP 与 HTTPS 监听
+// 未启用 TLS 时只在 server.port 提供 HTTP；启用后在 server.tls.port 提供 HTTPS（默认开启 HTTP/2），
+// server.port 改为跳转 HTTPS（redirect_http=true）或继续提供 HTTP 服务，并处理 ACME http-01 验证。
+// server.listen=unix / systemd 时 HTTP 监听改为 unix socket 或 systemd 传入的 socket
+type HTTPServers struct {
+	servers []*listeningServer
+}
+
+type listeningServer struct {
+	srv      *http.Server
+	listener net.Listener
+	tls      bool
+}
+
+// NewHTTPServers 按配置创建监听，监听失败时返回错误
+func NewHTTPServers(cfg config.ServerConfig, handler http.Handler, siteCache *SiteCache) (*HTTPServers, error) {
+	s := &HTTPServers{}
+	httpAddr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+
+	var activated []net.Listener
+	if cfg.Listen == ServerListenSystemd {
+		var err error
+		if activated, err = systemdListeners(); err != nil {
+			return nil, err
+		}
+	}
+
+	var plain http.Handler = handler
+	if cfg.TLS.Enabled {
+		tlsConfig, acmeHandler, err := buildTLSConfig(cfg.TLS, siteCache)
+		if err != nil {
+			return nil, err
+		}
+
+		httpsSrv := newHTTPServer(fmt.Sprintf("%s:%d", cfg.Host, cfg.TLS.Port), handler)
+		httpsSrv.TLSConfig = tlsConfig
+		if !cfg.TLS.HTTP2 {
+			// 非 nil 的空表会关闭 net/http 的自动 HTTP/2
+			httpsSrv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+			tlsConfig.NextProtos = removeProto(tlsConfig.NextProtos, "h2")
+		}
+
+		// systemd 传入两个 socket 时第二个（或名为 https 的）用于 HTTPS
+		var l net.Listener
+		if len(activated) > 1 {
+			l, activated = takeActivated(activated, "https", 1)
+		} else if l, err = net.Listen("tcp", httpsSrv.Addr); err != nil {
+			s.closeListeners()
+			return nil, fmt.Errorf("listen %s: %w", httpsSrv.Addr, err)
+		}
+		s.servers = append(s.servers, &listeningServer{srv: httpsSrv, listener: l, tls: true})
+
+		if cfg.TLS.RedirectHTTP {
+			plain = httpsRedirectHandler(cfg.TLS.Port)
+		}
+		if acmeHandler != nil {
+			plain = acmeHandler(plain)
+		}
+	}
+
+	httpSrv := newHTTPServer(httpAddr, plain)
+	var l net.Listener
+	var err error
+	switch cfg.Listen {
+	case ServerListenSystemd:
+		l, _ = takeActivated(activated, "http", 0)
+		httpSrv.Addr = l.Addr().String()
+	case ServerListenUnix:
+		if l, err = listenUnix(cfg.UnixSocket, cfg.UnixSocketMode); err != nil {
+			s.closeListeners()
+			return nil, err
+		}
+		httpSrv.Addr = "unix:" + cfg.UnixSocket
+	default:
+		if l, err = net.Listen("tcp", httpAddr); err != nil {
+			s.closeListeners()
+			return nil, fmt.Errorf("listen %s: %w", httpAddr, err)
+		}
+	}
+	if l.Addr().Network() == "unix" {
+		httpSrv.Handler = unixRemoteAddr(plain)
+	}
+	s.servers = append(s.servers, &listeningServer{srv: httpSrv, listener: l})
+	return s, nil
+}
+
+func (s *HTTPServers) closeListeners() {
+	for _, ls := range s.servers {
+		ls.listener.Close()
+	}
+}
+
+// listenUnix 监听 unix socket，启动前删除上次异常退出残留的 socket 文件
+func listenUnix(path, mode string) (net.Listener, error) {
+	if path == "" {
+		return nil, errors.New("server.unix_socket is required when server.listen=unix")
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("unix socket path %s exists and is not a socket", path)
+		}
+		os.Remove(path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create unix socket dir: %w", err)
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listen unix %s: %w", path, err)
+	}
+	if mode != "" {
+		perm, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil {
+			l.Close()
+			return nil, fmt.Errorf("invalid server.unix_socket_mode %q: %w", mode, err)
+		}
+		if err := os.Chmod(path, os.FileMode(perm)); err != nil {
+			l.Close()
+			return nil, fmt.Errorf("chmod unix socket: %w", err)
+		}
+	}
+	return l, nil
+}
+
+// systemdListeners 读取 systemd socket activation 传入的监听（LISTEN_FDS，从 fd 3 开始）
+func systemdListeners() ([]net.Listener, error) {
+	pid, _ := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	n, _ := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if pid != os.Getpid() || n <= 0 {
+		return nil, errors.New("server.listen=systemd but no sockets passed by systemd (LISTEN_PID/LISTEN_FDS)")
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, n)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("LISTEN_FD_%d", 3+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(3+i), name)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("systemd socket %s: %w", name, err)
+		}
+		listeners = append(listeners, namedListener{Listener: l, name: name})
+	}
+	log.Info().Int("count", n).Strs("names", names).Msg("Using systemd socket activation")
+	return listeners, nil
+}
+
+// namedListener 带 FileDescriptorName 的 systemd 监听
+type namedListener struct {
+	net.Listener
+	name string
+}
+
+// takeActivated 按名称（FileDescriptorName）取出监听，找不到时按位置取
+func takeActivated(listeners []net.Listener, name string, index int) (net.Listener, []net.Listener) {
+	pick := -1
+	for i, l := range listeners {
+		if nl, ok := l.(namedListener); ok && nl.name == name {
+			pick = i
+			break
+		}
+	}
+	if pick < 0 {
+		pick = index
+		if pick >= len(listeners) {
+			pick = 0
+		}
+	}
+	l := listeners[pick]
+	rest := append(append([]net.Listener{}, listeners[:pick]...), listeners[pick+1:]...)
+	return l, rest
+}
+
+// unixRemoteAddr unix socket 连接没有对端 IP，按本机回环地址处理，
+// 使 gin 的可信代理判断照常读取 Nginx 传入的 X-Forwarded-For / X-Real-IP
+func unixRemoteAddr(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, err := net.SplitHostPort(r.RemoteAddr); err != nil {
+			r.RemoteAddr = "127.0.0.1:0"
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         addr,
+		Handler:      handler,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 60 * time.Second,
+		IdleTimeout:  120 * time.Second,
+	}
+}
+
+// buildTLSConfig 证书文件优先，否则使用 autocert 按域名按需签发
+// autocert 时返回包装 HTTP 监听的 http-01 验证处理
+func buildTLSConfig(cfg config.TLSConfig, siteCache *SiteCache) (*tls.Config, func(http.Handler) http.Handler, error) {
+	minVersion := uint16(tls.VersionTLS12)
+	if cfg.MinVersion == "1.3" {
+		minVersion = tls.VersionTLS13
+	}
+
+	if cfg.CertFile != "" && cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load tls certificate: %w", err)
+		}
+		return &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   minVersion,
+			NextProtos:   []string{"h2", "http/1.1"},
+		}, nil, nil
+	}
+
+	if !cfg.Autocert {
+		return nil, nil, errors.New("server.tls requires cert_file/key_file or autocert")
+	}
+	if err := os.MkdirAll(cfg.AutocertCacheDir, 0700); err != nil {
+		return nil, nil, fmt.Errorf("create autocert cache dir: %w", err)
+	}
+
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+		Email:      cfg.AutocertEmail,
+		HostPolicy: siteHostPolicy(siteCache, cfg.AutocertHosts),
+	}
+	tlsConfig := m.TLSConfig()
+	tlsConfig.MinVersion = minVersion
+	log.Info().
+		Str("cache_dir", cfg.AutocertCacheDir).
+		Strs("extra_hosts", cfg.AutocertHosts).
+		Msg("Autocert enabled, certificates issued on demand for configured sites")
+	return tlsConfig, m.HTTPHandler, nil
+}
+
+// siteHostPolicy 只为站点表中启用的域名和额外配置的域名（如管理后台）签发证书，
+// 防止任意 SNI 触发签发耗尽 Let's Encrypt 配额
+func siteHostPolicy(siteCache *SiteCache, extraHosts []string) autocert.HostPolicy {
+	extra := make(map[string]bool, len(extraHosts))
+	for _, h := range extraHosts {
+		extra[strings.ToLower(strings.TrimSpace(h))] = true
+	}
+	return func(ctx context.Context, host string) error {
+		host = strings.ToLower(host)
+		if extra[host] {
+			return nil
+		}
+		if siteCache != nil {
+			site, err := siteCache.Get(ctx, host)
+			if err != nil {
+				return err
+			}
+			if site != nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("autocert: host %q is not a configured site", host)
+	}
+}
+
+// httpsRedirectHandler HTTP 请求 301 跳转到 HTTPS
+func httpsRedirectHandler(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, fmt.Sprint(httpsPort))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+func removeProto(protos []string, proto string) []string {
+	out := protos[:0:0]
+	for _, p := range protos {
+		if p != proto {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Start 在后台启动所有监听，服务异常退出时结束进程
+func (s *HTTPServers) Start() {
+	for _, ls := range s.servers {
+		go func(ls *listeningServer) {
+			log.Info().Str("addr", ls.srv.Addr).Bool("tls", ls.tls).Msg("Server starting")
+			var err error
+			if ls.tls {
+				// 证书已在 TLSConfig 中提供
+				err = ls.srv.ServeTLS(ls.listener, "", "")
+			} else {
+				err = ls.srv.Serve(ls.listener)
+			}
+			if err != nil && err != http.ErrServerClosed {
+				log.Fatal().Err(err).Str("addr", ls.srv.Addr).Msg("Server failed")
+			}
+		}(ls)
+	}
+}
+
+// Shutdown 优雅关闭所有监听
+func (s *HTTPServers) Shutdown(ctx context.Context) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(s.servers))
+	for i, ls := range s.servers {
+		wg.Add(1)
+		go func(i int, srv *http.Server) {
+			defer wg.Done()
+			errs[i] = srv.Shutdown(ctx)
+		}(i, ls.srv)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
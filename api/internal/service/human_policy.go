@@ -0,0 +1,60 @@
+// Package core provides per-site handling policies for non-spider visitors
+package core
+
+import (
+	"html"
+	"strings"
+
+	"seo-generator/api/internal/model"
+)
+
+// 非蜘蛛访问策略（sites.human_policy，NULL 按 spider_detector 全局配置）
+const (
+	HumanPolicyRedirect = "redirect" // 302 跳转到 human_target_url
+	HumanPolicyPage     = "page"     // 返回极简品牌页
+	HumanPolicyContent  = "content"  // 返回与蜘蛛相同的内容
+	HumanPolicyBlock    = "block"    // 返回 403
+)
+
+// ValidHumanPolicy 是否为已知的非蜘蛛访问策略
+func ValidHumanPolicy(policy string) bool {
+	switch policy {
+	case HumanPolicyRedirect, HumanPolicyPage, HumanPolicyContent, HumanPolicyBlock:
+		return true
+	}
+	return false
+}
+
+// SiteHumanPolicy 返回站点的非蜘蛛访问策略，未配置或无效时返回空字符串
+func SiteHumanPolicy(site *models.Site) string {
+	if site == nil || !site.HumanPolicy.Valid || !ValidHumanPolicy(site.HumanPolicy.String) {
+		return ""
+	}
+	return site.HumanPolicy.String
+}
+
+// RenderHumanPage 生成给普通访客的极简品牌页（站点名称 + 备案号）
+func RenderHumanPage(site *models.Site) []byte {
+	name := site.Name
+	if name == "" {
+		name = site.Domain
+	}
+	name = html.EscapeString(name)
+
+	var b strings.Builder
+	b.Grow(512)
+	b.WriteString(`<!DOCTYPE html><html lang="zh-CN"><head><meta charset="utf-8">`)
+	b.WriteString(`<meta name="viewport" content="width=device-width,initial-scale=1">`)
+	b.WriteString(`<meta name="robots" content="noindex,nofollow"><title>`)
+	b.WriteString(name)
+	b.WriteString(`</title><style>body{margin:0;min-height:100vh;display:flex;flex-direction:column;align-items:center;justify-content:center;font-family:sans-serif;color:#333;background:#f7f7f7}h1{font-weight:400}footer{position:fixed;bottom:16px;font-size:12px;color:#999}</style></head><body><h1>`)
+	b.WriteString(name)
+	b.WriteString(`</h1>`)
+	if site.ICPNumber.Valid && site.ICPNumber.String != "" {
+		b.WriteString(`<footer>`)
+		b.WriteString(html.EscapeString(site.ICPNumber.String))
+		b.WriteString(`</footer>`)
+	}
+	b.WriteString(`</body></html>`)
+	return []byte(b.String())
+}
@@ -0,0 +1,392 @@
+// Package core provides the backoffice message catalog (zh-CN / en-US) for API responses
+package core
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// 支持的语言
+const (
+	LocaleZhCN    = "zh-CN"
+	LocaleEnUS    = "en-US"
+	DefaultLocale = LocaleZhCN
+)
+
+// SupportedLocales 支持的语言列表
+var SupportedLocales = []string{LocaleZhCN, LocaleEnUS}
+
+// errorMessagesEnUS 错误码的英文文案（中文见 errorMessages）
+var errorMessagesEnUS = map[ErrorCode]string{
+	ErrSuccess:         "Success",
+	ErrUnknown:         "Unknown error",
+	ErrInvalidParam:    "Invalid parameter",
+	ErrUnauthorized:    "Unauthorized",
+	ErrForbidden:       "Forbidden",
+	ErrNotFound:        "Resource not found",
+	ErrMethodNotAllow:  "Method not allowed",
+	ErrTooManyRequests: "Too many requests",
+	ErrInternalServer:  "Internal server error",
+	ErrTimeout:         "Request timed out",
+	ErrValidation:      "Validation failed",
+	ErrConflict:        "Data was modified by someone else",
+	ErrPayloadTooLarge: "Request body too large",
+
+	ErrDBConnection: "Database connection failed",
+	ErrDBQuery:      "Database query failed",
+	ErrDBInsert:     "Database insert failed",
+	ErrDBUpdate:     "Database update failed",
+	ErrDBDelete:     "Database delete failed",
+	ErrDBDuplicate:  "Duplicate data",
+	ErrDBNotFound:   "Data not found",
+	ErrDBTxBegin:    "Failed to begin transaction",
+	ErrDBTxCommit:   "Failed to commit transaction",
+	ErrDBTxRollback: "Failed to roll back transaction",
+
+	ErrCacheConnection: "Cache connection failed",
+	ErrCacheGet:        "Cache read failed",
+	ErrCacheSet:        "Cache write failed",
+	ErrCacheDelete:     "Cache delete failed",
+	ErrCacheExpired:    "Cache expired",
+	ErrCacheFull:       "Cache is full",
+	ErrCacheMiss:       "Cache miss",
+	ErrCacheInvalid:    "Invalid cache data",
+
+	ErrTemplateNotFound:  "Template not found",
+	ErrTemplateParse:     "Failed to parse template",
+	ErrTemplateRender:    "Failed to render template",
+	ErrTemplateInvalid:   "Invalid template format",
+	ErrTemplateCompile:   "Failed to compile template",
+	ErrTemplateSyntax:    "Template syntax error",
+	ErrTemplateExecution: "Template execution failed",
+	ErrTemplateDataType:  "Template data type error",
+
+	ErrPoolExhausted: "Object pool exhausted",
+	ErrPoolTimeout:   "Timed out waiting for object pool",
+	ErrPoolClosed:    "Object pool closed",
+	ErrPoolInvalid:   "Invalid object pool",
+	ErrPoolOverflow:  "Object pool overflow",
+	ErrPoolGetFailed: "Failed to get from object pool",
+	ErrPoolPutFailed: "Failed to return to object pool",
+
+	ErrSiteNotFound:   "Site not found",
+	ErrSiteDisabled:   "Site disabled",
+	ErrSiteInvalid:    "Invalid site configuration",
+	ErrSiteDomain:     "Invalid site domain",
+	ErrSiteConfig:     "Site configuration error",
+	ErrSiteTemplate:   "Site template error",
+	ErrSiteGroup:      "Site group error",
+	ErrSitePermission: "Insufficient site permission",
+
+	ErrSchedulerNotRunning:   "Scheduler is not running",
+	ErrSchedulerTaskExist:    "Task already exists",
+	ErrSchedulerTaskNotFound: "Task not found",
+	ErrSchedulerInvalidCron:  "Invalid cron expression",
+	ErrSchedulerExecFailed:   "Task execution failed",
+	ErrSchedulerQueueFull:    "Task queue is full",
+	ErrSchedulerTimeout:      "Task execution timed out",
+	ErrSchedulerCancelled:    "Task cancelled",
+}
+
+// catalogMessage 文案目录条目（key 为稳定标识，前端可按 key 取用）
+type catalogMessage struct {
+	Key  string
+	ZhCN string
+	EnUS string
+}
+
+// messageCatalog handler 中的常用提示文案
+// handler 仍直接返回中文文案，响应时按中文原文查目录翻译；"前缀: 详情" 形式的文案只翻译前缀
+var messageCatalog = []catalogMessage{
+	{"invalid_request", "请求参数错误", "Invalid request parameters"},
+	{"invalid_param", "参数错误", "Invalid parameter"},
+	{"db_not_initialized", "数据库未初始化", "Database not initialized"},
+	{"db_not_connected", "数据库未连接", "Database not connected"},
+	{"tx_begin_failed", "开启事务失败", "Failed to begin transaction"},
+	{"tx_commit_failed", "提交事务失败", "Failed to commit transaction"},
+	{"save_failed", "保存失败", "Save failed"},
+	{"save_config_failed", "保存配置失败", "Failed to save configuration"},
+	{"delete_failed", "删除失败", "Delete failed"},
+	{"cleanup_failed", "清理失败", "Cleanup failed"},
+	{"read_body_failed", "读取请求体失败", "Failed to read request body"},
+	{"invalid_json", "请求体不是有效的 JSON", "Request body is not valid JSON"},
+
+	{"invalid_id", "无效的 ID", "Invalid ID"},
+	{"invalid_site_id", "无效的站点 ID", "Invalid site ID"},
+	{"invalid_site_group_id", "无效的站群 ID", "Invalid site group ID"},
+	{"invalid_site_group_id_alt", "无效的站点组 ID", "Invalid site group ID"},
+	{"invalid_group_id", "无效的分组 ID", "Invalid group ID"},
+	{"invalid_template_id", "无效的模板 ID", "Invalid template ID"},
+	{"invalid_article_id", "无效的文章 ID", "Invalid article ID"},
+	{"invalid_keyword_id", "无效的关键词 ID", "Invalid keyword ID"},
+	{"invalid_image_id", "无效的图片 ID", "Invalid image ID"},
+	{"invalid_task_id", "无效的任务 ID", "Invalid task ID"},
+	{"invalid_job_id", "无效的作业ID", "Invalid job ID"},
+	{"invalid_rule_id", "无效的规则 ID", "Invalid rule ID"},
+	{"invalid_admin_id", "无效的管理员 ID", "Invalid admin ID"},
+	{"invalid_admin_id_alt", "无效的管理员ID", "Invalid admin ID"},
+	{"invalid_session_id", "无效的会话 ID", "Invalid session ID"},
+	{"invalid_batch_id", "无效的批次 ID", "Invalid batch ID"},
+	{"invalid_file_id", "无效的文件 ID", "Invalid file ID"},
+	{"invalid_banned_word_id", "无效的违禁词 ID", "Invalid banned word ID"},
+	{"invalid_path", "无效的路径", "Invalid path"},
+	{"invalid_url", "无效的 URL", "Invalid URL"},
+	{"invalid_ip", "无效的 IP 地址", "Invalid IP address"},
+	{"invalid_regex", "无效的正则", "Invalid regular expression"},
+	{"invalid_start", "无效的 start 参数", "Invalid start parameter"},
+	{"invalid_end", "无效的 end 参数", "Invalid end parameter"},
+	{"invalid_start_time", "无效的开始时间", "Invalid start time"},
+	{"invalid_end_time", "无效的结束时间", "Invalid end time"},
+	{"invalid_range", "无效的 range 参数", "Invalid range parameter"},
+	{"start_after_end", "start 不能晚于 end", "start must not be later than end"},
+	{"start_before_end", "start 必须早于 end", "start must be earlier than end"},
+	{"range_too_long_90", "时间范围不能超过 90 天", "Time range must not exceed 90 days"},
+	{"range_too_long_366", "时间范围不能超过 366 天", "Time range must not exceed 366 days"},
+	{"percent_range", "percent 须在 0-100 之间", "percent must be between 0 and 100"},
+
+	{"site_not_found", "站点不存在", "Site not found"},
+	{"site_group_not_found", "站群不存在", "Site group not found"},
+	{"group_not_found", "分组不存在", "Group not found"},
+	{"template_not_found", "模板不存在", "Template not found"},
+	{"template_not_found_or_disabled", "模板不存在或未启用", "Template not found or disabled"},
+	{"article_not_found", "文章不存在", "Article not found"},
+	{"rule_not_found", "规则不存在", "Rule not found"},
+	{"policy_not_found", "策略不存在", "Policy not found"},
+	{"job_not_found", "作业不存在", "Job not found"},
+	{"batch_not_found", "批次不存在", "Batch not found"},
+	{"alert_not_found", "告警不存在", "Alert not found"},
+	{"feature_flag_not_found", "功能开关不存在", "Feature flag not found"},
+	{"snippet_not_found", "片段不存在", "Snippet not found"},
+	{"snippet_exists", "片段名已存在", "Snippet name already exists"},
+	{"snippet_in_use", "片段仍被模板或其他片段引用", "Snippet is still referenced by templates or other snippets"},
+	{"pinned_page_not_found", "固定页面不存在", "Pinned page not found"},
+	{"backup_not_found", "备份文件不存在", "Backup file not found"},
+	{"query_group_failed", "查询分组失败", "Failed to query group"},
+	{"update_default_group_failed", "更新默认分组失败", "Failed to update default group"},
+	{"template_name_required", "需要提供模板名称 (name 查询参数)", "Template name is required (name query parameter)"},
+
+	{"upload_required", "请上传文件", "Please upload a file"},
+	{"no_file_uploaded", "没有上传文件", "No file uploaded"},
+	{"read_file_failed", "无法读取文件", "Unable to read file"},
+	{"read_file_content_failed", "无法读取文件内容", "Unable to read file content"},
+	{"txt_only", "只支持 .txt 格式文件", "Only .txt files are supported"},
+	{"file_too_large_10mb", "文件过大，最大支持 10MB", "File too large, maximum 10MB"},
+	{"cannot_delete_root", "不能删除根目录", "Cannot delete the root directory"},
+	{"binary_not_editable", "不支持编辑二进制文件", "Binary files cannot be edited"},
+
+	{"token_expired", "Token 已过期", "Token expired"},
+	{"token_invalid", "无效的 Token", "Invalid token"},
+	{"token_generate_failed", "Token 生成失败", "Failed to generate token"},
+	{"missing_auth", "缺少认证信息", "Missing authentication"},
+	{"invalid_auth", "无效的认证信息", "Invalid authentication"},
+	{"invalid_user", "无效的用户信息", "Invalid user information"},
+	{"session_expired", "会话已失效，请重新登录", "Session expired, please log in again"},
+	{"session_create_failed", "会话创建失败", "Failed to create session"},
+	{"csrf_failed", "CSRF 校验失败", "CSRF check failed"},
+	{"bad_credentials", "用户名或密码错误", "Incorrect username or password"},
+	{"ip_not_allowed", "当前 IP 不在管理后台白名单中", "Your IP is not on the admin allowlist"},
+	{"password_hash_failed", "密码加密失败", "Failed to hash password"},
+	{"password_update_failed", "密码更新失败", "Failed to update password"},
+	{"unlock_failed", "解除锁定失败", "Failed to unlock"},
+	{"api_token_invalid", "无效的 API Token", "Invalid API token"},
+	{"api_token_disabled", "API Token 认证未启用", "API token authentication is disabled"},
+	{"api_token_not_configured", "API Token 未配置", "API token is not configured"},
+	{"ws_ticket_invalid", "连接票据无效或已过期", "Connection ticket is invalid or expired"},
+	{"invalid_locale", "不支持的语言", "Unsupported locale"},
+
+	{"anti_scrape_not_initialized", "反采集未初始化", "Anti-scrape is not initialized"},
+	{"spider_detector_not_initialized", "蜘蛛检测器未初始化", "Spider detector is not initialized"},
+	{"content_filter_not_initialized", "内容过滤器未初始化", "Content filter is not initialized"},
+	{"log_level_disabled", "日志级别管理未启用", "Log level management is disabled"},
+	{"link_audit_disabled", "链接审计未启用（link_audit.enabled）", "Link audit is disabled (link_audit.enabled)"},
+	{"egress_audit_disabled", "出站请求审计未启用（egress_audit.enabled）", "Egress audit is disabled (egress_audit.enabled)"},
+	{"query_cache_disabled", "查询缓存未启用（query_cache.enabled）", "Query cache is disabled (query_cache.enabled)"},
+	{"backup_passphrase_missing", "未配置备份口令（backup.passphrase）", "Backup passphrase is not configured (backup.passphrase)"},
+}
+
+// catalogByZh 中文原文 -> 目录条目
+var catalogByZh = func() map[string]*catalogMessage {
+	m := make(map[string]*catalogMessage, len(messageCatalog))
+	for i := range messageCatalog {
+		m[messageCatalog[i].ZhCN] = &messageCatalog[i]
+	}
+	return m
+}()
+
+// NormalizeLocale 规范化语言标识，不支持时返回空字符串（zh、zh-cn、zh_CN -> zh-CN；en、en-GB -> en-US）
+func NormalizeLocale(locale string) string {
+	l := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	switch {
+	case l == "":
+		return ""
+	case l == "zh" || strings.HasPrefix(l, "zh-"):
+		return LocaleZhCN
+	case l == "en" || strings.HasPrefix(l, "en-"):
+		return LocaleEnUS
+	}
+	return ""
+}
+
+// ParseAcceptLanguage 按 q 值选出 Accept-Language 中第一个支持的语言，没有时返回空字符串
+func ParseAcceptLanguage(header string) string {
+	type candidate struct {
+		locale string
+		q      float64
+		order  int
+	}
+	var candidates []candidate
+	for i, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if locale := NormalizeLocale(tag); locale != "" && q > 0 {
+			candidates = append(candidates, candidate{locale, q, i})
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].locale
+}
+
+// LocalePreferences 管理员语言偏好（admins.locale），按管理员缓存，修改时更新缓存
+type LocalePreferences struct {
+	db    *sqlx.DB
+	cache sync.Map // adminID -> locale（空字符串表示未设置）
+}
+
+var localePreferences *LocalePreferences
+
+// NewLocalePreferences 创建语言偏好并设为全局实例（RequestLocale 使用）
+func NewLocalePreferences(db *sqlx.DB) *LocalePreferences {
+	p := &LocalePreferences{db: db}
+	localePreferences = p
+	return p
+}
+
+// GetLocalePreferences 全局语言偏好，未初始化时为 nil
+func GetLocalePreferences() *LocalePreferences {
+	return localePreferences
+}
+
+// Get 管理员的语言偏好，未设置或查询失败时返回空字符串
+func (p *LocalePreferences) Get(ctx context.Context, adminID int) string {
+	if v, ok := p.cache.Load(adminID); ok {
+		return v.(string)
+	}
+	var locale string
+	if err := p.db.GetContext(ctx, &locale, "SELECT COALESCE(locale, '') FROM admins WHERE id = ?", adminID); err != nil {
+		// 查询失败（如 locale 列尚未迁移）同样缓存为未设置，避免每次响应都查库
+		log.Debug().Err(err).Int("admin_id", adminID).Msg("Failed to load admin locale preference")
+		p.cache.Store(adminID, "")
+		return ""
+	}
+	locale = NormalizeLocale(locale)
+	p.cache.Store(adminID, locale)
+	return locale
+}
+
+// Set 保存管理员的语言偏好，locale 为空时清除偏好（按 Accept-Language）
+func (p *LocalePreferences) Set(ctx context.Context, adminID int, locale string) error {
+	var value interface{}
+	if locale != "" {
+		value = locale
+	}
+	if _, err := p.db.ExecContext(ctx, "UPDATE admins SET locale = ? WHERE id = ?", value, adminID); err != nil {
+		return err
+	}
+	p.cache.Store(adminID, locale)
+	return nil
+}
+
+// RequestLocale 请求使用的语言：?lang= 参数 > 管理员偏好 > Accept-Language > 默认中文
+// 在响应时计算，认证中间件之后的响应可以使用管理员偏好
+func RequestLocale(c *gin.Context) string {
+	if c == nil || c.Request == nil {
+		return DefaultLocale
+	}
+	if locale := NormalizeLocale(c.Query("lang")); locale != "" {
+		return locale
+	}
+	if localePreferences != nil {
+		if adminID, ok := c.Get("admin_id"); ok {
+			if id, ok := adminID.(float64); ok {
+				if locale := localePreferences.Get(c.Request.Context(), int(id)); locale != "" {
+					return locale
+				}
+			}
+		}
+	}
+	if locale := ParseAcceptLanguage(c.GetHeader("Accept-Language")); locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// GetErrorMessageLocale 错误码在指定语言下的文案
+func GetErrorMessageLocale(code ErrorCode, locale string) string {
+	if locale == LocaleEnUS {
+		if msg, ok := errorMessagesEnUS[code]; ok {
+			return msg
+		}
+		return errorMessagesEnUS[ErrUnknown]
+	}
+	return GetErrorMessage(code)
+}
+
+// LocalizeMessage 翻译 handler 返回的中文文案，目录中没有的原样返回
+func LocalizeMessage(message, locale string) string {
+	if locale != LocaleEnUS || message == "" {
+		return message
+	}
+	if m, ok := catalogByZh[message]; ok {
+		return m.EnUS
+	}
+	// "前缀: 详情" / "前缀：详情" 只翻译前缀，详情（通常是底层错误）保留
+	for _, sep := range []string{": ", "：", ":"} {
+		if head, detail, ok := strings.Cut(message, sep); ok {
+			if m, ok := catalogByZh[strings.TrimSpace(head)]; ok {
+				return m.EnUS + ": " + strings.TrimSpace(detail)
+			}
+		}
+	}
+	return message
+}
+
+// MessageCatalog 指定语言的文案目录（key -> 文案），含错误码（code.<数字>）
+func MessageCatalog(locale string) map[string]string {
+	out := make(map[string]string, len(messageCatalog)+len(errorMessages))
+	for _, m := range messageCatalog {
+		if locale == LocaleEnUS {
+			out[m.Key] = m.EnUS
+		} else {
+			out[m.Key] = m.ZhCN
+		}
+	}
+	for code := range errorMessages {
+		out["code."+strconv.Itoa(int(code))] = GetErrorMessageLocale(code, locale)
+	}
+	return out
+}
+
+// localizedCodeMessage 按请求语言返回错误码文案
+func localizedCodeMessage(c *gin.Context, code ErrorCode) string {
+	return GetErrorMessageLocale(code, RequestLocale(c))
+}
+
+// localizedMessage 按请求语言翻译自定义文案
+func localizedMessage(c *gin.Context, message string) string {
+	return LocalizeMessage(message, RequestLocale(c))
+}
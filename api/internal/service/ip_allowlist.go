@@ -0,0 +1,327 @@
+// Package core provides the admin API IP allowlist
+package core
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// system_settings 中的白名单配置键
+const (
+	ipAllowlistEnabledKey = "admin_ip_allowlist_enabled"
+	ipAllowlistCIDRsKey   = "admin_ip_allowlist"
+)
+
+const (
+	ipAllowlistRefreshInterval = 30 * time.Second // 多实例部署时从数据库同步设置的间隔
+	ipAllowlistRecentRejects   = 100              // 保留最近被拒绝的请求数
+)
+
+// IPRejection 被白名单拒绝的请求
+type IPRejection struct {
+	IP        string    `json:"ip"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	UserAgent string    `json:"user_agent"`
+	Time      time.Time `json:"time"`
+}
+
+// IPAllowlistSettings 白名单设置快照
+type IPAllowlistSettings struct {
+	Enabled bool     `json:"enabled"`
+	CIDRs   []string `json:"cidrs"`
+}
+
+// IPAllowlist 管理接口 IP 白名单
+// 启用后只有白名单网段（以及本机地址）可以访问 /api/*、/ws/* 和 /sse/*；
+// 设置保存在 system_settings 中，定期刷新以同步多实例；
+// 配置文件中的应急令牌可绕过白名单，防止误配置后无法登录后台
+type IPAllowlist struct {
+	db     *sqlx.DB
+	config config.IPAllowlistConfig
+
+	mu       sync.RWMutex
+	enabled  bool
+	cidrs    []string
+	networks []*net.IPNet
+	proxies  []*net.IPNet
+
+	rejectMu sync.Mutex
+	rejects  []IPRejection
+	rejected int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewIPAllowlist 创建 IP 白名单，初始设置来自配置文件
+func NewIPAllowlist(db *sqlx.DB, cfg config.IPAllowlistConfig) *IPAllowlist {
+	a := &IPAllowlist{db: db, config: cfg}
+
+	proxies, err := ParseCIDRs(cfg.TrustedProxies)
+	if err != nil {
+		log.Warn().Err(err).Msg("Invalid ip_allowlist.trusted_proxies, ignoring")
+	}
+	a.proxies = proxies
+
+	networks, err := ParseCIDRs(cfg.CIDRs)
+	if err != nil {
+		log.Warn().Err(err).Msg("Invalid ip_allowlist.cidrs, ignoring")
+	}
+	a.enabled = cfg.Enabled
+	a.cidrs = normalizeCIDRs(networks)
+	a.networks = networks
+	return a
+}
+
+// Start 从数据库加载设置并定期刷新
+func (a *IPAllowlist) Start(ctx context.Context) {
+	if a.db == nil {
+		return
+	}
+	a.ctx, a.cancel = context.WithCancel(ctx)
+	a.reload(a.ctx)
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		ticker := time.NewTicker(ipAllowlistRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-ticker.C:
+				a.reload(a.ctx)
+			}
+		}
+	}()
+}
+
+// Stop 停止刷新
+func (a *IPAllowlist) Stop() {
+	if a.cancel != nil {
+		a.cancel()
+	}
+	a.wg.Wait()
+}
+
+// reload 读取 system_settings，未保存过设置时保留配置文件中的值
+func (a *IPAllowlist) reload(ctx context.Context) {
+	var rows []struct {
+		Key   string `db:"setting_key"`
+		Value string `db:"setting_value"`
+	}
+	if err := a.db.SelectContext(ctx, &rows,
+		"SELECT setting_key, COALESCE(setting_value, '') AS setting_value FROM system_settings WHERE setting_key IN (?, ?)",
+		ipAllowlistEnabledKey, ipAllowlistCIDRsKey); err != nil {
+		log.Warn().Err(err).Msg("Failed to load IP allowlist settings")
+		return
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, r := range rows {
+		switch r.Key {
+		case ipAllowlistEnabledKey:
+			a.enabled = r.Value == "true" || r.Value == "1"
+		case ipAllowlistCIDRsKey:
+			networks, err := ParseCIDRs(splitCIDRList(r.Value))
+			if err != nil {
+				log.Warn().Err(err).Msg("Invalid IP allowlist in system_settings, keeping previous list")
+				continue
+			}
+			a.networks = networks
+			a.cidrs = normalizeCIDRs(networks)
+		}
+	}
+}
+
+// Settings 返回当前设置
+func (a *IPAllowlist) Settings() IPAllowlistSettings {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	cidrs := make([]string, len(a.cidrs))
+	copy(cidrs, a.cidrs)
+	return IPAllowlistSettings{Enabled: a.enabled, CIDRs: cidrs}
+}
+
+// Update 保存设置到 system_settings 并立即生效
+func (a *IPAllowlist) Update(ctx context.Context, enabled bool, cidrs []string) (IPAllowlistSettings, error) {
+	networks, err := ParseCIDRs(cidrs)
+	if err != nil {
+		return IPAllowlistSettings{}, err
+	}
+	normalized := normalizeCIDRs(networks)
+
+	if a.db != nil {
+		enabledStr := "false"
+		if enabled {
+			enabledStr = "true"
+		}
+		for key, value := range map[string]string{
+			ipAllowlistEnabledKey: enabledStr,
+			ipAllowlistCIDRsKey:   strings.Join(normalized, "\n"),
+		} {
+			if _, err := a.db.ExecContext(ctx, `
+				INSERT INTO system_settings (setting_key, setting_value, setting_type, description)
+				VALUES (?, ?, 'string', '管理接口 IP 白名单')
+				ON DUPLICATE KEY UPDATE setting_value = VALUES(setting_value)`, key, value); err != nil {
+				return IPAllowlistSettings{}, err
+			}
+		}
+	}
+
+	a.mu.Lock()
+	a.enabled = enabled
+	a.networks = networks
+	a.cidrs = normalized
+	a.mu.Unlock()
+
+	log.Info().Bool("enabled", enabled).Strs("cidrs", normalized).Msg("IP allowlist updated")
+	return a.Settings(), nil
+}
+
+// Enabled 白名单是否启用
+func (a *IPAllowlist) Enabled() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.enabled
+}
+
+// Allowed 判断 IP 是否允许访问（本机地址始终允许）
+func (a *IPAllowlist) Allowed(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	if parsed.IsLoopback() {
+		return true
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return containsIP(a.networks, parsed)
+}
+
+// Exempt 路径是否不受白名单限制
+func (a *IPAllowlist) Exempt(path string) bool {
+	for _, prefix := range a.config.ExemptPaths {
+		if prefix != "" && strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// TrustedProxy 直连地址是否为受信代理（受信时才采用 X-Forwarded-For / X-Real-IP）
+func (a *IPAllowlist) TrustedProxy(remoteIP string) bool {
+	parsed := net.ParseIP(remoteIP)
+	return parsed != nil && containsIP(a.proxies, parsed)
+}
+
+// BypassValid 校验应急绕过令牌
+func (a *IPAllowlist) BypassValid(token string) bool {
+	if a.config.BypassToken == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(a.config.BypassToken)) == 1
+}
+
+// BypassConfigured 是否配置了应急绕过令牌
+func (a *IPAllowlist) BypassConfigured() bool {
+	return a.config.BypassToken != ""
+}
+
+// RecordRejection 记录被拒绝的请求
+func (a *IPAllowlist) RecordRejection(r IPRejection) {
+	atomic.AddInt64(&a.rejected, 1)
+	log.Warn().Str("ip", r.IP).Str("method", r.Method).Str("path", r.Path).Str("ua", r.UserAgent).
+		Msg("Admin API request rejected by IP allowlist")
+
+	a.rejectMu.Lock()
+	a.rejects = append(a.rejects, r)
+	if len(a.rejects) > ipAllowlistRecentRejects {
+		a.rejects = a.rejects[len(a.rejects)-ipAllowlistRecentRejects:]
+	}
+	a.rejectMu.Unlock()
+}
+
+// RecentRejections 最近被拒绝的请求（新的在前）及累计拒绝次数
+func (a *IPAllowlist) RecentRejections() ([]IPRejection, int64) {
+	a.rejectMu.Lock()
+	defer a.rejectMu.Unlock()
+	result := make([]IPRejection, 0, len(a.rejects))
+	for i := len(a.rejects) - 1; i >= 0; i-- {
+		result = append(result, a.rejects[i])
+	}
+	return result, atomic.LoadInt64(&a.rejected)
+}
+
+// ParseCIDRs 解析网段列表，单个 IP 视为 /32 或 /128
+func ParseCIDRs(items []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP: %q", item)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR: %q", item)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, n := range networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeCIDRs(networks []*net.IPNet) []string {
+	result := make([]string, 0, len(networks))
+	for _, n := range networks {
+		result = append(result, n.String())
+	}
+	return result
+}
+
+// splitCIDRList 拆分按换行或逗号分隔的网段
+func splitCIDRList(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == '\n' || r == ',' || r == ' ' || r == '\r' || r == '\t'
+	})
+}
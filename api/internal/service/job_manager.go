@@ -0,0 +1,577 @@
+// Package core provides background job framework for bulk admin operations
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// JobStatus 后台作业状态
+type JobStatus string
+
+const (
+	// JobStatusPending 排队中
+	JobStatusPending JobStatus = "pending"
+	// JobStatusRunning 执行中
+	JobStatusRunning JobStatus = "running"
+	// JobStatusSuccess 执行成功
+	JobStatusSuccess JobStatus = "success"
+	// JobStatusFailed 执行失败
+	JobStatusFailed JobStatus = "failed"
+	// JobStatusCancelled 已取消
+	JobStatusCancelled JobStatus = "cancelled"
+)
+
+var (
+	// ErrJobNotFound 作业不存在
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobQueueFull 作业队列已满
+	ErrJobQueueFull = errors.New("job queue is full")
+	// ErrJobFinished 作业已结束，无法取消
+	ErrJobFinished = errors.New("job already finished")
+)
+
+// Job 后台作业
+type Job struct {
+	ID         int64           `db:"id" json:"id"`
+	JobType    string          `db:"job_type" json:"job_type"`
+	Status     JobStatus       `db:"status" json:"status"`
+	Total      int64           `db:"total" json:"total"`
+	Processed  int64           `db:"processed" json:"processed"`
+	Params     json.RawMessage `db:"params" json:"params"`
+	Result     json.RawMessage `db:"result" json:"result"`
+	Message    string          `db:"message" json:"message"`
+	StartedAt  *time.Time      `db:"started_at" json:"started_at"`
+	FinishedAt *time.Time      `db:"finished_at" json:"finished_at"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// Progress 返回进度百分比（0-100），总量未知时返回 -1
+func (j *Job) Progress() float64 {
+	if j.Total <= 0 {
+		return -1
+	}
+	p := float64(j.Processed) / float64(j.Total) * 100
+	if p > 100 {
+		p = 100
+	}
+	return p
+}
+
+// Finished 是否已结束
+func (j *Job) Finished() bool {
+	return j.Status == JobStatusSuccess || j.Status == JobStatusFailed || j.Status == JobStatusCancelled
+}
+
+// JobEvent 作业状态变更事件（推送给 WebSocket 订阅者）
+type JobEvent struct {
+	Type      string    `json:"type"`
+	JobID     int64     `json:"job_id"`
+	JobType   string    `json:"job_type"`
+	Status    JobStatus `json:"status"`
+	Total     int64     `json:"total"`
+	Processed int64     `json:"processed"`
+	Progress  float64   `json:"progress"`
+	Message   string    `json:"message,omitempty"`
+	Result    any       `json:"result,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// JobFunc 作业执行函数，返回值会序列化为 JSON 存入 result 字段
+type JobFunc func(jc *JobContext) (any, error)
+
+// JobContext 作业执行上下文，用于汇报进度和感知取消
+type JobContext struct {
+	context.Context
+	job       *Job
+	manager   *JobManager
+	total     atomic.Int64
+	processed atomic.Int64
+	lastFlush atomic.Int64 // 上次写库时间（UnixNano）
+}
+
+// JobID 返回作业ID
+func (jc *JobContext) JobID() int64 {
+	return jc.job.ID
+}
+
+// SetTotal 设置总量
+func (jc *JobContext) SetTotal(total int64) {
+	jc.total.Store(total)
+	jc.report(true)
+}
+
+// Advance 增加已处理数量
+func (jc *JobContext) Advance(n int64) {
+	jc.processed.Add(n)
+	jc.report(false)
+}
+
+// Cancelled 作业是否已被取消
+func (jc *JobContext) Cancelled() bool {
+	return jc.Err() != nil
+}
+
+// report 推送进度事件，数据库写入按间隔节流
+func (jc *JobContext) report(force bool) {
+	total := jc.total.Load()
+	processed := jc.processed.Load()
+
+	jc.manager.publish(JobEvent{
+		Type:      "progress",
+		JobID:     jc.job.ID,
+		JobType:   jc.job.JobType,
+		Status:    JobStatusRunning,
+		Total:     total,
+		Processed: processed,
+		Progress:  (&Job{Total: total, Processed: processed}).Progress(),
+		Timestamp: time.Now(),
+	})
+
+	now := time.Now().UnixNano()
+	last := jc.lastFlush.Load()
+	if !force && time.Duration(now-last) < jobProgressFlushInterval {
+		return
+	}
+	if !jc.lastFlush.CompareAndSwap(last, now) {
+		return
+	}
+	jc.manager.updateProgress(jc.job.ID, total, processed)
+}
+
+// jobProgressFlushInterval 进度写库的最小间隔
+const jobProgressFlushInterval = time.Second
+
+// runningJob 正在执行的作业
+type runningJob struct {
+	cancel context.CancelFunc
+}
+
+// queuedJob 排队中的作业
+type queuedJob struct {
+	job *Job
+	fn  JobFunc
+}
+
+// JobManager 后台作业管理器
+// 批量操作提交后立即返回作业ID，由固定数量的 worker 异步执行
+type JobManager struct {
+	db       *sqlx.DB
+	handlers map[string]JobFunc
+	queue    chan queuedJob
+	workers  int
+
+	mu        sync.RWMutex
+	running   map[int64]*runningJob
+	cancelled map[int64]struct{} // 排队阶段被取消的作业
+
+	subMu       sync.RWMutex
+	subscribers map[chan JobEvent]struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewJobManager 创建作业管理器
+// workers: 并发执行的作业数量
+// queueSize: 排队作业的最大数量
+func NewJobManager(db *sqlx.DB, workers, queueSize int) *JobManager {
+	if workers <= 0 {
+		workers = 2
+	}
+	if queueSize <= 0 {
+		queueSize = 100
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &JobManager{
+		db:          db,
+		handlers:    make(map[string]JobFunc),
+		queue:       make(chan queuedJob, queueSize),
+		workers:     workers,
+		running:     make(map[int64]*runningJob),
+		cancelled:   make(map[int64]struct{}),
+		subscribers: make(map[chan JobEvent]struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start 启动 worker，并将上次异常退出时未完成的作业标记为失败
+func (m *JobManager) Start() {
+	if m.db != nil {
+		if _, err := m.db.Exec(
+			"UPDATE jobs SET status = ?, message = ?, finished_at = ? WHERE status IN (?, ?)",
+			JobStatusFailed, "服务重启，作业中断", time.Now(), JobStatusPending, JobStatusRunning,
+		); err != nil {
+			log.Warn().Err(err).Msg("Failed to mark interrupted jobs")
+		}
+	}
+
+	for i := 0; i < m.workers; i++ {
+		m.wg.Add(1)
+		go m.worker()
+	}
+	log.Info().Int("workers", m.workers).Msg("JobManager started")
+}
+
+// Stop 停止所有 worker，正在执行的作业会收到取消信号
+func (m *JobManager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// Register 注册命名作业类型
+func (m *JobManager) Register(jobType string, fn JobFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[jobType] = fn
+}
+
+// Submit 提交已注册类型的作业
+func (m *JobManager) Submit(ctx context.Context, jobType string, params any) (int64, error) {
+	m.mu.RLock()
+	fn, ok := m.handlers[jobType]
+	m.mu.RUnlock()
+	if !ok {
+		return 0, fmt.Errorf("no handler for job type: %s", jobType)
+	}
+	return m.SubmitFunc(ctx, jobType, params, fn)
+}
+
+// SubmitFunc 提交一个临时作业（闭包形式），立即返回作业ID
+func (m *JobManager) SubmitFunc(ctx context.Context, jobType string, params any, fn JobFunc) (int64, error) {
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return 0, fmt.Errorf("marshal params: %w", err)
+	}
+
+	now := time.Now()
+	result, err := m.db.ExecContext(ctx,
+		"INSERT INTO jobs (job_type, status, params, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+		jobType, JobStatusPending, paramsJSON, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("insert job: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("get last insert id: %w", err)
+	}
+
+	job := &Job{
+		ID:        id,
+		JobType:   jobType,
+		Status:    JobStatusPending,
+		Params:    paramsJSON,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	select {
+	case m.queue <- queuedJob{job: job, fn: fn}:
+	default:
+		m.finish(job, JobStatusFailed, nil, "作业队列已满")
+		return 0, ErrJobQueueFull
+	}
+
+	m.publish(JobEvent{
+		Type:      "status",
+		JobID:     id,
+		JobType:   jobType,
+		Status:    JobStatusPending,
+		Progress:  -1,
+		Timestamp: now,
+	})
+
+	log.Info().Int64("job_id", id).Str("type", jobType).Msg("Job submitted")
+	return id, nil
+}
+
+// Cancel 取消作业（排队中直接取消，执行中发送取消信号）
+func (m *JobManager) Cancel(ctx context.Context, id int64) error {
+	job, err := m.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if job.Finished() {
+		return ErrJobFinished
+	}
+
+	m.mu.Lock()
+	if rj, ok := m.running[id]; ok {
+		rj.cancel()
+	} else {
+		m.cancelled[id] = struct{}{}
+	}
+	m.mu.Unlock()
+
+	log.Info().Int64("job_id", id).Msg("Job cancellation requested")
+	return nil
+}
+
+// Get 获取作业详情
+func (m *JobManager) Get(ctx context.Context, id int64) (*Job, error) {
+	var job Job
+	err := m.db.GetContext(ctx, &job, `SELECT id, job_type, status, total, processed, params, result,
+		COALESCE(message, '') AS message, started_at, finished_at, created_at, updated_at
+		FROM jobs WHERE id = ?`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrJobNotFound
+		}
+		return nil, fmt.Errorf("query job: %w", err)
+	}
+	return &job, nil
+}
+
+// List 获取作业列表（按ID倒序）
+func (m *JobManager) List(ctx context.Context, jobType, status string, limit int) ([]Job, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	where := "1=1"
+	args := []interface{}{}
+	if jobType != "" {
+		where += " AND job_type = ?"
+		args = append(args, jobType)
+	}
+	if status != "" {
+		where += " AND status = ?"
+		args = append(args, status)
+	}
+	args = append(args, limit)
+
+	jobs := []Job{}
+	query := `SELECT id, job_type, status, total, processed, params, result,
+		COALESCE(message, '') AS message, started_at, finished_at, created_at, updated_at
+		FROM jobs WHERE ` + where + ` ORDER BY id DESC LIMIT ?`
+	if err := m.db.SelectContext(ctx, &jobs, query, args...); err != nil {
+		return nil, fmt.Errorf("query jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// Subscribe 订阅作业事件，返回事件通道和取消订阅函数
+func (m *JobManager) Subscribe() (<-chan JobEvent, func()) {
+	ch := make(chan JobEvent, 64)
+	m.subMu.Lock()
+	m.subscribers[ch] = struct{}{}
+	m.subMu.Unlock()
+
+	return ch, func() {
+		m.subMu.Lock()
+		if _, ok := m.subscribers[ch]; ok {
+			delete(m.subscribers, ch)
+			close(ch)
+		}
+		m.subMu.Unlock()
+	}
+}
+
+// GetStats 获取作业管理器统计
+func (m *JobManager) GetStats() map[string]interface{} {
+	m.mu.RLock()
+	running := len(m.running)
+	m.mu.RUnlock()
+
+	m.subMu.RLock()
+	subscribers := len(m.subscribers)
+	m.subMu.RUnlock()
+
+	return map[string]interface{}{
+		"workers":     m.workers,
+		"running":     running,
+		"queued":      len(m.queue),
+		"queue_size":  cap(m.queue),
+		"subscribers": subscribers,
+	}
+}
+
+// publish 非阻塞地广播事件，慢订阅者会丢弃事件
+func (m *JobManager) publish(event JobEvent) {
+	m.subMu.RLock()
+	defer m.subMu.RUnlock()
+	for ch := range m.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// worker 作业执行循环
+func (m *JobManager) worker() {
+	defer m.wg.Done()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case qj := <-m.queue:
+			m.execute(qj)
+		}
+	}
+}
+
+// execute 执行单个作业
+func (m *JobManager) execute(qj queuedJob) {
+	job := qj.job
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	defer cancel()
+
+	m.mu.Lock()
+	if _, ok := m.cancelled[job.ID]; ok {
+		delete(m.cancelled, job.ID)
+		m.mu.Unlock()
+		m.finish(job, JobStatusCancelled, nil, "作业已取消")
+		return
+	}
+	m.running[job.ID] = &runningJob{cancel: cancel}
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, job.ID)
+		m.mu.Unlock()
+	}()
+
+	now := time.Now()
+	job.Status = JobStatusRunning
+	job.StartedAt = &now
+	if _, err := m.db.Exec("UPDATE jobs SET status = ?, started_at = ?, updated_at = ? WHERE id = ?",
+		JobStatusRunning, now, now, job.ID); err != nil {
+		log.Error().Err(err).Int64("job_id", job.ID).Msg("Failed to mark job running")
+	}
+	m.publish(JobEvent{
+		Type:      "status",
+		JobID:     job.ID,
+		JobType:   job.JobType,
+		Status:    JobStatusRunning,
+		Progress:  -1,
+		Timestamp: now,
+	})
+
+	jc := &JobContext{Context: ctx, job: job, manager: m}
+
+	result, err := m.safeRun(qj.fn, jc)
+
+	job.Total = jc.total.Load()
+	job.Processed = jc.processed.Load()
+
+	switch {
+	case ctx.Err() != nil:
+		m.finish(job, JobStatusCancelled, result, "作业已取消")
+	case err != nil:
+		m.finish(job, JobStatusFailed, result, err.Error())
+	default:
+		m.finish(job, JobStatusSuccess, result, "")
+	}
+}
+
+// safeRun 执行作业函数并捕获 panic
+func (m *JobManager) safeRun(fn JobFunc, jc *JobContext) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panic: %v", r)
+			log.Error().Interface("panic", r).Int64("job_id", jc.job.ID).Msg("Job panicked")
+		}
+	}()
+	return fn(jc)
+}
+
+// finish 写入最终状态并广播
+func (m *JobManager) finish(job *Job, status JobStatus, result any, message string) {
+	var resultJSON []byte
+	if result != nil {
+		resultJSON, _ = json.Marshal(result)
+	}
+
+	now := time.Now()
+	job.Status = status
+	job.FinishedAt = &now
+	job.Message = message
+
+	if _, err := m.db.Exec(
+		"UPDATE jobs SET status = ?, total = ?, processed = ?, result = ?, message = ?, finished_at = ?, updated_at = ? WHERE id = ?",
+		status, job.Total, job.Processed, resultJSON, message, now, now, job.ID,
+	); err != nil {
+		log.Error().Err(err).Int64("job_id", job.ID).Msg("Failed to update job result")
+	}
+
+	m.publish(JobEvent{
+		Type:      "done",
+		JobID:     job.ID,
+		JobType:   job.JobType,
+		Status:    status,
+		Total:     job.Total,
+		Processed: job.Processed,
+		Progress:  job.Progress(),
+		Message:   message,
+		Result:    result,
+		Timestamp: now,
+	})
+
+	logEvent := log.Info()
+	if status == JobStatusFailed {
+		logEvent = log.Error()
+	}
+	logEvent.Int64("job_id", job.ID).
+		Str("type", job.JobType).
+		Str("status", string(status)).
+		Int64("processed", job.Processed).
+		Str("message", message).
+		Msg("Job finished")
+}
+
+// updateProgress 更新作业进度
+func (m *JobManager) updateProgress(id int64, total, processed int64) {
+	if _, err := m.db.Exec("UPDATE jobs SET total = ?, processed = ?, updated_at = ? WHERE id = ?",
+		total, processed, time.Now(), id); err != nil {
+		log.Warn().Err(err).Int64("job_id", id).Msg("Failed to update job progress")
+	}
+}
+
+// ChunkedDelete 分批物理删除，每批之间检查取消信号并汇报进度
+// table 和 where 由调用方保证安全（不可拼接用户输入）
+func ChunkedDelete(jc *JobContext, db *sqlx.DB, table, where string, args []interface{}, chunkSize int) (int64, error) {
+	if chunkSize <= 0 {
+		chunkSize = 5000
+	}
+
+	var total int64
+	if err := db.GetContext(jc, &total, "SELECT COUNT(*) FROM "+table+" WHERE "+where, args...); err != nil {
+		return 0, fmt.Errorf("count %s: %w", table, err)
+	}
+	jc.SetTotal(total)
+
+	query := "DELETE FROM " + table + " WHERE " + where + " LIMIT ?"
+	queryArgs := append(append([]interface{}{}, args...), chunkSize)
+
+	var deleted int64
+	for {
+		if jc.Cancelled() {
+			return deleted, jc.Err()
+		}
+		result, err := db.ExecContext(jc, query, queryArgs...)
+		if err != nil {
+			return deleted, fmt.Errorf("delete %s: %w", table, err)
+		}
+		affected, _ := result.RowsAffected()
+		deleted += affected
+		jc.Advance(affected)
+		if affected < int64(chunkSize) {
+			return deleted, nil
+		}
+	}
+}
@@ -0,0 +1,467 @@
+// Package core provides long-tail keyword expansion via search suggestion APIs
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"seo-generator/api/pkg/config"
+)
+
+// TaskTypeExpandKeywords 长尾关键词扩展任务类型
+const TaskTypeExpandKeywords TaskType = "expand_keywords"
+
+// 下拉联想来源
+const (
+	SuggestSourceBaidu = "baidu"
+	SuggestSourceSo360 = "so360"
+	SuggestSourceSogou = "sogou"
+)
+
+const defaultSuggestUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+
+// ExpandKeywordsParams 扩展任务参数
+// 种子来自 seeds 或 seed_group_id（按 ID 倒序取 seed_limit 个），两者可同时设置
+type ExpandKeywordsParams struct {
+	Seeds         []string `json:"seeds,omitempty"`
+	SeedGroupID   int      `json:"seed_group_id,omitempty"`
+	SeedLimit     int      `json:"seed_limit,omitempty"`
+	TargetGroupID int      `json:"target_group_id"`
+	Sources       []string `json:"sources,omitempty"`     // 为空使用配置的来源
+	Depth         int      `json:"depth,omitempty"`       // 扩展层数，默认 1（只扩展种子）
+	MaxResults    int      `json:"max_results,omitempty"` // 为空或超过配置上限时使用配置值
+}
+
+// ParseExpandKeywordsParams 解析扩展任务参数
+func ParseExpandKeywordsParams(data json.RawMessage) (*ExpandKeywordsParams, error) {
+	var params ExpandKeywordsParams
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &params); err != nil {
+			return nil, err
+		}
+	}
+	if params.TargetGroupID <= 0 {
+		return nil, fmt.Errorf("target_group_id is required")
+	}
+	if len(params.Seeds) == 0 && params.SeedGroupID <= 0 {
+		return nil, fmt.Errorf("seeds or seed_group_id is required")
+	}
+	for _, s := range params.Sources {
+		if !ValidSuggestSource(s) {
+			return nil, fmt.Errorf("unknown suggest source: %s", s)
+		}
+	}
+	return &params, nil
+}
+
+// ValidSuggestSource 是否为支持的下拉联想来源
+func ValidSuggestSource(source string) bool {
+	switch source {
+	case SuggestSourceBaidu, SuggestSourceSo360, SuggestSourceSogou:
+		return true
+	}
+	return false
+}
+
+// ExpandKeywordsResult 扩展结果（写入作业 result）
+type ExpandKeywordsResult struct {
+	TargetGroupID int   `json:"target_group_id"`
+	Seeds         int   `json:"seeds"`
+	Requests      int   `json:"requests"`    // 联想接口请求次数
+	Failed        int   `json:"failed"`      // 请求失败次数
+	Suggestions   int   `json:"suggestions"` // 返回的联想词总数
+	Duplicates    int   `json:"duplicates"`  // 本次任务内重复（含与种子重复）
+	Banned        int   `json:"banned"`      // 命中违禁词丢弃
+	Existing      int   `json:"existing"`    // 目标分组中已存在
+	Added         int   `json:"added"`
+	Truncated     bool  `json:"truncated"` // 达到 max_results 提前结束
+	DurationMs    int64 `json:"duration_ms"`
+}
+
+// KeywordExpander 长尾关键词扩展
+// 按层请求各来源的下拉联想接口（每个来源独立限速），本次任务内去重、过滤违禁词后
+// 批量 INSERT IGNORE 到目标分组，由目标分组唯一索引完成与已有关键词的去重
+type KeywordExpander struct {
+	db     *sqlx.DB
+	config config.KeywordExpandConfig
+	client *http.Client
+	filter *ContentFilter
+	pool   *PoolManager
+	funcs  *TemplateFuncsManager
+	jobs   *JobManager
+
+	limitersMu sync.Mutex
+	limiters   map[string]*suggestLimiter
+}
+
+// NewKeywordExpander 创建长尾关键词扩展，filter / pool / funcs / jobs 可为 nil
+func NewKeywordExpander(db *sqlx.DB, cfg config.KeywordExpandConfig, filter *ContentFilter, pool *PoolManager, funcs *TemplateFuncsManager, jobs *JobManager) *KeywordExpander {
+	if len(cfg.Sources) == 0 {
+		cfg.Sources = []string{SuggestSourceBaidu, SuggestSourceSo360, SuggestSourceSogou}
+	}
+	if cfg.RequestsPerSecond <= 0 {
+		cfg.RequestsPerSecond = 2
+	}
+	if cfg.TimeoutSeconds <= 0 {
+		cfg.TimeoutSeconds = 10
+	}
+	if cfg.MaxDepth <= 0 {
+		cfg.MaxDepth = 3
+	}
+	if cfg.MaxResults <= 0 {
+		cfg.MaxResults = 20000
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = defaultSuggestUserAgent
+	}
+	return &KeywordExpander{
+		db:       db,
+		config:   cfg,
+		client:   GetHTTPClient().Client(EgressPurposeKeywordSuggest, time.Duration(cfg.TimeoutSeconds)*time.Second),
+		filter:   filter,
+		pool:     pool,
+		funcs:    funcs,
+		jobs:     jobs,
+		limiters: make(map[string]*suggestLimiter),
+	}
+}
+
+// Submit 作为后台作业执行扩展，进度和结果（含去重统计）通过 /api/jobs 查看
+func (e *KeywordExpander) Submit(ctx context.Context, params *ExpandKeywordsParams) (int64, error) {
+	if e.jobs == nil {
+		return 0, fmt.Errorf("job manager not available")
+	}
+	return e.jobs.SubmitFunc(ctx, string(TaskTypeExpandKeywords), params, func(jc *JobContext) (any, error) {
+		var reported int
+		return e.Run(jc, params, func(done, total int) {
+			if total != reported {
+				jc.SetTotal(int64(total))
+				reported = total
+			}
+			jc.Advance(1)
+		})
+	})
+}
+
+// Run 执行扩展，progress 在每次请求后回调（已完成, 已知总请求数），可为 nil
+func (e *KeywordExpander) Run(ctx context.Context, params *ExpandKeywordsParams, progress func(done, total int)) (*ExpandKeywordsResult, error) {
+	start := time.Now()
+	result := &ExpandKeywordsResult{TargetGroupID: params.TargetGroupID}
+
+	sources := params.Sources
+	if len(sources) == 0 {
+		sources = e.config.Sources
+	}
+	depth := params.Depth
+	if depth <= 0 {
+		depth = 1
+	}
+	if depth > e.config.MaxDepth {
+		depth = e.config.MaxDepth
+	}
+	maxResults := params.MaxResults
+	if maxResults <= 0 || maxResults > e.config.MaxResults {
+		maxResults = e.config.MaxResults
+	}
+
+	seeds, err := e.loadSeeds(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	result.Seeds = len(seeds)
+
+	seen := make(map[string]bool, len(seeds))
+	for _, s := range seeds {
+		seen[s] = true
+	}
+
+	var accepted []string
+	frontier := seeds
+	done, total := 0, len(seeds)*len(sources)
+
+expand:
+	for level := 0; level < depth && len(frontier) > 0; level++ {
+		var next []string
+		for _, query := range frontier {
+			for _, source := range sources {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				suggestions, err := e.suggest(ctx, source, query)
+				result.Requests++
+				done++
+				if err != nil {
+					result.Failed++
+					log.Debug().Err(err).Str("source", source).Str("query", query).Msg("Keyword suggest request failed")
+				}
+				result.Suggestions += len(suggestions)
+
+				for _, s := range suggestions {
+					kw := normalizeSuggestion(s)
+					if kw == "" {
+						continue
+					}
+					if seen[kw] {
+						result.Duplicates++
+						continue
+					}
+					seen[kw] = true
+					if e.filter != nil && e.filter.Contains(kw) {
+						result.Banned++
+						continue
+					}
+					accepted = append(accepted, kw)
+					if level+1 < depth {
+						next = append(next, kw)
+						total += len(sources)
+					}
+					if len(accepted) >= maxResults {
+						result.Truncated = true
+						if progress != nil {
+							progress(done, done)
+						}
+						break expand
+					}
+				}
+				if progress != nil {
+					progress(done, total)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	added, err := e.save(ctx, params.TargetGroupID, accepted)
+	if err != nil {
+		return nil, err
+	}
+	result.Added = added
+	result.Existing = len(accepted) - added
+	result.DurationMs = time.Since(start).Milliseconds()
+
+	log.Info().Int("target_group_id", params.TargetGroupID).Int("seeds", result.Seeds).Int("requests", result.Requests).
+		Int("added", result.Added).Int("existing", result.Existing).Int("banned", result.Banned).
+		Msg("Keyword expansion finished")
+	return result, nil
+}
+
+// loadSeeds 收集并规范化种子关键词
+func (e *KeywordExpander) loadSeeds(ctx context.Context, params *ExpandKeywordsParams) ([]string, error) {
+	raw := append([]string{}, params.Seeds...)
+	if params.SeedGroupID > 0 {
+		limit := params.SeedLimit
+		if limit <= 0 {
+			limit = 100
+		}
+		var groupSeeds []string
+		if err := e.db.SelectContext(ctx, &groupSeeds,
+			"SELECT keyword FROM keywords WHERE group_id = ? AND status = 1 ORDER BY id DESC LIMIT ?",
+			params.SeedGroupID, limit); err != nil {
+			return nil, fmt.Errorf("load seed keywords: %w", err)
+		}
+		raw = append(raw, groupSeeds...)
+	}
+
+	seeds := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, s := range raw {
+		kw := normalizeSuggestion(s)
+		if kw == "" || seen[kw] {
+			continue
+		}
+		seen[kw] = true
+		seeds = append(seeds, kw)
+	}
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("no seed keywords")
+	}
+	return seeds, nil
+}
+
+// save 批量写入目标分组并同步关键词池，返回实际新增数量
+func (e *KeywordExpander) save(ctx context.Context, groupID int, keywords []string) (int, error) {
+	const batchSize = 5000
+	added := 0
+	for i := 0; i < len(keywords); i += batchSize {
+		batch := keywords[i:min(i+batchSize, len(keywords))]
+		valueStrings := make([]string, len(batch))
+		valueArgs := make([]interface{}, 0, len(batch)*2)
+		for j, kw := range batch {
+			valueStrings[j] = "(?, ?)"
+			valueArgs = append(valueArgs, groupID, kw)
+		}
+		res, err := e.db.ExecContext(ctx,
+			"INSERT IGNORE INTO keywords (group_id, keyword) VALUES "+strings.Join(valueStrings, ","), valueArgs...)
+		if err != nil {
+			return added, fmt.Errorf("insert keywords: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		added += int(n)
+	}
+
+	if added > 0 && e.pool != nil {
+		e.pool.ReloadKeywordGroup(ctx, groupID)
+		if e.funcs != nil {
+			e.funcs.ReloadKeywordGroup(groupID, e.pool.GetKeywords(groupID), e.pool.GetAllRawKeywords(groupID))
+		}
+	}
+	return added, nil
+}
+
+// suggest 按来源限速后请求下拉联想
+func (e *KeywordExpander) suggest(ctx context.Context, source, query string) ([]string, error) {
+	if err := e.limiter(source).Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	var endpoint string
+	switch source {
+	case SuggestSourceBaidu:
+		endpoint = "https://suggestion.baidu.com/su?action=opensearch&ie=utf-8&wd=" + url.QueryEscape(query)
+	case SuggestSourceSo360:
+		endpoint = "https://sug.so.360.cn/suggest?encodein=utf-8&encodeout=utf-8&format=json&word=" + url.QueryEscape(query)
+	case SuggestSourceSogou:
+		endpoint = "https://www.sogou.com/suggnew/ajajjson?type=web&key=" + url.QueryEscape(query)
+	default:
+		return nil, fmt.Errorf("unknown suggest source: %s", source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", e.config.UserAgent)
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	return parseSuggestions(source, body)
+}
+
+// parseSuggestions 解析各来源的响应格式
+//
+//	baidu: ["query", ["s1", "s2"]]
+//	so360: {"result": [{"word": "s1"}]}
+//	sogou: window.sogou.sug(["query", ["s1", "s2"], ...], -1);（GBK 编码）
+func parseSuggestions(source string, body []byte) ([]string, error) {
+	if !utf8.Valid(body) {
+		decoded, err := simplifiedchinese.GBK.NewDecoder().Bytes(body)
+		if err != nil {
+			return nil, fmt.Errorf("decode GBK: %w", err)
+		}
+		body = decoded
+	}
+
+	switch source {
+	case SuggestSourceSo360:
+		var resp struct {
+			Result []struct {
+				Word string `json:"word"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, err
+		}
+		out := make([]string, 0, len(resp.Result))
+		for _, r := range resp.Result {
+			out = append(out, r.Word)
+		}
+		return out, nil
+	case SuggestSourceSogou:
+		// 去掉 JSONP 包装，取第一个参数
+		l, r := bytes.IndexByte(body, '('), bytes.LastIndexByte(body, ')')
+		if l < 0 || r <= l {
+			return nil, fmt.Errorf("unexpected sogou response")
+		}
+		body = body[l+1 : r]
+		if i := bytes.LastIndexByte(body, ']'); i >= 0 {
+			body = body[:i+1]
+		}
+	}
+
+	var arr []json.RawMessage
+	if err := json.Unmarshal(body, &arr); err != nil {
+		return nil, err
+	}
+	if len(arr) < 2 {
+		return nil, nil
+	}
+	var out []string
+	if err := json.Unmarshal(arr[1], &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// normalizeSuggestion 规范化联想词：合并空白，过长或为空时返回空字符串
+func normalizeSuggestion(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" || utf8.RuneCountInString(s) > 64 {
+		return ""
+	}
+	return s
+}
+
+// limiter 获取来源的限速器
+func (e *KeywordExpander) limiter(source string) *suggestLimiter {
+	e.limitersMu.Lock()
+	defer e.limitersMu.Unlock()
+	l, ok := e.limiters[source]
+	if !ok {
+		l = &suggestLimiter{interval: time.Duration(float64(time.Second) / e.config.RequestsPerSecond)}
+		e.limiters[source] = l
+	}
+	return l
+}
+
+// suggestLimiter 固定间隔限速器，同一来源的所有任务共享
+type suggestLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// Wait 等待下一个可用时间点
+func (l *suggestLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
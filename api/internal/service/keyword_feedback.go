@@ -0,0 +1,448 @@
+// Package core provides the keyword crawl feedback loop
+package core
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// keywordScorePrior 得分平滑的先验页面数：页面较少的关键词向全局平均值收缩
+const keywordScorePrior = 5.0
+
+// PageKeywords 一次渲染使用的关键词
+type PageKeywords struct {
+	Domain         string
+	Path           string
+	KeywordGroupID int
+	Keywords       []string
+	RenderedAt     time.Time
+}
+
+// KeywordCrawlScore 关键词抓取得分
+type KeywordCrawlScore struct {
+	KeywordGroupID int        `db:"keyword_group_id" json:"keyword_group_id"`
+	Keyword        string     `db:"keyword" json:"keyword"`
+	Pages          int        `db:"pages" json:"pages"`
+	Crawls         int        `db:"crawls" json:"crawls"`
+	Score          float64    `db:"score" json:"score"`
+	LastCrawledAt  *time.Time `db:"last_crawled_at" json:"last_crawled_at"`
+	ComputedAt     time.Time  `db:"computed_at" json:"computed_at"`
+}
+
+// KeywordFeedbackResult 一次得分计算的结果
+type KeywordFeedbackResult struct {
+	Pages        int           `json:"pages"`
+	CrawledPages int           `json:"crawled_pages"`
+	Keywords     int           `json:"keywords"`
+	Pruned       int64         `json:"pruned"`
+	Duration     time.Duration `json:"duration"`
+	ComputedAt   time.Time     `json:"computed_at"`
+}
+
+type keywordScoreKey struct {
+	groupID int
+	keyword string
+}
+
+type keywordScoreAcc struct {
+	pages       int
+	crawls      int
+	lastCrawled time.Time
+}
+
+type pageVisit struct {
+	count int
+	last  time.Time
+}
+
+// KeywordFeedback 关键词抓取反馈
+// 渲染时记录页面使用的标题关键词（page_keywords，按域名+路径覆盖），定期把蜘蛛访问日志按 URL
+// 关联回关键词，计算每个关键词的平滑抓取频率得分写入 keyword_crawl_scores；
+// 开启加权选取时把每个分组得分最高的关键词交给关键词池优先选取
+type KeywordFeedback struct {
+	db     *sqlx.DB
+	pool   *PoolManager
+	config config.KeywordFeedbackConfig
+
+	queue   chan PageKeywords
+	dropped atomic.Int64
+
+	mu         sync.Mutex // 串行化得分计算
+	lastResult atomic.Pointer[KeywordFeedbackResult]
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewKeywordFeedback 创建关键词抓取反馈
+func NewKeywordFeedback(db *sqlx.DB, pool *PoolManager, cfg config.KeywordFeedbackConfig) *KeywordFeedback {
+	if cfg.WindowDays <= 0 {
+		cfg.WindowDays = 7
+	}
+	if cfg.RetentionDays < cfg.WindowDays {
+		cfg.RetentionDays = cfg.WindowDays
+	}
+	if cfg.ComputeIntervalMinutes <= 0 {
+		cfg.ComputeIntervalMinutes = 60
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 10000
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.BoostTopN <= 0 {
+		cfg.BoostTopN = 1000
+	}
+	return &KeywordFeedback{
+		db:     db,
+		pool:   pool,
+		config: cfg,
+		queue:  make(chan PageKeywords, cfg.QueueSize),
+	}
+}
+
+// Enabled 是否启用
+func (f *KeywordFeedback) Enabled() bool {
+	return f != nil && f.config.Enabled
+}
+
+// Start 启动批量写入和定时计算
+func (f *KeywordFeedback) Start(ctx context.Context) {
+	f.ctx, f.cancel = context.WithCancel(ctx)
+
+	if f.config.WeightedSelection {
+		if err := f.applyBoost(f.ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to load keyword crawl scores")
+		}
+	}
+
+	f.wg.Add(2)
+	go f.writeLoop()
+	go func() {
+		defer f.wg.Done()
+		ticker := time.NewTicker(time.Duration(f.config.ComputeIntervalMinutes) * time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-f.ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := f.Compute(f.ctx); err != nil {
+					log.Warn().Err(err).Msg("Failed to compute keyword crawl scores")
+				}
+			}
+		}
+	}()
+}
+
+// Stop 写入剩余记录后停止
+func (f *KeywordFeedback) Stop() {
+	if f.cancel != nil {
+		f.cancel()
+	}
+	f.wg.Wait()
+}
+
+// Record 记录页面使用的关键词（非阻塞，队列满时丢弃）
+func (f *KeywordFeedback) Record(domain, path string, groupID int, keywords []string) {
+	if !f.Enabled() || len(keywords) == 0 {
+		return
+	}
+	select {
+	case f.queue <- PageKeywords{Domain: domain, Path: path, KeywordGroupID: groupID, Keywords: keywords, RenderedAt: time.Now()}:
+	default:
+		f.dropped.Add(1)
+	}
+}
+
+func (f *KeywordFeedback) writeLoop() {
+	defer f.wg.Done()
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	batch := make([]PageKeywords, 0, f.config.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := f.write(context.Background(), batch); err != nil {
+			log.Warn().Err(err).Int("count", len(batch)).Msg("Failed to write page keywords")
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-f.ctx.Done():
+			for {
+				select {
+				case item := <-f.queue:
+					batch = append(batch, item)
+					if len(batch) >= f.config.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		case item := <-f.queue:
+			batch = append(batch, item)
+			if len(batch) >= f.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// write 批量写入页面关键词，同一页面重新渲染时覆盖
+func (f *KeywordFeedback) write(ctx context.Context, batch []PageKeywords) error {
+	placeholders := make([]string, 0, len(batch))
+	args := make([]interface{}, 0, len(batch)*6)
+	for _, p := range batch {
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?)")
+		args = append(args, p.Domain, md5Hex(p.Path), truncateRunes(p.Path, 500), p.KeywordGroupID,
+			strings.Join(p.Keywords, "\n"), p.RenderedAt)
+	}
+	_, err := f.db.ExecContext(ctx, `
+		INSERT INTO page_keywords (domain, path_hash, path, keyword_group_id, keywords, rendered_at)
+		VALUES `+strings.Join(placeholders, ",")+`
+		ON DUPLICATE KEY UPDATE keyword_group_id = VALUES(keyword_group_id), keywords = VALUES(keywords),
+			rendered_at = VALUES(rendered_at)`, args...)
+	return err
+}
+
+// Compute 关联蜘蛛访问与页面关键词，重新计算全部关键词得分
+func (f *KeywordFeedback) Compute(ctx context.Context) (*KeywordFeedbackResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	start := time.Now()
+	since := start.AddDate(0, 0, -f.config.WindowDays)
+	result := &KeywordFeedbackResult{ComputedAt: start.Truncate(time.Second)}
+
+	pruned, err := f.db.ExecContext(ctx, "DELETE FROM page_keywords WHERE rendered_at < ?",
+		start.AddDate(0, 0, -f.config.RetentionDays))
+	if err != nil {
+		return nil, fmt.Errorf("prune page keywords: %w", err)
+	}
+	result.Pruned, _ = pruned.RowsAffected()
+
+	// 1. 窗口内每个 URL 的蜘蛛访问次数
+	visits := make(map[string]pageVisit)
+	rows, err := f.db.QueryxContext(ctx, `
+		SELECT domain, path, COUNT(*) AS cnt, MAX(created_at) AS last_at
+		FROM spider_logs WHERE created_at >= ? GROUP BY domain, path`, since)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate spider logs: %w", err)
+	}
+	for rows.Next() {
+		var domain, path string
+		var v pageVisit
+		if err := rows.Scan(&domain, &path, &v.count, &v.last); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		visits[domain+"\x00"+path] = v
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("aggregate spider logs: %w", err)
+	}
+
+	// 2. 按页面关键词汇总
+	scores := make(map[keywordScoreKey]*keywordScoreAcc)
+	rows, err = f.db.QueryxContext(ctx, "SELECT domain, path, keyword_group_id, keywords FROM page_keywords")
+	if err != nil {
+		return nil, fmt.Errorf("load page keywords: %w", err)
+	}
+	totalCrawls := 0
+	for rows.Next() {
+		var domain, path, keywords string
+		var groupID int
+		if err := rows.Scan(&domain, &path, &groupID, &keywords); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		result.Pages++
+		v := visits[domain+"\x00"+path]
+		if v.count > 0 {
+			result.CrawledPages++
+			totalCrawls += v.count
+		}
+		for _, kw := range strings.Split(keywords, "\n") {
+			if kw == "" {
+				continue
+			}
+			key := keywordScoreKey{groupID: groupID, keyword: kw}
+			acc := scores[key]
+			if acc == nil {
+				acc = &keywordScoreAcc{}
+				scores[key] = acc
+			}
+			acc.pages++
+			acc.crawls += v.count
+			if v.last.After(acc.lastCrawled) {
+				acc.lastCrawled = v.last
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("load page keywords: %w", err)
+	}
+	result.Keywords = len(scores)
+
+	// 3. 平滑得分：(crawls + k*平均每页抓取) / (pages + k)
+	mean := 0.0
+	if result.Pages > 0 {
+		mean = float64(totalCrawls) / float64(result.Pages)
+	}
+	if err := f.saveScores(ctx, scores, mean, result.ComputedAt); err != nil {
+		return nil, err
+	}
+
+	if f.config.WeightedSelection {
+		if err := f.applyBoost(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to apply keyword boost")
+		}
+	}
+
+	result.Duration = time.Since(start)
+	f.lastResult.Store(result)
+	log.Info().
+		Int("pages", result.Pages).
+		Int("crawled_pages", result.CrawledPages).
+		Int("keywords", result.Keywords).
+		Int64("pruned", result.Pruned).
+		Dur("duration", result.Duration).
+		Msg("Keyword crawl scores computed")
+	return result, nil
+}
+
+func (f *KeywordFeedback) saveScores(ctx context.Context, scores map[keywordScoreKey]*keywordScoreAcc, mean float64, computedAt time.Time) error {
+	const cols = 8
+	placeholders := make([]string, 0, f.config.BatchSize)
+	args := make([]interface{}, 0, f.config.BatchSize*cols)
+	flush := func() error {
+		if len(placeholders) == 0 {
+			return nil
+		}
+		_, err := f.db.ExecContext(ctx, `
+			INSERT INTO keyword_crawl_scores
+				(keyword_group_id, keyword_hash, keyword, pages, crawls, score, last_crawled_at, computed_at)
+			VALUES `+strings.Join(placeholders, ",")+`
+			ON DUPLICATE KEY UPDATE pages = VALUES(pages), crawls = VALUES(crawls), score = VALUES(score),
+				last_crawled_at = VALUES(last_crawled_at), computed_at = VALUES(computed_at)`, args...)
+		placeholders = placeholders[:0]
+		args = args[:0]
+		return err
+	}
+
+	for key, acc := range scores {
+		var last interface{}
+		if !acc.lastCrawled.IsZero() {
+			last = acc.lastCrawled
+		}
+		score := (float64(acc.crawls) + keywordScorePrior*mean) / (float64(acc.pages) + keywordScorePrior)
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args, key.groupID, md5Hex(key.keyword), truncateRunes(key.keyword, 500),
+			acc.pages, acc.crawls, score, last, computedAt)
+		if len(placeholders) >= f.config.BatchSize {
+			if err := flush(); err != nil {
+				return fmt.Errorf("save keyword scores: %w", err)
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return fmt.Errorf("save keyword scores: %w", err)
+	}
+
+	// 本次未出现的关键词（页面记录已过期）删除
+	if _, err := f.db.ExecContext(ctx, "DELETE FROM keyword_crawl_scores WHERE computed_at < ?", computedAt); err != nil {
+		return fmt.Errorf("clean keyword scores: %w", err)
+	}
+	return nil
+}
+
+// applyBoost 把每个分组得分最高的关键词设为关键词池的优先列表
+func (f *KeywordFeedback) applyBoost(ctx context.Context) error {
+	if f.pool == nil {
+		return nil
+	}
+	var groupIDs []int
+	if err := f.db.SelectContext(ctx, &groupIDs, "SELECT DISTINCT keyword_group_id FROM keyword_crawl_scores"); err != nil {
+		return err
+	}
+	for _, groupID := range groupIDs {
+		var keywords []string
+		if err := f.db.SelectContext(ctx, &keywords, `
+			SELECT keyword FROM keyword_crawl_scores
+			WHERE keyword_group_id = ? AND crawls > 0
+			ORDER BY score DESC LIMIT ?`, groupID, f.config.BoostTopN); err != nil {
+			return err
+		}
+		f.pool.SetBoostedKeywords(groupID, keywords, f.config.BoostRatio)
+	}
+	return nil
+}
+
+// TopScores 返回分组内得分最高（order=desc）或最低（order=asc）的关键词
+func (f *KeywordFeedback) TopScores(ctx context.Context, groupID, limit int, ascending bool) ([]KeywordCrawlScore, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	order := "DESC"
+	if ascending {
+		order = "ASC"
+	}
+	items := []KeywordCrawlScore{}
+	err := f.db.SelectContext(ctx, &items, `
+		SELECT keyword_group_id, keyword, pages, crawls, score, last_crawled_at, computed_at
+		FROM keyword_crawl_scores WHERE keyword_group_id = ?
+		ORDER BY score `+order+`, crawls `+order+` LIMIT ?`, groupID, limit)
+	return items, err
+}
+
+// Status 返回最近一次计算结果和队列状态
+func (f *KeywordFeedback) Status() map[string]interface{} {
+	return map[string]interface{}{
+		"enabled":            f.Enabled(),
+		"weighted_selection": f.config.WeightedSelection,
+		"window_days":        f.config.WindowDays,
+		"queued":             len(f.queue),
+		"dropped":            f.dropped.Load(),
+		"last_result":        f.lastResult.Load(),
+	}
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncateRunes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -0,0 +1,222 @@
+// Package core provides quality filters applied when keywords are ingested
+package core
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// 关键词拒绝原因
+const (
+	KeywordRejectEmpty     = "empty"
+	KeywordRejectTooShort  = "too_short"
+	KeywordRejectTooLong   = "too_long"
+	KeywordRejectBanned    = "banned_pattern"
+	KeywordRejectStopword  = "stopword"
+	keywordRejectReportMax = 100 // 响应中列出的被拒关键词上限
+)
+
+// KeywordRejection 被拒绝的关键词
+type KeywordRejection struct {
+	Keyword string `json:"keyword"`
+	Reason  string `json:"reason"`
+	Detail  string `json:"detail,omitempty"` // 命中的正则或停用词
+}
+
+// KeywordFilterReport 过滤报告
+type KeywordFilterReport struct {
+	Accepted  int                `json:"accepted"`
+	Rejected  int                `json:"rejected"`
+	Reasons   map[string]int     `json:"reasons"`   // 各原因的拒绝数
+	Items     []KeywordRejection `json:"items"`     // 前 100 条被拒关键词
+	Truncated bool               `json:"truncated"` // items 是否被截断
+}
+
+// keywordFilterRules 不可变的规则快照
+type keywordFilterRules struct {
+	patterns  []*regexp.Regexp
+	global    []string         // 全局停用词（配置）
+	stopwords map[int][]string // groupID -> 停用词（keyword_stopwords 表，0 为全部分组）
+}
+
+// KeywordFilter 关键词入库过滤（长度、正则黑名单、分组停用词）
+//
+// 停用词用于剔除只由虚词组成的关键词：去掉全部停用词后剩余长度不足 min_length 即拒绝，
+// 而不是包含停用词就拒绝。
+type KeywordFilter struct {
+	db    *sqlx.DB
+	cfg   config.KeywordFilterConfig
+	rules atomic.Pointer[keywordFilterRules]
+}
+
+// NewKeywordFilter 创建关键词过滤器，未启用时返回 nil；无效的正则记录日志后跳过
+func NewKeywordFilter(db *sqlx.DB, cfg config.KeywordFilterConfig) *KeywordFilter {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.MinLength <= 0 {
+		cfg.MinLength = 1
+	}
+	f := &KeywordFilter{db: db, cfg: cfg}
+
+	rules := &keywordFilterRules{stopwords: map[int][]string{}}
+	for _, p := range cfg.BanPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			log.Warn().Err(err).Str("pattern", p).Msg("Invalid keyword ban pattern, skipped")
+			continue
+		}
+		rules.patterns = append(rules.patterns, re)
+	}
+	rules.global = normalizeStopwords(cfg.Stopwords)
+	f.rules.Store(rules)
+	return f
+}
+
+// Reload 从 keyword_stopwords 重新加载分组停用词
+func (f *KeywordFilter) Reload(ctx context.Context) error {
+	if f == nil || f.db == nil {
+		return nil
+	}
+	var rows []struct {
+		GroupID int    `db:"group_id"`
+		Word    string `db:"word"`
+	}
+	if err := f.db.SelectContext(ctx, &rows, "SELECT group_id, word FROM keyword_stopwords"); err != nil {
+		return fmt.Errorf("load keyword stopwords: %w", err)
+	}
+	byGroup := make(map[int][]string)
+	for _, r := range rows {
+		byGroup[r.GroupID] = append(byGroup[r.GroupID], r.Word)
+	}
+	for gid, words := range byGroup {
+		byGroup[gid] = normalizeStopwords(words)
+	}
+
+	old := f.rules.Load()
+	f.rules.Store(&keywordFilterRules{patterns: old.patterns, global: old.global, stopwords: byGroup})
+	return nil
+}
+
+// Stopwords 分组停用词（不含全局停用词）
+func (f *KeywordFilter) Stopwords(groupID int) []string {
+	if f == nil {
+		return []string{}
+	}
+	return append([]string{}, f.rules.Load().stopwords[groupID]...)
+}
+
+// SetStopwords 替换分组停用词并重新加载
+func (f *KeywordFilter) SetStopwords(ctx context.Context, groupID int, words []string) ([]string, error) {
+	words = normalizeStopwords(words)
+	tx, err := f.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "DELETE FROM keyword_stopwords WHERE group_id = ?", groupID); err != nil {
+		return nil, err
+	}
+	for _, w := range words {
+		if _, err := tx.ExecContext(ctx, "INSERT IGNORE INTO keyword_stopwords (group_id, word) VALUES (?, ?)", groupID, w); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return words, f.Reload(ctx)
+}
+
+// Check 检查单个关键词（已 TrimSpace），通过时返回 nil
+func (f *KeywordFilter) Check(groupID int, keyword string) *KeywordRejection {
+	if keyword == "" {
+		return &KeywordRejection{Keyword: keyword, Reason: KeywordRejectEmpty}
+	}
+	if f == nil {
+		return nil
+	}
+	n := utf8.RuneCountInString(keyword)
+	if n < f.cfg.MinLength {
+		return &KeywordRejection{Keyword: keyword, Reason: KeywordRejectTooShort, Detail: fmt.Sprintf("min %d", f.cfg.MinLength)}
+	}
+	if f.cfg.MaxLength > 0 && n > f.cfg.MaxLength {
+		return &KeywordRejection{Keyword: keyword, Reason: KeywordRejectTooLong, Detail: fmt.Sprintf("max %d", f.cfg.MaxLength)}
+	}
+
+	rules := f.rules.Load()
+	for _, re := range rules.patterns {
+		if re.MatchString(keyword) {
+			return &KeywordRejection{Keyword: keyword, Reason: KeywordRejectBanned, Detail: re.String()}
+		}
+	}
+
+	lists := [][]string{rules.stopwords[groupID], rules.global}
+	if groupID != 0 {
+		lists = append(lists, rules.stopwords[0])
+	}
+	rest := strings.ToLower(keyword)
+	var hit string
+	for _, list := range lists {
+		for _, w := range list {
+			if strings.Contains(rest, w) {
+				rest = strings.ReplaceAll(rest, w, "")
+				hit = w
+			}
+		}
+	}
+	if hit != "" && utf8.RuneCountInString(strings.Join(strings.Fields(rest), "")) < f.cfg.MinLength {
+		return &KeywordRejection{Keyword: keyword, Reason: KeywordRejectStopword, Detail: hit}
+	}
+	return nil
+}
+
+// Filter 过滤一批关键词（逐个 TrimSpace），返回通过的关键词和报告
+func (f *KeywordFilter) Filter(groupID int, keywords []string) ([]string, *KeywordFilterReport) {
+	report := &KeywordFilterReport{Reasons: map[string]int{}, Items: []KeywordRejection{}}
+	accepted := make([]string, 0, len(keywords))
+	for _, kw := range keywords {
+		kw = strings.TrimSpace(kw)
+		if r := f.Check(groupID, kw); r != nil {
+			report.Rejected++
+			report.Reasons[r.Reason]++
+			if len(report.Items) < keywordRejectReportMax {
+				report.Items = append(report.Items, *r)
+			} else {
+				report.Truncated = true
+			}
+			continue
+		}
+		accepted = append(accepted, kw)
+	}
+	report.Accepted = len(accepted)
+	return accepted, report
+}
+
+// normalizeStopwords 去空白、转小写、去重，长词在前（避免短词先替换破坏长词匹配）
+func normalizeStopwords(words []string) []string {
+	seen := make(map[string]bool, len(words))
+	out := make([]string, 0, len(words))
+	for _, w := range words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w == "" || seen[w] {
+			continue
+		}
+		seen[w] = true
+		out = append(out, w)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return utf8.RuneCountInString(out[i]) > utf8.RuneCountInString(out[j])
+	})
+	return out
+}
@@ -0,0 +1,398 @@
+// Package core provides search query import for keyword discovery
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// TaskTypeImportSearchQueries 搜索词导入任务类型
+const TaskTypeImportSearchQueries TaskType = "import_search_queries"
+
+// 候选状态
+const (
+	CandidatePending  = "pending"
+	CandidateApproved = "approved"
+	CandidateRejected = "rejected"
+)
+
+// keywordImportBatch 去重查询和写入的批大小
+const keywordImportBatch = 500
+
+// KeywordCandidate 搜索词候选
+type KeywordCandidate struct {
+	ID             int64      `db:"id" json:"id"`
+	Keyword        string     `db:"keyword" json:"keyword"`
+	Source         string     `db:"source" json:"source"`
+	Site           string     `db:"site" json:"site"`
+	Impressions    int        `db:"impressions" json:"impressions"`
+	Clicks         int        `db:"clicks" json:"clicks"`
+	Status         string     `db:"status" json:"status"`
+	KeywordGroupID *int       `db:"keyword_group_id" json:"keyword_group_id"`
+	ReviewedBy     *string    `db:"reviewed_by" json:"reviewed_by"`
+	ReviewedAt     *time.Time `db:"reviewed_at" json:"reviewed_at"`
+	FirstSeenAt    time.Time  `db:"first_seen_at" json:"first_seen_at"`
+	LastSeenAt     time.Time  `db:"last_seen_at" json:"last_seen_at"`
+}
+
+// KeywordCandidateFilter 候选列表筛选条件
+type KeywordCandidateFilter struct {
+	Status   string
+	Source   string
+	Search   string
+	Page     int
+	PageSize int
+}
+
+// KeywordImportResult 一次导入的结果
+type KeywordImportResult struct {
+	Source     string        `json:"source"`
+	Fetched    int           `json:"fetched"`    // 报表返回的行数
+	Filtered   int           `json:"filtered"`   // 展现量不足或无效被忽略的行数
+	Existing   int           `json:"existing"`   // 已在关键词库中的搜索词
+	Candidates int           `json:"candidates"` // 新增或更新的候选数
+	Duration   time.Duration `json:"duration"`
+	FinishedAt time.Time     `json:"finished_at"`
+}
+
+// KeywordImporter 搜索词导入
+// 定时从百度统计 / Google Search Console 拉取搜索词报表，按关键词去重并排除已在关键词库中的词，
+// 写入 keyword_candidates 待审核；审核通过后由调用方把关键词加入分组
+type KeywordImporter struct {
+	db      *sqlx.DB
+	config  config.KeywordImportConfig
+	sources map[string]SearchQuerySource
+
+	mu sync.Mutex // 串行化导入
+}
+
+// NewKeywordImporter 创建搜索词导入，只注册配置完整的来源
+func NewKeywordImporter(db *sqlx.DB, cfg config.KeywordImportConfig) *KeywordImporter {
+	if cfg.LookbackDays <= 0 {
+		cfg.LookbackDays = 7
+	}
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = 5000
+	}
+	if cfg.TimeoutSeconds <= 0 {
+		cfg.TimeoutSeconds = 30
+	}
+
+	client := GetHTTPClient().Client(EgressPurposeKeywordImport, time.Duration(cfg.TimeoutSeconds)*time.Second)
+	sources := make(map[string]SearchQuerySource)
+	if cfg.BaiduTongji.AccessToken != "" && len(cfg.BaiduTongji.SiteIDs) > 0 {
+		sources[QuerySourceBaiduTongji] = &baiduTongjiSource{
+			client:      client,
+			accessToken: cfg.BaiduTongji.AccessToken,
+			siteIDs:     cfg.BaiduTongji.SiteIDs,
+		}
+	}
+	if cfg.GSC.CredentialsFile != "" && len(cfg.GSC.SiteURLs) > 0 {
+		sources[QuerySourceGSC] = &gscSource{
+			client:   client,
+			credFile: cfg.GSC.CredentialsFile,
+			siteURLs: cfg.GSC.SiteURLs,
+		}
+	}
+	return &KeywordImporter{db: db, config: cfg, sources: sources}
+}
+
+// Sources 返回已配置的来源
+func (i *KeywordImporter) Sources() []string {
+	names := make([]string, 0, len(i.sources))
+	for _, name := range []string{QuerySourceBaiduTongji, QuerySourceGSC} {
+		if _, ok := i.sources[name]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// RunAll 依次从所有已配置的来源导入
+func (i *KeywordImporter) RunAll(ctx context.Context) ([]*KeywordImportResult, error) {
+	var results []*KeywordImportResult
+	var errs []string
+	for _, name := range i.Sources() {
+		result, err := i.Run(ctx, name)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		results = append(results, result)
+	}
+	if len(errs) > 0 {
+		return results, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return results, nil
+}
+
+// Run 从指定来源拉取最近 lookback_days 天的搜索词并写入候选队列
+func (i *KeywordImporter) Run(ctx context.Context, source string) (*KeywordImportResult, error) {
+	src, ok := i.sources[source]
+	if !ok {
+		return nil, fmt.Errorf("search query source not configured: %s", source)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	start := time.Now()
+	end := start.AddDate(0, 0, -1) // 当天数据不完整
+	rows, err := src.Fetch(ctx, end.AddDate(0, 0, -(i.config.LookbackDays-1)), end, i.config.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	result := &KeywordImportResult{Source: source, Fetched: len(rows)}
+
+	// 同一搜索词在多个站点出现时合并指标
+	merged := make(map[string]*SearchQueryRow, len(rows))
+	order := make([]string, 0, len(rows))
+	for _, row := range rows {
+		kw := normalizeSearchQuery(row.Query)
+		if kw == "" {
+			result.Filtered++
+			continue
+		}
+		if m, ok := merged[kw]; ok {
+			m.Impressions += row.Impressions
+			m.Clicks += row.Clicks
+			continue
+		}
+		r := row
+		r.Query = kw
+		merged[kw] = &r
+		order = append(order, kw)
+	}
+	keywords := order[:0]
+	for _, kw := range order {
+		if merged[kw].Impressions < i.config.MinImpressions {
+			result.Filtered++
+			continue
+		}
+		keywords = append(keywords, kw)
+	}
+
+	existing, err := i.existingKeywords(ctx, keywords)
+	if err != nil {
+		return nil, err
+	}
+
+	for off := 0; off < len(keywords); off += keywordImportBatch {
+		batch := keywords[off:min(off+keywordImportBatch, len(keywords))]
+		var sb strings.Builder
+		args := make([]interface{}, 0, len(batch)*6)
+		for _, kw := range batch {
+			if existing[kw] {
+				result.Existing++
+				continue
+			}
+			row := merged[kw]
+			if len(args) > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString("(?, ?, ?, ?, ?, ?)")
+			args = append(args, md5Hex(kw), kw, source, row.Site, row.Impressions, row.Clicks)
+			result.Candidates++
+		}
+		if len(args) == 0 {
+			continue
+		}
+		// 已存在的候选只刷新指标，保留审核状态
+		if _, err := i.db.ExecContext(ctx, `
+			INSERT INTO keyword_candidates (keyword_hash, keyword, source, site, impressions, clicks)
+			VALUES `+sb.String()+`
+			ON DUPLICATE KEY UPDATE impressions = VALUES(impressions), clicks = VALUES(clicks),
+				site = VALUES(site), last_seen_at = NOW()`, args...); err != nil {
+			return nil, fmt.Errorf("save keyword candidates: %w", err)
+		}
+	}
+
+	result.Duration = time.Since(start)
+	result.FinishedAt = time.Now()
+	log.Info().Str("source", source).Int("fetched", result.Fetched).Int("candidates", result.Candidates).
+		Int("existing", result.Existing).Dur("duration", result.Duration).Msg("Search queries imported")
+	return result, nil
+}
+
+// existingKeywords 返回已在关键词库（任意分组）中的搜索词
+func (i *KeywordImporter) existingKeywords(ctx context.Context, keywords []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	for off := 0; off < len(keywords); off += keywordImportBatch {
+		batch := keywords[off:min(off+keywordImportBatch, len(keywords))]
+		query, args, err := sqlx.In("SELECT DISTINCT keyword FROM keywords WHERE keyword IN (?)", batch)
+		if err != nil {
+			return nil, err
+		}
+		var found []string
+		if err := i.db.SelectContext(ctx, &found, query, args...); err != nil {
+			return nil, fmt.Errorf("query existing keywords: %w", err)
+		}
+		for _, kw := range found {
+			// 库中关键词可能含大写，按规范化后的形式比较
+			existing[normalizeSearchQuery(kw)] = true
+		}
+	}
+	return existing, nil
+}
+
+// List 分页查询候选，按展现量倒序
+func (i *KeywordImporter) List(ctx context.Context, f KeywordCandidateFilter) ([]KeywordCandidate, int64, error) {
+	where := []string{"1=1"}
+	args := []interface{}{}
+	if f.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, f.Status)
+	}
+	if f.Source != "" {
+		where = append(where, "source = ?")
+		args = append(args, f.Source)
+	}
+	if f.Search != "" {
+		where = append(where, "keyword LIKE ?")
+		args = append(args, "%"+f.Search+"%")
+	}
+	whereClause := strings.Join(where, " AND ")
+
+	var total int64
+	if err := i.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM keyword_candidates WHERE "+whereClause, args...); err != nil {
+		return nil, 0, err
+	}
+
+	items := []KeywordCandidate{}
+	err := i.db.SelectContext(ctx, &items, `
+		SELECT id, keyword, source, site, impressions, clicks, status, keyword_group_id, reviewed_by, reviewed_at,
+			first_seen_at, last_seen_at
+		FROM keyword_candidates WHERE `+whereClause+`
+		ORDER BY impressions DESC, id DESC LIMIT ? OFFSET ?`,
+		append(args, f.PageSize, (f.Page-1)*f.PageSize)...)
+	return items, total, err
+}
+
+// Approve 把待审核候选加入关键词分组，返回新加入分组的关键词（分组中已存在的不返回）
+func (i *KeywordImporter) Approve(ctx context.Context, ids []int64, groupID int, reviewer string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	query, args, err := sqlx.In("SELECT id, keyword FROM keyword_candidates WHERE id IN (?) AND status = ?", ids, CandidatePending)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		ID      int64  `db:"id"`
+		Keyword string `db:"keyword"`
+	}
+	if err := i.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	tx, err := i.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	added := make([]string, 0, len(rows))
+	approved := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		result, err := tx.ExecContext(ctx, "INSERT IGNORE INTO keywords (group_id, keyword) VALUES (?, ?)", groupID, row.Keyword)
+		if err != nil {
+			return nil, fmt.Errorf("insert keyword: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			added = append(added, row.Keyword)
+		}
+		approved = append(approved, row.ID)
+	}
+
+	query, args, err = sqlx.In(`
+		UPDATE keyword_candidates SET status = ?, keyword_group_id = ?, reviewed_by = ?, reviewed_at = NOW()
+		WHERE id IN (?)`, CandidateApproved, groupID, reviewer, approved)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// Reject 拒绝待审核候选，拒绝后再次导入不会重新进入待审核
+func (i *KeywordImporter) Reject(ctx context.Context, ids []int64, reviewer string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	query, args, err := sqlx.In(`
+		UPDATE keyword_candidates SET status = ?, reviewed_by = ?, reviewed_at = NOW()
+		WHERE id IN (?) AND status = ?`, CandidateRejected, reviewer, ids, CandidatePending)
+	if err != nil {
+		return 0, err
+	}
+	result, err := i.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// EnsureSchedule 按配置创建或更新定时导入任务
+func (i *KeywordImporter) EnsureSchedule(ctx context.Context, scheduler *Scheduler) error {
+	var existing struct {
+		ID       int64  `db:"id"`
+		CronExpr string `db:"cron_expr"`
+		Enabled  bool   `db:"enabled"`
+	}
+	err := i.db.GetContext(ctx, &existing,
+		"SELECT id, cron_expr, enabled FROM scheduled_tasks WHERE task_type = ? LIMIT 1", TaskTypeImportSearchQueries)
+	exists := err == nil && existing.ID > 0
+
+	if i.config.Schedule == "" || len(i.sources) == 0 {
+		if exists {
+			return scheduler.DeleteTask(ctx, existing.ID)
+		}
+		return nil
+	}
+
+	task := &ScheduledTask{
+		Name:     "搜索词导入",
+		TaskType: TaskTypeImportSearchQueries,
+		CronExpr: i.config.Schedule,
+		Params:   json.RawMessage("{}"),
+		Enabled:  true,
+	}
+	if exists {
+		// 保留后台手动设置的启用状态，只同步 Cron 表达式
+		if existing.CronExpr == i.config.Schedule {
+			return nil
+		}
+		task.ID = existing.ID
+		task.Enabled = existing.Enabled
+		return scheduler.UpdateTask(ctx, task)
+	}
+	_, err = scheduler.CreateTask(ctx, task)
+	return err
+}
+
+// normalizeSearchQuery 规范化搜索词：合并空白、转小写，过长或为空时返回空字符串
+func normalizeSearchQuery(q string) string {
+	q = strings.ToLower(strings.Join(strings.Fields(q), " "))
+	if q == "" || utf8.RuneCountInString(q) > 100 {
+		return ""
+	}
+	return q
+}
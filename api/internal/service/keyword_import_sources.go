@@ -0,0 +1,278 @@
+// Package core provides search query report clients for keyword discovery
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 搜索词来源
+const (
+	QuerySourceBaiduTongji = "baidu_tongji"
+	QuerySourceGSC         = "gsc"
+)
+
+const (
+	baiduTongjiEndpoint = "https://openapi.baidu.com/rest/2.0/tongji/report/getData"
+	gscEndpoint         = "https://searchconsole.googleapis.com/webmasters/v3/sites/%s/searchAnalytics/query"
+	gscScope            = "https://www.googleapis.com/auth/webmasters.readonly"
+	googleTokenURL      = "https://oauth2.googleapis.com/token"
+)
+
+// SearchQueryRow 搜索词报表中的一行
+type SearchQueryRow struct {
+	Query       string
+	Site        string
+	Impressions int
+	Clicks      int
+}
+
+// SearchQuerySource 搜索词报表来源
+type SearchQuerySource interface {
+	// Name 来源标识（baidu_tongji / gsc）
+	Name() string
+	// Fetch 拉取 [start, end] 日期范围内的搜索词
+	Fetch(ctx context.Context, start, end time.Time, maxRows int) ([]SearchQueryRow, error)
+}
+
+// baiduTongjiSource 百度统计搜索词报表（source/searchword/a）
+type baiduTongjiSource struct {
+	client      *http.Client
+	accessToken string
+	siteIDs     []string
+}
+
+func (s *baiduTongjiSource) Name() string { return QuerySourceBaiduTongji }
+
+func (s *baiduTongjiSource) Fetch(ctx context.Context, start, end time.Time, maxRows int) ([]SearchQueryRow, error) {
+	var rows []SearchQueryRow
+	for _, siteID := range s.siteIDs {
+		q := url.Values{}
+		q.Set("access_token", s.accessToken)
+		q.Set("site_id", siteID)
+		q.Set("method", "source/searchword/a")
+		q.Set("start_date", start.Format("20060102"))
+		q.Set("end_date", end.Format("20060102"))
+		q.Set("metrics", "pv_count,visitor_count")
+		q.Set("max_results", strconv.Itoa(maxRows))
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, baiduTongjiEndpoint+"?"+q.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			ErrorCode int    `json:"error_code"`
+			ErrorMsg  string `json:"error_msg"`
+			Result    struct {
+				// Items[0] 维度（每行 [{"name": 搜索词}]），Items[1] 指标（每行 [pv, uv]）
+				Items []json.RawMessage `json:"items"`
+			} `json:"result"`
+		}
+		if err := doJSON(s.client, req, &resp); err != nil {
+			return nil, fmt.Errorf("baidu tongji site %s: %w", siteID, err)
+		}
+		if resp.ErrorCode != 0 {
+			return nil, fmt.Errorf("baidu tongji site %s: %d %s", siteID, resp.ErrorCode, resp.ErrorMsg)
+		}
+		if len(resp.Result.Items) < 2 {
+			continue
+		}
+
+		var dims [][]struct {
+			Name string `json:"name"`
+		}
+		var metrics [][]json.RawMessage
+		if err := json.Unmarshal(resp.Result.Items[0], &dims); err != nil {
+			return nil, fmt.Errorf("baidu tongji site %s: parse dimensions: %w", siteID, err)
+		}
+		if err := json.Unmarshal(resp.Result.Items[1], &metrics); err != nil {
+			return nil, fmt.Errorf("baidu tongji site %s: parse metrics: %w", siteID, err)
+		}
+		for i, dim := range dims {
+			if len(dim) == 0 || i >= len(metrics) {
+				continue
+			}
+			row := SearchQueryRow{Query: dim[0].Name, Site: siteID}
+			if len(metrics[i]) > 0 {
+				row.Impressions = parseReportNumber(metrics[i][0])
+			}
+			if len(metrics[i]) > 1 {
+				row.Clicks = parseReportNumber(metrics[i][1])
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// parseReportNumber 百度统计指标可能是数字或字符串（无数据时为 "--"）
+func parseReportNumber(raw json.RawMessage) int {
+	var f float64
+	if err := json.Unmarshal(raw, &f); err == nil {
+		return int(f)
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		n, _ := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+		return int(n)
+	}
+	return 0
+}
+
+// gscSource Google Search Console 搜索分析（按 query 维度）
+// 使用服务账号 JSON 签发 JWT 换取访问令牌，令牌在过期前复用
+type gscSource struct {
+	client   *http.Client
+	credFile string
+	siteURLs []string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (s *gscSource) Name() string { return QuerySourceGSC }
+
+func (s *gscSource) Fetch(ctx context.Context, start, end time.Time, maxRows int) ([]SearchQueryRow, error) {
+	token, err := s.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []SearchQueryRow
+	for _, site := range s.siteURLs {
+		body, _ := json.Marshal(map[string]interface{}{
+			"startDate":  start.Format("2006-01-02"),
+			"endDate":    end.Format("2006-01-02"),
+			"dimensions": []string{"query"},
+			"rowLimit":   maxRows,
+		})
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+			fmt.Sprintf(gscEndpoint, url.PathEscape(site)), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+
+		var resp struct {
+			Rows []struct {
+				Keys        []string `json:"keys"`
+				Clicks      float64  `json:"clicks"`
+				Impressions float64  `json:"impressions"`
+			} `json:"rows"`
+		}
+		if err := doJSON(s.client, req, &resp); err != nil {
+			return nil, fmt.Errorf("gsc site %s: %w", site, err)
+		}
+		for _, r := range resp.Rows {
+			if len(r.Keys) == 0 {
+				continue
+			}
+			rows = append(rows, SearchQueryRow{
+				Query:       r.Keys[0],
+				Site:        site,
+				Impressions: int(r.Impressions),
+				Clicks:      int(r.Clicks),
+			})
+		}
+	}
+	return rows, nil
+}
+
+// accessToken 用服务账号换取访问令牌
+func (s *gscSource) accessToken(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Before(s.expires) {
+		return s.token, nil
+	}
+
+	data, err := os.ReadFile(s.credFile)
+	if err != nil {
+		return "", fmt.Errorf("read gsc credentials: %w", err)
+	}
+	var cred struct {
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(data, &cred); err != nil {
+		return "", fmt.Errorf("parse gsc credentials: %w", err)
+	}
+	if cred.TokenURI == "" {
+		cred.TokenURI = googleTokenURL
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(cred.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("parse gsc private key: %w", err)
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   cred.ClientEmail,
+		"scope": gscScope,
+		"aud":   cred.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("sign gsc assertion: %w", err)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cred.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := doJSON(s.client, req, &resp); err != nil {
+		return "", fmt.Errorf("gsc token: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return "", fmt.Errorf("gsc token: empty access token")
+	}
+	s.token = resp.AccessToken
+	s.expires = now.Add(time.Duration(resp.ExpiresIn)*time.Second - time.Minute)
+	return s.token, nil
+}
+
+// doJSON 发送请求并解析 JSON 响应，非 2xx 时返回响应体摘要
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet := string(body)
+		if len(snippet) > 300 {
+			snippet = snippet[:300]
+		}
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, snippet)
+	}
+	return json.Unmarshal(body, out)
+}
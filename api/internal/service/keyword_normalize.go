@@ -0,0 +1,343 @@
+// Package core provides bulk keyword normalization and deduplication
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/text/width"
+
+	"seo-generator/api/internal/repository"
+)
+
+// 规范化规则（按此顺序执行）
+const (
+	KeywordRuleFoldWidth  = "fold_width"  // 全角转半角（半角片假名转全角）
+	KeywordRuleStripPunct = "strip_punct" // 删除标点符号
+	KeywordRuleLowercase  = "lowercase"   // 转小写
+	KeywordRuleTrim       = "trim"        // 去除首尾空白并合并连续空白
+)
+
+// 规范化动作
+const (
+	KeywordActionUpdate = "update" // 改写为规范形式
+	KeywordActionDelete = "delete" // 与保留的关键词重复或规范化后为空，删除
+)
+
+const (
+	keywordNormalizeBatch   = 5000
+	keywordNormalizeSamples = 50
+)
+
+// ErrNoKeywordRules 未选择任何规范化规则
+var ErrNoKeywordRules = errors.New("no normalization rule selected")
+
+// KeywordNormalizeOptions 规范化选项
+type KeywordNormalizeOptions struct {
+	Trim       bool  `json:"trim"`
+	FoldWidth  bool  `json:"fold_width"`
+	Lowercase  bool  `json:"lowercase"`
+	StripPunct bool  `json:"strip_punct"`
+	CrossGroup bool  `json:"cross_group"` // 跨分组去重（保留 id 最小的一条），否则只在分组内去重
+	GroupIDs   []int `json:"group_ids"`   // 为空时处理全部分组
+	DryRun     bool  `json:"dry_run"`     // 只统计和预览，不修改数据
+}
+
+// KeywordNormalizeChange 预览中的一条变更
+type KeywordNormalizeChange struct {
+	ID          int64  `json:"id"`
+	GroupID     int    `json:"group_id"`
+	Before      string `json:"before"`
+	After       string `json:"after"`
+	Action      string `json:"action"`
+	DuplicateOf int64  `json:"duplicate_of,omitempty"` // 保留的关键词 ID
+}
+
+// KeywordNormalizeResult 规范化结果（dry_run 时为预计结果）
+type KeywordNormalizeResult struct {
+	DryRun         bool                     `json:"dry_run"`
+	Scanned        int64                    `json:"scanned"`
+	Updated        int64                    `json:"updated"`         // 改写的行数
+	Deleted        int64                    `json:"deleted"`         // 删除的行数（重复 + 空）
+	Duplicates     int64                    `json:"duplicates"`      // 规范化后重复的行数
+	Emptied        int64                    `json:"emptied"`         // 规范化后为空的行数
+	CollationDupes int64                    `json:"collation_dupes"` // 改写时与库内排序规则视为相同的行冲突而删除
+	RuleCounts     map[string]int64         `json:"rule_counts"`     // 每条规则改变的行数
+	Groups         []int                    `json:"groups"`          // 有变更的分组
+	Samples        []KeywordNormalizeChange `json:"samples"`
+}
+
+// Rules 已选择的规则（按执行顺序）
+func (o KeywordNormalizeOptions) Rules() []string {
+	var rules []string
+	if o.FoldWidth {
+		rules = append(rules, KeywordRuleFoldWidth)
+	}
+	if o.StripPunct {
+		rules = append(rules, KeywordRuleStripPunct)
+	}
+	if o.Lowercase {
+		rules = append(rules, KeywordRuleLowercase)
+	}
+	if o.Trim {
+		rules = append(rules, KeywordRuleTrim)
+	}
+	return rules
+}
+
+// NormalizeKeyword 按规则规范化关键词，changed 为每条规则是否改变了文本
+func NormalizeKeyword(s string, rules []string) (string, map[string]bool) {
+	changed := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		next := applyKeywordRule(rule, s)
+		if next != s {
+			changed[rule] = true
+			s = next
+		}
+	}
+	return s, changed
+}
+
+func applyKeywordRule(rule, s string) string {
+	switch rule {
+	case KeywordRuleFoldWidth:
+		return width.Fold.String(s)
+	case KeywordRuleStripPunct:
+		return strings.Map(func(r rune) rune {
+			if unicode.IsPunct(r) {
+				return -1
+			}
+			return r
+		}, s)
+	case KeywordRuleLowercase:
+		return strings.ToLower(s)
+	case KeywordRuleTrim:
+		return strings.Join(strings.FieldsFunc(s, unicode.IsSpace), " ")
+	}
+	return s
+}
+
+// keywordRow 待处理的关键词
+type keywordRow struct {
+	ID      int64  `db:"id"`
+	GroupID int    `db:"group_id"`
+	Keyword string `db:"keyword"`
+}
+
+// NormalizeKeywords 规范化并去重关键词
+//
+// 按 id 顺序扫描，规范化后重复的关键词保留 id 最小的一条；先删除重复行再改写，
+// 避免改写撞上尚未删除的重复行。去重集合只保存规范化文本的 128 位哈希。
+// jc 为 nil 时同步执行（不汇报进度）。
+func NormalizeKeywords(ctx context.Context, jc *JobContext, db *sqlx.DB, opts KeywordNormalizeOptions) (*KeywordNormalizeResult, error) {
+	rules := opts.Rules()
+	if len(rules) == 0 {
+		return nil, ErrNoKeywordRules
+	}
+
+	where := "1=1"
+	var args []interface{}
+	if len(opts.GroupIDs) > 0 {
+		query, inArgs, err := sqlx.In("group_id IN (?)", opts.GroupIDs)
+		if err != nil {
+			return nil, err
+		}
+		where, args = query, inArgs
+	}
+
+	if jc != nil {
+		var total int64
+		if err := db.GetContext(ctx, &total, "SELECT COUNT(*) FROM keywords WHERE "+where, args...); err == nil {
+			jc.SetTotal(total)
+		}
+	}
+
+	result := &KeywordNormalizeResult{
+		DryRun:     opts.DryRun,
+		RuleCounts: make(map[string]int64, len(rules)),
+		Groups:     []int{},
+		Samples:    []KeywordNormalizeChange{},
+	}
+	for _, rule := range rules {
+		result.RuleCounts[rule] = 0
+	}
+
+	kept := make(map[[16]byte]int64)
+	groups := make(map[int]bool)
+	var deletes []int64
+	updates := make(map[int64]string)
+	sample := func(ch KeywordNormalizeChange) {
+		if len(result.Samples) < keywordNormalizeSamples {
+			result.Samples = append(result.Samples, ch)
+		}
+	}
+
+	var lastID int64
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var rows []keywordRow
+		err := db.SelectContext(ctx, &rows, "SELECT id, group_id, keyword FROM keywords WHERE "+where+
+			" AND id > ? ORDER BY id LIMIT ?", append(args, lastID, keywordNormalizeBatch)...)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			result.Scanned++
+			normalized, changed := NormalizeKeyword(row.Keyword, rules)
+			for rule := range changed {
+				result.RuleCounts[rule]++
+			}
+
+			ch := KeywordNormalizeChange{ID: row.ID, GroupID: row.GroupID, Before: row.Keyword, After: normalized}
+			if normalized == "" {
+				result.Emptied++
+				ch.Action = KeywordActionDelete
+				deletes = append(deletes, row.ID)
+				groups[row.GroupID] = true
+				sample(ch)
+				continue
+			}
+
+			key := keywordDedupKey(normalized, row.GroupID, opts.CrossGroup)
+			if keepID, dup := kept[key]; dup {
+				result.Duplicates++
+				ch.Action = KeywordActionDelete
+				ch.DuplicateOf = keepID
+				deletes = append(deletes, row.ID)
+				groups[row.GroupID] = true
+				sample(ch)
+				continue
+			}
+			kept[key] = row.ID
+
+			if normalized != row.Keyword {
+				ch.Action = KeywordActionUpdate
+				updates[row.ID] = normalized
+				groups[row.GroupID] = true
+				sample(ch)
+			}
+		}
+		lastID = rows[len(rows)-1].ID
+		if jc != nil {
+			jc.Advance(int64(len(rows)))
+		}
+	}
+
+	for gid := range groups {
+		result.Groups = append(result.Groups, gid)
+	}
+	sort.Ints(result.Groups)
+
+	if opts.DryRun {
+		result.Deleted = int64(len(deletes))
+		result.Updated = int64(len(updates))
+		return result, nil
+	}
+
+	if jc != nil {
+		jc.SetTotal(result.Scanned + int64(len(deletes)+len(updates)))
+	}
+	if err := applyKeywordDeletes(ctx, jc, db, deletes, result); err != nil {
+		return result, err
+	}
+	if err := applyKeywordUpdates(ctx, jc, db, updates, result); err != nil {
+		return result, err
+	}
+
+	log.Info().Int64("scanned", result.Scanned).Int64("updated", result.Updated).
+		Int64("deleted", result.Deleted).Bool("cross_group", opts.CrossGroup).
+		Msg("Keywords normalized")
+	return result, nil
+}
+
+// keywordDedupKey 去重键：跨分组时只看文本，否则加上分组 ID
+func keywordDedupKey(normalized string, groupID int, crossGroup bool) [16]byte {
+	h := fnv.New128a()
+	if !crossGroup {
+		h.Write([]byte(strconv.Itoa(groupID)))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte(normalized))
+	var key [16]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+func applyKeywordDeletes(ctx context.Context, jc *JobContext, db *sqlx.DB, ids []int64, result *KeywordNormalizeResult) error {
+	for start := 0; start < len(ids); start += keywordNormalizeBatch {
+		end := start + keywordNormalizeBatch
+		if end > len(ids) {
+			end = len(ids)
+		}
+		query, args, err := sqlx.In("DELETE FROM keywords WHERE id IN (?)", ids[start:end])
+		if err != nil {
+			return err
+		}
+		res, err := db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("delete duplicate keywords: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		result.Deleted += n
+		if jc != nil {
+			jc.Advance(int64(end - start))
+		}
+	}
+	return nil
+}
+
+// applyKeywordUpdates 逐行改写；与库内排序规则视为相同的行冲突（1062）时删除该行
+func applyKeywordUpdates(ctx context.Context, jc *JobContext, db *sqlx.DB, updates map[int64]string, result *KeywordNormalizeResult) error {
+	ids := make([]int64, 0, len(updates))
+	for id := range updates {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for start := 0; start < len(ids); start += keywordNormalizeBatch {
+		end := start + keywordNormalizeBatch
+		if end > len(ids) {
+			end = len(ids)
+		}
+		tx, err := db.BeginTxx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids[start:end] {
+			res, err := tx.ExecContext(ctx, "UPDATE keywords SET keyword = ? WHERE id = ?", updates[id], id)
+			if repository.IsDuplicateKeyError(err) {
+				if _, err = tx.ExecContext(ctx, "DELETE FROM keywords WHERE id = ?", id); err == nil {
+					result.CollationDupes++
+					result.Deleted++
+				}
+			} else if err == nil {
+				n, _ := res.RowsAffected()
+				result.Updated += n
+			}
+			if err != nil {
+				tx.Rollback()
+				return fmt.Errorf("update keyword %d: %w", id, err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		if jc != nil {
+			jc.Advance(int64(end - start))
+		}
+	}
+	return nil
+}
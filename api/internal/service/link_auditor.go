@@ -0,0 +1,203 @@
+// Package core provides post-render auditing of page links (external link cap and rel enforcement)
+package core
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"seo-generator/api/pkg/config"
+)
+
+// linkAnchorPattern 完整的 <a ...>文字</a>（分组 1 为开始标签，分组 2 为链接文字）
+var linkAnchorPattern = regexp.MustCompile(`(?is)(<a\b[^>]*>)(.*?)</a\s*>`)
+
+// LinkAuditStats 单个域名的链接统计
+type LinkAuditStats struct {
+	Domain       string `json:"domain"`
+	Pages        int64  `json:"pages"`
+	Links        int64  `json:"links"`          // 全部 <a> 链接
+	External     int64  `json:"external"`       // 站外链接（不含 allow_domains）
+	Rewritten    int64  `json:"rewritten"`      // 补了 rel 的站外链接
+	Dropped      int64  `json:"dropped"`        // 超出上限被去掉的站外链接
+	OverCapPages int64  `json:"over_cap_pages"` // 站外链接超过上限的页面
+	MaxExternal  int64  `json:"max_external"`   // 单页最多的站外链接数
+}
+
+type linkAuditCounters struct {
+	pages, links, external, rewritten, dropped, overCap, maxExternal atomic.Int64
+}
+
+// LinkAuditor 渲染后链接审计
+// 统计页面中的链接，给站外链接补上配置的 rel（默认 nofollow），每页站外链接超过 max_external 时
+// 按 drop_excess 去掉多出的链接（保留链接文字）或只统计；统计按域名累计，重启后清零
+type LinkAuditor struct {
+	config config.LinkAuditConfig
+	rel    []string
+	allow  []string
+
+	domains sync.Map // domain -> *linkAuditCounters
+}
+
+// NewLinkAuditor 创建链接审计，未启用时返回 nil（Apply 对 nil 不做处理）
+func NewLinkAuditor(cfg config.LinkAuditConfig) *LinkAuditor {
+	if !cfg.Enabled {
+		return nil
+	}
+	a := &LinkAuditor{config: cfg, rel: strings.Fields(strings.ToLower(cfg.Rel))}
+	for _, d := range cfg.AllowDomains {
+		d = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "www.")
+		if d != "" {
+			a.allow = append(a.allow, d)
+		}
+	}
+	return a
+}
+
+// Apply 审计页面链接并按配置改写，domain 为当前站点域名
+func (a *LinkAuditor) Apply(page, domain string) string {
+	if a == nil {
+		return page
+	}
+	self := strings.TrimPrefix(strings.ToLower(domain), "www.")
+	var links, external, rewritten, dropped int64
+	page = linkAnchorPattern.ReplaceAllStringFunc(page, func(anchor string) string {
+		links++
+		m := linkAnchorPattern.FindStringSubmatch(anchor)
+		tag := m[1]
+		href := robotsHrefPattern.FindStringSubmatch(tag)
+		if href == nil || !a.external(html.UnescapeString(href[1]+href[2]+href[3]), self) {
+			return anchor
+		}
+		external++
+		if a.config.MaxExternal > 0 && external > int64(a.config.MaxExternal) && a.config.DropExcess {
+			dropped++
+			return m[2]
+		}
+		if newTag, changed := addRelTokens(tag, a.rel); changed {
+			rewritten++
+			return newTag + anchor[len(tag):]
+		}
+		return anchor
+	})
+
+	c := a.counters(domain)
+	c.pages.Add(1)
+	c.links.Add(links)
+	c.external.Add(external)
+	c.rewritten.Add(rewritten)
+	c.dropped.Add(dropped)
+	if a.config.MaxExternal > 0 && external > int64(a.config.MaxExternal) {
+		c.overCap.Add(1)
+	}
+	for {
+		max := c.maxExternal.Load()
+		if external <= max || c.maxExternal.CompareAndSwap(max, external) {
+			break
+		}
+	}
+	return page
+}
+
+// external 链接是否指向站外（allow_domains 及其子域名视为站内）
+func (a *LinkAuditor) external(href, self string) bool {
+	if !isExternalHref(href, self) {
+		return false
+	}
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	for _, d := range a.allow {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *LinkAuditor) counters(domain string) *linkAuditCounters {
+	if v, ok := a.domains.Load(domain); ok {
+		return v.(*linkAuditCounters)
+	}
+	v, _ := a.domains.LoadOrStore(domain, &linkAuditCounters{})
+	return v.(*linkAuditCounters)
+}
+
+// DomainStats 按域名的链接统计（按站外链接数降序），domain 不为空时只返回该域名
+func (a *LinkAuditor) DomainStats(domain string) []LinkAuditStats {
+	stats := []LinkAuditStats{}
+	a.domains.Range(func(key, value interface{}) bool {
+		if domain != "" && key.(string) != domain {
+			return true
+		}
+		c := value.(*linkAuditCounters)
+		stats = append(stats, LinkAuditStats{
+			Domain:       key.(string),
+			Pages:        c.pages.Load(),
+			Links:        c.links.Load(),
+			External:     c.external.Load(),
+			Rewritten:    c.rewritten.Load(),
+			Dropped:      c.dropped.Load(),
+			OverCapPages: c.overCap.Load(),
+			MaxExternal:  c.maxExternal.Load(),
+		})
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].External != stats[j].External {
+			return stats[i].External > stats[j].External
+		}
+		return stats[i].Domain < stats[j].Domain
+	})
+	return stats
+}
+
+// Stats 汇总统计和当前配置
+func (a *LinkAuditor) Stats() map[string]interface{} {
+	var total LinkAuditStats
+	domains := 0
+	for _, s := range a.DomainStats("") {
+		domains++
+		total.Pages += s.Pages
+		total.Links += s.Links
+		total.External += s.External
+		total.Rewritten += s.Rewritten
+		total.Dropped += s.Dropped
+		total.OverCapPages += s.OverCapPages
+		if s.MaxExternal > total.MaxExternal {
+			total.MaxExternal = s.MaxExternal
+		}
+	}
+	return map[string]interface{}{
+		"domains":       domains,
+		"pages":         total.Pages,
+		"links":         total.Links,
+		"external":      total.External,
+		"rewritten":     total.Rewritten,
+		"dropped":       total.Dropped,
+		"over_cap":      total.OverCapPages,
+		"max_external":  total.MaxExternal,
+		"rel":           a.config.Rel,
+		"cap":           a.config.MaxExternal,
+		"drop_excess":   a.config.DropExcess,
+		"allow_domains": a.config.AllowDomains,
+	}
+}
+
+// Reset 清空统计，domain 不为空时只清空该域名
+func (a *LinkAuditor) Reset(domain string) {
+	if domain != "" {
+		a.domains.Delete(domain)
+		return
+	}
+	a.domains.Range(func(key, _ interface{}) bool {
+		a.domains.Delete(key)
+		return true
+	})
+}
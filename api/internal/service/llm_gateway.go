@@ -0,0 +1,260 @@
+// Package core provides the OpenAI-compatible LLM gateway (budget, concurrency cap, cost tracking)
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// ErrLLMBudgetExceeded 当日请求数或 token 预算已用完
+var ErrLLMBudgetExceeded = errors.New("llm daily budget exceeded")
+
+// LLMUsage 单次调用的 token 用量和成本
+type LLMUsage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// LLMDailyUsage llm_usage 表中一天一个分组一种用途的汇总
+type LLMDailyUsage struct {
+	Date             string  `json:"date" db:"date"`
+	GroupID          int     `json:"group_id" db:"group_id"`
+	Purpose          string  `json:"purpose" db:"purpose"`
+	Requests         int64   `json:"requests" db:"requests"`
+	Failures         int64   `json:"failures" db:"failures"`
+	PromptTokens     int64   `json:"prompt_tokens" db:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens" db:"completion_tokens"`
+	Cost             float64 `json:"cost" db:"cost"`
+}
+
+// LLMGateway 调用 OpenAI 兼容的 chat/completions 接口
+//
+// 并发请求数受 max_concurrency 限制；每日请求数和 token 数超过预算后直接返回 ErrLLMBudgetExceeded，
+// 失败的请求也计入请求数。用量按 日期 + 分组 + 用途 累加到 llm_usage 表，重启后从表中恢复当日用量。
+type LLMGateway struct {
+	db     *sqlx.DB
+	cfg    config.LLMConfig
+	client *http.Client
+	sem    chan struct{}
+
+	mu       sync.Mutex
+	day      string
+	requests int64
+	tokens   int64
+	cost     float64
+}
+
+// NewLLMGateway 创建网关，未启用时返回 nil
+func NewLLMGateway(db *sqlx.DB, cfg config.LLMConfig) (*LLMGateway, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("llm: invalid endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("llm: model is required")
+	}
+	if cfg.MaxConcurrency <= 0 {
+		cfg.MaxConcurrency = 1
+	}
+	if cfg.TimeoutSeconds <= 0 {
+		cfg.TimeoutSeconds = 60
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	return &LLMGateway{
+		db:     db,
+		cfg:    cfg,
+		client: GetHTTPClient().Client(EgressPurposeLLM, time.Duration(cfg.TimeoutSeconds)*time.Second),
+		sem:    make(chan struct{}, cfg.MaxConcurrency),
+		day:    time.Now().Format("2006-01-02"),
+	}, nil
+}
+
+// Config 网关配置
+func (g *LLMGateway) Config() config.LLMConfig {
+	return g.cfg
+}
+
+// LoadUsage 从 llm_usage 恢复当日用量（预算按天计算，重启后不清零）
+func (g *LLMGateway) LoadUsage(ctx context.Context) error {
+	var today struct {
+		Requests int64   `db:"requests"`
+		Tokens   int64   `db:"tokens"`
+		Cost     float64 `db:"cost"`
+	}
+	day := time.Now().Format("2006-01-02")
+	if err := g.db.GetContext(ctx, &today,
+		`SELECT COALESCE(SUM(requests), 0) AS requests, COALESCE(SUM(prompt_tokens + completion_tokens), 0) AS tokens,
+		 COALESCE(SUM(cost), 0) AS cost FROM llm_usage WHERE date = ?`, day); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	g.day, g.requests, g.tokens, g.cost = day, today.Requests, today.Tokens, today.Cost
+	g.mu.Unlock()
+	return nil
+}
+
+// reserve 检查预算并占用一次请求额度
+func (g *LLMGateway) reserve() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if day := time.Now().Format("2006-01-02"); day != g.day {
+		g.day, g.requests, g.tokens, g.cost = day, 0, 0, 0
+	}
+	if g.cfg.DailyRequestBudget > 0 && g.requests >= int64(g.cfg.DailyRequestBudget) {
+		return ErrLLMBudgetExceeded
+	}
+	if g.cfg.DailyTokenBudget > 0 && g.tokens >= int64(g.cfg.DailyTokenBudget) {
+		return ErrLLMBudgetExceeded
+	}
+	g.requests++
+	return nil
+}
+
+// Complete 以 system 为系统提示词、user 为用户消息调用一次模型，返回模型输出
+// groupID 和 purpose 只用于用量统计
+func (g *LLMGateway) Complete(ctx context.Context, groupID int, purpose, system, user string) (string, LLMUsage, error) {
+	if err := g.reserve(); err != nil {
+		return "", LLMUsage{}, err
+	}
+	select {
+	case g.sem <- struct{}{}:
+	case <-ctx.Done():
+		return "", LLMUsage{}, ctx.Err()
+	}
+	defer func() { <-g.sem }()
+
+	text, usage, err := g.call(ctx, system, user)
+	g.record(groupID, purpose, usage, err != nil)
+	return text, usage, err
+}
+
+// chatCompletionResponse chat/completions 响应中用到的字段
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (g *LLMGateway) call(ctx context.Context, system, user string) (string, LLMUsage, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"model":       g.cfg.Model,
+		"temperature": g.cfg.Temperature,
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": user},
+		},
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.cfg.Endpoint+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", LLMUsage{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.cfg.APIKey)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", LLMUsage{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return "", LLMUsage{}, err
+	}
+
+	var out chatCompletionResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return "", LLMUsage{}, fmt.Errorf("llm: HTTP %d, invalid response: %w", resp.StatusCode, err)
+	}
+	usage := LLMUsage{PromptTokens: out.Usage.PromptTokens, CompletionTokens: out.Usage.CompletionTokens}
+	usage.Cost = float64(usage.PromptTokens)/1000*g.cfg.PromptPricePer1K +
+		float64(usage.CompletionTokens)/1000*g.cfg.CompletionPricePer1K
+	if resp.StatusCode != http.StatusOK {
+		if out.Error != nil && out.Error.Message != "" {
+			return "", usage, fmt.Errorf("llm: HTTP %d: %s", resp.StatusCode, out.Error.Message)
+		}
+		return "", usage, fmt.Errorf("llm: HTTP %d", resp.StatusCode)
+	}
+	if len(out.Choices) == 0 || strings.TrimSpace(out.Choices[0].Message.Content) == "" {
+		return "", usage, fmt.Errorf("llm: empty completion")
+	}
+	return strings.TrimSpace(out.Choices[0].Message.Content), usage, nil
+}
+
+// record 累加当日用量并写入 llm_usage
+func (g *LLMGateway) record(groupID int, purpose string, usage LLMUsage, failed bool) {
+	g.mu.Lock()
+	g.tokens += int64(usage.PromptTokens + usage.CompletionTokens)
+	g.cost += usage.Cost
+	day := g.day
+	g.mu.Unlock()
+
+	failures := 0
+	if failed {
+		failures = 1
+	}
+	if _, err := g.db.Exec(
+		`INSERT INTO llm_usage (date, group_id, purpose, requests, failures, prompt_tokens, completion_tokens, cost)
+		 VALUES (?, ?, ?, 1, ?, ?, ?, ?)
+		 ON DUPLICATE KEY UPDATE requests = requests + 1, failures = failures + VALUES(failures),
+		 prompt_tokens = prompt_tokens + VALUES(prompt_tokens), completion_tokens = completion_tokens + VALUES(completion_tokens),
+		 cost = cost + VALUES(cost)`,
+		day, groupID, purpose, failures, usage.PromptTokens, usage.CompletionTokens, usage.Cost); err != nil {
+		log.Warn().Err(err).Msg("Failed to record LLM usage")
+	}
+}
+
+// Stats 当日用量、预算和当前并发
+func (g *LLMGateway) Stats() map[string]interface{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return map[string]interface{}{
+		"date":                 g.day,
+		"requests":             g.requests,
+		"tokens":               g.tokens,
+		"cost":                 g.cost,
+		"daily_request_budget": g.cfg.DailyRequestBudget,
+		"daily_token_budget":   g.cfg.DailyTokenBudget,
+		"in_flight":            len(g.sem),
+		"max_concurrency":      cap(g.sem),
+		"model":                g.cfg.Model,
+	}
+}
+
+// Usage 最近 days 天的用量明细（按日期倒序）
+func (g *LLMGateway) Usage(ctx context.Context, days int) ([]LLMDailyUsage, error) {
+	rows := []LLMDailyUsage{}
+	err := g.db.SelectContext(ctx, &rows,
+		`SELECT DATE_FORMAT(date, '%Y-%m-%d') AS date, group_id, purpose, requests, failures, prompt_tokens, completion_tokens, cost
+		 FROM llm_usage WHERE date >= ? ORDER BY date DESC, group_id, purpose`,
+		time.Now().AddDate(0, 0, -days+1).Format("2006-01-02"))
+	return rows, err
+}
@@ -0,0 +1,227 @@
+// Package core provides LLM rewriting of article titles and contents before they enter the pools
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// LLM 用量统计中的用途
+const (
+	LLMPurposeRewriteTitle   = "rewrite_title"
+	LLMPurposeRewriteContent = "rewrite_content"
+)
+
+// RewritePolicy 文章分组的 LLM 改写设置（未配置的分组不改写）
+type RewritePolicy struct {
+	GroupID        int  `json:"group_id" db:"group_id"`
+	Enabled        bool `json:"enabled" db:"enabled"`
+	RewriteTitle   bool `json:"rewrite_title" db:"rewrite_title"`
+	RewriteContent bool `json:"rewrite_content" db:"rewrite_content"`
+}
+
+// RewriteResult 一批文章的改写结果
+type RewriteResult struct {
+	Total          int      `json:"total"`
+	Rewritten      int      `json:"rewritten"`
+	Skipped        int      `json:"skipped"` // 分组未启用、文章不存在或预算已用完
+	Failed         int      `json:"failed"`
+	BudgetExceeded bool     `json:"budget_exceeded"`
+	Usage          LLMUsage `json:"usage"`
+	Errors         []string `json:"errors,omitempty"` // 前 10 条错误
+}
+
+// ArticleRewriter 文章入库后、交给加工队列（拆分进 titles / contents 池）之前，用 LLM 改写标题和正文
+//
+// 后台接口添加的文章由 ArticlesHandler 提交改写作业；爬虫直接写库的文章不经过这里，
+// 可通过手动重跑接口按分组改写（rewritten_at 为空的文章）。
+type ArticleRewriter struct {
+	db       *sqlx.DB
+	gateway  *LLMGateway
+	jobs     *JobManager
+	policies atomic.Pointer[map[int]RewritePolicy]
+}
+
+// NewArticleRewriter 创建改写器，gateway 为 nil（未启用 LLM）时返回 nil
+func NewArticleRewriter(db *sqlx.DB, gateway *LLMGateway, jobs *JobManager) *ArticleRewriter {
+	if gateway == nil {
+		return nil
+	}
+	r := &ArticleRewriter{db: db, gateway: gateway, jobs: jobs}
+	empty := map[int]RewritePolicy{}
+	r.policies.Store(&empty)
+	return r
+}
+
+// Reload 从数据库重新加载分组设置
+func (r *ArticleRewriter) Reload(ctx context.Context) error {
+	var rows []RewritePolicy
+	if err := r.db.SelectContext(ctx, &rows,
+		"SELECT group_id, enabled, rewrite_title, rewrite_content FROM llm_rewrite_groups"); err != nil {
+		return fmt.Errorf("load llm rewrite groups: %w", err)
+	}
+	policies := make(map[int]RewritePolicy, len(rows))
+	for _, p := range rows {
+		policies[p.GroupID] = p
+	}
+	r.policies.Store(&policies)
+	log.Info().Int("groups", len(policies)).Msg("LLM rewrite groups loaded")
+	return nil
+}
+
+// PolicyFor 获取分组的改写设置
+func (r *ArticleRewriter) PolicyFor(groupID int) RewritePolicy {
+	if r != nil {
+		if p, ok := (*r.policies.Load())[groupID]; ok {
+			return p
+		}
+	}
+	return RewritePolicy{GroupID: groupID, RewriteTitle: true, RewriteContent: true}
+}
+
+// Enabled 分组是否启用改写，r 为 nil 时返回 false
+func (r *ArticleRewriter) Enabled(groupID int) bool {
+	p := r.PolicyFor(groupID)
+	return p.Enabled && (p.RewriteTitle || p.RewriteContent)
+}
+
+// SetPolicy 保存分组设置并重新加载
+func (r *ArticleRewriter) SetPolicy(ctx context.Context, p RewritePolicy) error {
+	if _, err := r.db.ExecContext(ctx,
+		`INSERT INTO llm_rewrite_groups (group_id, enabled, rewrite_title, rewrite_content) VALUES (?, ?, ?, ?)
+		 ON DUPLICATE KEY UPDATE enabled = VALUES(enabled), rewrite_title = VALUES(rewrite_title), rewrite_content = VALUES(rewrite_content)`,
+		p.GroupID, p.Enabled, p.RewriteTitle, p.RewriteContent); err != nil {
+		return err
+	}
+	return r.Reload(ctx)
+}
+
+// Submit 提交改写作业；then 在作业结束后以改写完成的文章 ID 调用（用于推入加工队列），可为 nil
+// requeueAll 为 true 时未改写的文章（分组未启用、失败、预算用完）也交给 then，保证新文章不会滞留
+func (r *ArticleRewriter) Submit(ctx context.Context, ids []int64, requeueAll bool, then func(ids []int64)) (int64, error) {
+	if r.jobs == nil {
+		return 0, fmt.Errorf("job manager not available")
+	}
+	return r.jobs.SubmitFunc(ctx, "llm_rewrite", map[string]interface{}{"count": len(ids)}, func(jc *JobContext) (any, error) {
+		jc.SetTotal(int64(len(ids)))
+		result, done := r.Run(jc, ids, jc.Advance)
+		if then != nil {
+			if requeueAll {
+				then(ids)
+			} else if len(done) > 0 {
+				then(done)
+			}
+		}
+		return result, nil
+	})
+}
+
+// Run 逐篇改写，返回结果和改写成功的文章 ID；预算用完后不再调用模型
+func (r *ArticleRewriter) Run(ctx context.Context, ids []int64, progress func(n int64)) (*RewriteResult, []int64) {
+	if progress == nil {
+		progress = func(int64) {}
+	}
+	result := &RewriteResult{Total: len(ids)}
+	var done []int64
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		if result.BudgetExceeded {
+			result.Skipped++
+			progress(1)
+			continue
+		}
+		ok, err := r.RewriteArticle(ctx, id, &result.Usage)
+		switch {
+		case errors.Is(err, ErrLLMBudgetExceeded):
+			result.BudgetExceeded = true
+			result.Skipped++
+		case err != nil:
+			result.Failed++
+			if len(result.Errors) < 10 {
+				result.Errors = append(result.Errors, fmt.Sprintf("article %d: %v", id, err))
+			}
+		case ok:
+			result.Rewritten++
+			done = append(done, id)
+		default:
+			result.Skipped++
+		}
+		progress(1)
+	}
+	if result.Rewritten > 0 || result.Failed > 0 {
+		log.Info().Int("rewritten", result.Rewritten).Int("failed", result.Failed).
+			Bool("budget_exceeded", result.BudgetExceeded).Float64("cost", result.Usage.Cost).Msg("Articles rewritten by LLM")
+	}
+	return result, done
+}
+
+// RewriteArticle 按分组设置改写一篇文章，分组未启用或文章不存在时返回 false
+// 改写后的标题与已有文章重复时保留原标题
+func (r *ArticleRewriter) RewriteArticle(ctx context.Context, id int64, usage *LLMUsage) (bool, error) {
+	var article struct {
+		GroupID int    `db:"group_id"`
+		Title   string `db:"title"`
+		Content string `db:"content"`
+	}
+	if err := r.db.GetContext(ctx, &article,
+		"SELECT group_id, title, content FROM original_articles WHERE id = ?", id); err != nil {
+		return false, nil
+	}
+	policy := r.PolicyFor(article.GroupID)
+	if !policy.Enabled {
+		return false, nil
+	}
+
+	cfg := r.gateway.Config()
+	content := article.Content
+	if policy.RewriteContent && content != "" && (cfg.MaxContentLength <= 0 || utf8.RuneCountInString(content) <= cfg.MaxContentLength) {
+		text, u, err := r.gateway.Complete(ctx, article.GroupID, LLMPurposeRewriteContent, cfg.ContentPrompt, content)
+		addLLMUsage(usage, u)
+		if err != nil {
+			return false, err
+		}
+		content = text
+	}
+	title := article.Title
+	if policy.RewriteTitle && title != "" {
+		text, u, err := r.gateway.Complete(ctx, article.GroupID, LLMPurposeRewriteTitle, cfg.TitlePrompt, title)
+		addLLMUsage(usage, u)
+		// 标题改写失败时保留原标题，仍保存已改写的正文
+		if err == nil {
+			title = strings.Trim(strings.SplitN(text, "\n", 2)[0], " \"'“”《》")
+		} else if content == article.Content {
+			return false, err
+		}
+	}
+
+	// 摘要置空，由摘要回填任务按改写后的正文重新生成
+	if _, err := r.db.ExecContext(ctx,
+		"UPDATE original_articles SET content = ?, summary = NULL, rewritten_at = NOW() WHERE id = ?", content, id); err != nil {
+		return false, err
+	}
+	if title != "" && title != article.Title {
+		if _, err := r.db.ExecContext(ctx,
+			"UPDATE IGNORE original_articles SET title = ? WHERE id = ?", title, id); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func addLLMUsage(total *LLMUsage, u LLMUsage) {
+	if total == nil {
+		return
+	}
+	total.PromptTokens += u.PromptTokens
+	total.CompletionTokens += u.CompletionTokens
+	total.Cost += u.Cost
+}
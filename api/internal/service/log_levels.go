@@ -0,0 +1,302 @@
+// Package core provides runtime log level and per-module level overrides
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// system_settings 中的日志级别配置键
+const (
+	logLevelKey        = "log_level"
+	logModuleLevelsKey = "log_module_levels"
+)
+
+const logLevelsRefreshInterval = 30 * time.Second // 多实例部署时从数据库同步设置的间隔
+
+// LogLevelSettings 日志级别设置快照
+// Modules 的键为模块名：源文件名（如 pool_manager）或包目录名（如 handler）
+type LogLevelSettings struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules"`
+}
+
+// logLevelState 当前生效的级别，由 moduleLevelHook 读取
+type logLevelState struct {
+	global  zerolog.Level
+	modules map[string]zerolog.Level
+}
+
+var currentLogLevels atomic.Pointer[logLevelState]
+
+// applyLogLevels 设置全局级别和模块级别
+// zerolog 全局级别取所有级别中的最低值，保证模块覆盖能放宽级别；
+// 其余事件由 moduleLevelHook 按模块或全局级别丢弃
+func applyLogLevels(global zerolog.Level, modules map[string]zerolog.Level) {
+	min := global
+	for _, lvl := range modules {
+		if lvl < min {
+			min = lvl
+		}
+	}
+	currentLogLevels.Store(&logLevelState{global: global, modules: modules})
+	zerolog.SetGlobalLevel(min)
+}
+
+// moduleLevelHook 按调用方所在模块过滤日志事件
+type moduleLevelHook struct{}
+
+// Run implements zerolog.Hook
+func (moduleLevelHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	st := currentLogLevels.Load()
+	if st == nil || len(st.modules) == 0 || level == zerolog.NoLevel {
+		return
+	}
+	min := st.global
+	file, dir := callerModule()
+	if lvl, ok := st.modules[file]; ok {
+		min = lvl
+	} else if lvl, ok := st.modules[dir]; ok {
+		min = lvl
+	}
+	if level < min {
+		e.Discard()
+	}
+}
+
+// callerModule 返回输出日志的源文件名（不含 .go）和包目录名
+// 仅在配置了模块级别时调用，跳过 zerolog 自身的栈帧
+func callerModule() (file, dir string) {
+	var pcs [16]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.Contains(frame.Function, "github.com/rs/zerolog") {
+			return strings.TrimSuffix(filepath.Base(frame.File), ".go"), filepath.Base(filepath.Dir(frame.File))
+		}
+		if !more {
+			return "", ""
+		}
+	}
+}
+
+// LogLevels 运行时日志级别管理
+// 设置保存在 system_settings 中，启动时恢复并定期刷新以同步多实例；
+// 未保存过设置时使用日志配置中的级别
+type LogLevels struct {
+	db   *sqlx.DB
+	base string
+
+	mu       sync.RWMutex
+	settings LogLevelSettings
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewLogLevels 创建日志级别管理器，base 为配置文件中的日志级别
+func NewLogLevels(db *sqlx.DB, base string) *LogLevels {
+	if _, err := zerolog.ParseLevel(base); err != nil || base == "" {
+		base = zerolog.InfoLevel.String()
+	}
+	return &LogLevels{
+		db:       db,
+		base:     base,
+		settings: LogLevelSettings{Level: base, Modules: map[string]string{}},
+	}
+}
+
+// Start 从数据库恢复设置并定期刷新
+func (l *LogLevels) Start(ctx context.Context) {
+	if l.db == nil {
+		return
+	}
+	l.ctx, l.cancel = context.WithCancel(ctx)
+	l.reload(l.ctx)
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		ticker := time.NewTicker(logLevelsRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.ctx.Done():
+				return
+			case <-ticker.C:
+				l.reload(l.ctx)
+			}
+		}
+	}()
+}
+
+// Stop 停止刷新
+func (l *LogLevels) Stop() {
+	if l.cancel != nil {
+		l.cancel()
+	}
+	l.wg.Wait()
+}
+
+// reload 读取 system_settings，设置未变化时不重复应用
+func (l *LogLevels) reload(ctx context.Context) {
+	var rows []struct {
+		Key   string `db:"setting_key"`
+		Value string `db:"setting_value"`
+	}
+	if err := l.db.SelectContext(ctx, &rows,
+		"SELECT setting_key, COALESCE(setting_value, '') AS setting_value FROM system_settings WHERE setting_key IN (?, ?)",
+		logLevelKey, logModuleLevelsKey); err != nil {
+		log.Warn().Err(err).Msg("Failed to load log level settings")
+		return
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	next := l.Settings()
+	for _, r := range rows {
+		switch r.Key {
+		case logLevelKey:
+			if r.Value != "" {
+				next.Level = r.Value
+			}
+		case logModuleLevelsKey:
+			modules := map[string]string{}
+			if r.Value != "" {
+				if err := json.Unmarshal([]byte(r.Value), &modules); err != nil {
+					log.Warn().Err(err).Msg("Invalid log module levels in system_settings, keeping previous levels")
+					continue
+				}
+			}
+			next.Modules = modules
+		}
+	}
+
+	global, modules, err := parseLogLevels(&next)
+	if err != nil {
+		log.Warn().Err(err).Msg("Invalid log level settings in system_settings, keeping previous levels")
+		return
+	}
+	if l.apply(next, global, modules) {
+		log.Info().Str("level", next.Level).Interface("modules", next.Modules).Msg("Log levels restored")
+	}
+}
+
+// Settings 返回当前设置
+func (l *LogLevels) Settings() LogLevelSettings {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	modules := make(map[string]string, len(l.settings.Modules))
+	for k, v := range l.settings.Modules {
+		modules[k] = v
+	}
+	return LogLevelSettings{Level: l.settings.Level, Modules: modules}
+}
+
+// Update 保存设置到 system_settings 并立即生效，level 为空表示恢复配置文件中的级别
+func (l *LogLevels) Update(ctx context.Context, settings LogLevelSettings) (LogLevelSettings, error) {
+	if settings.Level == "" {
+		settings.Level = l.base
+	}
+	if settings.Modules == nil {
+		settings.Modules = map[string]string{}
+	}
+	global, modules, err := parseLogLevels(&settings)
+	if err != nil {
+		return LogLevelSettings{}, err
+	}
+
+	if l.db != nil {
+		modulesJSON, _ := json.Marshal(settings.Modules)
+		for _, kv := range [][3]string{
+			{logLevelKey, settings.Level, "string"},
+			{logModuleLevelsKey, string(modulesJSON), "json"},
+		} {
+			if _, err := l.db.ExecContext(ctx, `
+				INSERT INTO system_settings (setting_key, setting_value, setting_type, description)
+				VALUES (?, ?, ?, '运行时日志级别')
+				ON DUPLICATE KEY UPDATE setting_value = VALUES(setting_value)`, kv[0], kv[1], kv[2]); err != nil {
+				return LogLevelSettings{}, err
+			}
+		}
+	}
+
+	l.apply(settings, global, modules)
+	LoggerFrom(ctx).Info().Str("level", settings.Level).Interface("modules", settings.Modules).Msg("Log levels updated")
+	return l.Settings(), nil
+}
+
+// apply 更新快照并应用级别，返回设置是否有变化
+func (l *LogLevels) apply(settings LogLevelSettings, global zerolog.Level, modules map[string]zerolog.Level) bool {
+	l.mu.Lock()
+	changed := l.settings.Level != settings.Level || len(l.settings.Modules) != len(settings.Modules)
+	for k, v := range settings.Modules {
+		if l.settings.Modules[k] != v {
+			changed = true
+		}
+	}
+	l.settings = settings
+	l.mu.Unlock()
+
+	applyLogLevels(global, modules)
+	return changed
+}
+
+// parseLogLevels 校验并解析级别名称（同时规范化 s 中的名称），模块名只允许小写字母、数字和下划线
+func parseLogLevels(s *LogLevelSettings) (zerolog.Level, map[string]zerolog.Level, error) {
+	global, err := parseLogLevel(s.Level)
+	if err != nil {
+		return global, nil, err
+	}
+	modules := make(map[string]zerolog.Level, len(s.Modules))
+	normalized := make(map[string]string, len(s.Modules))
+	for name, level := range s.Modules {
+		if !validModuleName(name) {
+			return global, nil, fmt.Errorf("invalid module name %q", name)
+		}
+		lvl, err := parseLogLevel(level)
+		if err != nil {
+			return global, nil, fmt.Errorf("module %s: %w", name, err)
+		}
+		modules[name] = lvl
+		normalized[name] = lvl.String()
+	}
+	s.Level = global.String()
+	s.Modules = normalized
+	return global, modules, nil
+}
+
+func parseLogLevel(s string) (zerolog.Level, error) {
+	lvl, err := zerolog.ParseLevel(strings.ToLower(strings.TrimSpace(s)))
+	if err != nil || s == "" || lvl == zerolog.NoLevel {
+		return zerolog.InfoLevel, fmt.Errorf("invalid log level %q", s)
+	}
+	return lvl, nil
+}
+
+func validModuleName(name string) bool {
+	if name == "" || len(name) > 64 {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		if !(ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' || ch == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,7 @@
 package core
 
 import (
+	"context"
 	"crypto/rand"
 	"fmt"
 	"io"
@@ -60,7 +61,6 @@ func SetupLogger(cfg *LogConfig) error {
 	if err != nil {
 		level = zerolog.InfoLevel
 	}
-	zerolog.SetGlobalLevel(level)
 
 	// Configure time format
 	zerolog.TimeFieldFormat = time.RFC3339
@@ -91,8 +91,9 @@ func SetupLogger(cfg *LogConfig) error {
 	// Create multi writer
 	multiWriter := io.MultiWriter(writers...)
 
-	// Set global logger
-	log.Logger = zerolog.New(multiWriter).With().Timestamp().Caller().Logger()
+	// Set global logger（模块级别由 moduleLevelHook 过滤，见 LogLevels）
+	log.Logger = zerolog.New(multiWriter).With().Timestamp().Caller().Logger().Hook(moduleLevelHook{})
+	applyLogLevels(level, nil)
 
 	log.Info().
 		Str("level", cfg.Level).
@@ -131,14 +132,32 @@ func buildFileWriter(cfg *LogConfig) (io.Writer, error) {
 	}, nil
 }
 
+// RequestIDHeader 请求 ID 头，Nginx/上游传入时沿用，否则生成，并在响应中返回
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen 外部传入的请求 ID 最大长度
+const maxRequestIDLen = 64
+
+type requestIDKey struct{}
+
 // RequestLogger returns a gin middleware for request logging
+// 请求 ID 同时写入 gin.Context、响应头和 c.Request 的 context（附带 request_id 字段的 logger），
+// 服务层通过 LoggerFrom(ctx) 输出的日志自动带上 request_id
 func RequestLogger() gin.HandlerFunc {
 	metrics := GetMetrics() // 获取全局指标实例
 
 	return func(c *gin.Context) {
-		// Generate request ID
-		requestID := generateRequestID()
+		// Use incoming request ID or generate one
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = generateRequestID()
+		}
 		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		reqLogger := log.With().Str("request_id", requestID).Logger()
+		ctx := ContextWithRequestID(c.Request.Context(), requestID)
+		c.Request = c.Request.WithContext(reqLogger.WithContext(ctx))
 
 		// Record start time
 		startTime := time.Now()
@@ -202,6 +221,23 @@ func RequestLogger() gin.HandlerFunc {
 	}
 }
 
+// validRequestID 外部传入的请求 ID 只接受有限长度的 [A-Za-z0-9._-]，防止日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		ch := id[i]
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '-' || ch == '_' || ch == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // generateRequestID generates a unique request ID
 func generateRequestID() string {
 	// Use timestamp + random suffix for simplicity
@@ -239,3 +275,28 @@ func WithRequestID(c *gin.Context) zerolog.Logger {
 	}
 	return log.With().Str("request_id", requestID).Logger()
 }
+
+// ContextWithRequestID returns a context carrying the request ID
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// LoggerFrom returns the request-scoped logger attached by RequestLogger,
+// falling back to the global logger for background contexts
+func LoggerFrom(ctx context.Context) *zerolog.Logger {
+	if ctx != nil {
+		if l := zerolog.Ctx(ctx); l != zerolog.DefaultContextLogger && l.GetLevel() != zerolog.Disabled {
+			return l
+		}
+	}
+	return &log.Logger
+}
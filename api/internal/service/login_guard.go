@@ -0,0 +1,409 @@
+// Package core provides login brute-force protection and anomaly detection
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// 登录尝试结果
+const (
+	LoginOutcomeSuccess = "success"
+	LoginOutcomeFailure = "failure"
+	LoginOutcomeLocked  = "locked"
+)
+
+// 登录异常类型
+const (
+	LoginAnomalyUserSpray   = "username_spray"    // 单个 IP 尝试大量不同用户名
+	LoginAnomalyDistributed = "distributed_brute" // 单个用户名被大量不同 IP 尝试
+	LoginAnomalyGlobalSpike = "global_spike"      // 全局失败次数激增（分布式密码喷洒）
+)
+
+// loginKeyPrefix Redis 键前缀
+const loginKeyPrefix = "login:"
+
+// LoginAttempt 登录尝试记录
+type LoginAttempt struct {
+	ID        int64     `db:"id" json:"id"`
+	IP        string    `db:"ip" json:"ip"`
+	Username  string    `db:"username" json:"username"`
+	UserAgent string    `db:"user_agent" json:"user_agent"`
+	Outcome   string    `db:"outcome" json:"outcome"`
+	Reason    string    `db:"reason" json:"reason"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// LoginAnomaly 可疑登录模式
+type LoginAnomaly struct {
+	Type     string `json:"type"`
+	Key      string `json:"key"` // IP 或用户名
+	Count    int    `json:"count"`
+	Distinct int    `json:"distinct"` // 不同用户名/IP 数量
+}
+
+// LoginGuard 登录防爆破
+// 按 IP、用户名+IP 分别计数失败次数（Redis），超过阈值后锁定该 IP 或该 IP 上的用户名，
+// 重复锁定时锁定时长按倍数递增；同一用户名在所有 IP 上的失败只做短时指数退避（不超过
+// user_backoff_max_seconds），避免攻击者通过反复输错密码把真实管理员长时间锁在门外。
+// 所有尝试写入 login_attempts 表，检测到密码喷洒等异常模式时触发告警
+type LoginGuard struct {
+	db     *sqlx.DB
+	rdb    *redis.Client
+	config config.LoginGuardConfig
+	alerts *AlertManager
+}
+
+// NewLoginGuard 创建登录防爆破，rdb 为 nil 时只记录不限流
+func NewLoginGuard(db *sqlx.DB, rdb *redis.Client, cfg config.LoginGuardConfig) *LoginGuard {
+	if cfg.WindowSeconds <= 0 {
+		cfg.WindowSeconds = 900
+	}
+	if cfg.LockoutBaseSeconds <= 0 {
+		cfg.LockoutBaseSeconds = 60
+	}
+	if cfg.LockoutMaxSeconds < cfg.LockoutBaseSeconds {
+		cfg.LockoutMaxSeconds = cfg.LockoutBaseSeconds
+	}
+	if cfg.UserBackoffMaxSeconds <= 0 {
+		cfg.UserBackoffMaxSeconds = 30
+	}
+	return &LoginGuard{db: db, rdb: rdb, config: cfg}
+}
+
+// SetAlertManager 设置告警管理器
+func (g *LoginGuard) SetAlertManager(am *AlertManager) {
+	g.alerts = am
+}
+
+func (g *LoginGuard) limiting() bool {
+	return g.config.Enabled && g.rdb != nil
+}
+
+func (g *LoginGuard) window() time.Duration {
+	return time.Duration(g.config.WindowSeconds) * time.Second
+}
+
+// Check 检查 IP、该 IP 上的用户名是否处于锁定中或用户名处于退避中，返回剩余等待时间
+func (g *LoginGuard) Check(ctx context.Context, ip, username string) (time.Duration, bool) {
+	if !g.limiting() {
+		return 0, false
+	}
+
+	name := normalizeLoginName(username)
+	pipe := g.rdb.Pipeline()
+	ttls := []*redis.DurationCmd{
+		pipe.PTTL(ctx, loginKeyPrefix+"lock:ip:"+ip),
+		pipe.PTTL(ctx, loginKeyPrefix+"lock:userip:"+loginPairKey(name, ip)),
+		pipe.PTTL(ctx, loginKeyPrefix+"backoff:user:"+name),
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		log.Warn().Err(err).Msg("LoginGuard check failed")
+		return 0, false
+	}
+
+	var remaining time.Duration
+	for _, ttl := range ttls {
+		if ttl.Val() > remaining {
+			remaining = ttl.Val()
+		}
+	}
+	if remaining <= 0 {
+		return 0, false
+	}
+	return remaining, true
+}
+
+// RecordLocked 记录被锁定拒绝的尝试
+func (g *LoginGuard) RecordLocked(ctx context.Context, ip, username, userAgent string) {
+	g.saveAttempt(ctx, ip, username, userAgent, LoginOutcomeLocked, "locked")
+}
+
+// RecordSuccess 登录成功：清除用户名失败计数和退避并记录
+func (g *LoginGuard) RecordSuccess(ctx context.Context, ip, username, userAgent string) {
+	if g.limiting() {
+		name := normalizeLoginName(username)
+		pair := loginPairKey(name, ip)
+		g.rdb.Del(ctx, loginKeyPrefix+"fail:user:"+name, loginKeyPrefix+"backoff:user:"+name,
+			loginKeyPrefix+"fail:userip:"+pair, loginKeyPrefix+"lockcount:userip:"+pair)
+	}
+	g.saveAttempt(ctx, ip, username, userAgent, LoginOutcomeSuccess, "")
+}
+
+// RecordFailure 登录失败：累加计数，超限时锁定 IP / 该 IP 上的用户名、对用户名退避，并检测异常模式
+func (g *LoginGuard) RecordFailure(ctx context.Context, ip, username, userAgent, reason string) {
+	g.saveAttempt(ctx, ip, username, userAgent, LoginOutcomeFailure, reason)
+	if !g.limiting() {
+		return
+	}
+
+	name := normalizeLoginName(username)
+	pair := loginPairKey(name, ip)
+	window := g.window()
+	bucket := time.Now().Unix() / int64(g.config.WindowSeconds)
+
+	pipe := g.rdb.Pipeline()
+	ipFails := pipe.Incr(ctx, loginKeyPrefix+"fail:ip:"+ip)
+	pipe.Expire(ctx, loginKeyPrefix+"fail:ip:"+ip, window)
+	pairFails := pipe.Incr(ctx, loginKeyPrefix+"fail:userip:"+pair)
+	pipe.Expire(ctx, loginKeyPrefix+"fail:userip:"+pair, window)
+	userFails := pipe.Incr(ctx, loginKeyPrefix+"fail:user:"+name)
+	pipe.Expire(ctx, loginKeyPrefix+"fail:user:"+name, window)
+
+	usersKey := loginKeyPrefix + "users:ip:" + ip
+	pipe.SAdd(ctx, usersKey, name)
+	pipe.Expire(ctx, usersKey, window)
+	distinctUsers := pipe.SCard(ctx, usersKey)
+
+	ipsKey := loginKeyPrefix + "ips:user:" + name
+	pipe.SAdd(ctx, ipsKey, ip)
+	pipe.Expire(ctx, ipsKey, window)
+	distinctIPs := pipe.SCard(ctx, ipsKey)
+
+	globalKey := fmt.Sprintf("%sfail:global:%d", loginKeyPrefix, bucket)
+	globalFails := pipe.Incr(ctx, globalKey)
+	pipe.Expire(ctx, globalKey, window)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Warn().Err(err).Msg("LoginGuard record failure failed")
+		return
+	}
+
+	if g.config.MaxFailuresPerIP > 0 && ipFails.Val() >= int64(g.config.MaxFailuresPerIP) {
+		g.lock(ctx, "ip", ip)
+	}
+	if g.config.MaxFailuresPerUser > 0 && pairFails.Val() >= int64(g.config.MaxFailuresPerUser) {
+		g.lock(ctx, "userip", pair)
+	}
+	if g.config.MaxFailuresPerUser > 0 && userFails.Val() >= int64(g.config.MaxFailuresPerUser) {
+		// 超过阈值后每次失败的退避时长翻倍（1s、2s、4s…），计数随窗口过期
+		over := userFails.Val() - int64(g.config.MaxFailuresPerUser) + 1
+		g.rdb.Set(ctx, loginKeyPrefix+"backoff:user:"+name, 1, LoginBackoff(1, g.config.UserBackoffMaxSeconds, over))
+	}
+
+	if g.config.SprayDistinctUsers > 0 && distinctUsers.Val() >= int64(g.config.SprayDistinctUsers) {
+		g.raiseAnomaly(ctx, LoginAnomalyUserSpray, ip,
+			fmt.Sprintf("IP %s 在 %d 分钟内尝试了 %d 个不同用户名", ip, g.config.WindowSeconds/60, distinctUsers.Val()),
+			float64(distinctUsers.Val()), float64(g.config.SprayDistinctUsers))
+	}
+	if g.config.DistributedIPs > 0 && distinctIPs.Val() >= int64(g.config.DistributedIPs) {
+		g.raiseAnomaly(ctx, LoginAnomalyDistributed, name,
+			fmt.Sprintf("用户名 %s 在 %d 分钟内被 %d 个不同 IP 尝试登录", name, g.config.WindowSeconds/60, distinctIPs.Val()),
+			float64(distinctIPs.Val()), float64(g.config.DistributedIPs))
+	}
+	if g.config.GlobalFailures > 0 && globalFails.Val() >= int64(g.config.GlobalFailures) {
+		g.raiseAnomaly(ctx, LoginAnomalyGlobalSpike, "global",
+			fmt.Sprintf("%d 分钟内登录失败 %d 次，疑似分布式密码喷洒", g.config.WindowSeconds/60, globalFails.Val()),
+			float64(globalFails.Val()), float64(g.config.GlobalFailures))
+	}
+}
+
+// lock 锁定 IP 或用户名，每次重复锁定时长翻倍，不超过上限
+func (g *LoginGuard) lock(ctx context.Context, kind, key string) {
+	countKey := loginKeyPrefix + "lockcount:" + kind + ":" + key
+	n, err := g.rdb.Incr(ctx, countKey).Result()
+	if err != nil {
+		log.Warn().Err(err).Msg("LoginGuard lock failed")
+		return
+	}
+	g.rdb.Expire(ctx, countKey, 24*time.Hour)
+
+	duration := LoginBackoff(g.config.LockoutBaseSeconds, g.config.LockoutMaxSeconds, n)
+
+	pipe := g.rdb.Pipeline()
+	pipe.Set(ctx, loginKeyPrefix+"lock:"+kind+":"+key, 1, duration)
+	pipe.Del(ctx, loginKeyPrefix+"fail:"+kind+":"+key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Warn().Err(err).Msg("LoginGuard lock failed")
+		return
+	}
+
+	log.Warn().Str("kind", kind).Str("key", key).Dur("duration", duration).Int64("lockouts", n).
+		Msg("Login locked due to repeated failures")
+}
+
+// LoginBackoff 第 n 次（从 1 开始）锁定或退避的时长：base 秒起每次翻倍，不超过 max 秒
+func LoginBackoff(base, max int, n int64) time.Duration {
+	seconds := base
+	for i := int64(1); i < n && seconds < max; i++ {
+		seconds *= 2
+	}
+	if seconds > max {
+		seconds = max
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// raiseAnomaly 触发异常告警，同一异常在窗口内只告警一次
+func (g *LoginGuard) raiseAnomaly(ctx context.Context, anomalyType, key, message string, value, threshold float64) {
+	dedupKey := loginKeyPrefix + "alerted:" + anomalyType + ":" + key
+	ok, err := g.rdb.SetNX(ctx, dedupKey, 1, g.window()).Result()
+	if err != nil || !ok {
+		return
+	}
+
+	log.Warn().Str("type", anomalyType).Str("key", key).Msg(message)
+	if g.alerts != nil {
+		g.alerts.Raise(AlertLevelWarning, "login_anomaly", message, value, threshold)
+	}
+}
+
+// Unlock 手动解除锁定，只传用户名时解除该用户名在所有 IP 上的锁定
+func (g *LoginGuard) Unlock(ctx context.Context, ip, username string) error {
+	if g.rdb == nil {
+		return nil
+	}
+	var keys []string
+	if ip != "" {
+		keys = append(keys, loginKeyPrefix+"lock:ip:"+ip, loginKeyPrefix+"fail:ip:"+ip, loginKeyPrefix+"lockcount:ip:"+ip)
+	}
+	if username != "" {
+		name := normalizeLoginName(username)
+		keys = append(keys, loginKeyPrefix+"backoff:user:"+name, loginKeyPrefix+"fail:user:"+name)
+		if ip != "" {
+			pair := loginPairKey(name, ip)
+			keys = append(keys, loginKeyPrefix+"lock:userip:"+pair, loginKeyPrefix+"fail:userip:"+pair, loginKeyPrefix+"lockcount:userip:"+pair)
+		} else {
+			for _, kind := range []string{"lock", "fail", "lockcount"} {
+				matched, err := g.scanKeys(ctx, loginKeyPrefix+kind+":userip:"+name+"@*")
+				if err != nil {
+					return err
+				}
+				keys = append(keys, matched...)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return g.rdb.Del(ctx, keys...).Err()
+}
+
+// scanKeys 按模式列出键
+func (g *LoginGuard) scanKeys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	iter := g.rdb.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+// saveAttempt 写入 login_attempts
+func (g *LoginGuard) saveAttempt(ctx context.Context, ip, username, userAgent, outcome, reason string) {
+	if g.db == nil {
+		return
+	}
+	if len(username) > 50 {
+		username = username[:50]
+	}
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	if _, err := g.db.ExecContext(ctx,
+		"INSERT INTO login_attempts (ip, username, user_agent, outcome, reason) VALUES (?, ?, ?, ?, ?)",
+		ip, username, userAgent, outcome, reason); err != nil {
+		log.Warn().Err(err).Msg("Failed to record login attempt")
+	}
+}
+
+// ListAttempts 分页查询登录记录
+func (g *LoginGuard) ListAttempts(ctx context.Context, ip, username, outcome string, page, pageSize int) ([]LoginAttempt, int64, error) {
+	where := []string{"1=1"}
+	args := []interface{}{}
+	if ip != "" {
+		where = append(where, "ip = ?")
+		args = append(args, ip)
+	}
+	if username != "" {
+		where = append(where, "username = ?")
+		args = append(args, username)
+	}
+	if outcome != "" {
+		where = append(where, "outcome = ?")
+		args = append(args, outcome)
+	}
+	whereClause := strings.Join(where, " AND ")
+
+	var total int64
+	if err := g.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM login_attempts WHERE "+whereClause, args...); err != nil {
+		return nil, 0, err
+	}
+
+	var items []LoginAttempt
+	err := g.db.SelectContext(ctx, &items, `
+		SELECT id, ip, username, COALESCE(user_agent, '') AS user_agent, outcome, COALESCE(reason, '') AS reason, created_at
+		FROM login_attempts WHERE `+whereClause+`
+		ORDER BY id DESC LIMIT ? OFFSET ?`,
+		append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// DetectAnomalies 基于 login_attempts 统计最近一段时间的可疑模式
+func (g *LoginGuard) DetectAnomalies(ctx context.Context, since time.Time) ([]LoginAnomaly, error) {
+	anomalies := []LoginAnomaly{}
+
+	var sprays []struct {
+		Key      string `db:"k"`
+		Count    int    `db:"cnt"`
+		Distinct int    `db:"distinct_cnt"`
+	}
+	if err := g.db.SelectContext(ctx, &sprays, `
+		SELECT ip AS k, COUNT(*) AS cnt, COUNT(DISTINCT username) AS distinct_cnt
+		FROM login_attempts
+		WHERE outcome != 'success' AND created_at >= ?
+		GROUP BY ip HAVING distinct_cnt >= ?
+		ORDER BY distinct_cnt DESC LIMIT 50`, since, maxInt(g.config.SprayDistinctUsers, 2)); err != nil {
+		return nil, err
+	}
+	for _, s := range sprays {
+		anomalies = append(anomalies, LoginAnomaly{Type: LoginAnomalyUserSpray, Key: s.Key, Count: s.Count, Distinct: s.Distinct})
+	}
+
+	var distributed []struct {
+		Key      string `db:"k"`
+		Count    int    `db:"cnt"`
+		Distinct int    `db:"distinct_cnt"`
+	}
+	if err := g.db.SelectContext(ctx, &distributed, `
+		SELECT username AS k, COUNT(*) AS cnt, COUNT(DISTINCT ip) AS distinct_cnt
+		FROM login_attempts
+		WHERE outcome != 'success' AND created_at >= ?
+		GROUP BY username HAVING distinct_cnt >= ?
+		ORDER BY distinct_cnt DESC LIMIT 50`, since, maxInt(g.config.DistributedIPs, 2)); err != nil {
+		return nil, err
+	}
+	for _, d := range distributed {
+		anomalies = append(anomalies, LoginAnomaly{Type: LoginAnomalyDistributed, Key: d.Key, Count: d.Count, Distinct: d.Distinct})
+	}
+
+	return anomalies, nil
+}
+
+// loginPairKey 用户名 + IP 组合键
+func loginPairKey(name, ip string) string {
+	return name + "@" + ip
+}
+
+// normalizeLoginName 用户名统一小写去空格，避免大小写绕过计数
+func normalizeLoginName(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
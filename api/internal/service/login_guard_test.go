@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"seo-generator/api/pkg/config"
+)
+
+func TestLoginBackoff(t *testing.T) {
+	tests := []struct {
+		name      string
+		base, max int
+		n         int64
+		want      time.Duration
+	}{
+		{"first lockout", 60, 3600, 1, 60 * time.Second},
+		{"second lockout doubles", 60, 3600, 2, 120 * time.Second},
+		{"fourth lockout", 60, 3600, 4, 480 * time.Second},
+		{"capped at max", 60, 3600, 10, 3600 * time.Second},
+		{"zero count uses base", 60, 3600, 0, 60 * time.Second},
+		{"base above max", 60, 30, 1, 30 * time.Second},
+		{"user backoff", 1, 30, 6, 30 * time.Second},
+		{"huge count does not overflow", 1, 30, 1 << 40, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LoginBackoff(tt.base, tt.max, tt.n); got != tt.want {
+				t.Errorf("LoginBackoff(%d, %d, %d) = %v, want %v", tt.base, tt.max, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeLoginName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"admin", "admin"},
+		{"  Admin ", "admin"},
+		{"ADMIN", "admin"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeLoginName(tt.in); got != tt.want {
+			t.Errorf("normalizeLoginName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewLoginGuard_Defaults(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.LoginGuardConfig
+		want config.LoginGuardConfig
+	}{
+		{
+			name: "zero values",
+			cfg:  config.LoginGuardConfig{},
+			want: config.LoginGuardConfig{WindowSeconds: 900, LockoutBaseSeconds: 60, LockoutMaxSeconds: 60, UserBackoffMaxSeconds: 30},
+		},
+		{
+			name: "max below base",
+			cfg:  config.LoginGuardConfig{WindowSeconds: 300, LockoutBaseSeconds: 120, LockoutMaxSeconds: 60, UserBackoffMaxSeconds: 10},
+			want: config.LoginGuardConfig{WindowSeconds: 300, LockoutBaseSeconds: 120, LockoutMaxSeconds: 120, UserBackoffMaxSeconds: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewLoginGuard(nil, nil, tt.cfg)
+			if g.config != tt.want {
+				t.Errorf("config = %+v, want %+v", g.config, tt.want)
+			}
+		})
+	}
+}
+
+// TestLoginGuard_CheckWithoutRedis 未配置 Redis 时只记录不限流
+func TestLoginGuard_CheckWithoutRedis(t *testing.T) {
+	g := NewLoginGuard(nil, nil, config.LoginGuardConfig{Enabled: true})
+	if wait, locked := g.Check(context.Background(), "1.2.3.4", "admin"); locked || wait != 0 {
+		t.Errorf("Check = (%v, %v), want (0, false)", wait, locked)
+	}
+}
@@ -27,8 +27,14 @@ func validatePoolType(poolType string) error {
 
 // PoolItem represents an item in the pool
 type PoolItem struct {
-	ID   int64  `db:"id" json:"id"`
-	Text string `db:"text" json:"text"`
+	ID      int64  `db:"id" json:"id"`
+	Text    string `db:"text" json:"text"`
+	Summary string `db:"summary" json:"summary,omitempty"` // 正文池：来源文章的摘要（original_articles.summary），为空时渲染时生成
+}
+
+// memorySize 条目的内存占用
+func (i PoolItem) memorySize() int64 {
+	return StringMemorySize(i.Text) + StringMemorySize(i.Summary)
 }
 
 // MemoryPool is a thread-safe FIFO queue for pool items
@@ -68,13 +74,24 @@ func (p *MemoryPool) Pop() (PoolItem, bool) {
 	p.items = p.items[1:]
 
 	// 减少内存计数
-	p.memoryBytes.Add(-StringMemorySize(item.Text))
+	p.memoryBytes.Add(-item.memorySize())
 	// 增加消费计数
 	p.consumedCount.Add(1)
 
 	return item, true
 }
 
+// Peek 返回队首条目但不取出（不计入消费）
+func (p *MemoryPool) Peek() (PoolItem, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if len(p.items) == 0 {
+		return PoolItem{}, false
+	}
+	return p.items[0], true
+}
+
 // Push adds items to the end of the pool, skipping items with duplicate IDs.
 // Returns the number of items actually added.
 func (p *MemoryPool) Push(items []PoolItem) int {
@@ -101,13 +118,32 @@ func (p *MemoryPool) Push(items []PoolItem) int {
 		}
 		p.loadedIDs[item.ID] = struct{}{}
 		p.items = append(p.items, item)
-		addedMem += StringMemorySize(item.Text)
+		addedMem += item.memorySize()
 		added++
 	}
 	p.memoryBytes.Add(addedMem)
 	return added
 }
 
+// Requeue puts reserved-but-unconsumed items back at the front of the pool.
+// 预留超时或释放的条目已在 loadedIDs 中，不经过去重直接放回队首，不受 maxSize 限制
+func (p *MemoryPool) Requeue(items []PoolItem) {
+	if len(items) == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var addedMem int64
+	for _, item := range items {
+		addedMem += item.memorySize()
+	}
+	p.items = append(append(make([]PoolItem, 0, len(items)+len(p.items)), items...), p.items...)
+	p.memoryBytes.Add(addedMem)
+	p.consumedCount.Add(-int64(len(items)))
+}
+
 // Len returns the current number of items in the pool
 func (p *MemoryPool) Len() int {
 	p.mu.RLock()
@@ -137,7 +173,7 @@ func (p *MemoryPool) Resize(newMaxSize int) {
 		// 计算被移除项的内存
 		var removedMem int64
 		for i := newMaxSize; i < len(p.items); i++ {
-			removedMem += StringMemorySize(p.items[i].Text)
+			removedMem += p.items[i].memorySize()
 		}
 		p.memoryBytes.Add(-removedMem)
 		p.items = p.items[:newMaxSize]
@@ -34,10 +34,17 @@ type Metrics struct {
 	SpiderRequests int64 // 爬虫请求数
 	NormalRequests int64 // 普通请求数
 
+	// 蜘蛛日志批量摄入指标
+	SpiderLogIngested int64 // 已接收入队的日志条数
+	SpiderLogDropped  int64 // 队列满丢弃的日志条数
+	SpiderLogWritten  int64 // 已写入数据库的日志条数
+	SpiderLogFailed   int64 // 写库失败的日志条数
+
 	// 时间窗口指标（用于计算 QPS）
-	windowStart     time.Time // 窗口开始时间
-	windowRequests  int64     // 窗口内请求数
-	windowLatencyNs int64     // 窗口内总延迟
+	windowStart      time.Time // 窗口开始时间
+	windowRequests   int64     // 窗口内请求数
+	windowLatencyNs  int64     // 窗口内总延迟
+	windowSpiderLogs int64     // 窗口内摄入日志数
 }
 
 // 全局指标实例
@@ -122,6 +129,20 @@ func (m *Metrics) RecordSpiderDetection(isSpider bool) {
 	}
 }
 
+// RecordSpiderLogIngest 记录蜘蛛日志摄入
+// accepted: 成功入队数量, dropped: 因缓冲区满丢弃的数量
+func (m *Metrics) RecordSpiderLogIngest(accepted, dropped int64) {
+	atomic.AddInt64(&m.SpiderLogIngested, accepted)
+	atomic.AddInt64(&m.windowSpiderLogs, accepted)
+	atomic.AddInt64(&m.SpiderLogDropped, dropped)
+}
+
+// RecordSpiderLogFlush 记录蜘蛛日志批量写库结果
+func (m *Metrics) RecordSpiderLogFlush(written, failed int64) {
+	atomic.AddInt64(&m.SpiderLogWritten, written)
+	atomic.AddInt64(&m.SpiderLogFailed, failed)
+}
+
 // MetricsSnapshot 指标快照（用于 JSON 序列化）
 type MetricsSnapshot struct {
 	// 请求指标
@@ -149,6 +170,13 @@ type MetricsSnapshot struct {
 	SpiderRequests int64 `json:"spider_requests"`
 	NormalRequests int64 `json:"normal_requests"`
 
+	// 蜘蛛日志摄入指标
+	SpiderLogIngested   int64   `json:"spider_log_ingested"`
+	SpiderLogDropped    int64   `json:"spider_log_dropped"`
+	SpiderLogWritten    int64   `json:"spider_log_written"`
+	SpiderLogFailed     int64   `json:"spider_log_failed"`
+	SpiderLogIngestRate float64 `json:"spider_log_ingest_rate"` // 条/秒
+
 	// 派生值
 	AvgLatencyMs float64 `json:"avg_latency_ms"`
 	MaxLatencyMs float64 `json:"max_latency_ms"`
@@ -189,7 +217,13 @@ func (m *Metrics) GetSnapshot() MetricsSnapshot {
 	spiderRequests := atomic.LoadInt64(&m.SpiderRequests)
 	normalRequests := atomic.LoadInt64(&m.NormalRequests)
 
+	spiderLogIngested := atomic.LoadInt64(&m.SpiderLogIngested)
+	spiderLogDropped := atomic.LoadInt64(&m.SpiderLogDropped)
+	spiderLogWritten := atomic.LoadInt64(&m.SpiderLogWritten)
+	spiderLogFailed := atomic.LoadInt64(&m.SpiderLogFailed)
+
 	windowRequests := atomic.LoadInt64(&m.windowRequests)
+	windowSpiderLogs := atomic.LoadInt64(&m.windowSpiderLogs)
 
 	// 计算派生值
 	var avgLatencyMs float64
@@ -200,10 +234,11 @@ func (m *Metrics) GetSnapshot() MetricsSnapshot {
 	maxLatencyMs := float64(maxLatencyNs) / 1e6
 
 	// 计算 QPS（基于时间窗口）
-	var qps float64
+	var qps, spiderLogRate float64
 	windowDuration := time.Since(m.windowStart).Seconds()
 	if windowDuration > 0 {
 		qps = float64(windowRequests) / windowDuration
+		spiderLogRate = float64(windowSpiderLogs) / windowDuration
 	}
 
 	// 计算池命中率
@@ -250,6 +285,13 @@ func (m *Metrics) GetSnapshot() MetricsSnapshot {
 		SpiderRequests: spiderRequests,
 		NormalRequests: normalRequests,
 
+		// 蜘蛛日志摄入指标
+		SpiderLogIngested:   spiderLogIngested,
+		SpiderLogDropped:    spiderLogDropped,
+		SpiderLogWritten:    spiderLogWritten,
+		SpiderLogFailed:     spiderLogFailed,
+		SpiderLogIngestRate: spiderLogRate,
+
 		// 派生值
 		AvgLatencyMs: avgLatencyMs,
 		MaxLatencyMs: maxLatencyMs,
@@ -273,6 +315,7 @@ func (m *Metrics) ResetWindow() {
 	m.windowStart = time.Now()
 	atomic.StoreInt64(&m.windowRequests, 0)
 	atomic.StoreInt64(&m.windowLatencyNs, 0)
+	atomic.StoreInt64(&m.windowSpiderLogs, 0)
 }
 
 // Reset 重置所有指标
@@ -297,5 +340,10 @@ func (m *Metrics) Reset() {
 	atomic.StoreInt64(&m.SpiderRequests, 0)
 	atomic.StoreInt64(&m.NormalRequests, 0)
 
+	atomic.StoreInt64(&m.SpiderLogIngested, 0)
+	atomic.StoreInt64(&m.SpiderLogDropped, 0)
+	atomic.StoreInt64(&m.SpiderLogWritten, 0)
+	atomic.StoreInt64(&m.SpiderLogFailed, 0)
+
 	m.ResetWindow()
 }
@@ -16,6 +16,11 @@ type Monitor struct {
 	interval     time.Duration     // 采集间隔
 	stopChan     chan struct{}     // 停止信号
 	running      bool              // 运行状态
+
+	// 其他组件注册的统计项，GetStats 按名称附加
+	extraStats map[string]func() map[string]interface{}
+	// 用户定义的告警规则，每次采集后评估
+	alertRules *AlertRules
 }
 
 // NewMonitor 创建监控服务
@@ -122,6 +127,13 @@ func (m *Monitor) collect() {
 	// 检查告警（不需要持有锁，AlertManager 有自己的锁）
 	m.alertManager.Check(snapshot)
 
+	m.mu.RLock()
+	rules := m.alertRules
+	m.mu.RUnlock()
+	if rules != nil {
+		rules.Evaluate(snapshot)
+	}
+
 	// 重置时间窗口
 	m.metrics.ResetWindow()
 }
@@ -175,17 +187,38 @@ func (m *Monitor) AddAlertRule(rule *AlertRule) {
 	m.alertManager.AddRule(rule)
 }
 
+// SetAlertRules 设置用户定义的告警规则
+func (m *Monitor) SetAlertRules(rules *AlertRules) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.alertRules = rules
+}
+
+// RegisterStats 注册组件统计，GetStats 返回时附加在 name 字段下
+func (m *Monitor) RegisterStats(name string, fn func() map[string]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.extraStats == nil {
+		m.extraStats = make(map[string]func() map[string]interface{})
+	}
+	m.extraStats[name] = fn
+}
+
 // GetStats 获取监控统计信息
 func (m *Monitor) GetStats() map[string]interface{} {
 	m.mu.RLock()
 	historyLen := len(m.history)
 	running := m.running
+	extra := make(map[string]func() map[string]interface{}, len(m.extraStats))
+	for name, fn := range m.extraStats {
+		extra[name] = fn
+	}
 	m.mu.RUnlock()
 
 	snapshot := m.metrics.GetSnapshot()
 	unresolvedAlerts := m.alertManager.GetUnresolvedAlerts()
 
-	return map[string]interface{}{
+	stats := map[string]interface{}{
 		// 监控服务状态
 		"running":       running,
 		"interval_ms":   m.interval.Milliseconds(),
@@ -211,6 +244,10 @@ func (m *Monitor) GetStats() map[string]interface{} {
 		// 时间戳
 		"timestamp": snapshot.Timestamp,
 	}
+	for name, fn := range extra {
+		stats[name] = fn()
+	}
+	return stats
 }
 
 // GetMetrics 获取指标收集器
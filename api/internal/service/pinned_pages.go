@@ -0,0 +1,230 @@
+// Package core provides per-URL pinned content that overrides template rendering
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// 固定页面内容类型
+const (
+	PinnedModeHTML    = "html"    // 直接返回手写 HTML，不经过防护和模板
+	PinnedModeArticle = "article" // 用站点模板渲染指定的原始文章（标题和正文固定）
+)
+
+// MaxPinnedHTMLSize 固定 HTML 的最大字节数
+const MaxPinnedHTMLSize = 2 << 20
+
+// ErrPinnedPageNotFound 固定页面不存在
+var ErrPinnedPageNotFound = errors.New("pinned page not found")
+
+// pinnedPageColumns pinned_pages 查询列
+const pinnedPageColumns = `id, domain, path, mode, html, article_id, enabled, note, created_at, updated_at`
+
+// PinnedPage 固定页面
+type PinnedPage struct {
+	ID        int64     `db:"id" json:"id"`
+	Domain    string    `db:"domain" json:"domain"`
+	Path      string    `db:"path" json:"path"`
+	Mode      string    `db:"mode" json:"mode"`
+	HTML      *string   `db:"html" json:"html,omitempty"`
+	ArticleID *int64    `db:"article_id" json:"article_id,omitempty"`
+	Enabled   bool      `db:"enabled" json:"enabled"`
+	Note      string    `db:"note" json:"note"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// PinnedContent 生效的固定内容（加载时已读取引用的文章）
+type PinnedContent struct {
+	ID      int64
+	Mode    string
+	HTML    string
+	Title   string // article 模式的文章标题
+	Content string // article 模式的文章正文
+}
+
+// ValidPinnedMode 是否为支持的内容类型
+func ValidPinnedMode(mode string) bool {
+	return mode == PinnedModeHTML || mode == PinnedModeArticle
+}
+
+// PinnedPages 固定页面
+// 指定 URL（域名 + 路径，精确匹配）显示手写内容，如站长平台验证页面；
+// 启用的记录启动和修改后整体加载到内存，ServePage 在渲染前无锁查找。
+// 固定页面不写入页面缓存，修改时清除该 URL 已缓存的页面（含各策略命名空间），保证 Nginx 不会返回旧页面
+type PinnedPages struct {
+	db         *sqlx.DB
+	cache      HTMLCache
+	siteCache  *SiteCache
+	strategies *SpiderStrategyResolver
+
+	pages atomic.Pointer[map[string]*PinnedContent]
+}
+
+// NewPinnedPages 创建固定页面
+func NewPinnedPages(db *sqlx.DB, cache HTMLCache, siteCache *SiteCache, strategies *SpiderStrategyResolver) *PinnedPages {
+	p := &PinnedPages{db: db, cache: cache, siteCache: siteCache, strategies: strategies}
+	empty := map[string]*PinnedContent{}
+	p.pages.Store(&empty)
+	return p
+}
+
+func pinnedKey(domain, path string) string {
+	return strings.ToLower(domain) + "\x00" + path
+}
+
+// Reload 从数据库重新加载启用的固定页面
+func (p *PinnedPages) Reload(ctx context.Context) error {
+	var rows []struct {
+		ID      int64          `db:"id"`
+		Domain  string         `db:"domain"`
+		Path    string         `db:"path"`
+		Mode    string         `db:"mode"`
+		HTML    sql.NullString `db:"html"`
+		Title   sql.NullString `db:"title"`
+		Content sql.NullString `db:"content"`
+	}
+	if err := p.db.SelectContext(ctx, &rows, `
+		SELECT p.id, p.domain, p.path, p.mode, p.html, a.title, a.content
+		FROM pinned_pages p LEFT JOIN original_articles a ON a.id = p.article_id
+		WHERE p.enabled = 1`); err != nil {
+		return fmt.Errorf("load pinned pages: %w", err)
+	}
+
+	pages := make(map[string]*PinnedContent, len(rows))
+	for _, r := range rows {
+		if r.Mode == PinnedModeArticle && !r.Content.Valid {
+			log.Warn().Int64("id", r.ID).Str("domain", r.Domain).Str("path", r.Path).Msg("Pinned page article not found, skipped")
+			continue
+		}
+		pages[pinnedKey(r.Domain, r.Path)] = &PinnedContent{
+			ID:      r.ID,
+			Mode:    r.Mode,
+			HTML:    r.HTML.String,
+			Title:   r.Title.String,
+			Content: r.Content.String,
+		}
+	}
+	p.pages.Store(&pages)
+	log.Info().Int("pages", len(pages)).Msg("Pinned pages loaded")
+	return nil
+}
+
+// Get 查找 URL 的固定内容，未固定时返回 nil
+func (p *PinnedPages) Get(domain, path string) *PinnedContent {
+	if p == nil {
+		return nil
+	}
+	pages := *p.pages.Load()
+	if len(pages) == 0 {
+		return nil
+	}
+	return pages[pinnedKey(domain, path)]
+}
+
+// Invalidate 清除 URL 已缓存的页面（原路径和站群各策略命名空间）
+func (p *PinnedPages) Invalidate(ctx context.Context, domain, path string) {
+	paths := []string{path}
+	if site, err := p.siteCache.Get(ctx, domain); err == nil && site != nil {
+		paths = p.strategies.CachePaths(site.SiteGroupID, path)
+	}
+	for _, cachePath := range paths {
+		if err := p.cache.Delete(domain, cachePath); err != nil {
+			log.Warn().Err(err).Str("domain", domain).Str("path", cachePath).Msg("Failed to invalidate pinned page cache")
+		}
+	}
+}
+
+// List 分页列出固定页面，domain 为空时列出全部
+func (p *PinnedPages) List(ctx context.Context, domain string, page, pageSize int) ([]PinnedPage, int64, error) {
+	where, args := "1=1", []interface{}{}
+	if domain != "" {
+		where, args = "domain = ?", append(args, domain)
+	}
+	var total int64
+	if err := p.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM pinned_pages WHERE "+where, args...); err != nil {
+		return nil, 0, err
+	}
+	// 列表不返回 HTML 内容，按 ID 获取详情
+	items := []PinnedPage{}
+	err := p.db.SelectContext(ctx, &items, `
+		SELECT id, domain, path, mode, NULL AS html, article_id, enabled, note, created_at, updated_at
+		FROM pinned_pages WHERE `+where+" ORDER BY id DESC LIMIT ? OFFSET ?",
+		append(args, pageSize, (page-1)*pageSize)...)
+	return items, total, err
+}
+
+// GetByID 获取固定页面
+func (p *PinnedPages) GetByID(ctx context.Context, id int64) (*PinnedPage, error) {
+	var page PinnedPage
+	if err := p.db.GetContext(ctx, &page, "SELECT "+pinnedPageColumns+" FROM pinned_pages WHERE id = ?", id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPinnedPageNotFound
+		}
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Create 创建固定页面，返回 ID
+func (p *PinnedPages) Create(ctx context.Context, page *PinnedPage) (int64, error) {
+	res, err := p.db.ExecContext(ctx, `
+		INSERT INTO pinned_pages (domain, path, mode, html, article_id, enabled, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		page.Domain, page.Path, page.Mode, page.HTML, page.ArticleID, page.Enabled, page.Note)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := res.LastInsertId()
+	p.changed(ctx, page.Domain, page.Path)
+	return id, nil
+}
+
+// Update 更新固定页面，URL 变更时同时清除旧 URL 的缓存
+func (p *PinnedPages) Update(ctx context.Context, page *PinnedPage) error {
+	old, err := p.GetByID(ctx, page.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := p.db.ExecContext(ctx, `
+		UPDATE pinned_pages SET domain = ?, path = ?, mode = ?, html = ?, article_id = ?, enabled = ?, note = ?
+		WHERE id = ?`,
+		page.Domain, page.Path, page.Mode, page.HTML, page.ArticleID, page.Enabled, page.Note, page.ID); err != nil {
+		return err
+	}
+	if old.Domain != page.Domain || old.Path != page.Path {
+		p.Invalidate(ctx, old.Domain, old.Path)
+	}
+	p.changed(ctx, page.Domain, page.Path)
+	return nil
+}
+
+// Delete 删除固定页面
+func (p *PinnedPages) Delete(ctx context.Context, id int64) error {
+	old, err := p.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := p.db.ExecContext(ctx, "DELETE FROM pinned_pages WHERE id = ?", id); err != nil {
+		return err
+	}
+	p.changed(ctx, old.Domain, old.Path)
+	return nil
+}
+
+// changed 重新加载并清除 URL 的缓存
+func (p *PinnedPages) changed(ctx context.Context, domain, path string) {
+	if err := p.Reload(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload pinned pages")
+	}
+	p.Invalidate(ctx, domain, path)
+}
@@ -0,0 +1,162 @@
+// Package core provides toneless pinyin conversion for URL slugs
+package core
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var (
+	pinyinOnce  sync.Once
+	pinyinTable map[rune]string
+)
+
+// loadPinyinTable 首次使用时解析内置拼音表
+func loadPinyinTable() {
+	pinyinTable = make(map[rune]string, 21000)
+	for _, line := range strings.Split(pinyinTableData, "\n") {
+		syllable, chars, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		for _, r := range chars {
+			if _, exists := pinyinTable[r]; !exists {
+				pinyinTable[r] = syllable
+			}
+		}
+	}
+}
+
+// Pinyin 返回单个汉字的无声调拼音，非汉字或未收录时返回 false
+func Pinyin(r rune) (string, bool) {
+	pinyinOnce.Do(loadPinyinTable)
+	s, ok := pinyinTable[r]
+	return s, ok
+}
+
+// pinyinToken 拼音词元：一个汉字的音节，或一段连续的 ASCII 字母数字（已转小写）
+type pinyinToken struct {
+	text string
+	han  bool
+}
+
+// pinyinTokens 将文本转换为拼音词元，未收录的字符和标点视为分隔
+func pinyinTokens(text string) []pinyinToken {
+	var tokens []pinyinToken
+	var ascii strings.Builder
+	flush := func() {
+		if ascii.Len() > 0 {
+			tokens = append(tokens, pinyinToken{text: ascii.String()})
+			ascii.Reset()
+		}
+	}
+	for _, r := range text {
+		if r < unicode.MaxASCII {
+			if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+				ascii.WriteRune(r)
+				continue
+			}
+			if 'A' <= r && r <= 'Z' {
+				ascii.WriteRune(r + ('a' - 'A'))
+				continue
+			}
+			flush()
+			continue
+		}
+		flush()
+		if s, ok := Pinyin(r); ok {
+			tokens = append(tokens, pinyinToken{text: s, han: true})
+		}
+	}
+	flush()
+	return tokens
+}
+
+// PinyinWords 将文本转换为拼音词序列
+// 汉字逐字转换，连续的 ASCII 字母数字保留为一个词（转小写），其余字符视为分隔
+func PinyinWords(text string) []string {
+	tokens := pinyinTokens(text)
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.text
+	}
+	return words
+}
+
+// PinyinSlugOptions 拼音 slug 选项
+// 内置拼音表不含声调，音节一律无声调输出
+type PinyinSlugOptions struct {
+	Separator string // 词之间的分隔符
+	MaxLen    int    // 最大长度，按词截断；第一个词就超长时直接截断
+	Initials  bool   // 汉字只取声母首字母："上海 SEO" -> "sh-seo"
+	UmlautU   bool   // ü 写作 u（lu、nue），默认写作 v（lv、nve）
+}
+
+// slug 按分组生成 slug，每组词元连写为一个词
+func (o PinyinSlugOptions) slug(groups [][]pinyinToken) string {
+	var b strings.Builder
+	var word strings.Builder
+	for _, group := range groups {
+		word.Reset()
+		for _, t := range group {
+			text := t.text
+			if t.han {
+				if o.UmlautU {
+					text = strings.ReplaceAll(text, "v", "u")
+				}
+				if o.Initials {
+					text = text[:1]
+				}
+			}
+			word.WriteString(text)
+		}
+		if word.Len() == 0 {
+			continue
+		}
+		if b.Len() == 0 {
+			if o.MaxLen > 0 && word.Len() > o.MaxLen {
+				return word.String()[:o.MaxLen]
+			}
+			b.WriteString(word.String())
+			continue
+		}
+		if o.MaxLen > 0 && b.Len()+len(o.Separator)+word.Len() > o.MaxLen {
+			break
+		}
+		b.WriteString(o.Separator)
+		b.WriteString(word.String())
+	}
+	return b.String()
+}
+
+// Slug 文本转 slug，每个汉字音节和每段字母数字各为一个词：
+// "上海 SEO 优化" -> "shang-hai-seo-you-hua"
+func (o PinyinSlugOptions) Slug(text string) string {
+	tokens := pinyinTokens(text)
+	groups := make([][]pinyinToken, len(tokens))
+	for i := range tokens {
+		groups[i] = tokens[i : i+1]
+	}
+	return o.slug(groups)
+}
+
+// SlugWords 按分词结果生成 slug，同一个词的音节连写：
+// ["上海", "SEO", "优化"] -> "shanghai-seo-youhua"
+func (o PinyinSlugOptions) SlugWords(words []string) string {
+	groups := make([][]pinyinToken, 0, len(words))
+	for _, w := range words {
+		groups = append(groups, pinyinTokens(w))
+	}
+	return o.slug(groups)
+}
+
+// PinyinSlugWords 按分词结果生成无声调拼音 slug，同一个词的音节连写
+func PinyinSlugWords(words []string, sep string, maxLen int) string {
+	return PinyinSlugOptions{Separator: sep, MaxLen: maxLen}.SlugWords(words)
+}
+
+// PinyinSlug 生成无声调拼音 slug，每个音节之间用分隔符
+func PinyinSlug(text, sep string, maxLen int) string {
+	return PinyinSlugOptions{Separator: sep, MaxLen: maxLen}.Slug(text)
+}
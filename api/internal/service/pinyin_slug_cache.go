@@ -0,0 +1,148 @@
+// Package core provides the precomputed pinyin slug cache for template rendering
+package core
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// 拼音 slug 声调处理
+const (
+	PinyinToneNone     = "none"     // 无声调全拼
+	PinyinToneInitials = "initials" // 只取首字母
+)
+
+// PinyinSlugCache 关键词拼音 slug 缓存
+// 关键词分组加载时在后台预先计算，渲染时只做一次 sync.Map 查找；
+// 未命中的文本实时计算并写入（超过上限后不再写入）。分词词典重新加载后整体失效
+type PinyinSlugCache struct {
+	opts    PinyinSlugOptions
+	byWord  bool
+	seg     *TextSegmenter
+	maxSize int64
+
+	entries sync.Map // text -> slug
+	size    atomic.Int64
+	version atomic.Uint64 // 缓存内容对应的分词词典版本
+	clearMu sync.Mutex
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewPinyinSlugCache 创建拼音 slug 缓存，seg 为 nil 时逐音节分隔
+func NewPinyinSlugCache(cfg config.PinyinSlugConfig, seg *TextSegmenter) *PinyinSlugCache {
+	switch cfg.Tone {
+	case PinyinToneNone, PinyinToneInitials:
+	default:
+		log.Warn().Str("tone", cfg.Tone).Msg("Unsupported pinyin slug tone (built-in table is toneless), using none")
+		cfg.Tone = PinyinToneNone
+	}
+	if cfg.MaxLen <= 0 {
+		cfg.MaxLen = 60
+	}
+	if cfg.CacheMaxEntries <= 0 {
+		cfg.CacheMaxEntries = 2000000
+	}
+	c := &PinyinSlugCache{
+		opts: PinyinSlugOptions{
+			Separator: cfg.Separator,
+			MaxLen:    cfg.MaxLen,
+			Initials:  cfg.Tone == PinyinToneInitials,
+			UmlautU:   cfg.Umlaut == "u",
+		},
+		byWord:  cfg.ByWord && seg != nil,
+		seg:     seg,
+		maxSize: int64(cfg.CacheMaxEntries),
+	}
+	if seg != nil {
+		c.version.Store(seg.Version())
+	}
+	return c
+}
+
+// Slug 获取文本的拼音 slug
+func (c *PinyinSlugCache) Slug(text string) string {
+	c.checkVersion()
+	if v, ok := c.entries.Load(text); ok {
+		c.hits.Add(1)
+		return v.(string)
+	}
+	c.misses.Add(1)
+	slug := c.build(text)
+	c.store(text, slug)
+	return slug
+}
+
+// Warm 预先计算一批文本的 slug（已缓存的跳过）
+func (c *PinyinSlugCache) Warm(texts []string) int {
+	c.checkVersion()
+	added := 0
+	for _, text := range texts {
+		if c.size.Load() >= c.maxSize {
+			break
+		}
+		if _, ok := c.entries.Load(text); ok {
+			continue
+		}
+		if c.store(text, c.build(text)) {
+			added++
+		}
+	}
+	return added
+}
+
+// Stats 缓存统计
+func (c *PinyinSlugCache) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		"entries":     c.size.Load(),
+		"max_entries": c.maxSize,
+		"hits":        c.hits.Load(),
+		"misses":      c.misses.Load(),
+		"by_word":     c.byWord,
+	}
+}
+
+func (c *PinyinSlugCache) build(text string) string {
+	if c.byWord {
+		return c.opts.SlugWords(c.seg.Cut(text))
+	}
+	return c.opts.Slug(text)
+}
+
+func (c *PinyinSlugCache) store(text, slug string) bool {
+	if c.size.Load() >= c.maxSize {
+		return false
+	}
+	if _, loaded := c.entries.LoadOrStore(text, slug); loaded {
+		return false
+	}
+	c.size.Add(1)
+	return true
+}
+
+// checkVersion 分词词典变化后清空缓存（之后按需重新计算）
+func (c *PinyinSlugCache) checkVersion() {
+	if c.seg == nil {
+		return
+	}
+	v := c.seg.Version()
+	if c.version.Load() == v {
+		return
+	}
+	c.clearMu.Lock()
+	defer c.clearMu.Unlock()
+	if c.version.Load() == v {
+		return
+	}
+	c.entries.Range(func(key, _ any) bool {
+		c.entries.Delete(key)
+		return true
+	})
+	c.size.Store(0)
+	c.version.Store(v)
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPinyin(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want string
+		ok   bool
+	}{
+		{'中', "zhong", true},
+		{'绿', "lv", true},
+		{'a', "", false},
+		{'，', "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Pinyin(tt.r)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Pinyin(%q) = (%q, %v), want (%q, %v)", tt.r, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPinyinWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{}},
+		{"上海", []string{"shang", "hai"}},
+		{"上海 SEO优化", []string{"shang", "hai", "seo", "you", "hua"}},
+		{"iPhone15，发布！", []string{"iphone15", "fa", "bu"}},
+	}
+	for _, tt := range tests {
+		if got := PinyinWords(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("PinyinWords(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPinyinSlugOptions_Slug(t *testing.T) {
+	tests := []struct {
+		name string
+		opts PinyinSlugOptions
+		text string
+		want string
+	}{
+		{"default", PinyinSlugOptions{Separator: "-"}, "上海 SEO 优化", "shang-hai-seo-you-hua"},
+		{"no separator", PinyinSlugOptions{}, "上海", "shanghai"},
+		{"max len cuts at word", PinyinSlugOptions{Separator: "-", MaxLen: 12}, "上海 SEO 优化", "shang-hai"},
+		{"first word too long", PinyinSlugOptions{Separator: "-", MaxLen: 3}, "上海", "sha"},
+		{"initials", PinyinSlugOptions{Separator: "-", Initials: true}, "上海 SEO", "s-h-seo"},
+		{"umlaut v", PinyinSlugOptions{Separator: "-"}, "绿色", "lv-se"},
+		{"umlaut u", PinyinSlugOptions{Separator: "-", UmlautU: true}, "绿色", "lu-se"},
+		{"punctuation only", PinyinSlugOptions{Separator: "-"}, "，。！", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Slug(tt.text); got != tt.want {
+				t.Errorf("Slug(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPinyinSlugOptions_SlugWords(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  PinyinSlugOptions
+		words []string
+		want  string
+	}{
+		{"words joined", PinyinSlugOptions{Separator: "-"}, []string{"上海", "SEO", "优化"}, "shanghai-seo-youhua"},
+		{"initials per word", PinyinSlugOptions{Separator: "-", Initials: true}, []string{"上海", "SEO"}, "sh-seo"},
+		{"empty words skipped", PinyinSlugOptions{Separator: "_"}, []string{"上海", "，", "优化"}, "shanghai_youhua"},
+		{"max len", PinyinSlugOptions{Separator: "-", MaxLen: 10}, []string{"上海", "优化"}, "shanghai"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.SlugWords(tt.words); got != tt.want {
+				t.Errorf("SlugWords(%q) = %q, want %q", tt.words, got, tt.want)
+			}
+		})
+	}
+}
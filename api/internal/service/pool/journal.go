@@ -0,0 +1,135 @@
+package pool
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Journal 消费标记预写日志（本地文件）
+//
+// 每次消费在写库前追加一行 "表名\tID"，批次写库成功后把日志重写为仍未写库的标记；
+// 进程异常退出后，启动时重放日志中的标记，已出池但未标记的标题/正文不会在重启后再次出池。
+// 追加不做 fsync：进程崩溃时内核缓冲区的数据仍会落盘，只有整机掉电可能丢失最后几条
+type Journal struct {
+	path string
+	f    *os.File
+}
+
+// OpenJournal 打开（不存在时创建）日志文件，返回其中尚未写库的标记
+func OpenJournal(path string) (*Journal, []UpdateTask, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	tasks := parseJournal(data)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	j := &Journal{path: path, f: f}
+	// 崩溃时写了一半的最后一行需要去掉，否则之后追加的记录会接在它后面
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		if err := j.Rewrite(tasks); err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+	}
+	return j, tasks, nil
+}
+
+// parseJournal 解析日志内容，忽略无法解析的行和没有换行结尾的最后一行（崩溃时写了一半）
+func parseJournal(data []byte) []UpdateTask {
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		data = data[:i+1]
+	} else {
+		data = nil
+	}
+	var tasks []UpdateTask
+	seen := make(map[UpdateTask]struct{})
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		table, idStr, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok || table == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil || id <= 0 {
+			continue
+		}
+		task := UpdateTask{Table: table, ID: id}
+		if _, dup := seen[task]; dup {
+			continue
+		}
+		seen[task] = struct{}{}
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// Path 日志文件路径
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Append 追加一条消费标记
+func (j *Journal) Append(task UpdateTask) error {
+	_, err := fmt.Fprintf(j.f, "%s\t%d\n", task.Table, task.ID)
+	return err
+}
+
+// Rewrite 将日志重写为 tasks（批次写库成功后调用，tasks 为仍未写库的标记）
+//
+// 先写入 <path>.tmp 并 fsync，再原子替换日志文件并 fsync 目录：任何时刻崩溃，磁盘上都是完整的旧日志或新日志。
+// 临时文件以追加方式打开，替换后直接作为新的日志句柄
+func (j *Journal) Rewrite(tasks []UpdateTask) error {
+	tmpPath := j.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	fail := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if len(tasks) > 0 {
+		var buf bytes.Buffer
+		for _, task := range tasks {
+			fmt.Fprintf(&buf, "%s\t%d\n", task.Table, task.ID)
+		}
+		if _, err := tmp.Write(buf.Bytes()); err != nil {
+			return fail(err)
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return fail(err)
+	}
+	j.f.Close()
+	j.f = tmp
+	return syncDir(filepath.Dir(j.path))
+}
+
+// syncDir fsync 目录，保证 rename 落盘
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// Close 关闭日志文件
+func (j *Journal) Close() error {
+	return j.f.Close()
+}
@@ -0,0 +1,116 @@
+package pool
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseJournal(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []UpdateTask
+	}{
+		{"空日志", "", nil},
+		{"正常行", "titles\t1\ncontents\t2\n", []UpdateTask{{Table: "titles", ID: 1}, {Table: "contents", ID: 2}}},
+		{"重复标记去重", "titles\t1\ntitles\t1\n", []UpdateTask{{Table: "titles", ID: 1}}},
+		{"无效行跳过", "titles\tabc\n\t3\ntitles\t0\nkeywords\t4\n", []UpdateTask{{Table: "keywords", ID: 4}}},
+		{"写了一半的最后一行忽略", "titles\t1\ncontents\t2", []UpdateTask{{Table: "titles", ID: 1}}},
+		{"只有半行", "titles\t1", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseJournal([]byte(tt.data)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseJournal(%q) = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJournal_RewriteThenReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.journal")
+
+	j, tasks, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal: %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Fatalf("新日志不应有标记，got %v", tasks)
+	}
+	for _, task := range []UpdateTask{{Table: "titles", ID: 1}, {Table: "titles", ID: 2}, {Table: "contents", ID: 3}} {
+		if err := j.Append(task); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	// 批次写库后只剩 contents/3 未写库
+	if err := j.Rewrite([]UpdateTask{{Table: "contents", ID: 3}}); err != nil {
+		t.Fatalf("Rewrite: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("重写后不应残留临时文件，stat err = %v", err)
+	}
+	// 重写后的句柄继续可追加
+	if err := j.Append(UpdateTask{Table: "keywords", ID: 4}); err != nil {
+		t.Fatalf("Append after Rewrite: %v", err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	j2, tasks, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer j2.Close()
+	want := []UpdateTask{{Table: "contents", ID: 3}, {Table: "keywords", ID: 4}}
+	if !reflect.DeepEqual(tasks, want) {
+		t.Errorf("重新打开后的标记 = %v, want %v", tasks, want)
+	}
+}
+
+func TestJournal_RewriteEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.journal")
+	j, _, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal: %v", err)
+	}
+	j.Append(UpdateTask{Table: "titles", ID: 1})
+	if err := j.Rewrite(nil); err != nil {
+		t.Fatalf("Rewrite: %v", err)
+	}
+	j.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if len(data) != 0 {
+		t.Errorf("全部写库后日志应为空，got %q", data)
+	}
+}
+
+func TestOpenJournal_TruncatesPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.journal")
+	if err := os.WriteFile(path, []byte("titles\t1\ncontents\t2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	j, tasks, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal: %v", err)
+	}
+	if want := []UpdateTask{{Table: "titles", ID: 1}}; !reflect.DeepEqual(tasks, want) {
+		t.Errorf("tasks = %v, want %v", tasks, want)
+	}
+	// 半行被去掉后，新追加的记录独占一行
+	j.Append(UpdateTask{Table: "keywords", ID: 5})
+	j.Close()
+
+	data, _ := os.ReadFile(path)
+	if got, want := string(data), "titles\t1\nkeywords\t5\n"; got != want {
+		t.Errorf("日志内容 = %q, want %q", got, want)
+	}
+}
@@ -26,6 +26,7 @@ type KeywordPool struct {
 	// 数据存储
 	data        map[int][]string // groupID -> encoded keywords
 	rawData     map[int][]string // groupID -> raw keywords
+	boosted     map[int]boostedKeywords
 	mu          sync.RWMutex
 	memoryBytes int64 // 内存占用追踪
 
@@ -46,6 +47,7 @@ func NewKeywordPool(db *sqlx.DB) *KeywordPool {
 		repo:    repository.NewKeywordRepository(db),
 		data:    make(map[int][]string),
 		rawData: make(map[int][]string),
+		boosted: make(map[int]boostedKeywords),
 		ctx:     ctx,
 		cancel:  cancel,
 		hits:    0,
@@ -246,6 +248,74 @@ func (p *KeywordPool) GetRawKeywords(groupID int, count int) []string {
 	return getRandomItems(items, count)
 }
 
+// boostedKeywords 抓取反馈得分较高的关键词，按 ratio 比例优先选取
+type boostedKeywords struct {
+	encoded []string
+	raw     []string
+	ratio   float64
+}
+
+// SetBoostedKeywords 设置分组的高分关键词（原始文本），ratio 为每次选取时命中高分列表的概率，
+// 传空列表或 ratio<=0 时恢复均匀随机
+func (p *KeywordPool) SetBoostedKeywords(groupID int, keywords []string, ratio float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(keywords) == 0 || ratio <= 0 {
+		delete(p.boosted, groupID)
+		return
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+	b := boostedKeywords{encoded: make([]string, len(keywords)), raw: make([]string, len(keywords)), ratio: ratio}
+	for i, kw := range keywords {
+		b.raw[i] = kw
+		b.encoded[i] = encodeText(kw)
+	}
+	p.boosted[groupID] = b
+}
+
+// GetRandomKeywordPairs 返回随机关键词的编码和原始文本（下标一一对应），
+// 设置了高分关键词的分组按比例优先从高分列表选取
+func (p *KeywordPool) GetRandomKeywordPairs(groupID int, count int) (encoded, raw []string) {
+	p.mu.RLock()
+	items, rawItems := p.data[groupID], p.rawData[groupID]
+	if len(items) == 0 {
+		items, rawItems = p.data[1], p.rawData[1]
+	}
+	boost, hasBoost := p.boosted[groupID]
+	p.mu.RUnlock()
+
+	n := len(items)
+	if n == 0 || count <= 0 || len(rawItems) != n {
+		return nil, nil
+	}
+	if count > n {
+		count = n
+	}
+	encoded = make([]string, 0, count)
+	raw = make([]string, 0, count)
+	seen := make(map[string]struct{}, count)
+	for attempts := 0; len(raw) < count && attempts < count*4; attempts++ {
+		var e, r string
+		// 高分列表重复过多时后半段尝试只做均匀随机，保证凑够数量
+		if hasBoost && attempts < count*2 && rand.Float64() < boost.ratio {
+			i := rand.IntN(len(boost.raw))
+			e, r = boost.encoded[i], boost.raw[i]
+		} else {
+			i := rand.IntN(n)
+			e, r = items[i], rawItems[i]
+		}
+		if _, dup := seen[r]; dup {
+			continue
+		}
+		seen[r] = struct{}{}
+		encoded = append(encoded, e)
+		raw = append(raw, r)
+	}
+	return encoded, raw
+}
+
 // AppendKeywords 追加关键词到内存(新增时调用)
 func (p *KeywordPool) AppendKeywords(groupID int, keywords []string) {
 	if len(keywords) == 0 {
@@ -15,22 +15,58 @@ import (
 type UpdateTask struct {
 	Table string
 	ID    int64
+
+	queuedAt time.Time // 入队时间（Add 时填写），用于计算标记延迟
 }
 
 // BatcherConfig configures the update batcher
 type BatcherConfig struct {
-	MaxBatch      int
-	FlushInterval time.Duration
+	MaxBatch      int           // 单条 UPDATE 最多的 ID 数，积压达到该值时立即写库
+	FlushInterval time.Duration // 最长攒批时间
+}
+
+// BatcherStats 消费标记批量写库的运行指标
+type BatcherStats struct {
+	MaxBatch        int        `json:"max_batch"`
+	FlushIntervalMs int64      `json:"flush_interval_ms"`
+	Pending         int        `json:"pending"`           // 等待写库的标记数
+	OldestPendingMs int64      `json:"oldest_pending_ms"` // 最早一条等待写库的标记已等待的时间（当前延迟）
+	Queued          int64      `json:"queued"`            // 累计入队
+	Updated         int64      `json:"updated"`           // 累计写库成功
+	Failed          int64      `json:"failed"`            // 累计写库失败次数（失败的批次重新入队）
+	Flushes         int64      `json:"flushes"`           // 累计成功写库批次
+	Statements      int64      `json:"statements"`        // 累计 UPDATE 语句数
+	AvgBatch        float64    `json:"avg_batch"`         // 每批平均标记数
+	LastLagMs       int64      `json:"last_lag_ms"`       // 最近一批中最早标记从入队到写库完成的时间
+	MaxLagMs        int64      `json:"max_lag_ms"`        // 启动以来最大的标记延迟
+	LastFlushMs     int64      `json:"last_flush_ms"`     // 最近一批写库耗时
+	LastFlushAt     *time.Time `json:"last_flush_at"`
+	LastError       string     `json:"last_error,omitempty"`
+	Journal         string     `json:"journal,omitempty"` // 预写日志路径，为空表示未启用
+	JournalErrors   int64      `json:"journal_errors"`    // 追加/重写日志失败次数
+	Replayed        int        `json:"replayed"`          // 启动时从日志重放的标记数
+	ReplayPending   int        `json:"replay_pending"`    // 重放的标记中尚未写库的数量
 }
 
 // UpdateBatcher batches status updates to reduce database pressure
 // and prevent message loss that occurs with channel-based approaches
+//
+// 消费标记先进入内存队列，积压达到 MaxBatch 或每 FlushInterval 写库一次，
+// 每张表按 MaxBatch 切分为 UPDATE ... WHERE id IN (...)；写库在锁外进行，不阻塞 Add
 type UpdateBatcher struct {
-	db     *sqlx.DB
-	config BatcherConfig
+	db *sqlx.DB
 
 	mu      sync.Mutex
+	config  BatcherConfig
 	pending []UpdateTask
+	stats   BatcherStats
+
+	journal  *Journal                      // 预写日志（可选）
+	replayed map[string]map[int64]struct{} // 从日志重放、尚未写库的标记（表名 -> ID）
+
+	flushMu sync.Mutex    // 同一时间只有一个批次在写库
+	kick    chan struct{} // 积压达到 MaxBatch 时通知后台立即写库
+	reset   chan struct{} // 配置变更时重置定时器
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -40,11 +76,14 @@ type UpdateBatcher struct {
 // NewUpdateBatcher creates a new update batcher
 func NewUpdateBatcher(db *sqlx.DB, config BatcherConfig) *UpdateBatcher {
 	ctx, cancel := context.WithCancel(context.Background())
+	config = normalizeBatcherConfig(config)
 
 	b := &UpdateBatcher{
 		db:      db,
 		config:  config,
 		pending: make([]UpdateTask, 0, config.MaxBatch),
+		kick:    make(chan struct{}, 1),
+		reset:   make(chan struct{}, 1),
 		ctx:     ctx,
 		cancel:  cancel,
 	}
@@ -55,76 +94,238 @@ func NewUpdateBatcher(db *sqlx.DB, config BatcherConfig) *UpdateBatcher {
 	return b
 }
 
+func normalizeBatcherConfig(config BatcherConfig) BatcherConfig {
+	if config.MaxBatch <= 0 {
+		config.MaxBatch = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	return config
+}
+
+// SetConfig 更新批次大小和攒批时间（立即生效）
+func (b *UpdateBatcher) SetConfig(config BatcherConfig) {
+	config = normalizeBatcherConfig(config)
+	b.mu.Lock()
+	b.config = config
+	b.mu.Unlock()
+	select {
+	case b.reset <- struct{}{}:
+	default:
+	}
+}
+
 // Add adds a task to the batch queue
 // This never blocks or drops tasks, solving the channel overflow issue
 func (b *UpdateBatcher) Add(task UpdateTask) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	task.queuedAt = time.Now()
 
+	b.mu.Lock()
+	if b.journal != nil {
+		if err := b.journal.Append(task); err != nil {
+			b.stats.JournalErrors++
+		}
+	}
 	b.pending = append(b.pending, task)
+	b.stats.Queued++
+	full := len(b.pending) >= b.config.MaxBatch
+	b.mu.Unlock()
 
-	if len(b.pending) >= b.config.MaxBatch {
-		b.flushLocked()
+	if full {
+		select {
+		case b.kick <- struct{}{}:
+		default:
+		}
 	}
 }
 
 // Stop stops the batcher and flushes remaining tasks
 func (b *UpdateBatcher) Stop() {
 	// First flush pending tasks (before canceling context)
-	b.mu.Lock()
-	b.flushLocked()
-	b.mu.Unlock()
+	b.flush()
 
 	// Then stop the background goroutine
 	b.cancel()
 	b.wg.Wait()
+
+	b.mu.Lock()
+	if b.journal != nil {
+		b.journal.Close()
+		b.journal = nil
+	}
+	b.mu.Unlock()
+}
+
+// ReplayJournal 打开预写日志并重放其中未写库的标记（立即写库一次），之后的消费标记都先写入日志
+// 应在池开始补充之前调用；写库失败的重放标记保留在队列中重试，期间 IsReplayPending 返回 true
+func (b *UpdateBatcher) ReplayJournal(path string) (int, error) {
+	journal, tasks, err := OpenJournal(path)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	b.mu.Lock()
+	if b.journal != nil {
+		b.journal.Close()
+	}
+	b.journal = journal
+	b.stats.Journal = path
+	b.stats.Replayed = len(tasks)
+	if len(tasks) > 0 {
+		b.replayed = make(map[string]map[int64]struct{})
+		for i := range tasks {
+			tasks[i].queuedAt = now
+			ids := b.replayed[tasks[i].Table]
+			if ids == nil {
+				ids = make(map[int64]struct{})
+				b.replayed[tasks[i].Table] = ids
+			}
+			ids[tasks[i].ID] = struct{}{}
+		}
+		b.pending = append(tasks, b.pending...)
+	}
+	b.mu.Unlock()
+
+	if len(tasks) > 0 {
+		b.flush()
+	}
+	return len(tasks), nil
+}
+
+// IsReplayPending 该 ID 是否为从日志重放、尚未写库的标记（补充池时应跳过，避免重复出池）
+func (b *UpdateBatcher) IsReplayPending(table string, id int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.replayed) == 0 {
+		return false
+	}
+	_, ok := b.replayed[table][id]
+	return ok
 }
 
-// flushLocked performs the flush while holding the lock
-func (b *UpdateBatcher) flushLocked() {
+// Stats 返回运行指标
+func (b *UpdateBatcher) Stats() BatcherStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := b.stats
+	stats.MaxBatch = b.config.MaxBatch
+	stats.FlushIntervalMs = b.config.FlushInterval.Milliseconds()
+	stats.Pending = len(b.pending)
+	if len(b.pending) > 0 {
+		stats.OldestPendingMs = time.Since(b.pending[0].queuedAt).Milliseconds()
+	}
+	if stats.Flushes > 0 {
+		stats.AvgBatch = float64(stats.Updated) / float64(stats.Flushes)
+	}
+	for _, ids := range b.replayed {
+		stats.ReplayPending += len(ids)
+	}
+	return stats
+}
+
+// flush 取出当前积压写库，失败时放回队列头部等待下次重试
+func (b *UpdateBatcher) flush() {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
 	if len(b.pending) == 0 {
+		b.mu.Unlock()
+		return
+	}
+	tasks := b.pending
+	maxBatch := b.config.MaxBatch
+	b.pending = make([]UpdateTask, 0, maxBatch)
+	b.mu.Unlock()
+
+	start := time.Now()
+	statements, err := b.write(tasks, maxBatch)
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		log.Error().Err(err).Int("count", len(tasks)).Msg("Batch update failed, will retry")
+		b.pending = append(tasks, b.pending...)
+		b.stats.Failed++
+		b.stats.LastError = err.Error()
 		return
 	}
+	lag := now.Sub(tasks[0].queuedAt).Milliseconds()
+	b.stats.Updated += int64(len(tasks))
+	b.stats.Flushes++
+	b.stats.Statements += int64(statements)
+	b.stats.LastLagMs = lag
+	if lag > b.stats.MaxLagMs {
+		b.stats.MaxLagMs = lag
+	}
+	b.stats.LastFlushMs = now.Sub(start).Milliseconds()
+	b.stats.LastFlushAt = &now
+	b.stats.LastError = ""
+	if b.replayed != nil {
+		for _, task := range tasks {
+			delete(b.replayed[task.Table], task.ID)
+		}
+		for table, ids := range b.replayed {
+			if len(ids) == 0 {
+				delete(b.replayed, table)
+			}
+		}
+	}
+	// 日志只保留写库期间新入队的标记
+	if b.journal != nil {
+		if err := b.journal.Rewrite(b.pending); err != nil {
+			b.stats.JournalErrors++
+			log.Warn().Err(err).Str("path", b.journal.Path()).Msg("Failed to compact pool journal")
+		}
+	}
 
+	log.Debug().
+		Int("count", len(tasks)).
+		Int("statements", statements).
+		Int64("lag_ms", lag).
+		Msg("Batch update completed")
+}
+
+// write 在一个事务中按表写入，每条 UPDATE 最多 maxBatch 个 ID
+func (b *UpdateBatcher) write(tasks []UpdateTask, maxBatch int) (int, error) {
 	// Group by table
 	grouped := make(map[string][]int64)
-	for _, task := range b.pending {
+	for _, task := range tasks {
 		grouped[task.Table] = append(grouped[task.Table], task.ID)
 	}
 
-	// Start transaction
-	tx, err := b.db.BeginTxx(b.ctx, nil)
+	// 使用独立 context：Stop 时 b.ctx 已取消也要写完剩余标记
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := b.db.BeginTxx(ctx, nil)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to begin transaction for batch update")
-		return
+		return 0, fmt.Errorf("begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Batch update each table
+	statements := 0
 	for table, ids := range grouped {
-		if err := b.batchUpdate(tx, table, ids); err != nil {
-			log.Error().Err(err).Str("table", table).Msg("Batch update failed")
-			return
+		for start := 0; start < len(ids); start += maxBatch {
+			end := min(start+maxBatch, len(ids))
+			if err := b.batchUpdate(ctx, tx, table, ids[start:end]); err != nil {
+				return 0, fmt.Errorf("update %s: %w", table, err)
+			}
+			statements++
 		}
 	}
 
-	// Commit transaction
 	if err := tx.Commit(); err != nil {
-		log.Error().Err(err).Msg("Failed to commit batch update")
-		return
+		return 0, fmt.Errorf("commit: %w", err)
 	}
-
-	log.Debug().
-		Int("count", len(b.pending)).
-		Interface("tables", grouped).
-		Msg("Batch update completed")
-
-	// Clear pending queue
-	b.pending = b.pending[:0]
+	return statements, nil
 }
 
 // batchUpdate updates status for a batch of IDs in a single table
-func (b *UpdateBatcher) batchUpdate(tx *sqlx.Tx, table string, ids []int64) error {
+func (b *UpdateBatcher) batchUpdate(ctx context.Context, tx *sqlx.Tx, table string, ids []int64) error {
 	if len(ids) == 0 {
 		return nil
 	}
@@ -154,7 +355,7 @@ func (b *UpdateBatcher) batchUpdate(tx *sqlx.Tx, table string, ids []int64) erro
 		args[i] = id
 	}
 
-	_, err := tx.Exec(query, args...)
+	_, err := tx.ExecContext(ctx, query, args...)
 	return err
 }
 
@@ -162,17 +363,25 @@ func (b *UpdateBatcher) batchUpdate(tx *sqlx.Tx, table string, ids []int64) erro
 func (b *UpdateBatcher) flushLoop() {
 	defer b.wg.Done()
 
-	ticker := time.NewTicker(b.config.FlushInterval)
+	b.mu.Lock()
+	interval := b.config.FlushInterval
+	b.mu.Unlock()
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-b.ctx.Done():
 			return
-		case <-ticker.C:
+		case <-b.reset:
 			b.mu.Lock()
-			b.flushLocked()
+			interval = b.config.FlushInterval
 			b.mu.Unlock()
+			ticker.Reset(interval)
+		case <-b.kick:
+			b.flush()
+		case <-ticker.C:
+			b.flush()
 		}
 	}
 }
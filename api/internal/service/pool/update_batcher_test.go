@@ -0,0 +1,174 @@
+package pool
+
+import (
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+// newTestBatcher 后台定时写库间隔足够长，测试中只由 flush/Stop/ReplayJournal 触发写库
+func newTestBatcher(t *testing.T, maxBatch int) (*UpdateBatcher, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	b := NewUpdateBatcher(sqlx.NewDb(db, "mysql"), BatcherConfig{MaxBatch: maxBatch, FlushInterval: time.Hour})
+	return b, mock
+}
+
+// expectUpdate 期望一条 UPDATE <table> ... WHERE id IN (ids...)
+func expectUpdate(mock sqlmock.Sqlmock, table string, ids ...int64) *sqlmock.ExpectedExec {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	query := "UPDATE " + table + " SET status = 0 WHERE id IN (" + placeholders + ")"
+	args := make([]driver.Value, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(args...)
+}
+
+func TestUpdateBatcher_FlushSplitsByMaxBatch(t *testing.T) {
+	b, mock := newTestBatcher(t, 2)
+
+	mock.ExpectBegin()
+	expectUpdate(mock, "titles", 1, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+	expectUpdate(mock, "titles", 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	b.mu.Lock()
+	for _, id := range []int64{1, 2, 3} {
+		b.pending = append(b.pending, UpdateTask{Table: "titles", ID: id, queuedAt: time.Now()})
+		b.stats.Queued++
+	}
+	b.mu.Unlock()
+	b.flush()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("SQL 期望未满足: %v", err)
+	}
+	stats := b.Stats()
+	if stats.Pending != 0 || stats.Updated != 3 || stats.Flushes != 1 || stats.Statements != 2 {
+		t.Errorf("stats = %+v, want pending=0 updated=3 flushes=1 statements=2", stats)
+	}
+	if stats.AvgBatch != 3 {
+		t.Errorf("AvgBatch = %v, want 3", stats.AvgBatch)
+	}
+	b.Stop()
+}
+
+func TestUpdateBatcher_FlushFailureRequeues(t *testing.T) {
+	b, mock := newTestBatcher(t, 10)
+
+	mock.ExpectBegin()
+	expectUpdate(mock, "contents", 7).WillReturnError(errors.New("deadlock"))
+	mock.ExpectRollback()
+
+	b.Add(UpdateTask{Table: "contents", ID: 7})
+	b.flush()
+
+	stats := b.Stats()
+	if stats.Pending != 1 || stats.Failed != 1 || stats.Updated != 0 {
+		t.Errorf("stats = %+v, want pending=1 failed=1 updated=0", stats)
+	}
+	if stats.LastError == "" {
+		t.Error("写库失败后 LastError 不应为空")
+	}
+
+	// 下次写库成功后清空积压和错误
+	mock.ExpectBegin()
+	expectUpdate(mock, "contents", 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	b.flush()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("SQL 期望未满足: %v", err)
+	}
+	stats = b.Stats()
+	if stats.Pending != 0 || stats.Updated != 1 || stats.LastError != "" {
+		t.Errorf("stats = %+v, want pending=0 updated=1 last_error empty", stats)
+	}
+	b.Stop()
+}
+
+func TestUpdateBatcher_InvalidTableSkipped(t *testing.T) {
+	b, mock := newTestBatcher(t, 10)
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	b.Add(UpdateTask{Table: "users; DROP TABLE users", ID: 1})
+	b.flush()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("非法表名不应执行 UPDATE: %v", err)
+	}
+	b.Stop()
+}
+
+func TestUpdateBatcher_ReplayJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.journal")
+	if err := os.WriteFile(path, []byte("titles\t5\ntitles\t5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b, mock := newTestBatcher(t, 10)
+
+	// 第一次重放写库失败：标记留在队列中，补充池时应跳过
+	mock.ExpectBegin()
+	expectUpdate(mock, "titles", 5).WillReturnError(errors.New("db down"))
+	mock.ExpectRollback()
+
+	n, err := b.ReplayJournal(path)
+	if err != nil {
+		t.Fatalf("ReplayJournal: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("重放数量 = %d, want 1（重复标记去重）", n)
+	}
+	if !b.IsReplayPending("titles", 5) {
+		t.Error("写库失败时重放标记应保持 pending")
+	}
+	if b.IsReplayPending("titles", 6) {
+		t.Error("未出现在日志中的 ID 不应为 pending")
+	}
+
+	// 新的消费标记先写入日志
+	b.Add(UpdateTask{Table: "contents", ID: 9})
+	data, _ := os.ReadFile(path)
+	if got, want := string(data), "titles\t5\ntitles\t5\ncontents\t9\n"; got != want {
+		t.Errorf("日志内容 = %q, want %q", got, want)
+	}
+
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectBegin()
+	expectUpdate(mock, "titles", 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectUpdate(mock, "contents", 9).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	b.flush()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("SQL 期望未满足: %v", err)
+	}
+	if b.IsReplayPending("titles", 5) {
+		t.Error("写库成功后重放标记不应再 pending")
+	}
+	stats := b.Stats()
+	if stats.Replayed != 1 || stats.ReplayPending != 0 || stats.Journal != path {
+		t.Errorf("stats = %+v, want replayed=1 replay_pending=0 journal=%s", stats, path)
+	}
+	// 全部写库后日志被压缩为空
+	if data, _ := os.ReadFile(path); len(data) != 0 {
+		t.Errorf("写库后日志应为空，got %q", data)
+	}
+	b.Stop()
+}
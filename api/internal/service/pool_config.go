@@ -22,6 +22,10 @@ type CachePoolConfig struct {
 	ContentWorkers          int     `db:"content_workers" json:"content_workers"`
 	ContentRefillIntervalMs int     `db:"content_refill_interval_ms" json:"content_refill_interval_ms"`
 	ContentThreshold        float64 `db:"content_threshold" json:"content_threshold"`
+	// 正文池分片补充（大分组按 id 范围分片，每个分片独立游标）
+	ContentShardThreshold int `db:"content_shard_threshold" json:"content_shard_threshold"` // 可用行数达到该值的分组启用分片，0 表示不分片
+	ContentShards         int `db:"content_shards" json:"content_shards"`                   // 分片数
+	ContentShardWorkers   int `db:"content_shard_workers" json:"content_shard_workers"`     // 并发补充的分片数
 	// cls类名池配置
 	ClsPoolSize         int     `db:"cls_pool_size" json:"cls_pool_size"`
 	ClsWorkers          int     `db:"cls_workers" json:"cls_workers"`
@@ -77,6 +81,9 @@ func DefaultCachePoolConfig() *CachePoolConfig {
 		ContentWorkers:               10,
 		ContentRefillIntervalMs:      50,
 		ContentThreshold:             0.4,
+		ContentShardThreshold:        1000000,
+		ContentShards:                16,
+		ContentShardWorkers:          4,
 		ClsPoolSize:                  100000,
 		ClsWorkers:                   4,
 		ClsRefillIntervalMs:          200,
@@ -106,8 +113,8 @@ func LoadCachePoolConfig(ctx context.Context, db *sqlx.DB) (*CachePoolConfig, er
 // SaveCachePoolConfig saves configuration to database
 func SaveCachePoolConfig(ctx context.Context, db *sqlx.DB, config *CachePoolConfig) error {
 	query := `
-		INSERT INTO pool_config (id, title_pool_size, title_workers, title_refill_interval_ms, title_threshold, content_pool_size, content_workers, content_refill_interval_ms, content_threshold, cls_pool_size, cls_workers, cls_refill_interval_ms, cls_threshold, url_pool_size, url_workers, url_refill_interval_ms, url_threshold, keyword_emoji_pool_size, keyword_emoji_workers, keyword_emoji_refill_interval_ms, keyword_emoji_threshold)
-		VALUES (1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO pool_config (id, title_pool_size, title_workers, title_refill_interval_ms, title_threshold, content_pool_size, content_workers, content_refill_interval_ms, content_threshold, content_shard_threshold, content_shards, content_shard_workers, cls_pool_size, cls_workers, cls_refill_interval_ms, cls_threshold, url_pool_size, url_workers, url_refill_interval_ms, url_threshold, keyword_emoji_pool_size, keyword_emoji_workers, keyword_emoji_refill_interval_ms, keyword_emoji_threshold)
+		VALUES (1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON DUPLICATE KEY UPDATE
 			title_pool_size = VALUES(title_pool_size),
 			title_workers = VALUES(title_workers),
@@ -117,6 +124,9 @@ func SaveCachePoolConfig(ctx context.Context, db *sqlx.DB, config *CachePoolConf
 			content_workers = VALUES(content_workers),
 			content_refill_interval_ms = VALUES(content_refill_interval_ms),
 			content_threshold = VALUES(content_threshold),
+			content_shard_threshold = VALUES(content_shard_threshold),
+			content_shards = VALUES(content_shards),
+			content_shard_workers = VALUES(content_shard_workers),
 			cls_pool_size = VALUES(cls_pool_size),
 			cls_workers = VALUES(cls_workers),
 			cls_refill_interval_ms = VALUES(cls_refill_interval_ms),
@@ -139,6 +149,9 @@ func SaveCachePoolConfig(ctx context.Context, db *sqlx.DB, config *CachePoolConf
 		config.ContentWorkers,
 		config.ContentRefillIntervalMs,
 		config.ContentThreshold,
+		config.ContentShardThreshold,
+		config.ContentShards,
+		config.ContentShardWorkers,
 		config.ClsPoolSize,
 		config.ClsWorkers,
 		config.ClsRefillIntervalMs,
@@ -0,0 +1,257 @@
+// Package core provides consumption-rate tracking and exhaustion forecasting for consumable pools
+package core
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// 预测相关默认值
+const (
+	consumptionWindowMinutes      = 60               // 消费速率统计窗口（分钟）
+	poolForecastInterval          = time.Minute      // 预测刷新间隔
+	DefaultPoolForecastAlertHours = 24.0             // 预计耗尽时间低于此值时告警
+	poolForecastCriticalHours     = 6.0              // 低于此值标记为 critical
+	poolForecastQueryTimeout      = 10 * time.Second // 剩余量统计查询超时
+)
+
+// 预测状态
+const (
+	ForecastStatusOK        = "ok"
+	ForecastStatusWarning   = "warning"
+	ForecastStatusCritical  = "critical"
+	ForecastStatusExhausted = "exhausted"
+	ForecastStatusIdle      = "idle" // 无消费，无法预测
+)
+
+// consumptionTracker 按分组统计每分钟消费量（环形缓冲区）
+type consumptionTracker struct {
+	mu      sync.Mutex
+	buckets map[int]*[consumptionWindowMinutes]int64 // groupID -> 每分钟消费数
+	minutes map[int]*[consumptionWindowMinutes]int64 // groupID -> 桶对应的分钟（Unix 分钟）
+}
+
+func newConsumptionTracker() *consumptionTracker {
+	return &consumptionTracker{
+		buckets: make(map[int]*[consumptionWindowMinutes]int64),
+		minutes: make(map[int]*[consumptionWindowMinutes]int64),
+	}
+}
+
+// record 记录一次消费
+func (t *consumptionTracker) record(groupID int, now time.Time) {
+	minute := now.Unix() / 60
+	idx := minute % consumptionWindowMinutes
+
+	t.mu.Lock()
+	b, ok := t.buckets[groupID]
+	if !ok {
+		b = &[consumptionWindowMinutes]int64{}
+		t.buckets[groupID] = b
+		t.minutes[groupID] = &[consumptionWindowMinutes]int64{}
+	}
+	m := t.minutes[groupID]
+	if m[idx] != minute {
+		m[idx] = minute
+		b[idx] = 0
+	}
+	b[idx]++
+	t.mu.Unlock()
+}
+
+// ratePerHour 返回窗口内的平均消费速率（条/小时）
+// 窗口按实际有数据的跨度计算，服务刚启动时不会被低估太多
+func (t *consumptionTracker) ratePerHour(groupID int, now time.Time) float64 {
+	current := now.Unix() / 60
+	oldest := current - consumptionWindowMinutes + 1
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, ok := t.buckets[groupID]
+	if !ok {
+		return 0
+	}
+	m := t.minutes[groupID]
+
+	var total int64
+	earliest := current
+	for i := 0; i < consumptionWindowMinutes; i++ {
+		if m[i] >= oldest && m[i] <= current && b[i] > 0 {
+			total += b[i]
+			if m[i] < earliest {
+				earliest = m[i]
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	spanMinutes := float64(current-earliest) + 1
+	return float64(total) / spanMinutes * 60
+}
+
+// PoolForecast 单个分组的消耗预测
+type PoolForecast struct {
+	PoolType        string   `json:"pool_type"`
+	GroupID         int      `json:"group_id"`
+	GroupName       string   `json:"group_name"`
+	InMemory        int      `json:"in_memory"`         // 内存池中待消费数量
+	Remaining       int64    `json:"remaining"`         // 数据库中可用数量（含已加载到内存的）
+	RatePerHour     float64  `json:"rate_per_hour"`     // 最近一小时平均消费速率
+	HoursUntilEmpty *float64 `json:"hours_until_empty"` // 预计耗尽小时数，无消费时为 null
+	Status          string   `json:"status"`
+}
+
+// PoolForecastReport 预测结果
+type PoolForecastReport struct {
+	Items          []PoolForecast `json:"items"`
+	ThresholdHours float64        `json:"threshold_hours"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// forecastLoop 定期刷新预测缓存
+func (m *PoolManager) forecastLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(poolForecastInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := m.RefreshForecast(m.ctx); err != nil && m.ctx.Err() == nil {
+				log.Warn().Err(err).Msg("Failed to refresh pool forecast")
+			}
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// RefreshForecast 重新统计剩余量并计算预测
+func (m *PoolManager) RefreshForecast(ctx context.Context) (*PoolForecastReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, poolForecastQueryTimeout)
+	defer cancel()
+
+	var rows []struct {
+		GroupID int   `db:"group_id"`
+		Count   int64 `db:"cnt"`
+	}
+	if err := m.db.SelectContext(ctx, &rows,
+		"SELECT group_id, COUNT(*) AS cnt FROM contents WHERE status = 1 GROUP BY group_id"); err != nil {
+		return nil, err
+	}
+	remaining := make(map[int]int64, len(rows))
+	for _, r := range rows {
+		remaining[r.GroupID] = r.Count
+	}
+
+	m.mu.RLock()
+	inMemory := make(map[int]int, len(m.contents))
+	for gid, p := range m.contents {
+		inMemory[gid] = p.Len()
+	}
+	m.mu.RUnlock()
+
+	names := m.getContentGroupNames()
+	now := time.Now()
+
+	items := make([]PoolForecast, 0, len(inMemory))
+	for gid, memLen := range inMemory {
+		items = append(items, buildForecast("contents", gid, names[gid], memLen, remaining[gid], m.consumption.ratePerHour(gid, now)))
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].GroupID < items[j].GroupID })
+
+	report := &PoolForecastReport{
+		Items:          items,
+		ThresholdHours: DefaultPoolForecastAlertHours,
+		UpdatedAt:      now,
+	}
+	m.forecast.Store(report)
+	return report, nil
+}
+
+// buildForecast 计算单个分组的预测
+func buildForecast(poolType string, groupID int, name string, inMemory int, remaining int64, rate float64) PoolForecast {
+	f := PoolForecast{
+		PoolType:    poolType,
+		GroupID:     groupID,
+		GroupName:   name,
+		InMemory:    inMemory,
+		Remaining:   remaining,
+		RatePerHour: rate,
+	}
+
+	switch {
+	case remaining == 0 && inMemory == 0:
+		zero := 0.0
+		f.HoursUntilEmpty = &zero
+		f.Status = ForecastStatusExhausted
+	case rate <= 0:
+		f.Status = ForecastStatusIdle
+	default:
+		hours := float64(remaining) / rate
+		f.HoursUntilEmpty = &hours
+		switch {
+		case hours < poolForecastCriticalHours:
+			f.Status = ForecastStatusCritical
+		case hours < DefaultPoolForecastAlertHours:
+			f.Status = ForecastStatusWarning
+		default:
+			f.Status = ForecastStatusOK
+		}
+	}
+	return f
+}
+
+// GetForecast 获取最近一次预测结果（未计算过时返回 nil）
+func (m *PoolManager) GetForecast() *PoolForecastReport {
+	return m.forecast.Load()
+}
+
+// minHoursUntilEmpty 返回所有有消费的分组中最短的预计耗尽时间
+func (m *PoolManager) minHoursUntilEmpty() (float64, bool) {
+	report := m.forecast.Load()
+	if report == nil {
+		return 0, false
+	}
+	minHours, found := 0.0, false
+	for _, f := range report.Items {
+		// 无消费的已耗尽分组不告警，避免未使用的分组产生噪音
+		if f.HoursUntilEmpty == nil || f.RatePerHour <= 0 {
+			continue
+		}
+		if !found || *f.HoursUntilEmpty < minHours {
+			minHours, found = *f.HoursUntilEmpty, true
+		}
+	}
+	return minHours, found
+}
+
+// NewPoolExhaustionAlertRule 创建正文池即将耗尽告警规则
+// 规则读取 PoolManager 缓存的预测结果，不在告警检查中查询数据库
+func NewPoolExhaustionAlertRule(m *PoolManager, thresholdHours float64) *AlertRule {
+	if thresholdHours <= 0 {
+		thresholdHours = DefaultPoolForecastAlertHours
+	}
+	return &AlertRule{
+		Name: "pool_exhaustion_forecast",
+		Type: "pool_forecast",
+		Condition: func(MetricsSnapshot) (bool, float64) {
+			hours, ok := m.minHoursUntilEmpty()
+			if !ok {
+				return false, 0
+			}
+			return hours < thresholdHours, hours
+		},
+		Threshold: thresholdHours,
+		Level:     AlertLevelWarning,
+		Message:   "正文池预计耗尽时间(小时)",
+		Cooldown:  30 * time.Minute,
+	}
+}
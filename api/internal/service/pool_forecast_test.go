@@ -0,0 +1,94 @@
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestConsumptionTracker_RatePerHour(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 30, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		records []time.Duration // 相对 now 的消费时间
+		at      time.Duration   // 计算速率的时间点（相对 now）
+		want    float64
+	}{
+		{"no records", nil, 0, 0},
+		{"single minute", []time.Duration{0, 0, 0}, 0, 180},
+		{"spread over ten minutes", []time.Duration{-9 * time.Minute, 0}, 0, 12},
+		{"outside window ignored", []time.Duration{-2 * time.Hour, 0}, 0, 60},
+		{"window expired", []time.Duration{0}, 2 * time.Hour, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newConsumptionTracker()
+			for _, d := range tt.records {
+				tracker.record(1, now.Add(d))
+			}
+			got := tracker.ratePerHour(1, now.Add(tt.at))
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ratePerHour = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsumptionTracker_SeparateGroups(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newConsumptionTracker()
+	tracker.record(1, now)
+	tracker.record(1, now)
+	tracker.record(2, now)
+
+	if got := tracker.ratePerHour(1, now); got != 120 {
+		t.Errorf("group 1 rate = %v, want 120", got)
+	}
+	if got := tracker.ratePerHour(2, now); got != 60 {
+		t.Errorf("group 2 rate = %v, want 60", got)
+	}
+	if got := tracker.ratePerHour(3, now); got != 0 {
+		t.Errorf("group 3 rate = %v, want 0", got)
+	}
+}
+
+func TestBuildForecast(t *testing.T) {
+	tests := []struct {
+		name      string
+		inMemory  int
+		remaining int64
+		rate      float64
+		status    string
+		hours     float64 // 负数表示 HoursUntilEmpty 应为 nil
+	}{
+		{"exhausted", 0, 0, 100, ForecastStatusExhausted, 0},
+		{"idle", 10, 1000, 0, ForecastStatusIdle, -1},
+		{"critical", 10, 100, 50, ForecastStatusCritical, 2},
+		{"warning", 10, 1000, 100, ForecastStatusWarning, 10},
+		{"ok", 10, 10000, 100, ForecastStatusOK, 100},
+		{"memory only counts as not exhausted", 5, 0, 10, ForecastStatusCritical, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := buildForecast("contents", 1, "default", tt.inMemory, tt.remaining, tt.rate)
+			if f.Status != tt.status {
+				t.Errorf("status = %q, want %q", f.Status, tt.status)
+			}
+			if tt.hours < 0 {
+				if f.HoursUntilEmpty != nil {
+					t.Errorf("hours = %v, want nil", *f.HoursUntilEmpty)
+				}
+				return
+			}
+			if f.HoursUntilEmpty == nil {
+				t.Fatalf("hours = nil, want %v", tt.hours)
+			}
+			if math.Abs(*f.HoursUntilEmpty-tt.hours) > 1e-9 {
+				t.Errorf("hours = %v, want %v", *f.HoursUntilEmpty, tt.hours)
+			}
+		})
+	}
+}
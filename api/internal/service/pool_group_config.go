@@ -0,0 +1,280 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// PoolGroupConfig 分组级缓存池配置（pool_group_config 表）
+//
+// 覆盖 CachePoolConfig 中对应池类型的全局值，字段为 nil 时沿用全局配置。
+// 正文池没有独立的补充协程，workers 用于该分组分片补充的并发数（覆盖 content_shard_workers）；
+// 补充间隔短于全局 content_refill_interval_ms 时按全局间隔检查
+type PoolGroupConfig struct {
+	PoolType         string    `db:"pool_type" json:"pool_type"`
+	GroupID          int       `db:"group_id" json:"group_id"`
+	PoolSize         *int      `db:"pool_size" json:"pool_size"`
+	Workers          *int      `db:"workers" json:"workers"`
+	RefillIntervalMs *int      `db:"refill_interval_ms" json:"refill_interval_ms"`
+	Threshold        *float64  `db:"threshold" json:"threshold"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// PoolGroupSettings 合并分组覆盖后的生效配置
+type PoolGroupSettings struct {
+	PoolSize         int     `json:"pool_size"`
+	Workers          int     `json:"workers"`
+	RefillIntervalMs int     `json:"refill_interval_ms"`
+	Threshold        float64 `json:"threshold"`
+}
+
+// RefillInterval returns the refill interval as time.Duration
+func (s PoolGroupSettings) RefillInterval() time.Duration {
+	return time.Duration(s.RefillIntervalMs) * time.Millisecond
+}
+
+// ThresholdCount 低于该条数时触发补充
+func (s PoolGroupSettings) ThresholdCount() int {
+	return int(float64(s.PoolSize) * s.Threshold)
+}
+
+// GroupSettings 返回池类型的全局配置叠加分组覆盖后的生效配置（override 可为 nil）
+func (c *CachePoolConfig) GroupSettings(poolType string, override *PoolGroupConfig) PoolGroupSettings {
+	s := PoolGroupSettings{
+		PoolSize:         c.TitlePoolSize,
+		Workers:          c.TitleWorkers,
+		RefillIntervalMs: c.TitleRefillIntervalMs,
+		Threshold:        c.TitleThreshold,
+	}
+	if poolType == "contents" {
+		s = PoolGroupSettings{
+			PoolSize:         c.ContentPoolSize,
+			Workers:          c.ContentShardWorkers,
+			RefillIntervalMs: c.ContentRefillIntervalMs,
+			Threshold:        c.ContentThreshold,
+		}
+	}
+	if override == nil {
+		return s
+	}
+	if override.PoolSize != nil {
+		s.PoolSize = *override.PoolSize
+	}
+	if override.Workers != nil {
+		s.Workers = *override.Workers
+	}
+	if override.RefillIntervalMs != nil {
+		s.RefillIntervalMs = *override.RefillIntervalMs
+	}
+	if override.Threshold != nil {
+		s.Threshold = *override.Threshold
+	}
+	return s
+}
+
+// LoadPoolGroupConfigs loads all group-level overrides
+func LoadPoolGroupConfigs(ctx context.Context, db *sqlx.DB) ([]PoolGroupConfig, error) {
+	var configs []PoolGroupConfig
+	err := db.SelectContext(ctx, &configs, "SELECT * FROM pool_group_config ORDER BY pool_type, group_id")
+	return configs, err
+}
+
+// SavePoolGroupConfig inserts or replaces a group-level override
+func SavePoolGroupConfig(ctx context.Context, db *sqlx.DB, config *PoolGroupConfig) error {
+	query := `
+		INSERT INTO pool_group_config (pool_type, group_id, pool_size, workers, refill_interval_ms, threshold)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			pool_size = VALUES(pool_size),
+			workers = VALUES(workers),
+			refill_interval_ms = VALUES(refill_interval_ms),
+			threshold = VALUES(threshold)
+	`
+	_, err := db.ExecContext(ctx, query,
+		config.PoolType,
+		config.GroupID,
+		config.PoolSize,
+		config.Workers,
+		config.RefillIntervalMs,
+		config.Threshold,
+	)
+	return err
+}
+
+// DeletePoolGroupConfig removes a group-level override
+func DeletePoolGroupConfig(ctx context.Context, db *sqlx.DB, poolType string, groupID int) error {
+	_, err := db.ExecContext(ctx, "DELETE FROM pool_group_config WHERE pool_type = ? AND group_id = ?", poolType, groupID)
+	return err
+}
+
+// PoolGroupConfigView 分组覆盖及合并后的生效配置（配置接口展示）
+type PoolGroupConfigView struct {
+	PoolGroupConfig
+	Effective PoolGroupSettings `json:"effective"`
+}
+
+func poolGroupKey(poolType string, groupID int) string {
+	return poolType + ":" + strconv.Itoa(groupID)
+}
+
+// groupSettingsWith 以 cfg 为全局配置计算分组生效配置（不获取 m.mu，调用方可持有 m.mu）
+func (m *PoolManager) groupSettingsWith(cfg *CachePoolConfig, poolType string, groupID int) PoolGroupSettings {
+	m.groupMu.RLock()
+	override := m.groupConfigs[poolGroupKey(poolType, groupID)]
+	m.groupMu.RUnlock()
+	return cfg.GroupSettings(poolType, override)
+}
+
+// GroupSettings 分组的生效配置（全局配置叠加分组覆盖）
+func (m *PoolManager) GroupSettings(poolType string, groupID int) PoolGroupSettings {
+	m.mu.RLock()
+	cfg := m.config
+	m.mu.RUnlock()
+	return m.groupSettingsWith(cfg, poolType, groupID)
+}
+
+// loadGroupConfigs 从数据库加载分组覆盖（失败时保留当前覆盖），返回覆盖有变化的标题池分组
+func (m *PoolManager) loadGroupConfigs(ctx context.Context) []int {
+	configs, err := LoadPoolGroupConfigs(ctx, m.db)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to load pool group configs, keeping current overrides")
+		return nil
+	}
+	overrides := make(map[string]*PoolGroupConfig, len(configs))
+	for i := range configs {
+		overrides[poolGroupKey(configs[i].PoolType, configs[i].GroupID)] = &configs[i]
+	}
+
+	m.groupMu.Lock()
+	old := m.groupConfigs
+	m.groupConfigs = overrides
+	m.groupMu.Unlock()
+
+	var changedTitles []int
+	for key, o := range overrides {
+		if o.PoolType == "titles" && !samePoolGroupConfig(old[key], o) {
+			changedTitles = append(changedTitles, o.GroupID)
+		}
+	}
+	for key, o := range old {
+		if _, ok := overrides[key]; !ok && o.PoolType == "titles" {
+			changedTitles = append(changedTitles, o.GroupID)
+		}
+	}
+	return changedTitles
+}
+
+// samePoolGroupConfig 两个分组覆盖的四项配置是否相同（nil 与 nil 相同）
+func samePoolGroupConfig(a, b *PoolGroupConfig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return equalPtr(a.PoolSize, b.PoolSize) && equalPtr(a.Workers, b.Workers) &&
+		equalPtr(a.RefillIntervalMs, b.RefillIntervalMs) && equalPtr(a.Threshold, b.Threshold)
+}
+
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// GetGroupConfigs 所有分组覆盖及生效配置
+func (m *PoolManager) GetGroupConfigs() []PoolGroupConfigView {
+	m.mu.RLock()
+	cfg := m.config
+	m.mu.RUnlock()
+
+	m.groupMu.RLock()
+	defer m.groupMu.RUnlock()
+	views := make([]PoolGroupConfigView, 0, len(m.groupConfigs))
+	for _, override := range m.groupConfigs {
+		views = append(views, PoolGroupConfigView{
+			PoolGroupConfig: *override,
+			Effective:       cfg.GroupSettings(override.PoolType, override),
+		})
+	}
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].PoolType != views[j].PoolType {
+			return views[i].PoolType < views[j].PoolType
+		}
+		return views[i].GroupID < views[j].GroupID
+	})
+	return views
+}
+
+// SetGroupConfig 保存分组覆盖并立即生效（正文池调整容量，标题池重建该分组的池和补充协程）
+func (m *PoolManager) SetGroupConfig(ctx context.Context, config *PoolGroupConfig) error {
+	if config.PoolType != "titles" {
+		if err := validatePoolType(config.PoolType); err != nil {
+			return err
+		}
+	}
+	if err := SavePoolGroupConfig(ctx, m.db, config); err != nil {
+		return fmt.Errorf("failed to save pool group config: %w", err)
+	}
+	saved := *config
+	saved.UpdatedAt = time.Now()
+	m.groupMu.Lock()
+	m.groupConfigs[poolGroupKey(config.PoolType, config.GroupID)] = &saved
+	m.groupMu.Unlock()
+
+	m.applyGroupConfig(config.PoolType, config.GroupID)
+	return nil
+}
+
+// DeleteGroupConfig 删除分组覆盖，该分组恢复使用全局配置
+func (m *PoolManager) DeleteGroupConfig(ctx context.Context, poolType string, groupID int) error {
+	if err := DeletePoolGroupConfig(ctx, m.db, poolType, groupID); err != nil {
+		return fmt.Errorf("failed to delete pool group config: %w", err)
+	}
+	m.groupMu.Lock()
+	delete(m.groupConfigs, poolGroupKey(poolType, groupID))
+	m.groupMu.Unlock()
+
+	m.applyGroupConfig(poolType, groupID)
+	return nil
+}
+
+// applyGroupConfig 使分组的生效配置作用到已创建的池
+func (m *PoolManager) applyGroupConfig(poolType string, groupID int) {
+	settings := m.GroupSettings(poolType, groupID)
+	if poolType == "titles" {
+		if m.titleGenerator != nil {
+			m.titleGenerator.RestartGroup(groupID)
+		}
+	} else {
+		m.mu.RLock()
+		memPool, exists := m.contents[groupID]
+		m.mu.RUnlock()
+		if exists && memPool.GetMaxSize() != settings.PoolSize {
+			memPool.Resize(settings.PoolSize)
+		}
+	}
+	log.Info().
+		Str("type", poolType).
+		Int("group", groupID).
+		Int("pool_size", settings.PoolSize).
+		Int("workers", settings.Workers).
+		Int("refill_interval_ms", settings.RefillIntervalMs).
+		Float64("threshold", settings.Threshold).
+		Msg("Pool group config applied")
+}
+
+// refillDue 补充间隔长于全局间隔的正文池分组是否到了补充检查时间
+func (m *PoolManager) refillDue(settings PoolGroupSettings, groupID int, now time.Time) bool {
+	m.groupMu.Lock()
+	defer m.groupMu.Unlock()
+	if last, ok := m.refillChecked[groupID]; ok && now.Sub(last) < settings.RefillInterval() {
+		return false
+	}
+	m.refillChecked[groupID] = now
+	return true
+}
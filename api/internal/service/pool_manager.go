@@ -5,6 +5,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sort"
 	"sync"
 	"sync/atomic"
@@ -14,6 +16,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"seo-generator/api/internal/service/pool"
+	"seo-generator/api/pkg/config"
 )
 
 // ErrCachePoolEmpty is returned when the cache pool is empty
@@ -36,8 +39,10 @@ type PoolManager struct {
 	poolManager *pool.Manager
 
 	// 辅助组件
-	encoder      *HTMLEntityEncoder
-	emojiManager *EmojiManager
+	encoder       *HTMLEntityEncoder
+	emojiManager  *EmojiManager
+	contentFilter *ContentFilter   // 违禁词过滤（可选）
+	pinyinSlugs   *PinyinSlugCache // 关键词拼音 slug 预计算缓存（可选）
 
 	// 配置和数据库
 	config *CachePoolConfig
@@ -53,6 +58,21 @@ type PoolManager struct {
 
 	// 状态追踪
 	lastRefresh time.Time
+
+	// 大分组分片补充状态（poolType:groupID -> *poolShardState）
+	shards sync.Map
+
+	// 消费速率与耗尽预测
+	consumption *consumptionTracker
+	forecast    atomic.Pointer[PoolForecastReport]
+
+	// 外部系统的标题/正文预留
+	reservations *poolReservations
+
+	// 分组级配置覆盖（poolType:groupID -> *PoolGroupConfig）
+	groupConfigs  map[string]*PoolGroupConfig
+	refillChecked map[int]time.Time // 正文池分组上次补充检查时间
+	groupMu       sync.RWMutex
 }
 
 // PoolGroupInfo 分组详情
@@ -95,16 +115,20 @@ func NewPoolManager(db *sqlx.DB) *PoolManager {
 	}
 
 	return &PoolManager{
-		titles:       make(map[int]*MemoryPool),
-		contents:     make(map[int]*MemoryPool),
-		poolManager:  pool.NewManager(db),
-		encoder:      GetEncoder(),
-		emojiManager: NewEmojiManager(),
-		config:       DefaultCachePoolConfig(),
-		db:           db,
-		ctx:          ctx,
-		cancel:       cancel,
-		batcher:      pool.NewUpdateBatcher(db, batcherConfig),
+		titles:        make(map[int]*MemoryPool),
+		contents:      make(map[int]*MemoryPool),
+		poolManager:   pool.NewManager(db),
+		encoder:       GetEncoder(),
+		emojiManager:  NewEmojiManager(),
+		config:        DefaultCachePoolConfig(),
+		db:            db,
+		ctx:           ctx,
+		cancel:        cancel,
+		batcher:       pool.NewUpdateBatcher(db, batcherConfig),
+		consumption:   newConsumptionTracker(),
+		reservations:  newPoolReservations(),
+		groupConfigs:  make(map[string]*PoolGroupConfig),
+		refillChecked: make(map[int]time.Time),
 	}
 }
 
@@ -116,6 +140,7 @@ func (m *PoolManager) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to load pool config: %w", err)
 	}
 	m.config = config
+	m.loadGroupConfigs(ctx)
 
 	// Discover and initialize pools for all groups (titles/contents)
 	groupIDs, err := m.discoverGroups(ctx)
@@ -162,6 +187,17 @@ func (m *PoolManager) Start(ctx context.Context) error {
 	go m.refillLoop()
 	// updateWorker 已替换为 UpdateBatcher（自动批量处理）
 
+	// 消耗预测：先计算一次，之后定期刷新
+	if _, err := m.RefreshForecast(ctx); err != nil {
+		log.Warn().Err(err).Msg("Initial pool forecast failed")
+	}
+	m.wg.Add(1)
+	go m.forecastLoop()
+
+	// 超时未确认的预留放回池中
+	m.wg.Add(1)
+	go m.reservationLoop()
+
 	imageGroupCount := len(m.poolManager.GetImagePool().GetAllGroups())
 
 	log.Info().
@@ -227,7 +263,7 @@ func (m *PoolManager) getOrCreatePool(poolType string, groupID int) *MemoryPool
 
 	// 双重检查
 	pools = m.contents
-	maxSize := m.config.ContentPoolSize
+	maxSize := m.groupSettingsWith(m.config, poolType, groupID).PoolSize
 	if pool, exists := pools[groupID]; exists {
 		return pool
 	}
@@ -239,27 +275,35 @@ func (m *PoolManager) getOrCreatePool(poolType string, groupID int) *MemoryPool
 }
 
 // Pop retrieves an item from the pool
-func (m *PoolManager) Pop(poolType string, groupID int) (string, error) {
+// ctx 只用于日志关联请求，池为空时的同步补充仍在后台 ctx 下查询，请求取消不会中断补充
+func (m *PoolManager) Pop(ctx context.Context, poolType string, groupID int) (string, error) {
+	item, err := m.PopItem(ctx, poolType, groupID)
+	return item.Text, err
+}
+
+// PopItem 同 Pop，返回完整条目（正文附带来源文章的摘要）
+func (m *PoolManager) PopItem(ctx context.Context, poolType string, groupID int) (PoolItem, error) {
 	// titles 使用 TitleGenerator
 	if poolType == "titles" {
 		if m.titleGenerator == nil {
-			return "", ErrCachePoolEmpty
+			return PoolItem{}, ErrCachePoolEmpty
 		}
-		return m.titleGenerator.Pop(groupID)
+		title, err := m.titleGenerator.Pop(groupID)
+		return PoolItem{Text: title}, err
 	}
 
 	if err := validatePoolType(poolType); err != nil {
-		return "", err
+		return PoolItem{}, err
 	}
 
 	memPool := m.getOrCreatePool(poolType, groupID)
 	item, ok := memPool.Pop()
 	if !ok {
 		// Try to refill and pop again
-		m.refillPool(memPool)
+		m.refillPool(ctx, memPool)
 		item, ok = memPool.Pop()
 		if !ok {
-			return "", ErrCachePoolEmpty
+			return PoolItem{}, ErrCachePoolEmpty
 		}
 	}
 
@@ -267,8 +311,34 @@ func (m *PoolManager) Pop(poolType string, groupID int) (string, error) {
 	if !m.stopped.Load() && m.batcher != nil {
 		m.batcher.Add(pool.UpdateTask{Table: poolType, ID: item.ID})
 	}
+	m.consumption.record(groupID, time.Now())
 
-	return item.Text, nil
+	return item, nil
+}
+
+// PeekItem 同 PopItem，但不取出条目：不标记已用、不计入消耗速度（渲染自检等只读渲染使用）
+func (m *PoolManager) PeekItem(ctx context.Context, poolType string, groupID int) (PoolItem, error) {
+	if poolType == "titles" {
+		if m.titleGenerator == nil {
+			return PoolItem{}, ErrCachePoolEmpty
+		}
+		return PoolItem{Text: m.titleGenerator.Preview(groupID)}, nil
+	}
+
+	if err := validatePoolType(poolType); err != nil {
+		return PoolItem{}, err
+	}
+
+	memPool := m.getOrCreatePool(poolType, groupID)
+	item, ok := memPool.Peek()
+	if !ok {
+		m.refillPool(ctx, memPool)
+		item, ok = memPool.Peek()
+		if !ok {
+			return PoolItem{}, ErrCachePoolEmpty
+		}
+	}
+	return item, nil
 }
 
 // refillLoop runs the background refill check
@@ -295,22 +365,26 @@ func (m *PoolManager) checkAndRefillAll() {
 	for _, p := range m.contents {
 		contentPools = append(contentPools, p)
 	}
-	poolSize := m.config.ContentPoolSize
-	thresholdRatio := m.config.ContentThreshold
+	cfg := m.config
 	m.mu.RUnlock()
 
-	// 计算阈值：池大小 * 阈值比例
-	threshold := int(float64(poolSize) * thresholdRatio)
-
+	now := time.Now()
 	for _, pool := range contentPools {
-		if pool.Len() < threshold && !pool.IsExhausted() {
-			m.refillPool(pool)
+		// 阈值：池大小 * 阈值比例（分组覆盖优先）
+		settings := m.groupSettingsWith(cfg, "contents", pool.GetGroupID())
+		if settings.RefillIntervalMs > cfg.ContentRefillIntervalMs && !m.refillDue(settings, pool.GetGroupID(), now) {
+			continue
+		}
+		if pool.Len() < settings.ThresholdCount() && !pool.IsExhausted() {
+			m.refillPool(m.ctx, pool)
 		}
 	}
 }
 
 // refillPool refills a single pool from database
-func (m *PoolManager) refillPool(memPool *MemoryPool) {
+// 日志使用 ctx 上的请求 logger（后台补充时为全局 logger）
+func (m *PoolManager) refillPool(ctx context.Context, memPool *MemoryPool) {
+	logger := LoggerFrom(ctx)
 	poolType := memPool.GetPoolType()
 	groupID := memPool.GetGroupID()
 	currentLen := memPool.Len()
@@ -326,25 +400,24 @@ func (m *PoolManager) refillPool(memPool *MemoryPool) {
 		column = "content"
 	}
 
-	query := fmt.Sprintf(`
-		SELECT id, %s as text FROM %s
-		WHERE group_id = ? AND status = 1
-		ORDER BY batch_id DESC, id ASC
-		LIMIT ?
-	`, column, poolType)
-
-	var items []PoolItem
-	err := m.db.SelectContext(m.ctx, &items, query, groupID, need)
+	items, err := m.fetchRefillItems(poolType, column, groupID, need)
 	if err != nil {
-		log.Error().Err(err).Str("type", poolType).Int("group", groupID).Msg("Failed to refill pool")
+		logger.Error().Err(err).Str("type", poolType).Int("group", groupID).Msg("Failed to refill pool")
 		return
 	}
 
+	if len(items) > 0 {
+		items = m.skipReplayPending(poolType, items)
+	}
+	if len(items) > 0 && poolType == "contents" && m.contentFilter != nil {
+		items = m.filterItems(poolType, groupID, items)
+	}
+
 	if len(items) > 0 {
 		added := memPool.Push(items)
 
 		if added > 0 {
-			log.Info().
+			logger.Info().
 				Str("type", poolType).
 				Int("group", groupID).
 				Int("added", added).
@@ -357,7 +430,7 @@ func (m *PoolManager) refillPool(memPool *MemoryPool) {
 	} else {
 		// DB 无数据，进入冷却避免空转
 		memPool.MarkExhausted(30 * time.Second)
-		log.Debug().
+		logger.Debug().
 			Str("type", poolType).
 			Int("group", groupID).
 			Int("need", need).
@@ -365,6 +438,99 @@ func (m *PoolManager) refillPool(memPool *MemoryPool) {
 	}
 }
 
+// ConfigureUpdates 设置消费标记批量写库的批次大小和攒批时间，配置了预写日志时重放上次未写库的标记
+// 需要在 Start 之前调用（重放的 ID 写库前不会被补充进池）
+func (m *PoolManager) ConfigureUpdates(cfg config.PoolUpdatesConfig, projectRoot string) {
+	m.batcher.SetConfig(pool.BatcherConfig{
+		MaxBatch:      cfg.MaxBatch,
+		FlushInterval: time.Duration(cfg.FlushIntervalMs) * time.Millisecond,
+	})
+	if cfg.JournalPath == "" {
+		return
+	}
+	path := cfg.JournalPath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(projectRoot, path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Error().Err(err).Str("path", path).Msg("Failed to create pool journal directory")
+		return
+	}
+	replayed, err := m.batcher.ReplayJournal(path)
+	if err != nil {
+		log.Error().Err(err).Str("path", path).Msg("Failed to open pool journal")
+		return
+	}
+	if replayed > 0 {
+		stats := m.batcher.Stats()
+		log.Info().Int("replayed", replayed).Int("pending", stats.ReplayPending).Str("path", path).
+			Msg("Pool consumption journal replayed")
+	}
+}
+
+// GetUpdateStats 消费标记批量写库的运行指标（积压数、标记延迟等）
+func (m *PoolManager) GetUpdateStats() pool.BatcherStats {
+	return m.batcher.Stats()
+}
+
+// SetContentFilter sets the banned-word filter applied at pool-fill time
+func (m *PoolManager) SetContentFilter(f *ContentFilter) {
+	m.contentFilter = f
+}
+
+// SetPinyinSlugCache 设置拼音 slug 缓存，并在后台为已加载的关键词预先计算 slug
+func (m *PoolManager) SetPinyinSlugCache(c *PinyinSlugCache) {
+	m.pinyinSlugs = c
+	go func() {
+		start := time.Now()
+		total := 0
+		for _, groupID := range m.GetKeywordGroupIDs() {
+			total += c.Warm(m.GetAllRawKeywords(groupID))
+		}
+		log.Info().Int("slugs", total).Dur("duration", time.Since(start)).Msg("Pinyin slugs precomputed")
+	}()
+}
+
+// PinyinSlug 获取关键词的拼音 slug（未设置缓存时实时计算）
+func (m *PoolManager) PinyinSlug(text string) string {
+	if m.pinyinSlugs != nil {
+		return m.pinyinSlugs.Slug(text)
+	}
+	return PinyinSlug(text, "-", 60)
+}
+
+// skipReplayPending 跳过从预写日志重放、尚未写库的 ID（已在上次运行中出池）
+func (m *PoolManager) skipReplayPending(poolType string, items []PoolItem) []PoolItem {
+	if m.batcher == nil {
+		return items
+	}
+	kept := items[:0]
+	for _, item := range items {
+		if !m.batcher.IsReplayPending(poolType, item.ID) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// filterItems 对填充的数据应用违禁词过滤
+// 被拒绝的条目直接标记为已使用，避免反复加载
+func (m *PoolManager) filterItems(poolType string, groupID int, items []PoolItem) []PoolItem {
+	kept := items[:0]
+	for _, item := range items {
+		text, result := m.contentFilter.Apply(groupID, item.Text, FilterSourcePool)
+		if result.Rejected {
+			if !m.stopped.Load() && m.batcher != nil {
+				m.batcher.Add(pool.UpdateTask{Table: poolType, ID: item.ID})
+			}
+			continue
+		}
+		item.Text = text
+		kept = append(kept, item)
+	}
+	return kept
+}
+
 // Reload reloads configuration from database
 func (m *PoolManager) Reload(ctx context.Context) error {
 	config, err := LoadCachePoolConfig(ctx, m.db)
@@ -372,14 +538,15 @@ func (m *PoolManager) Reload(ctx context.Context) error {
 		return err
 	}
 
+	changedTitleGroups := m.loadGroupConfigs(ctx)
+
 	m.mu.Lock()
-	oldConfig := m.config
 	m.config = config
 
-	// Resize content pools if needed
-	if config.ContentPoolSize != oldConfig.ContentPoolSize {
-		for _, pool := range m.contents {
-			pool.Resize(config.ContentPoolSize)
+	// Resize content pools if needed（分组覆盖优先）
+	for gid, pool := range m.contents {
+		if size := m.groupSettingsWith(config, "contents", gid).PoolSize; size != pool.GetMaxSize() {
+			pool.Resize(size)
 		}
 	}
 	m.mu.Unlock()
@@ -387,6 +554,9 @@ func (m *PoolManager) Reload(ctx context.Context) error {
 	// Reload TitleGenerator config
 	if m.titleGenerator != nil {
 		m.titleGenerator.Reload(config)
+		for _, gid := range changedTitleGroups {
+			m.titleGenerator.RestartGroup(gid)
+		}
 	}
 
 	// Reload KeywordEmojiGenerator config
@@ -420,7 +590,7 @@ func (m *PoolManager) GetStats() map[string]interface{} {
 		contentsStats[gid] = map[string]interface{}{
 			"current":   pool.Len(),
 			"max_size":  pool.GetMaxSize(),
-			"threshold": m.config.ContentThreshold,
+			"threshold": m.groupSettingsWith(m.config, "contents", gid).Threshold,
 		}
 	}
 	m.mu.RUnlock()
@@ -430,11 +600,14 @@ func (m *PoolManager) GetStats() map[string]interface{} {
 	imagesStats := m.poolManager.GetImagePool().GetAllGroups()
 
 	return map[string]interface{}{
-		"titles":   titlesStats,
-		"contents": contentsStats,
-		"keywords": keywordsStats,
-		"images":   imagesStats,
-		"emojis":   m.emojiManager.Count(),
+		"titles":       titlesStats,
+		"contents":     contentsStats,
+		"keywords":     keywordsStats,
+		"images":       imagesStats,
+		"emojis":       m.emojiManager.Count(),
+		"shards":       m.GetShardStats(),
+		"updates":      m.batcher.Stats(),
+		"reservations": m.GetReservationStats(),
 		"config": map[string]interface{}{
 			"title_pool_size":            m.config.TitlePoolSize,
 			"title_workers":              m.config.TitleWorkers,
@@ -444,6 +617,9 @@ func (m *PoolManager) GetStats() map[string]interface{} {
 			"content_workers":            m.config.ContentWorkers,
 			"content_refill_interval_ms": m.config.ContentRefillIntervalMs,
 			"content_threshold":          m.config.ContentThreshold,
+			"content_shard_threshold":    m.config.ContentShardThreshold,
+			"content_shards":             m.config.ContentShards,
+			"content_shard_workers":      m.config.ContentShardWorkers,
 		},
 	}
 }
@@ -475,6 +651,17 @@ func (m *PoolManager) GetRandomKeywords(groupID int, count int) []string {
 	return m.poolManager.GetKeywordPool().GetRandomKeywords(groupID, count)
 }
 
+// GetRandomKeywordPairs returns random keywords as pre-encoded and raw text (same order)
+// 兼容层: 代理到 pool.KeywordPool，启用抓取反馈加权时优先选取高分关键词
+func (m *PoolManager) GetRandomKeywordPairs(groupID int, count int) (encoded, raw []string) {
+	return m.poolManager.GetKeywordPool().GetRandomKeywordPairs(groupID, count)
+}
+
+// SetBoostedKeywords 设置分组的高分关键词（抓取反馈加权选取）
+func (m *PoolManager) SetBoostedKeywords(groupID int, keywords []string, ratio float64) {
+	m.poolManager.GetKeywordPool().SetBoostedKeywords(groupID, keywords, ratio)
+}
+
 // GetRawKeywords returns raw (not encoded) keywords
 // 兼容层: 代理到 pool.KeywordPool
 func (m *PoolManager) GetRawKeywords(groupID int, count int) []string {
@@ -485,6 +672,9 @@ func (m *PoolManager) GetRawKeywords(groupID int, count int) []string {
 // 兼容层: 代理到 pool.KeywordPool
 func (m *PoolManager) AppendKeywords(groupID int, keywords []string) {
 	m.poolManager.GetKeywordPool().AppendKeywords(groupID, keywords)
+	if m.pinyinSlugs != nil {
+		go m.pinyinSlugs.Warm(keywords)
+	}
 }
 
 // ReloadKeywordGroup 重载指定分组的关键词缓存（删除时调用）
@@ -497,6 +687,9 @@ func (m *PoolManager) ReloadKeywordGroup(ctx context.Context, groupID int) error
 	if m.titleGenerator != nil {
 		m.titleGenerator.SyncGroups(m.GetKeywordGroupIDs())
 	}
+	if m.pinyinSlugs != nil {
+		go m.pinyinSlugs.Warm(m.GetAllRawKeywords(groupID))
+	}
 	// 同步 KeywordEmojiGenerator 分组
 	if m.keywordEmojiGenerator != nil {
 		m.keywordEmojiGenerator.SyncGroups(m.GetKeywordGroupIDs())
@@ -977,19 +1170,19 @@ func (m *PoolManager) RefreshContents(ctx context.Context) {
 
 	for _, p := range pools {
 		p.Clear()
-		m.refillPool(p)
+		m.refillPool(ctx, p)
 	}
 
-	log.Info().Int("groups", len(pools)).Msg("All content pools reloaded")
+	LoggerFrom(ctx).Info().Int("groups", len(pools)).Msg("All content pools reloaded")
 }
 
 // ReloadContentGroup 重载指定分组的正文缓存池
 func (m *PoolManager) ReloadContentGroup(ctx context.Context, groupID int) {
 	memPool := m.getOrCreatePool("contents", groupID)
 	memPool.Clear()
-	m.refillPool(memPool)
+	m.refillPool(ctx, memPool)
 
-	log.Info().Int("group_id", groupID).Msg("Content pool group reloaded")
+	LoggerFrom(ctx).Info().Int("group_id", groupID).Msg("Content pool group reloaded")
 }
 
 // RefreshData 手动刷新指定数据池
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestPoolManager_PopItemCarriesSummary(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	m := NewPoolManager(sqlx.NewDb(db, "mysql"))
+	size := m.getOrCreatePool("contents", 1).GetMaxSize()
+
+	// 补充正文池时关联来源文章取摘要；没有来源文章的正文摘要为空
+	mock.ExpectQuery(regexp.QuoteMeta("LEFT JOIN original_articles a ON a.id = t.article_id")).
+		WithArgs(1, size).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "text", "summary"}).
+			AddRow(10, "正文一", "文章摘要").
+			AddRow(11, "正文二", ""))
+
+	item, err := m.PopItem(context.Background(), "contents", 1)
+	if err != nil {
+		t.Fatalf("PopItem: %v", err)
+	}
+	if item.ID != 10 || item.Text != "正文一" || item.Summary != "文章摘要" {
+		t.Errorf("PopItem = %+v, want id=10 带摘要", item)
+	}
+	text, err := m.Pop(context.Background(), "contents", 1)
+	if err != nil || text != "正文二" {
+		t.Errorf("Pop = (%q, %v), want 正文二", text, err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE contents SET status = 0 WHERE id IN (?,?)")).
+		WithArgs(int64(10), int64(11)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	m.Stop()
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("SQL 期望未满足: %v", err)
+	}
+}
+
+func TestMemoryPool_MemoryIncludesSummary(t *testing.T) {
+	pool := NewMemoryPool(1, "contents", 10)
+	item := PoolItem{ID: 1, Text: "正文", Summary: "摘要"}
+	pool.Push([]PoolItem{item})
+	if got, want := pool.MemoryBytes(), StringMemorySize(item.Text)+StringMemorySize(item.Summary); got != want {
+		t.Errorf("MemoryBytes = %d, want %d", got, want)
+	}
+	pool.Pop()
+	if got := pool.MemoryBytes(); got != 0 {
+		t.Errorf("出池后 MemoryBytes = %d, want 0", got)
+	}
+}
+
+func TestPoolManager_PeekItemDoesNotConsume(t *testing.T) {
+	m, mock := newReservationTestManager(t, 2)
+	memPool := m.getOrCreatePool("contents", 1)
+
+	// 只读渲染多次查看同一条，不出池、不计入消费和消耗速度
+	for i := 0; i < 2; i++ {
+		item, err := m.PeekItem(context.Background(), "contents", 1)
+		if err != nil || item.ID != 1 {
+			t.Fatalf("PeekItem = (%+v, %v), want id=1", item, err)
+		}
+	}
+	if memPool.Len() != 2 || memPool.ConsumedCount() != 0 {
+		t.Errorf("Len = %d, ConsumedCount = %d, want 2, 0", memPool.Len(), memPool.ConsumedCount())
+	}
+	if rate := m.consumption.ratePerHour(1, time.Now()); rate != 0 {
+		t.Errorf("ratePerHour = %v, want 0", rate)
+	}
+
+	// 正常出池仍从队首开始，只有出池的条目标记已用
+	item, err := m.PopItem(context.Background(), "contents", 1)
+	if err != nil || item.ID != 1 {
+		t.Fatalf("PopItem = (%+v, %v), want id=1", item, err)
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE contents SET status = 0 WHERE id IN (?)")).
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	m.Stop()
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("SQL 期望未满足: %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/internal/service/pool"
+)
+
+const (
+	// MaxReservationCount 单次预留的最大条数
+	MaxReservationCount = 1000
+	// DefaultReservationTTL 预留默认有效期，到期未确认的正文放回池中
+	DefaultReservationTTL = 5 * time.Minute
+	// MaxReservationTTL 预留最长有效期
+	MaxReservationTTL = time.Hour
+)
+
+var (
+	// ErrReservationNotFound 预留不存在（已确认、已释放或已超时放回）
+	ErrReservationNotFound = errors.New("reservation not found")
+)
+
+// ReservedItem 预留的一条标题/正文
+type ReservedItem struct {
+	ID      int64  `json:"id"` // 正文为 contents.id；标题由关键词实时生成，为 0
+	Text    string `json:"text"`
+	Summary string `json:"summary,omitempty"` // 正文来源文章的摘要
+}
+
+// PoolReservation 一次预留
+//
+// 外部系统从标题/正文池预留条目：正文出池但不标记为已消费，确认（ack）后才标记，
+// 释放或超时未确认的正文按原顺序放回池首，之后仍可被页面渲染或其他预留取走。
+// 标题由关键词实时生成、不对应数据库记录，确认和超时都不需要处理
+type PoolReservation struct {
+	ID        string         `json:"id"`
+	PoolType  string         `json:"pool_type"` // titles / contents
+	GroupID   int            `json:"group_id"`
+	Items     []ReservedItem `json:"items"`
+	CreatedAt time.Time      `json:"created_at"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// ReservationStats 预留统计
+type ReservationStats struct {
+	Active        int   `json:"active"`         // 未确认的预留数
+	ActiveItems   int   `json:"active_items"`   // 未确认的条目数
+	Reserved      int64 `json:"reserved"`       // 累计预留条目数
+	Acked         int64 `json:"acked"`          // 累计确认条目数
+	Released      int64 `json:"released"`       // 累计主动释放放回的条目数
+	Expired       int64 `json:"expired"`        // 累计超时放回的条目数
+	ExpiredLeases int64 `json:"expired_leases"` // 累计超时的预留数
+}
+
+// poolReservations 预留表（内存，进程重启后未确认的正文未被标记，重新从数据库加载）
+type poolReservations struct {
+	mu    sync.Mutex
+	items map[string]*PoolReservation
+	stats ReservationStats
+}
+
+func newPoolReservations() *poolReservations {
+	return &poolReservations{items: make(map[string]*PoolReservation)}
+}
+
+// Reserve 从池中预留 count 条标题/正文（正文池不足时先补充一次，返回的条数可能少于 count）
+func (m *PoolManager) Reserve(poolType string, groupID, count int, ttl time.Duration) (*PoolReservation, error) {
+	if poolType != "titles" {
+		if err := validatePoolType(poolType); err != nil {
+			return nil, err
+		}
+	}
+	count = min(max(count, 1), MaxReservationCount)
+	if ttl <= 0 {
+		ttl = DefaultReservationTTL
+	}
+	ttl = min(ttl, MaxReservationTTL)
+
+	items := make([]ReservedItem, 0, count)
+	if poolType == "titles" {
+		for i := 0; i < count; i++ {
+			title, err := m.Pop(m.ctx, "titles", groupID)
+			if err != nil {
+				break
+			}
+			items = append(items, ReservedItem{Text: title})
+		}
+	} else {
+		memPool := m.getOrCreatePool(poolType, groupID)
+		for len(items) < count {
+			item, ok := memPool.Pop()
+			if !ok {
+				if len(items) > 0 && memPool.IsExhausted() {
+					break
+				}
+				m.refillPool(m.ctx, memPool)
+				if item, ok = memPool.Pop(); !ok {
+					break
+				}
+			}
+			items = append(items, ReservedItem{ID: item.ID, Text: item.Text, Summary: item.Summary})
+		}
+	}
+	if len(items) == 0 {
+		return nil, ErrCachePoolEmpty
+	}
+
+	id, err := NewSessionID()
+	if err != nil {
+		m.requeue(poolType, groupID, items)
+		return nil, err
+	}
+	now := time.Now()
+	res := &PoolReservation{
+		ID:        id,
+		PoolType:  poolType,
+		GroupID:   groupID,
+		Items:     items,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	m.reservations.mu.Lock()
+	m.reservations.items[id] = res
+	m.reservations.stats.Reserved += int64(len(items))
+	m.reservations.mu.Unlock()
+	return res, nil
+}
+
+// Ack 确认预留：ids 为空时确认全部条目，否则只确认列出的条目，其余放回池中
+// 返回确认和放回的条目数
+func (m *PoolManager) Ack(reservationID string, ids []int64) (acked, released int, err error) {
+	res := m.takeReservation(reservationID)
+	if res == nil {
+		return 0, 0, ErrReservationNotFound
+	}
+
+	var keep map[int64]bool
+	if len(ids) > 0 {
+		keep = make(map[int64]bool, len(ids))
+		for _, id := range ids {
+			keep[id] = true
+		}
+	}
+	var rest []ReservedItem
+	now := time.Now()
+	for _, item := range res.Items {
+		if keep != nil && item.ID != 0 && !keep[item.ID] {
+			rest = append(rest, item)
+			continue
+		}
+		acked++
+		if item.ID != 0 && !m.stopped.Load() && m.batcher != nil {
+			m.batcher.Add(pool.UpdateTask{Table: res.PoolType, ID: item.ID})
+		}
+		if res.PoolType == "contents" {
+			m.consumption.record(res.GroupID, now)
+		}
+	}
+	m.requeue(res.PoolType, res.GroupID, rest)
+
+	m.reservations.mu.Lock()
+	m.reservations.stats.Acked += int64(acked)
+	m.reservations.stats.Released += int64(len(rest))
+	m.reservations.mu.Unlock()
+	return acked, len(rest), nil
+}
+
+// Release 释放预留，正文全部放回池中，返回放回的条目数
+func (m *PoolManager) Release(reservationID string) (int, error) {
+	res := m.takeReservation(reservationID)
+	if res == nil {
+		return 0, ErrReservationNotFound
+	}
+	m.requeue(res.PoolType, res.GroupID, res.Items)
+
+	m.reservations.mu.Lock()
+	m.reservations.stats.Released += int64(len(res.Items))
+	m.reservations.mu.Unlock()
+	return len(res.Items), nil
+}
+
+// GetReservation 查询未确认的预留
+func (m *PoolManager) GetReservation(reservationID string) *PoolReservation {
+	m.reservations.mu.Lock()
+	defer m.reservations.mu.Unlock()
+	return m.reservations.items[reservationID]
+}
+
+// GetReservationStats 预留统计
+func (m *PoolManager) GetReservationStats() ReservationStats {
+	m.reservations.mu.Lock()
+	defer m.reservations.mu.Unlock()
+	stats := m.reservations.stats
+	stats.Active = len(m.reservations.items)
+	for _, res := range m.reservations.items {
+		stats.ActiveItems += len(res.Items)
+	}
+	return stats
+}
+
+func (m *PoolManager) takeReservation(reservationID string) *PoolReservation {
+	m.reservations.mu.Lock()
+	defer m.reservations.mu.Unlock()
+	res, ok := m.reservations.items[reservationID]
+	if !ok {
+		return nil
+	}
+	delete(m.reservations.items, reservationID)
+	return res
+}
+
+// requeue 正文放回池首（标题实时生成，直接丢弃）
+func (m *PoolManager) requeue(poolType string, groupID int, items []ReservedItem) {
+	if poolType != "contents" || len(items) == 0 {
+		return
+	}
+	back := make([]PoolItem, 0, len(items))
+	for _, item := range items {
+		back = append(back, PoolItem{ID: item.ID, Text: item.Text, Summary: item.Summary})
+	}
+	m.getOrCreatePool(poolType, groupID).Requeue(back)
+}
+
+// expireReservations 超时未确认的预留放回池中
+func (m *PoolManager) expireReservations(now time.Time) {
+	var expired []*PoolReservation
+	m.reservations.mu.Lock()
+	for id, res := range m.reservations.items {
+		if now.After(res.ExpiresAt) {
+			expired = append(expired, res)
+			delete(m.reservations.items, id)
+		}
+	}
+	m.reservations.mu.Unlock()
+	if len(expired) == 0 {
+		return
+	}
+
+	items := 0
+	for _, res := range expired {
+		m.requeue(res.PoolType, res.GroupID, res.Items)
+		items += len(res.Items)
+	}
+	m.reservations.mu.Lock()
+	m.reservations.stats.Expired += int64(items)
+	m.reservations.stats.ExpiredLeases += int64(len(expired))
+	m.reservations.mu.Unlock()
+	log.Info().Int("reservations", len(expired)).Int("items", items).Msg("Expired pool reservations requeued")
+}
+
+// reservationLoop 定期放回超时的预留
+func (m *PoolManager) reservationLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			m.expireReservations(now)
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
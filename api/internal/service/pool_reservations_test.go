@@ -0,0 +1,197 @@
+package core
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+// newReservationTestManager 正文池分组 1 预置 ID 1..n，池标记为耗尽，预留时不会查库补充
+func newReservationTestManager(t *testing.T, n int) (*PoolManager, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m := NewPoolManager(sqlx.NewDb(db, "mysql"))
+	memPool := m.getOrCreatePool("contents", 1)
+	items := make([]PoolItem, 0, n)
+	for i := 1; i <= n; i++ {
+		items = append(items, PoolItem{ID: int64(i), Text: "content"})
+	}
+	memPool.Push(items)
+	memPool.MarkExhausted(time.Hour)
+	return m, mock
+}
+
+// poolIDs 池中剩余条目的 ID（按出池顺序）
+func poolIDs(m *PoolManager, groupID int) []int64 {
+	p := m.getOrCreatePool("contents", groupID)
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	ids := make([]int64, 0, len(p.items))
+	for _, item := range p.items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func reservedIDs(res *PoolReservation) []int64 {
+	ids := make([]int64, 0, len(res.Items))
+	for _, item := range res.Items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPoolManager_ReserveAndAck(t *testing.T) {
+	m, mock := newReservationTestManager(t, 4)
+
+	res, err := m.Reserve("contents", 1, 3, 0)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if got := reservedIDs(res); !equalIDs(got, []int64{1, 2, 3}) {
+		t.Errorf("预留 ID = %v, want [1 2 3]", got)
+	}
+	if got := res.ExpiresAt.Sub(res.CreatedAt); got != DefaultReservationTTL {
+		t.Errorf("默认有效期 = %v, want %v", got, DefaultReservationTTL)
+	}
+	if got := poolIDs(m, 1); !equalIDs(got, []int64{4}) {
+		t.Errorf("预留后池中 = %v, want [4]", got)
+	}
+	if m.GetReservation(res.ID) == nil {
+		t.Fatal("GetReservation 应返回未确认的预留")
+	}
+
+	// 只确认 1 和 3，2 放回池首
+	acked, released, err := m.Ack(res.ID, []int64{1, 3})
+	if err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if acked != 2 || released != 1 {
+		t.Errorf("Ack = (%d, %d), want (2, 1)", acked, released)
+	}
+	if got := poolIDs(m, 1); !equalIDs(got, []int64{2, 4}) {
+		t.Errorf("确认后池中 = %v, want [2 4]", got)
+	}
+	if _, _, err := m.Ack(res.ID, nil); !errors.Is(err, ErrReservationNotFound) {
+		t.Errorf("重复确认 err = %v, want ErrReservationNotFound", err)
+	}
+
+	stats := m.GetReservationStats()
+	if stats.Reserved != 3 || stats.Acked != 2 || stats.Released != 1 || stats.Active != 0 {
+		t.Errorf("stats = %+v, want reserved=3 acked=2 released=1 active=0", stats)
+	}
+
+	// 只有确认的条目标记为已消费
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE contents SET status = 0 WHERE id IN (?,?)")).
+		WithArgs(int64(1), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	m.Stop()
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("确认的条目应写库标记: %v", err)
+	}
+}
+
+func TestPoolManager_ReserveShortPool(t *testing.T) {
+	m, _ := newReservationTestManager(t, 2)
+	defer m.Stop()
+
+	// 池已耗尽时返回的条数少于请求数
+	res, err := m.Reserve("contents", 1, 5, 2*MaxReservationTTL)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if len(res.Items) != 2 {
+		t.Errorf("预留条数 = %d, want 2", len(res.Items))
+	}
+	if got := res.ExpiresAt.Sub(res.CreatedAt); got != MaxReservationTTL {
+		t.Errorf("有效期 = %v, want 上限 %v", got, MaxReservationTTL)
+	}
+	m.Release(res.ID)
+}
+
+func TestPoolManager_ReleaseRequeuesInOrder(t *testing.T) {
+	m, _ := newReservationTestManager(t, 3)
+	defer m.Stop()
+
+	res, err := m.Reserve("contents", 1, 2, time.Minute)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	n, err := m.Release(res.ID)
+	if err != nil || n != 2 {
+		t.Fatalf("Release = (%d, %v), want (2, nil)", n, err)
+	}
+	if got := poolIDs(m, 1); !equalIDs(got, []int64{1, 2, 3}) {
+		t.Errorf("释放后池中 = %v, want [1 2 3]", got)
+	}
+	if _, err := m.Release(res.ID); !errors.Is(err, ErrReservationNotFound) {
+		t.Errorf("重复释放 err = %v, want ErrReservationNotFound", err)
+	}
+}
+
+func TestPoolManager_ExpireReservations(t *testing.T) {
+	m, _ := newReservationTestManager(t, 3)
+	defer m.Stop()
+
+	res, err := m.Reserve("contents", 1, 2, time.Minute)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+
+	// 未到期不放回
+	m.expireReservations(res.ExpiresAt)
+	if m.GetReservation(res.ID) == nil {
+		t.Fatal("未到期的预留不应被放回")
+	}
+
+	m.expireReservations(res.ExpiresAt.Add(time.Second))
+	if m.GetReservation(res.ID) != nil {
+		t.Error("到期的预留应被移除")
+	}
+	if got := poolIDs(m, 1); !equalIDs(got, []int64{1, 2, 3}) {
+		t.Errorf("超时放回后池中 = %v, want [1 2 3]", got)
+	}
+	stats := m.GetReservationStats()
+	if stats.Expired != 2 || stats.ExpiredLeases != 1 {
+		t.Errorf("stats = %+v, want expired=2 expired_leases=1", stats)
+	}
+	if _, _, err := m.Ack(res.ID, nil); !errors.Is(err, ErrReservationNotFound) {
+		t.Errorf("超时后确认 err = %v, want ErrReservationNotFound", err)
+	}
+}
+
+func TestPoolManager_ReserveInvalid(t *testing.T) {
+	m, _ := newReservationTestManager(t, 0)
+	defer m.Stop()
+
+	if _, err := m.Reserve("users", 1, 1, 0); err == nil {
+		t.Error("非法池类型应返回错误")
+	}
+	// 未配置标题生成器时标题池为空
+	if _, err := m.Reserve("titles", 1, 1, 0); !errors.Is(err, ErrCachePoolEmpty) {
+		t.Errorf("标题预留 err = %v, want ErrCachePoolEmpty", err)
+	}
+}
@@ -0,0 +1,261 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// poolShardStatsTTL 分组行数和 id 范围的重新统计间隔
+const poolShardStatsTTL = 10 * time.Minute
+
+// poolShard 一个 id 范围分片：(lo, hi]，cursor 为上次加载到的 id
+type poolShard struct {
+	lo, hi int64
+	cursor int64
+}
+
+// poolShardState 一个分组的分片补充状态
+//
+// 分组可用行数达到 content_shard_threshold 时，按 [MIN(id), MAX(id)] 等分为 content_shards 个范围，
+// 每个分片从游标处按主键顺序取数（WHERE id > cursor AND id <= hi ORDER BY id LIMIT n），
+// 走 (group_id, status) 索引的主键后缀，补充耗时只与 LIMIT 有关；分片取完后游标回到起点，
+// 重新扫描时已加载过的 id 由 MemoryPool 去重。行数低于阈值时沿用原来的 batch_id 优先查询，
+// 分组在两种方式之间切换不需要任何数据迁移
+type poolShardState struct {
+	poolType string
+	groupID  int
+
+	mu        sync.Mutex
+	rows      int64
+	minID     int64
+	maxID     int64
+	shards    []poolShard
+	refreshed time.Time
+}
+
+// PoolShardStats 分片补充状态（统计接口展示）
+type PoolShardStats struct {
+	PoolType string  `json:"pool_type"`
+	GroupID  int     `json:"group_id"`
+	Rows     int64   `json:"rows"`
+	MinID    int64   `json:"min_id"`
+	MaxID    int64   `json:"max_id"`
+	Shards   int     `json:"shards"`
+	Cursors  []int64 `json:"cursors"`
+}
+
+func poolShardKey(poolType string, groupID int) string {
+	return poolType + ":" + strconv.Itoa(groupID)
+}
+
+// shardState 返回分组的分片状态，未启用分片或行数低于阈值时返回 nil
+func (m *PoolManager) shardState(poolType string, groupID int) *poolShardState {
+	m.mu.RLock()
+	threshold := m.config.ContentShardThreshold
+	shardCount := m.config.ContentShards
+	m.mu.RUnlock()
+	if threshold <= 0 || shardCount <= 1 || m.db == nil {
+		return nil
+	}
+
+	key := poolShardKey(poolType, groupID)
+	v, _ := m.shards.LoadOrStore(key, &poolShardState{poolType: poolType, groupID: groupID})
+	state := v.(*poolShardState)
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if time.Since(state.refreshed) >= poolShardStatsTTL || len(state.shards) != shardCount {
+		if err := state.refresh(m.ctx, m, shardCount); err != nil {
+			log.Warn().Err(err).Str("type", poolType).Int("group", groupID).Msg("Failed to count pool rows for sharding")
+			if state.refreshed.IsZero() {
+				return nil
+			}
+		}
+	}
+	if state.rows < int64(threshold) {
+		return nil
+	}
+	return state
+}
+
+// refresh 重新统计行数和 id 范围，id 范围变化时重建分片（游标仍在新范围内的保留）
+func (s *poolShardState) refresh(ctx context.Context, m *PoolManager, shardCount int) error {
+	var stats struct {
+		Rows  int64 `db:"cnt"`
+		MinID int64 `db:"min_id"`
+		MaxID int64 `db:"max_id"`
+	}
+	query := fmt.Sprintf(`SELECT COUNT(*) AS cnt, COALESCE(MIN(id), 0) AS min_id, COALESCE(MAX(id), 0) AS max_id
+		FROM %s WHERE group_id = ? AND status = 1`, s.poolType)
+	if err := m.db.GetContext(ctx, &stats, query, s.groupID); err != nil {
+		return err
+	}
+	s.refreshed = time.Now()
+	s.rows = stats.Rows
+	if stats.MinID == s.minID && stats.MaxID == s.maxID && len(s.shards) == shardCount {
+		return nil
+	}
+	s.minID, s.maxID = stats.MinID, stats.MaxID
+
+	old := s.shards
+	span := (stats.MaxID - stats.MinID + 1 + int64(shardCount) - 1) / int64(shardCount)
+	if span < 1 {
+		span = 1
+	}
+	s.shards = make([]poolShard, shardCount)
+	for i := range s.shards {
+		lo := stats.MinID - 1 + int64(i)*span
+		hi := lo + span
+		if i == shardCount-1 {
+			hi = math.MaxInt64 // 最后一个分片覆盖统计之后新写入的行
+		}
+		shard := poolShard{lo: lo, hi: hi, cursor: lo}
+		for _, prev := range old {
+			if prev.cursor > lo && prev.cursor < hi {
+				shard.cursor = prev.cursor
+				break
+			}
+		}
+		s.shards[i] = shard
+	}
+	return nil
+}
+
+// fetchSharded 从各分片并发取数（每个分片取 need/分片数 条），结果按分片轮流交错
+func (m *PoolManager) fetchSharded(state *poolShardState, column string, need int) ([]PoolItem, error) {
+	workers := m.GroupSettings(state.poolType, state.groupID).Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	state.mu.Lock()
+	shards := append([]poolShard(nil), state.shards...)
+	state.mu.Unlock()
+	if len(shards) == 0 {
+		return nil, nil
+	}
+	per := (need + len(shards) - 1) / len(shards)
+
+	query := refillSelect(state.poolType, column) + `
+		WHERE t.group_id = ? AND t.status = 1 AND t.id > ? AND t.id <= ?
+		ORDER BY t.id ASC
+		LIMIT ?`
+
+	results := make([][]PoolItem, len(shards))
+	errs := make([]error, len(shards))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i := range shards {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = m.db.SelectContext(m.ctx, &results[i], query, state.groupID, shards[i].cursor, shards[i].hi, per)
+		}(i)
+	}
+	wg.Wait()
+
+	// 更新游标：取到数据时前进到最后一个 id，不足 per 条说明分片已取完，回到起点
+	state.mu.Lock()
+	if len(state.shards) == len(shards) {
+		for i, items := range results {
+			if errs[i] != nil || state.shards[i].lo != shards[i].lo {
+				continue
+			}
+			if len(items) < per {
+				state.shards[i].cursor = state.shards[i].lo
+			} else {
+				state.shards[i].cursor = items[len(items)-1].ID
+			}
+		}
+	}
+	state.mu.Unlock()
+
+	var firstErr error
+	total := 0
+	for i, items := range results {
+		if errs[i] != nil && firstErr == nil {
+			firstErr = errs[i]
+		}
+		total += len(items)
+	}
+	if total == 0 {
+		return nil, firstErr
+	}
+
+	merged := make([]PoolItem, 0, total)
+	for j := 0; len(merged) < total; j++ {
+		for _, items := range results {
+			if j < len(items) {
+				merged = append(merged, items[j])
+			}
+		}
+	}
+	return merged, nil
+}
+
+// refillSelect 补充查询的 SELECT ... FROM 部分（表别名 t）
+// 正文同时取来源文章已存储的摘要，页面渲染时不必再从正文生成
+func refillSelect(poolType, column string) string {
+	if poolType == "contents" {
+		return `
+		SELECT t.id, t.content AS text, COALESCE(a.summary, '') AS summary
+		FROM contents t LEFT JOIN original_articles a ON a.id = t.article_id`
+	}
+	return fmt.Sprintf(`
+		SELECT t.id, t.%s AS text FROM %s t`, column, poolType)
+}
+
+// fetchRefillItems 取补充数据：大分组按分片取数，其余按批次优先取数
+func (m *PoolManager) fetchRefillItems(poolType, column string, groupID, need int) ([]PoolItem, error) {
+	if state := m.shardState(poolType, groupID); state != nil {
+		return m.fetchSharded(state, column, need)
+	}
+
+	query := refillSelect(poolType, column) + `
+		WHERE t.group_id = ? AND t.status = 1
+		ORDER BY t.batch_id DESC, t.id ASC
+		LIMIT ?`
+
+	var items []PoolItem
+	err := m.db.SelectContext(m.ctx, &items, query, groupID, need)
+	return items, err
+}
+
+// GetShardStats 当前启用分片补充的分组
+func (m *PoolManager) GetShardStats() []PoolShardStats {
+	m.mu.RLock()
+	threshold := int64(m.config.ContentShardThreshold)
+	m.mu.RUnlock()
+	stats := []PoolShardStats{}
+	m.shards.Range(func(_, value interface{}) bool {
+		state := value.(*poolShardState)
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		if threshold <= 0 || state.rows < threshold {
+			return true
+		}
+		item := PoolShardStats{
+			PoolType: state.poolType,
+			GroupID:  state.groupID,
+			Rows:     state.rows,
+			MinID:    state.minID,
+			MaxID:    state.maxID,
+			Shards:   len(state.shards),
+			Cursors:  make([]int64, 0, len(state.shards)),
+		}
+		for _, shard := range state.shards {
+			item.Cursors = append(item.Cursors, shard.cursor)
+		}
+		stats = append(stats, item)
+		return true
+	})
+	return stats
+}
@@ -0,0 +1,276 @@
+// Package core provides deterministic simulated publish dates for generated pages
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/internal/model"
+	"seo-generator/api/pkg/config"
+)
+
+// 发布日期的时间分布曲线
+const (
+	RecencyUniform     = "uniform"     // 范围内均匀分布
+	RecencyLinear      = "linear"      // 越近越多，概率随天数线性下降
+	RecencyExponential = "exponential" // 越近越多，按半衰期指数下降
+)
+
+// ValidRecencyCurve 校验分布曲线名
+func ValidRecencyCurve(name string) bool {
+	return name == RecencyUniform || name == RecencyLinear || name == RecencyExponential
+}
+
+// publishDatePathPattern URL 中的日期段（日期路径 URL 策略生成的 /20240101/123.html）
+var publishDatePathPattern = regexp.MustCompile(`(?:^|[/?])(20\d{6})/`)
+
+// PublishDatePolicy 站群发布日期分布
+type PublishDatePolicy struct {
+	SiteGroupID    int        `db:"site_group_id" json:"site_group_id"`
+	RangeDays      int        `db:"range_days" json:"range_days"`           // 日期分布在基准日之前多少天内
+	WeekdayWeights string     `db:"weekday_weights" json:"weekday_weights"` // 周一到周日的权重，逗号分隔
+	Recency        string     `db:"recency" json:"recency"`                 // uniform / linear / exponential
+	HalfLifeDays   int        `db:"half_life_days" json:"half_life_days"`   // exponential 曲线的半衰期
+	HourStart      int        `db:"hour_start" json:"hour_start"`           // 发布时间段 [hour_start, hour_end)
+	HourEnd        int        `db:"hour_end" json:"hour_end"`
+	AnchorDate     *time.Time `db:"anchor_date" json:"anchor_date"` // 基准日，NULL=策略更新日与站点创建日中较晚者
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+	IsDefault      bool       `db:"-" json:"is_default"`
+
+	weights [7]float64 // 解析后的权重，下标为 time.Weekday（周日=0）
+}
+
+// ParseWeekdayWeights 解析周一到周日的权重，少于 7 个时缺省为 1
+func ParseWeekdayWeights(s string) ([7]float64, error) {
+	w := [7]float64{1, 1, 1, 1, 1, 1, 1}
+	if strings.TrimSpace(s) == "" {
+		return w, nil
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) > 7 {
+		return w, fmt.Errorf("at most 7 weights (Monday..Sunday)")
+	}
+	positive := false
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || v < 0 {
+			return w, fmt.Errorf("invalid weight %q", p)
+		}
+		w[(i+1)%7] = v
+		positive = positive || v > 0
+	}
+	if !positive && len(parts) == 7 {
+		return w, fmt.Errorf("at least one weight must be positive")
+	}
+	return w, nil
+}
+
+// normalize 校验并补全策略字段
+func (p *PublishDatePolicy) normalize(def config.PublishDateConfig) {
+	if p.RangeDays <= 0 {
+		p.RangeDays = def.RangeDays
+	}
+	if !ValidRecencyCurve(p.Recency) {
+		p.Recency = def.Recency
+	}
+	if p.HalfLifeDays <= 0 {
+		p.HalfLifeDays = def.HalfLifeDays
+	}
+	if p.HourStart < 0 || p.HourEnd > 24 || p.HourStart >= p.HourEnd {
+		p.HourStart, p.HourEnd = def.HourStart, def.HourEnd
+	}
+	weights, err := ParseWeekdayWeights(p.WeekdayWeights)
+	if err != nil {
+		log.Warn().Err(err).Int("site_group_id", p.SiteGroupID).Msg("Invalid weekday weights, using equal weights")
+		weights, _ = ParseWeekdayWeights("")
+	}
+	p.weights = weights
+}
+
+// PublishDates 模拟发布日期
+//
+// 每个 URL 的发布日期由（域名, 路径）哈希确定，同一 URL 重新渲染日期不变：
+// 先按分布曲线取距基准日的天数，再按星期权重做确定性的接受/拒绝抽样，最后在发布时间段内取时分秒。
+// URL 本身带日期段（日期路径 URL 策略）时直接使用该日期，保证页面日期与 URL 一致
+type PublishDates struct {
+	db  *sqlx.DB
+	cfg config.PublishDateConfig
+
+	policies atomic.Pointer[map[int]*PublishDatePolicy]
+}
+
+// NewPublishDates 创建发布日期分布
+func NewPublishDates(db *sqlx.DB, cfg config.PublishDateConfig) *PublishDates {
+	if cfg.RangeDays <= 0 {
+		cfg.RangeDays = 365
+	}
+	if !ValidRecencyCurve(cfg.Recency) {
+		cfg.Recency = RecencyExponential
+	}
+	if cfg.HalfLifeDays <= 0 {
+		cfg.HalfLifeDays = 60
+	}
+	if cfg.HourStart < 0 || cfg.HourEnd > 24 || cfg.HourStart >= cfg.HourEnd {
+		cfg.HourStart, cfg.HourEnd = 8, 23
+	}
+	d := &PublishDates{db: db, cfg: cfg}
+	empty := map[int]*PublishDatePolicy{}
+	d.policies.Store(&empty)
+	return d
+}
+
+// Reload 从数据库重新加载站群策略
+func (d *PublishDates) Reload(ctx context.Context) error {
+	var rows []*PublishDatePolicy
+	if err := d.db.SelectContext(ctx, &rows, `
+		SELECT site_group_id, range_days, weekday_weights, recency, half_life_days, hour_start, hour_end, anchor_date, updated_at
+		FROM publish_date_policies`); err != nil {
+		return fmt.Errorf("load publish date policies: %w", err)
+	}
+	policies := make(map[int]*PublishDatePolicy, len(rows))
+	for _, p := range rows {
+		p.normalize(d.cfg)
+		policies[p.SiteGroupID] = p
+	}
+	d.policies.Store(&policies)
+	log.Info().Int("site_groups", len(policies)).Msg("Publish date policies loaded")
+	return nil
+}
+
+// Policy 返回站群生效的策略（未配置时返回全局默认）
+func (d *PublishDates) Policy(siteGroupID int) *PublishDatePolicy {
+	if p, ok := (*d.policies.Load())[siteGroupID]; ok {
+		return p
+	}
+	p := &PublishDatePolicy{
+		SiteGroupID:    siteGroupID,
+		RangeDays:      d.cfg.RangeDays,
+		WeekdayWeights: d.cfg.WeekdayWeights,
+		Recency:        d.cfg.Recency,
+		HalfLifeDays:   d.cfg.HalfLifeDays,
+		HourStart:      d.cfg.HourStart,
+		HourEnd:        d.cfg.HourEnd,
+		IsDefault:      true,
+	}
+	p.normalize(d.cfg)
+	return p
+}
+
+// Date 返回页面的模拟发布时间
+func (d *PublishDates) Date(site *models.Site, path string) time.Time {
+	p := d.Policy(site.SiteGroupID)
+	seed := splitmix64(domainHash(site.Domain) ^ domainHash(path))
+	day, ok := pathDate(path)
+	if !ok {
+		day = p.day(d.anchor(site, p), seed)
+	}
+	return p.timeOfDay(day, splitmix64(seed+1))
+}
+
+// anchor 基准日为参考日的前一天（页面日期不会落在参考日当天之后）
+// 参考日：显式配置 > 策略更新日与站点创建日中较晚者，且不晚于今天
+func (d *PublishDates) anchor(site *models.Site, p *PublishDatePolicy) time.Time {
+	var anchor time.Time
+	if p.AnchorDate != nil {
+		anchor = *p.AnchorDate
+	} else {
+		anchor = site.CreatedAt
+		if p.UpdatedAt.After(anchor) {
+			anchor = p.UpdatedAt
+		}
+	}
+	if now := time.Now(); anchor.IsZero() || anchor.After(now) {
+		anchor = now
+	}
+	return time.Date(anchor.Year(), anchor.Month(), anchor.Day()-1, 0, 0, 0, 0, time.Local)
+}
+
+// day 按曲线和星期权重取日期，最多尝试 8 次，都被拒绝时使用最后一次的结果
+func (p *PublishDatePolicy) day(anchor time.Time, seed uint64) time.Time {
+	maxWeight := 0.0
+	for _, w := range p.weights {
+		maxWeight = max(maxWeight, w)
+	}
+	var day time.Time
+	for i := 0; i < 8; i++ {
+		seed = splitmix64(seed)
+		day = anchor.AddDate(0, 0, -p.age(unitFloat(seed)))
+		if unitFloat(splitmix64(seed^0xa5a5a5a5))*maxWeight < p.weights[day.Weekday()] {
+			break
+		}
+	}
+	return day
+}
+
+// age 按分布曲线把 [0,1) 均匀值映射为距基准日的天数
+func (p *PublishDatePolicy) age(u float64) int {
+	r := float64(p.RangeDays)
+	var a float64
+	switch p.Recency {
+	case RecencyLinear:
+		// 密度 ∝ (R - a)，反函数 a = R(1 - √(1-u))
+		a = r * (1 - math.Sqrt(1-u))
+	case RecencyExponential:
+		// 截断在 [0, R) 的指数分布
+		lambda := math.Ln2 / float64(p.HalfLifeDays)
+		a = -math.Log(1-u*(1-math.Exp(-lambda*r))) / lambda
+	default:
+		a = u * r
+	}
+	return min(int(a), p.RangeDays-1)
+}
+
+// timeOfDay 在发布时间段内取时分秒
+// 只有 URL 日期段是今天时才可能晚于当前时间，此时取当天零点
+func (p *PublishDatePolicy) timeOfDay(day time.Time, seed uint64) time.Time {
+	seconds := uint64(p.HourEnd-p.HourStart) * 3600
+	t := day.Add(time.Duration(p.HourStart)*time.Hour + time.Duration(seed%seconds)*time.Second)
+	if t.After(time.Now()) {
+		return day
+	}
+	return t
+}
+
+// pathDate 从 URL 中解析日期段
+func pathDate(path string) (time.Time, bool) {
+	m := publishDatePathPattern.FindStringSubmatch(path)
+	if m == nil {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("20060102", m[1], time.Local)
+	if err != nil || t.After(time.Now()) {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// unitFloat 把 64 位种子映射为 [0,1)
+func unitFloat(x uint64) float64 {
+	return float64(x>>11) / (1 << 53)
+}
+
+// strftimeLayout strftime 格式到 Go 时间格式
+var strftimeLayout = strings.NewReplacer(
+	"%Y", "2006", "%m", "01", "%d", "02", "%H", "15", "%M", "04", "%S", "05",
+	"%y", "06", "%b", "Jan", "%a", "Mon", "%%", "%",
+)
+
+// DateLayout 模板中的日期格式：支持 Go 格式（2006-01-02）和 strftime（%Y-%m-%d），为空时使用默认格式
+func DateLayout(format string) string {
+	if format == "" {
+		return "2006-01-02 15:04:05"
+	}
+	if strings.Contains(format, "%") {
+		return strftimeLayout.Replace(format)
+	}
+	return format
+}
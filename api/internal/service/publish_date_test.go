@@ -0,0 +1,135 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"seo-generator/api/internal/model"
+	"seo-generator/api/pkg/config"
+)
+
+func TestParseWeekdayWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    [7]float64 // 下标为 time.Weekday（周日=0）
+		wantErr bool
+	}{
+		{"empty", "", [7]float64{1, 1, 1, 1, 1, 1, 1}, false},
+		{"full week", "1,1,1,1,1,0.4,0.3", [7]float64{0.3, 1, 1, 1, 1, 1, 0.4}, false},
+		{"partial defaults to 1", "2, 3", [7]float64{1, 2, 3, 1, 1, 1, 1}, false},
+		{"too many", "1,1,1,1,1,1,1,1", [7]float64{}, true},
+		{"negative", "1,-1", [7]float64{}, true},
+		{"not a number", "1,x", [7]float64{}, true},
+		{"all zero", "0,0,0,0,0,0,0", [7]float64{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWeekdayWeights(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("weights = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateLayout(t *testing.T) {
+	tests := []struct {
+		format, want string
+	}{
+		{"", "2006-01-02 15:04:05"},
+		{"2006/01/02", "2006/01/02"},
+		{"%Y-%m-%d", "2006-01-02"},
+		{"%Y年%m月%d日 %H:%M", "2006年01月02日 15:04"},
+		{"100%%", "100%"},
+	}
+	for _, tt := range tests {
+		if got := DateLayout(tt.format); got != tt.want {
+			t.Errorf("DateLayout(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestPathDate(t *testing.T) {
+	tests := []struct {
+		path string
+		want string // 空表示没有日期段
+	}{
+		{"/20240101/123.html", "2024-01-01"},
+		{"/news/20230615/abc.html", "2023-06-15"},
+		{"/page?x=1", ""},
+		{"/20241301/1.html", ""}, // 非法月份
+		{"/x20240101/1.html", ""},
+		{"/29991231/1.html", ""}, // 未来日期
+	}
+	for _, tt := range tests {
+		got, ok := pathDate(tt.path)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("pathDate(%q) = %v, want none", tt.path, got)
+			}
+			continue
+		}
+		if !ok || got.Format("2006-01-02") != tt.want {
+			t.Errorf("pathDate(%q) = (%v, %v), want %s", tt.path, got, ok, tt.want)
+		}
+	}
+}
+
+func TestPublishDates_Date(t *testing.T) {
+	created := time.Now().AddDate(0, -1, 0)
+	site := &models.Site{Domain: "example.com", SiteGroupID: 1, CreatedAt: created}
+	anchor := time.Date(created.Year(), created.Month(), created.Day()-1, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name string
+		cfg  config.PublishDateConfig
+	}{
+		{"uniform", config.PublishDateConfig{RangeDays: 30, Recency: RecencyUniform, HourStart: 9, HourEnd: 18}},
+		{"linear", config.PublishDateConfig{RangeDays: 90, Recency: RecencyLinear, HourStart: 8, HourEnd: 23}},
+		{"exponential", config.PublishDateConfig{RangeDays: 365, Recency: RecencyExponential, HalfLifeDays: 30}},
+		{"weekdays only", config.PublishDateConfig{RangeDays: 60, Recency: RecencyUniform, WeekdayWeights: "1,1,1,1,1,0,0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewPublishDates(nil, tt.cfg)
+			p := d.Policy(site.SiteGroupID)
+			weekend := 0
+			for i := 0; i < 200; i++ {
+				path := fmt.Sprintf("/article/%d.html", i)
+				got := d.Date(site, path)
+				if again := d.Date(site, path); !again.Equal(got) {
+					t.Fatalf("Date(%q) not deterministic: %v vs %v", path, got, again)
+				}
+				day := time.Date(got.Year(), got.Month(), got.Day(), 0, 0, 0, 0, time.Local)
+				if day.After(anchor) || day.Before(anchor.AddDate(0, 0, -p.RangeDays)) {
+					t.Errorf("Date(%q) = %v, out of range before %v", path, got, anchor)
+				}
+				if h := got.Hour(); h < p.HourStart || h >= p.HourEnd {
+					t.Errorf("Date(%q) hour = %d, want [%d, %d)", path, h, p.HourStart, p.HourEnd)
+				}
+				if wd := got.Weekday(); wd == time.Saturday || wd == time.Sunday {
+					weekend++
+				}
+			}
+			// 权重为 0 的星期只在 8 次抽样都被拒绝时出现
+			if tt.cfg.WeekdayWeights != "" && weekend > 5 {
+				t.Errorf("weekend dates = %d, want almost none", weekend)
+			}
+		})
+	}
+}
+
+// TestPublishDates_PathDate URL 带日期段时直接使用该日期
+func TestPublishDates_PathDate(t *testing.T) {
+	d := NewPublishDates(nil, config.PublishDateConfig{})
+	site := &models.Site{Domain: "example.com"}
+	got := d.Date(site, "/20240305/1.html")
+	if got.Format("2006-01-02") != "2024-03-05" {
+		t.Errorf("Date = %v, want 2024-03-05", got)
+	}
+}
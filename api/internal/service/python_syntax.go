@@ -0,0 +1,132 @@
+// Package core provides the Python syntax check run when spider project files are saved
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"seo-generator/api/pkg/config"
+)
+
+// 语法检查模式
+const (
+	PythonCheckOff   = "off"
+	PythonCheckWarn  = "warn"
+	PythonCheckBlock = "block"
+)
+
+// 语法检查结果状态（保存响应中的 syntax_check）
+const (
+	PythonCheckStatusOK          = "ok"
+	PythonCheckStatusErrors      = "errors"
+	PythonCheckStatusSkipped     = "skipped"
+	PythonCheckStatusUnavailable = "unavailable"
+)
+
+// pythonCheckConcurrency 同时运行的检查进程数
+const pythonCheckConcurrency = 4
+
+// pythonCheckScript 只调用 compile()（不执行代码），以 JSON 输出 SyntaxError（含 IndentationError / TabError）
+const pythonCheckScript = `import sys, json
+src = sys.stdin.buffer.read()
+try:
+    compile(src, sys.argv[1], "exec", dont_inherit=True)
+    print("[]")
+except SyntaxError as e:
+    print(json.dumps([{"line": e.lineno or 0, "column": e.offset or 0, "message": e.msg or str(e), "text": (e.text or "").rstrip()}]))
+except ValueError as e:
+    print(json.dumps([{"line": 0, "column": 0, "message": str(e), "text": ""}]))
+`
+
+// ErrPythonCheckUnavailable 解释器无法启动、超时或输出无法解析
+var ErrPythonCheckUnavailable = errors.New("python syntax check unavailable")
+
+// PythonSyntaxError 语法错误位置（行号、列号从 1 开始，未知时为 0）
+type PythonSyntaxError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+	Text    string `json:"text,omitempty"` // 出错的源码行
+}
+
+// PythonSyntaxChecker 通过外部解释器检查 Python 语法
+type PythonSyntaxChecker struct {
+	command []string
+	mode    string
+	timeout time.Duration
+	sem     chan struct{}
+}
+
+// NewPythonSyntaxChecker 创建 PythonSyntaxChecker，未启用或未配置命令时返回 nil
+func NewPythonSyntaxChecker(cfg config.PythonCheckConfig) *PythonSyntaxChecker {
+	if !cfg.Enabled || len(cfg.Command) == 0 {
+		return nil
+	}
+	if cfg.Mode != PythonCheckBlock {
+		cfg.Mode = PythonCheckWarn
+	}
+	if cfg.TimeoutSeconds <= 0 {
+		cfg.TimeoutSeconds = 5
+	}
+	return &PythonSyntaxChecker{
+		command: cfg.Command,
+		mode:    cfg.Mode,
+		timeout: time.Duration(cfg.TimeoutSeconds) * time.Second,
+		sem:     make(chan struct{}, pythonCheckConcurrency),
+	}
+}
+
+// Mode 默认检查模式（checker 为 nil 时为 off）
+func (p *PythonSyntaxChecker) Mode() string {
+	if p == nil {
+		return PythonCheckOff
+	}
+	return p.mode
+}
+
+// Applies 是否需要检查该文件（仅 .py）
+func (p *PythonSyntaxChecker) Applies(filename string) bool {
+	return p != nil && strings.EqualFold(path.Ext(filename), ".py")
+}
+
+// Check 检查源码，返回语法错误列表（为空表示通过）
+func (p *PythonSyntaxChecker) Check(ctx context.Context, filename, source string) ([]PythonSyntaxError, error) {
+	if p == nil {
+		return nil, ErrPythonCheckUnavailable
+	}
+	select {
+	case p.sem <- struct{}{}:
+		defer func() { <-p.sem }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	args := append(append([]string{}, p.command[1:]...), "-I", "-S", "-c", pythonCheckScript, path.Base(filename))
+	cmd := exec.CommandContext(ctx, p.command[0], args...)
+	cmd.Stdin = strings.NewReader(source)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %v: %s", ErrPythonCheckUnavailable, err, truncateRunes(msg, 200))
+		}
+		return nil, fmt.Errorf("%w: %v", ErrPythonCheckUnavailable, err)
+	}
+
+	errs := []PythonSyntaxError{}
+	if err := json.Unmarshal(bytes.TrimSpace(out), &errs); err != nil {
+		return nil, fmt.Errorf("%w: unexpected output", ErrPythonCheckUnavailable)
+	}
+	return errs, nil
+}
@@ -0,0 +1,360 @@
+// Package core provides a statement-level cache for hot admin list queries
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// queryCacheChannel 表数据变更后通知其他实例失效本机缓存
+const queryCacheChannel = "query_cache:invalidate"
+
+// queryTablePattern 语句引用的表（FROM / JOIN 之后的表名）
+var queryTablePattern = regexp.MustCompile("(?i)\\b(?:FROM|JOIN)\\s+`?([a-z0-9_]+)`?")
+
+var globalQueryCache atomic.Pointer[QueryCache]
+
+type queryCacheEntry struct {
+	data      []byte
+	tables    []string
+	statement string
+	createdAt time.Time
+	expiresAt time.Time
+}
+
+type queryStatementStats struct {
+	hits, misses atomic.Int64
+}
+
+// QueryStatementStats 单条语句（不含参数）的命中统计
+type QueryStatementStats struct {
+	Statement string  `json:"statement"`
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	HitRate   float64 `json:"hit_rate"`
+}
+
+// QueryCache 后台热点列表查询缓存
+// 按语句 + 参数缓存查询结果（JSON 序列化后保存，每次命中反序列化为新对象，调用方修改结果不影响缓存），
+// 语句引用的表发生写入时由写操作调用 Invalidate 按表失效，并通过 Redis 通知其他实例；
+// ttl_seconds 为兜底过期时间，覆盖直接改库等没有经过失效通知的写入。
+// 缓存只用于变化少、读取频繁的列表（站群、分组、模板选项、站点列表），查询始终走主库，
+// 避免失效后从延迟的副本读到旧数据再次缓存
+type QueryCache struct {
+	config   config.QueryCacheConfig
+	redis    *redis.Client
+	instance string
+
+	mu         sync.RWMutex
+	entries    map[string]*queryCacheEntry
+	generation uint64 // 每次失效递增，查询期间发生失效时结果不写入缓存
+
+	statements sync.Map // statement -> *queryStatementStats
+
+	hits          atomic.Int64
+	misses        atomic.Int64
+	invalidations atomic.Int64
+	remote        atomic.Int64
+	expired       atomic.Int64
+	evicted       atomic.Int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// InitQueryCache 创建查询缓存并设为全局实例，未启用时返回 nil（GetQueryCache 返回 nil，查询直接访问数据库）
+func InitQueryCache(cfg config.QueryCacheConfig, rdb *redis.Client) *QueryCache {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.TTLSeconds <= 0 {
+		cfg.TTLSeconds = 60
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 2000
+	}
+	host, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	qc := &QueryCache{
+		config:   cfg,
+		redis:    rdb,
+		instance: fmt.Sprintf("%s-%d", host, os.Getpid()),
+		entries:  make(map[string]*queryCacheEntry),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	globalQueryCache.Store(qc)
+	return qc
+}
+
+// GetQueryCache 全局查询缓存，未启用时为 nil（方法对 nil 直接查询数据库）
+func GetQueryCache() *QueryCache {
+	return globalQueryCache.Load()
+}
+
+// Start 启用 Redis 时订阅其他实例的失效通知
+func (q *QueryCache) Start() {
+	if q.redis != nil {
+		q.wg.Add(1)
+		go q.listen()
+	}
+	log.Info().
+		Int("ttl_seconds", q.config.TTLSeconds).
+		Int("max_entries", q.config.MaxEntries).
+		Bool("redis", q.redis != nil).
+		Msg("Query cache started")
+}
+
+// Stop 停止订阅
+func (q *QueryCache) Stop() {
+	q.cancel()
+	q.wg.Wait()
+}
+
+// Select 带缓存的 SelectContext，dest 为切片指针
+func (q *QueryCache) Select(ctx context.Context, db *sqlx.DB, dest interface{}, query string, args ...interface{}) error {
+	if q == nil {
+		return db.SelectContext(ctx, dest, query, args...)
+	}
+	return q.load(dest, query, args, func() error {
+		return db.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+// Get 带缓存的 GetContext（查询出错，包括没有结果时不缓存）
+func (q *QueryCache) Get(ctx context.Context, db *sqlx.DB, dest interface{}, query string, args ...interface{}) error {
+	if q == nil {
+		return db.GetContext(ctx, dest, query, args...)
+	}
+	return q.load(dest, query, args, func() error {
+		return db.GetContext(ctx, dest, query, args...)
+	})
+}
+
+func (q *QueryCache) load(dest interface{}, query string, args []interface{}, fetch func() error) error {
+	statement := strings.Join(strings.Fields(query), " ")
+	key := statement + "|" + fmt.Sprintf("%#v", args)
+	stats := q.statementStats(statement)
+
+	now := time.Now()
+	q.mu.RLock()
+	entry := q.entries[key]
+	gen := q.generation
+	q.mu.RUnlock()
+	if entry != nil {
+		if now.Before(entry.expiresAt) {
+			if err := json.Unmarshal(entry.data, dest); err == nil {
+				q.hits.Add(1)
+				stats.hits.Add(1)
+				return nil
+			}
+		} else {
+			q.expired.Add(1)
+		}
+	}
+
+	q.misses.Add(1)
+	stats.misses.Add(1)
+	if err := fetch(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(dest)
+	if err != nil {
+		return nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.generation != gen {
+		return nil
+	}
+	if _, ok := q.entries[key]; !ok && len(q.entries) >= q.config.MaxEntries {
+		q.evictLocked(now)
+	}
+	q.entries[key] = &queryCacheEntry{
+		data:      data,
+		tables:    queryTables(query),
+		statement: statement,
+		createdAt: now,
+		expiresAt: now.Add(time.Duration(q.config.TTLSeconds) * time.Second),
+	}
+	return nil
+}
+
+// evictLocked 先清理过期条目，仍然满时淘汰最早写入的条目
+func (q *QueryCache) evictLocked(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for k, e := range q.entries {
+		if !now.Before(e.expiresAt) {
+			delete(q.entries, k)
+			q.expired.Add(1)
+			continue
+		}
+		if oldestKey == "" || e.createdAt.Before(oldest) {
+			oldestKey, oldest = k, e.createdAt
+		}
+	}
+	if len(q.entries) >= q.config.MaxEntries && oldestKey != "" {
+		delete(q.entries, oldestKey)
+		q.evicted.Add(1)
+	}
+}
+
+func (q *QueryCache) statementStats(statement string) *queryStatementStats {
+	if v, ok := q.statements.Load(statement); ok {
+		return v.(*queryStatementStats)
+	}
+	v, _ := q.statements.LoadOrStore(statement, &queryStatementStats{})
+	return v.(*queryStatementStats)
+}
+
+// queryTables 语句引用的表名（小写，去重）
+func queryTables(query string) []string {
+	seen := map[string]bool{}
+	tables := []string{}
+	for _, m := range queryTablePattern.FindAllStringSubmatch(query, -1) {
+		t := strings.ToLower(m[1])
+		if !seen[t] {
+			seen[t] = true
+			tables = append(tables, t)
+		}
+	}
+	return tables
+}
+
+// Invalidate 表数据变更后失效引用这些表的缓存，并通知其他实例
+func (q *QueryCache) Invalidate(tables ...string) {
+	if q == nil || len(tables) == 0 {
+		return
+	}
+	q.invalidateLocal(tables)
+	if q.redis != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		payload := q.instance + "|" + strings.Join(tables, ",")
+		if err := q.redis.Publish(ctx, queryCacheChannel, payload).Err(); err != nil {
+			log.Warn().Err(err).Strs("tables", tables).Msg("Failed to publish query cache invalidation")
+		}
+	}
+}
+
+// Clear 清空全部缓存（同时通知其他实例）
+func (q *QueryCache) Clear() {
+	q.Invalidate("*")
+}
+
+func (q *QueryCache) invalidateLocal(tables []string) int {
+	drop := map[string]bool{}
+	for _, t := range tables {
+		drop[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.generation++
+	removed := 0
+	for k, e := range q.entries {
+		if drop["*"] {
+			delete(q.entries, k)
+			removed++
+			continue
+		}
+		for _, t := range e.tables {
+			if drop[t] {
+				delete(q.entries, k)
+				removed++
+				break
+			}
+		}
+	}
+	q.invalidations.Add(1)
+	return removed
+}
+
+func (q *QueryCache) listen() {
+	defer q.wg.Done()
+	for q.ctx.Err() == nil {
+		sub := q.redis.Subscribe(q.ctx, queryCacheChannel)
+		ch := sub.Channel()
+	loop:
+		for {
+			select {
+			case <-q.ctx.Done():
+				sub.Close()
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					break loop
+				}
+				from, tables, _ := strings.Cut(msg.Payload, "|")
+				if from == q.instance || tables == "" {
+					continue
+				}
+				q.remote.Add(1)
+				q.invalidateLocal(strings.Split(tables, ","))
+			}
+		}
+		sub.Close()
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// Stats 命中率和按语句的命中统计（按访问次数降序）
+func (q *QueryCache) Stats() map[string]interface{} {
+	q.mu.RLock()
+	entries := len(q.entries)
+	q.mu.RUnlock()
+
+	statements := []QueryStatementStats{}
+	q.statements.Range(func(key, value interface{}) bool {
+		s := value.(*queryStatementStats)
+		item := QueryStatementStats{Statement: key.(string), Hits: s.hits.Load(), Misses: s.misses.Load()}
+		item.HitRate = hitRate(item.Hits, item.Misses)
+		statements = append(statements, item)
+		return true
+	})
+	sort.Slice(statements, func(i, j int) bool {
+		return statements[i].Hits+statements[i].Misses > statements[j].Hits+statements[j].Misses
+	})
+
+	hits, misses := q.hits.Load(), q.misses.Load()
+	return map[string]interface{}{
+		"entries":              entries,
+		"max_entries":          q.config.MaxEntries,
+		"ttl_seconds":          q.config.TTLSeconds,
+		"hits":                 hits,
+		"misses":               misses,
+		"hit_rate":             hitRate(hits, misses),
+		"invalidations":        q.invalidations.Load(),
+		"remote_invalidations": q.remote.Load(),
+		"expired":              q.expired.Load(),
+		"evicted":              q.evicted.Load(),
+		"statements":           statements,
+	}
+}
+
+func hitRate(hits, misses int64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
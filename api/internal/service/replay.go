@@ -0,0 +1,510 @@
+// Package core provides end-to-end request replay from access logs or spider logs
+package core
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 回放请求来源
+const (
+	ReplaySourceAccessLog  = "access_log"
+	ReplaySourceSpiderLogs = "spider_logs"
+)
+
+const (
+	replayMaxRequests = 10000
+	replayMaxDiffs    = 100
+	replayMaxBody     = 8 << 20
+)
+
+// 访问日志中的管理接口、实时推送等非页面请求不回放（页面请求记录的是 Nginx 传入的 path 参数）
+var (
+	replaySkipPrefixes = []string{"/api/", "/ws/", "/sse/"}
+	replaySkipPaths    = []string{"/page", "/health", "/stats", "/metrics"}
+)
+
+// ReplayParams 回放参数
+type ReplayParams struct {
+	Source      string    `json:"source"` // access_log / spider_logs
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Domain      string    `json:"domain"`      // 为空时不限
+	SpiderOnly  bool      `json:"spider_only"` // 只回放蜘蛛请求（spider_logs 恒为蜘蛛请求）
+	Limit       int       `json:"limit"`
+	TargetURL   string    `json:"target_url"`   // 待验证实例的 API 地址，如 http://10.0.0.2:8080
+	BaselineURL string    `json:"baseline_url"` // 对照实例，为空时与日志中记录的状态码和耗时对比
+	Rate        float64   `json:"rate"`         // 每秒请求数
+	Concurrency int       `json:"concurrency"`
+	Timeout     int       `json:"timeout"` // 单个请求超时（秒）
+}
+
+// ReplayRequest 一个待回放的请求
+type ReplayRequest struct {
+	Domain    string  `json:"domain"`
+	Path      string  `json:"path"`
+	UserAgent string  `json:"user_agent"`
+	ClientIP  string  `json:"client_ip"`
+	Referer   string  `json:"referer,omitempty"`
+	Status    int     `json:"status"`     // 日志记录的状态码
+	LatencyMs float64 `json:"latency_ms"` // 日志记录的耗时
+}
+
+// ReplayLatency 耗时统计（毫秒）
+type ReplayLatency struct {
+	Avg float64 `json:"avg"`
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// ReplayDiff 一个结果不一致的请求
+type ReplayDiff struct {
+	Domain         string  `json:"domain"`
+	Path           string  `json:"path"`
+	UserAgent      string  `json:"user_agent"`
+	BaselineStatus int     `json:"baseline_status"`
+	TargetStatus   int     `json:"target_status"`
+	BaselineHash   string  `json:"baseline_hash,omitempty"`
+	TargetHash     string  `json:"target_hash,omitempty"`
+	BaselineMs     float64 `json:"baseline_ms"`
+	TargetMs       float64 `json:"target_ms"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// ReplayReport 回放对比报告
+// 基线为对照实例（baseline_url）或日志记录；只有对照实例回放时才比较内容哈希。
+// 未命中缓存的页面每次渲染内容不同，内容哈希一致性只对缓存命中的页面有意义
+type ReplayReport struct {
+	Source          string         `json:"source"`
+	Target          string         `json:"target"`
+	Baseline        string         `json:"baseline"` // 对照实例地址，或 "log"
+	Total           int            `json:"total"`
+	Completed       int            `json:"completed"`
+	Errors          int            `json:"errors"` // 请求目标实例失败（连接错误、超时）
+	StatusMatch     int            `json:"status_match"`
+	StatusMismatch  int            `json:"status_mismatch"`
+	HashCompared    bool           `json:"hash_compared"`
+	HashMatch       int            `json:"hash_match"`
+	HashMismatch    int            `json:"hash_mismatch"`
+	TargetStatus    map[string]int `json:"target_status"`
+	BaselineStatus  map[string]int `json:"baseline_status"`
+	TargetLatency   ReplayLatency  `json:"target_latency"`
+	BaselineLatency ReplayLatency  `json:"baseline_latency"`
+	Diffs           []ReplayDiff   `json:"diffs"` // 最多 100 条
+	DurationMs      int64          `json:"duration_ms"`
+}
+
+// replayResponse 一次请求的响应
+type replayResponse struct {
+	status    int
+	hash      string
+	latencyMs float64
+	err       error
+}
+
+// Replayer 请求回放
+// 从结构化访问日志或蜘蛛日志中取出一段时间的页面请求，按原始 UA、客户端 IP 和 Referer
+// 以指定速率回放到目标实例的 /page 接口（可同时回放到对照实例），生成状态码、耗时和内容哈希的对比报告，
+// 用于升级切换前验证新版本。以后台作业执行，报告保存在作业结果中
+type Replayer struct {
+	db            *sqlx.DB
+	jobs          *JobManager
+	accessLogPath string // 访问日志文件（access_log.output 为 file 时）
+}
+
+// NewReplayer 创建请求回放
+func NewReplayer(db *sqlx.DB, jobs *JobManager, accessLogPath string) *Replayer {
+	return &Replayer{db: db, jobs: jobs, accessLogPath: accessLogPath}
+}
+
+// Validate 校验并补全参数
+func (p *ReplayParams) Validate() error {
+	if p.Source == "" {
+		p.Source = ReplaySourceAccessLog
+	}
+	if p.Source != ReplaySourceAccessLog && p.Source != ReplaySourceSpiderLogs {
+		return fmt.Errorf("unknown source: %s", p.Source)
+	}
+	if err := validateReplayURL(p.TargetURL); err != nil {
+		return fmt.Errorf("target_url: %w", err)
+	}
+	if p.BaselineURL != "" {
+		if err := validateReplayURL(p.BaselineURL); err != nil {
+			return fmt.Errorf("baseline_url: %w", err)
+		}
+	}
+	p.TargetURL = strings.TrimRight(p.TargetURL, "/")
+	p.BaselineURL = strings.TrimRight(p.BaselineURL, "/")
+	if p.End.IsZero() {
+		p.End = time.Now()
+	}
+	if p.Start.IsZero() {
+		p.Start = p.End.Add(-time.Hour)
+	}
+	if !p.Start.Before(p.End) {
+		return errors.New("start must be before end")
+	}
+	if p.Limit <= 0 || p.Limit > replayMaxRequests {
+		p.Limit = 1000
+	}
+	if p.Rate <= 0 {
+		p.Rate = 10
+	}
+	p.Rate = min(p.Rate, 500)
+	if p.Concurrency <= 0 || p.Concurrency > 64 {
+		p.Concurrency = 8
+	}
+	if p.Timeout <= 0 || p.Timeout > 60 {
+		p.Timeout = 10
+	}
+	return nil
+}
+
+func validateReplayURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("must be an http(s) base url")
+	}
+	return nil
+}
+
+// Submit 作为后台作业执行回放
+func (r *Replayer) Submit(ctx context.Context, params ReplayParams) (int64, error) {
+	if r.jobs == nil {
+		return 0, fmt.Errorf("job manager not available")
+	}
+	if err := params.Validate(); err != nil {
+		return 0, err
+	}
+	return r.jobs.SubmitFunc(ctx, "replay", params, func(jc *JobContext) (any, error) {
+		return r.Run(jc, params, jc.SetTotal, jc.Advance)
+	})
+}
+
+// Load 读取待回放的请求
+func (r *Replayer) Load(ctx context.Context, params ReplayParams) ([]ReplayRequest, error) {
+	if params.Source == ReplaySourceSpiderLogs {
+		return r.loadSpiderLogs(ctx, params)
+	}
+	return r.loadAccessLog(params)
+}
+
+// loadSpiderLogs 从 spider_logs 读取
+func (r *Replayer) loadSpiderLogs(ctx context.Context, params ReplayParams) ([]ReplayRequest, error) {
+	query := `SELECT domain, path, ua, ip, status, resp_time FROM spider_logs
+		WHERE created_at >= ? AND created_at < ?`
+	args := []interface{}{params.Start, params.End}
+	if params.Domain != "" {
+		query += " AND domain = ?"
+		args = append(args, params.Domain)
+	}
+	query += " ORDER BY id LIMIT ?"
+	args = append(args, params.Limit)
+
+	var rows []struct {
+		Domain   string `db:"domain"`
+		Path     string `db:"path"`
+		UA       string `db:"ua"`
+		IP       string `db:"ip"`
+		Status   int    `db:"status"`
+		RespTime int    `db:"resp_time"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("load spider logs: %w", err)
+	}
+	requests := make([]ReplayRequest, 0, len(rows))
+	for _, row := range rows {
+		requests = append(requests, ReplayRequest{
+			Domain:    row.Domain,
+			Path:      row.Path,
+			UserAgent: row.UA,
+			ClientIP:  row.IP,
+			Status:    row.Status,
+			LatencyMs: float64(row.RespTime),
+		})
+	}
+	return requests, nil
+}
+
+// accessLogEntry 结构化访问日志中回放用到的字段
+type accessLogEntry struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	Domain    string    `json:"domain"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	LatencyMs float64   `json:"latency_ms"`
+	ClientIP  string    `json:"client_ip"`
+	Spider    string    `json:"spider"`
+	UserAgent string    `json:"user_agent"`
+	Referer   string    `json:"referer"`
+}
+
+// loadAccessLog 从访问日志文件读取（只读当前文件，不含已切分的备份）
+func (r *Replayer) loadAccessLog(params ReplayParams) ([]ReplayRequest, error) {
+	if r.accessLogPath == "" {
+		return nil, errors.New("access log file not configured (access_log.output must be file)")
+	}
+	f, err := os.Open(r.accessLogPath)
+	if err != nil {
+		return nil, fmt.Errorf("open access log: %w", err)
+	}
+	defer f.Close()
+
+	requests := []ReplayRequest{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() && len(requests) < params.Limit {
+		var e accessLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if e.Time.Before(params.Start) || !e.Time.Before(params.End) {
+			continue
+		}
+		if (e.Method != "" && e.Method != http.MethodGet) || e.Domain == "" || !strings.HasPrefix(e.Path, "/") || replaySkipped(e.Path) {
+			continue
+		}
+		if params.Domain != "" && e.Domain != params.Domain {
+			continue
+		}
+		if params.SpiderOnly && e.Spider == "" {
+			continue
+		}
+		requests = append(requests, ReplayRequest{
+			Domain:    e.Domain,
+			Path:      e.Path,
+			UserAgent: e.UserAgent,
+			ClientIP:  e.ClientIP,
+			Referer:   e.Referer,
+			Status:    e.Status,
+			LatencyMs: e.LatencyMs,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read access log: %w", err)
+	}
+	return requests, nil
+}
+
+func replaySkipped(path string) bool {
+	for _, prefix := range replaySkipPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	for _, p := range replaySkipPaths {
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Run 执行回放并生成报告；total/progress 可为 nil
+func (r *Replayer) Run(ctx context.Context, params ReplayParams, total, progress func(n int64)) (*ReplayReport, error) {
+	if total == nil {
+		total = func(int64) {}
+	}
+	if progress == nil {
+		progress = func(int64) {}
+	}
+	start := time.Now()
+
+	requests, err := r.Load(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	total(int64(len(requests)))
+
+	client := &http.Client{
+		Timeout: time.Duration(params.Timeout) * time.Second,
+		// 比较的是实例的直接响应，不跟随跳转
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	type outcome struct {
+		req      ReplayRequest
+		target   replayResponse
+		baseline replayResponse
+	}
+	outcomes := make([]outcome, len(requests))
+
+	// 按速率发放令牌，并发 worker 取令牌后发送
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < params.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				req := requests[i]
+				o := outcome{req: req}
+				o.target = replaySend(ctx, client, params.TargetURL, req)
+				if params.BaselineURL != "" {
+					o.baseline = replaySend(ctx, client, params.BaselineURL, req)
+				} else {
+					o.baseline = replayResponse{status: req.Status, latencyMs: req.LatencyMs}
+				}
+				outcomes[i] = o
+				progress(1)
+			}
+		}()
+	}
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / params.Rate))
+	sent := 0
+dispatch:
+	for i := range requests {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case <-ticker.C:
+		}
+		jobs <- i
+		sent++
+	}
+	ticker.Stop()
+	close(jobs)
+	wg.Wait()
+
+	report := &ReplayReport{
+		Source:         params.Source,
+		Target:         params.TargetURL,
+		Baseline:       params.BaselineURL,
+		Total:          len(requests),
+		HashCompared:   params.BaselineURL != "",
+		TargetStatus:   map[string]int{},
+		BaselineStatus: map[string]int{},
+		Diffs:          []ReplayDiff{},
+	}
+	if report.Baseline == "" {
+		report.Baseline = "log"
+	}
+	var targetMs, baselineMs []float64
+	for _, o := range outcomes[:sent] {
+		report.Completed++
+		if o.target.err != nil {
+			report.Errors++
+		} else {
+			report.TargetStatus[fmt.Sprint(o.target.status)]++
+			targetMs = append(targetMs, o.target.latencyMs)
+		}
+		if o.baseline.err == nil {
+			report.BaselineStatus[fmt.Sprint(o.baseline.status)]++
+			baselineMs = append(baselineMs, o.baseline.latencyMs)
+		}
+
+		statusMatch := o.target.err == nil && o.baseline.err == nil && o.target.status == o.baseline.status
+		if statusMatch {
+			report.StatusMatch++
+		} else {
+			report.StatusMismatch++
+		}
+		hashMatch := true
+		if report.HashCompared && o.target.err == nil && o.baseline.err == nil {
+			hashMatch = o.target.hash == o.baseline.hash
+			if hashMatch {
+				report.HashMatch++
+			} else {
+				report.HashMismatch++
+			}
+		}
+		if (!statusMatch || !hashMatch) && len(report.Diffs) < replayMaxDiffs {
+			diff := ReplayDiff{
+				Domain:         o.req.Domain,
+				Path:           o.req.Path,
+				UserAgent:      o.req.UserAgent,
+				BaselineStatus: o.baseline.status,
+				TargetStatus:   o.target.status,
+				BaselineHash:   o.baseline.hash,
+				TargetHash:     o.target.hash,
+				BaselineMs:     o.baseline.latencyMs,
+				TargetMs:       o.target.latencyMs,
+			}
+			if o.target.err != nil {
+				diff.Error = "target: " + o.target.err.Error()
+			} else if o.baseline.err != nil {
+				diff.Error = "baseline: " + o.baseline.err.Error()
+			}
+			report.Diffs = append(report.Diffs, diff)
+		}
+	}
+	report.TargetLatency = replayLatencyStats(targetMs)
+	report.BaselineLatency = replayLatencyStats(baselineMs)
+	report.DurationMs = time.Since(start).Milliseconds()
+	return report, ctx.Err()
+}
+
+// replaySend 以原始 UA、客户端 IP 和 Referer 请求实例的 /page 接口（与 Nginx 回源方式一致）
+func replaySend(ctx context.Context, client *http.Client, base string, r ReplayRequest) replayResponse {
+	query := url.Values{"ua": {r.UserAgent}, "path": {r.Path}, "domain": {r.Domain}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/page?"+query.Encode(), nil)
+	if err != nil {
+		return replayResponse{err: err}
+	}
+	req.Header.Set("User-Agent", r.UserAgent)
+	if r.ClientIP != "" {
+		req.Header.Set("X-Forwarded-For", r.ClientIP)
+		req.Header.Set("X-Real-IP", r.ClientIP)
+	}
+	if r.Referer != "" {
+		req.Header.Set("Referer", r.Referer)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return replayResponse{err: err}
+	}
+	defer resp.Body.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, io.LimitReader(resp.Body, replayMaxBody)); err != nil {
+		return replayResponse{status: resp.StatusCode, err: err}
+	}
+	return replayResponse{
+		status:    resp.StatusCode,
+		hash:      hex.EncodeToString(h.Sum(nil))[:16],
+		latencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+}
+
+// replayLatencyStats 计算平均值、分位数和最大值
+func replayLatencyStats(values []float64) ReplayLatency {
+	if len(values) == 0 {
+		return ReplayLatency{}
+	}
+	sort.Float64s(values)
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	at := func(q float64) float64 {
+		return values[min(len(values)-1, int(q*float64(len(values))))]
+	}
+	return ReplayLatency{
+		Avg: sum / float64(len(values)),
+		P50: at(0.5),
+		P95: at(0.95),
+		P99: at(0.99),
+		Max: values[len(values)-1],
+	}
+}
@@ -41,6 +41,14 @@ func NewPagedData(list interface{}, total int64, page, pageSize int) *PagedData
 	}
 }
 
+// CursorData represents keyset-paginated data (no total, next_cursor fetches the following page)
+type CursorData struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"next_cursor"`
+	HasMore    bool        `json:"has_more"`
+	PageSize   int         `json:"page_size"`
+}
+
 // getRequestID extracts request ID from gin context
 func getRequestID(c *gin.Context) string {
 	if requestID, exists := c.Get("request_id"); exists {
@@ -55,7 +63,7 @@ func getRequestID(c *gin.Context) string {
 func Success(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusOK, Response{
 		Code:      int(ErrSuccess),
-		Message:   GetErrorMessage(ErrSuccess),
+		Message:   localizedCodeMessage(c, ErrSuccess),
 		Data:      data,
 		Timestamp: time.Now().Unix(),
 		RequestID: getRequestID(c),
@@ -66,7 +74,7 @@ func Success(c *gin.Context, data interface{}) {
 func SuccessWithMessage(c *gin.Context, message string, data interface{}) {
 	c.JSON(http.StatusOK, Response{
 		Code:      int(ErrSuccess),
-		Message:   message,
+		Message:   localizedMessage(c, message),
 		Data:      data,
 		Timestamp: time.Now().Unix(),
 		RequestID: getRequestID(c),
@@ -77,13 +85,24 @@ func SuccessWithMessage(c *gin.Context, message string, data interface{}) {
 func SuccessPaged(c *gin.Context, list interface{}, total int64, page, pageSize int) {
 	c.JSON(http.StatusOK, Response{
 		Code:      int(ErrSuccess),
-		Message:   GetErrorMessage(ErrSuccess),
+		Message:   localizedCodeMessage(c, ErrSuccess),
 		Data:      NewPagedData(list, total, page, pageSize),
 		Timestamp: time.Now().Unix(),
 		RequestID: getRequestID(c),
 	})
 }
 
+// SuccessCursor sends a success response with keyset-paginated data
+func SuccessCursor(c *gin.Context, list interface{}, nextCursor string, hasMore bool, pageSize int) {
+	c.JSON(http.StatusOK, Response{
+		Code:      int(ErrSuccess),
+		Message:   localizedCodeMessage(c, ErrSuccess),
+		Data:      &CursorData{Items: list, NextCursor: nextCursor, HasMore: hasMore, PageSize: pageSize},
+		Timestamp: time.Now().Unix(),
+		RequestID: getRequestID(c),
+	})
+}
+
 // Fail sends a failure response with default error code
 func Fail(c *gin.Context) {
 	FailWithCode(c, ErrInternalServer)
@@ -94,7 +113,7 @@ func FailWithCode(c *gin.Context, code ErrorCode) {
 	httpStatus := GetHTTPStatus(code)
 	c.JSON(httpStatus, Response{
 		Code:      int(code),
-		Message:   GetErrorMessage(code),
+		Message:   localizedCodeMessage(c, code),
 		Timestamp: time.Now().Unix(),
 		RequestID: getRequestID(c),
 	})
@@ -105,7 +124,7 @@ func FailWithMessage(c *gin.Context, code ErrorCode, message string) {
 	httpStatus := GetHTTPStatus(code)
 	c.JSON(httpStatus, Response{
 		Code:      int(code),
-		Message:   message,
+		Message:   localizedMessage(c, message),
 		Timestamp: time.Now().Unix(),
 		RequestID: getRequestID(c),
 	})
@@ -119,8 +138,13 @@ func FailWithError(c *gin.Context, err *AppError) {
 	}
 
 	message := err.Message
+	if message == GetErrorMessage(err.Code) {
+		message = localizedCodeMessage(c, err.Code)
+	} else {
+		message = localizedMessage(c, message)
+	}
 	if err.Detail != "" {
-		message = err.Message + ": " + err.Detail
+		message = message + ": " + err.Detail
 	}
 
 	c.JSON(err.HTTPStatus(), Response{
@@ -153,7 +177,7 @@ func FailWithData(c *gin.Context, code ErrorCode, data interface{}) {
 	httpStatus := GetHTTPStatus(code)
 	c.JSON(httpStatus, Response{
 		Code:      int(code),
-		Message:   GetErrorMessage(code),
+		Message:   localizedCodeMessage(c, code),
 		Data:      data,
 		Timestamp: time.Now().Unix(),
 		RequestID: getRequestID(c),
@@ -165,7 +189,7 @@ func Abort(c *gin.Context, code ErrorCode) {
 	httpStatus := GetHTTPStatus(code)
 	c.AbortWithStatusJSON(httpStatus, Response{
 		Code:      int(code),
-		Message:   GetErrorMessage(code),
+		Message:   localizedCodeMessage(c, code),
 		Timestamp: time.Now().Unix(),
 		RequestID: getRequestID(c),
 	})
@@ -176,7 +200,7 @@ func AbortWithMessage(c *gin.Context, code ErrorCode, message string) {
 	httpStatus := GetHTTPStatus(code)
 	c.AbortWithStatusJSON(httpStatus, Response{
 		Code:      int(code),
-		Message:   message,
+		Message:   localizedMessage(c, message),
 		Timestamp: time.Now().Unix(),
 		RequestID: getRequestID(c),
 	})
@@ -0,0 +1,467 @@
+// Package core provides robots.txt compliance checks for spider projects
+package core
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"seo-generator/api/pkg/config"
+)
+
+// 项目 robots 合规模式
+const (
+	RobotsModeOff     = "off"     // 不检查（检查接口仍可调用，不记录违规）
+	RobotsModeWarn    = "warn"    // 允许抓取，记录违规
+	RobotsModeEnforce = "enforce" // 禁止抓取，记录被拦截的 URL
+)
+
+// robotsMaxBytes robots.txt 最多读取的字节数（RFC 9309 要求至少解析 500 KiB）
+const robotsMaxBytes = 512 * 1024
+
+// ValidRobotsMode 模式是否合法
+func ValidRobotsMode(mode string) bool {
+	switch mode {
+	case RobotsModeOff, RobotsModeWarn, RobotsModeEnforce:
+		return true
+	}
+	return false
+}
+
+// robotsRule 一条 Allow/Disallow 规则
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsGroup 一组 User-agent 及其规则
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay float64
+}
+
+// robotsFile 解析后的 robots.txt
+type robotsFile struct {
+	groups []robotsGroup
+	// status 抓取结果：ok / missing（4xx，全部允许）/ unreachable（5xx 或网络错误，全部禁止）
+	status string
+}
+
+// robotsEntry 缓存项
+type robotsEntry struct {
+	file      *robotsFile
+	fetchedAt time.Time
+	expiresAt time.Time
+	ready     chan struct{} // 抓取完成后关闭，并发请求同一站点时只抓取一次
+}
+
+// RobotsResult 单个 URL 的检查结果
+type RobotsResult struct {
+	URL        string  `json:"url"`
+	Allowed    bool    `json:"allowed"`               // 按项目模式的最终结论（warn 模式总是 true）
+	RobotsOK   bool    `json:"robots_allowed"`        // robots.txt 本身是否允许
+	Rule       string  `json:"rule,omitempty"`        // 命中的规则，如 "Disallow: /admin"
+	CrawlDelay float64 `json:"crawl_delay,omitempty"` // 匹配组的 Crawl-delay（秒）
+	Status     string  `json:"status"`                // robots.txt 抓取状态：ok/missing/unreachable/invalid_url
+}
+
+// RobotsChecker 抓取并缓存目标站点 robots.txt，批量检查 URL 是否允许抓取
+type RobotsChecker struct {
+	db     *sqlx.DB
+	config config.SpiderRobotsConfig
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]*robotsEntry // scheme://host -> 缓存
+}
+
+// NewRobotsChecker 创建 robots 合规检查器
+func NewRobotsChecker(db *sqlx.DB, cfg config.SpiderRobotsConfig) *RobotsChecker {
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "SEOSpider"
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = 3600
+	}
+	if cfg.ErrorTTL <= 0 {
+		cfg.ErrorTTL = 300
+	}
+	if cfg.FetchTimeout <= 0 {
+		cfg.FetchTimeout = 10
+	}
+	if cfg.MaxURLs <= 0 {
+		cfg.MaxURLs = 500
+	}
+	return &RobotsChecker{
+		db:     db,
+		config: cfg,
+		client: GetHTTPClient().Client(EgressPurposeRobotsCheck, time.Duration(cfg.FetchTimeout)*time.Second),
+		cache:  make(map[string]*robotsEntry),
+	}
+}
+
+// MaxURLs 单次批量检查的最大 URL 数
+func (r *RobotsChecker) MaxURLs() int {
+	return r.config.MaxURLs
+}
+
+// Check 批量检查 URL，userAgent 为空时使用配置的默认值
+func (r *RobotsChecker) Check(ctx context.Context, mode, userAgent string, urls []string) []RobotsResult {
+	if userAgent == "" {
+		userAgent = r.config.UserAgent
+	}
+	results := make([]RobotsResult, len(urls))
+	for i, raw := range urls {
+		res := RobotsResult{URL: raw, Allowed: true, RobotsOK: true}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			res.Status = "invalid_url"
+			results[i] = res
+			continue
+		}
+		file := r.get(ctx, u.Scheme+"://"+u.Host)
+		res.Status = file.status
+		res.RobotsOK, res.Rule, res.CrawlDelay = file.match(userAgent, robotsPath(u))
+		if !res.RobotsOK && mode == RobotsModeEnforce {
+			res.Allowed = false
+		}
+		results[i] = res
+	}
+	return results
+}
+
+// RecordViolations 记录 robots.txt 不允许的 URL（同一 URL 累加命中次数）
+func (r *RobotsChecker) RecordViolations(ctx context.Context, projectID int, mode string, results []RobotsResult) (int, error) {
+	if mode == RobotsModeOff {
+		return 0, nil
+	}
+	action := "warned"
+	if mode == RobotsModeEnforce {
+		action = "blocked"
+	}
+	recorded := 0
+	for _, res := range results {
+		if res.RobotsOK || res.Status == "invalid_url" {
+			continue
+		}
+		u, _ := url.Parse(res.URL)
+		sum := sha1.Sum([]byte(res.URL))
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO spider_robots_violations (project_id, url, url_hash, host, rule, action)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE hits = hits + 1, rule = VALUES(rule), action = VALUES(action), last_seen = NOW()
+		`, projectID, truncateRunes(res.URL, 2000), hex.EncodeToString(sum[:]), u.Host, truncateRunes(res.Rule, 500), action)
+		if err != nil {
+			return recorded, err
+		}
+		recorded++
+	}
+	return recorded, nil
+}
+
+// Invalidate 清除站点缓存，host 为空时清空全部
+func (r *RobotsChecker) Invalidate(host string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if host == "" {
+		r.cache = make(map[string]*robotsEntry)
+		return
+	}
+	for key := range r.cache {
+		if strings.HasSuffix(key, "://"+host) {
+			delete(r.cache, key)
+		}
+	}
+}
+
+// get 获取站点 robots.txt（带缓存）
+func (r *RobotsChecker) get(ctx context.Context, origin string) *robotsFile {
+	r.mu.Lock()
+	entry, ok := r.cache[origin]
+	if ok {
+		select {
+		case <-entry.ready:
+			if time.Now().Before(entry.expiresAt) {
+				r.mu.Unlock()
+				return entry.file
+			}
+			ok = false
+		default:
+		}
+	}
+	if !ok {
+		entry = &robotsEntry{ready: make(chan struct{})}
+		r.cache[origin] = entry
+		r.mu.Unlock()
+
+		// 不使用请求的 ctx：抓取结果会被缓存并共享给其他等待者
+		file := r.fetch(context.Background(), origin)
+		ttl := time.Duration(r.config.CacheTTL) * time.Second
+		if file.status == "unreachable" {
+			ttl = time.Duration(r.config.ErrorTTL) * time.Second
+		}
+		entry.file = file
+		entry.fetchedAt = time.Now()
+		entry.expiresAt = entry.fetchedAt.Add(ttl)
+		close(entry.ready)
+		return file
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-entry.ready:
+		return entry.file
+	case <-ctx.Done():
+		return &robotsFile{status: "unreachable"}
+	}
+}
+
+// fetch 抓取 robots.txt
+// 4xx 视为没有限制；5xx 和网络错误视为全部禁止（RFC 9309 2.3.1）
+func (r *RobotsChecker) fetch(ctx context.Context, origin string) *robotsFile {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return &robotsFile{status: "unreachable"}
+	}
+	req.Header.Set("User-Agent", r.config.UserAgent)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return &robotsFile{status: "unreachable"}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return parseRobots(io.LimitReader(resp.Body, robotsMaxBytes))
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return &robotsFile{status: "missing"}
+	default:
+		return &robotsFile{status: "unreachable"}
+	}
+}
+
+// parseRobots 解析 robots.txt，连续的 User-agent 行共享其后的规则
+func parseRobots(body io.Reader) *robotsFile {
+	file := &robotsFile{status: "ok"}
+	current := -1 // 当前组下标
+	inAgents := false
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), robotsMaxBytes)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				file.groups = append(file.groups, robotsGroup{})
+				current = len(file.groups) - 1
+			}
+			file.groups[current].agents = append(file.groups[current].agents, strings.ToLower(value))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			if current < 0 || value == "" {
+				continue
+			}
+			file.groups[current].rules = append(file.groups[current].rules, robotsRule{allow: key == "allow", pattern: value})
+		case "crawl-delay":
+			inAgents = false
+			if current < 0 {
+				continue
+			}
+			if d, err := strconv.ParseFloat(value, 64); err == nil && d >= 0 {
+				file.groups[current].crawlDelay = d
+			}
+		default:
+			// sitemap 等其他字段不影响分组
+		}
+	}
+	return file
+}
+
+// match 检查路径，返回是否允许、命中的规则和 Crawl-delay
+func (f *robotsFile) match(userAgent, path string) (bool, string, float64) {
+	switch f.status {
+	case "missing":
+		return true, "", 0
+	case "unreachable":
+		return false, "robots.txt unreachable", 0
+	}
+
+	// 选择 User-agent 最具体（最长）匹配的组，没有则使用 *；同名的多个组合并
+	ua := strings.ToLower(userAgent)
+	best := ""
+	for _, g := range f.groups {
+		for _, agent := range g.agents {
+			if agent != "*" && agent != "" && strings.Contains(ua, agent) && len(agent) > len(best) {
+				best = agent
+			}
+		}
+	}
+	if best == "" {
+		best = "*"
+	}
+	var rules []robotsRule
+	var delay float64
+	for _, g := range f.groups {
+		for _, agent := range g.agents {
+			if agent == best {
+				rules = append(rules, g.rules...)
+				if g.crawlDelay > delay {
+					delay = g.crawlDelay
+				}
+				break
+			}
+		}
+	}
+
+	// 最长匹配的规则生效，长度相同时 Allow 优先
+	var hit *robotsRule
+	for i := range rules {
+		rule := &rules[i]
+		if !robotsPatternMatch(rule.pattern, path) {
+			continue
+		}
+		if hit == nil || len(rule.pattern) > len(hit.pattern) || (len(rule.pattern) == len(hit.pattern) && rule.allow) {
+			hit = rule
+		}
+	}
+	if hit == nil {
+		return true, "", delay
+	}
+	prefix := "Disallow: "
+	if hit.allow {
+		prefix = "Allow: "
+	}
+	return hit.allow, prefix + hit.pattern, delay
+}
+
+// robotsPatternMatch 规则匹配，支持 * 通配和 $ 结尾锚定
+func robotsPatternMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for i := 1; i < len(parts); i++ {
+		part := parts[i]
+		if i == len(parts)-1 && anchored {
+			return len(path)-pos >= len(part) && strings.HasSuffix(path, part)
+		}
+		idx := strings.Index(path[pos:], part)
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(part)
+	}
+	return !anchored || pos == len(path)
+}
+
+// robotsPath URL 中参与匹配的部分（路径 + 查询）
+func robotsPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
+
+// RobotsReport 项目最近一次运行的 robots 违规汇总
+type RobotsReport struct {
+	Mode       string            `json:"mode"`
+	Since      *time.Time        `json:"since"` // 本次运行开始时间，为空时统计全部
+	Total      int               `json:"total"` // 违规 URL 数
+	Hits       int               `json:"hits"`  // 违规检查次数
+	Blocked    int               `json:"blocked"`
+	Warned     int               `json:"warned"`
+	ByHost     []RobotsHostCount `json:"by_host"`
+	Violations []RobotsViolation `json:"violations"`
+}
+
+// RobotsHostCount 按站点统计的违规数
+type RobotsHostCount struct {
+	Host  string `db:"host" json:"host"`
+	Count int    `db:"cnt" json:"count"`
+}
+
+// RobotsViolation 一条违规记录
+type RobotsViolation struct {
+	URL       string    `db:"url" json:"url"`
+	Host      string    `db:"host" json:"host"`
+	Rule      string    `db:"rule" json:"rule"`
+	Action    string    `db:"action" json:"action"`
+	Hits      int       `db:"hits" json:"hits"`
+	FirstSeen time.Time `db:"first_seen" json:"first_seen"`
+	LastSeen  time.Time `db:"last_seen" json:"last_seen"`
+}
+
+// Report 汇总 since 之后的违规（since 为空时为全部），最多返回 limit 条明细
+func (r *RobotsChecker) Report(ctx context.Context, projectID int, mode string, since *time.Time, limit int) (*RobotsReport, error) {
+	where := "project_id = ?"
+	args := []interface{}{projectID}
+	if since != nil {
+		where += " AND last_seen >= ?"
+		args = append(args, *since)
+	}
+
+	report := &RobotsReport{Mode: mode, Since: since, ByHost: []RobotsHostCount{}, Violations: []RobotsViolation{}}
+	var totals struct {
+		Total   int `db:"total"`
+		Hits    int `db:"hits"`
+		Blocked int `db:"blocked"`
+	}
+	err := r.db.GetContext(ctx, &totals, fmt.Sprintf(`
+		SELECT COUNT(*) AS total, COALESCE(SUM(hits), 0) AS hits,
+		       COALESCE(SUM(action = 'blocked'), 0) AS blocked
+		FROM spider_robots_violations WHERE %s
+	`, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	report.Total, report.Hits, report.Blocked = totals.Total, totals.Hits, totals.Blocked
+	report.Warned = totals.Total - totals.Blocked
+
+	if err := r.db.SelectContext(ctx, &report.ByHost, fmt.Sprintf(`
+		SELECT host, COUNT(*) AS cnt FROM spider_robots_violations WHERE %s
+		GROUP BY host ORDER BY cnt DESC LIMIT 20
+	`, where), args...); err != nil {
+		return nil, err
+	}
+	if err := r.db.SelectContext(ctx, &report.Violations, fmt.Sprintf(`
+		SELECT url, host, rule, action, hits, first_seen, last_seen
+		FROM spider_robots_violations WHERE %s
+		ORDER BY last_seen DESC LIMIT ?
+	`, where), append(args, limit)...); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
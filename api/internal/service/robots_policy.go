@@ -0,0 +1,450 @@
+// Package core provides per-site-group page-level robots policies (meta robots and external link rel)
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrRobotsPolicyNotFound 规则不存在
+var ErrRobotsPolicyNotFound = errors.New("robots policy not found")
+
+const robotsPolicyColumns = `id, site_group_id, name, pattern, min_page, robots, nofollow_external, priority, enabled, created_at, updated_at`
+
+var (
+	robotsMetaNamePattern  = regexp.MustCompile(`(?is)\bname\s*=\s*["']?\s*robots\s*["'\s/>]`)
+	robotsDirectivePattern = regexp.MustCompile(`^[a-z][a-z_-]*(?::[^,]+)?$`)
+	robotsAnchorPattern    = regexp.MustCompile(`(?is)<a\b[^>]*>`)
+	robotsHrefPattern      = regexp.MustCompile(`(?is)\shref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	robotsRelPattern       = regexp.MustCompile(`(?is)(\srel\s*=\s*)(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	// 规则未定义 page 命名分组时识别的常见分页写法：?page=N、?p=N、/page/N、list_N.html
+	robotsPageNumberPattern = regexp.MustCompile(`(?i)(?:[?&](?:page|p|pn)=(\d+))|(?:/page/(\d+))|(?:_(\d+)\.html?$)`)
+)
+
+// RobotsPolicy 页面级 robots 规则
+// pattern 为匹配 路径+查询串 的正则；min_page > 0 时只匹配页码 >= min_page 的分页页面
+// （页码取 pattern 中的 (?P<page>\d+) 分组，没有该分组时按常见分页写法识别）
+type RobotsPolicy struct {
+	ID               int64     `db:"id" json:"id"`
+	SiteGroupID      int       `db:"site_group_id" json:"site_group_id"` // 0 表示所有站群
+	Name             string    `db:"name" json:"name"`
+	Pattern          string    `db:"pattern" json:"pattern"`
+	MinPage          int       `db:"min_page" json:"min_page"`
+	Robots           string    `db:"robots" json:"robots"`                       // meta robots 内容，如 noindex,follow；为空不修改
+	NofollowExternal bool      `db:"nofollow_external" json:"nofollow_external"` // 站外链接加 rel="nofollow"
+	Priority         int       `db:"priority" json:"priority"`                   // 越大越优先
+	Enabled          bool      `db:"enabled" json:"enabled"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// RobotsDecision 页面的 robots 判定结果（未匹配任何规则时为零值）
+type RobotsDecision struct {
+	PolicyID         int64   `json:"policy_id"` // 提供 meta robots 的规则
+	PolicyName       string  `json:"policy_name"`
+	Robots           string  `json:"robots"`
+	NofollowExternal bool    `json:"nofollow_external"`
+	Page             int     `json:"page"`    // 识别到的页码，0 表示非分页页面
+	Matched          []int64 `json:"matched"` // 所有匹配的规则 ID
+}
+
+// Empty 是否无需处理
+func (d RobotsDecision) Empty() bool {
+	return d.Robots == "" && !d.NofollowExternal
+}
+
+// RobotsRuleTrace 规则评估过程（测试接口使用）
+type RobotsRuleTrace struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Matched bool   `json:"matched"`
+	Page    int    `json:"page"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type compiledRobotsPolicy struct {
+	policy  *RobotsPolicy
+	re      *regexp.Regexp
+	pageIdx int // page 命名分组下标，-1 表示没有
+}
+
+// match 规则是否匹配，返回识别到的页码和不匹配原因
+func (p *compiledRobotsPolicy) match(path string) (bool, int, string) {
+	m := p.re.FindStringSubmatch(path)
+	if m == nil {
+		return false, 0, "pattern not matched"
+	}
+	page := 0
+	if p.pageIdx >= 0 {
+		page, _ = strconv.Atoi(m[p.pageIdx])
+	} else {
+		page = detectPageNumber(path)
+	}
+	if p.policy.MinPage > 0 && page < p.policy.MinPage {
+		return false, page, fmt.Sprintf("page %d < min_page %d", page, p.policy.MinPage)
+	}
+	return true, page, ""
+}
+
+// detectPageNumber 按常见分页写法识别页码
+func detectPageNumber(path string) int {
+	m := robotsPageNumberPattern.FindStringSubmatch(path)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1] + m[2] + m[3])
+	return n
+}
+
+// RobotsPolicies 页面级 noindex/nofollow 规则引擎
+// 启用的规则启动和修改后整体加载到内存，按 优先级、站群专属优先于全局、ID 的顺序评估：
+// meta robots 取第一条设置了 robots 的匹配规则，站外链接 nofollow 任一匹配规则开启即生效。
+// 渲染后向 <head> 注入（或替换）meta robots，并给站外链接加 rel="nofollow"；已缓存的页面在重新渲染后生效
+type RobotsPolicies struct {
+	db    *sqlx.DB
+	rules atomic.Pointer[map[int][]*compiledRobotsPolicy] // siteGroupID -> 已排序规则（含全局规则）
+
+	applied atomic.Int64
+}
+
+// NewRobotsPolicies 创建 robots 规则引擎
+func NewRobotsPolicies(db *sqlx.DB) *RobotsPolicies {
+	p := &RobotsPolicies{db: db}
+	empty := map[int][]*compiledRobotsPolicy{}
+	p.rules.Store(&empty)
+	return p
+}
+
+// CompileRobotsPattern 编译规则正则，返回 page 分组下标（没有时为 -1）
+func CompileRobotsPattern(pattern string) (*regexp.Regexp, int, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, -1, err
+	}
+	return re, re.SubexpIndex("page"), nil
+}
+
+// NormalizeRobotsDirectives 规范化 meta robots 内容（小写、去空格），包含非法指令时返回错误
+func NormalizeRobotsDirectives(robots string) (string, error) {
+	if strings.TrimSpace(robots) == "" {
+		return "", nil
+	}
+	parts := strings.Split(robots, ",")
+	out := make([]string, 0, len(parts))
+	for _, part := range parts {
+		d := strings.ToLower(strings.TrimSpace(part))
+		if d == "" {
+			continue
+		}
+		if !robotsDirectivePattern.MatchString(d) {
+			return "", fmt.Errorf("invalid robots directive: %s", part)
+		}
+		out = append(out, d)
+	}
+	return strings.Join(out, ","), nil
+}
+
+// Reload 从数据库重新加载启用的规则
+func (p *RobotsPolicies) Reload(ctx context.Context) error {
+	var rows []*RobotsPolicy
+	if err := p.db.SelectContext(ctx, &rows, "SELECT "+robotsPolicyColumns+" FROM robots_policies WHERE enabled = 1"); err != nil {
+		return fmt.Errorf("load robots policies: %w", err)
+	}
+
+	var global []*compiledRobotsPolicy
+	groups := map[int][]*compiledRobotsPolicy{}
+	for _, row := range rows {
+		re, pageIdx, err := CompileRobotsPattern(row.Pattern)
+		if err != nil {
+			log.Warn().Err(err).Int64("id", row.ID).Str("pattern", row.Pattern).Msg("Invalid robots policy pattern, skipped")
+			continue
+		}
+		c := &compiledRobotsPolicy{policy: row, re: re, pageIdx: pageIdx}
+		if row.SiteGroupID == 0 {
+			global = append(global, c)
+		} else {
+			groups[row.SiteGroupID] = append(groups[row.SiteGroupID], c)
+		}
+	}
+
+	rules := make(map[int][]*compiledRobotsPolicy, len(groups)+1)
+	rules[0] = sortRobotsPolicies(global)
+	for groupID, list := range groups {
+		rules[groupID] = sortRobotsPolicies(append(list, global...))
+	}
+	p.rules.Store(&rules)
+	log.Info().Int("policies", len(rows)).Msg("Robots policies loaded")
+	return nil
+}
+
+func sortRobotsPolicies(list []*compiledRobotsPolicy) []*compiledRobotsPolicy {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i].policy, list[j].policy
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if (a.SiteGroupID == 0) != (b.SiteGroupID == 0) {
+			return a.SiteGroupID != 0
+		}
+		return a.ID < b.ID
+	})
+	return list
+}
+
+// groupRules 站群生效的规则（未单独配置的站群只有全局规则）
+func (p *RobotsPolicies) groupRules(siteGroupID int) []*compiledRobotsPolicy {
+	rules := *p.rules.Load()
+	if list, ok := rules[siteGroupID]; ok {
+		return list
+	}
+	return rules[0]
+}
+
+// Evaluate 评估页面路径（含查询串）
+// meta robots 取优先级最高的、设置了 robots 的匹配规则；任一匹配规则开启 nofollow_external 即生效
+func (p *RobotsPolicies) Evaluate(siteGroupID int, path string) RobotsDecision {
+	if p == nil {
+		return RobotsDecision{}
+	}
+	d, _ := p.evaluate(siteGroupID, path, false)
+	return d
+}
+
+// Explain 评估并返回每条规则的匹配过程（测试接口使用）
+func (p *RobotsPolicies) Explain(siteGroupID int, path string) (RobotsDecision, []RobotsRuleTrace) {
+	return p.evaluate(siteGroupID, path, true)
+}
+
+func (p *RobotsPolicies) evaluate(siteGroupID int, path string, trace bool) (RobotsDecision, []RobotsRuleTrace) {
+	var d RobotsDecision
+	traces := []RobotsRuleTrace{}
+	for _, rule := range p.groupRules(siteGroupID) {
+		ok, page, reason := rule.match(path)
+		if trace {
+			traces = append(traces, RobotsRuleTrace{ID: rule.policy.ID, Name: rule.policy.Name, Matched: ok, Page: page, Reason: reason})
+		}
+		if !ok {
+			continue
+		}
+		d.Matched = append(d.Matched, rule.policy.ID)
+		if d.Page == 0 {
+			d.Page = page
+		}
+		if d.Robots == "" && rule.policy.Robots != "" {
+			d.PolicyID, d.PolicyName, d.Robots = rule.policy.ID, rule.policy.Name, rule.policy.Robots
+		}
+		d.NofollowExternal = d.NofollowExternal || rule.policy.NofollowExternal
+		if !trace && d.Robots != "" && d.NofollowExternal {
+			break
+		}
+	}
+	return d, traces
+}
+
+// Apply 按判定处理页面：注入/替换 meta robots，站外链接加 rel="nofollow"
+func (p *RobotsPolicies) Apply(page, domain string, d RobotsDecision) string {
+	if d.Empty() {
+		return page
+	}
+	if d.Robots != "" {
+		page = setMetaRobots(page, d.Robots)
+	}
+	if d.NofollowExternal {
+		page = nofollowExternalLinks(page, domain)
+	}
+	p.applied.Add(1)
+	return page
+}
+
+// Stats 处理统计
+func (p *RobotsPolicies) Stats() map[string]interface{} {
+	rules := *p.rules.Load()
+	groups := 0
+	for groupID := range rules {
+		if groupID != 0 {
+			groups++
+		}
+	}
+	return map[string]interface{}{
+		"applied":      p.applied.Load(),
+		"global_rules": len(rules[0]),
+		"site_groups":  groups,
+	}
+}
+
+// setMetaRobots 替换 <head> 中已有的 meta robots，没有时注入
+func setMetaRobots(page, robots string) string {
+	tag := `<meta name="robots" content="` + html.EscapeString(robots) + `">`
+	head := page
+	if loc := tdkHeadClosePattern.FindStringIndex(page); loc != nil {
+		head = page[:loc[0]]
+	}
+	for _, loc := range tdkMetaPattern.FindAllStringIndex(head, -1) {
+		if robotsMetaNamePattern.MatchString(head[loc[0]:loc[1]]) {
+			return page[:loc[0]] + tag + page[loc[1]:]
+		}
+	}
+	if injected, ok := injectIntoHead(page, tag+"\n"); ok {
+		return injected
+	}
+	return page
+}
+
+// nofollowExternalLinks 给指向其他域名的 <a> 加 rel="nofollow"（www 与主域名视为同站）
+func nofollowExternalLinks(page, domain string) string {
+	self := strings.TrimPrefix(strings.ToLower(domain), "www.")
+	return robotsAnchorPattern.ReplaceAllStringFunc(page, func(tag string) string {
+		m := robotsHrefPattern.FindStringSubmatch(tag)
+		if m == nil || !isExternalHref(html.UnescapeString(m[1]+m[2]+m[3]), self) {
+			return tag
+		}
+		tag, _ = addRelTokens(tag, []string{"nofollow"})
+		return tag
+	})
+}
+
+// addRelTokens 给 <a> 开始标签的 rel 补上缺少的值（不区分大小写），返回新标签和是否有改动
+func addRelTokens(tag string, tokens []string) (string, bool) {
+	if len(tokens) == 0 {
+		return tag, false
+	}
+	value := ""
+	rel := robotsRelPattern.FindStringSubmatchIndex(tag)
+	if rel != nil {
+		for i := 4; i <= 8; i += 2 {
+			if rel[i] >= 0 {
+				value = tag[rel[i]:rel[i+1]]
+			}
+		}
+	}
+	existing := strings.Fields(value)
+	added := false
+	for _, token := range tokens {
+		found := false
+		for _, e := range existing {
+			if strings.EqualFold(e, token) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			value = strings.TrimSpace(value + " " + token)
+			added = true
+		}
+	}
+	if !added {
+		return tag, false
+	}
+	if rel != nil {
+		return tag[:rel[3]] + `"` + value + `"` + tag[rel[1]:], true
+	}
+	end := len(tag) - 1
+	if strings.HasSuffix(tag, "/>") {
+		end--
+	}
+	return tag[:end] + ` rel="` + value + `"` + tag[end:], true
+}
+
+// isExternalHref 链接是否指向其他域名（相对链接、锚点、javascript: 等视为站内）
+func isExternalHref(href, self string) bool {
+	href = strings.TrimSpace(href)
+	lower := strings.ToLower(href)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(lower, "//") {
+		return false
+	}
+	u, err := url.Parse(href)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.") != self
+}
+
+// List 列出规则，siteGroupID < 0 时列出全部
+func (p *RobotsPolicies) List(ctx context.Context, siteGroupID int) ([]RobotsPolicy, error) {
+	where, args := "1=1", []interface{}{}
+	if siteGroupID >= 0 {
+		where, args = "site_group_id = ?", append(args, siteGroupID)
+	}
+	items := []RobotsPolicy{}
+	err := p.db.SelectContext(ctx, &items, "SELECT "+robotsPolicyColumns+" FROM robots_policies WHERE "+where+
+		" ORDER BY site_group_id, priority DESC, id", args...)
+	return items, err
+}
+
+// GetByID 获取规则
+func (p *RobotsPolicies) GetByID(ctx context.Context, id int64) (*RobotsPolicy, error) {
+	var policy RobotsPolicy
+	if err := p.db.GetContext(ctx, &policy, "SELECT "+robotsPolicyColumns+" FROM robots_policies WHERE id = ?", id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRobotsPolicyNotFound
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Create 创建规则，返回 ID
+func (p *RobotsPolicies) Create(ctx context.Context, policy *RobotsPolicy) (int64, error) {
+	res, err := p.db.ExecContext(ctx, `
+		INSERT INTO robots_policies (site_group_id, name, pattern, min_page, robots, nofollow_external, priority, enabled)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		policy.SiteGroupID, policy.Name, policy.Pattern, policy.MinPage, policy.Robots, policy.NofollowExternal, policy.Priority, policy.Enabled)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := res.LastInsertId()
+	p.changed(ctx)
+	return id, nil
+}
+
+// Update 更新规则
+func (p *RobotsPolicies) Update(ctx context.Context, policy *RobotsPolicy) error {
+	res, err := p.db.ExecContext(ctx, `
+		UPDATE robots_policies SET site_group_id = ?, name = ?, pattern = ?, min_page = ?, robots = ?,
+			nofollow_external = ?, priority = ?, enabled = ?
+		WHERE id = ?`,
+		policy.SiteGroupID, policy.Name, policy.Pattern, policy.MinPage, policy.Robots,
+		policy.NofollowExternal, policy.Priority, policy.Enabled, policy.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := p.GetByID(ctx, policy.ID); err != nil {
+			return err
+		}
+	}
+	p.changed(ctx)
+	return nil
+}
+
+// Delete 删除规则
+func (p *RobotsPolicies) Delete(ctx context.Context, id int64) error {
+	res, err := p.db.ExecContext(ctx, "DELETE FROM robots_policies WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrRobotsPolicyNotFound
+	}
+	p.changed(ctx)
+	return nil
+}
+
+func (p *RobotsPolicies) changed(ctx context.Context) {
+	if err := p.Reload(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload robots policies")
+	}
+}
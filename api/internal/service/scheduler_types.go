@@ -139,3 +139,6 @@ func ParseRunSpiderParams(data json.RawMessage) (*RunSpiderParams, error) {
 	}
 	return &params, nil
 }
+
+// TaskTypeBackup 定时备份任务类型
+const TaskTypeBackup TaskType = "backup"
@@ -0,0 +1,422 @@
+// Package core provides Chinese word segmentation backed by gse
+package core
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-ego/gse"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// segmentMaxWordLen 自定义词最大长度（字符数）
+const segmentMaxWordLen = 16
+
+// SegmentWord 自定义词典词条
+type SegmentWord struct {
+	ID        int64     `db:"id" json:"id"`
+	Word      string    `db:"word" json:"word"`
+	Freq      int       `db:"freq" json:"freq"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// TextSegmenter 中文分词
+//
+// 基于 gse（jieba 算法的 Go 实现：词典 DAG 最大概率切分 + HMM 新词发现），默认加载 gse 自带的
+// 简体中文词典，再叠加 segmenter.dict_files（jieba dict.txt 格式，每行"词 词频 [词性]"）、
+// 后台维护的 segment_words 表和运行时登记的词（如同义词）。词典修改后整体重建并替换，
+// 连续的字母数字不经过 gse，作为一个词保留原大小写。
+type TextSegmenter struct {
+	db     *sqlx.DB
+	config config.SegmenterConfig
+
+	mu      sync.RWMutex
+	seg     *gse.Segmenter      // 当前词典，重建期间旧词典继续服务
+	words   int                 // 词典词数
+	runtime map[string]struct{} // 运行时登记的词，重建词典时保留
+
+	version atomic.Uint64 // 每次重新加载词典递增，供分词结果缓存判断失效
+}
+
+// NewTextSegmenter 创建分词器（空词典，需调用 Reload 加载）
+func NewTextSegmenter(db *sqlx.DB, cfg config.SegmenterConfig) *TextSegmenter {
+	if cfg.DefaultFreq <= 0 {
+		cfg.DefaultFreq = 1000
+	}
+	s := &TextSegmenter{db: db, config: cfg, runtime: make(map[string]struct{})}
+	s.seg = newGseSegmenter()
+	return s
+}
+
+// gseHMMOnce HMM 模型是 gse 的包级变量，只在第一次创建分词器时加载，避免重建词典时与分词并发写入
+var gseHMMOnce sync.Once
+
+// newGseSegmenter 空词典的 gse 分词器
+func newGseSegmenter() *gse.Segmenter {
+	seg := &gse.Segmenter{SkipLog: true, NotLoadHMM: true}
+	gseHMMOnce.Do(func() { seg.LoadModel() })
+	seg.LoadDictStr("")
+	return seg
+}
+
+// Reload 重新加载内置词典、词典文件和自定义词
+// 词典文件读取失败只记录日志，自定义词表读取失败返回错误（已加载的词典仍然生效）
+func (s *TextSegmenter) Reload(ctx context.Context) error {
+	seg := newGseSegmenter()
+	if s.config.BuiltinDict {
+		if err := seg.LoadDictEmbed("zh_s"); err != nil {
+			log.Warn().Err(err).Msg("Failed to load built-in segment dictionary")
+		}
+	}
+	for _, path := range s.config.DictFiles {
+		n, err := loadSegmentDictFile(seg, path)
+		if err != nil {
+			log.Warn().Err(err).Str("file", path).Msg("Failed to load segment dictionary file")
+			continue
+		}
+		log.Info().Str("file", path).Int("words", n).Msg("Segment dictionary file loaded")
+	}
+
+	var custom []SegmentWord
+	var err error
+	if s.db != nil {
+		err = s.db.SelectContext(ctx, &custom, "SELECT id, word, freq, created_at FROM segment_words")
+	}
+	for _, w := range custom {
+		// 自定义词覆盖词典中的词频
+		seg.ReAddToken(w.Word, float64(w.Freq))
+	}
+
+	s.mu.Lock()
+	for w := range s.runtime {
+		if _, _, ok := seg.Find(w); !ok {
+			seg.AddToken(w, float64(s.config.DefaultFreq))
+		}
+	}
+	seg.CalcToken()
+	s.seg = seg
+	s.words = seg.Dict.NumTokens()
+	s.mu.Unlock()
+	s.version.Add(1)
+
+	log.Info().Int("words", s.words).Int("custom", len(custom)).Msg("Segmenter dictionary loaded")
+	if err != nil {
+		return fmt.Errorf("load segment words: %w", err)
+	}
+	return nil
+}
+
+// AddRuntimeWords 登记不持久化的词（同义词表等），保证这些词按整词切分
+// 词典中已有的词不改变词频
+func (s *TextSegmenter) AddRuntimeWords(words []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	added := 0
+	for _, w := range words {
+		n := utf8.RuneCountInString(w)
+		if n < 2 || n > segmentMaxWordLen {
+			continue
+		}
+		s.runtime[w] = struct{}{}
+		if _, _, ok := s.seg.Find(w); !ok {
+			s.seg.AddToken(w, float64(s.config.DefaultFreq))
+			added++
+		}
+	}
+	if added > 0 {
+		s.seg.CalcToken()
+		s.words = s.seg.Dict.NumTokens()
+		s.version.Add(1)
+	}
+}
+
+// loadSegmentDictFile 读取 jieba 格式词典
+func loadSegmentDictFile(seg *gse.Segmenter, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	n := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		count := 1
+		if len(fields) > 1 {
+			if v, err := strconv.Atoi(fields[1]); err == nil && v > 0 {
+				count = v
+			}
+		}
+		var pos []string
+		if len(fields) > 2 {
+			pos = fields[2:3]
+		}
+		seg.ReAddToken(fields[0], float64(count), pos...)
+		n++
+	}
+	return n, scanner.Err()
+}
+
+// Cut 分词，只返回词（标点和空白丢弃）
+func (s *TextSegmenter) Cut(text string) []string {
+	tokens := s.Tokens(text)
+	words := tokens[:0]
+	for _, t := range tokens {
+		if r, _ := utf8.DecodeRuneInString(t); unicode.Is(unicode.Han, r) || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			words = append(words, t)
+		}
+	}
+	return words
+}
+
+// Tokens 切分为词和非词片段，所有片段按顺序拼接等于原文
+// 连续的汉字交给 gse 切分，连续的字母数字作为一个词，其余字符（标点、空白）各自成段
+func (s *TextSegmenter) Tokens(text string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tokens []string
+	hanStart, wordStart := -1, -1
+	flush := func(i int) {
+		if hanStart >= 0 {
+			tokens = append(tokens, s.seg.Cut(text[hanStart:i], true)...)
+			hanStart = -1
+		}
+		if wordStart >= 0 {
+			tokens = append(tokens, text[wordStart:i])
+			wordStart = -1
+		}
+	}
+	for i, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			if hanStart < 0 {
+				flush(i)
+				hanStart = i
+			}
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if wordStart < 0 {
+				flush(i)
+				wordStart = i
+			}
+		default:
+			flush(i)
+			tokens = append(tokens, string(r))
+		}
+	}
+	flush(len(text))
+	return tokens
+}
+
+// Version 词典版本，词典重新加载后变化
+func (s *TextSegmenter) Version() uint64 {
+	return s.version.Load()
+}
+
+// Stats 词典统计
+func (s *TextSegmenter) Stats() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return map[string]interface{}{
+		"words":        s.words,
+		"runtime":      len(s.runtime),
+		"builtin_dict": s.config.BuiltinDict,
+		"dict_files":   s.config.DictFiles,
+	}
+}
+
+// ListWords 自定义词列表
+func (s *TextSegmenter) ListWords(ctx context.Context, search string, page, pageSize int) ([]SegmentWord, int64, error) {
+	where, args := "1=1", []interface{}{}
+	if search != "" {
+		where += " AND word LIKE ?"
+		args = append(args, "%"+search+"%")
+	}
+	var total int64
+	if err := s.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM segment_words WHERE "+where, args...); err != nil {
+		return nil, 0, err
+	}
+	items := []SegmentWord{}
+	err := s.db.SelectContext(ctx, &items, "SELECT id, word, freq, created_at FROM segment_words WHERE "+where+
+		" ORDER BY id DESC LIMIT ? OFFSET ?", append(args, pageSize, (page-1)*pageSize)...)
+	return items, total, err
+}
+
+// AddWords 批量添加自定义词（已存在的更新词频），freq <= 0 使用默认词频，完成后重新加载词典
+func (s *TextSegmenter) AddWords(ctx context.Context, words []string, freq int) (int, error) {
+	if freq <= 0 {
+		freq = s.config.DefaultFreq
+	}
+	seen := make(map[string]struct{}, len(words))
+	valid := make([]string, 0, len(words))
+	for _, w := range words {
+		w = strings.TrimSpace(w)
+		n := utf8.RuneCountInString(w)
+		if n == 0 || n > segmentMaxWordLen || strings.ContainsFunc(w, unicode.IsSpace) {
+			continue
+		}
+		if _, dup := seen[w]; dup {
+			continue
+		}
+		seen[w] = struct{}{}
+		valid = append(valid, w)
+	}
+
+	for start := 0; start < len(valid); start += keywordImportBatch {
+		batch := valid[start:min(start+keywordImportBatch, len(valid))]
+		placeholders := make([]string, len(batch))
+		args := make([]interface{}, 0, len(batch)*2)
+		for i, w := range batch {
+			placeholders[i] = "(?, ?)"
+			args = append(args, w, freq)
+		}
+		if _, err := s.db.ExecContext(ctx, "INSERT INTO segment_words (word, freq) VALUES "+
+			strings.Join(placeholders, ",")+" ON DUPLICATE KEY UPDATE freq = VALUES(freq)", args...); err != nil {
+			return 0, fmt.Errorf("insert segment words: %w", err)
+		}
+	}
+	return len(valid), s.Reload(ctx)
+}
+
+// DeleteWord 删除自定义词并重新加载词典
+func (s *TextSegmenter) DeleteWord(ctx context.Context, id int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM segment_words WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	return true, s.Reload(ctx)
+}
+
+// ============================================================
+// 关键词密度
+// ============================================================
+
+// htmlTagPattern 密度分析前去除 HTML 标签
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// TermDensity 词频与密度
+type TermDensity struct {
+	Term    string  `json:"term"`
+	Count   int     `json:"count"`
+	Density float64 `json:"density"` // 百分比：出现次数 × 词数 / 总词数 × 100
+}
+
+// DensityReport 关键词密度报告
+type DensityReport struct {
+	TotalWords int           `json:"total_words"`
+	TotalChars int           `json:"total_chars"`
+	Terms      []TermDensity `json:"terms"`    // 高频词（不含单字）
+	Keywords   []TermDensity `json:"keywords"` // 指定关键词的密度，未出现的关键词不返回
+}
+
+// Density 分析文本的高频词和指定关键词密度
+// 关键词先分词再按词序列匹配，避免"上海"命中"上海滩"这类跨词位置
+func (s *TextSegmenter) Density(text string, keywords []string, top int) *DensityReport {
+	text = htmlTagPattern.ReplaceAllString(text, " ")
+	words := s.Cut(text)
+	report := &DensityReport{
+		TotalWords: len(words),
+		TotalChars: utf8.RuneCountInString(text),
+		Terms:      []TermDensity{},
+		Keywords:   []TermDensity{},
+	}
+	if len(words) == 0 {
+		return report
+	}
+	total := float64(len(words))
+
+	counts := make(map[string]int)
+	for _, w := range words {
+		if utf8.RuneCountInString(w) > 1 {
+			counts[strings.ToLower(w)]++
+		}
+	}
+	for term, count := range counts {
+		report.Terms = append(report.Terms, TermDensity{Term: term, Count: count, Density: float64(count) / total * 100})
+	}
+	sortTermDensity(report.Terms)
+	if top > 0 && len(report.Terms) > top {
+		report.Terms = report.Terms[:top]
+	}
+
+	// 按首词建立位置索引，关键词分组可能有上百万个词，逐个全文扫描太慢
+	lower := make([]string, len(words))
+	positions := make(map[string][]int)
+	for i, w := range words {
+		lower[i] = strings.ToLower(w)
+		positions[lower[i]] = append(positions[lower[i]], i)
+	}
+	seen := make(map[string]struct{}, len(keywords))
+	for _, kw := range keywords {
+		kw = strings.TrimSpace(kw)
+		if _, dup := seen[kw]; dup || kw == "" {
+			continue
+		}
+		seen[kw] = struct{}{}
+		seq := s.Cut(strings.ToLower(kw))
+		if len(seq) == 0 || len(positions[seq[0]]) == 0 {
+			continue
+		}
+		if count := countSequence(lower, positions[seq[0]], seq); count > 0 {
+			report.Keywords = append(report.Keywords, TermDensity{
+				Term:    kw,
+				Count:   count,
+				Density: float64(count*len(seq)) / total * 100,
+			})
+		}
+	}
+	sortTermDensity(report.Keywords)
+	return report
+}
+
+// countSequence 统计词序列在分词结果中不重叠出现的次数，starts 为首词出现的位置（升序）
+func countSequence(words []string, starts []int, seq []string) int {
+	count, next := 0, 0
+	for _, i := range starts {
+		if i < next || i+len(seq) > len(words) {
+			continue
+		}
+		match := true
+		for j := 1; j < len(seq); j++ {
+			if words[i+j] != seq[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			count++
+			next = i + len(seq)
+		}
+	}
+	return count
+}
+
+func sortTermDensity(items []TermDensity) {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Term < items[j].Term
+	})
+}
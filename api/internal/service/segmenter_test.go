@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"seo-generator/api/pkg/config"
+)
+
+var (
+	testSegmenterOnce sync.Once
+	testSegmenter     *TextSegmenter
+)
+
+// builtinTestSegmenter 加载内置词典的分词器（不访问数据库，只加载一次）
+func builtinTestSegmenter(t *testing.T) *TextSegmenter {
+	t.Helper()
+	testSegmenterOnce.Do(func() {
+		testSegmenter = NewTextSegmenter(nil, config.SegmenterConfig{BuiltinDict: true})
+		if err := testSegmenter.Reload(context.Background()); err != nil {
+			t.Fatalf("Reload: %v", err)
+		}
+	})
+	return testSegmenter
+}
+
+func TestTextSegmenter_Cut(t *testing.T) {
+	s := builtinTestSegmenter(t)
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"empty", "", []string{}},
+		{"chinese with latin", "我们在上海滩看到了人工智能的发展GPT4o",
+			[]string{"我们", "在", "上海滩", "看到", "了", "人工智能", "的", "发展", "GPT4o"}},
+		{"punctuation dropped", "你好，世界！", []string{"你好", "世界"}},
+		{"latin keeps case", "Hello World 2026", []string{"Hello", "World", "2026"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.Cut(tt.text)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cut(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTextSegmenter_TokensRoundTrip 所有片段按顺序拼接等于原文
+func TestTextSegmenter_TokensRoundTrip(t *testing.T) {
+	s := builtinTestSegmenter(t)
+
+	tests := []string{
+		"",
+		"纯中文句子没有标点",
+		"中文, mixed 文本。\n换行\t制表",
+		"<p>标签也原样保留</p>",
+		"emoji 😀 和全角，标点！",
+	}
+	for _, text := range tests {
+		if got := strings.Join(s.Tokens(text), ""); got != text {
+			t.Errorf("Tokens(%q) joined = %q", text, got)
+		}
+	}
+}
+
+func TestTextSegmenter_RuntimeWords(t *testing.T) {
+	s := NewTextSegmenter(nil, config.SegmenterConfig{})
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	s.AddRuntimeWords([]string{"伪原创"})
+
+	got := s.Cut("伪原创内容")
+	if len(got) == 0 || got[0] != "伪原创" {
+		t.Errorf("Cut after AddRuntimeWords = %q, want first word 伪原创", got)
+	}
+
+	// 重新加载词典后运行时登记的词仍然保留
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	got = s.Cut("伪原创内容")
+	if len(got) == 0 || got[0] != "伪原创" {
+		t.Errorf("Cut after Reload = %q, want first word 伪原创", got)
+	}
+}
@@ -0,0 +1,289 @@
+// Package core provides server-side session tracking for JWT revocation
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// 会话撤销原因
+const (
+	SessionRevokeLogout          = "logout"
+	SessionRevokeManual          = "revoked"
+	SessionRevokeLimit           = "session_limit"
+	SessionRevokePasswordChanged = "password_changed"
+)
+
+const (
+	sessionCacheTTL      = 30 * time.Second // 撤销状态缓存时间（多实例部署时的最大生效延迟）
+	sessionTouchInterval = time.Minute      // last_seen_at 最小更新间隔
+	sessionRetention     = 7 * 24 * time.Hour
+)
+
+// ErrSessionRevoked 会话已撤销或不存在
+var ErrSessionRevoked = errors.New("session revoked")
+
+// AdminSession 管理员登录会话
+type AdminSession struct {
+	ID           int64      `db:"id" json:"id"`
+	AdminID      int        `db:"admin_id" json:"admin_id"`
+	JTI          string     `db:"jti" json:"-"`
+	IP           string     `db:"ip" json:"ip"`
+	UserAgent    string     `db:"user_agent" json:"user_agent"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	LastSeenAt   time.Time  `db:"last_seen_at" json:"last_seen_at"`
+	ExpiresAt    time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt    *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	RevokeReason *string    `db:"revoke_reason" json:"revoke_reason,omitempty"`
+	Current      bool       `db:"-" json:"current"`
+}
+
+// sessionState 缓存的会话状态
+type sessionState struct {
+	valid     bool
+	checkedAt time.Time
+	touchedAt time.Time
+}
+
+// SessionStore 服务端会话表（admin_sessions）
+// 每个 JWT 携带 jti，AuthMiddleware 通过 jti 校验会话是否已撤销，
+// 实现退出登录、会话撤销、并发登录数限制和修改密码后强制下线
+type SessionStore struct {
+	db          *sqlx.DB
+	maxSessions int // 每个管理员最多同时在线的会话数，0 表示不限制
+
+	mu    sync.Mutex
+	cache map[string]*sessionState // jti -> 状态
+}
+
+// NewSessionStore 创建会话存储
+func NewSessionStore(db *sqlx.DB, maxSessions int) *SessionStore {
+	if maxSessions < 0 {
+		maxSessions = 0
+	}
+	return &SessionStore{
+		db:          db,
+		maxSessions: maxSessions,
+		cache:       make(map[string]*sessionState),
+	}
+}
+
+// NewSessionID 生成随机 jti
+func NewSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Create 登记新会话，超过并发上限时撤销最早的会话
+func (s *SessionStore) Create(ctx context.Context, adminID int, jti, ip, userAgent string, expiresAt time.Time) error {
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	if _, err := s.db.ExecContext(ctx,
+		`INSERT INTO admin_sessions (admin_id, jti, ip, user_agent, created_at, last_seen_at, expires_at)
+		 VALUES (?, ?, ?, ?, NOW(), NOW(), ?)`,
+		adminID, jti, ip, userAgent, expiresAt); err != nil {
+		return fmt.Errorf("create session: %w", err)
+	}
+
+	// 顺带清理过期较久的会话记录
+	s.db.ExecContext(ctx, "DELETE FROM admin_sessions WHERE admin_id = ? AND expires_at < ?",
+		adminID, time.Now().Add(-sessionRetention))
+
+	if s.maxSessions > 0 {
+		var active []string
+		err := s.db.SelectContext(ctx, &active, `
+			SELECT jti FROM admin_sessions
+			WHERE admin_id = ? AND revoked_at IS NULL AND expires_at > NOW()
+			ORDER BY created_at DESC, id DESC`, adminID)
+		if err != nil {
+			log.Warn().Err(err).Int("admin_id", adminID).Msg("Failed to query active sessions")
+			return nil
+		}
+		if len(active) <= s.maxSessions {
+			return nil
+		}
+		excess := active[s.maxSessions:]
+		for _, old := range excess {
+			if err := s.revokeJTI(ctx, old, SessionRevokeLimit); err != nil {
+				log.Warn().Err(err).Int("admin_id", adminID).Msg("Failed to revoke excess session")
+			}
+		}
+		log.Info().Int("admin_id", adminID).Int("revoked", len(excess)).Msg("Concurrent session limit reached, oldest sessions revoked")
+	}
+	return nil
+}
+
+// Validate 校验会话是否有效（结果缓存 sessionCacheTTL），并节流更新 last_seen_at
+func (s *SessionStore) Validate(ctx context.Context, jti string) error {
+	if jti == "" {
+		return ErrSessionRevoked
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	st, ok := s.cache[jti]
+	if ok && now.Sub(st.checkedAt) < sessionCacheTTL {
+		valid := st.valid
+		touch := valid && now.Sub(st.touchedAt) >= sessionTouchInterval
+		if touch {
+			st.touchedAt = now
+		}
+		s.mu.Unlock()
+		if touch {
+			s.touch(jti)
+		}
+		if !valid {
+			return ErrSessionRevoked
+		}
+		return nil
+	}
+	s.mu.Unlock()
+
+	var count int
+	if err := s.db.GetContext(ctx, &count,
+		"SELECT COUNT(*) FROM admin_sessions WHERE jti = ? AND revoked_at IS NULL AND expires_at > NOW()", jti); err != nil {
+		// 数据库异常时沿用上次的校验结果（已撤销的仍拒绝），从未校验过的会话一律拒绝
+		log.Warn().Err(err).Msg("Failed to validate session")
+		if ok && st.valid {
+			return nil
+		}
+		if ok {
+			return ErrSessionRevoked
+		}
+		return fmt.Errorf("validate session: %w", err)
+	}
+	valid := count > 0
+
+	s.mu.Lock()
+	s.cache[jti] = &sessionState{valid: valid, checkedAt: now, touchedAt: now}
+	if len(s.cache) > 10000 {
+		s.evictLocked(now)
+	}
+	s.mu.Unlock()
+
+	if !valid {
+		return ErrSessionRevoked
+	}
+	s.touch(jti)
+	return nil
+}
+
+// touch 更新最近活跃时间
+func (s *SessionStore) touch(jti string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.db.ExecContext(ctx, "UPDATE admin_sessions SET last_seen_at = NOW() WHERE jti = ?", jti)
+	}()
+}
+
+// evictLocked 清理过期缓存，调用方需持有锁
+func (s *SessionStore) evictLocked(now time.Time) {
+	for k, st := range s.cache {
+		if now.Sub(st.checkedAt) >= sessionCacheTTL {
+			delete(s.cache, k)
+		}
+	}
+}
+
+// revokeJTI 撤销指定 jti 的会话
+func (s *SessionStore) revokeJTI(ctx context.Context, jti, reason string) error {
+	if _, err := s.db.ExecContext(ctx,
+		"UPDATE admin_sessions SET revoked_at = NOW(), revoke_reason = ? WHERE jti = ? AND revoked_at IS NULL",
+		reason, jti); err != nil {
+		return err
+	}
+	s.markRevoked(jti)
+	return nil
+}
+
+// markRevoked 本实例内立即生效
+func (s *SessionStore) markRevoked(jti string) {
+	s.mu.Lock()
+	s.cache[jti] = &sessionState{valid: false, checkedAt: time.Now()}
+	s.mu.Unlock()
+}
+
+// RevokeByJTI 撤销当前 Token 对应的会话（退出登录）
+func (s *SessionStore) RevokeByJTI(ctx context.Context, jti, reason string) error {
+	if jti == "" {
+		return nil
+	}
+	return s.revokeJTI(ctx, jti, reason)
+}
+
+// Revoke 撤销管理员的指定会话，返回是否找到
+func (s *SessionStore) Revoke(ctx context.Context, adminID int, sessionID int64, reason string) (bool, error) {
+	var jti string
+	err := s.db.GetContext(ctx, &jti,
+		"SELECT jti FROM admin_sessions WHERE id = ? AND admin_id = ? AND revoked_at IS NULL", sessionID, adminID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, s.revokeJTI(ctx, jti, reason)
+}
+
+// RevokeAll 撤销管理员的全部会话，exceptJTI 非空时保留该会话，返回撤销数量
+func (s *SessionStore) RevokeAll(ctx context.Context, adminID int, exceptJTI, reason string) (int, error) {
+	var jtis []string
+	if err := s.db.SelectContext(ctx, &jtis,
+		"SELECT jti FROM admin_sessions WHERE admin_id = ? AND revoked_at IS NULL AND expires_at > NOW() AND jti != ?",
+		adminID, exceptJTI); err != nil {
+		return 0, err
+	}
+	if len(jtis) == 0 {
+		return 0, nil
+	}
+
+	query, args, err := sqlx.In(
+		"UPDATE admin_sessions SET revoked_at = NOW(), revoke_reason = ? WHERE jti IN (?) AND revoked_at IS NULL",
+		reason, jtis)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind(query), args...); err != nil {
+		return 0, err
+	}
+	for _, jti := range jtis {
+		s.markRevoked(jti)
+	}
+	return len(jtis), nil
+}
+
+// ListActive 列出管理员的在线会话
+func (s *SessionStore) ListActive(ctx context.Context, adminID int, currentJTI string) ([]AdminSession, error) {
+	var sessions []AdminSession
+	if err := s.db.SelectContext(ctx, &sessions, `
+		SELECT id, admin_id, jti, COALESCE(ip, '') AS ip, COALESCE(user_agent, '') AS user_agent,
+		       created_at, last_seen_at, expires_at, revoked_at, revoke_reason
+		FROM admin_sessions
+		WHERE admin_id = ? AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC`, adminID); err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].JTI == currentJTI
+	}
+	return sessions, nil
+}
+
+// MaxSessions 返回并发会话上限
+func (s *SessionStore) MaxSessions() int {
+	return s.maxSessions
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	sessionValidateQuery = "SELECT COUNT(*) FROM admin_sessions WHERE jti = ?"
+	sessionLookupQuery   = "SELECT jti FROM admin_sessions WHERE id = ? AND admin_id = ?"
+	sessionRevokeExec    = "UPDATE admin_sessions SET revoked_at = NOW()"
+)
+
+func newSessionTestStore(t *testing.T) (*SessionStore, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewSessionStore(sqlx.NewDb(db, "mysql"), 0), mock
+}
+
+func TestSessionStore_ValidateDBError(t *testing.T) {
+	tests := []struct {
+		name    string
+		cached  *bool // 缓存中已过期的上次校验结果，nil 表示从未校验
+		wantErr bool
+		revoked bool
+	}{
+		{"从未校验的会话拒绝", nil, true, false},
+		{"已撤销的会话仍拒绝", new(bool), true, true},
+		{"上次有效的会话放行", func() *bool { v := true; return &v }(), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newSessionTestStore(t)
+			if tt.cached != nil {
+				past := time.Now().Add(-2 * sessionCacheTTL)
+				s.cache["jti-1"] = &sessionState{valid: *tt.cached, checkedAt: past, touchedAt: time.Now()}
+			}
+			mock.ExpectQuery(regexp.QuoteMeta(sessionValidateQuery)).WillReturnError(errors.New("connection refused"))
+
+			err := s.Validate(t.Context(), "jti-1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrSessionRevoked) != tt.revoked {
+				t.Errorf("Validate() error = %v, revoked %v", err, tt.revoked)
+			}
+		})
+	}
+}
+
+func TestSessionStore_ValidateRevokedCached(t *testing.T) {
+	s, mock := newSessionTestStore(t)
+	mock.ExpectQuery(regexp.QuoteMeta(sessionValidateQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	if err := s.Validate(t.Context(), "jti-1"); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("Validate() error = %v, want ErrSessionRevoked", err)
+	}
+	// 缓存期内不再查库
+	if err := s.Validate(t.Context(), "jti-1"); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("cached Validate() error = %v, want ErrSessionRevoked", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSessionStore_Revoke(t *testing.T) {
+	dbErr := errors.New("connection refused")
+	tests := []struct {
+		name      string
+		lookupErr error
+		wantFound bool
+		wantErr   error
+	}{
+		{"撤销成功", nil, true, nil},
+		{"会话不存在", sql.ErrNoRows, false, nil},
+		{"数据库异常", dbErr, false, dbErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newSessionTestStore(t)
+			q := mock.ExpectQuery(regexp.QuoteMeta(sessionLookupQuery)).WithArgs(int64(7), 1)
+			if tt.lookupErr != nil {
+				q.WillReturnError(tt.lookupErr)
+			} else {
+				q.WillReturnRows(sqlmock.NewRows([]string{"jti"}).AddRow("jti-7"))
+				mock.ExpectExec(regexp.QuoteMeta(sessionRevokeExec)).
+					WithArgs(SessionRevokeManual, "jti-7").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			found, err := s.Revoke(t.Context(), 1, 7, SessionRevokeManual)
+			if found != tt.wantFound || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Revoke() = %v, %v; want %v, %v", found, err, tt.wantFound, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+			if tt.wantFound && s.Validate(t.Context(), "jti-7") == nil {
+				t.Error("revoked session still valid")
+			}
+		})
+	}
+}
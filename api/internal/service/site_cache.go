@@ -6,7 +6,6 @@ import (
 	"sync"
 
 	"github.com/jmoiron/sqlx"
-	"github.com/rs/zerolog/log"
 
 	"seo-generator/api/internal/model"
 )
@@ -18,6 +17,9 @@ type SiteCache struct {
 	cache sync.Map // domain -> *models.Site
 	count int64    // cached site count
 	mu    sync.RWMutex
+	sync  siteSyncState // 增量同步水位线和跨实例事件
+
+	redirects siteRedirectState // 站点重定向规则（已编译）
 }
 
 // NewSiteCache creates a new site cache (permanent mode, no TTL)
@@ -43,8 +45,9 @@ func (sc *SiteCache) LoadAll(ctx context.Context) error {
 	for i := range sites {
 		sc.cache.Store(sites[i].Domain, &sites[i])
 	}
+	sc.sync.trackLoaded(sites)
 
-	log.Info().
+	LoggerFrom(ctx).Info().
 		Int("count", len(sites)).
 		Msg("All sites loaded into cache")
 
@@ -77,8 +80,9 @@ func (sc *SiteCache) Get(ctx context.Context, domain string) (*models.Site, erro
 
 	// Cache the result
 	sc.cache.Store(domain, site)
+	sc.sync.track(site)
 
-	log.Debug().
+	LoggerFrom(ctx).Debug().
 		Str("domain", domain).
 		Str("template", site.Template).
 		Int("site_group_id", site.SiteGroupID).
@@ -87,8 +91,17 @@ func (sc *SiteCache) Get(ctx context.Context, domain string) (*models.Site, erro
 	return site, nil
 }
 
-// Reload reloads a single site from database
+// Reload reloads a single site from database and notifies other instances
 func (sc *SiteCache) Reload(ctx context.Context, domain string) error {
+	if err := sc.reloadLocal(ctx, domain); err != nil {
+		return err
+	}
+	sc.publish(ctx, SiteEventUpsert, domain)
+	return nil
+}
+
+// reloadLocal 从数据库重新加载单个站点（只更新本实例）
+func (sc *SiteCache) reloadLocal(ctx context.Context, domain string) error {
 	site := &models.Site{}
 	query := `SELECT * FROM sites WHERE domain = ? AND status = 1 LIMIT 1`
 
@@ -97,14 +110,16 @@ func (sc *SiteCache) Reload(ctx context.Context, domain string) error {
 		if err == sql.ErrNoRows {
 			// Site was deleted or disabled, remove from cache
 			sc.cache.Delete(domain)
-			log.Info().Str("domain", domain).Msg("Site removed from cache (not found or disabled)")
+			sc.sync.untrack(domain)
+			LoggerFrom(ctx).Info().Str("domain", domain).Msg("Site removed from cache (not found or disabled)")
 			return nil
 		}
 		return err
 	}
 
 	sc.cache.Store(domain, site)
-	log.Info().
+	sc.sync.track(site)
+	LoggerFrom(ctx).Info().
 		Str("domain", domain).
 		Str("template", site.Template).
 		Msg("Site cache reloaded")
@@ -124,9 +139,11 @@ func (sc *SiteCache) ReloadAll(ctx context.Context) error {
 	return sc.LoadAll(ctx)
 }
 
-// Invalidate removes a domain from the cache
+// Invalidate removes a domain from the cache and notifies other instances
 func (sc *SiteCache) Invalidate(domain string) {
 	sc.cache.Delete(domain)
+	sc.sync.untrack(domain)
+	sc.publish(context.Background(), SiteEventDelete, domain)
 }
 
 // InvalidateAll clears the entire cache
@@ -179,5 +196,6 @@ func (sc *SiteCache) GetStats() map[string]interface{} {
 	return map[string]interface{}{
 		"item_count":   count,
 		"memory_bytes": memoryBytes,
+		"sync":         sc.syncStats(),
 	}
 }
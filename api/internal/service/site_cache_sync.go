@@ -0,0 +1,292 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/internal/model"
+)
+
+// siteCacheChannel 站点缓存行级变更事件频道
+const siteCacheChannel = "site_cache:events"
+
+// 站点缓存变更事件类型
+const (
+	SiteEventUpsert = "upsert" // 站点新增或修改，按域名重新加载
+	SiteEventDelete = "delete" // 站点删除或停用，移出缓存
+)
+
+// siteCacheEvent 通过 Redis 广播的站点行级变更
+type siteCacheEvent struct {
+	Origin string `json:"origin"` // 发布实例，收到自己发布的事件时忽略
+	Op     string `json:"op"`
+	Domain string `json:"domain"`
+}
+
+// SiteSyncResult 一次增量同步的结果
+type SiteSyncResult struct {
+	Changed   int       `json:"changed"` // 重新加载的站点数
+	Removed   int       `json:"removed"` // 移出缓存的站点数（停用、删除、改域名后的旧域名）
+	Watermark time.Time `json:"watermark"`
+	Duration  string    `json:"duration"`
+}
+
+// siteSyncState 增量同步状态
+//
+// watermark 为已同步的最大 updated_at，每次只查询 updated_at >= watermark 的行（含同一秒内的后续写入，
+// 重复加载无副作用）；物理删除不会留下 updated_at，通过比对全部 id 发现。
+// ids 记录已加载站点的 id -> domain，用于处理删除和修改域名
+type siteSyncState struct {
+	mu        sync.Mutex
+	watermark time.Time
+	ids       map[int]string
+
+	redis    *redis.Client
+	instance string
+
+	syncs     int64
+	lastSync  time.Time
+	lastError string
+	published int64
+	received  int64
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// trackLoaded LoadAll 后记录全部站点的 id 和最大 updated_at
+func (s *siteSyncState) trackLoaded(sites []models.Site) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids = make(map[int]string, len(sites))
+	s.watermark = time.Time{}
+	for i := range sites {
+		s.ids[sites[i].ID] = sites[i].Domain
+		if sites[i].UpdatedAt.After(s.watermark) {
+			s.watermark = sites[i].UpdatedAt
+		}
+	}
+}
+
+// track 记录单个加载的站点（按需加载、单站重新加载）
+func (s *siteSyncState) track(site *models.Site) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ids == nil {
+		s.ids = make(map[int]string)
+	}
+	s.ids[site.ID] = site.Domain
+}
+
+// untrack 站点移出缓存后不再跟踪
+func (s *siteSyncState) untrack(domain string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, d := range s.ids {
+		if d == domain {
+			delete(s.ids, id)
+		}
+	}
+}
+
+// Sync 增量同步：只加载 updated_at 水位线之后变更的站点，并移除已删除的站点
+func (sc *SiteCache) Sync(ctx context.Context) (*SiteSyncResult, error) {
+	start := time.Now()
+	state := &sc.sync
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	changed := []models.Site{}
+	if err := sc.db.SelectContext(ctx, &changed, `SELECT * FROM sites WHERE updated_at >= ?`, state.watermark); err != nil {
+		state.lastError = err.Error()
+		return nil, err
+	}
+	var ids []int
+	if err := sc.db.SelectContext(ctx, &ids, `SELECT id FROM sites`); err != nil {
+		state.lastError = err.Error()
+		return nil, err
+	}
+	if state.ids == nil {
+		state.ids = make(map[int]string)
+	}
+
+	result := &SiteSyncResult{}
+	for i := range changed {
+		site := &changed[i]
+		if old, ok := state.ids[site.ID]; ok && old != site.Domain {
+			sc.cache.Delete(old)
+			result.Removed++
+		}
+		if site.Status == 1 {
+			sc.cache.Store(site.Domain, site)
+			state.ids[site.ID] = site.Domain
+			result.Changed++
+		} else {
+			if _, ok := state.ids[site.ID]; ok {
+				result.Removed++
+			}
+			sc.cache.Delete(site.Domain)
+			delete(state.ids, site.ID)
+		}
+		if site.UpdatedAt.After(state.watermark) {
+			state.watermark = site.UpdatedAt
+		}
+	}
+
+	existing := make(map[int]struct{}, len(ids))
+	for _, id := range ids {
+		existing[id] = struct{}{}
+	}
+	for id, domain := range state.ids {
+		if _, ok := existing[id]; !ok {
+			sc.cache.Delete(domain)
+			delete(state.ids, id)
+			result.Removed++
+		}
+	}
+
+	sc.mu.Lock()
+	sc.count = int64(len(state.ids))
+	sc.mu.Unlock()
+
+	state.syncs++
+	state.lastSync = time.Now()
+	state.lastError = ""
+	result.Watermark = state.watermark
+	result.Duration = time.Since(start).String()
+
+	if result.Changed > 0 || result.Removed > 0 {
+		LoggerFrom(ctx).Debug().Int("changed", result.Changed).Int("removed", result.Removed).
+			Time("watermark", state.watermark).Msg("Site cache synced")
+	}
+	return result, nil
+}
+
+// StartSync 启动定期增量同步，rdb 不为 nil 时订阅其他实例的行级变更事件
+func (sc *SiteCache) StartSync(interval time.Duration, rdb *redis.Client) {
+	state := &sc.sync
+	host, _ := os.Hostname()
+	state.instance = fmt.Sprintf("%s-%d", host, os.Getpid())
+	state.redis = rdb
+
+	ctx, cancel := context.WithCancel(context.Background())
+	state.cancel = cancel
+
+	if interval > 0 {
+		state.wg.Add(1)
+		go sc.syncLoop(ctx, interval)
+	}
+	if rdb != nil {
+		state.wg.Add(1)
+		go sc.listenEvents(ctx)
+	}
+	log.Info().Dur("interval", interval).Bool("events", rdb != nil).Msg("Site cache incremental sync started")
+}
+
+// StopSync 停止增量同步，写回重定向命中数
+func (sc *SiteCache) StopSync() {
+	if sc.sync.cancel != nil {
+		sc.sync.cancel()
+	}
+	sc.sync.wg.Wait()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sc.FlushRedirectHits(ctx)
+}
+
+func (sc *SiteCache) syncLoop(ctx context.Context, interval time.Duration) {
+	defer sc.sync.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := sc.Sync(ctx); err != nil && ctx.Err() == nil {
+				log.Warn().Err(err).Msg("Site cache incremental sync failed")
+			}
+			sc.FlushRedirectHits(ctx)
+		}
+	}
+}
+
+func (sc *SiteCache) listenEvents(ctx context.Context) {
+	defer sc.sync.wg.Done()
+	pubsub := sc.sync.redis.Subscribe(ctx, siteCacheChannel)
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-ch:
+			if msg == nil {
+				return
+			}
+			var event siteCacheEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil || event.Origin == sc.sync.instance {
+				continue
+			}
+			sc.sync.mu.Lock()
+			sc.sync.received++
+			sc.sync.mu.Unlock()
+
+			switch event.Op {
+			case SiteEventUpsert:
+				if err := sc.reloadLocal(ctx, event.Domain); err != nil {
+					log.Warn().Err(err).Str("domain", event.Domain).Msg("Failed to apply site cache event")
+				}
+			case SiteEventDelete:
+				sc.cache.Delete(event.Domain)
+				sc.sync.untrack(event.Domain)
+			case SiteEventRedirects:
+				if err := sc.LoadRedirects(ctx); err != nil {
+					log.Warn().Err(err).Msg("Failed to apply site redirects event")
+				}
+			}
+		}
+	}
+}
+
+// publish 通知其他实例应用同一变更（未配置 Redis 时其他实例靠定期同步追上）
+func (sc *SiteCache) publish(ctx context.Context, op, domain string) {
+	rdb := sc.sync.redis
+	if rdb == nil || domain == "" {
+		return
+	}
+	payload, _ := json.Marshal(siteCacheEvent{Origin: sc.sync.instance, Op: op, Domain: domain})
+	if err := rdb.Publish(ctx, siteCacheChannel, payload).Err(); err != nil {
+		LoggerFrom(ctx).Warn().Err(err).Str("domain", domain).Msg("Failed to publish site cache event")
+		return
+	}
+	sc.sync.mu.Lock()
+	sc.sync.published++
+	sc.sync.mu.Unlock()
+}
+
+// syncStats 增量同步统计
+func (sc *SiteCache) syncStats() map[string]interface{} {
+	state := &sc.sync
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	stats := map[string]interface{}{
+		"syncs":            state.syncs,
+		"watermark":        state.watermark,
+		"events_published": state.published,
+		"events_received":  state.received,
+		"last_error":       state.lastError,
+	}
+	if !state.lastSync.IsZero() {
+		stats["last_sync"] = state.lastSync
+	}
+	return stats
+}
@@ -0,0 +1,653 @@
+// Package core provides portable site group bundles (export/import between environments)
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// SiteGroupBundleFormat bundle 格式标识
+	SiteGroupBundleFormat = "seo-generator/site-group-bundle"
+	// SiteGroupBundleVersion 当前 bundle 版本，导入时拒绝更高版本
+	SiteGroupBundleVersion = 1
+)
+
+// 导入冲突处理策略
+const (
+	BundleConflictSkip      = "skip"      // 保留目标环境已有数据，引用指向已有数据
+	BundleConflictOverwrite = "overwrite" // 用 bundle 内容覆盖已有数据
+	BundleConflictRename    = "rename"    // 以新名称另建一份
+)
+
+// 导入结果动作
+const (
+	BundleActionCreated = "created"
+	BundleActionUpdated = "updated"
+	BundleActionSkipped = "skipped"
+	BundleActionRenamed = "renamed"
+)
+
+// ErrBundleInvalid bundle 格式或版本不支持
+var ErrBundleInvalid = errors.New("invalid site group bundle")
+
+// SiteGroupBundle 站群配方：站群 + 模板 + 数据分组 + 池配置
+type SiteGroupBundle struct {
+	Format        string            `json:"format"`
+	Version       int               `json:"version"`
+	ExportedAt    time.Time         `json:"exported_at"`
+	SiteGroup     BundleSiteGroup   `json:"site_group"`
+	Templates     []BundleTemplate  `json:"templates"`
+	KeywordGroups []BundleDataGroup `json:"keyword_groups"`
+	ImageGroups   []BundleDataGroup `json:"image_groups"`
+	ArticleGroups []BundleDataGroup `json:"article_groups"`
+	PoolConfig    *BundlePoolConfig `json:"pool_config,omitempty"`
+}
+
+// BundleSiteGroup 站群基本信息
+type BundleSiteGroup struct {
+	Name             string  `json:"name"`
+	Description      *string `json:"description"`
+	FallbackTemplate *string `json:"fallback_template"`
+}
+
+// BundleTemplate 模板
+type BundleTemplate struct {
+	Name        string   `json:"name" db:"name"`
+	DisplayName string   `json:"display_name" db:"display_name"`
+	Description *string  `json:"description" db:"description"`
+	Content     string   `json:"content" db:"content"`
+	Status      int      `json:"status" db:"status"`
+	Category    *string  `json:"category,omitempty" db:"category"`
+	Engine      *string  `json:"engine,omitempty" db:"engine"`
+	Tags        []string `json:"tags,omitempty" db:"-"`
+}
+
+// BundleDataGroup 关键词/图片/文章分组，Items 仅在导出时指定 include_data 才会填充（文章不导出正文）
+type BundleDataGroup struct {
+	Name        string   `json:"name" db:"name"`
+	Description *string  `json:"description" db:"description"`
+	IsDefault   int      `json:"is_default" db:"is_default"`
+	Status      int      `json:"status" db:"status"`
+	Items       []string `json:"items,omitempty" db:"-"`
+}
+
+// BundlePoolConfig 数据池配置（pool_config 表 + pool.* 系统设置，均为全局配置）
+type BundlePoolConfig struct {
+	Columns  map[string]string `json:"columns"`
+	Settings map[string]string `json:"settings"`
+}
+
+// BundleImportOptions 导入选项
+type BundleImportOptions struct {
+	// TargetGroupID 导入到已有站群，0 表示按 bundle 中的站群名称查找或新建
+	TargetGroupID int `json:"target_group_id"`
+	// GroupName 覆盖 bundle 中的站群名称
+	GroupName string `json:"group_name"`
+	// OnConflict 同名冲突处理：skip / overwrite / rename，默认 skip
+	OnConflict string `json:"on_conflict"`
+	// ApplyPoolConfig 是否应用 bundle 中的池配置（全局生效）
+	ApplyPoolConfig bool `json:"apply_pool_config"`
+	// DryRun 只生成报告不写入
+	DryRun bool `json:"dry_run"`
+}
+
+// BundleImportItem 单项导入结果
+type BundleImportItem struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	NewName string `json:"new_name,omitempty"`
+	Action  string `json:"action"`
+	ID      int64  `json:"id,omitempty"`
+	Items   int64  `json:"items,omitempty"`
+}
+
+// BundleImportReport 导入报告
+type BundleImportReport struct {
+	DryRun      bool               `json:"dry_run"`
+	SiteGroupID int64              `json:"site_group_id"`
+	Results     []BundleImportItem `json:"results"`
+	PoolConfig  string             `json:"pool_config"`
+}
+
+// bundleDataTables 数据分组表 -> 数据表及内容列
+var bundleDataTables = map[string]struct {
+	itemTable  string
+	itemColumn string
+}{
+	"keyword_groups": {"keywords", "keyword"},
+	"image_groups":   {"images", "url"},
+	"article_groups": {"", ""},
+}
+
+// bundleItemBatch 批量插入数据项的每批条数
+const bundleItemBatch = 1000
+
+// ExportSiteGroupBundle 导出站群为 bundle
+func ExportSiteGroupBundle(ctx context.Context, db *sqlx.DB, groupID int, includeData bool) (*SiteGroupBundle, error) {
+	bundle := &SiteGroupBundle{
+		Format:     SiteGroupBundleFormat,
+		Version:    SiteGroupBundleVersion,
+		ExportedAt: time.Now(),
+	}
+
+	if err := db.GetContext(ctx, &bundle.SiteGroup,
+		"SELECT name, description, fallback_template FROM site_groups WHERE id = ?", groupID); err != nil {
+		return nil, err
+	}
+
+	var templates []struct {
+		ID int `db:"id"`
+		BundleTemplate
+	}
+	if err := db.SelectContext(ctx, &templates,
+		`SELECT id, name, display_name, description, content, status, category, engine
+		 FROM templates WHERE site_group_id = ? ORDER BY id`, groupID); err != nil {
+		return nil, fmt.Errorf("export templates: %w", err)
+	}
+	for _, t := range templates {
+		tpl := t.BundleTemplate
+		if err := db.SelectContext(ctx, &tpl.Tags,
+			"SELECT tag FROM template_tags WHERE template_id = ? ORDER BY tag", t.ID); err != nil {
+			return nil, fmt.Errorf("export template tags: %w", err)
+		}
+		bundle.Templates = append(bundle.Templates, tpl)
+	}
+
+	for _, table := range []string{"keyword_groups", "image_groups", "article_groups"} {
+		groups, err := exportBundleDataGroups(ctx, db, table, groupID, includeData)
+		if err != nil {
+			return nil, err
+		}
+		switch table {
+		case "keyword_groups":
+			bundle.KeywordGroups = groups
+		case "image_groups":
+			bundle.ImageGroups = groups
+		default:
+			bundle.ArticleGroups = groups
+		}
+	}
+
+	pool, err := exportBundlePoolConfig(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	bundle.PoolConfig = pool
+
+	return bundle, nil
+}
+
+func exportBundleDataGroups(ctx context.Context, db *sqlx.DB, table string, groupID int, includeData bool) ([]BundleDataGroup, error) {
+	var rows []struct {
+		ID int `db:"id"`
+		BundleDataGroup
+	}
+	if err := db.SelectContext(ctx, &rows,
+		"SELECT id, name, description, is_default, status FROM "+table+" WHERE site_group_id = ? ORDER BY id", groupID); err != nil {
+		return nil, fmt.Errorf("export %s: %w", table, err)
+	}
+
+	meta := bundleDataTables[table]
+	groups := make([]BundleDataGroup, 0, len(rows))
+	for _, row := range rows {
+		g := row.BundleDataGroup
+		if includeData && meta.itemTable != "" {
+			if err := db.SelectContext(ctx, &g.Items,
+				"SELECT "+meta.itemColumn+" FROM "+meta.itemTable+" WHERE group_id = ? AND status = 1 ORDER BY id", row.ID); err != nil {
+				return nil, fmt.Errorf("export %s items: %w", meta.itemTable, err)
+			}
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+func exportBundlePoolConfig(ctx context.Context, db *sqlx.DB) (*BundlePoolConfig, error) {
+	pool := &BundlePoolConfig{Columns: map[string]string{}, Settings: map[string]string{}}
+
+	row := map[string]interface{}{}
+	err := db.QueryRowxContext(ctx, "SELECT * FROM pool_config WHERE id = 1").MapScan(row)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("export pool_config: %w", err)
+	}
+	for col, v := range row {
+		if col == "id" || col == "created_at" || col == "updated_at" || v == nil {
+			continue
+		}
+		if b, ok := v.([]byte); ok {
+			pool.Columns[col] = string(b)
+		} else {
+			pool.Columns[col] = fmt.Sprint(v)
+		}
+	}
+
+	var settings []struct {
+		Key   string `db:"setting_key"`
+		Value string `db:"setting_value"`
+	}
+	if err := db.SelectContext(ctx, &settings,
+		"SELECT setting_key, setting_value FROM system_settings WHERE setting_key LIKE 'pool.%'"); err != nil {
+		return nil, fmt.Errorf("export pool settings: %w", err)
+	}
+	for _, s := range settings {
+		pool.Settings[s.Key] = s.Value
+	}
+	return pool, nil
+}
+
+// ValidateSiteGroupBundle 校验 bundle 格式与版本
+func ValidateSiteGroupBundle(b *SiteGroupBundle) error {
+	if b.Format != SiteGroupBundleFormat {
+		return fmt.Errorf("%w: unknown format %q", ErrBundleInvalid, b.Format)
+	}
+	if b.Version < 1 || b.Version > SiteGroupBundleVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrBundleInvalid, b.Version)
+	}
+	if strings.TrimSpace(b.SiteGroup.Name) == "" {
+		return fmt.Errorf("%w: missing site group name", ErrBundleInvalid)
+	}
+	return nil
+}
+
+// ImportSiteGroupBundle 在事务中导入 bundle，dry_run 时回滚
+func ImportSiteGroupBundle(ctx context.Context, db *sqlx.DB, b *SiteGroupBundle, opts BundleImportOptions) (*BundleImportReport, error) {
+	if err := ValidateSiteGroupBundle(b); err != nil {
+		return nil, err
+	}
+	switch opts.OnConflict {
+	case "":
+		opts.OnConflict = BundleConflictSkip
+	case BundleConflictSkip, BundleConflictOverwrite, BundleConflictRename:
+	default:
+		return nil, fmt.Errorf("%w: unknown on_conflict %q", ErrBundleInvalid, opts.OnConflict)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	imp := &bundleImporter{ctx: ctx, tx: tx, opts: opts, report: &BundleImportReport{DryRun: opts.DryRun}}
+	if err := imp.run(b); err != nil {
+		return nil, err
+	}
+
+	if !opts.DryRun {
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		GetQueryCache().Invalidate("site_groups", "templates", "keyword_groups", "image_groups", "article_groups")
+	}
+	return imp.report, nil
+}
+
+type bundleImporter struct {
+	ctx    context.Context
+	tx     *sqlx.Tx
+	opts   BundleImportOptions
+	report *BundleImportReport
+}
+
+func (imp *bundleImporter) add(item BundleImportItem) {
+	imp.report.Results = append(imp.report.Results, item)
+}
+
+func (imp *bundleImporter) run(b *SiteGroupBundle) error {
+	groupID, err := imp.importSiteGroup(b.SiteGroup)
+	if err != nil {
+		return err
+	}
+	imp.report.SiteGroupID = groupID
+
+	// 模板重命名后需要同步修正站群的备用模板引用
+	renamed := map[string]string{}
+	for _, t := range b.Templates {
+		newName, err := imp.importTemplate(groupID, t)
+		if err != nil {
+			return err
+		}
+		if newName != t.Name {
+			renamed[t.Name] = newName
+		}
+	}
+	if fb := b.SiteGroup.FallbackTemplate; fb != nil {
+		if newName, ok := renamed[*fb]; ok {
+			if _, err := imp.tx.ExecContext(imp.ctx,
+				"UPDATE site_groups SET fallback_template = ? WHERE id = ? AND fallback_template = ?",
+				newName, groupID, *fb); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, g := range b.KeywordGroups {
+		if err := imp.importDataGroup("keyword_groups", groupID, g); err != nil {
+			return err
+		}
+	}
+	for _, g := range b.ImageGroups {
+		if err := imp.importDataGroup("image_groups", groupID, g); err != nil {
+			return err
+		}
+	}
+	for _, g := range b.ArticleGroups {
+		if err := imp.importDataGroup("article_groups", groupID, g); err != nil {
+			return err
+		}
+	}
+
+	return imp.importPoolConfig(b.PoolConfig)
+}
+
+func (imp *bundleImporter) importSiteGroup(g BundleSiteGroup) (int64, error) {
+	name := g.Name
+	if imp.opts.GroupName != "" {
+		name = imp.opts.GroupName
+	}
+
+	// 指定目标站群时直接映射，不修改站群本身
+	if imp.opts.TargetGroupID > 0 {
+		var existing string
+		if err := imp.tx.GetContext(imp.ctx, &existing,
+			"SELECT name FROM site_groups WHERE id = ?", imp.opts.TargetGroupID); err != nil {
+			if err == sql.ErrNoRows {
+				return 0, fmt.Errorf("%w: target site group %d not found", ErrBundleInvalid, imp.opts.TargetGroupID)
+			}
+			return 0, err
+		}
+		imp.add(BundleImportItem{Kind: "site_group", Name: existing, Action: BundleActionSkipped, ID: int64(imp.opts.TargetGroupID)})
+		return int64(imp.opts.TargetGroupID), nil
+	}
+
+	var existingID int64
+	err := imp.tx.GetContext(imp.ctx, &existingID, "SELECT id FROM site_groups WHERE name = ?", name)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if err == nil {
+		switch imp.opts.OnConflict {
+		case BundleConflictSkip:
+			imp.add(BundleImportItem{Kind: "site_group", Name: name, Action: BundleActionSkipped, ID: existingID})
+			return existingID, nil
+		case BundleConflictOverwrite:
+			if _, err := imp.tx.ExecContext(imp.ctx,
+				"UPDATE site_groups SET description = ?, fallback_template = ? WHERE id = ?",
+				g.Description, g.FallbackTemplate, existingID); err != nil {
+				return 0, err
+			}
+			imp.add(BundleImportItem{Kind: "site_group", Name: name, Action: BundleActionUpdated, ID: existingID})
+			return existingID, nil
+		}
+	}
+
+	newName := name
+	action := BundleActionCreated
+	if err == nil {
+		if newName, err = imp.freeName("site_groups", 0, name); err != nil {
+			return 0, err
+		}
+		action = BundleActionRenamed
+	}
+	result, err := imp.tx.ExecContext(imp.ctx,
+		`INSERT INTO site_groups (name, description, fallback_template, is_default, status)
+		 VALUES (?, ?, ?, 0, 1)`,
+		newName, g.Description, g.FallbackTemplate)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := result.LastInsertId()
+	imp.add(bundleItem("site_group", name, newName, action, id))
+	return id, nil
+}
+
+func (imp *bundleImporter) importTemplate(groupID int64, t BundleTemplate) (string, error) {
+	var existingID int64
+	err := imp.tx.GetContext(imp.ctx, &existingID,
+		"SELECT id FROM templates WHERE site_group_id = ? AND name = ?", groupID, t.Name)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	if err == nil {
+		switch imp.opts.OnConflict {
+		case BundleConflictSkip:
+			imp.add(BundleImportItem{Kind: "template", Name: t.Name, Action: BundleActionSkipped, ID: existingID})
+			return t.Name, nil
+		case BundleConflictOverwrite:
+			if _, err := imp.tx.ExecContext(imp.ctx,
+				`UPDATE templates SET display_name = ?, description = ?, content = ?, status = ?,
+				        category = ?, engine = ?, version = version + 1
+				 WHERE id = ?`,
+				t.DisplayName, t.Description, t.Content, t.Status, t.Category, t.Engine, existingID); err != nil {
+				return "", err
+			}
+			if err := imp.replaceTemplateTags(existingID, t.Tags); err != nil {
+				return "", err
+			}
+			imp.add(BundleImportItem{Kind: "template", Name: t.Name, Action: BundleActionUpdated, ID: existingID})
+			return t.Name, nil
+		}
+	}
+
+	newName := t.Name
+	action := BundleActionCreated
+	if err == nil {
+		if newName, err = imp.freeName("templates", groupID, t.Name); err != nil {
+			return "", err
+		}
+		action = BundleActionRenamed
+	}
+	result, err := imp.tx.ExecContext(imp.ctx,
+		`INSERT INTO templates (site_group_id, name, display_name, description, content, status, category, engine, version)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1)`,
+		groupID, newName, t.DisplayName, t.Description, t.Content, t.Status, t.Category, t.Engine)
+	if err != nil {
+		return "", err
+	}
+	id, _ := result.LastInsertId()
+	if err := imp.replaceTemplateTags(id, t.Tags); err != nil {
+		return "", err
+	}
+	imp.add(bundleItem("template", t.Name, newName, action, id))
+	return newName, nil
+}
+
+// replaceTemplateTags 用 bundle 中的标签替换模板标签
+func (imp *bundleImporter) replaceTemplateTags(templateID int64, tags []string) error {
+	if _, err := imp.tx.ExecContext(imp.ctx, "DELETE FROM template_tags WHERE template_id = ?", templateID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := imp.tx.ExecContext(imp.ctx,
+			"INSERT IGNORE INTO template_tags (template_id, tag) VALUES (?, ?)", templateID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (imp *bundleImporter) importDataGroup(table string, groupID int64, g BundleDataGroup) error {
+	kind := strings.TrimSuffix(table, "s")
+
+	var existingID int64
+	err := imp.tx.GetContext(imp.ctx, &existingID,
+		"SELECT id FROM "+table+" WHERE site_group_id = ? AND name = ?", groupID, g.Name)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil {
+		switch imp.opts.OnConflict {
+		case BundleConflictSkip:
+			imp.add(BundleImportItem{Kind: kind, Name: g.Name, Action: BundleActionSkipped, ID: existingID})
+			return nil
+		case BundleConflictOverwrite:
+			// 覆盖时不修改 is_default，避免同一站群出现多个默认分组
+			if _, err := imp.tx.ExecContext(imp.ctx,
+				"UPDATE "+table+" SET description = ?, status = ? WHERE id = ?",
+				g.Description, g.Status, existingID); err != nil {
+				return err
+			}
+			n, err := imp.insertItems(table, existingID, g.Items)
+			if err != nil {
+				return err
+			}
+			imp.add(BundleImportItem{Kind: kind, Name: g.Name, Action: BundleActionUpdated, ID: existingID, Items: n})
+			return nil
+		}
+	}
+
+	newName := g.Name
+	action := BundleActionCreated
+	if err == nil {
+		if newName, err = imp.freeName(table, groupID, g.Name); err != nil {
+			return err
+		}
+		action = BundleActionRenamed
+	}
+
+	// 目标站群已有默认分组时，导入的分组不再标记为默认
+	isDefault := g.IsDefault
+	if isDefault == 1 {
+		var defaults int
+		if err := imp.tx.GetContext(imp.ctx, &defaults,
+			"SELECT COUNT(*) FROM "+table+" WHERE site_group_id = ? AND is_default = 1", groupID); err != nil {
+			return err
+		}
+		if defaults > 0 {
+			isDefault = 0
+		}
+	}
+
+	result, err := imp.tx.ExecContext(imp.ctx,
+		"INSERT INTO "+table+" (site_group_id, name, description, is_default, status) VALUES (?, ?, ?, ?, ?)",
+		groupID, newName, g.Description, isDefault, g.Status)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	n, err := imp.insertItems(table, id, g.Items)
+	if err != nil {
+		return err
+	}
+	item := bundleItem(kind, g.Name, newName, action, id)
+	item.Items = n
+	imp.add(item)
+	return nil
+}
+
+// insertItems 批量写入分组数据，已存在的数据（唯一索引冲突）忽略
+func (imp *bundleImporter) insertItems(table string, groupID int64, items []string) (int64, error) {
+	meta := bundleDataTables[table]
+	if meta.itemTable == "" || len(items) == 0 {
+		return 0, nil
+	}
+
+	var total int64
+	for start := 0; start < len(items); start += bundleItemBatch {
+		end := start + bundleItemBatch
+		if end > len(items) {
+			end = len(items)
+		}
+		batch := items[start:end]
+
+		placeholders := make([]string, len(batch))
+		args := make([]interface{}, 0, len(batch)*2)
+		for i, v := range batch {
+			placeholders[i] = "(?, ?)"
+			args = append(args, groupID, v)
+		}
+		result, err := imp.tx.ExecContext(imp.ctx,
+			"INSERT IGNORE INTO "+meta.itemTable+" (group_id, "+meta.itemColumn+") VALUES "+strings.Join(placeholders, ","),
+			args...)
+		if err != nil {
+			return total, fmt.Errorf("import %s: %w", meta.itemTable, err)
+		}
+		n, _ := result.RowsAffected()
+		total += n
+	}
+	return total, nil
+}
+
+func (imp *bundleImporter) importPoolConfig(pool *BundlePoolConfig) error {
+	if pool == nil || !imp.opts.ApplyPoolConfig {
+		imp.report.PoolConfig = BundleActionSkipped
+		return nil
+	}
+
+	// 只更新目标环境 pool_config 表中存在的列，列名以目标表为准
+	current := map[string]interface{}{}
+	if err := imp.tx.QueryRowxContext(imp.ctx, "SELECT * FROM pool_config WHERE id = 1").MapScan(current); err != nil {
+		if err != sql.ErrNoRows {
+			return err
+		}
+	}
+	var sets []string
+	var args []interface{}
+	for col := range current {
+		v, ok := pool.Columns[col]
+		if !ok || col == "id" || col == "created_at" || col == "updated_at" {
+			continue
+		}
+		sets = append(sets, "`"+col+"` = ?")
+		args = append(args, v)
+	}
+	if len(sets) > 0 {
+		if _, err := imp.tx.ExecContext(imp.ctx,
+			"UPDATE pool_config SET "+strings.Join(sets, ", ")+" WHERE id = 1", args...); err != nil {
+			return fmt.Errorf("import pool_config: %w", err)
+		}
+	}
+
+	for key, value := range pool.Settings {
+		if !strings.HasPrefix(key, "pool.") {
+			continue
+		}
+		if _, err := imp.tx.ExecContext(imp.ctx,
+			`INSERT INTO system_settings (setting_key, setting_value) VALUES (?, ?)
+			 ON DUPLICATE KEY UPDATE setting_value = VALUES(setting_value)`,
+			key, value); err != nil {
+			return fmt.Errorf("import pool settings: %w", err)
+		}
+	}
+
+	imp.report.PoolConfig = BundleActionUpdated
+	return nil
+}
+
+// freeName 生成不冲突的名称：name_2、name_3 ...
+// site_groups 全局唯一，其余表在站群内唯一
+func (imp *bundleImporter) freeName(table string, groupID int64, name string) (string, error) {
+	for i := 2; i < 1000; i++ {
+		candidate := fmt.Sprintf("%s_%d", name, i)
+		var n int
+		var err error
+		if table == "site_groups" {
+			err = imp.tx.GetContext(imp.ctx, &n, "SELECT COUNT(*) FROM site_groups WHERE name = ?", candidate)
+		} else {
+			err = imp.tx.GetContext(imp.ctx, &n,
+				"SELECT COUNT(*) FROM "+table+" WHERE site_group_id = ? AND name = ?", groupID, candidate)
+		}
+		if err != nil {
+			return "", err
+		}
+		if n == 0 {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no free name for %q", name)
+}
+
+func bundleItem(kind, name, newName, action string, id int64) BundleImportItem {
+	item := BundleImportItem{Kind: kind, Name: name, Action: action, ID: id}
+	if newName != name {
+		item.NewName = newName
+	}
+	return item
+}
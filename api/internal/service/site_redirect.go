@@ -0,0 +1,249 @@
+// Package core provides per-site 301/302 redirect rules cached in SiteCache
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/internal/model"
+)
+
+// ErrSiteRedirectNotFound 重定向规则不存在
+var ErrSiteRedirectNotFound = errors.New("site redirect not found")
+
+// SiteEventRedirects 重定向规则变更事件（其他实例重新加载规则）
+const SiteEventRedirects = "redirects"
+
+const siteRedirectColumns = `id, site_id, pattern, target, code, priority, enabled, hits, last_hit_at, created_at, updated_at`
+
+// SiteRedirect 站点重定向规则
+// pattern 为匹配整个 路径+查询串 的正则（自动加 ^...$），target 可用 $1、${name} 引用分组，
+// 以 / 开头时为站内路径，也可以是完整 URL
+type SiteRedirect struct {
+	ID        int64      `db:"id" json:"id"`
+	SiteID    int        `db:"site_id" json:"site_id"`
+	Pattern   string     `db:"pattern" json:"pattern"`
+	Target    string     `db:"target" json:"target"`
+	Code      int        `db:"code" json:"code"`         // 301 或 302
+	Priority  int        `db:"priority" json:"priority"` // 越大越优先
+	Enabled   bool       `db:"enabled" json:"enabled"`
+	Hits      int64      `db:"hits" json:"hits"` // 含尚未写回数据库的命中
+	LastHitAt *time.Time `db:"last_hit_at" json:"last_hit_at"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// RedirectMatch 重定向匹配结果
+type RedirectMatch struct {
+	ID       int64  `json:"id"`
+	Code     int    `json:"code"`
+	Location string `json:"location"`
+}
+
+type compiledRedirect struct {
+	redirect *SiteRedirect
+	re       *regexp.Regexp
+}
+
+// siteRedirectState 已编译的重定向规则和待写回的命中数
+type siteRedirectState struct {
+	rules atomic.Pointer[map[int][]*compiledRedirect] // siteID -> 按优先级排序的规则
+	hits  sync.Map                                    // id -> *atomic.Int64，定期同步时写回数据库
+}
+
+// CompileRedirectPattern 编译重定向规则正则（匹配整个路径）
+func CompileRedirectPattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// ValidRedirectCode 是否为支持的重定向状态码
+func ValidRedirectCode(code int) bool {
+	return code == http.StatusMovedPermanently || code == http.StatusFound
+}
+
+// ReloadRedirects 重新加载启用的重定向规则并通知其他实例
+func (sc *SiteCache) ReloadRedirects(ctx context.Context, domain string) error {
+	if err := sc.LoadRedirects(ctx); err != nil {
+		return err
+	}
+	sc.publish(ctx, SiteEventRedirects, domain)
+	return nil
+}
+
+// LoadRedirects 从数据库加载启用的重定向规则（只更新本实例）
+func (sc *SiteCache) LoadRedirects(ctx context.Context) error {
+	var rows []*SiteRedirect
+	if err := sc.db.SelectContext(ctx, &rows, "SELECT "+siteRedirectColumns+" FROM site_redirects WHERE enabled = 1"); err != nil {
+		return fmt.Errorf("load site redirects: %w", err)
+	}
+	rules := map[int][]*compiledRedirect{}
+	for _, row := range rows {
+		re, err := CompileRedirectPattern(row.Pattern)
+		if err != nil {
+			log.Warn().Err(err).Int64("id", row.ID).Str("pattern", row.Pattern).Msg("Invalid redirect pattern, skipped")
+			continue
+		}
+		rules[row.SiteID] = append(rules[row.SiteID], &compiledRedirect{redirect: row, re: re})
+	}
+	for _, list := range rules {
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].redirect.Priority != list[j].redirect.Priority {
+				return list[i].redirect.Priority > list[j].redirect.Priority
+			}
+			return list[i].redirect.ID < list[j].redirect.ID
+		})
+	}
+	sc.redirects.rules.Store(&rules)
+	log.Info().Int("redirects", len(rows)).Msg("Site redirects loaded")
+	return nil
+}
+
+// MatchRedirect 按站点的重定向规则匹配路径（含查询串），未匹配时返回 nil；命中计数异步写回
+func (sc *SiteCache) MatchRedirect(site *models.Site, path string) *RedirectMatch {
+	m := sc.matchRedirect(site, path)
+	if m != nil {
+		if v, ok := sc.redirects.hits.Load(m.ID); ok {
+			v.(*atomic.Int64).Add(1)
+		} else {
+			counter := &atomic.Int64{}
+			actual, _ := sc.redirects.hits.LoadOrStore(m.ID, counter)
+			actual.(*atomic.Int64).Add(1)
+		}
+	}
+	return m
+}
+
+// TestRedirect 匹配路径但不计入命中（测试接口使用）
+func (sc *SiteCache) TestRedirect(site *models.Site, path string) *RedirectMatch {
+	return sc.matchRedirect(site, path)
+}
+
+func (sc *SiteCache) matchRedirect(site *models.Site, path string) *RedirectMatch {
+	rules := sc.redirects.rules.Load()
+	if site == nil || rules == nil {
+		return nil
+	}
+	for _, rule := range (*rules)[site.ID] {
+		loc := rule.re.FindStringSubmatchIndex(path)
+		if loc == nil {
+			continue
+		}
+		target := string(rule.re.ExpandString(nil, rule.redirect.Target, path, loc))
+		if target == path {
+			continue // 目标与原路径相同会造成循环
+		}
+		return &RedirectMatch{ID: rule.redirect.ID, Code: rule.redirect.Code, Location: target}
+	}
+	return nil
+}
+
+// FlushRedirectHits 把内存中的命中数写回数据库
+func (sc *SiteCache) FlushRedirectHits(ctx context.Context) {
+	sc.redirects.hits.Range(func(key, value interface{}) bool {
+		n := value.(*atomic.Int64).Swap(0)
+		if n == 0 {
+			return true
+		}
+		if _, err := sc.db.ExecContext(ctx,
+			"UPDATE site_redirects SET hits = hits + ?, last_hit_at = NOW() WHERE id = ?", n, key); err != nil {
+			value.(*atomic.Int64).Add(n) // 下次重试
+			log.Warn().Err(err).Interface("id", key).Msg("Failed to flush redirect hits")
+			return false
+		}
+		return true
+	})
+}
+
+// pendingRedirectHits 尚未写回的命中数
+func (sc *SiteCache) pendingRedirectHits(id int64) int64 {
+	if v, ok := sc.redirects.hits.Load(id); ok {
+		return v.(*atomic.Int64).Load()
+	}
+	return 0
+}
+
+// ListRedirects 站点的重定向规则（按优先级）
+func (sc *SiteCache) ListRedirects(ctx context.Context, siteID int) ([]SiteRedirect, error) {
+	items := []SiteRedirect{}
+	if err := sc.db.SelectContext(ctx, &items, "SELECT "+siteRedirectColumns+
+		" FROM site_redirects WHERE site_id = ? ORDER BY priority DESC, id", siteID); err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Hits += sc.pendingRedirectHits(items[i].ID)
+	}
+	return items, nil
+}
+
+// GetRedirect 获取站点的重定向规则
+func (sc *SiteCache) GetRedirect(ctx context.Context, siteID int, id int64) (*SiteRedirect, error) {
+	var r SiteRedirect
+	if err := sc.db.GetContext(ctx, &r, "SELECT "+siteRedirectColumns+
+		" FROM site_redirects WHERE id = ? AND site_id = ?", id, siteID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSiteRedirectNotFound
+		}
+		return nil, err
+	}
+	r.Hits += sc.pendingRedirectHits(r.ID)
+	return &r, nil
+}
+
+// SaveRedirect 创建（ID 为 0）或更新重定向规则，返回 ID
+func (sc *SiteCache) SaveRedirect(ctx context.Context, r *SiteRedirect, domain string) (int64, error) {
+	if r.ID == 0 {
+		res, err := sc.db.ExecContext(ctx, `
+			INSERT INTO site_redirects (site_id, pattern, target, code, priority, enabled)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			r.SiteID, r.Pattern, r.Target, r.Code, r.Priority, r.Enabled)
+		if err != nil {
+			return 0, err
+		}
+		r.ID, _ = res.LastInsertId()
+	} else {
+		res, err := sc.db.ExecContext(ctx, `
+			UPDATE site_redirects SET pattern = ?, target = ?, code = ?, priority = ?, enabled = ?
+			WHERE id = ? AND site_id = ?`,
+			r.Pattern, r.Target, r.Code, r.Priority, r.Enabled, r.ID, r.SiteID)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			if _, err := sc.GetRedirect(ctx, r.SiteID, r.ID); err != nil {
+				return 0, err
+			}
+		}
+	}
+	sc.redirectsChanged(ctx, domain)
+	return r.ID, nil
+}
+
+// DeleteRedirect 删除重定向规则
+func (sc *SiteCache) DeleteRedirect(ctx context.Context, siteID int, id int64, domain string) error {
+	res, err := sc.db.ExecContext(ctx, "DELETE FROM site_redirects WHERE id = ? AND site_id = ?", id, siteID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSiteRedirectNotFound
+	}
+	sc.redirects.hits.Delete(id)
+	sc.redirectsChanged(ctx, domain)
+	return nil
+}
+
+func (sc *SiteCache) redirectsChanged(ctx context.Context, domain string) {
+	if err := sc.ReloadRedirects(ctx, domain); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload site redirects")
+	}
+}
@@ -0,0 +1,408 @@
+// Package core provides field mapping for spider output items
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// FieldMappingTargets 可映射的目标字段
+var FieldMappingTargets = []string{"title", "content", "tags", "source_url"}
+
+// fieldMappingTagPattern strip_tags 使用的 HTML 标签匹配
+var fieldMappingTagPattern = regexp.MustCompile(`(?s)<[^>]*>`)
+
+// FieldMapping 爬虫输出字段映射
+// 目标字段 -> 表达式，表达式形如 `summary | strip_tags | truncate(200)`：
+// 第一段为来源字段（支持 a.b 取嵌套字段）或双引号字面量，之后每段为一个转换函数
+// 未配置的目标字段按同名来源字段取值
+type FieldMapping map[string]string
+
+// MappedItem 映射后的数据项
+type MappedItem struct {
+	Title     string   `json:"title"`
+	Content   string   `json:"content"`
+	Tags      []string `json:"tags"`
+	SourceURL string   `json:"source_url"`
+}
+
+// maxTagsLen original_articles.tags 列长度
+const maxTagsLen = 1000
+
+// JoinTags 逗号连接标签，超出列长度时丢弃末尾的标签
+func (m *MappedItem) JoinTags() string {
+	joined := ""
+	for _, tag := range m.Tags {
+		next := tag
+		if joined != "" {
+			next = joined + "," + tag
+		}
+		if utf8.RuneCountInString(next) > maxTagsLen {
+			break
+		}
+		joined = next
+	}
+	return joined
+}
+
+// fieldExpr 编译后的表达式
+type fieldExpr struct {
+	source  string // 来源字段路径
+	literal *string
+	filters []fieldFilter
+}
+
+// fieldFilter 转换函数
+type fieldFilter struct {
+	name string
+	args []string
+	re   *regexp.Regexp // regex_replace 预编译
+}
+
+// fieldFilterArgs 各转换函数的参数个数
+var fieldFilterArgs = map[string]int{
+	"trim":           0,
+	"lower":          0,
+	"upper":          0,
+	"strip_tags":     0,
+	"html_unescape":  0,
+	"collapse_space": 0,
+	"first":          0,
+	"truncate":       1,
+	"default":        1,
+	"prefix":         1,
+	"suffix":         1,
+	"split":          1,
+	"join":           1,
+	"replace":        2,
+	"regex_replace":  2,
+}
+
+// LoadFieldMapping 读取项目字段映射，未配置时返回 nil
+func LoadFieldMapping(ctx context.Context, db *sqlx.DB, projectID int) (FieldMapping, error) {
+	var raw *string
+	err := db.GetContext(ctx, &raw, "SELECT field_mapping FROM spider_projects WHERE id = ?", projectID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if raw == nil || *raw == "" {
+		return nil, nil
+	}
+	var mapping FieldMapping
+	if err := json.Unmarshal([]byte(*raw), &mapping); err != nil {
+		return nil, fmt.Errorf("invalid field_mapping: %w", err)
+	}
+	return mapping, nil
+}
+
+// FieldMapper 编译后的字段映射，可并发使用
+type FieldMapper struct {
+	exprs map[string]*fieldExpr
+}
+
+// Compile 校验并编译映射，未配置的目标字段按同名来源字段取值
+func (m FieldMapping) Compile() (*FieldMapper, error) {
+	for target := range m {
+		known := false
+		for _, t := range FieldMappingTargets {
+			if t == target {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("未知的目标字段 %q（可选 %s）", target, strings.Join(FieldMappingTargets, "/"))
+		}
+	}
+	mapper := &FieldMapper{exprs: make(map[string]*fieldExpr, len(FieldMappingTargets))}
+	for _, target := range FieldMappingTargets {
+		text := m[target]
+		if strings.TrimSpace(text) == "" {
+			text = target
+		}
+		expr, err := compileFieldExpr(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", target, err)
+		}
+		mapper.exprs[target] = expr
+	}
+	return mapper, nil
+}
+
+// Apply 将原始数据项映射为入库字段
+func (m *FieldMapper) Apply(item map[string]interface{}) *MappedItem {
+	return &MappedItem{
+		Title:     fieldValueString(m.exprs["title"].eval(item)),
+		Content:   fieldValueString(m.exprs["content"].eval(item)),
+		Tags:      fieldValueList(m.exprs["tags"].eval(item)),
+		SourceURL: fieldValueString(m.exprs["source_url"].eval(item)),
+	}
+}
+
+// compileFieldExpr 解析表达式
+func compileFieldExpr(text string) (*fieldExpr, error) {
+	parts, err := splitOutsideQuotes(text, '|')
+	if err != nil {
+		return nil, err
+	}
+	head := strings.TrimSpace(parts[0])
+	if head == "" {
+		return nil, errors.New("缺少来源字段")
+	}
+	expr := &fieldExpr{}
+	if strings.HasPrefix(head, `"`) {
+		lit, err := strconv.Unquote(head)
+		if err != nil {
+			return nil, fmt.Errorf("字面量格式错误: %s", head)
+		}
+		expr.literal = &lit
+	} else {
+		expr.source = head
+	}
+
+	for _, part := range parts[1:] {
+		part = strings.TrimSpace(part)
+		name, argText := part, ""
+		if i := strings.IndexByte(part, '('); i >= 0 {
+			if !strings.HasSuffix(part, ")") {
+				return nil, fmt.Errorf("函数缺少右括号: %s", part)
+			}
+			name, argText = strings.TrimSpace(part[:i]), part[i+1:len(part)-1]
+		}
+		want, ok := fieldFilterArgs[name]
+		if !ok {
+			return nil, fmt.Errorf("未知函数 %q", name)
+		}
+		var args []string
+		if strings.TrimSpace(argText) != "" {
+			rawArgs, err := splitOutsideQuotes(argText, ',')
+			if err != nil {
+				return nil, err
+			}
+			for _, a := range rawArgs {
+				a = strings.TrimSpace(a)
+				if strings.HasPrefix(a, `"`) {
+					if a, err = strconv.Unquote(a); err != nil {
+						return nil, fmt.Errorf("%s 参数格式错误", name)
+					}
+				}
+				args = append(args, a)
+			}
+		}
+		if len(args) != want {
+			return nil, fmt.Errorf("%s 需要 %d 个参数", name, want)
+		}
+		f := fieldFilter{name: name, args: args}
+		switch name {
+		case "truncate":
+			if n, err := strconv.Atoi(args[0]); err != nil || n <= 0 {
+				return nil, errors.New("truncate 参数必须为正整数")
+			}
+		case "regex_replace":
+			re, err := regexp.Compile(args[0])
+			if err != nil {
+				return nil, fmt.Errorf("regex_replace 正则错误: %w", err)
+			}
+			f.re = re
+		}
+		expr.filters = append(expr.filters, f)
+	}
+	return expr, nil
+}
+
+// splitOutsideQuotes 按分隔符拆分，忽略双引号内的分隔符
+func splitOutsideQuotes(s string, sep byte) ([]string, error) {
+	var parts []string
+	start, inQuote := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case inQuote && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			inQuote = !inQuote
+		case !inQuote && s[i] == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	if inQuote {
+		return nil, errors.New("引号未闭合")
+	}
+	return append(parts, s[start:]), nil
+}
+
+// eval 计算表达式，值为 string 或 []string
+func (e *fieldExpr) eval(item map[string]interface{}) interface{} {
+	var value interface{}
+	if e.literal != nil {
+		value = *e.literal
+	} else {
+		value = normalizeFieldValue(lookupField(item, e.source))
+	}
+	for _, f := range e.filters {
+		value = f.apply(value)
+	}
+	return value
+}
+
+// apply 执行转换函数；字符串函数作用于列表时逐项处理
+func (f fieldFilter) apply(value interface{}) interface{} {
+	switch f.name {
+	case "split":
+		src, ok := value.([]string)
+		if !ok {
+			src = []string{fieldValueString(value)}
+		}
+		var out []string
+		for _, s := range src {
+			for _, p := range strings.Split(s, f.args[0]) {
+				if p = strings.TrimSpace(p); p != "" {
+					out = append(out, p)
+				}
+			}
+		}
+		return out
+	case "join":
+		return strings.Join(fieldValueList(value), f.args[0])
+	case "first":
+		list, ok := value.([]string)
+		if !ok {
+			return value
+		}
+		if len(list) > 0 {
+			return list[0]
+		}
+		return ""
+	case "default":
+		if fieldValueString(value) == "" {
+			return f.args[0]
+		}
+		return value
+	}
+
+	if list, ok := value.([]string); ok {
+		out := make([]string, len(list))
+		for i, s := range list {
+			out[i] = f.applyString(s)
+		}
+		return out
+	}
+	return f.applyString(fieldValueString(value))
+}
+
+func (f fieldFilter) applyString(s string) string {
+	switch f.name {
+	case "trim":
+		return strings.TrimSpace(s)
+	case "lower":
+		return strings.ToLower(s)
+	case "upper":
+		return strings.ToUpper(s)
+	case "strip_tags":
+		return fieldMappingTagPattern.ReplaceAllString(s, "")
+	case "html_unescape":
+		return html.UnescapeString(s)
+	case "collapse_space":
+		return strings.Join(strings.Fields(s), " ")
+	case "truncate":
+		n, _ := strconv.Atoi(f.args[0])
+		if utf8.RuneCountInString(s) <= n {
+			return s
+		}
+		return string([]rune(s)[:n])
+	case "prefix":
+		if s == "" {
+			return s
+		}
+		return f.args[0] + s
+	case "suffix":
+		if s == "" {
+			return s
+		}
+		return s + f.args[0]
+	case "replace":
+		return strings.ReplaceAll(s, f.args[0], f.args[1])
+	case "regex_replace":
+		return f.re.ReplaceAllString(s, f.args[1])
+	}
+	return s
+}
+
+// lookupField 按 a.b.c 路径取值
+func lookupField(item map[string]interface{}, path string) interface{} {
+	var cur interface{} = item
+	for _, key := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = m[key]
+	}
+	return cur
+}
+
+// normalizeFieldValue 将 JSON 值统一为 string 或 []string
+func normalizeFieldValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []string:
+		return val
+	case []interface{}:
+		out := make([]string, 0, len(val))
+		for _, e := range val {
+			if s := fieldValueString(normalizeFieldValue(e)); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
+
+func fieldValueString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case []string:
+		return strings.Join(val, ",")
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// fieldValueList 列表值原样返回，字符串按逗号拆分
+func fieldValueList(v interface{}) []string {
+	if list, ok := v.([]string); ok {
+		return list
+	}
+	var out []string
+	for _, p := range strings.Split(fieldValueString(v), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
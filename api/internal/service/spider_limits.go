@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	models "seo-generator/api/internal/model"
+)
+
+// spiderRuntimeCheckInterval 运行时长检查间隔
+const spiderRuntimeCheckInterval = 15 * time.Second
+
+// LoadSpiderLimits 读取项目资源限制，用于下发 run 命令
+func LoadSpiderLimits(db *sqlx.DB, projectID int) (*models.SpiderResourceLimits, error) {
+	var limits models.SpiderResourceLimits
+	err := db.Get(&limits, `
+		SELECT max_runtime, max_memory_mb, max_requests, domain_delay_ms, robots_mode
+		FROM spider_projects WHERE id = ?
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return &limits, nil
+}
+
+// SpiderRuntimeGuard 爬虫运行时长守护
+// Worker 可能卡死或忽略限制，由 API 侧按 run_started_at 检查超出 max_runtime 的运行并发送 stop 命令
+type SpiderRuntimeGuard struct {
+	db    *sqlx.DB
+	redis *redis.Client
+}
+
+// NewSpiderRuntimeGuard 创建运行时长守护
+func NewSpiderRuntimeGuard(db *sqlx.DB, rdb *redis.Client) *SpiderRuntimeGuard {
+	return &SpiderRuntimeGuard{db: db, redis: rdb}
+}
+
+// Start 启动检查循环，ctx 取消时退出
+func (g *SpiderRuntimeGuard) Start(ctx context.Context) {
+	ticker := time.NewTicker(spiderRuntimeCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := g.check(ctx); err != nil {
+				log.Warn().Err(err).Msg("Spider runtime check failed")
+			}
+		}
+	}
+}
+
+// check 停止所有超时的运行
+func (g *SpiderRuntimeGuard) check(ctx context.Context) error {
+	var overdue []struct {
+		ID         int       `db:"id"`
+		MaxRuntime int       `db:"max_runtime"`
+		StartedAt  time.Time `db:"run_started_at"`
+	}
+	err := g.db.SelectContext(ctx, &overdue, `
+		SELECT id, max_runtime, run_started_at FROM spider_projects
+		WHERE status = 'running' AND max_runtime > 0 AND run_started_at IS NOT NULL
+		  AND run_started_at < NOW() - INTERVAL max_runtime SECOND
+	`)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range overdue {
+		reason := fmt.Sprintf("超出最大运行时长 %d 秒，已强制停止", p.MaxRuntime)
+		cmd := models.SpiderCommand{
+			Action:    "stop",
+			ProjectID: p.ID,
+			Timestamp: time.Now().Unix(),
+			Reason:    reason,
+		}
+		cmdJSON, _ := json.Marshal(cmd)
+		if err := g.redis.Publish(ctx, "spider:commands", cmdJSON).Err(); err != nil {
+			log.Warn().Err(err).Int("project_id", p.ID).Msg("Failed to publish spider stop command")
+			continue
+		}
+		// 条件更新，避免覆盖期间已结束并重新启动的运行
+		g.db.ExecContext(ctx, `
+			UPDATE spider_projects SET status = 'idle', last_error = ?
+			WHERE id = ? AND status = 'running' AND run_started_at = ?
+		`, reason, p.ID, p.StartedAt)
+		log.Warn().
+			Int("project_id", p.ID).
+			Int("max_runtime", p.MaxRuntime).
+			Time("run_started_at", p.StartedAt).
+			Msg("Spider run exceeded max runtime, stop command sent")
+	}
+	return nil
+}
@@ -0,0 +1,360 @@
+// Package core provides asynchronous batch ingestion of spider visit logs
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// SpiderLogBufferKey 内存缓冲区满时溢出的蜘蛛日志（Redis 列表，LPUSH 写入、RPOP 读取）
+const SpiderLogBufferKey = "spider_logs:buffer"
+
+// SpiderVisitRecord 单条蜘蛛访问记录
+type SpiderVisitRecord struct {
+	SpiderType string    `json:"spider_type"`
+	IP         string    `json:"ip"`
+	UA         string    `json:"ua"`
+	Domain     string    `json:"domain"`
+	Path       string    `json:"path"`
+	DNSOk      int       `json:"dns_ok"`
+	RespTime   int       `json:"resp_time"`
+	CacheHit   int       `json:"cache_hit"`
+	Status     int       `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	Bytes      int       `json:"-"` // 响应体字节数，只用于域名缓存流量统计，不写入日志表
+}
+
+// SpiderLogIngesterConfig 摄入器配置
+type SpiderLogIngesterConfig struct {
+	BufferSize     int           // 内存缓冲区容量，满时溢出到 Redis
+	BatchSize      int           // 单次批量写入条数
+	FlushInterval  time.Duration // 最长刷新间隔
+	RedisMaxLength int64         // Redis 溢出列表最大长度，超出时丢弃
+}
+
+// DefaultSpiderLogIngesterConfig 返回默认配置
+func DefaultSpiderLogIngesterConfig() SpiderLogIngesterConfig {
+	return SpiderLogIngesterConfig{
+		BufferSize:     50000,
+		BatchSize:      500,
+		FlushInterval:  2 * time.Second,
+		RedisMaxLength: 1000000,
+	}
+}
+
+// SpiderLogIngester 蜘蛛日志批量摄入器
+// 记录先进入内存缓冲区，由后台协程按批量或间隔写入 spider_logs；
+// 内存缓冲区满时溢出到 Redis 列表（跨重启保留），写入协程空闲时从 Redis 取回写库；
+// 未配置 Redis 或 Redis 列表也已满时丢弃并计数
+type SpiderLogIngester struct {
+	db      *sqlx.DB
+	config  SpiderLogIngesterConfig
+	buffer  chan SpiderVisitRecord
+	metrics *Metrics
+
+	redis   *redis.Client // 可选溢出缓冲
+	spilled atomic.Int64  // 溢出到 Redis 的条数
+
+	// 可选 ClickHouse 写入，mirrorMySQL 为 false 时不再写 MySQL
+	clickhouse  *ClickHouseSink
+	mirrorMySQL bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewSpiderLogIngester 创建蜘蛛日志摄入器
+func NewSpiderLogIngester(db *sqlx.DB, config SpiderLogIngesterConfig) *SpiderLogIngester {
+	defaults := DefaultSpiderLogIngesterConfig()
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaults.BufferSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaults.FlushInterval
+	}
+	if config.RedisMaxLength <= 0 {
+		config.RedisMaxLength = defaults.RedisMaxLength
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SpiderLogIngester{
+		db:          db,
+		config:      config,
+		buffer:      make(chan SpiderVisitRecord, config.BufferSize),
+		metrics:     GetMetrics(),
+		mirrorMySQL: true,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// SetClickHouseSink 设置 ClickHouse 写入器，需在 Start 之前调用
+func (i *SpiderLogIngester) SetClickHouseSink(sink *ClickHouseSink, mirrorMySQL bool) {
+	i.clickhouse = sink
+	i.mirrorMySQL = mirrorMySQL || sink == nil
+}
+
+// SetRedis 设置 Redis 溢出缓冲，需在 Start 之前调用
+func (i *SpiderLogIngester) SetRedis(rdb *redis.Client) {
+	i.redis = rdb
+}
+
+// Start 启动后台写入协程
+func (i *SpiderLogIngester) Start() {
+	i.wg.Add(1)
+	go i.run()
+	log.Info().
+		Int("buffer_size", i.config.BufferSize).
+		Int("batch_size", i.config.BatchSize).
+		Dur("flush_interval", i.config.FlushInterval).
+		Bool("redis_buffer", i.redis != nil).
+		Msg("SpiderLogIngester started")
+}
+
+// Stop 停止并写入缓冲区中剩余的记录
+func (i *SpiderLogIngester) Stop() {
+	i.cancel()
+	i.wg.Wait()
+}
+
+// Enqueue 将记录放入缓冲区，返回入队和丢弃的数量（溢出到 Redis 的计为入队）
+func (i *SpiderLogIngester) Enqueue(records []SpiderVisitRecord) (accepted, dropped int) {
+	var overflow []SpiderVisitRecord
+	for _, r := range records {
+		// 域名缓存统计与日志同源，缓冲区满丢弃的记录同样计数
+		GetDomainCacheStats().RecordVisit(r)
+		r = NormalizeVisitRecord(r)
+		select {
+		case i.buffer <- r:
+			accepted++
+		default:
+			overflow = append(overflow, r)
+		}
+	}
+	if len(overflow) > 0 {
+		spilled := i.spill(overflow)
+		accepted += spilled
+		dropped += len(overflow) - spilled
+	}
+	i.metrics.RecordSpiderLogIngest(int64(accepted), int64(dropped))
+	return accepted, dropped
+}
+
+// spill 将内存缓冲区放不下的记录写入 Redis 列表，返回写入条数
+func (i *SpiderLogIngester) spill(records []SpiderVisitRecord) int {
+	if i.redis == nil {
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	length, err := i.redis.LLen(ctx, SpiderLogBufferKey).Result()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to check spider log Redis buffer")
+		return 0
+	}
+	room := i.config.RedisMaxLength - length
+	if room <= 0 {
+		return 0
+	}
+	if int64(len(records)) > room {
+		records = records[:room]
+	}
+	values := make([]interface{}, 0, len(records))
+	for _, r := range records {
+		data, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		values = append(values, data)
+	}
+	if len(values) == 0 {
+		return 0
+	}
+	if err := i.redis.LPush(ctx, SpiderLogBufferKey, values...).Err(); err != nil {
+		log.Warn().Err(err).Int("count", len(values)).Msg("Failed to spill spider logs to Redis")
+		return 0
+	}
+	i.spilled.Add(int64(len(values)))
+	return len(values)
+}
+
+// drainRedis 内存缓冲区空闲时从 Redis 取回溢出的记录写库，每次最多 maxBatches 批
+func (i *SpiderLogIngester) drainRedis(maxBatches int) {
+	if i.redis == nil {
+		return
+	}
+	for n := 0; n < maxBatches && len(i.buffer) < i.config.BatchSize; n++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		raws, err := i.redis.RPopCount(ctx, SpiderLogBufferKey, i.config.BatchSize).Result()
+		cancel()
+		if err != nil {
+			if err != redis.Nil {
+				log.Warn().Err(err).Msg("Failed to read spider logs from Redis buffer")
+			}
+			return
+		}
+		batch := make([]SpiderVisitRecord, 0, len(raws))
+		for _, raw := range raws {
+			var r SpiderVisitRecord
+			if err := json.Unmarshal([]byte(raw), &r); err == nil {
+				batch = append(batch, r)
+			}
+		}
+		if len(batch) > 0 {
+			i.flush(batch)
+		}
+		if len(raws) < i.config.BatchSize {
+			return
+		}
+	}
+}
+
+// Pending 返回缓冲区中待写入的记录数
+func (i *SpiderLogIngester) Pending() int {
+	return len(i.buffer)
+}
+
+// redisPending Redis 溢出列表中待写入的记录数
+func (i *SpiderLogIngester) redisPending() int64 {
+	if i.redis == nil {
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	n, _ := i.redis.LLen(ctx, SpiderLogBufferKey).Result()
+	return n
+}
+
+// GetStats 获取摄入器统计
+func (i *SpiderLogIngester) GetStats() map[string]interface{} {
+	snapshot := i.metrics.GetSnapshot()
+	return map[string]interface{}{
+		"pending":       len(i.buffer),
+		"buffer_size":   i.config.BufferSize,
+		"redis":         i.redis != nil,
+		"redis_pending": i.redisPending(),
+		"spilled":       i.spilled.Load(),
+		"ingested":      snapshot.SpiderLogIngested,
+		"dropped":       snapshot.SpiderLogDropped,
+		"written":       snapshot.SpiderLogWritten,
+		"failed":        snapshot.SpiderLogFailed,
+		"clickhouse":    i.clickhouse != nil,
+	}
+}
+
+// NormalizeVisitRecord 按字符截断过长字段（不截断多字节字符）并补充默认值
+func NormalizeVisitRecord(r SpiderVisitRecord) SpiderVisitRecord {
+	r.UA = truncateRunes(r.UA, 500)
+	r.Path = truncateRunes(r.Path, 500)
+	if r.SpiderType == "" {
+		r.SpiderType = "unknown"
+	}
+	if r.Status == 0 {
+		r.Status = 200
+	}
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	return r
+}
+
+// run 后台批量写入循环
+func (i *SpiderLogIngester) run() {
+	defer i.wg.Done()
+
+	ticker := time.NewTicker(i.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpiderVisitRecord, 0, i.config.BatchSize)
+	for {
+		select {
+		case r := <-i.buffer:
+			batch = append(batch, r)
+			if len(batch) >= i.config.BatchSize {
+				i.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				i.flush(batch)
+				batch = batch[:0]
+			}
+			i.drainRedis(10)
+		case <-i.ctx.Done():
+			// 排空缓冲区
+			for {
+				select {
+				case r := <-i.buffer:
+					batch = append(batch, r)
+					if len(batch) >= i.config.BatchSize {
+						i.flush(batch)
+						batch = batch[:0]
+					}
+				default:
+					if len(batch) > 0 {
+						i.flush(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush 批量写入一批记录
+// 启用 ClickHouse 时写入 ClickHouse，并按配置决定是否同时写 MySQL
+func (i *SpiderLogIngester) flush(batch []SpiderVisitRecord) {
+	if i.clickhouse != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := i.clickhouse.Write(ctx, batch)
+		cancel()
+		if err != nil {
+			log.Error().Err(err).Int("count", len(batch)).Msg("Failed to write spider logs to ClickHouse")
+		}
+		if !i.mirrorMySQL {
+			if err != nil {
+				i.metrics.RecordSpiderLogFlush(0, int64(len(batch)))
+			} else {
+				i.metrics.RecordSpiderLogFlush(int64(len(batch)), 0)
+			}
+			return
+		}
+	}
+	i.flushMySQL(batch)
+}
+
+// flushMySQL 批量写入 MySQL spider_logs
+func (i *SpiderLogIngester) flushMySQL(batch []SpiderVisitRecord) {
+	valueStrings := make([]string, len(batch))
+	args := make([]interface{}, 0, len(batch)*10)
+	for j, r := range batch {
+		valueStrings[j] = "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		args = append(args, r.SpiderType, r.IP, r.UA, r.Domain, r.Path, r.DNSOk, r.RespTime, r.CacheHit, r.Status, r.CreatedAt)
+	}
+
+	query := `INSERT INTO spider_logs (spider_type, ip, ua, domain, path, dns_ok, resp_time, cache_hit, status, created_at)
+              VALUES ` + strings.Join(valueStrings, ",")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := i.db.ExecContext(ctx, query, args...); err != nil {
+		log.Error().Err(err).Int("count", len(batch)).Msg("Failed to bulk insert spider logs")
+		i.metrics.RecordSpiderLogFlush(0, int64(len(batch)))
+		return
+	}
+	i.metrics.RecordSpiderLogFlush(int64(len(batch)), 0)
+}
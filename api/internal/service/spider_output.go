@@ -0,0 +1,116 @@
+// Package core provides capture of spider test-run output items for preview
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// spiderTestLogPattern 测试运行日志频道（Worker 通过 level=ITEM 的日志发送数据项）
+const spiderTestLogPattern = "spider:logs:test_*"
+
+// SpiderTestItemsKey 项目最近测试数据项列表（最新在前）
+func SpiderTestItemsKey(projectID int) string {
+	return fmt.Sprintf("spider:test_items:%d", projectID)
+}
+
+// SpiderTestItem 一条测试运行数据项
+type SpiderTestItem struct {
+	Data       map[string]interface{} `json:"data"`
+	CapturedAt time.Time              `json:"captured_at"`
+}
+
+// SpiderOutputCapture 订阅测试运行日志，保存每个项目最近 N 条数据项，用于接入内容分组前预览和调试字段映射
+type SpiderOutputCapture struct {
+	redis  *redis.Client
+	config config.SpiderOutputConfig
+}
+
+// NewSpiderOutputCapture 创建测试数据项采集
+func NewSpiderOutputCapture(rdb *redis.Client, cfg config.SpiderOutputConfig) *SpiderOutputCapture {
+	if cfg.KeepItems <= 0 {
+		cfg.KeepItems = 50
+	}
+	if cfg.TTLHours <= 0 {
+		cfg.TTLHours = 168
+	}
+	return &SpiderOutputCapture{redis: rdb, config: cfg}
+}
+
+// Start 订阅并保存数据项，ctx 取消时退出
+func (s *SpiderOutputCapture) Start(ctx context.Context) {
+	pubsub := s.redis.PSubscribe(ctx, spiderTestLogPattern)
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			projectID, err := strconv.Atoi(strings.TrimPrefix(msg.Channel, "spider:logs:test_"))
+			if err != nil {
+				continue
+			}
+			s.capture(ctx, projectID, msg.Payload)
+		}
+	}
+}
+
+// capture 解析日志消息，数据项写入列表并裁剪
+func (s *SpiderOutputCapture) capture(ctx context.Context, projectID int, payload string) {
+	var msg struct {
+		Level   string `json:"level"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil || msg.Level != "ITEM" {
+		return
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(msg.Message), &data); err != nil {
+		return
+	}
+	item, _ := json.Marshal(SpiderTestItem{Data: data, CapturedAt: time.Now()})
+
+	key := SpiderTestItemsKey(projectID)
+	pipe := s.redis.Pipeline()
+	pipe.LPush(ctx, key, item)
+	pipe.LTrim(ctx, key, 0, int64(s.config.KeepItems-1))
+	pipe.Expire(ctx, key, time.Duration(s.config.TTLHours)*time.Hour)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Warn().Err(err).Int("project_id", projectID).Msg("Failed to save spider test item")
+	}
+}
+
+// Items 项目最近的测试数据项（最新在前）
+func (s *SpiderOutputCapture) Items(ctx context.Context, projectID int) ([]SpiderTestItem, error) {
+	raws, err := s.redis.LRange(ctx, SpiderTestItemsKey(projectID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]SpiderTestItem, 0, len(raws))
+	for _, raw := range raws {
+		var item SpiderTestItem
+		if json.Unmarshal([]byte(raw), &item) == nil {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// Clear 清空项目的测试数据项（开始新的测试运行前调用）
+func (s *SpiderOutputCapture) Clear(ctx context.Context, projectID int) error {
+	return s.redis.Del(ctx, SpiderTestItemsKey(projectID)).Err()
+}
@@ -0,0 +1,437 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+const (
+	// spiderStatsArchiverKey 归档进度（minute/hour/day 为已完成的下一个周期起点，Unix 秒）
+	spiderStatsArchiverKey = "spider:stats:archiver"
+	// spiderStatsBaselineKey 上次归档时各项目的累计计数（project_id → total,completed,failed,retried）
+	spiderStatsBaselineKey = "spider:stats:archiver:baseline"
+	// spiderStatsInsertBatch 单条 INSERT 的行数
+	spiderStatsInsertBatch = 500
+	// spiderStatsPruneBatch 单次 DELETE 的行数，避免长时间锁表
+	spiderStatsPruneBatch = 10000
+)
+
+// spiderStatsProgress 归档进度，各字段为下一个待处理周期的起点（零值表示从未运行）
+type spiderStatsProgress struct {
+	Minute time.Time // 下一个待归档的分钟
+	Hour   time.Time // 下一个待汇总的小时
+	Day    time.Time // 下一个待汇总的天
+}
+
+// SpiderStatsArchiver 爬虫实时统计归档服务
+// 每分钟把 Redis spider:<id>:stats 累计计数的增量写入 spider_stats_history 分钟记录，
+// 再按本地时区的整点、零点汇总为小时和天记录；基准计数和进度保存在 Redis，
+// 重启后补齐停机期间错过的汇总窗口，并按保留天数清理分钟和小时记录
+type SpiderStatsArchiver struct {
+	db     *sqlx.DB
+	redis  *redis.Client
+	reader *SpiderStatsReader
+	cfg    config.SpiderStatsConfig
+
+	mu        sync.Mutex
+	running   bool
+	stopCh    chan struct{}
+	lastPrune time.Time
+}
+
+// NewSpiderStatsArchiver 创建爬虫统计归档服务
+func NewSpiderStatsArchiver(db *sqlx.DB, rdb *redis.Client, cfg config.SpiderStatsConfig) *SpiderStatsArchiver {
+	if cfg.MinuteRetentionDays <= 0 {
+		cfg.MinuteRetentionDays = 7
+	}
+	if cfg.HourRetentionDays <= 0 {
+		cfg.HourRetentionDays = 30
+	}
+	return &SpiderStatsArchiver{
+		db:     db,
+		redis:  rdb,
+		reader: NewSpiderStatsReader(rdb),
+		cfg:    cfg,
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Start 启动归档服务（在 goroutine 中调用）
+func (a *SpiderStatsArchiver) Start(ctx context.Context) {
+	a.mu.Lock()
+	if a.running {
+		a.mu.Unlock()
+		return
+	}
+	a.running = true
+	a.stopCh = make(chan struct{})
+	a.mu.Unlock()
+
+	defer func() {
+		a.mu.Lock()
+		a.running = false
+		a.mu.Unlock()
+	}()
+
+	log.Info().Msg("SpiderStatsArchiver started")
+
+	// 启动时立即补齐错过的窗口
+	a.runTasks(ctx, time.Now())
+
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("SpiderStatsArchiver stopped (context cancelled)")
+			return
+		case <-a.stopCh:
+			log.Info().Msg("SpiderStatsArchiver stopped")
+			return
+		case now := <-ticker.C:
+			a.runTasks(ctx, now)
+		}
+	}
+}
+
+// Stop 停止归档服务
+func (a *SpiderStatsArchiver) Stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.running && a.stopCh != nil {
+		close(a.stopCh)
+	}
+}
+
+func (a *SpiderStatsArchiver) runTasks(ctx context.Context, now time.Time) {
+	progress, err := a.loadProgress(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("load spider stats archive progress error")
+		return
+	}
+
+	if err := a.archiveMinute(ctx, now, &progress); err != nil {
+		log.Error().Err(err).Msg("archive spider minute stats error")
+	}
+	if err := a.rollupHours(ctx, now, &progress); err != nil {
+		log.Error().Err(err).Msg("rollup spider hour stats error")
+	}
+	if err := a.rollupDays(ctx, now, &progress); err != nil {
+		log.Error().Err(err).Msg("rollup spider day stats error")
+	}
+
+	if now.Sub(a.lastPrune) >= time.Hour {
+		a.prune(ctx, "minute", now.AddDate(0, 0, -a.cfg.MinuteRetentionDays))
+		a.prune(ctx, "hour", now.AddDate(0, 0, -a.cfg.HourRetentionDays))
+		a.lastPrune = now
+	}
+}
+
+// archiveMinute 把上次归档以来的计数增量写入上一个完整分钟
+//
+// 停机期间的增量无法按分钟还原，整体记入恢复后的第一个分钟，avg_speed 按实际间隔折算；
+// 首次运行只记录基准，不把历史累计值当作增量写入
+func (a *SpiderStatsArchiver) archiveMinute(ctx context.Context, now time.Time, p *spiderStatsProgress) error {
+	bucket := now.Truncate(time.Minute).Add(-time.Minute)
+	if !p.Minute.IsZero() && bucket.Before(p.Minute) {
+		return nil
+	}
+
+	keys, err := a.reader.scanKeys(ctx)
+	if err != nil {
+		return err
+	}
+	counts, err := a.reader.hmget(ctx, keys)
+	if err != nil {
+		return err
+	}
+	baseline, err := a.loadBaseline(ctx)
+	if err != nil {
+		return err
+	}
+
+	minutes := 1.0
+	if !p.Minute.IsZero() {
+		if gap := bucket.Sub(p.Minute)/time.Minute + 1; gap > 1 {
+			minutes = float64(gap)
+			log.Warn().Time("since", p.Minute).Int64("minutes", int64(gap)).
+				Msg("Spider stats archiver missed minutes, recording the accumulated delta in one bucket")
+		}
+	}
+
+	current := make(map[string]interface{}, len(keys))
+	var rows []spiderStatsRow
+	for i, key := range keys {
+		projectID, ok := spiderStatsProjectID(key)
+		if !ok {
+			continue
+		}
+		cur := counts[i]
+		current[strconv.Itoa(projectID)] = formatSpiderStatCounts(cur)
+		if p.Minute.IsZero() {
+			continue
+		}
+		delta := spiderStatsDelta(cur, baseline[projectID])
+		if delta.Total == 0 && delta.Completed == 0 && delta.Failed == 0 && delta.Retried == 0 {
+			continue
+		}
+		rows = append(rows, spiderStatsRow{
+			ProjectID: projectID,
+			Counts:    delta,
+			AvgSpeed:  float64(delta.Completed) / minutes,
+		})
+	}
+
+	if err := a.insertMinuteRows(ctx, bucket, rows); err != nil {
+		return err
+	}
+
+	// 基准整体替换：消失的键（队列被清空）不再保留旧基准
+	next := bucket.Add(time.Minute)
+	pipe := a.redis.TxPipeline()
+	pipe.Del(ctx, spiderStatsBaselineKey)
+	if len(current) > 0 {
+		pipe.HSet(ctx, spiderStatsBaselineKey, current)
+	}
+	pipe.HSet(ctx, spiderStatsArchiverKey, "minute", next.Unix())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	p.Minute = next
+	return nil
+}
+
+// rollupHours 汇总已归档完整的小时（从进度记录的小时补到分钟归档完成的位置）
+func (a *SpiderStatsArchiver) rollupHours(ctx context.Context, now time.Time, p *spiderStatsProgress) error {
+	if p.Minute.IsZero() {
+		return nil
+	}
+	cursor := p.Hour
+	if cursor.IsZero() {
+		cursor = hourStart(now).Add(-time.Hour)
+	}
+	// 分钟记录已被清理的时段无法重新汇总
+	if floor := hourStart(now.AddDate(0, 0, -a.cfg.MinuteRetentionDays)); cursor.Before(floor) {
+		cursor = floor
+	}
+
+	for {
+		end := hourStart(cursor).Add(time.Hour)
+		if end.After(p.Minute) {
+			break
+		}
+		if err := a.rollup(ctx, "hour", "minute", cursor, end); err != nil {
+			return err
+		}
+		if err := a.redis.HSet(ctx, spiderStatsArchiverKey, "hour", end.Unix()).Err(); err != nil {
+			return err
+		}
+		cursor = end
+		p.Hour = end
+	}
+	return nil
+}
+
+// rollupDays 汇总小时记录已完整的天（按本地时区零点切分）
+func (a *SpiderStatsArchiver) rollupDays(ctx context.Context, now time.Time, p *spiderStatsProgress) error {
+	if p.Hour.IsZero() {
+		return nil
+	}
+	cursor := p.Day
+	if cursor.IsZero() {
+		cursor = dayStart(now).AddDate(0, 0, -1)
+	}
+	if floor := dayStart(now.AddDate(0, 0, -a.cfg.HourRetentionDays)); cursor.Before(floor) {
+		cursor = floor
+	}
+
+	for {
+		end := dayStart(cursor).AddDate(0, 0, 1)
+		if end.After(p.Hour) {
+			break
+		}
+		if err := a.rollup(ctx, "day", "hour", cursor, end); err != nil {
+			return err
+		}
+		if err := a.redis.HSet(ctx, spiderStatsArchiverKey, "day", end.Unix()).Err(); err != nil {
+			return err
+		}
+		cursor = end
+		p.Day = end
+	}
+	return nil
+}
+
+// rollup 把 [start, end) 内的 source 记录汇总为一条 target 记录，avg_speed 为该周期的每分钟完成数
+func (a *SpiderStatsArchiver) rollup(ctx context.Context, target, source string, start, end time.Time) error {
+	minutes := end.Sub(start).Minutes()
+	_, err := a.db.ExecContext(ctx, `
+		INSERT INTO spider_stats_history (project_id, period_type, period_start, total, completed, failed, retried, avg_speed)
+		SELECT project_id, ?, ?, SUM(total), SUM(completed), SUM(failed), SUM(retried), ROUND(SUM(completed) / ?, 2)
+		FROM spider_stats_history
+		WHERE period_type = ? AND period_start >= ? AND period_start < ?
+		GROUP BY project_id
+		ON DUPLICATE KEY UPDATE
+			total = VALUES(total),
+			completed = VALUES(completed),
+			failed = VALUES(failed),
+			retried = VALUES(retried),
+			avg_speed = VALUES(avg_speed)
+	`, target, start, minutes, source, start, end)
+	return err
+}
+
+// spiderStatsRow 待写入的分钟记录
+type spiderStatsRow struct {
+	ProjectID int
+	Counts    SpiderStatCounts
+	AvgSpeed  float64
+}
+
+// insertMinuteRows 批量写入分钟记录（重复执行覆盖同一分钟，保证重试幂等）
+func (a *SpiderStatsArchiver) insertMinuteRows(ctx context.Context, bucket time.Time, rows []spiderStatsRow) error {
+	for start := 0; start < len(rows); start += spiderStatsInsertBatch {
+		end := start + spiderStatsInsertBatch
+		if end > len(rows) {
+			end = len(rows)
+		}
+		batch := rows[start:end]
+
+		valueStrings := make([]string, len(batch))
+		args := make([]interface{}, 0, len(batch)*7)
+		for i, r := range batch {
+			valueStrings[i] = "(?, 'minute', ?, ?, ?, ?, ?, ?)"
+			args = append(args, r.ProjectID, bucket, r.Counts.Total, r.Counts.Completed, r.Counts.Failed, r.Counts.Retried, r.AvgSpeed)
+		}
+
+		_, err := a.db.ExecContext(ctx, `
+			INSERT INTO spider_stats_history
+			(project_id, period_type, period_start, total, completed, failed, retried, avg_speed)
+			VALUES `+strings.Join(valueStrings, ",")+`
+			ON DUPLICATE KEY UPDATE
+				total = VALUES(total),
+				completed = VALUES(completed),
+				failed = VALUES(failed),
+				retried = VALUES(retried),
+				avg_speed = VALUES(avg_speed)
+		`, args...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prune 分批删除 cutoff 之前的记录
+func (a *SpiderStatsArchiver) prune(ctx context.Context, periodType string, cutoff time.Time) {
+	var total int64
+	for {
+		result, err := a.db.ExecContext(ctx, `
+			DELETE FROM spider_stats_history WHERE period_type = ? AND period_start < ? LIMIT ?
+		`, periodType, cutoff, spiderStatsPruneBatch)
+		if err != nil {
+			log.Error().Err(err).Str("period_type", periodType).Msg("prune spider stats error")
+			return
+		}
+		affected, _ := result.RowsAffected()
+		total += affected
+		if affected < spiderStatsPruneBatch {
+			break
+		}
+	}
+	if total > 0 {
+		log.Info().Int64("count", total).Str("period_type", periodType).Msg("Pruned old spider stats records")
+	}
+}
+
+func (a *SpiderStatsArchiver) loadProgress(ctx context.Context) (spiderStatsProgress, error) {
+	var p spiderStatsProgress
+	values, err := a.redis.HGetAll(ctx, spiderStatsArchiverKey).Result()
+	if err != nil {
+		return p, err
+	}
+	parse := func(field string) time.Time {
+		sec, err := strconv.ParseInt(values[field], 10, 64)
+		if err != nil || sec <= 0 {
+			return time.Time{}
+		}
+		return time.Unix(sec, 0)
+	}
+	p.Minute = parse("minute")
+	p.Hour = parse("hour")
+	p.Day = parse("day")
+	return p, nil
+}
+
+func (a *SpiderStatsArchiver) loadBaseline(ctx context.Context) (map[int]SpiderStatCounts, error) {
+	values, err := a.redis.HGetAll(ctx, spiderStatsBaselineKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	baseline := make(map[int]SpiderStatCounts, len(values))
+	for field, value := range values {
+		projectID, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+		parts := strings.Split(value, ",")
+		if len(parts) != 4 {
+			continue
+		}
+		baseline[projectID] = SpiderStatCounts{
+			Total:     parseInt64(parts[0]),
+			Completed: parseInt64(parts[1]),
+			Failed:    parseInt64(parts[2]),
+			Retried:   parseInt64(parts[3]),
+		}
+	}
+	return baseline, nil
+}
+
+func formatSpiderStatCounts(c SpiderStatCounts) string {
+	return fmt.Sprintf("%d,%d,%d,%d", c.Total, c.Completed, c.Failed, c.Retried)
+}
+
+// spiderStatsDelta 计算增量；任一计数变小说明统计被清空重建，此时当前值即为增量
+func spiderStatsDelta(cur, last SpiderStatCounts) SpiderStatCounts {
+	if cur.Total < last.Total || cur.Completed < last.Completed || cur.Failed < last.Failed || cur.Retried < last.Retried {
+		return cur
+	}
+	return SpiderStatCounts{
+		Total:     cur.Total - last.Total,
+		Completed: cur.Completed - last.Completed,
+		Failed:    cur.Failed - last.Failed,
+		Retried:   cur.Retried - last.Retried,
+	}
+}
+
+// spiderStatsProjectID 从 spider:<id>:stats 解析项目 ID
+func spiderStatsProjectID(key string) (int, bool) {
+	parts := strings.Split(key, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	id, err := strconv.Atoi(parts[1])
+	return id, err == nil && id > 0
+}
+
+// hourStart 本地时区的整点
+func hourStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+}
+
+// dayStart 本地时区的零点
+func dayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
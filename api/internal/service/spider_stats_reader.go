@@ -0,0 +1,319 @@
+// Package core provides batched, briefly cached reads of the spider realtime stats hashes in Redis
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// spiderStatsCacheTTL 聚合结果缓存时间，仪表盘并发刷新时只读一次 Redis
+	spiderStatsCacheTTL = 3 * time.Second
+	// spiderStatsBatchSize 单个 pipeline 的 HMGET 数量
+	spiderStatsBatchSize = 500
+	// spiderStatsScanCount SCAN 每次返回的键数提示
+	spiderStatsScanCount = 1000
+	// spiderStatsLoadTimeout 单次加载（SCAN + 全部 HMGET）超时
+	spiderStatsLoadTimeout = 10 * time.Second
+)
+
+// spiderStatFields 概览需要的统计字段（HMGET 顺序）
+var spiderStatFields = []string{"total", "completed", "failed", "retried"}
+
+// SpiderStatCounts 项目实时统计计数
+type SpiderStatCounts struct {
+	Total     int64 `json:"total"`
+	Completed int64 `json:"completed"`
+	Failed    int64 `json:"failed"`
+	Retried   int64 `json:"retried"`
+}
+
+// SuccessRate 成功率（百分比，两位小数）
+func (s SpiderStatCounts) SuccessRate() float64 {
+	done := s.Completed + s.Failed
+	if done == 0 {
+		return 0
+	}
+	return math.Round(float64(s.Completed)/float64(done)*10000) / 100
+}
+
+func (s *SpiderStatCounts) add(o SpiderStatCounts) {
+	s.Total += o.Total
+	s.Completed += o.Completed
+	s.Failed += o.Failed
+	s.Retried += o.Retried
+}
+
+// redisOpMetrics 单类 Redis 调用的延迟统计
+type redisOpMetrics struct {
+	Calls   int64         `json:"calls"`
+	Errors  int64         `json:"errors"`
+	Keys    int64         `json:"keys"` // 涉及的键数（pipeline 内命令数）
+	Total   time.Duration `json:"-"`
+	Max     time.Duration `json:"-"`
+	Last    time.Duration `json:"-"`
+	AvgMs   float64       `json:"avg_ms"`
+	MaxMs   float64       `json:"max_ms"`
+	LastMs  float64       `json:"last_ms"`
+	LastErr string        `json:"last_error,omitempty"`
+}
+
+type spiderStatsCacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// spiderStatsCall 进行中的加载（同一缓存键的并发请求等待同一次读取）
+type spiderStatsCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// SpiderStatsReader 读取 spider:<id>:stats 哈希
+//
+// 全部项目概览先 SCAN 出键再分批 pipeline HMGET，按项目统计直接 pipeline HMGET；
+// 结果缓存几秒，并发请求合并为一次读取；每类调用记录延迟供 /api/spider-stats/redis-metrics 查看。
+type SpiderStatsReader struct {
+	rdb *redis.Client
+
+	mu      sync.Mutex
+	cache   map[string]spiderStatsCacheEntry
+	loading map[string]*spiderStatsCall
+	metrics map[string]*redisOpMetrics
+	hits    int64
+	misses  int64
+}
+
+// NewSpiderStatsReader 创建 SpiderStatsReader，rdb 为 nil 时返回 nil
+func NewSpiderStatsReader(rdb *redis.Client) *SpiderStatsReader {
+	if rdb == nil {
+		return nil
+	}
+	return &SpiderStatsReader{
+		rdb:     rdb,
+		cache:   make(map[string]spiderStatsCacheEntry),
+		loading: make(map[string]*spiderStatsCall),
+		metrics: make(map[string]*redisOpMetrics),
+	}
+}
+
+// Overview 汇总统计，projectID 为 0 时汇总全部项目（排除归档和测试键）
+func (r *SpiderStatsReader) Overview(ctx context.Context, projectID int) (SpiderStatCounts, error) {
+	v, err := r.cached(ctx, "overview:"+strconv.Itoa(projectID), func(ctx context.Context) (interface{}, error) {
+		if projectID > 0 {
+			counts, err := r.hmget(ctx, []string{fmt.Sprintf("spider:%d:stats", projectID)})
+			if err != nil {
+				return SpiderStatCounts{}, err
+			}
+			return counts[0], nil
+		}
+		keys, err := r.scanKeys(ctx)
+		if err != nil {
+			return SpiderStatCounts{}, err
+		}
+		counts, err := r.hmget(ctx, keys)
+		if err != nil {
+			return SpiderStatCounts{}, err
+		}
+		var sum SpiderStatCounts
+		for _, c := range counts {
+			sum.add(c)
+		}
+		return sum, nil
+	})
+	if err != nil {
+		return SpiderStatCounts{}, err
+	}
+	return v.(SpiderStatCounts), nil
+}
+
+// ByProject 按项目读取统计，没有统计的项目返回零值
+func (r *SpiderStatsReader) ByProject(ctx context.Context, projectIDs []int) (map[int]SpiderStatCounts, error) {
+	ids := append([]int(nil), projectIDs...)
+	sort.Ints(ids)
+	parts := make([]string, len(ids))
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+		keys[i] = fmt.Sprintf("spider:%d:stats", id)
+	}
+	v, err := r.cached(ctx, "by-project:"+strings.Join(parts, ","), func(ctx context.Context) (interface{}, error) {
+		counts, err := r.hmget(ctx, keys)
+		if err != nil {
+			return nil, err
+		}
+		result := make(map[int]SpiderStatCounts, len(ids))
+		for i, id := range ids {
+			result[id] = counts[i]
+		}
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[int]SpiderStatCounts), nil
+}
+
+// Stats 缓存命中和各类 Redis 调用的延迟统计
+func (r *SpiderStatsReader) Stats() map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ops := make(map[string]redisOpMetrics, len(r.metrics))
+	for name, m := range r.metrics {
+		snapshot := *m
+		if m.Calls > 0 {
+			snapshot.AvgMs = durationMs(m.Total / time.Duration(m.Calls))
+		}
+		snapshot.MaxMs = durationMs(m.Max)
+		snapshot.LastMs = durationMs(m.Last)
+		ops[name] = snapshot
+	}
+	return map[string]interface{}{
+		"cache_ttl_seconds": spiderStatsCacheTTL.Seconds(),
+		"cache_hits":        r.hits,
+		"cache_misses":      r.misses,
+		"cache_entries":     len(r.cache),
+		"ops":               ops,
+	}
+}
+
+// cached 读取缓存，未命中时加载（同一键的并发请求只加载一次）
+// 加载不随发起请求取消，避免一个请求断开导致等待中的其他请求一起失败
+func (r *SpiderStatsReader) cached(ctx context.Context, key string, load func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	r.mu.Lock()
+	if e, ok := r.cache[key]; ok && time.Now().Before(e.expiresAt) {
+		r.hits++
+		r.mu.Unlock()
+		return e.value, nil
+	}
+	if call, ok := r.loading[key]; ok {
+		r.hits++
+		r.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.value, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	r.misses++
+	call := &spiderStatsCall{done: make(chan struct{})}
+	r.loading[key] = call
+	r.mu.Unlock()
+
+	loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), spiderStatsLoadTimeout)
+	call.value, call.err = load(loadCtx)
+	cancel()
+
+	r.mu.Lock()
+	delete(r.loading, key)
+	now := time.Now()
+	for k, e := range r.cache {
+		if now.After(e.expiresAt) {
+			delete(r.cache, k)
+		}
+	}
+	if call.err == nil {
+		r.cache[key] = spiderStatsCacheEntry{value: call.value, expiresAt: now.Add(spiderStatsCacheTTL)}
+	}
+	r.mu.Unlock()
+	close(call.done)
+	return call.value, call.err
+}
+
+// scanKeys 扫描项目统计键（排除 archived 和 test 键）
+func (r *SpiderStatsReader) scanKeys(ctx context.Context) ([]string, error) {
+	start := time.Now()
+	var keys []string
+	iter := r.rdb.Scan(ctx, 0, "spider:*:stats", spiderStatsScanCount).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if strings.Contains(key, ":archived") || strings.HasPrefix(key, "test_spider:") {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	err := iter.Err()
+	r.observe("scan", start, len(keys), err)
+	return keys, err
+}
+
+// hmget 分批 pipeline HMGET，返回与 keys 顺序一致的计数
+func (r *SpiderStatsReader) hmget(ctx context.Context, keys []string) ([]SpiderStatCounts, error) {
+	counts := make([]SpiderStatCounts, len(keys))
+	for offset := 0; offset < len(keys); offset += spiderStatsBatchSize {
+		end := offset + spiderStatsBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		start := time.Now()
+		pipe := r.rdb.Pipeline()
+		cmds := make([]*redis.SliceCmd, 0, end-offset)
+		for _, key := range keys[offset:end] {
+			cmds = append(cmds, pipe.HMGet(ctx, key, spiderStatFields...))
+		}
+		_, err := pipe.Exec(ctx)
+		if err == redis.Nil {
+			err = nil
+		}
+		r.observe("pipeline_hmget", start, end-offset, err)
+		if err != nil {
+			return nil, err
+		}
+		for i, cmd := range cmds {
+			values := cmd.Val()
+			counts[offset+i] = SpiderStatCounts{
+				Total:     statInt(values, 0),
+				Completed: statInt(values, 1),
+				Failed:    statInt(values, 2),
+				Retried:   statInt(values, 3),
+			}
+		}
+	}
+	return counts, nil
+}
+
+func statInt(values []interface{}, i int) int64 {
+	if i >= len(values) {
+		return 0
+	}
+	s, _ := values[i].(string)
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+// observe 记录一次 Redis 调用
+func (r *SpiderStatsReader) observe(op string, start time.Time, keys int, err error) {
+	elapsed := time.Since(start)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.metrics[op]
+	if m == nil {
+		m = &redisOpMetrics{}
+		r.metrics[op] = m
+	}
+	m.Calls++
+	m.Keys += int64(keys)
+	m.Total += elapsed
+	m.Last = elapsed
+	if elapsed > m.Max {
+		m.Max = elapsed
+	}
+	if err != nil {
+		m.Errors++
+		m.LastErr = err.Error()
+	}
+}
+
+func durationMs(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*100) / 100
+}
@@ -0,0 +1,290 @@
+// Package core provides per-spider-type content strategies for page rendering
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// 标题格式占位符
+const (
+	TitleTokenKeyword  = "{keyword}"   // 依次取下一个关键词
+	TitleTokenEmoji    = "{emoji}"     // 随机 Emoji（同一标题内不重复）
+	TitleTokenSiteName = "{site_name}" // 站点名称
+)
+
+// defaultStrategyNamespace 站群配置了策略但当前蜘蛛未匹配时使用的缓存命名空间
+const defaultStrategyNamespace = "default"
+
+// SpiderStrategy 蜘蛛内容策略
+// 同一站群下按蜘蛛类型覆盖模板、关键词分组和标题格式，未设置的字段沿用站点配置
+type SpiderStrategy struct {
+	ID             int            `db:"id" json:"id"`
+	SiteGroupID    int            `db:"site_group_id" json:"site_group_id"`
+	SpiderType     string         `db:"spider_type" json:"spider_type"`
+	Template       sql.NullString `db:"template" json:"-"`
+	KeywordGroupID sql.NullInt64  `db:"keyword_group_id" json:"-"`
+	TitlePattern   sql.NullString `db:"title_pattern" json:"-"`
+	Status         int            `db:"status" json:"status"`
+	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// SpiderStrategyView 接口返回的策略（可空字段展开）
+type SpiderStrategyView struct {
+	*SpiderStrategy
+	TemplateName   *string `json:"template"`
+	KeywordGroup   *int64  `json:"keyword_group_id"`
+	TitleFormat    *string `json:"title_pattern"`
+	CacheNamespace string  `json:"cache_namespace"`
+}
+
+// View 转换为接口返回结构
+func (s *SpiderStrategy) View() SpiderStrategyView {
+	v := SpiderStrategyView{SpiderStrategy: s, CacheNamespace: s.SpiderType}
+	if s.Template.Valid {
+		v.TemplateName = &s.Template.String
+	}
+	if s.KeywordGroupID.Valid {
+		v.KeywordGroup = &s.KeywordGroupID.Int64
+	}
+	if s.TitlePattern.Valid {
+		v.TitleFormat = &s.TitlePattern.String
+	}
+	return v
+}
+
+// ValidateTitlePattern 校验标题格式：至少包含一个 {keyword}，花括号只能是已知占位符
+func ValidateTitlePattern(pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+	if !strings.Contains(pattern, TitleTokenKeyword) {
+		return fmt.Errorf("title pattern must contain %s", TitleTokenKeyword)
+	}
+	rest := pattern
+	for _, token := range []string{TitleTokenKeyword, TitleTokenEmoji, TitleTokenSiteName} {
+		rest = strings.ReplaceAll(rest, token, "")
+	}
+	if strings.ContainsAny(rest, "{}") {
+		return fmt.Errorf("title pattern contains unknown placeholder")
+	}
+	return nil
+}
+
+// TitleKeywordCount 标题格式需要的关键词数量
+func TitleKeywordCount(pattern string) int {
+	return strings.Count(pattern, TitleTokenKeyword)
+}
+
+// RenderTitlePattern 按格式生成标题，关键词不足时循环使用
+func RenderTitlePattern(pattern string, keywords []string, siteName string, emoji func(exclude map[string]bool) string) string {
+	used := make(map[string]bool, 2)
+	var b strings.Builder
+	b.Grow(len(pattern) + 64)
+	next := 0
+	for i := 0; i < len(pattern); {
+		switch {
+		case strings.HasPrefix(pattern[i:], TitleTokenKeyword):
+			if len(keywords) > 0 {
+				b.WriteString(keywords[next%len(keywords)])
+				next++
+			}
+			i += len(TitleTokenKeyword)
+		case strings.HasPrefix(pattern[i:], TitleTokenEmoji):
+			if e := emoji(used); e != "" {
+				used[e] = true
+				b.WriteString(e)
+			}
+			i += len(TitleTokenEmoji)
+		case strings.HasPrefix(pattern[i:], TitleTokenSiteName):
+			b.WriteString(siteName)
+			i += len(TitleTokenSiteName)
+		default:
+			b.WriteByte(pattern[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// ResolvedStrategy 一次请求解析出的策略
+// Strategy 为 nil 表示使用站点默认配置；Namespace 非空时页面缓存使用独立命名空间
+type ResolvedStrategy struct {
+	Strategy  *SpiderStrategy
+	Namespace string
+}
+
+// CachePath 返回带命名空间的缓存路径
+// 命名空间路径不会被 Nginx 直接命中（Nginx 按原始路径查找），由 ServePage 自行读取缓存
+func (r ResolvedStrategy) CachePath(path string) string {
+	if r.Namespace == "" {
+		return path
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return "/@" + r.Namespace + path
+}
+
+// strategyStatKey 策略统计键
+type strategyStatKey struct {
+	SiteGroupID int
+	SpiderType  string
+}
+
+type strategyCounters struct {
+	requests  atomic.Int64
+	cacheHits atomic.Int64
+	renders   atomic.Int64
+}
+
+// SpiderStrategyStats 按站群和蜘蛛类型拆分的请求统计
+type SpiderStrategyStats struct {
+	SiteGroupID int    `json:"site_group_id"`
+	SpiderType  string `json:"spider_type"`
+	Namespace   string `json:"namespace"`
+	Requests    int64  `json:"requests"`
+	CacheHits   int64  `json:"cache_hits"`
+	Renders     int64  `json:"renders"`
+}
+
+// SpiderStrategyResolver 蜘蛛内容策略解析器
+// 策略存储在数据库，启动和修改后整体重新加载，解析无锁
+type SpiderStrategyResolver struct {
+	db *sqlx.DB
+
+	rules atomic.Pointer[map[int]map[string]*SpiderStrategy]
+
+	statsMu sync.RWMutex
+	stats   map[strategyStatKey]*strategyCounters
+}
+
+// NewSpiderStrategyResolver 创建策略解析器
+func NewSpiderStrategyResolver(db *sqlx.DB) *SpiderStrategyResolver {
+	r := &SpiderStrategyResolver{db: db, stats: make(map[strategyStatKey]*strategyCounters)}
+	empty := map[int]map[string]*SpiderStrategy{}
+	r.rules.Store(&empty)
+	return r
+}
+
+// Reload 从数据库重新加载启用的策略
+func (r *SpiderStrategyResolver) Reload(ctx context.Context) error {
+	var rows []*SpiderStrategy
+	if err := r.db.SelectContext(ctx, &rows, `
+		SELECT id, site_group_id, spider_type, template, keyword_group_id, title_pattern, status, created_at, updated_at
+		FROM spider_strategies WHERE status = 1`); err != nil {
+		return fmt.Errorf("load spider strategies: %w", err)
+	}
+
+	rules := make(map[int]map[string]*SpiderStrategy)
+	for _, s := range rows {
+		if rules[s.SiteGroupID] == nil {
+			rules[s.SiteGroupID] = make(map[string]*SpiderStrategy)
+		}
+		rules[s.SiteGroupID][s.SpiderType] = s
+	}
+	r.rules.Store(&rules)
+
+	log.Info().Int("strategies", len(rows)).Int("site_groups", len(rules)).Msg("Spider strategies loaded")
+	return nil
+}
+
+// Resolve 按站群和蜘蛛类型解析策略
+// 站群没有任何策略时返回空结果（沿用原缓存路径）；有策略但蜘蛛未匹配时使用 default 命名空间，
+// 避免默认页面被 Nginx 直接返回给配置了策略的蜘蛛
+func (r *SpiderStrategyResolver) Resolve(siteGroupID int, spiderType string) ResolvedStrategy {
+	if r == nil {
+		return ResolvedStrategy{}
+	}
+	group := (*r.rules.Load())[siteGroupID]
+	if len(group) == 0 {
+		return ResolvedStrategy{}
+	}
+	if s, ok := group[spiderType]; ok {
+		return ResolvedStrategy{Strategy: s, Namespace: s.SpiderType}
+	}
+	return ResolvedStrategy{Namespace: defaultStrategyNamespace}
+}
+
+// CachePaths 返回路径在站群下所有可能的缓存路径（原路径、各策略命名空间和 default 命名空间），用于失效缓存
+func (r *SpiderStrategyResolver) CachePaths(siteGroupID int, path string) []string {
+	paths := []string{path}
+	if r == nil {
+		return paths
+	}
+	group := (*r.rules.Load())[siteGroupID]
+	if len(group) == 0 {
+		return paths
+	}
+	paths = append(paths, ResolvedStrategy{Namespace: defaultStrategyNamespace}.CachePath(path))
+	for _, s := range group {
+		paths = append(paths, ResolvedStrategy{Namespace: s.SpiderType}.CachePath(path))
+	}
+	return paths
+}
+
+// Record 记录一次请求（cacheHit=false 表示重新渲染）
+func (r *SpiderStrategyResolver) Record(siteGroupID int, spiderType string, cacheHit bool) {
+	if r == nil {
+		return
+	}
+	key := strategyStatKey{SiteGroupID: siteGroupID, SpiderType: spiderType}
+	r.statsMu.RLock()
+	c := r.stats[key]
+	r.statsMu.RUnlock()
+	if c == nil {
+		r.statsMu.Lock()
+		if c = r.stats[key]; c == nil {
+			c = &strategyCounters{}
+			r.stats[key] = c
+		}
+		r.statsMu.Unlock()
+	}
+
+	c.requests.Add(1)
+	if cacheHit {
+		c.cacheHits.Add(1)
+	} else {
+		c.renders.Add(1)
+	}
+}
+
+// Stats 返回按站群和蜘蛛类型拆分的统计（siteGroupID 为 0 时返回全部）
+func (r *SpiderStrategyResolver) Stats(siteGroupID int) []SpiderStrategyStats {
+	r.statsMu.RLock()
+	out := make([]SpiderStrategyStats, 0, len(r.stats))
+	for key, c := range r.stats {
+		if siteGroupID > 0 && key.SiteGroupID != siteGroupID {
+			continue
+		}
+		out = append(out, SpiderStrategyStats{
+			SiteGroupID: key.SiteGroupID,
+			SpiderType:  key.SpiderType,
+			Requests:    c.requests.Load(),
+			CacheHits:   c.cacheHits.Load(),
+			Renders:     c.renders.Load(),
+		})
+	}
+	r.statsMu.RUnlock()
+
+	for i := range out {
+		out[i].Namespace = r.Resolve(out[i].SiteGroupID, out[i].SpiderType).Namespace
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SiteGroupID != out[j].SiteGroupID {
+			return out[i].SiteGroupID < out[j].SiteGroupID
+		}
+		return out[i].Requests > out[j].Requests
+	})
+	return out
+}
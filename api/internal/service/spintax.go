@@ -0,0 +1,189 @@
+// Package core provides spintax expansion and synonym rewriting
+package core
+
+import (
+	"bufio"
+	"math/rand"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// spintaxGroupPattern 最内层的 spintax 分组（不含嵌套花括号且至少有一个 |）
+var spintaxGroupPattern = regexp.MustCompile(`\{([^{}]*\|[^{}]*)\}`)
+
+// spintaxMaxDepth spintax 最大嵌套层数
+const spintaxMaxDepth = 8
+
+// ExpandSpintax 展开 spintax：{a|b|c} 随机取一项，支持嵌套；不含 | 的花括号原样保留
+func ExpandSpintax(text string, pick func(n int) int) string {
+	for i := 0; i < spintaxMaxDepth && strings.Contains(text, "|"); i++ {
+		expanded := spintaxGroupPattern.ReplaceAllStringFunc(text, func(group string) string {
+			options := strings.Split(group[1:len(group)-1], "|")
+			return options[pick(len(options))]
+		})
+		if expanded == text {
+			break
+		}
+		text = expanded
+	}
+	return text
+}
+
+// SpintaxRewriter 正文伪原创：展开正文中的 spintax，再按同义词表替换词语
+//
+// 同义词替换以分词结果为单位，"上海"不会命中"上海滩"；同义词登记到分词词典，保证按整词切分。
+// 只改写 HTML 文本节点，标签和属性不变。同义词表由 spintax.synonym_files 加载，随分词词典一起重新加载。
+type SpintaxRewriter struct {
+	seg    *TextSegmenter
+	config config.SpintaxConfig
+
+	synonyms atomic.Pointer[map[string][]string] // 词 -> 同组的其他词
+	groups   atomic.Int64
+
+	rewritten atomic.Int64 // 改写的页面数
+	replaced  atomic.Int64 // 替换的词数
+}
+
+// NewSpintaxRewriter 创建伪原创改写器（需调用 Reload 加载同义词表）
+func NewSpintaxRewriter(seg *TextSegmenter, cfg config.SpintaxConfig) *SpintaxRewriter {
+	if cfg.Ratio <= 0 || cfg.Ratio > 1 {
+		cfg.Ratio = 0.5
+	}
+	r := &SpintaxRewriter{seg: seg, config: cfg}
+	empty := map[string][]string{}
+	r.synonyms.Store(&empty)
+	return r
+}
+
+// Enabled 是否启用
+func (r *SpintaxRewriter) Enabled() bool {
+	return r != nil && r.config.Enabled
+}
+
+// Reload 重新加载同义词表，读取失败的文件只记录日志
+func (r *SpintaxRewriter) Reload() {
+	synonyms := make(map[string][]string)
+	groups := 0
+	var words []string
+	for _, path := range r.config.SynonymFiles {
+		n, err := loadSynonymFile(path, synonyms, &words)
+		if err != nil {
+			log.Warn().Err(err).Str("file", path).Msg("Failed to load synonym file")
+			continue
+		}
+		groups += n
+	}
+	r.synonyms.Store(&synonyms)
+	r.groups.Store(int64(groups))
+	if r.seg != nil {
+		r.seg.AddRuntimeWords(words)
+	}
+	log.Info().Int("groups", groups).Int("words", len(synonyms)).Msg("Spintax synonyms loaded")
+}
+
+// loadSynonymFile 读取同义词表，每行一组，词之间用 |、逗号或空白分隔，可整体包在花括号中
+func loadSynonymFile(path string, synonyms map[string][]string, words *[]string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	n := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSuffix(strings.TrimPrefix(line, "{"), "}")
+		group := strings.FieldsFunc(line, func(r rune) bool {
+			return r == '|' || r == ',' || r == '，' || unicode.IsSpace(r)
+		})
+		if len(group) < 2 {
+			continue
+		}
+		for i, w := range group {
+			others := make([]string, 0, len(group)-1)
+			others = append(others, group[:i]...)
+			others = append(others, group[i+1:]...)
+			synonyms[w] = append(synonyms[w], others...)
+		}
+		*words = append(*words, group...)
+		n++
+	}
+	return n, scanner.Err()
+}
+
+// Rewrite 渲染时改写 HTML 正文，未启用时原样返回
+func (r *SpintaxRewriter) Rewrite(content string) string {
+	if !r.Enabled() || content == "" {
+		return content
+	}
+	out, replaced := r.Spin(content)
+	r.rewritten.Add(1)
+	r.replaced.Add(int64(replaced))
+	return out
+}
+
+// Spin 改写 HTML 正文的文本节点（不检查是否启用，供预览使用），返回改写结果和替换的词数
+func (r *SpintaxRewriter) Spin(content string) (string, int) {
+	replaced := 0
+	var b strings.Builder
+	b.Grow(len(content))
+	last := 0
+	for _, loc := range htmlTagPattern.FindAllStringIndex(content, -1) {
+		b.WriteString(r.rewriteText(content[last:loc[0]], &replaced))
+		b.WriteString(content[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(r.rewriteText(content[last:], &replaced))
+	return b.String(), replaced
+}
+
+// rewriteText 展开 spintax 并替换同义词
+func (r *SpintaxRewriter) rewriteText(text string, replaced *int) string {
+	if strings.TrimSpace(text) == "" {
+		return text
+	}
+	text = ExpandSpintax(text, rand.Intn)
+
+	synonyms := *r.synonyms.Load()
+	if len(synonyms) == 0 || r.seg == nil {
+		return text
+	}
+	tokens := r.seg.Tokens(text)
+	changed := false
+	for i, t := range tokens {
+		options := synonyms[t]
+		if len(options) == 0 || rand.Float64() >= r.config.Ratio {
+			continue
+		}
+		tokens[i] = options[rand.Intn(len(options))]
+		changed = true
+		*replaced++
+	}
+	if !changed {
+		return text
+	}
+	return strings.Join(tokens, "")
+}
+
+// Stats 同义词表和改写统计
+func (r *SpintaxRewriter) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		"enabled":   r.Enabled(),
+		"groups":    r.groups.Load(),
+		"words":     len(*r.synonyms.Load()),
+		"ratio":     r.config.Ratio,
+		"rewritten": r.rewritten.Load(),
+		"replaced":  r.replaced.Load(),
+	}
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"seo-generator/api/pkg/config"
+)
+
+func TestExpandSpintax(t *testing.T) {
+	first := func(n int) int { return 0 }
+	last := func(n int) int { return n - 1 }
+
+	tests := []struct {
+		name string
+		text string
+		pick func(n int) int
+		want string
+	}{
+		{"no spintax", "普通文本", first, "普通文本"},
+		{"first option", "{快速|迅速|飞快}地", first, "快速地"},
+		{"last option", "{快速|迅速|飞快}地", last, "飞快地"},
+		{"multiple groups", "{a|b} and {c|d}", last, "b and d"},
+		{"nested", "{好{很|非常}|棒}", first, "好很"},
+		{"nested inner last", "{好{很|非常}|棒}", last, "棒"},
+		{"braces without pipe kept", "{name} 与 {a|b}", first, "{name} 与 a"},
+		{"pipe outside braces kept", "a|b", first, "a|b"},
+		{"empty option", "x{|y}", first, "x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandSpintax(tt.text, tt.pick); got != tt.want {
+				t.Errorf("ExpandSpintax(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpintaxRewriter_Spin(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "synonyms.txt")
+	data := "# 注释\n美丽|漂亮\n{迅速|快速}\n单个\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	seg := NewTextSegmenter(nil, config.SegmenterConfig{})
+	r := NewSpintaxRewriter(seg, config.SpintaxConfig{SynonymFiles: []string{path}, Ratio: 1})
+	r.Reload()
+
+	if got := r.Stats()["groups"]; got != int64(2) {
+		t.Errorf("groups = %v, want 2", got)
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		want     string
+		replaced int
+	}{
+		{"empty", "", "", 0},
+		{"synonym replaced", "美丽的风景", "漂亮的风景", 1},
+		{"both directions", "漂亮，迅速", "美丽，快速", 2},
+		{"tags untouched", `<img alt="美丽"><p>美丽</p>`, `<img alt="美丽"><p>漂亮</p>`, 1},
+		{"spintax expanded", "<p>{美丽|美丽}</p>", "<p>漂亮</p>", 1},
+		{"no synonym", "普通文本", "普通文本", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, replaced := r.Spin(tt.content)
+			if got != tt.want {
+				t.Errorf("Spin(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+			if replaced != tt.replaced {
+				t.Errorf("replaced = %d, want %d", replaced, tt.replaced)
+			}
+		})
+	}
+}
+
+// TestSpintaxRewriter_RewriteDisabled 未启用时 Rewrite 原样返回
+func TestSpintaxRewriter_RewriteDisabled(t *testing.T) {
+	r := NewSpintaxRewriter(nil, config.SpintaxConfig{})
+	content := "{a|b}"
+	if got := r.Rewrite(content); got != content {
+		t.Errorf("Rewrite = %q, want %q", got, content)
+	}
+
+	var nilRewriter *SpintaxRewriter
+	if got := nilRewriter.Rewrite(content); got != content {
+		t.Errorf("nil Rewrite = %q, want %q", got, content)
+	}
+}
@@ -0,0 +1,260 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// 系统指标历史的精度
+const (
+	MetricsResolutionMinute = "1m"
+	MetricsResolution5Min   = "5m"
+	MetricsResolutionHour   = "1h"
+)
+
+// SystemMetricPoint 一个时间段的系统指标（system_metrics 表）
+// 1m 为每分钟采样一次，5m / 1h 为下一级精度的平均值（最大延迟和磁盘使用率取最大值）
+type SystemMetricPoint struct {
+	Resolution      string    `db:"resolution" json:"resolution"`
+	PeriodStart     time.Time `db:"period_start" json:"time"`
+	CPUPercent      float64   `db:"cpu_percent" json:"cpu_percent"`
+	MemoryPercent   float64   `db:"memory_percent" json:"memory_percent"`
+	MemoryUsedBytes int64     `db:"memory_used_bytes" json:"memory_used_bytes"`
+	Load1           float64   `db:"load1" json:"load1"`
+	Load5           float64   `db:"load5" json:"load5"`
+	Load15          float64   `db:"load15" json:"load15"`
+	NetSentBps      int64     `db:"net_sent_bps" json:"net_sent_bps"`
+	NetRecvBps      int64     `db:"net_recv_bps" json:"net_recv_bps"`
+	DiskPercent     float64   `db:"disk_percent" json:"disk_percent"` // 使用率最高的磁盘
+	QPS             float64   `db:"qps" json:"qps"`
+	AvgLatencyMs    float64   `db:"avg_latency_ms" json:"avg_latency_ms"`
+	MaxLatencyMs    float64   `db:"max_latency_ms" json:"max_latency_ms"`
+	CacheHitRate    float64   `db:"cache_hit_rate" json:"cache_hit_rate"`
+	Goroutines      int       `db:"goroutines" json:"goroutines"`
+	HeapAllocBytes  int64     `db:"heap_alloc_bytes" json:"heap_alloc_bytes"`
+	Samples         int       `db:"samples" json:"samples"` // 聚合的下级数据点数
+}
+
+// metricsDownsample 降采样层级：source 精度的数据按 step 聚合为 target 精度
+var metricsDownsample = []struct {
+	source, target string
+	step           time.Duration
+}{
+	{MetricsResolutionMinute, MetricsResolution5Min, 5 * time.Minute},
+	{MetricsResolution5Min, MetricsResolutionHour, time.Hour},
+}
+
+// SystemMetricsStore 系统指标历史持久化
+//
+// 每分钟把系统资源（SystemStatsCollector）和请求指标（Monitor）写入 system_metrics，
+// 并逐级降采样为 5 分钟、1 小时精度；各精度按配置的天数保留，重启后历史不丢失
+type SystemMetricsStore struct {
+	db        *sqlx.DB
+	collector *SystemStatsCollector
+	monitor   *Monitor
+	config    config.SystemMetricsConfig
+}
+
+// NewSystemMetricsStore 创建系统指标历史存储
+func NewSystemMetricsStore(db *sqlx.DB, collector *SystemStatsCollector, monitor *Monitor, cfg config.SystemMetricsConfig) *SystemMetricsStore {
+	if cfg.MinuteRetentionDays <= 0 {
+		cfg.MinuteRetentionDays = 2
+	}
+	if cfg.FiveMinRetentionDays <= 0 {
+		cfg.FiveMinRetentionDays = 14
+	}
+	if cfg.HourRetentionDays <= 0 {
+		cfg.HourRetentionDays = 365
+	}
+	return &SystemMetricsStore{db: db, collector: collector, monitor: monitor, config: cfg}
+}
+
+// Start 每分钟采样一次，运行到 ctx 取消
+func (s *SystemMetricsStore) Start(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	var lastCleanup time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.record(ctx, now); err != nil {
+				log.Warn().Err(err).Msg("Failed to record system metrics")
+			}
+			if err := s.downsample(ctx, now); err != nil {
+				log.Warn().Err(err).Msg("Failed to downsample system metrics")
+			}
+			if now.Sub(lastCleanup) >= time.Hour {
+				s.cleanup(ctx, now)
+				lastCleanup = now
+			}
+		}
+	}
+}
+
+// record 写入当前分钟的采样
+func (s *SystemMetricsStore) record(ctx context.Context, now time.Time) error {
+	point := SystemMetricPoint{
+		Resolution:  MetricsResolutionMinute,
+		PeriodStart: now.Truncate(time.Minute),
+		Samples:     1,
+	}
+	if s.collector != nil {
+		stats, err := s.collector.Collect()
+		if err != nil {
+			return fmt.Errorf("collect system stats: %w", err)
+		}
+		point.CPUPercent = stats.CPU.UsagePercent
+		point.MemoryPercent = stats.Memory.UsagePercent
+		point.MemoryUsedBytes = int64(stats.Memory.UsedBytes)
+		point.Load1, point.Load5, point.Load15 = stats.Load.Load1, stats.Load.Load5, stats.Load.Load15
+		point.NetSentBps = int64(stats.Network.BytesSentPerSec)
+		point.NetRecvBps = int64(stats.Network.BytesRecvPerSec)
+		for _, d := range stats.Disks {
+			point.DiskPercent = max(point.DiskPercent, d.UsagePercent)
+		}
+	}
+	if s.monitor != nil {
+		snapshot := s.monitor.GetCurrentSnapshot()
+		point.QPS = snapshot.QPS
+		point.AvgLatencyMs = snapshot.AvgLatencyMs
+		point.MaxLatencyMs = snapshot.MaxLatencyMs
+		point.CacheHitRate = snapshot.CacheHitRate
+		point.Goroutines = snapshot.NumGoroutine
+		point.HeapAllocBytes = int64(snapshot.HeapAllocBytes)
+	}
+
+	_, err := s.db.NamedExecContext(ctx, `
+		INSERT INTO system_metrics (resolution, period_start, cpu_percent, memory_percent, memory_used_bytes,
+			load1, load5, load15, net_sent_bps, net_recv_bps, disk_percent, qps, avg_latency_ms, max_latency_ms,
+			cache_hit_rate, goroutines, heap_alloc_bytes, samples)
+		VALUES (:resolution, :period_start, :cpu_percent, :memory_percent, :memory_used_bytes,
+			:load1, :load5, :load15, :net_sent_bps, :net_recv_bps, :disk_percent, :qps, :avg_latency_ms, :max_latency_ms,
+			:cache_hit_rate, :goroutines, :heap_alloc_bytes, :samples)
+		ON DUPLICATE KEY UPDATE
+			cpu_percent = VALUES(cpu_percent),
+			memory_percent = VALUES(memory_percent),
+			memory_used_bytes = VALUES(memory_used_bytes),
+			load1 = VALUES(load1),
+			load5 = VALUES(load5),
+			load15 = VALUES(load15),
+			net_sent_bps = VALUES(net_sent_bps),
+			net_recv_bps = VALUES(net_recv_bps),
+			disk_percent = VALUES(disk_percent),
+			qps = VALUES(qps),
+			avg_latency_ms = VALUES(avg_latency_ms),
+			max_latency_ms = VALUES(max_latency_ms),
+			cache_hit_rate = VALUES(cache_hit_rate),
+			goroutines = VALUES(goroutines),
+			heap_alloc_bytes = VALUES(heap_alloc_bytes)
+	`, point)
+	return err
+}
+
+// downsample 聚合已结束的 5 分钟和小时时间段
+// 每次重算最近两个时间段（ON DUPLICATE KEY UPDATE 可重复执行），错过一次也能补上
+func (s *SystemMetricsStore) downsample(ctx context.Context, now time.Time) error {
+	for _, level := range metricsDownsample {
+		current := now.Truncate(level.step)
+		for _, start := range []time.Time{current.Add(-2 * level.step), current.Add(-level.step)} {
+			if err := s.aggregate(ctx, level.source, level.target, start, start.Add(level.step)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// aggregate 将 [start, end) 内 source 精度的数据聚合为一条 target 精度的数据
+func (s *SystemMetricsStore) aggregate(ctx context.Context, source, target string, start, end time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO system_metrics (resolution, period_start, cpu_percent, memory_percent, memory_used_bytes,
+			load1, load5, load15, net_sent_bps, net_recv_bps, disk_percent, qps, avg_latency_ms, max_latency_ms,
+			cache_hit_rate, goroutines, heap_alloc_bytes, samples)
+		SELECT ?, ?, AVG(cpu_percent), AVG(memory_percent), AVG(memory_used_bytes),
+			AVG(load1), AVG(load5), AVG(load15), AVG(net_sent_bps), AVG(net_recv_bps), MAX(disk_percent),
+			AVG(qps), AVG(avg_latency_ms), MAX(max_latency_ms), AVG(cache_hit_rate), AVG(goroutines),
+			AVG(heap_alloc_bytes), COUNT(*)
+		FROM system_metrics
+		WHERE resolution = ? AND period_start >= ? AND period_start < ?
+		HAVING COUNT(*) > 0
+		ON DUPLICATE KEY UPDATE
+			cpu_percent = VALUES(cpu_percent),
+			memory_percent = VALUES(memory_percent),
+			memory_used_bytes = VALUES(memory_used_bytes),
+			load1 = VALUES(load1),
+			load5 = VALUES(load5),
+			load15 = VALUES(load15),
+			net_sent_bps = VALUES(net_sent_bps),
+			net_recv_bps = VALUES(net_recv_bps),
+			disk_percent = VALUES(disk_percent),
+			qps = VALUES(qps),
+			avg_latency_ms = VALUES(avg_latency_ms),
+			max_latency_ms = VALUES(max_latency_ms),
+			cache_hit_rate = VALUES(cache_hit_rate),
+			goroutines = VALUES(goroutines),
+			heap_alloc_bytes = VALUES(heap_alloc_bytes),
+			samples = VALUES(samples)
+	`, target, start, source, start, end)
+	return err
+}
+
+// cleanup 按各精度的保留天数删除过期数据
+func (s *SystemMetricsStore) cleanup(ctx context.Context, now time.Time) {
+	for resolution, days := range map[string]int{
+		MetricsResolutionMinute: s.config.MinuteRetentionDays,
+		MetricsResolution5Min:   s.config.FiveMinRetentionDays,
+		MetricsResolutionHour:   s.config.HourRetentionDays,
+	} {
+		cutoff := now.AddDate(0, 0, -days)
+		if _, err := s.db.ExecContext(ctx, "DELETE FROM system_metrics WHERE resolution = ? AND period_start < ?", resolution, cutoff); err != nil {
+			log.Warn().Err(err).Str("resolution", resolution).Msg("Failed to cleanup system metrics")
+		}
+	}
+}
+
+// ResolutionFor 按时间跨度和数据保留期选择精度（未指定精度时使用）
+// 6 小时内用 1m，3 天内用 5m，更长用 1h；起点早于该精度保留期时改用更粗的精度
+func (s *SystemMetricsStore) ResolutionFor(start, end time.Time) string {
+	span := end.Sub(start)
+	age := time.Since(start)
+	switch {
+	case span <= 6*time.Hour && age <= time.Duration(s.config.MinuteRetentionDays)*24*time.Hour:
+		return MetricsResolutionMinute
+	case span <= 72*time.Hour && age <= time.Duration(s.config.FiveMinRetentionDays)*24*time.Hour:
+		return MetricsResolution5Min
+	default:
+		return MetricsResolutionHour
+	}
+}
+
+// ValidResolution 是否为支持的精度
+func ValidResolution(resolution string) bool {
+	switch resolution {
+	case MetricsResolutionMinute, MetricsResolution5Min, MetricsResolutionHour:
+		return true
+	}
+	return false
+}
+
+// Query 查询 [start, end) 内指定精度的历史指标，resolution 为空时按时间跨度自动选择
+func (s *SystemMetricsStore) Query(ctx context.Context, start, end time.Time, resolution string) ([]SystemMetricPoint, string, error) {
+	if resolution == "" {
+		resolution = s.ResolutionFor(start, end)
+	}
+	points := []SystemMetricPoint{}
+	err := s.db.SelectContext(ctx, &points, `
+		SELECT * FROM system_metrics
+		WHERE resolution = ? AND period_start >= ? AND period_start < ?
+		ORDER BY period_start ASC
+	`, resolution, start, end)
+	return points, resolution, err
+}
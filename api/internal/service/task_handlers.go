@@ -200,14 +200,24 @@ func (h *RunSpiderHandler) Handle(task *ScheduledTask) TaskResult {
 		}
 	}
 
+	limits, err := LoadSpiderLimits(h.db, params.ProjectID)
+	if err != nil {
+		return TaskResult{
+			Success:  false,
+			Message:  fmt.Sprintf("读取资源限制失败: %v", err),
+			Duration: time.Since(startTime).Milliseconds(),
+		}
+	}
+
 	// 更新状态为 running
-	h.db.Exec("UPDATE spider_projects SET status = 'running' WHERE id = ?", params.ProjectID)
+	h.db.Exec("UPDATE spider_projects SET status = 'running', run_started_at = NOW() WHERE id = ?", params.ProjectID)
 
 	// 使用现有的 SpiderCommand 结构体
 	cmd := models.SpiderCommand{
 		Action:    "run",
 		ProjectID: params.ProjectID,
 		Timestamp: time.Now().Unix(),
+		Limits:    limits,
 	}
 	cmdJSON, _ := json.Marshal(cmd)
 
@@ -228,6 +238,275 @@ func (h *RunSpiderHandler) Handle(task *ScheduledTask) TaskResult {
 	}
 }
 
+// BackupHandler 定时备份处理器
+type BackupHandler struct {
+	backups *BackupManager
+}
+
+// NewBackupHandler 创建定时备份处理器
+func NewBackupHandler(backups *BackupManager) *BackupHandler {
+	return &BackupHandler{backups: backups}
+}
+
+// TaskType 返回任务类型
+func (h *BackupHandler) TaskType() TaskType {
+	return TaskTypeBackup
+}
+
+// Handle 执行定时备份任务
+func (h *BackupHandler) Handle(task *ScheduledTask) TaskResult {
+	startTime := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+	defer cancel()
+
+	path, size, err := h.backups.RunScheduled(ctx)
+	if err != nil {
+		return TaskResult{
+			Success:  false,
+			Message:  fmt.Sprintf("backup failed: %v", err),
+			Duration: time.Since(startTime).Milliseconds(),
+		}
+	}
+
+	return TaskResult{
+		Success:  true,
+		Message:  fmt.Sprintf("backup saved: %s (%d bytes)", path, size),
+		Duration: time.Since(startTime).Milliseconds(),
+	}
+}
+
+// KeywordImportHandler 搜索词导入处理器
+type KeywordImportHandler struct {
+	importer *KeywordImporter
+}
+
+// NewKeywordImportHandler 创建搜索词导入处理器
+func NewKeywordImportHandler(importer *KeywordImporter) *KeywordImportHandler {
+	return &KeywordImportHandler{importer: importer}
+}
+
+// TaskType 返回任务类型
+func (h *KeywordImportHandler) TaskType() TaskType {
+	return TaskTypeImportSearchQueries
+}
+
+// Handle 从所有已配置的来源导入搜索词
+func (h *KeywordImportHandler) Handle(task *ScheduledTask) TaskResult {
+	startTime := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	results, err := h.importer.RunAll(ctx)
+	candidates := 0
+	for _, r := range results {
+		candidates += r.Candidates
+	}
+	if err != nil {
+		return TaskResult{
+			Success:  false,
+			Message:  fmt.Sprintf("import failed: %v (candidates: %d)", err, candidates),
+			Duration: time.Since(startTime).Milliseconds(),
+		}
+	}
+
+	return TaskResult{
+		Success:  true,
+		Message:  fmt.Sprintf("imported %d sources, %d candidates", len(results), candidates),
+		Duration: time.Since(startTime).Milliseconds(),
+	}
+}
+
+// ExpandKeywordsHandler 长尾关键词扩展处理器
+// 任务参数见 ExpandKeywordsParams，扩展在后台作业中执行，进度和去重统计记录在作业中
+type ExpandKeywordsHandler struct {
+	expander *KeywordExpander
+}
+
+// NewExpandKeywordsHandler 创建长尾关键词扩展处理器
+func NewExpandKeywordsHandler(expander *KeywordExpander) *ExpandKeywordsHandler {
+	return &ExpandKeywordsHandler{expander: expander}
+}
+
+// TaskType 返回任务类型
+func (h *ExpandKeywordsHandler) TaskType() TaskType {
+	return TaskTypeExpandKeywords
+}
+
+// Handle 提交扩展作业
+func (h *ExpandKeywordsHandler) Handle(task *ScheduledTask) TaskResult {
+	startTime := time.Now()
+
+	params, err := ParseExpandKeywordsParams(task.Params)
+	if err != nil {
+		return TaskResult{
+			Success:  false,
+			Message:  fmt.Sprintf("参数解析失败: %v", err),
+			Duration: time.Since(startTime).Milliseconds(),
+		}
+	}
+
+	jobID, err := h.expander.Submit(context.Background(), params)
+	if err != nil {
+		return TaskResult{
+			Success:  false,
+			Message:  fmt.Sprintf("提交扩展作业失败: %v", err),
+			Duration: time.Since(startTime).Milliseconds(),
+		}
+	}
+
+	return TaskResult{
+		Success:  true,
+		Message:  fmt.Sprintf("已提交扩展作业 #%d（目标分组 %d）", jobID, params.TargetGroupID),
+		Duration: time.Since(startTime).Milliseconds(),
+	}
+}
+
+// ArchiveContentsHandler 冷正文归档处理器
+// 归档在后台作业中执行，进度和结果记录在作业中
+type ArchiveContentsHandler struct {
+	archiver *ContentArchiver
+}
+
+// NewArchiveContentsHandler 创建冷正文归档处理器
+func NewArchiveContentsHandler(archiver *ContentArchiver) *ArchiveContentsHandler {
+	return &ArchiveContentsHandler{archiver: archiver}
+}
+
+// TaskType 返回任务类型
+func (h *ArchiveContentsHandler) TaskType() TaskType {
+	return TaskTypeArchiveContents
+}
+
+// Handle 提交归档作业
+func (h *ArchiveContentsHandler) Handle(task *ScheduledTask) TaskResult {
+	startTime := time.Now()
+
+	jobID, err := h.archiver.Submit(context.Background())
+	if err != nil {
+		return TaskResult{
+			Success:  false,
+			Message:  fmt.Sprintf("提交归档作业失败: %v", err),
+			Duration: time.Since(startTime).Milliseconds(),
+		}
+	}
+
+	return TaskResult{
+		Success:  true,
+		Message:  fmt.Sprintf("已提交归档作业 #%d", jobID),
+		Duration: time.Since(startTime).Milliseconds(),
+	}
+}
+
+// FreshnessRefreshHandler 内容保鲜处理器
+// 保鲜在后台作业中执行，进度和结果记录在作业中
+type FreshnessRefreshHandler struct {
+	freshness *Freshness
+}
+
+// NewFreshnessRefreshHandler 创建内容保鲜处理器
+func NewFreshnessRefreshHandler(freshness *Freshness) *FreshnessRefreshHandler {
+	return &FreshnessRefreshHandler{freshness: freshness}
+}
+
+// TaskType 返回任务类型
+func (h *FreshnessRefreshHandler) TaskType() TaskType {
+	return TaskTypeFreshnessRefresh
+}
+
+// Handle 提交保鲜作业（处理所有启用的站群）
+func (h *FreshnessRefreshHandler) Handle(task *ScheduledTask) TaskResult {
+	startTime := time.Now()
+
+	jobID, err := h.freshness.Submit(context.Background(), 0)
+	if err != nil {
+		return TaskResult{
+			Success:  false,
+			Message:  fmt.Sprintf("提交保鲜作业失败: %v", err),
+			Duration: time.Since(startTime).Milliseconds(),
+		}
+	}
+
+	return TaskResult{
+		Success:  true,
+		Message:  fmt.Sprintf("已提交保鲜作业 #%d", jobID),
+		Duration: time.Since(startTime).Milliseconds(),
+	}
+}
+
+// ActivityRollupHandler 内容增量每日汇总处理器
+// 汇总在后台作业中执行，补齐 backfill_days 内所有未汇总的日期
+type ActivityRollupHandler struct {
+	stats *ActivityStats
+}
+
+// NewActivityRollupHandler 创建内容增量汇总处理器
+func NewActivityRollupHandler(stats *ActivityStats) *ActivityRollupHandler {
+	return &ActivityRollupHandler{stats: stats}
+}
+
+// TaskType 返回任务类型
+func (h *ActivityRollupHandler) TaskType() TaskType {
+	return TaskTypeActivityRollup
+}
+
+// Handle 提交汇总作业
+func (h *ActivityRollupHandler) Handle(task *ScheduledTask) TaskResult {
+	startTime := time.Now()
+
+	jobID, err := h.stats.Submit(context.Background(), time.Time{})
+	if err != nil {
+		return TaskResult{
+			Success:  false,
+			Message:  fmt.Sprintf("提交内容增量汇总作业失败: %v", err),
+			Duration: time.Since(startTime).Milliseconds(),
+		}
+	}
+
+	return TaskResult{
+		Success:  true,
+		Message:  fmt.Sprintf("已提交内容增量汇总作业 #%d", jobID),
+		Duration: time.Since(startTime).Milliseconds(),
+	}
+}
+
+// BackfillSummariesHandler 文章摘要回填处理器
+// 回填在后台作业中执行，进度和结果记录在作业中
+type BackfillSummariesHandler struct {
+	backfill *SummaryBackfill
+}
+
+// NewBackfillSummariesHandler 创建文章摘要回填处理器
+func NewBackfillSummariesHandler(backfill *SummaryBackfill) *BackfillSummariesHandler {
+	return &BackfillSummariesHandler{backfill: backfill}
+}
+
+// TaskType 返回任务类型
+func (h *BackfillSummariesHandler) TaskType() TaskType {
+	return TaskTypeBackfillSummaries
+}
+
+// Handle 提交回填作业
+func (h *BackfillSummariesHandler) Handle(task *ScheduledTask) TaskResult {
+	startTime := time.Now()
+
+	jobID, err := h.backfill.Submit(context.Background())
+	if err != nil {
+		return TaskResult{
+			Success:  false,
+			Message:  fmt.Sprintf("提交文章摘要回填作业失败: %v", err),
+			Duration: time.Since(startTime).Milliseconds(),
+		}
+	}
+
+	return TaskResult{
+		Success:  true,
+		Message:  fmt.Sprintf("已提交文章摘要回填作业 #%d", jobID),
+		Duration: time.Since(startTime).Milliseconds(),
+	}
+}
+
 // RegisterAllHandlers 注册所有任务处理器
 func RegisterAllHandlers(scheduler *Scheduler, poolManager *PoolManager, templateCache *TemplateCache, db *sqlx.DB, rdb *redis.Client) {
 	// 注册刷新数据池处理器
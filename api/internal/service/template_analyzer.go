@@ -24,6 +24,8 @@ type TemplateFuncStats struct {
 	ContentWithPinyin int `json:"content_with_pinyin"` // content_with_pinyin() 调用次数
 	RandomNumber      int `json:"random_number"`       // random_number() 调用次数
 	Now               int `json:"now"`                 // now() 调用次数
+
+	Custom map[string]int `json:"custom,omitempty"` // 函数包注册的函数调用次数
 }
 
 // Add 合并统计
@@ -38,11 +40,25 @@ func (s *TemplateFuncStats) Add(other *TemplateFuncStats) {
 	s.ContentWithPinyin += other.ContentWithPinyin
 	s.RandomNumber += other.RandomNumber
 	s.Now += other.Now
+	for name, n := range other.Custom {
+		if s.Custom == nil {
+			s.Custom = map[string]int{}
+		}
+		s.Custom[name] += n
+	}
 }
 
 // Multiply 乘以倍数（用于循环展开）
 func (s *TemplateFuncStats) Multiply(factor int) *TemplateFuncStats {
+	var custom map[string]int
+	if len(s.Custom) > 0 {
+		custom = make(map[string]int, len(s.Custom))
+		for name, n := range s.Custom {
+			custom[name] = n * factor
+		}
+	}
 	return &TemplateFuncStats{
+		Custom:            custom,
 		Cls:               s.Cls * factor,
 		RandomURL:         s.RandomURL * factor,
 		KeywordWithEmoji:  s.KeywordWithEmoji * factor,
@@ -88,13 +104,42 @@ func (s *TemplateFuncStats) Max(other *TemplateFuncStats) {
 	if other.Now > s.Now {
 		s.Now = other.Now
 	}
+	for name, n := range other.Custom {
+		if s.Custom == nil {
+			s.Custom = map[string]int{}
+		}
+		if n > s.Custom[name] {
+			s.Custom[name] = n
+		}
+	}
 }
 
 // Total 返回所有函数调用总次数
 func (s *TemplateFuncStats) Total() int {
 	return s.Cls + s.RandomURL + s.KeywordWithEmoji + s.RandomKeyword +
 		s.RandomImage + s.RandomTitle + s.RandomContent + s.ContentWithPinyin +
-		s.RandomNumber + s.Now
+		s.RandomNumber + s.Now + s.customTotal()
+}
+
+func (s *TemplateFuncStats) customTotal() int {
+	total := 0
+	for _, n := range s.Custom {
+		total += n
+	}
+	return total
+}
+
+// Cost 模板成本：内置函数每次调用计 1，注册函数按权重计
+func (s *TemplateFuncStats) Cost() int {
+	cost := s.Total() - s.customTotal()
+	for name, n := range s.Custom {
+		weight := 1
+		if fn, ok := LookupTemplateFunc(name); ok {
+			weight = fn.weight()
+		}
+		cost += n * weight
+	}
+	return cost
 }
 
 // TemplateAnalysis 单个模板分析结果
@@ -103,6 +148,7 @@ type TemplateAnalysis struct {
 	SiteGroupID  int                `json:"site_group_id"`
 	ContentHash  string             `json:"content_hash"`
 	Stats        *TemplateFuncStats `json:"stats"`
+	Cost         int                `json:"cost"`           // 成本（注册函数按权重计）
 	LoopCount    int                `json:"loop_count"`     // 循环层数
 	MaxLoopDepth int                `json:"max_loop_depth"` // 最大嵌套深度
 	AnalyzedAt   int64              `json:"analyzed_at"`    // 分析时间戳
@@ -205,6 +251,7 @@ func (a *TemplateAnalyzer) AnalyzeTemplate(name string, siteGroupID int, content
 		SiteGroupID:  siteGroupID,
 		ContentHash:  hash,
 		Stats:        stats,
+		Cost:         stats.Cost(),
 		LoopCount:    loopCount,
 		MaxLoopDepth: maxDepth,
 		AnalyzedAt:   currentTimestamp(),
@@ -231,6 +278,7 @@ func (a *TemplateAnalyzer) AnalyzeTemplate(name string, siteGroupID int, content
 		Str("template", name).
 		Int("site_group_id", siteGroupID).
 		Int("total_calls", stats.Total()).
+		Int("cost", analysis.Cost).
 		Int("loop_count", loopCount).
 		Int("max_depth", maxDepth).
 		Msg("Template analyzed")
@@ -256,6 +304,7 @@ func (a *TemplateAnalyzer) analyzeContent(content string) (stats *TemplateFuncSt
 	stats.ContentWithPinyin = len(a.funcPatterns["content_with_pinyin"].FindAllString(expandedContent, -1))
 	stats.RandomNumber = len(a.funcPatterns["random_number"].FindAllString(expandedContent, -1))
 	stats.Now = len(a.funcPatterns["now"].FindAllString(expandedContent, -1))
+	stats.Custom = countRegisteredFuncCalls(expandedContent)
 
 	return stats, loopCount, maxDepth
 }
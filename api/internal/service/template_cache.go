@@ -20,6 +20,7 @@ type TemplateCache struct {
 	count    int64
 	mu       sync.RWMutex
 	analyzer *TemplateAnalyzer // 模板分析器
+	snippets *TemplateSnippets // 模板片段库（载入时展开 include）
 }
 
 // NewTemplateCache creates a new template cache
@@ -49,6 +50,7 @@ func (tc *TemplateCache) LoadAll(ctx context.Context) error {
 	tc.mu.Unlock()
 
 	for i := range templates {
+		tc.expandSnippets(&templates[i])
 		key := cacheKey(templates[i].Name, templates[i].SiteGroupID)
 		tc.cache.Store(key, &templates[i])
 
@@ -58,7 +60,7 @@ func (tc *TemplateCache) LoadAll(ctx context.Context) error {
 		}
 	}
 
-	log.Info().
+	LoggerFrom(ctx).Info().
 		Int("count", len(templates)).
 		Msg("All templates loaded into cache")
 
@@ -103,9 +105,10 @@ func (tc *TemplateCache) GetWithFallback(ctx context.Context, name string, siteG
 	query := `SELECT * FROM templates WHERE name = ? AND site_group_id = ? AND status = 1 LIMIT 1`
 	err := tc.db.GetContext(ctx, tmpl, query, name, siteGroupID)
 	if err == nil {
+		tc.expandSnippets(tmpl)
 		key := cacheKey(name, siteGroupID)
 		tc.cache.Store(key, tmpl)
-		log.Debug().
+		LoggerFrom(ctx).Debug().
 			Str("name", name).
 			Int("site_group_id", siteGroupID).
 			Msg("Template loaded on-demand and cached")
@@ -121,6 +124,7 @@ func (tc *TemplateCache) GetWithFallback(ctx context.Context, name string, siteG
 		query = `SELECT * FROM templates WHERE name = ? AND site_group_id = 1 AND status = 1 LIMIT 1`
 		err = tc.db.GetContext(ctx, tmpl, query, name)
 		if err == nil {
+			tc.expandSnippets(tmpl)
 			key := cacheKey(name, 1)
 			tc.cache.Store(key, tmpl)
 			return tmpl, nil
@@ -153,7 +157,7 @@ func (tc *TemplateCache) Reload(ctx context.Context, name string, siteGroupID in
 				analyzer.RemoveAnalysis(name, siteGroupID)
 			}
 
-			log.Info().
+			LoggerFrom(ctx).Info().
 				Str("name", name).
 				Int("site_group_id", siteGroupID).
 				Msg("Template removed from cache (not found or disabled)")
@@ -162,13 +166,14 @@ func (tc *TemplateCache) Reload(ctx context.Context, name string, siteGroupID in
 		return err
 	}
 
+	tc.expandSnippets(tmpl)
 	key := cacheKey(name, siteGroupID)
 	tc.cache.Store(key, tmpl)
 
 	// 触发模板分析
 	tc.analyzeTemplate(tmpl)
 
-	log.Info().
+	LoggerFrom(ctx).Info().
 		Str("name", name).
 		Int("site_group_id", siteGroupID).
 		Msg("Template cache reloaded")
@@ -196,6 +201,7 @@ func (tc *TemplateCache) ReloadByName(ctx context.Context, name string) error {
 
 	// Store new versions
 	for i := range templates {
+		tc.expandSnippets(&templates[i])
 		key := cacheKey(templates[i].Name, templates[i].SiteGroupID)
 		tc.cache.Store(key, &templates[i])
 
@@ -203,7 +209,7 @@ func (tc *TemplateCache) ReloadByName(ctx context.Context, name string) error {
 		tc.analyzeTemplate(&templates[i])
 	}
 
-	log.Info().
+	LoggerFrom(ctx).Info().
 		Str("name", name).
 		Int("versions", len(templates)).
 		Msg("Template cache reloaded (all versions)")
@@ -310,6 +316,28 @@ func (tc *TemplateCache) SetAnalyzer(analyzer *TemplateAnalyzer) {
 	})
 }
 
+// SetSnippets 设置模板片段库（加载模板之前设置）
+func (tc *TemplateCache) SetSnippets(snippets *TemplateSnippets) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.snippets = snippets
+}
+
+// expandSnippets 展开模板中的 include；片段缺失或循环引用时对应位置为 HTML 注释并记录警告
+func (tc *TemplateCache) expandSnippets(tmpl *models.Template) {
+	tc.mu.RLock()
+	snippets := tc.snippets
+	tc.mu.RUnlock()
+	if snippets == nil {
+		return
+	}
+	content, err := snippets.Expand(tmpl.Content)
+	if err != nil {
+		log.Warn().Err(err).Str("template", tmpl.Name).Int("site_group_id", tmpl.SiteGroupID).Msg("Failed to expand template snippets")
+	}
+	tmpl.Content = content
+}
+
 // GetAnalyzer 获取模板分析器
 func (tc *TemplateCache) GetAnalyzer() *TemplateAnalyzer {
 	tc.mu.RLock()
@@ -0,0 +1,220 @@
+package core
+
+import (
+	"html/template"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// CompileCacheLimits 编译模板缓存上限
+type CompileCacheLimits struct {
+	MaxEntries int   // 最多缓存的模板数，<=0 不限制
+	MaxBytes   int64 // 估算内存上限，<=0 不限制
+}
+
+// compileCacheLimits 当前生效的缓存上限，启动时由 SetCompileCacheLimits 设置
+var compileCacheLimits atomic.Pointer[CompileCacheLimits]
+
+func init() {
+	compileCacheLimits.Store(&CompileCacheLimits{MaxEntries: 500, MaxBytes: 256 << 20})
+}
+
+// SetCompileCacheLimits 按配置设置编译模板缓存上限
+func SetCompileCacheLimits(cfg config.CompileCacheConfig) {
+	compileCacheLimits.Store(&CompileCacheLimits{
+		MaxEntries: cfg.MaxEntries,
+		MaxBytes:   int64(cfg.MaxMB) << 20,
+	})
+}
+
+// compiledTemplateEntry 一个模板（按内容哈希）的编译结果
+type compiledTemplateEntry struct {
+	compiled *template.Template                   // 未执行过的原始模板，每次执行前 Clone
+	fast     atomic.Pointer[CompiledFastTemplate] // 首次渲染后生成的快速模板
+	size     atomic.Int64                         // 估算内存占用
+	lastUsed atomic.Int64                         // 最近使用时间（UnixNano）
+}
+
+// compiledTemplateCache 编译模板缓存（近似 LRU）
+//
+// 命中路径只更新条目的最近使用时间（原子操作，不加锁）；超出条数或估算内存上限时
+// 按最近使用时间淘汰最久未用的条目。被淘汰的模板下次渲染时重新编译，不影响渲染结果。
+// 内存按模板源码和快速模板片段长度估算，用于相对比较和设置上限，不是精确值
+type compiledTemplateCache struct {
+	entries sync.Map // cache key -> *compiledTemplateEntry
+	count   atomic.Int64
+	bytes   atomic.Int64
+
+	hits      atomic.Int64 // 命中（快速模板或编译模板）
+	misses    atomic.Int64 // 未命中，需要编译
+	evictions atomic.Int64 // 因超出上限被淘汰的条目数
+
+	mu sync.Mutex // 保护条目增删和内存计数（只在编译、淘汰时获取，命中路径不加锁）
+}
+
+// get 查找缓存条目并更新最近使用时间
+func (c *compiledTemplateCache) get(key string) *compiledTemplateEntry {
+	v, ok := c.entries.Load(key)
+	if !ok {
+		c.misses.Add(1)
+		return nil
+	}
+	entry := v.(*compiledTemplateEntry)
+	entry.lastUsed.Store(time.Now().UnixNano())
+	c.hits.Add(1)
+	return entry
+}
+
+// add 缓存编译结果（并发编译同一模板时返回先存入的条目）
+func (c *compiledTemplateCache) add(key string, compiled *template.Template, sourceLen int) *compiledTemplateEntry {
+	entry := &compiledTemplateEntry{compiled: compiled}
+	entry.lastUsed.Store(time.Now().UnixNano())
+	// 解析树的节点、字段名和文本约为源码的 3 倍
+	entry.size.Store(int64(sourceLen) * 3)
+
+	c.mu.Lock()
+	v, loaded := c.entries.LoadOrStore(key, entry)
+	if !loaded {
+		c.count.Add(1)
+		c.bytes.Add(entry.size.Load())
+	}
+	c.mu.Unlock()
+	if loaded {
+		return v.(*compiledTemplateEntry)
+	}
+	c.evict()
+	return entry
+}
+
+// setFast 保存快速模板并计入内存占用（条目已被淘汰时只保存，不计入）
+func (c *compiledTemplateCache) setFast(key string, entry *compiledTemplateEntry, fast *CompiledFastTemplate) {
+	if !entry.fast.CompareAndSwap(nil, fast) {
+		return
+	}
+	size := fastTemplateSize(fast)
+	c.mu.Lock()
+	entry.size.Add(size)
+	if v, ok := c.entries.Load(key); ok && v == entry {
+		c.bytes.Add(size)
+	}
+	c.mu.Unlock()
+	c.evict()
+}
+
+// fastTemplateSize 估算快速模板的内存占用
+func fastTemplateSize(fast *CompiledFastTemplate) int64 {
+	var size int64
+	for _, s := range fast.Segments {
+		size += int64(len(s)) + 16
+	}
+	for _, p := range fast.Placeholders {
+		size += int64(len(p.Token)+len(p.Arg)) + 96
+		for _, arg := range p.Args {
+			size += int64(len(arg)) + 16
+		}
+	}
+	return size
+}
+
+// evict 超出上限时淘汰最久未用的条目
+func (c *compiledTemplateCache) evict() {
+	limits := compileCacheLimits.Load()
+	over := func() bool {
+		return (limits.MaxEntries > 0 && c.count.Load() > int64(limits.MaxEntries)) ||
+			(limits.MaxBytes > 0 && c.bytes.Load() > limits.MaxBytes)
+	}
+	if !over() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !over() {
+		return
+	}
+
+	type candidate struct {
+		key      string
+		entry    *compiledTemplateEntry
+		lastUsed int64
+	}
+	var candidates []candidate
+	c.entries.Range(func(k, v interface{}) bool {
+		entry := v.(*compiledTemplateEntry)
+		candidates = append(candidates, candidate{key: k.(string), entry: entry, lastUsed: entry.lastUsed.Load()})
+		return true
+	})
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].lastUsed < candidates[j].lastUsed })
+
+	// 至少保留最近使用的一个条目（单个模板超过内存上限时也能缓存）
+	evicted := 0
+	var freed int64
+	for _, cand := range candidates[:max(len(candidates)-1, 0)] {
+		if !over() {
+			break
+		}
+		if c.remove(cand.key, cand.entry) {
+			evicted++
+			freed += cand.entry.size.Load()
+		}
+	}
+	if evicted > 0 {
+		c.evictions.Add(int64(evicted))
+		log.Debug().Int("evicted", evicted).Int64("freed_bytes", freed).
+			Int64("entries", c.count.Load()).Msg("Compiled templates evicted")
+	}
+}
+
+// remove 删除条目（条目已被替换时不删除），调用方持有 c.mu
+func (c *compiledTemplateCache) remove(key string, entry *compiledTemplateEntry) bool {
+	if !c.entries.CompareAndDelete(key, entry) {
+		return false
+	}
+	c.count.Add(-1)
+	c.bytes.Add(-entry.size.Load())
+	return true
+}
+
+// clear 清空缓存（命中/淘汰计数保留）
+func (c *compiledTemplateCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries.Range(func(k, v interface{}) bool {
+		c.remove(k.(string), v.(*compiledTemplateEntry))
+		return true
+	})
+}
+
+// stats 缓存统计
+func (c *compiledTemplateCache) stats() map[string]interface{} {
+	fast := 0
+	c.entries.Range(func(_, v interface{}) bool {
+		if v.(*compiledTemplateEntry).fast.Load() != nil {
+			fast++
+		}
+		return true
+	})
+	hits, misses := c.hits.Load(), c.misses.Load()
+	hitRate := 0.0
+	if hits+misses > 0 {
+		hitRate = float64(hits) / float64(hits+misses)
+	}
+	limits := compileCacheLimits.Load()
+	return map[string]interface{}{
+		"compiled_templates": c.count.Load(),
+		"fast_templates":     fast,
+		"memory_bytes":       c.bytes.Load(),
+		"max_entries":        limits.MaxEntries,
+		"max_bytes":          limits.MaxBytes,
+		"hits":               hits,
+		"misses":             misses,
+		"hit_rate":           hitRate,
+		"evictions":          c.evictions.Load(),
+	}
+}
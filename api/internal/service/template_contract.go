@@ -0,0 +1,202 @@
+package core
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// 模板引用问题类型
+const (
+	TemplateIssueField   = "field"   // 引用了渲染上下文中不存在的变量
+	TemplateIssueFunc    = "func"    // 调用了未注册（或所属函数包未启用）的函数
+	TemplateIssueArgs    = "args"    // 函数参数个数不符或参数不是字面量
+	TemplateIssueSyntax  = "syntax"  // 转换后的模板无法解析
+	TemplateIssueSnippet = "snippet" // include 的片段不存在或循环引用
+)
+
+// TemplateContractIssue 模板数据契约检查发现的问题
+type TemplateContractIssue struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Line    int    `json:"line"` // 从 1 开始，0 表示无法定位
+	Message string `json:"message"`
+}
+
+// templateContextType 模板执行时的顶层数据（首次渲染使用 MarkerContext）
+var templateContextType = reflect.TypeOf(&MarkerContext{})
+
+// templateGoFuncs 转换后的 Go 模板可以直接调用的函数（text/template 内置函数和 iterate）
+var templateGoFuncs = map[string]bool{
+	"and": true, "or": true, "not": true, "len": true, "index": true, "slice": true,
+	"print": true, "printf": true, "println": true, "html": true, "js": true, "urlquery": true,
+	"call": true, "eq": true, "ne": true, "lt": true, "le": true, "gt": true, "ge": true,
+	"iterate": true,
+}
+
+// CheckTemplateContract 静态检查模板引用的变量和函数是否存在于渲染上下文和已启用的函数注册表中
+//
+// Jinja2 函数调用按原文检查（内置函数或已启用函数包中的函数），变量按转换后的 Go 模板检查
+// （{{ foo }} 转换为 {{$.Foo}}，要求 MarkerContext 有同名导出字段或方法）；
+// 运行时才能确定的问题（如 range 内部的 . 引用）不检查。没有问题时返回 nil。
+// include 的片段先展开再检查，行号按展开后的内容计算
+func CheckTemplateContract(content string) []TemplateContractIssue {
+	var issues []TemplateContractIssue
+	seen := map[string]bool{}
+	add := func(issue TemplateContractIssue) {
+		key := issue.Kind + "\x00" + issue.Name + "\x00" + strconv.Itoa(issue.Line)
+		if !seen[key] {
+			seen[key] = true
+			issues = append(issues, issue)
+		}
+	}
+
+	content, err := ExpandTemplateSnippets(content)
+	if err != nil {
+		add(TemplateContractIssue{Kind: TemplateIssueSnippet, Message: err.Error()})
+	}
+
+	// 未知函数调用在转换后仍是 Jinja2 语法，替换为等行数的空行后再解析，避免掩盖其余问题
+	var known strings.Builder
+	last := 0
+	for _, loc := range registeredFuncCallPattern.FindAllStringSubmatchIndex(content, -1) {
+		name := content[loc[2]:loc[3]]
+		line := lineAt(content, loc[0])
+		if builtinTemplateFuncs[name] {
+			continue
+		}
+		fn, ok := LookupTemplateFunc(name)
+		if !ok {
+			add(TemplateContractIssue{Kind: TemplateIssueFunc, Name: name, Line: line, Message: "未知函数 " + name + "()（未注册或所属函数包未启用）"})
+			known.WriteString(content[last:loc[0]])
+			known.WriteString(strings.Repeat("\n", strings.Count(content[loc[0]:loc[1]], "\n")))
+			last = loc[1]
+			continue
+		}
+		args, ok := parseTemplateFuncArgs(content[loc[4]:loc[5]])
+		if !ok {
+			add(TemplateContractIssue{Kind: TemplateIssueArgs, Name: name, Line: line, Message: name + "() 的参数只支持字符串/数字字面量"})
+		} else if len(args) != fn.Arity {
+			add(TemplateContractIssue{Kind: TemplateIssueArgs, Name: name, Line: line,
+				Message: name + "() 需要 " + strconv.Itoa(fn.Arity) + " 个参数，实际 " + strconv.Itoa(len(args)) + " 个"})
+		}
+	}
+
+	// 跳过函数检查解析，未定义的函数（如 {% if foo %} 转换后的 {{if foo}}）在遍历时报告，不影响其余引用的检查
+	known.WriteString(content[last:])
+	goTemplate := GetTemplateConverter().Convert(known.String())
+	tree := parse.New("contract")
+	tree.Mode = parse.SkipFuncCheck
+	trees := map[string]*parse.Tree{}
+	if _, err := tree.Parse(goTemplate, "", "", trees); err != nil {
+		add(TemplateContractIssue{Kind: TemplateIssueSyntax, Line: parseErrorLine(err), Message: err.Error()})
+		return issues
+	}
+	for _, t := range trees {
+		if t.Root == nil {
+			continue
+		}
+		walkTemplateNode(t.Root, false, func(kind, name string, pos parse.Pos) {
+			line := lineAt(goTemplate, int(pos))
+			switch {
+			case kind == TemplateIssueFunc && !templateGoFuncs[name]:
+				add(TemplateContractIssue{Kind: TemplateIssueFunc, Name: name, Line: line, Message: "未定义的函数或变量 " + name + "（{% if %} 等语句中的标识符不会转换为渲染上下文字段）"})
+			case kind == TemplateIssueField && !templateContextHas(name):
+				add(TemplateContractIssue{Kind: TemplateIssueField, Name: name, Line: line,
+					Message: "未知变量 " + templateVarName(name) + "（渲染上下文中不存在 " + name + "）"})
+			}
+		})
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues
+}
+
+// walkTemplateNode 遍历语法树，对函数调用和顶层数据的字段引用（$.X 和 range/with 外的 .X）调用 visit
+func walkTemplateNode(node parse.Node, nested bool, visit func(kind, name string, pos parse.Pos)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkTemplateNode(child, nested, visit)
+		}
+	case *parse.ActionNode:
+		walkTemplatePipe(n.Pipe, nested, visit)
+	case *parse.IfNode:
+		walkTemplatePipe(n.Pipe, nested, visit)
+		walkTemplateNode(n.List, nested, visit)
+		walkTemplateNode(n.ElseList, nested, visit)
+	case *parse.RangeNode:
+		walkTemplatePipe(n.Pipe, nested, visit)
+		walkTemplateNode(n.List, true, visit)
+		walkTemplateNode(n.ElseList, nested, visit)
+	case *parse.WithNode:
+		walkTemplatePipe(n.Pipe, nested, visit)
+		walkTemplateNode(n.List, true, visit)
+		walkTemplateNode(n.ElseList, nested, visit)
+	}
+}
+
+func walkTemplatePipe(pipe *parse.PipeNode, nested bool, visit func(kind, name string, pos parse.Pos)) {
+	if pipe == nil {
+		return
+	}
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			switch a := arg.(type) {
+			case *parse.IdentifierNode:
+				visit(TemplateIssueFunc, a.Ident, a.Pos)
+			case *parse.VariableNode:
+				if len(a.Ident) > 1 && a.Ident[0] == "$" {
+					visit(TemplateIssueField, a.Ident[1], a.Pos)
+				}
+			case *parse.FieldNode:
+				if !nested && len(a.Ident) > 0 {
+					visit(TemplateIssueField, a.Ident[0], a.Pos)
+				}
+			case *parse.PipeNode:
+				walkTemplatePipe(a, nested, visit)
+			}
+		}
+	}
+}
+
+// templateContextHas 渲染上下文是否有该导出字段或方法
+func templateContextHas(name string) bool {
+	if _, ok := templateContextType.MethodByName(name); ok {
+		return true
+	}
+	field, ok := templateContextType.Elem().FieldByName(name)
+	return ok && field.IsExported()
+}
+
+// templateVarName 转换前的 Jinja2 变量名（转换时只把首字母大写）
+func templateVarName(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// lineAt 偏移量所在行号（从 1 开始）
+func lineAt(s string, offset int) int {
+	if offset > len(s) {
+		offset = len(s)
+	}
+	return strings.Count(s[:offset], "\n") + 1
+}
+
+// parseErrorLine 从解析错误（template: contract:LINE: ...）中取行号
+func parseErrorLine(err error) int {
+	_, rest, ok := strings.Cut(err.Error(), "contract:")
+	if !ok {
+		return 0
+	}
+	num, _, _ := strings.Cut(rest, ":")
+	line, _ := strconv.Atoi(num)
+	return line
+}
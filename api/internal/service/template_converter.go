@@ -26,6 +26,20 @@ func NewTemplateConverter() *TemplateConverter {
 		pattern     string
 		replacement string
 	}{
+		// pinyin_slug(x) / pinyinSlug x：x 为随机关键词、标题或字符串常量
+		{`\{\{\s*pinyin_slug\s*\(\s*(?:random_keyword\s*\(\s*\)|keyword)\s*\)\s*\}\}`, `{{$.PinyinSlug $.RandomKeyword}}`},
+		{`\{\{\s*pinyin_slug\s*\(\s*title\s*\)\s*\}\}`, `{{$.PinyinSlug $.Title}}`},
+		{`\{\{\s*pinyin_slug\s*\(\s*['"]([^'"]*)['"]\s*\)\s*\}\}`, `{{$.PinyinSlug "${1}"}}`},
+		{`\{\{\s*pinyinSlug\s+(?:keyword|random_keyword\s*\(\s*\))\s*\}\}`, `{{$.PinyinSlug $.RandomKeyword}}`},
+		{`\{\{\s*pinyinSlug\s+title\s*\}\}`, `{{$.PinyinSlug $.Title}}`},
+		{`\{\{\s*pinyinSlug\s+['"]([^'"]*)['"]\s*\}\}`, `{{$.PinyinSlug "${1}"}}`},
+
+		// publish_date('%Y-%m-%d') / publishDate "2006-01-02"，不带格式时输出 2006-01-02 15:04:05
+		{`\{\{\s*publish_date\s*\(\s*\)\s*\}\}`, `{{$.PublishDate ""}}`},
+		{`\{\{\s*publish_date\s*\(\s*['"]([^'"]*)['"]\s*\)\s*\}\}`, `{{$.PublishDate "${1}"}}`},
+		{`\{\{\s*publishDate\s*\}\}`, `{{$.PublishDate ""}}`},
+		{`\{\{\s*publishDate\s+['"]([^'"]*)['"]\s*\}\}`, `{{$.PublishDate "${1}"}}`},
+
 		// Function calls without arguments
 		{`\{\{\s*random_keyword\s*\(\s*\)\s*\}\}`, `{{$.RandomKeyword}}`},
 		{`\{\{\s*random_hotspot\s*\(\s*\)\s*\}\}`, `{{$.RandomKeyword}}`},
@@ -33,10 +47,14 @@ func NewTemplateConverter() *TemplateConverter {
 		{`\{\{\s*random_keyword_emoji\s*\(\s*\)\s*\}\}`, `{{$.RandomKeywordEmoji}}`},
 		{`\{\{\s*random_url\s*\(\s*\)\s*\}\}`, `{{$.RandomURL}}`},
 		{`\{\{\s*random_image\s*\(\s*\)\s*\}\}`, `{{$.RandomImage}}`},
+		{`\{\{\s*internal_link\s*\(\s*\)\s*\}\}`, `{{$.InternalLink}}`},
 		{`\{\{\s*content\s*\(\s*\)\s*\}\}`, `{{$.Content}}`},
 		{`\{\{\s*content_with_pinyin\s*\(\s*\)\s*\}\}`, `{{$.Content}}`},
 		{`\{\{\s*now\s*\(\s*\)\s*\}\}`, `{{$.Now}}`},
 
+		// cls_name('x')：页面内一致的类名别名（用于 <style>）
+		{`\{\{\s*cls_name\s*\(\s*['"]([^'"]*)['"]\s*\)\s*\}\}`, `{{$.ClsName "${1}"}}`},
+
 		// cls() function with argument - needs special handling
 		// Use [^'"]* instead of [^'"]+ to allow empty strings like cls('')
 		{`\{\{\s*cls\s*\(\s*['"]([^'"]*)['"]\s*\)\s*\}\}`, `{{$.Cls "${1}"}}`},
@@ -98,6 +116,9 @@ func (tc *TemplateConverter) Convert(jinja2Template string) string {
 		result = rule.pattern.ReplaceAllString(result, rule.replacement)
 	}
 
+	// 已启用函数包中的函数：{{ name('arg') }} -> {{$.Call "name" "arg"}}
+	result = convertRegisteredFuncs(result)
+
 	// Handle remaining Jinja2 variable syntax {{ var }}
 	// Convert to {{$.Var}} with capitalized first letter
 	// Use $ to reference top-level context (works inside range blocks)
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+// common 函数包：常用随机数据，配置 template_funcs.packs 包含 "common" 时启用
+// 分支可仿照此文件新增自己的函数包，无需修改 TemplateFuncsManager 和模板转换规则
+func init() {
+	RegisterTemplateFuncPack(TemplateFuncPack{
+		Name: "common",
+		Funcs: []TemplateFunc{
+			{Name: "random_phone", Arity: 0, Cost: 1, Impl: randomPhoneFunc},
+			{Name: "random_ip", Arity: 0, Cost: 1, Impl: randomIPFunc},
+			{Name: "random_letters", Arity: 1, Cost: 1, Impl: randomLettersFunc},
+			{Name: "random_date", Arity: 2, Cost: 1, Impl: randomDateFunc},
+		},
+	})
+}
+
+var mobilePrefixes = []string{"130", "131", "132", "135", "136", "137", "138", "139", "150", "151", "152", "158", "159", "177", "180", "186", "188", "189"}
+
+// randomPhoneFunc random_phone()：随机手机号
+func randomPhoneFunc(_ *TemplateFuncsManager, _ *RenderData, _ []string) string {
+	return mobilePrefixes[rand.IntN(len(mobilePrefixes))] + strconv.Itoa(10000000+rand.IntN(90000000))
+}
+
+// randomIPFunc random_ip()：随机公网样式 IPv4
+func randomIPFunc(_ *TemplateFuncsManager, _ *RenderData, _ []string) string {
+	return strconv.Itoa(1+rand.IntN(223)) + "." + strconv.Itoa(rand.IntN(256)) + "." +
+		strconv.Itoa(rand.IntN(256)) + "." + strconv.Itoa(1+rand.IntN(254))
+}
+
+// randomLettersFunc random_letters(n)：n 个随机小写字母（最多 64 个）
+func randomLettersFunc(_ *TemplateFuncsManager, _ *RenderData, args []string) string {
+	n, _ := strconv.Atoi(args[0])
+	n = max(1, min(n, 64))
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('a' + rand.IntN(26))
+	}
+	return string(b)
+}
+
+// randomDateFunc random_date(days, format)：发布时间（无发布时间时为当前时间）之前 days 天内的随机时间
+func randomDateFunc(_ *TemplateFuncsManager, data *RenderData, args []string) string {
+	days, _ := strconv.Atoi(args[0])
+	days = max(1, days)
+	base := time.Now()
+	if data != nil && !data.PublishDate.IsZero() {
+		base = data.PublishDate
+	}
+	return base.Add(-time.Duration(rand.Int64N(int64(days) * int64(24*time.Hour)))).Format(DateLayout(args[1]))
+}
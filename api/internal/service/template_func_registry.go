@@ -0,0 +1,204 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+)
+
+// TemplateFuncImpl 模板函数实现，每次渲染调用，args 为模板中的字面量参数
+type TemplateFuncImpl func(fm *TemplateFuncsManager, data *RenderData, args []string) string
+
+// TemplateFunc 注册的模板函数
+// 模板中写作 {{ name('arg1', 'arg2') }}，参数只支持字符串/数字字面量；
+// 首次渲染时生成占位符，每次渲染调用 Impl 取值，返回值原样插入页面（需要时由 Impl 自行转义）
+type TemplateFunc struct {
+	Name  string           `json:"name"`  // 模板中的函数名（小写字母、数字、下划线）
+	Arity int              `json:"arity"` // 参数个数
+	Cost  int              `json:"cost"`  // 成本权重，TemplateAnalyzer 按 调用次数 × 权重 计入模板成本，<=0 时按 1
+	Impl  TemplateFuncImpl `json:"-"`
+	Pack  string           `json:"pack"` // 所属函数包（注册时填写）
+}
+
+// TemplateFuncPack 模板函数包，提供方在 init() 中注册，配置 template_funcs.packs 列出后才生效
+type TemplateFuncPack struct {
+	Name  string
+	Funcs []TemplateFunc
+}
+
+// builtinTemplateFuncs 内置函数名（由转换规则和 MarkerContext 直接处理，不能被函数包覆盖）
+var builtinTemplateFuncs = map[string]bool{
+	"cls": true, "cls_name": true, "random_url": true, "random_keyword": true, "random_hotspot": true,
+	"keyword_with_emoji": true, "random_keyword_emoji": true, "random_image": true,
+	"random_title": true, "random_content": true, "content": true, "content_with_pinyin": true,
+	"random_number": true, "now": true, "encode": true, "encode_text": true,
+	"internal_link": true, "pinyin_slug": true, "publish_date": true, "range": true,
+}
+
+var templateFuncNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+var (
+	templateFuncPacksMu sync.Mutex
+	templateFuncPacks   = map[string]TemplateFuncPack{} // 已注册的函数包
+	activeTemplateFuncs atomic.Pointer[map[string]*TemplateFunc]
+)
+
+func init() {
+	empty := map[string]*TemplateFunc{}
+	activeTemplateFuncs.Store(&empty)
+}
+
+// RegisterTemplateFuncPack 注册模板函数包（通常在 init() 中调用），名称不合法或与内置函数冲突时 panic
+func RegisterTemplateFuncPack(pack TemplateFuncPack) {
+	templateFuncPacksMu.Lock()
+	defer templateFuncPacksMu.Unlock()
+	if _, exists := templateFuncPacks[pack.Name]; exists {
+		panic("template func pack already registered: " + pack.Name)
+	}
+	for i := range pack.Funcs {
+		fn := &pack.Funcs[i]
+		if !templateFuncNamePattern.MatchString(fn.Name) || builtinTemplateFuncs[fn.Name] {
+			panic(fmt.Sprintf("template func pack %s: invalid or reserved function name %q", pack.Name, fn.Name))
+		}
+		if fn.Impl == nil || fn.Arity < 0 {
+			panic(fmt.Sprintf("template func pack %s: function %s has no implementation or negative arity", pack.Name, fn.Name))
+		}
+		fn.Pack = pack.Name
+	}
+	templateFuncPacks[pack.Name] = pack
+}
+
+// EnableTemplateFuncPacks 启用配置中列出的函数包（启动时调用），未注册的包名和重名函数记录警告后跳过
+func EnableTemplateFuncPacks(names []string) {
+	templateFuncPacksMu.Lock()
+	defer templateFuncPacksMu.Unlock()
+
+	funcs := map[string]*TemplateFunc{}
+	for _, name := range names {
+		pack, ok := templateFuncPacks[name]
+		if !ok {
+			log.Warn().Str("pack", name).Msg("Template func pack not registered, skipped")
+			continue
+		}
+		for i := range pack.Funcs {
+			fn := &pack.Funcs[i]
+			if existing, dup := funcs[fn.Name]; dup {
+				log.Warn().Str("func", fn.Name).Str("pack", name).Str("existing_pack", existing.Pack).
+					Msg("Template function already provided by another pack, skipped")
+				continue
+			}
+			funcs[fn.Name] = fn
+		}
+		log.Info().Str("pack", name).Int("funcs", len(pack.Funcs)).Msg("Template func pack enabled")
+	}
+	activeTemplateFuncs.Store(&funcs)
+}
+
+// LookupTemplateFunc 查找已启用的模板函数
+func LookupTemplateFunc(name string) (*TemplateFunc, bool) {
+	fn, ok := (*activeTemplateFuncs.Load())[name]
+	return fn, ok
+}
+
+// ListTemplateFuncs 已启用的模板函数（按名称排序）
+func ListTemplateFuncs() []TemplateFunc {
+	funcs := *activeTemplateFuncs.Load()
+	list := make([]TemplateFunc, 0, len(funcs))
+	for _, fn := range funcs {
+		list = append(list, *fn)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// ListTemplateFuncPacks 已注册的函数包名
+func ListTemplateFuncPacks() []string {
+	templateFuncPacksMu.Lock()
+	defer templateFuncPacksMu.Unlock()
+	names := make([]string, 0, len(templateFuncPacks))
+	for name := range templateFuncPacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// weight 成本权重
+func (fn *TemplateFunc) weight() int {
+	if fn.Cost <= 0 {
+		return 1
+	}
+	return fn.Cost
+}
+
+// registeredFuncCallPattern 注册函数调用：{{ name(args) }}
+var registeredFuncCallPattern = regexp.MustCompile(`\{\{\s*([a-z][a-z0-9_]*)\s*\(([^)]*)\)\s*\}\}`)
+
+// templateFuncArgPattern 字面量参数：'x'、"x" 或数字
+var templateFuncArgPattern = regexp.MustCompile(`^(?:'([^']*)'|"([^"]*)"|(-?\d+(?:\.\d+)?))$`)
+
+// templateFuncBadArgs 非字面量参数标记（转换时作为唯一参数前缀传给 Call）
+const templateFuncBadArgs = "\x00"
+
+// parseTemplateFuncArgs 解析字面量参数列表，含非字面量参数时返回 false
+func parseTemplateFuncArgs(raw string) ([]string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, true
+	}
+	parts := strings.Split(raw, ",")
+	args := make([]string, 0, len(parts))
+	for _, part := range parts {
+		m := templateFuncArgPattern.FindStringSubmatch(strings.TrimSpace(part))
+		if m == nil {
+			return nil, false
+		}
+		args = append(args, m[1]+m[2]+m[3])
+	}
+	return args, true
+}
+
+// convertRegisteredFuncs 将已启用函数的调用转换为 {{$.Call "name" "arg"...}}
+// 参数个数不符或参数不是字面量时同样转换，由 Call 在渲染时报错（错误信息带模板行列）
+func convertRegisteredFuncs(content string) string {
+	if len(*activeTemplateFuncs.Load()) == 0 {
+		return content
+	}
+	return registeredFuncCallPattern.ReplaceAllStringFunc(content, func(match string) string {
+		m := registeredFuncCallPattern.FindStringSubmatch(match)
+		if _, ok := LookupTemplateFunc(m[1]); !ok {
+			return match
+		}
+		var b strings.Builder
+		b.WriteString(`{{$.Call "` + m[1] + `"`)
+		args, ok := parseTemplateFuncArgs(m[2])
+		if !ok {
+			args = []string{templateFuncBadArgs + m[2]}
+		}
+		for _, arg := range args {
+			fmt.Fprintf(&b, " %q", arg)
+		}
+		b.WriteString("}}")
+		return b.String()
+	})
+}
+
+// countRegisteredFuncCalls 统计已启用函数的调用次数（供 TemplateAnalyzer 使用）
+func countRegisteredFuncCalls(content string) map[string]int {
+	var calls map[string]int
+	for _, m := range registeredFuncCallPattern.FindAllStringSubmatch(content, -1) {
+		if _, ok := LookupTemplateFunc(m[1]); !ok {
+			continue
+		}
+		if calls == nil {
+			calls = map[string]int{}
+		}
+		calls[m[1]]++
+	}
+	return calls
+}
@@ -37,6 +37,8 @@ type TemplateFuncsManager struct {
 	encoder               *HTMLEntityEncoder
 	emojiManager          *EmojiManager          // emoji 管理器引用
 	keywordEmojiGenerator *KeywordEmojiGenerator // 关键词表情生成器引用
+	pinyinSlugs           *PinyinSlugCache       // 拼音 slug 缓存（与 PoolManager 共用）
+	imageSigner           *ImageURLSigner        // 图片 URL 签名，nil 表示不签名
 }
 
 // NewTemplateFuncsManager 创建管理器
@@ -56,6 +58,16 @@ func (m *TemplateFuncsManager) SetKeywordEmojiGenerator(gen *KeywordEmojiGenerat
 	m.keywordEmojiGenerator = gen
 }
 
+// SetImageURLSigner 设置图片 URL 签名器
+func (m *TemplateFuncsManager) SetImageURLSigner(s *ImageURLSigner) {
+	m.imageSigner = s
+}
+
+// SetPinyinSlugCache 设置拼音 slug 缓存引用
+func (m *TemplateFuncsManager) SetPinyinSlugCache(c *PinyinSlugCache) {
+	m.pinyinSlugs = c
+}
+
 // stringMemorySizer 计算字符串内存占用的函数
 func stringMemorySizer(v any) int64 {
 	if s, ok := v.(string); ok {
@@ -160,6 +172,11 @@ func (m *TemplateFuncsManager) Cls(name string) string {
 	return generateRandomCls() + " " + name
 }
 
+// ClsWithAlias 生成带页面别名的 class 值：随机类名 + 页面内一致的别名 + 原类名
+func (m *TemplateFuncsManager) ClsWithAlias(name, alias string) string {
+	return m.Cls(alias + " " + name)
+}
+
 // RandomURL 从池中获取随机URL
 func (m *TemplateFuncsManager) RandomURL() string {
 	if m.urlPool != nil {
@@ -209,6 +226,48 @@ func (m *TemplateFuncsManager) RandomKeywordEmoji(groupID int) string {
 	return m.generateKeywordWithEmojiFromRaw(keyword)
 }
 
+// PinyinSlug 文本转拼音 slug（pinyinSlug 模板函数）
+func (m *TemplateFuncsManager) PinyinSlug(text string) string {
+	if m.pinyinSlugs != nil {
+		return m.pinyinSlugs.Slug(text)
+	}
+	// 降级：缓存未初始化时实时计算
+	return PinyinSlug(text, "-", 60)
+}
+
+// RandomKeywordSlug 随机关键词的拼音 slug（支持分组）
+func (m *TemplateFuncsManager) RandomKeywordSlug(groupID int) string {
+	data := m.keywordData.Load()
+	if data == nil {
+		return ""
+	}
+	rawKeywords := data.rawGroups[groupID]
+	if len(rawKeywords) == 0 {
+		rawKeywords = data.rawGroups[1]
+		if len(rawKeywords) == 0 {
+			return ""
+		}
+	}
+	return m.PinyinSlug(rawKeywords[rand.IntN(len(rawKeywords))])
+}
+
+// KeywordAt 按序号取原始关键词（序号对分组大小取模），分组为空时降级到默认分组
+// count 返回实际使用分组的关键词数，为 0 表示没有可用关键词
+func (m *TemplateFuncsManager) KeywordAt(groupID int, n uint64) (keyword string, count int) {
+	data := m.keywordData.Load()
+	if data == nil {
+		return "", 0
+	}
+	rawKeywords := data.rawGroups[groupID]
+	if len(rawKeywords) == 0 {
+		rawKeywords = data.rawGroups[1]
+		if len(rawKeywords) == 0 {
+			return "", 0
+		}
+	}
+	return rawKeywords[n%uint64(len(rawKeywords))], len(rawKeywords)
+}
+
 // RandomImage 获取随机图片URL（支持分组）
 func (m *TemplateFuncsManager) RandomImage(groupID int) string {
 	data := m.imageData.Load()
@@ -219,13 +278,19 @@ func (m *TemplateFuncsManager) RandomImage(groupID int) string {
 	urls := data.groups[groupID]
 	if len(urls) == 0 {
 		// 降级到默认分组
+		groupID = 1
 		urls = data.groups[1]
 		if len(urls) == 0 {
 			return ""
 		}
 	}
 
-	return urls[rand.IntN(len(urls))]
+	imgURL := urls[rand.IntN(len(urls))]
+	if m.imageSigner != nil {
+		// 按 URL 实际所属分组签名
+		return m.imageSigner.Sign(groupID, imgURL)
+	}
+	return imgURL
 }
 
 // RandomNumber 获取随机数
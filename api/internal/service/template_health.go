@@ -0,0 +1,324 @@
+// Package core provides per-template render error budgets with automatic degradation
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/pkg/config"
+)
+
+// defaultFallbackTemplate 站群未配置备用模板时使用的模板
+const defaultFallbackTemplate = "download_site"
+
+// renderWindow 单个模板的渲染统计窗口
+type renderWindow struct {
+	start    time.Time
+	renders  int
+	failures int
+	lastErr  string
+}
+
+// DegradedTemplate 已降级的模板
+type DegradedTemplate struct {
+	TemplateID  int       `db:"id" json:"template_id"`
+	Name        string    `db:"name" json:"name"`
+	SiteGroupID int       `db:"site_group_id" json:"site_group_id"`
+	DegradedAt  time.Time `db:"degraded_at" json:"degraded_at"`
+	Reason      string    `db:"degraded_reason" json:"reason"`
+}
+
+// TemplateRenderStats 模板当前窗口的渲染统计
+type TemplateRenderStats struct {
+	TemplateID  int     `json:"template_id"`
+	Renders     int     `json:"renders"`
+	Failures    int     `json:"failures"`
+	FailureRate float64 `json:"failure_rate"`
+	LastError   string  `json:"last_error,omitempty"`
+}
+
+// TemplateHealth 模板渲染错误预算
+// 按模板统计窗口内失败率，超过预算时自动降级：
+// 写库标记、触发告警，页面渲染改用站群的备用模板，直到管理员手动恢复
+type TemplateHealth struct {
+	db     *sqlx.DB
+	config config.TemplateBudgetConfig
+	alerts *AlertManager
+
+	mu        sync.Mutex
+	windows   map[int]*renderWindow     // templateID -> 窗口
+	degraded  map[int]*DegradedTemplate // templateID -> 降级信息
+	fallbacks map[int]string            // siteGroupID -> 备用模板名
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewTemplateHealth 创建模板错误预算管理器
+func NewTemplateHealth(db *sqlx.DB, cfg config.TemplateBudgetConfig) *TemplateHealth {
+	if cfg.WindowSeconds <= 0 {
+		cfg.WindowSeconds = 300
+	}
+	if cfg.MinRenders <= 0 {
+		cfg.MinRenders = 20
+	}
+	if cfg.MaxFailureRate <= 0 || cfg.MaxFailureRate > 1 {
+		cfg.MaxFailureRate = 0.5
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TemplateHealth{
+		db:        db,
+		config:    cfg,
+		windows:   make(map[int]*renderWindow),
+		degraded:  make(map[int]*DegradedTemplate),
+		fallbacks: make(map[int]string),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// SetAlertManager 设置告警管理器（降级时触发告警）
+func (h *TemplateHealth) SetAlertManager(am *AlertManager) {
+	h.alerts = am
+}
+
+// Start 加载降级状态和备用模板，并定期刷新备用模板配置
+func (h *TemplateHealth) Start(ctx context.Context) error {
+	if err := h.loadDegraded(ctx); err != nil {
+		return err
+	}
+	if err := h.loadFallbacks(ctx); err != nil {
+		return err
+	}
+
+	h.wg.Add(1)
+	go h.refreshLoop()
+	return nil
+}
+
+// Stop 停止后台刷新
+func (h *TemplateHealth) Stop() {
+	h.cancel()
+	h.wg.Wait()
+}
+
+// refreshLoop 定期刷新站群备用模板（站群配置修改后最多 1 分钟生效）
+func (h *TemplateHealth) refreshLoop() {
+	defer h.wg.Done()
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := h.loadFallbacks(h.ctx); err != nil && h.ctx.Err() == nil {
+				log.Warn().Err(err).Msg("Failed to reload fallback templates")
+			}
+		}
+	}
+}
+
+// loadDegraded 从数据库加载已降级的模板
+func (h *TemplateHealth) loadDegraded(ctx context.Context) error {
+	var rows []DegradedTemplate
+	err := h.db.SelectContext(ctx, &rows, `
+		SELECT id, name, site_group_id, degraded_at, COALESCE(degraded_reason, '') AS degraded_reason
+		FROM templates WHERE degraded = 1 AND degraded_at IS NOT NULL`)
+	if err != nil {
+		return fmt.Errorf("load degraded templates: %w", err)
+	}
+
+	h.mu.Lock()
+	h.degraded = make(map[int]*DegradedTemplate, len(rows))
+	for i := range rows {
+		h.degraded[rows[i].TemplateID] = &rows[i]
+	}
+	h.mu.Unlock()
+
+	if len(rows) > 0 {
+		log.Warn().Int("count", len(rows)).Msg("Degraded templates loaded, affected sites use fallback template")
+	}
+	return nil
+}
+
+// loadFallbacks 加载站群备用模板配置
+func (h *TemplateHealth) loadFallbacks(ctx context.Context) error {
+	var rows []struct {
+		ID       int    `db:"id"`
+		Fallback string `db:"fallback_template"`
+	}
+	if err := h.db.SelectContext(ctx, &rows,
+		"SELECT id, fallback_template FROM site_groups WHERE fallback_template IS NOT NULL AND fallback_template != ''"); err != nil {
+		return fmt.Errorf("load fallback templates: %w", err)
+	}
+
+	fallbacks := make(map[int]string, len(rows))
+	for _, r := range rows {
+		fallbacks[r.ID] = r.Fallback
+	}
+	h.mu.Lock()
+	h.fallbacks = fallbacks
+	h.mu.Unlock()
+	return nil
+}
+
+// IsDegraded 模板是否已降级
+func (h *TemplateHealth) IsDegraded(templateID int) bool {
+	h.mu.Lock()
+	_, ok := h.degraded[templateID]
+	h.mu.Unlock()
+	return ok
+}
+
+// FallbackTemplate 返回站群的备用模板名
+func (h *TemplateHealth) FallbackTemplate(siteGroupID int) string {
+	h.mu.Lock()
+	name, ok := h.fallbacks[siteGroupID]
+	h.mu.Unlock()
+	if !ok {
+		return defaultFallbackTemplate
+	}
+	return name
+}
+
+// RecordRender 记录一次渲染结果，失败率超预算时自动降级
+func (h *TemplateHealth) RecordRender(templateID int, name string, siteGroupID int, renderErr error) {
+	if !h.config.Enabled || templateID <= 0 {
+		return
+	}
+
+	now := time.Now()
+	window := time.Duration(h.config.WindowSeconds) * time.Second
+
+	h.mu.Lock()
+	if _, already := h.degraded[templateID]; already {
+		h.mu.Unlock()
+		return
+	}
+	w, ok := h.windows[templateID]
+	if !ok || now.Sub(w.start) > window {
+		w = &renderWindow{start: now}
+		h.windows[templateID] = w
+	}
+	w.renders++
+	if renderErr != nil {
+		w.failures++
+		w.lastErr = renderErr.Error()
+	}
+
+	if w.renders < h.config.MinRenders {
+		h.mu.Unlock()
+		return
+	}
+	rate := float64(w.failures) / float64(w.renders)
+	if rate <= h.config.MaxFailureRate {
+		h.mu.Unlock()
+		return
+	}
+
+	reason := fmt.Sprintf("渲染失败率 %.0f%% (%d/%d)，最近错误: %s", rate*100, w.failures, w.renders, w.lastErr)
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+	d := &DegradedTemplate{
+		TemplateID:  templateID,
+		Name:        name,
+		SiteGroupID: siteGroupID,
+		DegradedAt:  now,
+		Reason:      reason,
+	}
+	h.degraded[templateID] = d
+	delete(h.windows, templateID)
+	h.mu.Unlock()
+
+	h.onDegraded(d, rate)
+}
+
+// onDegraded 持久化降级状态并告警
+func (h *TemplateHealth) onDegraded(d *DegradedTemplate, rate float64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := h.db.ExecContext(ctx,
+		"UPDATE templates SET degraded = 1, degraded_at = ?, degraded_reason = ? WHERE id = ?",
+		d.DegradedAt, d.Reason, d.TemplateID); err != nil {
+		log.Error().Err(err).Int("template_id", d.TemplateID).Msg("Failed to persist template degradation")
+	}
+
+	log.Error().
+		Int("template_id", d.TemplateID).
+		Str("template", d.Name).
+		Int("site_group_id", d.SiteGroupID).
+		Str("fallback", h.FallbackTemplate(d.SiteGroupID)).
+		Msg("Template degraded due to render error budget exceeded")
+
+	if h.alerts != nil {
+		h.alerts.Raise(AlertLevelError, "template_degraded",
+			fmt.Sprintf("模板 %s (ID %d) 渲染失败率超限已自动降级，站点改用备用模板 %s",
+				d.Name, d.TemplateID, h.FallbackTemplate(d.SiteGroupID)),
+			rate*100, h.config.MaxFailureRate*100)
+	}
+}
+
+// Enable 手动恢复已降级的模板
+func (h *TemplateHealth) Enable(ctx context.Context, templateID int) error {
+	if _, err := h.db.ExecContext(ctx,
+		"UPDATE templates SET degraded = 0, degraded_at = NULL, degraded_reason = NULL WHERE id = ?", templateID); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	delete(h.degraded, templateID)
+	delete(h.windows, templateID)
+	remaining := len(h.degraded)
+	h.mu.Unlock()
+
+	if remaining == 0 && h.alerts != nil {
+		h.alerts.Resolve("template_degraded")
+	}
+	log.Info().Int("template_id", templateID).Msg("Template re-enabled")
+	return nil
+}
+
+// ListDegraded 返回已降级的模板
+func (h *TemplateHealth) ListDegraded() []DegradedTemplate {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]DegradedTemplate, 0, len(h.degraded))
+	for _, d := range h.degraded {
+		out = append(out, *d)
+	}
+	return out
+}
+
+// GetRenderStats 返回各模板当前窗口的渲染统计
+func (h *TemplateHealth) GetRenderStats() []TemplateRenderStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]TemplateRenderStats, 0, len(h.windows))
+	for id, w := range h.windows {
+		s := TemplateRenderStats{
+			TemplateID: id,
+			Renders:    w.renders,
+			Failures:   w.failures,
+			LastError:  w.lastErr,
+		}
+		if w.renders > 0 {
+			s.FailureRate = float64(w.failures) / float64(w.renders)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// GetConfig 返回错误预算配置
+func (h *TemplateHealth) GetConfig() config.TemplateBudgetConfig {
+	return h.config
+}
@@ -2,11 +2,11 @@ package core
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"html/template"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -16,7 +16,7 @@ import (
 type TemplateRenderer struct {
 	converter     *TemplateConverter
 	funcsManager  *TemplateFuncsManager
-	compiledCache sync.Map // cache key -> *template.Template
+	compiledCache compiledTemplateCache // cache key -> 编译模板和快速模板（有上限，淘汰后按需重新编译）
 	fastRenderer  *FastRenderer
 }
 
@@ -30,8 +30,18 @@ type RenderData struct {
 	AnalyticsCode  template.HTML
 	BaiduPushJS    template.HTML
 	ArticleContent template.HTML
+	Summary        string // 正文摘要（纯文本），模板 summary() 输出
 	Now            string
 	Content        string
+	URLGenerator   func() string                 // 按站群 URL 策略生成 random_url，nil 时使用 URL 池
+	InternalLink   func() string                 // 站内链接生成器，nil 时使用 URL 池 + 随机关键词
+	PublishDate    time.Time                     // 模拟发布时间，零值时使用当前时间
+	PluginFunc     func(name, arg string) string // 站群 WASM 扩展的 plugin() 实现，nil 时输出空
+	TrapLink       func() string                 // 蜜罐链接生成器（trap_link()），nil 时输出空
+	Archive        *ArchivePage                  // 归档列表页（archive_list() / archive_pagination()），非列表页为 nil
+	ArchiveLinks   func() string                 // 列表页分类入口生成器（archive_categories()），nil 时输出空
+
+	classAliases *ClassAliases // 本次渲染的类名别名表（每次渲染重新创建）
 
 	// Function results (called during render)
 	randomKeyword func() string
@@ -53,19 +63,33 @@ func NewTemplateRenderer(funcsManager *TemplateFuncsManager) *TemplateRenderer {
 
 // Render renders a Jinja2 template with the given data
 func (r *TemplateRenderer) Render(templateContent string, templateName string, data *RenderData, content string) (string, error) {
+	return r.RenderContext(context.Background(), templateContent, templateName, data, content)
+}
+
+// RenderContext 渲染模板，首次渲染受渲染预算限制（超时、iterate 长度、函数调用次数、输出大小），
+// ctx 的截止时间早于配置的超时时以 ctx 为准；快速渲染的占位符数已受首次渲染的调用次数限制
+func (r *TemplateRenderer) RenderContext(ctx context.Context, templateContent string, templateName string, data *RenderData, content string) (string, error) {
 	startTime := time.Now()
 
+	// 调试模式故障注入（压测时模拟渲染变慢或失败）
+	if err := GetFaultInjector().Inject(ctx, FaultTargetTemplate); err != nil {
+		return "", err
+	}
+
 	// Generate cache key from template content hash
 	hash := md5.Sum([]byte(templateContent))
 	cacheKey := hex.EncodeToString(hash[:])
 
-	// 设置 content 到 data.Content
+	// 设置 content 到 data.Content，每次渲染使用新的类名别名表
 	if data != nil {
 		data.Content = content
+		data.classAliases = NewClassAliases()
 	}
 
 	// 1. 尝试快速渲染（绕过反射）
-	if result, ok := r.fastRenderer.Render(cacheKey, data); ok {
+	entry := r.compiledCache.get(cacheKey)
+	if fast := entryFast(entry); fast != nil {
+		result := r.fastRenderer.RenderCompiled(fast, data)
 		elapsed := time.Since(startTime)
 		log.Debug().
 			Str("template", templateName).
@@ -79,13 +103,13 @@ func (r *TemplateRenderer) Render(templateContent string, templateName string, d
 	// 2. 首次渲染：使用 MarkerContext 生成占位符模板
 	// Check compiled template cache
 	var tmpl *template.Template
-	if cached, ok := r.compiledCache.Load(cacheKey); ok {
-		tmpl = cached.(*template.Template)
+	if entry != nil {
+		tmpl = entry.compiled
 	} else {
 		// Convert Jinja2 to Go template syntax
 		goTemplate := r.converter.Convert(templateContent)
 
-		// Create template with custom functions
+		// Create template with custom functions（执行时替换为绑定渲染预算的版本）
 		funcMap := template.FuncMap{
 			"iterate": IterateFunc,
 		}
@@ -98,19 +122,34 @@ func (r *TemplateRenderer) Render(templateContent string, templateName string, d
 		}
 
 		// Cache compiled template
-		r.compiledCache.Store(cacheKey, tmpl)
+		entry = r.compiledCache.add(cacheKey, tmpl, len(goTemplate))
+		tmpl = entry.compiled
 	}
 
 	// 使用 MarkerContext 渲染，收集占位符
 	// content 已在上面获取并设置到 data.Content
 	markerCtx := NewMarkerContext(data, content)
+	guard := newRenderGuard(ctx)
+	markerCtx.guard = guard
+
+	// html/template 执行后不能再 Clone，缓存的原始模板只用于克隆
+	exec, err := tmpl.Clone()
+	if err != nil {
+		return "", err
+	}
+	exec.Funcs(guard.funcMap())
 
 	// 从对象池获取 buffer
 	buf := bufferPool.Get().(*bytes.Buffer)
 	buf.Reset()
 
-	if err := tmpl.Execute(buf, markerCtx); err != nil {
+	if err := exec.Execute(guard.writer(buf), markerCtx); err != nil {
 		bufferPool.Put(buf)
+		if budgetErr, ok := AsRenderBudgetError(err); ok {
+			log.Warn().Err(err).Str("template", templateName).Str("limit", budgetErr.Limit).
+				Str("construct", budgetErr.Construct).Msg("Template render budget exceeded")
+			return "", err
+		}
 		log.Error().Err(err).Str("template", templateName).Msg("Failed to execute template with marker context")
 		return "", err
 	}
@@ -128,7 +167,7 @@ func (r *TemplateRenderer) Render(templateContent string, templateName string, d
 		Placeholders: placeholders,
 		TotalSize:    len(templateStr) + 50000, // 预留动态值空间
 	}
-	r.fastRenderer.Store(cacheKey, fastTemplate)
+	r.compiledCache.setFast(cacheKey, entry, fastTemplate)
 
 	// 4. 首次渲染：使用顺序写入方式返回结果
 	resultBuf := bufferPool.Get().(*bytes.Buffer)
@@ -162,34 +201,22 @@ func (r *TemplateRenderer) getPlaceholderValue(p Placeholder, data *RenderData)
 	return resolvePlaceholder(p, data, r.funcsManager)
 }
 
+// entryFast 缓存条目的快速模板（条目不存在或尚未首次渲染时为 nil）
+func entryFast(entry *compiledTemplateEntry) *CompiledFastTemplate {
+	if entry == nil {
+		return nil
+	}
+	return entry.fast.Load()
+}
+
 // ClearCache clears the compiled template cache and fast template cache
 func (r *TemplateRenderer) ClearCache() {
-	r.compiledCache = sync.Map{}
-	if r.fastRenderer != nil {
-		r.fastRenderer.ClearCache()
-	}
+	r.compiledCache.clear()
 }
 
-// GetCacheStats returns cache statistics
+// GetCacheStats returns cache statistics (entries, estimated memory, hits, misses and evictions)
 func (r *TemplateRenderer) GetCacheStats() map[string]interface{} {
-	var count int
-	r.compiledCache.Range(func(_, _ interface{}) bool {
-		count++
-		return true
-	})
-
-	stats := map[string]interface{}{
-		"compiled_templates": count,
-	}
-
-	// 添加快速渲染器统计
-	if r.fastRenderer != nil {
-		for k, v := range r.fastRenderer.GetStats() {
-			stats[k] = v
-		}
-	}
-
-	return stats
+	return r.compiledCache.stats()
 }
 
 // splitByPlaceholders 将模板按占位符拆分为静态片段
@@ -0,0 +1,406 @@
+// Package core provides blue/green template rollout with percentage ramp and auto-rollback
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// 灰度状态
+const (
+	RolloutActive     = "active"
+	RolloutCompleted  = "completed"
+	RolloutRolledBack = "rolled_back"
+)
+
+// AlertTypeTemplateRollback 模板灰度自动回滚告警类型
+const AlertTypeTemplateRollback = "template_rollout_rollback"
+
+var (
+	// ErrRolloutNotFound 灰度不存在
+	ErrRolloutNotFound = errors.New("template rollout not found")
+	// ErrRolloutNotActive 灰度已结束
+	ErrRolloutNotActive = errors.New("template rollout is not active")
+	// ErrRolloutExists 站群已有进行中的灰度
+	ErrRolloutExists = errors.New("site group already has an active rollout")
+	// ErrRolloutTemplateNotFound 新模板不存在或未启用
+	ErrRolloutTemplateNotFound = errors.New("rollout template not found or disabled in site group")
+
+	errRolloutTemplateUnavailable = errors.New("rollout template unavailable")
+)
+
+const templateRolloutColumns = `id, site_group_id, template, percent, status, max_error_delta, min_renders, reason, finished_at, created_at, updated_at`
+
+// TemplateRollout 模板灰度
+// 站群内按 URL 哈希取 percent% 的请求改用新模板渲染，其余请求使用站点原模板；
+// 两组的渲染错误率分别统计（每次调整比例后重新计数），新模板错误率超出原模板 max_error_delta 时自动回滚
+type TemplateRollout struct {
+	ID            int64      `db:"id" json:"id"`
+	SiteGroupID   int        `db:"site_group_id" json:"site_group_id"`
+	Template      string     `db:"template" json:"template"`
+	Percent       int        `db:"percent" json:"percent"`
+	Status        string     `db:"status" json:"status"`
+	MaxErrorDelta float64    `db:"max_error_delta" json:"max_error_delta"`
+	MinRenders    int        `db:"min_renders" json:"min_renders"`
+	Reason        string     `db:"reason" json:"reason"`
+	FinishedAt    *time.Time `db:"finished_at" json:"finished_at"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at" json:"updated_at"`
+
+	Stats *RolloutStats `db:"-" json:"stats,omitempty"` // 进行中的灰度的实时统计
+}
+
+// Namespace 新模板页面的缓存命名空间（每个灰度独立，回滚后不会命中旧页面）
+func (r *TemplateRollout) Namespace() string {
+	return "rollout-" + strconv.FormatInt(r.ID, 10)
+}
+
+// RolloutStats 当前比例下两组的渲染统计
+type RolloutStats struct {
+	ControlRenders  int64   `json:"control_renders"`
+	ControlFailures int64   `json:"control_failures"`
+	ControlRate     float64 `json:"control_error_rate"`
+	CanaryRenders   int64   `json:"canary_renders"`
+	CanaryFailures  int64   `json:"canary_failures"`
+	CanaryRate      float64 `json:"canary_error_rate"`
+}
+
+// RolloutAssignment 请求在灰度中的分组
+type RolloutAssignment struct {
+	Rollout *TemplateRollout
+	Canary  bool // true 表示使用新模板
+
+	state *rolloutState
+}
+
+// CachePath 新模板页面的缓存路径加上灰度命名空间，原模板页面不变
+func (a *RolloutAssignment) CachePath(path string) string {
+	if a == nil || !a.Canary {
+		return path
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return "/@" + a.Rollout.Namespace() + path
+}
+
+type rolloutState struct {
+	rollout *TemplateRollout
+
+	controlRenders, controlFailures atomic.Int64
+	canaryRenders, canaryFailures   atomic.Int64
+	rolledBack                      atomic.Bool
+}
+
+func (s *rolloutState) stats() *RolloutStats {
+	st := &RolloutStats{
+		ControlRenders:  s.controlRenders.Load(),
+		ControlFailures: s.controlFailures.Load(),
+		CanaryRenders:   s.canaryRenders.Load(),
+		CanaryFailures:  s.canaryFailures.Load(),
+	}
+	if st.ControlRenders > 0 {
+		st.ControlRate = float64(st.ControlFailures) / float64(st.ControlRenders)
+	}
+	if st.CanaryRenders > 0 {
+		st.CanaryRate = float64(st.CanaryFailures) / float64(st.CanaryRenders)
+	}
+	return st
+}
+
+// TemplateRollouts 模板灰度发布
+// 进行中的灰度在启动和修改后加载到内存；完成时把站群所有站点的模板切换为新模板
+type TemplateRollouts struct {
+	db        *sqlx.DB
+	siteCache *SiteCache
+	alerts    *AlertManager
+	active    atomic.Pointer[map[int]*rolloutState] // siteGroupID -> 进行中的灰度
+}
+
+// NewTemplateRollouts 创建模板灰度管理
+func NewTemplateRollouts(db *sqlx.DB, siteCache *SiteCache) *TemplateRollouts {
+	r := &TemplateRollouts{db: db, siteCache: siteCache}
+	empty := map[int]*rolloutState{}
+	r.active.Store(&empty)
+	return r
+}
+
+// SetAlertManager 设置告警管理器（自动回滚时告警）
+func (r *TemplateRollouts) SetAlertManager(alerts *AlertManager) {
+	r.alerts = alerts
+}
+
+// Reload 从数据库重新加载进行中的灰度（比例未变的灰度保留统计）
+func (r *TemplateRollouts) Reload(ctx context.Context) error {
+	var rows []*TemplateRollout
+	if err := r.db.SelectContext(ctx, &rows, "SELECT "+templateRolloutColumns+" FROM template_rollouts WHERE status = ?", RolloutActive); err != nil {
+		return fmt.Errorf("load template rollouts: %w", err)
+	}
+	old := *r.active.Load()
+	active := make(map[int]*rolloutState, len(rows))
+	for _, row := range rows {
+		if prev := old[row.SiteGroupID]; prev != nil && prev.rollout.ID == row.ID && prev.rollout.Percent == row.Percent {
+			active[row.SiteGroupID] = prev
+			continue
+		}
+		active[row.SiteGroupID] = &rolloutState{rollout: row}
+	}
+	r.active.Store(&active)
+	log.Info().Int("rollouts", len(rows)).Msg("Template rollouts loaded")
+	return nil
+}
+
+// Assign 返回请求在站群灰度中的分组，站群没有进行中的灰度时返回 nil
+// 按 域名+路径 哈希分桶，同一 URL 始终落在同一组，比例调大时原新模板 URL 不会切回
+func (r *TemplateRollouts) Assign(siteGroupID int, domain, path string) *RolloutAssignment {
+	if r == nil {
+		return nil
+	}
+	state := (*r.active.Load())[siteGroupID]
+	if state == nil || state.rolledBack.Load() {
+		return nil
+	}
+	h := fnv.New32a()
+	h.Write([]byte(domain))
+	h.Write([]byte(path))
+	return &RolloutAssignment{
+		Rollout: state.rollout,
+		Canary:  int(h.Sum32()%100) < state.rollout.Percent,
+		state:   state,
+	}
+}
+
+// RecordRender 记录渲染结果（template 为实际渲染的模板）；新模板错误率超出原模板 max_error_delta 时自动回滚
+// 新模板组因模板不可用或已降级改用了其他模板时按失败计；原模板渲染次数不足 min_renders 时按错误率 0 比较
+func (r *TemplateRollouts) RecordRender(a *RolloutAssignment, template string, err error) {
+	if a == nil || a.state == nil {
+		return
+	}
+	if a.Canary && err == nil && template != a.Rollout.Template {
+		err = errRolloutTemplateUnavailable
+	}
+	s := a.state
+	if !a.Canary {
+		s.controlRenders.Add(1)
+		if err != nil {
+			s.controlFailures.Add(1)
+		}
+		return
+	}
+	renders := s.canaryRenders.Add(1)
+	if err == nil {
+		return
+	}
+	s.canaryFailures.Add(1)
+
+	rollout := s.rollout
+	if renders < int64(rollout.MinRenders) {
+		return
+	}
+	st := s.stats()
+	baseline := st.ControlRate
+	if st.ControlRenders < int64(rollout.MinRenders) {
+		baseline = 0
+	}
+	if st.CanaryRate-baseline <= rollout.MaxErrorDelta || !s.rolledBack.CompareAndSwap(false, true) {
+		return
+	}
+	reason := fmt.Sprintf("auto: error rate %.2f%% vs %.2f%% (max delta %.2f%%)",
+		st.CanaryRate*100, baseline*100, rollout.MaxErrorDelta*100)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := r.finish(ctx, rollout.ID, RolloutRolledBack, reason); err != nil {
+			log.Error().Err(err).Int64("rollout", rollout.ID).Msg("Failed to roll back template rollout")
+		}
+	}()
+	log.Warn().Int64("rollout", rollout.ID).Int("site_group_id", rollout.SiteGroupID).
+		Str("template", rollout.Template).Str("reason", reason).Msg("Template rollout rolled back")
+	if r.alerts != nil {
+		r.alerts.Raise(AlertLevelError, AlertTypeTemplateRollback,
+			fmt.Sprintf("站群 %d 模板 %s 灰度（%d%%）已自动回滚: %s", rollout.SiteGroupID, rollout.Template, rollout.Percent, reason),
+			st.CanaryRate, baseline+rollout.MaxErrorDelta)
+	}
+}
+
+// List 列出灰度（最近的在前），siteGroupID 为 0 时列出全部
+func (r *TemplateRollouts) List(ctx context.Context, siteGroupID int) ([]*TemplateRollout, error) {
+	where, args := "1=1", []interface{}{}
+	if siteGroupID > 0 {
+		where, args = "site_group_id = ?", append(args, siteGroupID)
+	}
+	items := []*TemplateRollout{}
+	if err := r.db.SelectContext(ctx, &items, "SELECT "+templateRolloutColumns+" FROM template_rollouts WHERE "+where+
+		" ORDER BY id DESC LIMIT 200", args...); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		r.attachStats(item)
+	}
+	return items, nil
+}
+
+// GetByID 获取灰度
+func (r *TemplateRollouts) GetByID(ctx context.Context, id int64) (*TemplateRollout, error) {
+	var rollout TemplateRollout
+	if err := r.db.GetContext(ctx, &rollout, "SELECT "+templateRolloutColumns+" FROM template_rollouts WHERE id = ?", id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRolloutNotFound
+		}
+		return nil, err
+	}
+	r.attachStats(&rollout)
+	return &rollout, nil
+}
+
+func (r *TemplateRollouts) attachStats(rollout *TemplateRollout) {
+	if rollout.Status != RolloutActive {
+		return
+	}
+	if state := (*r.active.Load())[rollout.SiteGroupID]; state != nil && state.rollout.ID == rollout.ID {
+		rollout.Stats = state.stats()
+	}
+}
+
+// Create 创建灰度；新模板须为站群内启用的模板，每个站群同时只能有一个进行中的灰度
+func (r *TemplateRollouts) Create(ctx context.Context, rollout *TemplateRollout) (int64, error) {
+	if rollout.Percent < 0 || rollout.Percent > 100 {
+		return 0, errors.New("percent must be between 0 and 100")
+	}
+	if rollout.MaxErrorDelta <= 0 || rollout.MaxErrorDelta > 1 {
+		return 0, errors.New("max_error_delta must be between 0 and 1")
+	}
+	if rollout.MinRenders < 1 {
+		return 0, errors.New("min_renders must be positive")
+	}
+	var exists int
+	if err := r.db.GetContext(ctx, &exists,
+		"SELECT COUNT(*) FROM templates WHERE site_group_id = ? AND name = ? AND status = 1",
+		rollout.SiteGroupID, rollout.Template); err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		return 0, ErrRolloutTemplateNotFound
+	}
+	if err := r.db.GetContext(ctx, &exists,
+		"SELECT COUNT(*) FROM template_rollouts WHERE site_group_id = ? AND status = ?",
+		rollout.SiteGroupID, RolloutActive); err != nil {
+		return 0, err
+	}
+	if exists > 0 {
+		return 0, ErrRolloutExists
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO template_rollouts (site_group_id, template, percent, status, max_error_delta, min_renders)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		rollout.SiteGroupID, rollout.Template, rollout.Percent, RolloutActive, rollout.MaxErrorDelta, rollout.MinRenders)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := res.LastInsertId()
+	r.changed(ctx)
+	return id, nil
+}
+
+// SetPercent 调整新模板比例（两组统计重新计数）
+func (r *TemplateRollouts) SetPercent(ctx context.Context, id int64, percent int) error {
+	if percent < 0 || percent > 100 {
+		return errors.New("percent must be between 0 and 100")
+	}
+	if err := r.requireActive(ctx, id); err != nil {
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, "UPDATE template_rollouts SET percent = ? WHERE id = ? AND status = ?",
+		percent, id, RolloutActive); err != nil {
+		return err
+	}
+	r.changed(ctx)
+	return nil
+}
+
+// Complete 完成灰度：站群所有站点切换为新模板
+// 原模板的已缓存页面在过期或清理缓存后更新
+func (r *TemplateRollouts) Complete(ctx context.Context, id int64) error {
+	rollout, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rollout.Status != RolloutActive {
+		return ErrRolloutNotActive
+	}
+	if _, err := r.db.ExecContext(ctx,
+		"UPDATE sites SET template = ?, version = version + 1, updated_at = NOW() WHERE site_group_id = ?",
+		rollout.Template, rollout.SiteGroupID); err != nil {
+		return err
+	}
+	GetQueryCache().Invalidate("sites")
+	if err := r.finish(ctx, id, RolloutCompleted, ""); err != nil {
+		return err
+	}
+	if r.siteCache != nil {
+		if _, err := r.siteCache.Sync(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to sync site cache after template rollout")
+		}
+	}
+	return nil
+}
+
+// Rollback 手动回滚：所有请求恢复使用原模板
+func (r *TemplateRollouts) Rollback(ctx context.Context, id int64, reason string) error {
+	if err := r.requireActive(ctx, id); err != nil {
+		return err
+	}
+	if reason == "" {
+		reason = "manual"
+	}
+	return r.finish(ctx, id, RolloutRolledBack, reason)
+}
+
+func (r *TemplateRollouts) requireActive(ctx context.Context, id int64) error {
+	var status string
+	if err := r.db.GetContext(ctx, &status, "SELECT status FROM template_rollouts WHERE id = ?", id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRolloutNotFound
+		}
+		return err
+	}
+	if status != RolloutActive {
+		return ErrRolloutNotActive
+	}
+	return nil
+}
+
+// finish 结束灰度
+func (r *TemplateRollouts) finish(ctx context.Context, id int64, status, reason string) error {
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE template_rollouts SET status = ?, reason = ?, finished_at = NOW() WHERE id = ? AND status = ?",
+		status, reason, id, RolloutActive)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrRolloutNotActive
+	}
+	r.changed(ctx)
+	return nil
+}
+
+func (r *TemplateRollouts) changed(ctx context.Context) {
+	if err := r.Reload(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload template rollouts")
+	}
+}
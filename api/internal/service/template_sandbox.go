@@ -0,0 +1,199 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"sync/atomic"
+	"time"
+
+	"seo-generator/api/pkg/config"
+)
+
+// 模板渲染预算类型
+const (
+	RenderLimitTimeout  = "timeout"  // 渲染超时
+	RenderLimitIterate  = "iterate"  // 单次 iterate() 长度超限
+	RenderLimitCalls    = "calls"    // 函数调用次数超限
+	RenderLimitOutput   = "output"   // 输出大小超限
+	RenderLimitCanceled = "canceled" // 请求已取消
+)
+
+// RenderLimits 单次模板渲染的资源上限
+type RenderLimits struct {
+	Timeout        time.Duration
+	MaxIterate     int
+	MaxCalls       int64
+	MaxOutputBytes int
+}
+
+// renderLimits 当前生效的渲染上限，启动时由 SetRenderLimits 设置
+var renderLimits atomic.Pointer[RenderLimits]
+
+func init() {
+	renderLimits.Store(&RenderLimits{
+		Timeout:        2 * time.Second,
+		MaxIterate:     10000,
+		MaxCalls:       50000,
+		MaxOutputBytes: 10 << 20,
+	})
+}
+
+// SetRenderLimits 按配置设置模板渲染上限，<=0 的项不限制
+func SetRenderLimits(cfg config.TemplateSandboxConfig) {
+	renderLimits.Store(&RenderLimits{
+		Timeout:        time.Duration(cfg.TimeoutMs) * time.Millisecond,
+		MaxIterate:     cfg.MaxIterate,
+		MaxCalls:       int64(cfg.MaxCalls),
+		MaxOutputBytes: cfg.MaxOutputBytes,
+	})
+}
+
+// GetRenderLimits 当前生效的渲染上限
+func GetRenderLimits() RenderLimits {
+	return *renderLimits.Load()
+}
+
+// RenderBudgetError 模板渲染超出预算
+// 在模板函数内以 panic 抛出，由 text/template 转为执行错误（错误信息带模板行列和触发的表达式）
+type RenderBudgetError struct {
+	Limit     string // 超出的预算类型（RenderLimit*）
+	Construct string // 触发的模板结构，如 iterate、RandomKeyword、output
+	Value     int64
+	Max       int64
+	Elapsed   time.Duration
+	Err       error // canceled 时为 ctx.Err()
+}
+
+func (e *RenderBudgetError) Error() string {
+	switch e.Limit {
+	case RenderLimitIterate:
+		return fmt.Sprintf("template budget: iterate(%d) exceeds max_iterate %d", e.Value, e.Max)
+	case RenderLimitCalls:
+		return fmt.Sprintf("template budget: function calls exceed max_calls %d (at %s)", e.Max, e.Construct)
+	case RenderLimitOutput:
+		return fmt.Sprintf("template budget: output exceeds max_output_bytes %d", e.Max)
+	case RenderLimitCanceled:
+		return fmt.Sprintf("template render canceled after %s (at %s): %v", e.Elapsed.Round(time.Millisecond), e.Construct, e.Err)
+	default:
+		return fmt.Sprintf("template budget: render timeout after %s (at %s)", e.Elapsed.Round(time.Millisecond), e.Construct)
+	}
+}
+
+func (e *RenderBudgetError) Unwrap() error {
+	return e.Err
+}
+
+// AsRenderBudgetError 从渲染错误中取出预算错误
+func AsRenderBudgetError(err error) (*RenderBudgetError, bool) {
+	var budgetErr *RenderBudgetError
+	if errors.As(err, &budgetErr) {
+		return budgetErr, true
+	}
+	return nil, false
+}
+
+// placeholderConstructs 占位符对应的模板函数名（用于预算错误信息）
+var placeholderConstructs = map[PlaceholderType]string{
+	PlaceholderCls:            "cls",
+	PlaceholderURL:            "random_url",
+	PlaceholderKeyword:        "random_keyword",
+	PlaceholderKeywordEmoji:   "keyword_with_emoji",
+	PlaceholderImage:          "random_image",
+	PlaceholderNumber:         "random_number",
+	PlaceholderNow:            "now",
+	PlaceholderContent:        "content",
+	PlaceholderTitle:          "title",
+	PlaceholderArticleContent: "article_content",
+	PlaceholderInternalLink:   "internal_link",
+	PlaceholderPinyinSlug:     "pinyin_slug",
+	PlaceholderPublishDate:    "publish_date",
+	PlaceholderClsName:        "cls_name",
+}
+
+// renderGuard 单次渲染的预算跟踪（截止时间 + 函数调用计数）
+// 超时只能在函数调用、iterate 和输出写入时检查，无法中断单个正在执行的函数
+type renderGuard struct {
+	ctx      context.Context
+	limits   RenderLimits
+	start    time.Time
+	deadline time.Time
+	calls    atomic.Int64
+}
+
+func newRenderGuard(ctx context.Context) *renderGuard {
+	g := &renderGuard{ctx: ctx, limits: GetRenderLimits(), start: time.Now()}
+	if g.limits.Timeout > 0 {
+		g.deadline = g.start.Add(g.limits.Timeout)
+	}
+	if d, ok := ctx.Deadline(); ok && (g.deadline.IsZero() || d.Before(g.deadline)) {
+		g.deadline = d
+	}
+	return g
+}
+
+// exceeded 检查截止时间和请求取消，未超出时返回 nil
+func (g *renderGuard) exceeded(construct string) *RenderBudgetError {
+	if err := g.ctx.Err(); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return &RenderBudgetError{Limit: RenderLimitCanceled, Construct: construct, Elapsed: time.Since(g.start), Err: err}
+	}
+	if !g.deadline.IsZero() && time.Now().After(g.deadline) {
+		return &RenderBudgetError{Limit: RenderLimitTimeout, Construct: construct, Elapsed: time.Since(g.start)}
+	}
+	return nil
+}
+
+// call 记录一次函数调用
+func (g *renderGuard) call(construct string) {
+	if g == nil {
+		return
+	}
+	if n := g.calls.Add(1); g.limits.MaxCalls > 0 && n > g.limits.MaxCalls {
+		panic(&RenderBudgetError{Limit: RenderLimitCalls, Construct: construct, Value: n, Max: g.limits.MaxCalls})
+	}
+	if err := g.exceeded(construct); err != nil {
+		panic(err)
+	}
+}
+
+// iterate 带预算检查的 iterate()
+func (g *renderGuard) iterate(n int) []int {
+	if g.limits.MaxIterate > 0 && n > g.limits.MaxIterate {
+		panic(&RenderBudgetError{Limit: RenderLimitIterate, Construct: "iterate", Value: int64(n), Max: int64(g.limits.MaxIterate)})
+	}
+	g.call("iterate")
+	if n < 0 {
+		n = 0
+	}
+	return IterateFunc(n)
+}
+
+// funcMap 绑定本次渲染预算的模板函数
+func (g *renderGuard) funcMap() template.FuncMap {
+	return template.FuncMap{
+		"iterate": g.iterate,
+	}
+}
+
+// writer 检查截止时间和输出大小的 writer（返回错误会终止 Execute）
+func (g *renderGuard) writer(buf *bytes.Buffer) *budgetWriter {
+	return &budgetWriter{buf: buf, guard: g}
+}
+
+type budgetWriter struct {
+	buf   *bytes.Buffer
+	guard *renderGuard
+}
+
+func (w *budgetWriter) Write(p []byte) (int, error) {
+	g := w.guard
+	if g.limits.MaxOutputBytes > 0 && w.buf.Len()+len(p) > g.limits.MaxOutputBytes {
+		return 0, &RenderBudgetError{Limit: RenderLimitOutput, Construct: "output", Value: int64(w.buf.Len() + len(p)), Max: int64(g.limits.MaxOutputBytes)}
+	}
+	if err := g.exceeded("output"); err != nil {
+		return 0, err
+	}
+	return w.buf.Write(p)
+}
@@ -0,0 +1,452 @@
+// Package core provides reusable template snippets included via {{ include "name" ... }}
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// 片段相关错误
+var (
+	ErrSnippetNotFound = errors.New("template snippet not found")
+	ErrSnippetExists   = errors.New("template snippet already exists")
+	ErrSnippetInUse    = errors.New("template snippet is in use")
+	ErrSnippetInvalid  = errors.New("invalid template snippet")
+)
+
+// maxSnippetDepth 片段嵌套的最大层数（超过时视为循环引用）
+const maxSnippetDepth = 10
+
+var (
+	// snippetNamePattern 片段名：字母数字开头，可含 _ . -
+	snippetNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+	// includeTagPattern {{ include "name" arg1 arg2 }}，参数为带引号的字面量、变量或无嵌套括号的函数调用
+	includeTagPattern = regexp.MustCompile(`\{\{-?\s*include\s+("[^"]*"|'[^']*')((?:\s+(?:"[^"]*"|'[^']*'|[A-Za-z_][\w.]*(?:\([^(){}]*\))?|-?\d+(?:\.\d+)?))*)\s*-?\}\}`)
+
+	// includeArgPattern include 标签中的单个参数
+	includeArgPattern = regexp.MustCompile(`"[^"]*"|'[^']*'|[A-Za-z_][\w.]*(?:\([^(){}]*\))?|-?\d+(?:\.\d+)?`)
+
+	// snippetArgPattern 片段内的参数引用 {{ arg1 }}
+	snippetArgPattern = regexp.MustCompile(`\{\{\s*arg(\d+)\s*\}\}`)
+)
+
+// ValidSnippetName 片段名是否合法
+func ValidSnippetName(name string) bool {
+	return snippetNamePattern.MatchString(name)
+}
+
+// TemplateSnippet 模板片段
+// 模板中用 {{ include "name" arg1 arg2 }} 引用，片段内用 {{ arg1 }}、{{ arg2 }} 引用参数：
+// 带引号的参数按原文插入，其余参数（变量、函数调用）插入为 {{ 表达式 }}，缺少的参数为空。
+// 片段可以再 include 其他片段，加载模板时展开
+type TemplateSnippet struct {
+	ID          int       `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description"`
+	Content     string    `db:"content" json:"content"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// SnippetUsage 引用片段的模板
+type SnippetUsage struct {
+	TemplateID  int    `db:"id" json:"template_id"`
+	Name        string `db:"name" json:"name"`
+	SiteGroupID int    `db:"site_group_id" json:"site_group_id"`
+	Status      int    `db:"status" json:"status"`
+	Direct      bool   `db:"-" json:"direct"` // false 表示经由其他片段间接引用
+}
+
+// TemplateSnippetItem 片段列表项（含引用情况）
+type TemplateSnippetItem struct {
+	TemplateSnippet
+	Templates []SnippetUsage `json:"templates"`
+	Snippets  []string       `json:"snippets"` // 直接引用该片段的其他片段
+}
+
+// TemplateSnippets 模板片段库
+// 片段内容保存在内存中，模板载入 TemplateCache 时展开；片段变更后重新加载所有（直接或间接）引用它的模板
+type TemplateSnippets struct {
+	db       *sqlx.DB
+	cache    *TemplateCache
+	snippets atomic.Pointer[map[string]string] // name -> content
+}
+
+// 全局片段库（模板契约检查使用）
+var globalTemplateSnippets atomic.Pointer[TemplateSnippets]
+
+// NewTemplateSnippets 创建片段库，并设置为 TemplateCache 和模板检查使用的片段库
+func NewTemplateSnippets(db *sqlx.DB, cache *TemplateCache) *TemplateSnippets {
+	s := &TemplateSnippets{db: db, cache: cache}
+	s.snippets.Store(&map[string]string{})
+	if cache != nil {
+		cache.SetSnippets(s)
+	}
+	globalTemplateSnippets.Store(s)
+	return s
+}
+
+// ExpandTemplateSnippets 用全局片段库展开模板中的 include；未创建片段库时原样返回
+func ExpandTemplateSnippets(content string) (string, error) {
+	s := globalTemplateSnippets.Load()
+	if s == nil {
+		return content, nil
+	}
+	return s.Expand(content)
+}
+
+// Load 从数据库加载全部片段
+func (s *TemplateSnippets) Load(ctx context.Context) error {
+	var rows []TemplateSnippet
+	if err := s.db.SelectContext(ctx, &rows, "SELECT name, content FROM template_snippets"); err != nil {
+		return fmt.Errorf("load template snippets: %w", err)
+	}
+	m := make(map[string]string, len(rows))
+	for _, row := range rows {
+		m[row.Name] = row.Content
+	}
+	s.snippets.Store(&m)
+	log.Info().Int("snippets", len(m)).Msg("Template snippets loaded")
+	return nil
+}
+
+// Expand 展开 content 中的 include（包括片段内的嵌套 include）
+// 片段不存在或循环引用时该 include 替换为 HTML 注释，并返回第一个错误
+func (s *TemplateSnippets) Expand(content string) (string, error) {
+	if !strings.Contains(content, "include") {
+		return content, nil
+	}
+	return expandSnippets(*s.snippets.Load(), content, nil, nil)
+}
+
+// expandSnippets 递归展开 include；outer 为外层片段的参数（嵌套 include 的参数可以引用 arg1 等），stack 为当前展开路径
+func expandSnippets(snippets map[string]string, content string, outer []string, stack []string) (string, error) {
+	var firstErr error
+	result := includeTagPattern.ReplaceAllStringFunc(content, func(tag string) string {
+		m := includeTagPattern.FindStringSubmatch(tag)
+		name := unquoteSnippetArg(m[1])
+		args := includeArgPattern.FindAllString(m[2], -1)
+		for i, arg := range args {
+			if ref := snippetArgIndex(arg); ref > 0 {
+				args[i] = snippetArg(outer, ref)
+			}
+		}
+
+		var err error
+		body, ok := snippets[name]
+		switch {
+		case !ok:
+			err = fmt.Errorf("snippet %q not found", name)
+		case containsString(stack, name) || len(stack) >= maxSnippetDepth:
+			err = fmt.Errorf("snippet include cycle: %s -> %s", strings.Join(stack, " -> "), name)
+		}
+		if err == nil {
+			body = snippetArgPattern.ReplaceAllStringFunc(body, func(ref string) string {
+				n, _ := strconv.Atoi(snippetArgPattern.FindStringSubmatch(ref)[1])
+				return renderSnippetArg(snippetArg(args, n))
+			})
+			body, err = expandSnippets(snippets, body, args, append(stack, name))
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return "<!-- include " + strconv.Quote(name) + ": " + strings.ReplaceAll(err.Error(), "--", "") + " -->"
+		}
+		return body
+	})
+	return result, firstErr
+}
+
+// snippetArgIndex arg1 -> 1，不是参数引用时返回 0
+func snippetArgIndex(token string) int {
+	if !strings.HasPrefix(token, "arg") {
+		return 0
+	}
+	n, err := strconv.Atoi(token[3:])
+	if err != nil || n < 1 {
+		return 0
+	}
+	return n
+}
+
+// snippetArg 第 n 个参数（从 1 开始），不存在时为空字面量
+func snippetArg(args []string, n int) string {
+	if n < 1 || n > len(args) {
+		return `""`
+	}
+	return args[n-1]
+}
+
+// renderSnippetArg 带引号的参数按原文插入，其余插入为 {{ 表达式 }}
+func renderSnippetArg(arg string) string {
+	if len(arg) >= 2 && (arg[0] == '"' || arg[0] == '\'') {
+		return unquoteSnippetArg(arg)
+	}
+	return "{{ " + arg + " }}"
+}
+
+func unquoteSnippetArg(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// snippetIncludes content 直接 include 的片段名（去重）
+func snippetIncludes(content string) []string {
+	var names []string
+	for _, m := range includeTagPattern.FindAllStringSubmatch(content, -1) {
+		name := unquoteSnippetArg(m[1])
+		if !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// snippetClosure 从 names 出发（含自身）可达的全部片段
+func snippetClosure(snippets map[string]string, names []string) map[string]bool {
+	seen := map[string]bool{}
+	queue := append([]string(nil), names...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if body, ok := snippets[name]; ok {
+			queue = append(queue, snippetIncludes(body)...)
+		}
+	}
+	return seen
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// List 全部片段及其引用情况
+func (s *TemplateSnippets) List(ctx context.Context) ([]TemplateSnippetItem, error) {
+	var rows []TemplateSnippet
+	if err := s.db.SelectContext(ctx, &rows,
+		"SELECT id, name, description, content, created_at, updated_at FROM template_snippets ORDER BY name"); err != nil {
+		return nil, err
+	}
+	templates, err := s.includingTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snippets := *s.snippets.Load()
+	items := make([]TemplateSnippetItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, TemplateSnippetItem{
+			TemplateSnippet: row,
+			Templates:       usageOf(snippets, templates, row.Name),
+			Snippets:        snippetsIncluding(snippets, row.Name),
+		})
+	}
+	return items, nil
+}
+
+// Get 片段详情及其引用情况
+func (s *TemplateSnippets) Get(ctx context.Context, name string) (*TemplateSnippetItem, error) {
+	var row TemplateSnippet
+	if err := s.db.GetContext(ctx, &row,
+		"SELECT id, name, description, content, created_at, updated_at FROM template_snippets WHERE name = ?", name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSnippetNotFound
+		}
+		return nil, err
+	}
+	usage, err := s.Usage(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateSnippetItem{
+		TemplateSnippet: row,
+		Templates:       usage,
+		Snippets:        snippetsIncluding(*s.snippets.Load(), name),
+	}, nil
+}
+
+// Usage 直接或经由其他片段间接引用 name 的模板
+func (s *TemplateSnippets) Usage(ctx context.Context, name string) ([]SnippetUsage, error) {
+	templates, err := s.includingTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return usageOf(*s.snippets.Load(), templates, name), nil
+}
+
+// includingTemplates 内容中含 include 的模板
+func (s *TemplateSnippets) includingTemplates(ctx context.Context) ([]snippetTemplate, error) {
+	var rows []snippetTemplate
+	if err := s.db.SelectContext(ctx, &rows,
+		"SELECT id, name, site_group_id, status, content FROM templates WHERE content LIKE '%include%' ORDER BY site_group_id, name"); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+type snippetTemplate struct {
+	SnippetUsage
+	Content string `db:"content"`
+}
+
+func usageOf(snippets map[string]string, templates []snippetTemplate, name string) []SnippetUsage {
+	usage := []SnippetUsage{}
+	for _, t := range templates {
+		direct := snippetIncludes(t.Content)
+		if containsString(direct, name) {
+			u := t.SnippetUsage
+			u.Direct = true
+			usage = append(usage, u)
+		} else if snippetClosure(snippets, direct)[name] {
+			usage = append(usage, t.SnippetUsage)
+		}
+	}
+	return usage
+}
+
+func snippetsIncluding(snippets map[string]string, name string) []string {
+	names := []string{}
+	for other, body := range snippets {
+		if other != name && containsString(snippetIncludes(body), name) {
+			names = append(names, other)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Create 创建片段
+func (s *TemplateSnippets) Create(ctx context.Context, snippet *TemplateSnippet) (int, error) {
+	if err := s.validate(snippet.Name, snippet.Content); err != nil {
+		return 0, err
+	}
+	var exists int
+	if err := s.db.GetContext(ctx, &exists, "SELECT COUNT(*) FROM template_snippets WHERE name = ?", snippet.Name); err != nil {
+		return 0, err
+	}
+	if exists > 0 {
+		return 0, ErrSnippetExists
+	}
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO template_snippets (name, description, content) VALUES (?, ?, ?)",
+		snippet.Name, snippet.Description, snippet.Content)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := res.LastInsertId()
+	// 新片段可能被已保存的模板引用（之前展开为错误注释）
+	s.changed(ctx, snippet.Name)
+	return int(id), nil
+}
+
+// Update 更新片段内容和说明（名称不可修改），并重新加载引用它的模板
+func (s *TemplateSnippets) Update(ctx context.Context, snippet *TemplateSnippet) error {
+	if err := s.validate(snippet.Name, snippet.Content); err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE template_snippets SET description = ?, content = ? WHERE name = ?",
+		snippet.Description, snippet.Content, snippet.Name)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		var exists int
+		if err := s.db.GetContext(ctx, &exists, "SELECT COUNT(*) FROM template_snippets WHERE name = ?", snippet.Name); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return ErrSnippetNotFound
+		}
+	}
+	s.changed(ctx, snippet.Name)
+	return nil
+}
+
+// Delete 删除片段；仍被模板或其他片段引用时返回 ErrSnippetInUse
+func (s *TemplateSnippets) Delete(ctx context.Context, name string) error {
+	usage, err := s.Usage(ctx, name)
+	if err != nil {
+		return err
+	}
+	if len(usage) > 0 || len(snippetsIncluding(*s.snippets.Load(), name)) > 0 {
+		return ErrSnippetInUse
+	}
+	res, err := s.db.ExecContext(ctx, "DELETE FROM template_snippets WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSnippetNotFound
+	}
+	s.changed(ctx, name)
+	return nil
+}
+
+// validate 检查片段名，并用保存后的片段库展开片段，检查引用的片段是否存在、是否循环引用
+func (s *TemplateSnippets) validate(name, content string) error {
+	if !ValidSnippetName(name) {
+		return fmt.Errorf("%w: invalid name %q", ErrSnippetInvalid, name)
+	}
+	current := *s.snippets.Load()
+	next := make(map[string]string, len(current)+1)
+	for k, v := range current {
+		next[k] = v
+	}
+	next[name] = content
+	if _, err := expandSnippets(next, content, nil, []string{name}); err != nil {
+		return fmt.Errorf("%w: %v", ErrSnippetInvalid, err)
+	}
+	return nil
+}
+
+// changed 重新加载片段库，并重新加载（直接或间接）引用 name 的启用模板
+func (s *TemplateSnippets) changed(ctx context.Context, name string) {
+	if err := s.Load(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload template snippets")
+		return
+	}
+	if s.cache == nil {
+		return
+	}
+	usage, err := s.Usage(ctx, name)
+	if err != nil {
+		log.Warn().Err(err).Str("snippet", name).Msg("Failed to find templates including snippet")
+		return
+	}
+	reloaded := 0
+	for _, u := range usage {
+		if u.Status != 1 {
+			continue
+		}
+		if err := s.cache.Reload(ctx, u.Name, u.SiteGroupID); err != nil {
+			log.Warn().Err(err).Str("template", u.Name).Int("site_group_id", u.SiteGroupID).Msg("Failed to reload template after snippet change")
+			continue
+		}
+		reloaded++
+	}
+	log.Info().Str("snippet", name).Int("templates", reloaded).Msg("Templates reloaded after snippet change")
+}
@@ -0,0 +1,305 @@
+// Package core provides buffered per-template usage counters
+package core
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// templateUsageFlushInterval 渲染计数写库间隔
+const templateUsageFlushInterval = 30 * time.Second
+
+const (
+	// pageTemplateMaxPending 待写库的页面模板记录上限，超出时丢弃（下次渲染会再记录）
+	pageTemplateMaxPending = 20000
+	// pageTemplateRetention page_templates 中超过该时间未重新渲染的记录会被清理
+	pageTemplateRetention = 90 * 24 * time.Hour
+	pageTemplateBatchSize = 500
+)
+
+// templateUsageDelta 待写库的增量
+type templateUsageDelta struct {
+	renders  int64
+	lastUsed time.Time
+}
+
+// pageTemplate 页面最近一次渲染使用的模板
+type pageTemplate struct {
+	siteGroupID int
+	domain      string
+	path        string
+	template    string
+	renderedAt  time.Time
+}
+
+// TemplateUsage 模板使用统计
+// 页面渲染时只在内存累加，定期合并写入 templates.render_count / last_used_at，
+// 供模板库按最近使用排序和查找未使用模板。
+// 同时记录每个 URL 最近一次渲染使用的模板（page_templates），与蜘蛛日志关联统计各模板的抓取情况；
+// Nginx 直接命中缓存的访问也能按该表归属到模板。
+type TemplateUsage struct {
+	db *sqlx.DB
+
+	mu      sync.Mutex
+	pending map[int]*templateUsageDelta // templateID -> 增量
+	pages   map[string]pageTemplate     // domain + path -> 模板
+	purged  time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewTemplateUsage 创建模板使用统计
+func NewTemplateUsage(db *sqlx.DB) *TemplateUsage {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TemplateUsage{
+		db:      db,
+		pending: make(map[int]*templateUsageDelta),
+		pages:   make(map[string]pageTemplate),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Start 启动后台写库
+func (u *TemplateUsage) Start() {
+	u.wg.Add(1)
+	go u.flushLoop()
+}
+
+// Stop 停止后台写库并写入剩余计数
+func (u *TemplateUsage) Stop() {
+	u.cancel()
+	u.wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	u.flush(ctx)
+}
+
+// Record 记录一次渲染
+func (u *TemplateUsage) Record(templateID int) {
+	if templateID <= 0 {
+		return
+	}
+	now := time.Now()
+
+	u.mu.Lock()
+	d, ok := u.pending[templateID]
+	if !ok {
+		d = &templateUsageDelta{}
+		u.pending[templateID] = d
+	}
+	d.renders++
+	d.lastUsed = now
+	u.mu.Unlock()
+}
+
+// RecordPage 记录页面（domain + path）本次渲染使用的模板
+func (u *TemplateUsage) RecordPage(siteGroupID int, domain, path, template string) {
+	if domain == "" || template == "" {
+		return
+	}
+	if len(path) > 500 {
+		path = path[:500]
+	}
+	key := domain + "\x00" + path
+
+	u.mu.Lock()
+	if _, ok := u.pages[key]; ok || len(u.pages) < pageTemplateMaxPending {
+		u.pages[key] = pageTemplate{siteGroupID: siteGroupID, domain: domain, path: path, template: template, renderedAt: time.Now()}
+	}
+	u.mu.Unlock()
+}
+
+func (u *TemplateUsage) flushLoop() {
+	defer u.wg.Done()
+	ticker := time.NewTicker(templateUsageFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-u.ctx.Done():
+			return
+		case <-ticker.C:
+			u.flush(u.ctx)
+		}
+	}
+}
+
+// flush 写入累计增量，失败的增量合并回待写队列
+func (u *TemplateUsage) flush(ctx context.Context) {
+	u.mu.Lock()
+	batch := u.pending
+	u.pending = make(map[int]*templateUsageDelta, len(batch))
+	pages := u.pages
+	u.pages = make(map[string]pageTemplate, len(pages))
+	u.mu.Unlock()
+
+	u.flushPages(ctx, pages)
+
+	for id, d := range batch {
+		_, err := u.db.ExecContext(ctx,
+			`UPDATE templates SET render_count = render_count + ?,
+			        last_used_at = GREATEST(COALESCE(last_used_at, ?), ?)
+			 WHERE id = ?`,
+			d.renders, d.lastUsed, d.lastUsed, id)
+		if err == nil {
+			continue
+		}
+		if ctx.Err() == nil {
+			log.Warn().Err(err).Int("template_id", id).Msg("Failed to flush template usage")
+		}
+
+		u.mu.Lock()
+		if cur, ok := u.pending[id]; ok {
+			cur.renders += d.renders
+			if d.lastUsed.After(cur.lastUsed) {
+				cur.lastUsed = d.lastUsed
+			}
+		} else {
+			u.pending[id] = d
+		}
+		u.mu.Unlock()
+	}
+}
+
+// flushPages 批量写入页面模板记录，每小时清理一次过期记录
+func (u *TemplateUsage) flushPages(ctx context.Context, pages map[string]pageTemplate) {
+	rows := make([]pageTemplate, 0, len(pages))
+	for _, p := range pages {
+		rows = append(rows, p)
+	}
+	for start := 0; start < len(rows); start += pageTemplateBatchSize {
+		end := start + pageTemplateBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		chunk := rows[start:end]
+		values := make([]string, 0, len(chunk))
+		args := make([]interface{}, 0, len(chunk)*6)
+		for _, p := range chunk {
+			values = append(values, "(?, ?, ?, ?, ?, ?)")
+			args = append(args, p.domain, pagePathHash(p.path), p.path, p.siteGroupID, p.template, p.renderedAt)
+		}
+		if _, err := u.db.ExecContext(ctx,
+			`INSERT INTO page_templates (domain, path_hash, path, site_group_id, template, rendered_at) VALUES `+strings.Join(values, ",")+`
+			 ON DUPLICATE KEY UPDATE site_group_id = VALUES(site_group_id), template = VALUES(template), rendered_at = VALUES(rendered_at)`,
+			args...); err != nil {
+			if ctx.Err() == nil {
+				log.Warn().Err(err).Int("count", len(chunk)).Msg("Failed to flush page templates")
+			}
+			return
+		}
+	}
+
+	if time.Since(u.purged) < time.Hour {
+		return
+	}
+	u.purged = time.Now()
+	if _, err := u.db.ExecContext(ctx,
+		"DELETE FROM page_templates WHERE rendered_at < ? LIMIT 10000", time.Now().Add(-pageTemplateRetention)); err != nil && ctx.Err() == nil {
+		log.Warn().Err(err).Msg("Failed to purge page templates")
+	}
+}
+
+// pagePathHash page_templates.path_hash，与 SQL 中的 MD5(path) 一致
+func pagePathHash(path string) string {
+	sum := md5.Sum([]byte(path))
+	return hex.EncodeToString(sum[:])
+}
+
+// TemplateCrawlStat 一个站群下一个模板的蜘蛛抓取统计
+type TemplateCrawlStat struct {
+	SiteGroupID       int     `json:"site_group_id" db:"site_group_id"`
+	Template          string  `json:"template" db:"template"`
+	Pages             int64   `json:"pages" db:"-"`                                 // 使用该模板渲染过的 URL 数
+	Crawls            int64   `json:"crawls" db:"crawls"`                           // 蜘蛛访问次数
+	UniqueURLs        int64   `json:"unique_urls" db:"unique_urls"`                 // 被抓取的不同 URL 数
+	RevisitedURLs     int64   `json:"revisited_urls" db:"revisited_urls"`           // 被抓取两次以上的 URL 数
+	AvgRevisitSeconds float64 `json:"avg_revisit_seconds" db:"avg_revisit_seconds"` // 同一 URL 两次抓取的平均间隔
+	Coverage          float64 `json:"coverage" db:"-"`                              // unique_urls / pages
+}
+
+// CrawlReport 按站群和模板统计 since 以来的蜘蛛抓取（只统计 200 响应）
+// siteGroupID 为 0 时统计全部站群，spiderType 为空时统计全部蜘蛛；
+// 蜘蛛日志只写 ClickHouse（不镜像 MySQL）时没有数据
+func (u *TemplateUsage) CrawlReport(ctx context.Context, siteGroupID int, since time.Time, spiderType string) ([]TemplateCrawlStat, error) {
+	where := "l.created_at >= ? AND l.status = 200"
+	args := []interface{}{since}
+	pageWhere := "1 = 1"
+	var pageArgs []interface{}
+	if siteGroupID > 0 {
+		where += " AND p.site_group_id = ?"
+		args = append(args, siteGroupID)
+		pageWhere = "site_group_id = ?"
+		pageArgs = append(pageArgs, siteGroupID)
+	}
+	if spiderType != "" {
+		where += " AND l.spider_type = ?"
+		args = append(args, spiderType)
+	}
+
+	stats := []TemplateCrawlStat{}
+	if err := u.db.SelectContext(ctx, &stats, `
+		SELECT t.site_group_id, t.template, SUM(t.cnt) AS crawls, COUNT(*) AS unique_urls,
+		       SUM(t.cnt > 1) AS revisited_urls,
+		       COALESCE(AVG(CASE WHEN t.cnt > 1 THEN TIMESTAMPDIFF(SECOND, t.first_at, t.last_at) / (t.cnt - 1) END), 0) AS avg_revisit_seconds
+		FROM (
+			SELECT p.site_group_id, p.template, l.domain, l.path, COUNT(*) AS cnt,
+			       MIN(l.created_at) AS first_at, MAX(l.created_at) AS last_at
+			FROM spider_logs l
+			JOIN page_templates p ON p.domain = l.domain AND p.path_hash = MD5(l.path)
+			WHERE `+where+`
+			GROUP BY p.site_group_id, p.template, l.domain, l.path
+		) t
+		GROUP BY t.site_group_id, t.template`, args...); err != nil {
+		return nil, err
+	}
+
+	var pages []struct {
+		SiteGroupID int    `db:"site_group_id"`
+		Template    string `db:"template"`
+		Pages       int64  `db:"pages"`
+	}
+	if err := u.db.SelectContext(ctx, &pages,
+		"SELECT site_group_id, template, COUNT(*) AS pages FROM page_templates WHERE "+pageWhere+" GROUP BY site_group_id, template",
+		pageArgs...); err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(stats))
+	for i, s := range stats {
+		index[strconv.Itoa(s.SiteGroupID)+"/"+s.Template] = i
+	}
+	for _, p := range pages {
+		key := strconv.Itoa(p.SiteGroupID) + "/" + p.Template
+		if i, ok := index[key]; ok {
+			stats[i].Pages = p.Pages
+			continue
+		}
+		// 渲染过但统计期内没有被抓取的模板
+		stats = append(stats, TemplateCrawlStat{SiteGroupID: p.SiteGroupID, Template: p.Template, Pages: p.Pages})
+	}
+	for i := range stats {
+		if stats[i].Pages > 0 {
+			stats[i].Coverage = float64(stats[i].UniqueURLs) / float64(stats[i].Pages)
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].SiteGroupID != stats[j].SiteGroupID {
+			return stats[i].SiteGroupID < stats[j].SiteGroupID
+		}
+		return stats[i].Crawls > stats[j].Crawls
+	})
+	return stats, nil
+}
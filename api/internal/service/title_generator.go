@@ -15,6 +15,8 @@ import (
 type TitlePool struct {
 	ch            chan string
 	groupID       int
+	ctx           context.Context // 分组级配置变更时取消，只重启该分组的补充协程
+	cancel        context.CancelFunc
 	memoryBytes   atomic.Int64 // 内存占用追踪
 	consumedCount atomic.Int64 // 被消费的数量（Pop 计数）
 }
@@ -90,15 +92,30 @@ func (g *TitleGenerator) getOrCreatePool(groupID int) *TitlePool {
 		return pool
 	}
 
+	pool := g.newPool(groupID)
+	g.pools[groupID] = pool
+	return pool
+}
+
+// newPool 按分组生效配置创建标题池（调用方持有 g.mu）
+func (g *TitleGenerator) newPool(groupID int) *TitlePool {
+	size := g.settings(groupID).PoolSize
+	ctx, cancel := context.WithCancel(g.ctx)
 	pool := &TitlePool{
-		ch:      make(chan string, g.config.TitlePoolSize),
+		ch:      make(chan string, size),
 		groupID: groupID,
+		ctx:     ctx,
+		cancel:  cancel,
 	}
-	g.pools[groupID] = pool
-	log.Debug().Int("group_id", groupID).Int("size", g.config.TitlePoolSize).Msg("Created title pool")
+	log.Debug().Int("group_id", groupID).Int("size", size).Msg("Created title pool")
 	return pool
 }
 
+// settings 分组的生效配置（全局配置叠加分组覆盖）
+func (g *TitleGenerator) settings(groupID int) PoolGroupSettings {
+	return g.poolManager.groupSettingsWith(g.config, "titles", groupID)
+}
+
 // Pop 从标题池获取一个标题
 func (g *TitleGenerator) Pop(groupID int) (string, error) {
 	pool := g.getOrCreatePool(groupID)
@@ -117,9 +134,14 @@ func (g *TitleGenerator) Pop(groupID int) (string, error) {
 	}
 }
 
+// Preview 生成一个标题但不从池中取出（不计入消费）
+func (g *TitleGenerator) Preview(groupID int) string {
+	return g.generateTitle(groupID)
+}
+
 // fillPool 填充标题池
 func (g *TitleGenerator) fillPool(groupID int, pool *TitlePool) {
-	need := g.config.TitlePoolSize - len(pool.ch)
+	need := cap(pool.ch) - len(pool.ch)
 	if need <= 0 {
 		return
 	}
@@ -166,13 +188,15 @@ func (g *TitleGenerator) refillWorker(groupID int, pool *TitlePool) {
 		g.fillPool(groupID, pool)
 	}
 
-	ticker := time.NewTicker(g.config.TitleRefillInterval())
+	ticker := time.NewTicker(g.settings(groupID).RefillInterval())
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-g.ctx.Done():
 			return
+		case <-pool.ctx.Done():
+			return
 		case <-ticker.C:
 			if g.stopped.Load() {
 				return
@@ -182,7 +206,7 @@ func (g *TitleGenerator) refillWorker(groupID int, pool *TitlePool) {
 				continue
 			}
 			// 检查是否需要补充（低于阈值比例时触发）
-			thresholdCount := int(float64(g.config.TitlePoolSize) * g.config.TitleThreshold)
+			thresholdCount := int(float64(cap(pool.ch)) * g.settings(groupID).Threshold)
 			if len(pool.ch) < thresholdCount {
 				g.fillPool(groupID, pool)
 			}
@@ -205,11 +229,59 @@ func (g *TitleGenerator) Start(groupIDs []int) {
 		// Pop 方法有降级逻辑：池空时同步生成一条返回
 
 		// 启动 N 个填充协程
-		for i := 0; i < g.config.TitleWorkers; i++ {
-			g.wg.Add(1)
-			go g.refillWorker(groupID, pool)
+		g.startWorkers(groupID, pool)
+	}
+}
+
+// startWorkers 按分组生效配置启动填充协程
+func (g *TitleGenerator) startWorkers(groupID int, pool *TitlePool) {
+	for i := 0; i < g.settings(groupID).Workers; i++ {
+		g.wg.Add(1)
+		go g.refillWorker(groupID, pool)
+	}
+}
+
+// RestartGroup 按最新的分组配置重建指定分组的标题池和填充协程（其余分组不受影响）
+// 分组尚未创建标题池时不处理，之后创建时自然使用最新配置
+func (g *TitleGenerator) RestartGroup(groupID int) {
+	if g.stopped.Load() {
+		return
+	}
+
+	g.mu.Lock()
+	old, exists := g.pools[groupID]
+	if !exists {
+		g.mu.Unlock()
+		return
+	}
+	pool := g.newPool(groupID)
+	g.pools[groupID] = pool
+	g.mu.Unlock()
+
+	// 旧池中已生成的标题转入新池（超出新容量的丢弃），旧协程随旧池的 context 退出
+	old.cancel()
+	moved := 0
+	var movedMem int64
+drain:
+	for {
+		select {
+		case title := <-old.ch:
+			select {
+			case pool.ch <- title:
+				moved++
+				movedMem += StringMemorySize(title)
+			default:
+				break drain
+			}
+		default:
+			break drain
 		}
 	}
+	pool.memoryBytes.Add(movedMem)
+	pool.consumedCount.Add(old.consumedCount.Load())
+
+	g.startWorkers(groupID, pool)
+	log.Info().Int("group_id", groupID).Int("size", cap(pool.ch)).Int("moved", moved).Msg("TitleGenerator: restarted group")
 }
 
 // Stop 停止标题生成器
@@ -338,10 +410,7 @@ func (g *TitleGenerator) SyncGroups(groupIDs []int) {
 	for _, gid := range toAdd {
 		pool := g.getOrCreatePool(gid)
 		g.fillPool(gid, pool)
-		for i := 0; i < g.config.TitleWorkers; i++ {
-			g.wg.Add(1)
-			go g.refillWorker(gid, pool)
-		}
+		g.startWorkers(gid, pool)
 		log.Info().Int("group_id", gid).Msg("TitleGenerator: added new group")
 	}
 }
@@ -353,10 +422,10 @@ func (g *TitleGenerator) GetStats() map[int]map[string]int {
 
 	stats := make(map[int]map[string]int)
 	for groupID, pool := range g.pools {
-		thresholdCount := int(float64(g.config.TitlePoolSize) * g.config.TitleThreshold)
+		thresholdCount := int(float64(cap(pool.ch)) * g.settings(groupID).Threshold)
 		stats[groupID] = map[string]int{
 			"current":   len(pool.ch),
-			"max_size":  g.config.TitlePoolSize,
+			"max_size":  cap(pool.ch),
 			"threshold": thresholdCount,
 		}
 	}
@@ -370,7 +439,7 @@ func (g *TitleGenerator) GetTotalStats() (current, maxSize int, memoryBytes, con
 
 	for _, pool := range g.pools {
 		current += len(pool.ch)
-		maxSize += g.config.TitlePoolSize
+		maxSize += cap(pool.ch)
 		memoryBytes += pool.memoryBytes.Load()
 		consumedCount += pool.consumedCount.Load()
 	}
@@ -385,7 +454,7 @@ func (g *TitleGenerator) GetGroupStats() []PoolGroupInfo {
 	groups := make([]PoolGroupInfo, 0, len(g.pools))
 	for gid, pool := range g.pools {
 		current := len(pool.ch)
-		maxSize := g.config.TitlePoolSize
+		maxSize := cap(pool.ch)
 		consumed := int(pool.consumedCount.Load())
 		util := 0.0
 		if maxSize > 0 {
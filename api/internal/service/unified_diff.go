@@ -0,0 +1,185 @@
+// Package core provides line-based unified diffs
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffMaxEdits 编辑距离上限，超过时整体按"全部删除 + 全部新增"输出，避免超大差异占用过多内存
+const diffMaxEdits = 2000
+
+// TextDiff 两段文本的行级差异
+type TextDiff struct {
+	Unified string `json:"unified"` // unified diff 格式，无差异时为空
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+}
+
+// diffOp 编辑脚本中的一行：' ' 相同、'-' 删除、'+' 新增
+type diffOp struct {
+	kind byte
+	text string
+	ai   int // 该行之前 a 中已处理的行数
+	bi   int // 该行之前 b 中已处理的行数
+}
+
+// UnifiedDiff 生成 unified diff（Myers 算法），contextLines 为每个变更块前后保留的相同行数
+func UnifiedDiff(from, to, fromLabel, toLabel string, contextLines int) *TextDiff {
+	a, b := diffSplitLines(from), diffSplitLines(to)
+	ops := diffLines(a, b)
+
+	result := &TextDiff{}
+	var changes []int
+	for i, op := range ops {
+		switch op.kind {
+		case '-':
+			result.Removed++
+			changes = append(changes, i)
+		case '+':
+			result.Added++
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return result
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- " + fromLabel + "\n")
+	sb.WriteString("+++ " + toLabel + "\n")
+
+	// 相邻变更间隔不超过 2*contextLines 时合并为一个块
+	for i := 0; i < len(changes); {
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*contextLines+1 {
+			j++
+		}
+		start := max(changes[i]-contextLines, 0)
+		end := min(changes[j]+contextLines+1, len(ops))
+		writeDiffHunk(&sb, ops[start:end])
+		i = j + 1
+	}
+	result.Unified = sb.String()
+	return result
+}
+
+// writeDiffHunk 输出一个变更块
+func writeDiffHunk(sb *strings.Builder, ops []diffOp) {
+	aCount, bCount := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			aCount++
+		}
+		if op.kind != '-' {
+			bCount++
+		}
+	}
+	// unified 格式中行数为 0 时起始行号为变更位置的前一行
+	aStart, bStart := ops[0].ai+1, ops[0].bi+1
+	if aCount == 0 {
+		aStart--
+	}
+	if bCount == 0 {
+		bStart--
+	}
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+	for _, op := range ops {
+		sb.WriteByte(op.kind)
+		sb.WriteString(op.text)
+		if !strings.HasSuffix(op.text, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// diffSplitLines 按行拆分并保留换行符，用于区分末尾是否有换行
+func diffSplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines Myers 差异算法，返回完整编辑脚本
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	maxD := min(n+m, diffMaxEdits)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	// trace[d] 保存第 d 轮开始前 k ∈ [-d-1, d+1] 的 v 值
+	var trace [][]int
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return diffBacktrack(trace, a, b)
+			}
+		}
+	}
+	return diffReplaceAll(a, b)
+}
+
+// diffBacktrack 从 trace 回溯出编辑脚本
+func diffBacktrack(trace [][]int, a, b []string) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		snap := trace[d]
+		at := func(k int) int { return snap[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{kind: ' ', text: a[x-1], ai: x - 1, bi: y - 1})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, diffOp{kind: '+', text: b[y-1], ai: x, bi: y - 1})
+			} else {
+				ops = append(ops, diffOp{kind: '-', text: a[x-1], ai: x - 1, bi: y})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// diffReplaceAll 差异过大时的退化结果
+func diffReplaceAll(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for i, line := range a {
+		ops = append(ops, diffOp{kind: '-', text: line, ai: i})
+	}
+	for i, line := range b {
+		ops = append(ops, diffOp{kind: '+', text: line, ai: len(a), bi: i})
+	}
+	return ops
+}
@@ -0,0 +1,309 @@
+// Package core provides URL generation strategies shared by templates, internal links and sitemaps
+package core
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"math/rand/v2"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"seo-generator/api/internal/model"
+	"seo-generator/api/pkg/config"
+)
+
+// URL 生成策略
+const (
+	URLStrategyRandom  = "random"  // 随机乱码（/?123456789.html、/?20240101/12345.html），模板 random_url 走 URL 池
+	URLStrategyNumeric = "numeric" // 数字 ID：/1234567.html
+	URLStrategyDate    = "date"    // 日期路径：/20240101/1234567.html
+	URLStrategyPinyin  = "pinyin"  // 关键词拼音：/shanghai-seo.html
+	URLStrategyHybrid  = "hybrid"  // 拼音 + ID：/shanghai-seo-1234567.html
+)
+
+// URLStrategyNames 所有可选策略
+var URLStrategyNames = []string{URLStrategyRandom, URLStrategyNumeric, URLStrategyDate, URLStrategyPinyin, URLStrategyHybrid}
+
+// ValidURLStrategy 校验策略名
+func ValidURLStrategy(name string) bool {
+	for _, s := range URLStrategyNames {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// maxSitemapPageSize 单个 sitemap 文件的 URL 上限（sitemaps.org 协议）
+const maxSitemapPageSize = 50000
+
+// SiteGroupURLStrategy 站群 URL 策略
+type SiteGroupURLStrategy struct {
+	SiteGroupID   int       `db:"site_group_id" json:"site_group_id"`
+	Strategy      string    `db:"strategy" json:"strategy"`
+	URLSpace      int       `db:"url_space" json:"url_space"`
+	SlugSeparator string    `db:"slug_separator" json:"slug_separator"`
+	SlugMaxLen    int       `db:"slug_max_len" json:"slug_max_len"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+	IsDefault     bool      `db:"-" json:"is_default"` // 站群未单独配置，使用全局默认
+}
+
+// URLTarget 站内 URL 及其锚文本
+type URLTarget struct {
+	URL    string `json:"url"`
+	Anchor string `json:"anchor"`
+}
+
+// URLStrategyManager URL 策略管理器
+//
+// 每个站点有 url_space 个 URL，第 n 个 URL 由（域名, n）确定性生成，
+// 模板 random_url()、站内链接 internal_link() 和 sitemap 都从同一集合取值，三者始终一致。
+// 第 n 个 URL 的锚文本（拼音策略同时用于 slug）取关键词分组中按域名偏移后的第 n 个关键词，
+// 因此拼音策略的有效 URL 数不超过关键词数。
+type URLStrategyManager struct {
+	db    *sqlx.DB
+	cfg   config.URLStrategyConfig
+	funcs *TemplateFuncsManager
+	seg   *TextSegmenter // 可选，设置后拼音 slug 按词连写
+
+	groups atomic.Pointer[map[int]*SiteGroupURLStrategy]
+}
+
+// NewURLStrategyManager 创建 URL 策略管理器
+func NewURLStrategyManager(db *sqlx.DB, cfg config.URLStrategyConfig, funcs *TemplateFuncsManager, seg *TextSegmenter) *URLStrategyManager {
+	if !ValidURLStrategy(cfg.Default) {
+		cfg.Default = URLStrategyRandom
+	}
+	if cfg.URLSpace <= 0 {
+		cfg.URLSpace = 100000
+	}
+	if cfg.SlugMaxLen <= 0 {
+		cfg.SlugMaxLen = 60
+	}
+	if cfg.DateWindowDays <= 0 {
+		cfg.DateWindowDays = 365
+	}
+	if cfg.SitemapPageSize <= 0 || cfg.SitemapPageSize > maxSitemapPageSize {
+		cfg.SitemapPageSize = 5000
+	}
+	if cfg.SitemapScheme != "https" {
+		cfg.SitemapScheme = "http"
+	}
+	m := &URLStrategyManager{db: db, cfg: cfg, funcs: funcs, seg: seg}
+	empty := map[int]*SiteGroupURLStrategy{}
+	m.groups.Store(&empty)
+	return m
+}
+
+// Reload 从数据库重新加载站群策略
+func (m *URLStrategyManager) Reload(ctx context.Context) error {
+	var rows []*SiteGroupURLStrategy
+	if err := m.db.SelectContext(ctx, &rows, `
+		SELECT site_group_id, strategy, url_space, slug_separator, slug_max_len, updated_at
+		FROM url_strategies`); err != nil {
+		return fmt.Errorf("load url strategies: %w", err)
+	}
+
+	groups := make(map[int]*SiteGroupURLStrategy, len(rows))
+	for _, s := range rows {
+		if !ValidURLStrategy(s.Strategy) {
+			log.Warn().Int("site_group_id", s.SiteGroupID).Str("strategy", s.Strategy).Msg("Unknown url strategy, using default")
+			continue
+		}
+		if s.URLSpace <= 0 {
+			s.URLSpace = m.cfg.URLSpace
+		}
+		if s.SlugMaxLen <= 0 {
+			s.SlugMaxLen = m.cfg.SlugMaxLen
+		}
+		groups[s.SiteGroupID] = s
+	}
+	m.groups.Store(&groups)
+
+	log.Info().Int("site_groups", len(groups)).Str("default", m.cfg.Default).Msg("URL strategies loaded")
+	return nil
+}
+
+// Strategy 返回站群生效的策略（未配置时返回全局默认）
+func (m *URLStrategyManager) Strategy(siteGroupID int) *SiteGroupURLStrategy {
+	if s, ok := (*m.groups.Load())[siteGroupID]; ok {
+		return s
+	}
+	return &SiteGroupURLStrategy{
+		SiteGroupID:   siteGroupID,
+		Strategy:      m.cfg.Default,
+		URLSpace:      m.cfg.URLSpace,
+		SlugSeparator: m.cfg.SlugSeparator,
+		SlugMaxLen:    m.cfg.SlugMaxLen,
+		IsDefault:     true,
+	}
+}
+
+// SitemapPageSize 每个 sitemap 文件的 URL 数
+func (m *URLStrategyManager) SitemapPageSize() int {
+	return m.cfg.SitemapPageSize
+}
+
+// BaseURL 站点绝对地址前缀（sitemap 使用）
+func (m *URLStrategyManager) BaseURL(domain string) string {
+	return m.cfg.SitemapScheme + "://" + domain
+}
+
+// Size 站点的 URL 数（拼音策略受关键词数限制）
+func (m *URLStrategyManager) Size(site *models.Site, keywordGroupID int) int {
+	s := m.Strategy(site.SiteGroupID)
+	if s.Strategy == URLStrategyPinyin {
+		if _, count := m.funcs.KeywordAt(keywordGroupID, 0); count > 0 && count < s.URLSpace {
+			return count
+		}
+	}
+	return s.URLSpace
+}
+
+// At 生成站点第 n 个 URL 及其锚文本
+func (m *URLStrategyManager) At(site *models.Site, keywordGroupID int, n uint64) URLTarget {
+	return m.at(site, m.Strategy(site.SiteGroupID), keywordGroupID, domainHash(site.Domain), n)
+}
+
+func (m *URLStrategyManager) at(site *models.Site, s *SiteGroupURLStrategy, keywordGroupID int, dh uint64, n uint64) URLTarget {
+	keyword, _ := m.funcs.KeywordAt(keywordGroupID, n+dh)
+	seed := splitmix64(dh ^ n)
+	id := strconv.FormatUint(dh%9000*1000+100000+n, 10)
+
+	var url string
+	switch s.Strategy {
+	case URLStrategyNumeric:
+		url = "/" + id + ".html"
+	case URLStrategyDate:
+		url = "/" + m.dateOf(site, s, seed) + "/" + id + ".html"
+	case URLStrategyPinyin:
+		if slug := m.slug(keyword, s); slug != "" {
+			url = "/" + slug + ".html"
+		} else {
+			url = "/" + id + ".html"
+		}
+	case URLStrategyHybrid:
+		if slug := m.slug(keyword, s); slug != "" {
+			url = "/" + slug + s.SlugSeparator + id + ".html"
+		} else {
+			url = "/" + id + ".html"
+		}
+	default:
+		// 与 generateRandomURL 相同的两种格式，按种子确定
+		if seed%10 < 6 {
+			url = "/?" + strconv.FormatUint(seed%900000000+100000000, 10) + ".html"
+		} else {
+			day := m.epoch(site, s).AddDate(0, 0, -int(seed>>32%30))
+			url = "/?" + day.Format("20060102") + "/" + strconv.FormatUint(seed>>8%90000+10000, 10) + ".html"
+		}
+	}
+	return URLTarget{URL: url, Anchor: keyword}
+}
+
+// slug 关键词拼音 slug：有分词器时按词连写（shanghai-seo-youhua），否则逐音节分隔
+func (m *URLStrategyManager) slug(keyword string, s *SiteGroupURLStrategy) string {
+	if m.seg != nil {
+		return PinyinSlugWords(m.seg.Cut(keyword), s.SlugSeparator, s.SlugMaxLen)
+	}
+	return PinyinSlug(keyword, s.SlugSeparator, s.SlugMaxLen)
+}
+
+// Range 按序号生成 [offset, offset+limit) 范围内的 URL（sitemap 分页使用），不超过站点 URL 数
+func (m *URLStrategyManager) Range(site *models.Site, keywordGroupID int, offset, limit int) []URLTarget {
+	size := m.Size(site, keywordGroupID)
+	if offset >= size || limit <= 0 {
+		return nil
+	}
+	end := min(offset+limit, size)
+	s := m.Strategy(site.SiteGroupID)
+	dh := domainHash(site.Domain)
+	targets := make([]URLTarget, 0, end-offset)
+	for n := offset; n < end; n++ {
+		targets = append(targets, m.at(site, s, keywordGroupID, dh, uint64(n)))
+	}
+	return targets
+}
+
+// Links 随机取 count 个站内链接
+func (m *URLStrategyManager) Links(site *models.Site, keywordGroupID int, count int) []URLTarget {
+	size := uint64(m.Size(site, keywordGroupID))
+	s := m.Strategy(site.SiteGroupID)
+	dh := domainHash(site.Domain)
+	links := make([]URLTarget, 0, count)
+	for i := 0; i < count; i++ {
+		links = append(links, m.at(site, s, keywordGroupID, dh, rand.Uint64N(size)))
+	}
+	return links
+}
+
+// URLFunc 返回页面渲染用的 random_url 生成器
+// random 策略返回 nil，模板继续使用 URL 池（不需要与 sitemap 逐条对应）
+func (m *URLStrategyManager) URLFunc(site *models.Site, keywordGroupID int) func() string {
+	s := m.Strategy(site.SiteGroupID)
+	if s.Strategy == URLStrategyRandom {
+		return nil
+	}
+	size := uint64(m.Size(site, keywordGroupID))
+	dh := domainHash(site.Domain)
+	return func() string {
+		return m.at(site, s, keywordGroupID, dh, rand.Uint64N(size)).URL
+	}
+}
+
+// LinkFunc 返回页面渲染用的 internal_link 生成器，输出 <a href="URL">锚文本</a>
+func (m *URLStrategyManager) LinkFunc(site *models.Site, keywordGroupID int) func() string {
+	s := m.Strategy(site.SiteGroupID)
+	size := uint64(m.Size(site, keywordGroupID))
+	dh := domainHash(site.Domain)
+	return func() string {
+		t := m.at(site, s, keywordGroupID, dh, rand.Uint64N(size))
+		return internalLinkHTML(t.URL, t.Anchor)
+	}
+}
+
+// epoch 日期路径的基准日：策略生效日与站点创建日中较晚者，保证同一 URL 在策略不变时长期稳定
+func (m *URLStrategyManager) epoch(site *models.Site, s *SiteGroupURLStrategy) time.Time {
+	epoch := site.CreatedAt
+	if s.UpdatedAt.After(epoch) {
+		epoch = s.UpdatedAt
+	}
+	if epoch.IsZero() {
+		epoch = time.Now()
+	}
+	return time.Date(epoch.Year(), epoch.Month(), epoch.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// dateOf 日期路径：基准日之前 date_window_days 天内按种子分布
+func (m *URLStrategyManager) dateOf(site *models.Site, s *SiteGroupURLStrategy, seed uint64) string {
+	days := int(seed >> 16 % uint64(m.cfg.DateWindowDays))
+	return m.epoch(site, s).AddDate(0, 0, -days).Format("20060102")
+}
+
+// internalLinkHTML 生成站内链接 HTML
+func internalLinkHTML(url, anchor string) string {
+	if anchor == "" {
+		anchor = url
+	}
+	return `<a href="` + html.EscapeString(url) + `">` + html.EscapeString(anchor) + `</a>`
+}
+
+// domainHash 域名哈希，用于不同站点错开 ID 和关键词顺序
+func domainHash(domain string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(domain))
+	return h.Sum64()
+}
+
+// splitmix64 整数混淆，使相邻序号得到分散的种子
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
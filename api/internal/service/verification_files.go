@@ -0,0 +1,170 @@
+// Package core provides per-site search engine verification files served at the domain root
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxVerificationFileSize 验证文件内容最大字节数
+const MaxVerificationFileSize = 64 << 10
+
+// verificationFilenamePattern 验证文件名：只允许根目录下的普通文件名，如 baidu_verify_codeva-xxx.html、google1234.html
+var verificationFilenamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,254}$`)
+
+// ErrVerificationFileNotFound 验证文件不存在
+var ErrVerificationFileNotFound = errors.New("verification file not found")
+
+// VerificationFile 站点验证文件
+type VerificationFile struct {
+	ID          int64     `db:"id" json:"id"`
+	SiteID      int       `db:"site_id" json:"site_id"`
+	Filename    string    `db:"filename" json:"filename"`
+	Content     string    `db:"content" json:"content"`
+	ContentType string    `db:"content_type" json:"content_type"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ValidVerificationFilename 校验验证文件名
+func ValidVerificationFilename(name string) bool {
+	return verificationFilenamePattern.MatchString(name) && !strings.Contains(name, "..")
+}
+
+// VerificationContentType 按扩展名推断 Content-Type
+func VerificationContentType(filename string) string {
+	switch {
+	case strings.HasSuffix(strings.ToLower(filename), ".html"), strings.HasSuffix(strings.ToLower(filename), ".htm"):
+		return "text/html; charset=utf-8"
+	case strings.HasSuffix(strings.ToLower(filename), ".xml"):
+		return "application/xml; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// VerificationFiles 站点验证文件
+// 搜索引擎站长平台要求在域名根目录放置指定文件（baidu_verify_*.html、google*.html 等）；
+// 文件按站点存储在 site_verification_files 表，启动和修改后整体加载到内存，
+// ServePage 在防护和模板渲染之前按（域名, /文件名）精确匹配并直接返回
+type VerificationFiles struct {
+	db         *sqlx.DB
+	cache      HTMLCache
+	strategies *SpiderStrategyResolver
+
+	files atomic.Pointer[map[string]*VerificationFile]
+}
+
+// NewVerificationFiles 创建站点验证文件
+func NewVerificationFiles(db *sqlx.DB, cache HTMLCache, strategies *SpiderStrategyResolver) *VerificationFiles {
+	v := &VerificationFiles{db: db, cache: cache, strategies: strategies}
+	empty := map[string]*VerificationFile{}
+	v.files.Store(&empty)
+	return v
+}
+
+// Reload 从数据库重新加载所有验证文件
+func (v *VerificationFiles) Reload(ctx context.Context) error {
+	var rows []struct {
+		VerificationFile
+		Domain string `db:"domain"`
+	}
+	if err := v.db.SelectContext(ctx, &rows, `
+		SELECT f.id, f.site_id, f.filename, f.content, f.content_type, f.created_at, f.updated_at, s.domain
+		FROM site_verification_files f JOIN sites s ON s.id = f.site_id`); err != nil {
+		return fmt.Errorf("load verification files: %w", err)
+	}
+	files := make(map[string]*VerificationFile, len(rows))
+	for i := range rows {
+		f := rows[i].VerificationFile
+		files[strings.ToLower(rows[i].Domain)+"\x00/"+f.Filename] = &f
+	}
+	v.files.Store(&files)
+	log.Info().Int("files", len(files)).Msg("Verification files loaded")
+	return nil
+}
+
+// Get 查找域名根目录下的验证文件，path 形如 /google1234.html，未找到时返回 nil
+func (v *VerificationFiles) Get(domain, path string) *VerificationFile {
+	if v == nil || strings.LastIndexByte(path, '/') != 0 {
+		return nil
+	}
+	files := *v.files.Load()
+	if len(files) == 0 {
+		return nil
+	}
+	return files[strings.ToLower(domain)+"\x00"+path]
+}
+
+// List 站点的验证文件
+func (v *VerificationFiles) List(ctx context.Context, siteID int) ([]VerificationFile, error) {
+	files := []VerificationFile{}
+	err := v.db.SelectContext(ctx, &files, `
+		SELECT id, site_id, filename, content, content_type, created_at, updated_at
+		FROM site_verification_files WHERE site_id = ? ORDER BY filename`, siteID)
+	return files, err
+}
+
+// Save 创建或覆盖站点的同名验证文件
+func (v *VerificationFiles) Save(ctx context.Context, siteID int, filename, content string) (*VerificationFile, error) {
+	contentType := VerificationContentType(filename)
+	if _, err := v.db.ExecContext(ctx, `
+		INSERT INTO site_verification_files (site_id, filename, content, content_type) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE content = VALUES(content), content_type = VALUES(content_type)`,
+		siteID, filename, content, contentType); err != nil {
+		return nil, err
+	}
+	var f VerificationFile
+	if err := v.db.GetContext(ctx, &f, `
+		SELECT id, site_id, filename, content, content_type, created_at, updated_at
+		FROM site_verification_files WHERE site_id = ? AND filename = ?`, siteID, filename); err != nil {
+		return nil, err
+	}
+	v.changed(ctx, siteID, filename)
+	return &f, nil
+}
+
+// Delete 删除站点的验证文件
+func (v *VerificationFiles) Delete(ctx context.Context, siteID int, id int64) error {
+	var filename string
+	err := v.db.GetContext(ctx, &filename, "SELECT filename FROM site_verification_files WHERE id = ? AND site_id = ?", id, siteID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrVerificationFileNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := v.db.ExecContext(ctx, "DELETE FROM site_verification_files WHERE id = ?", id); err != nil {
+		return err
+	}
+	v.changed(ctx, siteID, filename)
+	return nil
+}
+
+// changed 重新加载，并清除该路径此前渲染的缓存页面（避免 Nginx 直接返回旧页面）
+func (v *VerificationFiles) changed(ctx context.Context, siteID int, filename string) {
+	if err := v.Reload(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload verification files")
+	}
+	var site struct {
+		Domain      string `db:"domain"`
+		SiteGroupID int    `db:"site_group_id"`
+	}
+	if err := v.db.GetContext(ctx, &site, "SELECT domain, site_group_id FROM sites WHERE id = ?", siteID); err != nil {
+		return
+	}
+	for _, cachePath := range v.strategies.CachePaths(site.SiteGroupID, "/"+filename) {
+		if err := v.cache.Delete(site.Domain, cachePath); err != nil {
+			log.Warn().Err(err).Str("domain", site.Domain).Str("path", cachePath).Msg("Failed to invalidate verification file cache")
+		}
+	}
+}
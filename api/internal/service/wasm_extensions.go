@@ -0,0 +1,549 @@
+// Package core provides WASM render extensions configured per site group
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"seo-generator/api/pkg/config"
+)
+
+// WASM 扩展导出函数（guest ABI）
+//
+// 模块需导出 memory 和 alloc(size i32) -> i32，可选导出 dealloc(ptr i32, size i32)；
+// 以下钩子函数签名均为 (ptr i32, len i32) -> i64，输入为 UTF-8 字节，
+// 返回值高 32 位为结果指针、低 32 位为结果长度（长度为 0 表示不修改）：
+//   - transform_title:   输入标题，返回新标题
+//   - transform_content: 输入正文，返回新正文
+//   - template_func:     输入 JSON {"name": "...", "arg": "..."}，返回插入页面的 HTML
+//
+// 模板中通过 {{ plugin('name', 'arg') }} 调用 template_func。
+// 模块以 WASI 运行（无文件系统和网络），每次调用受超时和内存上限限制，实例按模块复用
+const (
+	WASMExportTitle   = "transform_title"
+	WASMExportContent = "transform_content"
+	WASMExportFunc    = "template_func"
+)
+
+// ErrWASMModuleNotLoaded 模块未加载
+var ErrWASMModuleNotLoaded = errors.New("wasm module not loaded")
+
+func init() {
+	// plugin('name', 'arg')：调用站群 WASM 扩展的 template_func（配置 wasm_extensions.enabled 时启用）
+	RegisterTemplateFuncPack(TemplateFuncPack{
+		Name: "wasm",
+		Funcs: []TemplateFunc{
+			{Name: "plugin", Arity: 2, Cost: 5, Impl: func(_ *TemplateFuncsManager, data *RenderData, args []string) string {
+				if data == nil || data.PluginFunc == nil {
+					return ""
+				}
+				return data.PluginFunc(args[0], args[1])
+			}},
+		},
+	})
+}
+
+// WASMBinding 站群的 WASM 扩展配置
+type WASMBinding struct {
+	SiteGroupID      int       `db:"site_group_id" json:"site_group_id"`
+	Module           string    `db:"module" json:"module"` // 模块目录下的文件名，如 brand.wasm
+	TransformTitle   bool      `db:"transform_title" json:"transform_title"`
+	TransformContent bool      `db:"transform_content" json:"transform_content"`
+	TemplateFunc     bool      `db:"template_func" json:"template_func"`
+	Enabled          bool      `db:"enabled" json:"enabled"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// WASMModuleStatus 模块加载状态和调用统计
+type WASMModuleStatus struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+	LoadedAt time.Time `json:"loaded_at,omitempty"`
+	Exports  []string  `json:"exports"`
+	Error    string    `json:"error,omitempty"`
+	Calls    int64     `json:"calls"`
+	Errors   int64     `json:"errors"`
+	Timeouts int64     `json:"timeouts"`
+	AvgMs    float64   `json:"avg_ms"`
+}
+
+// wasmModule 已编译的模块和空闲实例
+type wasmModule struct {
+	name     string
+	size     int64
+	modTime  time.Time
+	loadedAt time.Time
+	compiled wazero.CompiledModule
+	exports  map[string]bool
+
+	mu     sync.Mutex
+	idle   []api.Module
+	closed bool
+
+	calls      atomic.Int64
+	errors     atomic.Int64
+	timeouts   atomic.Int64
+	totalNanos atomic.Int64
+}
+
+// WASMExtensions WASM 渲染扩展
+// 模块文件放在配置目录下，按修改时间热重载；站群绑定存储在 wasm_extensions 表。
+// 扩展调用失败（超时、陷阱、内存超限）时记录警告并使用原值，不影响页面渲染
+type WASMExtensions struct {
+	db      *sqlx.DB
+	cfg     config.WASMExtensionsConfig
+	runtime wazero.Runtime
+
+	reloadMu   sync.Mutex
+	modules    atomic.Pointer[map[string]*wasmModule]
+	bindings   atomic.Pointer[map[int]*WASMBinding]
+	loadErrors atomic.Pointer[map[string]string]
+}
+
+// NewWASMExtensions 创建 WASM 扩展运行时
+func NewWASMExtensions(ctx context.Context, db *sqlx.DB, cfg config.WASMExtensionsConfig) (*WASMExtensions, error) {
+	rtCfg := wazero.NewRuntimeConfig().WithCloseOnContextDone(true)
+	if cfg.MemoryLimitMB > 0 {
+		rtCfg = rtCfg.WithMemoryLimitPages(uint32(cfg.MemoryLimitMB) * 16) // 64KB/页
+	}
+	rt := wazero.NewRuntimeWithConfig(ctx, rtCfg)
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("instantiate wasi: %w", err)
+	}
+	e := &WASMExtensions{db: db, cfg: cfg, runtime: rt}
+	modules := map[string]*wasmModule{}
+	e.modules.Store(&modules)
+	bindings := map[int]*WASMBinding{}
+	e.bindings.Store(&bindings)
+	loadErrors := map[string]string{}
+	e.loadErrors.Store(&loadErrors)
+	return e, nil
+}
+
+// Start 加载模块和绑定，按间隔检查模块文件变化，ctx 结束时关闭运行时
+func (e *WASMExtensions) Start(ctx context.Context) {
+	if err := e.ReloadBindings(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to load wasm extension bindings")
+	}
+	e.ReloadModules(ctx)
+
+	interval := time.Duration(e.cfg.ReloadSeconds) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			e.runtime.Close(context.Background())
+			return
+		case <-ticker.C:
+			e.ReloadModules(ctx)
+		}
+	}
+}
+
+// ReloadModules 扫描模块目录，编译新增和变化的模块，移除已删除的模块
+func (e *WASMExtensions) ReloadModules(ctx context.Context) {
+	e.reloadMu.Lock()
+	defer e.reloadMu.Unlock()
+
+	old := *e.modules.Load()
+	modules := make(map[string]*wasmModule, len(old))
+	loadErrors := map[string]string{}
+
+	entries, err := os.ReadDir(e.cfg.Dir)
+	if err != nil && !os.IsNotExist(err) {
+		log.Warn().Err(err).Str("dir", e.cfg.Dir).Msg("Failed to read wasm module dir")
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".wasm") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		name := entry.Name()
+		if m, ok := old[name]; ok && m.size == info.Size() && m.modTime.Equal(info.ModTime()) {
+			modules[name] = m
+			continue
+		}
+		m, err := e.compile(ctx, name, info)
+		if err != nil {
+			loadErrors[name] = err.Error()
+			log.Warn().Err(err).Str("module", name).Msg("Failed to load wasm module")
+			// 新版本加载失败时继续使用旧版本
+			if prev, ok := old[name]; ok {
+				modules[name] = prev
+			}
+			continue
+		}
+		modules[name] = m
+		log.Info().Str("module", name).Strs("exports", m.exportList()).Msg("WASM module loaded")
+	}
+
+	e.modules.Store(&modules)
+	e.loadErrors.Store(&loadErrors)
+	for name, m := range old {
+		if modules[name] != m {
+			m.close(ctx)
+		}
+	}
+}
+
+// compile 编译模块并校验导出
+func (e *WASMExtensions) compile(ctx context.Context, name string, info os.FileInfo) (*wasmModule, error) {
+	code, err := os.ReadFile(filepath.Join(e.cfg.Dir, name))
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := e.runtime.CompileModule(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("compile: %w", err)
+	}
+	exported := compiled.ExportedFunctions()
+	if _, ok := exported["alloc"]; !ok {
+		compiled.Close(ctx)
+		return nil, errors.New("module must export alloc(size i32) -> i32")
+	}
+	if _, ok := compiled.ExportedMemories()["memory"]; !ok {
+		compiled.Close(ctx)
+		return nil, errors.New("module must export memory")
+	}
+	m := &wasmModule{
+		name:     name,
+		size:     info.Size(),
+		modTime:  info.ModTime(),
+		loadedAt: time.Now(),
+		compiled: compiled,
+		exports:  map[string]bool{},
+	}
+	for _, export := range []string{WASMExportTitle, WASMExportContent, WASMExportFunc} {
+		if def, ok := exported[export]; ok {
+			if len(def.ParamTypes()) != 2 || len(def.ResultTypes()) != 1 || def.ResultTypes()[0] != api.ValueTypeI64 {
+				compiled.Close(ctx)
+				return nil, fmt.Errorf("export %s must have signature (i32, i32) -> i64", export)
+			}
+			m.exports[export] = true
+		}
+	}
+	if len(m.exports) == 0 {
+		compiled.Close(ctx)
+		return nil, errors.New("module exports no hooks (transform_title, transform_content, template_func)")
+	}
+	return m, nil
+}
+
+// ReloadBindings 从数据库重新加载站群绑定
+func (e *WASMExtensions) ReloadBindings(ctx context.Context) error {
+	var rows []WASMBinding
+	if err := e.db.SelectContext(ctx, &rows, `
+		SELECT site_group_id, module, transform_title, transform_content, template_func, enabled, updated_at
+		FROM wasm_extensions WHERE enabled = 1`); err != nil {
+		return err
+	}
+	bindings := make(map[int]*WASMBinding, len(rows))
+	for i := range rows {
+		bindings[rows[i].SiteGroupID] = &rows[i]
+	}
+	e.bindings.Store(&bindings)
+	return nil
+}
+
+// module 站群启用了指定钩子时返回对应模块
+func (e *WASMExtensions) module(siteGroupID int, export string) *wasmModule {
+	if e == nil {
+		return nil
+	}
+	b, ok := (*e.bindings.Load())[siteGroupID]
+	if !ok {
+		return nil
+	}
+	switch export {
+	case WASMExportTitle:
+		ok = b.TransformTitle
+	case WASMExportContent:
+		ok = b.TransformContent
+	case WASMExportFunc:
+		ok = b.TemplateFunc
+	}
+	if !ok {
+		return nil
+	}
+	m := (*e.modules.Load())[b.Module]
+	if m == nil || !m.exports[export] {
+		return nil
+	}
+	return m
+}
+
+// TransformTitle 按站群扩展转换标题，未配置或调用失败时返回原标题
+func (e *WASMExtensions) TransformTitle(ctx context.Context, siteGroupID int, title string) string {
+	return e.transform(ctx, siteGroupID, WASMExportTitle, title)
+}
+
+// TransformContent 按站群扩展转换正文，未配置或调用失败时返回原正文
+func (e *WASMExtensions) TransformContent(ctx context.Context, siteGroupID int, content string) string {
+	return e.transform(ctx, siteGroupID, WASMExportContent, content)
+}
+
+func (e *WASMExtensions) transform(ctx context.Context, siteGroupID int, export, input string) string {
+	m := e.module(siteGroupID, export)
+	if m == nil {
+		return input
+	}
+	out, err := e.call(ctx, m, export, []byte(input))
+	if err != nil {
+		log.Warn().Err(err).Str("module", m.name).Str("export", export).Int("site_group_id", siteGroupID).
+			Msg("WASM extension call failed, using original value")
+		return input
+	}
+	if out == nil {
+		return input
+	}
+	return string(out)
+}
+
+// FuncFor 站群的 plugin() 模板函数实现，未配置时返回 nil
+func (e *WASMExtensions) FuncFor(ctx context.Context, siteGroupID int) func(name, arg string) string {
+	m := e.module(siteGroupID, WASMExportFunc)
+	if m == nil {
+		return nil
+	}
+	return func(name, arg string) string {
+		input, _ := json.Marshal(map[string]string{"name": name, "arg": arg})
+		out, err := e.call(ctx, m, WASMExportFunc, input)
+		if err != nil {
+			log.Warn().Err(err).Str("module", m.name).Str("func", name).Int("site_group_id", siteGroupID).
+				Msg("WASM template function failed")
+			return ""
+		}
+		return string(out)
+	}
+}
+
+// call 在模块实例中调用导出函数，返回 nil 表示不修改
+func (e *WASMExtensions) call(ctx context.Context, m *wasmModule, export string, input []byte) (out []byte, err error) {
+	start := time.Now()
+	m.calls.Add(1)
+	defer func() {
+		m.totalNanos.Add(int64(time.Since(start)))
+		if err != nil {
+			m.errors.Add(1)
+		}
+	}()
+
+	timeout := time.Duration(e.cfg.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = 100 * time.Millisecond
+	}
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	inst, err := m.acquire(callCtx, e.runtime)
+	if err != nil {
+		return nil, err
+	}
+	// 出错的实例可能已被关闭或状态损坏，不再复用
+	healthy := false
+	defer func() {
+		if healthy {
+			m.release(ctx, inst, e.cfg.MaxInstances)
+		} else {
+			inst.Close(context.Background())
+		}
+	}()
+
+	mem := inst.Memory()
+	inPtr, err := wasmAlloc(callCtx, inst, uint32(len(input)))
+	if err != nil {
+		return nil, e.callError(m, callCtx, err)
+	}
+	if len(input) > 0 && !mem.Write(inPtr, input) {
+		return nil, errors.New("write input out of range")
+	}
+	results, err := inst.ExportedFunction(export).Call(callCtx, uint64(inPtr), uint64(len(input)))
+	if err != nil {
+		return nil, e.callError(m, callCtx, err)
+	}
+	wasmDealloc(callCtx, inst, inPtr, uint32(len(input)))
+
+	outPtr, outLen := uint32(results[0]>>32), uint32(results[0])
+	if outLen == 0 {
+		healthy = true
+		return nil, nil
+	}
+	if e.cfg.MaxOutputBytes > 0 && int(outLen) > e.cfg.MaxOutputBytes {
+		return nil, fmt.Errorf("output %d bytes exceeds max_output_bytes %d", outLen, e.cfg.MaxOutputBytes)
+	}
+	data, ok := mem.Read(outPtr, outLen)
+	if !ok {
+		return nil, errors.New("read output out of range")
+	}
+	out = append([]byte(nil), data...)
+	wasmDealloc(callCtx, inst, outPtr, outLen)
+	healthy = true
+	return out, nil
+}
+
+// callError 区分超时
+func (e *WASMExtensions) callError(m *wasmModule, ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		m.timeouts.Add(1)
+		return fmt.Errorf("timeout after %dms: %w", e.cfg.TimeoutMs, err)
+	}
+	return err
+}
+
+func wasmAlloc(ctx context.Context, inst api.Module, size uint32) (uint32, error) {
+	results, err := inst.ExportedFunction("alloc").Call(ctx, uint64(size))
+	if err != nil {
+		return 0, fmt.Errorf("alloc: %w", err)
+	}
+	return uint32(results[0]), nil
+}
+
+func wasmDealloc(ctx context.Context, inst api.Module, ptr, size uint32) {
+	if fn := inst.ExportedFunction("dealloc"); fn != nil {
+		_, _ = fn.Call(ctx, uint64(ptr), uint64(size))
+	}
+}
+
+// acquire 取空闲实例，没有时新建
+func (m *wasmModule) acquire(ctx context.Context, rt wazero.Runtime) (api.Module, error) {
+	m.mu.Lock()
+	if n := len(m.idle); n > 0 {
+		inst := m.idle[n-1]
+		m.idle = m.idle[:n-1]
+		m.mu.Unlock()
+		return inst, nil
+	}
+	m.mu.Unlock()
+	// 匿名实例，允许同一模块多个实例并存；reactor 模块（TinyGo、Rust、Go c-shared）需要执行 _initialize
+	inst, err := rt.InstantiateModule(ctx, m.compiled, wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, fmt.Errorf("instantiate %s: %w", m.name, err)
+	}
+	return inst, nil
+}
+
+// release 归还实例，空闲实例超过上限或模块已卸载时关闭
+func (m *wasmModule) release(ctx context.Context, inst api.Module, maxIdle int) {
+	if maxIdle <= 0 {
+		maxIdle = 8
+	}
+	m.mu.Lock()
+	if !m.closed && len(m.idle) < maxIdle {
+		m.idle = append(m.idle, inst)
+		m.mu.Unlock()
+		return
+	}
+	m.mu.Unlock()
+	inst.Close(ctx)
+}
+
+// close 卸载模块（进行中的调用不受影响，结束后实例被关闭）
+func (m *wasmModule) close(ctx context.Context) {
+	m.mu.Lock()
+	idle := m.idle
+	m.idle, m.closed = nil, true
+	m.mu.Unlock()
+	for _, inst := range idle {
+		inst.Close(ctx)
+	}
+	m.compiled.Close(ctx)
+}
+
+func (m *wasmModule) exportList() []string {
+	exports := make([]string, 0, len(m.exports))
+	for export := range m.exports {
+		exports = append(exports, export)
+	}
+	sort.Strings(exports)
+	return exports
+}
+
+// Modules 模块加载状态（含加载失败的模块）
+func (e *WASMExtensions) Modules() []WASMModuleStatus {
+	modules := *e.modules.Load()
+	loadErrors := *e.loadErrors.Load()
+	list := make([]WASMModuleStatus, 0, len(modules)+len(loadErrors))
+	for name, m := range modules {
+		s := WASMModuleStatus{
+			Name:     name,
+			Size:     m.size,
+			ModTime:  m.modTime,
+			LoadedAt: m.loadedAt,
+			Exports:  m.exportList(),
+			Error:    loadErrors[name],
+			Calls:    m.calls.Load(),
+			Errors:   m.errors.Load(),
+			Timeouts: m.timeouts.Load(),
+		}
+		if s.Calls > 0 {
+			s.AvgMs = float64(m.totalNanos.Load()) / float64(s.Calls) / 1e6
+		}
+		list = append(list, s)
+	}
+	for name, msg := range loadErrors {
+		if _, ok := modules[name]; !ok {
+			list = append(list, WASMModuleStatus{Name: name, Exports: []string{}, Error: msg})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// ListBindings 所有站群绑定（含停用的）
+func (e *WASMExtensions) ListBindings(ctx context.Context) ([]WASMBinding, error) {
+	bindings := []WASMBinding{}
+	err := e.db.SelectContext(ctx, &bindings, `
+		SELECT site_group_id, module, transform_title, transform_content, template_func, enabled, updated_at
+		FROM wasm_extensions ORDER BY site_group_id`)
+	return bindings, err
+}
+
+// SaveBinding 创建或更新站群绑定
+func (e *WASMExtensions) SaveBinding(ctx context.Context, b *WASMBinding) error {
+	if _, err := e.db.ExecContext(ctx, `
+		INSERT INTO wasm_extensions (site_group_id, module, transform_title, transform_content, template_func, enabled)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE module = VALUES(module), transform_title = VALUES(transform_title),
+			transform_content = VALUES(transform_content), template_func = VALUES(template_func), enabled = VALUES(enabled)`,
+		b.SiteGroupID, b.Module, b.TransformTitle, b.TransformContent, b.TemplateFunc, b.Enabled); err != nil {
+		return err
+	}
+	return e.ReloadBindings(ctx)
+}
+
+// DeleteBinding 删除站群绑定
+func (e *WASMExtensions) DeleteBinding(ctx context.Context, siteGroupID int) error {
+	if _, err := e.db.ExecContext(ctx, "DELETE FROM wasm_extensions WHERE site_group_id = ?", siteGroupID); err != nil {
+		return err
+	}
+	return e.ReloadBindings(ctx)
+}
+
+// ValidWASMModuleName 模块文件名（模块目录下的 .wasm 文件，不含路径）
+func ValidWASMModuleName(name string) bool {
+	return strings.HasSuffix(name, ".wasm") && filepath.Base(name) == name && !strings.HasPrefix(name, ".")
+}
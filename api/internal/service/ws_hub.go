@@ -0,0 +1,210 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"seo-generator/api/pkg/config"
+)
+
+// wsTicketKeyPrefix Redis 中连接票据的键前缀
+const wsTicketKeyPrefix = "ws:ticket:"
+
+var (
+	ErrWSTicketInvalid = errors.New("websocket ticket invalid or expired")
+	ErrWSGlobalLimit   = errors.New("websocket connection limit reached")
+	ErrWSUserLimit     = errors.New("websocket per-user connection limit reached")
+)
+
+// WSTicket 实时推送连接票据
+// 由已认证的 REST 接口签发，一次性使用，有效期很短（浏览器 WebSocket 无法携带 Authorization 请求头）
+type WSTicket struct {
+	Ticket    string    `json:"ticket"`
+	AdminID   int       `json:"admin_id"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// WSHub 实时推送连接中心（WebSocket 和 SSE）
+//
+// 签发和校验连接票据，限制全局和单用户的同时连接数，统计各频道订阅数。
+// 有 Redis 时票据存 Redis（多实例间通用），否则存内存
+type WSHub struct {
+	rdb    *redis.Client
+	config config.WebSocketConfig
+
+	mu       sync.Mutex
+	tickets  map[string]WSTicket // 无 Redis 时使用
+	total    int
+	perUser  map[string]int
+	channels map[string]int
+
+	issued   int64
+	accepted int64
+	rejected map[string]int64 // 拒绝原因 -> 次数
+}
+
+// NewWSHub 创建连接中心，rdb 为 nil 时票据保存在本实例内存
+func NewWSHub(rdb *redis.Client, cfg config.WebSocketConfig) *WSHub {
+	if cfg.TicketTTLSeconds <= 0 {
+		cfg.TicketTTLSeconds = 30
+	}
+	return &WSHub{
+		rdb:      rdb,
+		config:   cfg,
+		tickets:  make(map[string]WSTicket),
+		perUser:  make(map[string]int),
+		channels: make(map[string]int),
+		rejected: make(map[string]int64),
+	}
+}
+
+// RequireTicket 连接是否必须携带票据
+func (h *WSHub) RequireTicket() bool {
+	return h.config.RequireTicket
+}
+
+// IssueTicket 为已认证的管理员签发连接票据
+func (h *WSHub) IssueTicket(ctx context.Context, adminID int, username string) (*WSTicket, error) {
+	token, err := NewSessionID()
+	if err != nil {
+		return nil, err
+	}
+	ttl := time.Duration(h.config.TicketTTLSeconds) * time.Second
+	ticket := WSTicket{Ticket: token, AdminID: adminID, Username: username, ExpiresAt: time.Now().Add(ttl)}
+
+	if h.rdb != nil {
+		payload, _ := json.Marshal(ticket)
+		if err := h.rdb.Set(ctx, wsTicketKeyPrefix+token, payload, ttl).Err(); err != nil {
+			return nil, err
+		}
+	} else {
+		h.mu.Lock()
+		now := time.Now()
+		for k, t := range h.tickets {
+			if now.After(t.ExpiresAt) {
+				delete(h.tickets, k)
+			}
+		}
+		h.tickets[token] = ticket
+		h.mu.Unlock()
+	}
+
+	h.mu.Lock()
+	h.issued++
+	h.mu.Unlock()
+	return &ticket, nil
+}
+
+// RedeemTicket 校验并作废票据
+func (h *WSHub) RedeemTicket(ctx context.Context, token string) (*WSTicket, error) {
+	if token == "" {
+		return nil, ErrWSTicketInvalid
+	}
+	if h.rdb != nil {
+		payload, err := h.rdb.GetDel(ctx, wsTicketKeyPrefix+token).Bytes()
+		if err == redis.Nil {
+			return nil, ErrWSTicketInvalid
+		}
+		if err != nil {
+			return nil, err
+		}
+		var ticket WSTicket
+		if err := json.Unmarshal(payload, &ticket); err != nil {
+			return nil, ErrWSTicketInvalid
+		}
+		return &ticket, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ticket, ok := h.tickets[token]
+	delete(h.tickets, token)
+	if !ok || time.Now().After(ticket.ExpiresAt) {
+		return nil, ErrWSTicketInvalid
+	}
+	return &ticket, nil
+}
+
+// Acquire 占用一个连接名额，返回的 release 在连接关闭时调用
+// user 为票据中的用户名（未启用票据时为客户端 IP），channel 为推送频道（路由路径）
+func (h *WSHub) Acquire(user, channel string) (func(), error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.config.MaxConnections > 0 && h.total >= h.config.MaxConnections {
+		h.rejected["global_limit"]++
+		return nil, ErrWSGlobalLimit
+	}
+	if h.config.MaxPerUser > 0 && h.perUser[user] >= h.config.MaxPerUser {
+		h.rejected["user_limit"]++
+		return nil, ErrWSUserLimit
+	}
+	h.total++
+	h.perUser[user]++
+	h.channels[channel]++
+	h.accepted++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			h.total--
+			if h.perUser[user]--; h.perUser[user] <= 0 {
+				delete(h.perUser, user)
+			}
+			if h.channels[channel]--; h.channels[channel] <= 0 {
+				delete(h.channels, channel)
+			}
+		})
+	}, nil
+}
+
+// Reject 记录一次被拒绝的连接（票据无效等）
+func (h *WSHub) Reject(reason string) {
+	h.mu.Lock()
+	h.rejected[reason]++
+	h.mu.Unlock()
+}
+
+// Stats 连接统计（监控接口展示）
+func (h *WSHub) Stats() map[string]interface{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	type userCount struct {
+		User        string `json:"user"`
+		Connections int    `json:"connections"`
+	}
+	users := make([]userCount, 0, len(h.perUser))
+	for user, n := range h.perUser {
+		users = append(users, userCount{User: user, Connections: n})
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Connections > users[j].Connections })
+
+	channels := make(map[string]int, len(h.channels))
+	for channel, n := range h.channels {
+		channels[channel] = n
+	}
+	rejected := make(map[string]int64, len(h.rejected))
+	for reason, n := range h.rejected {
+		rejected[reason] = n
+	}
+	return map[string]interface{}{
+		"connections":     h.total,
+		"max_connections": h.config.MaxConnections,
+		"max_per_user":    h.config.MaxPerUser,
+		"require_ticket":  h.config.RequireTicket,
+		"channels":        channels,
+		"users":           users,
+		"tickets_issued":  h.issued,
+		"accepted":        h.accepted,
+		"rejected":        rejected,
+	}
+}
@@ -12,12 +12,63 @@ import (
 
 // Config holds all configuration
 type Config struct {
-	Server         ServerConfig         `yaml:"server"`
-	Database       DatabaseConfig       `yaml:"database"`
-	Redis          RedisConfig          `yaml:"redis"`
-	Cache          CacheConfig          `yaml:"cache"`
-	SpiderDetector SpiderDetectorConfig `yaml:"spider_detector"`
-	Auth           AuthConfig           `yaml:"auth"`
+	Server          ServerConfig          `yaml:"server"`
+	Database        DatabaseConfig        `yaml:"database"`
+	Redis           RedisConfig           `yaml:"redis"`
+	Cache           CacheConfig           `yaml:"cache"`
+	SpiderDetector  SpiderDetectorConfig  `yaml:"spider_detector"`
+	Auth            AuthConfig            `yaml:"auth"`
+	CORS            CORSConfig            `yaml:"cors"`
+	ClickHouse      ClickHouseConfig      `yaml:"clickhouse"`
+	TemplateBudget  TemplateBudgetConfig  `yaml:"template_error_budget"`
+	TemplateSandbox TemplateSandboxConfig `yaml:"template_sandbox"`
+	TemplateCompile CompileCacheConfig    `yaml:"template_compile_cache"`
+	TemplateFuncs   TemplateFuncsConfig   `yaml:"template_funcs"`
+	WASMExtensions  WASMExtensionsConfig  `yaml:"wasm_extensions"`
+	GRPC            GRPCConfig            `yaml:"grpc"`
+	OpenAPI         OpenAPIConfig         `yaml:"openapi"`
+	LoginGuard      LoginGuardConfig      `yaml:"login_guard"`
+	IPAllowlist     IPAllowlistConfig     `yaml:"ip_allowlist"`
+	Backup          BackupConfig          `yaml:"backup"`
+	AccessLog       AccessLogConfig       `yaml:"access_log"`
+	ErrorReporting  ErrorReportingConfig  `yaml:"error_reporting"`
+	AntiScrape      AntiScrapeConfig      `yaml:"anti_scrape"`
+	KeywordFeedback KeywordFeedbackConfig `yaml:"keyword_feedback"`
+	KeywordImport   KeywordImportConfig   `yaml:"keyword_import"`
+	KeywordExpand   KeywordExpandConfig   `yaml:"keyword_expand"`
+	URLStrategy     URLStrategyConfig     `yaml:"url_strategy"`
+	Archive         ArchiveConfig         `yaml:"archive"`
+	PoolUpdates     PoolUpdatesConfig     `yaml:"pool_updates"`
+	Segmenter       SegmenterConfig       `yaml:"segmenter"`
+	Spintax         SpintaxConfig         `yaml:"spintax"`
+	PinyinSlug      PinyinSlugConfig      `yaml:"pinyin_slug"`
+	PublishDate     PublishDateConfig     `yaml:"publish_date"`
+	AutoTDK         AutoTDKConfig         `yaml:"auto_tdk"`
+	CSSObfuscation  CSSObfuscationConfig  `yaml:"css_obfuscation"`
+	ContentArchive  ContentArchiveConfig  `yaml:"content_archive"`
+	SpiderRobots    SpiderRobotsConfig    `yaml:"spider_robots"`
+	SpiderOutput    SpiderOutputConfig    `yaml:"spider_output"`
+	Freshness       FreshnessConfig       `yaml:"freshness"`
+	DomainMonitor   DomainMonitorConfig   `yaml:"domain_monitor"`
+	WebSocket       WebSocketConfig       `yaml:"websocket"`
+	SystemMetrics   SystemMetricsConfig   `yaml:"system_metrics"`
+	Alerting        AlertingConfig        `yaml:"alerting"`
+	Canary          CanaryConfig          `yaml:"canary"`
+	ActivityStats   ActivityStatsConfig   `yaml:"activity_stats"`
+	LinkAudit       LinkAuditConfig       `yaml:"link_audit"`
+	OutboundHTTP    OutboundHTTPConfig    `yaml:"outbound_http"`
+	EgressAudit     EgressAuditConfig     `yaml:"egress_audit"`
+	QueryCache      QueryCacheConfig      `yaml:"query_cache"`
+	Bootstrap       BootstrapConfig       `yaml:"bootstrap"`
+	WorkerFiles     WorkerFilesConfig     `yaml:"content_worker_files"`
+	Trash           TrashConfig           `yaml:"trash"`
+	PythonCheck     PythonCheckConfig     `yaml:"python_check"`
+	SpiderStats     SpiderStatsConfig     `yaml:"spider_stats"`
+	KeywordFilter   KeywordFilterConfig   `yaml:"keyword_filter"`
+	ImageSigning    ImageSigningConfig    `yaml:"image_signing"`
+	Excerpt         ExcerptConfig         `yaml:"excerpt"`
+	LLM             LLMConfig             `yaml:"llm"`
+	BodyLimits      BodyLimitsConfig      `yaml:"body_limits"`
 }
 
 // RedisConfig holds Redis configuration
@@ -35,6 +86,34 @@ type ServerConfig struct {
 	Port    int    `yaml:"port"`
 	Workers int    `yaml:"workers"`
 	Debug   bool   `yaml:"debug"`
+	// Listen HTTP 监听方式：tcp（host:port）、unix（unix_socket）、systemd（socket activation）
+	Listen         string    `yaml:"listen"`
+	UnixSocket     string    `yaml:"unix_socket"`
+	UnixSocketMode string    `yaml:"unix_socket_mode"` // 八进制权限，如 0660
+	TLS            TLSConfig `yaml:"tls"`
+	// DirectPages 不经过 Nginx 直接对外提供页面：未匹配的 GET 请求按 Host、路径和 User-Agent 渲染（启用 TLS 时总是开启）
+	DirectPages bool `yaml:"direct_pages"`
+}
+
+// TLSConfig HTTPS 配置（无 Nginx 的小型部署直接由 Go 服务终止 TLS）
+type TLSConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Port HTTPS 监听端口，server.port 继续监听 HTTP
+	Port int `yaml:"port"`
+	// CertFile / KeyFile 证书文件，配置后不使用 autocert
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// Autocert 通过 Let's Encrypt 为站点表中的域名按需签发证书
+	Autocert         bool     `yaml:"autocert"`
+	AutocertEmail    string   `yaml:"autocert_email"`
+	AutocertCacheDir string   `yaml:"autocert_cache_dir"`
+	AutocertHosts    []string `yaml:"autocert_hosts"` // 站点以外需要证书的域名（如管理后台）
+	// HTTP2 HTTPS 启用 HTTP/2
+	HTTP2 bool `yaml:"http2"`
+	// RedirectHTTP HTTP 请求 301 跳转到 HTTPS
+	RedirectHTTP bool `yaml:"redirect_http"`
+	// MinVersion 最低 TLS 版本：1.2 / 1.3
+	MinVersion string `yaml:"min_version"`
 }
 
 // DatabaseConfig holds database configuration
@@ -47,6 +126,12 @@ type DatabaseConfig struct {
 	Charset     string `yaml:"charset"`
 	PoolSize    int    `yaml:"pool_size"`
 	PoolRecycle int    `yaml:"pool_recycle"`
+
+	// 只读副本：后台列表、统计等只读查询走副本，复制延迟超过 replica_max_lag_seconds
+	// 或副本不可用时回退主库
+	Replicas             []string `yaml:"replicas"`                // 副本 DSN（user:pass@tcp(host:port)/db）
+	ReplicaMaxLagSeconds int      `yaml:"replica_max_lag_seconds"` // 允许的最大复制延迟
+	ReplicaCheckSeconds  int      `yaml:"replica_check_seconds"`   // 健康和延迟检查间隔
 }
 
 // CacheConfig holds cache configuration
@@ -56,6 +141,22 @@ type CacheConfig struct {
 	MaxSizeGB   float64 `yaml:"max_size_gb"`
 	GzipEnabled bool    `yaml:"gzip_enabled"`
 	Dir         string  `yaml:"dir"`
+	// Backend 缓存后端：disk（Nginx 直接读取）、redis（多实例共享）、hybrid（磁盘 + Redis）
+	Backend string `yaml:"backend"`
+	// RedisPrefix Redis 缓存 key 前缀
+	RedisPrefix string `yaml:"redis_prefix"`
+	// RedisStatsIntervalSeconds Redis 缓存统计扫描间隔
+	RedisStatsIntervalSeconds int `yaml:"redis_stats_interval_seconds"`
+	// WatchEnabled 用 fsnotify 监听磁盘缓存目录，持续增量更新统计
+	WatchEnabled bool `yaml:"watch_enabled"`
+	// WatchMaxDirs 监听目录数上限，超过时放弃监听（受 fs.inotify.max_user_watches 限制）
+	WatchMaxDirs int `yaml:"watch_max_dirs"`
+	// DomainMaxSizeMB 单域名缓存大小上限（MB），0 不限制，站点可单独覆盖
+	DomainMaxSizeMB float64 `yaml:"domain_max_size_mb"`
+	// DomainMaxEntries 单域名缓存条数上限，0 不限制，站点可单独覆盖
+	DomainMaxEntries int `yaml:"domain_max_entries"`
+	// SiteSyncSeconds 站点配置缓存增量同步间隔（秒），0 不定期同步
+	SiteSyncSeconds int `yaml:"site_sync_seconds"`
 }
 
 // SpiderDetectorConfig holds spider detector configuration
@@ -66,15 +167,552 @@ type SpiderDetectorConfig struct {
 
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
-	SecretKey                string `yaml:"secret_key"`
-	Algorithm                string `yaml:"algorithm"`
-	AccessTokenExpireMinutes int    `yaml:"access_token_expire_minutes"`
+	SecretKey                string           `yaml:"secret_key"`
+	Algorithm                string           `yaml:"algorithm"`
+	AccessTokenExpireMinutes int              `yaml:"access_token_expire_minutes"`
+	MaxSessions              int              `yaml:"max_sessions"` // 每个管理员最多同时在线会话数，0 不限制
+	Cookie                   AuthCookieConfig `yaml:"cookie"`
 	DefaultAdmin             struct {
 		Username string `yaml:"username"`
 		Password string `yaml:"password"`
 	} `yaml:"default_admin"`
 }
 
+// AuthCookieConfig holds cookie-based admin session configuration
+// 启用后登录时下发 HttpOnly Token Cookie，基于 Cookie 的写请求需携带 X-CSRF-Token（双重提交）
+type AuthCookieConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Secure   bool   `yaml:"secure"`    // 仅 HTTPS 发送
+	SameSite string `yaml:"same_site"` // strict / lax / none
+	Domain   string `yaml:"domain"`
+}
+
+// CORSConfig holds cross-origin policy for the admin API
+type CORSConfig struct {
+	AllowedOrigins   []string `yaml:"allowed_origins"` // "*" 表示任意来源（不能与 allow_credentials 同时使用）
+	AllowedMethods   []string `yaml:"allowed_methods"`
+	AllowedHeaders   []string `yaml:"allowed_headers"`
+	AllowCredentials bool     `yaml:"allow_credentials"`
+	MaxAge           int      `yaml:"max_age"` // 预检结果缓存秒数
+}
+
+// ClickHouseConfig holds ClickHouse analytics sink configuration
+type ClickHouseConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	URL         string `yaml:"url"` // HTTP 接口地址，如 http://clickhouse:8123
+	Database    string `yaml:"database"`
+	Table       string `yaml:"table"`
+	User        string `yaml:"user"`
+	Password    string `yaml:"password"`
+	MirrorMySQL bool   `yaml:"mirror_mysql"` // 启用后是否继续写入 MySQL spider_logs
+	Timezone    string `yaml:"timezone"`     // 按日/小时统计使用的时区（IANA 名称），为空时使用进程本地时区，与 MySQL 统计一致
+}
+
+// TemplateBudgetConfig holds per-template render error budget configuration
+type TemplateBudgetConfig struct {
+	Enabled        bool    `yaml:"enabled"`
+	WindowSeconds  int     `yaml:"window_seconds"`   // 统计窗口
+	MinRenders     int     `yaml:"min_renders"`      // 窗口内最少渲染次数，低于此值不判定
+	MaxFailureRate float64 `yaml:"max_failure_rate"` // 失败率上限（0-1），超过则自动降级
+}
+
+// TemplateSandboxConfig holds per-render template resource limits
+type TemplateSandboxConfig struct {
+	TimeoutMs      int `yaml:"timeout_ms"`       // 单次渲染超时（毫秒）
+	MaxIterate     int `yaml:"max_iterate"`      // 单次 iterate()/range(N) 最大长度
+	MaxCalls       int `yaml:"max_calls"`        // 单次渲染最多函数调用次数
+	MaxOutputBytes int `yaml:"max_output_bytes"` // 单次渲染最大输出字节数
+}
+
+// CompileCacheConfig holds compiled template cache limits
+type CompileCacheConfig struct {
+	MaxEntries int `yaml:"max_entries"` // 最多缓存的编译模板数，<=0 不限制
+	MaxMB      int `yaml:"max_mb"`      // 估算内存上限（MB），<=0 不限制
+}
+
+// TemplateFuncsConfig holds extra template function packs to enable
+type TemplateFuncsConfig struct {
+	Packs []string `yaml:"packs"` // 启用的函数包（在代码中注册，如 common）
+}
+
+// WASMExtensionsConfig holds WASM render extension runtime configuration
+type WASMExtensionsConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	Dir            string `yaml:"dir"`              // 模块目录（*.wasm，修改后自动重载）
+	MemoryLimitMB  int    `yaml:"memory_limit_mb"`  // 单个实例内存上限
+	TimeoutMs      int    `yaml:"timeout_ms"`       // 单次调用超时
+	MaxOutputBytes int    `yaml:"max_output_bytes"` // 单次调用最大输出
+	MaxInstances   int    `yaml:"max_instances"`    // 每个模块保留的空闲实例数
+	ReloadSeconds  int    `yaml:"reload_seconds"`   // 模块文件检查间隔
+}
+
+// GRPCConfig holds internal gRPC API configuration (content worker / spider runner)
+type GRPCConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Addr    string `yaml:"addr"`  // 监听地址，如 :9090
+	Token   string `yaml:"token"` // 共享令牌，启用 gRPC 时必填
+}
+
+// OpenAPIConfig holds OpenAPI spec and request validation configuration
+type OpenAPIConfig struct {
+	Enabled          bool `yaml:"enabled"`           // 提供 /api/openapi.json 和 /api/docs
+	ValidateRequests bool `yaml:"validate_requests"` // 按文档校验请求体
+}
+
+// LoginGuardConfig holds login brute-force protection configuration
+type LoginGuardConfig struct {
+	Enabled               bool `yaml:"enabled"`
+	WindowSeconds         int  `yaml:"window_seconds"`           // 失败计数窗口
+	MaxFailuresPerIP      int  `yaml:"max_failures_per_ip"`      // 单 IP 窗口内最大失败次数
+	MaxFailuresPerUser    int  `yaml:"max_failures_per_user"`    // 单用户名窗口内最大失败次数（同一 IP 超过时锁定，所有 IP 合计超过时退避）
+	LockoutBaseSeconds    int  `yaml:"lockout_base_seconds"`     // 首次锁定时长，重复锁定时翻倍
+	LockoutMaxSeconds     int  `yaml:"lockout_max_seconds"`      // 锁定时长上限
+	UserBackoffMaxSeconds int  `yaml:"user_backoff_max_seconds"` // 用户名退避时长上限（从 1 秒起每次失败翻倍）
+	SprayDistinctUsers    int  `yaml:"spray_distinct_users"`     // 单 IP 尝试不同用户名数告警阈值
+	DistributedIPs        int  `yaml:"distributed_ips"`          // 单用户名被不同 IP 尝试数告警阈值
+	GlobalFailures        int  `yaml:"global_failures"`          // 窗口内全局失败次数告警阈值
+}
+
+// IPAllowlistConfig holds admin API IP allowlist configuration
+// 启用状态和 CIDR 列表可在管理后台修改（保存到 system_settings，优先于此处的初始值）
+type IPAllowlistConfig struct {
+	Enabled        bool     `yaml:"enabled"`
+	CIDRs          []string `yaml:"cidrs"`           // 允许访问 /api/* 的网段
+	TrustedProxies []string `yaml:"trusted_proxies"` // 仅当直连地址在此列表中时才信任 X-Forwarded-For / X-Real-IP
+	ExemptPaths    []string `yaml:"exempt_paths"`    // 不受白名单限制的路径前缀（如 Nginx Lua 上报）
+	BypassToken    string   `yaml:"bypass_token"`    // 应急绕过令牌（请求头 X-Admin-Bypass），为空时禁用
+}
+
+// BackupConfig holds encrypted backup configuration
+type BackupConfig struct {
+	Passphrase string         `yaml:"passphrase"` // 加密口令，为空时禁止备份
+	LocalDir   string         `yaml:"local_dir"`
+	Keep       int            `yaml:"keep"`     // 本地保留份数，0 不清理
+	Schedule   string         `yaml:"schedule"` // 定时备份 Cron 表达式（秒 分 时 日 月 周），为空不创建定时任务
+	S3         BackupS3Config `yaml:"s3"`
+}
+
+// BackupS3Config holds S3-compatible object storage configuration for backups and archives
+type BackupS3Config struct {
+	Enabled   bool   `yaml:"enabled"`
+	Endpoint  string `yaml:"endpoint"` // 如 https://s3.amazonaws.com、http://minio:9000
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	Prefix    string `yaml:"prefix"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+	PathStyle bool   `yaml:"path_style"` // MinIO 等需使用路径风格
+}
+
+// AccessLogConfig holds the structured JSON access log configuration
+type AccessLogConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Output  string `yaml:"output"` // file / stdout
+	// FilePath 日志文件路径（相对路径基于项目根目录）
+	FilePath   string `yaml:"file_path"`
+	MaxSizeMB  int    `yaml:"max_size_mb"`
+	MaxBackups int    `yaml:"max_backups"`
+	MaxAgeDays int    `yaml:"max_age_days"`
+	Compress   bool   `yaml:"compress"`
+	// RotateInterval 按时间切分：hourly / daily，为空只按大小切分
+	RotateInterval string `yaml:"rotate_interval"`
+	// SampleRate 非蜘蛛请求采样率（0~1），SpiderSampleRate 蜘蛛请求采样率；5xx 始终记录
+	SampleRate       float64  `yaml:"sample_rate"`
+	SpiderSampleRate float64  `yaml:"spider_sample_rate"`
+	SkipPaths        []string `yaml:"skip_paths"` // 路径前缀，不记录
+}
+
+// ErrorReportingConfig holds panic / 5xx error reporting configuration
+// DSN 为 Sentry 兼容的 DSN（https://key@host/project_id），WebhookURL 为通用 JSON webhook，二者可同时配置
+type ErrorReportingConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	DSN         string `yaml:"dsn"`
+	WebhookURL  string `yaml:"webhook_url"`
+	Environment string `yaml:"environment"`
+	Release     string `yaml:"release"` // 为空时使用构建信息中的 VCS 版本
+	// PanicSampleRate panic 上报采样率，ErrorSampleRate 5xx 响应上报采样率（0~1）
+	PanicSampleRate float64 `yaml:"panic_sample_rate"`
+	ErrorSampleRate float64 `yaml:"error_sample_rate"`
+	// DedupWindowSeconds 相同错误（类型+路由+位置）在窗口内只上报一次，之后的上报附带抑制次数
+	DedupWindowSeconds int `yaml:"dedup_window_seconds"`
+	QueueSize          int `yaml:"queue_size"`
+	TimeoutSeconds     int `yaml:"timeout_seconds"`
+}
+
+// AntiScrapeConfig holds anti-scrape protection for non-spider page requests
+// 按 IP 统计窗口内请求数：超过 challenge_threshold 需通过 JS 挑战，超过 tarpit_threshold 延迟响应，
+// 超过 block_threshold、连续不通过挑战或访问蜜罐链接时加入封禁列表（多实例通过 Redis 同步）
+type AntiScrapeConfig struct {
+	Enabled            bool `yaml:"enabled"`
+	WindowSeconds      int  `yaml:"window_seconds"`
+	ChallengeThreshold int  `yaml:"challenge_threshold"`
+	TarpitThreshold    int  `yaml:"tarpit_threshold"`
+	BlockThreshold     int  `yaml:"block_threshold"`
+	// MaxUnsolvedChallenges 未通过挑战的次数达到该值后封禁
+	MaxUnsolvedChallenges int `yaml:"max_unsolved_challenges"`
+	TarpitDelayMs         int `yaml:"tarpit_delay_ms"`
+	MaxTarpitted          int `yaml:"max_tarpitted"` // 同时延迟中的请求上限，超过直接封禁，避免占满连接
+	BlockSeconds          int `yaml:"block_seconds"`
+	// CookieName / CookieTTLSeconds 挑战通过后的凭证 Cookie
+	CookieName       string `yaml:"cookie_name"`
+	CookieTTLSeconds int    `yaml:"cookie_ttl_seconds"`
+	Secret           string `yaml:"secret"`          // 凭证签名密钥，为空时使用 auth.secret_key
+	HoneypotPrefix   string `yaml:"honeypot_prefix"` // 蜜罐链接路径前缀，访问即封禁
+	// HoneypotAmnestyHours 蜜罐标记的坏爬虫记录和封禁时长（到期自动赦免），<=0 时使用 block_seconds
+	HoneypotAmnestyHours int `yaml:"honeypot_amnesty_hours"`
+	// RobotsTxt /robots.txt 基础内容，每个 User-agent 分组自动加入蜜罐前缀的 Disallow；为空时允许全部抓取
+	RobotsTxt string `yaml:"robots_txt"`
+	// Allowlist 不受限制的网段（如监控、合作方抓取）
+	Allowlist []string `yaml:"allowlist"`
+}
+
+// KeywordFeedbackConfig holds keyword crawl feedback configuration
+// 记录每个页面使用的标题关键词，定期与蜘蛛访问日志关联计算关键词抓取得分
+type KeywordFeedbackConfig struct {
+	Enabled                bool `yaml:"enabled"`
+	WindowDays             int  `yaml:"window_days"`              // 统计最近 N 天的蜘蛛访问
+	RetentionDays          int  `yaml:"retention_days"`           // 页面关键词记录保留天数
+	ComputeIntervalMinutes int  `yaml:"compute_interval_minutes"` // 得分计算间隔
+	QueueSize              int  `yaml:"queue_size"`
+	BatchSize              int  `yaml:"batch_size"`
+	// WeightedSelection 开启后标题关键词按 BoostRatio 概率从得分最高的 BoostTopN 个关键词中选取
+	WeightedSelection bool    `yaml:"weighted_selection"`
+	BoostRatio        float64 `yaml:"boost_ratio"`
+	BoostTopN         int     `yaml:"boost_top_n"`
+}
+
+// KeywordImportConfig holds search query import configuration (Baidu Tongji / Google Search Console)
+// 定时拉取搜索词报表，去重后进入候选队列，由管理员审核后加入关键词分组
+type KeywordImportConfig struct {
+	Schedule       string `yaml:"schedule"`        // Cron 表达式（秒 分 时 日 月 周），为空不创建定时任务
+	LookbackDays   int    `yaml:"lookback_days"`   // 每次拉取最近 N 天
+	MinImpressions int    `yaml:"min_impressions"` // 展现量（百度统计为浏览量）低于该值的搜索词忽略
+	MaxRows        int    `yaml:"max_rows"`        // 每个站点每次最多拉取的行数
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+	BaiduTongji    struct {
+		AccessToken string   `yaml:"access_token"`
+		SiteIDs     []string `yaml:"site_ids"`
+	} `yaml:"baidu_tongji"`
+	GSC struct {
+		CredentialsFile string   `yaml:"credentials_file"` // 服务账号 JSON 文件
+		SiteURLs        []string `yaml:"site_urls"`        // 如 https://example.com/ 或 sc-domain:example.com
+	} `yaml:"gsc"`
+}
+
+// KeywordExpandConfig holds long-tail keyword expansion configuration
+// 以种子关键词请求搜索引擎下拉联想接口，过滤违禁词后写入目标分组（作为定时任务执行）
+type KeywordExpandConfig struct {
+	Sources           []string `yaml:"sources"`             // 默认来源：baidu / so360 / sogou
+	RequestsPerSecond float64  `yaml:"requests_per_second"` // 每个来源每秒最多请求数
+	TimeoutSeconds    int      `yaml:"timeout_seconds"`
+	MaxDepth          int      `yaml:"max_depth"`   // 任务可设置的最大扩展层数
+	MaxResults        int      `yaml:"max_results"` // 单次任务最多写入的关键词数
+	UserAgent         string   `yaml:"user_agent"`
+}
+
+// URLStrategyConfig holds URL generation strategy defaults
+// 站群未单独配置时使用这里的默认策略，URL 模板函数、站内链接和 sitemap 共用同一套规则
+type URLStrategyConfig struct {
+	Default         string `yaml:"default"`           // random / numeric / date / pinyin / hybrid
+	URLSpace        int    `yaml:"url_space"`         // 每个站点的 URL 数量（站内链接和 sitemap 的取值范围）
+	SlugSeparator   string `yaml:"slug_separator"`    // 拼音 slug 分隔符
+	SlugMaxLen      int    `yaml:"slug_max_len"`      // 拼音 slug 最大长度（按音节截断）
+	DateWindowDays  int    `yaml:"date_window_days"`  // 日期路径分布在策略生效日之前多少天内
+	SitemapPageSize int    `yaml:"sitemap_page_size"` // 每个 sitemap 文件的 URL 数（上限 50000）
+	SitemapScheme   string `yaml:"sitemap_scheme"`    // sitemap 中绝对地址的协议：http / https
+}
+
+// ArchiveConfig holds paginated archive (list/category) page configuration
+// 归档列表页 /list/{分类}/{页码}.html：分类取自站点关键词，每页列出 URL 策略生成的站内 URL 和锚文本，
+// 同一站点的分页内容固定（URL 按序号轮流分配到各分类），并加入 sitemap
+type ArchiveConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	Prefix     string `yaml:"prefix"`     // 路径前缀
+	Categories int    `yaml:"categories"` // 每个站点的分类数
+	PageSize   int    `yaml:"page_size"`  // 每页链接数
+	MaxPages   int    `yaml:"max_pages"`  // 每个分类最多页数
+	Template   string `yaml:"template"`   // 列表页模板名，为空或不存在时使用站点模板
+	Sitemap    bool   `yaml:"sitemap"`    // 列表页加入 sitemap
+}
+
+// SegmenterConfig holds Chinese word segmentation configuration
+type SegmenterConfig struct {
+	BuiltinDict bool     `yaml:"builtin_dict"` // 加载 gse 自带的简体中文词典
+	DictFiles   []string `yaml:"dict_files"`   // 额外的 jieba dict.txt 格式词典（每行: 词 词频 [词性]）
+	DefaultFreq int      `yaml:"default_freq"` // 后台添加自定义词未指定词频时使用
+}
+
+// SpintaxConfig holds spintax / synonym rewriting configuration
+type SpintaxConfig struct {
+	Enabled      bool     `yaml:"enabled"`       // 渲染时对正文做伪原创改写
+	SynonymFiles []string `yaml:"synonym_files"` // 同义词表，每行一组（"词1|词2|词3" 或 "{词1|词2}"）
+	Ratio        float64  `yaml:"ratio"`         // 有同义词的词被替换的概率（0-1）
+}
+
+// PinyinSlugConfig holds the pinyinSlug template function configuration
+type PinyinSlugConfig struct {
+	Tone            string `yaml:"tone"`              // none=无声调全拼, initials=只取首字母；拼音表不含声调，不支持带调输出
+	Separator       string `yaml:"separator"`         // 词之间的分隔符
+	MaxLen          int    `yaml:"max_len"`           // 最大长度
+	ByWord          bool   `yaml:"by_word"`           // 按分词结果连写同一个词的音节
+	Umlaut          string `yaml:"umlaut"`            // ü 的写法：v / u
+	CacheMaxEntries int    `yaml:"cache_max_entries"` // slug 缓存上限
+}
+
+// PublishDateConfig holds the default simulated publish-date distribution
+type PublishDateConfig struct {
+	RangeDays      int    `yaml:"range_days"`      // 日期分布在基准日之前多少天内
+	WeekdayWeights string `yaml:"weekday_weights"` // 周一到周日的权重，如 "1,1,1,1,1,0.4,0.3"
+	Recency        string `yaml:"recency"`         // uniform / linear / exponential
+	HalfLifeDays   int    `yaml:"half_life_days"`  // exponential 曲线的半衰期
+	HourStart      int    `yaml:"hour_start"`      // 发布时间段起始小时
+	HourEnd        int    `yaml:"hour_end"`        // 发布时间段结束小时（不含）
+}
+
+// AutoTDKConfig holds automatic TDK generation settings
+// 是否启用由功能开关 auto_tdk 控制（可按站群覆盖）
+type AutoTDKConfig struct {
+	DescriptionLen int `yaml:"description_len"` // 描述摘要最大字数
+	MaxKeywords    int `yaml:"max_keywords"`    // keywords 最多包含的关键词数
+}
+
+// CSSObfuscationConfig holds post-render CSS obfuscation settings
+// 是否启用由功能开关 css_obfuscation 控制（可按站群覆盖）
+type CSSObfuscationConfig struct {
+	Rename    bool     `yaml:"rename"`     // 按页面随机重命名 <style> 中定义的 class/id
+	Shuffle   bool     `yaml:"shuffle"`    // 打乱规则顺序（不改变层叠结果）
+	JunkRules int      `yaml:"junk_rules"` // 每个 <style> 插入的干扰规则数上限
+	Whitelist []string `yaml:"whitelist"`  // 不改名的 class/id，支持前缀通配 js-*
+}
+
+// PoolUpdatesConfig holds batching settings for marking consumed pool items
+// 标题/正文被消费后先进入内存队列，积压达到 max_batch 或每 flush_interval_ms 合并为
+// UPDATE ... WHERE id IN (...) 写库
+type PoolUpdatesConfig struct {
+	MaxBatch        int    `yaml:"max_batch"`         // 单条 UPDATE 最多的 ID 数
+	FlushIntervalMs int    `yaml:"flush_interval_ms"` // 最长攒批时间（毫秒）
+	JournalPath     string `yaml:"journal_path"`      // 消费预写日志（相对项目根目录），为空不启用
+}
+
+// ContentArchiveConfig holds cold content archival settings
+type ContentArchiveConfig struct {
+	AfterDays   int            `yaml:"after_days"`   // 已使用（status=0）且创建超过多少天的正文归档
+	BatchRows   int            `yaml:"batch_rows"`   // 每个归档文件（批次）的行数
+	DeleteChunk int            `yaml:"delete_chunk"` // 每次 DELETE 的行数
+	MaxBatches  int            `yaml:"max_batches"`  // 单次运行最多归档的批次数，0 不限
+	Schedule    string         `yaml:"schedule"`     // 定时归档 Cron 表达式，为空不创建定时任务
+	LocalDir    string         `yaml:"local_dir"`    // 未启用 S3 时归档文件的保存目录，也用作上传前的临时目录
+	S3          BackupS3Config `yaml:"s3"`
+}
+
+// SpiderRobotsConfig holds robots.txt compliance checker settings
+type SpiderRobotsConfig struct {
+	UserAgent    string `yaml:"user_agent"`    // 项目未指定时用于匹配 robots.txt 的 User-agent
+	CacheTTL     int    `yaml:"cache_ttl"`     // robots.txt 缓存时间（秒）
+	ErrorTTL     int    `yaml:"error_ttl"`     // 抓取失败（5xx/网络错误）结果的缓存时间（秒）
+	FetchTimeout int    `yaml:"fetch_timeout"` // 抓取超时（秒）
+	MaxURLs      int    `yaml:"max_urls"`      // 单次批量检查的最大 URL 数
+}
+
+// SpiderOutputConfig holds spider test-run item capture settings
+type SpiderOutputConfig struct {
+	KeepItems int `yaml:"keep_items"` // 每个项目保留的最近测试数据项条数
+	TTLHours  int `yaml:"ttl_hours"`  // 测试数据项保留时间（小时）
+}
+
+// FreshnessConfig holds scheduled content freshness refresh settings
+type FreshnessConfig struct {
+	Schedule     string `yaml:"schedule"`      // 定时保鲜 Cron 表达式，为空不创建定时任务
+	LookbackDays int    `yaml:"lookback_days"` // 候选页面：最近多少天内被蜘蛛访问过的页面
+	HistoryDays  int    `yaml:"history_days"`  // 刷新记录保留天数
+	DelayMs      int    `yaml:"delay_ms"`      // 每个页面刷新后的间隔（毫秒），降低对渲染的影响
+}
+
+// DomainMonitorConfig holds site domain health monitoring settings
+type DomainMonitorConfig struct {
+	Enabled          bool   `yaml:"enabled"`
+	Interval         int    `yaml:"interval"`          // 检查间隔（秒）
+	Concurrency      int    `yaml:"concurrency"`       // 并发检查的域名数
+	Timeout          int    `yaml:"timeout"`           // 单项检查超时（秒）
+	Scheme           string `yaml:"scheme"`            // HTTP 检查协议：https / http（https 时同时检查证书）
+	EdgeAddr         string `yaml:"edge_addr"`         // HTTP 检查连接的公网入口 host:port，为空时按 DNS 解析结果连接
+	UserAgent        string `yaml:"user_agent"`        // HTTP 检查使用的 User-Agent
+	CertWarnDays     int    `yaml:"cert_warn_days"`    // 证书剩余天数低于该值时告警
+	FailureThreshold int    `yaml:"failure_threshold"` // 连续失败次数达到该值时告警
+	HistoryDays      int    `yaml:"history_days"`      // 检查记录保留天数
+}
+
+// WebSocketConfig holds realtime push (WebSocket / SSE) connection settings
+type WebSocketConfig struct {
+	RequireTicket    bool `yaml:"require_ticket"`     // 连接必须携带 POST /api/auth/ws-ticket 签发的票据（默认开启）
+	TicketTTLSeconds int  `yaml:"ticket_ttl_seconds"` // 票据有效期
+	MaxConnections   int  `yaml:"max_connections"`    // 全局同时连接数上限，0 不限制
+	MaxPerUser       int  `yaml:"max_per_user"`       // 单用户（关闭票据时为单个客户端 IP）同时连接数上限，0 不限制
+}
+
+// SystemMetricsConfig holds system metrics history persistence settings
+type SystemMetricsConfig struct {
+	Enabled              bool `yaml:"enabled"`
+	MinuteRetentionDays  int  `yaml:"minute_retention_days"`   // 1 分钟精度保留天数
+	FiveMinRetentionDays int  `yaml:"five_min_retention_days"` // 5 分钟精度保留天数
+	HourRetentionDays    int  `yaml:"hour_retention_days"`     // 1 小时精度保留天数
+}
+
+// AlertingConfig holds alert notification settings
+type AlertingConfig struct {
+	WebhookURL     string `yaml:"webhook_url"`     // 通知渠道为 webhook 且规则未单独配置地址时使用
+	TimeoutSeconds int    `yaml:"timeout_seconds"` // webhook 请求超时
+}
+
+// CanaryConfig holds synthetic page render self-check settings
+type CanaryConfig struct {
+	Enabled          bool   `yaml:"enabled"`
+	Interval         int    `yaml:"interval"`          // 检查间隔（秒）
+	Path             string `yaml:"path"`              // 渲染的页面路径
+	Timeout          int    `yaml:"timeout"`           // 单次渲染超时（秒）
+	MaxLatencyMs     int    `yaml:"max_latency_ms"`    // 渲染耗时超过该值视为失败
+	MinBytes         int    `yaml:"min_bytes"`         // 页面字节数低于该值视为失败
+	Concurrency      int    `yaml:"concurrency"`       // 并发检查的站群数
+	FailureThreshold int    `yaml:"failure_threshold"` // 连续失败次数达到该值时告警
+	HistoryDays      int    `yaml:"history_days"`      // 检查记录保留天数
+}
+
+// ActivityStatsConfig holds nightly content activity rollup settings
+type ActivityStatsConfig struct {
+	Schedule     string `yaml:"schedule"`      // 每日汇总 Cron 表达式，为空不创建定时任务
+	BackfillDays int    `yaml:"backfill_days"` // 未汇总的日期最多向前补多少天
+}
+
+// LinkAuditConfig holds post-render link auditing settings
+type LinkAuditConfig struct {
+	Enabled      bool     `yaml:"enabled"`
+	Rel          string   `yaml:"rel"`           // 站外链接必须带的 rel 值（空格分隔），为空不改写
+	MaxExternal  int      `yaml:"max_external"`  // 每页站外链接上限，0 不限
+	DropExcess   bool     `yaml:"drop_excess"`   // 超出上限的站外链接替换为链接文字，false 时只统计
+	AllowDomains []string `yaml:"allow_domains"` // 视为站内的域名（含子域名），不改写也不计入上限
+}
+
+// OutboundHTTPConfig holds settings for the shared outbound HTTP client
+// 外部接口调用（推送、建议词、搜索词报表、告警/错误上报 webhook、S3 等）共用
+type OutboundHTTPConfig struct {
+	TimeoutSeconds     int     `yaml:"timeout_seconds"`      // 调用方未指定超时时的默认超时（含重试）
+	MaxRetries         int     `yaml:"max_retries"`          // 失败重试次数（网络错误、429、5xx）
+	RetryBaseMs        int     `yaml:"retry_base_ms"`        // 重试退避基数，按 2^n 增长并加随机抖动
+	RetryMaxMs         int     `yaml:"retry_max_ms"`         // 单次退避上限
+	BreakerFailures    int     `yaml:"breaker_failures"`     // 同一主机连续失败多少次后熔断，0 不熔断
+	BreakerOpenSeconds int     `yaml:"breaker_open_seconds"` // 熔断持续时间，之后放行一次探测请求
+	RateLimit          float64 `yaml:"rate_limit"`           // 全局每秒请求数上限（含重试），0 不限
+	RateBurst          int     `yaml:"rate_burst"`           // 突发请求数
+	Proxy              string  `yaml:"proxy"`                // 代理地址（http/https/socks5），为空时使用 HTTP(S)_PROXY 环境变量
+}
+
+// EgressAuditConfig holds outbound request audit log settings
+type EgressAuditConfig struct {
+	Enabled       bool `yaml:"enabled"`
+	RetentionDays int  `yaml:"retention_days"` // 明细保留天数，0 不清理（每日汇总始终保留）
+	BufferSize    int  `yaml:"buffer_size"`    // 内存缓冲区容量，满时丢弃
+	BatchSize     int  `yaml:"batch_size"`     // 单次批量写入条数
+	FlushSeconds  int  `yaml:"flush_seconds"`  // 最长写入间隔
+}
+
+// QueryCacheConfig holds the statement-level cache for hot admin list queries
+type QueryCacheConfig struct {
+	Enabled    bool `yaml:"enabled"`
+	TTLSeconds int  `yaml:"ttl_seconds"` // 兜底过期时间（写入未经过失效通知时，如直接改库）
+	MaxEntries int  `yaml:"max_entries"` // 最多缓存的语句结果数，超出时淘汰最早写入的
+}
+
+// BootstrapConfig holds the seed manifest used to initialize a fresh install
+type BootstrapConfig struct {
+	Manifest string `yaml:"manifest"` // 初始化清单路径，相对路径基于项目根目录
+}
+
+// WorkerFilesConfig holds the root and limits of the content worker file manager
+type WorkerFilesConfig struct {
+	Root          string `yaml:"root"`             // 文件管理根目录，所有操作限制在该目录内（不跟随指向目录外的符号链接）
+	MaxFileSizeMB int    `yaml:"max_file_size_mb"` // 在线编辑和上传的单文件大小上限
+}
+
+// TrashConfig holds the recycle bin for content worker and spider project file deletions
+type TrashConfig struct {
+	RetentionDays int `yaml:"retention_days"` // 回收站保留天数，到期后定时清除
+}
+
+// PythonCheckConfig holds the syntax check run when spider .py files are saved
+type PythonCheckConfig struct {
+	Enabled        bool     `yaml:"enabled"`
+	Mode           string   `yaml:"mode"`            // warn：保存并返回语法错误；block：有语法错误时拒绝保存
+	Command        []string `yaml:"command"`         // Python 解释器命令，只做 compile()，不执行代码
+	TimeoutSeconds int      `yaml:"timeout_seconds"` // 单次检查超时
+}
+
+// SpiderStatsConfig holds the retention of archived spider stats (day rows are kept indefinitely)
+type SpiderStatsConfig struct {
+	MinuteRetentionDays int `yaml:"minute_retention_days"`
+	HourRetentionDays   int `yaml:"hour_retention_days"`
+}
+
+// KeywordFilterConfig holds the quality filters applied on keyword add/batch add/upload
+type KeywordFilterConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	MinLength   int      `yaml:"min_length"`   // 最小字符数（按 rune 计）
+	MaxLength   int      `yaml:"max_length"`   // 最大字符数，0 表示不限制
+	BanPatterns []string `yaml:"ban_patterns"` // 命中任一正则即拒绝（URL、电话号码等）
+	Stopwords   []string `yaml:"stopwords"`    // 全局停用词，分组停用词在 keyword_stopwords 表中维护
+}
+
+// ImageSigningConfig holds the CDN signing of image URLs emitted by template image functions
+type ImageSigningConfig struct {
+	Enabled          bool                `yaml:"enabled"`
+	ClockSkewSeconds int                 `yaml:"clock_skew_seconds"` // 过期时间额外放宽的秒数，容忍本机与 CDN 的时钟偏差
+	Groups           []ImageSigningGroup `yaml:"groups"`             // 需要签名的图片分组，未列出的分组不签名
+}
+
+// ImageSigningGroup holds the signing settings of one image group
+type ImageSigningGroup struct {
+	GroupID    int    `yaml:"group_id"`
+	Secret     string `yaml:"secret"`
+	Algorithm  string `yaml:"algorithm"`   // hmac_sha256（默认）/ type_a（阿里云、腾讯云 A 类鉴权）
+	TTLSeconds int    `yaml:"ttl_seconds"` // 签名有效期；type_a 须与 CDN 控制台的有效时长一致
+	Param      string `yaml:"param"`       // 签名参数名，默认 hmac_sha256 为 sign，type_a 为 auth_key
+}
+
+// ExcerptConfig holds article summary generation (on ingestion, backfill task and summary() template function)
+type ExcerptConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	Strategy  string `yaml:"strategy"`   // sentences：全文前 N 句；paragraph：第一个不短于 min_length 的段落
+	Sentences int    `yaml:"sentences"`  // sentences 策略取的句数
+	MinLength int    `yaml:"min_length"` // paragraph 策略跳过的短段落长度
+	MaxLength int    `yaml:"max_length"` // 摘要最大字符数，超出截断并加省略号
+	Pattern   string `yaml:"pattern"`    // 优先使用的提取正则（取第一个捕获组），未匹配时按 strategy
+	Schedule  string `yaml:"schedule"`   // 回填历史文章摘要的 Cron，为空时不创建定时任务
+	BatchSize int    `yaml:"batch_size"` // 回填每批处理的文章数
+}
+
+// LLMConfig holds the OpenAI-compatible gateway used to rewrite article titles and contents
+type LLMConfig struct {
+	Enabled              bool    `yaml:"enabled"`
+	Endpoint             string  `yaml:"endpoint"` // OpenAI 兼容接口地址，请求 {endpoint}/chat/completions
+	APIKey               string  `yaml:"api_key"`  // 也可用环境变量 LLM_API_KEY
+	Model                string  `yaml:"model"`
+	Temperature          float64 `yaml:"temperature"`
+	TimeoutSeconds       int     `yaml:"timeout_seconds"`
+	MaxConcurrency       int     `yaml:"max_concurrency"`         // 同时进行的请求上限
+	DailyRequestBudget   int     `yaml:"daily_request_budget"`    // 每日请求数上限，0 不限
+	DailyTokenBudget     int     `yaml:"daily_token_budget"`      // 每日 token 上限（输入 + 输出），0 不限
+	PromptPricePer1K     float64 `yaml:"prompt_price_per_1k"`     // 每千输入 token 价格（用于成本统计）
+	CompletionPricePer1K float64 `yaml:"completion_price_per_1k"` // 每千输出 token 价格
+	MaxContentLength     int     `yaml:"max_content_length"`      // 超过该字符数的正文不改写
+	TitlePrompt          string  `yaml:"title_prompt"`            // 改写标题的系统提示词
+	ContentPrompt        string  `yaml:"content_prompt"`          // 改写正文的系统提示词
+}
+
+// BodyLimitsConfig holds request body size limits for /api routes
+type BodyLimitsConfig struct {
+	DefaultMB int            `yaml:"default_mb"` // 未单独配置的路由的上限，0 不限
+	Routes    map[string]int `yaml:"routes"`     // "METHOD 路由模板" → 上限 MB，0 表示不限（由 handler 自行限制）
+}
+
 // RawConfig represents the raw YAML structure with environments
 type RawConfig struct {
 	Default     map[string]interface{} `yaml:"default"`
@@ -104,8 +742,10 @@ func Load(configPath string) (*Config, error) {
 
 	// Select environment config
 	var envConfig map[string]interface{}
+	envName := "development"
 	if env == "release" || env == "production" {
 		envConfig = raw.Production
+		envName = "production"
 	} else {
 		envConfig = raw.Development
 	}
@@ -116,20 +756,40 @@ func Load(configPath string) (*Config, error) {
 	// Parse into Config struct
 	cfg := &Config{
 		Server: ServerConfig{
-			Host:    getString(merged, "server.host", "127.0.0.1"),
-			Port:    getIntEnv("SERVER_PORT", getInt(merged, "server.port", 8080)),
-			Workers: getInt(merged, "server.workers", 1),
-			Debug:   getBool(merged, "server.debug", false),
+			Host:           getString(merged, "server.host", "127.0.0.1"),
+			Port:           getIntEnv("SERVER_PORT", getInt(merged, "server.port", 8080)),
+			Workers:        getInt(merged, "server.workers", 1),
+			Debug:          getBool(merged, "server.debug", false),
+			Listen:         getEnv("SERVER_LISTEN", getString(merged, "server.listen", "tcp")),
+			UnixSocket:     getString(merged, "server.unix_socket", "/run/seo-generator/api.sock"),
+			UnixSocketMode: getString(merged, "server.unix_socket_mode", "0660"),
+			DirectPages:    getBool(merged, "server.direct_pages", false),
+			TLS: TLSConfig{
+				Enabled:          getBool(merged, "server.tls.enabled", false),
+				Port:             getInt(merged, "server.tls.port", 443),
+				CertFile:         getString(merged, "server.tls.cert_file", ""),
+				KeyFile:          getString(merged, "server.tls.key_file", ""),
+				Autocert:         getBool(merged, "server.tls.autocert", false),
+				AutocertEmail:    getString(merged, "server.tls.autocert_email", ""),
+				AutocertCacheDir: getString(merged, "server.tls.autocert_cache_dir", "data/autocert"),
+				AutocertHosts:    getStringSlice(merged, "server.tls.autocert_hosts", nil),
+				HTTP2:            getBool(merged, "server.tls.http2", true),
+				RedirectHTTP:     getBool(merged, "server.tls.redirect_http", true),
+				MinVersion:       getString(merged, "server.tls.min_version", "1.2"),
+			},
 		},
 		Database: DatabaseConfig{
-			Host:        getEnv("DB_HOST", getString(merged, "database.host", "localhost")),
-			Port:        getIntEnv("DB_PORT", getInt(merged, "database.port", 3306)),
-			User:        getEnv("DB_USER", getString(merged, "database.user", "root")),
-			Password:    getEnv("DB_PASSWORD", getString(merged, "database.password", "")),
-			Database:    getEnv("DB_NAME", getString(merged, "database.database", "seo_generator")),
-			Charset:     getString(merged, "database.charset", "utf8mb4"),
-			PoolSize:    getInt(merged, "database.pool_size", 10),
-			PoolRecycle: getInt(merged, "database.pool_recycle", 3600),
+			Host:                 getEnv("DB_HOST", getString(merged, "database.host", "localhost")),
+			Port:                 getIntEnv("DB_PORT", getInt(merged, "database.port", 3306)),
+			User:                 getEnv("DB_USER", getString(merged, "database.user", "root")),
+			Password:             getEnv("DB_PASSWORD", getString(merged, "database.password", "")),
+			Database:             getEnv("DB_NAME", getString(merged, "database.database", "seo_generator")),
+			Charset:              getString(merged, "database.charset", "utf8mb4"),
+			PoolSize:             getInt(merged, "database.pool_size", 10),
+			PoolRecycle:          getInt(merged, "database.pool_recycle", 3600),
+			Replicas:             getStringSlice(merged, "database.replicas", nil),
+			ReplicaMaxLagSeconds: getInt(merged, "database.replica_max_lag_seconds", 5),
+			ReplicaCheckSeconds:  getInt(merged, "database.replica_check_seconds", 10),
 		},
 		Redis: RedisConfig{
 			Enabled:  getBoolEnv("REDIS_ENABLED", getBool(merged, "redis.enabled", false)),
@@ -144,6 +804,15 @@ func Load(configPath string) (*Config, error) {
 			TTLHours:    getInt(merged, "cache.ttl_hours", 24),
 			MaxSizeGB:   getFloat(merged, "cache.max_size_gb", 10.0),
 			GzipEnabled: getBool(merged, "cache.gzip_enabled", true),
+			Backend:     getString(merged, "cache.backend", "disk"),
+			RedisPrefix: getString(merged, "cache.redis_prefix", "html:"),
+
+			RedisStatsIntervalSeconds: getInt(merged, "cache.redis_stats_interval_seconds", 300),
+			WatchEnabled:              getBool(merged, "cache.watch_enabled", false),
+			WatchMaxDirs:              getInt(merged, "cache.watch_max_dirs", 50000),
+			DomainMaxSizeMB:           getFloat(merged, "cache.domain_max_size_mb", 0),
+			DomainMaxEntries:          getInt(merged, "cache.domain_max_entries", 0),
+			SiteSyncSeconds:           getInt(merged, "cache.site_sync_seconds", 30),
 		},
 		SpiderDetector: SpiderDetectorConfig{
 			Enabled:               getBool(merged, "spider_detector.enabled", true),
@@ -153,7 +822,422 @@ func Load(configPath string) (*Config, error) {
 			SecretKey:                getString(merged, "auth.secret_key", "default-secret-key-change-in-production"),
 			Algorithm:                getString(merged, "auth.algorithm", "HS256"),
 			AccessTokenExpireMinutes: getInt(merged, "auth.access_token_expire_minutes", 1440),
+			MaxSessions:              getInt(merged, "auth.max_sessions", 5),
+			Cookie: AuthCookieConfig{
+				Enabled:  getBool(merged, "auth.cookie.enabled", false),
+				Secure:   getBool(merged, "auth.cookie.secure", true),
+				SameSite: getString(merged, "auth.cookie.same_site", "strict"),
+				Domain:   getString(merged, "auth.cookie.domain", ""),
+			},
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:   getStringSlice(merged, "cors.allowed_origins", []string{"*"}),
+			AllowedMethods:   getStringSlice(merged, "cors.allowed_methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+			AllowedHeaders:   getStringSlice(merged, "cors.allowed_headers", []string{"Content-Type", "Authorization", "X-API-Token", "X-CSRF-Token"}),
+			AllowCredentials: getBool(merged, "cors.allow_credentials", false),
+			MaxAge:           getInt(merged, "cors.max_age", 600),
+		},
+		ClickHouse: ClickHouseConfig{
+			Enabled:     getBoolEnv("CLICKHOUSE_ENABLED", getBool(merged, "clickhouse.enabled", false)),
+			URL:         getEnv("CLICKHOUSE_URL", getString(merged, "clickhouse.url", "http://localhost:8123")),
+			Database:    getEnv("CLICKHOUSE_DATABASE", getString(merged, "clickhouse.database", "seo_generator")),
+			Table:       getString(merged, "clickhouse.table", "spider_logs"),
+			User:        getEnv("CLICKHOUSE_USER", getString(merged, "clickhouse.user", "default")),
+			Password:    getEnv("CLICKHOUSE_PASSWORD", getString(merged, "clickhouse.password", "")),
+			MirrorMySQL: getBool(merged, "clickhouse.mirror_mysql", true),
+			Timezone:    getString(merged, "clickhouse.timezone", ""),
+		},
+		TemplateBudget: TemplateBudgetConfig{
+			Enabled:        getBool(merged, "template_error_budget.enabled", true),
+			WindowSeconds:  getInt(merged, "template_error_budget.window_seconds", 300),
+			MinRenders:     getInt(merged, "template_error_budget.min_renders", 20),
+			MaxFailureRate: getFloat(merged, "template_error_budget.max_failure_rate", 0.5),
+		},
+		TemplateSandbox: TemplateSandboxConfig{
+			TimeoutMs:      getInt(merged, "template_sandbox.timeout_ms", 2000),
+			MaxIterate:     getInt(merged, "template_sandbox.max_iterate", 10000),
+			MaxCalls:       getInt(merged, "template_sandbox.max_calls", 50000),
+			MaxOutputBytes: getInt(merged, "template_sandbox.max_output_bytes", 10<<20),
+		},
+		TemplateCompile: CompileCacheConfig{
+			MaxEntries: getInt(merged, "template_compile_cache.max_entries", 500),
+			MaxMB:      getInt(merged, "template_compile_cache.max_mb", 256),
+		},
+		TemplateFuncs: TemplateFuncsConfig{
+			Packs: getStringSlice(merged, "template_funcs.packs", nil),
+		},
+		WASMExtensions: WASMExtensionsConfig{
+			Enabled:        getBool(merged, "wasm_extensions.enabled", false),
+			Dir:            getString(merged, "wasm_extensions.dir", "data/wasm"),
+			MemoryLimitMB:  getInt(merged, "wasm_extensions.memory_limit_mb", 64),
+			TimeoutMs:      getInt(merged, "wasm_extensions.timeout_ms", 100),
+			MaxOutputBytes: getInt(merged, "wasm_extensions.max_output_bytes", 1<<20),
+			MaxInstances:   getInt(merged, "wasm_extensions.max_instances", 8),
+			ReloadSeconds:  getInt(merged, "wasm_extensions.reload_seconds", 10),
+		},
+		GRPC: GRPCConfig{
+			Enabled: getBoolEnv("GRPC_ENABLED", getBool(merged, "grpc.enabled", false)),
+			Addr:    getEnv("GRPC_ADDR", getString(merged, "grpc.addr", ":9090")),
+			Token:   getEnv("GRPC_TOKEN", getString(merged, "grpc.token", "")),
+		},
+		OpenAPI: OpenAPIConfig{
+			Enabled:          getBool(merged, "openapi.enabled", true),
+			ValidateRequests: getBool(merged, "openapi.validate_requests", false),
+		},
+		IPAllowlist: IPAllowlistConfig{
+			Enabled:        getBool(merged, "ip_allowlist.enabled", false),
+			CIDRs:          getStringSlice(merged, "ip_allowlist.cidrs", nil),
+			TrustedProxies: getStringSlice(merged, "ip_allowlist.trusted_proxies", []string{"127.0.0.1/32", "::1/128"}),
+			ExemptPaths:    getStringSlice(merged, "ip_allowlist.exempt_paths", []string{"/api/log/"}),
+			BypassToken:    getEnv("ADMIN_BYPASS_TOKEN", getString(merged, "ip_allowlist.bypass_token", "")),
+		},
+		Backup: BackupConfig{
+			Passphrase: getEnv("BACKUP_PASSPHRASE", getString(merged, "backup.passphrase", "")),
+			LocalDir:   getString(merged, "backup.local_dir", "./data/backups"),
+			Keep:       getInt(merged, "backup.keep", 7),
+			Schedule:   getString(merged, "backup.schedule", "0 0 3 * * *"),
+			S3: BackupS3Config{
+				Enabled:   getBool(merged, "backup.s3.enabled", false),
+				Endpoint:  getString(merged, "backup.s3.endpoint", ""),
+				Region:    getString(merged, "backup.s3.region", "us-east-1"),
+				Bucket:    getString(merged, "backup.s3.bucket", ""),
+				Prefix:    getString(merged, "backup.s3.prefix", "backups/"),
+				AccessKey: getEnv("BACKUP_S3_ACCESS_KEY", getString(merged, "backup.s3.access_key", "")),
+				SecretKey: getEnv("BACKUP_S3_SECRET_KEY", getString(merged, "backup.s3.secret_key", "")),
+				PathStyle: getBool(merged, "backup.s3.path_style", false),
+			},
+		},
+		AccessLog: AccessLogConfig{
+			Enabled:          getBool(merged, "access_log.enabled", false),
+			Output:           getString(merged, "access_log.output", "file"),
+			FilePath:         getString(merged, "access_log.file_path", "logs/access.log"),
+			MaxSizeMB:        getInt(merged, "access_log.max_size_mb", 200),
+			MaxBackups:       getInt(merged, "access_log.max_backups", 14),
+			MaxAgeDays:       getInt(merged, "access_log.max_age_days", 14),
+			Compress:         getBool(merged, "access_log.compress", true),
+			RotateInterval:   getString(merged, "access_log.rotate_interval", "daily"),
+			SampleRate:       getFloat(merged, "access_log.sample_rate", 0.1),
+			SpiderSampleRate: getFloat(merged, "access_log.spider_sample_rate", 1),
+			SkipPaths:        getStringSlice(merged, "access_log.skip_paths", []string{"/health", "/ws/"}),
+		},
+		ErrorReporting: ErrorReportingConfig{
+			Enabled:            getBool(merged, "error_reporting.enabled", false),
+			DSN:                getEnv("ERROR_REPORTING_DSN", getString(merged, "error_reporting.dsn", "")),
+			WebhookURL:         getEnv("ERROR_REPORTING_WEBHOOK_URL", getString(merged, "error_reporting.webhook_url", "")),
+			Environment:        getEnv("ERROR_REPORTING_ENVIRONMENT", getString(merged, "error_reporting.environment", "")),
+			Release:            getEnv("ERROR_REPORTING_RELEASE", getString(merged, "error_reporting.release", "")),
+			PanicSampleRate:    getFloat(merged, "error_reporting.panic_sample_rate", 1),
+			ErrorSampleRate:    getFloat(merged, "error_reporting.error_sample_rate", 1),
+			DedupWindowSeconds: getInt(merged, "error_reporting.dedup_window_seconds", 60),
+			QueueSize:          getInt(merged, "error_reporting.queue_size", 100),
+			TimeoutSeconds:     getInt(merged, "error_reporting.timeout_seconds", 5),
+		},
+		KeywordFeedback: KeywordFeedbackConfig{
+			Enabled:                getBool(merged, "keyword_feedback.enabled", false),
+			WindowDays:             getInt(merged, "keyword_feedback.window_days", 7),
+			RetentionDays:          getInt(merged, "keyword_feedback.retention_days", 30),
+			ComputeIntervalMinutes: getInt(merged, "keyword_feedback.compute_interval_minutes", 60),
+			QueueSize:              getInt(merged, "keyword_feedback.queue_size", 10000),
+			BatchSize:              getInt(merged, "keyword_feedback.batch_size", 500),
+			WeightedSelection:      getBool(merged, "keyword_feedback.weighted_selection", false),
+			BoostRatio:             getFloat(merged, "keyword_feedback.boost_ratio", 0.3),
+			BoostTopN:              getInt(merged, "keyword_feedback.boost_top_n", 1000),
+		},
+		KeywordImport: func() KeywordImportConfig {
+			c := KeywordImportConfig{
+				Schedule:       getString(merged, "keyword_import.schedule", ""),
+				LookbackDays:   getInt(merged, "keyword_import.lookback_days", 7),
+				MinImpressions: getInt(merged, "keyword_import.min_impressions", 10),
+				MaxRows:        getInt(merged, "keyword_import.max_rows", 5000),
+				TimeoutSeconds: getInt(merged, "keyword_import.timeout_seconds", 30),
+			}
+			c.BaiduTongji.AccessToken = getEnv("BAIDU_TONGJI_ACCESS_TOKEN", getString(merged, "keyword_import.baidu_tongji.access_token", ""))
+			c.BaiduTongji.SiteIDs = getStringSlice(merged, "keyword_import.baidu_tongji.site_ids", nil)
+			c.GSC.CredentialsFile = getEnv("GSC_CREDENTIALS_FILE", getString(merged, "keyword_import.gsc.credentials_file", ""))
+			c.GSC.SiteURLs = getStringSlice(merged, "keyword_import.gsc.site_urls", nil)
+			return c
+		}(),
+		KeywordExpand: KeywordExpandConfig{
+			Sources:           getStringSlice(merged, "keyword_expand.sources", []string{"baidu", "so360", "sogou"}),
+			RequestsPerSecond: getFloat(merged, "keyword_expand.requests_per_second", 2),
+			TimeoutSeconds:    getInt(merged, "keyword_expand.timeout_seconds", 10),
+			MaxDepth:          getInt(merged, "keyword_expand.max_depth", 3),
+			MaxResults:        getInt(merged, "keyword_expand.max_results", 20000),
+			UserAgent:         getString(merged, "keyword_expand.user_agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"),
+		},
+		URLStrategy: URLStrategyConfig{
+			Default:         getString(merged, "url_strategy.default", "random"),
+			URLSpace:        getInt(merged, "url_strategy.url_space", 100000),
+			SlugSeparator:   getString(merged, "url_strategy.slug_separator", "-"),
+			SlugMaxLen:      getInt(merged, "url_strategy.slug_max_len", 60),
+			DateWindowDays:  getInt(merged, "url_strategy.date_window_days", 365),
+			SitemapPageSize: getInt(merged, "url_strategy.sitemap_page_size", 5000),
+			SitemapScheme:   getString(merged, "url_strategy.sitemap_scheme", "http"),
+		},
+		Archive: ArchiveConfig{
+			Enabled:    getBool(merged, "archive.enabled", false),
+			Prefix:     getString(merged, "archive.prefix", "/list/"),
+			Categories: getInt(merged, "archive.categories", 20),
+			PageSize:   getInt(merged, "archive.page_size", 30),
+			MaxPages:   getInt(merged, "archive.max_pages", 50),
+			Template:   getString(merged, "archive.template", ""),
+			Sitemap:    getBool(merged, "archive.sitemap", true),
+		},
+		PoolUpdates: PoolUpdatesConfig{
+			MaxBatch:        getInt(merged, "pool_updates.max_batch", 500),
+			FlushIntervalMs: getInt(merged, "pool_updates.flush_interval_ms", 1000),
+			JournalPath:     getString(merged, "pool_updates.journal_path", "data/pool_journal.log"),
+		},
+		Segmenter: SegmenterConfig{
+			BuiltinDict: getBool(merged, "segmenter.builtin_dict", true),
+			DictFiles:   getStringSlice(merged, "segmenter.dict_files", nil),
+			DefaultFreq: getInt(merged, "segmenter.default_freq", 1000),
+		},
+		Spintax: SpintaxConfig{
+			Enabled:      getBool(merged, "spintax.enabled", false),
+			SynonymFiles: getStringSlice(merged, "spintax.synonym_files", nil),
+			Ratio:        getFloat(merged, "spintax.ratio", 0.5),
+		},
+		PinyinSlug: PinyinSlugConfig{
+			Tone:            getString(merged, "pinyin_slug.tone", "none"),
+			Separator:       getString(merged, "pinyin_slug.separator", "-"),
+			MaxLen:          getInt(merged, "pinyin_slug.max_len", 60),
+			ByWord:          getBool(merged, "pinyin_slug.by_word", true),
+			Umlaut:          getString(merged, "pinyin_slug.umlaut", "v"),
+			CacheMaxEntries: getInt(merged, "pinyin_slug.cache_max_entries", 2000000),
+		},
+		PublishDate: PublishDateConfig{
+			RangeDays:      getInt(merged, "publish_date.range_days", 365),
+			WeekdayWeights: getString(merged, "publish_date.weekday_weights", "1,1,1,1,1,0.4,0.3"),
+			Recency:        getString(merged, "publish_date.recency", "exponential"),
+			HalfLifeDays:   getInt(merged, "publish_date.half_life_days", 60),
+			HourStart:      getInt(merged, "publish_date.hour_start", 8),
+			HourEnd:        getInt(merged, "publish_date.hour_end", 23),
+		},
+		AutoTDK: AutoTDKConfig{
+			DescriptionLen: getInt(merged, "auto_tdk.description_len", 120),
+			MaxKeywords:    getInt(merged, "auto_tdk.max_keywords", 5),
+		},
+		CSSObfuscation: CSSObfuscationConfig{
+			Rename:    getBool(merged, "css_obfuscation.rename", true),
+			Shuffle:   getBool(merged, "css_obfuscation.shuffle", true),
+			JunkRules: getInt(merged, "css_obfuscation.junk_rules", 6),
+			Whitelist: getStringSlice(merged, "css_obfuscation.whitelist", []string{"js-*", "active", "show", "hide"}),
 		},
+		ContentArchive: ContentArchiveConfig{
+			AfterDays:   getInt(merged, "content_archive.after_days", 30),
+			BatchRows:   getInt(merged, "content_archive.batch_rows", 50000),
+			DeleteChunk: getInt(merged, "content_archive.delete_chunk", 1000),
+			MaxBatches:  getInt(merged, "content_archive.max_batches", 20),
+			Schedule:    getString(merged, "content_archive.schedule", ""),
+			LocalDir:    getString(merged, "content_archive.local_dir", "./data/archives"),
+			S3: BackupS3Config{
+				Enabled:   getBool(merged, "content_archive.s3.enabled", false),
+				Endpoint:  getString(merged, "content_archive.s3.endpoint", ""),
+				Region:    getString(merged, "content_archive.s3.region", "us-east-1"),
+				Bucket:    getString(merged, "content_archive.s3.bucket", ""),
+				Prefix:    getString(merged, "content_archive.s3.prefix", "archives/contents/"),
+				AccessKey: getEnv("CONTENT_ARCHIVE_S3_ACCESS_KEY", getString(merged, "content_archive.s3.access_key", "")),
+				SecretKey: getEnv("CONTENT_ARCHIVE_S3_SECRET_KEY", getString(merged, "content_archive.s3.secret_key", "")),
+				PathStyle: getBool(merged, "content_archive.s3.path_style", false),
+			},
+		},
+		SpiderRobots: SpiderRobotsConfig{
+			UserAgent:    getString(merged, "spider_robots.user_agent", "SEOSpider"),
+			CacheTTL:     getInt(merged, "spider_robots.cache_ttl", 3600),
+			ErrorTTL:     getInt(merged, "spider_robots.error_ttl", 300),
+			FetchTimeout: getInt(merged, "spider_robots.fetch_timeout", 10),
+			MaxURLs:      getInt(merged, "spider_robots.max_urls", 500),
+		},
+		SpiderOutput: SpiderOutputConfig{
+			KeepItems: getInt(merged, "spider_output.keep_items", 50),
+			TTLHours:  getInt(merged, "spider_output.ttl_hours", 168),
+		},
+		Freshness: FreshnessConfig{
+			Schedule:     getString(merged, "freshness.schedule", "0 0 5 * * *"),
+			LookbackDays: getInt(merged, "freshness.lookback_days", 7),
+			HistoryDays:  getInt(merged, "freshness.history_days", 90),
+			DelayMs:      getInt(merged, "freshness.delay_ms", 20),
+		},
+		DomainMonitor: DomainMonitorConfig{
+			Enabled:          getBool(merged, "domain_monitor.enabled", true),
+			Interval:         getInt(merged, "domain_monitor.interval", 900),
+			Concurrency:      getInt(merged, "domain_monitor.concurrency", 8),
+			Timeout:          getInt(merged, "domain_monitor.timeout", 10),
+			Scheme:           getString(merged, "domain_monitor.scheme", "https"),
+			EdgeAddr:         getString(merged, "domain_monitor.edge_addr", ""),
+			UserAgent:        getString(merged, "domain_monitor.user_agent", "SEOGenerator-DomainMonitor/1.0"),
+			CertWarnDays:     getInt(merged, "domain_monitor.cert_warn_days", 14),
+			FailureThreshold: getInt(merged, "domain_monitor.failure_threshold", 3),
+			HistoryDays:      getInt(merged, "domain_monitor.history_days", 30),
+		},
+		WebSocket: WebSocketConfig{
+			RequireTicket:    getBool(merged, "websocket.require_ticket", true),
+			TicketTTLSeconds: getInt(merged, "websocket.ticket_ttl_seconds", 30),
+			MaxConnections:   getInt(merged, "websocket.max_connections", 500),
+			MaxPerUser:       getInt(merged, "websocket.max_per_user", 20),
+		},
+		SystemMetrics: SystemMetricsConfig{
+			Enabled:              getBool(merged, "system_metrics.enabled", true),
+			MinuteRetentionDays:  getInt(merged, "system_metrics.minute_retention_days", 2),
+			FiveMinRetentionDays: getInt(merged, "system_metrics.five_min_retention_days", 14),
+			HourRetentionDays:    getInt(merged, "system_metrics.hour_retention_days", 365),
+		},
+		Alerting: AlertingConfig{
+			WebhookURL:     getString(merged, "alerting.webhook_url", ""),
+			TimeoutSeconds: getInt(merged, "alerting.timeout_seconds", 5),
+		},
+		Canary: CanaryConfig{
+			Enabled:          getBool(merged, "canary.enabled", true),
+			Interval:         getInt(merged, "canary.interval", 60),
+			Path:             getString(merged, "canary.path", "/canary-check.html"),
+			Timeout:          getInt(merged, "canary.timeout", 10),
+			MaxLatencyMs:     getInt(merged, "canary.max_latency_ms", 3000),
+			MinBytes:         getInt(merged, "canary.min_bytes", 1024),
+			Concurrency:      getInt(merged, "canary.concurrency", 4),
+			FailureThreshold: getInt(merged, "canary.failure_threshold", 3),
+			HistoryDays:      getInt(merged, "canary.history_days", 7),
+		},
+		ActivityStats: ActivityStatsConfig{
+			Schedule:     getString(merged, "activity_stats.schedule", "0 20 0 * * *"),
+			BackfillDays: getInt(merged, "activity_stats.backfill_days", 30),
+		},
+		LinkAudit: LinkAuditConfig{
+			Enabled:      getBool(merged, "link_audit.enabled", false),
+			Rel:          getString(merged, "link_audit.rel", "nofollow"),
+			MaxExternal:  getInt(merged, "link_audit.max_external", 0),
+			DropExcess:   getBool(merged, "link_audit.drop_excess", false),
+			AllowDomains: getStringSlice(merged, "link_audit.allow_domains", nil),
+		},
+		OutboundHTTP: OutboundHTTPConfig{
+			TimeoutSeconds:     getInt(merged, "outbound_http.timeout_seconds", 15),
+			MaxRetries:         getInt(merged, "outbound_http.max_retries", 2),
+			RetryBaseMs:        getInt(merged, "outbound_http.retry_base_ms", 200),
+			RetryMaxMs:         getInt(merged, "outbound_http.retry_max_ms", 5000),
+			BreakerFailures:    getInt(merged, "outbound_http.breaker_failures", 5),
+			BreakerOpenSeconds: getInt(merged, "outbound_http.breaker_open_seconds", 30),
+			RateLimit:          getFloat(merged, "outbound_http.rate_limit", 0),
+			RateBurst:          getInt(merged, "outbound_http.rate_burst", 20),
+			Proxy:              getString(merged, "outbound_http.proxy", ""),
+		},
+		EgressAudit: EgressAuditConfig{
+			Enabled:       getBool(merged, "egress_audit.enabled", true),
+			RetentionDays: getInt(merged, "egress_audit.retention_days", 90),
+			BufferSize:    getInt(merged, "egress_audit.buffer_size", 10000),
+			BatchSize:     getInt(merged, "egress_audit.batch_size", 200),
+			FlushSeconds:  getInt(merged, "egress_audit.flush_seconds", 5),
+		},
+		QueryCache: QueryCacheConfig{
+			Enabled:    getBool(merged, "query_cache.enabled", true),
+			TTLSeconds: getInt(merged, "query_cache.ttl_seconds", 60),
+			MaxEntries: getInt(merged, "query_cache.max_entries", 2000),
+		},
+		Bootstrap: BootstrapConfig{
+			Manifest: getString(merged, "bootstrap.manifest", "data/bootstrap/seed.yaml"),
+		},
+		WorkerFiles: WorkerFilesConfig{
+			Root:          getString(merged, "content_worker_files.root", "/project/content_worker"),
+			MaxFileSizeMB: getInt(merged, "content_worker_files.max_file_size_mb", 10),
+		},
+		Trash: TrashConfig{
+			RetentionDays: getInt(merged, "trash.retention_days", 7),
+		},
+		PythonCheck: PythonCheckConfig{
+			Enabled:        getBool(merged, "python_check.enabled", true),
+			Mode:           getString(merged, "python_check.mode", "warn"),
+			Command:        getStringSlice(merged, "python_check.command", []string{"docker", "exec", "-i", "seo-generator-worker", "python3"}),
+			TimeoutSeconds: getInt(merged, "python_check.timeout_seconds", 5),
+		},
+		SpiderStats: SpiderStatsConfig{
+			MinuteRetentionDays: getInt(merged, "spider_stats.minute_retention_days", 7),
+			HourRetentionDays:   getInt(merged, "spider_stats.hour_retention_days", 30),
+		},
+		KeywordFilter: KeywordFilterConfig{
+			Enabled:     getBool(merged, "keyword_filter.enabled", true),
+			MinLength:   getInt(merged, "keyword_filter.min_length", 2),
+			MaxLength:   getInt(merged, "keyword_filter.max_length", 50),
+			BanPatterns: getStringSlice(merged, "keyword_filter.ban_patterns", []string{`(?i)^(https?://|www\.)`, `^\+?\d[\d\- ]{6,}\d$`}),
+			Stopwords:   getStringSlice(merged, "keyword_filter.stopwords", []string{}),
+		},
+		ImageSigning: ImageSigningConfig{
+			Enabled:          getBool(merged, "image_signing.enabled", false),
+			ClockSkewSeconds: getInt(merged, "image_signing.clock_skew_seconds", 300),
+			Groups:           getImageSigningGroups(merged, "image_signing.groups"),
+		},
+		Excerpt: ExcerptConfig{
+			Enabled:   getBool(merged, "excerpt.enabled", true),
+			Strategy:  getString(merged, "excerpt.strategy", "sentences"),
+			Sentences: getInt(merged, "excerpt.sentences", 2),
+			MinLength: getInt(merged, "excerpt.min_length", 30),
+			MaxLength: getInt(merged, "excerpt.max_length", 160),
+			Pattern:   getString(merged, "excerpt.pattern", ""),
+			Schedule:  getString(merged, "excerpt.schedule", "0 */30 * * * *"),
+			BatchSize: getInt(merged, "excerpt.batch_size", 500),
+		},
+		LLM: LLMConfig{
+			Enabled:              getBool(merged, "llm.enabled", false),
+			Endpoint:             getString(merged, "llm.endpoint", "https://api.openai.com/v1"),
+			APIKey:               getEnv("LLM_API_KEY", getString(merged, "llm.api_key", "")),
+			Model:                getString(merged, "llm.model", "gpt-4o-mini"),
+			Temperature:          getFloat(merged, "llm.temperature", 0.7),
+			TimeoutSeconds:       getInt(merged, "llm.timeout_seconds", 60),
+			MaxConcurrency:       getInt(merged, "llm.max_concurrency", 2),
+			DailyRequestBudget:   getInt(merged, "llm.daily_request_budget", 2000),
+			DailyTokenBudget:     getInt(merged, "llm.daily_token_budget", 0),
+			PromptPricePer1K:     getFloat(merged, "llm.prompt_price_per_1k", 0),
+			CompletionPricePer1K: getFloat(merged, "llm.completion_price_per_1k", 0),
+			MaxContentLength:     getInt(merged, "llm.max_content_length", 6000),
+			TitlePrompt:          getString(merged, "llm.title_prompt", "改写用户给出的文章标题，保持原意，不加引号和解释，只输出新标题。"),
+			ContentPrompt:        getString(merged, "llm.content_prompt", "改写用户给出的文章正文，保持原意和段落划分（段落之间用换行分隔），不加解释，只输出改写后的正文。"),
+		},
+		BodyLimits: BodyLimitsConfig{
+			DefaultMB: getInt(merged, "body_limits.default_mb", 32),
+			Routes: getIntMap(merged, "body_limits.routes", map[string]int{
+				"POST /api/articles/batch":    64,
+				"POST /api/keywords/batch":    16,
+				"POST /api/images/urls/batch": 16,
+				// 文件上传、备份恢复和蜘蛛日志批量上报由 handler 自行限制
+				"POST /api/keywords/upload":             0,
+				"POST /api/images/upload":               0,
+				"POST /api/admin/restore":               0,
+				"POST /api/log/spider/batch":            0,
+				"POST /api/content-worker/upload/*path": 0,
+			}),
+		},
+		AntiScrape: AntiScrapeConfig{
+			Enabled:               getBool(merged, "anti_scrape.enabled", false),
+			WindowSeconds:         getInt(merged, "anti_scrape.window_seconds", 60),
+			ChallengeThreshold:    getInt(merged, "anti_scrape.challenge_threshold", 30),
+			TarpitThreshold:       getInt(merged, "anti_scrape.tarpit_threshold", 120),
+			BlockThreshold:        getInt(merged, "anti_scrape.block_threshold", 300),
+			MaxUnsolvedChallenges: getInt(merged, "anti_scrape.max_unsolved_challenges", 10),
+			TarpitDelayMs:         getInt(merged, "anti_scrape.tarpit_delay_ms", 3000),
+			MaxTarpitted:          getInt(merged, "anti_scrape.max_tarpitted", 200),
+			BlockSeconds:          getInt(merged, "anti_scrape.block_seconds", 3600),
+			CookieName:            getString(merged, "anti_scrape.cookie_name", "_sgc"),
+			CookieTTLSeconds:      getInt(merged, "anti_scrape.cookie_ttl_seconds", 86400),
+			Secret:                getEnv("ANTI_SCRAPE_SECRET", getString(merged, "anti_scrape.secret", "")),
+			HoneypotPrefix:        getString(merged, "anti_scrape.honeypot_prefix", "/__hp/"),
+			HoneypotAmnestyHours:  getInt(merged, "anti_scrape.honeypot_amnesty_hours", 24),
+			RobotsTxt:             getString(merged, "anti_scrape.robots_txt", ""),
+			Allowlist:             getStringSlice(merged, "anti_scrape.allowlist", nil),
+		},
+		LoginGuard: LoginGuardConfig{
+			Enabled:               getBool(merged, "login_guard.enabled", true),
+			WindowSeconds:         getInt(merged, "login_guard.window_seconds", 900),
+			MaxFailuresPerIP:      getInt(merged, "login_guard.max_failures_per_ip", 20),
+			MaxFailuresPerUser:    getInt(merged, "login_guard.max_failures_per_user", 5),
+			LockoutBaseSeconds:    getInt(merged, "login_guard.lockout_base_seconds", 60),
+			LockoutMaxSeconds:     getInt(merged, "login_guard.lockout_max_seconds", 3600),
+			UserBackoffMaxSeconds: getInt(merged, "login_guard.user_backoff_max_seconds", 30),
+			SprayDistinctUsers:    getInt(merged, "login_guard.spray_distinct_users", 10),
+			DistributedIPs:        getInt(merged, "login_guard.distributed_ips", 10),
+			GlobalFailures:        getInt(merged, "login_guard.global_failures", 100),
+		},
+	}
+
+	if cfg.ErrorReporting.Environment == "" {
+		cfg.ErrorReporting.Environment = envName
 	}
 
 	globalConfig = cfg
@@ -281,6 +1365,61 @@ func getFloat(m map[string]interface{}, path string, defaultVal float64) float64
 	return defaultVal
 }
 
+func getStringSlice(m map[string]interface{}, path string, defaultVal []string) []string {
+	if v := getNestedValue(m, path); v != nil {
+		if items, ok := v.([]interface{}); ok {
+			result := make([]string, 0, len(items))
+			for _, item := range items {
+				if s, ok := item.(string); ok {
+					result = append(result, s)
+				}
+			}
+			return result
+		}
+	}
+	return defaultVal
+}
+
+// getIntMap 解析 map 配置，配置中的键覆盖 defaults 中的同名键
+func getIntMap(m map[string]interface{}, path string, defaults map[string]int) map[string]int {
+	result := make(map[string]int, len(defaults))
+	for k, v := range defaults {
+		result[k] = v
+	}
+	if items, ok := getNestedValue(m, path).(map[string]interface{}); ok {
+		for k, v := range items {
+			switch val := v.(type) {
+			case int:
+				result[k] = val
+			case float64:
+				result[k] = int(val)
+			}
+		}
+	}
+	return result
+}
+
+// getImageSigningGroups 解析 image_signing.groups 列表，密钥支持 IMAGE_SIGNING_SECRET_<group_id> 环境变量覆盖
+func getImageSigningGroups(m map[string]interface{}, path string) []ImageSigningGroup {
+	items, _ := getNestedValue(m, path).([]interface{})
+	groups := make([]ImageSigningGroup, 0, len(items))
+	for _, item := range items {
+		g, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		groupID := getInt(g, "group_id", 0)
+		groups = append(groups, ImageSigningGroup{
+			GroupID:    groupID,
+			Secret:     getEnv("IMAGE_SIGNING_SECRET_"+strconv.Itoa(groupID), getString(g, "secret", "")),
+			Algorithm:  getString(g, "algorithm", "hmac_sha256"),
+			TTLSeconds: getInt(g, "ttl_seconds", 3600),
+			Param:      getString(g, "param", ""),
+		})
+	}
+	return groups
+}
+
 func getBool(m map[string]interface{}, path string, defaultVal bool) bool {
 	if v := getNestedValue(m, path); v != nil {
 		if b, ok := v.(bool); ok {
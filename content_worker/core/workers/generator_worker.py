@@ -333,13 +333,14 @@ class GeneratorWorker:
         except Exception as e:
             logger.error(f"Failed to flush title buffer: {e}")
 
-    async def save_content(self, content: str, group_id: int) -> bool:
+    async def save_content(self, content: str, group_id: int, article_id: Optional[int] = None) -> bool:
         """
         保存正文到 contents 表
 
         Args:
             content: 正文文本（已标注拼音）
             group_id: 分组ID
+            article_id: 来源文章ID（正文池补充时据此带出文章摘要）
 
         Returns:
             是否保存成功
@@ -350,7 +351,8 @@ class GeneratorWorker:
         # 添加到缓冲区
         self._content_buffer.append({
             'content': content.strip(),
-            'group_id': group_id
+            'group_id': group_id,
+            'article_id': article_id or None
         })
 
         # 检查是否需要刷新
@@ -366,12 +368,12 @@ class GeneratorWorker:
 
         try:
             # 按 group_id 分组
-            groups: Dict[int, List[str]] = {}
+            groups: Dict[int, List[Dict[str, Any]]] = {}
             for item in self._content_buffer:
                 gid = item['group_id']
                 if gid not in groups:
                     groups[gid] = []
-                groups[gid].append(item['content'])
+                groups[gid].append(item)
 
             count = 0
             inserted_ids: Dict[int, List[int]] = {}  # group_id -> [content_ids]
@@ -383,13 +385,13 @@ class GeneratorWorker:
                         inserted_ids[group_id] = []
 
                         # 逐条插入以获取 ID
-                        for content in contents:
+                        for item in contents:
                             await cursor.execute(
                                 """
-                                INSERT INTO contents (content, group_id, batch_id)
-                                VALUES (%s, %s, %s)
+                                INSERT INTO contents (content, group_id, article_id, batch_id)
+                                VALUES (%s, %s, %s, %s)
                                 """,
-                                (content, group_id, batch_id)
+                                (item['content'], group_id, item['article_id'], batch_id)
                             )
                             inserted_ids[group_id].append(cursor.lastrowid)
                             count += 1
@@ -443,7 +445,7 @@ class GeneratorWorker:
                     # 拼音标注
                     annotated = self.annotator.annotate(para)
                     # 保存到 contents 表
-                    await self.save_content(annotated, group_id)
+                    await self.save_content(annotated, group_id, article_id)
                     paragraph_count += 1
 
             self._processed_count += 1
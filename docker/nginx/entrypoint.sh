@@ -4,6 +4,7 @@ set -e
 PAGE_PORT=${PAGE_PORT:-8009}
 ADMIN_PORT=${ADMIN_PORT:-8008}
 API_PORT=${API_PORT:-8080}
+ROBOTS_FROM_GO=${ROBOTS_FROM_GO:-true}
 
 # 从模板生成 nginx.conf
 sed "s/\${PAGE_PORT}/$PAGE_PORT/g; s/\${ADMIN_PORT}/$ADMIN_PORT/g; s/\${API_PORT}/$API_PORT/g" \
@@ -13,7 +14,7 @@ sed "s/\${PAGE_PORT}/$PAGE_PORT/g; s/\${ADMIN_PORT}/$ADMIN_PORT/g; s/\${API_PORT
 for f in /etc/nginx/templates/conf.d/*.template; do
   [ -f "$f" ] || continue
   filename=$(basename "$f" .template)
-  sed "s/\${PAGE_PORT}/$PAGE_PORT/g; s/\${ADMIN_PORT}/$ADMIN_PORT/g; s/\${API_PORT}/$API_PORT/g" \
+  sed "s/\${PAGE_PORT}/$PAGE_PORT/g; s/\${ADMIN_PORT}/$ADMIN_PORT/g; s/\${API_PORT}/$API_PORT/g; s/\${ROBOTS_FROM_GO}/$ROBOTS_FROM_GO/g" \
     "$f" > /etc/nginx/conf.d/"$filename"
 done
 
@@ -11,6 +11,81 @@ CREATE DATABASE IF NOT EXISTS seo_generator
 
 USE seo_generator;
 
+-- ============================================
+-- 升级辅助过程
+-- 本脚本可重复执行：新库按建表语句创建，已有库由文件末尾「结构升级」一节补齐新增的列和索引
+-- 给已有表新增列或索引时，同时修改建表语句并在「结构升级」中添加对应的 CALL
+-- ============================================
+DROP PROCEDURE IF EXISTS seo_add_column;
+DROP PROCEDURE IF EXISTS seo_add_index;
+DROP PROCEDURE IF EXISTS seo_drop_index;
+DROP PROCEDURE IF EXISTS seo_drop_column;
+DROP PROCEDURE IF EXISTS seo_exec_if_column;
+
+DELIMITER $$
+
+-- 列不存在时执行 ALTER TABLE tbl ADD COLUMN col definition
+CREATE PROCEDURE seo_add_column(IN tbl VARCHAR(64), IN col VARCHAR(64), IN definition TEXT)
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.COLUMNS
+                   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = tbl AND COLUMN_NAME = col) THEN
+        SET @seo_ddl = CONCAT('ALTER TABLE `', tbl, '` ADD COLUMN `', col, '` ', definition);
+        PREPARE seo_stmt FROM @seo_ddl;
+        EXECUTE seo_stmt;
+        DEALLOCATE PREPARE seo_stmt;
+    END IF;
+END$$
+
+-- 索引不存在时执行 ALTER TABLE tbl ADD definition（definition 如 'UNIQUE INDEX idx_x (a, b)'）
+CREATE PROCEDURE seo_add_index(IN tbl VARCHAR(64), IN idx VARCHAR(64), IN definition TEXT)
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.STATISTICS
+                   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = tbl AND INDEX_NAME = idx) THEN
+        SET @seo_ddl = CONCAT('ALTER TABLE `', tbl, '` ADD ', definition);
+        PREPARE seo_stmt FROM @seo_ddl;
+        EXECUTE seo_stmt;
+        DEALLOCATE PREPARE seo_stmt;
+    END IF;
+END$$
+
+-- 索引存在时删除（用于替换定义变化的索引，新索引须使用新名称）
+CREATE PROCEDURE seo_drop_index(IN tbl VARCHAR(64), IN idx VARCHAR(64))
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.STATISTICS
+               WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = tbl AND INDEX_NAME = idx) THEN
+        SET @seo_ddl = CONCAT('ALTER TABLE `', tbl, '` DROP INDEX `', idx, '`');
+        PREPARE seo_stmt FROM @seo_ddl;
+        EXECUTE seo_stmt;
+        DEALLOCATE PREPARE seo_stmt;
+    END IF;
+END$$
+
+-- 列存在时删除
+CREATE PROCEDURE seo_drop_column(IN tbl VARCHAR(64), IN col VARCHAR(64))
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.COLUMNS
+               WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = tbl AND COLUMN_NAME = col) THEN
+        SET @seo_ddl = CONCAT('ALTER TABLE `', tbl, '` DROP COLUMN `', col, '`');
+        PREPARE seo_stmt FROM @seo_ddl;
+        EXECUTE seo_stmt;
+        DEALLOCATE PREPARE seo_stmt;
+    END IF;
+END$$
+
+-- 列存在时执行 stmt（迁移旧列中的数据，之后再删除旧列）
+CREATE PROCEDURE seo_exec_if_column(IN tbl VARCHAR(64), IN col VARCHAR(64), IN stmt TEXT)
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.COLUMNS
+               WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = tbl AND COLUMN_NAME = col) THEN
+        SET @seo_ddl = stmt;
+        PREPARE seo_stmt FROM @seo_ddl;
+        EXECUTE seo_stmt;
+        DEALLOCATE PREPARE seo_stmt;
+    END IF;
+END$$
+
+DELIMITER ;
+
 -- ============================================
 -- 站群表（顶层管理单元）
 -- ============================================
@@ -19,6 +94,7 @@ CREATE TABLE IF NOT EXISTS site_groups (
     name VARCHAR(100) NOT NULL UNIQUE COMMENT '站群名称',
     description VARCHAR(500) DEFAULT NULL COMMENT '站群描述',
     is_default TINYINT DEFAULT 0 COMMENT '是否默认站群',
+    fallback_template VARCHAR(100) DEFAULT NULL COMMENT '备用模板名（模板降级时使用）',
     status TINYINT DEFAULT 1 COMMENT '状态: 1=启用, 0=禁用',
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
@@ -42,13 +118,19 @@ CREATE TABLE IF NOT EXISTS sites (
     icp_number VARCHAR(50) DEFAULT NULL COMMENT 'ICP备案号',
     baidu_token VARCHAR(100) DEFAULT NULL COMMENT '百度推送Token',
     analytics TEXT DEFAULT NULL COMMENT '统计代码',
+    cache_max_size_mb INT DEFAULT NULL COMMENT '页面缓存大小上限(MB)，NULL=使用全局默认，0=不限制',
+    cache_max_entries INT DEFAULT NULL COMMENT '页面缓存条数上限，NULL=使用全局默认，0=不限制',
+    human_policy VARCHAR(20) DEFAULT NULL COMMENT '非蜘蛛访问策略: redirect/page/content/block，NULL=按全局配置',
+    human_target_url VARCHAR(500) DEFAULT NULL COMMENT 'redirect 策略的跳转地址',
+    version INT DEFAULT 1 COMMENT '版本号（每次保存+1，用于并发编辑检测）',
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     INDEX idx_site_group (site_group_id),
     INDEX idx_status (status),
     INDEX idx_keyword_group (keyword_group_id),
     INDEX idx_image_group (image_group_id),
-    INDEX idx_article_group (article_group_id)
+    INDEX idx_article_group (article_group_id),
+    INDEX idx_updated_at (updated_at)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='站点表';
 
 -- ============================================
@@ -137,13 +219,21 @@ CREATE TABLE IF NOT EXISTS original_articles (
     source_url VARCHAR(500) NULL COMMENT '来源URL，爬虫抓取的原始页面URL',
     title VARCHAR(500) NOT NULL COMMENT '标题',
     content MEDIUMTEXT NOT NULL COMMENT '正文',
+    content_hash CHAR(32) DEFAULT NULL COMMENT '正文 MD5（按正文判断重复）',
+    summary VARCHAR(500) DEFAULT NULL COMMENT '摘要（纯文本，NULL 表示待回填）',
+    rewritten_at DATETIME DEFAULT NULL COMMENT 'LLM 改写时间',
+    tags VARCHAR(1000) DEFAULT NULL COMMENT '标签（逗号分隔，字段映射生成）',
     status TINYINT DEFAULT 1 COMMENT '状态: 1=可用, 0=已删除',
+    version INT NOT NULL DEFAULT 1 COMMENT '版本号（version 冲突策略插入新版本时递增）',
+    previous_id INT UNSIGNED DEFAULT NULL COMMENT '上一版本文章ID',
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     INDEX idx_group (group_id),
     INDEX idx_group_status (group_id, status),
     INDEX idx_source_id (source_id),
-    UNIQUE INDEX idx_group_title (group_id, title(255))
+    INDEX idx_group_source_url (group_id, source_url(191)),
+    UNIQUE INDEX idx_group_content_hash (group_id, content_hash),
+    UNIQUE INDEX idx_group_title_version (group_id, title(255), version)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='原始文章表（爬虫抓取 + 手工上传）';
 
 -- ============================================
@@ -167,6 +257,7 @@ CREATE TABLE IF NOT EXISTS titles (
 CREATE TABLE IF NOT EXISTS contents (
     id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
     group_id INT NOT NULL DEFAULT 1 COMMENT '所属分组ID',
+    article_id INT UNSIGNED DEFAULT NULL COMMENT '来源文章ID（补充正文池时取 original_articles.summary）',
     content MEDIUMTEXT NOT NULL COMMENT '已生成的完整正文（含拼音标注）',
     batch_id INT DEFAULT 0 COMMENT '批次号（用于优先最新）',
     status TINYINT DEFAULT 1 COMMENT '状态: 1=可用, 0=已使用',
@@ -187,10 +278,19 @@ CREATE TABLE IF NOT EXISTS templates (
     content MEDIUMTEXT NOT NULL COMMENT 'HTML模板内容',
     status TINYINT DEFAULT 1 COMMENT '状态: 1=启用, 0=禁用',
     version INT DEFAULT 1 COMMENT '版本号（每次保存+1）',
+    degraded TINYINT DEFAULT 0 COMMENT '是否因渲染失败率超限被降级: 1=已降级',
+    degraded_at DATETIME DEFAULT NULL COMMENT '降级时间',
+    degraded_reason VARCHAR(255) DEFAULT NULL COMMENT '降级原因',
+    category VARCHAR(50) DEFAULT NULL COMMENT '模板分类',
+    engine VARCHAR(20) DEFAULT NULL COMMENT '目标搜索引擎: baidu/sogou/360/shenma/google/bing，空表示通用',
+    render_count BIGINT UNSIGNED DEFAULT 0 COMMENT '累计渲染次数',
+    last_used_at DATETIME DEFAULT NULL COMMENT '最近一次渲染时间',
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     INDEX idx_site_group (site_group_id),
     INDEX idx_status (status),
+    INDEX idx_category (category),
+    INDEX idx_last_used (last_used_at),
     UNIQUE INDEX idx_site_group_name (site_group_id, name)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='模板表';
 
@@ -202,6 +302,7 @@ CREATE TABLE IF NOT EXISTS admins (
     username VARCHAR(50) NOT NULL UNIQUE COMMENT '用户名',
     password VARCHAR(255) NOT NULL COMMENT '密码哈希',
     last_login DATETIME DEFAULT NULL COMMENT '最后登录',
+    locale VARCHAR(10) DEFAULT NULL COMMENT '提示语言: zh-CN/en-US',
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='管理员表';
 
@@ -233,6 +334,9 @@ CREATE TABLE IF NOT EXISTS pool_config (
     content_workers INT NOT NULL DEFAULT 10 COMMENT '正文池工作线程数',
     content_refill_interval_ms INT NOT NULL DEFAULT 50 COMMENT '正文池补充间隔(毫秒)',
     content_threshold DECIMAL(3,2) NOT NULL DEFAULT 0.40 COMMENT '正文池补充阈值(0-1)',
+    content_shard_threshold INT NOT NULL DEFAULT 1000000 COMMENT '可用行数达到该值的分组启用分片补充，0=不分片',
+    content_shards INT NOT NULL DEFAULT 16 COMMENT '分片数',
+    content_shard_workers INT NOT NULL DEFAULT 4 COMMENT '并发补充的分片数',
     -- cls类名池配置
     cls_pool_size INT NOT NULL DEFAULT 100000 COMMENT 'cls池大小',
     cls_workers INT NOT NULL DEFAULT 4 COMMENT 'cls池工作线程数',
@@ -1401,6 +1505,18 @@ CREATE TABLE IF NOT EXISTS spider_projects (
     last_error TEXT COMMENT '最后错误信息',
     total_runs INT DEFAULT 0 COMMENT '累计运行次数',
     total_items INT DEFAULT 0 COMMENT '累计抓取数量',
+    run_started_at DATETIME DEFAULT NULL COMMENT '本次运行开始时间',
+
+    -- 资源限制（随 run 命令下发给 Worker，max_runtime 由 API 强制停止）
+    max_runtime INT NOT NULL DEFAULT 0 COMMENT '最长运行时间（秒），0=不限制',
+    max_memory_mb INT NOT NULL DEFAULT 0 COMMENT '最大内存（MB），0=不限制',
+    max_requests INT NOT NULL DEFAULT 0 COMMENT '单次运行最大请求数，0=不限制',
+    domain_delay_ms INT NOT NULL DEFAULT 0 COMMENT '同一域名请求最小间隔（毫秒），0=不限制',
+
+    -- 抓取行为
+    robots_mode VARCHAR(10) NOT NULL DEFAULT 'off' COMMENT 'robots.txt 合规模式: off/warn/enforce',
+    field_mapping JSON DEFAULT NULL COMMENT '字段映射，如 {"title": "headline | trim", "content": "body | strip_tags"}',
+    file_versions_keep INT NOT NULL DEFAULT 50 COMMENT '每个文件保留的版本数，0=不限制',
 
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
@@ -1567,3 +1683,826 @@ INSERT INTO scheduled_tasks (name, task_type, cron_expr, params, enabled) VALUES
 ('刷新模板缓存', 'refresh_template', '0 */30 * * * *', '{}', 1),
 ('清理过期缓存', 'clear_cache', '0 0 3 * * *', '{"max_age_hours": 24}', 1)
 ON DUPLICATE KEY UPDATE name = name;
+
+-- ============================================
+-- 后台作业表（批量操作异步执行）
+-- ============================================
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    job_type VARCHAR(50) NOT NULL COMMENT '作业类型: keywords_delete_all, keywords_upload, images_delete_all 等',
+    status ENUM('pending', 'running', 'success', 'failed', 'cancelled') NOT NULL DEFAULT 'pending' COMMENT '状态',
+    total BIGINT NOT NULL DEFAULT 0 COMMENT '总量',
+    processed BIGINT NOT NULL DEFAULT 0 COMMENT '已处理数量',
+    params JSON COMMENT '作业参数',
+    result JSON COMMENT '执行结果',
+    message TEXT COMMENT '错误或提示信息',
+    started_at DATETIME COMMENT '开始执行时间',
+    finished_at DATETIME COMMENT '结束时间',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_status (status),
+    INDEX idx_type_created (job_type, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='后台作业表';
+
+-- ============================================
+-- 违禁词表（内容过滤）
+-- ============================================
+CREATE TABLE IF NOT EXISTS banned_words (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    word VARCHAR(255) NOT NULL COMMENT '违禁词或正则表达式',
+    match_type ENUM('literal', 'regex') NOT NULL DEFAULT 'literal' COMMENT '匹配方式',
+    replacement VARCHAR(255) DEFAULT NULL COMMENT '替换文本（replace 策略使用）',
+    status TINYINT DEFAULT 1 COMMENT '状态: 1=启用, 0=禁用',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_word_type (word, match_type),
+    INDEX idx_status (status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='违禁词表';
+
+-- ============================================
+-- 违禁词分组策略表（按文章分组配置）
+-- ============================================
+CREATE TABLE IF NOT EXISTS banned_word_policies (
+    group_id INT PRIMARY KEY COMMENT '文章分组ID',
+    policy ENUM('off', 'mask', 'replace', 'reject') NOT NULL DEFAULT 'mask' COMMENT '处理策略',
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='违禁词分组策略';
+
+-- ============================================
+-- 违禁词命中统计表（按天聚合）
+-- ============================================
+CREATE TABLE IF NOT EXISTS banned_word_hits (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    word_id INT NOT NULL COMMENT '违禁词ID',
+    group_id INT NOT NULL COMMENT '文章分组ID',
+    source ENUM('insert', 'pool') NOT NULL COMMENT '命中来源: insert=文章入库, pool=正文池填充',
+    hit_date DATE NOT NULL COMMENT '日期',
+    hits INT NOT NULL DEFAULT 0 COMMENT '命中次数',
+    UNIQUE INDEX idx_word_group_source_date (word_id, group_id, source, hit_date),
+    INDEX idx_hit_date (hit_date)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='违禁词命中统计';
+
+-- ============================================
+-- 域名缓存统计表（按小时聚合，供 Grafana 等绘图）
+-- ============================================
+CREATE TABLE IF NOT EXISTS cache_stats_hourly (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    domain VARCHAR(255) NOT NULL COMMENT '域名',
+    hour_start DATETIME NOT NULL COMMENT '小时起点',
+    hits INT NOT NULL DEFAULT 0 COMMENT '缓存命中次数（Nginx 直接返回）',
+    misses INT NOT NULL DEFAULT 0 COMMENT '缓存未命中次数（动态生成）',
+    bytes BIGINT NOT NULL DEFAULT 0 COMMENT '响应字节数',
+    UNIQUE INDEX idx_domain_hour (domain, hour_start),
+    INDEX idx_hour_start (hour_start)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='域名缓存统计（小时）';
+
+-- ============================================
+-- 管理员会话表（JWT 撤销、并发登录限制）
+-- ============================================
+CREATE TABLE IF NOT EXISTS admin_sessions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    admin_id INT NOT NULL COMMENT '管理员ID',
+    jti VARCHAR(64) NOT NULL COMMENT 'Token ID（JWT jti）',
+    ip VARCHAR(45) DEFAULT NULL COMMENT '登录IP',
+    user_agent VARCHAR(255) DEFAULT NULL COMMENT '登录UA',
+    created_at DATETIME NOT NULL COMMENT '登录时间',
+    last_seen_at DATETIME NOT NULL COMMENT '最近活跃时间',
+    expires_at DATETIME NOT NULL COMMENT 'Token 过期时间',
+    revoked_at DATETIME DEFAULT NULL COMMENT '撤销时间',
+    revoke_reason VARCHAR(50) DEFAULT NULL COMMENT '撤销原因: logout/revoked/session_limit/password_changed',
+    UNIQUE INDEX idx_jti (jti),
+    INDEX idx_admin_active (admin_id, revoked_at, expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='管理员会话';
+
+-- ============================================
+-- 登录尝试记录表（防爆破审计、异常检测）
+-- ============================================
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    ip VARCHAR(45) NOT NULL COMMENT '来源IP',
+    username VARCHAR(50) NOT NULL DEFAULT '' COMMENT '尝试的用户名',
+    user_agent VARCHAR(255) DEFAULT NULL COMMENT 'User-Agent',
+    outcome ENUM('success', 'failure', 'locked') NOT NULL COMMENT '结果',
+    reason VARCHAR(50) DEFAULT NULL COMMENT '失败原因: user_not_found/bad_password/locked',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP COMMENT '尝试时间',
+    INDEX idx_created (created_at),
+    INDEX idx_ip_created (ip, created_at),
+    INDEX idx_username_created (username, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='登录尝试记录';
+
+-- ============================================
+-- 模板标签表（模板库筛选）
+-- ============================================
+CREATE TABLE IF NOT EXISTS template_tags (
+    template_id INT NOT NULL COMMENT '模板ID',
+    tag VARCHAR(50) NOT NULL COMMENT '标签',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (template_id, tag),
+    INDEX idx_tag (tag)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='模板标签';
+
+-- ============================================
+-- 功能开关表（实验性行为灰度）
+-- ============================================
+CREATE TABLE IF NOT EXISTS feature_flags (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    flag_key VARCHAR(100) NOT NULL COMMENT '开关标识',
+    description VARCHAR(255) DEFAULT NULL COMMENT '说明',
+    enabled TINYINT(1) NOT NULL DEFAULT 0 COMMENT '全局开关',
+    rollout_percent INT NOT NULL DEFAULT 100 COMMENT '灰度百分比(0-100)，按域名哈希分桶',
+    site_group_overrides JSON DEFAULT NULL COMMENT '站群覆盖 {"站群ID": true/false}',
+    updated_by VARCHAR(50) DEFAULT NULL COMMENT '最后修改人',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_flag_key (flag_key)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='功能开关';
+
+-- ============================================
+-- 蜘蛛内容策略表（按站群和蜘蛛类型切换模板/关键词分组/标题格式）
+-- ============================================
+CREATE TABLE IF NOT EXISTS spider_strategies (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    site_group_id INT NOT NULL COMMENT '站群ID',
+    spider_type VARCHAR(50) NOT NULL COMMENT '蜘蛛类型: baidu/google/sogou/...',
+    template VARCHAR(100) DEFAULT NULL COMMENT '模板名，NULL=使用站点模板',
+    keyword_group_id INT DEFAULT NULL COMMENT '关键词分组ID，NULL=使用站点分组',
+    title_pattern VARCHAR(255) DEFAULT NULL COMMENT '标题格式，如 {keyword}{emoji}{keyword}_{site_name}，NULL=默认格式',
+    status TINYINT DEFAULT 1 COMMENT '状态: 1=启用, 0=禁用',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_group_spider (site_group_id, spider_type)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='蜘蛛内容策略';
+
+-- ============================================
+-- 页面关键词记录（关键词抓取反馈）
+-- ============================================
+CREATE TABLE IF NOT EXISTS page_keywords (
+    domain VARCHAR(100) NOT NULL COMMENT '域名',
+    path_hash CHAR(32) NOT NULL COMMENT '路径 MD5',
+    path VARCHAR(500) NOT NULL COMMENT '访问路径',
+    keyword_group_id INT NOT NULL COMMENT '关键词分组ID',
+    keywords TEXT NOT NULL COMMENT '页面标题使用的关键词，换行分隔',
+    rendered_at DATETIME NOT NULL COMMENT '最近渲染时间',
+    PRIMARY KEY (domain, path_hash),
+    INDEX idx_rendered (rendered_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='页面关键词记录';
+
+-- ============================================
+-- 关键词抓取得分
+-- ============================================
+CREATE TABLE IF NOT EXISTS keyword_crawl_scores (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    keyword_group_id INT NOT NULL COMMENT '关键词分组ID',
+    keyword_hash CHAR(32) NOT NULL COMMENT '关键词 MD5',
+    keyword VARCHAR(500) NOT NULL COMMENT '关键词',
+    pages INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '使用该关键词的页面数',
+    crawls INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '统计窗口内这些页面的蜘蛛访问次数',
+    score DOUBLE NOT NULL DEFAULT 0 COMMENT '平滑后的每页抓取次数',
+    last_crawled_at DATETIME DEFAULT NULL COMMENT '最近一次被抓取',
+    computed_at DATETIME NOT NULL COMMENT '计算时间',
+    UNIQUE KEY uk_group_keyword (keyword_group_id, keyword_hash),
+    INDEX idx_group_score (keyword_group_id, score DESC)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='关键词抓取得分';
+
+-- ============================================
+-- 搜索词候选（百度统计 / Google Search Console 导入，审核后加入关键词分组）
+-- ============================================
+CREATE TABLE IF NOT EXISTS keyword_candidates (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    keyword_hash CHAR(32) NOT NULL COMMENT '关键词 MD5',
+    keyword VARCHAR(500) NOT NULL COMMENT '搜索词',
+    source VARCHAR(20) NOT NULL COMMENT '来源: baidu_tongji/gsc',
+    site VARCHAR(255) NOT NULL DEFAULT '' COMMENT '来源站点（百度统计 site_id / GSC 站点）',
+    impressions INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '展现量（百度统计为浏览量）',
+    clicks INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '点击量（百度统计为访客数）',
+    status ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending' COMMENT '审核状态',
+    keyword_group_id INT DEFAULT NULL COMMENT '审核通过后加入的关键词分组',
+    reviewed_by VARCHAR(50) DEFAULT NULL,
+    reviewed_at DATETIME DEFAULT NULL,
+    first_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_keyword (keyword_hash),
+    INDEX idx_status_impressions (status, impressions DESC)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='搜索词候选';
+
+-- ============================================
+-- 站群 URL 生成策略（random_url、站内链接与 sitemap 共用，未配置的站群使用 config.yaml 默认值）
+-- ============================================
+CREATE TABLE IF NOT EXISTS url_strategies (
+    site_group_id INT PRIMARY KEY COMMENT '站群ID',
+    strategy VARCHAR(20) NOT NULL DEFAULT 'random' COMMENT '策略: random/numeric/date/pinyin/hybrid',
+    url_space INT NOT NULL DEFAULT 0 COMMENT '每个站点的 URL 数，0=全局默认',
+    slug_separator VARCHAR(5) NOT NULL DEFAULT '-' COMMENT '拼音 slug 分隔符',
+    slug_max_len INT NOT NULL DEFAULT 0 COMMENT '拼音 slug 最大长度，0=全局默认',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='站群 URL 生成策略';
+
+-- ============================================
+-- 分词自定义词典（与 segmenter.dict_files 合并使用）
+-- ============================================
+CREATE TABLE IF NOT EXISTS segment_words (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    word VARCHAR(64) NOT NULL COMMENT '词',
+    freq INT UNSIGNED NOT NULL DEFAULT 1000 COMMENT '词频，越大越优先成词',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_word (word)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='分词自定义词典';
+
+-- ============================================
+-- 站群模拟发布日期分布（{{ publish_date() }}，未配置的站群使用 config.yaml 默认值）
+-- ============================================
+CREATE TABLE IF NOT EXISTS publish_date_policies (
+    site_group_id INT PRIMARY KEY COMMENT '站群ID',
+    range_days INT NOT NULL DEFAULT 0 COMMENT '日期分布在基准日之前多少天内，0=全局默认',
+    weekday_weights VARCHAR(64) NOT NULL DEFAULT '' COMMENT '周一到周日的权重，逗号分隔，空=全局默认',
+    recency VARCHAR(20) NOT NULL DEFAULT 'exponential' COMMENT '分布曲线: uniform/linear/exponential',
+    half_life_days INT NOT NULL DEFAULT 0 COMMENT 'exponential 曲线半衰期，0=全局默认',
+    hour_start TINYINT NOT NULL DEFAULT 8 COMMENT '发布时间段开始（小时）',
+    hour_end TINYINT NOT NULL DEFAULT 23 COMMENT '发布时间段结束（小时，不含）',
+    anchor_date DATE DEFAULT NULL COMMENT '基准日，NULL=策略更新日与站点创建日中较晚者',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='站群模拟发布日期分布';
+
+-- ============================================
+-- 冷正文归档批次（已使用的正文导出到对象存储后从 contents 删除，按批次恢复）
+-- ============================================
+CREATE TABLE IF NOT EXISTS content_archives (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY COMMENT '批次ID',
+    storage VARCHAR(10) NOT NULL DEFAULT 'local' COMMENT '存储: s3/local',
+    object_key VARCHAR(255) NOT NULL DEFAULT '' COMMENT '对象键（local 时为 local_dir 下的相对路径）',
+    cutoff DATETIME NOT NULL COMMENT '归档 created_at 早于该时间的已使用正文',
+    row_count INT NOT NULL DEFAULT 0 COMMENT '归档行数',
+    min_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    max_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    size_bytes BIGINT NOT NULL DEFAULT 0 COMMENT '归档文件大小（gzip）',
+    deleted_rows INT NOT NULL DEFAULT 0 COMMENT '从 contents 删除的行数',
+    restored_rows INT NOT NULL DEFAULT 0 COMMENT '恢复插入的行数',
+    status VARCHAR(20) NOT NULL DEFAULT 'exporting' COMMENT '状态: exporting/uploaded/purged/restored/failed',
+    error VARCHAR(500) DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    purged_at DATETIME DEFAULT NULL,
+    restored_at DATETIME DEFAULT NULL,
+    INDEX idx_status (status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='冷正文归档批次';
+
+-- ============================================
+-- 爬虫项目文件版本历史（每次保存记录一个版本，按项目 file_versions_keep 保留）
+-- ============================================
+CREATE TABLE IF NOT EXISTS spider_file_versions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    project_id INT NOT NULL COMMENT '所属项目ID',
+    path VARCHAR(500) NOT NULL COMMENT '文件路径',
+    content LONGTEXT NOT NULL COMMENT '文件内容快照',
+    size INT NOT NULL DEFAULT 0 COMMENT '内容字节数',
+    content_hash CHAR(40) NOT NULL DEFAULT '' COMMENT '内容 SHA1，与上一版本相同时不重复记录',
+    source VARCHAR(20) NOT NULL DEFAULT 'save' COMMENT '来源: create/save/restore',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_project_path (project_id, path(255), id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='爬虫项目文件版本历史';
+
+-- ============================================
+-- 爬虫 robots.txt 合规（项目 robots_mode，违规 URL 按项目去重累计）
+-- ============================================
+CREATE TABLE IF NOT EXISTS spider_robots_violations (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    project_id INT NOT NULL COMMENT '所属项目ID',
+    url VARCHAR(2000) NOT NULL COMMENT '被 robots.txt 禁止的 URL',
+    url_hash CHAR(40) NOT NULL COMMENT 'URL SHA1',
+    host VARCHAR(255) NOT NULL DEFAULT '',
+    rule VARCHAR(500) NOT NULL DEFAULT '' COMMENT '命中的规则',
+    action VARCHAR(10) NOT NULL DEFAULT 'warned' COMMENT 'warned=仅记录 blocked=已拦截',
+    hits INT NOT NULL DEFAULT 1 COMMENT '检查命中次数',
+    first_seen DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_seen DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_project_url (project_id, url_hash),
+    INDEX idx_project_last_seen (project_id, last_seen)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='爬虫 robots.txt 违规记录';
+
+-- ============================================
+-- 内容保鲜（按站群每天抽取部分缓存页面重新渲染，URL 和标题不变）
+-- ============================================
+CREATE TABLE IF NOT EXISTS freshness_policies (
+    site_group_id INT PRIMARY KEY COMMENT '站群ID',
+    enabled TINYINT NOT NULL DEFAULT 1 COMMENT '是否启用',
+    percent DECIMAL(5,2) NOT NULL DEFAULT 5.00 COMMENT '每次刷新的缓存页面比例（%）',
+    max_pages INT NOT NULL DEFAULT 1000 COMMENT '每次最多刷新的页面数，0=不限制',
+    min_interval_hours INT NOT NULL DEFAULT 72 COMMENT '同一 URL 两次刷新的最短间隔（小时）',
+    last_run_at DATETIME DEFAULT NULL,
+    last_refreshed INT NOT NULL DEFAULT 0 COMMENT '上次运行刷新的页面数',
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='内容保鲜站群策略';
+
+CREATE TABLE IF NOT EXISTS freshness_refreshes (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    site_group_id INT NOT NULL,
+    domain VARCHAR(100) NOT NULL,
+    path VARCHAR(500) NOT NULL COMMENT '页面路径',
+    cache_path VARCHAR(500) NOT NULL COMMENT '缓存路径（含策略命名空间）',
+    title VARCHAR(500) NOT NULL DEFAULT '' COMMENT '页面标题（后续刷新沿用）',
+    old_size INT NOT NULL DEFAULT 0,
+    new_size INT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL COMMENT 'refreshed/failed',
+    error VARCHAR(500) DEFAULT NULL,
+    job_id BIGINT DEFAULT NULL COMMENT '所属后台作业ID',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_url (domain, cache_path(191), id),
+    INDEX idx_path (domain, path(191)),
+    INDEX idx_group_time (site_group_id, created_at),
+    INDEX idx_time (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='内容保鲜刷新记录';
+
+-- ============================================
+-- 固定页面（指定 URL 显示手写 HTML 或固定文章，优先于缓存和模板渲染）
+-- ============================================
+CREATE TABLE IF NOT EXISTS pinned_pages (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    domain VARCHAR(100) NOT NULL COMMENT '域名',
+    path VARCHAR(500) NOT NULL COMMENT '路径（精确匹配）',
+    mode VARCHAR(10) NOT NULL DEFAULT 'html' COMMENT 'html=手写 HTML, article=站点模板渲染指定文章',
+    html MEDIUMTEXT DEFAULT NULL COMMENT '手写 HTML（mode=html）',
+    article_id INT UNSIGNED DEFAULT NULL COMMENT '原始文章ID（mode=article）',
+    enabled TINYINT NOT NULL DEFAULT 1,
+    note VARCHAR(255) NOT NULL DEFAULT '' COMMENT '备注',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_domain_path (domain, path(191))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='固定页面';
+
+-- ============================================
+-- 站点域名健康检查（最新结果 + 检查历史）
+-- ============================================
+CREATE TABLE IF NOT EXISTS site_health (
+    site_id INT PRIMARY KEY COMMENT '站点ID',
+    domain VARCHAR(100) NOT NULL,
+    dns_ok TINYINT NOT NULL DEFAULT 0,
+    dns_addrs VARCHAR(255) NOT NULL DEFAULT '' COMMENT '解析结果（逗号分隔）',
+    http_ok TINYINT NOT NULL DEFAULT 0,
+    http_status INT NOT NULL DEFAULT 0,
+    http_ms INT NOT NULL DEFAULT 0 COMMENT 'HTTP 响应耗时（毫秒）',
+    cert_ok TINYINT NOT NULL DEFAULT 0,
+    cert_expires_at DATETIME DEFAULT NULL,
+    cert_days_left INT DEFAULT NULL,
+    cert_issuer VARCHAR(255) NOT NULL DEFAULT '',
+    healthy TINYINT NOT NULL DEFAULT 0,
+    error VARCHAR(500) NOT NULL DEFAULT '' COMMENT '失败原因',
+    failure_streak INT NOT NULL DEFAULT 0 COMMENT '连续失败次数',
+    last_ok_at DATETIME DEFAULT NULL,
+    checked_at DATETIME NOT NULL,
+    INDEX idx_healthy (healthy, failure_streak)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='站点域名健康状态';
+
+CREATE TABLE IF NOT EXISTS site_health_checks (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    site_id INT NOT NULL,
+    domain VARCHAR(100) NOT NULL,
+    dns_ok TINYINT NOT NULL DEFAULT 0,
+    http_ok TINYINT NOT NULL DEFAULT 0,
+    http_status INT NOT NULL DEFAULT 0,
+    http_ms INT NOT NULL DEFAULT 0,
+    cert_ok TINYINT NOT NULL DEFAULT 0,
+    cert_days_left INT DEFAULT NULL,
+    healthy TINYINT NOT NULL DEFAULT 0,
+    error VARCHAR(500) NOT NULL DEFAULT '',
+    checked_at DATETIME NOT NULL,
+    INDEX idx_site (site_id, id),
+    INDEX idx_time (checked_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='站点域名健康检查历史';
+
+-- ============================================
+-- 站点搜索引擎验证文件（baidu_verify_*.html、google*.html 等，在域名根目录直接返回）
+-- ============================================
+CREATE TABLE IF NOT EXISTS site_verification_files (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    site_id INT NOT NULL COMMENT '站点ID',
+    filename VARCHAR(255) NOT NULL COMMENT '根目录文件名',
+    content MEDIUMTEXT NOT NULL COMMENT '文件内容',
+    content_type VARCHAR(100) NOT NULL DEFAULT 'text/html; charset=utf-8',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_site_filename (site_id, filename)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='站点验证文件';
+
+-- ============================================
+-- WASM 渲染扩展站群绑定
+-- ============================================
+CREATE TABLE IF NOT EXISTS wasm_extensions (
+    site_group_id INT NOT NULL PRIMARY KEY COMMENT '站群 ID',
+    module VARCHAR(255) NOT NULL COMMENT '模块文件名（模块目录下）',
+    transform_title TINYINT(1) NOT NULL DEFAULT 0 COMMENT '转换标题',
+    transform_content TINYINT(1) NOT NULL DEFAULT 0 COMMENT '转换正文',
+    template_func TINYINT(1) NOT NULL DEFAULT 0 COMMENT '提供 plugin() 模板函数',
+    enabled TINYINT(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='WASM 渲染扩展';
+
+-- ============================================
+-- 页面级 robots 规则（按 URL 正则注入 meta robots、站外链接 nofollow）
+-- ============================================
+CREATE TABLE IF NOT EXISTS robots_policies (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    site_group_id INT NOT NULL DEFAULT 0 COMMENT '站群 ID，0=所有站群',
+    name VARCHAR(100) NOT NULL DEFAULT '' COMMENT '规则名称',
+    pattern VARCHAR(500) NOT NULL COMMENT '匹配 路径+查询串 的正则，可用 (?P<page>\\d+) 提取页码',
+    min_page INT NOT NULL DEFAULT 0 COMMENT '页码 >= 该值才匹配，0=不限',
+    robots VARCHAR(200) NOT NULL DEFAULT '' COMMENT 'meta robots 内容，空=不修改',
+    nofollow_external TINYINT(1) NOT NULL DEFAULT 0 COMMENT '站外链接加 rel=nofollow',
+    priority INT NOT NULL DEFAULT 0 COMMENT '优先级，越大越优先',
+    enabled TINYINT(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_site_group (site_group_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='页面级 robots 规则';
+
+-- ============================================
+-- 分组级缓存池配置（覆盖 pool_config 中的全局值，NULL=沿用全局）
+-- ============================================
+CREATE TABLE IF NOT EXISTS pool_group_config (
+    pool_type VARCHAR(20) NOT NULL COMMENT '池类型：titles / contents',
+    group_id INT NOT NULL COMMENT '分组 ID（titles 为关键词分组，contents 为文章分组）',
+    pool_size INT NULL COMMENT '池大小',
+    workers INT NULL COMMENT '工作线程数（正文池为分片补充并发数）',
+    refill_interval_ms INT NULL COMMENT '补充间隔(毫秒)',
+    threshold DECIMAL(3,2) NULL COMMENT '补充阈值(0-1)',
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (pool_type, group_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='分组级缓存池配置';
+
+-- ============================================
+-- 系统指标历史（1m 采样，降采样为 5m / 1h）
+-- ============================================
+CREATE TABLE IF NOT EXISTS system_metrics (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    resolution ENUM('1m', '5m', '1h') NOT NULL COMMENT '精度',
+    period_start DATETIME NOT NULL COMMENT '时间段起点',
+    cpu_percent DOUBLE NOT NULL DEFAULT 0 COMMENT 'CPU 使用率',
+    memory_percent DOUBLE NOT NULL DEFAULT 0 COMMENT '内存使用率',
+    memory_used_bytes BIGINT NOT NULL DEFAULT 0 COMMENT '已用内存',
+    load1 DOUBLE NOT NULL DEFAULT 0,
+    load5 DOUBLE NOT NULL DEFAULT 0,
+    load15 DOUBLE NOT NULL DEFAULT 0,
+    net_sent_bps BIGINT NOT NULL DEFAULT 0 COMMENT '发送速率（字节/秒）',
+    net_recv_bps BIGINT NOT NULL DEFAULT 0 COMMENT '接收速率（字节/秒）',
+    disk_percent DOUBLE NOT NULL DEFAULT 0 COMMENT '使用率最高的磁盘',
+    qps DOUBLE NOT NULL DEFAULT 0,
+    avg_latency_ms DOUBLE NOT NULL DEFAULT 0,
+    max_latency_ms DOUBLE NOT NULL DEFAULT 0,
+    cache_hit_rate DOUBLE NOT NULL DEFAULT 0,
+    goroutines INT NOT NULL DEFAULT 0,
+    heap_alloc_bytes BIGINT NOT NULL DEFAULT 0,
+    samples INT NOT NULL DEFAULT 1 COMMENT '聚合的下级数据点数',
+    UNIQUE INDEX idx_resolution_period (resolution, period_start)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='系统指标历史';
+
+-- ============================================
+-- 自定义告警规则（指标持续满足条件时触发，条件恢复后自动解决）
+-- ============================================
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL COMMENT '规则名称',
+    metric VARCHAR(50) NOT NULL COMMENT '指标（error_rate、avg_latency_ms 等）',
+    comparator VARCHAR(2) NOT NULL COMMENT '比较符：> >= < <= == !=',
+    threshold DOUBLE NOT NULL COMMENT '阈值',
+    duration_seconds INT NOT NULL DEFAULT 0 COMMENT '条件持续满足多久后触发',
+    severity ENUM('info', 'warning', 'error') NOT NULL DEFAULT 'warning' COMMENT '告警级别',
+    channel VARCHAR(20) NOT NULL DEFAULT 'log' COMMENT '通知渠道：log / webhook',
+    webhook_url VARCHAR(500) NOT NULL DEFAULT '' COMMENT 'webhook 地址，为空时用 alerting.webhook_url',
+    enabled TINYINT(1) NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='自定义告警规则';
+
+-- ============================================
+-- 渲染自检记录（每个站群定期按正常流程渲染一页）
+-- ============================================
+CREATE TABLE IF NOT EXISTS canary_checks (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    site_group_id INT NOT NULL,
+    domain VARCHAR(100) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    success TINYINT NOT NULL DEFAULT 0,
+    latency_ms INT NOT NULL DEFAULT 0,
+    bytes INT NOT NULL DEFAULT 0,
+    error VARCHAR(500) NOT NULL DEFAULT '',
+    checked_at DATETIME NOT NULL,
+    INDEX idx_group (site_group_id, id),
+    INDEX idx_time (checked_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='渲染自检记录';
+
+-- ============================================
+-- 模板灰度发布（站群按比例切换到新模板，错误率回升时自动回滚）
+-- ============================================
+CREATE TABLE IF NOT EXISTS template_rollouts (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    site_group_id INT NOT NULL COMMENT '站群ID',
+    template VARCHAR(100) NOT NULL COMMENT '新模板名',
+    percent TINYINT NOT NULL DEFAULT 0 COMMENT '使用新模板的请求比例 0-100',
+    status ENUM('active', 'completed', 'rolled_back') NOT NULL DEFAULT 'active',
+    max_error_delta DOUBLE NOT NULL DEFAULT 0.05 COMMENT '新模板错误率超出原模板多少时自动回滚',
+    min_renders INT NOT NULL DEFAULT 50 COMMENT '新模板渲染次数达到该值后才判断回滚',
+    reason VARCHAR(255) NOT NULL DEFAULT '' COMMENT '回滚原因',
+    finished_at DATETIME DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_group_status (site_group_id, status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='模板灰度发布';
+
+-- ============================================
+-- 站点重定向规则（旧路径 301/302 到新地址，无需修改 Nginx）
+-- ============================================
+CREATE TABLE IF NOT EXISTS site_redirects (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    site_id INT NOT NULL COMMENT '站点ID',
+    pattern VARCHAR(500) NOT NULL COMMENT '匹配 路径+查询串 的正则（整体匹配）',
+    target VARCHAR(1000) NOT NULL COMMENT '跳转地址，可用 $1、${name} 引用分组',
+    code SMALLINT NOT NULL DEFAULT 301 COMMENT '301 或 302',
+    priority INT NOT NULL DEFAULT 0 COMMENT '越大越优先',
+    enabled TINYINT(1) NOT NULL DEFAULT 1,
+    hits BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '命中次数',
+    last_hit_at DATETIME DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_site (site_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='站点重定向规则';
+
+-- ============================================
+-- 内容增量统计（每日汇总：新增文章/关键词/标题/正文，标题和正文池剩余量）
+-- ============================================
+CREATE TABLE IF NOT EXISTS activity_daily_added (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    stat_date DATE NOT NULL,
+    kind VARCHAR(20) NOT NULL COMMENT 'article / keyword / title / content',
+    group_id INT NOT NULL COMMENT '所属分组ID',
+    source_id INT NOT NULL DEFAULT 0 COMMENT '文章数据源（spider_projects.id），0 表示手工上传或不区分',
+    added INT NOT NULL DEFAULT 0 COMMENT '当天新增条数',
+    UNIQUE INDEX idx_date_kind_group_source (stat_date, kind, group_id, source_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='每日新增内容汇总';
+
+CREATE TABLE IF NOT EXISTS pool_daily_snapshots (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    stat_date DATE NOT NULL,
+    kind VARCHAR(20) NOT NULL COMMENT 'title / content',
+    group_id INT NOT NULL,
+    available BIGINT NOT NULL DEFAULT 0 COMMENT '汇总时的可用条数',
+    added INT NOT NULL DEFAULT 0 COMMENT '当天新增条数',
+    consumed INT DEFAULT NULL COMMENT '当天消耗条数（前一天剩余 + 新增 - 当天剩余），缺少前一天快照时为空',
+    UNIQUE INDEX idx_date_kind_group (stat_date, kind, group_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='标题/正文池每日剩余量';
+
+CREATE TABLE IF NOT EXISTS activity_rollup_days (
+    stat_date DATE PRIMARY KEY,
+    rolled_at DATETIME NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='已汇总的日期';
+
+-- ============================================
+-- 模板片段（模板中用 {{ include "name" arg1 arg2 }} 引用，载入模板时展开）
+-- ============================================
+CREATE TABLE IF NOT EXISTS template_snippets (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(64) NOT NULL COMMENT '片段名（include 时引用）',
+    description VARCHAR(255) NOT NULL DEFAULT '' COMMENT '说明',
+    content MEDIUMTEXT NOT NULL COMMENT '片段内容，{{ arg1 }} 等引用 include 参数',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_name (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='模板片段';
+
+-- ============================================
+-- 出站请求审计（共享 HTTP 客户端发出的每次请求，含重试）
+-- ============================================
+CREATE TABLE IF NOT EXISTS egress_logs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    host VARCHAR(255) NOT NULL COMMENT '目标主机（含端口）',
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(255) NOT NULL DEFAULT '' COMMENT '请求路径（不含查询串）',
+    purpose VARCHAR(50) NOT NULL DEFAULT '' COMMENT '用途：robots_check / keyword_suggest / alert_webhook 等',
+    attempt INT NOT NULL DEFAULT 1 COMMENT '第几次尝试',
+    status INT NOT NULL DEFAULT 0 COMMENT 'HTTP 状态码，0 表示网络错误',
+    bytes_out BIGINT NOT NULL DEFAULT 0,
+    bytes_in BIGINT NOT NULL DEFAULT 0,
+    duration_ms INT NOT NULL DEFAULT 0,
+    error VARCHAR(500) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    INDEX idx_created (created_at),
+    INDEX idx_host_created (host, created_at),
+    INDEX idx_purpose_created (purpose, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='出站请求审计日志';
+
+CREATE TABLE IF NOT EXISTS egress_daily (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    stat_date DATE NOT NULL,
+    host VARCHAR(255) NOT NULL,
+    purpose VARCHAR(50) NOT NULL DEFAULT '',
+    requests BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0 COMMENT '网络错误和 4xx/5xx',
+    bytes_out BIGINT NOT NULL DEFAULT 0,
+    bytes_in BIGINT NOT NULL DEFAULT 0,
+    UNIQUE INDEX idx_date_host_purpose (stat_date, host, purpose)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='出站请求每日汇总';
+
+-- ============================================
+-- 内容处理代码文件管理审计（创建、保存、上传、删除、移动，含被拒绝的越界操作）
+-- ============================================
+CREATE TABLE IF NOT EXISTS content_worker_file_audit (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    op VARCHAR(20) NOT NULL COMMENT 'create / save / upload / delete / move',
+    path VARCHAR(500) NOT NULL DEFAULT '' COMMENT '相对根目录的路径',
+    new_path VARCHAR(500) NOT NULL DEFAULT '' COMMENT '移动目标路径',
+    size BIGINT NOT NULL DEFAULT 0 COMMENT '写入字节数',
+    username VARCHAR(100) NOT NULL DEFAULT '',
+    client_ip VARCHAR(64) NOT NULL DEFAULT '',
+    error VARCHAR(500) NOT NULL DEFAULT '' COMMENT '失败原因，空表示成功',
+    created_at DATETIME NOT NULL,
+    INDEX idx_created (created_at),
+    INDEX idx_path (path(191))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='内容处理代码文件操作审计';
+
+-- ============================================
+-- 文件回收站（内容处理代码文件和爬虫项目文件删除后保留，凭 token 恢复，到期清除）
+-- ============================================
+CREATE TABLE IF NOT EXISTS file_trash (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    token VARCHAR(32) NOT NULL COMMENT '恢复令牌',
+    source VARCHAR(20) NOT NULL COMMENT 'content_worker / spider_project',
+    project_id INT NOT NULL DEFAULT 0 COMMENT '爬虫项目 ID（content_worker 为 0）',
+    path VARCHAR(500) NOT NULL COMMENT '删除时的路径',
+    item_type VARCHAR(10) NOT NULL DEFAULT 'file' COMMENT 'file / dir',
+    items INT NOT NULL DEFAULT 1 COMMENT '包含的文件和目录数',
+    size BIGINT NOT NULL DEFAULT 0 COMMENT '内容字节数',
+    payload LONGTEXT NULL COMMENT '爬虫项目文件行（JSON），content_worker 内容在 .trash/<token> 目录',
+    deleted_by VARCHAR(100) NOT NULL DEFAULT '',
+    deleted_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    UNIQUE INDEX idx_token (token),
+    INDEX idx_source_project (source, project_id, deleted_at),
+    INDEX idx_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文件回收站';
+
+-- ============================================
+-- 关键词入库停用词（去掉停用词后剩余长度不足 keyword_filter.min_length 的关键词拒绝入库）
+-- ============================================
+CREATE TABLE IF NOT EXISTS keyword_stopwords (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NOT NULL DEFAULT 0 COMMENT '关键词分组 ID，0 表示全部分组',
+    word VARCHAR(100) NOT NULL COMMENT '停用词（小写）',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_group_word (group_id, word)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='关键词入库停用词';
+
+-- ============================================
+-- 文章入库清洗策略（未配置的分组默认启用清洗、保留链接、不改写图片）
+-- ============================================
+CREATE TABLE IF NOT EXISTS article_sanitize_policies (
+    group_id INT PRIMARY KEY COMMENT '文章分组ID',
+    enabled TINYINT NOT NULL DEFAULT 1 COMMENT '是否清洗 HTML',
+    base_url VARCHAR(500) NOT NULL DEFAULT '' COMMENT '补全相对链接的基准 URL（请求未带 source_url 时使用）',
+    image_proxy VARCHAR(500) NOT NULL DEFAULT '' COMMENT '图片代理地址，{url} 替换为转义后的原图地址',
+    iframe_hosts VARCHAR(1000) NOT NULL DEFAULT '' COMMENT '允许保留的 iframe 域名（逗号分隔）',
+    keep_links TINYINT NOT NULL DEFAULT 1 COMMENT '是否保留 <a> 链接',
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文章入库清洗策略';
+
+-- ============================================
+-- LLM 改写（分组设置、每日用量和成本）
+-- ============================================
+CREATE TABLE IF NOT EXISTS llm_rewrite_groups (
+    group_id INT PRIMARY KEY COMMENT '文章分组ID',
+    enabled TINYINT NOT NULL DEFAULT 0 COMMENT '是否启用改写',
+    rewrite_title TINYINT NOT NULL DEFAULT 1 COMMENT '改写标题',
+    rewrite_content TINYINT NOT NULL DEFAULT 1 COMMENT '改写正文',
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文章分组 LLM 改写设置';
+
+CREATE TABLE IF NOT EXISTS llm_usage (
+    date DATE NOT NULL COMMENT '日期',
+    group_id INT NOT NULL DEFAULT 0 COMMENT '文章分组ID',
+    purpose VARCHAR(50) NOT NULL COMMENT '用途（rewrite_title / rewrite_content）',
+    requests INT NOT NULL DEFAULT 0 COMMENT '请求数（含失败）',
+    failures INT NOT NULL DEFAULT 0 COMMENT '失败数',
+    prompt_tokens BIGINT NOT NULL DEFAULT 0 COMMENT '输入 token',
+    completion_tokens BIGINT NOT NULL DEFAULT 0 COMMENT '输出 token',
+    cost DECIMAL(12,4) NOT NULL DEFAULT 0 COMMENT '成本（按配置单价估算）',
+    PRIMARY KEY (date, group_id, purpose)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='LLM 每日用量';
+
+-- ============================================
+-- 页面模板归属（每个 URL 最近一次渲染使用的模板，与蜘蛛日志关联统计各模板的抓取）
+-- ============================================
+CREATE TABLE IF NOT EXISTS page_templates (
+    domain VARCHAR(100) NOT NULL COMMENT '域名',
+    path_hash CHAR(32) NOT NULL COMMENT 'MD5(path)',
+    path VARCHAR(500) NOT NULL COMMENT '访问路径',
+    site_group_id INT NOT NULL DEFAULT 1 COMMENT '站群ID',
+    template VARCHAR(100) NOT NULL COMMENT '模板名',
+    rendered_at DATETIME NOT NULL COMMENT '最近渲染时间',
+    PRIMARY KEY (domain, path_hash),
+    INDEX idx_group_template (site_group_id, template),
+    INDEX idx_rendered (rendered_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='页面模板归属';
+
+-- ============================================
+-- 文章批量导入冲突策略（正文哈希、版本）和推送方 API Token
+-- ============================================
+CREATE TABLE IF NOT EXISTS api_feeder_tokens (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL COMMENT '推送方名称',
+    token_hash CHAR(64) NOT NULL COMMENT 'Token 的 SHA-256',
+    token_prefix VARCHAR(16) NOT NULL COMMENT 'Token 前几位（列表中识别用）',
+    conflict_strategy VARCHAR(20) NOT NULL DEFAULT '' COMMENT '默认冲突策略：skip / overwrite / version，空为 skip',
+    conflict_key VARCHAR(20) NOT NULL DEFAULT '' COMMENT '冲突字段：title / content_hash / source_url，空为 title',
+    enabled TINYINT NOT NULL DEFAULT 1 COMMENT '是否启用',
+    last_used_at DATETIME DEFAULT NULL COMMENT '最近使用时间',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_token_hash (token_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='推送方 API Token';
+
+-- ============================================
+-- 命令行 Token（seogen-cli 按 scope 访问站点、关键词、图片、数据池和爬虫项目接口）
+-- ============================================
+CREATE TABLE IF NOT EXISTS cli_tokens (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL COMMENT '名称（使用者或用途）',
+    token_hash CHAR(64) NOT NULL COMMENT 'Token 的 SHA-256',
+    token_prefix VARCHAR(16) NOT NULL COMMENT 'Token 前几位（列表中识别用）',
+    scopes VARCHAR(255) NOT NULL COMMENT '权限范围，逗号分隔：sites/keywords/images/pools/spider，带 :read 只读',
+    enabled TINYINT NOT NULL DEFAULT 1 COMMENT '是否启用',
+    expires_at DATETIME DEFAULT NULL COMMENT '过期时间，NULL=不过期',
+    last_used_at DATETIME DEFAULT NULL COMMENT '最近使用时间',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_token_hash (token_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='seogen-cli 命令行 Token';
+
+-- ============================================
+-- 结构升级（已有数据库重复执行本脚本时补齐新增的列和索引，新库为空操作）
+-- ============================================
+-- 站群：模板降级备用模板
+CALL seo_add_column('site_groups', 'fallback_template', "VARCHAR(100) DEFAULT NULL COMMENT '备用模板名（模板降级时使用）' AFTER is_default");
+
+-- 站点：缓存配额、非蜘蛛访问策略、并发编辑版本号
+CALL seo_add_column('sites', 'cache_max_size_mb', "INT DEFAULT NULL COMMENT '页面缓存大小上限(MB)，NULL=使用全局默认，0=不限制' AFTER analytics");
+CALL seo_add_column('sites', 'cache_max_entries', "INT DEFAULT NULL COMMENT '页面缓存条数上限，NULL=使用全局默认，0=不限制' AFTER cache_max_size_mb");
+CALL seo_add_column('sites', 'human_policy', "VARCHAR(20) DEFAULT NULL COMMENT '非蜘蛛访问策略: redirect/page/content/block，NULL=按全局配置' AFTER cache_max_entries");
+CALL seo_add_column('sites', 'human_target_url', "VARCHAR(500) DEFAULT NULL COMMENT 'redirect 策略的跳转地址' AFTER human_policy");
+CALL seo_add_column('sites', 'version', "INT DEFAULT 1 COMMENT '版本号（每次保存+1，用于并发编辑检测）' AFTER human_target_url");
+
+-- 模板：渲染降级、模板库分类和使用统计
+CALL seo_add_column('templates', 'degraded', "TINYINT DEFAULT 0 COMMENT '是否因渲染失败率超限被降级: 1=已降级' AFTER version");
+CALL seo_add_column('templates', 'degraded_at', "DATETIME DEFAULT NULL COMMENT '降级时间' AFTER degraded");
+CALL seo_add_column('templates', 'degraded_reason', "VARCHAR(255) DEFAULT NULL COMMENT '降级原因' AFTER degraded_at");
+CALL seo_add_column('templates', 'category', "VARCHAR(50) DEFAULT NULL COMMENT '模板分类' AFTER degraded_reason");
+CALL seo_add_column('templates', 'engine', "VARCHAR(20) DEFAULT NULL COMMENT '目标搜索引擎: baidu/sogou/360/shenma/google/bing，空表示通用' AFTER category");
+CALL seo_add_column('templates', 'render_count', "BIGINT UNSIGNED DEFAULT 0 COMMENT '累计渲染次数' AFTER engine");
+CALL seo_add_column('templates', 'last_used_at', "DATETIME DEFAULT NULL COMMENT '最近一次渲染时间' AFTER render_count");
+CALL seo_add_index('templates', 'idx_category', 'INDEX idx_category (category)');
+CALL seo_add_index('templates', 'idx_last_used', 'INDEX idx_last_used (last_used_at)');
+
+-- 站点：缓存增量同步水位线索引
+CALL seo_add_index('sites', 'idx_updated_at', 'INDEX idx_updated_at (updated_at)');
+
+-- 管理员：提示语言
+CALL seo_add_column('admins', 'locale', "VARCHAR(10) DEFAULT NULL COMMENT '提示语言: zh-CN/en-US' AFTER last_login");
+
+-- 缓存池配置：正文池分片补充
+CALL seo_add_column('pool_config', 'content_shard_threshold', "INT NOT NULL DEFAULT 1000000 COMMENT '可用行数达到该值的分组启用分片补充，0=不分片' AFTER content_threshold");
+CALL seo_add_column('pool_config', 'content_shards', "INT NOT NULL DEFAULT 16 COMMENT '分片数' AFTER content_shard_threshold");
+CALL seo_add_column('pool_config', 'content_shard_workers', "INT NOT NULL DEFAULT 4 COMMENT '并发补充的分片数' AFTER content_shards");
+
+-- 爬虫项目：资源限制、robots 合规、字段映射、文件版本保留数
+CALL seo_add_column('spider_projects', 'run_started_at', "DATETIME DEFAULT NULL COMMENT '本次运行开始时间' AFTER total_items");
+CALL seo_add_column('spider_projects', 'max_runtime', "INT NOT NULL DEFAULT 0 COMMENT '最长运行时间（秒），0=不限制' AFTER run_started_at");
+CALL seo_add_column('spider_projects', 'max_memory_mb', "INT NOT NULL DEFAULT 0 COMMENT '最大内存（MB），0=不限制' AFTER max_runtime");
+CALL seo_add_column('spider_projects', 'max_requests', "INT NOT NULL DEFAULT 0 COMMENT '单次运行最大请求数，0=不限制' AFTER max_memory_mb");
+CALL seo_add_column('spider_projects', 'domain_delay_ms', "INT NOT NULL DEFAULT 0 COMMENT '同一域名请求最小间隔（毫秒），0=不限制' AFTER max_requests");
+CALL seo_add_column('spider_projects', 'robots_mode', "VARCHAR(10) NOT NULL DEFAULT 'off' COMMENT 'robots.txt 合规模式: off/warn/enforce' AFTER domain_delay_ms");
+CALL seo_add_column('spider_projects', 'field_mapping', "JSON DEFAULT NULL COMMENT '字段映射，如 {\"title\": \"headline | trim\", \"content\": \"body | strip_tags\"}' AFTER robots_mode");
+CALL seo_add_column('spider_projects', 'file_versions_keep', "INT NOT NULL DEFAULT 50 COMMENT '每个文件保留的版本数，0=不限制' AFTER field_mapping");
+
+-- 正文：来源文章（正文池带出文章摘要）
+CALL seo_add_column('contents', 'article_id', "INT UNSIGNED DEFAULT NULL COMMENT '来源文章ID（补充正文池时取 original_articles.summary）' AFTER group_id");
+
+-- 原始文章：正文哈希、摘要、改写时间、标签、版本（标题唯一索引改为按版本区分）
+CALL seo_add_column('original_articles', 'content_hash', "CHAR(32) DEFAULT NULL COMMENT '正文 MD5（按正文判断重复）' AFTER content");
+CALL seo_add_column('original_articles', 'summary', "VARCHAR(500) DEFAULT NULL COMMENT '摘要（纯文本，NULL 表示待回填）' AFTER content_hash");
+CALL seo_add_column('original_articles', 'rewritten_at', "DATETIME DEFAULT NULL COMMENT 'LLM 改写时间' AFTER summary");
+CALL seo_add_column('original_articles', 'tags', "VARCHAR(1000) DEFAULT NULL COMMENT '标签（逗号分隔，字段映射生成）' AFTER rewritten_at");
+CALL seo_add_column('original_articles', 'version', "INT NOT NULL DEFAULT 1 COMMENT '版本号（version 冲突策略插入新版本时递增）' AFTER status");
+CALL seo_add_column('original_articles', 'previous_id', "INT UNSIGNED DEFAULT NULL COMMENT '上一版本文章ID' AFTER version");
+CALL seo_add_index('original_articles', 'idx_group_source_url', 'INDEX idx_group_source_url (group_id, source_url(191))');
+CALL seo_add_index('original_articles', 'idx_group_content_hash', 'UNIQUE INDEX idx_group_content_hash (group_id, content_hash)');
+CALL seo_drop_index('original_articles', 'idx_group_title');
+CALL seo_add_index('original_articles', 'idx_group_title_version', 'UNIQUE INDEX idx_group_title_version (group_id, title(255), version)');
+
+-- 推送方 Token：明文改为只保存 SHA-256（已有 Token 原样可用）
+CALL seo_add_column('api_feeder_tokens', 'token_hash', "CHAR(64) NOT NULL DEFAULT '' COMMENT 'Token 的 SHA-256' AFTER name");
+CALL seo_add_column('api_feeder_tokens', 'token_prefix', "VARCHAR(16) NOT NULL DEFAULT '' COMMENT 'Token 前几位（列表中识别用）' AFTER token_hash");
+CALL seo_exec_if_column('api_feeder_tokens', 'token', "UPDATE api_feeder_tokens SET token_hash = SHA2(token, 256), token_prefix = LEFT(token, 13) WHERE token_hash = ''");
+CALL seo_drop_index('api_feeder_tokens', 'uk_token');
+CALL seo_drop_column('api_feeder_tokens', 'token');
+CALL seo_add_index('api_feeder_tokens', 'uk_token_hash', 'UNIQUE INDEX uk_token_hash (token_hash)');
+
+DROP PROCEDURE IF EXISTS seo_add_column;
+DROP PROCEDURE IF EXISTS seo_add_index;
+DROP PROCEDURE IF EXISTS seo_drop_index;
+DROP PROCEDURE IF EXISTS seo_drop_column;
+DROP PROCEDURE IF EXISTS seo_exec_if_column;
@@ -9,6 +9,7 @@ import type {
   PaginatedResponse
 } from '@/types'
 import { assertSuccess, type SuccessResponse, type CreateResponse, type CountResponse } from './shared'
+import { waitForJob, type Job } from './jobs'
 
 // ============================================
 // 响应类型
@@ -139,10 +140,18 @@ export async function batchMoveArticles(ids: number[], groupId: number): Promise
   return { moved: res.moved! }
 }
 
-export async function deleteAllArticles(groupId?: number): Promise<{ deleted: number }> {
-  const res: CountResponse = await request.delete('/articles/delete-all', {
+/** 删除全部（有作业管理器时后端返回 job_id 异步删除，这里轮询到作业结束） */
+export async function deleteAllArticles(
+  groupId?: number,
+  onProgress?: (job: Job) => void
+): Promise<{ deleted: number }> {
+  const res: CountResponse & { job_id?: number } = await request.delete('/articles/delete-all', {
     data: { group_id: groupId, confirm: true }
   })
   assertSuccess(res, '删除失败')
+  if (res.job_id) {
+    const result = await waitForJob<{ deleted: number }>(res.job_id, onProgress)
+    return { deleted: result.deleted || 0 }
+  }
   return { deleted: res.deleted! }
 }
@@ -18,6 +18,9 @@ export * from './images'
 export * from './articles'
 export * from './templates'
 
+// 后台作业
+export * from './jobs'
+
 // 蜘蛛（排除与 logs 冲突的 clearOldLogs）
 export { getSpiderLogs, getSpiderStats, getDailyStats, getHourlyStats, testSpiderDetection, getSpiderConfig } from './spiders'
 export { clearOldLogs as clearOldSpiderLogs } from './spiders'
@@ -0,0 +1,62 @@
+import request from '@/utils/request'
+
+// ============================================
+// 后台作业 API（删除全部、文件导入等耗时操作立即返回 job_id，结果通过作业查询获取）
+// ============================================
+
+export type JobStatus = 'pending' | 'running' | 'success' | 'failed' | 'cancelled'
+
+export interface Job<R = Record<string, any>> {
+  id: number
+  job_type: string
+  status: JobStatus
+  total: number
+  processed: number
+  result: R | null
+  message: string
+  progress: number // 0-100，总量未知时为 -1
+  created_at: string
+  started_at: string | null
+  finished_at: string | null
+}
+
+export async function getJob<R = Record<string, any>>(id: number): Promise<Job<R>> {
+  return request.get(`/jobs/${id}`)
+}
+
+export async function cancelJob(id: number): Promise<void> {
+  await request.post(`/jobs/${id}/cancel`)
+}
+
+/**
+ * 轮询作业直到结束，返回作业结果；失败或取消时抛出异常
+ * onProgress 每次轮询时回调，可用于显示进度
+ */
+export async function waitForJob<R = Record<string, any>>(
+  id: number,
+  onProgress?: (job: Job<R>) => void,
+  interval = 1000
+): Promise<R> {
+  for (;;) {
+    const job = await getJob<R>(id)
+    onProgress?.(job)
+    if (job.status === 'success') {
+      return (job.result || {}) as R
+    }
+    if (job.status === 'failed') {
+      throw new Error(job.message || '后台作业执行失败')
+    }
+    if (job.status === 'cancelled') {
+      throw new Error('后台作业已取消')
+    }
+    await new Promise(resolve => setTimeout(resolve, interval))
+  }
+}
+
+/** 作业进度提示文字 */
+export function jobProgressText(action: string, job: Job): string {
+  if (job.status === 'pending') {
+    return `${action}（排队中）`
+  }
+  return job.progress >= 0 ? `${action} ${Math.round(job.progress)}%` : `${action}，已处理 ${job.processed}`
+}
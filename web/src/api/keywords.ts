@@ -9,6 +9,7 @@ import type {
   BatchResult
 } from '@/types'
 import { assertSuccess, type SuccessResponse, type CreateResponse, type CountResponse } from './shared'
+import { waitForJob, type Job } from './jobs'
 
 // ============================================
 // 响应类型
@@ -138,11 +139,19 @@ export async function batchMoveKeywords(ids: number[], groupId: number): Promise
   return { moved: res.moved! }
 }
 
-export async function deleteAllKeywords(groupId?: number): Promise<{ deleted: number }> {
-  const res: CountResponse = await request.delete('/keywords/delete-all', {
+/** 删除全部（有作业管理器时后端返回 job_id 异步删除，这里轮询到作业结束） */
+export async function deleteAllKeywords(
+  groupId?: number,
+  onProgress?: (job: Job) => void
+): Promise<{ deleted: number }> {
+  const res: CountResponse & { job_id?: number } = await request.delete('/keywords/delete-all', {
     data: { group_id: groupId, confirm: true }
   })
   assertSuccess(res, '删除失败')
+  if (res.job_id) {
+    const result = await waitForJob<{ deleted: number }>(res.job_id, onProgress)
+    return { deleted: result.deleted || 0 }
+  }
   return { deleted: res.deleted! }
 }
 
@@ -150,22 +159,34 @@ export async function deleteAllKeywords(groupId?: number): Promise<{ deleted: nu
 // 文件上传 API
 // ============================================
 
-export async function uploadKeywordsFile(
-  file: File,
-  groupId: number
-): Promise<{
+interface KeywordUploadResult {
   success: boolean
   message: string
   total: number
   added: number
   skipped: number
-}> {
+  rejected?: number
+  job_id?: number
+}
+
+/** 上传关键词文件（有作业管理器时后端返回 job_id 异步导入，这里轮询到作业结束并返回导入结果） */
+export async function uploadKeywordsFile(
+  file: File,
+  groupId: number,
+  onProgress?: (job: Job) => void
+): Promise<KeywordUploadResult> {
   const formData = new FormData()
   formData.append('file', file)
   formData.append('group_id', String(groupId))
 
-  return request.post('/keywords/upload', formData, {
+  const res: KeywordUploadResult = await request.post('/keywords/upload', formData, {
     timeout: 300000,
     headers: { 'Content-Type': 'multipart/form-data' }
   })
+  assertSuccess(res, '上传失败')
+  if (res.job_id) {
+    const result = await waitForJob<{ added: number; skipped: number }>(res.job_id, onProgress)
+    return { ...res, added: result.added || 0, skipped: result.skipped || 0 }
+  }
+  return res
 }
@@ -92,40 +92,54 @@ export function subscribeRealtimeLogs(
   onLog: (log: RealtimeLog) => void,
   onError?: (error: string) => void
 ): () => void {
-  const wsUrl = buildWsUrl('/api/logs/ws')
   let ws: WebSocket | null = null
   let finished = false
 
-  try {
-    ws = new WebSocket(wsUrl)
-  } catch (e) {
-    onError?.(`WebSocket 创建失败: ${e}`)
-    return () => {}
-  }
-
-  ws.onmessage = (event) => {
+  const connect = (wsUrl: string) => {
     try {
-      const msg: RealtimeLog = JSON.parse(event.data)
-      if (msg.type === 'log') {
-        onLog(msg)
+      ws = new WebSocket(wsUrl)
+    } catch (e) {
+      onError?.(`WebSocket 创建失败: ${e}`)
+      return
+    }
+
+    ws.onmessage = (event) => {
+      try {
+        const msg: RealtimeLog = JSON.parse(event.data)
+        if (msg.type === 'log') {
+          onLog(msg)
+        }
+      } catch {
+        // 忽略解析错误
       }
-    } catch {
-      // 忽略解析错误
     }
-  }
 
-  ws.onerror = () => {
-    if (!finished) {
-      onError?.('WebSocket 连接失败')
+    ws.onerror = () => {
+      if (!finished) {
+        onError?.('WebSocket 连接失败')
+      }
     }
-  }
 
-  ws.onclose = (event) => {
-    if (!finished && !event.wasClean) {
-      onError?.('连接断开')
+    ws.onclose = (event) => {
+      if (!finished && !event.wasClean) {
+        onError?.('连接断开')
+      }
     }
   }
 
+  // 先获取连接票据再建立连接；票据返回前取消订阅则不再连接
+  buildWsUrl('/api/logs/ws')
+    .then((wsUrl) => {
+      if (!finished) {
+        connect(wsUrl)
+      }
+    })
+    .catch((e: Error) => {
+      if (!finished) {
+        onError?.(`获取连接票据失败: ${e.message}`)
+      }
+    })
+
   return () => {
     finished = true
     closeWebSocket(ws)
@@ -24,7 +24,20 @@ interface BackendSettings {
   spider_detector: { enabled: boolean; dns_verify_enabled: boolean; return_404_for_non_spider: boolean }
 }
 
+export interface DomainCacheUsage {
+  domain: string
+  entries: number
+  bytes: number
+  size_mb: number
+  max_entries: number
+  max_size_mb: number
+  over_quota: boolean
+  evictions: number
+  evicting: boolean
+}
+
 interface CacheStats {
+  domains: DomainCacheUsage[]
   html_cache_entries: number
   html_cache_memory_mb: number
   initialized: boolean
@@ -34,11 +47,33 @@ interface CacheStats {
   template_cache: { item_count: number; memory_bytes: number }
 }
 
+export interface CacheScanProgress {
+  state: 'idle' | 'running' | 'done' | 'failed' | 'cancelled'
+  resumed: boolean
+  started_at: string | null
+  finished_at: string | null
+  domains_total: number
+  domains_done: number
+  current_domain?: string
+  files_scanned: number
+  bytes_scanned: number
+  percent: number
+  error?: string
+  watching: boolean
+  watched_dirs: number
+}
+
 interface RecalculateResponse {
+  started: boolean
+  message: string
+  status: CacheScanProgress
+}
+
+interface RecalculateStatusResponse {
+  backend: string
+  status: CacheScanProgress
   total_entries: number
   total_size_mb: number
-  duration_ms: number
-  message: string
 }
 
 // ============================================
@@ -77,7 +112,8 @@ export async function getCacheStats(): Promise<CacheStats> {
     total_size_mb: 0,
     initialized: false,
     scanning: false,
-    last_scan_at: null as string | null
+    last_scan_at: null as string | null,
+    domains: [] as DomainCacheUsage[]
   }
 
   try {
@@ -92,6 +128,7 @@ export async function getCacheStats(): Promise<CacheStats> {
     initialized: htmlCacheStats.initialized ?? false,
     scanning: htmlCacheStats.scanning ?? false,
     last_scan_at: htmlCacheStats.last_scan_at || null,
+    domains: htmlCacheStats.domains || [],
     site_cache: htmlCacheStats.site_cache || { item_count: 0, memory_bytes: 0 },
     template_cache: htmlCacheStats.template_cache || { item_count: 0, memory_bytes: 0 }
   }
@@ -101,6 +138,10 @@ export async function recalculateCacheStats(): Promise<RecalculateResponse> {
   return request.post('/cache/stats/recalculate')
 }
 
+export async function getRecalculateStatus(): Promise<RecalculateStatusResponse> {
+  return request.get('/cache/stats/recalculate/status')
+}
+
 export function clearDomainCache(domain: string): Promise<{ success: boolean; cleared: number }> {
   return request.post(`/cache/clear/${domain}`)
 }
@@ -2,6 +2,8 @@
  * API 共享类型和工具函数
  */
 
+import request from '@/utils/request'
+
 // ============================================
 // 通用响应类型
 // ============================================
@@ -30,10 +32,18 @@ export interface CountResponse extends SuccessResponse {
 // WebSocket 工具函数
 // ============================================
 
-/** 构建 WebSocket URL */
-export function buildWsUrl(path: string): string {
+/** 获取实时推送连接票据（一次性，有效期很短，每次连接前重新获取） */
+export async function fetchWsTicket(): Promise<string> {
+  const res: { ticket: string } = await request.post('/auth/ws-ticket')
+  return res.ticket
+}
+
+/** 构建 WebSocket URL，附带连接票据（/ws/* 和 /sse/* 必须以 ?ticket= 携带） */
+export async function buildWsUrl(path: string): Promise<string> {
   const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
-  return `${protocol}//${window.location.host}${path}`
+  const ticket = await fetchWsTicket()
+  const sep = path.includes('?') ? '&' : '?'
+  return `${protocol}//${window.location.host}${path}${sep}ticket=${encodeURIComponent(ticket)}`
 }
 
 /** 安全关闭 WebSocket */
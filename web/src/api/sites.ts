@@ -19,6 +19,10 @@ interface BackendSite {
   icp_number?: string
   baidu_token?: string
   analytics?: string
+  cache_max_size_mb?: number | null
+  cache_max_entries?: number | null
+  human_policy?: string | null
+  human_target_url?: string | null
   created_at: string
   updated_at: string
 }
@@ -51,6 +55,8 @@ function convertSite(site: BackendSite): Site {
     icp_number: site.icp_number || null,
     baidu_token: site.baidu_token || null,
     analytics: site.analytics || null,
+    cache_max_size_mb: site.cache_max_size_mb ?? null,
+    cache_max_entries: site.cache_max_entries ?? null,
     created_at: site.created_at,
     updated_at: site.updated_at
   }
@@ -89,7 +95,9 @@ export async function createSite(data: SiteCreate): Promise<Site> {
     article_group_id: data.article_group_id,
     icp_number: data.icp_number,
     baidu_token: data.baidu_token,
-    analytics: data.analytics
+    analytics: data.analytics,
+    cache_max_size_mb: data.cache_max_size_mb ?? undefined,
+    cache_max_entries: data.cache_max_entries ?? undefined
   }
   const res: CreateResponse = await request.post('/sites', backendData)
   assertSuccess(res, '创建失败')
@@ -108,6 +116,8 @@ export async function createSite(data: SiteCreate): Promise<Site> {
     icp_number: data.icp_number || null,
     baidu_token: data.baidu_token || null,
     analytics: data.analytics || null,
+    cache_max_size_mb: data.cache_max_size_mb ?? null,
+    cache_max_entries: data.cache_max_entries ?? null,
     created_at: now,
     updated_at: now
   }
@@ -125,6 +135,9 @@ export async function updateSite(id: number, data: SiteUpdate): Promise<Site> {
   if (data.icp_number !== undefined) backendData.icp_number = data.icp_number
   if (data.baidu_token !== undefined) backendData.baidu_token = data.baidu_token
   if (data.analytics !== undefined) backendData.analytics = data.analytics
+  // 缓存配额 null 表示恢复全局默认，后端用负数表示
+  if (data.cache_max_size_mb !== undefined) backendData.cache_max_size_mb = data.cache_max_size_mb ?? -1
+  if (data.cache_max_entries !== undefined) backendData.cache_max_entries = data.cache_max_entries ?? -1
 
   const res: SuccessResponse = await request.put(`/sites/${id}`, backendData)
   assertSuccess(res, '更新失败')
@@ -255,58 +255,75 @@ interface LogSubscriptionHandlers {
   onItem?: (item: Record<string, unknown>) => void
 }
 
-function createLogSubscription(wsUrl: string, handlers: LogSubscriptionHandlers): () => void {
+function createLogSubscription(path: string, handlers: LogSubscriptionHandlers): () => void {
   const { onLog, onEnd, onError, onItem } = handlers
   let ws: WebSocket | null = null
   let finished = false
 
-  try {
-    ws = new WebSocket(wsUrl)
-  } catch (e) {
-    onError?.(`WebSocket 创建失败: ${e}`)
-    return () => {}
-  }
-
-  ws.onmessage = (event) => {
+  const connect = (wsUrl: string) => {
     try {
-      const msg = JSON.parse(event.data)
-      if (msg.type === 'log') {
-        if (msg.level === 'ITEM' && onItem) {
-          try {
-            onItem(JSON.parse(msg.message))
-          } catch {
+      ws = new WebSocket(wsUrl)
+    } catch (e) {
+      onError?.(`WebSocket 创建失败: ${e}`)
+      return
+    }
+
+    ws.onmessage = (event) => {
+      try {
+        const msg = JSON.parse(event.data)
+        if (msg.type === 'log') {
+          if (msg.level === 'ITEM' && onItem) {
+            try {
+              onItem(JSON.parse(msg.message))
+            } catch {
+              onLog(msg.level, msg.message)
+            }
+          } else {
             onLog(msg.level, msg.message)
           }
-        } else {
-          onLog(msg.level, msg.message)
+        } else if (msg.type === 'end') {
+          finished = true
+          onEnd()
+          closeWebSocket(ws)
+        } else if (msg.type === 'error') {
+          finished = true
+          onError?.(msg.message)
+          closeWebSocket(ws)
         }
-      } else if (msg.type === 'end') {
-        finished = true
-        onEnd()
-        closeWebSocket(ws)
-      } else if (msg.type === 'error') {
-        finished = true
-        onError?.(msg.message)
-        closeWebSocket(ws)
+      } catch {
+        // 忽略解析错误
       }
-    } catch {
-      // 忽略解析错误
     }
-  }
 
-  ws.onerror = () => {
-    if (!finished) {
-      onError?.('WebSocket 连接失败')
+    ws.onerror = () => {
+      if (!finished) {
+        onError?.('WebSocket 连接失败')
+      }
     }
-  }
 
-  ws.onclose = () => {
-    if (!finished) {
-      finished = true
-      onEnd()
+    ws.onclose = () => {
+      if (!finished) {
+        finished = true
+        onEnd()
+      }
     }
   }
 
+  // 先获取连接票据再建立连接；票据返回前取消订阅则不再连接
+  buildWsUrl(path)
+    .then((wsUrl) => {
+      if (!finished) {
+        connect(wsUrl)
+      }
+    })
+    .catch((e: Error) => {
+      if (!finished) {
+        finished = true
+        onError?.(`获取连接票据失败: ${e.message}`)
+        onEnd()
+      }
+    })
+
   return () => {
     finished = true
     closeWebSocket(ws)
@@ -319,8 +336,7 @@ export function subscribeProjectLogs(
   onEnd: () => void,
   onError?: (error: string) => void
 ): () => void {
-  const wsUrl = buildWsUrl(`/ws/spider-logs/${projectId}?type=project`)
-  return createLogSubscription(wsUrl, { onLog, onEnd, onError })
+  return createLogSubscription(`/ws/spider-logs/${projectId}?type=project`, { onLog, onEnd, onError })
 }
 
 export function subscribeTestLogs(
@@ -330,8 +346,7 @@ export function subscribeTestLogs(
   onEnd: () => void,
   onError?: (error: string) => void
 ): () => void {
-  const wsUrl = buildWsUrl(`/ws/spider-logs/${projectId}?type=test`)
-  return createLogSubscription(wsUrl, { onLog, onEnd, onError, onItem })
+  return createLogSubscription(`/ws/spider-logs/${projectId}?type=test`, { onLog, onEnd, onError, onItem })
 }
 
 // ============================================
@@ -3,16 +3,25 @@
  */
 
 import type { SystemStats } from '@/types/system-stats'
+import { buildWsUrl } from './shared'
 
 let ws: WebSocket | null = null
 let reconnectTimer: ReturnType<typeof setTimeout> | null = null
+let connectSeq = 0 // 每次连接/断开递增，丢弃过期的票据请求
 
-export function connectSystemStatsWs(onMessage: (data: SystemStats) => void): void {
+export async function connectSystemStatsWs(onMessage: (data: SystemStats) => void): Promise<void> {
   // 清理之前的连接
   disconnectSystemStatsWs()
 
-  const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
-  const wsUrl = `${protocol}//${window.location.host}/ws/system-stats`
+  const seq = connectSeq
+  let wsUrl: string
+  try {
+    wsUrl = await buildWsUrl('/ws/system-stats')
+  } catch (e) {
+    console.error('[SystemStats] Failed to get connection ticket:', e)
+    return
+  }
+  if (seq !== connectSeq) return
 
   ws = new WebSocket(wsUrl)
 
@@ -40,6 +49,7 @@ export function connectSystemStatsWs(onMessage: (data: SystemStats) => void): vo
 }
 
 export function disconnectSystemStatsWs(): void {
+  connectSeq++
   if (reconnectTimer) {
     clearTimeout(reconnectTimer)
     reconnectTimer = null
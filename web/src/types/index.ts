@@ -74,6 +74,10 @@ export interface Site {
   icp_number: string | null
   baidu_token: string | null
   analytics: string | null
+  cache_max_size_mb: number | null  // 页面缓存大小上限(MB)，null=全局默认，0=不限制
+  cache_max_entries: number | null  // 页面缓存条数上限，null=全局默认，0=不限制
+  human_policy: string | null       // 非蜘蛛访问策略：redirect/page/content/block，null=全局配置
+  human_target_url: string | null   // redirect 策略的跳转地址
   status: number  // 1=启用, 0=禁用
   created_at: string
   updated_at: string
@@ -90,6 +94,10 @@ export interface SiteCreate {
   icp_number?: string
   baidu_token?: string
   analytics?: string
+  cache_max_size_mb?: number | null
+  cache_max_entries?: number | null
+  human_policy?: string
+  human_target_url?: string
 }
 
 export interface SiteUpdate {
@@ -103,6 +111,10 @@ export interface SiteUpdate {
   icp_number?: string
   baidu_token?: string
   analytics?: string
+  cache_max_size_mb?: number | null
+  cache_max_entries?: number | null
+  human_policy?: string
+  human_target_url?: string
 }
 
 // 关键词分组
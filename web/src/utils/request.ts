@@ -28,6 +28,9 @@ function getDefaultErrorMessage(status?: number): string {
 const request = axios.create({
   baseURL: import.meta.env.VITE_API_BASE_URL || '/api',
   timeout: 30000,
+  // 后端启用 Cookie 会话时，axios 自动从 csrf_token Cookie 读取并放入 X-CSRF-Token 请求头（同源部署）
+  xsrfCookieName: 'csrf_token',
+  xsrfHeaderName: 'X-CSRF-Token',
 })
 
 // 请求拦截器